@@ -0,0 +1,22 @@
+package brotli
+
+/* Dictionary data (words and transforms) for 1 possible context */
+type encoderDictionary struct {
+	words                 *dictionary
+	cutoffTransformsCount uint32
+	cutoffTransforms      uint64
+	hash_table            []uint16
+	buckets               []uint16
+	dict_words            []dictWord
+}
+
+func initEncoderDictionary(dict *encoderDictionary) {
+	dict.words = getDictionary()
+
+	dict.hash_table = kStaticDictionaryHash[:]
+	dict.buckets = kStaticDictionaryBuckets[:]
+	dict.dict_words = kStaticDictionaryWords[:]
+
+	dict.cutoffTransformsCount = kCutoffTransformsCount
+	dict.cutoffTransforms = kCutoffTransforms
+}
@@ -0,0 +1,9496 @@
+// generated by go run gen.go; DO NOT EDIT
+
+// Package gobold provides the "Go Bold" TrueType font
+// from the Go font family. It is a proportional-width, sans-serif font.
+//
+// See https://blog.golang.org/go-fonts for details.
+package gobold
+
+// TTF is the data for the "Go Bold" TrueType font.
+var TTF = []byte{
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x80, 0x00, 0x03, 0x00, 0x60, 0x4f, 0x53, 0x2f, 0x32,
+	0xc6, 0x75, 0x39, 0xe8, 0x00, 0x00, 0x00, 0xec, 0x00, 0x00, 0x00, 0x60, 0x63, 0x6d, 0x61, 0x70,
+	0xbe, 0x92, 0x2d, 0x51, 0x00, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x05, 0x3e, 0x63, 0x76, 0x74, 0x20,
+	0x54, 0xb7, 0x1c, 0x58, 0x00, 0x02, 0x41, 0x30, 0x00, 0x00, 0x00, 0xb0, 0x66, 0x70, 0x67, 0x6d,
+	0x62, 0x2f, 0x03, 0x7f, 0x00, 0x02, 0x41, 0xe0, 0x00, 0x00, 0x0e, 0x0c, 0x67, 0x61, 0x73, 0x70,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x02, 0x41, 0x28, 0x00, 0x00, 0x00, 0x08, 0x67, 0x6c, 0x79, 0x66,
+	0x52, 0x5a, 0x50, 0x62, 0x00, 0x00, 0x06, 0x8c, 0x00, 0x01, 0xf9, 0xa0, 0x68, 0x65, 0x61, 0x64,
+	0x19, 0x05, 0x52, 0xf9, 0x00, 0x02, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x36, 0x68, 0x68, 0x65, 0x61,
+	0x0e, 0x5c, 0x08, 0x3d, 0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x00, 0x24, 0x68, 0x6d, 0x74, 0x78,
+	0x76, 0xdb, 0x06, 0xa1, 0x00, 0x02, 0x00, 0x88, 0x00, 0x00, 0x0b, 0x1e, 0x6c, 0x6f, 0x63, 0x61,
+	0x50, 0x7b, 0xd1, 0xc6, 0x00, 0x02, 0x0b, 0xa8, 0x00, 0x00, 0x05, 0x92, 0x6d, 0x61, 0x78, 0x70,
+	0x06, 0x46, 0x10, 0xa7, 0x00, 0x02, 0x11, 0x3c, 0x00, 0x00, 0x00, 0x20, 0x6e, 0x61, 0x6d, 0x65,
+	0xad, 0x58, 0x20, 0xe7, 0x00, 0x02, 0x11, 0x5c, 0x00, 0x00, 0x1b, 0x19, 0x70, 0x6f, 0x73, 0x74,
+	0xfc, 0xb8, 0x10, 0xd7, 0x00, 0x02, 0x2c, 0x78, 0x00, 0x00, 0x14, 0xb0, 0x70, 0x72, 0x65, 0x70,
+	0x8e, 0xd0, 0xa0, 0x76, 0x00, 0x02, 0x4f, 0xec, 0x00, 0x00, 0x00, 0xd6, 0x00, 0x03, 0x04, 0xe2,
+	0x02, 0x58, 0x00, 0x05, 0x00, 0x00, 0x05, 0x9a, 0x05, 0x33, 0x00, 0x00, 0x01, 0x1b, 0x05, 0x9a,
+	0x05, 0x33, 0x00, 0x00, 0x03, 0xd1, 0x00, 0x66, 0x02, 0x00, 0x08, 0x02, 0x02, 0x0b, 0x07, 0x03,
+	0x05, 0x00, 0x00, 0x00, 0x00, 0x04, 0xa0, 0x00, 0x02, 0xaf, 0x50, 0x00, 0x78, 0xfb, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20, 0x20, 0x20, 0x20, 0x00, 0x20, 0x00, 0x00, 0xff, 0xfd,
+	0x06, 0x2b, 0xfe, 0x75, 0x01, 0x89, 0x07, 0x8f, 0x01, 0xb0, 0x20, 0x00, 0x00, 0x9f, 0xdf, 0xd7,
+	0x00, 0x00, 0x04, 0x4a, 0x05, 0xc8, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x03, 0x34,
+	0x00, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x04, 0x03, 0x18, 0x00, 0x00, 0x00, 0xc2,
+	0x00, 0x80, 0x00, 0x06, 0x00, 0x42, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x7e, 0x01, 0x7f, 0x01, 0x92,
+	0x01, 0xdc, 0x01, 0xff, 0x02, 0x1b, 0x02, 0xc7, 0x02, 0xc9, 0x02, 0xdd, 0x03, 0x7e, 0x03, 0x8a,
+	0x03, 0x8c, 0x03, 0xa1, 0x03, 0xce, 0x04, 0x5f, 0x04, 0x91, 0x1e, 0x85, 0x1e, 0xf3, 0x20, 0x15,
+	0x20, 0x1e, 0x20, 0x22, 0x20, 0x26, 0x20, 0x30, 0x20, 0x33, 0x20, 0x3a, 0x20, 0x3c, 0x20, 0x3e,
+	0x20, 0x44, 0x20, 0x70, 0x20, 0x8e, 0x20, 0x99, 0x20, 0xa4, 0x20, 0xa7, 0x20, 0xac, 0x21, 0x05,
+	0x21, 0x13, 0x21, 0x16, 0x21, 0x22, 0x21, 0x26, 0x21, 0x2e, 0x21, 0x5e, 0x21, 0x95, 0x21, 0xa8,
+	0x22, 0x02, 0x22, 0x06, 0x22, 0x0f, 0x22, 0x12, 0x22, 0x15, 0x22, 0x1a, 0x22, 0x1f, 0x22, 0x2b,
+	0x22, 0x48, 0x22, 0x61, 0x22, 0x65, 0x23, 0x02, 0x23, 0x10, 0x23, 0x21, 0x25, 0x00, 0x25, 0x02,
+	0x25, 0x0c, 0x25, 0x10, 0x25, 0x14, 0x25, 0x18, 0x25, 0x1c, 0x25, 0x24, 0x25, 0x2c, 0x25, 0x34,
+	0x25, 0x3c, 0x25, 0x6c, 0x25, 0x80, 0x25, 0x84, 0x25, 0x88, 0x25, 0x8c, 0x25, 0x93, 0x25, 0xa1,
+	0x25, 0xac, 0x25, 0xb2, 0x25, 0xba, 0x25, 0xbc, 0x25, 0xc4, 0x25, 0xcb, 0x25, 0xcf, 0x25, 0xd9,
+	0x25, 0xe6, 0x26, 0x3c, 0x26, 0x40, 0x26, 0x42, 0x26, 0x60, 0x26, 0x63, 0x26, 0x66, 0x26, 0x6b,
+	0xf8, 0x00, 0xfb, 0x02, 0xff, 0xfd, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x20,
+	0x00, 0xa0, 0x01, 0x92, 0x01, 0xcd, 0x01, 0xfa, 0x02, 0x18, 0x02, 0xc6, 0x02, 0xc9, 0x02, 0xd8,
+	0x03, 0x7e, 0x03, 0x84, 0x03, 0x8c, 0x03, 0x8e, 0x03, 0xa3, 0x04, 0x00, 0x04, 0x90, 0x1e, 0x80,
+	0x1e, 0xf2, 0x20, 0x13, 0x20, 0x17, 0x20, 0x20, 0x20, 0x26, 0x20, 0x30, 0x20, 0x32, 0x20, 0x39,
+	0x20, 0x3c, 0x20, 0x3e, 0x20, 0x44, 0x20, 0x70, 0x20, 0x74, 0x20, 0x99, 0x20, 0xa3, 0x20, 0xa7,
+	0x20, 0xac, 0x21, 0x05, 0x21, 0x13, 0x21, 0x16, 0x21, 0x22, 0x21, 0x26, 0x21, 0x2e, 0x21, 0x5b,
+	0x21, 0x90, 0x21, 0xa8, 0x22, 0x02, 0x22, 0x06, 0x22, 0x0f, 0x22, 0x11, 0x22, 0x15, 0x22, 0x19,
+	0x22, 0x1e, 0x22, 0x29, 0x22, 0x48, 0x22, 0x60, 0x22, 0x64, 0x23, 0x02, 0x23, 0x10, 0x23, 0x20,
+	0x25, 0x00, 0x25, 0x02, 0x25, 0x0c, 0x25, 0x10, 0x25, 0x14, 0x25, 0x18, 0x25, 0x1c, 0x25, 0x24,
+	0x25, 0x2c, 0x25, 0x34, 0x25, 0x3c, 0x25, 0x50, 0x25, 0x80, 0x25, 0x84, 0x25, 0x88, 0x25, 0x8c,
+	0x25, 0x90, 0x25, 0xa0, 0x25, 0xaa, 0x25, 0xb2, 0x25, 0xba, 0x25, 0xbc, 0x25, 0xc4, 0x25, 0xca,
+	0x25, 0xcf, 0x25, 0xd8, 0x25, 0xe6, 0x26, 0x3a, 0x26, 0x40, 0x26, 0x42, 0x26, 0x60, 0x26, 0x63,
+	0x26, 0x65, 0x26, 0x6a, 0xf8, 0x00, 0xfb, 0x01, 0xff, 0xfd, 0xff, 0xff, 0x00, 0x01, 0xff, 0xf5,
+	0xff, 0xe3, 0xff, 0xc2, 0xff, 0xb0, 0xff, 0x76, 0xff, 0x59, 0xff, 0x41, 0xfe, 0x97, 0xfe, 0x96,
+	0xfe, 0x88, 0xfd, 0xe8, 0xfd, 0xe3, 0xfd, 0xe2, 0xfd, 0xe1, 0xfd, 0xe0, 0xfd, 0xaf, 0xfd, 0x7f,
+	0xe3, 0x91, 0xe3, 0x25, 0xe2, 0x06, 0xe2, 0x05, 0xe2, 0x04, 0xe2, 0x01, 0xe1, 0xf8, 0xe1, 0xf7,
+	0xe1, 0xf2, 0xe1, 0xf1, 0xe1, 0xf0, 0xe1, 0xeb, 0xe1, 0xc0, 0xe1, 0xbd, 0xe1, 0xb3, 0xe1, 0xaa,
+	0xe1, 0xa8, 0xe1, 0xa4, 0xe1, 0x4c, 0xe1, 0x3f, 0xe1, 0x3d, 0xe1, 0x32, 0xe1, 0x2f, 0xe1, 0x28,
+	0xe0, 0xfc, 0xe0, 0xcb, 0xe0, 0xb9, 0xe0, 0x60, 0xe0, 0x5d, 0xe0, 0x55, 0xe0, 0x54, 0xe0, 0x52,
+	0xe0, 0x4f, 0xe0, 0x4c, 0xe0, 0x43, 0xe0, 0x27, 0xe0, 0x10, 0xe0, 0x0e, 0xdf, 0x72, 0xdf, 0x65,
+	0xdf, 0x56, 0xdd, 0x78, 0xdd, 0x77, 0xdd, 0x6e, 0xdd, 0x6b, 0xdd, 0x68, 0xdd, 0x65, 0xdd, 0x62,
+	0xdd, 0x5b, 0xdd, 0x54, 0xdd, 0x4d, 0xdd, 0x46, 0xdd, 0x33, 0xdd, 0x20, 0xdd, 0x1d, 0xdd, 0x1a,
+	0xdd, 0x17, 0xdd, 0x14, 0xdd, 0x08, 0xdd, 0x00, 0xdc, 0xfb, 0xdc, 0xf4, 0xdc, 0xf3, 0xdc, 0xec,
+	0xdc, 0xe7, 0xdc, 0xe4, 0xdc, 0xdc, 0xdc, 0xd0, 0xdc, 0x7d, 0xdc, 0x7a, 0xdc, 0x79, 0xdc, 0x5c,
+	0xdc, 0x5a, 0xdc, 0x59, 0xdc, 0x56, 0x0a, 0xc2, 0x07, 0xc2, 0x02, 0xc8, 0x00, 0x01, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x06, 0x02, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05,
+	0x00, 0x06, 0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0a, 0x00, 0x0b, 0x00, 0x0c, 0x00, 0x0d,
+	0x00, 0x0e, 0x00, 0x0f, 0x00, 0x10, 0x00, 0x11, 0x00, 0x12, 0x00, 0x13, 0x00, 0x14, 0x00, 0x15,
+	0x00, 0x16, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x1b, 0x00, 0x1c, 0x00, 0x1d,
+	0x00, 0x1e, 0x00, 0x1f, 0x00, 0x20, 0x00, 0x21, 0x00, 0x22, 0x00, 0x23, 0x00, 0x24, 0x00, 0x25,
+	0x00, 0x26, 0x00, 0x27, 0x00, 0x28, 0x00, 0x29, 0x00, 0x2a, 0x00, 0x2b, 0x00, 0x2c, 0x00, 0x2d,
+	0x00, 0x2e, 0x00, 0x2f, 0x00, 0x30, 0x00, 0x31, 0x00, 0x32, 0x00, 0x33, 0x00, 0x34, 0x00, 0x35,
+	0x00, 0x36, 0x00, 0x37, 0x00, 0x38, 0x00, 0x39, 0x00, 0x3a, 0x00, 0x3b, 0x00, 0x3c, 0x00, 0x3d,
+	0x00, 0x3e, 0x00, 0x3f, 0x00, 0x40, 0x00, 0x41, 0x00, 0x42, 0x00, 0x43, 0x00, 0x44, 0x00, 0x45,
+	0x00, 0x46, 0x00, 0x47, 0x00, 0x48, 0x00, 0x49, 0x00, 0x4a, 0x00, 0x4b, 0x00, 0x4c, 0x00, 0x4d,
+	0x00, 0x4e, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x51, 0x00, 0x52, 0x00, 0x53, 0x00, 0x54, 0x00, 0x55,
+	0x00, 0x56, 0x00, 0x57, 0x00, 0x58, 0x00, 0x59, 0x00, 0x5a, 0x00, 0x5b, 0x00, 0x5c, 0x00, 0x5d,
+	0x00, 0x5e, 0x00, 0x5f, 0x00, 0x60, 0x00, 0x61, 0x00, 0x00, 0x00, 0x86, 0x00, 0x87, 0x00, 0x89,
+	0x00, 0x8b, 0x00, 0x93, 0x00, 0x98, 0x00, 0x9e, 0x00, 0xa3, 0x00, 0xa2, 0x00, 0xa4, 0x00, 0xa6,
+	0x00, 0xa5, 0x00, 0xa7, 0x00, 0xa9, 0x00, 0xab, 0x00, 0xaa, 0x00, 0xac, 0x00, 0xad, 0x00, 0xaf,
+	0x00, 0xae, 0x00, 0xb0, 0x00, 0xb1, 0x00, 0xb3, 0x00, 0xb5, 0x00, 0xb4, 0x00, 0xb6, 0x00, 0xb8,
+	0x00, 0xb7, 0x00, 0xbc, 0x00, 0xbb, 0x00, 0xbd, 0x00, 0xbe, 0x02, 0x24, 0x00, 0x72, 0x00, 0x64,
+	0x00, 0x65, 0x00, 0x69, 0x02, 0x26, 0x00, 0x78, 0x00, 0xa1, 0x00, 0x70, 0x00, 0x6b, 0x02, 0x54,
+	0x00, 0x76, 0x00, 0x6a, 0x02, 0x70, 0x00, 0x88, 0x00, 0x9a, 0x02, 0x6a, 0x00, 0x73, 0x02, 0x72,
+	0x02, 0x73, 0x00, 0x67, 0x00, 0x77, 0x02, 0x62, 0x02, 0x65, 0x02, 0x64, 0x01, 0xa0, 0x02, 0x6e,
+	0x00, 0x6c, 0x00, 0x7c, 0x02, 0x55, 0x00, 0xa8, 0x00, 0xba, 0x00, 0x81, 0x00, 0x63, 0x00, 0x6e,
+	0x02, 0x69, 0x01, 0x42, 0x02, 0x6f, 0x02, 0x63, 0x00, 0x6d, 0x00, 0x7d, 0x02, 0x27, 0x00, 0x03,
+	0x00, 0x82, 0x00, 0x85, 0x00, 0x97, 0x01, 0x14, 0x01, 0x15, 0x02, 0x19, 0x02, 0x1a, 0x02, 0x21,
+	0x02, 0x22, 0x02, 0x1d, 0x02, 0x1e, 0x00, 0xb9, 0x02, 0xb1, 0x00, 0xc1, 0x01, 0x3a, 0x02, 0x2f,
+	0x02, 0x50, 0x02, 0x2b, 0x02, 0x2c, 0x02, 0xc3, 0x02, 0xc4, 0x02, 0x25, 0x00, 0x79, 0x02, 0x1f,
+	0x02, 0x23, 0x02, 0x28, 0x00, 0x84, 0x00, 0x8c, 0x00, 0x83, 0x00, 0x8d, 0x00, 0x8a, 0x00, 0x8f,
+	0x00, 0x90, 0x00, 0x91, 0x00, 0x8e, 0x00, 0x95, 0x00, 0x96, 0x00, 0x00, 0x00, 0x94, 0x00, 0x9c,
+	0x00, 0x9d, 0x00, 0x9b, 0x00, 0xf3, 0x01, 0x5d, 0x01, 0x64, 0x00, 0x71, 0x01, 0x60, 0x01, 0x61,
+	0x01, 0x62, 0x00, 0x7a, 0x01, 0x65, 0x01, 0x63, 0x01, 0x5e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00,
+	0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01,
+	0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x21, 0x11, 0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x01, 0x00,
+	0x04, 0x00, 0xfc, 0x40, 0x03, 0x80, 0xfc, 0x80, 0x05, 0x00, 0xfb, 0x00, 0x40, 0x04, 0x80, 0x00,
+	0x00, 0x02, 0x00, 0xcb, 0x00, 0x00, 0x02, 0x07, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x09, 0x00, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x38, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x09, 0x04,
+	0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11,
+	0x01, 0x03, 0x11, 0x21, 0x11, 0x03, 0xcb, 0x01, 0x3c, 0xfe, 0xff, 0x31, 0x01, 0x28, 0x31, 0x01,
+	0x01, 0xfe, 0xff, 0x01, 0xb0, 0x02, 0xf0, 0x01, 0x28, 0xfe, 0xd8, 0xfd, 0x10, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x72, 0x03, 0xb8, 0x03, 0x59, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x24,
+	0x40, 0x21, 0x05, 0x03, 0x04, 0x03, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x01,
+	0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x06, 0x09, 0x17, 0x2b, 0x13, 0x03, 0x21, 0x03, 0x21, 0x03, 0x21, 0x03, 0xa3, 0x31, 0x01, 0x28,
+	0x3e, 0x01, 0x06, 0x31, 0x01, 0x28, 0x3d, 0x03, 0xb8, 0x02, 0x73, 0xfd, 0x8d, 0x02, 0x73, 0xfd,
+	0x8d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x19, 0x00, 0x00, 0x04, 0x5a, 0x05, 0xc8, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0xa9, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x28, 0x0e, 0x09, 0x02, 0x01, 0x0c,
+	0x0a, 0x02, 0x00, 0x0b, 0x01, 0x00, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0f, 0x08, 0x02,
+	0x02, 0x02, 0x03, 0x5f, 0x07, 0x05, 0x02, 0x03, 0x03, 0x3b, 0x4d, 0x10, 0x0d, 0x02, 0x0b, 0x0b,
+	0x39, 0x0b, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x07, 0x05, 0x02, 0x03, 0x0f,
+	0x08, 0x02, 0x02, 0x01, 0x03, 0x02, 0x68, 0x0e, 0x09, 0x02, 0x01, 0x0c, 0x0a, 0x02, 0x00, 0x0b,
+	0x01, 0x00, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x10, 0x0d, 0x02, 0x0b, 0x0b, 0x39, 0x0b,
+	0x4e, 0x1b, 0x40, 0x26, 0x06, 0x01, 0x04, 0x03, 0x04, 0x85, 0x07, 0x05, 0x02, 0x03, 0x0f, 0x08,
+	0x02, 0x02, 0x01, 0x03, 0x02, 0x68, 0x0e, 0x09, 0x02, 0x01, 0x0c, 0x0a, 0x02, 0x00, 0x0b, 0x01,
+	0x00, 0x67, 0x10, 0x0d, 0x02, 0x0b, 0x0b, 0x3c, 0x0b, 0x4e, 0x59, 0x59, 0x40, 0x1e, 0x00, 0x00,
+	0x1f, 0x1e, 0x1d, 0x1c, 0x00, 0x1b, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x17, 0x16, 0x15, 0x14, 0x13,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x1f, 0x2b, 0x33, 0x13, 0x23,
+	0x37, 0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x33, 0x03, 0x33, 0x13, 0x33, 0x03, 0x33, 0x07, 0x23,
+	0x03, 0x33, 0x07, 0x23, 0x03, 0x23, 0x13, 0x23, 0x03, 0x13, 0x33, 0x13, 0x23, 0x7d, 0x6a, 0xce,
+	0x1d, 0xd6, 0x54, 0xe8, 0x1e, 0xef, 0x6a, 0x99, 0x6b, 0xd5, 0x6b, 0x98, 0x6a, 0xcf, 0x1e, 0xd6,
+	0x53, 0xe7, 0x1d, 0xef, 0x6b, 0x98, 0x6a, 0xd5, 0x6a, 0x8f, 0xd5, 0x53, 0xd5, 0x01, 0xaa, 0x94,
+	0x01, 0x4d, 0x94, 0x01, 0xa9, 0xfe, 0x57, 0x01, 0xa9, 0xfe, 0x57, 0x94, 0xfe, 0xb3, 0x94, 0xfe,
+	0x56, 0x01, 0xaa, 0xfe, 0x56, 0x02, 0x3e, 0x01, 0x4d, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x63,
+	0xff, 0x60, 0x03, 0xf5, 0x06, 0x69, 0x00, 0x26, 0x00, 0x2b, 0x00, 0x30, 0x00, 0x74, 0x40, 0x1e,
+	0x14, 0x01, 0x03, 0x02, 0x19, 0x01, 0x04, 0x03, 0x2d, 0x2c, 0x2b, 0x27, 0x1d, 0x1a, 0x09, 0x06,
+	0x08, 0x01, 0x04, 0x05, 0x01, 0x00, 0x01, 0x04, 0x4c, 0x25, 0x01, 0x00, 0x01, 0x4b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x06, 0x01, 0x05, 0x02, 0x05, 0x63, 0x00, 0x04, 0x04,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x39,
+	0x00, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x03, 0x00, 0x04, 0x01, 0x03, 0x04, 0x69, 0x00, 0x02, 0x06,
+	0x01, 0x05, 0x02, 0x05, 0x63, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3c, 0x00, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x26, 0x00, 0x26, 0x13, 0x11, 0x1d, 0x15, 0x11, 0x07, 0x09,
+	0x1b, 0x2b, 0x05, 0x35, 0x06, 0x26, 0x27, 0x27, 0x35, 0x16, 0x17, 0x11, 0x27, 0x2e, 0x03, 0x35,
+	0x34, 0x3e, 0x02, 0x37, 0x35, 0x33, 0x15, 0x16, 0x17, 0x15, 0x26, 0x23, 0x11, 0x17, 0x04, 0x15,
+	0x14, 0x0e, 0x02, 0x07, 0x15, 0x03, 0x36, 0x35, 0x34, 0x27, 0x03, 0x11, 0x06, 0x15, 0x14, 0x01,
+	0xe0, 0x45, 0xa6, 0x63, 0x2f, 0xb7, 0xc6, 0x52, 0x58, 0x70, 0x3f, 0x18, 0x2e, 0x58, 0x7d, 0x6e,
+	0xa0, 0x9a, 0x8c, 0xc0, 0x66, 0x37, 0x01, 0x3e, 0x35, 0x5d, 0x7d, 0x66, 0x1f, 0xa9, 0xa9, 0x63,
+	0xa4, 0xa0, 0xa4, 0x01, 0x20, 0x1d, 0x0e, 0xda, 0x65, 0x0a, 0x01, 0xe4, 0x25, 0x30, 0x5d, 0x5d,
+	0x67, 0x45, 0x4d, 0x7f, 0x5e, 0x3b, 0x0a, 0xa2, 0xa2, 0x08, 0x37, 0xc9, 0x5b, 0xfe, 0x36, 0x1e,
+	0xb4, 0xe6, 0x4a, 0x8a, 0x6d, 0x4a, 0x0a, 0xa3, 0x01, 0x65, 0x24, 0x8f, 0x76, 0x5a, 0x01, 0x5c,
+	0x01, 0x6e, 0x1d, 0x88, 0x83, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0x54, 0xff, 0xdb, 0x06, 0xc9,
+	0x05, 0xed, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2b, 0x01, 0x10, 0x4b, 0xb0,
+	0x0e, 0x50, 0x58, 0x40, 0x2c, 0x0c, 0x01, 0x04, 0x0b, 0x01, 0x02, 0x07, 0x04, 0x02, 0x69, 0x00,
+	0x07, 0x00, 0x09, 0x08, 0x07, 0x09, 0x6a, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00,
+	0x3e, 0x4d, 0x0e, 0x01, 0x08, 0x08, 0x01, 0x61, 0x0d, 0x06, 0x0a, 0x03, 0x01, 0x01, 0x3f, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x34, 0x0c, 0x01, 0x04, 0x0b, 0x01, 0x02, 0x07,
+	0x04, 0x02, 0x69, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07, 0x09, 0x6a, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x0e, 0x01, 0x08, 0x08, 0x06, 0x61,
+	0x0d, 0x01, 0x06, 0x06, 0x39, 0x4d, 0x0a, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x34, 0x00, 0x00, 0x03, 0x00, 0x85, 0x0a, 0x01, 0x01, 0x06, 0x01, 0x86,
+	0x0c, 0x01, 0x04, 0x0b, 0x01, 0x02, 0x07, 0x04, 0x02, 0x69, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07,
+	0x09, 0x6a, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x0e, 0x01, 0x08, 0x08,
+	0x06, 0x61, 0x0d, 0x01, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x32, 0x00, 0x00, 0x03, 0x00,
+	0x85, 0x0a, 0x01, 0x01, 0x06, 0x01, 0x86, 0x00, 0x03, 0x00, 0x05, 0x04, 0x03, 0x05, 0x69, 0x0c,
+	0x01, 0x04, 0x0b, 0x01, 0x02, 0x07, 0x04, 0x02, 0x69, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07, 0x09,
+	0x6a, 0x0e, 0x01, 0x08, 0x08, 0x06, 0x61, 0x0d, 0x01, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x59,
+	0x59, 0x40, 0x2a, 0x25, 0x24, 0x19, 0x18, 0x11, 0x10, 0x05, 0x04, 0x00, 0x00, 0x29, 0x27, 0x24,
+	0x2b, 0x25, 0x2b, 0x1f, 0x1d, 0x18, 0x23, 0x19, 0x23, 0x15, 0x13, 0x10, 0x17, 0x11, 0x17, 0x0b,
+	0x09, 0x04, 0x0f, 0x05, 0x0f, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0f, 0x09, 0x17, 0x2b, 0x17, 0x01,
+	0x33, 0x01, 0x13, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32,
+	0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15,
+	0x14, 0x06, 0x27, 0x32, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0xe4, 0x04, 0x8c, 0xc8, 0xfb, 0x75,
+	0x02, 0xa1, 0xba, 0xbb, 0xa4, 0xa4, 0xbc, 0xbc, 0xa6, 0x84, 0x82, 0x81, 0x04, 0x33, 0xa2, 0xb9,
+	0xbd, 0xa2, 0xa4, 0xbc, 0xbc, 0xa6, 0x84, 0x82, 0x81, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x03, 0x09,
+	0xc7, 0xab, 0xad, 0xc5, 0xc5, 0xac, 0xae, 0xc5, 0x94, 0xdf, 0xdd, 0xde, 0xde, 0xfc, 0x88, 0xc8,
+	0xaf, 0xa9, 0xc4, 0xc5, 0xac, 0xaf, 0xc4, 0x94, 0xdf, 0xdd, 0xde, 0xde, 0x00, 0x03, 0x00, 0x2d,
+	0xff, 0xdb, 0x05, 0x7b, 0x05, 0xee, 0x00, 0x1c, 0x00, 0x26, 0x00, 0x2e, 0x00, 0x90, 0x40, 0x11,
+	0x13, 0x09, 0x02, 0x03, 0x05, 0x24, 0x1b, 0x15, 0x03, 0x04, 0x03, 0x02, 0x01, 0x00, 0x04, 0x03,
+	0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x04, 0x04,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1f, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3f, 0x01, 0x4e,
+	0x1b, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x05, 0x03, 0x02, 0x05, 0x69, 0x00, 0x03, 0x03, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e,
+	0x59, 0x59, 0x40, 0x09, 0x27, 0x28, 0x19, 0x28, 0x22, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x21, 0x21,
+	0x27, 0x06, 0x23, 0x22, 0x00, 0x35, 0x10, 0x25, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15,
+	0x14, 0x05, 0x16, 0x17, 0x36, 0x35, 0x35, 0x33, 0x14, 0x07, 0x16, 0x01, 0x06, 0x15, 0x14, 0x16,
+	0x33, 0x32, 0x37, 0x26, 0x27, 0x13, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x05, 0x72, 0xfe,
+	0xa4, 0x42, 0xae, 0xd3, 0xf0, 0xfe, 0xca, 0x01, 0x72, 0x6b, 0xec, 0xad, 0xa6, 0xd9, 0xfe, 0xb9,
+	0x80, 0xac, 0x51, 0xf9, 0xd8, 0x62, 0xfc, 0xfe, 0xb7, 0xb7, 0x85, 0x73, 0x63, 0xa5, 0x92, 0x81,
+	0xad, 0x84, 0x84, 0x4f, 0x74, 0x01, 0x0c, 0xce, 0x01, 0x32, 0x98, 0xba, 0x76, 0x87, 0xb8, 0xb1,
+	0x89, 0xd5, 0x98, 0xec, 0xd0, 0x92, 0x89, 0x19, 0xcd, 0xfc, 0x80, 0x02, 0x70, 0x52, 0xa9, 0x8d,
+	0xc4, 0x46, 0xd2, 0xf7, 0x01, 0x28, 0x5c, 0x81, 0x86, 0x81, 0x57, 0x00, 0x00, 0x01, 0x00, 0x53,
+	0x03, 0xb8, 0x01, 0x94, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x09, 0x17, 0x2b, 0x13, 0x03, 0x21, 0x03, 0x91, 0x3e, 0x01, 0x41, 0x4a, 0x03, 0xb8, 0x02, 0x73,
+	0xfd, 0x8d, 0x00, 0x00, 0x00, 0x01, 0x00, 0x54, 0xfe, 0xcc, 0x02, 0x6d, 0x06, 0x37, 0x00, 0x0b,
+	0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x15, 0x00, 0x11, 0x10, 0x01, 0x15, 0x26,
+	0x00, 0x11, 0x10, 0x00, 0x02, 0x6d, 0xfe, 0xf6, 0x01, 0x0a, 0xf3, 0xfe, 0xda, 0x01, 0x21, 0x06,
+	0x37, 0xbf, 0xfe, 0xf4, 0xfe, 0x15, 0xfe, 0x17, 0xfe, 0xf3, 0xbf, 0x83, 0x02, 0x09, 0x01, 0x2a,
+	0x01, 0x2b, 0x02, 0x00, 0x00, 0x01, 0x00, 0x3d, 0xfe, 0xcc, 0x02, 0x56, 0x06, 0x37, 0x00, 0x0b,
+	0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b, 0x13, 0x35, 0x00, 0x11, 0x10, 0x01, 0x35, 0x16,
+	0x00, 0x11, 0x10, 0x00, 0x3d, 0x01, 0x09, 0xfe, 0xf7, 0xf3, 0x01, 0x26, 0xfe, 0xde, 0xfe, 0xcc,
+	0xbf, 0x01, 0x0d, 0x01, 0xe7, 0x01, 0xed, 0x01, 0x0c, 0xbf, 0x83, 0xfd, 0xf9, 0xfe, 0xd4, 0xfe,
+	0xd6, 0xfd, 0xff, 0x00, 0x00, 0x05, 0x00, 0x57, 0x01, 0x17, 0x04, 0x20, 0x04, 0xb2, 0x00, 0x06,
+	0x00, 0x0b, 0x00, 0x10, 0x00, 0x17, 0x00, 0x1e, 0x00, 0x32, 0x40, 0x2f, 0x13, 0x01, 0x02, 0x01,
+	0x00, 0x01, 0x4c, 0x1e, 0x1a, 0x14, 0x12, 0x11, 0x0f, 0x0e, 0x0d, 0x0a, 0x09, 0x08, 0x05, 0x03,
+	0x02, 0x0e, 0x01, 0x49, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x00, 0x01, 0x51, 0x1d, 0x1b, 0x19, 0x18, 0x02, 0x09, 0x16, 0x2b, 0x01, 0x25, 0x13, 0x05,
+	0x36, 0x35, 0x34, 0x07, 0x05, 0x07, 0x03, 0x36, 0x07, 0x03, 0x27, 0x25, 0x16, 0x27, 0x25, 0x13,
+	0x05, 0x06, 0x15, 0x16, 0x03, 0x21, 0x03, 0x26, 0x23, 0x22, 0x07, 0x02, 0x8d, 0x01, 0x3a, 0x59,
+	0xfe, 0x93, 0x02, 0x09, 0x01, 0x12, 0xe3, 0x94, 0x44, 0x5c, 0x94, 0xe3, 0x01, 0x11, 0x21, 0x29,
+	0xfe, 0x96, 0x56, 0x01, 0x3b, 0x28, 0x01, 0x12, 0x01, 0x17, 0x4c, 0x21, 0x1f, 0x20, 0x1e, 0x03,
+	0x3e, 0xc1, 0xfe, 0xf7, 0x2f, 0x0f, 0x0a, 0x34, 0x64, 0xf3, 0xa6, 0x01, 0x4f, 0x0f, 0x0e, 0xfe,
+	0xb1, 0xa4, 0xf4, 0x3b, 0x52, 0x2e, 0x01, 0x0a, 0xc0, 0x2c, 0x33, 0x0a, 0x01, 0xdc, 0xfe, 0x9a,
+	0x10, 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x68, 0x00, 0x63, 0x04, 0x43, 0x04, 0x3e, 0x00, 0x0b,
+	0x00, 0x27, 0x40, 0x24, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x06, 0x01,
+	0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3b, 0x05, 0x4e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33,
+	0x11, 0x21, 0x15, 0x21, 0x11, 0x01, 0xf4, 0xfe, 0x74, 0x01, 0x8c, 0xc3, 0x01, 0x8c, 0xfe, 0x74,
+	0x63, 0x01, 0x8c, 0xc3, 0x01, 0x8c, 0xfe, 0x74, 0xc3, 0xfe, 0x74, 0x00, 0x00, 0x01, 0x00, 0x7c,
+	0xfe, 0xa2, 0x01, 0xbd, 0x01, 0x41, 0x00, 0x09, 0x00, 0x56, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40,
+	0x15, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x03,
+	0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x39, 0x00, 0x4e,
+	0x1b, 0x40, 0x12, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x3c, 0x00, 0x4e, 0x59, 0x59, 0xb6, 0x11, 0x12, 0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b,
+	0x33, 0x23, 0x11, 0x21, 0x15, 0x10, 0x21, 0x35, 0x32, 0x35, 0xf7, 0x7b, 0x01, 0x41, 0xfe, 0xbf,
+	0x7b, 0x01, 0x41, 0xf9, 0xfe, 0x5a, 0x6f, 0xcf, 0x00, 0x01, 0x00, 0x68, 0x01, 0xef, 0x04, 0x44,
+	0x02, 0xb2, 0x00, 0x03, 0x00, 0x1f, 0x40, 0x1c, 0x02, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x02,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x03, 0x09, 0x17, 0x2b, 0x01, 0x15, 0x21, 0x35, 0x04, 0x44, 0xfc, 0x24, 0x02, 0xb2, 0xc3,
+	0xc3, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7c, 0x00, 0x00, 0x01, 0xbd, 0x01, 0x41, 0x00, 0x03,
+	0x00, 0x30, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01,
+	0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09,
+	0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x7c, 0x01, 0x41, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xff, 0x85, 0x02, 0x39, 0x05, 0x7e, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x03, 0x09, 0x17, 0x2b, 0x15, 0x01, 0x33, 0x01, 0x01, 0x71, 0xc8, 0xfe, 0x8f, 0x7b, 0x05,
+	0xf9, 0xfa, 0x07, 0x00, 0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x04, 0x24, 0x05, 0xed, 0x00, 0x0b,
+	0x00, 0x12, 0x00, 0x19, 0x00, 0x5e, 0x40, 0x09, 0x18, 0x17, 0x11, 0x10, 0x04, 0x02, 0x03, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x06, 0x01, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x3e, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e,
+	0x1b, 0x40, 0x16, 0x00, 0x01, 0x06, 0x01, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x17, 0x14, 0x13, 0x0d, 0x0c,
+	0x01, 0x00, 0x13, 0x19, 0x14, 0x19, 0x0c, 0x12, 0x0d, 0x12, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x07, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00,
+	0x27, 0x32, 0x11, 0x34, 0x27, 0x01, 0x12, 0x13, 0x22, 0x11, 0x14, 0x17, 0x01, 0x02, 0x02, 0x3a,
+	0xdf, 0xfe, 0xf5, 0x01, 0x0c, 0xde, 0xdd, 0x01, 0x0d, 0xfe, 0xf4, 0xde, 0xd2, 0x04, 0xfe, 0x70,
+	0x2a, 0x98, 0xd2, 0x03, 0x01, 0x90, 0x2a, 0x25, 0x01, 0xac, 0x01, 0x5e, 0x01, 0x60, 0x01, 0xa8,
+	0xfe, 0x59, 0xfe, 0x9f, 0xfe, 0x9d, 0xfe, 0x59, 0xb9, 0x02, 0x51, 0x50, 0x45, 0xfe, 0x4f, 0xfe,
+	0xcb, 0x04, 0xa0, 0xfd, 0xb1, 0x50, 0x45, 0x01, 0xb1, 0x01, 0x33, 0x00, 0x00, 0x01, 0x00, 0xb6,
+	0x00, 0x00, 0x04, 0x2e, 0x05, 0xed, 0x00, 0x09, 0x00, 0x3b, 0xb6, 0x06, 0x05, 0x04, 0x03, 0x04,
+	0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03,
+	0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03,
+	0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x15,
+	0x11, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x35, 0x21, 0x11, 0x05, 0x35, 0x25, 0x11, 0x21, 0x15, 0xb6,
+	0x01, 0x28, 0xfe, 0xd8, 0x02, 0x50, 0x01, 0x28, 0xad, 0x04, 0x44, 0x4a, 0xb2, 0x94, 0xfa, 0xc0,
+	0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4d, 0x00, 0x00, 0x03, 0xf2, 0x05, 0xed, 0x00, 0x1a,
+	0x00, 0x55, 0x40, 0x0f, 0x0d, 0x01, 0x00, 0x01, 0x0c, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x01, 0x01,
+	0x02, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x69, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x1a, 0x00, 0x1a,
+	0x18, 0x23, 0x29, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x35, 0x36, 0x3f, 0x02, 0x36, 0x36, 0x35, 0x34,
+	0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x04, 0x15, 0x14, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21,
+	0x15, 0x4d, 0x51, 0x76, 0x66, 0x76, 0x7c, 0x4b, 0xdc, 0x8f, 0xd9, 0xe2, 0xb7, 0xe3, 0x01, 0x03,
+	0x7d, 0xa2, 0x63, 0xc0, 0x14, 0x02, 0x51, 0xea, 0x8f, 0x79, 0x69, 0x78, 0x7f, 0x8b, 0x6a, 0xe7,
+	0x6e, 0xd9, 0x54, 0xdf, 0xc4, 0x80, 0xcc, 0x8b, 0x53, 0xa3, 0x93, 0xea, 0x00, 0x01, 0x00, 0x89,
+	0xff, 0xdb, 0x03, 0xfd, 0x05, 0xed, 0x00, 0x1f, 0x00, 0x67, 0x40, 0x16, 0x12, 0x01, 0x03, 0x04,
+	0x11, 0x01, 0x02, 0x03, 0x19, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x05, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x42, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x28, 0x23, 0x23, 0x11, 0x23, 0x22, 0x06, 0x09, 0x1c,
+	0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x32, 0x36, 0x35, 0x34,
+	0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x10, 0x05, 0x04, 0x11, 0x14, 0x04, 0x23,
+	0x22, 0x89, 0xde, 0x6a, 0xf8, 0xad, 0xd7, 0x33, 0xe0, 0xae, 0xd7, 0x9f, 0x8d, 0xa3, 0xc6, 0xd9,
+	0xef, 0xfe, 0xb9, 0x01, 0x76, 0xfe, 0xda, 0xf1, 0xa5, 0x0b, 0xde, 0x55, 0xf1, 0xa7, 0x86, 0xb1,
+	0x70, 0x90, 0xd2, 0x54, 0xca, 0x42, 0xba, 0xa9, 0xfe, 0xfc, 0x6c, 0x56, 0xfe, 0xc6, 0xc2, 0xed,
+	0x00, 0x02, 0x00, 0x1f, 0x00, 0x00, 0x04, 0x2c, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x56,
+	0x40, 0x0b, 0x0d, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x16, 0x05, 0x01, 0x01, 0x06, 0x04, 0x02, 0x02, 0x03, 0x01, 0x02, 0x68, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x00, 0x01,
+	0x00, 0x85, 0x05, 0x01, 0x01, 0x06, 0x04, 0x02, 0x02, 0x03, 0x01, 0x02, 0x68, 0x00, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x11,
+	0x11, 0x12, 0x07, 0x09, 0x1a, 0x2b, 0x13, 0x35, 0x01, 0x21, 0x11, 0x33, 0x15, 0x23, 0x11, 0x21,
+	0x11, 0x25, 0x21, 0x11, 0x1f, 0x02, 0x76, 0x01, 0x0f, 0x88, 0x88, 0xfe, 0xfd, 0xfe, 0x6c, 0x01,
+	0x9a, 0x01, 0x8b, 0xde, 0x03, 0x5f, 0xfc, 0xa1, 0xde, 0xfe, 0x75, 0x01, 0x8b, 0xde, 0x02, 0x44,
+	0x00, 0x01, 0x00, 0x90, 0xff, 0xdb, 0x03, 0xf8, 0x05, 0xc8, 0x00, 0x21, 0x00, 0x5b, 0x40, 0x0a,
+	0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1d, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02,
+	0x02, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b, 0x40,
+	0x1b, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01,
+	0x69, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x28,
+	0x21, 0x11, 0x11, 0x28, 0x23, 0x06, 0x09, 0x1c, 0x2b, 0x37, 0x35, 0x16, 0x16, 0x33, 0x32, 0x3e,
+	0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x11, 0x21, 0x15, 0x21, 0x11, 0x33, 0x32, 0x1e, 0x02,
+	0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x90, 0x4b, 0x8f, 0x4f, 0x3c, 0x60, 0x44, 0x24, 0x31,
+	0x64, 0x9a, 0x6a, 0x7a, 0x03, 0x30, 0xfd, 0xa3, 0x1f, 0x7e, 0xdc, 0xa3, 0x5f, 0x5c, 0x99, 0xc5,
+	0x69, 0x42, 0xa1, 0x06, 0xd6, 0x24, 0x24, 0x2c, 0x4b, 0x62, 0x37, 0x52, 0x73, 0x49, 0x22, 0x02,
+	0xf4, 0xea, 0xfe, 0xab, 0x30, 0x6d, 0xb1, 0x81, 0x75, 0xb3, 0x79, 0x3e, 0x14, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x34, 0xff, 0xdb, 0x04, 0x25, 0x05, 0xed, 0x00, 0x16, 0x00, 0x20, 0x00, 0x5f,
+	0x40, 0x0e, 0x00, 0x01, 0x00, 0x03, 0x01, 0x01, 0x01, 0x00, 0x07, 0x01, 0x04, 0x01, 0x03, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x69, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3e, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x3f, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x03, 0x00, 0x00, 0x01, 0x03, 0x00, 0x69, 0x00,
+	0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x69, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42,
+	0x02, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x01,
+	0x15, 0x26, 0x23, 0x22, 0x02, 0x15, 0x17, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x02, 0x23, 0x22,
+	0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x03, 0x10, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32,
+	0x03, 0xdd, 0xc0, 0x5e, 0xa1, 0xb4, 0x01, 0x7b, 0xa7, 0xbc, 0xdc, 0xf5, 0xe5, 0xfc, 0xfe, 0xe5,
+	0x01, 0x57, 0x01, 0x23, 0x7f, 0x18, 0xc0, 0x64, 0x78, 0x75, 0x64, 0xc3, 0x05, 0xbf, 0xd8, 0x4e,
+	0xfe, 0xf8, 0xed, 0x18, 0x91, 0xf8, 0xd3, 0xff, 0xfe, 0xec, 0x01, 0x83, 0x01, 0x59, 0x01, 0x79,
+	0x01, 0xbd, 0xfb, 0xdf, 0x01, 0x37, 0xa8, 0x8b, 0x92, 0xaa, 0x00, 0x00, 0x00, 0x01, 0x00, 0x71,
+	0x00, 0x00, 0x04, 0x1b, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb4, 0x08, 0x01, 0x00, 0x01, 0x4b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38,
+	0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0f, 0x00, 0x01, 0x00, 0x00, 0x02,
+	0x01, 0x00, 0x67, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00,
+	0x0a, 0x00, 0x0a, 0x11, 0x14, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x36, 0x12, 0x13, 0x13, 0x21, 0x35,
+	0x21, 0x15, 0x00, 0x03, 0xb0, 0x14, 0xa1, 0xda, 0xea, 0xfd, 0x48, 0x03, 0xaa, 0xfd, 0xf4, 0x16,
+	0xa0, 0x01, 0x5c, 0x01, 0x61, 0x01, 0x7b, 0xf0, 0xf0, 0xfd, 0x1e, 0xfe, 0x0a, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x56, 0xff, 0xdb, 0x04, 0x36, 0x05, 0xed, 0x00, 0x16, 0x00, 0x20, 0x00, 0x2b,
+	0x00, 0x43, 0xb5, 0x0b, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15,
+	0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x00, 0x00, 0x02, 0x03, 0x00, 0x02, 0x69,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0xb6, 0x28, 0x28, 0x29,
+	0x25, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x26, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x07, 0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x24, 0x35, 0x34, 0x36, 0x25, 0x36, 0x35, 0x34,
+	0x23, 0x22, 0x15, 0x14, 0x17, 0x16, 0x07, 0x06, 0x15, 0x14, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26,
+	0x27, 0x01, 0x69, 0x73, 0x4e, 0xe8, 0xcb, 0xbb, 0xdd, 0xe1, 0xa7, 0x7d, 0xfe, 0xe3, 0xe3, 0xdd,
+	0xfe, 0xfd, 0x79, 0x01, 0xa9, 0x7b, 0xa3, 0xa8, 0x9b, 0x12, 0x58, 0x8e, 0xe5, 0x5e, 0x78, 0x42,
+	0x7f, 0x03, 0x1d, 0x5f, 0x89, 0x6e, 0xb0, 0xca, 0xb6, 0x9a, 0xd4, 0x9c, 0x6c, 0xae, 0x7d, 0xc4,
+	0xf7, 0xd8, 0xb9, 0x84, 0xbe, 0xd3, 0x5e, 0x99, 0xbc, 0xa3, 0x6f, 0x76, 0x0e, 0xe3, 0x85, 0xad,
+	0xf8, 0x71, 0x58, 0x51, 0x5c, 0x61, 0x00, 0x00, 0x00, 0x02, 0x00, 0x4f, 0xff, 0xdb, 0x04, 0x40,
+	0x05, 0xed, 0x00, 0x16, 0x00, 0x20, 0x00, 0x5f, 0x40, 0x0e, 0x07, 0x01, 0x01, 0x04, 0x01, 0x01,
+	0x00, 0x01, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00,
+	0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00,
+	0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x69, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x22, 0x24,
+	0x24, 0x24, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x12, 0x35, 0x35, 0x06,
+	0x23, 0x22, 0x26, 0x35, 0x34, 0x12, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x21, 0x22, 0x13, 0x10,
+	0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x97, 0xc1, 0x5d, 0xa2, 0xb3, 0x7c, 0xa7, 0xbc,
+	0xdc, 0xf6, 0xe4, 0xfc, 0x01, 0x1b, 0xfe, 0xa9, 0xfe, 0xde, 0x80, 0x18, 0xc0, 0x64, 0x79, 0x76,
+	0x64, 0xc3, 0x09, 0xd9, 0x4e, 0x01, 0x07, 0xed, 0x18, 0x91, 0xf8, 0xd4, 0xff, 0x01, 0x13, 0xfe,
+	0x7d, 0xfe, 0xa8, 0xfe, 0x87, 0xfe, 0x42, 0x04, 0x22, 0xfe, 0xc8, 0xa9, 0x8b, 0x91, 0xab, 0x00,
+	0x00, 0x02, 0x00, 0xd6, 0x00, 0x00, 0x02, 0x17, 0x04, 0x63, 0x00, 0x03, 0x00, 0x07, 0x00, 0x6a,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x17, 0x04, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x00, 0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x00,
+	0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x01, 0x11,
+	0x21, 0x11, 0xd6, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0x03, 0x22, 0x01, 0x41, 0xfe, 0xbf, 0xfc,
+	0xde, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xd6, 0xfe, 0xa2, 0x02, 0x17,
+	0x04, 0x63, 0x00, 0x03, 0x00, 0x0d, 0x00, 0xa5, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x20, 0x06,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x04, 0x05, 0x04, 0x65, 0x06, 0x01,
+	0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x00, 0x06, 0x01,
+	0x01, 0x03, 0x00, 0x01, 0x67, 0x00, 0x05, 0x00, 0x04, 0x05, 0x04, 0x65, 0x00, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x00, 0x06, 0x01, 0x01, 0x03,
+	0x00, 0x01, 0x67, 0x00, 0x05, 0x00, 0x04, 0x05, 0x04, 0x65, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0c, 0x0b, 0x0a, 0x09,
+	0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x13, 0x11, 0x21,
+	0x11, 0x03, 0x23, 0x11, 0x21, 0x15, 0x10, 0x21, 0x35, 0x32, 0x35, 0xd6, 0x01, 0x41, 0xc6, 0x7b,
+	0x01, 0x41, 0xfe, 0xbf, 0x7b, 0x03, 0x22, 0x01, 0x41, 0xfe, 0xbf, 0xfc, 0xde, 0x01, 0x41, 0xf9,
+	0xfe, 0x5a, 0x6f, 0xcf, 0x00, 0x01, 0x00, 0x68, 0x00, 0x63, 0x04, 0x43, 0x04, 0x3e, 0x00, 0x06,
+	0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x25, 0x01, 0x01, 0x15, 0x01, 0x15, 0x01, 0x04,
+	0x43, 0xfc, 0x25, 0x03, 0xdb, 0xfd, 0xdb, 0x02, 0x25, 0x63, 0x01, 0xed, 0x01, 0xee, 0xda, 0xfe,
+	0xed, 0x02, 0xfe, 0xee, 0x00, 0x02, 0x00, 0x68, 0x01, 0x0d, 0x04, 0x43, 0x03, 0x82, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f,
+	0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06,
+	0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x68, 0x03, 0xdb, 0xfc, 0x25,
+	0x03, 0xdb, 0x01, 0x0d, 0xd4, 0xd4, 0x01, 0xb2, 0xc3, 0xc3, 0x00, 0x00, 0x00, 0x01, 0x00, 0x69,
+	0x00, 0x63, 0x04, 0x44, 0x04, 0x3e, 0x00, 0x06, 0x00, 0x06, 0xb3, 0x06, 0x04, 0x01, 0x32, 0x2b,
+	0x13, 0x01, 0x35, 0x01, 0x35, 0x01, 0x01, 0x69, 0x02, 0x25, 0xfd, 0xdb, 0x03, 0xdb, 0xfc, 0x25,
+	0x01, 0x3d, 0x01, 0x12, 0x02, 0x01, 0x13, 0xda, 0xfe, 0x12, 0xfe, 0x13, 0x00, 0x02, 0x00, 0x8c,
+	0x00, 0x00, 0x04, 0x5f, 0x05, 0xed, 0x00, 0x03, 0x00, 0x1c, 0x00, 0x64, 0x40, 0x0a, 0x11, 0x01,
+	0x03, 0x04, 0x10, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00,
+	0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e,
+	0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1c,
+	0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x00,
+	0x00, 0x14, 0x12, 0x0f, 0x0d, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b,
+	0x21, 0x35, 0x21, 0x15, 0x03, 0x21, 0x35, 0x34, 0x36, 0x37, 0x37, 0x36, 0x35, 0x34, 0x21, 0x22,
+	0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x14, 0x06, 0x07, 0x07, 0x06, 0x06, 0x15, 0x01, 0x60, 0x01,
+	0x3c, 0x0a, 0xfe, 0xd8, 0x56, 0x72, 0x64, 0x7e, 0xfe, 0xf9, 0xd8, 0xa9, 0xc3, 0xdc, 0x02, 0x34,
+	0x62, 0x93, 0x53, 0x51, 0x34, 0xf7, 0xf7, 0x01, 0xb0, 0x12, 0x79, 0x9f, 0x55, 0x4a, 0x66, 0x8c,
+	0xbd, 0x53, 0xe2, 0x36, 0xfe, 0xa5, 0x69, 0x80, 0x58, 0x32, 0x30, 0x75, 0x83, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xbf, 0xff, 0xdb, 0x06, 0xf6, 0x05, 0xed, 0x00, 0x33, 0x00, 0x3e, 0x01, 0xc8,
+	0x40, 0x0a, 0x35, 0x01, 0x03, 0x0a, 0x33, 0x01, 0x09, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50,
+	0x58, 0x40, 0x32, 0x00, 0x03, 0x0a, 0x07, 0x0a, 0x03, 0x07, 0x80, 0x0b, 0x01, 0x07, 0x04, 0x01,
+	0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00,
+	0x0a, 0x0a, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x30, 0x00, 0x03, 0x0a,
+	0x07, 0x0a, 0x03, 0x07, 0x80, 0x06, 0x01, 0x05, 0x00, 0x0a, 0x03, 0x05, 0x0a, 0x69, 0x0b, 0x01,
+	0x07, 0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x32, 0x00, 0x03, 0x0a, 0x07, 0x0a, 0x03, 0x07, 0x80, 0x0b, 0x01, 0x07,
+	0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x00, 0x0a, 0x0a, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x30, 0x00,
+	0x03, 0x0a, 0x07, 0x0a, 0x03, 0x07, 0x80, 0x06, 0x01, 0x05, 0x00, 0x0a, 0x03, 0x05, 0x0a, 0x69,
+	0x0b, 0x01, 0x07, 0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40, 0x35, 0x00, 0x03, 0x0a, 0x0b, 0x0a, 0x03, 0x0b, 0x80, 0x06,
+	0x01, 0x05, 0x00, 0x0a, 0x03, 0x05, 0x0a, 0x69, 0x00, 0x0b, 0x07, 0x02, 0x0b, 0x59, 0x00, 0x07,
+	0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x3c, 0x00, 0x06, 0x05, 0x0a, 0x05, 0x06, 0x0a, 0x80, 0x00, 0x03, 0x0a, 0x0b,
+	0x0a, 0x03, 0x0b, 0x80, 0x00, 0x05, 0x00, 0x0a, 0x03, 0x05, 0x0a, 0x69, 0x00, 0x0b, 0x07, 0x02,
+	0x0b, 0x59, 0x00, 0x07, 0x04, 0x01, 0x02, 0x09, 0x07, 0x02, 0x6a, 0x00, 0x08, 0x08, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e,
+	0x1b, 0x40, 0x3a, 0x00, 0x06, 0x05, 0x0a, 0x05, 0x06, 0x0a, 0x80, 0x00, 0x03, 0x0a, 0x0b, 0x0a,
+	0x03, 0x0b, 0x80, 0x00, 0x01, 0x00, 0x08, 0x05, 0x01, 0x08, 0x69, 0x00, 0x05, 0x00, 0x0a, 0x03,
+	0x05, 0x0a, 0x69, 0x00, 0x0b, 0x07, 0x02, 0x0b, 0x59, 0x00, 0x07, 0x04, 0x01, 0x02, 0x09, 0x07,
+	0x02, 0x6a, 0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x40, 0x12, 0x3d, 0x3b, 0x38, 0x36, 0x32, 0x30, 0x24, 0x24, 0x22, 0x23, 0x22,
+	0x13, 0x24, 0x24, 0x21, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x21, 0x20, 0x00, 0x11, 0x14, 0x00, 0x23, 0x22, 0x35, 0x34, 0x37, 0x23, 0x06, 0x06, 0x23, 0x22,
+	0x35, 0x34, 0x00, 0x33, 0x32, 0x17, 0x16, 0x33, 0x33, 0x03, 0x06, 0x15, 0x14, 0x33, 0x32, 0x12,
+	0x35, 0x34, 0x00, 0x23, 0x20, 0x00, 0x11, 0x14, 0x00, 0x33, 0x32, 0x37, 0x03, 0x37, 0x26, 0x23,
+	0x22, 0x02, 0x15, 0x14, 0x33, 0x32, 0x36, 0x04, 0xa8, 0xaf, 0xae, 0xfe, 0xe3, 0xfe, 0x91, 0x02,
+	0x34, 0x01, 0x72, 0x01, 0x19, 0x01, 0x78, 0xfe, 0xcf, 0xe1, 0xa3, 0x38, 0x15, 0x4f, 0xdf, 0x63,
+	0xb3, 0x01, 0x43, 0xbc, 0x17, 0x2a, 0x3b, 0x48, 0x86, 0x6f, 0x0a, 0x4c, 0x77, 0xc2, 0xfe, 0xcf,
+	0xe0, 0xfe, 0xc4, 0xfe, 0x18, 0x01, 0x25, 0xe3, 0x9b, 0x9a, 0x07, 0x26, 0x50, 0x3e, 0x81, 0xba,
+	0x47, 0x36, 0xcf, 0x2d, 0x52, 0x01, 0x5b, 0x01, 0x0c, 0x01, 0x74, 0x02, 0x37, 0xfe, 0x9b, 0xfe,
+	0xf4, 0xfc, 0xfe, 0xa8, 0x6d, 0x2e, 0xb8, 0x96, 0xbd, 0xe7, 0xec, 0x01, 0x99, 0x06, 0x08, 0xfd,
+	0xd2, 0x34, 0x2d, 0x44, 0x01, 0x15, 0xab, 0xd6, 0x01, 0x23, 0xfe, 0x17, 0xfe, 0xc2, 0xd6, 0xfe,
+	0xed, 0x48, 0x02, 0x94, 0xba, 0x25, 0xfe, 0xe8, 0xc2, 0x7b, 0xdf, 0x00, 0x00, 0x02, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21,
+	0x03, 0x13, 0x21, 0x03, 0x0c, 0x02, 0x3e, 0x01, 0x34, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c,
+	0x97, 0xe3, 0x01, 0xcc, 0xe6, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02,
+	0x4e, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x05, 0x7e, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x14, 0x00, 0x1d, 0x00, 0x61, 0xb5, 0x06, 0x01, 0x05, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x00,
+	0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01,
+	0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1d, 0x1b, 0x17, 0x15, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b,
+	0x00, 0x0a, 0x21, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x04, 0x11,
+	0x14, 0x06, 0x23, 0x01, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x21, 0x11, 0x21, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x21, 0xad, 0x02, 0xcc, 0x01, 0xc8, 0xfe, 0x9d, 0x01, 0xa0, 0xf3, 0xe4,
+	0xfe, 0x28, 0x01, 0x1e, 0x82, 0x99, 0x7b, 0xab, 0xfe, 0xed, 0x01, 0x17, 0xc2, 0x93, 0xc5, 0x96,
+	0xfe, 0xef, 0x05, 0xc8, 0xfe, 0xb7, 0xfe, 0xf5, 0x6f, 0x64, 0xfe, 0xcd, 0xb1, 0xbd, 0x03, 0x60,
+	0x81, 0x6d, 0x65, 0x4a, 0xfb, 0xd5, 0x53, 0x6d, 0x72, 0x96, 0x00, 0x00, 0x00, 0x01, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0x7e, 0x05, 0xed, 0x00, 0x13, 0x00, 0x4d, 0x40, 0x0f, 0x0b, 0x01, 0x02, 0x01,
+	0x0c, 0x00, 0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01,
+	0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0xb6, 0x22,
+	0x23, 0x24, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x15, 0x06, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x21, 0x20, 0x17, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x21, 0x32, 0x05, 0x7e, 0xd7, 0xfe, 0xc0,
+	0xfe, 0x83, 0xfe, 0x66, 0x01, 0x9e, 0x01, 0x8f, 0x01, 0x03, 0xf1, 0xfe, 0xef, 0xc8, 0xfd, 0xff,
+	0x02, 0x1e, 0xeb, 0x01, 0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39,
+	0xf1, 0x5f, 0xfd, 0xc6, 0xfd, 0xc8, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x77,
+	0x05, 0xc8, 0x00, 0x08, 0x00, 0x11, 0x00, 0x46, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00,
+	0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69,
+	0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x11, 0x0f, 0x0b, 0x09, 0x00, 0x08, 0x00, 0x07, 0x21, 0x05, 0x09, 0x17, 0x2b, 0x33, 0x11,
+	0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x27, 0x33, 0x32, 0x12, 0x11, 0x34, 0x02, 0x23, 0x23,
+	0xad, 0x02, 0x03, 0x01, 0x58, 0x01, 0x6f, 0xfe, 0x7c, 0xfe, 0xa2, 0xb4, 0x6d, 0xf3, 0xef, 0xf0,
+	0xd3, 0x8c, 0x05, 0xc8, 0xfe, 0x93, 0xfe, 0xa8, 0xfe, 0x92, 0xfe, 0x6b, 0xd2, 0x01, 0x0d, 0x01,
+	0x12, 0xf5, 0x01, 0x17, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x56, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06,
+	0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0x05,
+	0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x04, 0xb5,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01,
+	0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e,
+	0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x09, 0x1a,
+	0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0xad, 0x04, 0x08, 0xfd, 0x2c,
+	0x02, 0x65, 0xfd, 0x9b, 0x05, 0xc8, 0xcb, 0xfe, 0x3e, 0xcc, 0xfd, 0x91, 0x00, 0x01, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xa5, 0x05, 0xed, 0x00, 0x1d, 0x00, 0x6a, 0x40, 0x12, 0x0f, 0x01, 0x02, 0x01,
+	0x10, 0x01, 0x05, 0x02, 0x1a, 0x01, 0x03, 0x04, 0x01, 0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1e, 0x06, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x06, 0x01,
+	0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22,
+	0x07, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x23, 0x22, 0x24, 0x27, 0x26, 0x11, 0x10, 0x37, 0x36,
+	0x24, 0x33, 0x20, 0x17, 0x15, 0x24, 0x23, 0x22, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11,
+	0x23, 0x35, 0x05, 0xa5, 0xfe, 0xe7, 0xe8, 0xf9, 0xfe, 0xd9, 0x6c, 0xc8, 0xbb, 0x6c, 0x01, 0x28,
+	0xf2, 0x01, 0x22, 0xf1, 0xfe, 0xd0, 0xdf, 0xfa, 0xfe, 0xfc, 0x01, 0x17, 0x01, 0x04, 0x47, 0x78,
+	0xfa, 0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e, 0x72, 0xd4, 0x01, 0x67, 0x01, 0x58, 0xd1, 0x79, 0x65,
+	0x39, 0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6, 0xfe, 0xee, 0xfe, 0xda, 0x0e, 0x01, 0x4b, 0xcb, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01,
+	0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03,
+	0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0xad, 0x01, 0x34, 0x02, 0x05, 0x01, 0x34, 0xfe, 0xcc, 0xfd, 0xfb, 0x05, 0xc8, 0xfd,
+	0xa7, 0x02, 0x59, 0xfa, 0x38, 0x02, 0xa3, 0xfd, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64,
+	0x00, 0x00, 0x03, 0x3c, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x18, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00,
+	0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02, 0x03, 0x01,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x3c,
+	0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x07, 0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15,
+	0x64, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0xd8, 0x03, 0xa1, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x4a, 0x40, 0x0a,
+	0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x12, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x38, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00,
+	0x03, 0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6,
+	0x23, 0x11, 0x13, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x15, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11,
+	0x21, 0x35, 0x21, 0x11, 0x10, 0x04, 0x21, 0x22, 0xba, 0xa9, 0x97, 0x73, 0xfe, 0xfc, 0x02, 0x38,
+	0xfe, 0xf4, 0xfe, 0xd9, 0xae, 0xfc, 0xdd, 0x38, 0x75, 0x9a, 0x04, 0x3e, 0xd2, 0xfb, 0x11, 0xfe,
+	0xf3, 0xf4, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0xb8, 0x05, 0xc8, 0x00, 0x0a,
+	0x00, 0x3f, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e,
+	0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x09, 0x19,
+	0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x11, 0xad, 0x01, 0x28, 0x02,
+	0x68, 0xff, 0xfd, 0xce, 0x02, 0xae, 0xfe, 0x7f, 0xfd, 0x9e, 0x05, 0xc8, 0xfd, 0x32, 0x02, 0xce,
+	0xfd, 0x68, 0xfc, 0xd0, 0x02, 0xd8, 0xfd, 0x28, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x04, 0xd1,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x3b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40,
+	0x11, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x3c,
+	0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x09, 0x18,
+	0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x15, 0xad, 0x01, 0x34, 0x02, 0xf0, 0x05, 0xc8, 0xfb, 0x0a,
+	0xd2, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0xfe, 0x05, 0xc8, 0x00, 0x0c,
+	0x00, 0x50, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x05, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00,
+	0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11, 0x12, 0x11, 0x06, 0x09,
+	0x1a, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x01, 0x21, 0x11, 0x21, 0x11, 0x01, 0x23, 0x01, 0x11, 0xad,
+	0x01, 0x98, 0x01, 0x24, 0x01, 0x2f, 0x01, 0x66, 0xfe, 0xe4, 0xfe, 0xd7, 0xf8, 0xfe, 0xde, 0x05,
+	0xc8, 0xfb, 0xef, 0x04, 0x11, 0xfa, 0x38, 0x04, 0x5d, 0xfc, 0x06, 0x04, 0x09, 0xfb, 0x94, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08,
+	0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01,
+	0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x01,
+	0x11, 0x33, 0x11, 0x21, 0x01, 0x11, 0xad, 0x01, 0x0f, 0x02, 0x67, 0xf7, 0xfe, 0xed, 0xfd, 0x9d,
+	0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xe9, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10,
+	0x12, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe,
+	0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01,
+	0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16,
+	0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x19, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00,
+	0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05,
+	0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00,
+	0x0b, 0x00, 0x0b, 0x25, 0x21, 0x06, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16,
+	0x15, 0x10, 0x21, 0x23, 0x11, 0x11, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x23, 0xad, 0x02, 0x5a,
+	0xbd, 0xba, 0x41, 0x5b, 0xfd, 0x97, 0xd6, 0x92, 0x01, 0x72, 0x92, 0xa5, 0xcd, 0x05, 0xc8, 0x2f,
+	0x46, 0x61, 0xb3, 0xfe, 0x05, 0xfd, 0xbc, 0x03, 0x0f, 0x01, 0x12, 0x7a, 0x62, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x50, 0xfe, 0xd8, 0x06, 0xce, 0x05, 0xed, 0x00, 0x11, 0x00, 0x1d, 0x00, 0x42,
+	0xb4, 0x03, 0x02, 0x02, 0x00, 0x49, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f,
+	0x00, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x00, 0x02, 0x02,
+	0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0xb6, 0x24, 0x26, 0x24, 0x35, 0x04, 0x09,
+	0x1a, 0x2b, 0x25, 0x04, 0x05, 0x07, 0x24, 0x27, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x01, 0x10, 0x12, 0x33, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02,
+	0x04, 0x80, 0x01, 0x21, 0x01, 0x2d, 0xc8, 0xfe, 0x72, 0xfa, 0x52, 0x28, 0xfe, 0xc4, 0xfe, 0x88,
+	0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfb, 0xae, 0xcb, 0xbb, 0xb9, 0xcc, 0xcd, 0xb8,
+	0xb8, 0xce, 0x22, 0x64, 0x20, 0xc6, 0x69, 0x9f, 0x05, 0x01, 0xa6, 0x01, 0x63, 0x01, 0x6d, 0x01,
+	0x9c, 0xfe, 0x64, 0xfe, 0x95, 0xfe, 0x1c, 0x01, 0xeb, 0xfe, 0xe9, 0xfe, 0xd1, 0x01, 0x2d, 0x01,
+	0x10, 0x01, 0x10, 0x01, 0x2e, 0xfe, 0xd4, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0xba,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x57, 0xb5, 0x06, 0x01, 0x02, 0x04, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05,
+	0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e,
+	0x1b, 0x40, 0x18, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x67, 0x06, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00,
+	0x12, 0x10, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x07, 0x09, 0x19, 0x2b, 0x33,
+	0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x01, 0x21, 0x01, 0x23, 0x11, 0x11, 0x33, 0x20, 0x11, 0x34,
+	0x21, 0x23, 0xad, 0x02, 0x85, 0x01, 0xc3, 0xfe, 0xe1, 0x01, 0xe4, 0xfe, 0xa6, 0xfe, 0x60, 0xf1,
+	0xa2, 0x01, 0x4f, 0xfe, 0xd5, 0xc6, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xdb, 0x81, 0xfd, 0x4d, 0x02,
+	0x5d, 0xfd, 0xa3, 0x03, 0x28, 0x01, 0x0f, 0xc6, 0x00, 0x01, 0x00, 0x63, 0xff, 0xda, 0x05, 0x09,
+	0x05, 0xed, 0x00, 0x23, 0x00, 0x4d, 0x40, 0x0f, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x02, 0x00,
+	0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0xb6, 0x2c, 0x23, 0x29, 0x22, 0x04,
+	0x09, 0x1a, 0x2b, 0x37, 0x35, 0x04, 0x33, 0x20, 0x35, 0x34, 0x2f, 0x02, 0x24, 0x26, 0x35, 0x10,
+	0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x15,
+	0x14, 0x04, 0x21, 0x22, 0x27, 0x66, 0x01, 0x1c, 0xef, 0x01, 0x54, 0x81, 0x89, 0xa3, 0xfe, 0xfb,
+	0xb0, 0x02, 0x5c, 0xfe, 0xe5, 0xee, 0xdf, 0xb5, 0x8c, 0x44, 0x61, 0x72, 0xaa, 0xf7, 0xbd, 0xfe,
+	0xa7, 0xfe, 0x8d, 0x8b, 0xae, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6,
+	0x01, 0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8,
+	0xd9, 0x1b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x28, 0x00, 0x00, 0x04, 0xbc, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x38, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01,
+	0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x21, 0x11,
+	0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x01, 0xd8, 0xfe, 0x50, 0x04, 0x94, 0xfe, 0x50, 0x04, 0xf3,
+	0xd5, 0xd5, 0xfb, 0x0d, 0x00, 0x01, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26, 0x05, 0xc8, 0x00, 0x14,
+	0x00, 0x36, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x11, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x11, 0x02, 0x01, 0x00,
+	0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0xb6,
+	0x25, 0x12, 0x23, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x20, 0x11,
+	0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0xa0, 0x01, 0x34,
+	0x8d, 0x9d, 0x01, 0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x05, 0xc8,
+	0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50,
+	0xdb, 0xc4, 0x00, 0x00, 0x00, 0x01, 0x00, 0x19, 0x00, 0x00, 0x05, 0x3e, 0x05, 0xc8, 0x00, 0x06,
+	0x00, 0x3a, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b,
+	0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x12, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x21, 0x01, 0x21, 0x01,
+	0x01, 0x33, 0x01, 0x02, 0x1b, 0xfd, 0xfe, 0x01, 0x49, 0x01, 0x84, 0x01, 0x74, 0xe4, 0xfe, 0x11,
+	0x05, 0xc8, 0xfb, 0xaf, 0x04, 0x51, 0xfa, 0x38, 0x00, 0x01, 0x00, 0x19, 0x00, 0x00, 0x07, 0x75,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x42, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x04, 0x02,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x03, 0x00, 0x85, 0x05,
+	0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c,
+	0x11, 0x12, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x21, 0x01, 0x01, 0x21, 0x13, 0x01,
+	0x33, 0x01, 0x21, 0x03, 0x01, 0x01, 0x95, 0xfe, 0x84, 0x01, 0x23, 0x01, 0x19, 0x01, 0x18, 0x01,
+	0x01, 0xff, 0x01, 0x2d, 0xdb, 0xfe, 0x65, 0xfe, 0xd9, 0xf0, 0xfe, 0xf8, 0x05, 0xc8, 0xfb, 0xc5,
+	0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03, 0xf7, 0xfc, 0x09, 0x00, 0x01, 0x00, 0x31,
+	0x00, 0x00, 0x05, 0x29, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01,
+	0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00,
+	0x38, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x21, 0x01,
+	0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x01, 0x31, 0x01, 0xda, 0xfe, 0x3b, 0x01, 0x67, 0x01, 0x2d,
+	0x01, 0x46, 0xf9, 0xfe, 0x3a, 0x01, 0xd6, 0xfe, 0x9a, 0xfe, 0xbf, 0xfe, 0xa8, 0x02, 0xd9, 0x02,
+	0xef, 0xfe, 0x0e, 0x01, 0xf2, 0xfd, 0x46, 0xfc, 0xf2, 0x02, 0x11, 0xfd, 0xef, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x1c, 0x00, 0x00, 0x05, 0x3b, 0x05, 0xc8, 0x00, 0x08, 0x00, 0x3c, 0xb7, 0x07,
+	0x04, 0x01, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01,
+	0x00, 0x02, 0x00, 0x85, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00,
+	0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x04, 0x09, 0x18, 0x2b, 0x21, 0x11, 0x01, 0x21, 0x01, 0x01,
+	0x33, 0x01, 0x11, 0x02, 0x07, 0xfe, 0x15, 0x01, 0x55, 0x01, 0x62, 0x01, 0x74, 0xf4, 0xfe, 0x00,
+	0x02, 0x6c, 0x03, 0x5c, 0xfd, 0x8f, 0x02, 0x71, 0xfc, 0xa6, 0xfd, 0x92, 0x00, 0x01, 0x00, 0x5e,
+	0x00, 0x00, 0x04, 0x86, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x4d, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01,
+	0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09,
+	0x12, 0x11, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21,
+	0x15, 0x5e, 0x02, 0xc2, 0xfd, 0x69, 0x03, 0xfd, 0xfd, 0x3e, 0x02, 0xc2, 0xd2, 0x04, 0x2b, 0xcb,
+	0xcb, 0xfb, 0xd5, 0xd2, 0x00, 0x01, 0x00, 0x9f, 0xfe, 0xd8, 0x02, 0x6e, 0x06, 0x2b, 0x00, 0x07,
+	0x00, 0x22, 0x40, 0x1f, 0x00, 0x02, 0x04, 0x01, 0x03, 0x02, 0x03, 0x63, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x05, 0x09, 0x19, 0x2b, 0x13, 0x11, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x9f, 0x01, 0xcf, 0xd8,
+	0xd8, 0xfe, 0xd8, 0x07, 0x53, 0xad, 0xfa, 0x07, 0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xff, 0x85, 0x02, 0x39, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x2e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x0c, 0x02, 0x01, 0x01, 0x00, 0x01, 0x86, 0x00, 0x00, 0x00, 0x38, 0x00, 0x4e, 0x1b, 0x40, 0x0a,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x05, 0x01, 0x33, 0x01, 0x01, 0x71, 0xfe, 0x8f,
+	0xc8, 0x01, 0x71, 0x7b, 0x06, 0x43, 0xf9, 0xbd, 0x00, 0x01, 0x00, 0x3c, 0xfe, 0xd8, 0x02, 0x0b,
+	0x06, 0x2b, 0x00, 0x07, 0x00, 0x1c, 0x40, 0x19, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x63, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x3a, 0x02, 0x4e, 0x11, 0x11, 0x11, 0x10, 0x04, 0x09,
+	0x1a, 0x2b, 0x01, 0x21, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x02, 0x0b, 0xfe, 0x31, 0xd8, 0xd8,
+	0x01, 0xcf, 0xfe, 0xd8, 0xad, 0x05, 0xf9, 0xad, 0x00, 0x01, 0x00, 0x68, 0x02, 0xbf, 0x04, 0x44,
+	0x05, 0xc8, 0x00, 0x06, 0x00, 0x20, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x15, 0x04, 0x01, 0x02, 0x00,
+	0x4a, 0x02, 0x01, 0x02, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x12, 0x03, 0x09,
+	0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x01, 0x01, 0x23, 0x01, 0x23, 0x01, 0x68, 0x01, 0xee,
+	0x01, 0xee, 0xcf, 0xfe, 0xe2, 0x02, 0xfe, 0xe2, 0x02, 0xbf, 0x03, 0x09, 0xfc, 0xf7, 0x01, 0xc4,
+	0xfe, 0x3c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xff, 0x53, 0x04, 0x73, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x15, 0x35, 0x21, 0x15, 0x04, 0x73, 0xad, 0xad,
+	0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4b, 0x05, 0x03, 0x02, 0x55, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x19, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0e, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00,
+	0x76, 0x11, 0x10, 0x02, 0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x23, 0x01, 0x21, 0x02,
+	0x55, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x45,
+	0xff, 0xe7, 0x04, 0x3b, 0x04, 0x63, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x97, 0x4b, 0xb0, 0x2d, 0x50,
+	0x58, 0x40, 0x14, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x00, 0x02, 0x05, 0x06,
+	0x05, 0x01, 0x02, 0x00, 0x05, 0x04, 0x4c, 0x1b, 0x40, 0x17, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01,
+	0x02, 0x03, 0x1d, 0x01, 0x07, 0x06, 0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05,
+	0x4c, 0x59, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x02, 0x00, 0x06, 0x07,
+	0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x07, 0x07,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0b, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23, 0x22, 0x08,
+	0x09, 0x1e, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10,
+	0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33, 0x32,
+	0x25, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34,
+	0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02, 0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52,
+	0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53, 0x40, 0x66, 0xa9, 0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01,
+	0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53,
+	0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x04, 0x94, 0x06, 0x2b, 0x00, 0x0d, 0x00, 0x16, 0x00, 0x86,
+	0x40, 0x0b, 0x04, 0x01, 0x05, 0x02, 0x16, 0x0e, 0x02, 0x04, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x1b, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05,
+	0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x3c, 0x4d, 0x00, 0x04, 0x04,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x22, 0x22, 0x24, 0x22,
+	0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x21, 0x21, 0x11, 0x21, 0x11, 0x36, 0x33, 0x32, 0x12, 0x15,
+	0x10, 0x00, 0x23, 0x22, 0x27, 0x16, 0x33, 0x32, 0x11, 0x10, 0x23, 0x22, 0x07, 0x01, 0xbc, 0xfe,
+	0xd8, 0x01, 0x28, 0x9d, 0xbc, 0xac, 0xd3, 0xfe, 0xef, 0xf3, 0x51, 0x83, 0x70, 0x37, 0xf6, 0xb3,
+	0x78, 0x72, 0x06, 0x2b, 0xfd, 0x69, 0xcf, 0xfe, 0xd5, 0xf5, 0xfe, 0xe4, 0xfe, 0xc0, 0xc9, 0x13,
+	0x01, 0x7d, 0x01, 0x61, 0xaf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x20,
+	0x04, 0x63, 0x00, 0x13, 0x00, 0x2e, 0x40, 0x2b, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x00, 0x02, 0x03,
+	0x02, 0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x23, 0x23, 0x23, 0x22,
+	0x04, 0x09, 0x1a, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15,
+	0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x04, 0x20, 0xd4, 0xa3, 0xfe, 0xde, 0xfe, 0xc3,
+	0x02, 0x75, 0xae, 0xaa, 0xd1, 0x72, 0xfe, 0xb1, 0xc1, 0xaa, 0x78, 0xe5, 0xcd, 0x31, 0x01, 0x2d,
+	0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x00, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xe7, 0x04, 0x4f, 0x06, 0x2b, 0x00, 0x0e, 0x00, 0x17, 0x00, 0xa2, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x0f, 0x0a, 0x01, 0x04, 0x01, 0x17, 0x0f, 0x02, 0x05, 0x04, 0x00, 0x01, 0x00, 0x05,
+	0x03, 0x4c, 0x1b, 0x40, 0x0f, 0x0a, 0x01, 0x04, 0x01, 0x17, 0x0f, 0x02, 0x05, 0x04, 0x00, 0x01,
+	0x03, 0x05, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x02, 0x02, 0x3a,
+	0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61,
+	0x03, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00,
+	0x02, 0x02, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03,
+	0x03, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x1f, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x00, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x40, 0x09, 0x22, 0x22, 0x11, 0x12, 0x24, 0x21, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x06,
+	0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x11, 0x21, 0x11, 0x21, 0x11, 0x26, 0x23,
+	0x22, 0x11, 0x10, 0x33, 0x32, 0x37, 0x03, 0x27, 0x9c, 0xbc, 0xac, 0xd3, 0x01, 0x11, 0xf3, 0x51,
+	0x82, 0x01, 0x28, 0xfe, 0xd8, 0x6f, 0x37, 0xf6, 0xb3, 0x78, 0x71, 0xb6, 0xcf, 0x01, 0x2b, 0xf5,
+	0x01, 0x1c, 0x01, 0x40, 0x19, 0x01, 0xe1, 0xf9, 0xd5, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f,
+	0xaf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07, 0x04, 0x63, 0x00, 0x10,
+	0x00, 0x15, 0x00, 0x33, 0x40, 0x30, 0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x02, 0x4c,
+	0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x21, 0x11, 0x21,
+	0x12, 0x24, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00,
+	0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x04, 0x07, 0xb7,
+	0xb8, 0xfe, 0xed, 0xfe, 0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d,
+	0xfe, 0x27, 0x01, 0x65, 0x9f, 0xa8, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31,
+	0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x00, 0x00, 0x00, 0x01, 0x00, 0x34,
+	0x00, 0x00, 0x02, 0xe0, 0x06, 0x44, 0x00, 0x13, 0x00, 0x85, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02,
+	0x0a, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x03, 0x03,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x05, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x07, 0x01, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x1b, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x05, 0x01, 0x00, 0x00, 0x01, 0x5f,
+	0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x1b,
+	0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x05, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x08, 0x09, 0x1c, 0x2b, 0x33,
+	0x11, 0x23, 0x35, 0x33, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x15, 0x33,
+	0x15, 0x23, 0x11, 0xa6, 0x72, 0x72, 0x01, 0x86, 0x54, 0x60, 0x52, 0x41, 0x7f, 0xb9, 0xb9, 0x03,
+	0x91, 0xb9, 0x4f, 0x01, 0xab, 0x1a, 0xc0, 0x21, 0xe7, 0x5a, 0xb9, 0xfc, 0x6f, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x50, 0xfe, 0x5c, 0x04, 0x4f, 0x04, 0x63, 0x00, 0x08, 0x00, 0x22, 0x00, 0x9e,
+	0x40, 0x13, 0x08, 0x00, 0x02, 0x01, 0x00, 0x09, 0x01, 0x02, 0x01, 0x1d, 0x01, 0x06, 0x02, 0x1c,
+	0x01, 0x05, 0x06, 0x04, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x04, 0x04, 0x3b, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59,
+	0x59, 0x40, 0x0a, 0x23, 0x25, 0x11, 0x24, 0x23, 0x22, 0x21, 0x07, 0x09, 0x1d, 0x2b, 0x01, 0x26,
+	0x23, 0x22, 0x11, 0x10, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33,
+	0x32, 0x17, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x36,
+	0x35, 0x03, 0x27, 0x6f, 0x37, 0xf6, 0xb3, 0x78, 0x71, 0x9c, 0xbc, 0xaa, 0xd5, 0x01, 0x14, 0xf0,
+	0x51, 0x82, 0x01, 0x28, 0x3c, 0x59, 0x94, 0xfe, 0xf4, 0xc1, 0xdd, 0xd9, 0x9d, 0xa3, 0x92, 0x03,
+	0x9a, 0x13, 0xfe, 0x8e, 0xfe, 0xac, 0xb0, 0xc8, 0xcf, 0x01, 0x28, 0xec, 0x01, 0x12, 0x01, 0x3d,
+	0x19, 0xfc, 0xba, 0xfb, 0xde, 0x4e, 0x81, 0x4f, 0xda, 0x57, 0x8c, 0x9d, 0x00, 0x01, 0x00, 0x94,
+	0x00, 0x00, 0x04, 0x5c, 0x06, 0x2b, 0x00, 0x10, 0x00, 0x55, 0x40, 0x0a, 0x03, 0x01, 0x03, 0x01,
+	0x0f, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x00, 0x00,
+	0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33,
+	0x11, 0x21, 0x11, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x94, 0x01, 0x28, 0xa9, 0xcc, 0x01, 0x2b, 0xfe, 0xd8, 0x33, 0x44, 0x78, 0x89, 0x06, 0x2b, 0xfd,
+	0x69, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x8a, 0x00, 0x00, 0x01, 0xc6, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x4e,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04,
+	0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x11,
+	0x21, 0x11, 0x01, 0x11, 0x21, 0x11, 0x94, 0x01, 0x28, 0xfe, 0xce, 0x01, 0x3c, 0x04, 0x4a, 0xfb,
+	0xb6, 0x05, 0x12, 0x01, 0x19, 0xfe, 0xe7, 0x00, 0x00, 0x02, 0xff, 0x70, 0xfe, 0x5d, 0x01, 0xc6,
+	0x06, 0x2b, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x35, 0x40, 0x32, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01,
+	0x02, 0x00, 0x02, 0x4c, 0x05, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x00,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x0d,
+	0x0d, 0x0d, 0x10, 0x0d, 0x10, 0x12, 0x22, 0x13, 0x22, 0x06, 0x09, 0x1a, 0x2b, 0x03, 0x35, 0x16,
+	0x33, 0x32, 0x36, 0x35, 0x11, 0x21, 0x11, 0x10, 0x21, 0x22, 0x13, 0x11, 0x21, 0x11, 0x90, 0x69,
+	0x33, 0x4e, 0x3a, 0x01, 0x28, 0xfe, 0x7a, 0x57, 0xab, 0x01, 0x3c, 0xfe, 0x85, 0xc6, 0x35, 0x64,
+	0x86, 0x04, 0x4a, 0xfb, 0xc9, 0xfe, 0x4a, 0x06, 0xb5, 0x01, 0x19, 0xfe, 0xe7, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x04, 0x6a, 0x06, 0x2b, 0x00, 0x0c, 0x00, 0x47, 0xb7, 0x0a,
+	0x07, 0x03, 0x03, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00,
+	0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e,
+	0x1b, 0x40, 0x12, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x04, 0x03, 0x02,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x13,
+	0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x33, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01,
+	0x23, 0x11, 0x94, 0x01, 0x28, 0x13, 0x01, 0x59, 0xf5, 0xfe, 0xc0, 0x01, 0x8d, 0xfe, 0xc4, 0xfe,
+	0xa1, 0x13, 0x06, 0x2b, 0xfc, 0x1f, 0x02, 0x00, 0xfe, 0x23, 0xfd, 0x93, 0x02, 0x25, 0xfd, 0xdb,
+	0x00, 0x01, 0x00, 0x87, 0xff, 0xe7, 0x02, 0x4f, 0x06, 0x2b, 0x00, 0x0c, 0x00, 0x23, 0x40, 0x20,
+	0x00, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x23, 0x12, 0x22, 0x03, 0x09, 0x19,
+	0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x02, 0x4f,
+	0x43, 0x4c, 0xfe, 0xc7, 0x01, 0x28, 0x2a, 0x42, 0x1b, 0xb6, 0xb6, 0x19, 0x01, 0x68, 0x04, 0xdc,
+	0xfb, 0x4b, 0x7c, 0x4d, 0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x06, 0x95, 0x04, 0x63, 0x00, 0x1c,
+	0x00, 0x7f, 0x40, 0x0c, 0x07, 0x03, 0x02, 0x04, 0x00, 0x1b, 0x13, 0x02, 0x03, 0x04, 0x02, 0x4c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x16, 0x06, 0x01, 0x04, 0x04, 0x00, 0x61, 0x02, 0x01, 0x02,
+	0x00, 0x00, 0x3b, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61,
+	0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b,
+	0x40, 0x1a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01, 0x01,
+	0x01, 0x41, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x10,
+	0x00, 0x00, 0x00, 0x1c, 0x00, 0x1c, 0x23, 0x12, 0x23, 0x12, 0x22, 0x22, 0x11, 0x09, 0x09, 0x1d,
+	0x2b, 0x33, 0x11, 0x21, 0x15, 0x36, 0x33, 0x32, 0x17, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11,
+	0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x21, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x94, 0x01,
+	0x28, 0x86, 0xcb, 0xdb, 0x41, 0x7a, 0xd7, 0x01, 0x1b, 0xfe, 0xd8, 0x29, 0x3c, 0x7f, 0x60, 0xfe,
+	0xd8, 0x2a, 0x3b, 0x7f, 0x61, 0x04, 0x4a, 0xb6, 0xcf, 0xd2, 0xd2, 0xfe, 0xa5, 0xfc, 0xf8, 0x02,
+	0xbf, 0x6e, 0x4d, 0xae, 0xfd, 0x34, 0x02, 0xbf, 0x6e, 0x4d, 0xae, 0xfd, 0x34, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x04, 0x5c, 0x04, 0x63, 0x00, 0x10, 0x00, 0x71, 0x40, 0x0a,
+	0x03, 0x01, 0x03, 0x00, 0x0f, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x13, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33,
+	0x11, 0x21, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x94, 0x01, 0x28, 0xa9, 0xcc, 0x01, 0x2b, 0xfe, 0xd8, 0x33, 0x44, 0x78, 0x89, 0x04, 0x4a, 0xb6,
+	0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x00, 0x02, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x99, 0x04, 0x63, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33,
+	0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70,
+	0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe,
+	0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4,
+	0x00, 0x02, 0x00, 0x94, 0xfe, 0x75, 0x04, 0x94, 0x04, 0x63, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x62,
+	0x40, 0x0f, 0x04, 0x01, 0x05, 0x01, 0x17, 0x0f, 0x02, 0x04, 0x05, 0x0e, 0x01, 0x03, 0x04, 0x03,
+	0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x00, 0x00,
+	0x3d, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00,
+	0x00, 0x00, 0x3d, 0x00, 0x4e, 0x59, 0x40, 0x09, 0x22, 0x23, 0x24, 0x22, 0x11, 0x10, 0x06, 0x09,
+	0x1c, 0x2b, 0x01, 0x21, 0x11, 0x21, 0x15, 0x36, 0x33, 0x32, 0x12, 0x15, 0x10, 0x00, 0x23, 0x22,
+	0x27, 0x35, 0x16, 0x33, 0x32, 0x11, 0x10, 0x23, 0x22, 0x07, 0x01, 0xbc, 0xfe, 0xd8, 0x01, 0x28,
+	0x9d, 0xbc, 0xac, 0xd3, 0xfe, 0xef, 0xf3, 0x51, 0x83, 0x70, 0x37, 0xf6, 0xb3, 0x78, 0x72, 0xfe,
+	0x75, 0x05, 0xd5, 0xb6, 0xcf, 0xfe, 0xd5, 0xf5, 0xfe, 0xe4, 0xfe, 0xc0, 0x19, 0xb0, 0x13, 0x01,
+	0x7d, 0x01, 0x61, 0xaf, 0x00, 0x02, 0x00, 0x50, 0xfe, 0x75, 0x04, 0x4f, 0x04, 0x63, 0x00, 0x0d,
+	0x00, 0x16, 0x00, 0x5e, 0x40, 0x0b, 0x16, 0x0e, 0x02, 0x05, 0x04, 0x00, 0x01, 0x00, 0x05, 0x02,
+	0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01, 0x01,
+	0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03,
+	0x3d, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00,
+	0x03, 0x03, 0x3d, 0x03, 0x4e, 0x59, 0x40, 0x09, 0x22, 0x22, 0x11, 0x11, 0x24, 0x21, 0x06, 0x09,
+	0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x21, 0x11, 0x21,
+	0x11, 0x26, 0x23, 0x22, 0x11, 0x10, 0x33, 0x32, 0x37, 0x03, 0x27, 0x9c, 0xbc, 0xac, 0xd3, 0x01,
+	0x11, 0xf3, 0x51, 0x82, 0x01, 0x28, 0xfe, 0xd8, 0x6f, 0x37, 0xf6, 0xb3, 0x78, 0x71, 0xb6, 0xcf,
+	0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0xfa, 0x2b, 0x05, 0x25, 0x13, 0xfe, 0x83, 0xfe,
+	0x9f, 0xaf, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x02, 0xfd, 0x04, 0x63, 0x00, 0x0d,
+	0x00, 0x8a, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0f, 0x03, 0x01, 0x02, 0x00, 0x0c, 0x08, 0x02,
+	0x03, 0x02, 0x02, 0x4c, 0x07, 0x01, 0x00, 0x4a, 0x1b, 0x40, 0x0f, 0x07, 0x01, 0x00, 0x01, 0x03,
+	0x01, 0x02, 0x00, 0x0c, 0x08, 0x02, 0x03, 0x02, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x39,
+	0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x15,
+	0x36, 0x33, 0x32, 0x17, 0x11, 0x26, 0x23, 0x22, 0x07, 0x11, 0xad, 0x01, 0x28, 0x53, 0xa3, 0x17,
+	0x1b, 0x38, 0x26, 0x77, 0x53, 0x04, 0x4a, 0xb6, 0xcf, 0x06, 0xfe, 0xf8, 0x17, 0x9a, 0xfd, 0x2e,
+	0x00, 0x01, 0x00, 0x7b, 0xff, 0xe7, 0x04, 0x0c, 0x04, 0x63, 0x00, 0x1e, 0x00, 0x2e, 0x40, 0x2b,
+	0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x29, 0x23, 0x28, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x37, 0x35, 0x16, 0x33,
+	0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22,
+	0x15, 0x14, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x7b, 0xe6, 0x9d, 0xdd, 0xaf,
+	0x64, 0xcd, 0x7b, 0x01, 0xcf, 0x9e, 0xc8, 0xdc, 0x66, 0xcf, 0xa1, 0x56, 0xdc, 0x95, 0xfe, 0xed,
+	0xe8, 0xcc, 0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb,
+	0x39, 0x70, 0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9, 0x00, 0x00, 0x00, 0x01, 0x00, 0x2a,
+	0xff, 0xe7, 0x02, 0x9c, 0x05, 0x43, 0x00, 0x14, 0x00, 0x32, 0x40, 0x2f, 0x00, 0x01, 0x05, 0x01,
+	0x01, 0x01, 0x00, 0x05, 0x02, 0x4c, 0x0b, 0x0a, 0x02, 0x02, 0x4a, 0x04, 0x01, 0x01, 0x01, 0x02,
+	0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x23, 0x11, 0x13, 0x11, 0x12, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x15, 0x06, 0x23,
+	0x20, 0x11, 0x11, 0x23, 0x35, 0x33, 0x35, 0x25, 0x15, 0x33, 0x15, 0x23, 0x11, 0x14, 0x16, 0x33,
+	0x32, 0x02, 0x99, 0x72, 0x4c, 0xfe, 0xc7, 0x78, 0x78, 0x01, 0x28, 0xd2, 0xd2, 0x2a, 0x42, 0x28,
+	0xba, 0xb9, 0x1a, 0x01, 0x68, 0x02, 0x42, 0xb9, 0xd7, 0x22, 0xf9, 0xb9, 0xfd, 0xe5, 0x7c, 0x4d,
+	0x00, 0x01, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x04, 0x4a, 0x00, 0x10, 0x00, 0x84, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b,
+	0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x13, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x05,
+	0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x17, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x06, 0x09,
+	0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37,
+	0x11, 0x21, 0x11, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32, 0x45, 0x77, 0x8a, 0x01,
+	0x28, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6,
+	0x00, 0x01, 0x00, 0x19, 0x00, 0x00, 0x04, 0x59, 0x04, 0x4a, 0x00, 0x06, 0x00, 0x3a, 0xb5, 0x03,
+	0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x06,
+	0x00, 0x06, 0x12, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x21, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01, 0x01,
+	0xa3, 0xfe, 0x76, 0x01, 0x38, 0x01, 0x15, 0x01, 0x17, 0xdc, 0xfe, 0x72, 0x04, 0x4a, 0xfc, 0xfb,
+	0x03, 0x05, 0xfb, 0xb6, 0x00, 0x01, 0x00, 0x3e, 0x00, 0x00, 0x05, 0xfc, 0x04, 0x4a, 0x00, 0x0c,
+	0x00, 0x42, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11,
+	0x06, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x21, 0x13, 0x13, 0x21, 0x13, 0x13, 0x33, 0x01, 0x21, 0x03,
+	0x03, 0x01, 0x48, 0xfe, 0xf6, 0x01, 0x0b, 0xb9, 0xc1, 0x01, 0x00, 0xaa, 0xc8, 0xc7, 0xfe, 0xe2,
+	0xfe, 0xe5, 0xa4, 0xbb, 0x04, 0x4a, 0xfc, 0xff, 0x03, 0x01, 0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6,
+	0x02, 0xf1, 0xfd, 0x0f, 0x00, 0x01, 0x00, 0x30, 0x00, 0x00, 0x04, 0x42, 0x04, 0x4a, 0x00, 0x0b,
+	0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05,
+	0x09, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x21, 0x13, 0x13, 0x33, 0x01, 0x01, 0x21, 0x03, 0x03, 0x30,
+	0x01, 0x66, 0xfe, 0xaa, 0x01, 0x51, 0xd9, 0xcf, 0xf0, 0xfe, 0xbb, 0x01, 0x5e, 0xfe, 0xaf, 0xe3,
+	0xe9, 0x02, 0x27, 0x02, 0x23, 0xfe, 0xa4, 0x01, 0x5c, 0xfd, 0xe4, 0xfd, 0xd2, 0x01, 0x6b, 0xfe,
+	0x95, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x19, 0xfe, 0x75, 0x04, 0x59, 0x04, 0x4a, 0x00, 0x07,
+	0x00, 0x1b, 0x40, 0x18, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x11, 0x12, 0x11, 0x03, 0x09, 0x19, 0x2b, 0x21, 0x01, 0x21,
+	0x13, 0x01, 0x33, 0x01, 0x21, 0x01, 0xa3, 0xfe, 0x76, 0x01, 0x38, 0xfe, 0x01, 0x2e, 0xdc, 0xfd,
+	0x80, 0xfe, 0xd2, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x6f,
+	0x00, 0x00, 0x03, 0x9d, 0x04, 0x4a, 0x00, 0x09, 0x00, 0x4f, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01,
+	0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09,
+	0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21, 0x15,
+	0x01, 0x21, 0x15, 0x6f, 0x01, 0xd7, 0xfe, 0x45, 0x03, 0x06, 0xfe, 0x29, 0x01, 0xe3, 0xc5, 0x02,
+	0xcc, 0xb9, 0xb9, 0xfd, 0x34, 0xc5, 0x00, 0x00, 0x00, 0x01, 0x00, 0x63, 0xfe, 0xd8, 0x02, 0xa1,
+	0x06, 0x2b, 0x00, 0x28, 0x00, 0x2f, 0x40, 0x2c, 0x14, 0x01, 0x05, 0x00, 0x01, 0x4c, 0x00, 0x00,
+	0x00, 0x05, 0x03, 0x00, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x3a, 0x02, 0x4e, 0x28, 0x26, 0x1f, 0x1e, 0x1d, 0x1c, 0x11, 0x17,
+	0x20, 0x06, 0x09, 0x19, 0x2b, 0x13, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x10, 0x21,
+	0x15, 0x22, 0x15, 0x14, 0x17, 0x17, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x07, 0x07, 0x06,
+	0x15, 0x14, 0x33, 0x15, 0x20, 0x11, 0x34, 0x37, 0x37, 0x36, 0x35, 0x34, 0x23, 0x23, 0x63, 0x3e,
+	0x8a, 0x13, 0x17, 0x16, 0x01, 0xb6, 0xbe, 0x0a, 0x0f, 0x0b, 0xc5, 0xc5, 0x0b, 0x0f, 0x0a, 0xbe,
+	0xfe, 0x4a, 0x16, 0x17, 0x13, 0x8a, 0x3e, 0x02, 0xe4, 0x83, 0x45, 0x49, 0x5c, 0x58, 0x53, 0x01,
+	0x2f, 0xad, 0x80, 0x1d, 0x3d, 0x56, 0x44, 0x49, 0xcc, 0x73, 0x74, 0xcc, 0x49, 0x45, 0x55, 0x3d,
+	0x1d, 0x80, 0xad, 0x01, 0x2f, 0x53, 0x58, 0x5c, 0x49, 0x46, 0x82, 0x00, 0x00, 0x01, 0x00, 0xb1,
+	0xfe, 0xd8, 0x01, 0x8d, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x09, 0x17, 0x2b, 0x13, 0x11, 0x33, 0x11, 0xb1, 0xdc, 0xfe, 0xd8, 0x07, 0x53, 0xf8, 0xad, 0x00,
+	0x00, 0x01, 0x00, 0x7b, 0xfe, 0xd8, 0x02, 0xb9, 0x06, 0x2b, 0x00, 0x28, 0x00, 0x2f, 0x40, 0x2c,
+	0x14, 0x01, 0x00, 0x05, 0x01, 0x4c, 0x00, 0x05, 0x00, 0x00, 0x02, 0x05, 0x00, 0x69, 0x00, 0x02,
+	0x00, 0x01, 0x02, 0x01, 0x65, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3a, 0x03, 0x4e,
+	0x28, 0x26, 0x1f, 0x1e, 0x1d, 0x1c, 0x11, 0x17, 0x20, 0x06, 0x09, 0x19, 0x2b, 0x01, 0x23, 0x22,
+	0x15, 0x14, 0x17, 0x17, 0x16, 0x15, 0x10, 0x21, 0x35, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35,
+	0x34, 0x37, 0x26, 0x35, 0x34, 0x37, 0x37, 0x36, 0x35, 0x34, 0x23, 0x35, 0x20, 0x11, 0x14, 0x07,
+	0x07, 0x06, 0x15, 0x14, 0x33, 0x33, 0x02, 0xb9, 0x3e, 0x8a, 0x13, 0x17, 0x16, 0xfe, 0x4a, 0xbe,
+	0x0a, 0x0e, 0x0c, 0xc5, 0xc5, 0x0c, 0x0e, 0x0a, 0xbe, 0x01, 0xb6, 0x16, 0x17, 0x13, 0x8a, 0x3e,
+	0x02, 0x1f, 0x83, 0x45, 0x49, 0x5c, 0x58, 0x53, 0xfe, 0xd1, 0xad, 0x80, 0x1d, 0x3d, 0x56, 0x44,
+	0x49, 0xcc, 0x74, 0x73, 0xcc, 0x49, 0x45, 0x55, 0x3e, 0x1c, 0x80, 0xad, 0xfe, 0xd1, 0x53, 0x58,
+	0x5c, 0x49, 0x46, 0x82, 0x00, 0x01, 0x00, 0x50, 0x01, 0x8a, 0x04, 0x5c, 0x03, 0x17, 0x00, 0x15,
+	0x00, 0x36, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2b, 0x0c, 0x0a, 0x02, 0x03, 0x00, 0x15, 0x01, 0x02,
+	0x02, 0x01, 0x02, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x01, 0x00, 0x03, 0x69, 0x00, 0x01, 0x02, 0x02,
+	0x01, 0x59, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x01, 0x02, 0x51, 0x23, 0x24, 0x23, 0x22,
+	0x04, 0x09, 0x1a, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x23, 0x10, 0x21, 0x32, 0x17, 0x17, 0x16,
+	0x33, 0x32, 0x35, 0x27, 0x33, 0x10, 0x21, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x15, 0xc1, 0x71,
+	0x01, 0x25, 0x76, 0x6b, 0x51, 0x5b, 0x5a, 0x90, 0x01, 0x71, 0xfe, 0xdb, 0x76, 0x6b, 0x51, 0x5b,
+	0x5a, 0x8f, 0x01, 0xbc, 0x01, 0x5b, 0x4e, 0x3b, 0x43, 0x90, 0x09, 0xfe, 0xa6, 0x4d, 0x3b, 0x43,
+	0x90, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb7, 0xfe, 0x82, 0x01, 0xdf, 0x04, 0x4a, 0x00, 0x03,
+	0x00, 0x09, 0x00, 0x2c, 0x40, 0x29, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x04, 0x04, 0x00,
+	0x00, 0x04, 0x09, 0x04, 0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b,
+	0x01, 0x11, 0x21, 0x11, 0x13, 0x13, 0x11, 0x21, 0x11, 0x13, 0x01, 0xdf, 0xfe, 0xd8, 0xf7, 0x31,
+	0xfe, 0xd8, 0x31, 0x04, 0x4a, 0xff, 0x00, 0x01, 0x00, 0xfe, 0x5d, 0xfd, 0x03, 0xfe, 0xd8, 0x01,
+	0x28, 0x02, 0xfd, 0x00, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x03, 0xff, 0x05, 0xc8, 0x00, 0x16,
+	0x00, 0x1b, 0x00, 0x7d, 0x40, 0x19, 0x07, 0x01, 0x02, 0x01, 0x18, 0x0c, 0x02, 0x03, 0x02, 0x17,
+	0x12, 0x0d, 0x03, 0x04, 0x03, 0x13, 0x01, 0x00, 0x04, 0x15, 0x01, 0x05, 0x00, 0x05, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x00,
+	0x05, 0x03, 0x00, 0x05, 0x7e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x69, 0x00, 0x01, 0x01,
+	0x38, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x04, 0x03, 0x00,
+	0x03, 0x04, 0x00, 0x80, 0x00, 0x00, 0x05, 0x03, 0x00, 0x05, 0x7e, 0x00, 0x02, 0x00, 0x03, 0x04,
+	0x02, 0x03, 0x69, 0x00, 0x01, 0x01, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59,
+	0x40, 0x0e, 0x00, 0x00, 0x00, 0x16, 0x00, 0x16, 0x11, 0x13, 0x11, 0x16, 0x11, 0x07, 0x09, 0x1b,
+	0x2b, 0x21, 0x35, 0x26, 0x00, 0x11, 0x10, 0x12, 0x37, 0x35, 0x33, 0x15, 0x16, 0x17, 0x15, 0x26,
+	0x27, 0x11, 0x36, 0x37, 0x15, 0x06, 0x07, 0x15, 0x03, 0x11, 0x06, 0x11, 0x10, 0x02, 0x83, 0xe8,
+	0xfe, 0xf9, 0xfe, 0xf1, 0x94, 0x77, 0x71, 0x7e, 0x6a, 0x75, 0x73, 0x72, 0x76, 0x94, 0xd3, 0xb0,
+	0x0e, 0x01, 0x32, 0x01, 0x01, 0x01, 0x02, 0x01, 0x26, 0x16, 0x99, 0x9b, 0x08, 0x20, 0xd8, 0x3a,
+	0x07, 0xfd, 0x08, 0x08, 0x2f, 0xc9, 0x27, 0x09, 0xb4, 0x01, 0x87, 0x02, 0xe4, 0x42, 0xfe, 0xd6,
+	0xfe, 0xd6, 0x00, 0x00, 0x00, 0x01, 0x00, 0x66, 0x00, 0x00, 0x03, 0xf7, 0x05, 0xed, 0x00, 0x1a,
+	0x00, 0x6d, 0x40, 0x0f, 0x0c, 0x01, 0x03, 0x02, 0x0d, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x01, 0x01,
+	0x06, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x04, 0x01, 0x01, 0x05, 0x01, 0x00,
+	0x06, 0x01, 0x00, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x06,
+	0x06, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00,
+	0x03, 0x01, 0x02, 0x03, 0x69, 0x04, 0x01, 0x01, 0x05, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x00,
+	0x06, 0x06, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00,
+	0x00, 0x1a, 0x00, 0x1a, 0x13, 0x11, 0x12, 0x23, 0x22, 0x11, 0x14, 0x09, 0x09, 0x1d, 0x2b, 0x33,
+	0x35, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22,
+	0x15, 0x15, 0x33, 0x15, 0x23, 0x14, 0x06, 0x07, 0x21, 0x15, 0x66, 0xc5, 0xa3, 0xa3, 0x01, 0xc1,
+	0x79, 0x92, 0x77, 0x70, 0xbd, 0xc3, 0xc3, 0x52, 0x86, 0x02, 0x7c, 0xea, 0x2e, 0xec, 0xb5, 0xb9,
+	0xaa, 0x01, 0xd1, 0x17, 0xcb, 0x29, 0xd6, 0xec, 0xb9, 0xc5, 0xb0, 0x5a, 0xea, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x02, 0x00, 0xad, 0x04, 0x70, 0x05, 0x1b, 0x00, 0x1d, 0x00, 0x29, 0x00, 0x46,
+	0x40, 0x43, 0x1a, 0x17, 0x03, 0x03, 0x02, 0x01, 0x13, 0x0f, 0x0a, 0x07, 0x04, 0x00, 0x03, 0x02,
+	0x4c, 0x19, 0x18, 0x02, 0x01, 0x04, 0x01, 0x4a, 0x12, 0x11, 0x09, 0x08, 0x04, 0x00, 0x49, 0x00,
+	0x01, 0x04, 0x01, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x00, 0x00, 0x03, 0x59, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x03, 0x00, 0x51, 0x1f, 0x1e, 0x25, 0x23, 0x1e, 0x29, 0x1f, 0x29,
+	0x2d, 0x2c, 0x05, 0x09, 0x18, 0x2b, 0x01, 0x37, 0x17, 0x07, 0x16, 0x15, 0x14, 0x07, 0x17, 0x07,
+	0x27, 0x31, 0x06, 0x23, 0x22, 0x27, 0x31, 0x07, 0x27, 0x37, 0x26, 0x35, 0x34, 0x37, 0x27, 0x37,
+	0x17, 0x36, 0x33, 0x32, 0x07, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26,
+	0x03, 0x2f, 0xc7, 0x7a, 0xc7, 0x4c, 0x4c, 0xc6, 0x7a, 0xc6, 0x78, 0x7e, 0x7f, 0x78, 0xc6, 0x7a,
+	0xc6, 0x4b, 0x4b, 0xc6, 0x7a, 0xc6, 0x78, 0x7f, 0x7e, 0x7c, 0x5e, 0x83, 0x83, 0x5c, 0x5b, 0x83,
+	0x82, 0x04, 0x55, 0xc6, 0x7a, 0xc6, 0x77, 0x80, 0x80, 0x76, 0xc7, 0x7a, 0xc6, 0x4b, 0x4b, 0xc6,
+	0x7a, 0xc7, 0x76, 0x80, 0x81, 0x76, 0xc6, 0x7a, 0xc6, 0x4b, 0xde, 0x82, 0x5d, 0x5b, 0x82, 0x82,
+	0x5c, 0x5b, 0x83, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x04, 0x73, 0x05, 0xc8, 0x00, 0x16,
+	0x00, 0x6b, 0xb5, 0x0b, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21,
+	0x06, 0x01, 0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x08, 0x01, 0x01, 0x09, 0x01, 0x00,
+	0x0a, 0x01, 0x00, 0x67, 0x05, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x39, 0x0a,
+	0x4e, 0x1b, 0x40, 0x21, 0x05, 0x01, 0x04, 0x03, 0x04, 0x85, 0x06, 0x01, 0x03, 0x07, 0x01, 0x02,
+	0x01, 0x03, 0x02, 0x68, 0x08, 0x01, 0x01, 0x09, 0x01, 0x00, 0x0a, 0x01, 0x00, 0x67, 0x0b, 0x01,
+	0x0a, 0x0a, 0x3c, 0x0a, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x00, 0x16, 0x00, 0x16, 0x15, 0x14,
+	0x11, 0x11, 0x11, 0x12, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x1f, 0x2b, 0x21, 0x11, 0x23,
+	0x35, 0x33, 0x35, 0x23, 0x35, 0x33, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01, 0x33, 0x15, 0x23, 0x15,
+	0x33, 0x15, 0x23, 0x11, 0x01, 0xa6, 0xf7, 0xf7, 0xf7, 0xf7, 0xfe, 0x5a, 0x01, 0x57, 0x01, 0x1e,
+	0x01, 0x1e, 0xe0, 0xfe, 0x5b, 0xf7, 0xf7, 0xf7, 0xf7, 0x01, 0x2e, 0x94, 0x94, 0x94, 0x02, 0xde,
+	0xfe, 0x0d, 0x01, 0xf3, 0xfd, 0x22, 0x94, 0x94, 0x94, 0xfe, 0xd2, 0x00, 0x00, 0x02, 0x00, 0xb1,
+	0xfe, 0xd8, 0x01, 0x8d, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x29, 0x40, 0x26, 0x00, 0x00,
+	0x04, 0x01, 0x01, 0x00, 0x01, 0x63, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a,
+	0x03, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x06, 0x09, 0x17, 0x2b, 0x13, 0x11, 0x33, 0x11, 0x03, 0x11, 0x33, 0x11, 0xb1, 0xdc, 0xdc,
+	0xdc, 0xfe, 0xd8, 0x02, 0xe4, 0xfd, 0x1c, 0x04, 0x6f, 0x02, 0xe4, 0xfd, 0x1c, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x8d, 0xfe, 0xb2, 0x03, 0xe6, 0x05, 0xee, 0x00, 0x26, 0x00, 0x31, 0x00, 0x52,
+	0x40, 0x12, 0x14, 0x01, 0x02, 0x01, 0x2d, 0x21, 0x15, 0x0d, 0x01, 0x05, 0x00, 0x02, 0x00, 0x01,
+	0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x03, 0x00,
+	0x03, 0x65, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x02, 0x4e, 0x1b, 0x40, 0x18,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6, 0x2c, 0x23, 0x2d, 0x22, 0x04, 0x09,
+	0x1a, 0x2b, 0x13, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x26, 0x35, 0x34, 0x37,
+	0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x16,
+	0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x10, 0x21, 0x22, 0x01, 0x36, 0x35, 0x34, 0x26, 0x27, 0x27,
+	0x06, 0x15, 0x14, 0x17, 0x96, 0xe3, 0x95, 0xe1, 0xa4, 0x8a, 0xa4, 0x8a, 0x87, 0x8d, 0xf4, 0xc8,
+	0xa7, 0xb8, 0xb2, 0x94, 0xde, 0x8f, 0x79, 0xb9, 0x92, 0x82, 0x91, 0xfe, 0x0d, 0x93, 0x01, 0x79,
+	0x34, 0x4a, 0x6b, 0xc2, 0x34, 0xbc, 0xfe, 0xea, 0xdb, 0x59, 0x8c, 0x55, 0x4f, 0x42, 0x4f, 0xa9,
+	0x7a, 0x9b, 0x95, 0x65, 0x9c, 0xa4, 0xc9, 0x29, 0xcd, 0x3c, 0x88, 0x54, 0x41, 0x37, 0x54, 0xab,
+	0x84, 0x96, 0x9d, 0x68, 0xac, 0xfe, 0x9c, 0x02, 0xc5, 0x4f, 0x43, 0x41, 0x52, 0x35, 0x61, 0x49,
+	0x43, 0x76, 0x5c, 0x00, 0x00, 0x02, 0x00, 0x14, 0x05, 0x03, 0x02, 0x96, 0x05, 0xe1, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x32, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x27, 0x02, 0x01, 0x00, 0x01, 0x01, 0x00,
+	0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x03, 0x04, 0x03, 0x01, 0x00, 0x01, 0x4f, 0x04,
+	0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09,
+	0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x14, 0xde,
+	0xc5, 0xdf, 0x05, 0x03, 0xde, 0xde, 0xde, 0xde, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xd4,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2d, 0x00, 0x60, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x55,
+	0x22, 0x01, 0x06, 0x05, 0x2d, 0x23, 0x02, 0x07, 0x06, 0x18, 0x01, 0x04, 0x07, 0x03, 0x4c, 0x00,
+	0x01, 0x00, 0x03, 0x05, 0x01, 0x03, 0x69, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x69, 0x00,
+	0x07, 0x00, 0x04, 0x02, 0x07, 0x04, 0x69, 0x09, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x09, 0x01,
+	0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x2c, 0x2a,
+	0x26, 0x24, 0x21, 0x1f, 0x1b, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b,
+	0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x20, 0x00, 0x11, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15,
+	0x14, 0x00, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x37, 0x02, 0xe7, 0xfe, 0xd5, 0xfe, 0x50, 0x01, 0xb2,
+	0x01, 0x32, 0x01, 0x32, 0x01, 0xb2, 0xfe, 0x4c, 0xfe, 0xc8, 0x01, 0x05, 0x01, 0x6c, 0xfe, 0x95,
+	0xfe, 0xff, 0xfe, 0x96, 0x01, 0x68, 0x02, 0x30, 0x8e, 0x7b, 0xc5, 0xf0, 0xe8, 0xc4, 0x80, 0x92,
+	0x8a, 0x75, 0x78, 0x97, 0xa5, 0x86, 0x85, 0x5e, 0x01, 0xb5, 0x01, 0x2f, 0x01, 0x33, 0x01, 0xb1,
+	0xfe, 0x4f, 0xfe, 0xcf, 0xfe, 0xc9, 0xfe, 0x51, 0x7b, 0x01, 0x68, 0x01, 0x02, 0xfe, 0x01, 0x6a,
+	0xfe, 0x96, 0xff, 0xfc, 0xfe, 0x93, 0xed, 0x2a, 0xeb, 0xbf, 0xbf, 0xe2, 0x23, 0x7f, 0x38, 0xae,
+	0x8b, 0x89, 0xa9, 0x32, 0x00, 0x02, 0x00, 0x31, 0x03, 0x37, 0x02, 0xca, 0x05, 0xed, 0x00, 0x1c,
+	0x00, 0x24, 0x00, 0x44, 0x40, 0x41, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x17,
+	0x02, 0x04, 0x06, 0x18, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x00, 0x03, 0x00, 0x02, 0x01, 0x03, 0x02,
+	0x69, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x07, 0x01, 0x04, 0x00, 0x00, 0x04, 0x59,
+	0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x04, 0x00, 0x51, 0x22, 0x23, 0x24, 0x13,
+	0x23, 0x22, 0x23, 0x21, 0x08, 0x0b, 0x1e, 0x2b, 0x01, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x21,
+	0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x15, 0x11, 0x14, 0x33, 0x32, 0x37,
+	0x15, 0x06, 0x23, 0x22, 0x27, 0x27, 0x35, 0x23, 0x22, 0x15, 0x14, 0x33, 0x32, 0x01, 0xae, 0x4c,
+	0x61, 0x5b, 0x75, 0x01, 0x58, 0x30, 0x76, 0x70, 0x67, 0x78, 0x80, 0x01, 0x26, 0x28, 0x0b, 0x0d,
+	0x3f, 0x30, 0x77, 0x29, 0x02, 0x2e, 0x89, 0x4b, 0x37, 0x03, 0x84, 0x4d, 0x70, 0x57, 0xe6, 0x2f,
+	0x5e, 0x3c, 0x8d, 0x2b, 0xcf, 0xfe, 0xde, 0x3b, 0x03, 0x7e, 0x0f, 0x4d, 0x77, 0x71, 0x5d, 0x4d,
+	0x00, 0x02, 0x00, 0x41, 0x00, 0x69, 0x04, 0x35, 0x03, 0xe1, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x08,
+	0xb5, 0x0b, 0x09, 0x05, 0x03, 0x02, 0x32, 0x2b, 0x09, 0x02, 0x07, 0x01, 0x01, 0x05, 0x01, 0x01,
+	0x07, 0x01, 0x01, 0x04, 0x35, 0xfe, 0xf9, 0x01, 0x07, 0x8b, 0xfe, 0x5f, 0x01, 0xa1, 0xfe, 0xc2,
+	0xfe, 0xfa, 0x01, 0x06, 0x8b, 0xfe, 0x60, 0x01, 0xa0, 0x03, 0x78, 0xfe, 0xad, 0xfe, 0xad, 0x69,
+	0x01, 0xbc, 0x01, 0xbc, 0x6c, 0xfe, 0xb0, 0xfe, 0xad, 0x69, 0x01, 0xbc, 0x01, 0xbc, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x68, 0x01, 0x28, 0x04, 0x43, 0x03, 0x78, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21,
+	0x00, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f,
+	0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x09,
+	0x18, 0x2b, 0x13, 0x35, 0x21, 0x11, 0x23, 0x11, 0x68, 0x03, 0xdb, 0xad, 0x02, 0xcc, 0xac, 0xfd,
+	0xb0, 0x01, 0xa4, 0x00, 0x00, 0x01, 0x00, 0x4a, 0x02, 0x1f, 0x02, 0x60, 0x02, 0xd8, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0x13, 0x35, 0x21, 0x15, 0x4a, 0x02, 0x16, 0x02, 0x1f, 0xb9, 0xb9, 0x00, 0x00, 0x04, 0x00, 0x0e,
+	0x00, 0x00, 0x05, 0xd6, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2a, 0x00, 0x69,
+	0xb1, 0x06, 0x64, 0x44, 0x40, 0x5e, 0x1e, 0x01, 0x06, 0x08, 0x01, 0x4c, 0x0c, 0x07, 0x02, 0x05,
+	0x06, 0x02, 0x06, 0x05, 0x02, 0x80, 0x00, 0x01, 0x00, 0x03, 0x04, 0x01, 0x03, 0x69, 0x00, 0x04,
+	0x00, 0x09, 0x08, 0x04, 0x09, 0x69, 0x00, 0x08, 0x00, 0x06, 0x05, 0x08, 0x06, 0x67, 0x0b, 0x01,
+	0x02, 0x00, 0x00, 0x02, 0x59, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x02, 0x00,
+	0x51, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x2a, 0x28, 0x26, 0x24, 0x18, 0x23, 0x18, 0x23, 0x22,
+	0x21, 0x20, 0x1f, 0x1b, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0d, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x20, 0x00, 0x11, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14,
+	0x00, 0x27, 0x11, 0x21, 0x32, 0x15, 0x14, 0x07, 0x13, 0x23, 0x03, 0x23, 0x11, 0x11, 0x33, 0x32,
+	0x35, 0x34, 0x23, 0x23, 0x02, 0xe9, 0xfe, 0xd5, 0xfe, 0x50, 0x01, 0xb2, 0x01, 0x32, 0x01, 0x32,
+	0x01, 0xb2, 0xfe, 0x4c, 0xfe, 0xc8, 0x01, 0x05, 0x01, 0x6c, 0xfe, 0x95, 0xfe, 0xff, 0xfe, 0x96,
+	0x01, 0x68, 0x14, 0x01, 0x5d, 0xf1, 0x98, 0xe2, 0xbb, 0xbd, 0x83, 0x58, 0xb6, 0xa3, 0x6b, 0x01,
+	0xb5, 0x01, 0x2f, 0x01, 0x33, 0x01, 0xb1, 0xfe, 0x4f, 0xfe, 0xcf, 0xfe, 0xc9, 0xfe, 0x51, 0x7b,
+	0x01, 0x68, 0x01, 0x02, 0xfe, 0x01, 0x6a, 0xfe, 0x96, 0xff, 0xfc, 0xfe, 0x93, 0xdb, 0x03, 0x22,
+	0xc7, 0x9f, 0x46, 0xfe, 0x8a, 0x01, 0x47, 0xfe, 0xb9, 0x01, 0xb6, 0x93, 0x6b, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x4e, 0x05, 0xa3, 0x04, 0x25, 0x06, 0x44, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x13, 0x35, 0x21, 0x15, 0x4e, 0x03, 0xd7, 0x05, 0xa3, 0xa1, 0xa1, 0x00,
+	0x00, 0x02, 0x00, 0x72, 0x03, 0xf4, 0x02, 0xc2, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x39,
+	0xb1, 0x06, 0x64, 0x44, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01,
+	0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00,
+	0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x06, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x01, 0x97, 0x78, 0xad, 0xae, 0x7a, 0x7a, 0xae, 0xae, 0x7c, 0x3f, 0x57, 0x57, 0x3d, 0x3d,
+	0x57, 0x57, 0x03, 0xf4, 0xaf, 0x79, 0x7a, 0xae, 0xae, 0x7a, 0x7c, 0xac, 0x94, 0x56, 0x3e, 0x3d,
+	0x57, 0x57, 0x3d, 0x3d, 0x57, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x68, 0x00, 0x00, 0x04, 0x43,
+	0x04, 0xa0, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x66, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x05,
+	0x01, 0x03, 0x06, 0x01, 0x02, 0x07, 0x03, 0x02, 0x67, 0x00, 0x04, 0x09, 0x01, 0x07, 0x00, 0x04,
+	0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x1f, 0x05, 0x01, 0x03, 0x06, 0x01, 0x02, 0x07, 0x03, 0x02, 0x67, 0x00, 0x04, 0x09, 0x01, 0x07,
+	0x00, 0x04, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a,
+	0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x09, 0x17, 0x2b, 0x33, 0x35,
+	0x21, 0x15, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x68, 0x03,
+	0xdb, 0xfd, 0xbc, 0xfe, 0x69, 0x01, 0x97, 0xad, 0x01, 0x97, 0xfe, 0x69, 0xad, 0xad, 0x01, 0x28,
+	0x01, 0x66, 0xad, 0x01, 0x65, 0xfe, 0x9b, 0xad, 0xfe, 0x9a, 0x00, 0x00, 0x00, 0x01, 0x00, 0x39,
+	0x02, 0xb5, 0x02, 0xf5, 0x06, 0x43, 0x00, 0x1a, 0x00, 0x34, 0x40, 0x31, 0x0d, 0x01, 0x00, 0x01,
+	0x0c, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x01, 0x01, 0x02, 0x01, 0x4b, 0x00, 0x00, 0x00, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x56, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x55, 0x03,
+	0x4e, 0x00, 0x00, 0x00, 0x1a, 0x00, 0x1a, 0x18, 0x23, 0x29, 0x05, 0x0b, 0x19, 0x2b, 0x13, 0x35,
+	0x36, 0x3f, 0x02, 0x36, 0x36, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15,
+	0x14, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x15, 0x39, 0x3d, 0x59, 0x4c, 0x59, 0x5d, 0x38, 0xa5,
+	0x6b, 0xa3, 0xa9, 0x8a, 0xaa, 0xc2, 0x5e, 0x79, 0x4a, 0x90, 0x0f, 0x01, 0xbc, 0x02, 0xb5, 0x8c,
+	0x56, 0x48, 0x3f, 0x48, 0x4d, 0x53, 0x40, 0x8a, 0x42, 0x82, 0x33, 0x86, 0x76, 0x4c, 0x7b, 0x53,
+	0x32, 0x62, 0x58, 0x8c, 0x00, 0x01, 0x00, 0x66, 0x02, 0x9f, 0x02, 0xfd, 0x06, 0x43, 0x00, 0x1f,
+	0x00, 0x3f, 0x40, 0x3c, 0x12, 0x01, 0x03, 0x04, 0x11, 0x01, 0x02, 0x03, 0x19, 0x01, 0x01, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00, 0x05, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02,
+	0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x56, 0x4d, 0x00, 0x00, 0x00, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x57, 0x05, 0x4e, 0x28, 0x23, 0x23, 0x11, 0x23, 0x22, 0x06, 0x0b, 0x1c,
+	0x2b, 0x13, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x32, 0x36, 0x35, 0x34,
+	0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x04, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x66, 0xa7, 0x4f, 0xba, 0x81, 0xa2, 0x26, 0xa8, 0x83, 0xa2, 0x77, 0x6a, 0x7b, 0x94, 0xa3,
+	0xb3, 0xf5, 0x01, 0x18, 0xdc, 0xb5, 0x7c, 0x02, 0xbb, 0x85, 0x33, 0x91, 0x64, 0x51, 0x6a, 0x43,
+	0x56, 0x7e, 0x32, 0x79, 0x28, 0x70, 0x65, 0x9c, 0x41, 0x34, 0xbc, 0x74, 0x8e, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x55, 0x05, 0x03, 0x02, 0x5f, 0x06, 0x44, 0x00, 0x03, 0x00, 0x1f, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x13, 0x21,
+	0x01, 0x55, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x94, 0xfe, 0x75, 0x04, 0x94, 0x04, 0x4a, 0x00, 0x14, 0x00, 0x82, 0x40, 0x0b,
+	0x07, 0x01, 0x01, 0x00, 0x13, 0x0f, 0x02, 0x03, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58,
+	0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x04, 0x01, 0x03,
+	0x03, 0x39, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x1c, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x01, 0x01,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x1b, 0x40,
+	0x1c, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x01, 0x01, 0x04,
+	0x61, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x3d, 0x05, 0x4e, 0x59, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x14, 0x00, 0x14, 0x23, 0x13, 0x12, 0x22, 0x11, 0x07, 0x09, 0x1b, 0x2b,
+	0x13, 0x11, 0x21, 0x11, 0x14, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x14, 0x17, 0x21, 0x26, 0x27,
+	0x06, 0x23, 0x22, 0x27, 0x11, 0x94, 0x01, 0x28, 0x89, 0x74, 0x75, 0x01, 0x28, 0x3e, 0xfe, 0xc0,
+	0x16, 0x10, 0x50, 0xac, 0x46, 0x30, 0xfe, 0x75, 0x05, 0xd5, 0xfd, 0x5a, 0xcc, 0xbf, 0x02, 0xb3,
+	0xfc, 0xfe, 0xc0, 0x88, 0x4c, 0x83, 0xe2, 0x1f, 0xfe, 0x69, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4e,
+	0xfe, 0xd8, 0x03, 0xae, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x4a, 0xb5, 0x01, 0x01, 0x01, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x04, 0x03, 0x02, 0x01, 0x02, 0x01, 0x86, 0x00,
+	0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x04, 0x03, 0x02,
+	0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x00,
+	0x02, 0x00, 0x02, 0x4f, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x11, 0x25,
+	0x05, 0x09, 0x19, 0x2b, 0x01, 0x11, 0x24, 0x11, 0x34, 0x36, 0x33, 0x21, 0x11, 0x23, 0x11, 0x23,
+	0x11, 0x01, 0xd9, 0xfe, 0x75, 0xc0, 0xdd, 0x01, 0xc3, 0xa1, 0x94, 0xfe, 0xd8, 0x04, 0x0c, 0x35,
+	0x01, 0x64, 0xb2, 0x99, 0xf9, 0x10, 0x06, 0x69, 0xf9, 0x97, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7b,
+	0x03, 0x0a, 0x01, 0xbc, 0x04, 0x4a, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x09, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x7b, 0x01, 0x41, 0x03, 0x0a, 0x01, 0x40, 0xfe, 0xc0,
+	0x00, 0x01, 0x00, 0x7b, 0xfe, 0x50, 0x02, 0x30, 0x00, 0x00, 0x00, 0x11, 0x00, 0x38, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x2d, 0x02, 0x01, 0x03, 0x00, 0x0a, 0x01, 0x02, 0x03, 0x09, 0x01, 0x01, 0x02,
+	0x03, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x00, 0x02, 0x01, 0x01, 0x02, 0x59,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x02, 0x01, 0x51, 0x22, 0x23, 0x25, 0x10, 0x04, 0x09,
+	0x1a, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x33, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27,
+	0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x23, 0x01, 0x0c, 0x88, 0x4c, 0xe8, 0x90, 0x69, 0x52,
+	0x6a, 0x47, 0x2f, 0x79, 0xc3, 0x14, 0x71, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b, 0x0f, 0x3c, 0x54,
+	0x00, 0x01, 0x00, 0x88, 0x02, 0xb5, 0x03, 0x22, 0x06, 0x43, 0x00, 0x09, 0x00, 0x22, 0x40, 0x1f,
+	0x06, 0x05, 0x04, 0x03, 0x04, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02,
+	0x02, 0x55, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x15, 0x11, 0x04, 0x0b, 0x18, 0x2b,
+	0x13, 0x35, 0x33, 0x11, 0x07, 0x35, 0x25, 0x11, 0x33, 0x15, 0x88, 0xde, 0xde, 0x01, 0xbc, 0xde,
+	0x02, 0xb5, 0x67, 0x02, 0x90, 0x2d, 0x6b, 0x59, 0xfc, 0xd9, 0x67, 0x00, 0x00, 0x02, 0x00, 0x28,
+	0x03, 0x37, 0x02, 0xd3, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c,
+	0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x0b, 0x16, 0x2b, 0x01, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x35, 0x34, 0x23, 0x22, 0x15,
+	0x14, 0x01, 0x79, 0x9b, 0xb6, 0xb6, 0x9f, 0x9f, 0xb7, 0xb7, 0xa0, 0x79, 0x78, 0x77, 0x03, 0x37,
+	0xbb, 0xa0, 0xa2, 0xb9, 0xb9, 0xa1, 0xa3, 0xb9, 0x80, 0xdd, 0xda, 0xdb, 0xdc, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x3e, 0x00, 0x69, 0x04, 0x32, 0x03, 0xe1, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x08,
+	0xb5, 0x0b, 0x09, 0x05, 0x03, 0x02, 0x32, 0x2b, 0x37, 0x01, 0x01, 0x37, 0x01, 0x01, 0x25, 0x01,
+	0x01, 0x37, 0x01, 0x01, 0x3e, 0x01, 0x06, 0xfe, 0xfa, 0x8b, 0x01, 0xa0, 0xfe, 0x60, 0x01, 0x3d,
+	0x01, 0x07, 0xfe, 0xf9, 0x8b, 0x01, 0xa1, 0xfe, 0x5f, 0xd2, 0x01, 0x53, 0x01, 0x53, 0x69, 0xfe,
+	0x44, 0xfe, 0x44, 0x6c, 0x01, 0x50, 0x01, 0x53, 0x69, 0xfe, 0x44, 0xfe, 0x44, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x25, 0xff, 0xdb, 0x06, 0x24, 0x05, 0xed, 0x00, 0x05, 0x00, 0x09, 0x00, 0x14,
+	0x00, 0x17, 0x00, 0xa8, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x15, 0x03, 0x02, 0x01, 0x03, 0x04, 0x01,
+	0x17, 0x01, 0x00, 0x04, 0x02, 0x4c, 0x0d, 0x01, 0x05, 0x01, 0x4b, 0x04, 0x01, 0x01, 0x4a, 0x4b,
+	0xb0, 0x0e, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x01, 0x04, 0x01, 0x85, 0x09, 0x01, 0x00, 0x04, 0x05,
+	0x04, 0x00, 0x05, 0x80, 0x00, 0x04, 0x00, 0x02, 0x04, 0x57, 0x08, 0x01, 0x05, 0x06, 0x01, 0x03,
+	0x02, 0x05, 0x03, 0x68, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x0b, 0x07, 0x0a, 0x03, 0x02, 0x04, 0x02,
+	0x4f, 0x1b, 0x40, 0x2f, 0x00, 0x01, 0x04, 0x01, 0x85, 0x09, 0x01, 0x00, 0x04, 0x05, 0x04, 0x00,
+	0x05, 0x80, 0x0a, 0x01, 0x02, 0x07, 0x02, 0x86, 0x00, 0x04, 0x00, 0x07, 0x04, 0x57, 0x08, 0x01,
+	0x05, 0x06, 0x01, 0x03, 0x07, 0x05, 0x03, 0x68, 0x00, 0x04, 0x04, 0x07, 0x5f, 0x0b, 0x01, 0x07,
+	0x04, 0x07, 0x4f, 0x59, 0x40, 0x21, 0x0a, 0x0a, 0x06, 0x06, 0x00, 0x00, 0x16, 0x15, 0x0a, 0x14,
+	0x0a, 0x14, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e, 0x0c, 0x0b, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07,
+	0x00, 0x05, 0x00, 0x05, 0x0c, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x11, 0x07, 0x35,
+	0x25, 0x11, 0x01, 0x01, 0x33, 0x01, 0x25, 0x35, 0x21, 0x35, 0x01, 0x33, 0x11, 0x33, 0x15, 0x23,
+	0x15, 0x01, 0x33, 0x11, 0x01, 0x03, 0xde, 0x01, 0xbc, 0xfe, 0xc6, 0x04, 0x53, 0x98, 0xfb, 0xac,
+	0x03, 0xc7, 0xfe, 0x5c, 0x01, 0x9d, 0xca, 0x5c, 0x5c, 0xfe, 0x40, 0xfe, 0x02, 0x67, 0x02, 0xc9,
+	0x37, 0x85, 0x6f, 0xfc, 0x7a, 0xfd, 0x74, 0x06, 0x12, 0xf9, 0xee, 0x25, 0xe2, 0xa6, 0x01, 0xf0,
+	0xfe, 0x10, 0xa6, 0xe2, 0x01, 0x88, 0x01, 0x30, 0x00, 0x03, 0x00, 0x25, 0xff, 0xdb, 0x06, 0x68,
+	0x05, 0xed, 0x00, 0x05, 0x00, 0x09, 0x00, 0x23, 0x00, 0xa4, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x19,
+	0x03, 0x02, 0x01, 0x03, 0x04, 0x01, 0x16, 0x01, 0x03, 0x04, 0x15, 0x01, 0x00, 0x03, 0x03, 0x4c,
+	0x0b, 0x01, 0x05, 0x01, 0x4b, 0x04, 0x01, 0x01, 0x4a, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x29,
+	0x00, 0x01, 0x04, 0x01, 0x85, 0x07, 0x01, 0x00, 0x03, 0x05, 0x03, 0x00, 0x05, 0x80, 0x00, 0x04,
+	0x00, 0x03, 0x00, 0x04, 0x03, 0x6a, 0x00, 0x05, 0x02, 0x02, 0x05, 0x57, 0x00, 0x05, 0x05, 0x02,
+	0x5f, 0x09, 0x06, 0x08, 0x03, 0x02, 0x05, 0x02, 0x4f, 0x1b, 0x40, 0x2d, 0x00, 0x01, 0x04, 0x01,
+	0x85, 0x07, 0x01, 0x00, 0x03, 0x05, 0x03, 0x00, 0x05, 0x80, 0x08, 0x01, 0x02, 0x06, 0x02, 0x86,
+	0x00, 0x04, 0x00, 0x03, 0x00, 0x04, 0x03, 0x6a, 0x00, 0x05, 0x06, 0x06, 0x05, 0x57, 0x00, 0x05,
+	0x05, 0x06, 0x5f, 0x09, 0x01, 0x06, 0x05, 0x06, 0x4f, 0x59, 0x40, 0x1d, 0x0a, 0x0a, 0x06, 0x06,
+	0x00, 0x00, 0x0a, 0x23, 0x0a, 0x23, 0x22, 0x21, 0x19, 0x17, 0x14, 0x12, 0x06, 0x09, 0x06, 0x09,
+	0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x0a, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x11,
+	0x07, 0x35, 0x25, 0x11, 0x01, 0x01, 0x33, 0x01, 0x25, 0x35, 0x36, 0x37, 0x37, 0x36, 0x36, 0x35,
+	0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x07, 0x07, 0x06, 0x07,
+	0x21, 0x15, 0x01, 0x03, 0xde, 0x01, 0xbc, 0xfe, 0xa1, 0x04, 0x53, 0x98, 0xfb, 0xac, 0x02, 0xda,
+	0x39, 0x2b, 0x4c, 0x88, 0x50, 0x99, 0x64, 0x85, 0x95, 0x8f, 0x94, 0xb6, 0x4d, 0x74, 0x43, 0x76,
+	0x12, 0x01, 0x8d, 0x02, 0x67, 0x02, 0xc9, 0x37, 0x85, 0x6f, 0xfc, 0x7a, 0xfd, 0x74, 0x06, 0x12,
+	0xf9, 0xee, 0x25, 0xa9, 0x40, 0x28, 0x45, 0x7c, 0x73, 0x49, 0x7a, 0x3e, 0x96, 0x2e, 0x87, 0x6f,
+	0x4d, 0x73, 0x5f, 0x37, 0x61, 0x38, 0xa9, 0x00, 0x00, 0x04, 0x00, 0x63, 0xff, 0xdb, 0x06, 0x4a,
+	0x05, 0xed, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x2c, 0x00, 0x2f, 0x00, 0xd2, 0xb1, 0x06, 0x64, 0x44,
+	0x40, 0x1c, 0x11, 0x01, 0x03, 0x04, 0x10, 0x01, 0x02, 0x03, 0x17, 0x01, 0x01, 0x02, 0x01, 0x01,
+	0x00, 0x09, 0x2f, 0x00, 0x02, 0x05, 0x00, 0x05, 0x4c, 0x25, 0x01, 0x0a, 0x01, 0x4b, 0x4b, 0xb0,
+	0x0e, 0x50, 0x58, 0x40, 0x3c, 0x00, 0x09, 0x01, 0x00, 0x01, 0x09, 0x00, 0x80, 0x0f, 0x0c, 0x0e,
+	0x03, 0x07, 0x08, 0x07, 0x86, 0x06, 0x01, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x02,
+	0x00, 0x01, 0x09, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x0a, 0x00, 0x05, 0x69, 0x0d, 0x01,
+	0x0a, 0x08, 0x08, 0x0a, 0x57, 0x0d, 0x01, 0x0a, 0x0a, 0x08, 0x60, 0x0b, 0x01, 0x08, 0x0a, 0x08,
+	0x50, 0x1b, 0x40, 0x42, 0x00, 0x09, 0x01, 0x00, 0x01, 0x09, 0x00, 0x80, 0x0f, 0x01, 0x0c, 0x08,
+	0x07, 0x08, 0x0c, 0x07, 0x80, 0x0e, 0x01, 0x07, 0x07, 0x84, 0x06, 0x01, 0x04, 0x00, 0x03, 0x02,
+	0x04, 0x03, 0x69, 0x00, 0x02, 0x00, 0x01, 0x09, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x0a,
+	0x00, 0x05, 0x69, 0x0d, 0x01, 0x0a, 0x08, 0x08, 0x0a, 0x57, 0x0d, 0x01, 0x0a, 0x0a, 0x08, 0x60,
+	0x0b, 0x01, 0x08, 0x0a, 0x08, 0x50, 0x59, 0x40, 0x20, 0x22, 0x22, 0x1e, 0x1e, 0x2e, 0x2d, 0x22,
+	0x2c, 0x22, 0x2c, 0x2b, 0x2a, 0x29, 0x28, 0x27, 0x26, 0x24, 0x23, 0x1e, 0x21, 0x1e, 0x21, 0x12,
+	0x27, 0x23, 0x22, 0x21, 0x22, 0x22, 0x10, 0x09, 0x1d, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x35,
+	0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x23, 0x35, 0x33, 0x32, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35,
+	0x36, 0x33, 0x20, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x13, 0x01, 0x33, 0x01,
+	0x25, 0x35, 0x21, 0x35, 0x01, 0x33, 0x11, 0x33, 0x15, 0x23, 0x15, 0x01, 0x33, 0x11, 0x63, 0x87,
+	0x64, 0xa2, 0xff, 0x3c, 0x2d, 0xf3, 0x8a, 0x6c, 0x70, 0x91, 0x7a, 0x01, 0x40, 0xcd, 0xe8, 0xc2,
+	0xad, 0x7e, 0x1b, 0x04, 0x54, 0x97, 0xfb, 0xad, 0x03, 0x90, 0xfe, 0x5c, 0x01, 0x9c, 0xca, 0x5d,
+	0x5d, 0xfe, 0x40, 0xff, 0x02, 0x66, 0x96, 0x34, 0x80, 0xa8, 0x7f, 0x92, 0x6d, 0x33, 0x87, 0x2b,
+	0xd7, 0xa0, 0x3e, 0x35, 0xbd, 0x78, 0x85, 0xfd, 0x92, 0x06, 0x12, 0xf9, 0xee, 0x25, 0xe2, 0xa6,
+	0x01, 0xf0, 0xfe, 0x10, 0xa6, 0xe2, 0x01, 0x88, 0x01, 0x30, 0x00, 0x00, 0x00, 0x02, 0x00, 0x84,
+	0xfe, 0x75, 0x04, 0x57, 0x04, 0x4a, 0x00, 0x03, 0x00, 0x1c, 0x00, 0x3b, 0x40, 0x38, 0x10, 0x01,
+	0x03, 0x02, 0x11, 0x01, 0x04, 0x03, 0x02, 0x4c, 0x00, 0x02, 0x00, 0x03, 0x00, 0x02, 0x03, 0x80,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x62,
+	0x00, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x00, 0x00, 0x14, 0x12, 0x0f, 0x0d, 0x05, 0x04, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x15, 0x21, 0x35, 0x13, 0x21, 0x15, 0x14, 0x06,
+	0x07, 0x07, 0x06, 0x15, 0x14, 0x21, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x11, 0x34, 0x36, 0x37,
+	0x37, 0x36, 0x36, 0x35, 0x03, 0x83, 0xfe, 0xc4, 0x0a, 0x01, 0x28, 0x56, 0x72, 0x64, 0x7e, 0x01,
+	0x07, 0xd8, 0xa9, 0xc3, 0xdc, 0xfd, 0xcc, 0x62, 0x93, 0x53, 0x51, 0x34, 0x04, 0x4a, 0xf7, 0xf7,
+	0xfe, 0x50, 0x12, 0x61, 0x9f, 0x55, 0x4a, 0x66, 0x8c, 0xbd, 0x53, 0xe2, 0x36, 0x01, 0x5b, 0x69,
+	0x80, 0x58, 0x32, 0x30, 0x75, 0x83, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x65, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00,
+	0x05, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00,
+	0x05, 0x00, 0x05, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02,
+	0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0e, 0x0d,
+	0x0c, 0x0b, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33,
+	0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x13, 0x23, 0x01, 0x21, 0x0c, 0x02,
+	0x3e, 0x01, 0x34, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc, 0xe6, 0xed,
+	0xc9, 0xfe, 0xbf, 0x01, 0x19, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02,
+	0x4e, 0x01, 0xb0, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6b, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06,
+	0x00, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x07, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x06, 0x05, 0x85,
+	0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x0b, 0x0b,
+	0x00, 0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11,
+	0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03,
+	0x03, 0x13, 0x21, 0x01, 0x0c, 0x02, 0x3e, 0x01, 0x34, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c,
+	0x97, 0xe3, 0x01, 0xcc, 0xe6, 0x91, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x05, 0xc8, 0xfa, 0x38, 0x01,
+	0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0xb0, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x12,
+	0x00, 0x74, 0x40, 0x0a, 0x10, 0x01, 0x06, 0x05, 0x0a, 0x01, 0x04, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06,
+	0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03,
+	0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07,
+	0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x0b, 0x0b, 0x00,
+	0x00, 0x0b, 0x12, 0x0b, 0x12, 0x0f, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x13, 0x21,
+	0x03, 0x01, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x0c, 0x02, 0x3e, 0x01, 0x34, 0x02, 0x3c,
+	0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc, 0xe6, 0xfe, 0xb4, 0xf1, 0x01, 0x11, 0xf1,
+	0xb3, 0xc5, 0x03, 0xc5, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e,
+	0x01, 0xb0, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x21, 0x00, 0x80, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05, 0x06, 0x0a,
+	0x69, 0x00, 0x07, 0x09, 0x01, 0x05, 0x00, 0x07, 0x05, 0x6a, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0b, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x2a, 0x00, 0x00, 0x05, 0x04, 0x05, 0x00, 0x04, 0x80, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05,
+	0x06, 0x0a, 0x69, 0x00, 0x07, 0x09, 0x01, 0x05, 0x00, 0x07, 0x05, 0x6a, 0x00, 0x04, 0x00, 0x02,
+	0x01, 0x04, 0x02, 0x68, 0x0b, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x00,
+	0x00, 0x21, 0x1f, 0x1a, 0x18, 0x17, 0x16, 0x15, 0x13, 0x0f, 0x0d, 0x0c, 0x0b, 0x09, 0x08, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03,
+	0x21, 0x03, 0x13, 0x21, 0x03, 0x03, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x35,
+	0x33, 0x10, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x0c, 0x02, 0x3e, 0x01, 0x34,
+	0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc, 0xe6, 0x9e, 0x94, 0xca, 0x40,
+	0x3e, 0x26, 0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44,
+	0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0xb0, 0x01, 0x41,
+	0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00, 0x04, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x07, 0x40, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x12, 0x00, 0x78,
+	0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x07, 0x01,
+	0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x24, 0x00, 0x00, 0x06, 0x04, 0x06, 0x00, 0x04, 0x80, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a,
+	0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x09, 0x03,
+	0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1e, 0x0f, 0x0f, 0x0b, 0x0b, 0x00, 0x00, 0x0f,
+	0x12, 0x0f, 0x12, 0x11, 0x10, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03,
+	0x13, 0x21, 0x03, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x0c, 0x02, 0x3e, 0x01, 0x34,
+	0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc, 0xe6, 0xfe, 0xed, 0xde, 0xc5,
+	0xdf, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x01, 0xc4, 0xde,
+	0xde, 0xde, 0xde, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x16,
+	0x00, 0x19, 0x00, 0x25, 0x00, 0x78, 0xb5, 0x19, 0x01, 0x06, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x24, 0x00, 0x01, 0x00, 0x08, 0x07, 0x01, 0x08, 0x69, 0x00, 0x06, 0x00, 0x04,
+	0x03, 0x06, 0x04, 0x68, 0x0a, 0x01, 0x07, 0x07, 0x3a, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x09, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x27, 0x02, 0x01, 0x00, 0x07, 0x06,
+	0x07, 0x00, 0x06, 0x80, 0x00, 0x01, 0x00, 0x08, 0x07, 0x01, 0x08, 0x69, 0x00, 0x06, 0x00, 0x04,
+	0x03, 0x06, 0x04, 0x68, 0x0a, 0x01, 0x07, 0x07, 0x3a, 0x4d, 0x09, 0x05, 0x02, 0x03, 0x03, 0x3c,
+	0x03, 0x4e, 0x59, 0x40, 0x18, 0x1b, 0x1a, 0x00, 0x00, 0x21, 0x1f, 0x1a, 0x25, 0x1b, 0x25, 0x18,
+	0x17, 0x00, 0x16, 0x00, 0x16, 0x11, 0x11, 0x16, 0x26, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x01,
+	0x33, 0x26, 0x27, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x06, 0x07, 0x33,
+	0x01, 0x21, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x13, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22,
+	0x06, 0x15, 0x14, 0x16, 0x0c, 0x02, 0x3e, 0x49, 0x2e, 0x25, 0x43, 0x88, 0x62, 0x61, 0x89, 0x45,
+	0x25, 0x2f, 0x46, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc, 0xe6, 0x2f,
+	0x35, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0x05, 0xc8, 0x11, 0x26, 0x45, 0x60, 0x62, 0x89, 0x89,
+	0x61, 0x63, 0x44, 0x25, 0x11, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x01,
+	0x8b, 0x48, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x49, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0c,
+	0x00, 0x00, 0x07, 0xc2, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x12, 0x00, 0x73, 0xb5, 0x12, 0x01, 0x02,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00, 0x02, 0x00, 0x03, 0x08, 0x02,
+	0x03, 0x67, 0x00, 0x08, 0x00, 0x06, 0x04, 0x08, 0x06, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05,
+	0x4e, 0x1b, 0x40, 0x25, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03,
+	0x08, 0x02, 0x03, 0x67, 0x00, 0x08, 0x00, 0x06, 0x04, 0x08, 0x06, 0x67, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x09, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x11, 0x10,
+	0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1d, 0x2b, 0x33,
+	0x01, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x03, 0x01,
+	0x21, 0x11, 0x0c, 0x03, 0x80, 0x04, 0x07, 0xfd, 0x59, 0x02, 0x38, 0xfd, 0xc8, 0x02, 0xd6, 0xfc,
+	0x02, 0xfe, 0x24, 0xe7, 0x01, 0x5b, 0x01, 0x68, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xcc, 0xfe, 0x3e,
+	0xd2, 0x01, 0x7e, 0xfe, 0x82, 0x02, 0x3e, 0x02, 0x53, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x50,
+	0xfe, 0x50, 0x05, 0x7e, 0x05, 0xed, 0x00, 0x25, 0x00, 0xb1, 0x40, 0x1c, 0x1d, 0x01, 0x05, 0x04,
+	0x1e, 0x00, 0x02, 0x06, 0x05, 0x14, 0x01, 0x02, 0x00, 0x06, 0x04, 0x01, 0x03, 0x00, 0x0c, 0x01,
+	0x02, 0x03, 0x0b, 0x01, 0x01, 0x02, 0x06, 0x4c, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x26, 0x00,
+	0x03, 0x00, 0x02, 0x00, 0x03, 0x72, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d,
+	0x00, 0x06, 0x06, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x43, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00, 0x03, 0x00,
+	0x02, 0x00, 0x03, 0x02, 0x80, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d, 0x00,
+	0x06, 0x06, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x43, 0x01, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x00,
+	0x04, 0x00, 0x05, 0x06, 0x04, 0x05, 0x69, 0x00, 0x06, 0x06, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0a,
+	0x22, 0x23, 0x27, 0x22, 0x23, 0x25, 0x12, 0x07, 0x09, 0x1d, 0x2b, 0x01, 0x15, 0x06, 0x05, 0x07,
+	0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x23, 0x37,
+	0x24, 0x27, 0x26, 0x11, 0x10, 0x00, 0x21, 0x20, 0x17, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x21,
+	0x32, 0x05, 0x7e, 0xd3, 0xfe, 0xc7, 0x33, 0xe8, 0x90, 0x69, 0x52, 0x6a, 0x47, 0x2f, 0x79, 0xc3,
+	0x14, 0x64, 0xfe, 0xda, 0xab, 0xcd, 0x01, 0x9e, 0x01, 0x8f, 0x01, 0x03, 0xf1, 0xfe, 0xef, 0xc8,
+	0xfd, 0xff, 0x02, 0x1e, 0xeb, 0x01, 0x1e, 0xe3, 0x5e, 0x02, 0x4c, 0x19, 0x83, 0x45, 0x5e, 0x1e,
+	0x5b, 0x0f, 0x3c, 0x54, 0x97, 0x1b, 0xa8, 0xca, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1,
+	0x5f, 0xfd, 0xc6, 0xfd, 0xc8, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x28, 0x00,
+	0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00,
+	0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03,
+	0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e,
+	0x59, 0x40, 0x12, 0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x09, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11,
+	0x21, 0x15, 0x01, 0x23, 0x01, 0x21, 0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03,
+	0x39, 0xfe, 0x65, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38,
+	0xd2, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x74, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x00,
+	0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x06, 0x07, 0x06, 0x85,
+	0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c,
+	0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x13, 0x21, 0x01, 0xad, 0x04, 0x3e, 0xfc,
+	0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0xfd, 0x11, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x05, 0xc8,
+	0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x7f,
+	0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x06,
+	0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x09, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x06, 0x07, 0x06, 0x85,
+	0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00,
+	0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05,
+	0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f,
+	0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33,
+	0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x13, 0x21, 0x13, 0x23,
+	0x27, 0x23, 0x07, 0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0xfc, 0x46,
+	0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38,
+	0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0x1a, 0x07, 0x40, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x7e, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07,
+	0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b,
+	0x40, 0x28, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c,
+	0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0xfc, 0x67, 0xde, 0xd9, 0xdf,
+	0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x03, 0x3c, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x60,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x04, 0x00,
+	0x85, 0x05, 0x01, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x06, 0x01, 0x02, 0x02,
+	0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x05, 0x01, 0x03, 0x02, 0x04, 0x03, 0x68, 0x06,
+	0x01, 0x02, 0x02, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x10, 0x04,
+	0x04, 0x04, 0x0f, 0x04, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x12, 0x11, 0x10, 0x09, 0x09, 0x1d, 0x2b,
+	0x01, 0x23, 0x01, 0x21, 0x01, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15,
+	0x02, 0x8f, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0xfe, 0xc6, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0x06,
+	0x4e, 0x01, 0x41, 0xf8, 0x71, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x03, 0x3c, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x6c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x04,
+	0x01, 0x85, 0x05, 0x01, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x06, 0x01, 0x02,
+	0x02, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x00, 0x01,
+	0x00, 0x85, 0x08, 0x01, 0x01, 0x04, 0x01, 0x85, 0x00, 0x04, 0x05, 0x01, 0x03, 0x02, 0x04, 0x03,
+	0x68, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40,
+	0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x09, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x01,
+	0x01, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x11, 0xf1, 0x01,
+	0x19, 0xfe, 0xbf, 0xfe, 0x8a, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe,
+	0xbf, 0xf9, 0xb2, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x02, 0x00, 0x56,
+	0x00, 0x00, 0x03, 0x49, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x13, 0x00, 0x76, 0xb5, 0x05, 0x01, 0x01,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09,
+	0x02, 0x02, 0x01, 0x05, 0x01, 0x85, 0x06, 0x01, 0x04, 0x04, 0x05, 0x5f, 0x00, 0x05, 0x05, 0x38,
+	0x4d, 0x07, 0x01, 0x03, 0x03, 0x08, 0x60, 0x0a, 0x01, 0x08, 0x08, 0x39, 0x08, 0x4e, 0x1b, 0x40,
+	0x22, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x02, 0x02, 0x01, 0x05, 0x01, 0x85, 0x00, 0x05, 0x06,
+	0x01, 0x04, 0x03, 0x05, 0x04, 0x67, 0x07, 0x01, 0x03, 0x03, 0x08, 0x60, 0x0a, 0x01, 0x08, 0x08,
+	0x3c, 0x08, 0x4e, 0x59, 0x40, 0x1b, 0x08, 0x08, 0x00, 0x00, 0x08, 0x13, 0x08, 0x13, 0x12, 0x11,
+	0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x0b, 0x09,
+	0x18, 0x2b, 0x13, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x35, 0x33, 0x11, 0x23, 0x35,
+	0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x56, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xa5,
+	0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0xf9, 0xb2,
+	0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x03, 0x00, 0x64, 0x00, 0x00, 0x03, 0x3c,
+	0x07, 0x40, 0x00, 0x03, 0x00, 0x07, 0x00, 0x13, 0x00, 0x76, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x24, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01, 0x67, 0x07, 0x01, 0x05,
+	0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c, 0x01,
+	0x09, 0x09, 0x39, 0x09, 0x4e, 0x1b, 0x40, 0x22, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01,
+	0x06, 0x00, 0x01, 0x67, 0x00, 0x06, 0x07, 0x01, 0x05, 0x04, 0x06, 0x05, 0x67, 0x08, 0x01, 0x04,
+	0x04, 0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40, 0x22, 0x08, 0x08, 0x04,
+	0x04, 0x00, 0x00, 0x08, 0x13, 0x08, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a,
+	0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0d, 0x09, 0x17, 0x2b,
+	0x13, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15,
+	0x23, 0x11, 0x33, 0x15, 0x80, 0xde, 0xd9, 0xdf, 0xfd, 0x4e, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2,
+	0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0xf9, 0x9e, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2,
+	0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x05, 0x77, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x19, 0x00, 0x60,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00,
+	0x67, 0x00, 0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f,
+	0x08, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x01, 0x02,
+	0x05, 0x69, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x04, 0x04, 0x03,
+	0x5f, 0x08, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x19, 0x18, 0x17,
+	0x16, 0x15, 0x13, 0x0f, 0x0d, 0x00, 0x0c, 0x00, 0x0b, 0x21, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b,
+	0x33, 0x11, 0x23, 0x35, 0x33, 0x11, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x27, 0x33, 0x32,
+	0x12, 0x11, 0x34, 0x02, 0x23, 0x23, 0x11, 0x33, 0x15, 0x23, 0xad, 0xad, 0xad, 0x02, 0x03, 0x01,
+	0x58, 0x01, 0x6f, 0xfe, 0x7c, 0xfe, 0xa2, 0xb4, 0x6d, 0xf3, 0xef, 0xf0, 0xd3, 0x8c, 0xd2, 0xd2,
+	0x02, 0x91, 0xb9, 0x02, 0x7e, 0xfe, 0x93, 0xfe, 0xa8, 0xfe, 0x92, 0xfe, 0x6b, 0xd2, 0x01, 0x0d,
+	0x01, 0x12, 0xf5, 0x01, 0x17, 0xfe, 0x4d, 0xb9, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x20, 0x00, 0x6e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69,
+	0x00, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x0a,
+	0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x20, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04,
+	0x05, 0x09, 0x69, 0x00, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x6a, 0x01, 0x01, 0x00, 0x00,
+	0x02, 0x5f, 0x0a, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x18, 0x00, 0x00, 0x20,
+	0x1e, 0x19, 0x17, 0x16, 0x15, 0x14, 0x12, 0x0e, 0x0c, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11,
+	0x12, 0x11, 0x0b, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x11, 0x33, 0x11, 0x21, 0x01, 0x11,
+	0x13, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x35, 0x33, 0x10, 0x23, 0x22, 0x27,
+	0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0xad, 0x01, 0x0f, 0x02, 0x67, 0xf7, 0xfe, 0xed, 0xfd, 0x9d,
+	0x86, 0x94, 0xca, 0x40, 0x3e, 0x26, 0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17,
+	0x08, 0x3d, 0x1d, 0x44, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d,
+	0x06, 0x4e, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d,
+	0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x65, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04,
+	0x01, 0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x07, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x05, 0x04,
+	0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x07,
+	0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x17, 0x0d,
+	0x0c, 0x01, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00,
+	0x0b, 0x01, 0x0b, 0x08, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x01,
+	0x23, 0x01, 0x21, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01,
+	0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0x01, 0x83, 0xc9, 0xfe,
+	0xbf, 0x01, 0x19, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96,
+	0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe,
+	0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xe9, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x6b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61,
+	0x06, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08,
+	0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x07, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d,
+	0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10,
+	0x12, 0x03, 0x13, 0x21, 0x01, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01,
+	0x4f, 0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0x10, 0xf1,
+	0x01, 0x19, 0xfe, 0xbf, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe,
+	0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3,
+	0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x03, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xe9, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x76, 0xb5, 0x1d,
+	0x01, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18, 0x1f,
+	0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x0a, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x13, 0x21, 0x13,
+	0x23, 0x27, 0x23, 0x07, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f,
+	0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0xc0, 0xf1, 0x01,
+	0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe,
+	0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d,
+	0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5,
+	0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2e,
+	0x00, 0x7d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05,
+	0x09, 0x69, 0x00, 0x06, 0x08, 0x01, 0x04, 0x01, 0x06, 0x04, 0x6a, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x3f,
+	0x00, 0x4e, 0x1b, 0x40, 0x27, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69, 0x00, 0x06,
+	0x08, 0x01, 0x04, 0x01, 0x06, 0x04, 0x6a, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b,
+	0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1f, 0x0d,
+	0x0c, 0x01, 0x00, 0x2e, 0x2c, 0x27, 0x25, 0x24, 0x23, 0x22, 0x20, 0x1c, 0x1a, 0x19, 0x18, 0x13,
+	0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10,
+	0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33,
+	0x32, 0x35, 0x33, 0x10, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x03, 0x12, 0xfe,
+	0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe,
+	0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0x12, 0x94, 0xca, 0x40, 0x3e, 0x26, 0x1f, 0x40, 0x1b, 0x43,
+	0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01,
+	0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16,
+	0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7, 0x01, 0x41,
+	0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00, 0x04, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xe9, 0x07, 0x40, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x75,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01,
+	0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x09, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x06, 0x01, 0x04,
+	0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x35, 0x33,
+	0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01,
+	0x4f, 0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0x91, 0xde,
+	0xd9, 0xdf, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe,
+	0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef,
+	0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xbb, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x01, 0x00, 0x63,
+	0x00, 0x5e, 0x04, 0x48, 0x04, 0x43, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x09, 0x03, 0x01, 0x32, 0x2b,
+	0x37, 0x01, 0x01, 0x37, 0x01, 0x01, 0x17, 0x01, 0x01, 0x07, 0x01, 0x01, 0x63, 0x01, 0x78, 0xfe,
+	0x88, 0x7a, 0x01, 0x78, 0x01, 0x79, 0x7a, 0xfe, 0x87, 0x01, 0x79, 0x7a, 0xfe, 0x87, 0xfe, 0x88,
+	0xd8, 0x01, 0x78, 0x01, 0x79, 0x7a, 0xfe, 0x88, 0x01, 0x78, 0x7a, 0xfe, 0x87, 0xfe, 0x88, 0x7a,
+	0x01, 0x78, 0xfe, 0x88, 0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9, 0x05, 0xed, 0x00, 0x07,
+	0x00, 0x0f, 0x00, 0x23, 0x00, 0x5d, 0x40, 0x11, 0x18, 0x01, 0x00, 0x02, 0x1b, 0x11, 0x0f, 0x07,
+	0x04, 0x01, 0x00, 0x22, 0x01, 0x04, 0x01, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18,
+	0x00, 0x00, 0x00, 0x02, 0x61, 0x03, 0x01, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61,
+	0x06, 0x05, 0x02, 0x04, 0x04, 0x3f, 0x04, 0x4e, 0x1b, 0x40, 0x16, 0x03, 0x01, 0x02, 0x00, 0x00,
+	0x01, 0x02, 0x00, 0x69, 0x00, 0x01, 0x01, 0x04, 0x61, 0x06, 0x05, 0x02, 0x04, 0x04, 0x42, 0x04,
+	0x4e, 0x59, 0x40, 0x0e, 0x10, 0x10, 0x10, 0x23, 0x10, 0x23, 0x25, 0x12, 0x2a, 0x26, 0x21, 0x07,
+	0x09, 0x1b, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x02, 0x11, 0x14, 0x17, 0x17, 0x16, 0x33, 0x32, 0x12,
+	0x11, 0x34, 0x27, 0x01, 0x37, 0x26, 0x11, 0x10, 0x00, 0x21, 0x20, 0x17, 0x37, 0x33, 0x07, 0x16,
+	0x11, 0x10, 0x00, 0x21, 0x20, 0x27, 0x07, 0x04, 0x26, 0x61, 0xa9, 0xb8, 0xcd, 0x30, 0x4c, 0x62,
+	0xa7, 0xb9, 0xcd, 0x30, 0xfb, 0xde, 0xb2, 0xb2, 0x01, 0x7d, 0x01, 0x53, 0x01, 0x07, 0xa5, 0x5f,
+	0xbe, 0xb2, 0xb2, 0xfe, 0x82, 0xfe, 0xae, 0xfe, 0xfa, 0xa6, 0x5f, 0x04, 0xa6, 0x7c, 0xfe, 0xd3,
+	0xfe, 0xf0, 0xa5, 0x90, 0x8e, 0x7b, 0x01, 0x2c, 0x01, 0x0f, 0xa5, 0x92, 0xfb, 0xc2, 0xdf, 0xe2,
+	0x01, 0x48, 0x01, 0x6e, 0x01, 0x9b, 0x77, 0x77, 0xdf, 0xdf, 0xfe, 0xb5, 0xfe, 0x92, 0xfe, 0x65,
+	0x78, 0x78, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26, 0x07, 0x8f, 0x00, 0x14,
+	0x00, 0x18, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x04, 0x05, 0x85,
+	0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04,
+	0x00, 0x04, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x09, 0x11, 0x15, 0x25, 0x12, 0x23, 0x10, 0x06, 0x09, 0x1c,
+	0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06,
+	0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x01, 0x23, 0x01, 0x21, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01,
+	0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x03, 0x16, 0xc9, 0xfe, 0xbf,
+	0x01, 0x19, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7,
+	0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x10, 0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa0,
+	0xff, 0xdb, 0x05, 0x26, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x18, 0x00, 0x54, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1c, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00,
+	0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40,
+	0x0e, 0x15, 0x15, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x07, 0x09, 0x1b, 0x2b,
+	0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23,
+	0x22, 0x27, 0x26, 0x26, 0x35, 0x01, 0x13, 0x21, 0x01, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01, 0x1c,
+	0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x01, 0x98, 0xf1, 0x01, 0x19, 0xfe,
+	0xbf, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f,
+	0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x10, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0xa0,
+	0xff, 0xdb, 0x05, 0x26, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x5e, 0xb5, 0x1a, 0x01, 0x05,
+	0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07,
+	0x06, 0x02, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07,
+	0x06, 0x02, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x15, 0x15, 0x15, 0x1c, 0x15, 0x1c,
+	0x11, 0x16, 0x25, 0x12, 0x23, 0x10, 0x08, 0x09, 0x1c, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33,
+	0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x13,
+	0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01, 0x1c, 0x01, 0x0c,
+	0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0xde, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03,
+	0xc5, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f,
+	0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x10, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26, 0x07, 0x40, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c,
+	0x00, 0x61, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03,
+	0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00,
+	0x01, 0x80, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01,
+	0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x19, 0x19, 0x15, 0x15,
+	0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0a,
+	0x09, 0x1b, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06,
+	0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01, 0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b,
+	0x55, 0x01, 0x0d, 0xde, 0xd9, 0xdf, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2,
+	0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde, 0xde, 0xde,
+	0x00, 0x02, 0x00, 0x1c, 0x00, 0x00, 0x05, 0x3b, 0x07, 0x8f, 0x00, 0x08, 0x00, 0x0c, 0x00, 0x62,
+	0xb7, 0x07, 0x04, 0x01, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b,
+	0x06, 0x01, 0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x03, 0x03, 0x02, 0x5f, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1d, 0x06, 0x01,
+	0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x01, 0x01, 0x00, 0x02, 0x03, 0x00, 0x02, 0x7e, 0x00,
+	0x03, 0x03, 0x02, 0x5f, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x09, 0x09,
+	0x00, 0x00, 0x09, 0x0c, 0x09, 0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x07, 0x09,
+	0x18, 0x2b, 0x21, 0x11, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01, 0x11, 0x01, 0x13, 0x21, 0x01, 0x02,
+	0x07, 0xfe, 0x15, 0x01, 0x55, 0x01, 0x62, 0x01, 0x74, 0xf4, 0xfe, 0x00, 0xfe, 0xe2, 0xf1, 0x01,
+	0x19, 0xfe, 0xbf, 0x02, 0x6c, 0x03, 0x5c, 0xfd, 0x8f, 0x02, 0x71, 0xfc, 0xa6, 0xfd, 0x92, 0x06,
+	0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x25,
+	0x05, 0xc8, 0x00, 0x0d, 0x00, 0x15, 0x00, 0x56, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00,
+	0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01,
+	0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x00,
+	0x00, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x15,
+	0x13, 0x10, 0x0e, 0x00, 0x0d, 0x00, 0x0d, 0x25, 0x21, 0x11, 0x07, 0x09, 0x19, 0x2b, 0x33, 0x11,
+	0x21, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15, 0x10, 0x21, 0x23, 0x11, 0x11, 0x33, 0x20, 0x11,
+	0x34, 0x26, 0x23, 0x23, 0xad, 0x01, 0x2e, 0x01, 0x24, 0xd0, 0xba, 0x41, 0x5b, 0xfd, 0x84, 0xce,
+	0x8a, 0x01, 0x85, 0x92, 0xb8, 0xc5, 0x05, 0xc8, 0xfe, 0xe5, 0x30, 0x45, 0x62, 0xb3, 0xfe, 0x05,
+	0xfe, 0xd8, 0x01, 0xf4, 0x01, 0x11, 0x7b, 0x61, 0x00, 0x01, 0x00, 0x94, 0xff, 0xe7, 0x04, 0x99,
+	0x06, 0x44, 0x00, 0x2b, 0x00, 0xb0, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x16, 0x01, 0x02,
+	0x03, 0x15, 0x01, 0x01, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x16, 0x01, 0x02, 0x03, 0x15, 0x01,
+	0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x05, 0x04, 0x02, 0x01, 0x01,
+	0x42, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x3a, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x00,
+	0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00,
+	0x03, 0x69, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x42, 0x01, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x2b, 0x00, 0x2b, 0x2e, 0x23,
+	0x2e, 0x22, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x10, 0x21, 0x32, 0x16, 0x15, 0x14, 0x07, 0x07,
+	0x06, 0x15, 0x14, 0x17, 0x17, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32,
+	0x35, 0x34, 0x2f, 0x02, 0x26, 0x35, 0x34, 0x3f, 0x02, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x11,
+	0x94, 0x01, 0xbe, 0xc3, 0xe3, 0x41, 0x3d, 0x46, 0x63, 0x55, 0xad, 0xd3, 0xb1, 0x60, 0x7d, 0x7a,
+	0x46, 0x8c, 0x55, 0x62, 0x4e, 0x3d, 0x27, 0x2f, 0x31, 0x2b, 0x9c, 0x9c, 0x04, 0x6b, 0x01, 0xd9,
+	0x8d, 0x78, 0x61, 0x5b, 0x56, 0x62, 0x2a, 0x2c, 0x77, 0x65, 0xcf, 0x96, 0x98, 0xb5, 0x20, 0xc1,
+	0x28, 0x7e, 0x3c, 0x65, 0x74, 0x5b, 0x4d, 0x63, 0x47, 0x47, 0x51, 0x57, 0x4a, 0x46, 0x8a, 0xd5,
+	0xfb, 0x47, 0x00, 0x00, 0x00, 0x03, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b, 0x06, 0x44, 0x00, 0x1c,
+	0x00, 0x25, 0x00, 0x29, 0x00, 0xe3, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x14, 0x01, 0x03,
+	0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x04,
+	0x4c, 0x1b, 0x40, 0x17, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x07, 0x06,
+	0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x2c, 0x00, 0x08, 0x09, 0x04, 0x09, 0x08, 0x04, 0x80, 0x00, 0x02, 0x00, 0x06, 0x05,
+	0x02, 0x06, 0x69, 0x00, 0x09, 0x09, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x29, 0x00, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08,
+	0x85, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04,
+	0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x33, 0x00, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x00, 0x02, 0x00,
+	0x06, 0x07, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00,
+	0x07, 0x07, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x29, 0x28, 0x11, 0x23, 0x23, 0x13,
+	0x23, 0x22, 0x23, 0x23, 0x22, 0x0a, 0x09, 0x1f, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23,
+	0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33,
+	0x20, 0x11, 0x11, 0x14, 0x33, 0x32, 0x25, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x13,
+	0x23, 0x01, 0x21, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02,
+	0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53,
+	0x40, 0x66, 0x99, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0xa9, 0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01,
+	0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53,
+	0x04, 0x53, 0x01, 0x41, 0x00, 0x03, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b, 0x06, 0x44, 0x00, 0x1c,
+	0x00, 0x25, 0x00, 0x29, 0x00, 0xea, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x14, 0x01, 0x03,
+	0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x04,
+	0x4c, 0x1b, 0x40, 0x17, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x07, 0x06,
+	0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x2d, 0x0a, 0x01, 0x09, 0x08, 0x04, 0x08, 0x09, 0x04, 0x80, 0x00, 0x02, 0x00, 0x06,
+	0x05, 0x02, 0x06, 0x69, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04,
+	0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x08, 0x09, 0x08, 0x85, 0x0a, 0x01, 0x09,
+	0x04, 0x09, 0x85, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x34, 0x00, 0x08, 0x09, 0x08, 0x85, 0x0a, 0x01, 0x09, 0x04, 0x09, 0x85,
+	0x00, 0x02, 0x00, 0x06, 0x07, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x26, 0x26, 0x26,
+	0x29, 0x26, 0x29, 0x12, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23, 0x22, 0x0b, 0x09, 0x1f, 0x2b,
+	0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35,
+	0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33, 0x32, 0x25, 0x35, 0x23,
+	0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x03, 0x13, 0x21, 0x01, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7,
+	0x34, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02, 0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98,
+	0x52, 0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53, 0x40, 0x66, 0xe6, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0xa9,
+	0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9, 0xfe,
+	0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53, 0x04, 0x53, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b, 0x06, 0x44, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x2d,
+	0x00, 0xf7, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x18, 0x2b, 0x01, 0x09, 0x08, 0x14, 0x01, 0x03,
+	0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05,
+	0x4c, 0x1b, 0x40, 0x1b, 0x2b, 0x01, 0x09, 0x08, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03,
+	0x1d, 0x01, 0x07, 0x06, 0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x06, 0x4c, 0x59,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2e, 0x0b, 0x0a, 0x02, 0x09, 0x08, 0x04, 0x08, 0x09, 0x04,
+	0x80, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x08, 0x09,
+	0x08, 0x85, 0x0b, 0x0a, 0x02, 0x09, 0x04, 0x09, 0x85, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x35, 0x00, 0x08, 0x09, 0x08, 0x85,
+	0x0b, 0x0a, 0x02, 0x09, 0x04, 0x09, 0x85, 0x00, 0x02, 0x00, 0x06, 0x07, 0x02, 0x06, 0x69, 0x00,
+	0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x40, 0x14, 0x26, 0x26, 0x26, 0x2d, 0x26, 0x2d, 0x2a, 0x29, 0x12, 0x23, 0x23, 0x13,
+	0x23, 0x22, 0x23, 0x23, 0x22, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23,
+	0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33,
+	0x20, 0x11, 0x11, 0x14, 0x33, 0x32, 0x25, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01,
+	0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b,
+	0xa9, 0x92, 0xb3, 0x02, 0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe,
+	0x82, 0x46, 0xf7, 0x53, 0x40, 0x66, 0xfe, 0x60, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5,
+	0xa9, 0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9,
+	0xfe, 0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53, 0x04, 0x53, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5,
+	0x00, 0x03, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b, 0x06, 0x4e, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x3c,
+	0x01, 0x40, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02,
+	0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x04, 0x4c, 0x1b, 0x40, 0x17,
+	0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x07, 0x06, 0x00, 0x01, 0x05, 0x07,
+	0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x35, 0x00,
+	0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x0d, 0x0d, 0x09, 0x61, 0x0b, 0x01, 0x09, 0x09,
+	0x3a, 0x4d, 0x0c, 0x01, 0x08, 0x08, 0x0a, 0x61, 0x00, 0x0a, 0x0a, 0x38, 0x4d, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x33, 0x0b, 0x01, 0x09, 0x00,
+	0x0d, 0x08, 0x09, 0x0d, 0x69, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x0c, 0x01, 0x08,
+	0x08, 0x0a, 0x61, 0x00, 0x0a, 0x0a, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x31, 0x0b, 0x01, 0x09, 0x00, 0x0d, 0x08, 0x09, 0x0d, 0x69,
+	0x00, 0x0a, 0x0c, 0x01, 0x08, 0x04, 0x0a, 0x08, 0x6a, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x3b, 0x0b, 0x01, 0x09, 0x00, 0x0d,
+	0x08, 0x09, 0x0d, 0x69, 0x00, 0x0a, 0x0c, 0x01, 0x08, 0x04, 0x0a, 0x08, 0x6a, 0x00, 0x02, 0x00,
+	0x06, 0x07, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00,
+	0x07, 0x07, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x16, 0x3c, 0x3a, 0x35, 0x33, 0x32,
+	0x31, 0x30, 0x2e, 0x2a, 0x28, 0x11, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23, 0x22, 0x0e, 0x09,
+	0x1f, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21,
+	0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33, 0x32, 0x25,
+	0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x03, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16,
+	0x33, 0x32, 0x35, 0x33, 0x10, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x04, 0x34,
+	0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02, 0x0a, 0x4f, 0xac, 0x9b, 0xb1,
+	0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53, 0x40, 0x66, 0xf2, 0x94, 0xca,
+	0x40, 0x3e, 0x26, 0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d,
+	0x44, 0xa9, 0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe,
+	0xa9, 0xfe, 0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53, 0x04, 0x5d, 0x01, 0x41, 0x2b, 0x1a, 0x16,
+	0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x45,
+	0xff, 0xe7, 0x04, 0x3b, 0x05, 0xeb, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x29, 0x00, 0x2d, 0x00, 0xf4,
+	0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d,
+	0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x04, 0x4c, 0x1b, 0x40, 0x17, 0x14, 0x01,
+	0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x07, 0x06, 0x00, 0x01, 0x05, 0x07, 0x05, 0x01,
+	0x02, 0x00, 0x05, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x02, 0x00,
+	0x06, 0x05, 0x02, 0x06, 0x69, 0x0d, 0x0b, 0x0c, 0x03, 0x09, 0x09, 0x08, 0x5f, 0x0a, 0x01, 0x08,
+	0x08, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05,
+	0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58,
+	0x40, 0x2b, 0x0a, 0x01, 0x08, 0x0d, 0x0b, 0x0c, 0x03, 0x09, 0x04, 0x08, 0x09, 0x67, 0x00, 0x02,
+	0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d,
+	0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x35,
+	0x0a, 0x01, 0x08, 0x0d, 0x0b, 0x0c, 0x03, 0x09, 0x04, 0x08, 0x09, 0x67, 0x00, 0x02, 0x00, 0x06,
+	0x07, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x07,
+	0x07, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1a, 0x2a, 0x2a, 0x26, 0x26, 0x2a, 0x2d, 0x2a,
+	0x2d, 0x2c, 0x2b, 0x26, 0x29, 0x26, 0x29, 0x12, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23, 0x22,
+	0x0e, 0x09, 0x1f, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35,
+	0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33,
+	0x32, 0x25, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35,
+	0x33, 0x15, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02, 0x0a,
+	0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53, 0x40,
+	0x66, 0xfe, 0x99, 0xde, 0xc5, 0xdf, 0xa9, 0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64,
+	0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53, 0x04, 0x5d,
+	0xde, 0xde, 0xde, 0xde, 0x00, 0x04, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b, 0x06, 0xd8, 0x00, 0x1c,
+	0x00, 0x25, 0x00, 0x31, 0x00, 0x3d, 0x00, 0xcb, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x14,
+	0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00,
+	0x05, 0x04, 0x4c, 0x1b, 0x40, 0x17, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01,
+	0x07, 0x06, 0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x59, 0x4b, 0xb0,
+	0x2d, 0x50, 0x58, 0x40, 0x31, 0x00, 0x09, 0x00, 0x0b, 0x0a, 0x09, 0x0b, 0x69, 0x0d, 0x01, 0x0a,
+	0x0c, 0x01, 0x08, 0x04, 0x0a, 0x08, 0x69, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00,
+	0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x3b, 0x00, 0x09, 0x00, 0x0b, 0x0a, 0x09, 0x0b,
+	0x69, 0x0d, 0x01, 0x0a, 0x0c, 0x01, 0x08, 0x04, 0x0a, 0x08, 0x69, 0x00, 0x02, 0x00, 0x06, 0x07,
+	0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x07, 0x07,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x33, 0x32, 0x27, 0x26, 0x39, 0x37, 0x32, 0x3d, 0x33,
+	0x3d, 0x2d, 0x2b, 0x26, 0x31, 0x27, 0x31, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23, 0x22, 0x0e,
+	0x09, 0x1e, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10,
+	0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33, 0x32,
+	0x25, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x03, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02, 0x0a, 0x4f,
+	0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53, 0x40, 0x66,
+	0x35, 0x60, 0x87, 0x88, 0x62, 0x61, 0x89, 0x89, 0x63, 0x35, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47,
+	0xa9, 0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9,
+	0xfe, 0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53, 0x04, 0x53, 0x8a, 0x60, 0x62, 0x89, 0x89, 0x61,
+	0x63, 0x88, 0x6f, 0x48, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x49, 0x00, 0x00, 0x03, 0x00, 0x45,
+	0xff, 0xe7, 0x06, 0xb0, 0x04, 0x63, 0x00, 0x21, 0x00, 0x2a, 0x00, 0x2f, 0x00, 0x81, 0x40, 0x14,
+	0x13, 0x0f, 0x02, 0x02, 0x03, 0x0e, 0x01, 0x01, 0x02, 0x22, 0x1d, 0x02, 0x06, 0x05, 0x1e, 0x01,
+	0x00, 0x06, 0x04, 0x4c, 0x4b, 0xb0, 0x31, 0x50, 0x58, 0x40, 0x23, 0x0a, 0x01, 0x01, 0x08, 0x01,
+	0x05, 0x06, 0x01, 0x05, 0x69, 0x0b, 0x01, 0x02, 0x02, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41,
+	0x4d, 0x09, 0x01, 0x06, 0x06, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x28, 0x00, 0x08, 0x05, 0x01, 0x08, 0x59, 0x0a, 0x01, 0x01, 0x00, 0x05, 0x06, 0x01, 0x05, 0x67,
+	0x0b, 0x01, 0x02, 0x02, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x09, 0x01, 0x06, 0x06,
+	0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x12, 0x2f, 0x2d, 0x2c, 0x2b,
+	0x2a, 0x28, 0x22, 0x23, 0x21, 0x12, 0x22, 0x23, 0x22, 0x24, 0x21, 0x0c, 0x09, 0x1f, 0x2b, 0x25,
+	0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x24, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36,
+	0x33, 0x32, 0x17, 0x36, 0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x37, 0x15, 0x06, 0x23,
+	0x20, 0x03, 0x35, 0x23, 0x20, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x03,
+	0x24, 0x9c, 0xf1, 0x98, 0xba, 0x01, 0x29, 0x01, 0x16, 0x54, 0xca, 0xb2, 0xb5, 0xd0, 0xc1, 0xb0,
+	0xa5, 0x9a, 0xb8, 0xef, 0xe2, 0xfd, 0x47, 0x20, 0x01, 0x41, 0x99, 0xbf, 0xd6, 0xd6, 0xfe, 0xcc,
+	0xf8, 0x4b, 0xfe, 0xd4, 0x59, 0x43, 0x6b, 0x01, 0x8c, 0x01, 0x99, 0xbd, 0xbf, 0xc0, 0xd9, 0xae,
+	0x8e, 0xb5, 0xc2, 0x68, 0xab, 0x62, 0xcc, 0x4c, 0x79, 0x79, 0xfe, 0xcc, 0xfe, 0xbb, 0xfe, 0xc6,
+	0x45, 0xd0, 0x3e, 0x01, 0x2e, 0xdf, 0xb3, 0x3f, 0x52, 0x01, 0xe1, 0x01, 0x1c, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x4a, 0xfe, 0x50, 0x04, 0x20, 0x04, 0x63, 0x00, 0x25, 0x00, 0x83, 0x40, 0x1c,
+	0x1c, 0x01, 0x05, 0x04, 0x1d, 0x00, 0x02, 0x06, 0x05, 0x14, 0x01, 0x02, 0x00, 0x06, 0x04, 0x01,
+	0x03, 0x00, 0x0c, 0x01, 0x02, 0x03, 0x0b, 0x01, 0x01, 0x02, 0x06, 0x4c, 0x4b, 0xb0, 0x10, 0x50,
+	0x58, 0x40, 0x26, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x72, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x03, 0x00, 0x02,
+	0x00, 0x03, 0x02, 0x80, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x06,
+	0x06, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x43, 0x01, 0x4e, 0x59, 0x40, 0x0a, 0x23, 0x23, 0x26, 0x22, 0x23, 0x25, 0x12, 0x07, 0x09, 0x1d,
+	0x2b, 0x25, 0x15, 0x06, 0x07, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33,
+	0x32, 0x35, 0x34, 0x23, 0x23, 0x37, 0x26, 0x27, 0x26, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x04, 0x20, 0xce, 0xa0, 0x3b, 0xe8, 0x90, 0x69, 0x52,
+	0x6a, 0x47, 0x2f, 0x79, 0xc3, 0x14, 0x6d, 0xcb, 0x7c, 0x9e, 0x02, 0x75, 0xae, 0xaa, 0xd1, 0x72,
+	0xfe, 0xb1, 0xc1, 0xaa, 0x78, 0xe5, 0xcd, 0x2f, 0x02, 0x58, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b,
+	0x0f, 0x3c, 0x54, 0xa4, 0x1a, 0x75, 0x97, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a,
+	0xb2, 0xca, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x15, 0x00, 0x19, 0x00, 0x76, 0x40, 0x0a, 0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03,
+	0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x06, 0x07, 0x01, 0x07, 0x06, 0x01,
+	0x80, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x05,
+	0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x01, 0x06, 0x85,
+	0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0b,
+	0x11, 0x11, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x08, 0x09, 0x1e, 0x2b, 0x25, 0x15, 0x06, 0x23,
+	0x20, 0x00, 0x11, 0x34, 0x00, 0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10,
+	0x23, 0x22, 0x01, 0x23, 0x01, 0x21, 0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe, 0xc5, 0x01, 0x13,
+	0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65, 0x9f, 0xa8, 0x01,
+	0x6d, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31,
+	0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x59, 0x01, 0x41, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07, 0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19,
+	0x00, 0x7d, 0x40, 0x0a, 0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x2b, 0x08, 0x01, 0x07, 0x06, 0x01, 0x06, 0x07, 0x01, 0x80, 0x00, 0x04,
+	0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x28, 0x00, 0x06, 0x07, 0x06, 0x85, 0x08, 0x01, 0x07, 0x01, 0x07, 0x85, 0x00, 0x04,
+	0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x10, 0x16, 0x16,
+	0x16, 0x19, 0x16, 0x19, 0x12, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x09, 0x09, 0x1d, 0x2b, 0x25,
+	0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00, 0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32,
+	0x01, 0x21, 0x10, 0x23, 0x22, 0x03, 0x13, 0x21, 0x01, 0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe,
+	0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65,
+	0x9f, 0xa8, 0x1c, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe,
+	0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x59, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x15, 0x00, 0x1d, 0x00, 0x84, 0x40, 0x0e, 0x1b, 0x01, 0x07, 0x06, 0x00, 0x01, 0x03, 0x02,
+	0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2c, 0x09, 0x08, 0x02,
+	0x07, 0x06, 0x01, 0x06, 0x07, 0x01, 0x80, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00,
+	0x06, 0x06, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x07, 0x06,
+	0x85, 0x09, 0x08, 0x02, 0x07, 0x01, 0x07, 0x85, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67,
+	0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x11, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d, 0x11, 0x12,
+	0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x0a, 0x09, 0x1e, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00,
+	0x11, 0x34, 0x00, 0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22,
+	0x03, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe, 0xc5,
+	0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65, 0x9f,
+	0xa8, 0xcc, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01,
+	0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x01, 0x59,
+	0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x04, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07,
+	0x05, 0xeb, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x86, 0x40, 0x0a, 0x00, 0x01,
+	0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2b, 0x00,
+	0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x0b, 0x09, 0x0a, 0x03, 0x07, 0x07, 0x06, 0x5f, 0x08,
+	0x01, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x08, 0x01, 0x06,
+	0x0b, 0x09, 0x0a, 0x03, 0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02,
+	0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x18, 0x1a, 0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a,
+	0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x12, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x0c, 0x09,
+	0x1d, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00, 0x33, 0x32, 0x12, 0x11, 0x21,
+	0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x03, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe, 0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f,
+	0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65, 0x9f, 0xa8, 0x94, 0xde, 0xc5, 0xdf, 0xf5, 0xd0, 0x3e,
+	0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01,
+	0x19, 0x01, 0x63, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x02, 0x0a,
+	0x06, 0x44, 0x00, 0x03, 0x00, 0x07, 0x00, 0x6a, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x19, 0x00,
+	0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16,
+	0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04,
+	0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02,
+	0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x09,
+	0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x13, 0x23, 0x01, 0x21, 0x94, 0x01, 0x28, 0x4e, 0xc9, 0xfe,
+	0xbf, 0x01, 0x19, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x02, 0x00, 0x46,
+	0x00, 0x00, 0x02, 0x50, 0x06, 0x44, 0x00, 0x03, 0x00, 0x07, 0x00, 0x71, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x1a, 0x05, 0x01, 0x03, 0x02, 0x00, 0x02, 0x03, 0x00, 0x80, 0x00, 0x02, 0x02, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x02, 0x03, 0x02, 0x85, 0x05, 0x01, 0x03, 0x00, 0x03, 0x85,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x17, 0x00,
+	0x02, 0x03, 0x02, 0x85, 0x05, 0x01, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07,
+	0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21,
+	0x11, 0x01, 0x13, 0x21, 0x01, 0x94, 0x01, 0x28, 0xfe, 0x8a, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x04,
+	0x4a, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xae,
+	0x00, 0x00, 0x02, 0xa1, 0x06, 0x44, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x7d, 0xb5, 0x09, 0x01, 0x03,
+	0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1b, 0x06, 0x04, 0x02, 0x03, 0x02, 0x00,
+	0x02, 0x03, 0x00, 0x80, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x02, 0x03,
+	0x02, 0x85, 0x06, 0x04, 0x02, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x04, 0x02,
+	0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b, 0x08, 0x07, 0x06, 0x05,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x13, 0x21,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0x94, 0x01, 0x28, 0xfd, 0xf2, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5,
+	0x03, 0xc5, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00,
+	0x00, 0x03, 0xff, 0xe7, 0x00, 0x00, 0x02, 0x69, 0x05, 0xeb, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x7b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x05, 0x07, 0x03, 0x03, 0x03, 0x02,
+	0x5f, 0x04, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x04, 0x01, 0x02, 0x08, 0x05,
+	0x07, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x04, 0x01, 0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00, 0x02,
+	0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59,
+	0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07,
+	0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21,
+	0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x94, 0x01, 0x28, 0xfe, 0x2b, 0xde, 0xc5,
+	0xdf, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x02, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x99, 0x06, 0x9b, 0x00, 0x1b, 0x00, 0x26, 0x00, 0x73, 0x40, 0x16, 0x0b, 0x08,
+	0x02, 0x00, 0x01, 0x1b, 0x02, 0x01, 0x03, 0x03, 0x00, 0x19, 0x01, 0x05, 0x03, 0x03, 0x4c, 0x0a,
+	0x09, 0x02, 0x01, 0x4a, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3b, 0x4d, 0x06,
+	0x01, 0x04, 0x04, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x03,
+	0x00, 0x05, 0x04, 0x03, 0x05, 0x69, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3a, 0x4d,
+	0x06, 0x01, 0x04, 0x04, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x1d,
+	0x1c, 0x22, 0x20, 0x1c, 0x26, 0x1d, 0x26, 0x24, 0x29, 0x11, 0x23, 0x07, 0x09, 0x1a, 0x2b, 0x13,
+	0x27, 0x37, 0x26, 0x23, 0x23, 0x35, 0x32, 0x17, 0x37, 0x17, 0x07, 0x04, 0x12, 0x11, 0x10, 0x00,
+	0x23, 0x22, 0x00, 0x35, 0x34, 0x00, 0x33, 0x32, 0x17, 0x26, 0x27, 0x13, 0x32, 0x36, 0x35, 0x10,
+	0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0xfc, 0x56, 0xbe, 0x88, 0x71, 0x21, 0xf3, 0xd4, 0xdc, 0x57,
+	0xac, 0x01, 0x02, 0xff, 0xfe, 0xcd, 0xf8, 0xf5, 0xfe, 0xd1, 0x01, 0x23, 0xde, 0x4f, 0x5c, 0x51,
+	0xb1, 0x81, 0x74, 0x88, 0xf6, 0x74, 0x89, 0x87, 0x04, 0x46, 0x66, 0xa1, 0x24, 0xba, 0x4b, 0xbb,
+	0x67, 0x92, 0x92, 0xfe, 0x48, 0xfe, 0xf9, 0xfe, 0xeb, 0xfe, 0xab, 0x01, 0x31, 0xf6, 0xed, 0x01,
+	0x36, 0x1a, 0x96, 0x6a, 0xfb, 0x89, 0xd0, 0xb2, 0x01, 0x57, 0xc6, 0xa8, 0xa5, 0xc6, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x04, 0x5c, 0x06, 0x4e, 0x00, 0x10, 0x00, 0x27, 0x00, 0xef,
+	0x40, 0x0a, 0x03, 0x01, 0x03, 0x00, 0x0f, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x29, 0x00, 0x0a, 0x0a, 0x06, 0x61, 0x08, 0x01, 0x06, 0x06, 0x3a, 0x4d, 0x09, 0x01,
+	0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x3b, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d,
+	0x50, 0x58, 0x40, 0x2d, 0x00, 0x0a, 0x0a, 0x06, 0x61, 0x08, 0x01, 0x06, 0x06, 0x3a, 0x4d, 0x09,
+	0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05, 0x06,
+	0x0a, 0x69, 0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x29, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x05, 0x06, 0x0a, 0x69,
+	0x00, 0x07, 0x09, 0x01, 0x05, 0x01, 0x07, 0x05, 0x6a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x19, 0x00, 0x00, 0x27, 0x25, 0x20, 0x1e, 0x1d, 0x1c, 0x1b, 0x19, 0x15,
+	0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0c, 0x09, 0x1a, 0x2b, 0x33,
+	0x11, 0x21, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x03, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x35, 0x33, 0x10, 0x23, 0x22, 0x27,
+	0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x94, 0x01, 0x28, 0xa9, 0xcc, 0x01, 0x2b, 0xfe, 0xd8, 0x33,
+	0x44, 0x78, 0x89, 0x0c, 0x94, 0xca, 0x40, 0x3e, 0x26, 0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40,
+	0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02,
+	0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x05, 0x0d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe,
+	0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x6a, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x24, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01,
+	0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0c, 0x01, 0x00,
+	0x1b, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x08, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00,
+	0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x01, 0x23, 0x01, 0x21,
+	0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70, 0x80,
+	0x81, 0x6d, 0x6d, 0x80, 0x80, 0x01, 0x36, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0x19, 0x01, 0x3b, 0x01,
+	0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6,
+	0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x63, 0x01, 0x41, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x99, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x70, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04,
+	0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x04, 0x05,
+	0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11,
+	0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x22,
+	0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26,
+	0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x13, 0x21, 0x01, 0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01,
+	0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70, 0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0x5c,
+	0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8,
+	0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04,
+	0x63, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x7b, 0xb5, 0x1d, 0x01, 0x05, 0x04, 0x01,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x26, 0x09, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05,
+	0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x23, 0x00, 0x04, 0x05, 0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18,
+	0x1f, 0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10,
+	0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x01, 0x13, 0x21,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d,
+	0xfe, 0xd3, 0xfd, 0x70, 0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0xfe, 0xf4, 0xf1, 0x01, 0x11, 0xf1,
+	0xb3, 0xc5, 0x03, 0xc5, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe,
+	0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x63,
+	0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99,
+	0x06, 0x4e, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2e, 0x00, 0xb7, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40,
+	0x2d, 0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x08, 0x01, 0x04, 0x04,
+	0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69, 0x08,
+	0x01, 0x04, 0x04, 0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x40, 0x29, 0x07, 0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69, 0x00, 0x06, 0x08,
+	0x01, 0x04, 0x01, 0x06, 0x04, 0x6a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40,
+	0x1f, 0x0d, 0x0c, 0x01, 0x00, 0x2e, 0x2c, 0x27, 0x25, 0x24, 0x23, 0x22, 0x20, 0x1c, 0x1a, 0x19,
+	0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16,
+	0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02,
+	0x16, 0x33, 0x32, 0x35, 0x33, 0x10, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x02,
+	0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70, 0x80, 0x81,
+	0x6d, 0x6d, 0x80, 0x80, 0x5e, 0x94, 0xca, 0x40, 0x3e, 0x26, 0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9,
+	0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01,
+	0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3,
+	0xb1, 0xd4, 0x04, 0x6d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10,
+	0x06, 0x2d, 0x00, 0x00, 0x00, 0x04, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99, 0x05, 0xeb, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x79, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x25, 0x0b,
+	0x07, 0x0a, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01,
+	0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x1c, 0x1c, 0x18,
+	0x18, 0x0d, 0x0c, 0x01, 0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a,
+	0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16,
+	0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70,
+	0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0xd3, 0xde, 0xc5, 0xdf, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01,
+	0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2,
+	0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x6d, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x03, 0x00, 0x68,
+	0x00, 0x25, 0x04, 0x43, 0x04, 0x7b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0xe2, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x06, 0x01,
+	0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x00, 0x06,
+	0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x06, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x00, 0x06, 0x01, 0x01,
+	0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x00, 0x06, 0x01,
+	0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02,
+	0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x02, 0x03, 0x4f, 0x59,
+	0x59, 0x59, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a,
+	0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b,
+	0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x21, 0x15, 0x01, 0xda, 0xf7, 0xf7,
+	0xf7, 0xfd, 0x97, 0x03, 0xdb, 0x03, 0x85, 0xf6, 0xf6, 0xfc, 0xa0, 0xf7, 0xf7, 0x01, 0xd5, 0xad,
+	0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99, 0x04, 0x63, 0x00, 0x13,
+	0x00, 0x1b, 0x00, 0x23, 0x00, 0x4b, 0x40, 0x48, 0x0f, 0x0c, 0x02, 0x05, 0x02, 0x22, 0x21, 0x1a,
+	0x19, 0x04, 0x04, 0x05, 0x05, 0x02, 0x02, 0x00, 0x04, 0x03, 0x4c, 0x08, 0x01, 0x05, 0x05, 0x02,
+	0x61, 0x03, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x01, 0x06, 0x02,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1d, 0x1c, 0x15, 0x14, 0x01, 0x00, 0x1c, 0x23, 0x1d, 0x23, 0x14,
+	0x1b, 0x15, 0x1b, 0x0e, 0x0d, 0x0b, 0x09, 0x04, 0x03, 0x00, 0x13, 0x01, 0x13, 0x09, 0x09, 0x16,
+	0x2b, 0x05, 0x22, 0x27, 0x07, 0x23, 0x37, 0x26, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x37, 0x33,
+	0x07, 0x16, 0x15, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x36, 0x27, 0x01, 0x16, 0x13, 0x22, 0x06,
+	0x15, 0x06, 0x17, 0x01, 0x26, 0x02, 0x6b, 0xb1, 0x7f, 0x42, 0xaf, 0x89, 0x89, 0x01, 0x2c, 0xfb,
+	0xb6, 0x81, 0x42, 0xaf, 0x8a, 0x8a, 0xfe, 0xd3, 0xfd, 0x7c, 0x8e, 0x01, 0x1a, 0xfe, 0x6a, 0x42,
+	0x65, 0x79, 0x8e, 0x01, 0x1b, 0x01, 0x96, 0x45, 0x19, 0x51, 0x51, 0xaa, 0x9b, 0xf9, 0x01, 0x06,
+	0x01, 0x38, 0x52, 0x52, 0xaa, 0x9a, 0xf8, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0x65, 0x53,
+	0xfe, 0x0b, 0x4a, 0x03, 0x0a, 0xd2, 0xb3, 0x66, 0x55, 0x01, 0xf6, 0x4a, 0x00, 0x02, 0x00, 0x88,
+	0xff, 0xe7, 0x04, 0x50, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xd6, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a,
+	0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x20, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05,
+	0x01, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00,
+	0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x11,
+	0x00, 0x00, 0x14, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x08, 0x09,
+	0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37,
+	0x11, 0x21, 0x11, 0x01, 0x23, 0x01, 0x21, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32,
+	0x45, 0x77, 0x8a, 0x01, 0x28, 0xfe, 0xdb, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0xb6, 0xcf, 0x01, 0x5b,
+	0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41, 0x00,
+	0x00, 0x02, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xde,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02,
+	0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x08, 0x01, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00,
+	0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x07,
+	0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x08,
+	0x01, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05,
+	0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14,
+	0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x35,
+	0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x01,
+	0x13, 0x21, 0x01, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32, 0x45, 0x77, 0x8a, 0x01,
+	0x28, 0xfd, 0x5d, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41,
+	0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xec,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0e, 0x16, 0x01, 0x06, 0x05, 0x0d, 0x01, 0x02, 0x01, 0x01,
+	0x01, 0x00, 0x02, 0x03, 0x4c, 0x1b, 0x40, 0x0e, 0x16, 0x01, 0x06, 0x05, 0x0d, 0x01, 0x02, 0x01,
+	0x01, 0x01, 0x04, 0x02, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x09, 0x07,
+	0x02, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x08, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x26, 0x09, 0x07, 0x02, 0x06, 0x05, 0x01, 0x05, 0x06,
+	0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x01,
+	0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05,
+	0x85, 0x09, 0x07, 0x02, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01,
+	0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12,
+	0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23,
+	0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x01, 0x13, 0x21,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32, 0x45, 0x77,
+	0x8a, 0x01, 0x28, 0xfc, 0xa2, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xb6, 0xcf, 0x01,
+	0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41,
+	0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x03, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x05, 0xeb, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0xe8, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02,
+	0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01,
+	0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x0b, 0x08, 0x0a, 0x03,
+	0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x1d, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05,
+	0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06,
+	0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1d,
+	0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14,
+	0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x35,
+	0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x01,
+	0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32,
+	0x45, 0x77, 0x8a, 0x01, 0x28, 0xfc, 0xc7, 0xde, 0xed, 0xdf, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08,
+	0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x00,
+	0x00, 0x02, 0x00, 0x19, 0xfe, 0x75, 0x04, 0x59, 0x06, 0x44, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x53,
+	0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01,
+	0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x03, 0x04, 0x03, 0x85,
+	0x05, 0x01, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d,
+	0x02, 0x4e, 0x59, 0x40, 0x0d, 0x08, 0x08, 0x08, 0x0b, 0x08, 0x0b, 0x12, 0x11, 0x12, 0x11, 0x06,
+	0x09, 0x1a, 0x2b, 0x21, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x13, 0x13, 0x21, 0x01, 0x01,
+	0xa3, 0xfe, 0x76, 0x01, 0x38, 0xfe, 0x01, 0x2e, 0xdc, 0xfd, 0x80, 0xfe, 0xd2, 0xe5, 0xf1, 0x01,
+	0x19, 0xfe, 0xbf, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x06, 0x8e, 0x01, 0x41, 0xfe,
+	0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94, 0xfe, 0x75, 0x04, 0x94, 0x06, 0x2b, 0x00, 0x0e,
+	0x00, 0x17, 0x00, 0x3a, 0x40, 0x37, 0x04, 0x01, 0x05, 0x02, 0x17, 0x0f, 0x02, 0x04, 0x05, 0x0e,
+	0x01, 0x03, 0x04, 0x03, 0x4c, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x00,
+	0x00, 0x3d, 0x00, 0x4e, 0x22, 0x23, 0x24, 0x22, 0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x21,
+	0x11, 0x21, 0x11, 0x36, 0x33, 0x32, 0x12, 0x15, 0x10, 0x00, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33,
+	0x32, 0x11, 0x10, 0x23, 0x22, 0x07, 0x01, 0xbc, 0xfe, 0xd8, 0x01, 0x28, 0x9d, 0xbc, 0xac, 0xd3,
+	0xfe, 0xef, 0xf3, 0x51, 0x83, 0x70, 0x37, 0xf6, 0xb3, 0x78, 0x72, 0xfe, 0x75, 0x07, 0xb6, 0xfd,
+	0x69, 0xcf, 0xfe, 0xd5, 0xf5, 0xfe, 0xe4, 0xfe, 0xc0, 0x19, 0xb0, 0x13, 0x01, 0x7d, 0x01, 0x61,
+	0xaf, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x19, 0xfe, 0x75, 0x04, 0x59, 0x05, 0xeb, 0x00, 0x07,
+	0x00, 0x0b, 0x00, 0x0f, 0x00, 0x5c, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x1d,
+	0x50, 0x58, 0x40, 0x1a, 0x08, 0x06, 0x07, 0x03, 0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03,
+	0x38, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40,
+	0x18, 0x05, 0x01, 0x03, 0x08, 0x06, 0x07, 0x03, 0x04, 0x00, 0x03, 0x04, 0x67, 0x01, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x59, 0x40, 0x15, 0x0c, 0x0c, 0x08, 0x08,
+	0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x08, 0x0b, 0x08, 0x0b, 0x12, 0x11, 0x12, 0x11, 0x09, 0x09,
+	0x1a, 0x2b, 0x21, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x13, 0x35, 0x33, 0x15, 0x33, 0x35,
+	0x33, 0x15, 0x01, 0xa3, 0xfe, 0x76, 0x01, 0x38, 0xfe, 0x01, 0x2e, 0xdc, 0xfd, 0x80, 0xfe, 0xd2,
+	0x59, 0xde, 0xd9, 0xdf, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x06, 0x98, 0xde, 0xde,
+	0xde, 0xde, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x19, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6a, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1e, 0x00, 0x05, 0x08, 0x01, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00,
+	0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x00, 0x06, 0x04, 0x06, 0x00, 0x04, 0x80, 0x00, 0x05, 0x08,
+	0x01, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x03,
+	0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e, 0x0b,
+	0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b,
+	0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x01, 0x35, 0x21, 0x15, 0x0c,
+	0x02, 0x3e, 0x01, 0x34, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc, 0xe6,
+	0xfe, 0xb6, 0x02, 0xe4, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e,
+	0x01, 0xce, 0xad, 0xad, 0x00, 0x03, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b, 0x05, 0xc4, 0x00, 0x1c,
+	0x00, 0x25, 0x00, 0x29, 0x00, 0xe3, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x14, 0x01, 0x03,
+	0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x04,
+	0x4c, 0x1b, 0x40, 0x17, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x07, 0x06,
+	0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x2a, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x0a, 0x01, 0x09, 0x09, 0x08,
+	0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d,
+	0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2d, 0x50, 0x58, 0x40, 0x28, 0x00, 0x08, 0x0a, 0x01, 0x09, 0x04, 0x08, 0x09, 0x67, 0x00, 0x02,
+	0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d,
+	0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x32,
+	0x00, 0x08, 0x0a, 0x01, 0x09, 0x04, 0x08, 0x09, 0x67, 0x00, 0x02, 0x00, 0x06, 0x07, 0x02, 0x06,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61,
+	0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x26, 0x26, 0x26, 0x29, 0x26, 0x29, 0x12, 0x23, 0x23, 0x13,
+	0x23, 0x22, 0x23, 0x23, 0x22, 0x0b, 0x09, 0x1f, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23,
+	0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33,
+	0x20, 0x11, 0x11, 0x14, 0x33, 0x32, 0x25, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01,
+	0x35, 0x21, 0x15, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02,
+	0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53,
+	0x40, 0x66, 0xfe, 0x4b, 0x02, 0xe4, 0xa9, 0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64,
+	0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53, 0x04, 0x67,
+	0xad, 0xad, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x16, 0x00, 0x74, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x06, 0x00, 0x08, 0x00, 0x06,
+	0x08, 0x69, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x26, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85,
+	0x00, 0x00, 0x08, 0x04, 0x08, 0x00, 0x04, 0x80, 0x00, 0x06, 0x00, 0x08, 0x00, 0x06, 0x08, 0x69,
+	0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x40, 0x16, 0x00, 0x00, 0x15, 0x13, 0x11, 0x10, 0x0f, 0x0d, 0x0c, 0x0b, 0x09, 0x08, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03,
+	0x21, 0x03, 0x13, 0x21, 0x03, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22,
+	0x26, 0x0c, 0x02, 0x3e, 0x01, 0x34, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01,
+	0xcc, 0xe6, 0xfe, 0xc6, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0x05,
+	0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x02, 0xf1, 0x8e, 0x8e, 0x93,
+	0xae, 0xad, 0x00, 0x00, 0x00, 0x03, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b, 0x06, 0x44, 0x00, 0x1c,
+	0x00, 0x25, 0x00, 0x31, 0x01, 0x2a, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x14, 0x01, 0x03,
+	0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x04,
+	0x4c, 0x1b, 0x40, 0x17, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x07, 0x06,
+	0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x2f, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x0a, 0x01, 0x08, 0x08, 0x3a,
+	0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x62, 0x01, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2f, 0x0a, 0x01, 0x08, 0x09, 0x08, 0x85,
+	0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09,
+	0x38, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05,
+	0x00, 0x62, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40,
+	0x2d, 0x0a, 0x01, 0x08, 0x09, 0x08, 0x85, 0x00, 0x09, 0x00, 0x0b, 0x04, 0x09, 0x0b, 0x69, 0x00,
+	0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41,
+	0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x62, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x37, 0x0a, 0x01, 0x08, 0x09, 0x08, 0x85, 0x00, 0x09, 0x00, 0x0b, 0x04, 0x09, 0x0b, 0x69, 0x00,
+	0x02, 0x00, 0x06, 0x07, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41,
+	0x4d, 0x00, 0x07, 0x07, 0x00, 0x62, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12, 0x30, 0x2e, 0x2c,
+	0x2b, 0x2a, 0x28, 0x11, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23, 0x22, 0x0c, 0x09, 0x1f, 0x2b,
+	0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35,
+	0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33, 0x32, 0x25, 0x35, 0x23,
+	0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23,
+	0x22, 0x26, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02, 0x0a,
+	0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53, 0x40,
+	0x66, 0xfe, 0x6c, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0xa9, 0xa6,
+	0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a,
+	0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53, 0x05, 0x94, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x0c, 0xfe, 0x8e, 0x05, 0xba, 0x05, 0xc8, 0x00, 0x14, 0x00, 0x17, 0x00, 0x93,
+	0x40, 0x13, 0x17, 0x01, 0x06, 0x00, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01, 0x03, 0x02, 0x03, 0x4c,
+	0x11, 0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x00, 0x04,
+	0x01, 0x06, 0x04, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x05, 0x02, 0x01, 0x01, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3d, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1c, 0x00, 0x06, 0x00, 0x04, 0x01, 0x06, 0x04, 0x68, 0x00, 0x02, 0x00, 0x03, 0x02,
+	0x03, 0x65, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x05, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06, 0x00, 0x04, 0x01, 0x06, 0x04, 0x68, 0x00,
+	0x02, 0x00, 0x03, 0x02, 0x03, 0x65, 0x07, 0x05, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59,
+	0x40, 0x10, 0x00, 0x00, 0x16, 0x15, 0x00, 0x14, 0x00, 0x14, 0x14, 0x23, 0x23, 0x11, 0x11, 0x08,
+	0x09, 0x1b, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x23, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06,
+	0x23, 0x20, 0x35, 0x34, 0x37, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x0c, 0x02, 0x3e, 0x01, 0x34,
+	0x02, 0x3c, 0x9d, 0xba, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9, 0xe1, 0x97, 0xfd, 0x9c, 0x97,
+	0xe3, 0x01, 0xcc, 0xe6, 0x05, 0xc8, 0xfa, 0x38, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76,
+	0x5d, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x45,
+	0xfe, 0x8e, 0x04, 0x3b, 0x04, 0x63, 0x00, 0x2c, 0x00, 0x35, 0x00, 0xea, 0x4b, 0xb0, 0x2d, 0x50,
+	0x58, 0x40, 0x1c, 0x12, 0x01, 0x02, 0x03, 0x11, 0x01, 0x01, 0x02, 0x2d, 0x1b, 0x02, 0x04, 0x08,
+	0x1c, 0x03, 0x02, 0x00, 0x04, 0x26, 0x01, 0x06, 0x00, 0x27, 0x01, 0x07, 0x06, 0x06, 0x4c, 0x1b,
+	0x40, 0x1f, 0x12, 0x01, 0x02, 0x03, 0x11, 0x01, 0x01, 0x02, 0x2d, 0x01, 0x09, 0x08, 0x1b, 0x01,
+	0x04, 0x09, 0x1c, 0x03, 0x02, 0x00, 0x04, 0x26, 0x01, 0x06, 0x00, 0x27, 0x01, 0x07, 0x06, 0x07,
+	0x4c, 0x59, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x29, 0x00, 0x01, 0x00, 0x08, 0x04, 0x01, 0x08,
+	0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x00,
+	0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x61, 0x00, 0x07, 0x07, 0x3d,
+	0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x26, 0x00, 0x01, 0x00, 0x08, 0x04, 0x01,
+	0x08, 0x69, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x30, 0x00, 0x01, 0x00, 0x08, 0x09, 0x01, 0x08, 0x69, 0x00, 0x06, 0x00, 0x07, 0x06,
+	0x07, 0x65, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x00,
+	0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x35, 0x33, 0x24, 0x23, 0x23, 0x34, 0x13, 0x23, 0x22,
+	0x23, 0x25, 0x0a, 0x09, 0x1f, 0x2b, 0x21, 0x33, 0x26, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35,
+	0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33,
+	0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x27, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23,
+	0x20, 0x35, 0x34, 0x13, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x03, 0x0e, 0x06, 0x4b,
+	0x1e, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02, 0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98,
+	0x52, 0x10, 0x18, 0x07, 0x5e, 0x47, 0x0e, 0x0d, 0x89, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9,
+	0x71, 0x46, 0xf7, 0x53, 0x40, 0x66, 0x22, 0x54, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62,
+	0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a, 0x81, 0x04, 0xa6, 0x1c, 0x01, 0x4b, 0x51, 0x5f, 0x0f, 0x51,
+	0x1d, 0x9f, 0x76, 0x01, 0x72, 0xdf, 0xb2, 0x3f, 0x53, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0x7e, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x6b, 0x40, 0x0f, 0x0b, 0x01,
+	0x02, 0x01, 0x0c, 0x00, 0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01,
+	0x05, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x14, 0x14, 0x14, 0x17, 0x14, 0x17, 0x12, 0x22,
+	0x23, 0x24, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x01, 0x15, 0x06, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x21, 0x20, 0x17, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x21, 0x32, 0x01, 0x13, 0x21, 0x01, 0x05,
+	0x7e, 0xd7, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0x66, 0x01, 0x9e, 0x01, 0x8f, 0x01, 0x03, 0xf1, 0xfe,
+	0xef, 0xc8, 0xfd, 0xff, 0x02, 0x1e, 0xeb, 0xfe, 0x1f, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x01, 0x1e,
+	0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6, 0xfd,
+	0xc8, 0x05, 0x9e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x20,
+	0x06, 0x44, 0x00, 0x13, 0x00, 0x17, 0x00, 0x70, 0x40, 0x0f, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x00,
+	0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x23,
+	0x06, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05,
+	0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x14, 0x14, 0x14, 0x17, 0x14, 0x17, 0x12,
+	0x23, 0x23, 0x23, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10,
+	0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x01, 0x13, 0x21, 0x01,
+	0x04, 0x20, 0xd4, 0xa3, 0xfe, 0xde, 0xfe, 0xc3, 0x02, 0x75, 0xae, 0xaa, 0xd1, 0x72, 0xfe, 0xb1,
+	0xc1, 0xaa, 0x78, 0xfe, 0x87, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0xe5, 0xcd, 0x31, 0x01, 0x2d, 0x01,
+	0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x04, 0x58, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x02, 0x00, 0x50, 0xff, 0xdb, 0x05, 0x7e, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x72,
+	0x40, 0x13, 0x19, 0x01, 0x05, 0x04, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x00, 0x02, 0x03, 0x02, 0x01,
+	0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x02,
+	0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x40, 0x0f, 0x14, 0x14, 0x14, 0x1b, 0x14, 0x1b, 0x11, 0x12, 0x22, 0x23, 0x24, 0x22, 0x08, 0x09,
+	0x1c, 0x2b, 0x01, 0x15, 0x06, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x17, 0x15, 0x24,
+	0x23, 0x20, 0x11, 0x10, 0x21, 0x32, 0x01, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x05, 0x7e,
+	0xd7, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0x66, 0x01, 0x9e, 0x01, 0x8f, 0x01, 0x03, 0xf1, 0xfe, 0xef,
+	0xc8, 0xfd, 0xff, 0x02, 0x1e, 0xeb, 0xfd, 0x57, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5,
+	0x01, 0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd,
+	0xc6, 0xfd, 0xc8, 0x05, 0x9e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x02, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x20, 0x06, 0x44, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x77, 0x40, 0x13, 0x19, 0x01,
+	0x05, 0x04, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x00, 0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x04,
+	0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24, 0x07, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05,
+	0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x0f, 0x14, 0x14, 0x14, 0x1b, 0x14, 0x1b, 0x11, 0x12, 0x23, 0x23, 0x23, 0x22, 0x08,
+	0x09, 0x1c, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x01, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x04,
+	0x20, 0xd4, 0xa3, 0xfe, 0xde, 0xfe, 0xc3, 0x02, 0x75, 0xae, 0xaa, 0xd1, 0x72, 0xfe, 0xb1, 0xc1,
+	0xaa, 0x78, 0xfd, 0xbf, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xe5, 0xcd, 0x31, 0x01,
+	0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x04, 0x58, 0x01, 0x41,
+	0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x02, 0x00, 0x50, 0xff, 0xdb, 0x05, 0x7e, 0x07, 0x94, 0x00, 0x13,
+	0x00, 0x17, 0x00, 0x67, 0x40, 0x0f, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x00, 0x02, 0x03, 0x02, 0x01,
+	0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x06, 0x01,
+	0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x04, 0x06,
+	0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x14, 0x14, 0x14, 0x17,
+	0x14, 0x17, 0x12, 0x22, 0x23, 0x24, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x01, 0x15, 0x06, 0x21, 0x20,
+	0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x17, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x21, 0x32, 0x01,
+	0x11, 0x21, 0x11, 0x05, 0x7e, 0xd7, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0x66, 0x01, 0x9e, 0x01, 0x8f,
+	0x01, 0x03, 0xf1, 0xfe, 0xef, 0xc8, 0xfd, 0xff, 0x02, 0x1e, 0xeb, 0xfe, 0x3e, 0x01, 0x28, 0x01,
+	0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6,
+	0xfd, 0xc8, 0x05, 0xbc, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x02, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x20,
+	0x06, 0x3f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x6b, 0x40, 0x0f, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x00,
+	0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x32, 0x50, 0x58, 0x40, 0x20,
+	0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x1e, 0x00, 0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x40, 0x0e, 0x14, 0x14, 0x14, 0x17, 0x14, 0x17, 0x12, 0x23, 0x23, 0x23, 0x22, 0x07,
+	0x09, 0x1b, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x01, 0x11, 0x21, 0x11, 0x04, 0x20, 0xd4, 0xa3, 0xfe,
+	0xde, 0xfe, 0xc3, 0x02, 0x75, 0xae, 0xaa, 0xd1, 0x72, 0xfe, 0xb1, 0xc1, 0xaa, 0x78, 0xfe, 0xa6,
+	0x01, 0x28, 0xe5, 0xcd, 0x31, 0x01, 0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a,
+	0xb2, 0xca, 0x04, 0x6c, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x02, 0x00, 0x50, 0xff, 0xdb, 0x05, 0x7e,
+	0x07, 0x8f, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x72, 0x40, 0x13, 0x19, 0x01, 0x04, 0x05, 0x0b, 0x01,
+	0x02, 0x01, 0x0c, 0x00, 0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x14, 0x14, 0x14, 0x1b, 0x14, 0x1b,
+	0x11, 0x12, 0x22, 0x23, 0x24, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x01, 0x15, 0x06, 0x21, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x21, 0x20, 0x17, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x21, 0x32, 0x13, 0x03,
+	0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x05, 0x7e, 0xd7, 0xfe, 0xc0, 0xfe, 0x83, 0xfe, 0x66, 0x01,
+	0x9e, 0x01, 0x8f, 0x01, 0x03, 0xf1, 0xfe, 0xef, 0xc8, 0xfd, 0xff, 0x02, 0x1e, 0xeb, 0x3e, 0xf1,
+	0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x01, 0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01,
+	0x7e, 0x01, 0x8b, 0x39, 0xf1, 0x5f, 0xfd, 0xc6, 0xfd, 0xc8, 0x06, 0xdf, 0xfe, 0xbf, 0x01, 0x41,
+	0xc6, 0xc6, 0x00, 0x00, 0x00, 0x02, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x20, 0x06, 0x44, 0x00, 0x13,
+	0x00, 0x1b, 0x00, 0x77, 0x40, 0x13, 0x19, 0x01, 0x04, 0x05, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x00,
+	0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24,
+	0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x07, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04,
+	0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x14, 0x14, 0x14, 0x1b, 0x14,
+	0x1b, 0x11, 0x12, 0x23, 0x23, 0x23, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20,
+	0x00, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x13,
+	0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x04, 0x20, 0xd4, 0xa3, 0xfe, 0xde, 0xfe, 0xc3, 0x02,
+	0x75, 0xae, 0xaa, 0xd1, 0x72, 0xfe, 0xb1, 0xc1, 0xaa, 0x78, 0xb2, 0xf1, 0xfe, 0xef, 0xf1, 0xb3,
+	0xc5, 0x03, 0xc5, 0xe5, 0xcd, 0x31, 0x01, 0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe,
+	0x8a, 0xb2, 0xca, 0x05, 0x99, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x03, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0x77, 0x07, 0x8f, 0x00, 0x08, 0x00, 0x11, 0x00, 0x19, 0x00, 0x6f, 0xb5, 0x17,
+	0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05,
+	0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x60, 0x07, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x20, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x00,
+	0x03, 0x02, 0x00, 0x03, 0x69, 0x00, 0x02, 0x02, 0x01, 0x60, 0x07, 0x01, 0x01, 0x01, 0x3c, 0x01,
+	0x4e, 0x59, 0x40, 0x18, 0x12, 0x12, 0x00, 0x00, 0x12, 0x19, 0x12, 0x19, 0x16, 0x15, 0x14, 0x13,
+	0x11, 0x0f, 0x0b, 0x09, 0x00, 0x08, 0x00, 0x07, 0x21, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x27, 0x33, 0x32, 0x12, 0x11, 0x34, 0x02, 0x23, 0x23, 0x01,
+	0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0xad, 0x02, 0x03, 0x01, 0x58, 0x01, 0x6f, 0xfe, 0x7c,
+	0xfe, 0xa2, 0xb4, 0x6d, 0xf3, 0xef, 0xf0, 0xd3, 0x8c, 0x02, 0x2a, 0xf1, 0xfe, 0xef, 0xf1, 0xb3,
+	0xc5, 0x03, 0xc5, 0x05, 0xc8, 0xfe, 0x93, 0xfe, 0xa8, 0xfe, 0x92, 0xfe, 0x6b, 0xd2, 0x01, 0x0d,
+	0x01, 0x12, 0xf5, 0x01, 0x17, 0x02, 0x92, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x50, 0xff, 0xe7, 0x05, 0xc1, 0x06, 0x2b, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x21,
+	0x01, 0x12, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x10, 0x1e, 0x0a, 0x02, 0x04, 0x01, 0x17, 0x0f,
+	0x02, 0x05, 0x04, 0x00, 0x01, 0x00, 0x05, 0x03, 0x4c, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x13, 0x0a, 0x01, 0x08, 0x01, 0x1e, 0x01, 0x04, 0x08, 0x17, 0x0f, 0x02, 0x05, 0x04, 0x00, 0x01,
+	0x00, 0x05, 0x04, 0x4c, 0x1b, 0x40, 0x13, 0x0a, 0x01, 0x08, 0x01, 0x1e, 0x01, 0x04, 0x08, 0x17,
+	0x0f, 0x02, 0x05, 0x04, 0x00, 0x01, 0x03, 0x05, 0x04, 0x4c, 0x59, 0x59, 0x4b, 0xb0, 0x10, 0x50,
+	0x58, 0x40, 0x22, 0x00, 0x06, 0x06, 0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x04,
+	0x04, 0x01, 0x61, 0x08, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x26, 0x00, 0x06, 0x06,
+	0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x06, 0x06, 0x02, 0x5f,
+	0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x06, 0x06, 0x02, 0x5f, 0x07, 0x01, 0x02,
+	0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0c, 0x14, 0x11, 0x12, 0x22, 0x22, 0x11, 0x12, 0x24, 0x21,
+	0x09, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x26, 0x23, 0x22, 0x11, 0x10, 0x33, 0x32, 0x37, 0x01, 0x23, 0x11, 0x21,
+	0x15, 0x10, 0x05, 0x35, 0x32, 0x35, 0x03, 0x27, 0x9c, 0xbc, 0xac, 0xd3, 0x01, 0x11, 0xf3, 0x51,
+	0x82, 0x01, 0x28, 0xfe, 0xd8, 0x6f, 0x37, 0xf6, 0xb3, 0x78, 0x71, 0x02, 0x09, 0x72, 0x01, 0x03,
+	0xfe, 0xfd, 0x72, 0xb6, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19, 0x01, 0xe1, 0xf9,
+	0xd5, 0x03, 0x9a, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x03, 0x85, 0x01, 0x28, 0xe5, 0xfe, 0xaa,
+	0x15, 0x66, 0xa5, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x05, 0x77, 0x05, 0xc8, 0x00, 0x0c,
+	0x00, 0x19, 0x00, 0x60, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x07, 0x01,
+	0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00,
+	0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02,
+	0x00, 0x05, 0x01, 0x02, 0x05, 0x69, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67,
+	0x00, 0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x00,
+	0x00, 0x19, 0x18, 0x17, 0x16, 0x15, 0x13, 0x0f, 0x0d, 0x00, 0x0c, 0x00, 0x0b, 0x21, 0x11, 0x11,
+	0x09, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x23, 0x35, 0x33, 0x11, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x21, 0x27, 0x33, 0x32, 0x12, 0x11, 0x34, 0x02, 0x23, 0x23, 0x11, 0x33, 0x15, 0x23, 0xad, 0xad,
+	0xad, 0x02, 0x03, 0x01, 0x58, 0x01, 0x6f, 0xfe, 0x7c, 0xfe, 0xa2, 0xb4, 0x6d, 0xf3, 0xef, 0xf0,
+	0xd3, 0x8c, 0xd2, 0xd2, 0x02, 0x9d, 0xad, 0x02, 0x7e, 0xfe, 0x93, 0xfe, 0xa8, 0xfe, 0x92, 0xfe,
+	0x6b, 0xd2, 0x01, 0x0d, 0x01, 0x12, 0xf5, 0x01, 0x17, 0xfe, 0x4d, 0xad, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xe7, 0x04, 0xe3, 0x06, 0x2b, 0x00, 0x16, 0x00, 0x1f, 0x00, 0xad, 0x40, 0x0f, 0x0c, 0x01,
+	0x08, 0x02, 0x1f, 0x17, 0x02, 0x09, 0x08, 0x02, 0x01, 0x00, 0x09, 0x03, 0x4c, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x25, 0x06, 0x01, 0x04, 0x07, 0x01, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x05,
+	0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x09, 0x09,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x29, 0x06, 0x01, 0x04, 0x07, 0x01, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x05, 0x05, 0x3a, 0x4d,
+	0x00, 0x08, 0x08, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x39, 0x4d, 0x00,
+	0x09, 0x09, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b, 0x40, 0x29, 0x06, 0x01, 0x04,
+	0x07, 0x01, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x1e, 0x1c, 0x22, 0x11, 0x11, 0x11,
+	0x11, 0x12, 0x24, 0x22, 0x10, 0x0a, 0x09, 0x1f, 0x2b, 0x21, 0x21, 0x35, 0x06, 0x23, 0x22, 0x02,
+	0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x35, 0x21, 0x35, 0x21, 0x35, 0x21, 0x15, 0x33, 0x15, 0x23,
+	0x01, 0x26, 0x23, 0x22, 0x11, 0x10, 0x33, 0x32, 0x37, 0x04, 0x4f, 0xfe, 0xd8, 0x9c, 0xbc, 0xac,
+	0xd3, 0x01, 0x11, 0xf3, 0x51, 0x82, 0xfe, 0xfd, 0x01, 0x03, 0x01, 0x28, 0x94, 0x94, 0xfe, 0xd8,
+	0x6f, 0x37, 0xf6, 0xb3, 0x78, 0x71, 0xb6, 0xcf, 0x01, 0x2b, 0xf5, 0x01, 0x1c, 0x01, 0x40, 0x19,
+	0x88, 0xac, 0xad, 0xad, 0xac, 0xfe, 0xc8, 0x13, 0xfe, 0x83, 0xfe, 0x9f, 0xaf, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x07, 0x19, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x70,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67,
+	0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40,
+	0x25, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08,
+	0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c,
+	0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b,
+	0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15,
+	0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0xfc, 0x58, 0x02, 0xe4, 0x05,
+	0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x6c, 0xad, 0xad, 0x00, 0x03, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x07, 0x05, 0xc4, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x78, 0x40, 0x0a,
+	0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x28, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x08, 0x01, 0x07, 0x07, 0x06, 0x5f, 0x00,
+	0x06, 0x06, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x06, 0x08, 0x01,
+	0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x10, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x12, 0x21, 0x11, 0x21, 0x12,
+	0x24, 0x22, 0x09, 0x09, 0x1d, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00, 0x33,
+	0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x03, 0x35, 0x21, 0x15,
+	0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe, 0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f,
+	0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65, 0x9f, 0xa8, 0xc5, 0x02, 0xe4, 0xf5, 0xd0, 0x3e, 0x01,
+	0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19,
+	0x01, 0x6d, 0xad, 0xad, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x7a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2c, 0x08, 0x01, 0x06, 0x07, 0x06,
+	0x85, 0x00, 0x07, 0x00, 0x09, 0x00, 0x07, 0x09, 0x69, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85,
+	0x00, 0x07, 0x00, 0x09, 0x00, 0x07, 0x09, 0x69, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68,
+	0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05,
+	0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x16, 0x14, 0x12, 0x11, 0x10, 0x0e, 0x0d,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x11,
+	0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37,
+	0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03,
+	0x39, 0xfc, 0x65, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0x05, 0xc8,
+	0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x07, 0x8f, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07, 0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x21,
+	0x00, 0xb6, 0x40, 0x0a, 0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x68, 0x08, 0x01, 0x06,
+	0x06, 0x3a, 0x4d, 0x00, 0x09, 0x09, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2d, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85,
+	0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x68, 0x00, 0x09, 0x09, 0x07, 0x61, 0x00, 0x07, 0x07,
+	0x38, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2b, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85,
+	0x00, 0x07, 0x00, 0x09, 0x01, 0x07, 0x09, 0x69, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x68,
+	0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x20, 0x1e, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x12, 0x24, 0x22, 0x0a, 0x09, 0x1f, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34,
+	0x00, 0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x03, 0x33,
+	0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed,
+	0xfe, 0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01,
+	0x65, 0x9f, 0xa8, 0xb4, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0xf5,
+	0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01,
+	0xe1, 0x01, 0x19, 0x02, 0x9a, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0x1a, 0x07, 0x94, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x70, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00, 0x03,
+	0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09,
+	0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c,
+	0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x11, 0x21, 0x11, 0xad, 0x04, 0x3e, 0xfc,
+	0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0xfd, 0x2a, 0x01, 0x28, 0x05, 0xc8, 0xcb, 0xfe, 0x63,
+	0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x6c, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x07, 0x06, 0x3f, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x78, 0x40, 0x0a,
+	0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x32, 0x50, 0x58, 0x40,
+	0x28, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x08, 0x01, 0x07, 0x07, 0x06, 0x5f, 0x00,
+	0x06, 0x06, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x06, 0x08, 0x01,
+	0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x10, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x12, 0x21, 0x11, 0x21, 0x12,
+	0x24, 0x22, 0x09, 0x09, 0x1d, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00, 0x33,
+	0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x13, 0x11, 0x21, 0x11,
+	0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe, 0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f,
+	0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65, 0x9f, 0xa8, 0x19, 0x01, 0x28, 0xf5, 0xd0, 0x3e, 0x01,
+	0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19,
+	0x01, 0x6d, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0xfe, 0x8e, 0x05, 0x1a,
+	0x05, 0xc8, 0x00, 0x19, 0x00, 0xa7, 0x40, 0x0a, 0x12, 0x01, 0x06, 0x05, 0x13, 0x01, 0x07, 0x06,
+	0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x29, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x61, 0x00, 0x07, 0x07, 0x3d,
+	0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e,
+	0x1b, 0x40, 0x24, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04,
+	0x02, 0x03, 0x67, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09,
+	0x08, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x19, 0x00,
+	0x19, 0x23, 0x23, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33, 0x11, 0x21,
+	0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x23, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37,
+	0x15, 0x06, 0x23, 0x20, 0x35, 0x34, 0x37, 0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65,
+	0x03, 0x39, 0xa1, 0xba, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9, 0xe1, 0x05, 0xc8, 0xcb, 0xfe,
+	0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x5d, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x4a, 0xfe, 0x8e, 0x04, 0x07, 0x04, 0x63, 0x00, 0x1e, 0x00, 0x23, 0x00, 0x78,
+	0x40, 0x12, 0x00, 0x01, 0x05, 0x04, 0x01, 0x01, 0x02, 0x05, 0x09, 0x01, 0x00, 0x02, 0x0a, 0x01,
+	0x01, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x00, 0x04, 0x05,
+	0x06, 0x04, 0x67, 0x00, 0x07, 0x07, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3d,
+	0x01, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x06, 0x00, 0x04, 0x05, 0x06, 0x04, 0x67, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x65, 0x00, 0x07, 0x07, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05,
+	0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x21, 0x11, 0x21, 0x12,
+	0x25, 0x13, 0x23, 0x26, 0x08, 0x09, 0x1e, 0x2b, 0x25, 0x15, 0x06, 0x07, 0x06, 0x15, 0x14, 0x33,
+	0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x35, 0x34, 0x37, 0x24, 0x27, 0x26, 0x11, 0x34, 0x00, 0x33,
+	0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x04, 0x07, 0x61, 0x61,
+	0xa2, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9, 0xa9, 0xfe, 0xf9, 0x99, 0x9d, 0x01, 0x13, 0xe4,
+	0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65, 0x9f, 0xa8, 0xf5, 0xd0,
+	0x21, 0x0f, 0x51, 0x58, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x67, 0x53, 0x05, 0x98, 0x9e, 0x01, 0x12,
+	0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x7f,
+	0xb5, 0x11, 0x01, 0x06, 0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x0a, 0x08,
+	0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x09, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x0a, 0x08, 0x02, 0x07, 0x06,
+	0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00,
+	0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05,
+	0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f,
+	0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33,
+	0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x03, 0x03, 0x21, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0xd3, 0xf1,
+	0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2,
+	0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x84, 0x40, 0x0e, 0x1b, 0x01, 0x06, 0x07,
+	0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x2c, 0x00, 0x06, 0x07, 0x01, 0x07, 0x06, 0x01, 0x80, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02,
+	0x68, 0x09, 0x08, 0x02, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29,
+	0x09, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x01, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02,
+	0x03, 0x04, 0x02, 0x68, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x11, 0x16, 0x16, 0x16, 0x1d,
+	0x16, 0x1d, 0x11, 0x12, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x0a, 0x09, 0x1e, 0x2b, 0x25, 0x15,
+	0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00, 0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01,
+	0x21, 0x10, 0x23, 0x22, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x04, 0x07, 0xb7, 0xb8,
+	0xfe, 0xed, 0xfe, 0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe,
+	0x27, 0x01, 0x65, 0x9f, 0xa8, 0x02, 0x27, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xf5,
+	0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01,
+	0xe1, 0x01, 0x19, 0x02, 0x9a, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xa5, 0x07, 0x8f, 0x00, 0x1d, 0x00, 0x25, 0x00, 0x90, 0x40, 0x16, 0x23, 0x01,
+	0x07, 0x06, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x05, 0x02, 0x1a, 0x01, 0x03, 0x04, 0x01, 0x01,
+	0x00, 0x03, 0x05, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x06, 0x07, 0x06, 0x85,
+	0x0a, 0x08, 0x02, 0x07, 0x01, 0x07, 0x85, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02,
+	0x07, 0x01, 0x07, 0x85, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x6a, 0x09, 0x01, 0x05, 0x00,
+	0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x18, 0x1e, 0x1e, 0x00, 0x00, 0x1e, 0x25, 0x1e, 0x25, 0x22, 0x21, 0x20, 0x1f, 0x00,
+	0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22, 0x0b, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x23,
+	0x22, 0x24, 0x27, 0x26, 0x11, 0x10, 0x37, 0x36, 0x24, 0x33, 0x20, 0x17, 0x15, 0x24, 0x23, 0x22,
+	0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23, 0x35, 0x01, 0x13, 0x21, 0x13, 0x23, 0x27,
+	0x23, 0x07, 0x05, 0xa5, 0xfe, 0xe7, 0xe8, 0xf9, 0xfe, 0xd9, 0x6c, 0xc8, 0xbb, 0x6c, 0x01, 0x28,
+	0xf2, 0x01, 0x22, 0xf1, 0xfe, 0xd0, 0xdf, 0xfa, 0xfe, 0xfc, 0x01, 0x17, 0x01, 0x04, 0x47, 0x78,
+	0xfa, 0xfe, 0x84, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x02, 0xcf, 0xfd, 0x54, 0x48,
+	0x5e, 0x72, 0xd4, 0x01, 0x67, 0x01, 0x58, 0xd1, 0x79, 0x65, 0x39, 0xf1, 0x5f, 0xfe, 0xdb, 0xfe,
+	0xe6, 0xfe, 0xee, 0xfe, 0xda, 0x0e, 0x01, 0x4b, 0xcb, 0x03, 0x7f, 0x01, 0x41, 0xfe, 0xbf, 0xc5,
+	0xc5, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x50, 0xfe, 0x5c, 0x04, 0x4f, 0x06, 0x44, 0x00, 0x08,
+	0x00, 0x22, 0x00, 0x2a, 0x01, 0x0d, 0x40, 0x17, 0x28, 0x01, 0x08, 0x07, 0x08, 0x00, 0x02, 0x01,
+	0x00, 0x09, 0x01, 0x02, 0x01, 0x1d, 0x01, 0x06, 0x02, 0x1c, 0x01, 0x05, 0x06, 0x05, 0x4c, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x2f, 0x0a, 0x09, 0x02, 0x08, 0x07, 0x03, 0x07, 0x08, 0x03, 0x80,
+	0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00,
+	0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x33, 0x0a, 0x09, 0x02,
+	0x08, 0x07, 0x03, 0x07, 0x08, 0x03, 0x80, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x30, 0x00, 0x07, 0x08, 0x07, 0x85, 0x0a, 0x09, 0x02,
+	0x08, 0x03, 0x08, 0x85, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x30, 0x00, 0x07, 0x08, 0x07, 0x85,
+	0x0a, 0x09, 0x02, 0x08, 0x03, 0x08, 0x85, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12,
+	0x23, 0x23, 0x23, 0x2a, 0x23, 0x2a, 0x11, 0x14, 0x23, 0x25, 0x11, 0x24, 0x23, 0x22, 0x21, 0x0b,
+	0x09, 0x1f, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x11, 0x10, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22,
+	0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27,
+	0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x01, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x27,
+	0x6f, 0x37, 0xf6, 0xb3, 0x78, 0x71, 0x9c, 0xbc, 0xaa, 0xd5, 0x01, 0x14, 0xf0, 0x51, 0x82, 0x01,
+	0x28, 0x3c, 0x59, 0x94, 0xfe, 0xf4, 0xc1, 0xdd, 0xd9, 0x9d, 0xa3, 0x92, 0xfd, 0xd3, 0xf1, 0x01,
+	0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x03, 0x9a, 0x13, 0xfe, 0x8e, 0xfe, 0xac, 0xb0, 0xc8, 0xcf,
+	0x01, 0x28, 0xec, 0x01, 0x12, 0x01, 0x3d, 0x19, 0xfc, 0xba, 0xfb, 0xde, 0x4e, 0x81, 0x4f, 0xda,
+	0x57, 0x8c, 0x9d, 0x04, 0xac, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xa5, 0x07, 0x8f, 0x00, 0x1d, 0x00, 0x29, 0x00, 0x8e, 0x40, 0x12, 0x0f, 0x01,
+	0x02, 0x01, 0x10, 0x01, 0x05, 0x02, 0x1a, 0x01, 0x03, 0x04, 0x01, 0x01, 0x00, 0x03, 0x04, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2c, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00,
+	0x09, 0x01, 0x07, 0x09, 0x69, 0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09,
+	0x01, 0x07, 0x09, 0x69, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x6a, 0x0a, 0x01, 0x05, 0x00,
+	0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x16, 0x00, 0x00, 0x28, 0x26, 0x24, 0x23, 0x22, 0x20, 0x1f, 0x1e, 0x00, 0x1d, 0x00,
+	0x1d, 0x12, 0x24, 0x23, 0x28, 0x22, 0x0b, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x23, 0x22, 0x24,
+	0x27, 0x26, 0x11, 0x10, 0x37, 0x36, 0x24, 0x33, 0x20, 0x17, 0x15, 0x24, 0x23, 0x22, 0x00, 0x11,
+	0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23, 0x35, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06,
+	0x06, 0x23, 0x22, 0x26, 0x05, 0xa5, 0xfe, 0xe7, 0xe8, 0xf9, 0xfe, 0xd9, 0x6c, 0xc8, 0xbb, 0x6c,
+	0x01, 0x28, 0xf2, 0x01, 0x22, 0xf1, 0xfe, 0xd0, 0xdf, 0xfa, 0xfe, 0xfc, 0x01, 0x17, 0x01, 0x04,
+	0x47, 0x78, 0xfa, 0xfe, 0x91, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0,
+	0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e, 0x72, 0xd4, 0x01, 0x67, 0x01, 0x58, 0xd1, 0x79, 0x65, 0x39,
+	0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6, 0xfe, 0xee, 0xfe, 0xda, 0x0e, 0x01, 0x4b, 0xcb, 0x04, 0xc0,
+	0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x50, 0xfe, 0x5c, 0x04, 0x4f,
+	0x06, 0x44, 0x00, 0x08, 0x00, 0x22, 0x00, 0x2e, 0x01, 0x0f, 0x40, 0x13, 0x08, 0x00, 0x02, 0x01,
+	0x00, 0x09, 0x01, 0x02, 0x01, 0x1d, 0x01, 0x06, 0x02, 0x1c, 0x01, 0x05, 0x06, 0x04, 0x4c, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x30, 0x09, 0x01, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x0a, 0x0a, 0x08,
+	0x61, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x34, 0x09, 0x01,
+	0x07, 0x07, 0x3a, 0x4d, 0x00, 0x0a, 0x0a, 0x08, 0x61, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x04,
+	0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43,
+	0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x34, 0x09, 0x01, 0x07, 0x08, 0x07, 0x85,
+	0x00, 0x0a, 0x0a, 0x08, 0x61, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40,
+	0x32, 0x09, 0x01, 0x07, 0x08, 0x07, 0x85, 0x00, 0x08, 0x00, 0x0a, 0x03, 0x08, 0x0a, 0x69, 0x00,
+	0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x43, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x10, 0x2d, 0x2b, 0x29, 0x28, 0x21, 0x13, 0x23, 0x25,
+	0x11, 0x24, 0x23, 0x22, 0x21, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x11, 0x10, 0x33,
+	0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x21, 0x11, 0x14,
+	0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x01, 0x33, 0x16, 0x33,
+	0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x27, 0x6f, 0x37, 0xf6, 0xb3, 0x78, 0x71,
+	0x9c, 0xbc, 0xaa, 0xd5, 0x01, 0x14, 0xf0, 0x51, 0x82, 0x01, 0x28, 0x3c, 0x59, 0x94, 0xfe, 0xf4,
+	0xc1, 0xdd, 0xd9, 0x9d, 0xa3, 0x92, 0xfd, 0xe3, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0,
+	0x91, 0x91, 0xc0, 0x03, 0x9a, 0x13, 0xfe, 0x8e, 0xfe, 0xac, 0xb0, 0xc8, 0xcf, 0x01, 0x28, 0xec,
+	0x01, 0x12, 0x01, 0x3d, 0x19, 0xfc, 0xba, 0xfb, 0xde, 0x4e, 0x81, 0x4f, 0xda, 0x57, 0x8c, 0x9d,
+	0x05, 0xed, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x02, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xa5,
+	0x07, 0x94, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x84, 0x40, 0x12, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01,
+	0x05, 0x02, 0x1a, 0x01, 0x03, 0x04, 0x01, 0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x01, 0x06, 0x07, 0x67, 0x08, 0x01, 0x05, 0x00,
+	0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09,
+	0x01, 0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x08, 0x01,
+	0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x16, 0x1e, 0x1e, 0x00, 0x00, 0x1e, 0x21, 0x1e, 0x21, 0x20, 0x1f, 0x00,
+	0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22, 0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x23,
+	0x22, 0x24, 0x27, 0x26, 0x11, 0x10, 0x37, 0x36, 0x24, 0x33, 0x20, 0x17, 0x15, 0x24, 0x23, 0x22,
+	0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23, 0x35, 0x03, 0x11, 0x21, 0x11, 0x05, 0xa5,
+	0xfe, 0xe7, 0xe8, 0xf9, 0xfe, 0xd9, 0x6c, 0xc8, 0xbb, 0x6c, 0x01, 0x28, 0xf2, 0x01, 0x22, 0xf1,
+	0xfe, 0xd0, 0xdf, 0xfa, 0xfe, 0xfc, 0x01, 0x17, 0x01, 0x04, 0x47, 0x78, 0xfa, 0x89, 0x01, 0x28,
+	0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e, 0x72, 0xd4, 0x01, 0x67, 0x01, 0x58, 0xd1, 0x79, 0x65, 0x39,
+	0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6, 0xfe, 0xee, 0xfe, 0xda, 0x0e, 0x01, 0x4b, 0xcb, 0x03, 0x9d,
+	0x01, 0x28, 0xfe, 0xd8, 0x00, 0x03, 0x00, 0x50, 0xfe, 0x5c, 0x04, 0x4f, 0x06, 0x3f, 0x00, 0x08,
+	0x00, 0x22, 0x00, 0x26, 0x00, 0xfc, 0x40, 0x13, 0x08, 0x00, 0x02, 0x01, 0x00, 0x09, 0x01, 0x02,
+	0x01, 0x1d, 0x01, 0x06, 0x02, 0x1c, 0x01, 0x05, 0x06, 0x04, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x2b, 0x09, 0x01, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x2f, 0x09, 0x01, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d,
+	0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x32, 0x50, 0x58, 0x40, 0x2f, 0x09, 0x01, 0x08, 0x08,
+	0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x2d, 0x00, 0x07,
+	0x09, 0x01, 0x08, 0x03, 0x07, 0x08, 0x67, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x11,
+	0x23, 0x23, 0x23, 0x26, 0x23, 0x26, 0x14, 0x23, 0x25, 0x11, 0x24, 0x23, 0x22, 0x21, 0x0a, 0x09,
+	0x1e, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x11, 0x10, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x02,
+	0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x35,
+	0x16, 0x33, 0x32, 0x36, 0x35, 0x01, 0x11, 0x21, 0x11, 0x03, 0x27, 0x6f, 0x37, 0xf6, 0xb3, 0x78,
+	0x71, 0x9c, 0xbc, 0xaa, 0xd5, 0x01, 0x14, 0xf0, 0x51, 0x82, 0x01, 0x28, 0x3c, 0x59, 0x94, 0xfe,
+	0xf4, 0xc1, 0xdd, 0xd9, 0x9d, 0xa3, 0x92, 0xfe, 0xd8, 0x01, 0x28, 0x03, 0x9a, 0x13, 0xfe, 0x8e,
+	0xfe, 0xac, 0xb0, 0xc8, 0xcf, 0x01, 0x28, 0xec, 0x01, 0x12, 0x01, 0x3d, 0x19, 0xfc, 0xba, 0xfb,
+	0xde, 0x4e, 0x81, 0x4f, 0xda, 0x57, 0x8c, 0x9d, 0x04, 0xc0, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x50, 0xfe, 0x50, 0x05, 0xa5, 0x05, 0xed, 0x00, 0x1d, 0x00, 0x2c, 0x00, 0x9e,
+	0x40, 0x1a, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x05, 0x02, 0x1a, 0x01, 0x03, 0x04, 0x01, 0x01,
+	0x00, 0x03, 0x26, 0x01, 0x08, 0x09, 0x25, 0x01, 0x07, 0x08, 0x06, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x30, 0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x06, 0x00, 0x09,
+	0x08, 0x06, 0x09, 0x69, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07,
+	0x43, 0x07, 0x4e, 0x1b, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x0a, 0x01,
+	0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x69, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07,
+	0x07, 0x43, 0x07, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x2c, 0x2b, 0x29, 0x27, 0x24, 0x22, 0x1f,
+	0x1e, 0x00, 0x1d, 0x00, 0x1d, 0x12, 0x24, 0x23, 0x28, 0x22, 0x0b, 0x09, 0x1b, 0x2b, 0x01, 0x11,
+	0x04, 0x23, 0x22, 0x24, 0x27, 0x26, 0x11, 0x10, 0x37, 0x36, 0x24, 0x33, 0x20, 0x17, 0x15, 0x24,
+	0x23, 0x22, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23, 0x35, 0x03, 0x20, 0x15, 0x14,
+	0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x05, 0xa5, 0xfe, 0xe7, 0xe8,
+	0xf9, 0xfe, 0xd9, 0x6c, 0xc8, 0xbb, 0x6c, 0x01, 0x28, 0xf2, 0x01, 0x22, 0xf1, 0xfe, 0xd0, 0xdf,
+	0xfa, 0xfe, 0xfc, 0x01, 0x17, 0x01, 0x04, 0x47, 0x78, 0xfa, 0xba, 0x01, 0x6b, 0x8d, 0x64, 0x52,
+	0x72, 0x42, 0x2d, 0x80, 0xa5, 0x02, 0xcf, 0xfd, 0x54, 0x48, 0x5e, 0x72, 0xd4, 0x01, 0x67, 0x01,
+	0x58, 0xd1, 0x79, 0x65, 0x39, 0xf1, 0x5f, 0xfe, 0xdb, 0xfe, 0xe6, 0xfe, 0xee, 0xfe, 0xda, 0x0e,
+	0x01, 0x4b, 0xcb, 0xfc, 0xd0, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x50, 0xfe, 0x5c, 0x04, 0x4f, 0x07, 0x68, 0x00, 0x08, 0x00, 0x22, 0x00, 0x2c,
+	0x01, 0x17, 0x40, 0x13, 0x08, 0x00, 0x02, 0x01, 0x00, 0x09, 0x01, 0x02, 0x01, 0x1d, 0x01, 0x06,
+	0x02, 0x1c, 0x01, 0x05, 0x06, 0x04, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x32, 0x00, 0x09,
+	0x00, 0x0a, 0x07, 0x09, 0x0a, 0x69, 0x00, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x36, 0x00, 0x09, 0x00, 0x0a, 0x07, 0x09, 0x0a, 0x69,
+	0x00, 0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x32, 0x50, 0x58, 0x40, 0x36, 0x00, 0x09, 0x00, 0x0a, 0x07, 0x09, 0x0a, 0x69, 0x00, 0x08,
+	0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x34, 0x00,
+	0x09, 0x00, 0x0a, 0x07, 0x09, 0x0a, 0x69, 0x00, 0x07, 0x00, 0x08, 0x03, 0x07, 0x08, 0x67, 0x00,
+	0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x43, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x10, 0x2b, 0x2a, 0x29, 0x28, 0x11, 0x13, 0x23, 0x25,
+	0x11, 0x24, 0x23, 0x22, 0x21, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x11, 0x10, 0x33,
+	0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x21, 0x11, 0x14,
+	0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x03, 0x33, 0x11, 0x21,
+	0x35, 0x10, 0x21, 0x15, 0x22, 0x15, 0x03, 0x27, 0x6f, 0x37, 0xf6, 0xb3, 0x78, 0x71, 0x9c, 0xbc,
+	0xaa, 0xd5, 0x01, 0x14, 0xf0, 0x51, 0x82, 0x01, 0x28, 0x3c, 0x59, 0x94, 0xfe, 0xf4, 0xc1, 0xdd,
+	0xd9, 0x9d, 0xa3, 0x92, 0x72, 0x72, 0xfe, 0xd8, 0x01, 0x28, 0x72, 0x03, 0x9a, 0x13, 0xfe, 0x8e,
+	0xfe, 0xac, 0xb0, 0xc8, 0xcf, 0x01, 0x28, 0xec, 0x01, 0x12, 0x01, 0x3d, 0x19, 0xfc, 0xba, 0xfb,
+	0xde, 0x4e, 0x81, 0x4f, 0xda, 0x57, 0x8c, 0x9d, 0x05, 0xe8, 0xfe, 0xd8, 0xe6, 0x01, 0x6b, 0x67,
+	0xa4, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x71, 0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x01,
+	0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x05, 0x02, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07,
+	0x00, 0x07, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x03,
+	0x5f, 0x09, 0x05, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00,
+	0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21,
+	0x11, 0x03, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0xad, 0x01, 0x34, 0x02, 0x05, 0x01, 0x34,
+	0xfe, 0xcc, 0xfd, 0xfb, 0x77, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x05, 0xc8, 0xfd,
+	0xa7, 0x02, 0x59, 0xfa, 0x38, 0x02, 0xa3, 0xfd, 0x5d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5,
+	0xc5, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x04, 0x5c, 0x07, 0xcf, 0x00, 0x10,
+	0x00, 0x18, 0x00, 0x7b, 0x40, 0x0e, 0x16, 0x01, 0x06, 0x05, 0x03, 0x01, 0x03, 0x01, 0x0f, 0x01,
+	0x02, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85,
+	0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40,
+	0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00,
+	0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02,
+	0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18, 0x15,
+	0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x33,
+	0x11, 0x21, 0x11, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x03, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x94, 0x01, 0x28, 0xa9, 0xcc, 0x01, 0x2b, 0xfe,
+	0xd8, 0x33, 0x44, 0x78, 0x89, 0xcb, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x06, 0x2b,
+	0xfd, 0x69, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x06, 0x8e,
+	0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x19, 0x00, 0x00, 0x05, 0xae,
+	0x05, 0xc8, 0x00, 0x03, 0x00, 0x17, 0x00, 0x68, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x07,
+	0x05, 0x02, 0x03, 0x08, 0x02, 0x02, 0x01, 0x00, 0x03, 0x01, 0x67, 0x00, 0x00, 0x00, 0x0a, 0x09,
+	0x00, 0x0a, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0c, 0x0b, 0x02, 0x09, 0x09, 0x39, 0x09,
+	0x4e, 0x1b, 0x40, 0x22, 0x07, 0x05, 0x02, 0x03, 0x08, 0x02, 0x02, 0x01, 0x00, 0x03, 0x01, 0x67,
+	0x00, 0x00, 0x00, 0x0a, 0x09, 0x00, 0x0a, 0x67, 0x06, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c, 0x0b,
+	0x02, 0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40, 0x16, 0x04, 0x04, 0x04, 0x17, 0x04, 0x17, 0x16,
+	0x15, 0x14, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x12, 0x11, 0x10, 0x0d, 0x09, 0x1f, 0x2b,
+	0x01, 0x21, 0x35, 0x21, 0x01, 0x11, 0x23, 0x35, 0x33, 0x35, 0x21, 0x15, 0x21, 0x35, 0x21, 0x15,
+	0x33, 0x15, 0x23, 0x11, 0x21, 0x11, 0x21, 0x11, 0x01, 0xe1, 0x02, 0x05, 0xfd, 0xfb, 0xfe, 0xcc,
+	0x94, 0x94, 0x01, 0x34, 0x02, 0x05, 0x01, 0x34, 0x94, 0x94, 0xfe, 0xcc, 0xfd, 0xfb, 0x03, 0x6f,
+	0xdb, 0xfb, 0xb6, 0x04, 0x4a, 0x94, 0xea, 0xea, 0xea, 0xea, 0x94, 0xfb, 0xb6, 0x02, 0xa3, 0xfd,
+	0x5d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x19, 0x00, 0x00, 0x04, 0x5c, 0x06, 0x2b, 0x00, 0x18,
+	0x00, 0x6d, 0x40, 0x0a, 0x0b, 0x01, 0x07, 0x05, 0x17, 0x01, 0x06, 0x07, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x21, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00,
+	0x02, 0x02, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x09, 0x08,
+	0x02, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x21, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05,
+	0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x41, 0x4d, 0x09, 0x08, 0x02, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00,
+	0x18, 0x00, 0x18, 0x23, 0x12, 0x22, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33,
+	0x11, 0x23, 0x35, 0x33, 0x35, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x36, 0x33, 0x20, 0x11, 0x11,
+	0x21, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x94, 0x7b, 0x7b, 0x01, 0x28, 0x01, 0x28, 0xfe,
+	0xd8, 0xa9, 0xcc, 0x01, 0x2b, 0xfe, 0xd8, 0x33, 0x44, 0x78, 0x89, 0x04, 0xea, 0x94, 0xad, 0xad,
+	0x94, 0xfe, 0xaa, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x03, 0x3c, 0x07, 0xa3, 0x00, 0x16, 0x00, 0x22, 0x00, 0x76,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a, 0x03, 0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x69,
+	0x00, 0x02, 0x04, 0x01, 0x00, 0x08, 0x02, 0x00, 0x6a, 0x09, 0x01, 0x07, 0x07, 0x08, 0x5f, 0x00,
+	0x08, 0x08, 0x38, 0x4d, 0x0a, 0x01, 0x06, 0x06, 0x0b, 0x5f, 0x0c, 0x01, 0x0b, 0x0b, 0x39, 0x0b,
+	0x4e, 0x1b, 0x40, 0x28, 0x03, 0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x69, 0x00, 0x02, 0x04,
+	0x01, 0x00, 0x08, 0x02, 0x00, 0x6a, 0x00, 0x08, 0x09, 0x01, 0x07, 0x06, 0x08, 0x07, 0x67, 0x0a,
+	0x01, 0x06, 0x06, 0x0b, 0x5f, 0x0c, 0x01, 0x0b, 0x0b, 0x3c, 0x0b, 0x4e, 0x59, 0x40, 0x16, 0x17,
+	0x17, 0x17, 0x22, 0x17, 0x22, 0x21, 0x20, 0x1f, 0x1e, 0x11, 0x11, 0x12, 0x25, 0x21, 0x11, 0x24,
+	0x21, 0x10, 0x0d, 0x09, 0x1f, 0x2b, 0x01, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32,
+	0x35, 0x33, 0x10, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x03, 0x35, 0x33, 0x11,
+	0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x04, 0x94, 0xca, 0x40, 0x3e, 0x26, 0x1f,
+	0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44, 0xa0, 0xd2, 0xd2,
+	0x02, 0xd8, 0xd2, 0xd2, 0x06, 0x62, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b,
+	0x1a, 0x10, 0x06, 0x2d, 0xf9, 0x16, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00,
+	0x00, 0x02, 0xff, 0xc8, 0x00, 0x00, 0x02, 0x87, 0x06, 0x4e, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x8d,
+	0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x22, 0x00, 0x07, 0x07, 0x03, 0x61, 0x05, 0x01, 0x03, 0x03,
+	0x3a, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x04, 0x61, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x08, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x20, 0x05, 0x01, 0x03, 0x00, 0x07, 0x02, 0x03, 0x07, 0x69, 0x06, 0x01, 0x02, 0x02, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x1e, 0x05, 0x01, 0x03, 0x00, 0x07, 0x02, 0x03, 0x07, 0x69, 0x00, 0x04, 0x06,
+	0x01, 0x02, 0x00, 0x04, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x01, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x00, 0x00, 0x1a, 0x18, 0x13, 0x11, 0x10, 0x0f, 0x0e, 0x0c,
+	0x08, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21,
+	0x11, 0x01, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x35, 0x33, 0x10, 0x23, 0x22,
+	0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x94, 0x01, 0x28, 0xfe, 0xa0, 0x94, 0xca, 0x40, 0x3e,
+	0x26, 0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44, 0x04,
+	0x4a, 0xfb, 0xb6, 0x05, 0x0d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a,
+	0x10, 0x06, 0x2d, 0x00, 0x00, 0x02, 0x00, 0x5e, 0x00, 0x00, 0x03, 0x42, 0x07, 0x19, 0x00, 0x03,
+	0x00, 0x0f, 0x00, 0x68, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x00, 0x08, 0x01, 0x01,
+	0x04, 0x00, 0x01, 0x67, 0x05, 0x01, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x06,
+	0x01, 0x02, 0x02, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x1f, 0x00,
+	0x00, 0x08, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x05, 0x01, 0x03, 0x02, 0x04, 0x03,
+	0x67, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40,
+	0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15,
+	0x01, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x5e, 0x02, 0xe4, 0xfd,
+	0x22, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0x06, 0x6c, 0xad, 0xad, 0xf9, 0x94, 0xd2, 0x04, 0x24,
+	0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb6, 0x00, 0x00, 0x02, 0x9a,
+	0x05, 0xc4, 0x00, 0x03, 0x00, 0x07, 0x00, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x05,
+	0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04,
+	0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02,
+	0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40,
+	0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x06, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x35, 0x21, 0x15, 0x94, 0x01, 0x28, 0xfd,
+	0xfa, 0x02, 0xe4, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x17, 0xad, 0xad, 0x00, 0x00, 0x02, 0x00, 0x64,
+	0x00, 0x00, 0x03, 0x3c, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x6a, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x26, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x03, 0x06, 0x01, 0x03,
+	0x69, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x08, 0x01, 0x04, 0x04,
+	0x09, 0x5f, 0x0a, 0x01, 0x09, 0x09, 0x39, 0x09, 0x4e, 0x1b, 0x40, 0x24, 0x02, 0x01, 0x00, 0x01,
+	0x00, 0x85, 0x00, 0x01, 0x00, 0x03, 0x06, 0x01, 0x03, 0x69, 0x00, 0x06, 0x07, 0x01, 0x05, 0x04,
+	0x06, 0x05, 0x68, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0a, 0x01, 0x09, 0x09, 0x3c, 0x09, 0x4e,
+	0x59, 0x40, 0x12, 0x0c, 0x0c, 0x0c, 0x17, 0x0c, 0x17, 0x11, 0x11, 0x11, 0x11, 0x13, 0x22, 0x11,
+	0x21, 0x10, 0x0b, 0x09, 0x1f, 0x2b, 0x13, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23,
+	0x22, 0x26, 0x03, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x6e, 0x94,
+	0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0x1b, 0xd2, 0xd2, 0x02, 0xd8, 0xd2,
+	0xd2, 0x07, 0x8f, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0xf9, 0x05, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb,
+	0xdc, 0xd2, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc6, 0x00, 0x00, 0x02, 0x89, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x0f, 0x00, 0x7b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1c, 0x04, 0x01, 0x02, 0x02, 0x3a,
+	0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x04,
+	0x01, 0x02, 0x03, 0x02, 0x85, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1a, 0x04, 0x01,
+	0x02, 0x03, 0x02, 0x85, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x69, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0e, 0x0c,
+	0x0a, 0x09, 0x08, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x33,
+	0x11, 0x21, 0x11, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x94,
+	0x01, 0x28, 0xfe, 0x0a, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0x04,
+	0x4a, 0xfb, 0xb6, 0x06, 0x44, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64,
+	0xfe, 0x8e, 0x03, 0x3c, 0x05, 0xc8, 0x00, 0x19, 0x00, 0x95, 0x40, 0x0a, 0x12, 0x01, 0x06, 0x05,
+	0x13, 0x01, 0x07, 0x06, 0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x23, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x08,
+	0x02, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x61, 0x00, 0x07, 0x07, 0x3d, 0x07, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x03,
+	0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f,
+	0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x03, 0x01, 0x01,
+	0x00, 0x02, 0x01, 0x67, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x04, 0x01, 0x00, 0x00, 0x05,
+	0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00,
+	0x19, 0x00, 0x19, 0x23, 0x23, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33,
+	0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x23, 0x06, 0x15, 0x14, 0x33,
+	0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x35, 0x34, 0x37, 0x64, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2,
+	0xe3, 0xba, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9, 0xe1, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb,
+	0xdc, 0xd2, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x5d, 0x00, 0x00, 0x02, 0x00, 0x3d,
+	0xfe, 0x8e, 0x02, 0x2b, 0x06, 0x2b, 0x00, 0x10, 0x00, 0x14, 0x00, 0x90, 0x40, 0x0f, 0x06, 0x01,
+	0x00, 0x02, 0x07, 0x01, 0x01, 0x00, 0x02, 0x4c, 0x00, 0x01, 0x02, 0x01, 0x4b, 0x4b, 0xb0, 0x29,
+	0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00,
+	0x03, 0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x3d, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x01, 0x65, 0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03,
+	0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x01, 0x65, 0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03,
+	0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x11, 0x11, 0x11,
+	0x14, 0x11, 0x14, 0x12, 0x11, 0x13, 0x23, 0x23, 0x07, 0x09, 0x1b, 0x2b, 0x21, 0x06, 0x15, 0x14,
+	0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x35, 0x34, 0x37, 0x23, 0x11, 0x21, 0x25, 0x11, 0x21,
+	0x11, 0x01, 0xbc, 0xba, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9, 0xe1, 0x8a, 0x01, 0x28, 0xfe,
+	0xce, 0x01, 0x3c, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x5d, 0x04, 0x4a, 0xc3, 0x01,
+	0x1e, 0xfe, 0xe2, 0x00, 0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x03, 0x3c, 0x07, 0x8e, 0x00, 0x03,
+	0x00, 0x0f, 0x00, 0x68, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x00, 0x08, 0x01, 0x01,
+	0x04, 0x00, 0x01, 0x67, 0x05, 0x01, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x06,
+	0x01, 0x02, 0x02, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x1f, 0x00,
+	0x00, 0x08, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x05, 0x01, 0x03, 0x02, 0x04, 0x03,
+	0x67, 0x06, 0x01, 0x02, 0x02, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40,
+	0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x08,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x09, 0x17, 0x2b, 0x01, 0x11, 0x21, 0x11,
+	0x01, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x36, 0x01, 0x34,
+	0xfd, 0xfa, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0x06, 0x6c, 0x01, 0x22, 0xfe, 0xde, 0xf9, 0x94,
+	0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x01, 0xbc,
+	0x04, 0x4a, 0x00, 0x03, 0x00, 0x30, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x02, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x94, 0x01, 0x28, 0x04, 0x4a, 0xfb,
+	0xb6, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x64, 0xfe, 0xd8, 0x06, 0x29, 0x05, 0xc8, 0x00, 0x0f,
+	0x00, 0x1b, 0x00, 0x6c, 0x40, 0x0a, 0x01, 0x01, 0x00, 0x09, 0x00, 0x01, 0x03, 0x00, 0x02, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x07, 0x05,
+	0x02, 0x01, 0x01, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x09,
+	0x5f, 0x0a, 0x01, 0x09, 0x09, 0x39, 0x09, 0x4e, 0x1b, 0x40, 0x1f, 0x06, 0x01, 0x02, 0x07, 0x05,
+	0x02, 0x01, 0x04, 0x02, 0x01, 0x67, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x08, 0x01, 0x04,
+	0x04, 0x09, 0x5f, 0x0a, 0x01, 0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40, 0x12, 0x10, 0x10, 0x10,
+	0x1b, 0x10, 0x1b, 0x11, 0x11, 0x11, 0x11, 0x12, 0x23, 0x11, 0x13, 0x22, 0x0b, 0x09, 0x1f, 0x2b,
+	0x01, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x23, 0x35, 0x21, 0x11, 0x10, 0x04, 0x23, 0x22,
+	0x01, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x03, 0x6d, 0x89, 0x44,
+	0x52, 0x68, 0xd2, 0x02, 0x07, 0xfe, 0xfe, 0xe1, 0x4a, 0xfc, 0x68, 0xd2, 0xd2, 0x02, 0xd8, 0xd2,
+	0xd2, 0xfe, 0xf7, 0xd8, 0x26, 0x75, 0x9a, 0x04, 0x3e, 0xd2, 0xfb, 0x11, 0xfe, 0xf3, 0xf4, 0x01,
+	0x28, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x94,
+	0xfe, 0x5d, 0x03, 0xf6, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x14, 0x00, 0x18, 0x00, 0x84,
+	0x40, 0x0a, 0x09, 0x01, 0x04, 0x01, 0x08, 0x01, 0x06, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a,
+	0x4d, 0x05, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x01, 0x01, 0x01, 0x39, 0x4d, 0x00, 0x04, 0x04,
+	0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x03,
+	0x03, 0x02, 0x5f, 0x07, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x05, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09,
+	0x01, 0x01, 0x01, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e,
+	0x59, 0x40, 0x20, 0x15, 0x15, 0x04, 0x04, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x14,
+	0x12, 0x10, 0x0f, 0x0c, 0x0a, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0c, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x11, 0x21, 0x11, 0x03, 0x35, 0x16, 0x33,
+	0x32, 0x36, 0x35, 0x11, 0x21, 0x11, 0x10, 0x21, 0x22, 0x13, 0x11, 0x21, 0x11, 0x94, 0x01, 0x28,
+	0xfe, 0xd8, 0x01, 0x28, 0x13, 0x6a, 0x33, 0x4d, 0x3a, 0x01, 0x29, 0xfe, 0x7a, 0x57, 0xb4, 0x01,
+	0x29, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x12, 0x01, 0x19, 0xfe, 0xe7, 0xf9, 0x73, 0xc6, 0x35, 0x64,
+	0x86, 0x04, 0x4a, 0xfb, 0xc9, 0xfe, 0x4a, 0x06, 0xb5, 0x01, 0x19, 0xfe, 0xe7, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0xfe, 0xd8, 0x04, 0x0e, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x17, 0x00, 0x73,
+	0x40, 0x0e, 0x05, 0x01, 0x01, 0x00, 0x09, 0x01, 0x03, 0x04, 0x08, 0x01, 0x06, 0x03, 0x03, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x07, 0x02, 0x02, 0x01,
+	0x05, 0x01, 0x85, 0x00, 0x03, 0x00, 0x06, 0x03, 0x06, 0x65, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x00,
+	0x05, 0x05, 0x38, 0x04, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x00, 0x01, 0x00, 0x85, 0x07, 0x02, 0x02,
+	0x01, 0x05, 0x01, 0x85, 0x00, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x68, 0x00, 0x03, 0x06, 0x06,
+	0x03, 0x59, 0x00, 0x03, 0x03, 0x06, 0x61, 0x00, 0x06, 0x03, 0x06, 0x51, 0x59, 0x40, 0x13, 0x00,
+	0x00, 0x17, 0x15, 0x12, 0x11, 0x10, 0x0f, 0x0c, 0x0a, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x08,
+	0x09, 0x18, 0x2b, 0x01, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0x35, 0x16, 0x33, 0x32,
+	0x36, 0x35, 0x11, 0x21, 0x35, 0x21, 0x11, 0x10, 0x04, 0x21, 0x22, 0x01, 0x1b, 0xf1, 0x01, 0x11,
+	0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xfe, 0x32, 0xba, 0xa9, 0x97, 0x73, 0xfe, 0xfc, 0x02, 0x38, 0xfe,
+	0xf4, 0xfe, 0xd9, 0xae, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0xf8, 0xb6, 0xdd, 0x38,
+	0x75, 0x9a, 0x04, 0x3e, 0xd2, 0xfb, 0x11, 0xfe, 0xf3, 0xf4, 0x00, 0x00, 0x00, 0x02, 0xff, 0x70,
+	0xfe, 0x5d, 0x02, 0x98, 0x06, 0x44, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x67, 0x40, 0x0e, 0x12, 0x01,
+	0x04, 0x03, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x02, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x1f, 0x06, 0x05, 0x02, 0x04, 0x03, 0x01, 0x03, 0x04, 0x01, 0x80, 0x00, 0x03, 0x03,
+	0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43,
+	0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x05, 0x02, 0x04, 0x01, 0x04,
+	0x85, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02,
+	0x4e, 0x59, 0x40, 0x0e, 0x0d, 0x0d, 0x0d, 0x14, 0x0d, 0x14, 0x11, 0x12, 0x22, 0x13, 0x22, 0x07,
+	0x09, 0x1b, 0x2b, 0x03, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x21, 0x11, 0x10, 0x21, 0x22,
+	0x03, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x90, 0x69, 0x33, 0x4e, 0x3a, 0x01, 0x28, 0xfe,
+	0x7a, 0x57, 0x3a, 0xf1, 0x01, 0x12, 0xf0, 0xb3, 0xc5, 0x02, 0xc6, 0xfe, 0x85, 0xc6, 0x35, 0x64,
+	0x86, 0x04, 0x4a, 0xfb, 0xc9, 0xfe, 0x4a, 0x06, 0xa6, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0xfe, 0x50, 0x05, 0xb8, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x19, 0x00, 0x74,
+	0x40, 0x10, 0x09, 0x06, 0x03, 0x03, 0x02, 0x00, 0x13, 0x01, 0x06, 0x07, 0x12, 0x01, 0x05, 0x06,
+	0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07,
+	0x69, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06,
+	0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x00, 0x07,
+	0x06, 0x04, 0x07, 0x69, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x08, 0x03, 0x02, 0x02, 0x02, 0x3c,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x40, 0x14, 0x00,
+	0x00, 0x19, 0x18, 0x16, 0x14, 0x11, 0x0f, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11,
+	0x09, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x11, 0x17,
+	0x20, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0xad, 0x01,
+	0x28, 0x02, 0x68, 0xff, 0xfd, 0xce, 0x02, 0xae, 0xfe, 0x7f, 0xfd, 0x9e, 0x7b, 0x01, 0x6b, 0x8d,
+	0x64, 0x52, 0x72, 0x42, 0x2d, 0x80, 0xa5, 0x05, 0xc8, 0xfd, 0x32, 0x02, 0xce, 0xfd, 0x68, 0xfc,
+	0xd0, 0x02, 0xd8, 0xfd, 0x28, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0xfe, 0x50, 0x04, 0x6a, 0x06, 0x2b, 0x00, 0x0c, 0x00, 0x1b, 0x00, 0x7c,
+	0x40, 0x10, 0x0a, 0x07, 0x03, 0x03, 0x02, 0x01, 0x15, 0x01, 0x06, 0x07, 0x14, 0x01, 0x05, 0x06,
+	0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07,
+	0x69, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x24,
+	0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01,
+	0x3b, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x43, 0x05, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x1b, 0x1a, 0x18, 0x16, 0x13, 0x11, 0x0e,
+	0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x13, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11,
+	0x33, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x23, 0x11, 0x17, 0x20, 0x15, 0x14, 0x06, 0x23, 0x22,
+	0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x94, 0x01, 0x28, 0x13, 0x01, 0x59, 0xf5, 0xfe,
+	0xc0, 0x01, 0x8d, 0xfe, 0xc4, 0xfe, 0xa1, 0x13, 0x4a, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72, 0x42,
+	0x2d, 0x80, 0xa5, 0x06, 0x2b, 0xfc, 0x1f, 0x02, 0x00, 0xfe, 0x23, 0xfd, 0x93, 0x02, 0x25, 0xfd,
+	0xdb, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x00, 0x01, 0x00, 0x94,
+	0x00, 0x00, 0x04, 0x6a, 0x04, 0x4a, 0x00, 0x0c, 0x00, 0x3f, 0xb7, 0x0a, 0x07, 0x03, 0x03, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b,
+	0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0c,
+	0x00, 0x0c, 0x12, 0x13, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x33, 0x01, 0x33,
+	0x01, 0x01, 0x21, 0x01, 0x23, 0x11, 0x94, 0x01, 0x28, 0x13, 0x01, 0x59, 0xf5, 0xfe, 0xc0, 0x01,
+	0x8d, 0xfe, 0xc4, 0xfe, 0xa1, 0x13, 0x04, 0x4a, 0xfe, 0x00, 0x02, 0x00, 0xfe, 0x23, 0xfd, 0x93,
+	0x02, 0x25, 0xfd, 0xdb, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x04, 0xd1, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x09, 0x00, 0x59, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85,
+	0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60,
+	0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06,
+	0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05,
+	0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00, 0x00, 0x06, 0x09, 0x06,
+	0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x21,
+	0x11, 0x21, 0x15, 0x01, 0x13, 0x21, 0x01, 0xad, 0x01, 0x34, 0x02, 0xf0, 0xfc, 0x47, 0xf1, 0x01,
+	0x19, 0xfe, 0xbf, 0x05, 0xc8, 0xfb, 0x0a, 0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x62, 0xff, 0xe7, 0x02, 0x62, 0x07, 0xcf, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x35,
+	0x40, 0x32, 0x00, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x00, 0x03, 0x04, 0x03,
+	0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x0d, 0x0d, 0x0d, 0x10, 0x0d, 0x10, 0x12, 0x23, 0x12,
+	0x22, 0x06, 0x09, 0x1a, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16,
+	0x33, 0x32, 0x01, 0x13, 0x21, 0x01, 0x02, 0x4f, 0x43, 0x4c, 0xfe, 0xc7, 0x01, 0x28, 0x2a, 0x42,
+	0x1b, 0xfe, 0x2c, 0xf1, 0x01, 0x0f, 0xfe, 0xbf, 0xb6, 0xb6, 0x19, 0x01, 0x68, 0x04, 0xdc, 0xfb,
+	0x4b, 0x7c, 0x4d, 0x05, 0xe1, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0xfe, 0x50, 0x04, 0xd1, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x14, 0x00, 0x73, 0x40, 0x0a, 0x0e, 0x01,
+	0x05, 0x06, 0x0d, 0x01, 0x04, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00,
+	0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x69, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02,
+	0x60, 0x07, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x43,
+	0x04, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03,
+	0x06, 0x69, 0x00, 0x01, 0x01, 0x02, 0x60, 0x07, 0x01, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x05, 0x05,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x43, 0x04, 0x4e, 0x59, 0x40, 0x13, 0x00, 0x00, 0x14, 0x13, 0x11,
+	0x0f, 0x0c, 0x0a, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x08, 0x09, 0x18, 0x2b, 0x33,
+	0x11, 0x21, 0x11, 0x21, 0x15, 0x05, 0x20, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33,
+	0x32, 0x35, 0x34, 0x27, 0xad, 0x01, 0x34, 0x02, 0xf0, 0xfd, 0x7f, 0x01, 0x6b, 0x8d, 0x64, 0x52,
+	0x72, 0x42, 0x2d, 0x80, 0xa5, 0x05, 0xc8, 0xfb, 0x0a, 0xd2, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62,
+	0x06, 0x41, 0x3a, 0x08, 0x00, 0x02, 0x00, 0x87, 0xfe, 0x50, 0x02, 0x4f, 0x06, 0x2b, 0x00, 0x0e,
+	0x00, 0x1b, 0x00, 0x41, 0x40, 0x3e, 0x0f, 0x01, 0x06, 0x05, 0x10, 0x01, 0x04, 0x06, 0x08, 0x01,
+	0x02, 0x03, 0x07, 0x01, 0x01, 0x02, 0x04, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69,
+	0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x06, 0x06, 0x04, 0x62, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x23, 0x12, 0x23, 0x12, 0x23, 0x23,
+	0x10, 0x07, 0x09, 0x1d, 0x2b, 0x17, 0x20, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33,
+	0x32, 0x35, 0x34, 0x27, 0x01, 0x15, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33,
+	0x32, 0xd4, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72, 0x42, 0x2d, 0x80, 0xa5, 0x01, 0x7b, 0x43, 0x4c,
+	0xfe, 0xc7, 0x01, 0x28, 0x2a, 0x42, 0x1b, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a,
+	0x08, 0x01, 0x7a, 0xb6, 0x19, 0x01, 0x68, 0x04, 0xdc, 0xfb, 0x4b, 0x7c, 0x4d, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x04, 0xd1, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x0f, 0x00, 0x62,
+	0xb5, 0x0c, 0x01, 0x01, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05,
+	0x03, 0x01, 0x03, 0x05, 0x01, 0x80, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x04, 0x01, 0x00, 0x00, 0x38,
+	0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x06, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1d,
+	0x00, 0x05, 0x03, 0x01, 0x03, 0x05, 0x01, 0x80, 0x04, 0x01, 0x00, 0x00, 0x03, 0x05, 0x00, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x02, 0x60, 0x06, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x11,
+	0x00, 0x00, 0x0e, 0x0d, 0x09, 0x08, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09,
+	0x18, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x15, 0x01, 0x23, 0x11, 0x21, 0x15, 0x10, 0x05, 0x35,
+	0x32, 0x35, 0xad, 0x01, 0x34, 0x02, 0xf0, 0xfe, 0xc3, 0x72, 0x01, 0x03, 0xfe, 0xfd, 0x72, 0x05,
+	0xc8, 0xfb, 0x0a, 0xd2, 0x04, 0xa0, 0x01, 0x28, 0xe5, 0xfe, 0xaa, 0x15, 0x66, 0xa5, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x87, 0xff, 0xe7, 0x03, 0x41, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x16, 0x00, 0x32,
+	0x40, 0x2f, 0x0a, 0x06, 0x02, 0x05, 0x02, 0x0b, 0x01, 0x03, 0x05, 0x02, 0x4c, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05,
+	0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x23, 0x12, 0x24, 0x14, 0x11, 0x10, 0x06, 0x09,
+	0x1c, 0x2b, 0x01, 0x23, 0x11, 0x21, 0x15, 0x10, 0x05, 0x35, 0x32, 0x35, 0x03, 0x15, 0x06, 0x23,
+	0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x02, 0xb0, 0x72, 0x01, 0x03, 0xfe, 0xfd,
+	0x72, 0x61, 0x43, 0x4c, 0xfe, 0xc7, 0x01, 0x28, 0x2a, 0x42, 0x1b, 0x05, 0x03, 0x01, 0x28, 0xe5,
+	0xfe, 0xaa, 0x15, 0x66, 0xa5, 0xfb, 0xd0, 0xb6, 0x19, 0x01, 0x68, 0x04, 0xdc, 0xfb, 0x4b, 0x7c,
+	0x4d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x04, 0xd1, 0x05, 0xc8, 0x00, 0x05,
+	0x00, 0x09, 0x00, 0x55, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x03, 0x06, 0x01, 0x04,
+	0x01, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01,
+	0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x06,
+	0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x3c,
+	0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00, 0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00,
+	0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x15, 0x01,
+	0x11, 0x21, 0x11, 0xad, 0x01, 0x34, 0x02, 0xf0, 0xfe, 0x45, 0x01, 0x28, 0x05, 0xc8, 0xfb, 0x0a,
+	0xd2, 0x02, 0x8e, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x02, 0x00, 0x87, 0xff, 0xe7, 0x03, 0x9c,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x10, 0x00, 0x36, 0x40, 0x33, 0x04, 0x01, 0x04, 0x01, 0x05, 0x01,
+	0x02, 0x04, 0x02, 0x4c, 0x00, 0x00, 0x05, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x03, 0x03,
+	0x3a, 0x4d, 0x00, 0x04, 0x04, 0x02, 0x62, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x00, 0x00, 0x10,
+	0x0e, 0x0b, 0x0a, 0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x11,
+	0x21, 0x11, 0x01, 0x15, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x02,
+	0x73, 0x01, 0x29, 0xfe, 0xb3, 0x43, 0x4c, 0xfe, 0xc7, 0x01, 0x28, 0x2a, 0x42, 0x1b, 0x02, 0x8e,
+	0x01, 0x28, 0xfe, 0xd8, 0xfe, 0x28, 0xb6, 0x19, 0x01, 0x68, 0x04, 0xdc, 0xfb, 0x4b, 0x7c, 0x4d,
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x04, 0xd1, 0x05, 0xc8, 0x00, 0x0d, 0x00, 0x4a, 0x40, 0x0d,
+	0x0a, 0x09, 0x08, 0x07, 0x04, 0x03, 0x02, 0x01, 0x08, 0x01, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01,
+	0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01,
+	0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x0d,
+	0x00, 0x0d, 0x15, 0x15, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x07, 0x35, 0x37, 0x11, 0x21, 0x11,
+	0x37, 0x15, 0x07, 0x11, 0x21, 0x15, 0xad, 0xad, 0xad, 0x01, 0x34, 0xf7, 0xf7, 0x02, 0xf0, 0x02,
+	0x54, 0x5a, 0xc1, 0x5b, 0x02, 0xb2, 0xfd, 0xf4, 0x85, 0xc5, 0x84, 0xfd, 0xda, 0xd2, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x03, 0xff, 0xe7, 0x02, 0x6c, 0x06, 0x2b, 0x00, 0x18, 0x00, 0x2b, 0x40, 0x28,
+	0x13, 0x12, 0x11, 0x10, 0x0c, 0x0b, 0x0a, 0x08, 0x00, 0x09, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02,
+	0x02, 0x4c, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x27, 0x1a, 0x22, 0x03, 0x09, 0x19, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x03, 0x26,
+	0x35, 0x11, 0x31, 0x07, 0x35, 0x37, 0x17, 0x11, 0x21, 0x11, 0x37, 0x15, 0x07, 0x11, 0x14, 0x16,
+	0x33, 0x32, 0x02, 0x52, 0x43, 0x4c, 0xfe, 0xe1, 0x18, 0x02, 0x87, 0x86, 0x01, 0x01, 0x28, 0xba,
+	0xba, 0x2a, 0x42, 0x1b, 0xb6, 0xb6, 0x19, 0x01, 0x2d, 0x1a, 0x21, 0x01, 0x27, 0x48, 0xc3, 0x4c,
+	0x04, 0x02, 0xf2, 0xfd, 0xaf, 0x64, 0xc3, 0x64, 0xfe, 0x5f, 0x7c, 0x4d, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0x1a, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x5c, 0xb6, 0x08, 0x03, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x04, 0x05, 0x04, 0x85,
+	0x07, 0x01, 0x05, 0x00, 0x05, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x03, 0x02, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x00,
+	0x05, 0x85, 0x01, 0x01, 0x00, 0x00, 0x02, 0x60, 0x06, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x12, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x11, 0x33, 0x11, 0x21,
+	0x01, 0x11, 0x13, 0x13, 0x21, 0x01, 0xad, 0x01, 0x0f, 0x02, 0x67, 0xf7, 0xfe, 0xed, 0xfd, 0x9d,
+	0xb2, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3,
+	0xfc, 0x0d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x04, 0x5c,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xcb, 0x40, 0x0a, 0x03, 0x01, 0x03, 0x00, 0x0f, 0x01,
+	0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x08, 0x01, 0x06, 0x05, 0x00,
+	0x05, 0x06, 0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x29,
+	0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05,
+	0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06,
+	0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x07, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00,
+	0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x09,
+	0x09, 0x1a, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x34, 0x26,
+	0x23, 0x22, 0x07, 0x11, 0x11, 0x13, 0x21, 0x01, 0x94, 0x01, 0x28, 0xa9, 0xcc, 0x01, 0x2b, 0xfe,
+	0xd8, 0x33, 0x44, 0x78, 0x89, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5,
+	0xfc, 0xf8, 0x02, 0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0xfe, 0x50, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x18, 0x00, 0x73,
+	0x40, 0x0f, 0x08, 0x03, 0x02, 0x02, 0x00, 0x12, 0x01, 0x06, 0x07, 0x11, 0x01, 0x05, 0x06, 0x03,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69,
+	0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x00, 0x07, 0x06,
+	0x04, 0x07, 0x69, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x08, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00,
+	0x18, 0x17, 0x15, 0x13, 0x10, 0x0e, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x09,
+	0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x11, 0x33, 0x11, 0x21, 0x01, 0x11, 0x17, 0x20, 0x15,
+	0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0xad, 0x01, 0x0f, 0x02,
+	0x67, 0xf7, 0xfe, 0xed, 0xfd, 0x9d, 0xac, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72, 0x42, 0x2d, 0x80,
+	0xa5, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d, 0x61, 0xab, 0x44,
+	0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x00, 0x02, 0x00, 0x94, 0xfe, 0x50, 0x04, 0x5c,
+	0x04, 0x63, 0x00, 0x10, 0x00, 0x1f, 0x00, 0xb7, 0x40, 0x12, 0x03, 0x01, 0x03, 0x00, 0x0f, 0x01,
+	0x02, 0x03, 0x19, 0x01, 0x07, 0x08, 0x18, 0x01, 0x06, 0x07, 0x04, 0x4c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x25, 0x00, 0x05, 0x00, 0x08, 0x07, 0x05, 0x08, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x07, 0x07,
+	0x06, 0x61, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29,
+	0x00, 0x05, 0x00, 0x08, 0x07, 0x05, 0x08, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x07,
+	0x07, 0x06, 0x61, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x05, 0x00, 0x08,
+	0x07, 0x05, 0x08, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x09, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x15, 0x00, 0x00, 0x1f, 0x1e, 0x1c, 0x1a, 0x17,
+	0x15, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x33,
+	0x11, 0x21, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x17, 0x20, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x94,
+	0x01, 0x28, 0xa9, 0xcc, 0x01, 0x2b, 0xfe, 0xd8, 0x33, 0x44, 0x78, 0x89, 0x4a, 0x01, 0x6b, 0x8d,
+	0x64, 0x52, 0x72, 0x42, 0x2d, 0x80, 0xa5, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02,
+	0xbf, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x11, 0x00, 0x65,
+	0x40, 0x0b, 0x0f, 0x01, 0x04, 0x05, 0x08, 0x03, 0x02, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1a, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85,
+	0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40,
+	0x1a, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00,
+	0x00, 0x02, 0x60, 0x07, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x16, 0x0a, 0x0a,
+	0x00, 0x00, 0x0a, 0x11, 0x0a, 0x11, 0x0e, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12,
+	0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x11, 0x33, 0x11, 0x21, 0x01, 0x11, 0x01,
+	0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0xad, 0x01, 0x0f, 0x02, 0x67, 0xf7, 0xfe, 0xed, 0xfd,
+	0x9d, 0x02, 0xb9, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x05, 0xc8, 0xfc, 0x0d, 0x03,
+	0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d, 0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x04, 0x5c, 0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xd5,
+	0x40, 0x0e, 0x16, 0x01, 0x05, 0x06, 0x03, 0x01, 0x03, 0x00, 0x0f, 0x01, 0x02, 0x03, 0x03, 0x4c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80, 0x09,
+	0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b,
+	0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x26, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08,
+	0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x09,
+	0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05,
+	0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00,
+	0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22,
+	0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11,
+	0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x94, 0x01,
+	0x28, 0xa9, 0xcc, 0x01, 0x2b, 0xfe, 0xd8, 0x33, 0x44, 0x78, 0x89, 0x02, 0x1b, 0xf1, 0xfe, 0xef,
+	0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf, 0x6b,
+	0x50, 0xae, 0xfd, 0x34, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x02, 0x00, 0x0e,
+	0x00, 0x00, 0x05, 0x23, 0x06, 0x2b, 0x00, 0x10, 0x00, 0x1a, 0x00, 0x99, 0x40, 0x0b, 0x17, 0x03,
+	0x02, 0x03, 0x00, 0x0f, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1e,
+	0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x07,
+	0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3a, 0x4d,
+	0x07, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05, 0x05, 0x06, 0x5f,
+	0x00, 0x06, 0x06, 0x3a, 0x4d, 0x07, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x40,
+	0x13, 0x00, 0x00, 0x19, 0x18, 0x14, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22,
+	0x11, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x11, 0x21, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11,
+	0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x01, 0x23, 0x11, 0x21, 0x15, 0x10, 0x05, 0x35, 0x32, 0x35,
+	0x01, 0x5b, 0x01, 0x28, 0xa9, 0xcc, 0x01, 0x2b, 0xfe, 0xd8, 0x33, 0x44, 0x78, 0x89, 0xfd, 0xfd,
+	0x72, 0x01, 0x03, 0xfe, 0xfd, 0x72, 0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xf8, 0x02, 0xbf,
+	0x6b, 0x50, 0xae, 0xfd, 0x34, 0x05, 0x03, 0x01, 0x28, 0xe5, 0xfe, 0xaa, 0x15, 0x66, 0xa5, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0xfe, 0x5c, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x12, 0x00, 0x5b, 0x40, 0x10,
+	0x11, 0x03, 0x02, 0x04, 0x00, 0x10, 0x0c, 0x02, 0x03, 0x04, 0x0b, 0x01, 0x02, 0x03, 0x03, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40,
+	0x17, 0x01, 0x01, 0x00, 0x00, 0x04, 0x5f, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x03, 0x03,
+	0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x12, 0x00,
+	0x12, 0x23, 0x23, 0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x11, 0x33, 0x11,
+	0x15, 0x10, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x01, 0x11, 0xad, 0x01, 0x0f, 0x02,
+	0x67, 0xf7, 0xfe, 0x94, 0x5d, 0x5a, 0x43, 0x4b, 0x9b, 0xfd, 0x84, 0x05, 0xc8, 0xfc, 0x0d, 0x03,
+	0xf3, 0xfa, 0x38, 0x2e, 0xfe, 0x8a, 0x18, 0xc7, 0x19, 0xb3, 0x04, 0x1e, 0xfc, 0x0d, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x94, 0xfe, 0x5c, 0x04, 0x5c, 0x04, 0x63, 0x00, 0x19, 0x00, 0x95, 0x40, 0x12,
+	0x03, 0x01, 0x04, 0x00, 0x18, 0x01, 0x05, 0x04, 0x0d, 0x01, 0x03, 0x05, 0x0c, 0x01, 0x02, 0x03,
+	0x04, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x04, 0x04, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x05, 0x05,
+	0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x20,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06,
+	0x01, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x25, 0x23, 0x23, 0x22, 0x11, 0x07,
+	0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x10, 0x21, 0x22, 0x27,
+	0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x94, 0x01, 0x28,
+	0xa9, 0xcc, 0x01, 0x2b, 0xfe, 0x9b, 0x4d, 0x69, 0x41, 0x36, 0x47, 0x35, 0x33, 0x44, 0x78, 0x89,
+	0x04, 0x4a, 0xb6, 0xcf, 0xfe, 0xa5, 0xfc, 0xc2, 0xfe, 0x92, 0x17, 0xc4, 0x15, 0x53, 0x70, 0x02,
+	0xda, 0x6b, 0x50, 0xae, 0xfd, 0x34, 0x00, 0x00, 0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9,
+	0x07, 0x19, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x67, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x3f, 0x00,
+	0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x69, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a,
+	0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16,
+	0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12,
+	0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x35, 0x21, 0x15, 0x03, 0x12, 0xfe,
+	0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe,
+	0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0xb9, 0x02, 0xe4, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d,
+	0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01,
+	0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xc5, 0xad, 0xad, 0x00,
+	0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99, 0x05, 0xc4, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x6b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00,
+	0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01,
+	0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04,
+	0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00,
+	0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x01, 0x35, 0x21, 0x15, 0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c,
+	0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70, 0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0xfe, 0xfb,
+	0x02, 0xe4, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe,
+	0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x77, 0xad, 0xad,
+	0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x71, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00,
+	0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x23, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00,
+	0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x0d, 0x0c, 0x01, 0x00, 0x22, 0x20, 0x1e, 0x1d, 0x1c,
+	0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a,
+	0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25,
+	0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x33, 0x16, 0x33, 0x32,
+	0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01,
+	0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc,
+	0xa8, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0x25, 0x01, 0xa1, 0x01,
+	0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b,
+	0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x06, 0xe8,
+	0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23, 0x00, 0xa5, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x27, 0x06, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x38,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00,
+	0x62, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27,
+	0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x38, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x62,
+	0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85,
+	0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x62, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x59, 0x40, 0x1b, 0x0d, 0x0c, 0x01, 0x00, 0x22, 0x20, 0x1e, 0x1d, 0x1c, 0x1a, 0x19, 0x18, 0x13,
+	0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05,
+	0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34,
+	0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06,
+	0x23, 0x22, 0x26, 0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3,
+	0xfd, 0x70, 0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0xf4, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10,
+	0xc0, 0x91, 0x91, 0xc0, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe,
+	0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x05, 0xa4,
+	0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x75, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08,
+	0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03,
+	0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x09, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x1c, 0x1c, 0x18,
+	0x18, 0x0d, 0x0c, 0x01, 0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a,
+	0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16,
+	0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12,
+	0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33,
+	0x01, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe,
+	0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0x81, 0xf1, 0xe4, 0xfe, 0xbf, 0xe5,
+	0xf0, 0xe5, 0xfe, 0xbf, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe,
+	0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3,
+	0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x05, 0xa7, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x04, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0x79, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x07, 0x0a, 0x03, 0x05,
+	0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01,
+	0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00,
+	0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33, 0x01, 0x02, 0x6b, 0xf6,
+	0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70, 0x80, 0x81, 0x6d, 0x6d,
+	0x80, 0x80, 0xce, 0xf1, 0xe4, 0xfe, 0xbf, 0xe5, 0xf0, 0xe5, 0xfe, 0xbf, 0x19, 0x01, 0x3b, 0x01,
+	0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6,
+	0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x04, 0x63, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x02, 0x00, 0x50, 0xff, 0xdb, 0x07, 0xc3, 0x05, 0xed, 0x00, 0x14, 0x00, 0x1f, 0x00, 0xba,
+	0x40, 0x0a, 0x16, 0x01, 0x05, 0x04, 0x15, 0x01, 0x07, 0x06, 0x02, 0x4c, 0x4b, 0xb0, 0x0e, 0x50,
+	0x58, 0x40, 0x2b, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x67, 0x08, 0x01, 0x04, 0x04, 0x02,
+	0x61, 0x03, 0x01, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00,
+	0x39, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x31, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x67, 0x00, 0x08,
+	0x08, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x03,
+	0x38, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x2d, 0x00, 0x02, 0x00, 0x08, 0x04, 0x02,
+	0x08, 0x69, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05,
+	0x06, 0x67, 0x00, 0x07, 0x07, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x1f, 0x1d, 0x23, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x24, 0x21, 0x10, 0x0a, 0x09, 0x1f, 0x2b, 0x21, 0x21, 0x06, 0x23, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x21, 0x32, 0x17, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x25,
+	0x11, 0x26, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x33, 0x32, 0x07, 0xc3, 0xfc, 0x6a, 0x7a, 0x9b,
+	0xfe, 0xb4, 0xfe, 0x84, 0x01, 0x7b, 0x01, 0x4c, 0x9a, 0x7c, 0x03, 0x68, 0xfd, 0x99, 0x01, 0xf8,
+	0xfe, 0x08, 0x02, 0x95, 0xfc, 0x37, 0x67, 0x7e, 0xb3, 0xcb, 0xcb, 0xb3, 0x7e, 0x25, 0x01, 0x9e,
+	0x01, 0x6b, 0x01, 0x6b, 0x01, 0x9e, 0x25, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0x20, 0x03, 0xe5,
+	0x4b, 0xfe, 0xcf, 0xfe, 0xf3, 0xfe, 0xf4, 0xfe, 0xcf, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a,
+	0xff, 0xe7, 0x07, 0x21, 0x04, 0x63, 0x00, 0x04, 0x00, 0x1d, 0x00, 0x29, 0x00, 0x90, 0x4b, 0xb0,
+	0x20, 0x50, 0x58, 0x40, 0x0b, 0x0f, 0x01, 0x04, 0x03, 0x14, 0x10, 0x02, 0x05, 0x04, 0x02, 0x4c,
+	0x1b, 0x40, 0x0b, 0x0f, 0x01, 0x04, 0x03, 0x14, 0x10, 0x02, 0x05, 0x08, 0x02, 0x4c, 0x59, 0x4b,
+	0xb0, 0x20, 0x50, 0x58, 0x40, 0x22, 0x00, 0x00, 0x00, 0x03, 0x04, 0x00, 0x03, 0x67, 0x09, 0x01,
+	0x01, 0x01, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x0a, 0x08, 0x02, 0x04, 0x04, 0x05,
+	0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40, 0x2c, 0x00, 0x00, 0x00, 0x03, 0x04,
+	0x00, 0x03, 0x67, 0x09, 0x01, 0x01, 0x01, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x00,
+	0x04, 0x04, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x4d, 0x0a, 0x01, 0x08, 0x08, 0x05, 0x61,
+	0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x40, 0x13, 0x1f, 0x1e, 0x25, 0x23, 0x1e, 0x29,
+	0x1f, 0x29, 0x24, 0x22, 0x23, 0x21, 0x12, 0x22, 0x21, 0x10, 0x0b, 0x09, 0x1e, 0x2b, 0x01, 0x21,
+	0x10, 0x23, 0x22, 0x27, 0x36, 0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x37, 0x15, 0x06,
+	0x23, 0x20, 0x27, 0x06, 0x23, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x03, 0x32, 0x36, 0x35,
+	0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x04, 0x99, 0x01, 0x65, 0x9f, 0xa8, 0xc0, 0x89,
+	0xdb, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xaf, 0xb7, 0xb7, 0xfe, 0xf6, 0xa7, 0x9b,
+	0xf5, 0xfc, 0xfe, 0xd4, 0x01, 0x2c, 0xfb, 0xf6, 0xf8, 0x70, 0x80, 0x81, 0x6c, 0x6d, 0x80, 0x7f,
+	0x02, 0x91, 0x01, 0x19, 0x2a, 0x8f, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x45, 0xd0, 0x3e, 0x9a,
+	0x9a, 0x01, 0x39, 0x01, 0x05, 0x01, 0x05, 0x01, 0x39, 0xfc, 0x3d, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2,
+	0xb3, 0xb1, 0xd4, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x12, 0x00, 0x16, 0x00, 0x75, 0xb5, 0x06, 0x01, 0x02, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00,
+	0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38,
+	0x4d, 0x08, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x06, 0x07, 0x06,
+	0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x68, 0x00,
+	0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59,
+	0x40, 0x18, 0x13, 0x13, 0x00, 0x00, 0x13, 0x16, 0x13, 0x16, 0x15, 0x14, 0x12, 0x10, 0x0e, 0x0c,
+	0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11,
+	0x10, 0x05, 0x01, 0x21, 0x01, 0x23, 0x11, 0x11, 0x33, 0x20, 0x11, 0x34, 0x21, 0x23, 0x13, 0x13,
+	0x21, 0x01, 0xad, 0x02, 0x85, 0x01, 0xc3, 0xfe, 0xe1, 0x01, 0xe4, 0xfe, 0xa6, 0xfe, 0x60, 0xf1,
+	0xa2, 0x01, 0x4f, 0xfe, 0xd5, 0xc6, 0x31, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x05, 0xc8, 0xfe, 0x91,
+	0xfe, 0xdb, 0x81, 0xfd, 0x4d, 0x02, 0x5d, 0xfd, 0xa3, 0x03, 0x28, 0x01, 0x0f, 0xc6, 0x01, 0x51,
+	0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x02, 0xfd, 0x06, 0x44, 0x00, 0x0d,
+	0x00, 0x11, 0x00, 0xe3, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0f, 0x07, 0x01, 0x00, 0x05, 0x03,
+	0x01, 0x02, 0x00, 0x0c, 0x08, 0x02, 0x03, 0x02, 0x03, 0x4c, 0x1b, 0x40, 0x0f, 0x07, 0x01, 0x00,
+	0x01, 0x03, 0x01, 0x02, 0x00, 0x0c, 0x08, 0x02, 0x03, 0x02, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x20, 0x07, 0x01, 0x05, 0x04, 0x00, 0x04, 0x05, 0x00, 0x80, 0x00, 0x04, 0x04,
+	0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24, 0x07, 0x01, 0x05, 0x04,
+	0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05,
+	0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x14, 0x0e, 0x0e, 0x00, 0x00, 0x0e, 0x11, 0x0e, 0x11, 0x10, 0x0f, 0x00, 0x0d, 0x00, 0x0d,
+	0x23, 0x22, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x36, 0x33, 0x32, 0x17, 0x11,
+	0x26, 0x23, 0x22, 0x07, 0x11, 0x03, 0x13, 0x21, 0x01, 0xad, 0x01, 0x28, 0x53, 0xa3, 0x17, 0x1b,
+	0x38, 0x26, 0x77, 0x53, 0xf7, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x04, 0x4a, 0xb6, 0xcf, 0x06, 0xfe,
+	0xf8, 0x17, 0x9a, 0xfd, 0x2e, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0xad,
+	0xfe, 0x50, 0x05, 0xba, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x21, 0x00, 0x8c, 0x40, 0x0e,
+	0x06, 0x01, 0x02, 0x04, 0x1b, 0x01, 0x08, 0x09, 0x1a, 0x01, 0x07, 0x08, 0x03, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x06, 0x00,
+	0x09, 0x08, 0x06, 0x09, 0x69, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0a,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x43, 0x07,
+	0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02,
+	0x01, 0x04, 0x02, 0x67, 0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x69, 0x0a, 0x03, 0x02, 0x01,
+	0x01, 0x3c, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x59, 0x40,
+	0x18, 0x00, 0x00, 0x21, 0x20, 0x1e, 0x1c, 0x19, 0x17, 0x14, 0x13, 0x12, 0x10, 0x0e, 0x0c, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x14, 0x21, 0x0b, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10,
+	0x05, 0x01, 0x21, 0x01, 0x23, 0x11, 0x11, 0x33, 0x20, 0x11, 0x34, 0x21, 0x23, 0x13, 0x20, 0x15,
+	0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0xad, 0x02, 0x85, 0x01,
+	0xc3, 0xfe, 0xe1, 0x01, 0xe4, 0xfe, 0xa6, 0xfe, 0x60, 0xf1, 0xa2, 0x01, 0x4f, 0xfe, 0xd5, 0xc6,
+	0x94, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72, 0x42, 0x2d, 0x80, 0xa5, 0x05, 0xc8, 0xfe, 0x91, 0xfe,
+	0xdb, 0x81, 0xfd, 0x4d, 0x02, 0x5d, 0xfd, 0xa3, 0x03, 0x28, 0x01, 0x0f, 0xc6, 0xfa, 0xa2, 0xab,
+	0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x02, 0x00, 0xad, 0xfe, 0x50, 0x02, 0xfd,
+	0x04, 0x63, 0x00, 0x0d, 0x00, 0x1c, 0x00, 0xd8, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17, 0x03,
+	0x01, 0x02, 0x00, 0x0c, 0x08, 0x02, 0x03, 0x02, 0x16, 0x01, 0x06, 0x07, 0x15, 0x01, 0x05, 0x06,
+	0x04, 0x4c, 0x07, 0x01, 0x00, 0x4a, 0x1b, 0x40, 0x17, 0x07, 0x01, 0x00, 0x01, 0x03, 0x01, 0x02,
+	0x00, 0x0c, 0x08, 0x02, 0x03, 0x02, 0x16, 0x01, 0x06, 0x07, 0x15, 0x01, 0x05, 0x06, 0x05, 0x4c,
+	0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69,
+	0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x01, 0x03, 0x03, 0x39,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x28, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x03, 0x03, 0x39,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x00,
+	0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x00, 0x00, 0x1c, 0x1b, 0x19,
+	0x17, 0x14, 0x12, 0x0f, 0x0e, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x09, 0x09, 0x19, 0x2b,
+	0x33, 0x11, 0x21, 0x15, 0x36, 0x33, 0x32, 0x17, 0x11, 0x26, 0x23, 0x22, 0x07, 0x11, 0x07, 0x20,
+	0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0xad, 0x01, 0x28,
+	0x53, 0xa3, 0x17, 0x1b, 0x38, 0x26, 0x77, 0x53, 0xc5, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72, 0x42,
+	0x2d, 0x80, 0xa5, 0x04, 0x4a, 0xb6, 0xcf, 0x06, 0xfe, 0xf8, 0x17, 0x9a, 0xfd, 0x2e, 0x61, 0xab,
+	0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x05, 0xba,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x1a, 0x00, 0x7e, 0x40, 0x0a, 0x18, 0x01, 0x06, 0x07,
+	0x06, 0x01, 0x02, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x0a, 0x08, 0x02,
+	0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02,
+	0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00,
+	0x06, 0x85, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x67, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x13, 0x13, 0x00,
+	0x00, 0x13, 0x1a, 0x13, 0x1a, 0x17, 0x16, 0x15, 0x14, 0x12, 0x10, 0x0e, 0x0c, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x14, 0x21, 0x0b, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x01,
+	0x21, 0x01, 0x23, 0x11, 0x11, 0x33, 0x20, 0x11, 0x34, 0x21, 0x23, 0x01, 0x03, 0x21, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0xad, 0x02, 0x85, 0x01, 0xc3, 0xfe, 0xe1, 0x01, 0xe4, 0xfe, 0xa6, 0xfe, 0x60,
+	0xf1, 0xa2, 0x01, 0x4f, 0xfe, 0xd5, 0xc6, 0x02, 0x59, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03,
+	0xc5, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xdb, 0x81, 0xfd, 0x4d, 0x02, 0x5d, 0xfd, 0xa3, 0x03, 0x28,
+	0x01, 0x0f, 0xc6, 0x02, 0x92, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x02, 0x00, 0x15,
+	0x00, 0x00, 0x03, 0x08, 0x06, 0x44, 0x00, 0x0d, 0x00, 0x15, 0x00, 0xf1, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x13, 0x13, 0x01, 0x04, 0x05, 0x07, 0x01, 0x00, 0x04, 0x03, 0x01, 0x02, 0x00, 0x0c,
+	0x08, 0x02, 0x03, 0x02, 0x04, 0x4c, 0x1b, 0x40, 0x13, 0x13, 0x01, 0x04, 0x05, 0x07, 0x01, 0x00,
+	0x01, 0x03, 0x01, 0x02, 0x00, 0x0c, 0x08, 0x02, 0x03, 0x02, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x00, 0x05, 0x04, 0x00, 0x80, 0x08, 0x06, 0x02, 0x05,
+	0x05, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x01,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x00, 0x04, 0x05,
+	0x01, 0x05, 0x04, 0x01, 0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39,
+	0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05,
+	0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x08,
+	0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03,
+	0x4e, 0x59, 0x59, 0x59, 0x40, 0x16, 0x0e, 0x0e, 0x00, 0x00, 0x0e, 0x15, 0x0e, 0x15, 0x12, 0x11,
+	0x10, 0x0f, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21,
+	0x15, 0x36, 0x33, 0x32, 0x17, 0x11, 0x26, 0x23, 0x22, 0x07, 0x11, 0x01, 0x03, 0x21, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0xad, 0x01, 0x28, 0x53, 0xa3, 0x17, 0x1b, 0x38, 0x26, 0x77, 0x53, 0x01, 0x33,
+	0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x04, 0x4a, 0xb6, 0xcf, 0x06, 0xfe, 0xf8, 0x17,
+	0x9a, 0xfd, 0x2e, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x02, 0x00, 0x63,
+	0xff, 0xda, 0x05, 0x09, 0x07, 0x8f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x6b, 0x40, 0x0f, 0x10, 0x01,
+	0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01,
+	0x05, 0x85, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x24, 0x24, 0x24, 0x27, 0x24, 0x27, 0x13, 0x2c,
+	0x23, 0x29, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x37, 0x35, 0x04, 0x33, 0x20, 0x35, 0x34, 0x2f, 0x02,
+	0x24, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x1f,
+	0x02, 0x16, 0x16, 0x15, 0x14, 0x04, 0x21, 0x22, 0x27, 0x13, 0x13, 0x21, 0x01, 0x66, 0x01, 0x1c,
+	0xef, 0x01, 0x54, 0x81, 0x89, 0xa3, 0xfe, 0xfb, 0xb0, 0x02, 0x5c, 0xfe, 0xe5, 0xee, 0xdf, 0xb5,
+	0x8c, 0x44, 0x61, 0x72, 0xaa, 0xf7, 0xbd, 0xfe, 0xa7, 0xfe, 0x8d, 0x8b, 0xae, 0xed, 0xf1, 0x01,
+	0x19, 0xfe, 0xbf, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c,
+	0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9, 0x1b,
+	0x06, 0x59, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x7b, 0xff, 0xe7, 0x04, 0x0c,
+	0x06, 0x44, 0x00, 0x1e, 0x00, 0x22, 0x00, 0x70, 0x40, 0x0f, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01,
+	0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x23,
+	0x06, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05,
+	0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x1f, 0x1f, 0x1f, 0x22, 0x1f, 0x22, 0x12,
+	0x29, 0x23, 0x28, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27,
+	0x27, 0x26, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17, 0x17,
+	0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x13, 0x13, 0x21, 0x01, 0x7b, 0xe6, 0x9d, 0xdd, 0xaf,
+	0x64, 0xcd, 0x7b, 0x01, 0xcf, 0x9e, 0xc8, 0xdc, 0x66, 0xcf, 0xa1, 0x56, 0xdc, 0x95, 0xfe, 0xed,
+	0xe8, 0xcc, 0x55, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53,
+	0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70, 0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9,
+	0x05, 0x1c, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x63, 0xff, 0xda, 0x05, 0x09,
+	0x07, 0x8f, 0x00, 0x23, 0x00, 0x2b, 0x00, 0x72, 0x40, 0x13, 0x29, 0x01, 0x05, 0x04, 0x10, 0x01,
+	0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02,
+	0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x24, 0x24, 0x24, 0x2b, 0x24, 0x2b,
+	0x11, 0x13, 0x2c, 0x23, 0x29, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x35, 0x04, 0x33, 0x20, 0x35,
+	0x34, 0x2f, 0x02, 0x24, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15,
+	0x14, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x15, 0x14, 0x04, 0x21, 0x22, 0x27, 0x13, 0x13, 0x21, 0x13,
+	0x23, 0x27, 0x23, 0x07, 0x66, 0x01, 0x1c, 0xef, 0x01, 0x54, 0x81, 0x89, 0xa3, 0xfe, 0xfb, 0xb0,
+	0x02, 0x5c, 0xfe, 0xe5, 0xee, 0xdf, 0xb5, 0x8c, 0x44, 0x61, 0x72, 0xaa, 0xf7, 0xbd, 0xfe, 0xa7,
+	0xfe, 0x8d, 0x8b, 0xae, 0x4a, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x0d, 0xfc, 0x63,
+	0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e,
+	0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9, 0x1b, 0x06, 0x59, 0x01, 0x41, 0xfe, 0xbf,
+	0xc5, 0xc5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x7b, 0xff, 0xe7, 0x04, 0x0c, 0x06, 0x44, 0x00, 0x1e,
+	0x00, 0x26, 0x00, 0x77, 0x40, 0x13, 0x24, 0x01, 0x05, 0x04, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01,
+	0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24,
+	0x07, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05,
+	0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x1f, 0x1f, 0x1f, 0x26, 0x1f,
+	0x26, 0x11, 0x12, 0x29, 0x23, 0x28, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32,
+	0x35, 0x34, 0x27, 0x27, 0x26, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15,
+	0x14, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x03, 0x13, 0x21, 0x13, 0x23, 0x27,
+	0x23, 0x07, 0x7b, 0xe6, 0x9d, 0xdd, 0xaf, 0x64, 0xcd, 0x7b, 0x01, 0xcf, 0x9e, 0xc8, 0xdc, 0x66,
+	0xcf, 0xa1, 0x56, 0xdc, 0x95, 0xfe, 0xed, 0xe8, 0xcc, 0x77, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5,
+	0x03, 0xc5, 0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb,
+	0x39, 0x70, 0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9, 0x05, 0x1c, 0x01, 0x41, 0xfe, 0xbf,
+	0xc5, 0xc5, 0x00, 0x00, 0x00, 0x01, 0x00, 0x63, 0xfe, 0x50, 0x05, 0x09, 0x05, 0xed, 0x00, 0x36,
+	0x00, 0xb4, 0x40, 0x1b, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x06,
+	0x00, 0x23, 0x01, 0x05, 0x06, 0x2b, 0x01, 0x04, 0x05, 0x2a, 0x01, 0x03, 0x04, 0x06, 0x4c, 0x4b,
+	0xb0, 0x12, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x04, 0x06, 0x05, 0x72, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3f,
+	0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x27, 0x00, 0x05, 0x06, 0x04, 0x06, 0x05, 0x04, 0x80, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3f, 0x4d,
+	0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x05,
+	0x06, 0x04, 0x06, 0x05, 0x04, 0x80, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00,
+	0x00, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x43, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x35, 0x33, 0x32, 0x30, 0x2e, 0x2c, 0x29, 0x27, 0x23,
+	0x29, 0x22, 0x07, 0x09, 0x19, 0x2b, 0x37, 0x35, 0x04, 0x33, 0x20, 0x35, 0x34, 0x2f, 0x02, 0x24,
+	0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x1f, 0x02,
+	0x16, 0x16, 0x15, 0x14, 0x07, 0x06, 0x07, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35,
+	0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x23, 0x37, 0x23, 0x22, 0x27, 0x66, 0x01, 0x1c, 0xef, 0x01,
+	0x54, 0x81, 0x89, 0xa3, 0xfe, 0xfb, 0xb0, 0x02, 0x5c, 0xfe, 0xe5, 0xee, 0xdf, 0xb5, 0x8c, 0x44,
+	0x61, 0x72, 0xaa, 0xf7, 0xbd, 0xad, 0x83, 0xf7, 0x36, 0xe8, 0x90, 0x69, 0x52, 0x6a, 0x47, 0x2f,
+	0x79, 0xc3, 0x14, 0x60, 0x1a, 0x8b, 0xae, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63,
+	0xb4, 0xa6, 0x01, 0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4,
+	0xa6, 0xe8, 0x6d, 0x52, 0x13, 0x52, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b, 0x0f, 0x3c, 0x54, 0x90,
+	0x1b, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7b, 0xfe, 0x50, 0x04, 0x0c, 0x04, 0x63, 0x00, 0x30,
+	0x00, 0x86, 0x40, 0x1b, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x06,
+	0x00, 0x1f, 0x01, 0x05, 0x06, 0x27, 0x01, 0x04, 0x05, 0x26, 0x01, 0x03, 0x04, 0x06, 0x4c, 0x4b,
+	0xb0, 0x10, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x04, 0x06, 0x05, 0x72, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42,
+	0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x1b, 0x40, 0x27, 0x00,
+	0x05, 0x06, 0x04, 0x06, 0x05, 0x04, 0x80, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x00, 0x00, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x30, 0x2f, 0x2e, 0x2c, 0x2a, 0x28, 0x25,
+	0x23, 0x23, 0x28, 0x22, 0x07, 0x09, 0x19, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27,
+	0x27, 0x26, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17, 0x17,
+	0x16, 0x16, 0x15, 0x14, 0x07, 0x06, 0x07, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35,
+	0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x23, 0x37, 0x26, 0x7b, 0xe6, 0x9d, 0xdd, 0xaf, 0x64, 0xcd,
+	0x7b, 0x01, 0xcf, 0x9e, 0xc8, 0xdc, 0x66, 0xcf, 0xa1, 0x56, 0xdc, 0x95, 0x8a, 0x69, 0xa1, 0x3e,
+	0xe8, 0x90, 0x69, 0x52, 0x6a, 0x47, 0x2f, 0x79, 0xc3, 0x14, 0x68, 0xba, 0x24, 0xd8, 0x5c, 0x78,
+	0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70, 0x44, 0x3d, 0x21, 0x53,
+	0x8d, 0x7c, 0x9c, 0x5d, 0x46, 0x11, 0x5d, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b, 0x0f, 0x3c, 0x54,
+	0x9e, 0x05, 0x00, 0x00, 0x00, 0x02, 0x00, 0x63, 0xff, 0xda, 0x05, 0x09, 0x07, 0x8f, 0x00, 0x23,
+	0x00, 0x2b, 0x00, 0x72, 0x40, 0x13, 0x29, 0x01, 0x04, 0x05, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01,
+	0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21,
+	0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x1f, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x24, 0x24, 0x24, 0x2b, 0x24, 0x2b, 0x11, 0x13, 0x2c, 0x23,
+	0x29, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x35, 0x04, 0x33, 0x20, 0x35, 0x34, 0x2f, 0x02, 0x24,
+	0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x1f, 0x02,
+	0x16, 0x16, 0x15, 0x14, 0x04, 0x21, 0x22, 0x27, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x66, 0x01, 0x1c, 0xef, 0x01, 0x54, 0x81, 0x89, 0xa3, 0xfe, 0xfb, 0xb0, 0x02, 0x5c, 0xfe, 0xe5,
+	0xee, 0xdf, 0xb5, 0x8c, 0x44, 0x61, 0x72, 0xaa, 0xf7, 0xbd, 0xfe, 0xa7, 0xfe, 0x8d, 0x8b, 0xae,
+	0x03, 0x26, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37,
+	0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c,
+	0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9, 0x1b, 0x07, 0x9a, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00,
+	0x00, 0x02, 0x00, 0x7b, 0xff, 0xe7, 0x04, 0x0c, 0x06, 0x44, 0x00, 0x1e, 0x00, 0x26, 0x00, 0x77,
+	0x40, 0x13, 0x24, 0x01, 0x04, 0x05, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x00,
+	0x01, 0x03, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x05, 0x01,
+	0x05, 0x04, 0x01, 0x80, 0x07, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x62, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e,
+	0x1b, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x62, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x1f, 0x1f, 0x1f, 0x26, 0x1f, 0x26, 0x11, 0x12, 0x29,
+	0x23, 0x28, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27,
+	0x26, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x16,
+	0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x7b, 0xe6,
+	0x9d, 0xdd, 0xaf, 0x64, 0xcd, 0x7b, 0x01, 0xcf, 0x9e, 0xc8, 0xdc, 0x66, 0xcf, 0xa1, 0x56, 0xdc,
+	0x95, 0xfe, 0xed, 0xe8, 0xcc, 0x02, 0x75, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x24,
+	0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70, 0x44,
+	0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9, 0x06, 0x5d, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00,
+	0x00, 0x01, 0x00, 0x28, 0xfe, 0x50, 0x04, 0xbc, 0x05, 0xc8, 0x00, 0x19, 0x00, 0x76, 0x40, 0x0e,
+	0x09, 0x01, 0x06, 0x03, 0x11, 0x01, 0x05, 0x06, 0x10, 0x01, 0x04, 0x05, 0x03, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x03, 0x05, 0x03, 0x06, 0x05, 0x80, 0x02, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x08, 0x07, 0x02, 0x03, 0x03, 0x39, 0x4d, 0x00,
+	0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x43, 0x04, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x06, 0x03,
+	0x05, 0x03, 0x06, 0x05, 0x80, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x08, 0x07,
+	0x02, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x43, 0x04, 0x4e,
+	0x59, 0x40, 0x10, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x22, 0x23, 0x25, 0x11, 0x11, 0x11, 0x11,
+	0x09, 0x09, 0x1d, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x23, 0x07, 0x16, 0x15,
+	0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x23, 0x37, 0x01, 0xd8,
+	0xfe, 0x50, 0x04, 0x94, 0xfe, 0x50, 0x4f, 0x4c, 0xe8, 0x90, 0x69, 0x52, 0x6a, 0x47, 0x2f, 0x79,
+	0xc3, 0x14, 0x79, 0x04, 0xfd, 0xcb, 0xcb, 0xfb, 0x03, 0x71, 0x19, 0x83, 0x45, 0x5e, 0x1e, 0x5b,
+	0x0f, 0x3c, 0x54, 0xb6, 0x00, 0x01, 0x00, 0x2a, 0xfe, 0x50, 0x02, 0x9c, 0x05, 0x43, 0x00, 0x25,
+	0x00, 0x54, 0x40, 0x51, 0x00, 0x01, 0x08, 0x04, 0x14, 0x01, 0x02, 0x00, 0x08, 0x04, 0x01, 0x03,
+	0x00, 0x0c, 0x01, 0x02, 0x03, 0x0b, 0x01, 0x01, 0x02, 0x05, 0x4c, 0x1c, 0x1b, 0x02, 0x05, 0x4a,
+	0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x07, 0x01, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01,
+	0x05, 0x05, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x43, 0x01, 0x4e, 0x23, 0x11, 0x13, 0x11, 0x14, 0x22, 0x23,
+	0x25, 0x12, 0x09, 0x09, 0x1f, 0x2b, 0x25, 0x15, 0x06, 0x07, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x23, 0x37, 0x26, 0x11, 0x11, 0x23, 0x35,
+	0x33, 0x35, 0x25, 0x15, 0x33, 0x15, 0x23, 0x11, 0x14, 0x16, 0x33, 0x32, 0x02, 0x99, 0x6f, 0x4a,
+	0x3b, 0xe8, 0x90, 0x69, 0x52, 0x6a, 0x47, 0x2f, 0x79, 0xc3, 0x14, 0x73, 0xc1, 0x78, 0x78, 0x01,
+	0x28, 0xd2, 0xd2, 0x2a, 0x42, 0x28, 0xba, 0xb9, 0x19, 0x01, 0x58, 0x19, 0x83, 0x45, 0x5e, 0x1e,
+	0x5b, 0x0f, 0x3c, 0x54, 0xae, 0x3c, 0x01, 0x1b, 0x02, 0x42, 0xb9, 0xd7, 0x22, 0xf9, 0xb9, 0xfd,
+	0xe5, 0x7c, 0x4d, 0x00, 0x00, 0x02, 0x00, 0x28, 0x00, 0x00, 0x04, 0xbc, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0f, 0x00, 0x65, 0xb5, 0x0d, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x1e, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x02, 0x01,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x1c, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x68, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x40, 0x16, 0x08, 0x08, 0x00, 0x00, 0x08, 0x0f, 0x08, 0x0f, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21,
+	0x11, 0x13, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x01, 0xd8, 0xfe, 0x50, 0x04, 0x94, 0xfe,
+	0x50, 0xe0, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x04, 0xfd, 0xcb, 0xcb, 0xfb, 0x03,
+	0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x02, 0x00, 0x2a, 0xff, 0xe7, 0x03, 0xac,
+	0x06, 0xbf, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x49, 0x40, 0x46, 0x0b, 0x0a, 0x02, 0x08, 0x06, 0x1b,
+	0x01, 0x02, 0x08, 0x00, 0x01, 0x05, 0x01, 0x01, 0x01, 0x00, 0x05, 0x04, 0x4c, 0x00, 0x08, 0x06,
+	0x02, 0x06, 0x08, 0x02, 0x80, 0x00, 0x07, 0x00, 0x06, 0x08, 0x07, 0x06, 0x67, 0x04, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x14, 0x11, 0x11, 0x23, 0x11, 0x13, 0x11, 0x12, 0x22, 0x09, 0x09, 0x1f,
+	0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x11, 0x11, 0x23, 0x35, 0x33, 0x35, 0x25, 0x15, 0x33, 0x15,
+	0x23, 0x11, 0x14, 0x16, 0x33, 0x32, 0x13, 0x23, 0x11, 0x21, 0x15, 0x10, 0x05, 0x35, 0x32, 0x35,
+	0x02, 0x99, 0x72, 0x4c, 0xfe, 0xc7, 0x78, 0x78, 0x01, 0x28, 0xdc, 0xdc, 0x2a, 0x42, 0x28, 0xbd,
+	0x72, 0x01, 0x03, 0xfe, 0xfd, 0x72, 0xba, 0xb9, 0x1a, 0x01, 0x68, 0x02, 0x42, 0xb9, 0xd7, 0x22,
+	0xf9, 0xb9, 0xfd, 0xe5, 0x7c, 0x4d, 0x04, 0xea, 0x01, 0x28, 0xe5, 0xfe, 0xaa, 0x15, 0x66, 0xa5,
+	0x00, 0x01, 0x00, 0x28, 0x00, 0x00, 0x04, 0xbc, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x54, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1c, 0x05, 0x01, 0x01, 0x06, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x04,
+	0x01, 0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07,
+	0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x03, 0x04, 0x01, 0x02, 0x01, 0x03, 0x02, 0x67, 0x05, 0x01, 0x01,
+	0x06, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x08, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40,
+	0x10, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09,
+	0x1d, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x01, 0xd8, 0xfe, 0xcb, 0x01, 0x35, 0xfe, 0x50, 0x04, 0x94, 0xfe, 0x50, 0x01, 0x35,
+	0xfe, 0xcb, 0x02, 0xbf, 0xad, 0x01, 0x91, 0xcb, 0xcb, 0xfe, 0x6f, 0xad, 0xfd, 0x41, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x2a, 0xff, 0xe7, 0x02, 0x9c, 0x05, 0x43, 0x00, 0x1c, 0x00, 0x46, 0x40, 0x43,
+	0x16, 0x01, 0x07, 0x06, 0x17, 0x01, 0x08, 0x07, 0x02, 0x4c, 0x08, 0x07, 0x02, 0x02, 0x4a, 0x05,
+	0x01, 0x00, 0x0a, 0x09, 0x02, 0x06, 0x07, 0x00, 0x06, 0x67, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f,
+	0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x07, 0x07, 0x08, 0x61, 0x00, 0x08, 0x08, 0x42, 0x08,
+	0x4e, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x1c, 0x23, 0x23, 0x11, 0x11, 0x11, 0x13, 0x11, 0x11, 0x11,
+	0x0b, 0x09, 0x1f, 0x2b, 0x13, 0x35, 0x33, 0x35, 0x23, 0x35, 0x33, 0x35, 0x25, 0x15, 0x33, 0x15,
+	0x23, 0x15, 0x33, 0x15, 0x23, 0x15, 0x14, 0x16, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x11,
+	0x35, 0x40, 0x62, 0x78, 0x78, 0x01, 0x28, 0xd2, 0xd2, 0xc6, 0xc6, 0x2a, 0x42, 0x28, 0x3b, 0x72,
+	0x4c, 0xfe, 0xc7, 0x02, 0x2b, 0x94, 0xd2, 0xb9, 0xd7, 0x22, 0xf9, 0xb9, 0xd2, 0x94, 0xb5, 0x7c,
+	0x4d, 0x0d, 0xb9, 0x1a, 0x01, 0x68, 0xdc, 0x00, 0x00, 0x02, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26,
+	0x07, 0x8f, 0x00, 0x14, 0x00, 0x2b, 0x00, 0x65, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x07,
+	0x01, 0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69, 0x00, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04,
+	0x6a, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f,
+	0x03, 0x4e, 0x1b, 0x40, 0x26, 0x02, 0x01, 0x00, 0x04, 0x01, 0x04, 0x00, 0x01, 0x80, 0x07, 0x01,
+	0x05, 0x00, 0x09, 0x04, 0x05, 0x09, 0x69, 0x00, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x6a,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x2b, 0x29,
+	0x21, 0x11, 0x24, 0x21, 0x15, 0x25, 0x12, 0x23, 0x10, 0x0a, 0x09, 0x1f, 0x2b, 0x13, 0x21, 0x11,
+	0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26,
+	0x26, 0x35, 0x01, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x35, 0x33, 0x10, 0x23,
+	0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01, 0x1c, 0x01,
+	0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x01, 0x8c, 0x94, 0xca, 0x40, 0x3e, 0x26,
+	0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44, 0x05, 0xc8,
+	0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50,
+	0xdb, 0xc4, 0x04, 0x10, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10,
+	0x06, 0x2d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x06, 0x4e, 0x00, 0x10,
+	0x00, 0x27, 0x01, 0x02, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01,
+	0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02,
+	0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x29, 0x00, 0x0a, 0x0a, 0x06, 0x61, 0x08,
+	0x01, 0x06, 0x06, 0x3a, 0x4d, 0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x0a, 0x0a, 0x06, 0x61,
+	0x08, 0x01, 0x06, 0x06, 0x3a, 0x4d, 0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b,
+	0x08, 0x01, 0x06, 0x00, 0x0a, 0x05, 0x06, 0x0a, 0x69, 0x09, 0x01, 0x05, 0x05, 0x07, 0x61, 0x00,
+	0x07, 0x07, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x08, 0x01,
+	0x06, 0x00, 0x0a, 0x05, 0x06, 0x0a, 0x69, 0x00, 0x07, 0x09, 0x01, 0x05, 0x01, 0x07, 0x05, 0x6a,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x19, 0x00, 0x00, 0x27, 0x25,
+	0x20, 0x1e, 0x1d, 0x1c, 0x1b, 0x19, 0x15, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23,
+	0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14,
+	0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x01, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33,
+	0x32, 0x35, 0x33, 0x10, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x03, 0x28, 0xa9,
+	0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0xfd, 0x50, 0x94, 0xca, 0x40,
+	0x3e, 0x26, 0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44,
+	0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05,
+	0x0d, 0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00,
+	0x00, 0x02, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26, 0x07, 0x19, 0x00, 0x14, 0x00, 0x18, 0x00, 0x53,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x04, 0x06, 0x01, 0x05, 0x00, 0x04, 0x05, 0x67,
+	0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x1d, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x00, 0x04, 0x06,
+	0x01, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03,
+	0x4e, 0x59, 0x40, 0x0e, 0x15, 0x15, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x07,
+	0x09, 0x1b, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06,
+	0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x13, 0x35, 0x21, 0x15, 0xa0, 0x01, 0x34, 0x8d,
+	0x9d, 0x01, 0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0xe5, 0x02, 0xe4,
+	0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d,
+	0x74, 0x50, 0xdb, 0xc4, 0x04, 0x2e, 0xad, 0xad, 0x00, 0x02, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50,
+	0x05, 0xc4, 0x00, 0x10, 0x00, 0x14, 0x00, 0xab, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d,
+	0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01,
+	0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1e, 0x08, 0x01,
+	0x06, 0x06, 0x05, 0x5f, 0x00, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x06, 0x06, 0x05, 0x5f, 0x00, 0x05, 0x05, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x08, 0x01, 0x06, 0x01, 0x05,
+	0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00,
+	0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x09,
+	0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x11, 0x21, 0x11, 0x01, 0x35, 0x21, 0x15, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28,
+	0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0xfc, 0xaa, 0x02, 0xe4, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08,
+	0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x17, 0xad, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x20, 0x00, 0x5a,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00,
+	0x07, 0x00, 0x05, 0x07, 0x69, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x02,
+	0x01, 0x00, 0x07, 0x01, 0x07, 0x00, 0x01, 0x80, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x69,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0b, 0x22, 0x11,
+	0x21, 0x15, 0x25, 0x12, 0x23, 0x10, 0x08, 0x09, 0x1e, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33,
+	0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x13,
+	0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0xa0, 0x01, 0x34, 0x8d, 0x9d,
+	0x01, 0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0xf6, 0x94, 0x29, 0xa5,
+	0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f,
+	0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x05, 0x51, 0x8e, 0x8e,
+	0x93, 0xae, 0xad, 0x00, 0x00, 0x02, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x1c, 0x00, 0xea, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01,
+	0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02,
+	0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d,
+	0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x27, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x27, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x07, 0x01,
+	0x05, 0x06, 0x05, 0x85, 0x00, 0x06, 0x00, 0x08, 0x01, 0x06, 0x08, 0x69, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x00, 0x00, 0x1b, 0x19, 0x17, 0x16, 0x15, 0x13,
+	0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x35,
+	0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x01,
+	0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x28, 0xa9, 0xcd, 0xfe,
+	0xd6, 0x01, 0x28, 0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0xfc, 0xba, 0x94, 0x29, 0xa5, 0xa3, 0x2a,
+	0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50,
+	0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x06, 0x44, 0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x03, 0x00, 0xa0,
+	0xff, 0xdb, 0x05, 0x26, 0x08, 0x19, 0x00, 0x14, 0x00, 0x20, 0x00, 0x2c, 0x00, 0x6e, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x00, 0x07, 0x06, 0x05, 0x07, 0x69, 0x09, 0x01, 0x06,
+	0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x69, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x26, 0x02, 0x01, 0x00, 0x04, 0x01,
+	0x04, 0x00, 0x01, 0x80, 0x00, 0x05, 0x00, 0x07, 0x06, 0x05, 0x07, 0x69, 0x09, 0x01, 0x06, 0x08,
+	0x01, 0x04, 0x00, 0x06, 0x04, 0x69, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03,
+	0x4e, 0x59, 0x40, 0x17, 0x22, 0x21, 0x16, 0x15, 0x28, 0x26, 0x21, 0x2c, 0x22, 0x2c, 0x1c, 0x1a,
+	0x15, 0x20, 0x16, 0x20, 0x25, 0x12, 0x23, 0x10, 0x0a, 0x09, 0x1a, 0x2b, 0x13, 0x21, 0x11, 0x14,
+	0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26,
+	0x35, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01, 0x1c,
+	0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x02, 0x54, 0x60, 0x87, 0x88, 0x62,
+	0x61, 0x89, 0x89, 0x63, 0x35, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0x05, 0xc8, 0xfc, 0x75, 0xd6,
+	0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04,
+	0x06, 0x8a, 0x60, 0x62, 0x89, 0x89, 0x61, 0x63, 0x88, 0x6f, 0x48, 0x34, 0x33, 0x48, 0x48, 0x33,
+	0x33, 0x49, 0x00, 0x00, 0x00, 0x03, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x06, 0xd8, 0x00, 0x10,
+	0x00, 0x1c, 0x00, 0x28, 0x00, 0xca, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02,
+	0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01,
+	0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x00, 0x08,
+	0x07, 0x06, 0x08, 0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01, 0x07, 0x05, 0x69, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x00, 0x06, 0x00, 0x08, 0x07, 0x06, 0x08,
+	0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01, 0x07, 0x05, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x00, 0x08, 0x07, 0x06, 0x08, 0x69, 0x0b, 0x01, 0x07,
+	0x0a, 0x01, 0x05, 0x01, 0x07, 0x05, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04,
+	0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59,
+	0x40, 0x1d, 0x1e, 0x1d, 0x12, 0x11, 0x00, 0x00, 0x24, 0x22, 0x1d, 0x28, 0x1e, 0x28, 0x18, 0x16,
+	0x11, 0x1c, 0x12, 0x1c, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b,
+	0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21,
+	0x11, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01,
+	0x28, 0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0xfe, 0x18, 0x60, 0x87, 0x88, 0x62, 0x61, 0x89, 0x89,
+	0x63, 0x35, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41,
+	0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x03, 0x8a, 0x60, 0x62, 0x89, 0x89, 0x61, 0x63,
+	0x88, 0x6f, 0x48, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x49, 0x00, 0x00, 0x00, 0x03, 0x00, 0xa0,
+	0xff, 0xdb, 0x05, 0x26, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0x61, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05,
+	0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f,
+	0x03, 0x4e, 0x1b, 0x40, 0x20, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01,
+	0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x19, 0x19, 0x15, 0x15, 0x19, 0x1c, 0x19, 0x1c,
+	0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0a, 0x09, 0x1b, 0x2b, 0x13,
+	0x21, 0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22,
+	0x27, 0x26, 0x26, 0x35, 0x01, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33, 0x01, 0xa0, 0x01, 0x34, 0x8d,
+	0x9d, 0x01, 0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x01, 0x1c, 0xf1,
+	0xe4, 0xfe, 0xbf, 0xe5, 0xf0, 0xe5, 0xfe, 0xbf, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f,
+	0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x10, 0x01, 0x41,
+	0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x03, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x6a,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0xe8, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01,
+	0x02, 0x01, 0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21,
+	0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05,
+	0x5f, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x01, 0x05,
+	0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b,
+	0x08, 0x0a, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01,
+	0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x59, 0x59, 0x40, 0x1d, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16,
+	0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0c, 0x09,
+	0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37,
+	0x11, 0x21, 0x11, 0x01, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33, 0x01, 0x03, 0x28, 0xa9, 0xcd, 0xfe,
+	0xd6, 0x01, 0x28, 0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0xfc, 0xcc, 0xf1, 0xe4, 0xfe, 0xbf, 0xe5,
+	0xf0, 0xe5, 0xfe, 0xbf, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02,
+	0xcc, 0xfb, 0xb6, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xa0, 0xfe, 0x8e, 0x05, 0x26, 0x05, 0xc8, 0x00, 0x21, 0x00, 0x77, 0x40, 0x0a,
+	0x15, 0x01, 0x03, 0x05, 0x16, 0x01, 0x04, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x1b, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3f,
+	0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x18, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x02, 0x01, 0x00, 0x00, 0x38,
+	0x4d, 0x00, 0x01, 0x01, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b, 0x40, 0x18, 0x02,
+	0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x00, 0x01, 0x01, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x13, 0x23, 0x29, 0x12, 0x23,
+	0x10, 0x06, 0x09, 0x1c, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11,
+	0x14, 0x06, 0x07, 0x06, 0x07, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x35,
+	0x34, 0x37, 0x22, 0x27, 0x26, 0x26, 0x35, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01, 0x1c, 0x01, 0x0c,
+	0x4e, 0x67, 0x44, 0x59, 0xaa, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9, 0x92, 0xfc, 0x9b, 0x6b,
+	0x55, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f,
+	0x34, 0x1b, 0x53, 0x5a, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x5f, 0x4f, 0x74, 0x50, 0xdb, 0xc4, 0x00,
+	0x00, 0x01, 0x00, 0x88, 0xfe, 0x8e, 0x04, 0x50, 0x04, 0x4a, 0x00, 0x1d, 0x00, 0xd5, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x13, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x00, 0x02, 0x00, 0x02, 0x17, 0x01,
+	0x05, 0x00, 0x18, 0x01, 0x06, 0x05, 0x04, 0x4c, 0x1b, 0x40, 0x17, 0x0d, 0x01, 0x02, 0x01, 0x01,
+	0x01, 0x04, 0x02, 0x17, 0x01, 0x05, 0x00, 0x18, 0x01, 0x06, 0x05, 0x04, 0x4c, 0x00, 0x01, 0x04,
+	0x01, 0x4b, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1c, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x04, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x3d, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x20, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3d, 0x06, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x06, 0x05, 0x06, 0x65, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x06, 0x05, 0x06, 0x65, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0a, 0x23, 0x23, 0x11, 0x12, 0x23, 0x12, 0x22, 0x07,
+	0x09, 0x1d, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x11, 0x21, 0x11, 0x23, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x35,
+	0x34, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0x8a,
+	0xba, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41,
+	0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x56, 0x5e, 0x5f, 0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x00,
+	0x00, 0x02, 0x00, 0x19, 0x00, 0x00, 0x07, 0x75, 0x07, 0x8f, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x69,
+	0x40, 0x0c, 0x12, 0x01, 0x06, 0x05, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06,
+	0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x1b, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02,
+	0x01, 0x02, 0x00, 0x03, 0x00, 0x85, 0x08, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40,
+	0x17, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x14, 0x0d, 0x14, 0x11, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00,
+	0x0c, 0x11, 0x12, 0x12, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x21, 0x01, 0x01, 0x21, 0x13,
+	0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x03, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0x95,
+	0xfe, 0x84, 0x01, 0x23, 0x01, 0x19, 0x01, 0x18, 0x01, 0x01, 0xff, 0x01, 0x2d, 0xdb, 0xfe, 0x65,
+	0xfe, 0xd9, 0xf0, 0xfe, 0xf8, 0x47, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x05, 0xc8,
+	0xfb, 0xc5, 0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03, 0xf7, 0xfc, 0x09, 0x06, 0x4e,
+	0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3e, 0x00, 0x00, 0x05, 0xfc,
+	0x06, 0x44, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x96, 0x40, 0x0c, 0x12, 0x01, 0x06, 0x05, 0x0b, 0x06,
+	0x03, 0x03, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1e, 0x09, 0x07, 0x02,
+	0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x02, 0x01, 0x02, 0x00,
+	0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1e, 0x09, 0x07, 0x02, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80, 0x02, 0x01, 0x02,
+	0x00, 0x00, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x08, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x1e, 0x09, 0x07, 0x02, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80, 0x02, 0x01,
+	0x02, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x08, 0x04, 0x02, 0x03, 0x03, 0x3c,
+	0x03, 0x4e, 0x59, 0x59, 0x40, 0x17, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x14, 0x0d, 0x14, 0x11, 0x10,
+	0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x01,
+	0x21, 0x13, 0x13, 0x21, 0x13, 0x13, 0x33, 0x01, 0x21, 0x0b, 0x02, 0x13, 0x21, 0x13, 0x23, 0x27,
+	0x23, 0x07, 0x01, 0x48, 0xfe, 0xf6, 0x01, 0x0b, 0xb9, 0xc1, 0x01, 0x00, 0xaa, 0xc8, 0xc7, 0xfe,
+	0xe2, 0xfe, 0xe5, 0xa4, 0xbb, 0x9a, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x04, 0x4a,
+	0xfc, 0xff, 0x03, 0x01, 0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6, 0x02, 0xf1, 0xfd, 0x0f, 0x05, 0x03,
+	0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x1c, 0x00, 0x00, 0x05, 0x3b,
+	0x07, 0x8f, 0x00, 0x08, 0x00, 0x10, 0x00, 0x6b, 0x40, 0x0c, 0x0e, 0x01, 0x04, 0x03, 0x07, 0x04,
+	0x01, 0x03, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x07, 0x05, 0x02,
+	0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x03, 0x03,
+	0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1e, 0x07, 0x05, 0x02, 0x04,
+	0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x01, 0x01, 0x00, 0x02, 0x03, 0x00, 0x02, 0x7e, 0x00, 0x03,
+	0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x15, 0x09, 0x09, 0x00,
+	0x00, 0x09, 0x10, 0x09, 0x10, 0x0d, 0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x08,
+	0x09, 0x18, 0x2b, 0x21, 0x11, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01, 0x11, 0x01, 0x13, 0x21, 0x13,
+	0x23, 0x27, 0x23, 0x07, 0x02, 0x07, 0xfe, 0x15, 0x01, 0x55, 0x01, 0x62, 0x01, 0x74, 0xf4, 0xfe,
+	0x00, 0xfe, 0x27, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x02, 0x6c, 0x03, 0x5c, 0xfd,
+	0x8f, 0x02, 0x71, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00,
+	0x00, 0x02, 0x00, 0x19, 0xfe, 0x75, 0x04, 0x59, 0x06, 0x44, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x5b,
+	0x40, 0x0a, 0x0d, 0x01, 0x04, 0x03, 0x03, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x1b, 0x06, 0x05, 0x02, 0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x03, 0x03,
+	0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40,
+	0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x05, 0x02, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x59, 0x40, 0x0e, 0x08, 0x08, 0x08, 0x0f,
+	0x08, 0x0f, 0x11, 0x12, 0x11, 0x12, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x21, 0x01, 0x21, 0x13, 0x01,
+	0x33, 0x01, 0x21, 0x13, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0xa3, 0xfe, 0x76, 0x01,
+	0x38, 0xfe, 0x01, 0x2e, 0xdc, 0xfd, 0x80, 0xfe, 0xd2, 0x43, 0xf1, 0x01, 0x11, 0xf1, 0xb3, 0xc5,
+	0x03, 0xc5, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x06, 0x8e, 0x01, 0x41, 0xfe, 0xbf,
+	0xc5, 0xc5, 0x00, 0x00, 0x00, 0x03, 0x00, 0x1c, 0x00, 0x00, 0x05, 0x3b, 0x07, 0x40, 0x00, 0x08,
+	0x00, 0x0c, 0x00, 0x10, 0x00, 0x67, 0xb7, 0x07, 0x04, 0x01, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x05, 0x01, 0x03, 0x09, 0x06, 0x08, 0x03, 0x04, 0x00, 0x03,
+	0x04, 0x67, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x1c, 0x01, 0x01, 0x00, 0x04, 0x02, 0x04, 0x00, 0x02, 0x80, 0x05, 0x01, 0x03, 0x09, 0x06,
+	0x08, 0x03, 0x04, 0x00, 0x03, 0x04, 0x67, 0x07, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40,
+	0x1b, 0x0d, 0x0d, 0x09, 0x09, 0x00, 0x00, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x09, 0x0c, 0x09,
+	0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x0a, 0x09, 0x18, 0x2b, 0x21, 0x11, 0x01,
+	0x21, 0x01, 0x01, 0x33, 0x01, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x02, 0x07,
+	0xfe, 0x15, 0x01, 0x55, 0x01, 0x62, 0x01, 0x74, 0xf4, 0xfe, 0x00, 0xfe, 0x60, 0xde, 0xc5, 0xdf,
+	0x02, 0x6c, 0x03, 0x5c, 0xfd, 0x8f, 0x02, 0x71, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x62, 0xde, 0xde,
+	0xde, 0xde, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5e, 0x00, 0x00, 0x04, 0x86, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x0d, 0x00, 0x6b, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05,
+	0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d,
+	0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33,
+	0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x01, 0x13, 0x21, 0x01, 0x5e, 0x02, 0xc2,
+	0xfd, 0x69, 0x03, 0xfd, 0xfd, 0x3e, 0x02, 0xc2, 0xfd, 0x2d, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0xd2,
+	0x04, 0x2b, 0xcb, 0xcb, 0xfb, 0xd5, 0xd2, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x6f, 0x00, 0x00, 0x03, 0x9d, 0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x9a,
+	0xb7, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x24,
+	0x07, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x21,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03,
+	0x4e, 0x59, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00,
+	0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21,
+	0x15, 0x01, 0x21, 0x15, 0x01, 0x13, 0x21, 0x01, 0x6f, 0x01, 0xd7, 0xfe, 0x45, 0x03, 0x06, 0xfe,
+	0x29, 0x01, 0xe3, 0xfd, 0xaa, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0xc5, 0x02, 0xcc, 0xb9, 0xb9, 0xfd,
+	0x34, 0xc5, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x5e, 0x00, 0x00, 0x04, 0x86,
+	0x07, 0x94, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x67, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02,
+	0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04, 0x05,
+	0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01,
+	0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d,
+	0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33,
+	0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x01, 0x11, 0x21, 0x11, 0x5e, 0x02, 0xc2,
+	0xfd, 0x69, 0x03, 0xfd, 0xfd, 0x3e, 0x02, 0xc2, 0xfd, 0x6d, 0x01, 0x28, 0xd2, 0x04, 0x2b, 0xcb,
+	0xcb, 0xfb, 0xd5, 0xd2, 0x06, 0x6c, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x00, 0x02, 0x00, 0x6f,
+	0x00, 0x00, 0x03, 0x9d, 0x06, 0x3f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x95, 0xb7, 0x06, 0x01, 0x00,
+	0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x07, 0x01, 0x05, 0x05,
+	0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x32, 0x50, 0x58, 0x40, 0x21, 0x07, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06,
+	0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04,
+	0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03,
+	0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00,
+	0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19,
+	0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x01, 0x11, 0x21, 0x11, 0x6f,
+	0x01, 0xd7, 0xfe, 0x45, 0x03, 0x06, 0xfe, 0x29, 0x01, 0xe3, 0xfd, 0xdd, 0x01, 0x28, 0xc5, 0x02,
+	0xcc, 0xb9, 0xb9, 0xfd, 0x34, 0xc5, 0x05, 0x17, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x02, 0x00, 0x5e,
+	0x00, 0x00, 0x04, 0x86, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x11, 0x00, 0x76, 0x40, 0x0e, 0x0f, 0x01,
+	0x04, 0x05, 0x01, 0x4c, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x03,
+	0x5f, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a,
+	0x11, 0x0a, 0x11, 0x0e, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x09, 0x09,
+	0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x03, 0x03, 0x21, 0x03,
+	0x33, 0x17, 0x33, 0x37, 0x5e, 0x02, 0xc2, 0xfd, 0x69, 0x03, 0xfd, 0xfd, 0x3e, 0x02, 0xc2, 0x85,
+	0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xd2, 0x04, 0x2b, 0xcb, 0xcb, 0xfb, 0xd5, 0xd2,
+	0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x02, 0x00, 0x6f, 0x00, 0x00, 0x03, 0x9d,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x11, 0x00, 0xa6, 0x40, 0x0e, 0x0f, 0x01, 0x04, 0x05, 0x01, 0x4c,
+	0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x25, 0x00,
+	0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05,
+	0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40,
+	0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x11, 0x0a, 0x11, 0x0e,
+	0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x35,
+	0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x03, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x6f, 0x01, 0xd7, 0xfe, 0x45, 0x03, 0x06, 0xfe, 0x29, 0x01, 0xe3, 0x15, 0xf1, 0xfe, 0xef, 0xf1,
+	0xb3, 0xc5, 0x03, 0xc5, 0xc5, 0x02, 0xcc, 0xb9, 0xb9, 0xfd, 0x34, 0xc5, 0x06, 0x44, 0xfe, 0xbf,
+	0x01, 0x41, 0xc6, 0xc6, 0x00, 0x01, 0x00, 0x34, 0x00, 0x00, 0x02, 0xe0, 0x06, 0x44, 0x00, 0x0f,
+	0x00, 0x7d, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02, 0x0a, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x04, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x04,
+	0x4e, 0x1b, 0x40, 0x19, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x00, 0x00, 0x00, 0x01,
+	0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x23, 0x22, 0x11, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33,
+	0x11, 0x23, 0x35, 0x33, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x11, 0xa6,
+	0x72, 0x72, 0x01, 0x86, 0x54, 0x60, 0x52, 0x41, 0x7f, 0x03, 0x91, 0xb9, 0x4f, 0x01, 0xab, 0x1a,
+	0xc0, 0x21, 0xe7, 0xfb, 0x5c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x31, 0xfe, 0xd8, 0x04, 0x40,
+	0x05, 0xed, 0x00, 0x13, 0x00, 0x65, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02, 0x0a, 0x01, 0x01, 0x03,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x07, 0x01, 0x06, 0x00, 0x06, 0x86, 0x04,
+	0x01, 0x01, 0x05, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x3e, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x07, 0x01, 0x06, 0x00, 0x06, 0x86, 0x00, 0x02, 0x00,
+	0x03, 0x01, 0x02, 0x03, 0x69, 0x04, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x04, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x05, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x13, 0x00,
+	0x13, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x08, 0x09, 0x1c, 0x2b, 0x13, 0x13, 0x23, 0x35, 0x33,
+	0x37, 0x12, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x03, 0x07, 0x33, 0x15, 0x23, 0x03, 0x31,
+	0xc6, 0x95, 0xb9, 0x14, 0x75, 0x01, 0xc8, 0x75, 0x5f, 0x7b, 0x5d, 0xc9, 0x34, 0x22, 0xb1, 0xd6,
+	0xc5, 0xfe, 0xd8, 0x03, 0xe1, 0xb9, 0x5a, 0x02, 0x21, 0x12, 0xcc, 0x26, 0xfe, 0xee, 0xb1, 0xb9,
+	0xfc, 0x1f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x12, 0x00, 0x74, 0x40, 0x0a, 0x10, 0x01, 0x05, 0x06, 0x0a, 0x01, 0x04, 0x00,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85,
+	0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x08, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x09, 0x07, 0x02,
+	0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40,
+	0x18, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x12, 0x0b, 0x12, 0x0f, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03,
+	0x21, 0x03, 0x13, 0x21, 0x03, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x0c, 0x02, 0x3e,
+	0x01, 0x34, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc, 0xe6, 0x01, 0xa7,
+	0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75,
+	0x02, 0x50, 0x02, 0x4e, 0x02, 0xf1, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x03, 0x00, 0x45,
+	0xff, 0xe7, 0x04, 0x3b, 0x06, 0x44, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x2d, 0x00, 0xf7, 0x4b, 0xb0,
+	0x2d, 0x50, 0x58, 0x40, 0x18, 0x2b, 0x01, 0x08, 0x09, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02,
+	0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x1b, 0x40, 0x1b,
+	0x2b, 0x01, 0x08, 0x09, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x07, 0x06,
+	0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x06, 0x4c, 0x59, 0x4b, 0xb0, 0x29, 0x50,
+	0x58, 0x40, 0x2e, 0x00, 0x08, 0x09, 0x04, 0x09, 0x08, 0x04, 0x80, 0x00, 0x02, 0x00, 0x06, 0x05,
+	0x02, 0x06, 0x69, 0x0b, 0x0a, 0x02, 0x09, 0x09, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x2b, 0x0b, 0x0a, 0x02, 0x09, 0x08, 0x09, 0x85,
+	0x00, 0x08, 0x04, 0x08, 0x85, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x35, 0x0b, 0x0a, 0x02, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08,
+	0x04, 0x08, 0x85, 0x00, 0x02, 0x00, 0x06, 0x07, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61,
+	0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x14,
+	0x26, 0x26, 0x26, 0x2d, 0x26, 0x2d, 0x2a, 0x29, 0x12, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23,
+	0x22, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26,
+	0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14,
+	0x33, 0x32, 0x25, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01, 0x03, 0x21, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9, 0x92, 0xb3, 0x02,
+	0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82, 0x46, 0xf7, 0x53,
+	0x40, 0x66, 0x01, 0x60, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xa9, 0xa6, 0x1c, 0x8f,
+	0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a, 0x81, 0x70,
+	0xdf, 0xb2, 0x3f, 0x53, 0x05, 0x94, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x02, 0x00, 0x57,
+	0x00, 0x00, 0x03, 0x4a, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x73, 0xb5, 0x11, 0x01, 0x06,
+	0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07,
+	0x85, 0x00, 0x06, 0x02, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38,
+	0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40,
+	0x22, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02, 0x06, 0x85, 0x00, 0x02, 0x03,
+	0x01, 0x01, 0x00, 0x02, 0x01, 0x68, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05,
+	0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f,
+	0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33,
+	0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x13, 0x03, 0x21, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0x64, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0x0e, 0xf1, 0xfe, 0xef, 0xf1, 0xb3,
+	0xc5, 0x03, 0xc5, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x07, 0x8f, 0xfe, 0xbf, 0x01,
+	0x41, 0xc6, 0xc6, 0x00, 0x00, 0x02, 0xff, 0xaf, 0x00, 0x00, 0x02, 0xa2, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x0b, 0x00, 0x7d, 0xb5, 0x09, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58,
+	0x40, 0x1b, 0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x06, 0x04, 0x02, 0x03, 0x03, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x18, 0x06, 0x04, 0x02, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02,
+	0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18,
+	0x06, 0x04, 0x02, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x05, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x0b, 0x04, 0x0b, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17,
+	0x2b, 0x33, 0x11, 0x21, 0x11, 0x13, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x94, 0x01, 0x28,
+	0xe6, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x04, 0x4a, 0xfb, 0xb6, 0x06, 0x44, 0xfe,
+	0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x76, 0xb5, 0x1d, 0x01, 0x04, 0x05, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x09, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x08, 0x01,
+	0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x09, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18, 0x1f, 0x1c, 0x1b, 0x1a, 0x19,
+	0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b,
+	0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11,
+	0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe, 0x82,
+	0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0x02, 0x33, 0xf1, 0xfe, 0xef, 0xf1, 0xb3,
+	0xc5, 0x03, 0xc5, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96,
+	0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe,
+	0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x06, 0xe8, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f,
+	0x00, 0x7b, 0xb5, 0x1d, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x26,
+	0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x09, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x62, 0x07,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x09, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85,
+	0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08,
+	0x01, 0x02, 0x02, 0x00, 0x62, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18,
+	0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18, 0x1f, 0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00,
+	0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x02, 0x6b, 0xf6,
+	0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70, 0x80, 0x81, 0x6d, 0x6d,
+	0x80, 0x80, 0x01, 0xe7, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x19, 0x01, 0x3b, 0x01,
+	0x03, 0x01, 0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6,
+	0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x05, 0xa4, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26, 0x07, 0x8f, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x5e,
+	0xb5, 0x1a, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x07, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x07, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x15, 0x15,
+	0x15, 0x1c, 0x15, 0x1c, 0x11, 0x16, 0x25, 0x12, 0x23, 0x10, 0x08, 0x09, 0x1c, 0x2b, 0x13, 0x21,
+	0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27,
+	0x26, 0x26, 0x35, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0xa0, 0x01, 0x34, 0x8d, 0x9d,
+	0x01, 0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x03, 0xbd, 0xf1, 0xfe,
+	0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2,
+	0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x05, 0x51, 0xfe, 0xbf, 0x01, 0x41,
+	0xc6, 0xc6, 0x00, 0x00, 0x00, 0x02, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x18, 0x00, 0xec, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0e, 0x16, 0x01, 0x05, 0x06, 0x0d,
+	0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x03, 0x4c, 0x1b, 0x40, 0x0e, 0x16, 0x01, 0x05, 0x06,
+	0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x22, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x08, 0x04, 0x02, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x01,
+	0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06,
+	0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04,
+	0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23,
+	0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18,
+	0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b,
+	0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21,
+	0x11, 0x03, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01,
+	0x28, 0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0x6a, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5,
+	0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x06,
+	0x44, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x00, 0x04, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26,
+	0x08, 0x7d, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0x20, 0x00, 0x7b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x26, 0x00, 0x08, 0x0c, 0x01, 0x09, 0x04, 0x08, 0x09, 0x67, 0x06, 0x01, 0x04, 0x0b,
+	0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01,
+	0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x29, 0x02, 0x01, 0x00, 0x05,
+	0x01, 0x05, 0x00, 0x01, 0x80, 0x00, 0x08, 0x0c, 0x01, 0x09, 0x04, 0x08, 0x09, 0x67, 0x06, 0x01,
+	0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1e, 0x1d, 0x1d, 0x19, 0x19, 0x15, 0x15, 0x1d, 0x20,
+	0x1d, 0x20, 0x1f, 0x1e, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25,
+	0x12, 0x23, 0x10, 0x0d, 0x09, 0x1b, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11,
+	0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x01, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x21, 0x15, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01, 0x1c, 0x01,
+	0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x01, 0x0d, 0xde, 0xd9, 0xdf, 0xfd, 0x2e,
+	0x02, 0xe4, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7,
+	0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde, 0xde, 0xde, 0x01, 0x6e, 0xad, 0xad,
+	0x00, 0x04, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x07, 0x28, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18,
+	0x00, 0x1c, 0x01, 0x14, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01,
+	0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02,
+	0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05,
+	0x09, 0x0a, 0x67, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x09, 0x0e, 0x01,
+	0x0a, 0x05, 0x09, 0x0a, 0x67, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05,
+	0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x2c, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x07, 0x01, 0x05, 0x0d, 0x08,
+	0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04,
+	0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x2c, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c,
+	0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04,
+	0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x25, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a,
+	0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10,
+	0x12, 0x23, 0x12, 0x22, 0x0f, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21,
+	0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x21, 0x15, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32, 0x45, 0x77,
+	0x8a, 0x01, 0x28, 0xfc, 0xc7, 0xde, 0xed, 0xdf, 0xfd, 0x39, 0x02, 0xe4, 0xb6, 0xcf, 0x01, 0x5b,
+	0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde,
+	0xde, 0x01, 0x6e, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26,
+	0x08, 0xf3, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0x20, 0x00, 0x7f, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x28, 0x00, 0x08, 0x09, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x09, 0x85, 0x06, 0x01,
+	0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x08,
+	0x09, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x09, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00,
+	0x01, 0x80, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x00, 0x01,
+	0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1e, 0x1d, 0x1d, 0x19, 0x19,
+	0x15, 0x15, 0x1d, 0x20, 0x1d, 0x20, 0x1f, 0x1e, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18,
+	0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0d, 0x09, 0x1b, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16,
+	0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35,
+	0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x13, 0x21, 0x01, 0xa0, 0x01, 0x34, 0x8d,
+	0x9d, 0x01, 0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x01, 0x0d, 0xde,
+	0xd9, 0xdf, 0xfe, 0x3c, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01,
+	0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x24, 0xde,
+	0xde, 0xde, 0xde, 0x01, 0x50, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x88,
+	0xff, 0xe7, 0x04, 0x50, 0x07, 0xa8, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x01, 0x1c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02,
+	0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a,
+	0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x30, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e,
+	0x01, 0x0a, 0x05, 0x0a, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05,
+	0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x2e, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x07, 0x01, 0x05,
+	0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b,
+	0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x2e, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x07, 0x01,
+	0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x59, 0x40, 0x25, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x1c,
+	0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12,
+	0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0f, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23,
+	0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x01, 0x35, 0x33,
+	0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x13, 0x21, 0x01, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01,
+	0x28, 0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0xfc, 0xc7, 0xde, 0xed, 0xdf, 0xfe, 0x47, 0xf1, 0x01,
+	0x19, 0xfe, 0xbf, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc,
+	0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x01, 0x5a, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0xa0, 0xff, 0xdb, 0x05, 0x26, 0x08, 0xf3, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c,
+	0x00, 0x24, 0x00, 0x8a, 0xb5, 0x22, 0x01, 0x08, 0x09, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x29, 0x0d, 0x0a, 0x02, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x06, 0x01,
+	0x04, 0x0c, 0x07, 0x0b, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2c, 0x0d, 0x0a,
+	0x02, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05,
+	0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x0c, 0x07, 0x0b, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x00,
+	0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x20, 0x1d, 0x1d, 0x19,
+	0x19, 0x15, 0x15, 0x1d, 0x24, 0x1d, 0x24, 0x21, 0x20, 0x1f, 0x1e, 0x19, 0x1c, 0x19, 0x1c, 0x1b,
+	0x1a, 0x15, 0x18, 0x15, 0x18, 0x16, 0x25, 0x12, 0x23, 0x10, 0x0e, 0x09, 0x1b, 0x2b, 0x13, 0x21,
+	0x11, 0x14, 0x16, 0x33, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27,
+	0x26, 0x26, 0x35, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x13, 0x03, 0x21, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01, 0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed,
+	0xfc, 0x9b, 0x6b, 0x55, 0x01, 0x0d, 0xde, 0xd9, 0xdf, 0x1a, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5,
+	0x03, 0xc5, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2, 0xfc, 0x73, 0xcd, 0xd7,
+	0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde, 0xde, 0xde, 0x02, 0x91, 0xfe, 0xbf,
+	0x01, 0x41, 0xc6, 0xc6, 0x00, 0x04, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x07, 0xa8, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x20, 0x01, 0x2a, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0e, 0x1e,
+	0x01, 0x09, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x03, 0x4c, 0x1b, 0x40, 0x0e,
+	0x1e, 0x01, 0x09, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x01, 0x01, 0x04, 0x02, 0x03, 0x4c, 0x59, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x2d, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05,
+	0x09, 0x85, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0c, 0x04, 0x02, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x31, 0x0f, 0x0b, 0x02, 0x0a, 0x09,
+	0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07,
+	0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0c, 0x01, 0x04, 0x04, 0x39,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x2f, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85,
+	0x07, 0x01, 0x05, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x0c, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2f, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05,
+	0x09, 0x85, 0x07, 0x01, 0x05, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x0c, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x27, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11,
+	0x00, 0x00, 0x19, 0x20, 0x19, 0x20, 0x1d, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16,
+	0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x10, 0x09,
+	0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37,
+	0x11, 0x21, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x13, 0x03, 0x21, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01, 0x28, 0x32, 0x45, 0x77, 0x8a, 0x01,
+	0x28, 0xfc, 0xc7, 0xde, 0xed, 0xdf, 0x25, 0xf1, 0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0xb6,
+	0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb, 0xb6, 0x05, 0x0d,
+	0xde, 0xde, 0xde, 0xde, 0x02, 0x9b, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00, 0x04, 0x00, 0xa0,
+	0xff, 0xdb, 0x05, 0x26, 0x08, 0xf3, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0x20, 0x00, 0x79,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08,
+	0x85, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40,
+	0x2a, 0x00, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01,
+	0x05, 0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1a, 0x19, 0x19,
+	0x15, 0x15, 0x20, 0x1f, 0x1e, 0x1d, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18,
+	0x16, 0x25, 0x12, 0x23, 0x10, 0x0c, 0x09, 0x1b, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x20,
+	0x11, 0x11, 0x21, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x01, 0x35,
+	0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x23, 0x01, 0x21, 0xa0, 0x01, 0x34, 0x8d, 0x9d, 0x01,
+	0x1c, 0x01, 0x0c, 0x4e, 0x67, 0x8d, 0xed, 0xfc, 0x9b, 0x6b, 0x55, 0x01, 0x0d, 0xde, 0xd9, 0xdf,
+	0xfb, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0x05, 0xc8, 0xfc, 0x75, 0xd6, 0xc0, 0x01, 0x7f, 0x03, 0xa2,
+	0xfc, 0x73, 0xcd, 0xd7, 0x4f, 0x6d, 0x74, 0x50, 0xdb, 0xc4, 0x04, 0x24, 0xde, 0xde, 0xde, 0xde,
+	0x01, 0x50, 0x01, 0x41, 0x00, 0x04, 0x00, 0x88, 0xff, 0xe7, 0x04, 0x50, 0x07, 0xa8, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x01, 0x14, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0a, 0x0d,
+	0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01,
+	0x01, 0x01, 0x04, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x0a,
+	0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f,
+	0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40,
+	0x2f, 0x00, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06,
+	0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b,
+	0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05,
+	0x09, 0x85, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2d, 0x00, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05,
+	0x09, 0x85, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x21, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00,
+	0x1c, 0x1b, 0x1a, 0x19, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12,
+	0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0e, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23,
+	0x20, 0x11, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x01, 0x35, 0x33,
+	0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x23, 0x01, 0x21, 0x03, 0x28, 0xa9, 0xcd, 0xfe, 0xd6, 0x01,
+	0x28, 0x32, 0x45, 0x77, 0x8a, 0x01, 0x28, 0xfc, 0xc7, 0xde, 0xed, 0xdf, 0xf0, 0xc9, 0xfe, 0xbf,
+	0x01, 0x19, 0xb6, 0xcf, 0x01, 0x5b, 0x03, 0x08, 0xfd, 0x41, 0x6b, 0x50, 0xae, 0x02, 0xcc, 0xfb,
+	0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x01, 0x5a, 0x01, 0x41, 0x00, 0x00, 0x03, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x08, 0x91, 0x00, 0x1a, 0x00, 0x1d, 0x00, 0x29, 0x00, 0x6a, 0x40, 0x0c,
+	0x03, 0x01, 0x06, 0x00, 0x1d, 0x12, 0x0b, 0x03, 0x04, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1f, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x04, 0x00,
+	0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x01, 0x05, 0x05, 0x3e, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06, 0x05, 0x06, 0x85, 0x07,
+	0x01, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x03, 0x01, 0x01,
+	0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x1f, 0x1e, 0x25, 0x23, 0x1e, 0x29, 0x1f, 0x29, 0x1a,
+	0x11, 0x11, 0x1a, 0x11, 0x08, 0x09, 0x1b, 0x2b, 0x01, 0x13, 0x21, 0x01, 0x23, 0x16, 0x17, 0x16,
+	0x15, 0x14, 0x07, 0x07, 0x01, 0x21, 0x03, 0x21, 0x03, 0x23, 0x01, 0x26, 0x27, 0x26, 0x35, 0x34,
+	0x37, 0x36, 0x37, 0x03, 0x21, 0x03, 0x13, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15,
+	0x14, 0x16, 0x02, 0x85, 0xf1, 0x01, 0x0f, 0xfe, 0xbf, 0x01, 0x27, 0x20, 0x45, 0x45, 0x0c, 0x02,
+	0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe1, 0x02, 0x3e, 0x06, 0x06, 0x43, 0x44, 0x20, 0x27,
+	0xb6, 0x01, 0xcc, 0xe6, 0x2d, 0x35, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0x07, 0x50, 0x01, 0x41,
+	0xfe, 0xbf, 0x11, 0x21, 0x44, 0x61, 0x63, 0x44, 0x0c, 0xfa, 0x3a, 0x01, 0x8b, 0xfe, 0x75, 0x05,
+	0xc8, 0x05, 0x06, 0x45, 0x60, 0x62, 0x44, 0x21, 0x11, 0xfb, 0x00, 0x02, 0x4e, 0x01, 0x5f, 0x48,
+	0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x49, 0x00, 0x00, 0x04, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b,
+	0x07, 0x8f, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x36, 0x00, 0x42, 0x00, 0xd7, 0x4b, 0xb0, 0x2d, 0x50,
+	0x58, 0x40, 0x18, 0x30, 0x01, 0x0b, 0x09, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d,
+	0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x1b, 0x40, 0x1b, 0x30, 0x01,
+	0x0b, 0x09, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x07, 0x06, 0x00, 0x01,
+	0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x06, 0x4c, 0x59, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40,
+	0x33, 0x00, 0x09, 0x0b, 0x09, 0x85, 0x00, 0x0b, 0x0a, 0x0b, 0x85, 0x0d, 0x01, 0x0a, 0x0c, 0x01,
+	0x08, 0x04, 0x0a, 0x08, 0x69, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x3d, 0x00, 0x09, 0x0b, 0x09, 0x85, 0x00, 0x0b, 0x0a, 0x0b,
+	0x85, 0x0d, 0x01, 0x0a, 0x0c, 0x01, 0x08, 0x04, 0x0a, 0x08, 0x69, 0x00, 0x02, 0x00, 0x06, 0x07,
+	0x02, 0x06, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x07, 0x07,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x38, 0x37, 0x27, 0x26, 0x3e, 0x3c, 0x37, 0x42, 0x38,
+	0x42, 0x2f, 0x2e, 0x26, 0x36, 0x27, 0x36, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23, 0x22, 0x0e,
+	0x09, 0x1e, 0x2b, 0x25, 0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10,
+	0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33, 0x32,
+	0x25, 0x35, 0x23, 0x22, 0x15, 0x14, 0x16, 0x33, 0x32, 0x03, 0x22, 0x26, 0x35, 0x34, 0x37, 0x36,
+	0x37, 0x13, 0x21, 0x01, 0x16, 0x17, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26,
+	0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9,
+	0x92, 0xb3, 0x02, 0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82,
+	0x46, 0xf7, 0x53, 0x40, 0x66, 0x38, 0x60, 0x87, 0x44, 0x20, 0x26, 0xf1, 0x01, 0x0f, 0xfe, 0xbf,
+	0x26, 0x20, 0x45, 0x89, 0x63, 0x35, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0xa9, 0xa6, 0x1c, 0x8f,
+	0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62, 0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a, 0x81, 0x70,
+	0xdf, 0xb2, 0x3f, 0x53, 0x03, 0xdd, 0x8a, 0x60, 0x62, 0x44, 0x20, 0x12, 0x01, 0x40, 0xfe, 0xbf,
+	0x11, 0x20, 0x44, 0x61, 0x63, 0x88, 0x6f, 0x48, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x49, 0x00,
+	0x00, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x07, 0xc2, 0x07, 0x8f, 0x00, 0x0f, 0x00, 0x12, 0x00, 0x16,
+	0x00, 0x91, 0xb5, 0x12, 0x01, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x32,
+	0x00, 0x09, 0x0a, 0x09, 0x85, 0x0c, 0x01, 0x0a, 0x00, 0x0a, 0x85, 0x00, 0x02, 0x00, 0x03, 0x08,
+	0x02, 0x03, 0x67, 0x00, 0x08, 0x00, 0x06, 0x04, 0x08, 0x06, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0b, 0x07, 0x02, 0x05, 0x05, 0x39,
+	0x05, 0x4e, 0x1b, 0x40, 0x30, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0c, 0x01, 0x0a, 0x00, 0x0a, 0x85,
+	0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x08, 0x02, 0x03, 0x67,
+	0x00, 0x08, 0x00, 0x06, 0x04, 0x08, 0x06, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0b, 0x07, 0x02,
+	0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1a, 0x13, 0x13, 0x00, 0x00, 0x13, 0x16, 0x13, 0x16,
+	0x15, 0x14, 0x11, 0x10, 0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d,
+	0x09, 0x1d, 0x2b, 0x33, 0x01, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21,
+	0x11, 0x21, 0x03, 0x01, 0x21, 0x11, 0x13, 0x13, 0x21, 0x01, 0x0c, 0x03, 0x80, 0x04, 0x07, 0xfd,
+	0x59, 0x02, 0x38, 0xfd, 0xc8, 0x02, 0xd6, 0xfc, 0x02, 0xfe, 0x24, 0xe7, 0x01, 0x5b, 0x01, 0x68,
+	0x69, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xcc, 0xfe, 0x3e, 0xd2, 0x01,
+	0x7e, 0xfe, 0x82, 0x02, 0x3e, 0x02, 0x53, 0x01, 0xbd, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x45, 0xff, 0xe7, 0x06, 0xb0, 0x06, 0x44, 0x00, 0x21, 0x00, 0x2a, 0x00, 0x2f,
+	0x00, 0x33, 0x00, 0xd9, 0x40, 0x14, 0x13, 0x0f, 0x02, 0x02, 0x03, 0x0e, 0x01, 0x01, 0x02, 0x22,
+	0x1d, 0x02, 0x06, 0x05, 0x1e, 0x01, 0x00, 0x06, 0x04, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x31, 0x0e, 0x01, 0x0d, 0x0c, 0x03, 0x0c, 0x0d, 0x03, 0x80, 0x0a, 0x01, 0x01, 0x08, 0x01, 0x05,
+	0x06, 0x01, 0x05, 0x69, 0x00, 0x0c, 0x0c, 0x3a, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x03, 0x61, 0x04,
+	0x01, 0x03, 0x03, 0x41, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x31, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x0c, 0x0d, 0x0c, 0x85, 0x0e,
+	0x01, 0x0d, 0x03, 0x0d, 0x85, 0x0a, 0x01, 0x01, 0x08, 0x01, 0x05, 0x06, 0x01, 0x05, 0x69, 0x0b,
+	0x01, 0x02, 0x02, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x00,
+	0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x33, 0x00, 0x0c, 0x0d, 0x0c, 0x85,
+	0x0e, 0x01, 0x0d, 0x03, 0x0d, 0x85, 0x00, 0x08, 0x05, 0x01, 0x08, 0x59, 0x0a, 0x01, 0x01, 0x00,
+	0x05, 0x06, 0x01, 0x05, 0x67, 0x0b, 0x01, 0x02, 0x02, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41,
+	0x4d, 0x09, 0x01, 0x06, 0x06, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59,
+	0x40, 0x1a, 0x30, 0x30, 0x30, 0x33, 0x30, 0x33, 0x32, 0x31, 0x2f, 0x2d, 0x2c, 0x2b, 0x2a, 0x28,
+	0x22, 0x23, 0x21, 0x12, 0x22, 0x23, 0x22, 0x24, 0x21, 0x0f, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23,
+	0x22, 0x26, 0x35, 0x34, 0x24, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32,
+	0x17, 0x36, 0x33, 0x32, 0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x03,
+	0x35, 0x23, 0x20, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x01, 0x13, 0x21,
+	0x01, 0x03, 0x24, 0x9c, 0xf1, 0x98, 0xba, 0x01, 0x29, 0x01, 0x16, 0x54, 0xca, 0xb2, 0xb5, 0xd0,
+	0xc1, 0xb0, 0xa5, 0x9a, 0xb8, 0xef, 0xe2, 0xfd, 0x47, 0x20, 0x01, 0x41, 0x99, 0xbf, 0xd6, 0xd6,
+	0xfe, 0xcc, 0xf8, 0x4b, 0xfe, 0xd4, 0x59, 0x43, 0x6b, 0x01, 0x8c, 0x01, 0x99, 0xbd, 0xbf, 0xfe,
+	0xf9, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0xc0, 0xd9, 0xae, 0x8e, 0xb5, 0xc2, 0x68, 0xab, 0x62, 0xcc,
+	0x4c, 0x79, 0x79, 0xfe, 0xcc, 0xfe, 0xbb, 0xfe, 0xc6, 0x45, 0xd0, 0x3e, 0x01, 0x2e, 0xdf, 0xb3,
+	0x3f, 0x52, 0x01, 0xe1, 0x01, 0x1c, 0x01, 0x56, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x04, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xe9, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x7b,
+	0x40, 0x11, 0x18, 0x01, 0x00, 0x02, 0x1b, 0x11, 0x0f, 0x07, 0x04, 0x01, 0x00, 0x22, 0x01, 0x04,
+	0x01, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09,
+	0x01, 0x07, 0x02, 0x07, 0x85, 0x00, 0x00, 0x00, 0x02, 0x61, 0x03, 0x01, 0x02, 0x02, 0x3e, 0x4d,
+	0x00, 0x01, 0x01, 0x04, 0x61, 0x08, 0x05, 0x02, 0x04, 0x04, 0x3f, 0x04, 0x4e, 0x1b, 0x40, 0x21,
+	0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x02, 0x07, 0x85, 0x03, 0x01, 0x02, 0x00, 0x00,
+	0x01, 0x02, 0x00, 0x6a, 0x00, 0x01, 0x01, 0x04, 0x61, 0x08, 0x05, 0x02, 0x04, 0x04, 0x42, 0x04,
+	0x4e, 0x59, 0x40, 0x16, 0x24, 0x24, 0x10, 0x10, 0x24, 0x27, 0x24, 0x27, 0x26, 0x25, 0x10, 0x23,
+	0x10, 0x23, 0x25, 0x12, 0x2a, 0x26, 0x21, 0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x26, 0x23, 0x22, 0x02,
+	0x11, 0x14, 0x17, 0x17, 0x16, 0x33, 0x32, 0x12, 0x11, 0x34, 0x27, 0x01, 0x37, 0x26, 0x11, 0x10,
+	0x00, 0x21, 0x20, 0x17, 0x37, 0x33, 0x07, 0x16, 0x11, 0x10, 0x00, 0x21, 0x20, 0x27, 0x07, 0x01,
+	0x13, 0x21, 0x01, 0x04, 0x26, 0x61, 0xa9, 0xb8, 0xcd, 0x30, 0x4c, 0x62, 0xa7, 0xb9, 0xcd, 0x30,
+	0xfb, 0xde, 0xb2, 0xb2, 0x01, 0x7d, 0x01, 0x53, 0x01, 0x07, 0xa5, 0x5f, 0xbe, 0xb2, 0xb2, 0xfe,
+	0x82, 0xfe, 0xae, 0xfe, 0xfa, 0xa6, 0x5f, 0x01, 0x49, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x04, 0xa6,
+	0x7c, 0xfe, 0xd3, 0xfe, 0xf0, 0xa5, 0x90, 0x8e, 0x7b, 0x01, 0x2c, 0x01, 0x0f, 0xa5, 0x92, 0xfb,
+	0xc2, 0xdf, 0xe2, 0x01, 0x48, 0x01, 0x6e, 0x01, 0x9b, 0x77, 0x77, 0xdf, 0xdf, 0xfe, 0xb5, 0xfe,
+	0x92, 0xfe, 0x65, 0x78, 0x78, 0x06, 0x73, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x04, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x99, 0x06, 0x44, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23, 0x00, 0x27, 0x00, 0x93,
+	0x40, 0x13, 0x0f, 0x0c, 0x02, 0x05, 0x02, 0x22, 0x21, 0x1a, 0x19, 0x04, 0x04, 0x05, 0x05, 0x02,
+	0x02, 0x00, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x28, 0x0b, 0x01, 0x07, 0x06,
+	0x02, 0x06, 0x07, 0x02, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x02, 0x61,
+	0x03, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x00, 0x61, 0x01, 0x08, 0x02, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0b, 0x01, 0x07, 0x02,
+	0x07, 0x85, 0x0a, 0x01, 0x05, 0x05, 0x02, 0x61, 0x03, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x09, 0x01,
+	0x04, 0x04, 0x00, 0x61, 0x01, 0x08, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x23, 0x24,
+	0x24, 0x1d, 0x1c, 0x15, 0x14, 0x01, 0x00, 0x24, 0x27, 0x24, 0x27, 0x26, 0x25, 0x1c, 0x23, 0x1d,
+	0x23, 0x14, 0x1b, 0x15, 0x1b, 0x0e, 0x0d, 0x0b, 0x09, 0x04, 0x03, 0x00, 0x13, 0x01, 0x13, 0x0c,
+	0x09, 0x16, 0x2b, 0x05, 0x22, 0x27, 0x07, 0x23, 0x37, 0x26, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17,
+	0x37, 0x33, 0x07, 0x16, 0x15, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x36, 0x27, 0x01, 0x16, 0x13,
+	0x22, 0x06, 0x15, 0x06, 0x17, 0x01, 0x26, 0x01, 0x13, 0x21, 0x01, 0x02, 0x6b, 0xb1, 0x7f, 0x42,
+	0xaf, 0x89, 0x89, 0x01, 0x2c, 0xfb, 0xb6, 0x81, 0x42, 0xaf, 0x8a, 0x8a, 0xfe, 0xd3, 0xfd, 0x7c,
+	0x8e, 0x01, 0x1a, 0xfe, 0x6a, 0x42, 0x65, 0x79, 0x8e, 0x01, 0x1b, 0x01, 0x96, 0x45, 0xfe, 0xd3,
+	0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x19, 0x51, 0x51, 0xaa, 0x9b, 0xf9, 0x01, 0x06, 0x01, 0x38, 0x52,
+	0x52, 0xaa, 0x9a, 0xf8, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0x65, 0x53, 0xfe, 0x0b, 0x4a,
+	0x03, 0x0a, 0xd2, 0xb3, 0x66, 0x55, 0x01, 0xf6, 0x4a, 0x01, 0x59, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0x63, 0xfe, 0x50, 0x05, 0x09, 0x05, 0xed, 0x00, 0x23, 0x00, 0x32, 0x00, 0x7e,
+	0x40, 0x17, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x2c,
+	0x01, 0x06, 0x07, 0x2b, 0x01, 0x05, 0x06, 0x05, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27,
+	0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x4d, 0x00, 0x06, 0x06, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01,
+	0x02, 0x69, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59,
+	0x40, 0x0b, 0x12, 0x23, 0x23, 0x12, 0x2c, 0x23, 0x29, 0x22, 0x08, 0x09, 0x1e, 0x2b, 0x37, 0x35,
+	0x04, 0x33, 0x20, 0x35, 0x34, 0x2f, 0x02, 0x24, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x15, 0x14, 0x04, 0x21, 0x22, 0x27,
+	0x05, 0x20, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x66,
+	0x01, 0x1c, 0xef, 0x01, 0x54, 0x81, 0x89, 0xa3, 0xfe, 0xfb, 0xb0, 0x02, 0x5c, 0xfe, 0xe5, 0xee,
+	0xdf, 0xb5, 0x8c, 0x44, 0x61, 0x72, 0xaa, 0xf7, 0xbd, 0xfe, 0xa7, 0xfe, 0x8d, 0x8b, 0xae, 0x01,
+	0x3f, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72, 0x42, 0x2d, 0x80, 0xa5, 0x0d, 0xfc, 0x63, 0xc5, 0x80,
+	0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6, 0x01, 0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24,
+	0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8, 0xd9, 0x1b, 0x56, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41,
+	0x3a, 0x08, 0x00, 0x00, 0x00, 0x02, 0x00, 0x7b, 0xfe, 0x50, 0x04, 0x0c, 0x04, 0x63, 0x00, 0x1e,
+	0x00, 0x2d, 0x00, 0x4c, 0x40, 0x49, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x00,
+	0x01, 0x03, 0x00, 0x27, 0x01, 0x06, 0x07, 0x26, 0x01, 0x05, 0x06, 0x05, 0x4c, 0x00, 0x04, 0x00,
+	0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x43, 0x05, 0x4e, 0x12, 0x23, 0x23, 0x11, 0x29, 0x23, 0x28, 0x22, 0x08, 0x09, 0x1e, 0x2b,
+	0x37, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17,
+	0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x17,
+	0x20, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x7b, 0xe6,
+	0x9d, 0xdd, 0xaf, 0x64, 0xcd, 0x7b, 0x01, 0xcf, 0x9e, 0xc8, 0xdc, 0x66, 0xcf, 0xa1, 0x56, 0xdc,
+	0x95, 0xfe, 0xed, 0xe8, 0xcc, 0x83, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72, 0x42, 0x2d, 0x80, 0xa5,
+	0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47, 0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70,
+	0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c, 0x9c, 0xb9, 0x48, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41,
+	0x3a, 0x08, 0x00, 0x00, 0x00, 0x02, 0x00, 0x28, 0xfe, 0x50, 0x04, 0xbc, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x16, 0x00, 0x74, 0x40, 0x0a, 0x10, 0x01, 0x06, 0x07, 0x0f, 0x01, 0x05, 0x06, 0x02, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x02,
+	0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x08, 0x01, 0x03, 0x03, 0x39, 0x4d,
+	0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x01,
+	0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x08,
+	0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x59, 0x40, 0x14, 0x00, 0x00, 0x16, 0x15, 0x13, 0x11, 0x0e, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11,
+	0x05, 0x20, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x01,
+	0xd8, 0xfe, 0x50, 0x04, 0x94, 0xfe, 0x50, 0xfe, 0xe4, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72, 0x42,
+	0x2d, 0x80, 0xa5, 0x04, 0xfd, 0xcb, 0xcb, 0xfb, 0x03, 0x61, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06,
+	0x41, 0x3a, 0x08, 0x00, 0x00, 0x02, 0x00, 0x2a, 0xfe, 0x50, 0x02, 0x9c, 0x05, 0x43, 0x00, 0x14,
+	0x00, 0x23, 0x00, 0x51, 0x40, 0x4e, 0x00, 0x01, 0x05, 0x01, 0x01, 0x01, 0x00, 0x05, 0x1d, 0x01,
+	0x08, 0x09, 0x1c, 0x01, 0x07, 0x08, 0x04, 0x4c, 0x0b, 0x0a, 0x02, 0x02, 0x4a, 0x00, 0x06, 0x00,
+	0x09, 0x08, 0x06, 0x09, 0x69, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b,
+	0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61,
+	0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x23, 0x22, 0x23, 0x23, 0x11, 0x23, 0x11, 0x13, 0x11, 0x12,
+	0x22, 0x0a, 0x09, 0x1f, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x11, 0x11, 0x23, 0x35, 0x33, 0x35,
+	0x25, 0x15, 0x33, 0x15, 0x23, 0x11, 0x14, 0x16, 0x33, 0x32, 0x01, 0x20, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x02, 0x99, 0x72, 0x4c, 0xfe, 0xc7, 0x78,
+	0x78, 0x01, 0x28, 0xd2, 0xd2, 0x2a, 0x42, 0x28, 0xfe, 0xc0, 0x01, 0x6b, 0x8d, 0x64, 0x52, 0x72,
+	0x42, 0x2d, 0x80, 0xa5, 0xba, 0xb9, 0x1a, 0x01, 0x68, 0x02, 0x42, 0xb9, 0xd7, 0x22, 0xf9, 0xb9,
+	0xfd, 0xe5, 0x7c, 0x4d, 0xfe, 0xf2, 0xab, 0x44, 0x60, 0x0d, 0x62, 0x06, 0x41, 0x3a, 0x08, 0x00,
+	0x00, 0x01, 0xff, 0xdc, 0x05, 0x03, 0x02, 0xcf, 0x06, 0x44, 0x00, 0x07, 0x00, 0x27, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1c, 0x05, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x03,
+	0x02, 0x02, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x09, 0x18,
+	0x2b, 0xb1, 0x06, 0x00, 0x44, 0x03, 0x13, 0x21, 0x13, 0x23, 0x27, 0x23, 0x07, 0x24, 0xf1, 0x01,
+	0x11, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xc5, 0xc5, 0x00, 0x00,
+	0x00, 0x01, 0xff, 0xdc, 0x05, 0x03, 0x02, 0xcf, 0x06, 0x44, 0x00, 0x07, 0x00, 0x27, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1c, 0x05, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x03, 0x02, 0x02, 0x01, 0x00, 0x01,
+	0x85, 0x00, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x09, 0x18,
+	0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x03, 0x21, 0x03, 0x33, 0x17, 0x33, 0x37, 0x02, 0xcf, 0xf1,
+	0xfe, 0xef, 0xf1, 0xb3, 0xc5, 0x03, 0xc5, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xc6, 0xc6, 0x00,
+	0x00, 0x01, 0xff, 0xe3, 0x05, 0x17, 0x02, 0xc7, 0x05, 0xc4, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x03, 0x35, 0x21, 0x15, 0x1d, 0x02, 0xe4, 0x05, 0x17, 0xad, 0xad, 0x00,
+	0x00, 0x01, 0xff, 0xf3, 0x05, 0x03, 0x02, 0xb6, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x28, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1d, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x03, 0x03, 0x01, 0x59,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x01, 0x03, 0x51, 0x22, 0x11, 0x21, 0x10, 0x04, 0x09,
+	0x1a, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x03, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23,
+	0x22, 0x26, 0x0d, 0x94, 0x29, 0xa5, 0xa3, 0x2a, 0x94, 0x10, 0xc0, 0x91, 0x91, 0xc0, 0x06, 0x44,
+	0x8e, 0x8e, 0x93, 0xae, 0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xc1, 0x05, 0x17, 0x01, 0xe9,
+	0x06, 0x3f, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x11, 0x21, 0x11,
+	0xc1, 0x01, 0x28, 0x05, 0x17, 0x01, 0x28, 0xfe, 0xd8, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x6b,
+	0x05, 0x03, 0x02, 0x3f, 0x06, 0xd8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x39, 0xb1, 0x06, 0x64, 0x44,
+	0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02,
+	0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01,
+	0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16,
+	0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x01, 0x52, 0x60,
+	0x87, 0x88, 0x62, 0x61, 0x89, 0x89, 0x63, 0x35, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0x05, 0x03,
+	0x8a, 0x60, 0x62, 0x89, 0x89, 0x61, 0x63, 0x88, 0x6f, 0x48, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33,
+	0x49, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x5e, 0xfe, 0x8e, 0x02, 0x4c, 0x00, 0x00, 0x00, 0x0d,
+	0x00, 0x52, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0a, 0x07, 0x01, 0x01, 0x00, 0x08, 0x01, 0x02, 0x01,
+	0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x01, 0x01, 0x00, 0x70, 0x00,
+	0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02, 0x62, 0x00, 0x02, 0x01, 0x02, 0x52, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01,
+	0x02, 0x62, 0x00, 0x02, 0x01, 0x02, 0x52, 0x59, 0xb5, 0x23, 0x23, 0x10, 0x03, 0x09, 0x19, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x21, 0x33, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20,
+	0x35, 0x34, 0x01, 0x3f, 0x9e, 0xba, 0xa2, 0x55, 0x32, 0x57, 0x70, 0xfe, 0xd9, 0x56, 0x5e, 0x5f,
+	0x0f, 0x51, 0x1d, 0x9f, 0x76, 0x00, 0x00, 0x00, 0x00, 0x01, 0xff, 0xf5, 0x05, 0x0d, 0x02, 0xb4,
+	0x06, 0x4e, 0x00, 0x16, 0x00, 0x2e, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x23, 0x00, 0x02, 0x05, 0x00,
+	0x02, 0x59, 0x03, 0x01, 0x01, 0x00, 0x05, 0x00, 0x01, 0x05, 0x69, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x04, 0x01, 0x00, 0x02, 0x00, 0x52, 0x25, 0x21, 0x11, 0x24, 0x21, 0x10, 0x06, 0x09, 0x1c, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x13, 0x23, 0x10, 0x33, 0x32, 0x1f, 0x02, 0x16, 0x33, 0x32, 0x35, 0x33,
+	0x10, 0x23, 0x22, 0x27, 0x27, 0x26, 0x27, 0x26, 0x23, 0x22, 0x89, 0x94, 0xca, 0x40, 0x3e, 0x26,
+	0x1f, 0x40, 0x1b, 0x43, 0x94, 0xc9, 0x40, 0x3e, 0x27, 0x17, 0x08, 0x3d, 0x1d, 0x44, 0x05, 0x0d,
+	0x01, 0x41, 0x2b, 0x1a, 0x16, 0x2d, 0x88, 0xfe, 0xbf, 0x2b, 0x1a, 0x10, 0x06, 0x2d, 0x00, 0x00,
+	0x00, 0x02, 0xff, 0xae, 0x05, 0x03, 0x02, 0xfc, 0x06, 0x44, 0x00, 0x03, 0x00, 0x07, 0x00, 0x32,
+	0xb1, 0x06, 0x64, 0x44, 0x40, 0x27, 0x02, 0x01, 0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x05, 0x03, 0x04, 0x03, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04,
+	0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x03, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33, 0x01, 0x52, 0xf1, 0xe4, 0xfe, 0xbf, 0xe5,
+	0xf0, 0xe5, 0xfe, 0xbf, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xd6, 0xfe, 0xa2, 0x02, 0x17, 0x04, 0x63, 0x00, 0x03, 0x00, 0x0d, 0x00, 0xa5,
+	0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x2b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x29, 0x4d, 0x00, 0x05, 0x05, 0x04,
+	0x61, 0x00, 0x04, 0x04, 0x2d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1d, 0x00,
+	0x05, 0x00, 0x04, 0x05, 0x04, 0x65, 0x06, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b,
+	0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1b, 0x00, 0x00, 0x06, 0x01, 0x01, 0x03, 0x00, 0x01, 0x67, 0x00, 0x05, 0x00,
+	0x04, 0x05, 0x04, 0x65, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b,
+	0x40, 0x1b, 0x00, 0x00, 0x06, 0x01, 0x01, 0x03, 0x00, 0x01, 0x67, 0x00, 0x05, 0x00, 0x04, 0x05,
+	0x04, 0x65, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x12, 0x00, 0x00, 0x0c, 0x0b, 0x0a, 0x09, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x07, 0x08, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x03, 0x23, 0x11, 0x21, 0x15, 0x10, 0x21,
+	0x35, 0x32, 0x35, 0xd6, 0x01, 0x41, 0xc6, 0x7b, 0x01, 0x41, 0xfe, 0xbf, 0x7b, 0x03, 0x22, 0x01,
+	0x41, 0xfe, 0xbf, 0xfc, 0xde, 0x01, 0x41, 0xf9, 0xfe, 0x5a, 0x6f, 0xcf, 0x00, 0x01, 0x00, 0x76,
+	0x05, 0x03, 0x02, 0x5a, 0x06, 0xa6, 0x00, 0x03, 0x00, 0x1f, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x14,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x03, 0x08, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x13, 0x21, 0x01, 0x76, 0xd2, 0x01,
+	0x12, 0xfe, 0xb0, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x19,
+	0x05, 0x0d, 0x03, 0x9e, 0x07, 0x1f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x48, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x3d, 0x00, 0x04, 0x00, 0x04, 0x85, 0x08, 0x01, 0x05, 0x00, 0x01, 0x00, 0x05,
+	0x01, 0x80, 0x02, 0x01, 0x00, 0x05, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x60, 0x07,
+	0x03, 0x06, 0x03, 0x01, 0x00, 0x01, 0x50, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08,
+	0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x08,
+	0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x35, 0x33, 0x15, 0x21, 0x35, 0x33, 0x15, 0x25, 0x13,
+	0x21, 0x01, 0x19, 0xde, 0x01, 0xc9, 0xde, 0xfd, 0xbc, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x05, 0x0d,
+	0xde, 0xde, 0xde, 0xde, 0x6f, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x0a,
+	0x00, 0x00, 0x05, 0xba, 0x06, 0xa6, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6e, 0xb5, 0x0a,
+	0x01, 0x04, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x00, 0x05,
+	0x85, 0x08, 0x01, 0x06, 0x00, 0x04, 0x00, 0x06, 0x04, 0x80, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b,
+	0x40, 0x20, 0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x00, 0x06, 0x00, 0x85, 0x08, 0x01, 0x06, 0x04,
+	0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x2c,
+	0x01, 0x4e, 0x59, 0x40, 0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09,
+	0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01,
+	0x21, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x25, 0x13, 0x21, 0x01, 0x0c, 0x02, 0x3e, 0x01, 0x34,
+	0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc, 0xe6, 0xfd, 0x54, 0xd2, 0x01,
+	0x12, 0xfe, 0xb0, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x65,
+	0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x01, 0x00, 0xb4, 0x03, 0x09, 0x01, 0xf5, 0x04, 0x4a, 0x00, 0x03,
+	0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x01, 0x4e,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x08, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0xb4,
+	0x01, 0x41, 0x03, 0x09, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x00, 0x06, 0x97,
+	0x06, 0xa6, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x00,
+	0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x09, 0x07, 0x02, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05,
+	0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x09, 0x07,
+	0x02, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x08, 0x1b, 0x2b, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15,
+	0x01, 0x13, 0x21, 0x01, 0x02, 0x2a, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39,
+	0xf9, 0x73, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2,
+	0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0a, 0x00, 0x00, 0x06, 0x92,
+	0x06, 0xa6, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x24, 0x00,
+	0x06, 0x00, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x01, 0x00, 0x07, 0x01, 0x80, 0x00, 0x01, 0x00,
+	0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x08, 0x05, 0x02, 0x03, 0x03,
+	0x29, 0x03, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x06, 0x00, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x01,
+	0x00, 0x07, 0x01, 0x80, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00,
+	0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x08, 0x1b, 0x2b, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x01, 0x13, 0x21, 0x01, 0x02, 0x25, 0x01, 0x34, 0x02, 0x05, 0x01, 0x34, 0xfe, 0xcc, 0xfd, 0xfb,
+	0xfc, 0xb1, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x05, 0xc8, 0xfd, 0xa7, 0x02, 0x59, 0xfa, 0x38, 0x02,
+	0xa3, 0xfd, 0x5d, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6a,
+	0x00, 0x00, 0x04, 0x1e, 0x06, 0xa6, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0xbf, 0x4b, 0xb0, 0x0c, 0x50,
+	0x58, 0x40, 0x20, 0x00, 0x06, 0x02, 0x02, 0x06, 0x70, 0x09, 0x07, 0x03, 0x03, 0x01, 0x01, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05,
+	0x29, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x26, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x02, 0x06, 0x85,
+	0x09, 0x07, 0x03, 0x03, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x26, 0x00, 0x06, 0x02, 0x06, 0x85, 0x09, 0x01, 0x07, 0x02, 0x01, 0x02, 0x07, 0x01, 0x80,
+	0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x06, 0x02, 0x06, 0x85,
+	0x09, 0x01, 0x07, 0x02, 0x01, 0x02, 0x07, 0x01, 0x80, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02,
+	0x01, 0x68, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59,
+	0x59, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b,
+	0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x21, 0x35, 0x33, 0x11, 0x23,
+	0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x13, 0x21, 0x01, 0x01, 0x46, 0xd2, 0xd2, 0x02,
+	0xd8, 0xd2, 0xd2, 0xfb, 0x4c, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb,
+	0xdc, 0xd2, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x03, 0x00, 0x00, 0xff, 0xdb, 0x06, 0x49,
+	0x06, 0xa6, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x98, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x04, 0x01, 0x04, 0x85, 0x08, 0x05, 0x02, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x2e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x00, 0x04, 0x01, 0x04, 0x85, 0x08, 0x01, 0x05, 0x03,
+	0x02, 0x03, 0x05, 0x02, 0x80, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x07,
+	0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x08, 0x01, 0x05, 0x03, 0x02, 0x03, 0x05, 0x02, 0x80, 0x00, 0x01, 0x00,
+	0x03, 0x05, 0x01, 0x03, 0x69, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x32,
+	0x00, 0x4e, 0x59, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b,
+	0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08,
+	0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32,
+	0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x01, 0x13, 0x21, 0x01, 0x03, 0x72,
+	0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac,
+	0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0xfd, 0x3d, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x25, 0x01,
+	0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc,
+	0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0,
+	0x04, 0x5c, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x14, 0x00, 0x00, 0x07, 0x57,
+	0x06, 0xa6, 0x00, 0x10, 0x00, 0x14, 0x00, 0xa7, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x0f, 0x0c,
+	0x01, 0x00, 0x01, 0x08, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x0b, 0x01, 0x01, 0x01, 0x4b, 0x1b, 0x40,
+	0x0f, 0x0c, 0x01, 0x04, 0x01, 0x08, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x0b, 0x01, 0x01, 0x01, 0x4b,
+	0x59, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x18, 0x00, 0x03, 0x01, 0x03, 0x85, 0x06, 0x04, 0x02,
+	0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x03, 0x01, 0x03, 0x85, 0x06, 0x01, 0x04,
+	0x01, 0x00, 0x01, 0x04, 0x00, 0x80, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x28, 0x4d,
+	0x05, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x03, 0x01, 0x03, 0x85, 0x06,
+	0x01, 0x04, 0x01, 0x00, 0x01, 0x04, 0x00, 0x80, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x69,
+	0x05, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x13, 0x11, 0x11, 0x00, 0x00, 0x11,
+	0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x11, 0x13, 0x07, 0x08, 0x18, 0x2b, 0x21,
+	0x11, 0x10, 0x00, 0x23, 0x35, 0x20, 0x00, 0x13, 0x12, 0x00, 0x37, 0x15, 0x06, 0x00, 0x11, 0x11,
+	0x01, 0x13, 0x21, 0x01, 0x04, 0x18, 0xfe, 0xda, 0xc9, 0x01, 0x24, 0x01, 0x4f, 0x4e, 0x5b, 0x01,
+	0x4f, 0xc3, 0xcf, 0xfe, 0xc5, 0xfa, 0xc7, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x01, 0xb4, 0x01, 0x53,
+	0x01, 0xf0, 0xd1, 0xfe, 0xdd, 0xfe, 0xbc, 0x01, 0x0a, 0x01, 0x49, 0x14, 0xb9, 0x31, 0xfd, 0xf4,
+	0xfe, 0xd2, 0xfe, 0x5c, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0x00, 0x00, 0x06, 0x54, 0x06, 0xa6, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x9d, 0xb5, 0x1e, 0x12, 0x02,
+	0x00, 0x01, 0x4b, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x01, 0x06, 0x85, 0x09,
+	0x07, 0x02, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x03,
+	0x60, 0x08, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x26, 0x00, 0x06, 0x01, 0x06, 0x85, 0x09, 0x01, 0x07, 0x04, 0x00, 0x04, 0x07, 0x00, 0x80, 0x00,
+	0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x03, 0x60, 0x08,
+	0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x06, 0x01, 0x06, 0x85, 0x09,
+	0x01, 0x07, 0x04, 0x00, 0x04, 0x07, 0x00, 0x80, 0x00, 0x01, 0x00, 0x04, 0x07, 0x01, 0x04, 0x69,
+	0x02, 0x01, 0x00, 0x00, 0x03, 0x60, 0x08, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x59,
+	0x40, 0x16, 0x20, 0x20, 0x00, 0x00, 0x20, 0x23, 0x20, 0x23, 0x22, 0x21, 0x00, 0x1f, 0x00, 0x1f,
+	0x26, 0x11, 0x15, 0x25, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x33, 0x35, 0x21, 0x26, 0x02, 0x35, 0x10,
+	0x00, 0x21, 0x20, 0x00, 0x11, 0x14, 0x02, 0x07, 0x21, 0x15, 0x21, 0x35, 0x36, 0x12, 0x35, 0x34,
+	0x02, 0x23, 0x22, 0x02, 0x15, 0x14, 0x12, 0x17, 0x15, 0x01, 0x13, 0x21, 0x01, 0xa9, 0x01, 0x76,
+	0xac, 0xac, 0x01, 0x83, 0x01, 0x35, 0x01, 0x34, 0x01, 0x83, 0xac, 0xac, 0x01, 0x76, 0xfd, 0x95,
+	0x83, 0x8d, 0xd0, 0xaa, 0xab, 0xd0, 0x8d, 0x83, 0xfc, 0xec, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0xd7,
+	0x88, 0x01, 0x39, 0xbc, 0x01, 0x27, 0x01, 0x72, 0xfe, 0x8e, 0xfe, 0xd9, 0xbb, 0xfe, 0xc6, 0x88,
+	0xd7, 0xd7, 0x70, 0x01, 0x2e, 0xc9, 0xe1, 0x01, 0x03, 0xfe, 0xfc, 0xe1, 0xc9, 0xfe, 0xd3, 0x70,
+	0xd7, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x04, 0xff, 0xc8, 0xff, 0xe7, 0x03, 0x4d,
+	0x07, 0x1f, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x8d, 0x40, 0x0a, 0x0f, 0x01,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2c, 0x00,
+	0x07, 0x03, 0x07, 0x85, 0x0b, 0x01, 0x08, 0x03, 0x04, 0x03, 0x08, 0x04, 0x80, 0x0a, 0x06, 0x09,
+	0x03, 0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x28, 0x4d, 0x00, 0x01, 0x01, 0x2b, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x07,
+	0x03, 0x07, 0x85, 0x0b, 0x01, 0x08, 0x03, 0x04, 0x03, 0x08, 0x04, 0x80, 0x05, 0x01, 0x03, 0x0a,
+	0x06, 0x09, 0x03, 0x04, 0x01, 0x03, 0x04, 0x68, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x14, 0x14, 0x10,
+	0x10, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x10, 0x13, 0x10,
+	0x13, 0x13, 0x23, 0x15, 0x21, 0x0c, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26,
+	0x35, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x01, 0x35, 0x33, 0x15, 0x21, 0x35, 0x33,
+	0x15, 0x25, 0x13, 0x21, 0x01, 0x02, 0xe1, 0x77, 0x7a, 0xa0, 0x56, 0x3e, 0x2b, 0x01, 0x28, 0x41,
+	0x4e, 0x42, 0x57, 0xfc, 0xe7, 0xde, 0x01, 0xc9, 0xde, 0xfd, 0xbc, 0xd2, 0x01, 0x12, 0xfe, 0xb0,
+	0x19, 0x32, 0x49, 0x34, 0xa2, 0xb4, 0x02, 0x90, 0xfd, 0x5e, 0x8c, 0x73, 0x2a, 0x04, 0x3a, 0xde,
+	0xde, 0xde, 0xde, 0x6f, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x02, 0x00, 0x0c, 0x00, 0x00, 0x05, 0xba,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00,
+	0x00, 0x28, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x00,
+	0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05, 0x03, 0x02, 0x01, 0x01,
+	0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11,
+	0x11, 0x06, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03,
+	0x0c, 0x02, 0x3e, 0x01, 0x34, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c, 0x97, 0xe3, 0x01, 0xcc,
+	0xe6, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02, 0x4e, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x05, 0x7e, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x1d,
+	0x00, 0x61, 0xb5, 0x06, 0x01, 0x05, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e,
+	0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x28, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40,
+	0x1c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05,
+	0x67, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x1d, 0x1b, 0x17, 0x15, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0a, 0x21, 0x07,
+	0x08, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x04, 0x11, 0x14, 0x06, 0x23, 0x01,
+	0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x21, 0x11, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x21, 0xad, 0x02, 0xcc, 0x01, 0xc8, 0xfe, 0x9d, 0x01, 0xa0, 0xf3, 0xe4, 0xfe, 0x28, 0x01, 0x1e,
+	0x82, 0x99, 0x7b, 0xab, 0xfe, 0xed, 0x01, 0x17, 0xc2, 0x93, 0xc5, 0x96, 0xfe, 0xef, 0x05, 0xc8,
+	0xfe, 0xb7, 0xfe, 0xf5, 0x6f, 0x64, 0xfe, 0xcd, 0xb1, 0xbd, 0x03, 0x60, 0x81, 0x6d, 0x65, 0x4a,
+	0xfb, 0xd5, 0x53, 0x6d, 0x72, 0x96, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x04, 0xbb,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x31, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x10, 0x00, 0x02, 0x02,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x00, 0x00, 0x00, 0x29, 0x00, 0x4e, 0x1b, 0x40, 0x0e,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x67, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x4e, 0x59, 0xb5,
+	0x11, 0x11, 0x10, 0x03, 0x08, 0x19, 0x2b, 0x21, 0x21, 0x11, 0x21, 0x15, 0x21, 0x01, 0xe1, 0xfe,
+	0xcc, 0x04, 0x0e, 0xfd, 0x26, 0x05, 0xc8, 0xdf, 0x00, 0x02, 0x00, 0x1e, 0x00, 0x00, 0x05, 0xa2,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x08, 0x00, 0x43, 0xb5, 0x04, 0x01, 0x02, 0x02, 0x01, 0x4b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x60,
+	0x03, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x60, 0x03, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x12, 0x04, 0x08, 0x17, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x01,
+	0x15, 0x01, 0x01, 0x21, 0x1e, 0x02, 0x3e, 0x01, 0x06, 0x02, 0x40, 0xfd, 0x0c, 0xfe, 0x5d, 0x03,
+	0x48, 0xf7, 0x04, 0xd1, 0xfb, 0x2f, 0xf7, 0x04, 0x84, 0xfc, 0x73, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x28, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b,
+	0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0xad, 0x04, 0x3e, 0xfc,
+	0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2,
+	0x00, 0x01, 0x00, 0x5e, 0x00, 0x00, 0x04, 0x86, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x4d, 0xb7, 0x06,
+	0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03,
+	0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35,
+	0x21, 0x15, 0x01, 0x21, 0x15, 0x5e, 0x02, 0xc2, 0xfd, 0x69, 0x03, 0xfd, 0xfd, 0x3e, 0x02, 0xc2,
+	0xd2, 0x04, 0x2b, 0xcb, 0xcb, 0xfb, 0xd5, 0xd2, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00,
+	0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03,
+	0x29, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01,
+	0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x33, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0xad, 0x01, 0x34, 0x02, 0x05, 0x01,
+	0x34, 0xfe, 0xcc, 0xfd, 0xfb, 0x05, 0xc8, 0xfd, 0xa7, 0x02, 0x59, 0xfa, 0x38, 0x02, 0xa3, 0xfd,
+	0x5d, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9, 0x05, 0xed, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x67, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08,
+	0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d,
+	0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40, 0x1e,
+	0x00, 0x01, 0x00, 0x03, 0x04, 0x01, 0x03, 0x69, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05,
+	0x67, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40,
+	0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23,
+	0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x35, 0x21, 0x15, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01,
+	0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9,
+	0xcd, 0xcc, 0x2c, 0x01, 0xcc, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64,
+	0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe,
+	0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x01, 0xf3, 0xcc, 0xcc, 0x00, 0x00, 0x01, 0x00, 0x64,
+	0x00, 0x00, 0x03, 0x3c, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x18, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x04, 0x01, 0x00, 0x00,
+	0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02, 0x03, 0x01,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c,
+	0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x07, 0x08, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15,
+	0x64, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0xb8, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb7, 0x09,
+	0x06, 0x03, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01,
+	0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01,
+	0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c,
+	0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x11, 0x21,
+	0x11, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x11, 0xad, 0x01, 0x28, 0x02, 0x68, 0xff, 0xfd, 0xce,
+	0x02, 0xae, 0xfe, 0x7f, 0xfd, 0x9e, 0x05, 0xc8, 0xfd, 0x32, 0x02, 0xce, 0xfd, 0x68, 0xfc, 0xd0,
+	0x02, 0xd8, 0xfd, 0x28, 0x00, 0x01, 0x00, 0x0e, 0x00, 0x00, 0x05, 0x48, 0x05, 0xc8, 0x00, 0x06,
+	0x00, 0x3a, 0xb5, 0x01, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d,
+	0x00, 0x01, 0x01, 0x28, 0x4d, 0x03, 0x02, 0x02, 0x00, 0x00, 0x29, 0x00, 0x4e, 0x1b, 0x40, 0x0d,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x03, 0x02, 0x02, 0x00, 0x00, 0x2c, 0x00, 0x4e, 0x59, 0x40, 0x0b,
+	0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x11, 0x12, 0x04, 0x08, 0x18, 0x2b, 0x21, 0x01, 0x01, 0x23,
+	0x01, 0x21, 0x01, 0x04, 0x03, 0xfe, 0x7b, 0xfe, 0x7d, 0xed, 0x02, 0x02, 0x01, 0x3a, 0x01, 0xfe,
+	0x04, 0x6e, 0xfb, 0x92, 0x05, 0xc8, 0xfa, 0x38, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0xfe,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x50, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01,
+	0x00, 0x00, 0x28, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00,
+	0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02,
+	0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11,
+	0x12, 0x11, 0x06, 0x08, 0x1a, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x01, 0x21, 0x11, 0x21, 0x11, 0x01,
+	0x23, 0x01, 0x11, 0xad, 0x01, 0x98, 0x01, 0x24, 0x01, 0x2f, 0x01, 0x66, 0xfe, 0xe4, 0xfe, 0xd7,
+	0xf8, 0xfe, 0xde, 0x05, 0xc8, 0xfb, 0xef, 0x04, 0x11, 0xfa, 0x38, 0x04, 0x5d, 0xfc, 0x06, 0x04,
+	0x09, 0xfb, 0x94, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x09,
+	0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x0e, 0x01, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b,
+	0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x05, 0x08, 0x19, 0x2b,
+	0x33, 0x11, 0x21, 0x01, 0x11, 0x33, 0x11, 0x21, 0x01, 0x11, 0xad, 0x01, 0x0f, 0x02, 0x67, 0xf7,
+	0xfe, 0xed, 0xfd, 0x9d, 0x05, 0xc8, 0xfc, 0x0d, 0x03, 0xf3, 0xfa, 0x38, 0x03, 0xf3, 0xfc, 0x0d,
+	0x00, 0x03, 0x00, 0x28, 0x00, 0x00, 0x04, 0xfe, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x66, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02,
+	0x03, 0x67, 0x08, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x28, 0x4d, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x08, 0x01,
+	0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04,
+	0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x35, 0x21, 0x15,
+	0x01, 0x35, 0x21, 0x15, 0x28, 0x04, 0xd6, 0xfb, 0xc4, 0x03, 0xa2, 0xfb, 0xfc, 0x04, 0x66, 0x01,
+	0x04, 0xfe, 0xfc, 0x02, 0x82, 0xf0, 0xf0, 0x02, 0x4c, 0xfa, 0xfa, 0x00, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0xe9, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x32,
+	0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x08, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10,
+	0x12, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01, 0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe,
+	0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9, 0xcd, 0xcc, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01,
+	0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe, 0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16,
+	0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef, 0xfe, 0xf3, 0xfe, 0xd0, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01,
+	0x29, 0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x67, 0x04, 0x03,
+	0x02, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0xad, 0x04,
+	0x6d, 0xfe, 0xcc, 0xfd, 0xfb, 0x05, 0xc8, 0xfa, 0x38, 0x04, 0xfd, 0xfb, 0x03, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00,
+	0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e,
+	0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02,
+	0x03, 0x01, 0x67, 0x05, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13,
+	0x11, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x25, 0x21, 0x06, 0x08, 0x18, 0x2b, 0x33, 0x11, 0x21,
+	0x32, 0x16, 0x17, 0x16, 0x15, 0x10, 0x21, 0x23, 0x11, 0x11, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23,
+	0x23, 0xad, 0x02, 0x5a, 0xbd, 0xba, 0x41, 0x5b, 0xfd, 0x97, 0xd6, 0x92, 0x01, 0x72, 0x92, 0xa5,
+	0xcd, 0x05, 0xc8, 0x2f, 0x46, 0x61, 0xb3, 0xfe, 0x05, 0xfd, 0xbc, 0x03, 0x0f, 0x01, 0x12, 0x7a,
+	0x62, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x46, 0x00, 0x00, 0x04, 0x9b, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x56, 0x40, 0x10, 0x08, 0x02, 0x02, 0x02, 0x01, 0x01, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x03,
+	0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x01, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x29, 0x03,
+	0x4e, 0x1b, 0x40, 0x14, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x02, 0x03,
+	0x5f, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x12, 0x11, 0x14, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x01, 0x35, 0x21, 0x15, 0x21,
+	0x01, 0x01, 0x21, 0x15, 0x46, 0x01, 0xdb, 0xfe, 0x56, 0x04, 0x1a, 0xfd, 0x6e, 0x01, 0x86, 0xfd,
+	0xf8, 0x03, 0x1e, 0xf4, 0x01, 0xe3, 0x02, 0x26, 0xcb, 0xcb, 0xfe, 0x06, 0xfd, 0xf4, 0xf7, 0x00,
+	0x00, 0x01, 0x00, 0x28, 0x00, 0x00, 0x04, 0xbc, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03,
+	0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15,
+	0x21, 0x11, 0x01, 0xd8, 0xfe, 0x50, 0x04, 0x94, 0xfe, 0x50, 0x04, 0xf3, 0xd5, 0xd5, 0xfb, 0x0d,
+	0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x05, 0x42, 0x05, 0xc8, 0x00, 0x10, 0x00, 0x49, 0x40, 0x0e,
+	0x0c, 0x01, 0x00, 0x01, 0x08, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x0b, 0x01, 0x01, 0x4a, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x03,
+	0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0f, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x69, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x10, 0x00,
+	0x10, 0x11, 0x13, 0x04, 0x08, 0x18, 0x2b, 0x21, 0x11, 0x10, 0x00, 0x23, 0x35, 0x20, 0x00, 0x13,
+	0x12, 0x00, 0x37, 0x15, 0x06, 0x00, 0x11, 0x11, 0x02, 0x03, 0xfe, 0xda, 0xc9, 0x01, 0x24, 0x01,
+	0x4f, 0x4e, 0x5b, 0x01, 0x4f, 0xc3, 0xcf, 0xfe, 0xc5, 0x01, 0xb4, 0x01, 0x53, 0x01, 0xf0, 0xd1,
+	0xfe, 0xdd, 0xfe, 0xbc, 0x01, 0x0a, 0x01, 0x49, 0x14, 0xb9, 0x31, 0xfd, 0xf4, 0xfe, 0xd2, 0xfe,
+	0x5c, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x69, 0x00, 0x00, 0x06, 0x28, 0x05, 0xc8, 0x00, 0x11,
+	0x00, 0x18, 0x00, 0x1f, 0x00, 0x53, 0x40, 0x09, 0x1a, 0x19, 0x18, 0x12, 0x04, 0x00, 0x01, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01,
+	0x00, 0x69, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40,
+	0x16, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x69, 0x00, 0x02, 0x02, 0x05, 0x5f,
+	0x06, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11,
+	0x14, 0x11, 0x11, 0x14, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x21, 0x35, 0x24, 0x00, 0x35, 0x34, 0x00,
+	0x25, 0x35, 0x21, 0x15, 0x04, 0x00, 0x15, 0x14, 0x00, 0x05, 0x15, 0x01, 0x06, 0x06, 0x15, 0x14,
+	0x16, 0x17, 0x01, 0x11, 0x36, 0x36, 0x35, 0x34, 0x26, 0x02, 0xc2, 0xfe, 0xea, 0xfe, 0xbd, 0x01,
+	0x43, 0x01, 0x16, 0x01, 0x0e, 0x01, 0x0c, 0x01, 0x4c, 0xfe, 0xbe, 0xfe, 0xea, 0xfe, 0xf2, 0xa6,
+	0xa5, 0xa5, 0xa6, 0x01, 0x0e, 0xa6, 0xa4, 0xa4, 0xca, 0x0c, 0x01, 0x26, 0xe8, 0xe9, 0x01, 0x25,
+	0x0c, 0xca, 0xca, 0x0c, 0xfe, 0xdb, 0xe9, 0xe8, 0xfe, 0xda, 0x0c, 0xca, 0x04, 0x33, 0x0d, 0xad,
+	0x95, 0x96, 0xac, 0x0c, 0x02, 0x9d, 0xfd, 0x63, 0x0c, 0xac, 0x96, 0x95, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x31, 0x00, 0x00, 0x05, 0x29, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09,
+	0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e,
+	0x01, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40,
+	0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59,
+	0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x08, 0x19, 0x2b, 0x33,
+	0x01, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x01, 0x31, 0x01, 0xda, 0xfe, 0x3b,
+	0x01, 0x67, 0x01, 0x2d, 0x01, 0x46, 0xf9, 0xfe, 0x3a, 0x01, 0xd6, 0xfe, 0x9a, 0xfe, 0xbf, 0xfe,
+	0xa8, 0x02, 0xd9, 0x02, 0xef, 0xfe, 0x0e, 0x01, 0xf2, 0xfd, 0x46, 0xfc, 0xf2, 0x02, 0x11, 0xfd,
+	0xef, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x50, 0x00, 0x00, 0x06, 0x29, 0x05, 0xc8, 0x00, 0x27,
+	0x00, 0x50, 0x40, 0x09, 0x26, 0x15, 0x12, 0x01, 0x04, 0x05, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x14, 0x04, 0x01, 0x00, 0x00, 0x01, 0x61, 0x03, 0x02, 0x02, 0x01, 0x01, 0x28,
+	0x4d, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x15, 0x04, 0x01, 0x00, 0x05, 0x01,
+	0x00, 0x59, 0x03, 0x02, 0x02, 0x01, 0x01, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x27, 0x00, 0x27, 0x22, 0x17, 0x17, 0x22, 0x17, 0x07, 0x08,
+	0x1b, 0x2b, 0x21, 0x11, 0x26, 0x26, 0x27, 0x27, 0x26, 0x26, 0x23, 0x23, 0x35, 0x33, 0x32, 0x16,
+	0x17, 0x17, 0x16, 0x16, 0x17, 0x11, 0x21, 0x11, 0x36, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x33,
+	0x15, 0x23, 0x22, 0x06, 0x07, 0x07, 0x06, 0x06, 0x07, 0x11, 0x02, 0xa2, 0xd6, 0xc5, 0x20, 0x0e,
+	0x12, 0x34, 0x36, 0x0d, 0x13, 0xb3, 0xa0, 0x26, 0x12, 0x1b, 0x47, 0x52, 0x01, 0x35, 0x52, 0x47,
+	0x1b, 0x12, 0x26, 0xa0, 0xb3, 0x13, 0x0d, 0x36, 0x34, 0x12, 0x0e, 0x20, 0xc6, 0xd5, 0x02, 0x3f,
+	0x17, 0xb5, 0xd5, 0x5b, 0x78, 0x4a, 0xcb, 0x89, 0xd1, 0x60, 0x95, 0x71, 0x0a, 0x02, 0xca, 0xfd,
+	0x36, 0x0a, 0x71, 0x95, 0x60, 0xd1, 0x89, 0xcb, 0x4a, 0x78, 0x5b, 0xd5, 0xb5, 0x17, 0xfd, 0xc1,
+	0x00, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x06, 0x0a, 0x05, 0xed, 0x00, 0x1f, 0x00, 0x51, 0xb5, 0x1e,
+	0x12, 0x02, 0x00, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x04, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03,
+	0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x69, 0x02,
+	0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e,
+	0x00, 0x00, 0x00, 0x1f, 0x00, 0x1f, 0x26, 0x11, 0x15, 0x25, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x33,
+	0x35, 0x21, 0x26, 0x02, 0x35, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x14, 0x02, 0x07, 0x21, 0x15,
+	0x21, 0x35, 0x36, 0x12, 0x35, 0x34, 0x02, 0x23, 0x22, 0x02, 0x15, 0x14, 0x12, 0x17, 0x15, 0x5f,
+	0x01, 0x76, 0xac, 0xac, 0x01, 0x83, 0x01, 0x35, 0x01, 0x34, 0x01, 0x83, 0xac, 0xac, 0x01, 0x76,
+	0xfd, 0x95, 0x83, 0x8d, 0xd0, 0xaa, 0xab, 0xd0, 0x8d, 0x83, 0xd7, 0x88, 0x01, 0x39, 0xbc, 0x01,
+	0x27, 0x01, 0x72, 0xfe, 0x8e, 0xfe, 0xd9, 0xbb, 0xfe, 0xc6, 0x88, 0xd7, 0xd7, 0x70, 0x01, 0x2e,
+	0xc9, 0xe1, 0x01, 0x03, 0xfe, 0xfc, 0xe1, 0xc9, 0xfe, 0xd3, 0x70, 0xd7, 0x00, 0x03, 0x00, 0x64,
+	0x00, 0x00, 0x03, 0x3c, 0x07, 0x40, 0x00, 0x03, 0x00, 0x07, 0x00, 0x13, 0x00, 0x76, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x24, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01,
+	0x67, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x28, 0x4d, 0x08, 0x01, 0x04, 0x04,
+	0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x29, 0x09, 0x4e, 0x1b, 0x40, 0x22, 0x02, 0x01, 0x00, 0x0b,
+	0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01, 0x67, 0x00, 0x06, 0x07, 0x01, 0x05, 0x04, 0x06, 0x05,
+	0x67, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x2c, 0x09, 0x4e, 0x59, 0x40,
+	0x22, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x13, 0x08, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e,
+	0x0d, 0x0c, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0d, 0x08, 0x17, 0x2b, 0x13, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x11,
+	0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x80, 0xde, 0xd9, 0xdf, 0xfd, 0x4e, 0xd2, 0xd2,
+	0x02, 0xd8, 0xd2, 0xd2, 0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0xf9, 0x9e, 0xc8, 0x04, 0x2e, 0xd2,
+	0xd2, 0xfb, 0xd2, 0xc8, 0x00, 0x03, 0x00, 0x14, 0x00, 0x00, 0x05, 0x42, 0x07, 0x40, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x72, 0x40, 0x0f, 0x0c, 0x01, 0x00, 0x01, 0x08, 0x01, 0x02, 0x00,
+	0x02, 0x4c, 0x0b, 0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x05, 0x01,
+	0x03, 0x09, 0x06, 0x08, 0x03, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x28, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x05, 0x01,
+	0x03, 0x09, 0x06, 0x08, 0x03, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01,
+	0x00, 0x69, 0x07, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x1b, 0x15, 0x15, 0x11, 0x11,
+	0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10,
+	0x00, 0x10, 0x11, 0x13, 0x0a, 0x08, 0x18, 0x2b, 0x21, 0x11, 0x10, 0x00, 0x23, 0x35, 0x20, 0x00,
+	0x13, 0x12, 0x00, 0x37, 0x15, 0x06, 0x00, 0x11, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0x02, 0x03, 0xfe, 0xda, 0xc9, 0x01, 0x24, 0x01, 0x4f, 0x4e, 0x5b, 0x01, 0x4f, 0xc3, 0xcf,
+	0xfe, 0xc5, 0xfe, 0x3d, 0xde, 0xd9, 0xdf, 0x01, 0xb4, 0x01, 0x53, 0x01, 0xf0, 0xd1, 0xfe, 0xdd,
+	0xfe, 0xbc, 0x01, 0x0a, 0x01, 0x49, 0x14, 0xb9, 0x31, 0xfd, 0xf4, 0xfe, 0xd2, 0xfe, 0x5c, 0x06,
+	0x62, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe9, 0x04, 0xbe,
+	0x06, 0xa6, 0x00, 0x03, 0x00, 0x2e, 0x00, 0x43, 0x00, 0xb5, 0x40, 0x09, 0x43, 0x29, 0x20, 0x09,
+	0x04, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x23, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85, 0x00, 0x06, 0x06, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03,
+	0x31, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x09, 0x05, 0x02, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x03,
+	0x01, 0x85, 0x00, 0x04, 0x04, 0x2b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31,
+	0x4d, 0x09, 0x01, 0x05, 0x05, 0x29, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32,
+	0x02, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85,
+	0x00, 0x04, 0x04, 0x2b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x09,
+	0x01, 0x05, 0x05, 0x2c, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x3f, 0x3d, 0x35, 0x33, 0x04, 0x2e, 0x04, 0x2e,
+	0x26, 0x25, 0x1b, 0x19, 0x0f, 0x0d, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x08, 0x17, 0x2b, 0x01,
+	0x13, 0x21, 0x01, 0x01, 0x2e, 0x03, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e,
+	0x04, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x17, 0x3e, 0x03, 0x37, 0x33, 0x06, 0x02, 0x07, 0x1e, 0x03,
+	0x17, 0x01, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x02, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x02,
+	0x37, 0x01, 0xf9, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x01, 0x33, 0x0c, 0x1b, 0x1f, 0x24, 0x13, 0x1a,
+	0x42, 0x5d, 0x7c, 0x53, 0x69, 0x8d, 0x56, 0x25, 0x10, 0x27, 0x40, 0x5f, 0x80, 0x54, 0x4c, 0x6c,
+	0x4f, 0x3c, 0x1b, 0x32, 0x0a, 0x16, 0x14, 0x0e, 0x02, 0xef, 0x1b, 0x74, 0x49, 0x14, 0x37, 0x3d,
+	0x3d, 0x1a, 0xfe, 0x0f, 0x1d, 0x2d, 0x2d, 0x32, 0x21, 0x2b, 0x34, 0x1c, 0x09, 0x07, 0x18, 0x30,
+	0x29, 0x2d, 0x51, 0x43, 0x33, 0x11, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfa, 0xfd, 0x18, 0x43,
+	0x4e, 0x56, 0x2a, 0x34, 0x71, 0x5e, 0x3d, 0x59, 0x94, 0xbf, 0x66, 0x44, 0x91, 0x88, 0x7a, 0x5c,
+	0x35, 0x2d, 0x50, 0x6b, 0x3f, 0x72, 0x20, 0x59, 0x66, 0x6d, 0x34, 0xa9, 0xfe, 0xd9, 0x89, 0x33,
+	0x7c, 0x83, 0x83, 0x3a, 0x02, 0x41, 0x44, 0x75, 0x55, 0x31, 0x49, 0x71, 0x8b, 0x42, 0x36, 0x72,
+	0x5d, 0x3c, 0x3a, 0x55, 0x62, 0x29, 0x00, 0x00, 0x00, 0x02, 0x00, 0x47, 0xff, 0xe7, 0x03, 0x97,
+	0x06, 0xa6, 0x00, 0x03, 0x00, 0x22, 0x00, 0x57, 0x40, 0x54, 0x13, 0x01, 0x04, 0x03, 0x14, 0x01,
+	0x05, 0x04, 0x0c, 0x01, 0x06, 0x05, 0x04, 0x01, 0x07, 0x06, 0x05, 0x01, 0x02, 0x07, 0x05, 0x4c,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85, 0x00, 0x05, 0x00, 0x06, 0x07,
+	0x05, 0x06, 0x69, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x07, 0x07,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x00, 0x00, 0x22, 0x20, 0x1e, 0x1c, 0x1b, 0x19,
+	0x17, 0x15, 0x12, 0x10, 0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0x01,
+	0x13, 0x21, 0x01, 0x01, 0x15, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x25, 0x26, 0x35, 0x34, 0x36,
+	0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x21, 0x33, 0x15, 0x23, 0x20, 0x15, 0x14,
+	0x33, 0x32, 0x01, 0xb3, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x01, 0x3e, 0xd5, 0xac, 0xc7, 0xf6, 0x01,
+	0x1e, 0xf9, 0xf8, 0xd8, 0x9b, 0x90, 0x90, 0x7b, 0xc8, 0x01, 0x49, 0x33, 0x6a, 0xfe, 0xd5, 0xcb,
+	0x79, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfb, 0xec, 0xbf, 0x49, 0xac, 0x8b, 0xcb, 0x6d, 0x3f,
+	0xb7, 0x82, 0x95, 0x1d, 0xb8, 0x1d, 0x76, 0x8d, 0xb9, 0xb2, 0x9b, 0x00, 0x00, 0x02, 0x00, 0x41,
+	0xfe, 0x75, 0x04, 0x5c, 0x06, 0xa6, 0x00, 0x14, 0x00, 0x18, 0x00, 0xa6, 0x40, 0x0a, 0x06, 0x01,
+	0x03, 0x00, 0x13, 0x01, 0x04, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x00,
+	0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01,
+	0x01, 0x00, 0x00, 0x2b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01,
+	0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x31, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b,
+	0x40, 0x26, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00,
+	0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x07, 0x01, 0x04, 0x04,
+	0x2c, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x15, 0x15, 0x15, 0x00, 0x00,
+	0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x00, 0x14, 0x00, 0x14, 0x23, 0x13, 0x23, 0x13, 0x09, 0x08,
+	0x1a, 0x2b, 0x33, 0x11, 0x34, 0x27, 0x21, 0x16, 0x17, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x21,
+	0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x13, 0x13, 0x21, 0x01, 0x82, 0x41, 0x01, 0x40, 0x16,
+	0x13, 0xa1, 0xd5, 0x9e, 0x9e, 0xfe, 0xd8, 0x44, 0x44, 0x88, 0x7a, 0x1d, 0xd2, 0x01, 0x12, 0xfe,
+	0xb0, 0x03, 0x01, 0xbe, 0x8b, 0x4d, 0x83, 0xe9, 0xc0, 0xbf, 0xfb, 0x91, 0x04, 0x3b, 0x61, 0x61,
+	0xbc, 0xfd, 0x4a, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x91,
+	0xff, 0xe7, 0x02, 0xe1, 0x06, 0xa6, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x35, 0x40, 0x32, 0x0f, 0x01,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04,
+	0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x32, 0x00, 0x4e, 0x10, 0x10, 0x10, 0x13, 0x10, 0x13, 0x13, 0x23, 0x15, 0x21, 0x06, 0x08, 0x1a,
+	0x2b, 0x25, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x01, 0x13, 0x21, 0x01, 0x02, 0xe1, 0x77, 0x7a, 0xa0, 0x56, 0x3e, 0x2b, 0x01, 0x28, 0x41,
+	0x4e, 0x42, 0x57, 0xfd, 0xe2, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x19, 0x32, 0x49, 0x34, 0xa2, 0xb4,
+	0x02, 0x90, 0xfd, 0x5e, 0x8c, 0x73, 0x2a, 0x04, 0x30, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x87, 0xff, 0xe7, 0x04, 0x35, 0x07, 0x1f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d,
+	0x00, 0x21, 0x00, 0x84, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x08, 0x04, 0x08, 0x85,
+	0x0c, 0x01, 0x09, 0x04, 0x05, 0x04, 0x09, 0x05, 0x80, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x05, 0x04,
+	0x5f, 0x06, 0x01, 0x04, 0x04, 0x28, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01,
+	0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x08, 0x04, 0x08, 0x85,
+	0x0c, 0x01, 0x09, 0x04, 0x05, 0x04, 0x09, 0x05, 0x80, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03,
+	0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62,
+	0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x59, 0x40, 0x1e, 0x1e, 0x1e, 0x1a, 0x1a, 0x16, 0x16, 0x1e,
+	0x21, 0x1e, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x16,
+	0x24, 0x14, 0x23, 0x10, 0x0d, 0x08, 0x1b, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x36,
+	0x27, 0x12, 0x03, 0x21, 0x12, 0x11, 0x10, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x11, 0x35,
+	0x33, 0x15, 0x21, 0x35, 0x33, 0x15, 0x25, 0x13, 0x21, 0x01, 0x87, 0x01, 0x28, 0x61, 0x6d, 0x72,
+	0x6f, 0x03, 0x03, 0xc7, 0x01, 0x34, 0x6a, 0xfe, 0xf7, 0xde, 0xc3, 0x7e, 0x50, 0x36, 0xde, 0x01,
+	0xc9, 0xde, 0xfd, 0xbc, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x04, 0x4a, 0xfd, 0xf0, 0xed, 0xad, 0xb6,
+	0x7e, 0x01, 0x29, 0x01, 0x4d, 0xfe, 0xea, 0xfe, 0xf9, 0xfe, 0xf5, 0xfe, 0xc5, 0x76, 0x4a, 0xc5,
+	0xd6, 0x02, 0xcb, 0xde, 0xde, 0xde, 0xde, 0x6f, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x02, 0x00, 0x4a,
+	0xff, 0xe9, 0x04, 0xbe, 0x04, 0x63, 0x00, 0x2a, 0x00, 0x3f, 0x00, 0x8a, 0x40, 0x09, 0x3f, 0x25,
+	0x1c, 0x05, 0x04, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04,
+	0x04, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x06, 0x03,
+	0x02, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x00, 0x02,
+	0x02, 0x2b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x06, 0x01, 0x03,
+	0x03, 0x29, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x40,
+	0x20, 0x00, 0x02, 0x02, 0x2b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d,
+	0x06, 0x01, 0x03, 0x03, 0x2c, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x32, 0x00,
+	0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x3b, 0x39, 0x31, 0x2f, 0x00, 0x2a, 0x00, 0x2a, 0x1a,
+	0x2a, 0x29, 0x07, 0x08, 0x19, 0x2b, 0x21, 0x2e, 0x03, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02,
+	0x35, 0x34, 0x3e, 0x04, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x17, 0x3e, 0x03, 0x37, 0x33, 0x06, 0x02,
+	0x07, 0x1e, 0x03, 0x17, 0x01, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x02, 0x15, 0x14, 0x1e, 0x02, 0x33,
+	0x32, 0x3e, 0x02, 0x37, 0x03, 0xc0, 0x0c, 0x1b, 0x1f, 0x24, 0x13, 0x1a, 0x42, 0x5d, 0x7c, 0x53,
+	0x69, 0x8d, 0x56, 0x25, 0x10, 0x27, 0x40, 0x5f, 0x80, 0x54, 0x4c, 0x6c, 0x4f, 0x3c, 0x1b, 0x32,
+	0x0a, 0x16, 0x14, 0x0e, 0x02, 0xef, 0x1b, 0x74, 0x49, 0x14, 0x37, 0x3d, 0x3d, 0x1a, 0xfe, 0x0f,
+	0x1d, 0x2d, 0x2d, 0x32, 0x21, 0x2b, 0x34, 0x1c, 0x09, 0x07, 0x18, 0x30, 0x29, 0x2d, 0x51, 0x43,
+	0x33, 0x11, 0x18, 0x43, 0x4e, 0x56, 0x2a, 0x34, 0x71, 0x5e, 0x3d, 0x59, 0x94, 0xbf, 0x66, 0x44,
+	0x91, 0x88, 0x7a, 0x5c, 0x35, 0x2d, 0x50, 0x6b, 0x3f, 0x72, 0x20, 0x59, 0x66, 0x6d, 0x34, 0xa9,
+	0xfe, 0xd9, 0x89, 0x33, 0x7c, 0x83, 0x83, 0x3a, 0x02, 0x41, 0x44, 0x75, 0x55, 0x31, 0x49, 0x71,
+	0x8b, 0x42, 0x36, 0x72, 0x5d, 0x3c, 0x3a, 0x55, 0x62, 0x29, 0x00, 0x00, 0x00, 0x02, 0x00, 0x94,
+	0xfe, 0x75, 0x04, 0x8a, 0x06, 0x44, 0x00, 0x13, 0x00, 0x28, 0x00, 0x47, 0x40, 0x44, 0x0a, 0x01,
+	0x06, 0x03, 0x1f, 0x01, 0x05, 0x06, 0x12, 0x01, 0x01, 0x05, 0x03, 0x4c, 0x00, 0x03, 0x00, 0x06,
+	0x05, 0x03, 0x06, 0x69, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x05,
+	0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x32, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x00,
+	0x00, 0x28, 0x26, 0x22, 0x20, 0x1c, 0x1a, 0x16, 0x14, 0x00, 0x13, 0x00, 0x13, 0x2a, 0x23, 0x08,
+	0x08, 0x18, 0x2b, 0x13, 0x11, 0x10, 0x12, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x07, 0x16, 0x16,
+	0x15, 0x14, 0x00, 0x23, 0x22, 0x27, 0x11, 0x13, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22,
+	0x06, 0x15, 0x11, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x94, 0xfd, 0xfe, 0xaa,
+	0xe3, 0x81, 0x97, 0xbc, 0xca, 0xfe, 0xe4, 0xdb, 0x60, 0x77, 0x3d, 0x19, 0x69, 0x7b, 0x49, 0x3a,
+	0x5c, 0x5b, 0x66, 0x5d, 0x61, 0x81, 0xc4, 0x89, 0x1b, 0xfe, 0x75, 0x05, 0x4f, 0x01, 0x40, 0x01,
+	0x40, 0xbf, 0xa0, 0x77, 0xbd, 0x4d, 0x2e, 0xe9, 0xa2, 0xc1, 0xfe, 0xfd, 0x26, 0xfe, 0x68, 0x05,
+	0x1f, 0xb7, 0x87, 0x5c, 0x5d, 0xbb, 0xbb, 0xfc, 0xc0, 0x35, 0x97, 0x6e, 0x86, 0xb6, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x07, 0xfe, 0x75, 0x04, 0x6c, 0x04, 0x4a, 0x00, 0x1e, 0x00, 0x1c, 0x40, 0x19,
+	0x16, 0x0b, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x02, 0x02,
+	0x2d, 0x02, 0x4e, 0x1b, 0x18, 0x15, 0x03, 0x08, 0x19, 0x2b, 0x25, 0x26, 0x02, 0x26, 0x26, 0x27,
+	0x21, 0x1e, 0x03, 0x17, 0x36, 0x12, 0x37, 0x33, 0x0e, 0x05, 0x07, 0x16, 0x15, 0x14, 0x07, 0x23,
+	0x26, 0x35, 0x34, 0x01, 0xc5, 0x34, 0x6c, 0x70, 0x74, 0x3a, 0x01, 0x52, 0x2a, 0x49, 0x42, 0x3c,
+	0x1d, 0x38, 0x92, 0x5b, 0xe0, 0x23, 0x4d, 0x4f, 0x4d, 0x45, 0x3a, 0x13, 0x3a, 0x48, 0xfb, 0x3d,
+	0x80, 0x88, 0x01, 0x0c, 0xfa, 0xe0, 0x5c, 0x4b, 0xa5, 0xad, 0xae, 0x54, 0x98, 0x01, 0x5b, 0xac,
+	0x34, 0x92, 0xa8, 0xb3, 0xab, 0x98, 0x38, 0xba, 0x62, 0x84, 0x99, 0x8a, 0x6e, 0x5f, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x8b, 0x06, 0x44, 0x00, 0x1e, 0x00, 0x2a, 0x00, 0x29,
+	0x40, 0x26, 0x08, 0x01, 0x01, 0x00, 0x09, 0x01, 0x03, 0x01, 0x02, 0x4c, 0x00, 0x01, 0x01, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02,
+	0x4e, 0x2a, 0x2c, 0x23, 0x25, 0x04, 0x08, 0x1a, 0x2b, 0x01, 0x26, 0x26, 0x35, 0x34, 0x24, 0x33,
+	0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x1f, 0x03, 0x16, 0x12, 0x15, 0x14, 0x00,
+	0x23, 0x22, 0x00, 0x35, 0x34, 0x36, 0x05, 0x06, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35,
+	0x34, 0x26, 0x01, 0xbe, 0xb8, 0x8e, 0x01, 0x08, 0xed, 0xa8, 0x94, 0x9d, 0xa4, 0x64, 0x64, 0x60,
+	0x5e, 0x5c, 0x59, 0xc9, 0xaf, 0xfe, 0xd1, 0xf0, 0xf0, 0xfe, 0xce, 0xba, 0x01, 0x67, 0x77, 0x76,
+	0x85, 0x6b, 0x66, 0x82, 0x69, 0x03, 0xcd, 0x67, 0x96, 0x59, 0x89, 0x98, 0x22, 0xd0, 0x39, 0x2e,
+	0x2d, 0x2c, 0x3a, 0x3b, 0x38, 0x37, 0x7f, 0xfe, 0xfd, 0xad, 0xe7, 0xfe, 0xdd, 0x01, 0x12, 0xd6,
+	0xad, 0xff, 0x26, 0x44, 0xbe, 0x7a, 0x8c, 0xad, 0xb2, 0x8c, 0x7e, 0xa7, 0x00, 0x01, 0x00, 0x47,
+	0xff, 0xe7, 0x03, 0x85, 0x04, 0x63, 0x00, 0x1e, 0x00, 0x3f, 0x40, 0x3c, 0x0f, 0x01, 0x02, 0x01,
+	0x10, 0x01, 0x03, 0x02, 0x08, 0x01, 0x04, 0x03, 0x00, 0x01, 0x05, 0x04, 0x01, 0x01, 0x00, 0x05,
+	0x05, 0x4c, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x69, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x22,
+	0x21, 0x22, 0x23, 0x28, 0x22, 0x06, 0x08, 0x1c, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x22, 0x26, 0x35,
+	0x34, 0x25, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x21,
+	0x33, 0x15, 0x23, 0x20, 0x15, 0x14, 0x33, 0x32, 0x03, 0x85, 0xd5, 0xac, 0xc7, 0xf6, 0x01, 0x1e,
+	0xf9, 0xf8, 0xd8, 0x9b, 0x90, 0x90, 0x7b, 0xc8, 0x01, 0x49, 0x33, 0x6a, 0xfe, 0xd5, 0xcb, 0x79,
+	0xef, 0xbf, 0x49, 0xac, 0x8b, 0xcb, 0x6d, 0x3f, 0xb7, 0x82, 0x95, 0x1d, 0xb8, 0x1d, 0x76, 0x8d,
+	0xb9, 0xb2, 0x9b, 0x00, 0x00, 0x01, 0xff, 0xff, 0xfe, 0x5d, 0x04, 0x12, 0x06, 0x44, 0x00, 0x42,
+	0x00, 0x54, 0x40, 0x12, 0x2d, 0x01, 0x02, 0x03, 0x2c, 0x01, 0x01, 0x02, 0x02, 0x4c, 0x0f, 0x0e,
+	0x09, 0x04, 0x03, 0x05, 0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2d,
+	0x01, 0x4e, 0x1b, 0x40, 0x12, 0x00, 0x02, 0x00, 0x01, 0x02, 0x01, 0x65, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0b, 0x3c, 0x39, 0x33, 0x31, 0x2a, 0x28,
+	0x20, 0x1d, 0x04, 0x08, 0x16, 0x2b, 0x01, 0x26, 0x26, 0x27, 0x11, 0x1e, 0x03, 0x17, 0x3e, 0x03,
+	0x37, 0x17, 0x0e, 0x03, 0x07, 0x0e, 0x03, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x33, 0x32, 0x1e, 0x02,
+	0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x27, 0x35, 0x1e, 0x03, 0x33, 0x32, 0x36, 0x35, 0x34,
+	0x2e, 0x02, 0x23, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x12, 0x01, 0x51, 0x5e, 0xa3, 0x51, 0x3c,
+	0x66, 0x6f, 0x87, 0x5d, 0x31, 0x61, 0x6e, 0x80, 0x51, 0x4d, 0x22, 0x4c, 0x66, 0x86, 0x5c, 0x39,
+	0x57, 0x3b, 0x1d, 0x10, 0x37, 0x67, 0x57, 0x14, 0x5d, 0x81, 0x51, 0x25, 0x29, 0x55, 0x84, 0x5a,
+	0x20, 0x58, 0x34, 0x18, 0x28, 0x27, 0x28, 0x1a, 0x36, 0x3c, 0x1d, 0x2e, 0x38, 0x1b, 0x15, 0x8d,
+	0xc1, 0x76, 0x34, 0x81, 0x04, 0x75, 0x07, 0x30, 0x1f, 0x01, 0x01, 0x24, 0x36, 0x2a, 0x20, 0x0c,
+	0x38, 0x5a, 0x48, 0x37, 0x17, 0x98, 0x2d, 0x56, 0x4d, 0x43, 0x1b, 0x46, 0xa3, 0xab, 0xad, 0x4f,
+	0x42, 0x62, 0x40, 0x20, 0x30, 0x53, 0x6f, 0x3e, 0x51, 0x81, 0x5a, 0x31, 0x09, 0x0a, 0xcb, 0x06,
+	0x0a, 0x06, 0x03, 0x3b, 0x3c, 0x20, 0x28, 0x17, 0x08, 0x41, 0x7d, 0xb6, 0x74, 0xa3, 0x01, 0x4f,
+	0x00, 0x01, 0x00, 0x41, 0xfe, 0x75, 0x04, 0x5c, 0x04, 0x63, 0x00, 0x14, 0x00, 0x7d, 0x40, 0x0a,
+	0x06, 0x01, 0x03, 0x00, 0x13, 0x01, 0x04, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x17, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05, 0x01, 0x04, 0x04,
+	0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b,
+	0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x00,
+	0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05, 0x01, 0x04,
+	0x04, 0x2c, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00,
+	0x14, 0x00, 0x14, 0x23, 0x13, 0x23, 0x13, 0x06, 0x08, 0x1a, 0x2b, 0x33, 0x11, 0x34, 0x27, 0x21,
+	0x16, 0x17, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x21, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x82, 0x41, 0x01, 0x40, 0x16, 0x13, 0xa1, 0xd5, 0x9e, 0x9e, 0xfe, 0xd8, 0x44, 0x44, 0x88, 0x7a,
+	0x03, 0x01, 0xbe, 0x8b, 0x4d, 0x83, 0xe9, 0xc0, 0xbf, 0xfb, 0x91, 0x04, 0x3b, 0x61, 0x61, 0xbc,
+	0xfd, 0x4a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x09, 0x06, 0x44, 0x00, 0x06,
+	0x00, 0x17, 0x00, 0x33, 0x00, 0x36, 0x40, 0x33, 0x00, 0x00, 0x06, 0x01, 0x03, 0x02, 0x00, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x05, 0x61, 0x00, 0x05, 0x05, 0x2a, 0x4d, 0x00, 0x02, 0x02, 0x04, 0x61,
+	0x07, 0x01, 0x04, 0x04, 0x32, 0x04, 0x4e, 0x19, 0x18, 0x07, 0x07, 0x27, 0x25, 0x18, 0x33, 0x19,
+	0x33, 0x07, 0x17, 0x07, 0x17, 0x29, 0x22, 0x10, 0x08, 0x08, 0x19, 0x2b, 0x01, 0x21, 0x10, 0x02,
+	0x23, 0x22, 0x02, 0x03, 0x15, 0x14, 0x1e, 0x04, 0x33, 0x32, 0x3e, 0x04, 0x35, 0x35, 0x03, 0x22,
+	0x2e, 0x04, 0x35, 0x34, 0x3e, 0x04, 0x33, 0x32, 0x1e, 0x04, 0x15, 0x14, 0x0e, 0x04, 0x01, 0x6e,
+	0x01, 0x77, 0x59, 0x62, 0x62, 0x5a, 0x02, 0x02, 0x0c, 0x17, 0x2b, 0x40, 0x2e, 0x2e, 0x3f, 0x2a,
+	0x18, 0x0b, 0x03, 0xbd, 0x62, 0x93, 0x6b, 0x46, 0x29, 0x11, 0x11, 0x29, 0x46, 0x6b, 0x93, 0x62,
+	0x62, 0x93, 0x6b, 0x45, 0x29, 0x11, 0x11, 0x29, 0x45, 0x6b, 0x93, 0x03, 0x81, 0x01, 0x06, 0x01,
+	0x04, 0xfe, 0xfc, 0xfe, 0x41, 0x2b, 0x31, 0x72, 0x71, 0x68, 0x51, 0x30, 0x30, 0x51, 0x69, 0x71,
+	0x71, 0x31, 0x2b, 0xfd, 0x1f, 0x41, 0x73, 0x9c, 0xb5, 0xc6, 0x64, 0x63, 0xc6, 0xb5, 0x9c, 0x73,
+	0x41, 0x41, 0x73, 0x9c, 0xb5, 0xc6, 0x63, 0x64, 0xc6, 0xb5, 0x9c, 0x73, 0x41, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x91, 0xff, 0xe7, 0x02, 0xe1, 0x04, 0x4a, 0x00, 0x0f, 0x00, 0x23, 0x40, 0x20,
+	0x0f, 0x01, 0x02, 0x01, 0x00, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x23, 0x15, 0x21, 0x03, 0x08, 0x19,
+	0x2b, 0x25, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x02, 0xe1, 0x77, 0x7a, 0xa0, 0x56, 0x3e, 0x2b, 0x01, 0x28, 0x41, 0x4e, 0x42, 0x57, 0x19,
+	0x32, 0x49, 0x34, 0xa2, 0xb4, 0x02, 0x90, 0xfd, 0x5e, 0x8c, 0x73, 0x2a, 0x00, 0x01, 0x00, 0x94,
+	0x00, 0x00, 0x04, 0x63, 0x04, 0x4a, 0x00, 0x12, 0x00, 0x4a, 0xb7, 0x11, 0x0e, 0x03, 0x03, 0x03,
+	0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x13, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01,
+	0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x13,
+	0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03,
+	0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x14, 0x21, 0x15, 0x11,
+	0x06, 0x08, 0x1a, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x37, 0x37, 0x36, 0x36, 0x33, 0x15, 0x27, 0x22,
+	0x06, 0x07, 0x07, 0x01, 0x21, 0x01, 0x11, 0x94, 0x01, 0x28, 0x52, 0x65, 0x9b, 0x99, 0x8a, 0x19,
+	0x40, 0x7a, 0x67, 0x32, 0x01, 0x9e, 0xfe, 0xc1, 0xfe, 0x98, 0x04, 0x4a, 0xfd, 0xf3, 0x68, 0x7e,
+	0xc1, 0x66, 0xce, 0x01, 0x60, 0x82, 0x3e, 0xfd, 0xa3, 0x02, 0x15, 0xfd, 0xeb, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x1b, 0x00, 0x00, 0x04, 0x8a, 0x06, 0x2b, 0x00, 0x1f, 0x00, 0x5d, 0xb6, 0x09,
+	0x06, 0x02, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x20, 0x50, 0x58, 0x40, 0x12, 0x00, 0x01, 0x01,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x2a, 0x4d, 0x04, 0x03, 0x02, 0x00, 0x00, 0x29, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x10, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x04,
+	0x03, 0x02, 0x00, 0x00, 0x29, 0x00, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02,
+	0x01, 0x69, 0x04, 0x03, 0x02, 0x00, 0x00, 0x2c, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x1f, 0x00, 0x1f, 0x21, 0x26, 0x17, 0x05, 0x08, 0x19, 0x2b, 0x21, 0x2e, 0x03, 0x27, 0x03,
+	0x01, 0x23, 0x01, 0x27, 0x2e, 0x03, 0x23, 0x23, 0x35, 0x33, 0x32, 0x1e, 0x04, 0x17, 0x01, 0x1e,
+	0x03, 0x17, 0x03, 0x3e, 0x20, 0x2c, 0x26, 0x23, 0x17, 0x77, 0xfe, 0xde, 0xde, 0x01, 0x93, 0x2d,
+	0x18, 0x2d, 0x3a, 0x53, 0x3d, 0x15, 0x1e, 0x5c, 0x8a, 0x68, 0x4c, 0x3f, 0x37, 0x1f, 0x01, 0x06,
+	0x1d, 0x35, 0x35, 0x35, 0x1e, 0x3c, 0x65, 0x62, 0x66, 0x3d, 0x01, 0x3f, 0xfd, 0x1b, 0x04, 0x08,
+	0x7b, 0x40, 0x4c, 0x27, 0x0b, 0xea, 0x0c, 0x20, 0x38, 0x56, 0x7b, 0x52, 0xfd, 0x3f, 0x4e, 0x86,
+	0x75, 0x69, 0x31, 0x00, 0x00, 0x01, 0x00, 0x94, 0xfe, 0x75, 0x04, 0xa4, 0x04, 0x4a, 0x00, 0x15,
+	0x00, 0x82, 0x40, 0x0b, 0x08, 0x01, 0x01, 0x00, 0x14, 0x10, 0x02, 0x03, 0x01, 0x02, 0x4c, 0x4b,
+	0xb0, 0x1b, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x04, 0x01, 0x03, 0x03, 0x29, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x29,
+	0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x32, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d,
+	0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x2c, 0x4d,
+	0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x32, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d, 0x05,
+	0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x15, 0x00, 0x15, 0x23, 0x13, 0x12, 0x23, 0x11,
+	0x07, 0x08, 0x1b, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11,
+	0x14, 0x17, 0x21, 0x26, 0x27, 0x06, 0x23, 0x22, 0x27, 0x11, 0x94, 0x01, 0x28, 0x44, 0x5e, 0x65,
+	0x7c, 0x01, 0x28, 0x3d, 0xfe, 0xc0, 0x16, 0x0f, 0x7c, 0x8a, 0x4d, 0x30, 0xfe, 0x75, 0x05, 0xd5,
+	0xfd, 0x5a, 0x66, 0x66, 0xbf, 0x02, 0xb3, 0xfc, 0xfe, 0xbf, 0x89, 0x4f, 0x80, 0xe2, 0x1f, 0xfe,
+	0x69, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x09, 0x00, 0x00, 0x04, 0x32, 0x04, 0x4a, 0x00, 0x22,
+	0x00, 0x3b, 0xb5, 0x0f, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c,
+	0x00, 0x00, 0x00, 0x22, 0x00, 0x22, 0x19, 0x18, 0x17, 0x04, 0x08, 0x17, 0x2b, 0x21, 0x2e, 0x05,
+	0x27, 0x21, 0x1e, 0x05, 0x17, 0x3e, 0x05, 0x35, 0x34, 0x27, 0x21, 0x16, 0x15, 0x14, 0x0e, 0x04,
+	0x07, 0x01, 0x8d, 0x14, 0x37, 0x41, 0x47, 0x49, 0x48, 0x20, 0x01, 0x4c, 0x20, 0x39, 0x33, 0x2d,
+	0x27, 0x20, 0x0c, 0x16, 0x32, 0x32, 0x2d, 0x22, 0x15, 0x1e, 0x01, 0x02, 0x0f, 0x25, 0x3e, 0x4f,
+	0x54, 0x53, 0x22, 0x4c, 0xbe, 0xcd, 0xd2, 0xc1, 0xa6, 0x3a, 0x46, 0x9d, 0xa1, 0x9f, 0x8f, 0x79,
+	0x2b, 0x24, 0x66, 0x78, 0x84, 0x83, 0x7c, 0x34, 0x4f, 0x4e, 0x35, 0x38, 0x41, 0xa2, 0xb2, 0xba,
+	0xb0, 0xa0, 0x3e, 0x00, 0x00, 0x01, 0x00, 0x15, 0xfe, 0x5d, 0x03, 0xdf, 0x06, 0x50, 0x00, 0x59,
+	0x00, 0x89, 0x40, 0x15, 0x1a, 0x11, 0x0b, 0x06, 0x04, 0x01, 0x00, 0x43, 0x01, 0x06, 0x07, 0x42,
+	0x01, 0x05, 0x06, 0x03, 0x4c, 0x0c, 0x01, 0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2a,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x80, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x6a,
+	0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x00, 0x07, 0x07, 0x29, 0x4d, 0x00,
+	0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x01, 0x00,
+	0x02, 0x00, 0x01, 0x02, 0x80, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x6a, 0x00, 0x06, 0x00,
+	0x05, 0x06, 0x05, 0x65, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x04, 0x04, 0x07, 0x61, 0x00, 0x07,
+	0x07, 0x2c, 0x07, 0x4e, 0x59, 0x40, 0x13, 0x51, 0x4d, 0x46, 0x44, 0x40, 0x3e, 0x36, 0x33, 0x2b,
+	0x29, 0x28, 0x26, 0x1f, 0x1e, 0x17, 0x15, 0x08, 0x08, 0x16, 0x2b, 0x01, 0x26, 0x26, 0x35, 0x34,
+	0x36, 0x37, 0x2e, 0x03, 0x27, 0x35, 0x1e, 0x03, 0x17, 0x3e, 0x03, 0x33, 0x32, 0x16, 0x17, 0x17,
+	0x0e, 0x03, 0x23, 0x06, 0x06, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x33, 0x15, 0x23, 0x22, 0x0e, 0x02,
+	0x15, 0x14, 0x1e, 0x02, 0x33, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26,
+	0x27, 0x35, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x22, 0x2e, 0x02,
+	0x35, 0x34, 0x3e, 0x02, 0x01, 0xa9, 0x8b, 0x80, 0x22, 0x24, 0x20, 0x35, 0x31, 0x2f, 0x1a, 0x2e,
+	0x50, 0x52, 0x5e, 0x3c, 0x16, 0x3c, 0x4b, 0x5b, 0x35, 0x28, 0x62, 0x2d, 0x24, 0x1d, 0x51, 0x68,
+	0x7e, 0x4b, 0x17, 0x20, 0x18, 0x3f, 0x6e, 0x57, 0x81, 0x79, 0x6b, 0x88, 0x50, 0x1e, 0x1f, 0x38,
+	0x4b, 0x2d, 0x20, 0x66, 0x91, 0x5b, 0x2a, 0x45, 0x7c, 0xab, 0x65, 0x2a, 0x5c, 0x33, 0x5e, 0x69,
+	0x3d, 0x52, 0x32, 0x15, 0x2d, 0x41, 0x48, 0x1b, 0x17, 0x75, 0xa9, 0x6d, 0x35, 0x2a, 0x57, 0x84,
+	0x03, 0x38, 0x2d, 0x99, 0x70, 0x33, 0x68, 0x2a, 0x05, 0x0e, 0x12, 0x15, 0x0c, 0xd7, 0x19, 0x29,
+	0x20, 0x18, 0x09, 0x15, 0x2b, 0x22, 0x15, 0x09, 0x08, 0x75, 0x20, 0x3a, 0x2c, 0x1a, 0x22, 0x4f,
+	0x3d, 0x24, 0x55, 0x48, 0x31, 0xb9, 0x30, 0x4e, 0x62, 0x32, 0x34, 0x4c, 0x31, 0x18, 0x26, 0x4d,
+	0x73, 0x4c, 0x63, 0x86, 0x50, 0x22, 0x09, 0x0a, 0xcb, 0x19, 0x0f, 0x1e, 0x2c, 0x1e, 0x25, 0x2a,
+	0x14, 0x04, 0x34, 0x65, 0x92, 0x5f, 0x46, 0x83, 0x70, 0x59, 0x00, 0x00, 0x00, 0x02, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x99, 0x04, 0x63, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01,
+	0x00, 0x00, 0x32, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x08, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33,
+	0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70,
+	0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0xfe,
+	0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2, 0xb3, 0xb1, 0xd4,
+	0x00, 0x01, 0x00, 0x21, 0x00, 0x00, 0x05, 0xe3, 0x04, 0x4a, 0x00, 0x13, 0x00, 0x50, 0x40, 0x0a,
+	0x05, 0x01, 0x00, 0x01, 0x04, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x06, 0x05, 0x02,
+	0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x2b, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x00, 0x13, 0x00, 0x13, 0x13, 0x13, 0x11, 0x23, 0x21, 0x07, 0x08, 0x1b, 0x2b, 0x21, 0x11,
+	0x23, 0x22, 0x07, 0x11, 0x36, 0x33, 0x21, 0x15, 0x23, 0x11, 0x14, 0x17, 0x21, 0x26, 0x35, 0x11,
+	0x21, 0x11, 0x01, 0x4e, 0x14, 0x73, 0xa6, 0x7d, 0xc4, 0x04, 0x81, 0xf5, 0x6c, 0xfe, 0xae, 0x42,
+	0xfe, 0xb0, 0x03, 0x6c, 0x6c, 0x01, 0x05, 0x45, 0xde, 0xfd, 0xcd, 0xdb, 0x5e, 0x53, 0xf6, 0x02,
+	0x23, 0xfc, 0x94, 0x00, 0x00, 0x02, 0x00, 0x87, 0xfe, 0x75, 0x04, 0xa9, 0x04, 0x63, 0x00, 0x0d,
+	0x00, 0x19, 0x00, 0x5f, 0x40, 0x0a, 0x0e, 0x01, 0x03, 0x04, 0x0c, 0x01, 0x01, 0x03, 0x02, 0x4c,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x31,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x2d,
+	0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00, 0x00, 0x31, 0x4d, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e,
+	0x59, 0x40, 0x0f, 0x00, 0x00, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0d, 0x00, 0x0d, 0x24, 0x23, 0x06,
+	0x08, 0x18, 0x2b, 0x13, 0x11, 0x10, 0x12, 0x21, 0x20, 0x00, 0x15, 0x10, 0x00, 0x21, 0x22, 0x27,
+	0x11, 0x11, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x11, 0x87, 0xf6, 0x01,
+	0x0b, 0x01, 0x1c, 0x01, 0x05, 0xfe, 0xbd, 0xfe, 0xf7, 0x5c, 0x52, 0x4d, 0x57, 0x8f, 0x9f, 0x79,
+	0x7e, 0x7e, 0x5d, 0xfe, 0x75, 0x02, 0xca, 0x01, 0xba, 0x01, 0x6a, 0xfe, 0xf6, 0xea, 0xfe, 0xf4,
+	0xfe, 0x9d, 0x1b, 0xfe, 0x5a, 0x02, 0x79, 0x35, 0xec, 0xb5, 0x9c, 0xb4, 0xdc, 0xfe, 0xe7, 0x00,
+	0x00, 0x01, 0x00, 0x4a, 0xfe, 0x5d, 0x04, 0x46, 0x04, 0x63, 0x00, 0x37, 0x00, 0x66, 0x40, 0x12,
+	0x1b, 0x01, 0x03, 0x02, 0x1c, 0x01, 0x04, 0x03, 0x00, 0x01, 0x00, 0x01, 0x37, 0x01, 0x05, 0x00,
+	0x04, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x31, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x4d, 0x00, 0x00, 0x00,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x05, 0x00,
+	0x05, 0x65, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x31, 0x4d, 0x00, 0x04, 0x04, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x09, 0x27, 0x38, 0x28, 0x3a, 0x44, 0x21,
+	0x06, 0x08, 0x1c, 0x2b, 0x05, 0x16, 0x33, 0x32, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x22, 0x2e,
+	0x02, 0x35, 0x34, 0x3e, 0x04, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x15, 0x2e, 0x03, 0x23, 0x22, 0x0e,
+	0x02, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x33, 0x20, 0x11, 0x14, 0x0e, 0x04, 0x23, 0x22, 0x27, 0x01,
+	0x9e, 0x5e, 0x69, 0xf3, 0x2d, 0x42, 0x4b, 0x1e, 0x3e, 0x84, 0xbf, 0x7b, 0x3a, 0x34, 0x5c, 0x7e,
+	0x94, 0xa4, 0x5f, 0x28, 0x4b, 0x3e, 0x36, 0x1e, 0x20, 0x37, 0x35, 0x36, 0x1e, 0x67, 0x9d, 0x69,
+	0x35, 0x1d, 0x43, 0x6c, 0x4f, 0x22, 0x01, 0x97, 0x2c, 0x4b, 0x63, 0x6e, 0x73, 0x34, 0x54, 0x65,
+	0xc5, 0x19, 0x77, 0x27, 0x29, 0x14, 0x03, 0x3a, 0x74, 0xae, 0x75, 0x67, 0xb2, 0x92, 0x71, 0x4e,
+	0x28, 0x03, 0x06, 0x09, 0x06, 0xc8, 0x0a, 0x0e, 0x0a, 0x05, 0x42, 0x76, 0xa2, 0x60, 0x45, 0x63,
+	0x40, 0x1e, 0xfe, 0xce, 0x46, 0x6a, 0x4d, 0x33, 0x1f, 0x0c, 0x13, 0x00, 0x00, 0x02, 0x00, 0x4a,
+	0xff, 0xe7, 0x05, 0x6b, 0x04, 0x63, 0x00, 0x0b, 0x00, 0x1b, 0x00, 0x69, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x23, 0x00, 0x01, 0x01, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x05,
+	0x05, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x31, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x02, 0x61, 0x07,
+	0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x2b, 0x4d, 0x06, 0x01, 0x00,
+	0x00, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0c, 0x01,
+	0x00, 0x17, 0x16, 0x15, 0x14, 0x13, 0x11, 0x0c, 0x1b, 0x0d, 0x1b, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x08, 0x08, 0x16, 0x2b, 0x25, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x17, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x17, 0x21, 0x15, 0x21, 0x16, 0x15, 0x10,
+	0x00, 0x02, 0x6f, 0x70, 0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0x67, 0xf6, 0xfe, 0xd5, 0x01, 0x2c,
+	0xfb, 0x64, 0x54, 0x02, 0x42, 0xfe, 0xd5, 0x59, 0xfe, 0xd3, 0xa0, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2,
+	0xb3, 0xb1, 0xd4, 0xb9, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06, 0x01, 0x38, 0x19, 0xd2, 0x8a, 0xc7,
+	0xfe, 0xf7, 0xfe, 0xc9, 0x00, 0x01, 0x00, 0x0a, 0x00, 0x00, 0x03, 0x88, 0x04, 0x4a, 0x00, 0x0f,
+	0x00, 0x4a, 0x40, 0x0a, 0x07, 0x01, 0x00, 0x01, 0x06, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x0f, 0x00, 0x0f, 0x11, 0x23, 0x23, 0x05, 0x08, 0x19, 0x2b, 0x21, 0x26, 0x11, 0x11,
+	0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x21, 0x15, 0x21, 0x11, 0x14, 0x17, 0x01, 0x87, 0x44, 0x67,
+	0x61, 0x71, 0x69, 0x83, 0x02, 0x92, 0xfe, 0xe3, 0x4f, 0x99, 0x01, 0x12, 0x01, 0xcd, 0x31, 0xdc,
+	0x27, 0xd2, 0xfd, 0xc5, 0xc4, 0x79, 0x00, 0x00, 0x00, 0x01, 0x00, 0x87, 0xff, 0xe7, 0x04, 0x35,
+	0x04, 0x4a, 0x00, 0x15, 0x00, 0x1b, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01,
+	0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x24, 0x14, 0x23, 0x10, 0x04, 0x08, 0x1a,
+	0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x36, 0x27, 0x12, 0x03, 0x21, 0x12, 0x11, 0x10,
+	0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x87, 0x01, 0x28, 0x61, 0x6d, 0x72, 0x6f, 0x03, 0x03,
+	0xc7, 0x01, 0x34, 0x6a, 0xfe, 0xf7, 0xde, 0xc3, 0x7e, 0x50, 0x36, 0x04, 0x4a, 0xfd, 0xf0, 0xed,
+	0xad, 0xb6, 0x7e, 0x01, 0x29, 0x01, 0x4d, 0xfe, 0xea, 0xfe, 0xf9, 0xfe, 0xf5, 0xfe, 0xc5, 0x76,
+	0x4a, 0xc5, 0xd6, 0x00, 0x00, 0x02, 0x00, 0x4a, 0xfe, 0x75, 0x05, 0x6f, 0x04, 0x63, 0x00, 0x27,
+	0x00, 0x37, 0x00, 0x55, 0x40, 0x09, 0x28, 0x1e, 0x1b, 0x0b, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x13, 0x04, 0x01, 0x00, 0x00, 0x01, 0x61, 0x05, 0x03, 0x02, 0x01,
+	0x01, 0x31, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x05, 0x01, 0x03, 0x03, 0x2b,
+	0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x33, 0x31, 0x00, 0x27,
+	0x00, 0x27, 0x1a, 0x2e, 0x11, 0x06, 0x08, 0x19, 0x2b, 0x01, 0x15, 0x22, 0x0e, 0x02, 0x15, 0x14,
+	0x1e, 0x02, 0x17, 0x35, 0x34, 0x12, 0x36, 0x36, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02,
+	0x07, 0x11, 0x21, 0x11, 0x2e, 0x03, 0x35, 0x34, 0x3e, 0x02, 0x01, 0x3e, 0x03, 0x35, 0x34, 0x2e,
+	0x02, 0x23, 0x22, 0x0e, 0x02, 0x15, 0x02, 0x29, 0x31, 0x4d, 0x34, 0x1c, 0x13, 0x32, 0x56, 0x43,
+	0x18, 0x52, 0x9d, 0x84, 0x72, 0xa2, 0x67, 0x30, 0x49, 0x8d, 0xcd, 0x84, 0xfe, 0xf1, 0x86, 0xbc,
+	0x76, 0x37, 0x3c, 0x77, 0xb4, 0x01, 0x97, 0x58, 0x6d, 0x3c, 0x14, 0x10, 0x23, 0x36, 0x26, 0x27,
+	0x34, 0x1e, 0x0d, 0x04, 0x4a, 0xb9, 0x31, 0x58, 0x7d, 0x4b, 0x55, 0x80, 0x5e, 0x3f, 0x15, 0xe1,
+	0xa6, 0x01, 0x08, 0xb9, 0x62, 0x41, 0x7d, 0xb4, 0x73, 0x96, 0xe4, 0x9e, 0x5a, 0x0c, 0xfe, 0x75,
+	0x01, 0x8b, 0x0e, 0x56, 0x94, 0xd4, 0x8b, 0x77, 0xba, 0x7f, 0x43, 0xfc, 0x6f, 0x09, 0x55, 0x80,
+	0x9f, 0x53, 0x3c, 0x69, 0x4e, 0x2e, 0x2b, 0x55, 0x7d, 0x52, 0x00, 0x00, 0x00, 0x01, 0xff, 0xe3,
+	0xfe, 0x75, 0x04, 0xb9, 0x04, 0x4a, 0x00, 0x1a, 0x00, 0x1f, 0x40, 0x1c, 0x18, 0x0d, 0x0a, 0x03,
+	0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2d, 0x02,
+	0x4e, 0x15, 0x17, 0x16, 0x14, 0x04, 0x08, 0x1a, 0x2b, 0x01, 0x03, 0x26, 0x26, 0x27, 0x21, 0x1e,
+	0x03, 0x17, 0x01, 0x33, 0x01, 0x13, 0x1e, 0x03, 0x17, 0x21, 0x26, 0x26, 0x27, 0x27, 0x01, 0x23,
+	0x01, 0xc1, 0xcf, 0x37, 0x63, 0x33, 0x01, 0x39, 0x16, 0x34, 0x41, 0x52, 0x33, 0x01, 0x13, 0xf6,
+	0xfe, 0x6a, 0xe9, 0x1b, 0x3f, 0x40, 0x3d, 0x18, 0xfe, 0xbc, 0x39, 0x62, 0x32, 0x72, 0xfe, 0xa4,
+	0xf7, 0x01, 0x79, 0x01, 0x7c, 0x64, 0xa9, 0x48, 0x20, 0x4c, 0x68, 0x8a, 0x5d, 0x01, 0xbb, 0xfd,
+	0x71, 0xfe, 0x59, 0x30, 0x72, 0x71, 0x67, 0x25, 0x55, 0xad, 0x5c, 0xd4, 0xfd, 0xce, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x31, 0xfe, 0x75, 0x05, 0x9f, 0x05, 0x03, 0x00, 0x1d, 0x00, 0x56, 0x40, 0x0b,
+	0x11, 0x0e, 0x02, 0x03, 0x00, 0x01, 0x01, 0x04, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x17, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x29, 0x4d, 0x00, 0x01, 0x01,
+	0x04, 0x5f, 0x05, 0x01, 0x04, 0x04, 0x2d, 0x04, 0x4e, 0x1b, 0x40, 0x17, 0x02, 0x01, 0x00, 0x00,
+	0x2b, 0x4d, 0x00, 0x03, 0x03, 0x2c, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x5f, 0x05, 0x01, 0x04, 0x04,
+	0x2d, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x1d, 0x14, 0x16, 0x17, 0x17,
+	0x06, 0x08, 0x1a, 0x2b, 0x01, 0x11, 0x26, 0x00, 0x11, 0x35, 0x34, 0x27, 0x21, 0x16, 0x15, 0x15,
+	0x14, 0x16, 0x17, 0x11, 0x21, 0x11, 0x36, 0x36, 0x35, 0x34, 0x27, 0x21, 0x16, 0x15, 0x10, 0x00,
+	0x07, 0x11, 0x02, 0x70, 0xfe, 0xfe, 0xfe, 0x3f, 0x01, 0x1a, 0x37, 0x56, 0x98, 0x01, 0x11, 0x78,
+	0x94, 0x51, 0x01, 0x16, 0x4d, 0xfe, 0xc7, 0xe5, 0xfe, 0x75, 0x01, 0x8b, 0x15, 0x01, 0x22, 0x01,
+	0x52, 0x87, 0xd0, 0x6a, 0x66, 0xcb, 0x77, 0xfb, 0xcb, 0x23, 0x04, 0x4a, 0xfb, 0xb6, 0x1a, 0xdb,
+	0xdf, 0xe4, 0xd9, 0xd4, 0xe7, 0xfe, 0xd2, 0xfe, 0xae, 0x0f, 0xfe, 0x75, 0x00, 0x01, 0x00, 0x4a,
+	0xff, 0xe7, 0x06, 0x78, 0x04, 0x4a, 0x00, 0x3e, 0x00, 0x2f, 0x40, 0x2c, 0x22, 0x19, 0x02, 0x02,
+	0x03, 0x01, 0x4c, 0x00, 0x03, 0x01, 0x02, 0x01, 0x03, 0x02, 0x80, 0x05, 0x01, 0x01, 0x01, 0x2b,
+	0x4d, 0x04, 0x01, 0x02, 0x02, 0x00, 0x62, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x29, 0x19,
+	0x28, 0x16, 0x27, 0x19, 0x22, 0x07, 0x08, 0x1d, 0x2b, 0x01, 0x06, 0x06, 0x23, 0x22, 0x2e, 0x04,
+	0x35, 0x34, 0x12, 0x37, 0x21, 0x06, 0x02, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x36, 0x37, 0x26,
+	0x35, 0x34, 0x37, 0x33, 0x16, 0x15, 0x14, 0x07, 0x1e, 0x03, 0x33, 0x32, 0x3e, 0x04, 0x35, 0x34,
+	0x02, 0x27, 0x21, 0x16, 0x12, 0x15, 0x14, 0x0e, 0x04, 0x23, 0x22, 0x26, 0x03, 0x61, 0x45, 0xca,
+	0x8d, 0x47, 0x6f, 0x54, 0x3b, 0x25, 0x11, 0x40, 0x45, 0x01, 0x27, 0x52, 0x4c, 0x13, 0x2a, 0x46,
+	0x33, 0x50, 0x66, 0x24, 0x3a, 0x3c, 0xee, 0x3b, 0x39, 0x0c, 0x22, 0x34, 0x48, 0x31, 0x25, 0x37,
+	0x28, 0x1b, 0x10, 0x06, 0x4c, 0x52, 0x01, 0x27, 0x45, 0x40, 0x10, 0x25, 0x3a, 0x54, 0x70, 0x47,
+	0x91, 0xc9, 0x01, 0x13, 0x97, 0x95, 0x2f, 0x51, 0x6d, 0x7c, 0x86, 0x41, 0x96, 0x01, 0x18, 0x85,
+	0x92, 0xfe, 0xe8, 0x8a, 0x32, 0x79, 0x69, 0x47, 0x85, 0x85, 0x89, 0x75, 0x8c, 0x95, 0x96, 0x8b,
+	0x73, 0x8b, 0x2a, 0x5e, 0x4e, 0x34, 0x23, 0x3a, 0x4a, 0x4d, 0x4a, 0x1d, 0x8a, 0x01, 0x18, 0x92,
+	0x86, 0xfe, 0xe9, 0x96, 0x40, 0x84, 0x7c, 0x6e, 0x52, 0x30, 0x96, 0x00, 0x00, 0x03, 0xff, 0xff,
+	0xff, 0xe7, 0x02, 0xe1, 0x05, 0xeb, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x69, 0x40, 0x0a,
+	0x0f, 0x01, 0x02, 0x01, 0x00, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40,
+	0x1e, 0x08, 0x06, 0x07, 0x03, 0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x28, 0x4d, 0x00,
+	0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b,
+	0x40, 0x1c, 0x05, 0x01, 0x03, 0x08, 0x06, 0x07, 0x03, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01,
+	0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40,
+	0x15, 0x14, 0x14, 0x10, 0x10, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x10, 0x13, 0x10, 0x13, 0x13,
+	0x23, 0x15, 0x21, 0x09, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x11,
+	0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x02,
+	0xe1, 0x77, 0x7a, 0xa0, 0x56, 0x3e, 0x2b, 0x01, 0x28, 0x41, 0x4e, 0x42, 0x57, 0xfd, 0x1e, 0xde,
+	0xd9, 0xdf, 0x19, 0x32, 0x49, 0x34, 0xa2, 0xb4, 0x02, 0x90, 0xfd, 0x5e, 0x8c, 0x73, 0x2a, 0x04,
+	0x3a, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x87, 0xff, 0xe7, 0x04, 0x35,
+	0x05, 0xeb, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x60, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40,
+	0x1f, 0x09, 0x07, 0x08, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x28, 0x4d, 0x02,
+	0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e,
+	0x1b, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02,
+	0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e,
+	0x59, 0x40, 0x16, 0x1a, 0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16,
+	0x19, 0x16, 0x24, 0x14, 0x23, 0x10, 0x0a, 0x08, 0x1b, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33,
+	0x32, 0x36, 0x27, 0x12, 0x03, 0x21, 0x12, 0x11, 0x10, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35,
+	0x13, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x87, 0x01, 0x28, 0x61, 0x6d, 0x72, 0x6f, 0x03,
+	0x03, 0xc7, 0x01, 0x34, 0x6a, 0xfe, 0xf7, 0xde, 0xc3, 0x7e, 0x50, 0x36, 0x7c, 0xde, 0xd9, 0xdf,
+	0x04, 0x4a, 0xfd, 0xf0, 0xed, 0xad, 0xb6, 0x7e, 0x01, 0x29, 0x01, 0x4d, 0xfe, 0xea, 0xfe, 0xf9,
+	0xfe, 0xf5, 0xfe, 0xc5, 0x76, 0x4a, 0xc5, 0xd6, 0x02, 0xcb, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99, 0x06, 0xa6, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x40, 0x40, 0x3d, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06,
+	0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b,
+	0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08,
+	0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32,
+	0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x13, 0x21, 0x01, 0x02, 0x6b,
+	0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d, 0xfe, 0xd3, 0xfd, 0x70, 0x80, 0x81, 0x6d,
+	0x6d, 0x80, 0x80, 0x0b, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01, 0x06,
+	0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2, 0xd2,
+	0xb3, 0xb1, 0xd4, 0x04, 0x63, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x87,
+	0xff, 0xe7, 0x04, 0x35, 0x06, 0xa6, 0x00, 0x15, 0x00, 0x19, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19,
+	0x16, 0x24, 0x14, 0x23, 0x10, 0x07, 0x08, 0x1b, 0x2b, 0x13, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x36, 0x27, 0x12, 0x03, 0x21, 0x12, 0x11, 0x10, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x01,
+	0x13, 0x21, 0x01, 0x87, 0x01, 0x28, 0x61, 0x6d, 0x72, 0x6f, 0x03, 0x03, 0xc7, 0x01, 0x34, 0x6a,
+	0xfe, 0xf7, 0xde, 0xc3, 0x7e, 0x50, 0x36, 0x01, 0x36, 0xd2, 0x01, 0x12, 0xfe, 0xb0, 0x04, 0x4a,
+	0xfd, 0xf0, 0xed, 0xad, 0xb6, 0x7e, 0x01, 0x29, 0x01, 0x4d, 0xfe, 0xea, 0xfe, 0xf9, 0xfe, 0xf5,
+	0xfe, 0xc5, 0x76, 0x4a, 0xc5, 0xd6, 0x02, 0xc1, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x02, 0x00, 0x4a,
+	0xff, 0xe7, 0x06, 0x78, 0x06, 0xa6, 0x00, 0x03, 0x00, 0x42, 0x00, 0x48, 0x40, 0x45, 0x26, 0x1d,
+	0x02, 0x04, 0x05, 0x01, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x01, 0x01, 0x03, 0x01, 0x85,
+	0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x07, 0x01, 0x03, 0x03, 0x2b, 0x4d, 0x06, 0x01,
+	0x04, 0x04, 0x02, 0x62, 0x08, 0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x00, 0x00, 0x41, 0x3f, 0x36,
+	0x35, 0x2c, 0x2a, 0x22, 0x21, 0x1b, 0x19, 0x12, 0x11, 0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0a, 0x08, 0x17, 0x2b, 0x01, 0x13, 0x21, 0x01, 0x13, 0x06, 0x06, 0x23, 0x22, 0x2e, 0x04, 0x35,
+	0x34, 0x12, 0x37, 0x21, 0x06, 0x02, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x36, 0x37, 0x26, 0x35,
+	0x34, 0x37, 0x33, 0x16, 0x15, 0x14, 0x07, 0x1e, 0x03, 0x33, 0x32, 0x3e, 0x04, 0x35, 0x34, 0x02,
+	0x27, 0x21, 0x16, 0x12, 0x15, 0x14, 0x0e, 0x04, 0x23, 0x22, 0x26, 0x02, 0xad, 0xd2, 0x01, 0x12,
+	0xfe, 0xb0, 0x20, 0x45, 0xca, 0x8d, 0x47, 0x6f, 0x54, 0x3b, 0x25, 0x11, 0x40, 0x45, 0x01, 0x27,
+	0x52, 0x4c, 0x13, 0x2a, 0x46, 0x33, 0x50, 0x66, 0x24, 0x3a, 0x3c, 0xee, 0x3b, 0x39, 0x0c, 0x22,
+	0x34, 0x48, 0x31, 0x25, 0x37, 0x28, 0x1b, 0x10, 0x06, 0x4c, 0x52, 0x01, 0x27, 0x45, 0x40, 0x10,
+	0x25, 0x3a, 0x54, 0x70, 0x47, 0x91, 0xc9, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfc, 0x10, 0x97,
+	0x95, 0x2f, 0x51, 0x6d, 0x7c, 0x86, 0x41, 0x96, 0x01, 0x18, 0x85, 0x92, 0xfe, 0xe8, 0x8a, 0x32,
+	0x79, 0x69, 0x47, 0x85, 0x85, 0x89, 0x75, 0x8c, 0x95, 0x96, 0x8b, 0x73, 0x8b, 0x2a, 0x5e, 0x4e,
+	0x34, 0x23, 0x3a, 0x4a, 0x4d, 0x4a, 0x1d, 0x8a, 0x01, 0x18, 0x92, 0x86, 0xfe, 0xe9, 0x96, 0x40,
+	0x84, 0x7c, 0x6e, 0x52, 0x30, 0x96, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x28, 0x00,
+	0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x08, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00,
+	0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03,
+	0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e,
+	0x59, 0x40, 0x12, 0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11,
+	0x21, 0x15, 0x01, 0x23, 0x01, 0x21, 0xad, 0x04, 0x3e, 0xfc, 0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03,
+	0x39, 0xfe, 0x65, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38,
+	0xd2, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a,
+	0x07, 0x40, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x7e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x2a, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00,
+	0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x08, 0x01,
+	0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a,
+	0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c, 0x0c, 0x00, 0x00, 0x10,
+	0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x0d, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0xad, 0x04, 0x3e, 0xfc,
+	0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0xfc, 0x67, 0xde, 0xd9, 0xdf, 0x05, 0xc8, 0xcb, 0xfe,
+	0x63, 0xc6, 0xfe, 0x38, 0xd2, 0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x01, 0x00, 0x19,
+	0xff, 0xf4, 0x06, 0xc5, 0x05, 0xc8, 0x00, 0x1d, 0x00, 0xae, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x0f, 0x00, 0x01, 0x03, 0x00, 0x15, 0x0b, 0x02, 0x02, 0x03, 0x0a, 0x01, 0x01, 0x02, 0x03, 0x4c,
+	0x1b, 0x40, 0x0f, 0x00, 0x01, 0x03, 0x00, 0x15, 0x0b, 0x02, 0x02, 0x03, 0x0a, 0x01, 0x04, 0x02,
+	0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00,
+	0x03, 0x69, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x1a, 0x4d, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x23, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00,
+	0x06, 0x06, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x1b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x07, 0x01, 0x05, 0x00, 0x06, 0x05, 0x67,
+	0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x00, 0x04, 0x04, 0x1d, 0x4d, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x11, 0x11, 0x11, 0x12,
+	0x24, 0x23, 0x24, 0x21, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x36, 0x33, 0x20, 0x00, 0x15, 0x14, 0x00,
+	0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x21,
+	0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x03, 0x13, 0xcc, 0xc1, 0x01, 0x1f, 0x01, 0x06, 0xfe, 0xec,
+	0xd1, 0x46, 0x5f, 0x3d, 0x2c, 0x69, 0x7e, 0x95, 0x96, 0xa1, 0xac, 0xfe, 0xd1, 0xfe, 0x35, 0x05,
+	0x01, 0xfd, 0xf9, 0x03, 0x52, 0x88, 0xfe, 0xf9, 0xd0, 0xeb, 0xfe, 0xdc, 0x10, 0xba, 0x0c, 0x9f,
+	0x92, 0x7b, 0x91, 0x8b, 0xfd, 0x9c, 0x04, 0xfd, 0xcb, 0xcb, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x04, 0x7f, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x09, 0x00, 0x4f, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1b, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85, 0x00, 0x02,
+	0x02, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b, 0x40,
+	0x19, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x02,
+	0x00, 0x01, 0x02, 0x68, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0x40, 0x0d, 0x06, 0x06, 0x06,
+	0x09, 0x06, 0x09, 0x12, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1a, 0x2b, 0x21, 0x21, 0x11, 0x21, 0x15,
+	0x21, 0x03, 0x13, 0x21, 0x01, 0x01, 0xe1, 0xfe, 0xcc, 0x03, 0xd2, 0xfd, 0x62, 0x0a, 0xf1, 0x01,
+	0x19, 0xfe, 0xbf, 0x05, 0xc8, 0xdf, 0x01, 0x65, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x01, 0x00, 0x5a,
+	0xff, 0xdb, 0x05, 0x6b, 0x05, 0xed, 0x00, 0x18, 0x00, 0x63, 0x40, 0x12, 0x0b, 0x01, 0x02, 0x01,
+	0x0c, 0x01, 0x03, 0x02, 0x00, 0x01, 0x05, 0x04, 0x01, 0x01, 0x00, 0x05, 0x04, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x20,
+	0x00, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x00,
+	0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e,
+	0x59, 0x40, 0x09, 0x22, 0x11, 0x12, 0x23, 0x24, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x15, 0x06,
+	0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x17, 0x15, 0x24, 0x23, 0x22, 0x06, 0x07, 0x21,
+	0x15, 0x21, 0x14, 0x00, 0x33, 0x32, 0x05, 0x6b, 0xe5, 0xfe, 0xd6, 0xfe, 0x94, 0xfe, 0x6a, 0x01,
+	0x99, 0x01, 0x7b, 0xf2, 0xfd, 0xfe, 0xe3, 0xb7, 0xce, 0xfa, 0x16, 0x02, 0xcf, 0xfd, 0x2b, 0x01,
+	0x1e, 0xe2, 0xda, 0x01, 0x20, 0xe3, 0x62, 0x01, 0x9a, 0x01, 0x6f, 0x01, 0x76, 0x01, 0x93, 0x3b,
+	0xf1, 0x61, 0xe7, 0xd2, 0xc6, 0xdb, 0xfe, 0xe8, 0x00, 0x01, 0x00, 0x63, 0xff, 0xda, 0x05, 0x09,
+	0x05, 0xed, 0x00, 0x23, 0x00, 0x4d, 0x40, 0x0f, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x02, 0x00,
+	0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x20, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x03, 0x4e, 0x59, 0xb6, 0x2c, 0x23, 0x29, 0x22, 0x04,
+	0x07, 0x1a, 0x2b, 0x37, 0x35, 0x04, 0x33, 0x20, 0x35, 0x34, 0x2f, 0x02, 0x24, 0x26, 0x35, 0x10,
+	0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x15,
+	0x14, 0x04, 0x21, 0x22, 0x27, 0x66, 0x01, 0x1c, 0xef, 0x01, 0x54, 0x81, 0x89, 0xa3, 0xfe, 0xfb,
+	0xb0, 0x02, 0x5c, 0xfe, 0xe5, 0xee, 0xdf, 0xb5, 0x8c, 0x44, 0x61, 0x72, 0xaa, 0xf7, 0xbd, 0xfe,
+	0xa7, 0xfe, 0x8d, 0x8b, 0xae, 0x0d, 0xfc, 0x63, 0xc5, 0x80, 0x37, 0x34, 0x3e, 0x63, 0xb4, 0xa6,
+	0x01, 0x9c, 0x33, 0xea, 0x52, 0x4c, 0x62, 0x3e, 0x46, 0x24, 0x2c, 0x3f, 0x5c, 0xc4, 0xa6, 0xe8,
+	0xd9, 0x1b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64, 0x00, 0x00, 0x03, 0x3c, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x4a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x1a, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05,
+	0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11,
+	0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x64, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2, 0xd2,
+	0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2, 0x00, 0x00, 0x03, 0x00, 0x64, 0x00, 0x00, 0x03, 0x3c,
+	0x07, 0x40, 0x00, 0x03, 0x00, 0x07, 0x00, 0x13, 0x00, 0x76, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x24, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01, 0x06, 0x00, 0x01, 0x67, 0x07, 0x01, 0x05,
+	0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x1a, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x09, 0x5f, 0x0c, 0x01,
+	0x09, 0x09, 0x1b, 0x09, 0x4e, 0x1b, 0x40, 0x22, 0x02, 0x01, 0x00, 0x0b, 0x03, 0x0a, 0x03, 0x01,
+	0x06, 0x00, 0x01, 0x67, 0x00, 0x06, 0x07, 0x01, 0x05, 0x04, 0x06, 0x05, 0x67, 0x08, 0x01, 0x04,
+	0x04, 0x09, 0x5f, 0x0c, 0x01, 0x09, 0x09, 0x1d, 0x09, 0x4e, 0x59, 0x40, 0x22, 0x08, 0x08, 0x04,
+	0x04, 0x00, 0x00, 0x08, 0x13, 0x08, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a,
+	0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0d, 0x07, 0x17, 0x2b,
+	0x13, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15,
+	0x23, 0x11, 0x33, 0x15, 0x85, 0xde, 0xd9, 0xdf, 0xfd, 0x49, 0xd2, 0xd2, 0x02, 0xd8, 0xd2, 0xd2,
+	0x06, 0x62, 0xde, 0xde, 0xde, 0xde, 0xf9, 0x9e, 0xd2, 0x04, 0x24, 0xd2, 0xd2, 0xfb, 0xdc, 0xd2,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0xd8, 0x03, 0xa1, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x4a, 0x40, 0x0a,
+	0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x12, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x1a, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00,
+	0x03, 0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6,
+	0x23, 0x11, 0x13, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x15, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11,
+	0x23, 0x35, 0x21, 0x11, 0x10, 0x04, 0x21, 0x22, 0xba, 0xa9, 0x97, 0x73, 0xf0, 0x02, 0x24, 0xfe,
+	0xf4, 0xfe, 0xd9, 0xae, 0xfc, 0xdd, 0x38, 0x75, 0x9a, 0x04, 0x3e, 0xd2, 0xfb, 0x11, 0xfe, 0xf3,
+	0xf4, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x28, 0x00, 0x00, 0x08, 0x70, 0x05, 0xc8, 0x00, 0x1b,
+	0x00, 0x24, 0x00, 0x64, 0x40, 0x0a, 0x09, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x08, 0x01, 0x03, 0x49,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x69, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01,
+	0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67,
+	0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x69, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03,
+	0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x24, 0x22, 0x1e, 0x1c, 0x00, 0x1b, 0x00,
+	0x1a, 0x21, 0x1d, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x15, 0x10, 0x07, 0x06, 0x06,
+	0x07, 0x35, 0x36, 0x36, 0x37, 0x13, 0x13, 0x11, 0x21, 0x11, 0x33, 0x32, 0x16, 0x17, 0x16, 0x15,
+	0x14, 0x07, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x04, 0x2e, 0xfe,
+	0x4b, 0x43, 0x44, 0xe1, 0xe9, 0x99, 0x8c, 0x06, 0x07, 0x03, 0x03, 0xff, 0x8b, 0xe3, 0xae, 0x4d,
+	0xab, 0xc3, 0x85, 0xfe, 0x6a, 0x36, 0x83, 0xb4, 0xaf, 0xad, 0xb3, 0x86, 0x04, 0xfd, 0x75, 0xfd,
+	0x60, 0xb6, 0x9c, 0x85, 0x11, 0xda, 0x0b, 0xcc, 0xe5, 0x01, 0x07, 0x01, 0x11, 0x01, 0x1a, 0xfd,
+	0x96, 0x1c, 0x30, 0x6a, 0xe3, 0xf9, 0x79, 0x53, 0xbe, 0x7d, 0x7e, 0x73, 0x73, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x08, 0x30, 0x05, 0xc8, 0x00, 0x14, 0x00, 0x1d, 0x00, 0x62,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x03, 0x01, 0x01, 0x08, 0x01, 0x05, 0x07, 0x01, 0x05,
+	0x69, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x07, 0x07, 0x04, 0x60, 0x09, 0x06, 0x02, 0x04,
+	0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x24, 0x03, 0x01, 0x01, 0x08, 0x01, 0x05, 0x07, 0x01, 0x05,
+	0x69, 0x02, 0x01, 0x00, 0x00, 0x04, 0x5f, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x4d, 0x00, 0x07,
+	0x07, 0x04, 0x60, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x13, 0x00, 0x00,
+	0x1d, 0x1b, 0x17, 0x15, 0x00, 0x14, 0x00, 0x14, 0x11, 0x26, 0x21, 0x11, 0x11, 0x11, 0x0a, 0x07,
+	0x1c, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x33, 0x20, 0x17, 0x16, 0x15, 0x14,
+	0x07, 0x06, 0x21, 0x21, 0x11, 0x21, 0x11, 0x25, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23,
+	0xad, 0x01, 0x2e, 0x02, 0x13, 0x01, 0x2e, 0x8b, 0x01, 0x64, 0x7a, 0xab, 0xc3, 0x85, 0xfe, 0x6a,
+	0xfe, 0x9c, 0xfd, 0xed, 0x03, 0x41, 0x79, 0xb8, 0xb5, 0xae, 0xb2, 0x86, 0x05, 0xc8, 0xfd, 0x96,
+	0x02, 0x6a, 0xfd, 0x96, 0x4b, 0x6b, 0xe3, 0xf9, 0x79, 0x53, 0x02, 0x9f, 0xfd, 0x61, 0xbf, 0x7d,
+	0x7d, 0x73, 0x73, 0x00, 0x00, 0x01, 0x00, 0x28, 0x00, 0x00, 0x06, 0x74, 0x05, 0xc8, 0x00, 0x15,
+	0x00, 0x5d, 0x40, 0x0a, 0x07, 0x01, 0x05, 0x03, 0x14, 0x01, 0x04, 0x05, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x00, 0x05, 0x04, 0x03, 0x05, 0x69, 0x02, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x07, 0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e,
+	0x1b, 0x40, 0x19, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x00, 0x03, 0x00, 0x05,
+	0x04, 0x03, 0x05, 0x69, 0x07, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x00, 0x15, 0x00, 0x15, 0x23, 0x13, 0x22, 0x11, 0x11, 0x11, 0x08, 0x07, 0x1c, 0x2b, 0x21,
+	0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x36, 0x33, 0x20, 0x16, 0x15, 0x11, 0x21, 0x11, 0x34,
+	0x26, 0x23, 0x22, 0x07, 0x11, 0x01, 0xc7, 0xfe, 0x61, 0x04, 0x81, 0xfe, 0x52, 0xa4, 0xef, 0x01,
+	0x0b, 0xdb, 0xfe, 0xcc, 0x6c, 0x8c, 0xb2, 0x9b, 0x04, 0xfd, 0xcb, 0xcb, 0xfe, 0x46, 0x8c, 0xef,
+	0xf6, 0xfe, 0x16, 0x01, 0xe5, 0x8d, 0x7d, 0x9a, 0xfd, 0xab, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x04, 0xe2, 0x07, 0x8f, 0x00, 0x25, 0x00, 0x29, 0x00, 0x79, 0xb6, 0x15, 0x03, 0x02,
+	0x04, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x06, 0x07, 0x06, 0x85,
+	0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x04, 0x02, 0x03, 0x02, 0x04, 0x03, 0x80, 0x00, 0x02,
+	0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03,
+	0x4e, 0x1b, 0x40, 0x27, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00,
+	0x04, 0x02, 0x03, 0x02, 0x04, 0x03, 0x80, 0x00, 0x02, 0x04, 0x00, 0x02, 0x59, 0x01, 0x01, 0x00,
+	0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x26, 0x26,
+	0x00, 0x00, 0x26, 0x29, 0x26, 0x29, 0x28, 0x27, 0x00, 0x25, 0x00, 0x25, 0x16, 0x1e, 0x11, 0x37,
+	0x11, 0x0a, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x36, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33,
+	0x32, 0x37, 0x15, 0x22, 0x06, 0x0f, 0x03, 0x06, 0x07, 0x16, 0x16, 0x1f, 0x02, 0x16, 0x17, 0x21,
+	0x26, 0x2f, 0x02, 0x26, 0x27, 0x23, 0x11, 0x13, 0x13, 0x21, 0x01, 0xad, 0x01, 0x28, 0x30, 0x44,
+	0x72, 0x39, 0x5f, 0x8e, 0x84, 0x10, 0x40, 0x5a, 0x43, 0x35, 0x28, 0x2b, 0x2b, 0x45, 0x84, 0x7b,
+	0x8f, 0x57, 0x29, 0x3d, 0x33, 0x4c, 0xfe, 0xbc, 0x16, 0x07, 0x3d, 0x52, 0x7a, 0x56, 0x4d, 0x16,
+	0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x05, 0xc8, 0xfd, 0x8b, 0x06, 0x4d, 0xbe, 0x5f, 0x9f, 0x64, 0x02,
+	0xbf, 0x2b, 0x56, 0x41, 0x49, 0x47, 0x75, 0x3e, 0x1e, 0x84, 0xab, 0x4a, 0x76, 0x69, 0x8e, 0x2d,
+	0x0d, 0x75, 0x98, 0xe5, 0x6e, 0xfd, 0x66, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x13, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x0d, 0x00, 0x4d,
+	0xb6, 0x0d, 0x08, 0x02, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00,
+	0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x02, 0x00, 0x85, 0x05, 0x01, 0x02, 0x02, 0x1a, 0x4d, 0x04,
+	0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00,
+	0x02, 0x00, 0x85, 0x05, 0x01, 0x02, 0x02, 0x03, 0x60, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e,
+	0x59, 0x40, 0x09, 0x11, 0x12, 0x11, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x23, 0x01,
+	0x21, 0x01, 0x21, 0x11, 0x21, 0x11, 0x01, 0x21, 0x11, 0x21, 0x11, 0x03, 0x7f, 0xc9, 0xfe, 0xbf,
+	0x01, 0x19, 0x01, 0x51, 0x01, 0x34, 0xfe, 0xcc, 0xfe, 0x02, 0xfe, 0xcc, 0x01, 0x34, 0x06, 0x4e,
+	0x01, 0x41, 0xfe, 0x39, 0xfa, 0x38, 0x03, 0xfc, 0xfc, 0x04, 0x05, 0xc8, 0xfc, 0x04, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x3a, 0xff, 0xdb, 0x04, 0xf3, 0x07, 0x8f, 0x00, 0x11, 0x00, 0x21, 0x00, 0x8a,
+	0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01,
+	0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07,
+	0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00,
+	0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x01, 0x01,
+	0x00, 0x07, 0x03, 0x07, 0x00, 0x03, 0x80, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x00,
+	0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x22, 0x13,
+	0x23, 0x13, 0x21, 0x24, 0x13, 0x11, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x01, 0x21, 0x01, 0x33, 0x01,
+	0x21, 0x01, 0x02, 0x07, 0x06, 0x21, 0x23, 0x35, 0x33, 0x32, 0x36, 0x37, 0x03, 0x33, 0x15, 0x14,
+	0x16, 0x33, 0x32, 0x36, 0x35, 0x27, 0x33, 0x14, 0x06, 0x23, 0x22, 0x26, 0x02, 0x29, 0xfe, 0x11,
+	0x01, 0x4d, 0x01, 0x45, 0x07, 0x01, 0x1b, 0x01, 0x05, 0xfe, 0x41, 0x83, 0x77, 0x64, 0xfe, 0xe9,
+	0x2b, 0x25, 0x85, 0x8b, 0x4b, 0xac, 0xd2, 0x3d, 0x3e, 0x3e, 0x3e, 0x01, 0xd2, 0xa7, 0xa6, 0xa7,
+	0xa6, 0x01, 0x9e, 0x04, 0x2a, 0xfd, 0x0c, 0x02, 0xf4, 0xfb, 0xcd, 0xfe, 0xf9, 0x61, 0x52, 0xd2,
+	0x4b, 0x83, 0x06, 0x14, 0x18, 0x54, 0x53, 0x54, 0x55, 0x16, 0xa1, 0xa0, 0xa0, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0xfe, 0x7f, 0x05, 0x13, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x40, 0x18,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03,
+	0x1d, 0x4d, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x23, 0x11, 0xad, 0x01, 0x34, 0x01, 0xfe, 0x01, 0x34, 0xfe, 0x3b, 0xdc,
+	0x05, 0xc8, 0xfb, 0x0a, 0x04, 0xf6, 0xfa, 0x38, 0xfe, 0x7f, 0x01, 0x81, 0x00, 0x02, 0x00, 0x0c,
+	0x00, 0x00, 0x05, 0xba, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x21, 0x01, 0x21, 0x03, 0x21,
+	0x03, 0x13, 0x21, 0x03, 0x0c, 0x02, 0x3e, 0x01, 0x34, 0x02, 0x3c, 0xfe, 0xc5, 0x97, 0xfd, 0x9c,
+	0x97, 0xe3, 0x01, 0xcc, 0xe6, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x50, 0x02,
+	0x4e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x5c, 0x05, 0xc8, 0x00, 0x0f,
+	0x00, 0x18, 0x00, 0x58, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04,
+	0x02, 0x05, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04,
+	0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x04, 0x04, 0x03,
+	0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x18, 0x16, 0x12,
+	0x10, 0x00, 0x0f, 0x00, 0x0e, 0x21, 0x11, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15, 0x14, 0x07, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x21, 0xad, 0x04, 0x2d, 0xfd, 0x01, 0x01, 0x0e, 0xdd, 0xa1, 0x4c, 0xa9,
+	0xc1, 0x84, 0xfe, 0x9b, 0xd7, 0xf6, 0xb8, 0xa5, 0x9e, 0xb2, 0xfe, 0xfd, 0x05, 0xc8, 0xcb, 0xfe,
+	0x61, 0x1c, 0x30, 0x6a, 0xe3, 0xf9, 0x79, 0x53, 0xbe, 0x7d, 0x7e, 0x73, 0x73, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x05, 0x7e, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x1d,
+	0x00, 0x61, 0xb5, 0x06, 0x01, 0x05, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e,
+	0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x1a, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40,
+	0x1c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05,
+	0x67, 0x00, 0x04, 0x04, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x1d, 0x1b, 0x17, 0x15, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0a, 0x21, 0x07,
+	0x07, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x04, 0x11, 0x14, 0x06, 0x23, 0x01,
+	0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x21, 0x11, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x21, 0xad, 0x02, 0xcc, 0x01, 0xc8, 0xfe, 0x9d, 0x01, 0xa0, 0xf3, 0xe4, 0xfe, 0x28, 0x01, 0x1e,
+	0x82, 0x99, 0x7b, 0xab, 0xfe, 0xed, 0x01, 0x17, 0xc2, 0x93, 0xc5, 0x96, 0xfe, 0xef, 0x05, 0xc8,
+	0xfe, 0xb7, 0xfe, 0xf5, 0x6f, 0x64, 0xfe, 0xcd, 0xb1, 0xbd, 0x03, 0x60, 0x81, 0x6d, 0x65, 0x4a,
+	0xfb, 0xd5, 0x53, 0x6d, 0x72, 0x96, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x04, 0x7f,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x31, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x10, 0x00, 0x02, 0x02,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b, 0x40, 0x0e,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x67, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0xb5,
+	0x11, 0x11, 0x10, 0x03, 0x07, 0x19, 0x2b, 0x21, 0x21, 0x11, 0x21, 0x15, 0x21, 0x01, 0xe1, 0xfe,
+	0xcc, 0x03, 0xd2, 0xfd, 0x62, 0x05, 0xc8, 0xe1, 0x00, 0x02, 0x00, 0x0f, 0xfe, 0x7f, 0x05, 0x98,
+	0x05, 0xc8, 0x00, 0x0e, 0x00, 0x15, 0x00, 0x70, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x27, 0x00,
+	0x06, 0x06, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04,
+	0x5f, 0x00, 0x04, 0x04, 0x1b, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05,
+	0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x01, 0x00, 0x06, 0x00, 0x01, 0x06,
+	0x67, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1d, 0x4d, 0x09, 0x07,
+	0x02, 0x03, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x59, 0x40,
+	0x16, 0x0f, 0x0f, 0x00, 0x00, 0x0f, 0x15, 0x0f, 0x15, 0x11, 0x10, 0x00, 0x0e, 0x00, 0x0e, 0x11,
+	0x11, 0x11, 0x14, 0x11, 0x0a, 0x07, 0x1b, 0x2b, 0x13, 0x11, 0x33, 0x36, 0x12, 0x11, 0x35, 0x21,
+	0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x11, 0x01, 0x11, 0x21, 0x15, 0x10, 0x02, 0x07, 0x0f, 0x4b,
+	0x8b, 0x8b, 0x03, 0x74, 0xb4, 0xdc, 0xfc, 0x2f, 0x02, 0xdb, 0xfe, 0xc0, 0x89, 0x6f, 0xfe, 0x7f,
+	0x02, 0x53, 0xcc, 0x02, 0x24, 0x01, 0x59, 0xad, 0xfb, 0x0a, 0xfd, 0xad, 0x01, 0x81, 0xfe, 0x7f,
+	0x02, 0x53, 0x04, 0x34, 0x19, 0xfe, 0xc5, 0xfd, 0xc0, 0xa0, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0x1a, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b,
+	0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0xad, 0x04, 0x3e, 0xfc,
+	0xf6, 0x02, 0x9b, 0xfd, 0x65, 0x03, 0x39, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xc6, 0xfe, 0x38, 0xd2,
+	0x00, 0x01, 0x00, 0x24, 0x00, 0x00, 0x07, 0x17, 0x05, 0xc8, 0x00, 0x41, 0x00, 0x6a, 0x40, 0x09,
+	0x35, 0x24, 0x21, 0x11, 0x04, 0x01, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f,
+	0x0a, 0x09, 0x02, 0x01, 0x03, 0x00, 0x03, 0x01, 0x00, 0x80, 0x07, 0x01, 0x03, 0x03, 0x04, 0x61,
+	0x06, 0x05, 0x02, 0x04, 0x04, 0x1a, 0x4d, 0x08, 0x02, 0x02, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b,
+	0x40, 0x20, 0x0a, 0x09, 0x02, 0x01, 0x03, 0x00, 0x03, 0x01, 0x00, 0x80, 0x07, 0x01, 0x03, 0x01,
+	0x04, 0x03, 0x59, 0x06, 0x05, 0x02, 0x04, 0x04, 0x00, 0x5f, 0x08, 0x02, 0x02, 0x00, 0x00, 0x1d,
+	0x00, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x00, 0x41, 0x00, 0x41, 0x1c, 0x11, 0x19, 0x18, 0x11,
+	0x1c, 0x16, 0x11, 0x11, 0x0b, 0x07, 0x1f, 0x2b, 0x01, 0x11, 0x21, 0x11, 0x23, 0x06, 0x06, 0x03,
+	0x07, 0x06, 0x07, 0x21, 0x36, 0x37, 0x37, 0x36, 0x36, 0x37, 0x26, 0x26, 0x27, 0x27, 0x26, 0x26,
+	0x23, 0x35, 0x32, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x17, 0x11, 0x21, 0x11, 0x36, 0x36, 0x37, 0x36,
+	0x37, 0x37, 0x36, 0x36, 0x33, 0x15, 0x22, 0x06, 0x07, 0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x17,
+	0x17, 0x16, 0x17, 0x21, 0x27, 0x27, 0x02, 0x26, 0x27, 0x04, 0x2e, 0xfe, 0xdf, 0x45, 0x3c, 0x67,
+	0x7a, 0x33, 0x02, 0x0c, 0xfe, 0xba, 0x43, 0x5e, 0x28, 0x54, 0x7d, 0x81, 0x4a, 0x53, 0x42, 0x1d,
+	0x3c, 0x68, 0x42, 0xa7, 0xbf, 0x58, 0x1a, 0x27, 0x3f, 0x3a, 0x38, 0x01, 0x21, 0x39, 0x3f, 0x38,
+	0x0e, 0x1a, 0x1a, 0x59, 0xbe, 0xa7, 0x43, 0x66, 0x3d, 0x1d, 0x43, 0x51, 0x4c, 0x82, 0x7e, 0x53,
+	0x28, 0x5c, 0x45, 0xfe, 0xba, 0x0e, 0x33, 0x7e, 0x62, 0x3d, 0x02, 0x9c, 0xfd, 0x64, 0x02, 0x9c,
+	0x30, 0xbb, 0xfe, 0xe4, 0x76, 0x04, 0x1b, 0x72, 0xda, 0x5c, 0xc2, 0x81, 0x1c, 0x25, 0x62, 0x80,
+	0x39, 0x75, 0x4d, 0xbf, 0x75, 0xae, 0x34, 0x4f, 0x7e, 0x42, 0x0d, 0x02, 0x73, 0xfd, 0x8d, 0x11,
+	0x48, 0x74, 0x1b, 0x34, 0x34, 0xaf, 0x74, 0xbf, 0x4c, 0x76, 0x39, 0x82, 0x60, 0x25, 0x1c, 0x82,
+	0xc1, 0x5c, 0xd7, 0x75, 0x1f, 0x76, 0x01, 0x25, 0xb2, 0x30, 0x00, 0x00, 0x00, 0x01, 0x00, 0x69,
+	0xff, 0xdb, 0x04, 0x99, 0x05, 0xee, 0x00, 0x24, 0x00, 0x67, 0x40, 0x16, 0x15, 0x01, 0x03, 0x04,
+	0x14, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x05, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x1f, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x20, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x22, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x2a, 0x23, 0x24, 0x21, 0x24, 0x22, 0x06, 0x07, 0x1c,
+	0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x33, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x04, 0x15, 0x14, 0x06, 0x07, 0x16,
+	0x16, 0x15, 0x14, 0x04, 0x21, 0x22, 0x69, 0xec, 0xb3, 0xa4, 0xa6, 0xdc, 0xdb, 0x5d, 0x5c, 0xc9,
+	0xc9, 0x8e, 0xa2, 0xba, 0xc1, 0xc7, 0xf7, 0x01, 0x21, 0x01, 0x0e, 0x8d, 0x8d, 0xa2, 0xa3, 0xfe,
+	0xbb, 0xfe, 0xd4, 0xea, 0x11, 0xdd, 0x51, 0x86, 0x75, 0x91, 0x91, 0xbf, 0x78, 0x79, 0x62, 0x62,
+	0x45, 0xc8, 0x3d, 0xb1, 0xb0, 0x79, 0xb0, 0x37, 0x30, 0xc9, 0x9a, 0xce, 0xf1, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x13, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x36, 0xb6, 0x09,
+	0x04, 0x02, 0x01, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x03, 0x01, 0x00,
+	0x00, 0x1a, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x0d, 0x03, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0xb6, 0x11, 0x12, 0x11, 0x10,
+	0x04, 0x07, 0x1a, 0x2b, 0x01, 0x21, 0x11, 0x21, 0x11, 0x01, 0x21, 0x11, 0x21, 0x11, 0x03, 0xdf,
+	0x01, 0x34, 0xfe, 0xcc, 0xfe, 0x02, 0xfe, 0xcc, 0x01, 0x34, 0x05, 0xc8, 0xfa, 0x38, 0x03, 0xfc,
+	0xfc, 0x04, 0x05, 0xc8, 0xfc, 0x04, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x13,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x19, 0x00, 0x7c, 0xb6, 0x09, 0x04, 0x02, 0x01, 0x00, 0x01, 0x4c,
+	0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05,
+	0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x03, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x02, 0x01, 0x01, 0x01,
+	0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x06, 0x01, 0x04, 0x05, 0x04,
+	0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x03, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x02,
+	0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00,
+	0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x03, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01,
+	0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x22, 0x13, 0x23, 0x12, 0x11, 0x12, 0x11, 0x10,
+	0x08, 0x07, 0x1e, 0x2b, 0x01, 0x21, 0x11, 0x21, 0x11, 0x01, 0x21, 0x11, 0x21, 0x11, 0x03, 0x33,
+	0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x27, 0x33, 0x14, 0x06, 0x23, 0x22, 0x26, 0x03, 0xdf,
+	0x01, 0x34, 0xfe, 0xcc, 0xfe, 0x02, 0xfe, 0xcc, 0x01, 0x34, 0x47, 0xd2, 0x3d, 0x3e, 0x3e, 0x3e,
+	0x01, 0xd2, 0xa7, 0xa6, 0xa7, 0xa6, 0x05, 0xc8, 0xfa, 0x38, 0x03, 0xfc, 0xfc, 0x04, 0x05, 0xc8,
+	0xfc, 0x04, 0x05, 0xc3, 0x18, 0x54, 0x53, 0x54, 0x55, 0x16, 0xa1, 0xa0, 0xa0, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x04, 0xe2, 0x05, 0xc8, 0x00, 0x25, 0x00, 0x5b, 0xb6, 0x15,
+	0x03, 0x02, 0x04, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x04, 0x02,
+	0x03, 0x02, 0x04, 0x03, 0x80, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x04, 0x02, 0x03, 0x02,
+	0x04, 0x03, 0x80, 0x00, 0x02, 0x04, 0x00, 0x02, 0x59, 0x01, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06,
+	0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x25, 0x00, 0x25,
+	0x16, 0x1e, 0x11, 0x37, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x36, 0x36, 0x37,
+	0x37, 0x36, 0x36, 0x33, 0x32, 0x37, 0x15, 0x22, 0x06, 0x0f, 0x03, 0x06, 0x07, 0x16, 0x16, 0x1f,
+	0x02, 0x16, 0x17, 0x21, 0x26, 0x2f, 0x02, 0x26, 0x27, 0x23, 0x11, 0xad, 0x01, 0x28, 0x30, 0x44,
+	0x72, 0x39, 0x5f, 0x8e, 0x84, 0x10, 0x40, 0x5a, 0x43, 0x35, 0x28, 0x2b, 0x2b, 0x45, 0x84, 0x7b,
+	0x8f, 0x57, 0x29, 0x3d, 0x33, 0x4c, 0xfe, 0xbc, 0x16, 0x07, 0x3d, 0x52, 0x7a, 0x56, 0x4d, 0x05,
+	0xc8, 0xfd, 0x8b, 0x06, 0x4d, 0xbe, 0x5f, 0x9f, 0x64, 0x02, 0xbf, 0x2b, 0x56, 0x41, 0x49, 0x47,
+	0x75, 0x3e, 0x1e, 0x84, 0xab, 0x4a, 0x76, 0x69, 0x8e, 0x2d, 0x0d, 0x75, 0x98, 0xe5, 0x6e, 0xfd,
+	0x66, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x04, 0xf0, 0x05, 0xc8, 0x00, 0x12,
+	0x00, 0x43, 0xb5, 0x01, 0x01, 0x01, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12,
+	0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1b,
+	0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x67, 0x04, 0x03, 0x02,
+	0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x11, 0x11,
+	0x18, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x35, 0x36, 0x36, 0x37, 0x13, 0x36, 0x35, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0x21, 0x15, 0x10, 0x07, 0x06, 0x06, 0x14, 0x98, 0x82, 0x0b, 0x0c, 0x04, 0x03, 0xa7,
+	0xfe, 0xcb, 0xfe, 0xb0, 0x43, 0x3a, 0xf1, 0xda, 0x0b, 0xbd, 0xf5, 0x01, 0x06, 0x4f, 0xc2, 0x01,
+	0x1a, 0xfa, 0x38, 0x04, 0xfd, 0x75, 0xfd, 0x60, 0xb6, 0x9d, 0x87, 0x00, 0x00, 0x01, 0x00, 0xad,
+	0x00, 0x00, 0x05, 0xfd, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x50, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03,
+	0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x02,
+	0x5f, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c,
+	0x00, 0x0c, 0x12, 0x11, 0x12, 0x11, 0x06, 0x07, 0x1a, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x01, 0x21,
+	0x11, 0x21, 0x11, 0x01, 0x23, 0x01, 0x11, 0xad, 0x01, 0x98, 0x01, 0x24, 0x01, 0x2e, 0x01, 0x66,
+	0xfe, 0xe4, 0xfe, 0xd8, 0xf8, 0xfe, 0xde, 0x05, 0xc8, 0xfb, 0xef, 0x04, 0x11, 0xfa, 0x38, 0x04,
+	0x5d, 0xfc, 0x06, 0x04, 0x09, 0xfb, 0x94, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x1a,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00,
+	0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03,
+	0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01,
+	0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0xad, 0x01, 0x34, 0x02, 0x05, 0x01,
+	0x34, 0xfe, 0xcc, 0xfd, 0xfb, 0x05, 0xc8, 0xfd, 0xa7, 0x02, 0x59, 0xfa, 0x38, 0x02, 0xa3, 0xfd,
+	0x5d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x50, 0xff, 0xdb, 0x05, 0xe9, 0x05, 0xed, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x1f, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x20,
+	0x00, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01,
+	0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x07, 0x16,
+	0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12,
+	0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x12, 0xfe, 0xb8, 0xfe, 0x86, 0x01,
+	0x7d, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x7d, 0xfe, 0x82, 0xfe, 0xac, 0xbe, 0xcd, 0xcd, 0xb8, 0xb9,
+	0xcd, 0xcc, 0x25, 0x01, 0xa1, 0x01, 0x68, 0x01, 0x6d, 0x01, 0x9c, 0xfe, 0x64, 0xfe, 0x96, 0xfe,
+	0x8e, 0xfe, 0x66, 0xcc, 0x01, 0x2b, 0x01, 0x16, 0x01, 0x0d, 0x01, 0x2d, 0xfe, 0xd3, 0xfe, 0xef,
+	0xfe, 0xf3, 0xfe, 0xd0, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x05, 0x13, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1a, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x00, 0x02, 0x67, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0xad, 0x04, 0x66, 0xfe, 0xcc, 0xfe, 0x02, 0x05, 0xc8, 0xfa,
+	0x38, 0x04, 0xfd, 0xfb, 0x03, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x06,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00,
+	0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a,
+	0x4d, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x04, 0x03,
+	0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05, 0x01, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x25,
+	0x21, 0x06, 0x07, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15, 0x10, 0x21, 0x23,
+	0x11, 0x11, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x23, 0xad, 0x02, 0x46, 0xbd, 0xba, 0x41, 0x5b,
+	0xfd, 0x97, 0xc2, 0x7e, 0x01, 0x72, 0x92, 0xa5, 0xb9, 0x05, 0xc8, 0x2f, 0x46, 0x61, 0xb3, 0xfe,
+	0x05, 0xfd, 0xbc, 0x03, 0x0f, 0x01, 0x12, 0x7a, 0x62, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x50,
+	0xff, 0xdb, 0x05, 0x7e, 0x05, 0xed, 0x00, 0x13, 0x00, 0x4d, 0x40, 0x0f, 0x0b, 0x01, 0x02, 0x01,
+	0x0c, 0x00, 0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x20, 0x00, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01,
+	0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0xb6, 0x22,
+	0x23, 0x24, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x01, 0x15, 0x06, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x21, 0x20, 0x17, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x21, 0x32, 0x05, 0x7e, 0xd7, 0xfe, 0xc0,
+	0xfe, 0x83, 0xfe, 0x66, 0x01, 0x9e, 0x01, 0x8f, 0x01, 0x03, 0xf1, 0xfe, 0xef, 0xc8, 0xfd, 0xff,
+	0x02, 0x1e, 0xeb, 0x01, 0x1e, 0xe3, 0x60, 0x01, 0x93, 0x01, 0x76, 0x01, 0x7e, 0x01, 0x8b, 0x39,
+	0xf1, 0x5f, 0xfd, 0xc6, 0xfd, 0xc8, 0x00, 0x00, 0x00, 0x01, 0x00, 0x28, 0x00, 0x00, 0x04, 0xbc,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b,
+	0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x1d,
+	0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07,
+	0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x01, 0xd8, 0xfe, 0x50, 0x04, 0x94,
+	0xfe, 0x50, 0x04, 0xf3, 0xd5, 0xd5, 0xfb, 0x0d, 0x00, 0x01, 0x00, 0x3a, 0xff, 0xdb, 0x04, 0xf3,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x3d, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x11, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00,
+	0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x01, 0x01, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03,
+	0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0xb6, 0x21, 0x24, 0x13, 0x11, 0x04,
+	0x07, 0x1a, 0x2b, 0x01, 0x01, 0x21, 0x01, 0x33, 0x01, 0x21, 0x01, 0x02, 0x07, 0x06, 0x21, 0x23,
+	0x35, 0x33, 0x32, 0x36, 0x37, 0x02, 0x29, 0xfe, 0x11, 0x01, 0x4d, 0x01, 0x45, 0x07, 0x01, 0x1b,
+	0x01, 0x05, 0xfe, 0x41, 0x83, 0x77, 0x64, 0xfe, 0xe9, 0x2b, 0x25, 0x85, 0x8b, 0x4b, 0x01, 0x9e,
+	0x04, 0x2a, 0xfd, 0x0c, 0x02, 0xf4, 0xfb, 0xcd, 0xfe, 0xf9, 0x61, 0x52, 0xd2, 0x4b, 0x83, 0x00,
+	0x00, 0x03, 0x00, 0x50, 0x00, 0x00, 0x06, 0x84, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x18, 0x00, 0x1f,
+	0x00, 0x6a, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x21, 0x03, 0x01, 0x01, 0x0b, 0x09, 0x02, 0x06,
+	0x07, 0x01, 0x06, 0x69, 0x08, 0x01, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x00, 0x02,
+	0x02, 0x1a, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x21, 0x03, 0x01, 0x01,
+	0x0b, 0x09, 0x02, 0x06, 0x07, 0x01, 0x06, 0x69, 0x08, 0x01, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07,
+	0x00, 0x69, 0x00, 0x02, 0x02, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40,
+	0x1a, 0x19, 0x19, 0x00, 0x00, 0x19, 0x1f, 0x19, 0x1f, 0x1b, 0x1a, 0x18, 0x17, 0x13, 0x12, 0x00,
+	0x11, 0x00, 0x11, 0x14, 0x11, 0x11, 0x14, 0x11, 0x0c, 0x07, 0x1b, 0x2b, 0x21, 0x35, 0x24, 0x00,
+	0x35, 0x34, 0x00, 0x25, 0x35, 0x21, 0x15, 0x04, 0x00, 0x15, 0x14, 0x00, 0x05, 0x15, 0x01, 0x06,
+	0x06, 0x15, 0x14, 0x16, 0x17, 0x01, 0x11, 0x36, 0x36, 0x35, 0x34, 0x26, 0x02, 0xe3, 0xfe, 0xad,
+	0xfe, 0xc0, 0x01, 0x40, 0x01, 0x53, 0x01, 0x0e, 0x01, 0x53, 0x01, 0x40, 0xfe, 0xc0, 0xfe, 0xad,
+	0xfe, 0xf2, 0xe3, 0xa2, 0xa2, 0xe3, 0x01, 0x0e, 0xe3, 0xa2, 0xa2, 0xca, 0x0c, 0x01, 0x26, 0xe8,
+	0xe9, 0x01, 0x25, 0x0c, 0xca, 0xca, 0x0c, 0xfe, 0xdb, 0xe9, 0xe8, 0xfe, 0xda, 0x0c, 0xca, 0x04,
+	0x3d, 0x03, 0xb7, 0x9f, 0xa0, 0xb6, 0x02, 0x02, 0xb1, 0xfd, 0x4f, 0x02, 0xb6, 0xa0, 0x9f, 0xb7,
+	0x00, 0x01, 0x00, 0x31, 0x00, 0x00, 0x05, 0x29, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09,
+	0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e,
+	0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40,
+	0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59,
+	0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x07, 0x19, 0x2b, 0x33,
+	0x01, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x01, 0x31, 0x01, 0xda, 0xfe, 0x3b,
+	0x01, 0x67, 0x01, 0x2d, 0x01, 0x46, 0xf9, 0xfe, 0x3a, 0x01, 0xd6, 0xfe, 0x9a, 0xfe, 0xbf, 0xfe,
+	0xa8, 0x02, 0xd9, 0x02, 0xef, 0xfe, 0x0e, 0x01, 0xf2, 0xfd, 0x46, 0xfc, 0xf2, 0x02, 0x11, 0xfd,
+	0xef, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xad, 0xfe, 0x7f, 0x05, 0xa5, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x51, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x03, 0x1a, 0x4d, 0x04,
+	0x01, 0x00, 0x00, 0x02, 0x60, 0x00, 0x02, 0x02, 0x1b, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x01, 0x60,
+	0x00, 0x01, 0x01, 0x1e, 0x01, 0x4e, 0x1b, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x00, 0x03, 0x85, 0x04,
+	0x01, 0x00, 0x00, 0x02, 0x60, 0x00, 0x02, 0x02, 0x1d, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x01, 0x60,
+	0x00, 0x01, 0x01, 0x1e, 0x01, 0x4e, 0x59, 0x40, 0x09, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06,
+	0x07, 0x1c, 0x2b, 0x25, 0x33, 0x11, 0x23, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x05,
+	0x0f, 0x96, 0xdc, 0xfb, 0xe4, 0x01, 0x34, 0x01, 0xfa, 0x01, 0x34, 0xd2, 0xfd, 0xad, 0x01, 0x81,
+	0x05, 0xc8, 0xfb, 0x0a, 0x04, 0xf6, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7d, 0x00, 0x00, 0x04, 0xf2,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x51, 0x40, 0x0a, 0x0e, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00,
+	0x6a, 0x03, 0x01, 0x01, 0x01, 0x1a, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x04, 0x5f, 0x05,
+	0x01, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x12,
+	0x23, 0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b, 0x21, 0x11, 0x06, 0x23, 0x22, 0x26, 0x35, 0x11, 0x21,
+	0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21, 0x11, 0x03, 0xbe, 0xaf, 0xc5, 0xef, 0xde, 0x01,
+	0x35, 0x61, 0x7c, 0xa6, 0x89, 0x01, 0x34, 0x02, 0x54, 0x5a, 0xec, 0xf8, 0x01, 0xea, 0xfe, 0x1c,
+	0x92, 0x78, 0x5a, 0x02, 0x94, 0xfa, 0x38, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x07, 0x5d,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x44, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x14, 0x04, 0x02, 0x02,
+	0x00, 0x00, 0x1a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05,
+	0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x01, 0x00, 0x85, 0x03, 0x01, 0x01, 0x01, 0x05,
+	0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0xad, 0x01, 0x34, 0x01, 0x8f, 0x01, 0x2c, 0x01, 0x8d, 0x01,
+	0x34, 0x05, 0xc8, 0xfb, 0x0a, 0x04, 0xf6, 0xfb, 0x0a, 0x04, 0xf6, 0xfa, 0x38, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xad, 0xfe, 0x75, 0x07, 0xf5, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x59, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x20, 0x05, 0x03, 0x02, 0x01, 0x01, 0x1a, 0x4d, 0x06, 0x04, 0x02, 0x02,
+	0x02, 0x00, 0x60, 0x00, 0x00, 0x00, 0x1b, 0x4d, 0x06, 0x04, 0x02, 0x02, 0x02, 0x07, 0x60, 0x00,
+	0x07, 0x07, 0x1e, 0x07, 0x4e, 0x1b, 0x40, 0x20, 0x05, 0x03, 0x02, 0x01, 0x02, 0x01, 0x85, 0x06,
+	0x04, 0x02, 0x02, 0x02, 0x00, 0x60, 0x00, 0x00, 0x00, 0x1d, 0x4d, 0x06, 0x04, 0x02, 0x02, 0x02,
+	0x07, 0x60, 0x00, 0x07, 0x07, 0x1e, 0x07, 0x4e, 0x59, 0x40, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x10, 0x08, 0x07, 0x1e, 0x2b, 0x21, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x33, 0x13, 0x23, 0x07, 0x19, 0xf9, 0x94, 0x01, 0x34, 0x01, 0x92, 0x01,
+	0x34, 0x01, 0x92, 0x01, 0x34, 0x87, 0x01, 0xdc, 0x05, 0xc8, 0xfb, 0x0a, 0x04, 0xf6, 0xfb, 0x0a,
+	0x04, 0xf6, 0xfb, 0x0a, 0xfd, 0xa3, 0x00, 0x00, 0x00, 0x02, 0x00, 0x18, 0x00, 0x00, 0x06, 0xa5,
+	0x05, 0xc8, 0x00, 0x0f, 0x00, 0x18, 0x00, 0x58, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x00,
+	0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a,
+	0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1c,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67,
+	0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00,
+	0x00, 0x18, 0x16, 0x12, 0x10, 0x00, 0x0f, 0x00, 0x0e, 0x21, 0x11, 0x11, 0x07, 0x07, 0x19, 0x2b,
+	0x21, 0x11, 0x21, 0x35, 0x21, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15, 0x10, 0x07, 0x06, 0x21,
+	0x27, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x21, 0x01, 0xd2, 0xfe, 0x46, 0x02, 0xef, 0x01,
+	0x15, 0xe4, 0xaf, 0x4b, 0xab, 0xc3, 0x84, 0xfe, 0x98, 0xef, 0x01, 0x0d, 0xb8, 0xa5, 0xa6, 0xb9,
+	0xfe, 0xf5, 0x04, 0xfd, 0xcb, 0xfd, 0xaa, 0x1d, 0x2f, 0x6a, 0xed, 0xfe, 0xfd, 0x79, 0x53, 0xbf,
+	0x7d, 0x87, 0x7d, 0x73, 0x00, 0x03, 0x00, 0xad, 0x00, 0x00, 0x07, 0x28, 0x05, 0xc8, 0x00, 0x03,
+	0x00, 0x10, 0x00, 0x19, 0x00, 0x66, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x00,
+	0x06, 0x05, 0x03, 0x06, 0x67, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x60,
+	0x08, 0x04, 0x07, 0x03, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x03, 0x00, 0x06,
+	0x05, 0x03, 0x06, 0x67, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x04, 0x07, 0x03, 0x01, 0x01,
+	0x1d, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x60, 0x08, 0x04, 0x07, 0x03, 0x01, 0x01, 0x1d, 0x01, 0x4e,
+	0x59, 0x40, 0x18, 0x04, 0x04, 0x00, 0x00, 0x19, 0x17, 0x13, 0x11, 0x04, 0x10, 0x04, 0x0f, 0x09,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x07, 0x17, 0x2b, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x33, 0x20, 0x17, 0x16, 0x15, 0x10, 0x07, 0x06, 0x21, 0x27, 0x33, 0x32,
+	0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x05, 0xfa, 0x01, 0x2e, 0xf9, 0x85, 0x01, 0x2e, 0xb3, 0x01,
+	0x68, 0x7a, 0xab, 0xc3, 0x85, 0xfe, 0x8e, 0x86, 0xab, 0xb8, 0xaf, 0xad, 0xb7, 0xae, 0x05, 0xc8,
+	0xfa, 0x38, 0x05, 0xc8, 0xfd, 0xaa, 0x4c, 0x6a, 0xed, 0xfe, 0xfd, 0x79, 0x53, 0xbf, 0x7d, 0x87,
+	0x7d, 0x73, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad, 0x00, 0x00, 0x05, 0x70, 0x05, 0xc8, 0x00, 0x0d,
+	0x00, 0x16, 0x00, 0x4f, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03,
+	0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x60, 0x05, 0x01, 0x02,
+	0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x04,
+	0x03, 0x01, 0x04, 0x67, 0x00, 0x03, 0x03, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e,
+	0x59, 0x40, 0x0f, 0x00, 0x00, 0x16, 0x14, 0x10, 0x0e, 0x00, 0x0d, 0x00, 0x0c, 0x21, 0x11, 0x06,
+	0x07, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15, 0x10, 0x07, 0x06,
+	0x21, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0xad, 0x01, 0x34, 0x01, 0x06, 0xe4,
+	0xaf, 0x4b, 0xab, 0xc3, 0x85, 0xfe, 0x98, 0xdf, 0xfe, 0xb8, 0xa5, 0xa6, 0xba, 0xfb, 0x05, 0xc8,
+	0xfd, 0xaa, 0x1c, 0x30, 0x6a, 0xed, 0xfe, 0xfd, 0x79, 0x53, 0xbf, 0x7d, 0x87, 0x7d, 0x73, 0x00,
+	0x00, 0x01, 0x00, 0x46, 0xff, 0xdb, 0x05, 0x57, 0x05, 0xed, 0x00, 0x18, 0x00, 0x63, 0x40, 0x12,
+	0x0f, 0x01, 0x03, 0x04, 0x0e, 0x01, 0x02, 0x03, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x04, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x1f, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x20, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x22, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x23, 0x22, 0x11, 0x12, 0x22, 0x06, 0x07, 0x1c,
+	0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x00, 0x35, 0x21, 0x35, 0x21, 0x26, 0x26, 0x23, 0x22, 0x05,
+	0x35, 0x36, 0x33, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x46, 0xe2, 0xdf, 0xe7, 0x01, 0x1e,
+	0xfd, 0x26, 0x02, 0xd4, 0x16, 0xfa, 0xd3, 0xbc, 0xfe, 0xed, 0xf3, 0xf7, 0x01, 0x80, 0x01, 0x99,
+	0xfe, 0x6a, 0xfe, 0x8f, 0xfe, 0xd1, 0x3b, 0xe3, 0x6e, 0x01, 0x18, 0xdb, 0xc6, 0xd2, 0xe7, 0x5f,
+	0xf1, 0x39, 0xfe, 0x6d, 0xfe, 0x8a, 0xfe, 0x91, 0xfe, 0x66, 0x00, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0xff, 0xdb, 0x07, 0xe6, 0x05, 0xed, 0x00, 0x12, 0x00, 0x1e, 0x00, 0x9e, 0x4b, 0xb0, 0x0e, 0x50,
+	0x58, 0x40, 0x21, 0x00, 0x01, 0x00, 0x04, 0x06, 0x01, 0x04, 0x67, 0x00, 0x07, 0x07, 0x00, 0x61,
+	0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x03, 0x61, 0x08, 0x05, 0x02, 0x03,
+	0x03, 0x20, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x00, 0x01, 0x00, 0x04,
+	0x06, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x1f, 0x4d, 0x08, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x20, 0x03, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x02, 0x00, 0x07, 0x01, 0x02, 0x07, 0x69,
+	0x00, 0x01, 0x00, 0x04, 0x06, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05,
+	0x05, 0x1d, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x03, 0x4e, 0x59,
+	0x59, 0x40, 0x16, 0x14, 0x13, 0x00, 0x00, 0x1a, 0x18, 0x13, 0x1e, 0x14, 0x1e, 0x00, 0x12, 0x00,
+	0x12, 0x12, 0x24, 0x22, 0x11, 0x11, 0x0a, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x33, 0x12,
+	0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x03, 0x23, 0x11, 0x25, 0x32, 0x12,
+	0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0xad, 0x01, 0x34, 0xdb, 0x25, 0x01, 0x49,
+	0x01, 0x25, 0x01, 0x3c, 0x01, 0x5b, 0xfe, 0xa5, 0xfe, 0xc4, 0xfe, 0xd8, 0xfe, 0xb1, 0x1b, 0xdc,
+	0x03, 0x69, 0xa8, 0xba, 0xba, 0xa3, 0xa3, 0xba, 0xb9, 0x05, 0xc8, 0xfd, 0x7c, 0x01, 0x54, 0x01,
+	0x55, 0xfe, 0x69, 0xfe, 0x8e, 0xfe, 0x8e, 0xfe, 0x69, 0x01, 0x5b, 0x01, 0x4f, 0xfd, 0x7b, 0x94,
+	0x01, 0x3a, 0x01, 0x1a, 0x01, 0x12, 0x01, 0x3a, 0xfe, 0xc5, 0xfe, 0xeb, 0xfe, 0xee, 0xfe, 0xc2,
+	0x00, 0x02, 0x00, 0x3e, 0x00, 0x00, 0x05, 0x13, 0x05, 0xc8, 0x00, 0x17, 0x00, 0x20, 0x00, 0x4e,
+	0xb5, 0x0c, 0x01, 0x00, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x05,
+	0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x00, 0x04, 0x05, 0x02,
+	0x04, 0x69, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01,
+	0x4e, 0x59, 0x40, 0x09, 0x24, 0x21, 0x11, 0x2e, 0x13, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x23,
+	0x06, 0x03, 0x07, 0x21, 0x36, 0x3f, 0x03, 0x36, 0x37, 0x26, 0x26, 0x35, 0x34, 0x37, 0x36, 0x36,
+	0x33, 0x21, 0x11, 0x21, 0x11, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x33, 0x03, 0xe4, 0xaf,
+	0x6f, 0xe0, 0x21, 0xfe, 0x79, 0x50, 0x55, 0x2b, 0x1b, 0x42, 0x66, 0x7b, 0xb7, 0xb6, 0x92, 0x47,
+	0xab, 0xf1, 0x01, 0xbf, 0xfe, 0xd1, 0x87, 0xb0, 0x9b, 0xa5, 0xba, 0x73, 0x02, 0x61, 0x7c, 0xfe,
+	0x58, 0x3d, 0x5e, 0x83, 0x43, 0x29, 0x69, 0x9c, 0x48, 0x28, 0xc8, 0x9f, 0xc7, 0x7b, 0x3b, 0x22,
+	0xfa, 0x38, 0x05, 0x09, 0x78, 0x78, 0x7c, 0x7c, 0x00, 0x02, 0x00, 0x45, 0xff, 0xe7, 0x04, 0x3b,
+	0x04, 0x63, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x97, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x14,
+	0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x00, 0x02, 0x05, 0x06, 0x05, 0x01, 0x02, 0x00,
+	0x05, 0x04, 0x4c, 0x1b, 0x40, 0x17, 0x14, 0x01, 0x03, 0x04, 0x13, 0x01, 0x02, 0x03, 0x1d, 0x01,
+	0x07, 0x06, 0x00, 0x01, 0x05, 0x07, 0x05, 0x01, 0x02, 0x00, 0x05, 0x05, 0x4c, 0x59, 0x4b, 0xb0,
+	0x2d, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d, 0x07, 0x01, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x22, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x02, 0x00, 0x06, 0x07, 0x02, 0x06, 0x69, 0x00,
+	0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x22, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e,
+	0x59, 0x40, 0x0b, 0x23, 0x23, 0x13, 0x23, 0x22, 0x23, 0x23, 0x22, 0x08, 0x07, 0x1e, 0x2b, 0x25,
+	0x17, 0x06, 0x23, 0x22, 0x27, 0x23, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34,
+	0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x11, 0x14, 0x33, 0x32, 0x25, 0x35, 0x23, 0x22,
+	0x15, 0x14, 0x16, 0x33, 0x32, 0x04, 0x34, 0x07, 0x5e, 0x47, 0xb7, 0x34, 0x0d, 0x6b, 0xa9, 0x92,
+	0xb3, 0x02, 0x0a, 0x4f, 0xac, 0x9b, 0xb1, 0xb5, 0xc7, 0x01, 0x98, 0x52, 0x10, 0xfe, 0x82, 0x46,
+	0xf7, 0x53, 0x40, 0x66, 0xa9, 0xa6, 0x1c, 0x8f, 0x8f, 0xb1, 0x90, 0x01, 0x76, 0x64, 0xab, 0x62,
+	0xcc, 0x4c, 0xfe, 0xa9, 0xfe, 0x1a, 0x81, 0x70, 0xdf, 0xb2, 0x3f, 0x53, 0x00, 0x02, 0x00, 0x5f,
+	0xff, 0xe7, 0x04, 0xa7, 0x06, 0x60, 0x00, 0x17, 0x00, 0x23, 0x00, 0x3b, 0x40, 0x38, 0x12, 0x01,
+	0x03, 0x02, 0x00, 0x01, 0x05, 0x00, 0x18, 0x01, 0x04, 0x05, 0x03, 0x4c, 0x11, 0x01, 0x02, 0x4a,
+	0x00, 0x02, 0x00, 0x03, 0x00, 0x02, 0x03, 0x69, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x1c, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x22, 0x01, 0x4e, 0x24, 0x25, 0x33,
+	0x34, 0x24, 0x21, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x36, 0x33, 0x32, 0x12, 0x15, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x23, 0x22, 0x06, 0x03,
+	0x07, 0x14, 0x12, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x01, 0x88, 0x8a, 0xe6, 0xc8,
+	0xe7, 0xfe, 0xda, 0xfe, 0xfa, 0xfe, 0xec, 0xfe, 0xf8, 0x01, 0x33, 0x01, 0x45, 0x21, 0x94, 0x75,
+	0x5b, 0x96, 0x20, 0xae, 0xac, 0x0e, 0x01, 0x8b, 0x71, 0x71, 0x7f, 0x6b, 0x6b, 0x8f, 0x03, 0x6b,
+	0xd3, 0xfe, 0xe0, 0xf7, 0xfe, 0xf0, 0xfe, 0xd0, 0x01, 0x5b, 0x01, 0x74, 0x01, 0xc7, 0x01, 0xae,
+	0x35, 0xbe, 0x30, 0xee, 0xfe, 0x2f, 0x22, 0xe8, 0xfe, 0xf7, 0xcd, 0xb4, 0xa2, 0xa3, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x96, 0x00, 0x00, 0x04, 0x7d, 0x04, 0x4a, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x20,
+	0x00, 0x63, 0xb5, 0x08, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e,
+	0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x1c, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40,
+	0x1e, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59,
+	0x40, 0x12, 0x00, 0x00, 0x20, 0x1e, 0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d,
+	0x21, 0x07, 0x07, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x16, 0x15, 0x14, 0x06, 0x07, 0x16, 0x16,
+	0x15, 0x14, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x33, 0x32,
+	0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x96, 0x01, 0xcb, 0x01, 0x08, 0xe8, 0x78, 0x81, 0x97, 0x8e,
+	0xdc, 0xfe, 0xe7, 0xdd, 0x5a, 0xd3, 0x76, 0x98, 0xa5, 0x66, 0x66, 0x91, 0x85, 0x82, 0x8f, 0x6b,
+	0x04, 0x4a, 0x78, 0x82, 0x65, 0x89, 0x24, 0x23, 0x8e, 0x6f, 0x9b, 0x83, 0xb3, 0x33, 0x55, 0x52,
+	0x57, 0xa7, 0x4b, 0x4b, 0x3b, 0x3b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x03, 0x3c,
+	0x04, 0x4a, 0x00, 0x05, 0x00, 0x3b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40,
+	0x11, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x07, 0x18,
+	0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x96, 0x02, 0xa6, 0xfe, 0xa1, 0x04, 0x4a, 0xd2, 0xfc,
+	0x88, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0a, 0xfe, 0xa7, 0x04, 0xf8, 0x04, 0x4a, 0x00, 0x0e,
+	0x00, 0x15, 0x00, 0xea, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x06, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x1c, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04,
+	0x1b, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1e,
+	0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x1f, 0x08, 0x05, 0x02, 0x03, 0x00, 0x03,
+	0x53, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00,
+	0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x27, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00,
+	0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x03, 0x5f,
+	0x08, 0x05, 0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1f,
+	0x08, 0x05, 0x02, 0x03, 0x00, 0x03, 0x53, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c,
+	0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b,
+	0x40, 0x1f, 0x08, 0x05, 0x02, 0x03, 0x00, 0x03, 0x53, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x00, 0x01,
+	0x01, 0x1c, 0x4d, 0x09, 0x07, 0x02, 0x03, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1d, 0x04,
+	0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x16, 0x0f, 0x0f, 0x00, 0x00, 0x0f, 0x15, 0x0f, 0x15, 0x11,
+	0x10, 0x00, 0x0e, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11, 0x0a, 0x07, 0x1b, 0x2b, 0x13, 0x11,
+	0x33, 0x36, 0x12, 0x35, 0x35, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x11, 0x01, 0x11, 0x23,
+	0x15, 0x14, 0x02, 0x07, 0x0a, 0x65, 0x6b, 0x6b, 0x03, 0x13, 0xa0, 0xdc, 0xfc, 0xca, 0x02, 0x56,
+	0xf1, 0x54, 0x53, 0xfe, 0xa7, 0x02, 0x1e, 0x88, 0x01, 0x85, 0xfe, 0x7a, 0xfc, 0x7b, 0xfd, 0xe2,
+	0x01, 0x59, 0xfe, 0xa7, 0x02, 0x1e, 0x02, 0xc9, 0x0c, 0xc2, 0xfe, 0xa2, 0x9d, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07, 0x04, 0x63, 0x00, 0x10, 0x00, 0x15, 0x00, 0x33,
+	0x40, 0x30, 0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x00, 0x04, 0x00, 0x02,
+	0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x06,
+	0x07, 0x1c, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00, 0x33, 0x32, 0x12, 0x11,
+	0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe,
+	0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65,
+	0x9f, 0xa8, 0xf5, 0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6,
+	0xfe, 0xc6, 0x01, 0xe1, 0x01, 0x19, 0x00, 0x00, 0x00, 0x01, 0x00, 0x05, 0x00, 0x00, 0x05, 0xa7,
+	0x04, 0x4a, 0x00, 0x3d, 0x00, 0x6c, 0xb6, 0x2f, 0x0e, 0x02, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x20, 0x0a, 0x01, 0x00, 0x06, 0x01, 0x04, 0x03, 0x00, 0x04, 0x67, 0x08,
+	0x01, 0x02, 0x02, 0x01, 0x61, 0x0c, 0x0b, 0x09, 0x03, 0x01, 0x01, 0x1c, 0x4d, 0x07, 0x05, 0x02,
+	0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x0a, 0x01, 0x00, 0x06, 0x01, 0x04, 0x03, 0x00,
+	0x04, 0x67, 0x08, 0x01, 0x02, 0x02, 0x01, 0x61, 0x0c, 0x0b, 0x09, 0x03, 0x01, 0x01, 0x1c, 0x4d,
+	0x07, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x00, 0x3d, 0x00,
+	0x3d, 0x3c, 0x3b, 0x36, 0x35, 0x1c, 0x15, 0x11, 0x11, 0x15, 0x1c, 0x11, 0x15, 0x11, 0x0d, 0x07,
+	0x1f, 0x2b, 0x01, 0x11, 0x32, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x15, 0x06, 0x07, 0x07, 0x06,
+	0x07, 0x16, 0x16, 0x17, 0x17, 0x16, 0x17, 0x16, 0x17, 0x21, 0x26, 0x27, 0x27, 0x26, 0x27, 0x23,
+	0x11, 0x23, 0x11, 0x23, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x36, 0x37, 0x36, 0x37, 0x37, 0x36,
+	0x36, 0x37, 0x26, 0x27, 0x27, 0x26, 0x27, 0x35, 0x32, 0x16, 0x17, 0x17, 0x16, 0x16, 0x33, 0x11,
+	0x03, 0x54, 0x2c, 0x4a, 0x35, 0x15, 0x33, 0x72, 0xa4, 0x52, 0x27, 0x29, 0x41, 0x76, 0x5a, 0x5d,
+	0x3d, 0x2c, 0x09, 0x22, 0x29, 0x2f, 0xfe, 0xe2, 0x0e, 0x02, 0x2e, 0x85, 0x4d, 0x25, 0xfc, 0x25,
+	0x4d, 0x85, 0x2e, 0x03, 0x0d, 0xfe, 0xe2, 0x2e, 0x2a, 0x22, 0x09, 0x2c, 0x3d, 0x5c, 0x5b, 0x76,
+	0x42, 0x28, 0x27, 0x52, 0x9a, 0x7b, 0x34, 0x15, 0x35, 0x49, 0x2d, 0x04, 0x4a, 0xfe, 0x34, 0x41,
+	0x7b, 0x39, 0x89, 0x4e, 0xb9, 0x04, 0x53, 0x57, 0x87, 0x2d, 0x1b, 0x58, 0x74, 0x55, 0x11, 0x45,
+	0x56, 0x47, 0x1a, 0x04, 0x5e, 0xfc, 0x5d, 0xfe, 0x2b, 0x01, 0xd5, 0x5d, 0xfc, 0x5e, 0x04, 0x1a,
+	0x47, 0x56, 0x45, 0x11, 0x55, 0x74, 0x58, 0x1b, 0x2d, 0x87, 0x57, 0x53, 0x04, 0xb9, 0x4e, 0x89,
+	0x39, 0x7b, 0x41, 0x01, 0xcc, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x3c, 0xff, 0xe7, 0x03, 0xa5,
+	0x04, 0x63, 0x00, 0x24, 0x00, 0x3f, 0x40, 0x3c, 0x15, 0x01, 0x03, 0x04, 0x14, 0x01, 0x02, 0x03,
+	0x1d, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00, 0x05, 0x4c, 0x00, 0x02,
+	0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d,
+	0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x22, 0x05, 0x4e, 0x2a, 0x23, 0x24, 0x21, 0x24,
+	0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23,
+	0x35, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15,
+	0x14, 0x06, 0x07, 0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x3c, 0xb8, 0x8d, 0x89, 0x75, 0x9c,
+	0x9c, 0x35, 0x39, 0x8b, 0x8b, 0x6f, 0x82, 0x7b, 0xa6, 0xa9, 0xbd, 0xf0, 0xdd, 0x67, 0x66, 0x78,
+	0x78, 0xfe, 0xf4, 0xf6, 0x9f, 0x17, 0xcb, 0x3f, 0x50, 0x50, 0x56, 0x56, 0xaa, 0x47, 0x48, 0x43,
+	0x44, 0x35, 0xb8, 0x31, 0x88, 0x88, 0x53, 0x84, 0x30, 0x25, 0x87, 0x62, 0x9c, 0xbb, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x04, 0x57, 0x04, 0x4a, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08,
+	0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01,
+	0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11,
+	0x01, 0x21, 0x11, 0x21, 0x11, 0x01, 0x94, 0x01, 0x16, 0x01, 0x85, 0x01, 0x28, 0xfe, 0xea, 0xfe,
+	0x7b, 0x04, 0x4a, 0xfd, 0x35, 0x02, 0xcb, 0xfb, 0xb6, 0x02, 0xcb, 0xfd, 0x35, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x04, 0x57, 0x06, 0x44, 0x00, 0x09, 0x00, 0x19, 0x00, 0x88,
+	0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x1d, 0x06,
+	0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01,
+	0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05,
+	0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07,
+	0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59,
+	0x59, 0x40, 0x14, 0x00, 0x00, 0x18, 0x16, 0x14, 0x13, 0x10, 0x0e, 0x0b, 0x0a, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x12, 0x11, 0x09, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x21, 0x11, 0x21,
+	0x11, 0x01, 0x03, 0x33, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x27, 0x33, 0x14, 0x06, 0x23,
+	0x22, 0x26, 0x94, 0x01, 0x16, 0x01, 0x85, 0x01, 0x28, 0xfe, 0xea, 0xfe, 0x7b, 0x9c, 0xd2, 0x3d,
+	0x3e, 0x3e, 0x3e, 0x01, 0xd2, 0xa7, 0xa6, 0xa7, 0xa6, 0x04, 0x4a, 0xfd, 0x35, 0x02, 0xcb, 0xfb,
+	0xb6, 0x02, 0xcb, 0xfd, 0x35, 0x06, 0x44, 0x18, 0x54, 0x53, 0x54, 0x55, 0x16, 0xa1, 0xa0, 0xa0,
+	0x00, 0x01, 0x00, 0x94, 0x00, 0x00, 0x03, 0xe7, 0x04, 0x4a, 0x00, 0x20, 0x00, 0x5a, 0xb5, 0x13,
+	0x01, 0x05, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x05,
+	0x04, 0x01, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x07,
+	0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01,
+	0x05, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x07, 0x06, 0x02,
+	0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x20, 0x00, 0x20, 0x14, 0x1b,
+	0x21, 0x25, 0x11, 0x11, 0x08, 0x07, 0x1c, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x32, 0x36, 0x37, 0x37,
+	0x36, 0x36, 0x37, 0x33, 0x15, 0x23, 0x22, 0x06, 0x07, 0x07, 0x06, 0x07, 0x16, 0x16, 0x17, 0x17,
+	0x16, 0x17, 0x21, 0x26, 0x27, 0x26, 0x27, 0x23, 0x11, 0x94, 0x01, 0x0b, 0x26, 0x2d, 0x4d, 0x1d,
+	0x43, 0x78, 0x6a, 0x2c, 0x12, 0x30, 0x2b, 0x34, 0x1c, 0x4e, 0x76, 0x62, 0x6e, 0x3f, 0x32, 0x59,
+	0x21, 0xfe, 0xd1, 0x13, 0x43, 0x64, 0x42, 0x1d, 0x04, 0x4a, 0xfe, 0x2e, 0x4a, 0x8e, 0x34, 0x7c,
+	0x49, 0x01, 0xb9, 0x27, 0x5e, 0x33, 0x80, 0x1e, 0x16, 0x6a, 0x80, 0x5a, 0xb2, 0x2f, 0x22, 0x98,
+	0xe1, 0x37, 0xfe, 0x2e, 0x00, 0x01, 0x00, 0x1e, 0x00, 0x00, 0x04, 0x7f, 0x04, 0x4a, 0x00, 0x0e,
+	0x00, 0x49, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x5f, 0x00, 0x01,
+	0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x61, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00,
+	0x02, 0x61, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00,
+	0x0e, 0x00, 0x0e, 0x11, 0x11, 0x14, 0x11, 0x06, 0x07, 0x1a, 0x2b, 0x33, 0x35, 0x32, 0x37, 0x36,
+	0x11, 0x35, 0x21, 0x11, 0x21, 0x11, 0x21, 0x15, 0x10, 0x02, 0x1e, 0x89, 0x2c, 0x30, 0x03, 0x7c,
+	0xfe, 0xd8, 0xfe, 0xc1, 0xd2, 0xc6, 0xa3, 0xac, 0x01, 0x91, 0xa4, 0xfb, 0xb6, 0x03, 0x85, 0x12,
+	0xfd, 0xfd, 0xfe, 0x90, 0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x05, 0x55, 0x04, 0x4a, 0x00, 0x0e,
+	0x00, 0x50, 0xb7, 0x0d, 0x09, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00,
+	0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1d, 0x02,
+	0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e, 0x13, 0x11, 0x13, 0x11, 0x06, 0x07,
+	0x1a, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x33, 0x01, 0x21, 0x11, 0x21, 0x11, 0x23, 0x01, 0x23, 0x01,
+	0x11, 0x96, 0x01, 0x1d, 0x01, 0x4c, 0x02, 0x01, 0x28, 0x01, 0x2c, 0xfe, 0xf0, 0x01, 0xfe, 0xee,
+	0xbe, 0xfe, 0xf4, 0x04, 0x4a, 0xfd, 0x09, 0x02, 0xf7, 0xfb, 0xb6, 0x02, 0xf5, 0xfd, 0x55, 0x02,
+	0xa4, 0xfd, 0x12, 0x00, 0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x04, 0x3f, 0x04, 0x4a, 0x00, 0x0b,
+	0x00, 0x48, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04,
+	0x67, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b,
+	0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x96, 0x01, 0x28, 0x01, 0x59, 0x01, 0x28, 0xfe, 0xd8, 0xfe,
+	0xa7, 0x04, 0x4a, 0xfe, 0x58, 0x01, 0xa8, 0xfb, 0xb6, 0x01, 0xe9, 0xfe, 0x17, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x99, 0x04, 0x63, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2d,
+	0x40, 0x2a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x05, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x07, 0x16, 0x2b, 0x05, 0x22, 0x00,
+	0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x02, 0x6b, 0xf6, 0xfe, 0xd5, 0x01, 0x2c, 0xfb, 0xfb, 0x01, 0x2d,
+	0xfe, 0xd3, 0xfd, 0x70, 0x80, 0x81, 0x6d, 0x6d, 0x80, 0x80, 0x19, 0x01, 0x3b, 0x01, 0x03, 0x01,
+	0x06, 0x01, 0x38, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1, 0xd2,
+	0xd2, 0xb3, 0xb1, 0xd4, 0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x04, 0x3f, 0x04, 0x4a, 0x00, 0x07,
+	0x00, 0x3e, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x12, 0x00, 0x02,
+	0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b,
+	0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x96, 0x03, 0xa9, 0xfe, 0xd8, 0xfe, 0xa7, 0x04,
+	0x4a, 0xfb, 0xb6, 0x03, 0x85, 0xfc, 0x7b, 0x00, 0x00, 0x02, 0x00, 0x94, 0xfe, 0x75, 0x04, 0x94,
+	0x04, 0x63, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x62, 0x40, 0x0f, 0x04, 0x01, 0x05, 0x01, 0x17, 0x0f,
+	0x02, 0x04, 0x05, 0x0e, 0x01, 0x03, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b,
+	0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x22, 0x4d, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x01,
+	0x01, 0x1c, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x21, 0x4d, 0x00, 0x04, 0x04,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x4d, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x4e, 0x59, 0x40, 0x09,
+	0x22, 0x23, 0x24, 0x22, 0x11, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x21, 0x11, 0x21, 0x15, 0x36,
+	0x33, 0x32, 0x12, 0x15, 0x10, 0x00, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x11, 0x10, 0x23,
+	0x22, 0x07, 0x01, 0xbc, 0xfe, 0xd8, 0x01, 0x28, 0x9d, 0xbc, 0xac, 0xd3, 0xfe, 0xef, 0xf3, 0x51,
+	0x83, 0x70, 0x37, 0xf6, 0xb3, 0x78, 0x72, 0xfe, 0x75, 0x05, 0xd5, 0xb6, 0xcf, 0xfe, 0xd5, 0xf5,
+	0xfe, 0xe4, 0xfe, 0xc0, 0x19, 0xb0, 0x13, 0x01, 0x7d, 0x01, 0x61, 0xaf, 0x00, 0x01, 0x00, 0x4a,
+	0xff, 0xe7, 0x04, 0x20, 0x04, 0x63, 0x00, 0x13, 0x00, 0x2e, 0x40, 0x2b, 0x0a, 0x01, 0x02, 0x01,
+	0x0b, 0x00, 0x02, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e,
+	0x23, 0x23, 0x23, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10,
+	0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x04, 0x20, 0xd4, 0xa3,
+	0xfe, 0xde, 0xfe, 0xc3, 0x02, 0x75, 0xae, 0xaa, 0xd1, 0x72, 0xfe, 0xb1, 0xc1, 0xaa, 0x78, 0xe5,
+	0xcd, 0x31, 0x01, 0x2d, 0x01, 0x12, 0x02, 0x3d, 0x2b, 0xd6, 0x3b, 0xfe, 0x8a, 0xb2, 0xca, 0x00,
+	0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x03, 0xd7, 0x04, 0x4a, 0x00, 0x07, 0x00, 0x3e, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35,
+	0x21, 0x15, 0x21, 0x11, 0x01, 0x61, 0xfe, 0xb3, 0x03, 0xc3, 0xfe, 0xb3, 0x03, 0x85, 0xc5, 0xc5,
+	0xfc, 0x7b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x75, 0x04, 0x73, 0x04, 0x4a, 0x00, 0x10,
+	0x00, 0x21, 0x40, 0x1e, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x21, 0x23, 0x12, 0x11, 0x04,
+	0x07, 0x1a, 0x2b, 0x25, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01, 0x02, 0x06, 0x21, 0x23, 0x35, 0x33,
+	0x32, 0x36, 0x37, 0x37, 0x01, 0xb7, 0xfe, 0x49, 0x01, 0x23, 0x01, 0x2a, 0x01, 0x33, 0xf3, 0xfe,
+	0x2e, 0x7e, 0xaa, 0xfe, 0xf6, 0x20, 0x1c, 0x74, 0x7a, 0x24, 0x27, 0x28, 0x04, 0x22, 0xfd, 0x38,
+	0x02, 0xc8, 0xfb, 0xc9, 0xfe, 0xdf, 0x7d, 0xc6, 0x2d, 0x44, 0x53, 0x00, 0x00, 0x03, 0x00, 0x4a,
+	0xfe, 0x75, 0x06, 0xb6, 0x06, 0x2b, 0x00, 0x19, 0x00, 0x24, 0x00, 0x2f, 0x01, 0x8f, 0x40, 0x13,
+	0x0e, 0x0b, 0x02, 0x06, 0x01, 0x26, 0x25, 0x24, 0x1a, 0x04, 0x07, 0x06, 0x18, 0x01, 0x02, 0x00,
+	0x07, 0x03, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09,
+	0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x21, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00,
+	0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x0e, 0x50, 0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01,
+	0x61, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00,
+	0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58,
+	0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01,
+	0x01, 0x21, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d, 0x0a,
+	0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x24, 0x00, 0x02,
+	0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x08,
+	0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e,
+	0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09,
+	0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x21, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00,
+	0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x1b, 0x50, 0x58, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01,
+	0x61, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00,
+	0x00, 0x1b, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01, 0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01,
+	0x01, 0x21, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x1b, 0x4d, 0x0a,
+	0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x02, 0x01, 0x02, 0x85, 0x09, 0x01,
+	0x06, 0x06, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x21, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x00, 0x61,
+	0x04, 0x01, 0x00, 0x00, 0x1d, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1e, 0x05, 0x4e, 0x59, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x59, 0x40, 0x16, 0x00, 0x00, 0x2f, 0x2d, 0x29, 0x27, 0x23, 0x21, 0x1d, 0x1b,
+	0x00, 0x19, 0x00, 0x19, 0x24, 0x22, 0x12, 0x24, 0x22, 0x0b, 0x07, 0x1b, 0x2b, 0x01, 0x11, 0x06,
+	0x23, 0x22, 0x02, 0x35, 0x34, 0x00, 0x33, 0x32, 0x17, 0x11, 0x21, 0x11, 0x36, 0x33, 0x32, 0x00,
+	0x15, 0x14, 0x00, 0x23, 0x22, 0x27, 0x11, 0x01, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33,
+	0x32, 0x37, 0x01, 0x11, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x02, 0xf8, 0x5d,
+	0x8a, 0xcb, 0xfc, 0x01, 0x01, 0xcc, 0x98, 0x49, 0x01, 0x0f, 0x48, 0x98, 0xca, 0x01, 0x05, 0xff,
+	0x00, 0xc9, 0x8a, 0x5c, 0xfe, 0xf1, 0x47, 0x3f, 0x6f, 0x8e, 0x8c, 0x6e, 0x3a, 0x4f, 0x01, 0x0f,
+	0x4f, 0x3a, 0x73, 0x87, 0x8e, 0x70, 0x3e, 0xfe, 0x75, 0x01, 0xe1, 0x62, 0x01, 0x3b, 0xf3, 0xf4,
+	0x01, 0x41, 0x63, 0x02, 0x37, 0xfd, 0xc9, 0x63, 0xfe, 0xbf, 0xf4, 0xf3, 0xfe, 0xc5, 0x62, 0xfe,
+	0x1f, 0x04, 0xe6, 0x30, 0xd1, 0x99, 0x9f, 0xc3, 0x34, 0x02, 0x68, 0xfd, 0x98, 0x34, 0xc3, 0x9f,
+	0x99, 0xd1, 0x00, 0x00, 0x00, 0x01, 0x00, 0x30, 0x00, 0x00, 0x04, 0x42, 0x04, 0x4a, 0x00, 0x0b,
+	0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b,
+	0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05,
+	0x07, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x21, 0x13, 0x13, 0x33, 0x01, 0x01, 0x21, 0x03, 0x03, 0x30,
+	0x01, 0x66, 0xfe, 0xaa, 0x01, 0x51, 0xd9, 0xcf, 0xf0, 0xfe, 0xbb, 0x01, 0x5e, 0xfe, 0xaf, 0xe3,
+	0xe9, 0x02, 0x27, 0x02, 0x23, 0xfe, 0xa4, 0x01, 0x5c, 0xfd, 0xe4, 0xfd, 0xd2, 0x01, 0x6b, 0xfe,
+	0x95, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94, 0xfe, 0xa7, 0x04, 0xd2, 0x04, 0x4a, 0x00, 0x0b,
+	0x00, 0xbb, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x1e, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x04,
+	0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x18, 0x00,
+	0x04, 0x01, 0x04, 0x54, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60,
+	0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1e, 0x02,
+	0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x01, 0x04, 0x54, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x18,
+	0x00, 0x04, 0x01, 0x04, 0x54, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05,
+	0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00,
+	0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21,
+	0x11, 0x21, 0x11, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x94, 0x01, 0x29, 0x01, 0x4d, 0x01, 0x28,
+	0xa0, 0xdc, 0x04, 0x4a, 0xfc, 0x7b, 0x03, 0x85, 0xfc, 0x7b, 0xfd, 0xe2, 0x01, 0x59, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x5a, 0x00, 0x00, 0x04, 0x11, 0x04, 0x4a, 0x00, 0x11, 0x00, 0x51, 0x40, 0x0a,
+	0x0e, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00,
+	0x6a, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x12, 0x23, 0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b, 0x21,
+	0x11, 0x06, 0x23, 0x22, 0x26, 0x35, 0x11, 0x21, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x21,
+	0x11, 0x02, 0xe9, 0x8e, 0x89, 0xd2, 0xa6, 0x01, 0x28, 0x41, 0x69, 0x61, 0x5c, 0x01, 0x28, 0x01,
+	0x9d, 0x31, 0xa7, 0xc4, 0x01, 0x73, 0xfe, 0xcc, 0x90, 0x62, 0x2a, 0x01, 0xfc, 0xfb, 0xb6, 0x00,
+	0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x06, 0x15, 0x04, 0x4a, 0x00, 0x0b, 0x00, 0x44, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00,
+	0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07,
+	0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x96, 0x01,
+	0x0e, 0x01, 0x2b, 0x01, 0x0d, 0x01, 0x2b, 0x01, 0x0e, 0x04, 0x4a, 0xfc, 0x7b, 0x03, 0x85, 0xfc,
+	0x7b, 0x03, 0x85, 0xfb, 0xb6, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x94, 0xfe, 0xa7, 0x06, 0x8c,
+	0x04, 0x4a, 0x00, 0x0f, 0x00, 0xc9, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x21, 0x04, 0x02, 0x02,
+	0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b,
+	0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x06, 0x60, 0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x12, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x06, 0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00,
+	0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b, 0x07, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b, 0x4d, 0x05, 0x03, 0x02, 0x01,
+	0x01, 0x06, 0x60, 0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x1a, 0x00, 0x06, 0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03, 0x02,
+	0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b, 0x07, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x06,
+	0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07,
+	0x60, 0x08, 0x01, 0x07, 0x07, 0x1d, 0x07, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00,
+	0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x07, 0x1d, 0x2b, 0x33,
+	0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x94,
+	0x01, 0x0e, 0x01, 0x17, 0x01, 0x0e, 0x01, 0x17, 0x01, 0x0e, 0xa0, 0xdc, 0x04, 0x4a, 0xfc, 0x7b,
+	0x03, 0x85, 0xfc, 0x7b, 0x03, 0x85, 0xfc, 0x7b, 0xfd, 0xe2, 0x01, 0x59, 0x00, 0x02, 0xff, 0xff,
+	0x00, 0x00, 0x05, 0x8b, 0x04, 0x4a, 0x00, 0x0c, 0x00, 0x15, 0x00, 0x5a, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03,
+	0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01,
+	0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d,
+	0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x15, 0x13, 0x0f, 0x0d, 0x00, 0x0c, 0x00, 0x0b, 0x21,
+	0x11, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x11, 0x21, 0x20, 0x16, 0x15,
+	0x14, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x01, 0x42, 0xfe, 0xbd,
+	0x02, 0x6b, 0x01, 0x1f, 0x01, 0x19, 0xe9, 0xfd, 0xfe, 0xd7, 0xfb, 0xfc, 0x88, 0x75, 0x73, 0x87,
+	0xff, 0x03, 0x85, 0xc5, 0xfe, 0x80, 0xa0, 0xbe, 0xc3, 0xa9, 0xb9, 0x53, 0x5e, 0x58, 0x4f, 0x00,
+	0x00, 0x03, 0x00, 0x94, 0x00, 0x00, 0x06, 0x41, 0x04, 0x4a, 0x00, 0x0a, 0x00, 0x13, 0x00, 0x17,
+	0x00, 0x5d, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04,
+	0x67, 0x05, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x60, 0x08, 0x06, 0x07, 0x03,
+	0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67,
+	0x05, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x60, 0x08, 0x06, 0x07, 0x03, 0x02,
+	0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x17, 0x14, 0x14, 0x00, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16,
+	0x15, 0x13, 0x11, 0x0d, 0x0b, 0x00, 0x0a, 0x00, 0x09, 0x21, 0x11, 0x09, 0x07, 0x18, 0x2b, 0x33,
+	0x11, 0x21, 0x11, 0x33, 0x20, 0x16, 0x15, 0x14, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34,
+	0x26, 0x23, 0x23, 0x01, 0x11, 0x21, 0x11, 0x94, 0x01, 0x22, 0xba, 0x01, 0x05, 0xea, 0xfd, 0xfe,
+	0xeb, 0x97, 0x9d, 0x75, 0x75, 0x73, 0x73, 0xa1, 0x03, 0x69, 0x01, 0x22, 0x04, 0x4a, 0xfe, 0x80,
+	0xa4, 0xba, 0xc0, 0xac, 0xb9, 0x53, 0x5e, 0x58, 0x4f, 0xfd, 0xef, 0x04, 0x4a, 0xfb, 0xb6, 0x00,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x04, 0xa1, 0x04, 0x4a, 0x00, 0x0a, 0x00, 0x13, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d,
+	0x00, 0x03, 0x03, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x13, 0x11, 0x0d, 0x0b, 0x00, 0x0a, 0x00, 0x09, 0x21, 0x11, 0x06, 0x07, 0x18, 0x2b, 0x33,
+	0x11, 0x21, 0x11, 0x33, 0x20, 0x16, 0x15, 0x14, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34,
+	0x26, 0x23, 0x23, 0x94, 0x01, 0x28, 0xf7, 0x01, 0x04, 0xea, 0xfd, 0xfe, 0xd7, 0xbf, 0xc0, 0x88,
+	0x75, 0x73, 0x87, 0xc3, 0x04, 0x4a, 0xfe, 0x80, 0xa0, 0xbe, 0xc3, 0xa9, 0xb9, 0x53, 0x5e, 0x58,
+	0x4f, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x35, 0xff, 0xe7, 0x04, 0x21, 0x04, 0x63, 0x00, 0x18,
+	0x00, 0x3b, 0x40, 0x38, 0x0f, 0x01, 0x03, 0x04, 0x0e, 0x01, 0x02, 0x03, 0x01, 0x01, 0x00, 0x01,
+	0x00, 0x01, 0x05, 0x00, 0x04, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x22, 0x05, 0x4e, 0x24, 0x23, 0x22, 0x11, 0x12, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x35, 0x16,
+	0x33, 0x32, 0x36, 0x37, 0x21, 0x35, 0x21, 0x26, 0x26, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20,
+	0x00, 0x11, 0x10, 0x00, 0x21, 0x22, 0x35, 0x92, 0xce, 0xa6, 0xac, 0x0f, 0xfe, 0x42, 0x01, 0xbe,
+	0x04, 0x9d, 0xa7, 0xbd, 0xa3, 0xa8, 0xce, 0x01, 0x30, 0x01, 0x2d, 0xfe, 0xe0, 0xfe, 0xd1, 0xf0,
+	0x20, 0xc4, 0x44, 0x9d, 0x9e, 0xb9, 0x8f, 0x87, 0x3f, 0xca, 0x2e, 0xfe, 0xde, 0xfe, 0xef, 0xfe,
+	0xdb, 0xfe, 0xdc, 0x00, 0x00, 0x02, 0x00, 0x94, 0xff, 0xe7, 0x06, 0x8b, 0x04, 0x63, 0x00, 0x14,
+	0x00, 0x20, 0x00, 0xa5, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x00, 0x01, 0x06,
+	0x04, 0x01, 0x67, 0x00, 0x07, 0x07, 0x03, 0x61, 0x05, 0x01, 0x03, 0x03, 0x1c, 0x4d, 0x09, 0x01,
+	0x06, 0x06, 0x00, 0x61, 0x02, 0x08, 0x02, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x29, 0x00, 0x04, 0x00, 0x01, 0x06, 0x04, 0x01, 0x67, 0x00, 0x03, 0x03, 0x1c,
+	0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x21, 0x4d, 0x00, 0x02, 0x02, 0x1b, 0x4d,
+	0x09, 0x01, 0x06, 0x06, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x1b, 0x40, 0x29,
+	0x00, 0x04, 0x00, 0x01, 0x06, 0x04, 0x01, 0x67, 0x00, 0x03, 0x03, 0x1c, 0x4d, 0x00, 0x07, 0x07,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x21, 0x4d, 0x00, 0x02, 0x02, 0x1d, 0x4d, 0x09, 0x01, 0x06, 0x06,
+	0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1b, 0x16, 0x15, 0x01,
+	0x00, 0x1c, 0x1a, 0x15, 0x20, 0x16, 0x20, 0x10, 0x0e, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05,
+	0x04, 0x00, 0x14, 0x01, 0x14, 0x0a, 0x07, 0x16, 0x2b, 0x05, 0x22, 0x27, 0x26, 0x27, 0x23, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x33, 0x36, 0x37, 0x36, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32,
+	0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x04, 0x67, 0xf6, 0x91, 0x75, 0x16,
+	0xa5, 0xfe, 0xe4, 0x01, 0x1c, 0xa5, 0x15, 0x77, 0x91, 0xfb, 0xfb, 0x01, 0x23, 0xfe, 0xdd, 0xfd,
+	0x70, 0x76, 0x77, 0x6d, 0x6d, 0x76, 0x76, 0x19, 0x9d, 0x80, 0xc4, 0xfe, 0x38, 0x04, 0x4a, 0xfe,
+	0x38, 0xc6, 0x7f, 0x9c, 0xfe, 0xc8, 0xfe, 0xfc, 0xfe, 0xf7, 0xfe, 0xc9, 0xb9, 0xd1, 0xb6, 0xb1,
+	0xd2, 0xd2, 0xb3, 0xb1, 0xd4, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x35, 0x00, 0x00, 0x04, 0x17,
+	0x04, 0x4a, 0x00, 0x17, 0x00, 0x20, 0x00, 0x50, 0xb5, 0x0d, 0x01, 0x00, 0x05, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04,
+	0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x19, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x21,
+	0x11, 0x2b, 0x16, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x23, 0x06, 0x0f, 0x02, 0x06, 0x07, 0x21,
+	0x36, 0x37, 0x37, 0x36, 0x37, 0x26, 0x26, 0x35, 0x34, 0x37, 0x36, 0x21, 0x21, 0x11, 0x21, 0x11,
+	0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x33, 0x02, 0xfb, 0x9c, 0x3c, 0x3b, 0x38, 0x32, 0x06,
+	0x0c, 0xfe, 0xc9, 0x47, 0x38, 0x19, 0x6b, 0x5f, 0x75, 0x75, 0xa7, 0x56, 0x01, 0x0e, 0x01, 0x5f,
+	0xfe, 0xe4, 0x69, 0x65, 0x64, 0x67, 0x67, 0x64, 0x01, 0xaa, 0x43, 0x73, 0x6e, 0x61, 0x0c, 0x19,
+	0x6a, 0x6a, 0x31, 0xca, 0x26, 0x26, 0x91, 0x6a, 0xbb, 0x50, 0x29, 0xfb, 0xb6, 0x03, 0x9d, 0x53,
+	0x53, 0x54, 0x53, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x15, 0x00, 0x19, 0x00, 0x3f, 0x40, 0x3c, 0x00, 0x01, 0x03, 0x02, 0x01, 0x01, 0x00, 0x03,
+	0x02, 0x4c, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x01, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02,
+	0x03, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x11, 0x11, 0x21, 0x11, 0x21, 0x12, 0x24,
+	0x22, 0x08, 0x07, 0x1e, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00, 0x33, 0x32,
+	0x12, 0x11, 0x21, 0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x01, 0x23, 0x01, 0x21, 0x04,
+	0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe, 0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f, 0x01,
+	0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65, 0x9f, 0xa8, 0x01, 0x6c, 0xc9, 0xfe, 0xc0, 0x01, 0x18, 0xf5,
+	0xd0, 0x3e, 0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01,
+	0xe1, 0x01, 0x19, 0x01, 0x59, 0x01, 0x41, 0x00, 0x00, 0x04, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x07,
+	0x05, 0xeb, 0x00, 0x10, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x86, 0x40, 0x0a, 0x00, 0x01,
+	0x03, 0x02, 0x01, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x2b, 0x00,
+	0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x0b, 0x09, 0x0a, 0x03, 0x07, 0x07, 0x06, 0x5f, 0x08,
+	0x01, 0x06, 0x06, 0x1a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00,
+	0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x08, 0x01, 0x06,
+	0x0b, 0x09, 0x0a, 0x03, 0x07, 0x01, 0x06, 0x07, 0x67, 0x00, 0x04, 0x00, 0x02, 0x03, 0x04, 0x02,
+	0x67, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x40, 0x18, 0x1a, 0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a,
+	0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x12, 0x21, 0x11, 0x21, 0x12, 0x24, 0x22, 0x0c, 0x07,
+	0x1d, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x34, 0x00, 0x33, 0x32, 0x12, 0x11, 0x21,
+	0x12, 0x21, 0x32, 0x01, 0x21, 0x10, 0x23, 0x22, 0x03, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0x04, 0x07, 0xb7, 0xb8, 0xfe, 0xed, 0xfe, 0xc5, 0x01, 0x13, 0xe4, 0xec, 0xda, 0xfd, 0x7b, 0x1f,
+	0x01, 0x2a, 0x8d, 0xfe, 0x27, 0x01, 0x65, 0x9f, 0xa8, 0x94, 0xde, 0xc5, 0xdf, 0xf5, 0xd0, 0x3e,
+	0x01, 0x3b, 0x01, 0x12, 0xfe, 0x01, 0x31, 0xfe, 0xd1, 0xfe, 0xb6, 0xfe, 0xc6, 0x01, 0xe1, 0x01,
+	0x19, 0x01, 0x63, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x01, 0x00, 0x14, 0xfe, 0x5c, 0x04, 0x5c,
+	0x06, 0x2b, 0x00, 0x22, 0x00, 0xaf, 0x40, 0x12, 0x0b, 0x01, 0x08, 0x05, 0x21, 0x01, 0x09, 0x08,
+	0x16, 0x01, 0x07, 0x09, 0x15, 0x01, 0x06, 0x07, 0x04, 0x4c, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40,
+	0x28, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00, 0x05, 0x00, 0x08, 0x09,
+	0x05, 0x08, 0x69, 0x00, 0x02, 0x02, 0x09, 0x5f, 0x0a, 0x01, 0x09, 0x09, 0x1b, 0x4d, 0x00, 0x07,
+	0x07, 0x06, 0x61, 0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x25, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00, 0x05, 0x00, 0x08, 0x09,
+	0x05, 0x08, 0x69, 0x00, 0x07, 0x00, 0x06, 0x07, 0x06, 0x65, 0x00, 0x02, 0x02, 0x09, 0x5f, 0x0a,
+	0x01, 0x09, 0x09, 0x1b, 0x09, 0x4e, 0x1b, 0x40, 0x25, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05,
+	0x01, 0x00, 0x67, 0x00, 0x05, 0x00, 0x08, 0x09, 0x05, 0x08, 0x69, 0x00, 0x07, 0x00, 0x06, 0x07,
+	0x06, 0x65, 0x00, 0x02, 0x02, 0x09, 0x5f, 0x0a, 0x01, 0x09, 0x09, 0x1d, 0x09, 0x4e, 0x59, 0x59,
+	0x40, 0x12, 0x00, 0x00, 0x00, 0x22, 0x00, 0x22, 0x25, 0x23, 0x24, 0x22, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x0b, 0x07, 0x1f, 0x2b, 0x33, 0x11, 0x23, 0x35, 0x33, 0x35, 0x21, 0x15, 0x21, 0x15, 0x21,
+	0x11, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x10, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x36,
+	0x35, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0xb4, 0xa0, 0xa0, 0x01, 0x28, 0x01, 0x58, 0xfe,
+	0xa8, 0x86, 0xbe, 0x93, 0xa9, 0xfe, 0x94, 0x50, 0x37, 0x1f, 0x36, 0x42, 0x34, 0x3e, 0x34, 0x6d,
+	0x79, 0x04, 0x84, 0xad, 0xfa, 0xfa, 0xad, 0xfe, 0x3e, 0xe8, 0xba, 0xa1, 0xfd, 0x74, 0xfe, 0x99,
+	0x15, 0xab, 0x07, 0x4f, 0x65, 0x02, 0x3d, 0x5d, 0x5e, 0xc7, 0xfe, 0x06, 0x00, 0x02, 0x00, 0x96,
+	0x00, 0x00, 0x03, 0x41, 0x06, 0x44, 0x00, 0x05, 0x00, 0x09, 0x00, 0x59, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40,
+	0x13, 0x06, 0x06, 0x00, 0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11,
+	0x11, 0x07, 0x07, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x03, 0x13, 0x21, 0x01, 0x96,
+	0x02, 0xa6, 0xfe, 0x83, 0x88, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x04, 0x4a, 0xd2, 0xfc, 0x88, 0x05,
+	0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4a, 0xff, 0xe7, 0x04, 0x18,
+	0x04, 0x63, 0x00, 0x18, 0x00, 0x3b, 0x40, 0x38, 0x0b, 0x01, 0x02, 0x01, 0x0c, 0x01, 0x03, 0x02,
+	0x00, 0x01, 0x05, 0x04, 0x01, 0x01, 0x00, 0x05, 0x04, 0x4c, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03,
+	0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x22, 0x11, 0x12, 0x23, 0x24, 0x22, 0x06, 0x07, 0x1c,
+	0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23,
+	0x22, 0x06, 0x07, 0x21, 0x15, 0x21, 0x16, 0x16, 0x33, 0x32, 0x04, 0x18, 0xa3, 0xe2, 0xfe, 0xd6,
+	0xfe, 0xe1, 0x01, 0x2d, 0x01, 0x26, 0xba, 0xa8, 0xa3, 0xa9, 0x9e, 0x9a, 0x06, 0x01, 0xbe, 0xfe,
+	0x42, 0x0f, 0xab, 0xa7, 0xb0, 0xe4, 0xc4, 0x39, 0x01, 0x24, 0x01, 0x25, 0x01, 0x11, 0x01, 0x22,
+	0x2e, 0xca, 0x3f, 0x8b, 0x8b, 0xb9, 0x9e, 0x9d, 0x00, 0x01, 0x00, 0x7b, 0xff, 0xe7, 0x04, 0x0c,
+	0x04, 0x63, 0x00, 0x1e, 0x00, 0x2e, 0x40, 0x2b, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00,
+	0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x03, 0x4e, 0x29, 0x23, 0x28, 0x22,
+	0x04, 0x07, 0x1a, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x26, 0x35,
+	0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14,
+	0x04, 0x23, 0x22, 0x7b, 0xe6, 0x9d, 0xdd, 0xaf, 0x64, 0xcd, 0x7b, 0x01, 0xcf, 0x9e, 0xc8, 0xdc,
+	0x66, 0xcf, 0xa1, 0x56, 0xdc, 0x95, 0xfe, 0xed, 0xe8, 0xcc, 0x24, 0xd8, 0x5c, 0x78, 0x49, 0x47,
+	0x28, 0x53, 0x7a, 0x7a, 0x01, 0x4c, 0x27, 0xcb, 0x39, 0x70, 0x44, 0x3d, 0x21, 0x53, 0x8d, 0x7c,
+	0x9c, 0xb9, 0x00, 0x00, 0x00, 0x02, 0x00, 0x89, 0x00, 0x00, 0x01, 0xb1, 0x05, 0xfa, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0xa8, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b,
+	0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00,
+	0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2b, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02,
+	0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01,
+	0x1d, 0x01, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x07, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11,
+	0x01, 0x35, 0x21, 0x15, 0x89, 0x01, 0x28, 0xfe, 0xd8, 0x01, 0x28, 0x04, 0x4a, 0xfb, 0xb6, 0x05,
+	0x03, 0xf7, 0xf7, 0x00, 0x00, 0x03, 0xff, 0xdf, 0x00, 0x00, 0x02, 0x61, 0x05, 0xeb, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x0b, 0x00, 0x7b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x05, 0x07,
+	0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01, 0x02, 0x02, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1c, 0x4d,
+	0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x04,
+	0x01, 0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d,
+	0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x04, 0x01, 0x02, 0x08, 0x05, 0x07,
+	0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x1d,
+	0x01, 0x4e, 0x59, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b,
+	0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x07, 0x17,
+	0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x8c, 0x01, 0x28,
+	0xfe, 0x2b, 0xde, 0xc5, 0xdf, 0x04, 0x4a, 0xfb, 0xb6, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x00,
+	0x00, 0x02, 0xff, 0xb6, 0xfe, 0x5d, 0x01, 0xb7, 0x05, 0xfa, 0x00, 0x0c, 0x00, 0x10, 0x00, 0xc0,
+	0x40, 0x0a, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50,
+	0x58, 0x40, 0x1b, 0x05, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1a, 0x4d, 0x00, 0x01,
+	0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x12, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x05, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00,
+	0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x05, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x03,
+	0x1a, 0x4d, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x1e,
+	0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x05, 0x01, 0x04, 0x01,
+	0x03, 0x04, 0x67, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02,
+	0x1e, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x03, 0x05, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x02, 0x00, 0x02, 0x66, 0x00, 0x01, 0x01, 0x1c, 0x01, 0x4e, 0x59, 0x59, 0x59, 0x59,
+	0x40, 0x0d, 0x0d, 0x0d, 0x0d, 0x10, 0x0d, 0x10, 0x12, 0x22, 0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b,
+	0x03, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x21, 0x11, 0x10, 0x21, 0x22, 0x13, 0x35, 0x21,
+	0x15, 0x4a, 0x46, 0x29, 0x4e, 0x1c, 0x01, 0x28, 0xfe, 0x98, 0x4d, 0x83, 0x01, 0x28, 0xfe, 0x71,
+	0xb8, 0x13, 0x64, 0x86, 0x04, 0x4a, 0xfb, 0xc9, 0xfe, 0x4a, 0x06, 0xa6, 0xf7, 0xf7, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x54, 0x00, 0x00, 0x07, 0x76, 0x04, 0x4a, 0x00, 0x15, 0x00, 0x1e, 0x00, 0x9f,
+	0x4b, 0xb0, 0x26, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x00, 0x07, 0x02, 0x04, 0x07, 0x67, 0x00,
+	0x00, 0x00, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1c, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x01, 0x61, 0x08,
+	0x05, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x2b, 0x00,
+	0x04, 0x00, 0x07, 0x02, 0x04, 0x07, 0x67, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1c,
+	0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x08, 0x05, 0x02, 0x01, 0x01, 0x1b, 0x4d, 0x00, 0x06, 0x06,
+	0x01, 0x61, 0x08, 0x05, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x04, 0x00,
+	0x07, 0x02, 0x04, 0x07, 0x67, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1c, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x08, 0x05, 0x02, 0x01, 0x01, 0x1d, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61,
+	0x08, 0x05, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1e, 0x1c,
+	0x18, 0x16, 0x00, 0x15, 0x00, 0x14, 0x21, 0x14, 0x11, 0x13, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x21,
+	0x11, 0x21, 0x15, 0x10, 0x02, 0x21, 0x35, 0x32, 0x37, 0x36, 0x11, 0x35, 0x21, 0x11, 0x33, 0x20,
+	0x16, 0x15, 0x14, 0x06, 0x21, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x03, 0x80,
+	0xfe, 0xc8, 0xd1, 0xfe, 0xdd, 0x89, 0x2d, 0x2f, 0x03, 0x68, 0xd9, 0x01, 0x13, 0xe9, 0xfa, 0xfe,
+	0xda, 0xb5, 0xb6, 0x88, 0x75, 0x73, 0x87, 0xb9, 0x03, 0x85, 0x12, 0xfd, 0xfe, 0xfe, 0x8f, 0xc6,
+	0xa3, 0xac, 0x01, 0x91, 0xa4, 0xfe, 0x80, 0xa4, 0xba, 0xc0, 0xac, 0xb9, 0x53, 0x5e, 0x58, 0x4f,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x06, 0xf6, 0x04, 0x4a, 0x00, 0x12, 0x00, 0x1b, 0x00, 0x5b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x08, 0x01, 0x00, 0x07, 0x03, 0x00,
+	0x69, 0x04, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x60, 0x09, 0x06, 0x02, 0x01,
+	0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x08, 0x01, 0x00, 0x07, 0x03, 0x00,
+	0x69, 0x04, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x60, 0x09, 0x06, 0x02, 0x01,
+	0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x13, 0x00, 0x00, 0x1b, 0x19, 0x15, 0x13, 0x00, 0x12, 0x00,
+	0x11, 0x21, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x07, 0x1c, 0x2b, 0x21, 0x11, 0x21, 0x11, 0x21,
+	0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x33, 0x20, 0x16, 0x15, 0x14, 0x06, 0x21, 0x27, 0x33,
+	0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x03, 0x28, 0xfe, 0x8e, 0xfe, 0xde, 0x01, 0x22, 0x01,
+	0x72, 0x01, 0x22, 0xa9, 0x01, 0x16, 0xed, 0xfd, 0xfe, 0xd7, 0x86, 0x8c, 0x89, 0x75, 0x73, 0x87,
+	0x90, 0x01, 0xfd, 0xfe, 0x03, 0x04, 0x4a, 0xfe, 0x6c, 0x01, 0x94, 0xfe, 0x6c, 0xa5, 0xaf, 0xb5,
+	0xad, 0xb9, 0x53, 0x54, 0x4e, 0x4f, 0x00, 0x00, 0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x04, 0x5c,
+	0x06, 0x2b, 0x00, 0x19, 0x00, 0x62, 0x40, 0x0a, 0x00, 0x01, 0x02, 0x00, 0x0d, 0x01, 0x01, 0x02,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1e, 0x07, 0x01, 0x05, 0x08, 0x01, 0x04, 0x00,
+	0x05, 0x04, 0x67, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01, 0x5f,
+	0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x07, 0x01, 0x05, 0x08, 0x01, 0x04,
+	0x00, 0x05, 0x04, 0x67, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01,
+	0x5f, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x12, 0x23, 0x13, 0x21, 0x09, 0x07, 0x1f, 0x2b, 0x01, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x21,
+	0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x21, 0x11, 0x23, 0x35, 0x33, 0x35, 0x21, 0x15, 0x21,
+	0x15, 0x21, 0x01, 0xdc, 0x90, 0xb4, 0x93, 0xa9, 0xfe, 0xd8, 0x34, 0x3e, 0x63, 0x83, 0xfe, 0xd8,
+	0xa0, 0xa0, 0x01, 0x28, 0x01, 0x58, 0xfe, 0xa8, 0x02, 0xc2, 0xe8, 0xba, 0xa1, 0xfd, 0xb1, 0x02,
+	0x06, 0x5d, 0x5e, 0xc7, 0xfe, 0x06, 0x04, 0x84, 0xad, 0xfa, 0xfa, 0xad, 0x00, 0x02, 0x00, 0x94,
+	0x00, 0x00, 0x03, 0xe7, 0x06, 0x44, 0x00, 0x20, 0x00, 0x24, 0x00, 0x78, 0xb5, 0x13, 0x01, 0x05,
+	0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x07, 0x08, 0x07, 0x85, 0x0a,
+	0x01, 0x08, 0x00, 0x08, 0x85, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e,
+	0x1b, 0x40, 0x26, 0x00, 0x07, 0x08, 0x07, 0x85, 0x0a, 0x01, 0x08, 0x00, 0x08, 0x85, 0x00, 0x01,
+	0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c,
+	0x4d, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x17, 0x21, 0x21, 0x00, 0x00,
+	0x21, 0x24, 0x21, 0x24, 0x23, 0x22, 0x00, 0x20, 0x00, 0x20, 0x14, 0x1b, 0x21, 0x25, 0x11, 0x11,
+	0x0b, 0x07, 0x1c, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x32, 0x36, 0x37, 0x37, 0x36, 0x36, 0x37, 0x33,
+	0x15, 0x23, 0x22, 0x06, 0x07, 0x07, 0x06, 0x07, 0x16, 0x16, 0x17, 0x17, 0x16, 0x17, 0x21, 0x26,
+	0x27, 0x26, 0x27, 0x23, 0x11, 0x03, 0x13, 0x21, 0x01, 0x94, 0x01, 0x0b, 0x26, 0x2d, 0x4d, 0x1d,
+	0x43, 0x78, 0x6a, 0x2c, 0x12, 0x30, 0x2b, 0x34, 0x1c, 0x4e, 0x76, 0x62, 0x6e, 0x3f, 0x32, 0x59,
+	0x21, 0xfe, 0xd1, 0x13, 0x43, 0x64, 0x42, 0x1d, 0x36, 0xf1, 0x01, 0x19, 0xfe, 0xbf, 0x04, 0x4a,
+	0xfe, 0x2e, 0x4a, 0x8e, 0x34, 0x7c, 0x49, 0x01, 0xb9, 0x27, 0x5e, 0x33, 0x80, 0x1e, 0x16, 0x6a,
+	0x80, 0x5a, 0xb2, 0x2f, 0x22, 0x98, 0xe1, 0x37, 0xfe, 0x2e, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x02, 0x00, 0x94, 0x00, 0x00, 0x04, 0x57, 0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x56,
+	0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00,
+	0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06,
+	0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x03, 0x02, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x0d, 0x0c, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11,
+	0x12, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x01, 0x21, 0x11, 0x21, 0x11, 0x01,
+	0x01, 0x23, 0x01, 0x21, 0x94, 0x01, 0x16, 0x01, 0x85, 0x01, 0x28, 0xfe, 0xea, 0xfe, 0x7b, 0x01,
+	0x61, 0xc9, 0xfe, 0xbf, 0x01, 0x19, 0x04, 0x4a, 0xfd, 0x35, 0x02, 0xcb, 0xfb, 0xb6, 0x02, 0xcb,
+	0xfd, 0x35, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x75, 0x04, 0x73,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x20, 0x00, 0x5f, 0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x0e, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00,
+	0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62,
+	0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x1b, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00,
+	0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03,
+	0x02, 0x62, 0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x22, 0x13, 0x23, 0x14, 0x21,
+	0x23, 0x12, 0x11, 0x08, 0x07, 0x1e, 0x2b, 0x25, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01, 0x02, 0x06,
+	0x21, 0x23, 0x35, 0x33, 0x32, 0x36, 0x37, 0x37, 0x03, 0x33, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36,
+	0x35, 0x27, 0x33, 0x14, 0x06, 0x23, 0x22, 0x26, 0x01, 0xb7, 0xfe, 0x49, 0x01, 0x23, 0x01, 0x2a,
+	0x01, 0x33, 0xf3, 0xfe, 0x2e, 0x7e, 0xaa, 0xfe, 0xf6, 0x20, 0x1c, 0x74, 0x7a, 0x24, 0x27, 0x8e,
+	0xd2, 0x3d, 0x3e, 0x3e, 0x3e, 0x01, 0xd2, 0xa7, 0xa6, 0xa7, 0xa6, 0x28, 0x04, 0x22, 0xfd, 0x38,
+	0x02, 0xc8, 0xfb, 0xc9, 0xfe, 0xdf, 0x7d, 0xc6, 0x2d, 0x44, 0x53, 0x06, 0x45, 0x18, 0x54, 0x53,
+	0x54, 0x55, 0x16, 0xa1, 0xa0, 0xa0, 0x00, 0x00, 0x00, 0x01, 0x00, 0x96, 0xfe, 0xa7, 0x04, 0x3f,
+	0x04, 0x4a, 0x00, 0x0b, 0x00, 0xaf, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00,
+	0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x03,
+	0x04, 0x86, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02,
+	0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00,
+	0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x03,
+	0x04, 0x86, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02,
+	0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x04, 0x03, 0x04, 0x86, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e,
+	0x59, 0x59, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0x23,
+	0x11, 0x96, 0x01, 0x29, 0x01, 0x57, 0x01, 0x29, 0xfe, 0x99, 0xdc, 0x04, 0x4a, 0xfc, 0x7b, 0x03,
+	0x85, 0xfb, 0xb6, 0xfe, 0xa7, 0x01, 0x59, 0x00, 0x00, 0x01, 0x00, 0xad, 0x00, 0x00, 0x03, 0xc7,
+	0x06, 0xf1, 0x00, 0x07, 0x00, 0x44, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00,
+	0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x01, 0x03, 0x03,
+	0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00, 0x02, 0x03,
+	0x00, 0x02, 0x68, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11,
+	0x21, 0x11, 0xad, 0x02, 0x3e, 0xdc, 0xfe, 0x1a, 0x05, 0xc8, 0x01, 0x29, 0xfe, 0x0c, 0xfb, 0x03,
+	0x00, 0x01, 0x00, 0x96, 0x00, 0x00, 0x03, 0x70, 0x05, 0x41, 0x00, 0x07, 0x00, 0x66, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x01, 0x00, 0x00, 0x01, 0x70, 0x00, 0x02, 0x02, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b,
+	0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00,
+	0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03,
+	0x1d, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11,
+	0x05, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x35, 0x33, 0x11, 0x21, 0x11, 0x96, 0x01, 0xfe, 0xdc,
+	0xfe, 0x4f, 0x04, 0x4a, 0xf7, 0xfe, 0x37, 0xfc, 0x88, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x19,
+	0x00, 0x00, 0x07, 0x75, 0x07, 0x8f, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x62, 0xb7, 0x0b, 0x06, 0x03,
+	0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x05, 0x06, 0x00,
+	0x06, 0x05, 0x00, 0x80, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x5f,
+	0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x05, 0x06, 0x00, 0x06,
+	0x05, 0x00, 0x80, 0x02, 0x01, 0x02, 0x00, 0x03, 0x06, 0x00, 0x03, 0x7e, 0x00, 0x06, 0x06, 0x03,
+	0x5f, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x10, 0x0f,
+	0x0e, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x08, 0x09, 0x1a, 0x2b, 0x21, 0x01,
+	0x21, 0x01, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x01, 0x23, 0x01, 0x21, 0x01,
+	0x95, 0xfe, 0x84, 0x01, 0x23, 0x01, 0x19, 0x01, 0x18, 0x01, 0x01, 0xff, 0x01, 0x2d, 0xdb, 0xfe,
+	0x65, 0xfe, 0xd9, 0xf0, 0xfe, 0xf8, 0x01, 0xb4, 0xbf, 0xfe, 0xbf, 0x01, 0x0f, 0x05, 0xc8, 0xfb,
+	0xc5, 0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03, 0xf7, 0xfc, 0x09, 0x06, 0x4e, 0x01,
+	0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3e, 0x00, 0x00, 0x05, 0xfc, 0x06, 0x44, 0x00, 0x0c,
+	0x00, 0x10, 0x00, 0x85, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x29,
+	0x50, 0x58, 0x40, 0x1c, 0x00, 0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80, 0x00, 0x06, 0x06, 0x3a,
+	0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80,
+	0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x5f, 0x07, 0x04, 0x02, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80, 0x02,
+	0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x06, 0x06, 0x03, 0x5f, 0x07, 0x04, 0x02, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0c, 0x00,
+	0x0c, 0x11, 0x12, 0x12, 0x11, 0x08, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x21, 0x13, 0x13, 0x21, 0x13,
+	0x13, 0x33, 0x01, 0x21, 0x03, 0x03, 0x01, 0x23, 0x01, 0x21, 0x01, 0x48, 0xfe, 0xf6, 0x01, 0x0b,
+	0xb9, 0xc1, 0x01, 0x00, 0xaa, 0xc8, 0xc7, 0xfe, 0xe2, 0xfe, 0xe5, 0xa4, 0xbb, 0x01, 0x5f, 0xbf,
+	0xfe, 0xbf, 0x01, 0x0f, 0x04, 0x4a, 0xfc, 0xff, 0x03, 0x01, 0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6,
+	0x02, 0xf1, 0xfd, 0x0f, 0x05, 0x03, 0x01, 0x41, 0x00, 0x02, 0x00, 0x19, 0x00, 0x00, 0x07, 0x75,
+	0x07, 0x8f, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x68, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00,
+	0x80, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x07, 0x04, 0x02,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00,
+	0x80, 0x02, 0x01, 0x02, 0x00, 0x03, 0x05, 0x00, 0x03, 0x7e, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x07,
+	0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x15, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x10,
+	0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x09, 0x09, 0x1a, 0x2b,
+	0x21, 0x01, 0x21, 0x01, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x13, 0x13, 0x21,
+	0x01, 0x01, 0x95, 0xfe, 0x84, 0x01, 0x23, 0x01, 0x19, 0x01, 0x18, 0x01, 0x01, 0xff, 0x01, 0x2d,
+	0xdb, 0xfe, 0x65, 0xfe, 0xd9, 0xf0, 0xfe, 0xf8, 0xb2, 0xf1, 0x01, 0x0f, 0xfe, 0xbf, 0x05, 0xc8,
+	0xfb, 0xc5, 0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03, 0xf7, 0xfc, 0x09, 0x06, 0x4e,
+	0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x3e, 0x00, 0x00, 0x05, 0xfc, 0x06, 0x44, 0x00, 0x0c,
+	0x00, 0x10, 0x00, 0x8c, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x29,
+	0x50, 0x58, 0x40, 0x1d, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80, 0x00, 0x05, 0x05,
+	0x3a, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06,
+	0x00, 0x80, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x07, 0x04,
+	0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06,
+	0x00, 0x80, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x07, 0x04,
+	0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x15, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x10,
+	0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x09, 0x09, 0x1a, 0x2b,
+	0x21, 0x01, 0x21, 0x13, 0x13, 0x21, 0x13, 0x13, 0x33, 0x01, 0x21, 0x03, 0x03, 0x13, 0x13, 0x21,
+	0x01, 0x01, 0x48, 0xfe, 0xf6, 0x01, 0x0b, 0xb9, 0xc1, 0x01, 0x00, 0xaa, 0xc8, 0xc7, 0xfe, 0xe2,
+	0xfe, 0xe5, 0xa4, 0xbb, 0x5f, 0xf1, 0x01, 0x0f, 0xfe, 0xbf, 0x04, 0x4a, 0xfc, 0xff, 0x03, 0x01,
+	0xfc, 0xfb, 0x03, 0x05, 0xfb, 0xb6, 0x02, 0xf1, 0xfd, 0x0f, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x03, 0x00, 0x19, 0x00, 0x00, 0x07, 0x75, 0x07, 0x40, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x14,
+	0x00, 0x6d, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x02, 0x01,
+	0x02, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e,
+	0x02, 0x01, 0x02, 0x00, 0x06, 0x03, 0x06, 0x00, 0x03, 0x80, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a,
+	0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x09, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40,
+	0x1d, 0x11, 0x11, 0x0d, 0x0d, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x0d, 0x10, 0x0d,
+	0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0c, 0x09, 0x1a, 0x2b, 0x21,
+	0x01, 0x21, 0x01, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x03, 0x01, 0x03, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x01, 0x95, 0xfe, 0x84, 0x01, 0x23, 0x01, 0x19, 0x01, 0x18, 0x01, 0x01,
+	0xff, 0x01, 0x2d, 0xdb, 0xfe, 0x65, 0xfe, 0xd9, 0xf0, 0xfe, 0xf8, 0x18, 0xde, 0xd9, 0xdf, 0x05,
+	0xc8, 0xfb, 0xc5, 0x04, 0x3b, 0xfb, 0xc2, 0x04, 0x3e, 0xfa, 0x38, 0x03, 0xf7, 0xfc, 0x09, 0x06,
+	0x62, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x3e, 0x00, 0x00, 0x05, 0xfc,
+	0x05, 0xeb, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x14, 0x00, 0x90, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x1d, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06,
+	0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x09,
+	0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1b, 0x07,
+	0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00,
+	0x3b, 0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x07, 0x01, 0x05,
+	0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d,
+	0x09, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x1d, 0x11, 0x11, 0x0d, 0x0d,
+	0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c,
+	0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x21, 0x13, 0x13, 0x21,
+	0x13, 0x13, 0x33, 0x01, 0x21, 0x0b, 0x02, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x48,
+	0xfe, 0xf6, 0x01, 0x0b, 0xb9, 0xc1, 0x01, 0x00, 0xaa, 0xc8, 0xc7, 0xfe, 0xe2, 0xfe, 0xe5, 0xa4,
+	0xbb, 0x75, 0xde, 0xed, 0xdf, 0x04, 0x4a, 0xfc, 0xff, 0x03, 0x01, 0xfc, 0xfb, 0x03, 0x05, 0xfb,
+	0xb6, 0x02, 0xf1, 0xfd, 0x0f, 0x05, 0x0d, 0xde, 0xde, 0xde, 0xde, 0x00, 0x00, 0x02, 0x00, 0x1c,
+	0x00, 0x00, 0x05, 0x3b, 0x07, 0x8f, 0x00, 0x08, 0x00, 0x0c, 0x00, 0x5c, 0xb7, 0x07, 0x04, 0x01,
+	0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x03, 0x04, 0x00,
+	0x04, 0x03, 0x00, 0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x05,
+	0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x00, 0x04, 0x03, 0x00,
+	0x80, 0x01, 0x01, 0x00, 0x02, 0x04, 0x00, 0x02, 0x7e, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x05, 0x01,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x08,
+	0x00, 0x08, 0x12, 0x12, 0x06, 0x09, 0x18, 0x2b, 0x21, 0x11, 0x01, 0x21, 0x01, 0x01, 0x33, 0x01,
+	0x11, 0x13, 0x23, 0x01, 0x21, 0x02, 0x07, 0xfe, 0x15, 0x01, 0x55, 0x01, 0x62, 0x01, 0x74, 0xf4,
+	0xfe, 0x00, 0x39, 0xbf, 0xfe, 0xbf, 0x01, 0x0f, 0x02, 0x6c, 0x03, 0x5c, 0xfd, 0x8f, 0x02, 0x71,
+	0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x02, 0x00, 0x19, 0xfe, 0x75, 0x04, 0x59,
+	0x06, 0x44, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x4b, 0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x29, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x04, 0x00, 0x04, 0x03, 0x00, 0x80, 0x00, 0x04,
+	0x04, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b,
+	0x40, 0x16, 0x00, 0x04, 0x03, 0x04, 0x85, 0x00, 0x03, 0x00, 0x03, 0x85, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x59, 0xb7, 0x11, 0x11, 0x11, 0x12, 0x11, 0x05,
+	0x09, 0x1b, 0x2b, 0x21, 0x01, 0x21, 0x13, 0x01, 0x33, 0x01, 0x21, 0x01, 0x23, 0x01, 0x21, 0x01,
+	0xa3, 0xfe, 0x76, 0x01, 0x38, 0xfe, 0x01, 0x2e, 0xdc, 0xfd, 0x80, 0xfe, 0xd2, 0x02, 0x59, 0xbf,
+	0xfe, 0xbf, 0x01, 0x0f, 0x04, 0x4a, 0xfd, 0x3a, 0x02, 0xc6, 0xfa, 0x2b, 0x06, 0x8e, 0x01, 0x41,
+	0x00, 0x01, 0x00, 0x58, 0x02, 0x19, 0x04, 0x1a, 0x02, 0xc3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15,
+	0x58, 0x03, 0xc2, 0x02, 0x19, 0xaa, 0xaa, 0x00, 0x00, 0x01, 0x00, 0x50, 0x02, 0x19, 0x07, 0xb0,
+	0x02, 0xc3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x50, 0x07, 0x60, 0x02, 0x19, 0xaa, 0xaa, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0x19, 0x08, 0x00, 0x02, 0xdc, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15,
+	0x08, 0x00, 0x02, 0x19, 0xc3, 0xc3, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0x6b,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x37, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2c, 0x00, 0x00,
+	0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x05, 0x01, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x15,
+	0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x04, 0x6b, 0xfb, 0x95, 0x04, 0x6b, 0x91, 0x91, 0x91,
+	0xfe, 0xe1, 0x91, 0x91, 0x00, 0x01, 0x00, 0x7c, 0x03, 0xaa, 0x01, 0xbd, 0x06, 0x2b, 0x00, 0x09,
+	0x00, 0x1c, 0x40, 0x19, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x63, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x3a, 0x03, 0x4e, 0x11, 0x12, 0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x33,
+	0x11, 0x21, 0x35, 0x10, 0x21, 0x15, 0x22, 0x15, 0x01, 0x41, 0x7c, 0xfe, 0xbf, 0x01, 0x41, 0x7c,
+	0x04, 0xea, 0xfe, 0xc0, 0xf8, 0x01, 0x89, 0x6f, 0xb2, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7c,
+	0x03, 0xaa, 0x01, 0xbd, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x74, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x15, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x3b, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x12, 0x00, 0x03,
+	0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x15, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01,
+	0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3b, 0x02, 0x4e, 0x1b, 0x40, 0x12,
+	0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a,
+	0x00, 0x4e, 0x59, 0x59, 0x59, 0xb6, 0x11, 0x12, 0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x13, 0x23,
+	0x11, 0x21, 0x15, 0x10, 0x21, 0x35, 0x32, 0x35, 0xf7, 0x7b, 0x01, 0x41, 0xfe, 0xbf, 0x7b, 0x04,
+	0xea, 0x01, 0x41, 0xf8, 0xfe, 0x77, 0x6f, 0xb2, 0x00, 0x01, 0x00, 0x7c, 0xfe, 0xbf, 0x01, 0xbd,
+	0x01, 0x41, 0x00, 0x09, 0x00, 0x38, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x12, 0x00, 0x03, 0x00,
+	0x02, 0x03, 0x02, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b,
+	0x40, 0x12, 0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x3c, 0x00, 0x4e, 0x59, 0xb6, 0x11, 0x12, 0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x33, 0x23,
+	0x11, 0x21, 0x15, 0x10, 0x21, 0x35, 0x32, 0x35, 0xf7, 0x7b, 0x01, 0x41, 0xfe, 0xbf, 0x7b, 0x01,
+	0x41, 0xf9, 0xfe, 0x77, 0x6f, 0xb2, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7c, 0x03, 0xaa, 0x01, 0xbd,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x74, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x03, 0x03,
+	0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3b,
+	0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01,
+	0x65, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x15, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x01,
+	0x01, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3b, 0x01, 0x4e, 0x1b, 0x40, 0x12, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x01, 0x65, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x03, 0x4e, 0x59, 0x59,
+	0x59, 0xb6, 0x11, 0x12, 0x11, 0x11, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x14, 0x33, 0x15, 0x20, 0x11,
+	0x35, 0x21, 0x11, 0x23, 0x01, 0x42, 0x7b, 0xfe, 0xbf, 0x01, 0x41, 0x7b, 0x04, 0xcb, 0xb2, 0x6f,
+	0x01, 0x89, 0xf8, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x82, 0x03, 0xc2, 0x03, 0x7f,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x13, 0x00, 0x24, 0x40, 0x21, 0x11, 0x10, 0x07, 0x06, 0x04, 0x00,
+	0x4a, 0x02, 0x01, 0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01,
+	0x01, 0x00, 0x01, 0x4f, 0x11, 0x17, 0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x33, 0x11, 0x21,
+	0x35, 0x10, 0x25, 0x15, 0x06, 0x15, 0x05, 0x33, 0x11, 0x21, 0x35, 0x10, 0x25, 0x15, 0x06, 0x15,
+	0x01, 0x3b, 0x6f, 0xfe, 0xd8, 0x01, 0x28, 0x6f, 0x01, 0xd5, 0x6f, 0xfe, 0xd8, 0x01, 0x28, 0x6f,
+	0x04, 0xea, 0xfe, 0xd8, 0xe0, 0x01, 0x6f, 0x1a, 0x6f, 0x1f, 0x93, 0x20, 0xfe, 0xd8, 0xe0, 0x01,
+	0x6f, 0x1a, 0x6f, 0x1f, 0x93, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x82, 0x03, 0xc2, 0x03, 0x7f,
+	0x06, 0x2b, 0x00, 0x09, 0x00, 0x13, 0x00, 0x1e, 0x40, 0x1b, 0x11, 0x10, 0x07, 0x06, 0x04, 0x00,
+	0x49, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x01, 0x3a, 0x00, 0x4e, 0x11, 0x17,
+	0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x13, 0x23, 0x11, 0x21, 0x15, 0x10, 0x05, 0x35, 0x36, 0x35,
+	0x25, 0x23, 0x11, 0x21, 0x15, 0x10, 0x05, 0x35, 0x36, 0x35, 0xf1, 0x6f, 0x01, 0x28, 0xfe, 0xd8,
+	0x6f, 0x01, 0xd5, 0x6f, 0x01, 0x28, 0xfe, 0xd8, 0x6f, 0x05, 0x03, 0x01, 0x28, 0xdf, 0xfe, 0x90,
+	0x1a, 0x6f, 0x20, 0x93, 0x1f, 0x01, 0x28, 0xdf, 0xfe, 0x90, 0x1a, 0x6f, 0x20, 0x93, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x82, 0xfe, 0xbf, 0x03, 0x7f, 0x01, 0x28, 0x00, 0x09, 0x00, 0x13, 0x00, 0x36,
+	0xb6, 0x11, 0x10, 0x07, 0x06, 0x04, 0x00, 0x49, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x0d, 0x03,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x40, 0x0d, 0x03,
+	0x01, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3c, 0x00, 0x4e, 0x59, 0xb6, 0x11, 0x17,
+	0x11, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0x33, 0x23, 0x11, 0x21, 0x15, 0x10, 0x05, 0x35, 0x36, 0x35,
+	0x25, 0x23, 0x11, 0x21, 0x15, 0x10, 0x05, 0x35, 0x36, 0x35, 0xf1, 0x6f, 0x01, 0x28, 0xfe, 0xd8,
+	0x6f, 0x01, 0xd5, 0x6f, 0x01, 0x28, 0xfe, 0xd8, 0x6f, 0x01, 0x28, 0xdf, 0xfe, 0x91, 0x1b, 0x6f,
+	0x20, 0x93, 0x1f, 0x01, 0x28, 0xdf, 0xfe, 0x91, 0x1b, 0x6f, 0x20, 0x93, 0x00, 0x01, 0x00, 0x5e,
+	0xfe, 0xd8, 0x04, 0x14, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x50, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x16, 0x06, 0x01, 0x05, 0x00, 0x05, 0x86, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00,
+	0x68, 0x00, 0x02, 0x02, 0x38, 0x02, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x01, 0x02, 0x85, 0x06,
+	0x01, 0x05, 0x00, 0x05, 0x86, 0x03, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x03, 0x01, 0x01, 0x01,
+	0x00, 0x60, 0x04, 0x01, 0x00, 0x01, 0x00, 0x50, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x01, 0x13, 0x05, 0x35, 0x05, 0x03,
+	0x21, 0x03, 0x25, 0x15, 0x25, 0x13, 0x01, 0xa5, 0x19, 0xfe, 0xa0, 0x01, 0x60, 0x19, 0x01, 0x28,
+	0x19, 0x01, 0x60, 0xfe, 0xa0, 0x19, 0xfe, 0xd8, 0x04, 0x4a, 0x19, 0xde, 0x18, 0x01, 0xf9, 0xfe,
+	0x07, 0x18, 0xde, 0x19, 0xfb, 0xb6, 0x00, 0x00, 0x00, 0x01, 0x00, 0x5e, 0xfe, 0xd8, 0x04, 0x14,
+	0x05, 0xc8, 0x00, 0x13, 0x00, 0x68, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x20, 0x0a, 0x01, 0x09,
+	0x00, 0x09, 0x86, 0x05, 0x01, 0x03, 0x06, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x07, 0x01, 0x01,
+	0x08, 0x01, 0x00, 0x09, 0x01, 0x00, 0x67, 0x00, 0x04, 0x04, 0x38, 0x04, 0x4e, 0x1b, 0x40, 0x28,
+	0x00, 0x04, 0x03, 0x04, 0x85, 0x0a, 0x01, 0x09, 0x00, 0x09, 0x86, 0x05, 0x01, 0x03, 0x06, 0x01,
+	0x02, 0x01, 0x03, 0x02, 0x68, 0x07, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x07, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x08, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x59, 0x40, 0x12, 0x00, 0x00, 0x00, 0x13, 0x00,
+	0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x13,
+	0x05, 0x35, 0x05, 0x11, 0x05, 0x35, 0x05, 0x03, 0x21, 0x03, 0x25, 0x15, 0x25, 0x11, 0x25, 0x15,
+	0x25, 0x13, 0x01, 0xa5, 0x19, 0xfe, 0xa0, 0x01, 0x60, 0xfe, 0xa0, 0x01, 0x60, 0x19, 0x01, 0x28,
+	0x19, 0x01, 0x60, 0xfe, 0xa0, 0x01, 0x60, 0xfe, 0xa0, 0x19, 0xfe, 0xd8, 0x01, 0xfa, 0x19, 0xde,
+	0x19, 0x01, 0xa4, 0x19, 0xde, 0x18, 0x01, 0xf9, 0xfe, 0x07, 0x18, 0xde, 0x19, 0xfe, 0x5c, 0x19,
+	0xde, 0x19, 0xfe, 0x06, 0x00, 0x01, 0x00, 0x2e, 0x01, 0xf7, 0x02, 0x9f, 0x04, 0x69, 0x00, 0x0b,
+	0x00, 0x1a, 0x40, 0x17, 0x02, 0x01, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x00, 0x4e,
+	0x01, 0x00, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x03, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x01, 0x61, 0x7c, 0xb7, 0xb8, 0x81, 0x81, 0xb7,
+	0xb9, 0x01, 0xf7, 0xba, 0x7f, 0x81, 0xb8, 0xb8, 0x83, 0x82, 0xb5, 0x00, 0x00, 0x03, 0x00, 0xb5,
+	0x00, 0x00, 0x07, 0x4b, 0x01, 0x41, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x12, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x05, 0x07, 0x03,
+	0x06, 0x05, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x12, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01,
+	0x5f, 0x08, 0x05, 0x07, 0x03, 0x06, 0x05, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08,
+	0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0xb5, 0x01, 0x41, 0x01, 0x6a, 0x01, 0x40, 0x01, 0x6a, 0x01,
+	0x41, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x07, 0x00, 0x17, 0xff, 0xdb, 0x07, 0xea, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x1f,
+	0x00, 0x27, 0x00, 0x33, 0x00, 0x3b, 0x00, 0x3f, 0x01, 0x39, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40,
+	0x32, 0x0f, 0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01,
+	0x07, 0x06, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x0c, 0x01, 0x01, 0x01, 0x38, 0x4d,
+	0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x61, 0x14, 0x0d, 0x12, 0x08, 0x10, 0x05, 0x04, 0x04,
+	0x39, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x3a, 0x0f, 0x01, 0x02, 0x0e, 0x01,
+	0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01, 0x07, 0x06, 0x05, 0x07, 0x69, 0x00,
+	0x0c, 0x0c, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x13, 0x0a,
+	0x11, 0x03, 0x06, 0x06, 0x04, 0x61, 0x12, 0x08, 0x10, 0x03, 0x04, 0x04, 0x39, 0x4d, 0x14, 0x01,
+	0x0d, 0x0d, 0x39, 0x0d, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x3a, 0x00, 0x0c, 0x01,
+	0x0c, 0x85, 0x14, 0x01, 0x0d, 0x04, 0x0d, 0x86, 0x0f, 0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02,
+	0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01, 0x07, 0x06, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x61, 0x12, 0x08,
+	0x10, 0x03, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x38, 0x00, 0x0c, 0x01, 0x0c, 0x85, 0x14,
+	0x01, 0x0d, 0x04, 0x0d, 0x86, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0f, 0x01, 0x02,
+	0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01, 0x07, 0x06, 0x05, 0x07,
+	0x69, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x61, 0x12, 0x08, 0x10, 0x03, 0x04, 0x04, 0x3c,
+	0x04, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x3b, 0x3c, 0x3c, 0x35, 0x34, 0x29, 0x28, 0x21, 0x20, 0x15,
+	0x14, 0x0d, 0x0c, 0x01, 0x00, 0x3c, 0x3f, 0x3c, 0x3f, 0x3e, 0x3d, 0x39, 0x37, 0x34, 0x3b, 0x35,
+	0x3b, 0x2f, 0x2d, 0x28, 0x33, 0x29, 0x33, 0x25, 0x23, 0x20, 0x27, 0x21, 0x27, 0x1b, 0x19, 0x14,
+	0x1f, 0x15, 0x1f, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x15,
+	0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27,
+	0x32, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16,
+	0x15, 0x14, 0x06, 0x27, 0x32, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x05, 0x22, 0x26, 0x35, 0x34,
+	0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x05,
+	0x01, 0x33, 0x01, 0x01, 0x4c, 0x8f, 0xa6, 0xa7, 0x92, 0x93, 0xa5, 0xa8, 0x92, 0x75, 0x73, 0x74,
+	0x02, 0xfe, 0x90, 0xa6, 0xa7, 0x92, 0x92, 0xa7, 0xa8, 0x93, 0x75, 0x73, 0x73, 0x03, 0x44, 0x90,
+	0xa6, 0xa7, 0x92, 0x92, 0xa7, 0xa7, 0x94, 0x76, 0x74, 0x73, 0xf9, 0xe3, 0x04, 0x54, 0x97, 0xfb,
+	0xac, 0x02, 0xe4, 0xc7, 0xac, 0xac, 0xc5, 0xc6, 0xb1, 0xaa, 0xc3, 0x94, 0xdf, 0xdd, 0xde, 0xde,
+	0xfc, 0x88, 0xc7, 0xab, 0xad, 0xc5, 0xc5, 0xac, 0xaf, 0xc4, 0x94, 0xdf, 0xdd, 0xde, 0xde, 0x94,
+	0xc7, 0xab, 0xad, 0xc5, 0xc5, 0xac, 0xaf, 0xc4, 0x94, 0xdf, 0xdd, 0xdd, 0xdf, 0xb9, 0x06, 0x12,
+	0xf9, 0xee, 0x00, 0x00, 0x00, 0x01, 0x00, 0x32, 0x03, 0xdb, 0x01, 0xb8, 0x06, 0x2b, 0x00, 0x03,
+	0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x13, 0x21, 0x03, 0x32,
+	0x76, 0x01, 0x10, 0xd9, 0x03, 0xdb, 0x02, 0x50, 0xfd, 0xb0, 0x00, 0x00, 0x00, 0x02, 0x00, 0x49,
+	0x03, 0xdb, 0x03, 0x8c, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x05, 0x03,
+	0x04, 0x03, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x04, 0x04, 0x00,
+	0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b,
+	0x13, 0x13, 0x21, 0x03, 0x21, 0x13, 0x21, 0x03, 0x49, 0x77, 0x01, 0x10, 0xda, 0x01, 0x0f, 0x78,
+	0x01, 0x0f, 0xda, 0x03, 0xdb, 0x02, 0x50, 0xfd, 0xb0, 0x02, 0x50, 0xfd, 0xb0, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x3e, 0x00, 0x69, 0x02, 0x69, 0x03, 0xe1, 0x00, 0x05, 0x00, 0x06, 0xb3, 0x05,
+	0x03, 0x01, 0x32, 0x2b, 0x09, 0x02, 0x07, 0x01, 0x01, 0x02, 0x69, 0xfe, 0xfa, 0x01, 0x06, 0x8b,
+	0xfe, 0x60, 0x01, 0xa0, 0x03, 0x78, 0xfe, 0xad, 0xfe, 0xad, 0x69, 0x01, 0xbc, 0x01, 0xbc, 0x00,
+	0x00, 0x01, 0x00, 0x41, 0x00, 0x69, 0x02, 0x6c, 0x03, 0xe1, 0x00, 0x05, 0x00, 0x06, 0xb3, 0x05,
+	0x03, 0x01, 0x32, 0x2b, 0x37, 0x01, 0x01, 0x37, 0x01, 0x01, 0x41, 0x01, 0x06, 0xfe, 0xfa, 0x8b,
+	0x01, 0xa0, 0xfe, 0x60, 0xd2, 0x01, 0x53, 0x01, 0x53, 0x69, 0xfe, 0x44, 0xfe, 0x44, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0xb4, 0x00, 0x00, 0x04, 0x2b, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0d,
+	0x00, 0x13, 0x00, 0x68, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x1d, 0x0b, 0x07, 0x09, 0x03, 0x03,
+	0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x0a,
+	0x05, 0x08, 0x03, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x06, 0x01, 0x02, 0x0b, 0x07,
+	0x09, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x04, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x0a, 0x05, 0x08,
+	0x03, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x22, 0x0e, 0x0e, 0x0a, 0x0a, 0x04, 0x04, 0x00,
+	0x00, 0x0e, 0x13, 0x0e, 0x13, 0x11, 0x10, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x04, 0x09, 0x04,
+	0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0c, 0x09, 0x17, 0x2b, 0x33, 0x35, 0x21, 0x15,
+	0x03, 0x03, 0x11, 0x21, 0x11, 0x03, 0x01, 0x35, 0x21, 0x15, 0x03, 0x03, 0x11, 0x21, 0x11, 0x03,
+	0xb4, 0x01, 0x28, 0xf7, 0x31, 0x01, 0x28, 0x31, 0x01, 0x58, 0x01, 0x28, 0xf6, 0x32, 0x01, 0x28,
+	0x31, 0xf7, 0xf7, 0x01, 0xa3, 0x02, 0xfd, 0x01, 0x28, 0xfe, 0xd8, 0xfd, 0x03, 0xfe, 0x5d, 0xf7,
+	0xf7, 0x01, 0xa3, 0x02, 0xfd, 0x01, 0x28, 0xfe, 0xd8, 0xfd, 0x03, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x06, 0x44, 0x02, 0xaa, 0x06, 0xf3, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44,
+	0x11, 0x35, 0x21, 0x15, 0x02, 0xaa, 0x06, 0x44, 0xaf, 0xaf, 0x00, 0x00, 0x00, 0x01, 0xfe, 0x3c,
+	0xff, 0xdb, 0x03, 0x1c, 0x05, 0xed, 0x00, 0x03, 0x00, 0x2e, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0a,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x05, 0x01, 0x33, 0x01, 0xfe, 0x3c, 0x04, 0x40,
+	0xa0, 0xfb, 0xc0, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x00, 0x03, 0x00, 0x3c, 0x02, 0x9f, 0x03, 0x1b,
+	0x06, 0x43, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x19, 0x00, 0x3b, 0x40, 0x38, 0x18, 0x17, 0x11, 0x10,
+	0x04, 0x02, 0x03, 0x01, 0x4c, 0x06, 0x01, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x56, 0x4d,
+	0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x57, 0x00, 0x4e, 0x14, 0x13, 0x0d,
+	0x0c, 0x01, 0x00, 0x13, 0x19, 0x14, 0x19, 0x0c, 0x12, 0x0d, 0x12, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x07, 0x0b, 0x16, 0x2b, 0x01, 0x22, 0x02, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x06, 0x27, 0x32, 0x11, 0x34, 0x27, 0x01, 0x16, 0x13, 0x22, 0x11, 0x14, 0x17, 0x01, 0x26, 0x01,
+	0xab, 0xa7, 0xc8, 0xc9, 0xa6, 0xa6, 0xca, 0xc9, 0xa7, 0x9e, 0x03, 0xfe, 0xd4, 0x1f, 0x72, 0x9d,
+	0x02, 0x01, 0x2c, 0x20, 0x02, 0x9f, 0x01, 0x00, 0xd2, 0xd3, 0xff, 0xfe, 0xd4, 0xd5, 0xfd, 0x6e,
+	0x01, 0x64, 0x30, 0x2a, 0xfe, 0xfc, 0xba, 0x02, 0xc7, 0xfe, 0x9d, 0x30, 0x29, 0x01, 0x04, 0xb8,
+	0x00, 0x02, 0x00, 0x17, 0x02, 0xb5, 0x03, 0x21, 0x06, 0x2d, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x33,
+	0x40, 0x30, 0x0d, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x01, 0x01, 0x4b, 0x05, 0x01, 0x01,
+	0x06, 0x04, 0x02, 0x02, 0x03, 0x01, 0x02, 0x68, 0x00, 0x00, 0x00, 0x54, 0x4d, 0x00, 0x03, 0x03,
+	0x55, 0x03, 0x4e, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x11, 0x11, 0x12, 0x07,
+	0x0b, 0x1a, 0x2b, 0x13, 0x35, 0x01, 0x33, 0x11, 0x33, 0x15, 0x23, 0x15, 0x23, 0x35, 0x25, 0x21,
+	0x11, 0x17, 0x01, 0xd8, 0xcc, 0x66, 0x66, 0xc3, 0xfe, 0xd1, 0x01, 0x34, 0x03, 0xa2, 0x85, 0x02,
+	0x06, 0xfd, 0xfa, 0x85, 0xed, 0xed, 0x85, 0x01, 0x5c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6c,
+	0x02, 0x9f, 0x02, 0xfa, 0x06, 0x2d, 0x00, 0x21, 0x00, 0x33, 0x40, 0x30, 0x01, 0x01, 0x00, 0x01,
+	0x00, 0x01, 0x05, 0x00, 0x02, 0x4c, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x03,
+	0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x54, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x57, 0x05, 0x4e, 0x28, 0x21, 0x11, 0x11, 0x28, 0x23, 0x06, 0x0b, 0x1c, 0x2b, 0x13, 0x35, 0x16,
+	0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x11, 0x21, 0x15, 0x21, 0x15,
+	0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x6c, 0x38, 0x6b, 0x3b, 0x2d,
+	0x48, 0x33, 0x1b, 0x24, 0x4b, 0x74, 0x4f, 0x5c, 0x02, 0x64, 0xfe, 0x3a, 0x18, 0x5e, 0xa5, 0x7a,
+	0x48, 0x45, 0x73, 0x94, 0x4f, 0x31, 0x79, 0x02, 0xb8, 0x81, 0x16, 0x16, 0x1b, 0x2d, 0x3b, 0x21,
+	0x31, 0x45, 0x2c, 0x14, 0x01, 0xc6, 0x8d, 0xcc, 0x1d, 0x42, 0x6a, 0x4d, 0x46, 0x6c, 0x48, 0x25,
+	0x0c, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x27, 0x02, 0x9f, 0x03, 0x1b, 0x06, 0x43, 0x00, 0x16,
+	0x00, 0x20, 0x00, 0x37, 0x40, 0x34, 0x00, 0x01, 0x00, 0x03, 0x01, 0x01, 0x01, 0x00, 0x06, 0x01,
+	0x04, 0x01, 0x03, 0x4c, 0x00, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x69, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x56, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x57, 0x02,
+	0x4e, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06, 0x0b, 0x1c, 0x2b, 0x01, 0x15, 0x26, 0x23, 0x22,
+	0x06, 0x15, 0x17, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x00,
+	0x33, 0x32, 0x03, 0x34, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x02, 0xe5, 0x90, 0x46,
+	0x79, 0x87, 0x01, 0x5c, 0x7d, 0x8d, 0xa5, 0xb7, 0xac, 0xbd, 0xd4, 0x01, 0x01, 0xda, 0x5f, 0x12,
+	0x90, 0x4b, 0x5a, 0x58, 0x4b, 0x92, 0x06, 0x27, 0x81, 0x2e, 0x9e, 0x8e, 0x0f, 0x57, 0x94, 0x7f,
+	0x99, 0xa5, 0xe8, 0xcf, 0xe2, 0x01, 0x0b, 0xfd, 0x86, 0xba, 0x65, 0x53, 0x58, 0x66, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x54, 0x02, 0xb5, 0x03, 0x14, 0x06, 0x2d, 0x00, 0x0a, 0x00, 0x24, 0x40, 0x21,
+	0x08, 0x01, 0x00, 0x01, 0x4b, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x54, 0x4d, 0x03,
+	0x01, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x14, 0x04, 0x0b,
+	0x18, 0x2b, 0x13, 0x36, 0x36, 0x37, 0x37, 0x21, 0x35, 0x21, 0x15, 0x00, 0x03, 0x84, 0x0f, 0x78,
+	0xa4, 0xaf, 0xfd, 0xf6, 0x02, 0xc0, 0xfe, 0x77, 0x11, 0x02, 0xb5, 0x60, 0xd0, 0xd4, 0xe4, 0x90,
+	0x90, 0xfe, 0x45, 0xfe, 0xd3, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x40, 0x02, 0x9f, 0x03, 0x28,
+	0x06, 0x43, 0x00, 0x16, 0x00, 0x20, 0x00, 0x2b, 0x00, 0x25, 0x40, 0x22, 0x0b, 0x01, 0x03, 0x02,
+	0x01, 0x4c, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x56, 0x4d, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x57, 0x01, 0x4e, 0x28, 0x28, 0x29, 0x25, 0x04, 0x0b, 0x1a, 0x2b, 0x01,
+	0x26, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16, 0x16, 0x15, 0x14, 0x06,
+	0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x25, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x17, 0x16,
+	0x07, 0x06, 0x15, 0x14, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x27, 0x01, 0x0e, 0x56, 0x3a, 0xae,
+	0x98, 0x8c, 0xa6, 0xa9, 0x7d, 0x5e, 0xd6, 0xaa, 0xa6, 0xc2, 0x5b, 0x01, 0x3f, 0x5c, 0x7a, 0x7e,
+	0x74, 0x0d, 0x42, 0x6a, 0xac, 0x46, 0x5a, 0x31, 0x60, 0x04, 0x93, 0x39, 0x52, 0x42, 0x6a, 0x79,
+	0x6d, 0x5d, 0x7f, 0x5e, 0x40, 0x69, 0x4b, 0x75, 0x94, 0x81, 0x6f, 0x4f, 0x72, 0x7f, 0x38, 0x5c,
+	0x71, 0x62, 0x43, 0x46, 0x09, 0x88, 0x50, 0x68, 0x94, 0x43, 0x35, 0x31, 0x37, 0x3a, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x3b, 0x02, 0x9f, 0x03, 0x30, 0x06, 0x43, 0x00, 0x16, 0x00, 0x20, 0x00, 0x37,
+	0x40, 0x34, 0x06, 0x01, 0x01, 0x04, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c,
+	0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x56, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x57, 0x03, 0x4e, 0x24, 0x22, 0x24,
+	0x24, 0x24, 0x22, 0x06, 0x0b, 0x1c, 0x2b, 0x13, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x35, 0x06,
+	0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x00, 0x23, 0x22, 0x13, 0x14,
+	0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x71, 0x91, 0x45, 0x7a, 0x86, 0x5d, 0x7d, 0x8d,
+	0xa5, 0xb8, 0xab, 0xbd, 0xd5, 0xfe, 0xfe, 0xd9, 0x60, 0x12, 0x90, 0x4b, 0x5b, 0x59, 0x4b, 0x92,
+	0x02, 0xba, 0x82, 0x2f, 0x9e, 0x8e, 0x0f, 0x57, 0x95, 0x7f, 0x99, 0xa5, 0xe8, 0xcf, 0xe2, 0xfe,
+	0xf5, 0x02, 0x7a, 0xbb, 0x65, 0x54, 0x57, 0x66, 0x00, 0x01, 0x00, 0x4e, 0x02, 0xf0, 0x03, 0x32,
+	0x05, 0x40, 0x00, 0x0b, 0x00, 0x2c, 0x40, 0x29, 0x00, 0x02, 0x01, 0x05, 0x02, 0x57, 0x03, 0x01,
+	0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x05, 0x5f, 0x06, 0x01, 0x05,
+	0x02, 0x05, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x0b,
+	0x1b, 0x2b, 0x01, 0x35, 0x21, 0x35, 0x21, 0x35, 0x33, 0x15, 0x21, 0x15, 0x21, 0x15, 0x01, 0x77,
+	0xfe, 0xd7, 0x01, 0x29, 0x92, 0x01, 0x29, 0xfe, 0xd7, 0x02, 0xf0, 0xee, 0x75, 0xed, 0xed, 0x75,
+	0xee, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4e, 0x03, 0xe4, 0x03, 0x32, 0x04, 0x4c, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x0b, 0x17, 0x2b,
+	0x13, 0x35, 0x21, 0x15, 0x4e, 0x02, 0xe4, 0x03, 0xe4, 0x68, 0x68, 0x00, 0x00, 0x02, 0x00, 0x4e,
+	0x03, 0x56, 0x03, 0x32, 0x04, 0xcf, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02,
+	0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x0b, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x01,
+	0x35, 0x21, 0x15, 0x4e, 0x02, 0xe4, 0xfd, 0x1c, 0x02, 0xe4, 0x03, 0x56, 0x7f, 0x7f, 0x01, 0x04,
+	0x75, 0x75, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa4, 0x01, 0xfd, 0x02, 0x36, 0x06, 0x6f, 0x00, 0x0b,
+	0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x15, 0x06, 0x11, 0x10, 0x17, 0x15, 0x26,
+	0x02, 0x35, 0x34, 0x12, 0x02, 0x36, 0xc7, 0xc7, 0xb6, 0xdc, 0xd8, 0x06, 0x6f, 0x72, 0xa1, 0xfe,
+	0xd9, 0xfe, 0xdb, 0xa1, 0x72, 0x4e, 0x01, 0x38, 0xb3, 0xb3, 0x01, 0x33, 0x00, 0x01, 0x00, 0x92,
+	0x01, 0xfd, 0x02, 0x25, 0x06, 0x6f, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b,
+	0x13, 0x35, 0x36, 0x11, 0x10, 0x27, 0x35, 0x16, 0x12, 0x15, 0x14, 0x02, 0x92, 0xc7, 0xc7, 0xb7,
+	0xdc, 0xd9, 0x01, 0xfd, 0x72, 0xa1, 0x01, 0x24, 0x01, 0x28, 0xa1, 0x72, 0x4e, 0xfe, 0xc8, 0xb4,
+	0xb3, 0xfe, 0xcd, 0x00, 0x00, 0x01, 0x00, 0x6f, 0x02, 0xb5, 0x03, 0x45, 0x05, 0x56, 0x00, 0x10,
+	0x00, 0x50, 0x40, 0x0a, 0x03, 0x01, 0x03, 0x00, 0x0f, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x22, 0x50, 0x58, 0x40, 0x14, 0x00, 0x03, 0x02, 0x00, 0x03, 0x59, 0x01, 0x01, 0x00, 0x00, 0x02,
+	0x5f, 0x05, 0x04, 0x02, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x03,
+	0x02, 0x01, 0x03, 0x69, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02, 0x02, 0x02, 0x55, 0x02,
+	0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x0b,
+	0x1a, 0x2b, 0x13, 0x11, 0x33, 0x15, 0x36, 0x33, 0x32, 0x15, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23,
+	0x22, 0x07, 0x11, 0x6f, 0xde, 0x7e, 0x99, 0xe1, 0xde, 0x27, 0x33, 0x5a, 0x66, 0x02, 0xb5, 0x02,
+	0x92, 0x6d, 0x7c, 0xd0, 0xfe, 0x2f, 0x01, 0xa5, 0x41, 0x30, 0x69, 0xfe, 0x53, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x3c, 0xfe, 0xb6, 0x03, 0x1b, 0x02, 0x5a, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x19,
+	0x00, 0x3b, 0x40, 0x38, 0x18, 0x17, 0x11, 0x10, 0x04, 0x02, 0x03, 0x01, 0x4c, 0x06, 0x01, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01,
+	0x00, 0x00, 0x4d, 0x00, 0x4e, 0x14, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x19, 0x14, 0x19, 0x0c,
+	0x12, 0x0d, 0x12, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x07, 0x0a, 0x16, 0x2b, 0x01, 0x22, 0x02,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x11, 0x34, 0x27, 0x01, 0x16,
+	0x13, 0x22, 0x11, 0x14, 0x17, 0x01, 0x26, 0x01, 0xab, 0xa7, 0xc8, 0xc9, 0xa6, 0xa6, 0xca, 0xc9,
+	0xa7, 0x9e, 0x03, 0xfe, 0xd4, 0x1f, 0x72, 0x9d, 0x02, 0x01, 0x2c, 0x20, 0xfe, 0xb6, 0x01, 0x00,
+	0xd2, 0xd3, 0xff, 0xfe, 0xd4, 0xd5, 0xfd, 0x6e, 0x01, 0x64, 0x30, 0x2a, 0xfe, 0xfc, 0xba, 0x02,
+	0xc7, 0xfe, 0x9d, 0x30, 0x29, 0x01, 0x04, 0xb8, 0x00, 0x01, 0x00, 0x88, 0xfe, 0xcc, 0x03, 0x22,
+	0x02, 0x5a, 0x00, 0x09, 0x00, 0x22, 0x40, 0x1f, 0x06, 0x05, 0x04, 0x03, 0x04, 0x00, 0x4a, 0x01,
+	0x01, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x09,
+	0x00, 0x09, 0x15, 0x11, 0x04, 0x0a, 0x18, 0x2b, 0x13, 0x35, 0x33, 0x11, 0x07, 0x35, 0x25, 0x11,
+	0x33, 0x15, 0x88, 0xde, 0xde, 0x01, 0xbc, 0xde, 0xfe, 0xcc, 0x67, 0x02, 0x90, 0x2d, 0x6b, 0x59,
+	0xfc, 0xd9, 0x67, 0x00, 0x00, 0x01, 0x00, 0x39, 0xfe, 0xcc, 0x02, 0xf5, 0x02, 0x5a, 0x00, 0x1a,
+	0x00, 0x34, 0x40, 0x31, 0x0d, 0x01, 0x00, 0x01, 0x0c, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x01, 0x01,
+	0x02, 0x01, 0x4b, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4c, 0x4d, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x49, 0x03, 0x4e, 0x00, 0x00, 0x00, 0x1a, 0x00, 0x1a, 0x18,
+	0x23, 0x29, 0x05, 0x0a, 0x19, 0x2b, 0x13, 0x35, 0x36, 0x3f, 0x02, 0x36, 0x36, 0x35, 0x34, 0x23,
+	0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x15,
+	0x39, 0x3d, 0x59, 0x4c, 0x59, 0x5d, 0x38, 0xa5, 0x6b, 0xa3, 0xa9, 0x8a, 0xaa, 0xc2, 0x5e, 0x79,
+	0x4a, 0x90, 0x0f, 0x01, 0xbc, 0xfe, 0xcc, 0x8c, 0x56, 0x48, 0x3f, 0x48, 0x4d, 0x53, 0x40, 0x8a,
+	0x42, 0x82, 0x33, 0x86, 0x76, 0x4c, 0x7b, 0x53, 0x32, 0x62, 0x58, 0x8c, 0x00, 0x01, 0x00, 0x66,
+	0xfe, 0xb6, 0x02, 0xfd, 0x02, 0x5a, 0x00, 0x1f, 0x00, 0x3f, 0x40, 0x3c, 0x12, 0x01, 0x03, 0x04,
+	0x11, 0x01, 0x02, 0x03, 0x19, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x05, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x4c, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x4d, 0x05, 0x4e, 0x28,
+	0x23, 0x23, 0x11, 0x23, 0x22, 0x06, 0x0a, 0x1c, 0x2b, 0x13, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34,
+	0x26, 0x23, 0x23, 0x35, 0x32, 0x36, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16,
+	0x15, 0x14, 0x07, 0x04, 0x15, 0x14, 0x06, 0x23, 0x22, 0x66, 0xa7, 0x4f, 0xba, 0x81, 0xa2, 0x26,
+	0xa8, 0x83, 0xa2, 0x77, 0x6a, 0x7b, 0x94, 0xa3, 0xb3, 0xf5, 0x01, 0x18, 0xdc, 0xb5, 0x7c, 0xfe,
+	0xd2, 0x85, 0x33, 0x91, 0x64, 0x51, 0x6a, 0x43, 0x56, 0x7e, 0x32, 0x79, 0x28, 0x70, 0x65, 0x9c,
+	0x41, 0x34, 0xbc, 0x74, 0x8e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x17, 0xfe, 0xcc, 0x03, 0x21,
+	0x02, 0x44, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x33, 0x40, 0x30, 0x0d, 0x01, 0x01, 0x00, 0x01, 0x4c,
+	0x01, 0x01, 0x01, 0x01, 0x4b, 0x05, 0x01, 0x01, 0x06, 0x04, 0x02, 0x02, 0x03, 0x01, 0x02, 0x68,
+	0x00, 0x00, 0x00, 0x48, 0x4d, 0x00, 0x03, 0x03, 0x49, 0x03, 0x4e, 0x00, 0x00, 0x0c, 0x0b, 0x00,
+	0x0a, 0x00, 0x0a, 0x11, 0x11, 0x11, 0x12, 0x07, 0x0a, 0x1a, 0x2b, 0x17, 0x35, 0x01, 0x33, 0x11,
+	0x33, 0x15, 0x23, 0x15, 0x23, 0x35, 0x25, 0x21, 0x11, 0x17, 0x01, 0xd8, 0xcc, 0x66, 0x66, 0xc3,
+	0xfe, 0xd1, 0x01, 0x34, 0x47, 0x85, 0x02, 0x06, 0xfd, 0xfa, 0x85, 0xed, 0xed, 0x85, 0x01, 0x5c,
+	0x00, 0x01, 0x00, 0x6c, 0xfe, 0xb6, 0x02, 0xfa, 0x02, 0x44, 0x00, 0x21, 0x00, 0x33, 0x40, 0x30,
+	0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00, 0x02, 0x4c, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04,
+	0x01, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x48, 0x4d, 0x00, 0x00, 0x00, 0x05,
+	0x61, 0x00, 0x05, 0x05, 0x4d, 0x05, 0x4e, 0x28, 0x21, 0x11, 0x11, 0x28, 0x23, 0x06, 0x0a, 0x1c,
+	0x2b, 0x13, 0x35, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x11,
+	0x21, 0x15, 0x21, 0x15, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x6c,
+	0x38, 0x6b, 0x3b, 0x2d, 0x48, 0x33, 0x1b, 0x24, 0x4b, 0x74, 0x4f, 0x5c, 0x02, 0x64, 0xfe, 0x3a,
+	0x18, 0x5e, 0xa5, 0x7a, 0x48, 0x45, 0x73, 0x94, 0x4f, 0x31, 0x79, 0xfe, 0xcf, 0x81, 0x16, 0x16,
+	0x1b, 0x2d, 0x3b, 0x21, 0x31, 0x45, 0x2c, 0x14, 0x01, 0xc6, 0x8d, 0xcc, 0x1d, 0x42, 0x6a, 0x4d,
+	0x46, 0x6c, 0x48, 0x25, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x27, 0xfe, 0xb6, 0x03, 0x1b,
+	0x02, 0x5a, 0x00, 0x16, 0x00, 0x20, 0x00, 0x37, 0x40, 0x34, 0x00, 0x01, 0x00, 0x03, 0x01, 0x01,
+	0x01, 0x00, 0x06, 0x01, 0x04, 0x01, 0x03, 0x4c, 0x00, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x69,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x4c, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x4d, 0x02, 0x4e, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06, 0x0a, 0x1c, 0x2b, 0x01,
+	0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x17, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22,
+	0x26, 0x35, 0x34, 0x00, 0x33, 0x32, 0x03, 0x34, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32,
+	0x02, 0xe5, 0x90, 0x46, 0x79, 0x87, 0x01, 0x5c, 0x7d, 0x8d, 0xa5, 0xb7, 0xac, 0xbd, 0xd4, 0x01,
+	0x01, 0xda, 0x5f, 0x12, 0x90, 0x4b, 0x5a, 0x58, 0x4b, 0x92, 0x02, 0x3e, 0x81, 0x2e, 0x9e, 0x8e,
+	0x0f, 0x57, 0x94, 0x7f, 0x99, 0xa5, 0xe8, 0xcf, 0xe2, 0x01, 0x0b, 0xfd, 0x86, 0xba, 0x65, 0x53,
+	0x58, 0x66, 0x00, 0x00, 0x00, 0x01, 0x00, 0x54, 0xfe, 0xcc, 0x03, 0x14, 0x02, 0x44, 0x00, 0x0a,
+	0x00, 0x24, 0x40, 0x21, 0x08, 0x01, 0x00, 0x01, 0x4b, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01,
+	0x01, 0x48, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a,
+	0x11, 0x14, 0x04, 0x0a, 0x18, 0x2b, 0x13, 0x36, 0x36, 0x37, 0x37, 0x21, 0x35, 0x21, 0x15, 0x00,
+	0x03, 0x84, 0x0f, 0x78, 0xa4, 0xaf, 0xfd, 0xf6, 0x02, 0xc0, 0xfe, 0x77, 0x11, 0xfe, 0xcc, 0x60,
+	0xd0, 0xd4, 0xe4, 0x90, 0x90, 0xfe, 0x45, 0xfe, 0xd3, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x40,
+	0xfe, 0xb6, 0x03, 0x28, 0x02, 0x5a, 0x00, 0x16, 0x00, 0x20, 0x00, 0x2b, 0x00, 0x25, 0x40, 0x22,
+	0x0b, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x4c, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4d, 0x01, 0x4e, 0x28, 0x28, 0x29, 0x25, 0x04,
+	0x0a, 0x1a, 0x2b, 0x25, 0x26, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16,
+	0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x25, 0x36, 0x35, 0x34, 0x23, 0x22,
+	0x15, 0x14, 0x17, 0x16, 0x07, 0x06, 0x15, 0x14, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x27, 0x01,
+	0x0e, 0x56, 0x3a, 0xae, 0x98, 0x8c, 0xa6, 0xa9, 0x7d, 0x5e, 0xd6, 0xaa, 0xa6, 0xc2, 0x5b, 0x01,
+	0x3f, 0x5c, 0x7a, 0x7e, 0x74, 0x0d, 0x42, 0x6a, 0xac, 0x46, 0x5a, 0x31, 0x60, 0xaa, 0x39, 0x52,
+	0x42, 0x6a, 0x79, 0x6d, 0x5d, 0x7f, 0x5e, 0x40, 0x69, 0x4b, 0x75, 0x94, 0x81, 0x6f, 0x4f, 0x72,
+	0x7f, 0x38, 0x5c, 0x71, 0x62, 0x43, 0x46, 0x09, 0x88, 0x50, 0x68, 0x94, 0x43, 0x35, 0x31, 0x37,
+	0x3a, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3b, 0xfe, 0xb6, 0x03, 0x30, 0x02, 0x5a, 0x00, 0x16,
+	0x00, 0x20, 0x00, 0x37, 0x40, 0x34, 0x06, 0x01, 0x01, 0x04, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01,
+	0x03, 0x00, 0x03, 0x4c, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x4c, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x4d, 0x03,
+	0x4e, 0x24, 0x22, 0x24, 0x24, 0x24, 0x22, 0x06, 0x0a, 0x1c, 0x2b, 0x13, 0x35, 0x16, 0x33, 0x32,
+	0x36, 0x35, 0x35, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x00,
+	0x23, 0x22, 0x13, 0x14, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x71, 0x91, 0x45, 0x7a,
+	0x86, 0x5d, 0x7d, 0x8d, 0xa5, 0xb8, 0xab, 0xbd, 0xd5, 0xfe, 0xfe, 0xd9, 0x60, 0x12, 0x90, 0x4b,
+	0x5b, 0x59, 0x4b, 0x92, 0xfe, 0xd1, 0x82, 0x2f, 0x9e, 0x8e, 0x0f, 0x57, 0x95, 0x7f, 0x99, 0xa5,
+	0xe8, 0xcf, 0xe2, 0xfe, 0xf5, 0x02, 0x7a, 0xbb, 0x65, 0x54, 0x57, 0x66, 0x00, 0x01, 0x00, 0x4e,
+	0xff, 0x07, 0x03, 0x32, 0x01, 0x57, 0x00, 0x0b, 0x00, 0x4d, 0x4b, 0xb0, 0x2f, 0x50, 0x58, 0x40,
+	0x16, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x06, 0x01, 0x05, 0x05, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x4a, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x02, 0x01, 0x05, 0x02, 0x57,
+	0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x05, 0x5f, 0x06,
+	0x01, 0x05, 0x02, 0x05, 0x4f, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x07, 0x0a, 0x1b, 0x2b, 0x05, 0x35, 0x21, 0x35, 0x21, 0x35, 0x33, 0x15, 0x21,
+	0x15, 0x21, 0x15, 0x01, 0x77, 0xfe, 0xd7, 0x01, 0x29, 0x92, 0x01, 0x29, 0xfe, 0xd7, 0xf9, 0xee,
+	0x75, 0xed, 0xed, 0x75, 0xee, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4e, 0xff, 0xfb, 0x03, 0x32,
+	0x00, 0x63, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x0a, 0x17, 0x2b, 0x17, 0x35, 0x21, 0x15, 0x4e, 0x02, 0xe4, 0x05, 0x68, 0x68, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x4e, 0xff, 0x6d, 0x03, 0x32, 0x00, 0xe6, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f,
+	0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00,
+	0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x0a, 0x17, 0x2b, 0x17,
+	0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x4e, 0x02, 0xe4, 0xfd, 0x1c, 0x02, 0xe4, 0x93, 0x7f,
+	0x7f, 0x01, 0x04, 0x75, 0x75, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa4, 0xfe, 0x14, 0x02, 0x36,
+	0x02, 0x86, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x06, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x15, 0x06, 0x11,
+	0x10, 0x17, 0x15, 0x26, 0x02, 0x35, 0x34, 0x12, 0x02, 0x36, 0xc7, 0xc7, 0xb6, 0xdc, 0xd8, 0x02,
+	0x86, 0x72, 0xa1, 0xfe, 0xd9, 0xfe, 0xdb, 0xa1, 0x72, 0x4e, 0x01, 0x38, 0xb3, 0xb3, 0x01, 0x33,
+	0x00, 0x01, 0x00, 0x92, 0xfe, 0x14, 0x02, 0x25, 0x02, 0x86, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x06,
+	0x00, 0x01, 0x32, 0x2b, 0x13, 0x35, 0x36, 0x11, 0x10, 0x27, 0x35, 0x16, 0x12, 0x15, 0x14, 0x02,
+	0x92, 0xc7, 0xc7, 0xb7, 0xdc, 0xd9, 0xfe, 0x14, 0x72, 0xa1, 0x01, 0x24, 0x01, 0x28, 0xa1, 0x72,
+	0x4e, 0xfe, 0xc8, 0xb4, 0xb3, 0xfe, 0xcd, 0x00, 0x00, 0x01, 0x00, 0x6f, 0xfe, 0xcc, 0x03, 0x45,
+	0x01, 0x6d, 0x00, 0x10, 0x00, 0x51, 0x40, 0x0a, 0x03, 0x01, 0x03, 0x00, 0x0f, 0x01, 0x02, 0x03,
+	0x02, 0x4c, 0x4b, 0xb0, 0x20, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x4a, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00,
+	0x00, 0x00, 0x4a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4a, 0x4d, 0x05, 0x04,
+	0x02, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23,
+	0x12, 0x22, 0x11, 0x06, 0x0a, 0x1a, 0x2b, 0x13, 0x11, 0x33, 0x15, 0x36, 0x33, 0x32, 0x15, 0x11,
+	0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x6f, 0xde, 0x7e, 0x99, 0xe1, 0xde, 0x27, 0x33,
+	0x5a, 0x66, 0xfe, 0xcc, 0x02, 0x92, 0x6d, 0x7c, 0xd0, 0xfe, 0x2f, 0x01, 0xa5, 0x41, 0x30, 0x69,
+	0xfe, 0x53, 0x00, 0x00, 0x00, 0x01, 0x00, 0x3c, 0x00, 0x00, 0x04, 0x4b, 0x05, 0xc8, 0x00, 0x15,
+	0x00, 0xf0, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0f, 0x0b, 0x01, 0x02, 0x01, 0x07, 0x01, 0x04,
+	0x02, 0x10, 0x0c, 0x02, 0x05, 0x04, 0x03, 0x4c, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x0f,
+	0x0b, 0x01, 0x02, 0x03, 0x07, 0x01, 0x04, 0x02, 0x10, 0x0c, 0x02, 0x05, 0x04, 0x03, 0x4c, 0x1b,
+	0x40, 0x0f, 0x0b, 0x01, 0x02, 0x03, 0x07, 0x01, 0x04, 0x06, 0x10, 0x0c, 0x02, 0x05, 0x04, 0x03,
+	0x4c, 0x59, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1c, 0x03, 0x01, 0x02, 0x06, 0x01, 0x04,
+	0x05, 0x02, 0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x07,
+	0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x21, 0x00, 0x03,
+	0x02, 0x04, 0x03, 0x59, 0x00, 0x02, 0x06, 0x01, 0x04, 0x05, 0x02, 0x04, 0x69, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x22, 0x00, 0x02, 0x00, 0x06, 0x04, 0x02, 0x06, 0x67, 0x00,
+	0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38,
+	0x4d, 0x08, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x00, 0x00, 0x01,
+	0x03, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x06, 0x04, 0x02, 0x06, 0x67, 0x00, 0x03, 0x00, 0x04,
+	0x05, 0x03, 0x04, 0x69, 0x08, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x40,
+	0x10, 0x00, 0x00, 0x00, 0x15, 0x00, 0x15, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x11, 0x09, 0x09,
+	0x1d, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x36, 0x33, 0x32, 0x17, 0x11, 0x26,
+	0x23, 0x22, 0x07, 0x11, 0x23, 0x11, 0x23, 0x11, 0x3c, 0x03, 0x53, 0xfd, 0xa7, 0x01, 0xf4, 0x47,
+	0xa7, 0x18, 0x1b, 0x44, 0x26, 0x66, 0x51, 0xfa, 0xfa, 0x05, 0xc8, 0xcb, 0xfe, 0x63, 0xcf, 0xe7,
+	0x06, 0xfe, 0xfe, 0x12, 0xb3, 0xfe, 0x31, 0x02, 0x94, 0xfd, 0x6c, 0x00, 0x00, 0x01, 0x00, 0x6f,
+	0x00, 0x00, 0x04, 0x0f, 0x05, 0xee, 0x00, 0x22, 0x00, 0x87, 0x40, 0x0f, 0x11, 0x01, 0x05, 0x04,
+	0x12, 0x01, 0x03, 0x05, 0x02, 0x4c, 0x01, 0x01, 0x0a, 0x01, 0x4b, 0x4b, 0xb0, 0x2b, 0x50, 0x58,
+	0x40, 0x2a, 0x06, 0x01, 0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02, 0x67, 0x08, 0x01, 0x01, 0x09,
+	0x01, 0x00, 0x0a, 0x01, 0x00, 0x67, 0x00, 0x05, 0x05, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d,
+	0x00, 0x0a, 0x0a, 0x0b, 0x5f, 0x0c, 0x01, 0x0b, 0x0b, 0x39, 0x0b, 0x4e, 0x1b, 0x40, 0x28, 0x00,
+	0x04, 0x00, 0x05, 0x03, 0x04, 0x05, 0x69, 0x06, 0x01, 0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02,
+	0x67, 0x08, 0x01, 0x01, 0x09, 0x01, 0x00, 0x0a, 0x01, 0x00, 0x67, 0x00, 0x0a, 0x0a, 0x0b, 0x5f,
+	0x0c, 0x01, 0x0b, 0x0b, 0x3c, 0x0b, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x00, 0x22, 0x00, 0x22,
+	0x21, 0x20, 0x1e, 0x1d, 0x11, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x11, 0x15, 0x0d, 0x09, 0x1f,
+	0x2b, 0x33, 0x35, 0x36, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x35, 0x23, 0x35, 0x33, 0x35, 0x10,
+	0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x15, 0x33, 0x15, 0x23, 0x15, 0x33, 0x15, 0x23,
+	0x06, 0x07, 0x21, 0x15, 0x6f, 0x6e, 0x57, 0xc5, 0xc5, 0xc5, 0xc5, 0x01, 0xc1, 0x78, 0x93, 0x78,
+	0x6f, 0xbd, 0xd8, 0xd8, 0xd8, 0xd8, 0x2c, 0xac, 0x02, 0x8b, 0xea, 0x1a, 0x7d, 0x83, 0x18, 0x94,
+	0xc6, 0x94, 0x12, 0x01, 0xd2, 0x18, 0xcb, 0x29, 0xd6, 0x54, 0x94, 0xc6, 0x94, 0xbe, 0x74, 0xea,
+	0x00, 0x04, 0x00, 0x3d, 0xff, 0xe7, 0x08, 0x8e, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x15, 0x00, 0x2a,
+	0x00, 0x49, 0x01, 0xff, 0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0x19, 0x21, 0x20, 0x02, 0x07, 0x04,
+	0x3a, 0x01, 0x03, 0x07, 0x3b, 0x01, 0x01, 0x06, 0x2c, 0x2a, 0x02, 0x0a, 0x01, 0x2b, 0x16, 0x02,
+	0x02, 0x0a, 0x05, 0x4c, 0x1b, 0x4b, 0xb0, 0x1e, 0x50, 0x58, 0x40, 0x19, 0x21, 0x20, 0x02, 0x0c,
+	0x04, 0x3a, 0x01, 0x03, 0x07, 0x3b, 0x01, 0x01, 0x06, 0x2c, 0x2a, 0x02, 0x0a, 0x01, 0x2b, 0x16,
+	0x02, 0x02, 0x0a, 0x05, 0x4c, 0x1b, 0x40, 0x19, 0x21, 0x20, 0x02, 0x0c, 0x04, 0x3a, 0x01, 0x03,
+	0x07, 0x3b, 0x01, 0x01, 0x06, 0x2c, 0x2a, 0x02, 0x0a, 0x01, 0x2b, 0x16, 0x02, 0x02, 0x0b, 0x05,
+	0x4c, 0x59, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2d, 0x0c, 0x08, 0x02, 0x07, 0x0d, 0x09,
+	0x02, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x04,
+	0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x02, 0x61, 0x0e, 0x05,
+	0x0f, 0x03, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0x31, 0x0c,
+	0x08, 0x02, 0x07, 0x0d, 0x09, 0x02, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a,
+	0x03, 0x01, 0x69, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0f, 0x01, 0x02,
+	0x02, 0x39, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x36, 0x00, 0x0c, 0x07, 0x06, 0x0c, 0x59, 0x08, 0x01,
+	0x07, 0x0d, 0x09, 0x02, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01,
+	0x69, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x39,
+	0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x1e, 0x50, 0x58, 0x40, 0x37, 0x00, 0x0c, 0x00, 0x0d, 0x06, 0x0c, 0x0d, 0x69, 0x08, 0x01,
+	0x07, 0x09, 0x01, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69,
+	0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x39, 0x4d,
+	0x0b, 0x01, 0x0a, 0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x41, 0x00, 0x0c, 0x00, 0x0d, 0x06, 0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07,
+	0x09, 0x01, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00,
+	0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x0a, 0x0a, 0x05, 0x61, 0x0e, 0x01,
+	0x05, 0x05, 0x42, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x0b, 0x0b, 0x05, 0x61, 0x0e,
+	0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40, 0x3f, 0x00, 0x00, 0x00, 0x04, 0x0c, 0x00, 0x04,
+	0x69, 0x00, 0x0c, 0x00, 0x0d, 0x06, 0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07, 0x09, 0x01, 0x06, 0x01,
+	0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x0a, 0x0a, 0x05, 0x61,
+	0x0e, 0x01, 0x05, 0x05, 0x42, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x3c, 0x4d, 0x00, 0x0b, 0x0b, 0x05,
+	0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x23, 0x00,
+	0x00, 0x49, 0x47, 0x3e, 0x3c, 0x39, 0x37, 0x2f, 0x2d, 0x29, 0x27, 0x25, 0x24, 0x23, 0x22, 0x1f,
+	0x1e, 0x1d, 0x1c, 0x19, 0x17, 0x15, 0x13, 0x0f, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x26, 0x21, 0x10,
+	0x09, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15, 0x14, 0x00, 0x23, 0x23, 0x11,
+	0x11, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x01, 0x06, 0x23, 0x22, 0x26, 0x35, 0x11,
+	0x23, 0x35, 0x33, 0x35, 0x25, 0x15, 0x33, 0x15, 0x23, 0x11, 0x14, 0x33, 0x32, 0x37, 0x17, 0x35,
+	0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x3d, 0x01, 0x78,
+	0x9e, 0x9a, 0x38, 0x52, 0xfe, 0xf2, 0xec, 0x30, 0x27, 0x6b, 0x7e, 0x6b, 0x7d, 0x28, 0x04, 0x33,
+	0x4f, 0x47, 0x9a, 0x86, 0x53, 0x53, 0x01, 0x0a, 0x9c, 0x9c, 0x6c, 0x1b, 0x25, 0x58, 0xb1, 0x72,
+	0x7d, 0x69, 0x3e, 0x87, 0x6f, 0x01, 0x70, 0x7a, 0x88, 0x86, 0x61, 0x81, 0x5e, 0x39, 0x9b, 0x77,
+	0xca, 0xa6, 0xad, 0x05, 0xc8, 0x31, 0x44, 0x62, 0xb2, 0xec, 0xfe, 0xf1, 0xfd, 0xbc, 0x03, 0x0f,
+	0x95, 0x7f, 0x76, 0x64, 0xfb, 0x06, 0x1c, 0x90, 0xa5, 0x01, 0xab, 0xaa, 0x8d, 0x24, 0xb1, 0xaa,
+	0xfe, 0x76, 0x9a, 0x0b, 0x98, 0xc2, 0x46, 0x4d, 0x32, 0x2d, 0x1b, 0x3a, 0x7e, 0x60, 0x01, 0x14,
+	0x22, 0xc1, 0x38, 0x4b, 0x35, 0x24, 0x16, 0x3a, 0x7a, 0x62, 0x83, 0xa0, 0x00, 0x01, 0x00, 0x00,
+	0xff, 0xdb, 0x04, 0x27, 0x05, 0xee, 0x00, 0x26, 0x00, 0x8a, 0x40, 0x12, 0x0d, 0x01, 0x04, 0x03,
+	0x0e, 0x01, 0x02, 0x04, 0x21, 0x01, 0x09, 0x08, 0x22, 0x01, 0x0a, 0x09, 0x04, 0x4c, 0x4b, 0xb0,
+	0x2b, 0x50, 0x58, 0x40, 0x2a, 0x05, 0x01, 0x02, 0x06, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x07,
+	0x01, 0x00, 0x0c, 0x0b, 0x02, 0x08, 0x09, 0x00, 0x08, 0x67, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x3e, 0x4d, 0x00, 0x09, 0x09, 0x0a, 0x61, 0x00, 0x0a, 0x0a, 0x3f, 0x0a, 0x4e, 0x1b,
+	0x40, 0x28, 0x00, 0x03, 0x00, 0x04, 0x02, 0x03, 0x04, 0x69, 0x05, 0x01, 0x02, 0x06, 0x01, 0x01,
+	0x00, 0x02, 0x01, 0x67, 0x07, 0x01, 0x00, 0x0c, 0x0b, 0x02, 0x08, 0x09, 0x00, 0x08, 0x67, 0x00,
+	0x09, 0x09, 0x0a, 0x61, 0x00, 0x0a, 0x0a, 0x42, 0x0a, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x00,
+	0x26, 0x00, 0x26, 0x25, 0x23, 0x20, 0x1e, 0x11, 0x14, 0x11, 0x11, 0x23, 0x21, 0x11, 0x14, 0x11,
+	0x0d, 0x09, 0x1f, 0x2b, 0x11, 0x37, 0x33, 0x26, 0x35, 0x34, 0x37, 0x23, 0x37, 0x33, 0x12, 0x21,
+	0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x03, 0x21, 0x07, 0x21, 0x06, 0x15, 0x14, 0x17, 0x21, 0x07,
+	0x21, 0x16, 0x16, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x03, 0x3f, 0x42, 0x03, 0x05, 0x83,
+	0x3f, 0x62, 0x8b, 0x01, 0xe4, 0x99, 0x7e, 0x77, 0x8d, 0xfd, 0x5d, 0x02, 0x06, 0x3e, 0xfe, 0x18,
+	0x02, 0x03, 0x01, 0x9f, 0x3f, 0xfe, 0xbe, 0x34, 0xa5, 0x9f, 0x6d, 0x7a, 0x7a, 0xb2, 0xfe, 0x21,
+	0x81, 0x01, 0xe1, 0xad, 0x2e, 0x2a, 0x39, 0x34, 0xad, 0x01, 0xee, 0x26, 0xd6, 0x37, 0xfe, 0xd7,
+	0xad, 0x21, 0x30, 0x44, 0x30, 0xad, 0xb3, 0x8e, 0x35, 0xcc, 0x2e, 0x02, 0x06, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x4a, 0x00, 0x00, 0x06, 0xcc, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x17, 0x00, 0x21,
+	0x00, 0x2b, 0x00, 0x5e, 0x40, 0x5b, 0x0d, 0x01, 0x04, 0x00, 0x17, 0x0e, 0x02, 0x05, 0x04, 0x02,
+	0x4c, 0x03, 0x01, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00, 0x05, 0x00, 0x02, 0x07, 0x05,
+	0x02, 0x69, 0x00, 0x07, 0x00, 0x09, 0x08, 0x07, 0x09, 0x69, 0x0c, 0x01, 0x08, 0x01, 0x01, 0x08,
+	0x59, 0x0c, 0x01, 0x08, 0x08, 0x01, 0x61, 0x0b, 0x06, 0x0a, 0x03, 0x01, 0x08, 0x01, 0x51, 0x23,
+	0x22, 0x19, 0x18, 0x00, 0x00, 0x28, 0x26, 0x22, 0x2b, 0x23, 0x2b, 0x1e, 0x1c, 0x18, 0x21, 0x19,
+	0x21, 0x16, 0x14, 0x11, 0x0f, 0x0c, 0x0a, 0x07, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0d, 0x06,
+	0x17, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x01, 0x06, 0x23, 0x22, 0x35, 0x34, 0x00, 0x33, 0x32, 0x17,
+	0x07, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x01, 0x20, 0x35, 0x34, 0x00, 0x33,
+	0x20, 0x15, 0x14, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x23, 0x22, 0x06, 0x15, 0x14, 0x4a, 0x05,
+	0xc9, 0xb9, 0xfa, 0x37, 0x01, 0xd6, 0x85, 0x9f, 0xf9, 0x01, 0x1d, 0xb7, 0x52, 0x5c, 0x1e, 0x62,
+	0x42, 0x4d, 0x77, 0x6e, 0x55, 0x7f, 0x01, 0xb8, 0xfe, 0xfb, 0x01, 0x23, 0xc8, 0x01, 0x08, 0xfe,
+	0xdd, 0x84, 0x4c, 0x67, 0x58, 0x4b, 0x68, 0x05, 0xc8, 0xfa, 0x38, 0x03, 0x49, 0x38, 0xdb, 0xba,
+	0x01, 0x22, 0x27, 0x9b, 0x38, 0xb0, 0x72, 0x6c, 0x41, 0xfc, 0x0f, 0xde, 0xc2, 0x01, 0x18, 0xde,
+	0xc2, 0xfe, 0xe8, 0x8d, 0xaa, 0x7e, 0x76, 0xaa, 0x7b, 0x79, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0xff, 0xe7, 0x03, 0xcf, 0x06, 0x50, 0x00, 0x0f, 0x00, 0x3b, 0x00, 0x30, 0x40, 0x2d, 0x2e, 0x29,
+	0x28, 0x23, 0x1a, 0x19, 0x10, 0x00, 0x08, 0x01, 0x00, 0x01, 0x4c, 0x00, 0x03, 0x00, 0x00, 0x01,
+	0x03, 0x00, 0x69, 0x00, 0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02,
+	0x01, 0x02, 0x51, 0x33, 0x31, 0x25, 0x2c, 0x27, 0x04, 0x06, 0x19, 0x2b, 0x01, 0x36, 0x36, 0x35,
+	0x34, 0x2e, 0x02, 0x23, 0x22, 0x0e, 0x04, 0x15, 0x03, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x36,
+	0x37, 0x17, 0x02, 0x02, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x35, 0x0e, 0x03, 0x07, 0x27, 0x3e, 0x03,
+	0x37, 0x35, 0x10, 0x12, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x01, 0xfd, 0x66, 0x66,
+	0x04, 0x10, 0x21, 0x1c, 0x1f, 0x2a, 0x1b, 0x0e, 0x07, 0x01, 0x01, 0x03, 0x12, 0x26, 0x24, 0x39,
+	0x59, 0x1b, 0xc6, 0x36, 0xdf, 0xa6, 0x68, 0x7e, 0x44, 0x15, 0x18, 0x28, 0x28, 0x2c, 0x1c, 0x25,
+	0x18, 0x38, 0x39, 0x36, 0x16, 0xdb, 0xe5, 0x4c, 0x6f, 0x48, 0x22, 0x48, 0x7a, 0xa1, 0x03, 0x19,
+	0x62, 0xf0, 0x89, 0x18, 0x36, 0x2c, 0x1d, 0x2f, 0x4e, 0x66, 0x6e, 0x6e, 0x2f, 0xfe, 0x90, 0x3f,
+	0x36, 0x6e, 0x59, 0x38, 0xaa, 0xb7, 0x25, 0xfe, 0xf7, 0xfe, 0xfb, 0x44, 0x73, 0x96, 0x53, 0x27,
+	0x05, 0x08, 0x06, 0x06, 0x03, 0xac, 0x04, 0x0a, 0x0c, 0x10, 0x09, 0xfa, 0x01, 0x72, 0x01, 0x73,
+	0x30, 0x55, 0x74, 0x44, 0x75, 0xde, 0xc2, 0x9d, 0x00, 0x04, 0x00, 0xaa, 0x00, 0x00, 0x08, 0x4b,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x19, 0x00, 0x21, 0x00, 0x5d, 0x40, 0x5a, 0x08, 0x01,
+	0x09, 0x07, 0x03, 0x01, 0x08, 0x09, 0x02, 0x4c, 0x01, 0x01, 0x00, 0x07, 0x00, 0x85, 0x00, 0x07,
+	0x00, 0x09, 0x08, 0x07, 0x09, 0x69, 0x0d, 0x01, 0x08, 0x0c, 0x01, 0x06, 0x04, 0x08, 0x06, 0x69,
+	0x00, 0x04, 0x02, 0x02, 0x04, 0x57, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x0b, 0x05, 0x0a, 0x03, 0x04,
+	0x02, 0x04, 0x02, 0x4f, 0x1b, 0x1a, 0x0f, 0x0e, 0x0a, 0x0a, 0x00, 0x00, 0x1f, 0x1d, 0x1a, 0x21,
+	0x1b, 0x21, 0x15, 0x13, 0x0e, 0x19, 0x0f, 0x19, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09,
+	0x00, 0x09, 0x11, 0x12, 0x11, 0x0e, 0x06, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11,
+	0x23, 0x01, 0x11, 0x21, 0x35, 0x21, 0x15, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16,
+	0x15, 0x14, 0x06, 0x27, 0x32, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0xaa, 0xf7, 0x02, 0x5c, 0xf7,
+	0xf7, 0xfd, 0xa4, 0x04, 0x03, 0x02, 0x69, 0xfe, 0xc7, 0xa2, 0xcb, 0xcc, 0xa6, 0xa5, 0xcd, 0xcd,
+	0xa8, 0x7e, 0x7b, 0x7c, 0x05, 0xc8, 0xfc, 0x36, 0x03, 0xca, 0xfa, 0x38, 0x03, 0xcb, 0xfc, 0x35,
+	0xad, 0xad, 0x01, 0x35, 0xdf, 0xb2, 0xb3, 0xdd, 0xdd, 0xb2, 0xb6, 0xdc, 0xb9, 0xd8, 0xd7, 0xd7,
+	0xd8, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc5, 0x02, 0xe4, 0x07, 0x3a, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x14, 0x00, 0x4a, 0x40, 0x47, 0x13, 0x10, 0x0b, 0x03, 0x07, 0x00, 0x01, 0x4c, 0x00, 0x07,
+	0x00, 0x03, 0x00, 0x07, 0x03, 0x80, 0x0a, 0x08, 0x06, 0x09, 0x04, 0x03, 0x03, 0x84, 0x05, 0x04,
+	0x02, 0x01, 0x00, 0x00, 0x01, 0x57, 0x05, 0x04, 0x02, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00,
+	0x01, 0x00, 0x4f, 0x08, 0x08, 0x00, 0x00, 0x08, 0x14, 0x08, 0x14, 0x12, 0x11, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0b, 0x06, 0x19, 0x2b, 0x01, 0x11,
+	0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x21, 0x11, 0x33, 0x13, 0x13, 0x33, 0x11, 0x23, 0x11, 0x03,
+	0x23, 0x03, 0x11, 0x01, 0xd1, 0xfe, 0xf4, 0x02, 0xde, 0xfe, 0xf4, 0x01, 0x8e, 0xfe, 0x9c, 0x9f,
+	0xdc, 0xb3, 0xa0, 0x90, 0x9e, 0x02, 0xe4, 0x02, 0x69, 0x7b, 0x7b, 0xfd, 0x97, 0x02, 0xe4, 0xfe,
+	0x28, 0x01, 0xd8, 0xfd, 0x1c, 0x02, 0x06, 0xfe, 0x2c, 0x01, 0xe1, 0xfd, 0xed, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x51, 0x00, 0x00, 0x05, 0xd4, 0x05, 0xed, 0x00, 0x1f, 0x00, 0x33, 0x40, 0x30,
+	0x1e, 0x12, 0x02, 0x00, 0x01, 0x4b, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x69, 0x02, 0x01,
+	0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x00,
+	0x03, 0x4f, 0x00, 0x00, 0x00, 0x1f, 0x00, 0x1f, 0x26, 0x11, 0x15, 0x25, 0x11, 0x07, 0x06, 0x1b,
+	0x2b, 0x33, 0x35, 0x21, 0x26, 0x02, 0x35, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x14, 0x02, 0x07,
+	0x21, 0x15, 0x21, 0x35, 0x36, 0x12, 0x35, 0x34, 0x02, 0x23, 0x22, 0x02, 0x15, 0x14, 0x12, 0x17,
+	0x15, 0x51, 0x01, 0x62, 0xac, 0xac, 0x01, 0x83, 0x01, 0x35, 0x01, 0x34, 0x01, 0x83, 0xac, 0xac,
+	0x01, 0x62, 0xfd, 0xa9, 0x8d, 0x8d, 0xd0, 0xb4, 0xb5, 0xd0, 0x8d, 0x8d, 0xcc, 0x88, 0x01, 0x44,
+	0xbc, 0x01, 0x27, 0x01, 0x72, 0xfe, 0x8e, 0xfe, 0xd9, 0xbb, 0xfe, 0xbc, 0x89, 0xcc, 0xcc, 0x70,
+	0x01, 0x39, 0xc9, 0xe1, 0x01, 0x03, 0xfe, 0xfc, 0xe1, 0xc9, 0xfe, 0xc8, 0x70, 0xcc, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0xff, 0xe7, 0x05, 0x52, 0x03, 0x8b, 0x00, 0x1f, 0x00, 0x30, 0x00, 0x40,
+	0x40, 0x3d, 0x2f, 0x23, 0x02, 0x05, 0x06, 0x18, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x00, 0x00, 0x03,
+	0x04, 0x03, 0x00, 0x04, 0x80, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x05, 0x00,
+	0x03, 0x00, 0x05, 0x03, 0x67, 0x00, 0x04, 0x01, 0x01, 0x04, 0x59, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x00, 0x01, 0x04, 0x01, 0x51, 0x27, 0x11, 0x27, 0x24, 0x28, 0x23, 0x10, 0x07, 0x06, 0x1d, 0x2b,
+	0x25, 0x33, 0x06, 0x07, 0x06, 0x23, 0x22, 0x26, 0x27, 0x26, 0x35, 0x34, 0x37, 0x36, 0x36, 0x33,
+	0x32, 0x16, 0x17, 0x16, 0x15, 0x15, 0x21, 0x22, 0x15, 0x15, 0x14, 0x17, 0x16, 0x16, 0x33, 0x32,
+	0x01, 0x21, 0x32, 0x35, 0x35, 0x34, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x15, 0x15,
+	0x14, 0x04, 0x70, 0x5e, 0x55, 0x55, 0x9a, 0xaf, 0x8b, 0xfb, 0x59, 0x98, 0x98, 0x59, 0xfb, 0x8b,
+	0x8b, 0xfb, 0x5a, 0x97, 0xfc, 0x09, 0x0f, 0x19, 0x34, 0xda, 0x6a, 0xeb, 0xfd, 0x93, 0x03, 0x00,
+	0x11, 0x1a, 0x36, 0xd8, 0x69, 0x69, 0xd9, 0x34, 0x19, 0x9b, 0x4b, 0x25, 0x44, 0x56, 0x4d, 0x83,
+	0xac, 0xac, 0x84, 0x4d, 0x55, 0x55, 0x4d, 0x84, 0xac, 0x0d, 0x0d, 0xe4, 0x20, 0x1a, 0x35, 0x49,
+	0x01, 0xc3, 0x0d, 0xe5, 0x1f, 0x1a, 0x35, 0x4a, 0x4a, 0x35, 0x1a, 0x1f, 0xe5, 0x0d, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x14, 0xff, 0xdb, 0x06, 0x98, 0x05, 0xed, 0x00, 0x05, 0x00, 0x09, 0x00, 0x1d,
+	0x00, 0x25, 0x00, 0x30, 0x00, 0xaf, 0x40, 0x10, 0x03, 0x02, 0x01, 0x03, 0x03, 0x01, 0x14, 0x01,
+	0x06, 0x00, 0x02, 0x4c, 0x04, 0x01, 0x01, 0x4a, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x23, 0x07,
+	0x01, 0x00, 0x05, 0x06, 0x05, 0x00, 0x06, 0x80, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x6a,
+	0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x06, 0x06, 0x02, 0x61, 0x04, 0x08, 0x02, 0x02, 0x02, 0x3f,
+	0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x23, 0x00, 0x01, 0x03, 0x01, 0x85, 0x07,
+	0x01, 0x00, 0x05, 0x06, 0x05, 0x00, 0x06, 0x80, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x6a,
+	0x00, 0x06, 0x06, 0x02, 0x61, 0x04, 0x08, 0x02, 0x02, 0x02, 0x3f, 0x02, 0x4e, 0x1b, 0x40, 0x27,
+	0x00, 0x01, 0x03, 0x01, 0x85, 0x07, 0x01, 0x00, 0x05, 0x06, 0x05, 0x00, 0x06, 0x80, 0x08, 0x01,
+	0x02, 0x04, 0x02, 0x86, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x6a, 0x00, 0x06, 0x06, 0x04,
+	0x61, 0x00, 0x04, 0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x19, 0x06, 0x06, 0x00, 0x00, 0x2c,
+	0x2a, 0x23, 0x21, 0x1a, 0x18, 0x10, 0x0e, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00,
+	0x05, 0x09, 0x09, 0x16, 0x2b, 0x13, 0x11, 0x07, 0x35, 0x25, 0x11, 0x01, 0x01, 0x33, 0x01, 0x01,
+	0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22,
+	0x26, 0x35, 0x34, 0x25, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x17, 0x06, 0x15, 0x14, 0x16,
+	0x33, 0x32, 0x36, 0x35, 0x34, 0x27, 0xf2, 0xde, 0x01, 0xbc, 0xfe, 0xeb, 0x04, 0x40, 0xa0, 0xfb,
+	0xc0, 0x03, 0x35, 0x80, 0xad, 0x8c, 0x87, 0x9d, 0x8a, 0xb5, 0xc5, 0xa2, 0x9a, 0xba, 0x01, 0x96,
+	0x3e, 0x78, 0x6d, 0x2d, 0x4e, 0x5f, 0x46, 0x3a, 0x50, 0x8f, 0x02, 0x67, 0x02, 0xc9, 0x37, 0x85,
+	0x6f, 0xfc, 0x7a, 0xfd, 0x74, 0x06, 0x12, 0xf9, 0xee, 0x01, 0xf8, 0x51, 0x7e, 0x69, 0x80, 0x6e,
+	0x5f, 0x6e, 0x68, 0x66, 0x90, 0x79, 0x92, 0x83, 0x6c, 0x9b, 0xb3, 0x3c, 0x3e, 0x6e, 0x54, 0x3e,
+	0xeb, 0x46, 0x4e, 0x40, 0x58, 0x40, 0x2d, 0x44, 0x4f, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0x32,
+	0xff, 0xdb, 0x06, 0x7a, 0x05, 0xed, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x35, 0x00, 0x3d, 0x00, 0x48,
+	0x00, 0xe7, 0x40, 0x1a, 0x11, 0x01, 0x03, 0x04, 0x10, 0x01, 0x02, 0x03, 0x17, 0x01, 0x01, 0x02,
+	0x01, 0x01, 0x00, 0x0a, 0x00, 0x01, 0x05, 0x00, 0x2c, 0x01, 0x0b, 0x05, 0x06, 0x4c, 0x4b, 0xb0,
+	0x29, 0x50, 0x58, 0x40, 0x32, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00,
+	0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x06, 0x01, 0x04, 0x04, 0x3e, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x07, 0x61, 0x09,
+	0x0c, 0x02, 0x07, 0x07, 0x3f, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x36, 0x0c,
+	0x01, 0x07, 0x09, 0x07, 0x86, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00,
+	0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x06, 0x01, 0x04, 0x04, 0x3e, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00,
+	0x09, 0x09, 0x42, 0x09, 0x4e, 0x1b, 0x40, 0x34, 0x0c, 0x01, 0x07, 0x09, 0x07, 0x86, 0x06, 0x01,
+	0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00,
+	0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41,
+	0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42, 0x09, 0x4e, 0x59, 0x59, 0x40, 0x18,
+	0x1e, 0x1e, 0x44, 0x42, 0x3b, 0x39, 0x32, 0x30, 0x28, 0x26, 0x1e, 0x21, 0x1e, 0x21, 0x12, 0x27,
+	0x23, 0x22, 0x21, 0x22, 0x22, 0x0d, 0x09, 0x1d, 0x2b, 0x13, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34,
+	0x23, 0x23, 0x35, 0x33, 0x32, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x15, 0x14,
+	0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x13, 0x01, 0x33, 0x01, 0x01, 0x26, 0x35, 0x34, 0x36,
+	0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x25,
+	0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x17, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35,
+	0x34, 0x27, 0x32, 0x87, 0x64, 0xa2, 0xff, 0x3c, 0x2e, 0xf2, 0x8a, 0x6b, 0x71, 0x92, 0x79, 0x01,
+	0x40, 0xcd, 0xe8, 0xc1, 0xad, 0x7f, 0x57, 0x03, 0xe6, 0xa0, 0xfc, 0x1a, 0x02, 0xc4, 0x80, 0xad,
+	0x8c, 0x87, 0x9d, 0x8a, 0xb5, 0xc5, 0xa2, 0x9a, 0xba, 0x01, 0x96, 0x3e, 0x78, 0x6d, 0x2d, 0x4e,
+	0x5f, 0x46, 0x3a, 0x50, 0x8f, 0x02, 0x66, 0x96, 0x34, 0x80, 0xa8, 0x7f, 0x92, 0x6d, 0x32, 0x86,
+	0x2b, 0xd7, 0xa0, 0x3e, 0x35, 0xbd, 0x77, 0x86, 0xfd, 0x92, 0x06, 0x12, 0xf9, 0xee, 0x01, 0xf8,
+	0x51, 0x7e, 0x69, 0x80, 0x6e, 0x5f, 0x6e, 0x68, 0x66, 0x90, 0x79, 0x92, 0x83, 0x6c, 0x9b, 0xb3,
+	0x3c, 0x3e, 0x6e, 0x54, 0x3e, 0xeb, 0x46, 0x4e, 0x40, 0x58, 0x40, 0x2d, 0x44, 0x4f, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x46, 0xff, 0xdb, 0x06, 0x66, 0x05, 0xed, 0x00, 0x16, 0x00, 0x1a, 0x00, 0x2e,
+	0x00, 0x36, 0x00, 0x41, 0x01, 0x9e, 0x40, 0x12, 0x09, 0x01, 0x08, 0x01, 0x01, 0x01, 0x00, 0x0a,
+	0x00, 0x01, 0x05, 0x00, 0x25, 0x01, 0x0b, 0x05, 0x04, 0x4c, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40,
+	0x32, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05,
+	0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x04,
+	0x61, 0x00, 0x04, 0x04, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07,
+	0x3f, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x36, 0x00, 0x08, 0x00, 0x0a, 0x00,
+	0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x06, 0x06, 0x38, 0x4d,
+	0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07, 0x3f, 0x07,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x36, 0x00, 0x06, 0x02, 0x06, 0x85, 0x00, 0x08,
+	0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x03,
+	0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07, 0x3f, 0x07, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x34, 0x00, 0x06, 0x02, 0x06, 0x85, 0x00, 0x04, 0x00, 0x01,
+	0x08, 0x04, 0x01, 0x69, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00, 0x00, 0x05,
+	0x0b, 0x00, 0x05, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x0b,
+	0x0b, 0x07, 0x61, 0x09, 0x0c, 0x02, 0x07, 0x07, 0x3f, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50,
+	0x58, 0x40, 0x38, 0x00, 0x06, 0x02, 0x06, 0x85, 0x0c, 0x01, 0x07, 0x09, 0x07, 0x86, 0x00, 0x04,
+	0x00, 0x01, 0x08, 0x04, 0x01, 0x69, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a, 0x6a, 0x00, 0x00,
+	0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d,
+	0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42, 0x09, 0x4e, 0x1b, 0x40, 0x36, 0x00, 0x06,
+	0x02, 0x06, 0x85, 0x0c, 0x01, 0x07, 0x09, 0x07, 0x86, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x04, 0x00, 0x01, 0x08, 0x04, 0x01, 0x69, 0x00, 0x08, 0x00, 0x0a, 0x00, 0x08, 0x0a,
+	0x6a, 0x00, 0x00, 0x00, 0x05, 0x0b, 0x00, 0x05, 0x69, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09,
+	0x09, 0x42, 0x09, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x18, 0x17, 0x17, 0x3d, 0x3b, 0x34,
+	0x32, 0x2b, 0x29, 0x21, 0x1f, 0x17, 0x1a, 0x17, 0x1a, 0x12, 0x24, 0x21, 0x11, 0x12, 0x22, 0x22,
+	0x0d, 0x09, 0x1d, 0x2b, 0x13, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x21, 0x22, 0x07, 0x11, 0x21,
+	0x15, 0x21, 0x15, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x13, 0x01, 0x33, 0x01, 0x01,
+	0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22,
+	0x26, 0x35, 0x34, 0x25, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x17, 0x06, 0x15, 0x14, 0x16,
+	0x33, 0x32, 0x36, 0x35, 0x34, 0x27, 0x46, 0x84, 0x49, 0x95, 0xfe, 0xf0, 0x23, 0x1f, 0x02, 0x2b,
+	0xfe, 0x5c, 0x1e, 0xb7, 0xe2, 0xc4, 0xa0, 0x5f, 0x47, 0x03, 0x96, 0xa0, 0xfc, 0x6a, 0x02, 0xa6,
+	0x80, 0xad, 0x8c, 0x87, 0x9d, 0x8a, 0xb5, 0xc5, 0xa2, 0x9a, 0xba, 0x01, 0x96, 0x3e, 0x78, 0x6d,
+	0x2d, 0x4e, 0x5f, 0x46, 0x3a, 0x50, 0x8f, 0x02, 0x59, 0x92, 0x32, 0x96, 0xb7, 0x06, 0x01, 0xc8,
+	0xa8, 0x9f, 0xa5, 0x86, 0x80, 0x9c, 0xfd, 0xa1, 0x06, 0x12, 0xf9, 0xee, 0x01, 0xf8, 0x51, 0x7e,
+	0x69, 0x80, 0x6e, 0x5f, 0x6e, 0x68, 0x66, 0x90, 0x79, 0x92, 0x83, 0x6c, 0x9b, 0xb3, 0x3c, 0x3e,
+	0x6e, 0x54, 0x3e, 0xeb, 0x46, 0x4e, 0x40, 0x58, 0x40, 0x2d, 0x44, 0x4f, 0x00, 0x05, 0x00, 0x32,
+	0xff, 0xdb, 0x06, 0x66, 0x05, 0xed, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x21, 0x00, 0x29, 0x00, 0x34,
+	0x01, 0x34, 0x40, 0x0b, 0x18, 0x01, 0x08, 0x02, 0x01, 0x4c, 0x07, 0x01, 0x00, 0x01, 0x4b, 0x4b,
+	0xb0, 0x0e, 0x50, 0x58, 0x40, 0x29, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07, 0x02, 0x08, 0x80, 0x00,
+	0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x6a, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x01,
+	0x38, 0x4d, 0x00, 0x08, 0x08, 0x04, 0x61, 0x06, 0x0a, 0x02, 0x04, 0x04, 0x3f, 0x04, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x2d, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07, 0x02, 0x08, 0x80,
+	0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x6a, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x04, 0x61, 0x06, 0x0a, 0x02, 0x04,
+	0x04, 0x3f, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x03, 0x01, 0x03,
+	0x85, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07, 0x02, 0x08, 0x80, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05,
+	0x07, 0x6a, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x04,
+	0x61, 0x06, 0x0a, 0x02, 0x04, 0x04, 0x3f, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40,
+	0x31, 0x00, 0x03, 0x01, 0x03, 0x85, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07, 0x02, 0x08, 0x80, 0x0a,
+	0x01, 0x04, 0x06, 0x04, 0x86, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x6a, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42,
+	0x06, 0x4e, 0x1b, 0x40, 0x2f, 0x00, 0x03, 0x01, 0x03, 0x85, 0x09, 0x01, 0x02, 0x07, 0x08, 0x07,
+	0x02, 0x08, 0x80, 0x0a, 0x01, 0x04, 0x06, 0x04, 0x86, 0x00, 0x01, 0x00, 0x00, 0x05, 0x01, 0x00,
+	0x67, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x6a, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06,
+	0x06, 0x42, 0x06, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x1b, 0x0a, 0x0a, 0x00, 0x00, 0x30, 0x2e,
+	0x27, 0x25, 0x1e, 0x1c, 0x14, 0x12, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09,
+	0x11, 0x13, 0x0b, 0x09, 0x18, 0x2b, 0x13, 0x36, 0x13, 0x37, 0x21, 0x35, 0x21, 0x15, 0x00, 0x03,
+	0x03, 0x01, 0x33, 0x01, 0x01, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16,
+	0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x25, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14,
+	0x17, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x27, 0x53, 0x16, 0xe5, 0xb5, 0xfe,
+	0x2f, 0x02, 0x7b, 0xfe, 0xa9, 0x1a, 0xd8, 0x04, 0x40, 0xa0, 0xfb, 0xc0, 0x03, 0x5a, 0x80, 0xad,
+	0x8c, 0x87, 0x9d, 0x8a, 0xb5, 0xc5, 0xa2, 0x9a, 0xba, 0x01, 0x96, 0x3e, 0x78, 0x6d, 0x2d, 0x4e,
+	0x5f, 0x46, 0x3a, 0x50, 0x8f, 0x02, 0x50, 0xb5, 0x01, 0x2c, 0xee, 0xa9, 0xa9, 0xfe, 0x7a, 0xfe,
+	0xb7, 0xfd, 0x8b, 0x06, 0x12, 0xf9, 0xee, 0x01, 0xf8, 0x51, 0x7e, 0x69, 0x80, 0x6e, 0x5f, 0x6e,
+	0x68, 0x66, 0x90, 0x79, 0x92, 0x83, 0x6c, 0x9b, 0xb3, 0x3c, 0x3e, 0x6e, 0x54, 0x3e, 0xeb, 0x46,
+	0x4e, 0x40, 0x58, 0x40, 0x2d, 0x44, 0x4f, 0x00, 0x00, 0x01, 0x00, 0x64, 0x00, 0xa1, 0x07, 0x38,
+	0x04, 0x00, 0x00, 0x06, 0x00, 0x26, 0x40, 0x23, 0x03, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x04, 0x01,
+	0x01, 0x4a, 0x02, 0x01, 0x00, 0x49, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x14, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x01, 0x21, 0x13, 0x01,
+	0x01, 0x03, 0x21, 0x07, 0x38, 0xfb, 0x6b, 0xad, 0xfd, 0x14, 0x02, 0xec, 0xad, 0x04, 0x95, 0x01,
+	0xfa, 0xfe, 0xa7, 0x01, 0xb0, 0x01, 0xaf, 0xfe, 0xa7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x51,
+	0xfe, 0x75, 0x03, 0xb0, 0x06, 0x44, 0x00, 0x06, 0x00, 0x19, 0x40, 0x16, 0x05, 0x04, 0x03, 0x02,
+	0x01, 0x05, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x06, 0x00, 0x06, 0x02,
+	0x06, 0x16, 0x2b, 0x01, 0x11, 0x05, 0x01, 0x01, 0x25, 0x11, 0x01, 0xaa, 0xfe, 0xa7, 0x01, 0xb0,
+	0x01, 0xaf, 0xfe, 0xa7, 0xfe, 0x75, 0x05, 0x90, 0xad, 0x02, 0xec, 0xfd, 0x14, 0xad, 0xfa, 0x70,
+	0x00, 0x01, 0x00, 0xc8, 0x00, 0xa2, 0x07, 0x9c, 0x04, 0x01, 0x00, 0x06, 0x00, 0x26, 0x40, 0x23,
+	0x03, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x02, 0x01, 0x00, 0x4a, 0x04, 0x01, 0x01, 0x49, 0x00, 0x00,
+	0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x00, 0x01, 0x4f, 0x14, 0x10,
+	0x02, 0x06, 0x18, 0x2b, 0x13, 0x21, 0x03, 0x01, 0x01, 0x13, 0x21, 0xc8, 0x04, 0x95, 0xad, 0x02,
+	0xec, 0xfd, 0x14, 0xad, 0xfb, 0x6b, 0x02, 0xa8, 0x01, 0x59, 0xfe, 0x50, 0xfe, 0x51, 0x01, 0x59,
+	0x00, 0x01, 0x00, 0x51, 0xfe, 0x75, 0x03, 0xb0, 0x06, 0x44, 0x00, 0x06, 0x00, 0x19, 0x40, 0x16,
+	0x05, 0x04, 0x03, 0x02, 0x01, 0x05, 0x00, 0x49, 0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00,
+	0x06, 0x00, 0x06, 0x02, 0x06, 0x16, 0x2b, 0x01, 0x11, 0x25, 0x01, 0x01, 0x05, 0x11, 0x02, 0x57,
+	0x01, 0x59, 0xfe, 0x50, 0xfe, 0x51, 0x01, 0x59, 0x06, 0x44, 0xfa, 0x70, 0xad, 0xfd, 0x14, 0x02,
+	0xec, 0xad, 0x05, 0x90, 0x00, 0x01, 0x00, 0x64, 0x00, 0xa1, 0x07, 0x9c, 0x04, 0x00, 0x00, 0x09,
+	0x00, 0x28, 0x40, 0x25, 0x05, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x04, 0x01, 0x02, 0x00, 0x4a, 0x09,
+	0x06, 0x02, 0x01, 0x49, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x00, 0x01, 0x4f, 0x14, 0x12, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x01, 0x03, 0x21, 0x03, 0x01,
+	0x01, 0x13, 0x21, 0x13, 0x64, 0x02, 0xec, 0xad, 0x02, 0xba, 0xad, 0x02, 0xec, 0xfd, 0x14, 0xad,
+	0xfd, 0x46, 0xad, 0x02, 0x51, 0x01, 0xaf, 0xfe, 0xa7, 0x01, 0x59, 0xfe, 0x51, 0xfe, 0x50, 0x01,
+	0x59, 0xfe, 0xa7, 0x00, 0x00, 0x01, 0x00, 0x51, 0xfe, 0x75, 0x03, 0xb0, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x06, 0xb3, 0x05, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x01, 0x25, 0x11, 0x25, 0x01, 0x01, 0x05,
+	0x11, 0x05, 0x02, 0x01, 0x01, 0xaf, 0xfe, 0xa7, 0x01, 0x59, 0xfe, 0x51, 0xfe, 0x50, 0x01, 0x59,
+	0xfe, 0xa7, 0x06, 0x44, 0xfd, 0x14, 0xad, 0xfc, 0xaf, 0xad, 0xfd, 0x14, 0x02, 0xec, 0xad, 0x03,
+	0x51, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0x51, 0xfe, 0x5d, 0x03, 0xb0, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x0d, 0x00, 0x24, 0x40, 0x21, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0x09,
+	0x00, 0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x00,
+	0x01, 0x4f, 0x11, 0x1a, 0x02, 0x06, 0x18, 0x2b, 0x01, 0x01, 0x25, 0x11, 0x25, 0x01, 0x01, 0x05,
+	0x11, 0x05, 0x11, 0x21, 0x15, 0x21, 0x02, 0x01, 0x01, 0xaf, 0xfe, 0xa7, 0x01, 0x59, 0xfe, 0x51,
+	0xfe, 0x50, 0x01, 0x59, 0xfe, 0xa7, 0x03, 0x5f, 0xfc, 0xa1, 0x06, 0x44, 0xfd, 0x14, 0xad, 0xfd,
+	0xda, 0xad, 0xfd, 0x14, 0x02, 0xec, 0xad, 0x02, 0x26, 0xad, 0xfb, 0xb2, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x21, 0xff, 0xe7, 0x03, 0xd6, 0x06, 0x44, 0x00, 0x16, 0x00, 0x20, 0x00, 0x32,
+	0x40, 0x2f, 0x11, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69,
+	0x00, 0x02, 0x00, 0x04, 0x05, 0x02, 0x04, 0x69, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05,
+	0x05, 0x01, 0x61, 0x00, 0x01, 0x05, 0x01, 0x51, 0x23, 0x22, 0x24, 0x24, 0x25, 0x21, 0x06, 0x06,
+	0x1c, 0x2b, 0x13, 0x12, 0x21, 0x32, 0x00, 0x11, 0x10, 0x03, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10,
+	0x00, 0x33, 0x32, 0x17, 0x35, 0x34, 0x26, 0x23, 0x22, 0x01, 0x26, 0x23, 0x22, 0x02, 0x15, 0x14,
+	0x33, 0x32, 0x12, 0x42, 0xa6, 0x01, 0x10, 0xdb, 0x01, 0x03, 0xda, 0xa7, 0xfa, 0x91, 0xa9, 0x01,
+	0x5e, 0xcd, 0x62, 0x6d, 0xd9, 0xab, 0xa2, 0x02, 0x1a, 0x3f, 0x4d, 0x6e, 0xbd, 0x5f, 0x72, 0xc4,
+	0x04, 0xfb, 0x01, 0x49, 0xfe, 0x97, 0xfe, 0xcf, 0xfe, 0x52, 0xfe, 0xd3, 0xe8, 0xba, 0x9f, 0x01,
+	0x0d, 0x01, 0xca, 0x4d, 0x21, 0xaf, 0xdd, 0xfd, 0x8b, 0x48, 0xfe, 0xc4, 0xb9, 0x85, 0x01, 0x40,
+	0x00, 0x02, 0x00, 0x1f, 0x00, 0x00, 0x05, 0xbf, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x08, 0x00, 0x2b,
+	0x40, 0x28, 0x04, 0x01, 0x02, 0x02, 0x01, 0x4b, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x01,
+	0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00,
+	0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x12, 0x04, 0x06, 0x17, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x01,
+	0x15, 0x01, 0x01, 0x21, 0x1f, 0x02, 0x4c, 0x01, 0x06, 0x02, 0x4e, 0xfc, 0xfe, 0xfe, 0x4f, 0x03,
+	0x64, 0xf7, 0x04, 0xd1, 0xfb, 0x2f, 0xf7, 0x04, 0x84, 0xfc, 0x73, 0x00, 0x00, 0x01, 0x00, 0x8c,
+	0xfe, 0x75, 0x06, 0x0a, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27, 0x06, 0x05, 0x02, 0x03,
+	0x00, 0x03, 0x86, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x04, 0x02,
+	0x02, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x07, 0x06, 0x1b, 0x2b, 0x01, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x01, 0x20, 0x94, 0x05, 0x7e, 0x94, 0xfe, 0xcc, 0xfe, 0x12, 0xfe, 0x75, 0x06, 0x88, 0xcb, 0xcb,
+	0xf9, 0x78, 0x06, 0x88, 0xf9, 0x78, 0x00, 0x00, 0x00, 0x01, 0x00, 0x3c, 0xfe, 0xd8, 0x05, 0x70,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x37, 0x40, 0x34, 0x08, 0x02, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x03,
+	0x01, 0x01, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00,
+	0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x02, 0x03, 0x4f,
+	0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x11, 0x14, 0x05, 0x06, 0x19, 0x2b, 0x13, 0x11, 0x01,
+	0x01, 0x35, 0x21, 0x15, 0x21, 0x01, 0x01, 0x21, 0x11, 0x3c, 0x02, 0x0b, 0xfe, 0x0e, 0x04, 0xf6,
+	0xfc, 0x7d, 0x01, 0xc7, 0xfd, 0xc8, 0x04, 0x19, 0xfe, 0xd8, 0x01, 0x00, 0x02, 0x92, 0x02, 0x93,
+	0xcb, 0xcb, 0xfd, 0xa6, 0xfd, 0x35, 0xff, 0x00, 0x00, 0x01, 0x00, 0x68, 0x01, 0xfa, 0x04, 0x43,
+	0x02, 0xa7, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x06, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x68, 0x03, 0xdb, 0x01, 0xfa, 0xad, 0xad, 0x00,
+	0x00, 0x01, 0xff, 0x18, 0xfe, 0xd8, 0x02, 0x3e, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x03, 0x06, 0x17, 0x2b, 0x03, 0x01, 0x33, 0x01, 0xe8, 0x02, 0x71, 0xb5, 0xfd, 0x8f, 0xfe,
+	0xd8, 0x07, 0x53, 0xf8, 0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4b, 0x01, 0xd5, 0x01, 0xee,
+	0x03, 0x79, 0x00, 0x0b, 0x00, 0x18, 0x40, 0x15, 0x00, 0x00, 0x01, 0x01, 0x00, 0x59, 0x00, 0x00,
+	0x00, 0x01, 0x61, 0x00, 0x01, 0x00, 0x01, 0x51, 0x24, 0x22, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x34,
+	0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x4b, 0x7c, 0x56, 0x57, 0x7a, 0x7a,
+	0x57, 0x58, 0x7a, 0x02, 0xa9, 0x55, 0x7b, 0x7b, 0x57, 0x57, 0x7b, 0x7b, 0x00, 0x01, 0x00, 0x00,
+	0xff, 0x3b, 0x04, 0x64, 0x07, 0x2e, 0x00, 0x08, 0x00, 0x1a, 0x40, 0x17, 0x08, 0x03, 0x02, 0x01,
+	0x04, 0x01, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x14,
+	0x02, 0x06, 0x18, 0x2b, 0x13, 0x27, 0x25, 0x01, 0x13, 0x33, 0x01, 0x23, 0x01, 0x5f, 0x5f, 0x01,
+	0x69, 0x01, 0x6e, 0xee, 0x9f, 0xfe, 0xae, 0xa5, 0xfe, 0x7c, 0x01, 0x76, 0xa0, 0xce, 0xfd, 0x81,
+	0x06, 0xc9, 0xf8, 0x0d, 0x02, 0x8b, 0x00, 0x00, 0x00, 0x03, 0x00, 0x3b, 0x00, 0x94, 0x05, 0x79,
+	0x04, 0x0c, 0x00, 0x0e, 0x00, 0x36, 0x00, 0x45, 0x00, 0x3a, 0x40, 0x37, 0x23, 0x01, 0x06, 0x00,
+	0x01, 0x4c, 0x00, 0x07, 0x00, 0x02, 0x07, 0x59, 0x05, 0x01, 0x02, 0x00, 0x00, 0x06, 0x02, 0x00,
+	0x69, 0x00, 0x06, 0x01, 0x03, 0x06, 0x59, 0x00, 0x01, 0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01,
+	0x03, 0x61, 0x04, 0x01, 0x03, 0x01, 0x03, 0x51, 0x25, 0x26, 0x28, 0x28, 0x28, 0x28, 0x25, 0x22,
+	0x08, 0x06, 0x1e, 0x2b, 0x01, 0x26, 0x26, 0x23, 0x22, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x3e,
+	0x02, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x2e, 0x02,
+	0x27, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e, 0x02, 0x33, 0x32, 0x1e, 0x02, 0x17,
+	0x16, 0x16, 0x33, 0x32, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x22, 0x0e, 0x02, 0x02, 0x61, 0x42, 0x7e,
+	0x3a, 0x85, 0x11, 0x24, 0x37, 0x25, 0x27, 0x45, 0x3b, 0x32, 0x99, 0x30, 0x53, 0x51, 0x57, 0x34,
+	0x4c, 0x74, 0x4e, 0x27, 0x29, 0x52, 0x7c, 0x53, 0x2f, 0x52, 0x53, 0x57, 0x34, 0x30, 0x53, 0x52,
+	0x57, 0x34, 0x4d, 0x73, 0x4e, 0x27, 0x29, 0x52, 0x7c, 0x53, 0x2f, 0x53, 0x52, 0x58, 0xa2, 0x42,
+	0x7e, 0x3a, 0x85, 0x11, 0x24, 0x37, 0x25, 0x27, 0x45, 0x3b, 0x32, 0x02, 0x40, 0x69, 0x6d, 0xf3,
+	0x21, 0x48, 0x3d, 0x27, 0x31, 0x48, 0x51, 0xcf, 0x47, 0x6b, 0x47, 0x24, 0x43, 0x72, 0x95, 0x52,
+	0x5c, 0xac, 0x84, 0x50, 0x29, 0x4a, 0x69, 0x41, 0x47, 0x6b, 0x47, 0x24, 0x43, 0x72, 0x95, 0x52,
+	0x5c, 0xac, 0x84, 0x50, 0x29, 0x4a, 0x6a, 0xcf, 0x69, 0x6d, 0xf3, 0x21, 0x48, 0x3d, 0x27, 0x31,
+	0x48, 0x51, 0x00, 0x00, 0x00, 0x01, 0x01, 0x6a, 0x00, 0x00, 0x06, 0x6e, 0x05, 0x04, 0x00, 0x05,
+	0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00,
+	0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x01, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05,
+	0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x6a, 0xc8, 0x04,
+	0x3c, 0x05, 0x04, 0xfb, 0xc4, 0xc8, 0x00, 0x00, 0x00, 0x01, 0x00, 0x93, 0x00, 0x00, 0x05, 0x33,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x20, 0x40, 0x1d, 0x02, 0x01, 0x00, 0x01, 0x00, 0x86, 0x00, 0x03,
+	0x01, 0x01, 0x03, 0x59, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x03, 0x01, 0x51, 0x23, 0x13,
+	0x23, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x21, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x11,
+	0x23, 0x11, 0x34, 0x00, 0x33, 0x32, 0x00, 0x15, 0x05, 0x33, 0xc3, 0xe8, 0xa5, 0xa5, 0xe8, 0xc3,
+	0x01, 0x5b, 0xf5, 0xf6, 0x01, 0x5a, 0x03, 0x77, 0xa5, 0xe9, 0xe8, 0xa6, 0xfc, 0x89, 0x03, 0x78,
+	0xf6, 0x01, 0x5a, 0xfe, 0xa6, 0xf6, 0x00, 0x00, 0x00, 0x01, 0x00, 0x93, 0x00, 0x00, 0x05, 0x33,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x20, 0x40, 0x1d, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01,
+	0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x01, 0x03, 0x51, 0x23, 0x13,
+	0x23, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x23, 0x11, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x11,
+	0x23, 0x11, 0x14, 0x00, 0x33, 0x32, 0x00, 0x35, 0x05, 0x33, 0xc3, 0xe8, 0xa5, 0xa5, 0xe8, 0xc3,
+	0x01, 0x5b, 0xf5, 0xf6, 0x01, 0x5a, 0x05, 0xc8, 0xfc, 0x89, 0xa5, 0xe9, 0xe8, 0xa6, 0x03, 0x77,
+	0xfc, 0x88, 0xf6, 0xfe, 0xa6, 0x01, 0x5a, 0xf6, 0x00, 0x01, 0x00, 0x0c, 0xfe, 0xd8, 0x02, 0x25,
+	0x07, 0x87, 0x00, 0x5d, 0x00, 0x41, 0x40, 0x3e, 0x1d, 0x01, 0x01, 0x02, 0x4c, 0x42, 0x02, 0x05,
+	0x04, 0x02, 0x4c, 0x00, 0x01, 0x02, 0x04, 0x02, 0x01, 0x04, 0x80, 0x00, 0x04, 0x05, 0x02, 0x04,
+	0x05, 0x7e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x69, 0x00, 0x05, 0x03, 0x03, 0x05, 0x59,
+	0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x05, 0x03, 0x51, 0x52, 0x51, 0x48, 0x46, 0x3e, 0x3c,
+	0x19, 0x28, 0x2d, 0x06, 0x06, 0x19, 0x2b, 0x13, 0x2e, 0x05, 0x35, 0x34, 0x3e, 0x04, 0x33, 0x32,
+	0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x36, 0x37, 0x26, 0x23,
+	0x22, 0x0e, 0x02, 0x15, 0x14, 0x1e, 0x06, 0x17, 0x17, 0x1e, 0x03, 0x15, 0x14, 0x0e, 0x04, 0x23,
+	0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e, 0x02, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x06, 0x07, 0x16,
+	0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x04, 0x27, 0xc0, 0x01, 0x04, 0x04, 0x04, 0x04, 0x02,
+	0x08, 0x15, 0x23, 0x35, 0x4a, 0x31, 0x1b, 0x32, 0x25, 0x16, 0x08, 0x12, 0x1b, 0x13, 0x0a, 0x14,
+	0x11, 0x0b, 0x06, 0x04, 0x09, 0x09, 0x18, 0x1f, 0x12, 0x07, 0x03, 0x05, 0x06, 0x07, 0x07, 0x05,
+	0x04, 0x01, 0x06, 0x02, 0x04, 0x04, 0x03, 0x08, 0x15, 0x23, 0x35, 0x4a, 0x31, 0x1b, 0x32, 0x25,
+	0x16, 0x08, 0x12, 0x1b, 0x13, 0x0a, 0x14, 0x11, 0x0b, 0x06, 0x04, 0x09, 0x09, 0x18, 0x1f, 0x12,
+	0x07, 0x04, 0x07, 0x07, 0x07, 0x06, 0x01, 0x03, 0x91, 0x1d, 0x51, 0x5f, 0x66, 0x64, 0x5d, 0x26,
+	0x31, 0x6c, 0x6a, 0x60, 0x4a, 0x2b, 0x11, 0x20, 0x2f, 0x1d, 0x14, 0x24, 0x1d, 0x11, 0x05, 0x0f,
+	0x1a, 0x15, 0x08, 0x21, 0x08, 0x05, 0x40, 0x5e, 0x6b, 0x2b, 0x0a, 0x3d, 0x56, 0x6a, 0x6e, 0x6c,
+	0x5b, 0x45, 0x0f, 0x8b, 0x2f, 0x89, 0x96, 0x93, 0x39, 0x31, 0x6c, 0x6a, 0x60, 0x4a, 0x2b, 0x11,
+	0x20, 0x2f, 0x1d, 0x13, 0x25, 0x1d, 0x11, 0x05, 0x0f, 0x1a, 0x15, 0x08, 0x21, 0x08, 0x05, 0x40,
+	0x5e, 0x6b, 0x2b, 0x0e, 0x5f, 0x83, 0x95, 0x89, 0x6b, 0x17, 0x00, 0x00, 0x00, 0x02, 0x00, 0x45,
+	0x00, 0x92, 0x04, 0x1f, 0x04, 0x0e, 0x00, 0x15, 0x00, 0x2b, 0x00, 0x4c, 0x40, 0x49, 0x0c, 0x0a,
+	0x02, 0x03, 0x00, 0x15, 0x01, 0x02, 0x02, 0x01, 0x22, 0x20, 0x02, 0x07, 0x04, 0x2b, 0x17, 0x02,
+	0x06, 0x05, 0x04, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x01, 0x00, 0x03, 0x69, 0x00, 0x01, 0x00, 0x02,
+	0x04, 0x01, 0x02, 0x69, 0x00, 0x04, 0x00, 0x07, 0x05, 0x04, 0x07, 0x69, 0x00, 0x05, 0x06, 0x06,
+	0x05, 0x59, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x05, 0x06, 0x51, 0x23, 0x24, 0x23, 0x24,
+	0x23, 0x24, 0x23, 0x22, 0x08, 0x06, 0x1e, 0x2b, 0x13, 0x23, 0x10, 0x33, 0x32, 0x17, 0x17, 0x16,
+	0x33, 0x32, 0x35, 0x35, 0x33, 0x10, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x15, 0x11, 0x23,
+	0x10, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x35, 0x35, 0x33, 0x10, 0x23, 0x22, 0x27, 0x27,
+	0x26, 0x23, 0x22, 0x15, 0xb6, 0x71, 0xee, 0x5d, 0xa2, 0x4a, 0x94, 0x38, 0x66, 0x71, 0xee, 0x5d,
+	0xa2, 0x4a, 0x93, 0x38, 0x67, 0x71, 0xee, 0x5d, 0xa2, 0x4a, 0x94, 0x38, 0x66, 0x71, 0xee, 0x5d,
+	0xa2, 0x4a, 0x93, 0x38, 0x67, 0x02, 0xb3, 0x01, 0x5b, 0x56, 0x28, 0x4e, 0x90, 0x09, 0xfe, 0xa5,
+	0x56, 0x28, 0x4e, 0x90, 0xfe, 0x09, 0x01, 0x5c, 0x57, 0x27, 0x4e, 0x8f, 0x0a, 0xfe, 0xa4, 0x57,
+	0x27, 0x4e, 0x8f, 0x00, 0x00, 0x01, 0x00, 0x5e, 0x00, 0x31, 0x04, 0x06, 0x04, 0x6f, 0x00, 0x13,
+	0x00, 0x6c, 0x4b, 0xb0, 0x0b, 0x50, 0x58, 0x40, 0x29, 0x00, 0x04, 0x03, 0x03, 0x04, 0x70, 0x00,
+	0x09, 0x00, 0x00, 0x09, 0x71, 0x05, 0x01, 0x03, 0x06, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x07,
+	0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x07, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x08, 0x01, 0x00, 0x01,
+	0x00, 0x4f, 0x1b, 0x40, 0x27, 0x00, 0x04, 0x03, 0x04, 0x85, 0x00, 0x09, 0x00, 0x09, 0x86, 0x05,
+	0x01, 0x03, 0x06, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x07, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57,
+	0x07, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x08, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x59, 0x40, 0x0e, 0x13,
+	0x12, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x0a, 0x06, 0x1f, 0x2b, 0x01, 0x21,
+	0x35, 0x21, 0x37, 0x21, 0x35, 0x21, 0x37, 0x33, 0x07, 0x21, 0x15, 0x21, 0x07, 0x21, 0x15, 0x21,
+	0x07, 0x23, 0x01, 0x80, 0xfe, 0xde, 0x01, 0x61, 0x4b, 0xfe, 0x54, 0x01, 0xed, 0x4e, 0x9c, 0x4f,
+	0x01, 0x20, 0xfe, 0xa0, 0x4a, 0x01, 0xaa, 0xfe, 0x14, 0x4f, 0x9c, 0x01, 0x1f, 0xc2, 0xde, 0xc3,
+	0xed, 0xed, 0xc3, 0xde, 0xc2, 0xee, 0x00, 0x00, 0x00, 0x03, 0x00, 0x68, 0x00, 0x70, 0x04, 0x43,
+	0x04, 0x33, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x40, 0x40, 0x3d, 0x00, 0x04, 0x08, 0x01,
+	0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00,
+	0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x08,
+	0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x06, 0x17, 0x2b, 0x37, 0x35, 0x21, 0x15, 0x01, 0x35,
+	0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x68, 0x03, 0xdb, 0xfc, 0x25, 0x03, 0xdb, 0xfc, 0x25, 0x03,
+	0xdb, 0x70, 0xb9, 0xb9, 0x01, 0x85, 0xb9, 0xb9, 0x01, 0x85, 0xb9, 0xb9, 0x00, 0x02, 0x00, 0x45,
+	0x00, 0x00, 0x04, 0x1e, 0x05, 0x00, 0x00, 0x03, 0x00, 0x0a, 0x00, 0x27, 0x40, 0x24, 0x0a, 0x08,
+	0x07, 0x06, 0x05, 0x04, 0x06, 0x00, 0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x06, 0x17, 0x2b, 0x33, 0x35, 0x21, 0x15, 0x11, 0x01, 0x01, 0x15, 0x01, 0x15, 0x01, 0x46, 0x03,
+	0xd8, 0xfc, 0x27, 0x03, 0xd9, 0xfd, 0xdd, 0x02, 0x23, 0xc3, 0xc3, 0x01, 0x28, 0x01, 0xec, 0x01,
+	0xec, 0xda, 0xfe, 0xef, 0x02, 0xfe, 0xef, 0x00, 0x00, 0x02, 0x00, 0x46, 0x00, 0x00, 0x04, 0x1f,
+	0x05, 0x00, 0x00, 0x03, 0x00, 0x0a, 0x00, 0x27, 0x40, 0x24, 0x0a, 0x09, 0x08, 0x07, 0x05, 0x04,
+	0x06, 0x00, 0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01,
+	0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33,
+	0x35, 0x21, 0x15, 0x01, 0x01, 0x35, 0x01, 0x35, 0x01, 0x01, 0x46, 0x03, 0xd8, 0xfc, 0x28, 0x02,
+	0x23, 0xfd, 0xdd, 0x03, 0xd9, 0xfc, 0x27, 0xc3, 0xc3, 0x02, 0x02, 0x01, 0x11, 0x02, 0x01, 0x11,
+	0xda, 0xfe, 0x14, 0xfe, 0x14, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x8a, 0x00, 0x00, 0x04, 0x4c,
+	0x04, 0xa0, 0x00, 0x04, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x08, 0x07, 0x06, 0x04, 0x03, 0x02,
+	0x06, 0x01, 0x4a, 0x02, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f,
+	0x00, 0x00, 0x01, 0x00, 0x4f, 0x05, 0x05, 0x05, 0x09, 0x05, 0x09, 0x10, 0x03, 0x06, 0x17, 0x2b,
+	0x21, 0x21, 0x11, 0x01, 0x01, 0x03, 0x11, 0x01, 0x01, 0x11, 0x04, 0x4c, 0xfc, 0x3e, 0x01, 0xe1,
+	0x01, 0xe1, 0xb9, 0xfe, 0xd8, 0xfe, 0xd8, 0x02, 0xbf, 0x01, 0xe1, 0xfe, 0x1f, 0xfd, 0xfa, 0x01,
+	0xb9, 0x01, 0x28, 0xfe, 0xd8, 0xfe, 0x47, 0x00, 0x00, 0x01, 0x00, 0x55, 0x01, 0x14, 0x04, 0x54,
+	0x03, 0x78, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x02, 0x00, 0x86, 0x00, 0x01, 0x02,
+	0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x01, 0x02, 0x4f, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x01, 0x11, 0x23, 0x11, 0x21, 0x15,
+	0x01, 0x01, 0xac, 0x03, 0xff, 0x02, 0xb5, 0xfe, 0x5f, 0x02, 0x64, 0xc3, 0x00, 0x01, 0x01, 0xe5,
+	0xfe, 0x50, 0x04, 0x2c, 0x06, 0x50, 0x00, 0x19, 0x00, 0x5b, 0xb6, 0x10, 0x0d, 0x02, 0x01, 0x02,
+	0x01, 0x4c, 0x4b, 0xb0, 0x0d, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x01, 0x02, 0x03, 0x02, 0x01, 0x72,
+	0x04, 0x01, 0x03, 0x03, 0x84, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61,
+	0x00, 0x02, 0x00, 0x02, 0x51, 0x1b, 0x40, 0x1d, 0x00, 0x01, 0x02, 0x03, 0x02, 0x01, 0x03, 0x80,
+	0x04, 0x01, 0x03, 0x03, 0x84, 0x00, 0x00, 0x02, 0x02, 0x00, 0x59, 0x00, 0x00, 0x00, 0x02, 0x61,
+	0x00, 0x02, 0x00, 0x02, 0x51, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x25, 0x24,
+	0x24, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x11, 0x10, 0x37, 0x12, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06,
+	0x23, 0x22, 0x35, 0x34, 0x37, 0x37, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17, 0x16, 0x15, 0x11, 0x01,
+	0xe5, 0x3b, 0x60, 0xde, 0x5e, 0x70, 0x4e, 0x3c, 0x7f, 0x07, 0x07, 0x15, 0x0b, 0x56, 0x0e, 0x1f,
+	0xfe, 0x50, 0x04, 0xb3, 0x01, 0xa5, 0xa2, 0x01, 0x06, 0x63, 0x53, 0x40, 0x51, 0x90, 0x0c, 0x15,
+	0x14, 0x06, 0x8d, 0x2f, 0x73, 0xf8, 0xaa, 0xfb, 0x4d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa2,
+	0xfe, 0x50, 0x02, 0xe8, 0x07, 0x8f, 0x00, 0x19, 0x00, 0x59, 0xb6, 0x10, 0x0d, 0x02, 0x02, 0x01,
+	0x01, 0x4c, 0x4b, 0xb0, 0x0d, 0x50, 0x58, 0x40, 0x1c, 0x04, 0x01, 0x03, 0x01, 0x03, 0x85, 0x00,
+	0x01, 0x02, 0x02, 0x01, 0x70, 0x00, 0x02, 0x00, 0x00, 0x02, 0x59, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x02, 0x00, 0x52, 0x1b, 0x40, 0x1b, 0x04, 0x01, 0x03, 0x01, 0x03, 0x85, 0x00, 0x01,
+	0x02, 0x01, 0x85, 0x00, 0x02, 0x00, 0x00, 0x02, 0x59, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x02, 0x00, 0x52, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x25, 0x24, 0x24, 0x05,
+	0x06, 0x19, 0x2b, 0x01, 0x11, 0x10, 0x07, 0x02, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32,
+	0x15, 0x14, 0x07, 0x07, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x26, 0x35, 0x11, 0x02, 0xe8, 0x3b,
+	0x5f, 0xde, 0x5e, 0x70, 0x4e, 0x3c, 0x7f, 0x07, 0x07, 0x15, 0x0b, 0x56, 0x0f, 0x1f, 0x07, 0x8f,
+	0xfa, 0x0e, 0xfe, 0x5b, 0xa2, 0xfe, 0xfa, 0x63, 0x54, 0x3f, 0x52, 0x91, 0x0b, 0x15, 0x15, 0x06,
+	0x8d, 0x30, 0x73, 0xf7, 0xaa, 0x05, 0xf2, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd,
+	0x03, 0x3a, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x04, 0xcd, 0x02, 0xa6, 0x94, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x01,
+	0x33, 0x11, 0x23, 0x02, 0x1d, 0x94, 0x94, 0x07, 0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x02, 0x1d,
+	0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x05, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x02, 0x01, 0x02,
+	0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x00, 0x01,
+	0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b, 0x01, 0x21, 0x15, 0x21, 0x11, 0x23, 0x02, 0x1d,
+	0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x03, 0x3a, 0x94, 0xfb, 0xaa, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x02, 0xb1, 0x03, 0x3a, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x02, 0x01,
+	0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x00,
+	0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x11, 0x35,
+	0x21, 0x11, 0x23, 0x11, 0x02, 0xb1, 0x94, 0x02, 0xa6, 0x94, 0xfb, 0x16, 0x04, 0x56, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x1d, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f,
+	0x00, 0x02, 0x01, 0x02, 0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b, 0x01, 0x33, 0x11, 0x21,
+	0x15, 0x21, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0x50, 0x07, 0x8f, 0xfb, 0xab, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f,
+	0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06,
+	0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x02, 0x1d, 0x94, 0x02, 0xa6, 0x94, 0x04, 0x55,
+	0xfb, 0x17, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x03, 0x02, 0x03, 0x86, 0x00, 0x01,
+	0x02, 0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x01, 0x02, 0x4f, 0x11, 0x11,
+	0x11, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x02, 0x1d,
+	0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x07, 0x8f, 0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x2a, 0x40, 0x27,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x03, 0x02, 0x86, 0x00, 0x00, 0x03, 0x03, 0x00, 0x57,
+	0x00, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11,
+	0x02, 0x1d, 0x94, 0x94, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xf6, 0xc1, 0x04, 0x56, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x07, 0x00, 0x27, 0x40, 0x24,
+	0x00, 0x01, 0x00, 0x01, 0x86, 0x04, 0x01, 0x03, 0x00, 0x00, 0x03, 0x57, 0x04, 0x01, 0x03, 0x03,
+	0x00, 0x5f, 0x02, 0x01, 0x00, 0x03, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11,
+	0x11, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x15, 0x21, 0x11, 0x23, 0x11, 0x21, 0x35, 0x04, 0xcd, 0xfd,
+	0xe3, 0x94, 0xfd, 0xe4, 0x03, 0x3a, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0x94, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x27, 0x40, 0x24, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01,
+	0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19,
+	0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0x02, 0xa6,
+	0x94, 0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x04, 0x03,
+	0x04, 0x86, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06,
+	0x05, 0x02, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23,
+	0x11, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0xab,
+	0x94, 0xfb, 0xaa, 0x04, 0x56, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x02, 0x12, 0x04, 0xcd,
+	0x03, 0xce, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x00, 0x04, 0x01, 0x01, 0x02,
+	0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x05, 0x01,
+	0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x06, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x04,
+	0xcd, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8, 0x94, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x22,
+	0x40, 0x1f, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x05, 0x03, 0x04, 0x03, 0x01, 0x01, 0x76, 0x04,
+	0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x06,
+	0x17, 0x2b, 0x01, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x01, 0x89, 0x94, 0x94, 0x94, 0xfe,
+	0x50, 0x09, 0x3f, 0xf6, 0xc1, 0x09, 0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d,
+	0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x09, 0x00, 0x2e, 0x40, 0x2b, 0x05, 0x01, 0x04, 0x03,
+	0x04, 0x86, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57,
+	0x00, 0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x02, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09,
+	0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15,
+	0x21, 0x11, 0x02, 0x1d, 0x02, 0xb0, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0xfe, 0x50, 0x05, 0x7e,
+	0x94, 0x94, 0x94, 0xfc, 0x3e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd,
+	0x03, 0x3a, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x05, 0x04, 0x02, 0x02, 0x01, 0x02, 0x86, 0x00,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x00, 0x01, 0x4f,
+	0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x01, 0x11,
+	0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0x89, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x94,
+	0xfe, 0x50, 0x04, 0xea, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x00, 0x00, 0x02, 0x01, 0x89,
+	0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x33, 0x40, 0x30, 0x04, 0x01,
+	0x01, 0x03, 0x01, 0x86, 0x06, 0x01, 0x02, 0x00, 0x00, 0x05, 0x02, 0x00, 0x67, 0x00, 0x05, 0x03,
+	0x03, 0x05, 0x57, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x00, 0x03, 0x05, 0x03, 0x4f, 0x00, 0x00, 0x0b,
+	0x0a, 0x09, 0x08, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x06, 0x18, 0x2b, 0x01,
+	0x15, 0x21, 0x11, 0x23, 0x11, 0x01, 0x21, 0x11, 0x23, 0x11, 0x21, 0x04, 0xcd, 0xfd, 0x50, 0x94,
+	0x03, 0x44, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x03, 0xce, 0x94, 0xfb, 0x16, 0x05, 0x7e, 0xfe, 0x44,
+	0xfc, 0x3e, 0x04, 0x56, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1, 0x03, 0xce, 0x00, 0x09,
+	0x00, 0x2e, 0x40, 0x2b, 0x00, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x05, 0x01, 0x04, 0x03, 0x00,
+	0x04, 0x67, 0x00, 0x03, 0x02, 0x02, 0x03, 0x57, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x03,
+	0x02, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b,
+	0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0xb1, 0x94, 0xfd, 0xe3, 0x02,
+	0x1d, 0x03, 0x3a, 0x94, 0xfa, 0x82, 0x03, 0xc2, 0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x03, 0x45, 0x03, 0x3a, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x05, 0x04, 0x02, 0x02,
+	0x00, 0x02, 0x86, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x03, 0x01,
+	0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06,
+	0x1a, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0x89, 0xfe, 0x77,
+	0x03, 0x45, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0xfb, 0x16, 0x04, 0x56, 0xfb, 0xaa, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x03, 0xce, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x38,
+	0x40, 0x35, 0x04, 0x01, 0x01, 0x02, 0x01, 0x86, 0x00, 0x03, 0x07, 0x01, 0x05, 0x00, 0x03, 0x05,
+	0x67, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x00,
+	0x02, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05,
+	0x00, 0x05, 0x11, 0x11, 0x08, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35,
+	0x21, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0xfe, 0x77, 0x03, 0x45, 0x94, 0x02, 0x12, 0x94, 0xfb,
+	0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0xfa, 0x82, 0x04, 0xea, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d,
+	0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x67, 0x00, 0x03, 0x04, 0x04, 0x03, 0x57, 0x00,
+	0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x10, 0x05, 0x06,
+	0x1b, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x02, 0x1d, 0x94, 0x02,
+	0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0x50, 0x07, 0x8f, 0xfc, 0x3f, 0x94, 0x94, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x01, 0x89, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x23, 0x40, 0x20,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x03, 0x01, 0x01, 0x04, 0x04, 0x01, 0x57, 0x03, 0x01, 0x01,
+	0x01, 0x04, 0x5f, 0x00, 0x04, 0x01, 0x04, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x10, 0x05, 0x06, 0x1b,
+	0x2b, 0x01, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x01, 0x89, 0x94, 0x94, 0x94,
+	0x01, 0x88, 0xfc, 0xbc, 0x07, 0x8f, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x01, 0x89, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x2a,
+	0x40, 0x27, 0x04, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x02, 0x00, 0x00, 0x05, 0x02, 0x00, 0x67,
+	0x00, 0x05, 0x03, 0x03, 0x05, 0x57, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x00, 0x03, 0x05, 0x03, 0x4f,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06, 0x06, 0x1c, 0x2b, 0x01, 0x21, 0x11, 0x33, 0x11, 0x21,
+	0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x04, 0xcd, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfc, 0xbc, 0x94,
+	0x02, 0xb0, 0x03, 0x3a, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0x05, 0x7d, 0xfb, 0x17, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0x12, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x2e, 0x40, 0x2b,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x05, 0x01, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03,
+	0x02, 0x02, 0x03, 0x57, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x03, 0x02, 0x4f, 0x00, 0x00,
+	0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x11, 0x35, 0x21, 0x11,
+	0x33, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0x1d, 0x94, 0xfd, 0x4f, 0x02, 0x1d, 0x03, 0x3a, 0x94,
+	0x03, 0xc1, 0xfa, 0x83, 0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x03, 0x45,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x23, 0x40, 0x20, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x04, 0x01,
+	0x01, 0x03, 0x03, 0x01, 0x57, 0x04, 0x01, 0x01, 0x01, 0x03, 0x5f, 0x00, 0x03, 0x01, 0x03, 0x4f,
+	0x11, 0x11, 0x11, 0x11, 0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11,
+	0x21, 0x35, 0x21, 0x01, 0x89, 0x94, 0x94, 0x94, 0xfc, 0xbb, 0x01, 0x89, 0x07, 0x8f, 0xfb, 0xab,
+	0x04, 0x55, 0xfb, 0x17, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x02, 0x12, 0x03, 0x45,
+	0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x38, 0x40, 0x35, 0x04, 0x01, 0x01, 0x00, 0x01, 0x85,
+	0x00, 0x00, 0x06, 0x01, 0x02, 0x03, 0x00, 0x02, 0x67, 0x00, 0x03, 0x05, 0x05, 0x03, 0x57, 0x00,
+	0x03, 0x03, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x03, 0x05, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x06, 0x0b,
+	0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x08, 0x06, 0x18, 0x2b,
+	0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x01, 0x35, 0x21, 0x11, 0x33, 0x11, 0x01, 0x89, 0x94, 0xfd,
+	0xe3, 0x02, 0xb1, 0x94, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfb, 0xab, 0xfe, 0xd8, 0x94, 0x04, 0xe9,
+	0xfa, 0x83, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x2e, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x05, 0x04, 0x05, 0x86, 0x00, 0x01,
+	0x00, 0x02, 0x03, 0x01, 0x02, 0x67, 0x00, 0x03, 0x04, 0x04, 0x03, 0x57, 0x00, 0x03, 0x03, 0x04,
+	0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06, 0x06, 0x1c, 0x2b,
+	0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x23, 0x02, 0x1d, 0x94, 0x02,
+	0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x07, 0x8f, 0xfc, 0x3f, 0x94, 0x94, 0x94, 0xfc,
+	0x3e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x0b, 0x00, 0x37, 0x40, 0x34, 0x02, 0x01, 0x00, 0x03, 0x00, 0x85, 0x07, 0x05, 0x06, 0x03,
+	0x01, 0x04, 0x01, 0x86, 0x00, 0x03, 0x04, 0x04, 0x03, 0x57, 0x00, 0x03, 0x03, 0x04, 0x5f, 0x00,
+	0x04, 0x03, 0x04, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b, 0x0a, 0x09, 0x08, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x08, 0x06, 0x17, 0x2b, 0x01, 0x11, 0x33, 0x11, 0x33,
+	0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x01, 0x89, 0x94, 0x94, 0x94, 0x01, 0x88, 0xfe, 0x78,
+	0xfe, 0x50, 0x09, 0x3f, 0xf6, 0xc1, 0x09, 0x3f, 0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0f,
+	0x00, 0x32, 0x40, 0x2f, 0x03, 0x01, 0x00, 0x04, 0x00, 0x85, 0x06, 0x01, 0x01, 0x05, 0x01, 0x86,
+	0x00, 0x04, 0x00, 0x02, 0x07, 0x04, 0x02, 0x67, 0x00, 0x07, 0x05, 0x05, 0x07, 0x57, 0x00, 0x07,
+	0x07, 0x05, 0x5f, 0x00, 0x05, 0x07, 0x05, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10,
+	0x08, 0x06, 0x1e, 0x2b, 0x01, 0x33, 0x11, 0x23, 0x01, 0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x21,
+	0x11, 0x23, 0x11, 0x21, 0x01, 0x89, 0x94, 0x94, 0x03, 0x44, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfe,
+	0x78, 0x94, 0x02, 0x1c, 0x07, 0x8f, 0xf6, 0xc1, 0x04, 0xea, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44,
+	0xfc, 0x3e, 0x04, 0x56, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x34, 0x40, 0x31, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x03, 0x02, 0x86, 0x00, 0x00,
+	0x06, 0x01, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x03, 0x03, 0x04, 0x57, 0x00, 0x04, 0x04,
+	0x03, 0x5f, 0x00, 0x03, 0x04, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x35,
+	0x21, 0x35, 0x02, 0x1d, 0x94, 0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xf6,
+	0xc1, 0x03, 0xc2, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x35, 0x40, 0x32, 0x04, 0x01, 0x02, 0x01, 0x02, 0x85,
+	0x07, 0x05, 0x06, 0x03, 0x03, 0x00, 0x03, 0x86, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x08, 0x08, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b,
+	0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08, 0x06, 0x19, 0x2b, 0x01, 0x11, 0x21,
+	0x35, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x01, 0x89, 0xfe, 0x77, 0x01, 0x89, 0x94,
+	0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0x04, 0x55, 0xf6, 0xc1, 0x09, 0x3f, 0xf6, 0xc1, 0x00,
+	0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x0f,
+	0x00, 0x42, 0x40, 0x3f, 0x06, 0x01, 0x04, 0x03, 0x04, 0x85, 0x07, 0x01, 0x01, 0x02, 0x01, 0x86,
+	0x00, 0x03, 0x09, 0x01, 0x05, 0x00, 0x03, 0x05, 0x67, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00,
+	0x00, 0x00, 0x02, 0x5f, 0x08, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x0f, 0x0e,
+	0x0d, 0x0c, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11,
+	0x0a, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35, 0x21, 0x11, 0x33, 0x11,
+	0x13, 0x33, 0x11, 0x23, 0x02, 0x1d, 0x94, 0xfe, 0x77, 0x01, 0x89, 0x94, 0x94, 0x94, 0x94, 0x02,
+	0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0x03, 0xc1, 0xfb, 0xab, 0x04, 0x55, 0xf6,
+	0xc1, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x03,
+	0x00, 0x0b, 0x00, 0x39, 0x40, 0x36, 0x00, 0x04, 0x03, 0x04, 0x86, 0x00, 0x00, 0x06, 0x01, 0x01,
+	0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07,
+	0x05, 0x02, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b, 0x0a, 0x09,
+	0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x08, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21,
+	0x15, 0x01, 0x35, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x04, 0xcd, 0xfb, 0x33, 0x04, 0xcd, 0xfd,
+	0xe4, 0x94, 0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8, 0x94, 0x94, 0xfc, 0x3e, 0x03, 0xc2, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27,
+	0x04, 0x01, 0x02, 0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01,
+	0x5f, 0x06, 0x05, 0x03, 0x03, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11,
+	0x23, 0x11, 0x23, 0x11, 0x04, 0xcd, 0xfe, 0x78, 0x94, 0x94, 0x94, 0x02, 0xa6, 0x94, 0x94, 0xfb,
+	0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x04, 0x56, 0x00, 0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x03, 0xce, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0f, 0x00, 0x40, 0x40, 0x3d, 0x06, 0x01, 0x03, 0x04,
+	0x03, 0x86, 0x00, 0x00, 0x08, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x07, 0x01, 0x02, 0x04, 0x04,
+	0x02, 0x57, 0x07, 0x01, 0x02, 0x02, 0x04, 0x5f, 0x05, 0x09, 0x02, 0x04, 0x02, 0x04, 0x4f, 0x04,
+	0x04, 0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x04, 0x09, 0x04, 0x09, 0x08, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x01, 0x35,
+	0x21, 0x11, 0x23, 0x11, 0x21, 0x21, 0x11, 0x23, 0x11, 0x21, 0x04, 0xcd, 0xfb, 0x33, 0x02, 0x1d,
+	0x94, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8, 0x94, 0xfb,
+	0xaa, 0x03, 0xc2, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x00, 0x02, 0x00, 0x00, 0x02, 0x12, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x37, 0x40, 0x34, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02,
+	0x01, 0x00, 0x06, 0x01, 0x03, 0x04, 0x00, 0x03, 0x67, 0x00, 0x04, 0x05, 0x05, 0x04, 0x57, 0x00,
+	0x04, 0x04, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x04, 0x05, 0x4f, 0x08, 0x08, 0x00, 0x00, 0x08, 0x0b,
+	0x08, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08, 0x06, 0x19, 0x2b, 0x11,
+	0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x02, 0x1d, 0x94, 0x02, 0x1c,
+	0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfc, 0x3f, 0x94, 0xfe, 0xd8, 0x94, 0x94,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2c, 0x40, 0x29,
+	0x03, 0x01, 0x01, 0x00, 0x01, 0x85, 0x04, 0x02, 0x02, 0x00, 0x05, 0x05, 0x00, 0x57, 0x04, 0x02,
+	0x02, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x00, 0x05, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11,
+	0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x89, 0x94, 0x94, 0x94, 0x01, 0x88, 0x02, 0xa6, 0x94,
+	0x04, 0x55, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00,
+	0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x3e, 0x40, 0x3b,
+	0x04, 0x01, 0x01, 0x00, 0x01, 0x85, 0x05, 0x01, 0x00, 0x03, 0x08, 0x02, 0x02, 0x06, 0x00, 0x02,
+	0x67, 0x00, 0x06, 0x07, 0x07, 0x06, 0x57, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x09, 0x01, 0x07, 0x06,
+	0x07, 0x4f, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x0b, 0x0a, 0x09, 0x08,
+	0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x0a, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11,
+	0x33, 0x11, 0x21, 0x21, 0x11, 0x33, 0x11, 0x21, 0x01, 0x35, 0x21, 0x15, 0x01, 0x89, 0x94, 0x02,
+	0xb0, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfb,
+	0xab, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x3d, 0x40, 0x3a, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x00, 0x06, 0x05, 0x06, 0x86, 0x02, 0x01, 0x00, 0x0a, 0x09, 0x02, 0x03, 0x04, 0x00, 0x03,
+	0x67, 0x08, 0x01, 0x04, 0x05, 0x05, 0x04, 0x57, 0x08, 0x01, 0x04, 0x04, 0x05, 0x5f, 0x07, 0x01,
+	0x05, 0x04, 0x05, 0x4f, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x0b, 0x06, 0x1f, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21,
+	0x15, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0x1d, 0x94, 0x02, 0x1c,
+	0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03, 0x3a, 0x94, 0x03, 0xc1,
+	0xfc, 0x3f, 0x94, 0x94, 0x94, 0xfc, 0x3e, 0x03, 0xc2, 0x94, 0x94, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x38, 0x40, 0x35, 0x04, 0x01, 0x02, 0x01,
+	0x02, 0x85, 0x0a, 0x09, 0x02, 0x07, 0x00, 0x07, 0x86, 0x05, 0x03, 0x02, 0x01, 0x00, 0x00, 0x01,
+	0x57, 0x05, 0x03, 0x02, 0x01, 0x01, 0x00, 0x5f, 0x08, 0x06, 0x02, 0x00, 0x01, 0x00, 0x4f, 0x00,
+	0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x06,
+	0x1f, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0x89, 0xfe, 0x77, 0x01, 0x89, 0x94, 0x94, 0x94, 0x01,
+	0x88, 0xfe, 0x78, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x04, 0x55,
+	0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x11, 0x00, 0x17, 0x00, 0x4f,
+	0x40, 0x4c, 0x07, 0x01, 0x04, 0x03, 0x04, 0x85, 0x0a, 0x01, 0x01, 0x02, 0x01, 0x86, 0x08, 0x01,
+	0x03, 0x06, 0x0d, 0x02, 0x05, 0x00, 0x03, 0x05, 0x67, 0x0b, 0x01, 0x00, 0x02, 0x02, 0x00, 0x57,
+	0x0b, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x09, 0x0c, 0x02, 0x02, 0x00, 0x02, 0x4f, 0x06, 0x06, 0x00,
+	0x00, 0x17, 0x16, 0x15, 0x14, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e, 0x0d, 0x0c, 0x06, 0x0b, 0x06,
+	0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x0e, 0x06, 0x18, 0x2b, 0x11,
+	0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x21, 0x11, 0x33, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x23, 0x11, 0x21, 0x02, 0x1d, 0x94, 0xfe, 0x77, 0x01, 0x89, 0x94, 0x02,
+	0xb0, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x02, 0x12, 0x94, 0xfb, 0xaa,
+	0x03, 0xc2, 0x01, 0x28, 0x94, 0x03, 0xc1, 0xfb, 0xab, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0xfc,
+	0x3e, 0x04, 0x56, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xf0, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x11, 0x11, 0x21, 0x11, 0x04, 0xcd, 0x02,
+	0xf0, 0x04, 0x9f, 0xfb, 0x61, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x02, 0xf0, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01,
+	0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x04, 0xcd, 0xfb, 0x33, 0x02,
+	0xf0, 0xfb, 0x60, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02,
+	0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x04, 0xcd, 0xfb, 0x33, 0x07, 0x8f, 0xf6, 0xc1, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0x67, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11,
+	0x21, 0x11, 0x21, 0x02, 0x67, 0xfd, 0x99, 0x07, 0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x02, 0x66,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x01, 0x21, 0x11, 0x21, 0x02,
+	0x66, 0x02, 0x67, 0xfd, 0x99, 0x07, 0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x00, 0x00, 0x12, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x06, 0xcb, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b, 0x00, 0x2f, 0x00, 0x33,
+	0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0xf9, 0x40, 0xf6, 0x14, 0x0a,
+	0x02, 0x00, 0x2e, 0x15, 0x29, 0x0b, 0x24, 0x05, 0x01, 0x02, 0x00, 0x01, 0x67, 0x16, 0x0c, 0x02,
+	0x02, 0x2f, 0x17, 0x2a, 0x0d, 0x25, 0x05, 0x03, 0x04, 0x02, 0x03, 0x67, 0x18, 0x0e, 0x02, 0x04,
+	0x30, 0x19, 0x2b, 0x0f, 0x26, 0x05, 0x05, 0x06, 0x04, 0x05, 0x67, 0x1a, 0x10, 0x02, 0x06, 0x31,
+	0x1b, 0x2c, 0x11, 0x27, 0x05, 0x07, 0x08, 0x06, 0x07, 0x67, 0x1c, 0x12, 0x02, 0x08, 0x32, 0x1d,
+	0x2d, 0x13, 0x28, 0x05, 0x09, 0x1e, 0x08, 0x09, 0x67, 0x22, 0x20, 0x02, 0x1e, 0x1f, 0x1f, 0x1e,
+	0x57, 0x22, 0x20, 0x02, 0x1e, 0x1e, 0x1f, 0x5f, 0x35, 0x23, 0x34, 0x21, 0x33, 0x05, 0x1f, 0x1e,
+	0x1f, 0x4f, 0x44, 0x44, 0x40, 0x40, 0x3c, 0x3c, 0x38, 0x38, 0x34, 0x34, 0x30, 0x30, 0x2c, 0x2c,
+	0x28, 0x28, 0x24, 0x24, 0x20, 0x20, 0x1c, 0x1c, 0x18, 0x18, 0x14, 0x14, 0x10, 0x10, 0x0c, 0x0c,
+	0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x44, 0x47, 0x44, 0x47, 0x46, 0x45, 0x40, 0x43, 0x40, 0x43,
+	0x42, 0x41, 0x3c, 0x3f, 0x3c, 0x3f, 0x3e, 0x3d, 0x38, 0x3b, 0x38, 0x3b, 0x3a, 0x39, 0x34, 0x37,
+	0x34, 0x37, 0x36, 0x35, 0x30, 0x33, 0x30, 0x33, 0x32, 0x31, 0x2c, 0x2f, 0x2c, 0x2f, 0x2e, 0x2d,
+	0x28, 0x2b, 0x28, 0x2b, 0x2a, 0x29, 0x24, 0x27, 0x24, 0x27, 0x26, 0x25, 0x20, 0x23, 0x20, 0x23,
+	0x22, 0x21, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x14, 0x17,
+	0x14, 0x17, 0x16, 0x15, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d,
+	0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x36, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33,
+	0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33,
+	0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0xce, 0x01, 0xce,
+	0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0xcb, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0x01,
+	0xce, 0xfe, 0x65, 0xce, 0xcb, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce,
+	0xfc, 0xce, 0xcd, 0xcb, 0xce, 0xcb, 0xce, 0x06, 0x06, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe,
+	0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06,
+	0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0xfe, 0x75, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0x00, 0x00, 0x24, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b, 0x00, 0x2f, 0x00, 0x33,
+	0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0x4b, 0x00, 0x4f, 0x00, 0x53,
+	0x00, 0x57, 0x00, 0x5b, 0x00, 0x5f, 0x00, 0x63, 0x00, 0x67, 0x00, 0x6b, 0x00, 0x6f, 0x00, 0x73,
+	0x00, 0x77, 0x00, 0x7b, 0x00, 0x7f, 0x00, 0x83, 0x00, 0x87, 0x00, 0x8b, 0x00, 0x8f, 0x00, 0x00,
+	0x11, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc,
+	0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc,
+	0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc,
+	0xcc, 0x02, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xfb, 0x33, 0xcc, 0xd0, 0xcc,
+	0xd0, 0xcc, 0xfc, 0xca, 0xcc, 0xd0, 0xcc, 0xd0, 0xc7, 0x05, 0x41, 0xc3, 0xc3, 0xfe, 0x75, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0x06, 0xf3, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc4,
+	0xc4, 0x05, 0x67, 0xc3, 0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3,
+	0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x05, 0x67, 0xc3, 0xc3, 0xfe, 0x75, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0x06, 0xf3, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc4,
+	0xc4, 0x06, 0xf1, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xf7, 0x85, 0xc3, 0xc3, 0xc3, 0xc3, 0xc3,
+	0xc3, 0x00, 0x00, 0x00, 0x00, 0x13, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23,
+	0x00, 0x27, 0x00, 0x2b, 0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43,
+	0x00, 0x47, 0x00, 0x4b, 0x00, 0x00, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x21, 0x35, 0x23, 0x15, 0x21, 0x35, 0x23, 0x15, 0x01, 0x21,
+	0x11, 0x21, 0xce, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x02, 0x67,
+	0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x02, 0x67, 0xce, 0x01, 0x9b,
+	0xce, 0x01, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0xfe, 0x69, 0xcd, 0x02, 0x66, 0xce, 0x02, 0x67,
+	0xce, 0xfc, 0x01, 0x04, 0xcd, 0xfb, 0x33, 0x06, 0x06, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe,
+	0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06,
+	0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0xfe, 0x75, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0x09, 0x3f, 0xf6, 0xc1, 0x00,
+	0x00, 0x01, 0x00, 0x64, 0x00, 0x00, 0x04, 0x71, 0x04, 0x0d, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x64, 0x04, 0x0d, 0x04, 0x0d, 0xfb, 0xf3,
+	0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0x71, 0x04, 0x0d, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2a,
+	0x40, 0x27, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57,
+	0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06, 0x05,
+	0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x25, 0x21,
+	0x11, 0x21, 0x64, 0x04, 0x0d, 0xfc, 0x56, 0x03, 0x48, 0xfc, 0xb8, 0x04, 0x0d, 0xfb, 0xf3, 0x63,
+	0x03, 0x48, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64, 0x01, 0x95, 0x02, 0x72, 0x03, 0xa3, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b,
+	0x13, 0x11, 0x21, 0x11, 0x64, 0x02, 0x0e, 0x01, 0x95, 0x02, 0x0e, 0xfd, 0xf2, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0x01, 0x9f, 0x02, 0x72, 0x03, 0xad, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2a,
+	0x40, 0x27, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57,
+	0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06, 0x05,
+	0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x25, 0x21,
+	0x11, 0x21, 0x64, 0x02, 0x0e, 0xfe, 0x55, 0x01, 0x49, 0xfe, 0xb7, 0x01, 0x9f, 0x02, 0x0e, 0xfd,
+	0xf2, 0x63, 0x01, 0x48, 0x00, 0x01, 0x00, 0x00, 0x02, 0x00, 0x08, 0x00, 0x04, 0x00, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b,
+	0x11, 0x11, 0x21, 0x11, 0x08, 0x00, 0x02, 0x00, 0x02, 0x00, 0xfe, 0x00, 0x00, 0x01, 0x00, 0xfa,
+	0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x15, 0x40, 0x12, 0x01, 0x01, 0x00, 0x4a,
+	0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x02, 0x00, 0x02, 0x02, 0x06, 0x16, 0x2b, 0x33,
+	0x01, 0x01, 0xfa, 0x02, 0xfc, 0x02, 0xfb, 0x05, 0xf7, 0xfa, 0x09, 0x00, 0x00, 0x01, 0x00, 0xfa,
+	0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b,
+	0x13, 0x01, 0x01, 0xfa, 0x05, 0xf7, 0xfa, 0x09, 0x05, 0xf7, 0xfd, 0x04, 0xfd, 0x05, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x15, 0x40, 0x12,
+	0x01, 0x01, 0x00, 0x49, 0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x02, 0x00, 0x02, 0x02,
+	0x06, 0x16, 0x2b, 0x09, 0x02, 0x06, 0xf1, 0xfd, 0x04, 0xfd, 0x05, 0x05, 0xf7, 0xfa, 0x09, 0x05,
+	0xf7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02,
+	0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x21, 0x01, 0x01, 0x06, 0xf1, 0xfa, 0x09, 0x05,
+	0xf7, 0x02, 0xfc, 0x02, 0xfb, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x20, 0x01, 0x22, 0x03, 0xd3,
+	0x04, 0xd5, 0x00, 0x03, 0x00, 0x07, 0x00, 0x08, 0xb5, 0x07, 0x05, 0x03, 0x01, 0x02, 0x32, 0x2b,
+	0x09, 0x07, 0x03, 0xd3, 0xfe, 0x26, 0xfe, 0x27, 0x01, 0xd9, 0x01, 0x33, 0xfe, 0xcd, 0xfe, 0xce,
+	0x01, 0x32, 0x02, 0xfc, 0xfe, 0x26, 0x01, 0xda, 0x01, 0xd9, 0xfe, 0x27, 0x01, 0x32, 0xfe, 0xce,
+	0xfe, 0xcd, 0x00, 0x00, 0x00, 0x02, 0x00, 0xae, 0x00, 0xde, 0x04, 0x26, 0x04, 0x56, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01,
+	0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00,
+	0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x06, 0x06, 0x16, 0x2b, 0x25, 0x22, 0x00, 0x35, 0x34, 0x00, 0x33, 0x32, 0x00, 0x15, 0x14,
+	0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x02, 0x63, 0xb2,
+	0xfe, 0xfd, 0x01, 0x04, 0xb8, 0xb9, 0x01, 0x03, 0xfe, 0xf9, 0xba, 0x92, 0xcd, 0xca, 0x90, 0x8f,
+	0xca, 0xc9, 0xde, 0x01, 0x07, 0xb5, 0xb8, 0x01, 0x04, 0xfe, 0xfb, 0xba, 0xb8, 0xfe, 0xff, 0x63,
+	0xc8, 0x8e, 0x92, 0xcb, 0xcb, 0x8f, 0x8d, 0xcc, 0x00, 0x01, 0x00, 0xae, 0x00, 0xde, 0x04, 0x26,
+	0x04, 0x56, 0x00, 0x0b, 0x00, 0x18, 0x40, 0x15, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00,
+	0x00, 0x76, 0x01, 0x00, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x03, 0x06, 0x16, 0x2b, 0x25, 0x22,
+	0x00, 0x35, 0x34, 0x00, 0x33, 0x32, 0x00, 0x15, 0x14, 0x00, 0x02, 0x63, 0xb2, 0xfe, 0xfd, 0x01,
+	0x04, 0xb8, 0xb9, 0x01, 0x03, 0xfe, 0xf9, 0xde, 0x01, 0x07, 0xb5, 0xb8, 0x01, 0x04, 0xfe, 0xfb,
+	0xba, 0xb8, 0xfe, 0xff, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x0f, 0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x03, 0x01, 0x85, 0x00, 0x03, 0x02, 0x03, 0x85,
+	0x04, 0x01, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x76, 0x05, 0x04, 0x0b, 0x09, 0x04, 0x0f,
+	0x05, 0x0f, 0x11, 0x10, 0x05, 0x06, 0x18, 0x2b, 0x01, 0x21, 0x11, 0x21, 0x01, 0x32, 0x00, 0x35,
+	0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x04, 0xcd, 0xfb, 0x33, 0x04, 0xcd, 0xfd, 0x93,
+	0xbc, 0x01, 0x07, 0xfe, 0xfd, 0xb9, 0xb8, 0xfe, 0xfc, 0x01, 0x02, 0xfe, 0x50, 0x09, 0x3f, 0xf9,
+	0xa5, 0x01, 0x01, 0xb8, 0xba, 0x01, 0x05, 0xfe, 0xfc, 0xb8, 0xb5, 0xfe, 0xf9, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x1b,
+	0x00, 0x37, 0x40, 0x34, 0x00, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x05, 0x03, 0x85, 0x00, 0x05,
+	0x04, 0x05, 0x85, 0x07, 0x01, 0x04, 0x02, 0x04, 0x85, 0x06, 0x01, 0x02, 0x01, 0x02, 0x85, 0x00,
+	0x01, 0x01, 0x76, 0x11, 0x10, 0x05, 0x04, 0x17, 0x15, 0x10, 0x1b, 0x11, 0x1b, 0x0b, 0x09, 0x04,
+	0x0f, 0x05, 0x0f, 0x11, 0x10, 0x08, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x01, 0x32, 0x00,
+	0x35, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x37, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x16, 0x15, 0x14, 0x06, 0x04, 0xcd, 0xfb, 0x33, 0x02, 0x60, 0xec, 0x01, 0x46, 0xfe, 0xba,
+	0xe5, 0xe6, 0xfe, 0xbb, 0x01, 0x43, 0xe2, 0xae, 0xfc, 0xfd, 0xb3, 0xb2, 0xfe, 0xfe, 0x07, 0x8f,
+	0xf6, 0xc1, 0x02, 0x75, 0x01, 0x42, 0xea, 0xe5, 0x01, 0x45, 0xfe, 0xbb, 0xe6, 0xe4, 0xfe, 0xb9,
+	0x7b, 0xff, 0xb1, 0xb3, 0xfd, 0xfd, 0xb2, 0xb6, 0xfb, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x42,
+	0x01, 0x71, 0x02, 0x94, 0x03, 0xc3, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x03,
+	0x00, 0x01, 0x00, 0x03, 0x01, 0x69, 0x04, 0x01, 0x00, 0x02, 0x02, 0x00, 0x59, 0x04, 0x01, 0x00,
+	0x00, 0x02, 0x61, 0x05, 0x01, 0x02, 0x00, 0x02, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16, 0x2b, 0x01, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x17, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x16, 0x15, 0x14, 0x06, 0x01, 0x69, 0x52, 0x75, 0x73, 0x52, 0x52, 0x72, 0x72, 0x4d, 0x77,
+	0xad, 0xae, 0x7b, 0x7c, 0xad, 0xb0, 0x01, 0xd6, 0x72, 0x50, 0x54, 0x73, 0x73, 0x52, 0x50, 0x74,
+	0x65, 0xb0, 0x79, 0x7b, 0xae, 0xae, 0x7d, 0x7b, 0xac, 0x00, 0x00, 0x00, 0x00, 0x05, 0x01, 0x0c,
+	0xff, 0xdb, 0x07, 0x1e, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2f, 0x00, 0x3b,
+	0x00, 0x66, 0x40, 0x63, 0x06, 0x01, 0x04, 0x08, 0x05, 0x08, 0x04, 0x05, 0x80, 0x00, 0x01, 0x00,
+	0x03, 0x09, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x09, 0x0f, 0x0a, 0x0e, 0x03, 0x08, 0x04, 0x09, 0x08,
+	0x69, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x69, 0x0d, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59,
+	0x0d, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0c, 0x01, 0x00, 0x02, 0x00, 0x51, 0x31, 0x30, 0x25, 0x24,
+	0x0d, 0x0c, 0x01, 0x00, 0x37, 0x35, 0x30, 0x3b, 0x31, 0x3b, 0x2b, 0x29, 0x24, 0x2f, 0x25, 0x2f,
+	0x22, 0x20, 0x1e, 0x1d, 0x1c, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05,
+	0x00, 0x0b, 0x01, 0x0b, 0x10, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20,
+	0x00, 0x11, 0x10, 0x00, 0x25, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x03, 0x33, 0x12, 0x21, 0x20, 0x13, 0x33, 0x06, 0x04, 0x23, 0x22, 0x24, 0x13, 0x22, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x21, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32,
+	0x16, 0x15, 0x14, 0x06, 0x04, 0x0c, 0xfe, 0xc5, 0xfe, 0x3b, 0x01, 0xc7, 0x01, 0x42, 0x01, 0x42,
+	0x01, 0xc7, 0xfe, 0x37, 0xfe, 0xb8, 0x01, 0x0b, 0x01, 0x72, 0xfe, 0x90, 0xfe, 0xfb, 0xfe, 0xfb,
+	0xfe, 0x90, 0x01, 0x6e, 0xda, 0x6f, 0x49, 0x01, 0x29, 0x01, 0x29, 0x49, 0x6f, 0x1f, 0xfe, 0xfc,
+	0xbe, 0xbe, 0xfe, 0xfc, 0xca, 0x32, 0x48, 0x48, 0x33, 0x33, 0x49, 0x49, 0x01, 0xb9, 0x32, 0x48,
+	0x49, 0x33, 0x33, 0x48, 0x48, 0x25, 0x01, 0xca, 0x01, 0x3f, 0x01, 0x42, 0x01, 0xc7, 0xfe, 0x3a,
+	0xfe, 0xbf, 0xfe, 0xb9, 0xfe, 0x3c, 0x94, 0x01, 0x6e, 0x01, 0x08, 0x01, 0x04, 0x01, 0x70, 0xfe,
+	0x90, 0xfe, 0xfb, 0xfe, 0xfe, 0xfe, 0x8d, 0x02, 0x4a, 0xfe, 0xd2, 0x01, 0x2e, 0xd4, 0xfb, 0xfb,
+	0x01, 0x7b, 0x48, 0x33, 0x33, 0x48, 0x48, 0x33, 0x34, 0x47, 0x48, 0x33, 0x33, 0x48, 0x48, 0x33,
+	0x34, 0x47, 0x00, 0x00, 0x00, 0x04, 0x01, 0x2d, 0xff, 0xdb, 0x07, 0x3f, 0x05, 0xed, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x23, 0x00, 0x2f, 0x00, 0x59, 0x40, 0x56, 0x0b, 0x05, 0x02, 0x03, 0x06, 0x04,
+	0x06, 0x03, 0x04, 0x80, 0x00, 0x01, 0x09, 0x01, 0x07, 0x06, 0x01, 0x07, 0x69, 0x0d, 0x08, 0x0c,
+	0x03, 0x06, 0x00, 0x04, 0x02, 0x06, 0x04, 0x69, 0x00, 0x02, 0x00, 0x00, 0x02, 0x59, 0x00, 0x02,
+	0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x02, 0x00, 0x51, 0x25, 0x24, 0x19, 0x18, 0x0c, 0x0c, 0x01,
+	0x00, 0x2b, 0x29, 0x24, 0x2f, 0x25, 0x2f, 0x1f, 0x1d, 0x18, 0x23, 0x19, 0x23, 0x0c, 0x17, 0x0c,
+	0x17, 0x16, 0x14, 0x13, 0x12, 0x10, 0x0e, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0e, 0x06, 0x16,
+	0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x01, 0x16, 0x04,
+	0x33, 0x32, 0x24, 0x37, 0x23, 0x02, 0x21, 0x20, 0x03, 0x37, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x04, 0x2d, 0xfe, 0xc5, 0xfe, 0x3b, 0x01, 0xc7, 0x01, 0x42, 0x01, 0x42, 0x01, 0xc7, 0xfe,
+	0x37, 0xfc, 0xdf, 0x1f, 0x01, 0x04, 0xbe, 0xbe, 0x01, 0x04, 0x1f, 0x6f, 0x49, 0xfe, 0xd7, 0xfe,
+	0xd7, 0x49, 0x7a, 0x34, 0x49, 0x49, 0x33, 0x33, 0x48, 0x48, 0x02, 0x1f, 0x35, 0x48, 0x48, 0x33,
+	0x33, 0x49, 0x48, 0x25, 0x01, 0xca, 0x01, 0x3f, 0x01, 0x42, 0x01, 0xc7, 0xfe, 0x3a, 0xfe, 0xbf,
+	0xfe, 0xb9, 0xfe, 0x3c, 0x02, 0xde, 0xd4, 0xfb, 0xfb, 0xd4, 0xfe, 0xd2, 0x01, 0x2e, 0xa7, 0x47,
+	0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x48, 0x00,
+	0x00, 0x02, 0x00, 0xad, 0xff, 0xe7, 0x06, 0xa7, 0x05, 0xe1, 0x00, 0x27, 0x00, 0x33, 0x00, 0x60,
+	0x40, 0x5d, 0x19, 0x18, 0x17, 0x15, 0x12, 0x10, 0x0f, 0x0e, 0x08, 0x07, 0x02, 0x1a, 0x0d, 0x02,
+	0x01, 0x07, 0x21, 0x06, 0x02, 0x06, 0x00, 0x26, 0x24, 0x23, 0x22, 0x05, 0x04, 0x03, 0x01, 0x08,
+	0x05, 0x06, 0x04, 0x4c, 0x00, 0x02, 0x00, 0x07, 0x01, 0x02, 0x07, 0x69, 0x03, 0x01, 0x01, 0x04,
+	0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x09, 0x01, 0x06, 0x05, 0x05, 0x06, 0x59, 0x09, 0x01, 0x06,
+	0x06, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x06, 0x05, 0x4f, 0x29, 0x28, 0x00, 0x00, 0x2f, 0x2d, 0x28,
+	0x33, 0x29, 0x33, 0x00, 0x27, 0x00, 0x27, 0x11, 0x18, 0x18, 0x11, 0x18, 0x0a, 0x06, 0x1b, 0x2b,
+	0x05, 0x35, 0x26, 0x27, 0x07, 0x27, 0x37, 0x26, 0x27, 0x23, 0x35, 0x33, 0x36, 0x37, 0x27, 0x37,
+	0x17, 0x36, 0x37, 0x35, 0x33, 0x15, 0x16, 0x17, 0x37, 0x17, 0x07, 0x16, 0x17, 0x33, 0x15, 0x23,
+	0x06, 0x07, 0x17, 0x07, 0x27, 0x06, 0x07, 0x15, 0x03, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22,
+	0x06, 0x15, 0x14, 0x16, 0x03, 0x60, 0x7b, 0x71, 0xb1, 0x69, 0xb1, 0x4a, 0x18, 0xfc, 0xfc, 0x18,
+	0x4a, 0xb1, 0x69, 0xb1, 0x71, 0x7b, 0x94, 0x7b, 0x71, 0xb1, 0x68, 0xb0, 0x4a, 0x18, 0xfc, 0xfc,
+	0x18, 0x4a, 0xb0, 0x68, 0xb1, 0x71, 0x7b, 0x4f, 0x9e, 0xd9, 0xd9, 0x99, 0x9a, 0xd8, 0xd7, 0x19,
+	0xfc, 0x15, 0x4d, 0xb1, 0x69, 0xb0, 0x69, 0x84, 0x94, 0x84, 0x69, 0xb0, 0x69, 0xb1, 0x4d, 0x15,
+	0xfc, 0xfc, 0x15, 0x4d, 0xb1, 0x69, 0xb0, 0x69, 0x84, 0x94, 0x84, 0x69, 0xb0, 0x69, 0xb1, 0x4d,
+	0x15, 0xfc, 0x01, 0x8b, 0xd7, 0x9c, 0x99, 0xd8, 0xd8, 0x9a, 0x98, 0xda, 0x00, 0x02, 0x00, 0x66,
+	0xfe, 0x75, 0x05, 0x9a, 0x06, 0x44, 0x00, 0x16, 0x00, 0x22, 0x00, 0x4a, 0x40, 0x47, 0x11, 0x05,
+	0x02, 0x01, 0x06, 0x01, 0x4c, 0x09, 0x01, 0x06, 0x07, 0x01, 0x07, 0x06, 0x01, 0x80, 0x08, 0x01,
+	0x05, 0x00, 0x05, 0x86, 0x00, 0x02, 0x00, 0x07, 0x06, 0x02, 0x07, 0x69, 0x03, 0x01, 0x01, 0x00,
+	0x00, 0x01, 0x57, 0x03, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x04, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x18,
+	0x17, 0x00, 0x00, 0x1e, 0x1c, 0x17, 0x22, 0x18, 0x22, 0x00, 0x16, 0x00, 0x16, 0x11, 0x16, 0x26,
+	0x11, 0x11, 0x0a, 0x06, 0x1b, 0x2b, 0x01, 0x35, 0x21, 0x35, 0x21, 0x11, 0x24, 0x00, 0x11, 0x10,
+	0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x05, 0x11, 0x21, 0x15, 0x21, 0x15, 0x03, 0x32, 0x00,
+	0x35, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x02, 0xb6, 0xfe, 0x3e, 0x01, 0xc2, 0xfe,
+	0xfa, 0xfe, 0xb6, 0x01, 0x86, 0x01, 0x14, 0x01, 0x14, 0x01, 0x86, 0xfe, 0xb6, 0xfe, 0xfa, 0x01,
+	0xc2, 0xfe, 0x3e, 0x50, 0xdc, 0x01, 0x30, 0xfe, 0xd1, 0xd7, 0xd7, 0xfe, 0xd1, 0x01, 0x2e, 0xfe,
+	0x75, 0xf7, 0x94, 0x01, 0x14, 0x25, 0x01, 0x71, 0x01, 0x00, 0x01, 0x14, 0x01, 0x86, 0xfe, 0x7a,
+	0xfe, 0xec, 0xff, 0x00, 0xfe, 0x8f, 0x25, 0xfe, 0xec, 0x94, 0xf7, 0x03, 0x2f, 0x01, 0x2d, 0xda,
+	0xd6, 0x01, 0x2f, 0xfe, 0xd1, 0xd7, 0xd4, 0xfe, 0xce, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x2b,
+	0xff, 0xb5, 0x06, 0x57, 0x07, 0x2e, 0x00, 0x14, 0x00, 0x20, 0x00, 0x08, 0xb5, 0x1d, 0x17, 0x0e,
+	0x04, 0x02, 0x32, 0x2b, 0x01, 0x13, 0x05, 0x27, 0x25, 0x13, 0x07, 0x03, 0x03, 0x16, 0x17, 0x12,
+	0x00, 0x05, 0x04, 0x00, 0x03, 0x02, 0x00, 0x25, 0x36, 0x01, 0x16, 0x04, 0x37, 0x36, 0x12, 0x27,
+	0x26, 0x24, 0x07, 0x06, 0x02, 0x04, 0x0c, 0xdb, 0xfe, 0x95, 0x26, 0x02, 0x5e, 0xa3, 0x8f, 0x61,
+	0xdb, 0xb6, 0x36, 0x48, 0xfe, 0xeb, 0xfe, 0xf5, 0xfe, 0xf6, 0xfe, 0x24, 0x48, 0x47, 0x01, 0x15,
+	0x01, 0x0c, 0xdb, 0xfd, 0xda, 0x39, 0x01, 0x71, 0xd3, 0xcf, 0xd5, 0x37, 0x38, 0xfe, 0x8d, 0xd0,
+	0xcd, 0xd9, 0x04, 0xe2, 0x01, 0x7c, 0x61, 0x8f, 0xa2, 0xfd, 0xa1, 0x26, 0x01, 0x6a, 0xfe, 0x85,
+	0x99, 0xcd, 0xfe, 0xf5, 0xfe, 0x1d, 0x47, 0x48, 0x01, 0x17, 0x01, 0x0c, 0x01, 0x0b, 0x01, 0xd9,
+	0x48, 0x3b, 0xfc, 0xc1, 0xd4, 0xd8, 0x39, 0x37, 0x01, 0x74, 0xcf, 0xcf, 0xd7, 0x38, 0x37, 0xfe,
+	0x8e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x32, 0x00, 0x00, 0x04, 0x0d, 0x05, 0x36, 0x00, 0x18,
+	0x00, 0x20, 0x40, 0x1d, 0x17, 0x0c, 0x01, 0x03, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85,
+	0x03, 0x01, 0x02, 0x02, 0x76, 0x00, 0x00, 0x00, 0x18, 0x00, 0x18, 0x16, 0x14, 0x22, 0x04, 0x06,
+	0x17, 0x2b, 0x21, 0x13, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x37, 0x37, 0x36, 0x37, 0x16,
+	0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x13, 0x01, 0xa4, 0x5b, 0x68, 0x90,
+	0x5d, 0x78, 0x48, 0x6c, 0x71, 0x73, 0x55, 0x55, 0x74, 0x71, 0x6c, 0x48, 0x78, 0x5e, 0x8f, 0x68,
+	0x5b, 0x01, 0x64, 0x4a, 0x89, 0x83, 0x6e, 0x95, 0x73, 0x79, 0x7b, 0xa6, 0xa6, 0x7b, 0x79, 0x73,
+	0x95, 0x6f, 0x82, 0x89, 0x4a, 0xfe, 0x9c, 0x00, 0x00, 0x01, 0x00, 0x32, 0x00, 0x00, 0x05, 0x0d,
+	0x04, 0xfb, 0x00, 0x20, 0x00, 0x30, 0x40, 0x2d, 0x1f, 0x15, 0x0b, 0x01, 0x04, 0x00, 0x01, 0x01,
+	0x4c, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x00, 0x01, 0x85, 0x04, 0x01, 0x00, 0x05,
+	0x00, 0x85, 0x06, 0x01, 0x05, 0x05, 0x76, 0x00, 0x00, 0x00, 0x20, 0x00, 0x20, 0x24, 0x25, 0x25,
+	0x24, 0x22, 0x07, 0x06, 0x1b, 0x2b, 0x21, 0x13, 0x02, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x17, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x36, 0x33, 0x32, 0x16,
+	0x15, 0x14, 0x06, 0x23, 0x22, 0x03, 0x13, 0x02, 0x19, 0x59, 0x71, 0xc6, 0x71, 0x98, 0xa2, 0x85,
+	0x32, 0x3a, 0x34, 0x9c, 0x73, 0x72, 0x9b, 0x33, 0x39, 0x32, 0x86, 0xa2, 0x98, 0x70, 0xc7, 0x72,
+	0x5a, 0x02, 0x02, 0xfe, 0xef, 0xa0, 0x75, 0x83, 0x9e, 0x11, 0x66, 0x59, 0x7d, 0xa9, 0xa9, 0x7d,
+	0x59, 0x66, 0x11, 0x9e, 0x83, 0x75, 0xa0, 0x01, 0x11, 0xfd, 0xfe, 0x00, 0x00, 0x01, 0x00, 0x4a,
+	0xff, 0xe2, 0x04, 0x75, 0x04, 0xbe, 0x00, 0x19, 0x00, 0x11, 0x40, 0x0e, 0x0d, 0x01, 0x00, 0x49,
+	0x01, 0x01, 0x00, 0x00, 0x76, 0x22, 0x2a, 0x02, 0x06, 0x18, 0x2b, 0x05, 0x26, 0x2f, 0x04, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x13, 0x12, 0x33, 0x32, 0x16, 0x15, 0x14, 0x0f, 0x04, 0x06, 0x02,
+	0x5f, 0x34, 0x13, 0x5a, 0x42, 0x37, 0x43, 0xb8, 0x95, 0x73, 0xd7, 0x36, 0x36, 0xd8, 0x73, 0x95,
+	0xb8, 0x42, 0x38, 0x42, 0x5a, 0x13, 0x1e, 0x57, 0x19, 0x7f, 0x5f, 0x47, 0x54, 0xe9, 0xbe, 0x91,
+	0xbb, 0xfe, 0xb4, 0x01, 0x4c, 0xbb, 0x91, 0xbe, 0xe9, 0x54, 0x47, 0x5f, 0x7f, 0x19, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x28, 0xff, 0xde, 0x03, 0xed, 0x05, 0x3b, 0x00, 0x07, 0x00, 0x06, 0xb3, 0x04,
+	0x00, 0x01, 0x32, 0x2b, 0x05, 0x02, 0x01, 0x00, 0x13, 0x12, 0x01, 0x00, 0x02, 0x0b, 0xc3, 0xfe,
+	0xe0, 0x01, 0x20, 0xc3, 0xc5, 0x01, 0x1d, 0xfe, 0xe3, 0x22, 0x01, 0x99, 0x01, 0x16, 0x01, 0x14,
+	0x01, 0x9a, 0xfe, 0x67, 0xfe, 0xeb, 0xfe, 0xea, 0x00, 0x01, 0x00, 0x31, 0xff, 0xdb, 0x03, 0xcf,
+	0x05, 0xc8, 0x00, 0x1e, 0x00, 0x2c, 0x40, 0x29, 0x14, 0x0b, 0x0a, 0x03, 0x02, 0x00, 0x00, 0x01,
+	0x01, 0x02, 0x02, 0x4c, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x01, 0x01, 0x02, 0x59, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x02, 0x01, 0x51, 0x1e, 0x1c, 0x18, 0x16, 0x11, 0x03, 0x06,
+	0x17, 0x2b, 0x01, 0x11, 0x33, 0x15, 0x14, 0x17, 0x17, 0x16, 0x15, 0x14, 0x07, 0x27, 0x36, 0x35,
+	0x34, 0x27, 0x27, 0x26, 0x27, 0x26, 0x27, 0x11, 0x10, 0x21, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x01, 0xca, 0x63, 0x83, 0x46, 0xd9, 0x6b, 0x45, 0x3e, 0x58, 0x4a, 0x16, 0x34, 0x1d, 0x27,
+	0xfe, 0xab, 0x49, 0x5e, 0xae, 0x75, 0x3c, 0x01, 0x2d, 0x04, 0x9b, 0x1a, 0x83, 0x64, 0x35, 0xa5,
+	0x8c, 0x68, 0x87, 0x34, 0x54, 0x3d, 0x3d, 0x4e, 0x43, 0x13, 0x25, 0x13, 0x2d, 0xfd, 0x2d, 0xfe,
+	0x31, 0x4c, 0x3c, 0x5a, 0x87, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64, 0xfe, 0xeb, 0x05, 0x29,
+	0x05, 0xed, 0x00, 0x1a, 0x00, 0x33, 0x40, 0x30, 0x19, 0x01, 0x01, 0x03, 0x0b, 0x01, 0x02, 0x01,
+	0x02, 0x4c, 0x1a, 0x0d, 0x0c, 0x00, 0x04, 0x03, 0x4a, 0x00, 0x01, 0x02, 0x00, 0x01, 0x59, 0x00,
+	0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x69, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00, 0x01, 0x00,
+	0x51, 0x23, 0x27, 0x23, 0x23, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x14, 0x06, 0x23, 0x22, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x17, 0x11, 0x01, 0x11, 0x14, 0x07, 0x06, 0x23, 0x22, 0x35, 0x34, 0x36,
+	0x33, 0x32, 0x17, 0x11, 0x02, 0x5c, 0xa9, 0xa3, 0xac, 0xac, 0x76, 0x40, 0x33, 0x03, 0x30, 0x5e,
+	0x62, 0x8b, 0xaa, 0xac, 0x7b, 0x33, 0x38, 0x03, 0xf7, 0xfc, 0xc6, 0xe5, 0xed, 0x8c, 0x5c, 0x85,
+	0x18, 0x04, 0x67, 0x01, 0x46, 0xfc, 0x0f, 0xff, 0x63, 0x69, 0x87, 0x5b, 0x82, 0x16, 0x03, 0x6f,
+	0x00, 0x0d, 0x00, 0xfd, 0xff, 0x33, 0x07, 0x03, 0x06, 0x44, 0x00, 0x1a, 0x00, 0x26, 0x00, 0x32,
+	0x00, 0x4b, 0x00, 0x64, 0x00, 0x72, 0x00, 0x7e, 0x00, 0x8a, 0x00, 0xa4, 0x00, 0xfe, 0x01, 0x20,
+	0x01, 0x2e, 0x01, 0x3c, 0x08, 0xa4, 0x41, 0x22, 0x00, 0xfc, 0x00, 0xa8, 0x00, 0x02, 0x00, 0x01,
+	0x00, 0x10, 0x00, 0xef, 0x00, 0xb5, 0x00, 0x02, 0x00, 0x02, 0x00, 0x01, 0x00, 0x6e, 0x00, 0x01,
+	0x00, 0x08, 0x00, 0x09, 0x01, 0x05, 0x00, 0x01, 0x00, 0x04, 0x00, 0x08, 0x01, 0x2f, 0x01, 0x24,
+	0x00, 0x02, 0x00, 0x1a, 0x00, 0x16, 0x00, 0x56, 0x00, 0x01, 0x00, 0x0c, 0x00, 0x0e, 0x00, 0xe5,
+	0x00, 0xbf, 0x00, 0x02, 0x00, 0x12, 0x00, 0x07, 0x00, 0x07, 0x00, 0x4c, 0x4b, 0xb0, 0x0a, 0x50,
+	0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05,
+	0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04,
+	0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00,
+	0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a, 0x1b, 0x06, 0x80, 0x0d,
+	0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01,
+	0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13,
+	0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84,
+	0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00,
+	0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02,
+	0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x0c, 0x50, 0x58,
+	0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01,
+	0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08,
+	0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19,
+	0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b, 0x0d, 0x80, 0x00, 0x0d,
+	0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a,
+	0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07,
+	0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e,
+	0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03,
+	0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a,
+	0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b,
+	0x4b, 0xb0, 0x0d, 0x50, 0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10,
+	0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08,
+	0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17,
+	0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a,
+	0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e,
+	0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12,
+	0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14,
+	0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69,
+	0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16,
+	0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b,
+	0xb0, 0x0f, 0x50, 0x58, 0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01,
+	0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80,
+	0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00,
+	0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b,
+	0x0d, 0x80, 0x00, 0x0d, 0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e,
+	0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00,
+	0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11,
+	0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01,
+	0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25,
+	0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a,
+	0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f,
+	0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03,
+	0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01,
+	0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01,
+	0x1b, 0x1a, 0x06, 0x1a, 0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00,
+	0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07,
+	0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a,
+	0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03,
+	0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02,
+	0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16,
+	0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85,
+	0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08,
+	0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00,
+	0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b,
+	0x1a, 0x0d, 0x1a, 0x1b, 0x0d, 0x80, 0x00, 0x0d, 0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e,
+	0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a,
+	0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13,
+	0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f,
+	0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08,
+	0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a,
+	0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x13, 0x50, 0x58, 0x40, 0x97, 0x24,
+	0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01,
+	0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04,
+	0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19,
+	0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a, 0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a,
+	0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c,
+	0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e,
+	0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03,
+	0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17,
+	0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61,
+	0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x9d, 0x24, 0x01,
+	0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85,
+	0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17,
+	0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16,
+	0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b, 0x0d, 0x80, 0x00, 0x0d, 0x06, 0x1a, 0x0d, 0x06,
+	0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23,
+	0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12,
+	0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11,
+	0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08,
+	0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25,
+	0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x16, 0x50,
+	0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05,
+	0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04,
+	0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00,
+	0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a, 0x1b, 0x06, 0x80, 0x0d,
+	0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01,
+	0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13,
+	0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84,
+	0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00,
+	0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02,
+	0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x18, 0x50, 0x58,
+	0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01,
+	0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08,
+	0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19,
+	0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b, 0x0d, 0x80, 0x00, 0x0d,
+	0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a,
+	0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07,
+	0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e,
+	0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03,
+	0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a,
+	0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b,
+	0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10,
+	0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08,
+	0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17,
+	0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a,
+	0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e,
+	0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12,
+	0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14,
+	0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69,
+	0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16,
+	0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x40,
+	0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01,
+	0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17,
+	0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16,
+	0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b, 0x0d, 0x80, 0x00, 0x0d, 0x06,
+	0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e,
+	0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12,
+	0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14,
+	0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69,
+	0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16,
+	0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x41, 0x5f, 0x01, 0x00, 0x00, 0xff, 0x00,
+	0xa6, 0x00, 0xa5, 0x00, 0x8c, 0x00, 0x8b, 0x00, 0x74, 0x00, 0x73, 0x00, 0x66, 0x00, 0x65, 0x00,
+	0x34, 0x00, 0x33, 0x00, 0x1c, 0x00, 0x1b, 0x00, 0x01, 0x00, 0x00, 0x01, 0x38, 0x01, 0x36, 0x01,
+	0x32, 0x01, 0x31, 0x01, 0x2a, 0x01, 0x28, 0x01, 0x23, 0x01, 0x21, 0x01, 0x1d, 0x01, 0x1b, 0x01,
+	0x18, 0x01, 0x16, 0x01, 0x0b, 0x01, 0x09, 0x00, 0xff, 0x01, 0x20, 0x01, 0x00, 0x01, 0x20, 0x00,
+	0xf8, 0x00, 0xf6, 0x00, 0xe0, 0x00, 0xde, 0x00, 0xd9, 0x00, 0xd6, 0x00, 0xd3, 0x00, 0xce, 0x00,
+	0xc8, 0x00, 0xc6, 0x00, 0xae, 0x00, 0xac, 0x00, 0xa5, 0x00, 0xfe, 0x00, 0xa6, 0x00, 0xfe, 0x00,
+	0xa1, 0x00, 0x9f, 0x00, 0x99, 0x00, 0x97, 0x00, 0x8b, 0x00, 0xa4, 0x00, 0x8c, 0x00, 0xa4, 0x00,
+	0x7a, 0x00, 0x78, 0x00, 0x73, 0x00, 0x7e, 0x00, 0x74, 0x00, 0x7e, 0x00, 0x6c, 0x00, 0x6a, 0x00,
+	0x65, 0x00, 0x72, 0x00, 0x66, 0x00, 0x72, 0x00, 0x5c, 0x00, 0x5a, 0x00, 0x52, 0x00, 0x50, 0x00,
+	0x40, 0x00, 0x3e, 0x00, 0x33, 0x00, 0x4b, 0x00, 0x34, 0x00, 0x4b, 0x00, 0x22, 0x00, 0x20, 0x00,
+	0x1b, 0x00, 0x26, 0x00, 0x1c, 0x00, 0x26, 0x00, 0x0d, 0x00, 0x0b, 0x00, 0x00, 0x00, 0x1a, 0x00,
+	0x01, 0x00, 0x1a, 0x00, 0x26, 0x00, 0x06, 0x00, 0x16, 0x2b, 0x01, 0x32, 0x36, 0x37, 0x36, 0x36,
+	0x35, 0x34, 0x26, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x06, 0x15, 0x14, 0x1e, 0x02,
+	0x17, 0x16, 0x17, 0x16, 0x03, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36,
+	0x17, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x05, 0x32, 0x36, 0x37,
+	0x36, 0x36, 0x35, 0x34, 0x26, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x06, 0x15, 0x14,
+	0x17, 0x1e, 0x03, 0x01, 0x34, 0x2e, 0x02, 0x23, 0x22, 0x0e, 0x02, 0x15, 0x14, 0x1e, 0x02, 0x33,
+	0x32, 0x3e, 0x02, 0x37, 0x3e, 0x03, 0x01, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15,
+	0x14, 0x1e, 0x02, 0x01, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x17,
+	0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x01, 0x32, 0x36, 0x35, 0x34,
+	0x2e, 0x02, 0x27, 0x2e, 0x03, 0x23, 0x22, 0x06, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x1e, 0x02,
+	0x01, 0x32, 0x16, 0x17, 0x3e, 0x03, 0x33, 0x32, 0x16, 0x15, 0x14, 0x0e, 0x02, 0x07, 0x1e, 0x03,
+	0x15, 0x14, 0x0e, 0x02, 0x07, 0x1e, 0x03, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x27, 0x2e, 0x03,
+	0x27, 0x06, 0x06, 0x23, 0x22, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x37, 0x2e, 0x03, 0x35, 0x34, 0x3e, 0x02, 0x37, 0x2e, 0x03, 0x35, 0x34, 0x36,
+	0x33, 0x32, 0x1e, 0x02, 0x17, 0x36, 0x36, 0x01, 0x32, 0x36, 0x35, 0x34, 0x26, 0x27, 0x0e, 0x03,
+	0x23, 0x22, 0x2e, 0x02, 0x27, 0x0e, 0x03, 0x15, 0x14, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x33, 0x32,
+	0x1e, 0x02, 0x17, 0x06, 0x26, 0x27, 0x14, 0x1e, 0x02, 0x33, 0x33, 0x32, 0x3e, 0x02, 0x27, 0x06,
+	0x06, 0x07, 0x14, 0x1e, 0x02, 0x33, 0x37, 0x32, 0x3e, 0x02, 0x02, 0xad, 0x29, 0x56, 0x22, 0x26,
+	0x26, 0x29, 0x2a, 0x26, 0x56, 0x21, 0x2f, 0x55, 0x22, 0x22, 0x26, 0x03, 0x0a, 0x13, 0x0f, 0x1d,
+	0x2f, 0x34, 0x22, 0x21, 0x27, 0x2a, 0x1e, 0x23, 0x29, 0x27, 0x13, 0x0c, 0x08, 0x08, 0x0e, 0x07,
+	0x0c, 0x06, 0x11, 0x03, 0x1c, 0x30, 0x56, 0x20, 0x20, 0x22, 0x2b, 0x29, 0x20, 0x4e, 0x2a, 0x3d,
+	0x4e, 0x17, 0x1d, 0x24, 0x34, 0x0b, 0x22, 0x2d, 0x38, 0x01, 0x1a, 0x03, 0x0c, 0x17, 0x14, 0x1c,
+	0x47, 0x3e, 0x2a, 0x0b, 0x11, 0x12, 0x07, 0x14, 0x0f, 0x09, 0x0a, 0x0f, 0x23, 0x34, 0x23, 0x11,
+	0xfd, 0xbe, 0x25, 0x24, 0x21, 0x28, 0x28, 0x28, 0x05, 0x10, 0x20, 0x01, 0xc9, 0x21, 0x26, 0x2a,
+	0x1d, 0x24, 0x27, 0x25, 0x15, 0x0b, 0x08, 0x08, 0x0d, 0x06, 0x0d, 0x06, 0x0f, 0xfc, 0xe2, 0x14,
+	0x1b, 0x1c, 0x30, 0x3f, 0x22, 0x04, 0x0b, 0x0f, 0x13, 0x0b, 0x17, 0x26, 0x23, 0x2f, 0x30, 0x0d,
+	0x11, 0x15, 0x13, 0x19, 0x01, 0x93, 0x9f, 0xf0, 0x52, 0x30, 0x3c, 0x2c, 0x28, 0x1d, 0x20, 0x1f,
+	0x0f, 0x27, 0x41, 0x33, 0x1a, 0x1c, 0x0e, 0x02, 0x0f, 0x28, 0x46, 0x36, 0x0c, 0x16, 0x12, 0x0b,
+	0x19, 0x22, 0x31, 0x4c, 0x0f, 0x02, 0x05, 0x07, 0x07, 0x02, 0x2f, 0x6b, 0x3f, 0x34, 0x42, 0x39,
+	0x3f, 0x32, 0x15, 0x27, 0x13, 0x0c, 0x21, 0x28, 0x2c, 0x18, 0x23, 0x28, 0x1a, 0x09, 0x5d, 0x6a,
+	0x35, 0x0d, 0x08, 0x15, 0x22, 0x1b, 0x1b, 0x36, 0x2b, 0x1c, 0x21, 0x27, 0x17, 0x20, 0x27, 0x36,
+	0x2e, 0x52, 0xfb, 0x01, 0x16, 0x17, 0x16, 0x1c, 0x1a, 0x04, 0x15, 0x1a, 0x1e, 0x0d, 0x0b, 0x19,
+	0x18, 0x13, 0x04, 0x09, 0x14, 0x11, 0x0b, 0x1c, 0x13, 0x0d, 0x16, 0x17, 0x16, 0x0d, 0x0c, 0x1b,
+	0x1b, 0x1a, 0x1c, 0x0e, 0x34, 0x23, 0x01, 0x07, 0x0e, 0x0e, 0x26, 0x0a, 0x0b, 0x05, 0x01, 0x7c,
+	0x14, 0x32, 0x1d, 0x02, 0x07, 0x0c, 0x0b, 0x2f, 0x07, 0x08, 0x04, 0x01, 0x03, 0x8c, 0x20, 0x1d,
+	0x22, 0x5b, 0x38, 0x39, 0x5f, 0x1f, 0x1d, 0x11, 0x24, 0x24, 0x24, 0x5c, 0x2e, 0x0c, 0x21, 0x26,
+	0x2a, 0x13, 0x26, 0x14, 0x17, 0x01, 0x33, 0x2a, 0x19, 0x1d, 0x27, 0x25, 0x1b, 0x1c, 0x2b, 0x2e,
+	0x0a, 0x0b, 0x0d, 0x08, 0x05, 0x0e, 0x0a, 0xf8, 0x24, 0x20, 0x20, 0x52, 0x2d, 0x32, 0x55, 0x20,
+	0x1a, 0x1d, 0x29, 0x1a, 0x1d, 0x56, 0x31, 0x49, 0x42, 0x0e, 0x1d, 0x16, 0x0e, 0xfe, 0xb0, 0x09,
+	0x11, 0x0d, 0x08, 0x23, 0x33, 0x3c, 0x18, 0x0e, 0x15, 0x0f, 0x08, 0x0f, 0x16, 0x19, 0x0b, 0x1a,
+	0x1c, 0x13, 0x13, 0x01, 0x6d, 0x1a, 0x14, 0x17, 0x19, 0x16, 0x1b, 0x07, 0x10, 0x0d, 0x09, 0x01,
+	0x10, 0x2a, 0x17, 0x1d, 0x28, 0x25, 0x1b, 0x1b, 0x2b, 0x2d, 0x0a, 0x0c, 0x0e, 0x08, 0x05, 0x0e,
+	0x0a, 0xfd, 0x0e, 0x1c, 0x19, 0x1a, 0x1b, 0x16, 0x1b, 0x1b, 0x03, 0x0e, 0x0f, 0x0c, 0x22, 0x20,
+	0x18, 0x28, 0x1d, 0x10, 0x10, 0x13, 0x10, 0x04, 0x9b, 0x42, 0x50, 0x15, 0x29, 0x21, 0x14, 0x1f,
+	0x19, 0x1a, 0x37, 0x37, 0x35, 0x19, 0x35, 0x76, 0x81, 0x8b, 0x4b, 0xa3, 0xf5, 0xb1, 0x75, 0x24,
+	0x15, 0x2f, 0x30, 0x30, 0x16, 0x20, 0x1f, 0x35, 0x35, 0x04, 0x14, 0x1a, 0x1a, 0x0a, 0x08, 0x07,
+	0x05, 0x06, 0x05, 0x02, 0x02, 0x1c, 0x3c, 0x33, 0x21, 0x26, 0x26, 0x26, 0x48, 0x26, 0x2f, 0x9b,
+	0xbb, 0xcb, 0x5e, 0x4c, 0x97, 0x8d, 0x83, 0x39, 0x1b, 0x3a, 0x3a, 0x3c, 0x1d, 0x23, 0x2d, 0x1b,
+	0x26, 0x28, 0x0c, 0x4d, 0x47, 0xfc, 0xe6, 0x15, 0x10, 0x1a, 0x28, 0x18, 0x03, 0x06, 0x06, 0x04,
+	0x04, 0x06, 0x07, 0x04, 0x07, 0x14, 0x18, 0x1b, 0x0e, 0x14, 0x13, 0x08, 0x09, 0x08, 0x07, 0x09,
+	0x07, 0x1c, 0x03, 0x07, 0x0f, 0x1a, 0x33, 0x28, 0x1a, 0x16, 0x24, 0x2c, 0x2e, 0x0c, 0x0c, 0x02,
+	0x1a, 0x2c, 0x21, 0x12, 0x01, 0x1d, 0x2c, 0x33, 0x00, 0x02, 0x00, 0x34, 0x00, 0x00, 0x04, 0x5a,
+	0x06, 0x44, 0x00, 0x16, 0x00, 0x1a, 0x01, 0x72, 0x40, 0x0a, 0x0a, 0x01, 0x08, 0x02, 0x0b, 0x01,
+	0x09, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x3a, 0x4d, 0x0b, 0x01, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d,
+	0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58, 0x40, 0x27, 0x00, 0x08, 0x0b, 0x01,
+	0x09, 0x01, 0x08, 0x09, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x06,
+	0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05,
+	0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x29, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x3a, 0x4d, 0x0b, 0x01, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x3a, 0x4d,
+	0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x29, 0x00, 0x03, 0x03, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x0b, 0x01, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38,
+	0x4d, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02,
+	0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x27, 0x00, 0x08, 0x0b,
+	0x01, 0x09, 0x01, 0x08, 0x09, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3a, 0x4d,
+	0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x25, 0x00, 0x02, 0x00, 0x03,
+	0x09, 0x02, 0x03, 0x69, 0x00, 0x08, 0x0b, 0x01, 0x09, 0x01, 0x08, 0x09, 0x67, 0x06, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05,
+	0x4e, 0x1b, 0x40, 0x25, 0x00, 0x02, 0x00, 0x03, 0x09, 0x02, 0x03, 0x69, 0x00, 0x08, 0x0b, 0x01,
+	0x09, 0x01, 0x08, 0x09, 0x67, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x40,
+	0x18, 0x17, 0x17, 0x00, 0x00, 0x17, 0x1a, 0x17, 0x1a, 0x19, 0x18, 0x00, 0x16, 0x00, 0x16, 0x11,
+	0x11, 0x12, 0x23, 0x23, 0x11, 0x11, 0x0c, 0x09, 0x1d, 0x2b, 0x33, 0x11, 0x23, 0x35, 0x33, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x15, 0x21, 0x11, 0x21, 0x11, 0x21,
+	0x11, 0x01, 0x35, 0x33, 0x15, 0xa6, 0x72, 0x72, 0xc5, 0xb6, 0x70, 0x74, 0x72, 0x3b, 0x8a, 0x02,
+	0x8c, 0xfe, 0xd8, 0xfe, 0x9c, 0x01, 0x96, 0xf6, 0x03, 0x91, 0xb9, 0x4c, 0xcf, 0xdf, 0x1f, 0xbe,
+	0x21, 0xfa, 0x44, 0xfb, 0xb6, 0x03, 0x91, 0xfc, 0x6f, 0x05, 0x03, 0xf7, 0xf7, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x34, 0xff, 0xe7, 0x04, 0xfa, 0x06, 0x44, 0x00, 0x20, 0x00, 0xf6, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x0e, 0x06, 0x01, 0x02, 0x01, 0x00, 0x01, 0x09, 0x03, 0x01, 0x01, 0x00,
+	0x09, 0x03, 0x4c, 0x1b, 0x40, 0x0f, 0x06, 0x01, 0x02, 0x01, 0x00, 0x01, 0x09, 0x03, 0x02, 0x4c,
+	0x01, 0x01, 0x04, 0x01, 0x4b, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x23, 0x00, 0x01, 0x01,
+	0x07, 0x61, 0x08, 0x01, 0x07, 0x07, 0x3a, 0x4d, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01,
+	0x02, 0x02, 0x3b, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x62, 0x04, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x29, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x00, 0x01, 0x01,
+	0x07, 0x61, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01, 0x02,
+	0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2b, 0x50, 0x58, 0x40, 0x29, 0x00, 0x07, 0x00, 0x01, 0x02,
+	0x07, 0x01, 0x69, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01,
+	0x02, 0x02, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x07, 0x00, 0x01, 0x02, 0x07, 0x01, 0x69, 0x00,
+	0x08, 0x08, 0x3a, 0x4d, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x3b, 0x4d,
+	0x00, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x0e, 0x20, 0x1e, 0x11, 0x22, 0x11, 0x11, 0x11, 0x11, 0x12, 0x23, 0x22,
+	0x0a, 0x09, 0x1f, 0x2b, 0x25, 0x15, 0x06, 0x23, 0x20, 0x11, 0x11, 0x26, 0x23, 0x22, 0x15, 0x15,
+	0x33, 0x15, 0x23, 0x11, 0x21, 0x11, 0x23, 0x35, 0x33, 0x35, 0x10, 0x21, 0x32, 0x17, 0x21, 0x11,
+	0x16, 0x17, 0x16, 0x33, 0x32, 0x04, 0xfa, 0x43, 0x4c, 0xfe, 0xc7, 0x56, 0x45, 0xc9, 0xd6, 0xd6,
+	0xfe, 0xd8, 0x72, 0x72, 0x01, 0x92, 0x4f, 0xa2, 0x01, 0x31, 0x02, 0x13, 0x15, 0x42, 0x1b, 0xb6,
+	0xb6, 0x19, 0x01, 0x68, 0x04, 0x1c, 0x22, 0xe6, 0x5d, 0xb9, 0xfc, 0x6f, 0x03, 0x91, 0xb9, 0x45,
+	0x01, 0xb5, 0x19, 0xfb, 0x33, 0x68, 0x23, 0x26, 0x00, 0x03, 0x00, 0x00, 0xff, 0x00, 0x08, 0x00,
+	0x07, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x1f, 0x00, 0x46, 0x40, 0x43, 0x15, 0x01, 0x04, 0x03,
+	0x16, 0x02, 0x02, 0x02, 0x04, 0x02, 0x4c, 0x01, 0x01, 0x03, 0x4a, 0x03, 0x01, 0x00, 0x49, 0x00,
+	0x03, 0x04, 0x03, 0x85, 0x00, 0x04, 0x02, 0x04, 0x85, 0x00, 0x00, 0x01, 0x00, 0x86, 0x05, 0x01,
+	0x02, 0x01, 0x01, 0x02, 0x57, 0x05, 0x01, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x02, 0x01, 0x4f,
+	0x09, 0x08, 0x19, 0x17, 0x14, 0x12, 0x08, 0x1f, 0x09, 0x1f, 0x11, 0x14, 0x06, 0x06, 0x18, 0x2b,
+	0x11, 0x09, 0x02, 0x03, 0x21, 0x11, 0x21, 0x35, 0x21, 0x35, 0x34, 0x36, 0x37, 0x37, 0x36, 0x35,
+	0x34, 0x24, 0x21, 0x22, 0x07, 0x11, 0x36, 0x33, 0x32, 0x15, 0x14, 0x07, 0x07, 0x06, 0x15, 0x04,
+	0x00, 0x04, 0x00, 0xfc, 0x00, 0xc5, 0x01, 0x69, 0xfe, 0x97, 0x01, 0x69, 0x3a, 0x59, 0x43, 0x95,
+	0xfe, 0xdd, 0xfe, 0xfc, 0xbc, 0xbd, 0xcb, 0x85, 0xc4, 0x79, 0x47, 0x88, 0x03, 0x00, 0x04, 0x00,
+	0xfc, 0x00, 0xfc, 0x00, 0x01, 0x00, 0x01, 0x0f, 0x88, 0x35, 0x5a, 0x72, 0x54, 0x40, 0x8c, 0x80,
+	0x93, 0xa4, 0x3c, 0xfe, 0xfa, 0x60, 0xa7, 0x76, 0x81, 0x4d, 0x92, 0x6e, 0x00, 0x03, 0x00, 0x50,
+	0xff, 0xdb, 0x04, 0x23, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x42, 0x40, 0x3f,
+	0x00, 0x01, 0x00, 0x03, 0x04, 0x01, 0x03, 0x69, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05,
+	0x67, 0x07, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01,
+	0x00, 0x02, 0x00, 0x51, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15,
+	0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x06, 0x16, 0x2b,
+	0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x11, 0x10,
+	0x23, 0x22, 0x11, 0x10, 0x13, 0x35, 0x33, 0x15, 0x02, 0x34, 0xd9, 0xfe, 0xf5, 0x01, 0x0c, 0xde,
+	0xdc, 0x01, 0x0d, 0xfe, 0xf4, 0xdf, 0xb0, 0xae, 0xaf, 0x4b, 0xc9, 0x25, 0x01, 0xac, 0x01, 0x5e,
+	0x01, 0x60, 0x01, 0xa8, 0xfe, 0x59, 0xfe, 0xa3, 0xfe, 0x99, 0xfe, 0x59, 0xb9, 0x02, 0x5c, 0x02,
+	0x44, 0xfd, 0xb1, 0xfd, 0xaf, 0x02, 0x05, 0xc9, 0xc9, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xdb, 0x04, 0x24, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c,
+	0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16, 0x2b, 0x05, 0x22, 0x00,
+	0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x32, 0x11, 0x10, 0x23, 0x22, 0x11,
+	0x10, 0x02, 0x3a, 0xdf, 0xfe, 0xf5, 0x01, 0x0c, 0xde, 0xdd, 0x01, 0x0d, 0xfe, 0xf4, 0xde, 0xc8,
+	0xc8, 0xc8, 0x25, 0x01, 0xac, 0x01, 0x5e, 0x01, 0x60, 0x01, 0xa8, 0xfe, 0x59, 0xfe, 0x9f, 0xfe,
+	0x9d, 0xfe, 0x59, 0xb9, 0x02, 0x51, 0x02, 0x4f, 0xfd, 0xb1, 0xfd, 0xaf, 0x00, 0x01, 0x00, 0x00,
+	0x00, 0x02, 0x02, 0x8f, 0x8b, 0x72, 0x54, 0x07, 0x5f, 0x0f, 0x3c, 0xf5, 0x00, 0x0f, 0x08, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0xd4, 0x49, 0x69, 0x00, 0x00, 0x00, 0x00, 0x00, 0xde, 0xcc, 0x9b, 0x65,
+	0xfe, 0x3c, 0xfe, 0x14, 0x08, 0x8e, 0x08, 0xf3, 0x00, 0x01, 0x00, 0x09, 0x00, 0x02, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x07, 0x8f, 0xfe, 0x50, 0x00, 0x00, 0x08, 0xeb,
+	0xfe, 0x3c, 0xfe, 0x3a, 0x08, 0x8e, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xc7, 0x06, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x02, 0x39, 0x00, 0x00, 0x02, 0x39, 0x00, 0x00, 0x02, 0xaa, 0x00, 0xcb, 0x03, 0xcb, 0x00, 0x72,
+	0x04, 0x73, 0x00, 0x19, 0x04, 0x73, 0x00, 0x63, 0x07, 0x1d, 0x00, 0x54, 0x05, 0xc7, 0x00, 0x2d,
+	0x01, 0xe7, 0x00, 0x53, 0x02, 0xaa, 0x00, 0x54, 0x02, 0xaa, 0x00, 0x3d, 0x04, 0x77, 0x00, 0x57,
+	0x04, 0xac, 0x00, 0x68, 0x02, 0x39, 0x00, 0x7c, 0x04, 0xac, 0x00, 0x68, 0x02, 0x39, 0x00, 0x7c,
+	0x02, 0x39, 0x00, 0x00, 0x04, 0x73, 0x00, 0x50, 0x04, 0x73, 0x00, 0xb6, 0x04, 0x73, 0x00, 0x4d,
+	0x04, 0x73, 0x00, 0x89, 0x04, 0x73, 0x00, 0x1f, 0x04, 0x73, 0x00, 0x90, 0x04, 0x73, 0x00, 0x34,
+	0x04, 0x73, 0x00, 0x71, 0x04, 0x73, 0x00, 0x56, 0x04, 0x73, 0x00, 0x4f, 0x02, 0xaa, 0x00, 0xd6,
+	0x02, 0xaa, 0x00, 0xd6, 0x04, 0xac, 0x00, 0x68, 0x04, 0xac, 0x00, 0x68, 0x04, 0xac, 0x00, 0x69,
+	0x04, 0xe3, 0x00, 0x8c, 0x07, 0xcd, 0x00, 0xbf, 0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0xad,
+	0x05, 0xc7, 0x00, 0x50, 0x05, 0xc7, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad, 0x04, 0xe3, 0x00, 0xad,
+	0x06, 0x39, 0x00, 0x50, 0x05, 0xc7, 0x00, 0xad, 0x03, 0xa0, 0x00, 0x64, 0x04, 0x73, 0x00, 0x00,
+	0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0xad, 0x06, 0xaa, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad,
+	0x06, 0x39, 0x00, 0x50, 0x05, 0x56, 0x00, 0xad, 0x06, 0x39, 0x00, 0x50, 0x05, 0xc7, 0x00, 0xad,
+	0x05, 0x56, 0x00, 0x63, 0x04, 0xe3, 0x00, 0x28, 0x05, 0xc7, 0x00, 0xa0, 0x05, 0x56, 0x00, 0x19,
+	0x07, 0x8d, 0x00, 0x19, 0x05, 0x56, 0x00, 0x31, 0x05, 0x56, 0x00, 0x1c, 0x04, 0xe3, 0x00, 0x5e,
+	0x02, 0xaa, 0x00, 0x9f, 0x02, 0x39, 0x00, 0x00, 0x02, 0xaa, 0x00, 0x3c, 0x04, 0xac, 0x00, 0x68,
+	0x04, 0x73, 0x00, 0x00, 0x02, 0xaa, 0x00, 0x4b, 0x04, 0x73, 0x00, 0x45, 0x04, 0xe3, 0x00, 0x94,
+	0x04, 0x73, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x50, 0x04, 0x73, 0x00, 0x4a, 0x02, 0xaa, 0x00, 0x34,
+	0x04, 0xe3, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x94, 0x02, 0x50, 0x00, 0x8a, 0x02, 0x4d, 0xff, 0x70,
+	0x04, 0x73, 0x00, 0x94, 0x02, 0x63, 0x00, 0x87, 0x07, 0x1d, 0x00, 0x94, 0x04, 0xe3, 0x00, 0x94,
+	0x04, 0xe3, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x94, 0x04, 0xe3, 0x00, 0x50, 0x03, 0x1d, 0x00, 0xad,
+	0x04, 0x73, 0x00, 0x7b, 0x02, 0xaa, 0x00, 0x2a, 0x04, 0xe3, 0x00, 0x88, 0x04, 0x73, 0x00, 0x19,
+	0x06, 0x39, 0x00, 0x3e, 0x04, 0x73, 0x00, 0x30, 0x04, 0x73, 0x00, 0x19, 0x04, 0x00, 0x00, 0x6f,
+	0x03, 0x1d, 0x00, 0x63, 0x02, 0x3d, 0x00, 0xb1, 0x03, 0x1d, 0x00, 0x7b, 0x04, 0xac, 0x00, 0x50,
+	0x02, 0x39, 0x00, 0x00, 0x02, 0xaa, 0x00, 0xb7, 0x04, 0x73, 0x00, 0x94, 0x04, 0x73, 0x00, 0x66,
+	0x04, 0x73, 0x00, 0x02, 0x04, 0x73, 0x00, 0x00, 0x02, 0x3d, 0x00, 0xb1, 0x04, 0x73, 0x00, 0x8d,
+	0x02, 0xaa, 0x00, 0x14, 0x05, 0xe5, 0x00, 0x0c, 0x02, 0xf6, 0x00, 0x31, 0x04, 0x73, 0x00, 0x41,
+	0x04, 0xac, 0x00, 0x68, 0x02, 0xaa, 0x00, 0x4a, 0x05, 0xe5, 0x00, 0x0e, 0x04, 0x73, 0x00, 0x4e,
+	0x03, 0x33, 0x00, 0x72, 0x04, 0xac, 0x00, 0x68, 0x03, 0xf5, 0x00, 0x39, 0x03, 0xf5, 0x00, 0x66,
+	0x02, 0xaa, 0x00, 0x55, 0x04, 0xe3, 0x00, 0x94, 0x04, 0x73, 0x00, 0x4e, 0x02, 0x38, 0x00, 0x7b,
+	0x02, 0xaa, 0x00, 0x7b, 0x03, 0xf5, 0x00, 0x88, 0x02, 0xec, 0x00, 0x28, 0x04, 0x73, 0x00, 0x3e,
+	0x06, 0xac, 0x00, 0x25, 0x06, 0xac, 0x00, 0x25, 0x06, 0xac, 0x00, 0x63, 0x04, 0xe3, 0x00, 0x84,
+	0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x0c,
+	0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x0c, 0x08, 0x00, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0x50,
+	0x05, 0x56, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad,
+	0x03, 0xa0, 0x00, 0x64, 0x03, 0xa0, 0x00, 0x64, 0x03, 0xa0, 0x00, 0x56, 0x03, 0xa0, 0x00, 0x64,
+	0x05, 0xc7, 0x00, 0x00, 0x05, 0xc7, 0x00, 0xad, 0x06, 0x39, 0x00, 0x50, 0x06, 0x39, 0x00, 0x50,
+	0x06, 0x39, 0x00, 0x50, 0x06, 0x39, 0x00, 0x50, 0x06, 0x39, 0x00, 0x50, 0x04, 0xac, 0x00, 0x63,
+	0x06, 0x39, 0x00, 0x50, 0x05, 0xc7, 0x00, 0xa0, 0x05, 0xc7, 0x00, 0xa0, 0x05, 0xc7, 0x00, 0xa0,
+	0x05, 0xc7, 0x00, 0xa0, 0x05, 0x56, 0x00, 0x1c, 0x05, 0x56, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94,
+	0x04, 0x73, 0x00, 0x45, 0x04, 0x73, 0x00, 0x45, 0x04, 0x73, 0x00, 0x45, 0x04, 0x73, 0x00, 0x45,
+	0x04, 0x73, 0x00, 0x45, 0x04, 0x73, 0x00, 0x45, 0x07, 0x1d, 0x00, 0x45, 0x04, 0x73, 0x00, 0x4a,
+	0x04, 0x73, 0x00, 0x4a, 0x04, 0x73, 0x00, 0x4a, 0x04, 0x73, 0x00, 0x4a, 0x04, 0x73, 0x00, 0x4a,
+	0x02, 0x50, 0x00, 0x00, 0x02, 0x50, 0x00, 0x46, 0x02, 0x50, 0xff, 0xae, 0x02, 0x50, 0xff, 0xe7,
+	0x04, 0xe3, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x94, 0x04, 0xe3, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x4a,
+	0x04, 0xe3, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x4a, 0x04, 0xac, 0x00, 0x68,
+	0x04, 0xe3, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x88, 0x04, 0xe3, 0x00, 0x88, 0x04, 0xe3, 0x00, 0x88,
+	0x04, 0xe3, 0x00, 0x88, 0x04, 0x73, 0x00, 0x19, 0x04, 0xe3, 0x00, 0x94, 0x04, 0x73, 0x00, 0x19,
+	0x05, 0xc7, 0x00, 0x0c, 0x04, 0x73, 0x00, 0x45, 0x05, 0xc7, 0x00, 0x0c, 0x04, 0x73, 0x00, 0x45,
+	0x05, 0xc7, 0x00, 0x0c, 0x04, 0x73, 0x00, 0x45, 0x05, 0xc7, 0x00, 0x50, 0x04, 0x73, 0x00, 0x4a,
+	0x05, 0xc7, 0x00, 0x50, 0x04, 0x73, 0x00, 0x4a, 0x05, 0xc7, 0x00, 0x50, 0x04, 0x73, 0x00, 0x4a,
+	0x05, 0xc7, 0x00, 0x50, 0x04, 0x73, 0x00, 0x4a, 0x05, 0xc7, 0x00, 0xad, 0x05, 0xc0, 0x00, 0x50,
+	0x05, 0xc7, 0x00, 0x00, 0x04, 0xe3, 0x00, 0x50, 0x05, 0x56, 0x00, 0xad, 0x04, 0x73, 0x00, 0x4a,
+	0x05, 0x56, 0x00, 0xad, 0x04, 0x73, 0x00, 0x4a, 0x05, 0x56, 0x00, 0xad, 0x04, 0x73, 0x00, 0x4a,
+	0x05, 0x56, 0x00, 0xad, 0x04, 0x73, 0x00, 0x4a, 0x05, 0x56, 0x00, 0xad, 0x04, 0x73, 0x00, 0x4a,
+	0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x50, 0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x50,
+	0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x50, 0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x50,
+	0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94, 0x05, 0xc7, 0x00, 0x19, 0x04, 0xe3, 0x00, 0x19,
+	0x03, 0xa0, 0x00, 0x64, 0x02, 0x50, 0xff, 0xc8, 0x03, 0xa0, 0x00, 0x5e, 0x02, 0x50, 0xff, 0xb6,
+	0x03, 0xa0, 0x00, 0x64, 0x02, 0x50, 0xff, 0xc6, 0x03, 0xa0, 0x00, 0x64, 0x02, 0x50, 0x00, 0x3d,
+	0x03, 0xa0, 0x00, 0x64, 0x02, 0x50, 0x00, 0x94, 0x06, 0xfb, 0x00, 0x64, 0x04, 0x7d, 0x00, 0x94,
+	0x04, 0x73, 0x00, 0x00, 0x02, 0x43, 0xff, 0x70, 0x05, 0xc7, 0x00, 0xad, 0x04, 0x73, 0x00, 0x94,
+	0x04, 0x73, 0x00, 0x94, 0x04, 0xe3, 0x00, 0xad, 0x02, 0x63, 0x00, 0x62, 0x04, 0xe3, 0x00, 0xad,
+	0x02, 0x63, 0x00, 0x87, 0x04, 0xe3, 0x00, 0xad, 0x03, 0x41, 0x00, 0x87, 0x04, 0xe3, 0x00, 0xad,
+	0x03, 0xd5, 0x00, 0x87, 0x04, 0xe3, 0x00, 0x00, 0x02, 0x85, 0x00, 0x03, 0x05, 0xc7, 0x00, 0xad,
+	0x04, 0xe3, 0x00, 0x94, 0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94, 0x05, 0xc7, 0x00, 0xad,
+	0x04, 0xe3, 0x00, 0x94, 0x05, 0xab, 0x00, 0x0e, 0x05, 0xc7, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x94,
+	0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x4a, 0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x4a,
+	0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x4a, 0x08, 0x00, 0x00, 0x50, 0x07, 0x8d, 0x00, 0x4a,
+	0x05, 0xc7, 0x00, 0xad, 0x03, 0x1d, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad, 0x03, 0x1d, 0x00, 0xad,
+	0x05, 0xc7, 0x00, 0xad, 0x03, 0x1d, 0x00, 0x15, 0x05, 0x56, 0x00, 0x63, 0x04, 0x73, 0x00, 0x7b,
+	0x05, 0x56, 0x00, 0x63, 0x04, 0x73, 0x00, 0x7b, 0x05, 0x56, 0x00, 0x63, 0x04, 0x73, 0x00, 0x7b,
+	0x05, 0x56, 0x00, 0x63, 0x04, 0x73, 0x00, 0x7b, 0x04, 0xe3, 0x00, 0x28, 0x02, 0xaa, 0x00, 0x2a,
+	0x04, 0xe3, 0x00, 0x28, 0x03, 0xd5, 0x00, 0x2a, 0x04, 0xe3, 0x00, 0x28, 0x02, 0xaa, 0x00, 0x2a,
+	0x05, 0xc7, 0x00, 0xa0, 0x04, 0xe3, 0x00, 0x88, 0x05, 0xc7, 0x00, 0xa0, 0x04, 0xe3, 0x00, 0x88,
+	0x05, 0xc7, 0x00, 0xa0, 0x04, 0xe3, 0x00, 0x88, 0x05, 0xc7, 0x00, 0xa0, 0x04, 0xe3, 0x00, 0x88,
+	0x05, 0xc7, 0x00, 0xa0, 0x04, 0xe3, 0x00, 0x88, 0x05, 0xc7, 0x00, 0xa0, 0x04, 0xe3, 0x00, 0x88,
+	0x07, 0x8d, 0x00, 0x19, 0x06, 0x39, 0x00, 0x3e, 0x05, 0x56, 0x00, 0x1c, 0x04, 0x73, 0x00, 0x19,
+	0x05, 0x56, 0x00, 0x1c, 0x04, 0xe3, 0x00, 0x5e, 0x04, 0x00, 0x00, 0x6f, 0x04, 0xe3, 0x00, 0x5e,
+	0x04, 0x00, 0x00, 0x6f, 0x04, 0xe3, 0x00, 0x5e, 0x04, 0x00, 0x00, 0x6f, 0x02, 0x75, 0x00, 0x34,
+	0x04, 0x73, 0x00, 0x31, 0x05, 0xc7, 0x00, 0x0c, 0x04, 0x73, 0x00, 0x45, 0x03, 0xa0, 0x00, 0x57,
+	0x02, 0x50, 0xff, 0xaf, 0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x4a, 0x05, 0xc7, 0x00, 0xa0,
+	0x04, 0xe3, 0x00, 0x88, 0x05, 0xc7, 0x00, 0xa0, 0x04, 0xe3, 0x00, 0x88, 0x05, 0xc7, 0x00, 0xa0,
+	0x04, 0xe3, 0x00, 0x88, 0x05, 0xc7, 0x00, 0xa0, 0x04, 0xe3, 0x00, 0x88, 0x05, 0xc7, 0x00, 0xa0,
+	0x04, 0xe3, 0x00, 0x88, 0x05, 0xc7, 0x00, 0x0c, 0x04, 0x73, 0x00, 0x45, 0x08, 0x00, 0x00, 0x0c,
+	0x07, 0x1d, 0x00, 0x45, 0x06, 0x39, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x4a, 0x05, 0x56, 0x00, 0x63,
+	0x04, 0x73, 0x00, 0x7b, 0x04, 0xe3, 0x00, 0x28, 0x02, 0xaa, 0x00, 0x2a, 0x02, 0xaa, 0xff, 0xdc,
+	0x02, 0xaa, 0xff, 0xdc, 0x02, 0xaa, 0xff, 0xe3, 0x02, 0xaa, 0xff, 0xf3, 0x02, 0xaa, 0x00, 0xc1,
+	0x02, 0xaa, 0x00, 0x6b, 0x02, 0xaa, 0x00, 0x5e, 0x02, 0xaa, 0xff, 0xf5, 0x02, 0xaa, 0xff, 0xae,
+	0x02, 0xaa, 0x00, 0xd6, 0x02, 0xaa, 0x00, 0x76, 0x03, 0xb8, 0x00, 0x19, 0x05, 0xc7, 0x00, 0x0a,
+	0x02, 0xaa, 0x00, 0xb4, 0x06, 0xd3, 0x00, 0x0a, 0x07, 0x3f, 0x00, 0x0a, 0x04, 0x82, 0xff, 0x6a,
+	0x06, 0x99, 0x00, 0x00, 0x07, 0x6b, 0x00, 0x14, 0x06, 0xb4, 0x00, 0x00, 0x03, 0x14, 0xff, 0xc8,
+	0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc7, 0x00, 0xad, 0x04, 0xcf, 0x00, 0xad, 0x05, 0xc0, 0x00, 0x1e,
+	0x05, 0x56, 0x00, 0xad, 0x04, 0xe3, 0x00, 0x5e, 0x05, 0xc7, 0x00, 0xad, 0x06, 0x39, 0x00, 0x50,
+	0x03, 0xa0, 0x00, 0x64, 0x05, 0xc7, 0x00, 0xad, 0x05, 0x56, 0x00, 0x0e, 0x06, 0xaa, 0x00, 0xad,
+	0x05, 0xc7, 0x00, 0xad, 0x05, 0x26, 0x00, 0x28, 0x06, 0x39, 0x00, 0x50, 0x05, 0xc7, 0x00, 0xad,
+	0x05, 0x56, 0x00, 0xad, 0x04, 0xcd, 0x00, 0x46, 0x04, 0xe3, 0x00, 0x28, 0x05, 0x56, 0x00, 0x14,
+	0x06, 0x91, 0x00, 0x69, 0x05, 0x56, 0x00, 0x31, 0x06, 0x79, 0x00, 0x50, 0x06, 0x6a, 0x00, 0x5f,
+	0x03, 0xa0, 0x00, 0x64, 0x05, 0x56, 0x00, 0x14, 0x04, 0xeb, 0x00, 0x4a, 0x03, 0x9c, 0x00, 0x47,
+	0x04, 0xe3, 0x00, 0x41, 0x03, 0x14, 0x00, 0x91, 0x04, 0xa8, 0x00, 0x87, 0x04, 0xeb, 0x00, 0x4a,
+	0x04, 0xe2, 0x00, 0x94, 0x04, 0x73, 0x00, 0x07, 0x04, 0xda, 0x00, 0x4a, 0x03, 0xcc, 0x00, 0x47,
+	0x03, 0xaf, 0xff, 0xff, 0x04, 0xe3, 0x00, 0x41, 0x04, 0x53, 0x00, 0x4a, 0x03, 0x14, 0x00, 0x91,
+	0x04, 0x76, 0x00, 0x94, 0x04, 0x73, 0x00, 0x1b, 0x04, 0xe5, 0x00, 0x94, 0x04, 0x73, 0x00, 0x09,
+	0x03, 0x90, 0x00, 0x15, 0x04, 0xe3, 0x00, 0x4a, 0x06, 0x20, 0x00, 0x21, 0x04, 0xf3, 0x00, 0x87,
+	0x04, 0x29, 0x00, 0x4a, 0x05, 0x79, 0x00, 0x4a, 0x03, 0x92, 0x00, 0x0a, 0x04, 0xa8, 0x00, 0x87,
+	0x05, 0xb9, 0x00, 0x4a, 0x04, 0x9b, 0xff, 0xe3, 0x06, 0x07, 0x00, 0x31, 0x06, 0xc2, 0x00, 0x4a,
+	0x03, 0x14, 0xff, 0xff, 0x04, 0xa8, 0x00, 0x87, 0x04, 0xe3, 0x00, 0x4a, 0x04, 0xa8, 0x00, 0x87,
+	0x06, 0xc2, 0x00, 0x4a, 0x05, 0x56, 0x00, 0xad, 0x05, 0x5a, 0x00, 0xad, 0x07, 0x15, 0x00, 0x19,
+	0x04, 0x89, 0x00, 0xad, 0x05, 0xb1, 0x00, 0x5a, 0x05, 0x56, 0x00, 0x63, 0x03, 0xa0, 0x00, 0x64,
+	0x03, 0xa0, 0x00, 0x64, 0x04, 0x73, 0x00, 0x00, 0x08, 0xc0, 0x00, 0x28, 0x08, 0x80, 0x00, 0xad,
+	0x07, 0x00, 0x00, 0x28, 0x04, 0xe2, 0x00, 0xad, 0x05, 0xc0, 0x00, 0xad, 0x04, 0xfa, 0x00, 0x3a,
+	0x05, 0xc0, 0x00, 0xad, 0x05, 0xc7, 0x00, 0x0c, 0x05, 0xc0, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad,
+	0x04, 0x89, 0x00, 0xad, 0x05, 0xb3, 0x00, 0x0f, 0x05, 0x56, 0x00, 0xad, 0x07, 0x3b, 0x00, 0x24,
+	0x05, 0x03, 0x00, 0x69, 0x05, 0xc0, 0x00, 0xad, 0x05, 0xc0, 0x00, 0xad, 0x04, 0xe2, 0x00, 0xad,
+	0x05, 0x9d, 0x00, 0x14, 0x06, 0xaa, 0x00, 0xad, 0x05, 0xc7, 0x00, 0xad, 0x06, 0x39, 0x00, 0x50,
+	0x05, 0xc0, 0x00, 0xad, 0x05, 0x56, 0x00, 0xad, 0x05, 0xc7, 0x00, 0x50, 0x04, 0xe3, 0x00, 0x28,
+	0x04, 0xfa, 0x00, 0x3a, 0x06, 0xd4, 0x00, 0x50, 0x05, 0x56, 0x00, 0x31, 0x05, 0xd8, 0x00, 0xad,
+	0x05, 0x9f, 0x00, 0x7d, 0x08, 0x0a, 0x00, 0xad, 0x08, 0x27, 0x00, 0xad, 0x06, 0xf5, 0x00, 0x18,
+	0x07, 0xd5, 0x00, 0xad, 0x05, 0xc0, 0x00, 0xad, 0x05, 0xb1, 0x00, 0x46, 0x08, 0x40, 0x00, 0xad,
+	0x05, 0xc0, 0x00, 0x3e, 0x04, 0x73, 0x00, 0x45, 0x04, 0xf1, 0x00, 0x5f, 0x04, 0xeb, 0x00, 0x96,
+	0x03, 0x55, 0x00, 0x96, 0x05, 0x14, 0x00, 0x0a, 0x04, 0x73, 0x00, 0x4a, 0x05, 0xac, 0x00, 0x05,
+	0x03, 0xfa, 0x00, 0x3c, 0x04, 0xeb, 0x00, 0x94, 0x04, 0xeb, 0x00, 0x94, 0x04, 0x01, 0x00, 0x94,
+	0x05, 0x15, 0x00, 0x1e, 0x05, 0xeb, 0x00, 0x96, 0x04, 0xd5, 0x00, 0x96, 0x04, 0xe3, 0x00, 0x4a,
+	0x04, 0xd5, 0x00, 0x96, 0x04, 0xe3, 0x00, 0x94, 0x04, 0x73, 0x00, 0x4a, 0x03, 0xeb, 0x00, 0x14,
+	0x04, 0x73, 0x00, 0x00, 0x07, 0x00, 0x00, 0x4a, 0x04, 0x73, 0x00, 0x30, 0x04, 0xeb, 0x00, 0x94,
+	0x04, 0xa5, 0x00, 0x5a, 0x06, 0xab, 0x00, 0x96, 0x06, 0xc0, 0x00, 0x94, 0x05, 0xd5, 0xff, 0xff,
+	0x06, 0xd5, 0x00, 0x94, 0x04, 0xeb, 0x00, 0x94, 0x04, 0x6b, 0x00, 0x35, 0x06, 0xd5, 0x00, 0x94,
+	0x04, 0xab, 0x00, 0x35, 0x04, 0x73, 0x00, 0x4a, 0x04, 0x73, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x14,
+	0x03, 0x55, 0x00, 0x96, 0x04, 0x6b, 0x00, 0x4a, 0x04, 0x73, 0x00, 0x7b, 0x02, 0x39, 0x00, 0x89,
+	0x02, 0x40, 0xff, 0xdf, 0x02, 0x39, 0xff, 0xb6, 0x07, 0xc0, 0x00, 0x54, 0x07, 0x40, 0x00, 0x94,
+	0x04, 0xe3, 0x00, 0x14, 0x04, 0x01, 0x00, 0x94, 0x04, 0xeb, 0x00, 0x94, 0x04, 0x73, 0x00, 0x00,
+	0x04, 0xd5, 0x00, 0x96, 0x03, 0xe5, 0x00, 0xad, 0x03, 0x93, 0x00, 0x96, 0x07, 0x8d, 0x00, 0x19,
+	0x06, 0x39, 0x00, 0x3e, 0x07, 0x8d, 0x00, 0x19, 0x06, 0x39, 0x00, 0x3e, 0x07, 0x8d, 0x00, 0x19,
+	0x06, 0x39, 0x00, 0x3e, 0x05, 0x56, 0x00, 0x1c, 0x04, 0x73, 0x00, 0x19, 0x04, 0x73, 0x00, 0x58,
+	0x08, 0x00, 0x00, 0x50, 0x08, 0x00, 0x00, 0x00, 0x04, 0x6b, 0x00, 0x00, 0x02, 0x39, 0x00, 0x7c,
+	0x02, 0x39, 0x00, 0x7c, 0x02, 0x39, 0x00, 0x7c, 0x02, 0x39, 0x00, 0x7c, 0x04, 0x00, 0x00, 0x82,
+	0x04, 0x00, 0x00, 0x82, 0x04, 0x00, 0x00, 0x82, 0x04, 0x73, 0x00, 0x5e, 0x04, 0x73, 0x00, 0x5e,
+	0x02, 0xcd, 0x00, 0x2e, 0x08, 0x00, 0x00, 0xb5, 0x08, 0x00, 0x00, 0x17, 0x01, 0xeb, 0x00, 0x32,
+	0x03, 0xd5, 0x00, 0x49, 0x02, 0xaa, 0x00, 0x3e, 0x02, 0xaa, 0x00, 0x41, 0x04, 0xd5, 0x00, 0xb4,
+	0x02, 0xaa, 0x00, 0x00, 0x01, 0x56, 0xfe, 0x3c, 0x03, 0xf5, 0x00, 0x3c, 0x03, 0xf5, 0x00, 0x17,
+	0x03, 0xf5, 0x00, 0x6c, 0x03, 0xf5, 0x00, 0x27, 0x03, 0xf5, 0x00, 0x54, 0x03, 0xf5, 0x00, 0x40,
+	0x03, 0xf5, 0x00, 0x3b, 0x03, 0xf5, 0x00, 0x4e, 0x03, 0xf5, 0x00, 0x4e, 0x03, 0xf5, 0x00, 0x4e,
+	0x03, 0x2b, 0x00, 0xa4, 0x03, 0x2b, 0x00, 0x92, 0x03, 0xf5, 0x00, 0x6f, 0x03, 0xf5, 0x00, 0x3c,
+	0x03, 0xf5, 0x00, 0x88, 0x03, 0xf5, 0x00, 0x39, 0x03, 0xf5, 0x00, 0x66, 0x03, 0xf5, 0x00, 0x17,
+	0x03, 0xf5, 0x00, 0x6c, 0x03, 0xf5, 0x00, 0x27, 0x03, 0xf5, 0x00, 0x54, 0x03, 0xf5, 0x00, 0x40,
+	0x03, 0xf5, 0x00, 0x3b, 0x03, 0xf5, 0x00, 0x4e, 0x03, 0xf5, 0x00, 0x4e, 0x03, 0xf5, 0x00, 0x4e,
+	0x03, 0x2b, 0x00, 0xa4, 0x03, 0x2b, 0x00, 0x92, 0x03, 0xf5, 0x00, 0x6f, 0x04, 0x73, 0x00, 0x3c,
+	0x04, 0x73, 0x00, 0x6f, 0x08, 0xc0, 0x00, 0x3d, 0x04, 0x73, 0x00, 0x00, 0x07, 0x15, 0x00, 0x4a,
+	0x03, 0xe9, 0x00, 0x00, 0x08, 0xeb, 0x00, 0xaa, 0x08, 0x00, 0x00, 0xc5, 0x06, 0x25, 0x00, 0x51,
+	0x05, 0xb6, 0x00, 0x64, 0x06, 0xac, 0x00, 0x14, 0x06, 0xac, 0x00, 0x32, 0x06, 0xac, 0x00, 0x46,
+	0x06, 0xac, 0x00, 0x32, 0x08, 0x00, 0x00, 0x64, 0x04, 0x00, 0x00, 0x51, 0x08, 0x00, 0x00, 0xc8,
+	0x04, 0x00, 0x00, 0x51, 0x08, 0x00, 0x00, 0x64, 0x04, 0x00, 0x00, 0x51, 0x04, 0x00, 0x00, 0x51,
+	0x03, 0xf4, 0x00, 0x21, 0x04, 0xe5, 0x00, 0x1f, 0x06, 0x96, 0x00, 0x8c, 0x05, 0xb4, 0x00, 0x3c,
+	0x04, 0xac, 0x00, 0x68, 0x01, 0x56, 0xff, 0x18, 0x02, 0x39, 0x00, 0x4b, 0x04, 0x64, 0x00, 0x00,
+	0x05, 0xb4, 0x00, 0x3b, 0x07, 0xd5, 0x01, 0x6a, 0x05, 0xc7, 0x00, 0x93, 0x05, 0xc7, 0x00, 0x93,
+	0x02, 0x31, 0x00, 0x0c, 0x04, 0x64, 0x00, 0x45, 0x04, 0x64, 0x00, 0x5e, 0x04, 0xab, 0x00, 0x68,
+	0x04, 0x64, 0x00, 0x45, 0x04, 0x64, 0x00, 0x46, 0x04, 0xd5, 0x00, 0x8a, 0x04, 0xac, 0x00, 0x55,
+	0x04, 0xcd, 0x01, 0xe5, 0x04, 0xcd, 0x00, 0xa2, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x66, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xd5, 0x00, 0x64, 0x04, 0xd5, 0x00, 0x64,
+	0x02, 0xd6, 0x00, 0x64, 0x02, 0xd6, 0x00, 0x64, 0x08, 0x00, 0x00, 0x00, 0x07, 0xeb, 0x00, 0xfa,
+	0x07, 0xeb, 0x00, 0xfa, 0x07, 0xeb, 0x00, 0xfa, 0x07, 0xeb, 0x00, 0xfa, 0x03, 0xf4, 0x00, 0x20,
+	0x04, 0xd5, 0x00, 0xae, 0x04, 0xd5, 0x00, 0xae, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x02, 0xd6, 0x00, 0x42, 0x08, 0x2b, 0x01, 0x0c, 0x08, 0x6b, 0x01, 0x2d, 0x07, 0x55, 0x00, 0xad,
+	0x06, 0x00, 0x00, 0x66, 0x06, 0x00, 0x00, 0x2b, 0x04, 0x40, 0x00, 0x32, 0x05, 0x40, 0x00, 0x32,
+	0x04, 0xc0, 0x00, 0x4a, 0x04, 0x15, 0x00, 0x28, 0x04, 0x00, 0x00, 0x31, 0x05, 0xfe, 0x00, 0x64,
+	0x08, 0x00, 0x00, 0xfd, 0x04, 0xee, 0x00, 0x34, 0x05, 0x0e, 0x00, 0x34, 0x08, 0x00, 0x00, 0x00,
+	0x04, 0x73, 0x00, 0x50, 0x00, 0x50, 0x00, 0x00, 0x00, 0x00, 0x00, 0x2a, 0x00, 0x2a, 0x00, 0x2a,
+	0x00, 0x2a, 0x00, 0x6a, 0x00, 0x94, 0x01, 0x20, 0x01, 0xa6, 0x02, 0x70, 0x03, 0x00, 0x03, 0x1c,
+	0x03, 0x3c, 0x03, 0x5c, 0x03, 0xb4, 0x03, 0xe0, 0x04, 0x1e, 0x04, 0x3c, 0x04, 0x62, 0x04, 0x7c,
+	0x04, 0xe0, 0x05, 0x14, 0x05, 0x68, 0x05, 0xca, 0x06, 0x12, 0x06, 0x72, 0x06, 0xd8, 0x07, 0x12,
+	0x07, 0x76, 0x07, 0xda, 0x08, 0x26, 0x08, 0x94, 0x08, 0xac, 0x08, 0xd8, 0x08, 0xf0, 0x09, 0x52,
+	0x0a, 0x98, 0x0a, 0xdc, 0x0b, 0x40, 0x0b, 0x8e, 0x0b, 0xd4, 0x0c, 0x16, 0x0c, 0x50, 0x0c, 0xba,
+	0x0c, 0xf8, 0x0d, 0x32, 0x0d, 0x74, 0x0d, 0xae, 0x0d, 0xdc, 0x0e, 0x22, 0x0e, 0x58, 0x0e, 0xb0,
+	0x0e, 0xfa, 0x0f, 0x56, 0x0f, 0xa6, 0x10, 0x04, 0x10, 0x34, 0x10, 0x74, 0x10, 0xa6, 0x10, 0xe8,
+	0x11, 0x2a, 0x11, 0x60, 0x11, 0x9c, 0x11, 0xc0, 0x11, 0xe6, 0x12, 0x06, 0x12, 0x2c, 0x12, 0x4c,
+	0x12, 0x68, 0x12, 0xea, 0x13, 0x56, 0x13, 0x90, 0x14, 0x0c, 0x14, 0x50, 0x14, 0xb2, 0x15, 0x38,
+	0x15, 0x82, 0x15, 0xbe, 0x15, 0xfa, 0x16, 0x3a, 0x16, 0x64, 0x16, 0xd2, 0x17, 0x28, 0x17, 0x6a,
+	0x17, 0xc4, 0x18, 0x1c, 0x18, 0x7a, 0x18, 0xc0, 0x18, 0xfa, 0x19, 0x5a, 0x19, 0x8c, 0x19, 0xcc,
+	0x1a, 0x0c, 0x1a, 0x30, 0x1a, 0x6e, 0x1a, 0xc0, 0x1a, 0xda, 0x1b, 0x2c, 0x1b, 0x6c, 0x1b, 0x6c,
+	0x1b, 0x9c, 0x1c, 0x0c, 0x1c, 0x6a, 0x1c, 0xcc, 0x1d, 0x28, 0x1d, 0x52, 0x1d, 0xc4, 0x1d, 0xee,
+	0x1e, 0x6c, 0x1e, 0xc2, 0x1e, 0xea, 0x1f, 0x0c, 0x1f, 0x28, 0x1f, 0xaa, 0x1f, 0xca, 0x20, 0x0e,
+	0x20, 0x60, 0x20, 0xa4, 0x20, 0xf2, 0x21, 0x12, 0x21, 0x78, 0x21, 0xb8, 0x21, 0xd2, 0x22, 0x0a,
+	0x22, 0x30, 0x22, 0x6a, 0x22, 0x92, 0x23, 0x16, 0x23, 0xa6, 0x24, 0x58, 0x24, 0xa6, 0x24, 0xfe,
+	0x25, 0x5a, 0x25, 0xbe, 0x26, 0x38, 0x26, 0x9c, 0x27, 0x18, 0x27, 0x78, 0x28, 0x0e, 0x28, 0x66,
+	0x28, 0xc2, 0x29, 0x28, 0x29, 0x8a, 0x29, 0xda, 0x2a, 0x30, 0x2a, 0x8e, 0x2a, 0xea, 0x2b, 0x46,
+	0x2b, 0xb2, 0x2c, 0x20, 0x2c, 0x90, 0x2d, 0x0a, 0x2d, 0x98, 0x2e, 0x10, 0x2e, 0x34, 0x2e, 0xa4,
+	0x2e, 0xf8, 0x2f, 0x50, 0x2f, 0xb2, 0x30, 0x12, 0x30, 0x66, 0x30, 0xb6, 0x31, 0x4c, 0x31, 0xfc,
+	0x32, 0xb2, 0x33, 0x72, 0x34, 0x68, 0x35, 0x24, 0x35, 0xe0, 0x36, 0x6a, 0x36, 0xe4, 0x37, 0x52,
+	0x37, 0xc4, 0x38, 0x3e, 0x38, 0xb6, 0x39, 0x00, 0x39, 0x50, 0x39, 0xaa, 0x3a, 0x00, 0x3a, 0x7a,
+	0x3b, 0x2e, 0x3b, 0x98, 0x3c, 0x06, 0x3c, 0x7e, 0x3d, 0x24, 0x3d, 0x98, 0x3e, 0x24, 0x3e, 0x88,
+	0x3f, 0x1a, 0x3f, 0xb2, 0x40, 0x54, 0x40, 0xf2, 0x41, 0x3c, 0x41, 0x84, 0x41, 0xd4, 0x42, 0x2c,
+	0x42, 0xdc, 0x43, 0x44, 0x44, 0x22, 0x44, 0x98, 0x45, 0x58, 0x45, 0xbe, 0x46, 0x22, 0x46, 0x90,
+	0x46, 0xfc, 0x47, 0x5e, 0x47, 0xbe, 0x48, 0x2c, 0x48, 0x98, 0x49, 0x02, 0x49, 0xc4, 0x4a, 0x20,
+	0x4a, 0xaa, 0x4b, 0x00, 0x4b, 0x6c, 0x4b, 0xd2, 0x4c, 0x68, 0x4c, 0xc0, 0x4d, 0x2e, 0x4d, 0xaa,
+	0x4e, 0x22, 0x4e, 0x86, 0x4f, 0x00, 0x4f, 0x8c, 0x50, 0x58, 0x50, 0xe6, 0x51, 0xb6, 0x52, 0x34,
+	0x52, 0xf2, 0x53, 0x8a, 0x54, 0x5c, 0x54, 0xbc, 0x55, 0x26, 0x55, 0x84, 0x55, 0xe2, 0x56, 0x52,
+	0x56, 0xc4, 0x57, 0x16, 0x57, 0x50, 0x57, 0xac, 0x58, 0x08, 0x58, 0x78, 0x58, 0xe4, 0x59, 0x36,
+	0x59, 0x5c, 0x59, 0xc0, 0x5a, 0x32, 0x5a, 0x98, 0x5a, 0xf2, 0x5b, 0x5a, 0x5b, 0xc8, 0x5c, 0x04,
+	0x5c, 0x4a, 0x5c, 0x88, 0x5c, 0xe4, 0x5d, 0x32, 0x5d, 0x82, 0x5d, 0xc4, 0x5e, 0x06, 0x5e, 0x42,
+	0x5e, 0x82, 0x5e, 0xc0, 0x5f, 0x0e, 0x5f, 0x9a, 0x5f, 0xfe, 0x60, 0x8a, 0x60, 0xe2, 0x61, 0x78,
+	0x61, 0xf2, 0x62, 0x42, 0x62, 0xb6, 0x63, 0x22, 0x63, 0x8a, 0x64, 0x06, 0x64, 0x96, 0x65, 0x12,
+	0x65, 0x8a, 0x66, 0x20, 0x66, 0xac, 0x67, 0x14, 0x67, 0xa8, 0x68, 0x26, 0x68, 0xbe, 0x69, 0x30,
+	0x69, 0xd0, 0x6a, 0x46, 0x6a, 0xb6, 0x6b, 0x34, 0x6b, 0xac, 0x6c, 0x54, 0x6c, 0xdc, 0x6d, 0x5a,
+	0x6d, 0xd2, 0x6e, 0x34, 0x6e, 0x94, 0x6e, 0xe6, 0x6f, 0x3a, 0x6f, 0x82, 0x6f, 0xce, 0x70, 0x44,
+	0x71, 0x02, 0x71, 0x56, 0x71, 0xd2, 0x72, 0x34, 0x72, 0xd8, 0x73, 0x54, 0x73, 0xf8, 0x74, 0x5e,
+	0x75, 0x02, 0x75, 0x72, 0x76, 0x0a, 0x76, 0x6e, 0x76, 0xe6, 0x77, 0x42, 0x77, 0x94, 0x77, 0xec,
+	0x78, 0x42, 0x78, 0xae, 0x79, 0x00, 0x79, 0x68, 0x79, 0xc6, 0x7a, 0x3c, 0x7a, 0x96, 0x7a, 0xec,
+	0x7b, 0x50, 0x7c, 0x10, 0x7c, 0x6c, 0x7c, 0xc6, 0x7d, 0x42, 0x7d, 0xba, 0x7e, 0x1c, 0x7e, 0xbe,
+	0x7f, 0x32, 0x7f, 0xee, 0x80, 0x68, 0x81, 0x2a, 0x81, 0xac, 0x82, 0x78, 0x82, 0xec, 0x83, 0xa8,
+	0x84, 0x26, 0x84, 0xf2, 0x85, 0x6a, 0x86, 0x28, 0x86, 0xb0, 0x87, 0x42, 0x87, 0xcc, 0x88, 0x34,
+	0x88, 0x94, 0x88, 0xf2, 0x89, 0x1a, 0x89, 0x42, 0x89, 0x62, 0x89, 0x8e, 0x89, 0xb0, 0x89, 0xf4,
+	0x8a, 0x36, 0x8a, 0x72, 0x8a, 0xa2, 0x8b, 0x10, 0x8b, 0x30, 0x8b, 0x70, 0x8b, 0xcc, 0x8b, 0xe6,
+	0x8c, 0x3e, 0x8c, 0x98, 0x8d, 0x16, 0x8d, 0x9e, 0x8e, 0x20, 0x8e, 0xae, 0x8f, 0x26, 0x8f, 0x6a,
+	0x8f, 0xce, 0x8f, 0xf6, 0x90, 0x30, 0x90, 0x72, 0x90, 0xae, 0x90, 0xec, 0x91, 0x58, 0x91, 0x92,
+	0x91, 0xcc, 0x91, 0xfe, 0x92, 0x44, 0x92, 0x7a, 0x92, 0xc8, 0x93, 0x20, 0x93, 0x52, 0x93, 0x9c,
+	0x93, 0xe2, 0x94, 0x12, 0x94, 0x5c, 0x94, 0xc2, 0x95, 0x04, 0x95, 0x6a, 0x95, 0xc8, 0x96, 0x24,
+	0x96, 0x8e, 0x97, 0x4e, 0x97, 0xb0, 0x98, 0x30, 0x98, 0x72, 0x98, 0xf0, 0x99, 0x90, 0x99, 0xf2,
+	0x9a, 0x32, 0x9a, 0x88, 0x9a, 0xd4, 0x9b, 0x5a, 0x9b, 0xbc, 0x9c, 0x22, 0x9c, 0x50, 0x9c, 0x9a,
+	0x9c, 0xfc, 0x9d, 0x64, 0x9d, 0xb4, 0x9e, 0x70, 0x9e, 0xb2, 0x9e, 0xfc, 0x9f, 0x5a, 0x9f, 0xd8,
+	0xa0, 0x3c, 0xa0, 0x7e, 0xa0, 0xb4, 0xa1, 0x30, 0xa1, 0x72, 0xa1, 0xd0, 0xa2, 0x40, 0xa2, 0x9e,
+	0xa3, 0x02, 0xa3, 0x58, 0xa3, 0xa0, 0xa4, 0x26, 0xa4, 0x7e, 0xa4, 0xe0, 0xa5, 0x68, 0xa5, 0xa8,
+	0xa6, 0x06, 0xa6, 0x64, 0xa6, 0x9e, 0xa6, 0xfa, 0xa7, 0x3c, 0xa7, 0xaa, 0xa8, 0x0c, 0xa8, 0x60,
+	0xa8, 0xe2, 0xa9, 0x2a, 0xa9, 0xaa, 0xa9, 0xe8, 0xaa, 0x2c, 0xaa, 0x82, 0xaa, 0xe6, 0xab, 0x0e,
+	0xab, 0x70, 0xab, 0xb2, 0xac, 0x50, 0xac, 0xba, 0xac, 0xee, 0xad, 0x5a, 0xad, 0xc4, 0xae, 0x08,
+	0xae, 0x4e, 0xae, 0x8c, 0xae, 0xe4, 0xaf, 0x16, 0xaf, 0x60, 0xaf, 0xae, 0xaf, 0xde, 0xb0, 0x22,
+	0xb0, 0x92, 0xb0, 0xd4, 0xb1, 0x16, 0xb1, 0x5e, 0xb1, 0x9a, 0xb1, 0xe6, 0xb2, 0x3c, 0xb2, 0x9c,
+	0xb2, 0xea, 0xb3, 0x48, 0xb3, 0xd2, 0xb4, 0x2e, 0xb4, 0xb0, 0xb5, 0x0a, 0xb5, 0x6e, 0xb5, 0x9c,
+	0xb6, 0x3a, 0xb6, 0x7e, 0xb7, 0x16, 0xb7, 0x6a, 0xb7, 0xa2, 0xb8, 0x12, 0xb8, 0x74, 0xb8, 0xb4,
+	0xb8, 0xfc, 0xb9, 0x3a, 0xb9, 0x7c, 0xb9, 0xae, 0xba, 0x08, 0xba, 0x42, 0xba, 0x74, 0xba, 0xa8,
+	0xbb, 0xbc, 0xbb, 0xfc, 0xbc, 0x72, 0xbc, 0xba, 0xbc, 0xf6, 0xbd, 0x78, 0xbd, 0xca, 0xbe, 0x22,
+	0xbe, 0x6c, 0xbe, 0xb4, 0xbf, 0x3e, 0xbf, 0x9c, 0xbf, 0xee, 0xc0, 0x66, 0xc0, 0xf0, 0xc1, 0x36,
+	0xc1, 0x7e, 0xc1, 0xc4, 0xc2, 0x2c, 0xc2, 0x82, 0xc3, 0x02, 0xc3, 0x82, 0xc3, 0xde, 0xc4, 0x38,
+	0xc4, 0xb2, 0xc4, 0xfe, 0xc5, 0x66, 0xc5, 0xd6, 0xc6, 0x0a, 0xc6, 0x50, 0xc6, 0xac, 0xc7, 0x16,
+	0xc7, 0x74, 0xc7, 0xe2, 0xc8, 0x46, 0xc8, 0xb8, 0xc9, 0x06, 0xc9, 0x4a, 0xc9, 0x66, 0xc9, 0x82,
+	0xc9, 0x9e, 0xc9, 0xcc, 0xc9, 0xf0, 0xca, 0x3e, 0xca, 0x6e, 0xca, 0xbe, 0xca, 0xf6, 0xcb, 0x2a,
+	0xcb, 0x68, 0xcb, 0xae, 0xcc, 0x0c, 0xcc, 0x30, 0xcc, 0x72, 0xcd, 0x6c, 0xcd, 0x88, 0xcd, 0xb2,
+	0xcd, 0xca, 0xcd, 0xe2, 0xce, 0x40, 0xce, 0x60, 0xce, 0x86, 0xce, 0xd2, 0xcf, 0x08, 0xcf, 0x54,
+	0xcf, 0xa2, 0xcf, 0xce, 0xd0, 0x22, 0xd0, 0x6e, 0xd0, 0x9c, 0xd0, 0xb8, 0xd0, 0xe4, 0xd1, 0x00,
+	0xd1, 0x1c, 0xd1, 0x62, 0xd1, 0xae, 0xd1, 0xd4, 0xd2, 0x18, 0xd2, 0x66, 0xd2, 0x9a, 0xd2, 0xe6,
+	0xd3, 0x34, 0xd3, 0x60, 0xd3, 0xb4, 0xd4, 0x00, 0xd4, 0x3e, 0xd4, 0x5a, 0xd4, 0x86, 0xd4, 0xa2,
+	0xd4, 0xbe, 0xd5, 0x04, 0xd5, 0xa0, 0xd6, 0x12, 0xd7, 0x78, 0xd7, 0xfa, 0xd8, 0x70, 0xd8, 0xde,
+	0xd9, 0x44, 0xd9, 0x92, 0xd9, 0xe2, 0xda, 0x4a, 0xda, 0xf0, 0xdb, 0xca, 0xdc, 0xf8, 0xdd, 0xe6,
+	0xde, 0x10, 0xde, 0x32, 0xde, 0x5a, 0xde, 0x7c, 0xde, 0xac, 0xde, 0xcc, 0xdf, 0x02, 0xdf, 0x52,
+	0xdf, 0x80, 0xdf, 0xae, 0xdf, 0xe6, 0xe0, 0x02, 0xe0, 0x1e, 0xe0, 0x40, 0xe0, 0x66, 0xe0, 0xe4,
+	0xe1, 0x06, 0xe1, 0x36, 0xe1, 0x66, 0xe2, 0x00, 0xe2, 0x64, 0xe2, 0xbe, 0xe2, 0xf8, 0xe3, 0x26,
+	0xe3, 0x56, 0xe3, 0x86, 0xe3, 0xa8, 0xe4, 0x00, 0xe4, 0x56, 0xe4, 0x72, 0xe4, 0x88, 0xe4, 0xa8,
+	0xe4, 0xca, 0xe4, 0xea, 0xe5, 0x0c, 0xe5, 0x32, 0xe5, 0x5a, 0xe5, 0x80, 0xe5, 0xa6, 0xe5, 0xd6,
+	0xe6, 0x02, 0xe6, 0x28, 0xe6, 0x56, 0xe6, 0x80, 0xe6, 0xb4, 0xe6, 0xe0, 0xe7, 0x0a, 0xe7, 0x40,
+	0xe7, 0x6a, 0xe7, 0x92, 0xe7, 0xc2, 0xe7, 0xee, 0xe8, 0x16, 0xe8, 0x4c, 0xe8, 0x7c, 0xe8, 0xb2,
+	0xe8, 0xec, 0xe9, 0x1e, 0xe9, 0x52, 0xe9, 0x94, 0xe9, 0xca, 0xe9, 0xf6, 0xea, 0x36, 0xea, 0x6a,
+	0xea, 0x98, 0xea, 0xd8, 0xeb, 0x18, 0xeb, 0x58, 0xeb, 0xac, 0xeb, 0xc6, 0xeb, 0xdc, 0xeb, 0xf2,
+	0xec, 0x08, 0xec, 0x20, 0xed, 0x10, 0xed, 0xec, 0xee, 0x6a, 0xee, 0x82, 0xee, 0xac, 0xee, 0xca,
+	0xee, 0xf4, 0xef, 0x10, 0xef, 0x28, 0xef, 0x3a, 0xef, 0x54, 0xef, 0x66, 0xef, 0x84, 0xef, 0xc6,
+	0xef, 0xec, 0xf0, 0x22, 0xf0, 0x70, 0xf0, 0xb0, 0xf1, 0x4c, 0xf1, 0xca, 0xf2, 0x48, 0xf2, 0xb0,
+	0xf2, 0xfc, 0xf3, 0x36, 0xf3, 0x80, 0xf3, 0xb2, 0xf3, 0xce, 0xf4, 0x16, 0xf4, 0x5a, 0xfa, 0x56,
+	0xfb, 0x3a, 0xfb, 0xe6, 0xfc, 0x40, 0xfc, 0x90, 0xfc, 0xd0, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0xc8, 0x01, 0x3d, 0x00, 0x24, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xd8, 0x01, 0x5c,
+	0x00, 0x8d, 0x00, 0x00, 0x01, 0xf4, 0x0e, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x19,
+	0x01, 0x32, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x41, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x41, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x04, 0x00, 0x43, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x21,
+	0x00, 0x47, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x07, 0x00, 0x68, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0x23, 0x00, 0x6f, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x06, 0x00, 0x07, 0x00, 0x92, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x15,
+	0x00, 0x99, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09, 0x00, 0x1f, 0x00, 0xae, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x0a, 0x01, 0x42, 0x00, 0xcd, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x0c, 0x00, 0x0f, 0x02, 0x0f, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x06, 0x82,
+	0x02, 0x1e, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12, 0x00, 0x07, 0x08, 0xa0, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x00, 0x00, 0x82, 0x08, 0xa7, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x01, 0x00, 0x04, 0x09, 0x29, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x02, 0x00, 0x08,
+	0x09, 0x2d, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x03, 0x00, 0x42, 0x09, 0x35, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x04, 0x00, 0x0e, 0x09, 0x77, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x05, 0x00, 0x46, 0x09, 0x85, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x06, 0x00, 0x0e,
+	0x09, 0xcb, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x08, 0x00, 0x2a, 0x09, 0xd9, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x09, 0x00, 0x3e, 0x0a, 0x03, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x0a, 0x02, 0x84, 0x0a, 0x41, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x0c, 0x00, 0x1e,
+	0x0c, 0xc5, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x0d, 0x0d, 0x04, 0x0c, 0xe3, 0x43, 0x6f,
+	0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x28, 0x63, 0x29, 0x20, 0x32, 0x30, 0x31, 0x36,
+	0x20, 0x62, 0x79, 0x20, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20, 0x48, 0x6f,
+	0x6c, 0x6d, 0x65, 0x73, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x2e, 0x20, 0x41, 0x6c, 0x6c, 0x20, 0x72,
+	0x69, 0x67, 0x68, 0x74, 0x73, 0x20, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x2e, 0x47,
+	0x6f, 0x42, 0x6f, 0x6c, 0x64, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x26, 0x48, 0x6f, 0x6c,
+	0x6d, 0x65, 0x73, 0x49, 0x6e, 0x63, 0x2e, 0x3a, 0x20, 0x47, 0x6f, 0x20, 0x42, 0x6f, 0x6c, 0x64,
+	0x3a, 0x20, 0x32, 0x30, 0x31, 0x36, 0x47, 0x6f, 0x20, 0x42, 0x6f, 0x6c, 0x64, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x20, 0x32, 0x2e, 0x30, 0x31, 0x30, 0x3b, 0x20, 0x74, 0x74, 0x66, 0x61,
+	0x75, 0x74, 0x6f, 0x68, 0x69, 0x6e, 0x74, 0x20, 0x28, 0x76, 0x31, 0x2e, 0x38, 0x2e, 0x33, 0x29,
+	0x47, 0x6f, 0x2d, 0x42, 0x6f, 0x6c, 0x64, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26,
+	0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x4b, 0x72, 0x69, 0x73,
+	0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x43, 0x68, 0x61, 0x72,
+	0x6c, 0x65, 0x73, 0x20, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x47, 0x6f, 0x20, 0x69, 0x73,
+	0x20, 0x61, 0x20, 0x68, 0x75, 0x6d, 0x61, 0x6e, 0x69, 0x73, 0x74, 0x69, 0x63, 0x20, 0x73, 0x61,
+	0x6e, 0x73, 0x2d, 0x73, 0x65, 0x72, 0x69, 0x66, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x66, 0x6f,
+	0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x47, 0x6f, 0x20, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67,
+	0x65, 0x2e, 0x20, 0x49, 0x74, 0x73, 0x20, 0x78, 0x2d, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x2c,
+	0x20, 0x73, 0x74, 0x65, 0x6d, 0x20, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x2c, 0x20, 0x61, 0x6e,
+	0x64, 0x20, 0x64, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x63, 0x74, 0x69, 0x76, 0x65, 0x20, 0x66, 0x6f,
+	0x72, 0x6d, 0x73, 0x20, 0x6f, 0x66, 0x20, 0x7a, 0x65, 0x72, 0x6f, 0x2c, 0x20, 0x63, 0x61, 0x70,
+	0x69, 0x74, 0x61, 0x6c, 0x20, 0x4f, 0x2c, 0x20, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x63, 0x61, 0x73,
+	0x65, 0x20, 0x6c, 0x2c, 0x20, 0x66, 0x69, 0x67, 0x75, 0x72, 0x65, 0x20, 0x6f, 0x6e, 0x65, 0x2c,
+	0x20, 0x61, 0x6e, 0x64, 0x20, 0x63, 0x61, 0x70, 0x69, 0x74, 0x61, 0x6c, 0x20, 0x49, 0x20, 0x66,
+	0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x20, 0x74, 0x68, 0x65, 0x20, 0x44, 0x49, 0x4e, 0x20, 0x31, 0x34,
+	0x35, 0x30, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x6c, 0x65, 0x67, 0x69, 0x62, 0x69, 0x6c, 0x69,
+	0x74, 0x79, 0x20, 0x73, 0x74, 0x61, 0x6e, 0x64, 0x61, 0x72, 0x64, 0x2e, 0x20, 0x47, 0x6f, 0x27,
+	0x73, 0x20, 0x57, 0x47, 0x4c, 0x20, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74, 0x65, 0x72, 0x20,
+	0x73, 0x65, 0x74, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x20, 0x55, 0x6e, 0x69,
+	0x63, 0x6f, 0x64, 0x65, 0x20, 0x4c, 0x61, 0x74, 0x69, 0x6e, 0x2c, 0x20, 0x47, 0x72, 0x65, 0x65,
+	0x6b, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x43, 0x79, 0x72, 0x69, 0x6c, 0x6c, 0x69, 0x63, 0x20, 0x61,
+	0x6c, 0x70, 0x68, 0x61, 0x62, 0x65, 0x74, 0x73, 0x20, 0x70, 0x6c, 0x75, 0x73, 0x20, 0x73, 0x79,
+	0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x67, 0x72, 0x61, 0x70, 0x68, 0x69,
+	0x63, 0x61, 0x6c, 0x20, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x6c, 0x75, 0x63,
+	0x69, 0x64, 0x61, 0x66, 0x6f, 0x6e, 0x74, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x43, 0x6f, 0x70, 0x79,
+	0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x28, 0x63, 0x29, 0x20, 0x32, 0x30, 0x31, 0x36, 0x20, 0x42,
+	0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20,
+	0x49, 0x6e, 0x63, 0x2e, 0x2e, 0x20, 0x41, 0x6c, 0x6c, 0x20, 0x72, 0x69, 0x67, 0x68, 0x74, 0x73,
+	0x20, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x2e, 0x0a, 0x0a, 0x44, 0x69, 0x73, 0x74,
+	0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x6f, 0x66, 0x20, 0x74, 0x68, 0x69, 0x73,
+	0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x69, 0x73, 0x20, 0x67, 0x6f, 0x76, 0x65, 0x72, 0x6e, 0x65,
+	0x64, 0x20, 0x62, 0x79, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69,
+	0x6e, 0x67, 0x20, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x2e, 0x20, 0x49, 0x66, 0x20, 0x79,
+	0x6f, 0x75, 0x20, 0x64, 0x6f, 0x20, 0x6e, 0x6f, 0x74, 0x20, 0x61, 0x67, 0x72, 0x65, 0x65, 0x20,
+	0x74, 0x6f, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x2c,
+	0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x69, 0x6e, 0x67, 0x20, 0x74, 0x68, 0x65, 0x20, 0x64,
+	0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72, 0x2c, 0x20, 0x64, 0x6f, 0x20, 0x6e, 0x6f,
+	0x74, 0x20, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x20, 0x6f, 0x72, 0x20,
+	0x6d, 0x6f, 0x64, 0x69, 0x66, 0x79, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x66, 0x6f, 0x6e, 0x74,
+	0x2e, 0x0a, 0x0a, 0x52, 0x65, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f,
+	0x6e, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x75, 0x73, 0x65, 0x20, 0x69, 0x6e, 0x20, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x20, 0x66,
+	0x6f, 0x72, 0x6d, 0x73, 0x2c, 0x20, 0x77, 0x69, 0x74, 0x68, 0x20, 0x6f, 0x72, 0x20, 0x77, 0x69,
+	0x74, 0x68, 0x6f, 0x75, 0x74, 0x20, 0x6d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2c, 0x20, 0x61, 0x72, 0x65, 0x20, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x74, 0x74, 0x65,
+	0x64, 0x20, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x64, 0x20, 0x74, 0x68, 0x61, 0x74, 0x20,
+	0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x63, 0x6f,
+	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x61, 0x72, 0x65, 0x20, 0x6d, 0x65, 0x74,
+	0x3a, 0x0a, 0x0a, 0x20, 0x20, 0x20, 0x2a, 0x20, 0x52, 0x65, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x6f, 0x66, 0x20, 0x73, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x20, 0x63, 0x6f, 0x64, 0x65, 0x20, 0x6d, 0x75, 0x73, 0x74, 0x20, 0x72, 0x65, 0x74, 0x61,
+	0x69, 0x6e, 0x20, 0x74, 0x68, 0x65, 0x20, 0x61, 0x62, 0x6f, 0x76, 0x65, 0x20, 0x63, 0x6f, 0x70,
+	0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x2c, 0x20, 0x74,
+	0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x73, 0x74, 0x20, 0x6f, 0x66, 0x20, 0x63, 0x6f, 0x6e, 0x64,
+	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66,
+	0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69,
+	0x6d, 0x65, 0x72, 0x2e, 0x0a, 0x0a, 0x20, 0x20, 0x20, 0x2a, 0x20, 0x52, 0x65, 0x64, 0x69, 0x73,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x69, 0x6e, 0x20, 0x62, 0x69,
+	0x6e, 0x61, 0x72, 0x79, 0x20, 0x66, 0x6f, 0x72, 0x6d, 0x20, 0x6d, 0x75, 0x73, 0x74, 0x20, 0x72,
+	0x65, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x65, 0x20, 0x74, 0x68, 0x65, 0x20, 0x61, 0x62, 0x6f,
+	0x76, 0x65, 0x20, 0x63, 0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x6e, 0x6f, 0x74,
+	0x69, 0x63, 0x65, 0x2c, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x73, 0x74, 0x20, 0x6f,
+	0x66, 0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x61, 0x6e, 0x64,
+	0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x64,
+	0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72, 0x20, 0x69, 0x6e, 0x20, 0x74, 0x68, 0x65,
+	0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x61,
+	0x6e, 0x64, 0x2f, 0x6f, 0x72, 0x20, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x20, 0x6d, 0x61, 0x74, 0x65,
+	0x72, 0x69, 0x61, 0x6c, 0x73, 0x20, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x64, 0x20, 0x77,
+	0x69, 0x74, 0x68, 0x20, 0x74, 0x68, 0x65, 0x20, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x0a, 0x0a, 0x20, 0x20, 0x20, 0x2a, 0x20, 0x4e, 0x65, 0x69, 0x74,
+	0x68, 0x65, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x6e, 0x61, 0x6d, 0x65, 0x20, 0x6f, 0x66, 0x20,
+	0x47, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x20, 0x6e, 0x6f, 0x72, 0x20,
+	0x74, 0x68, 0x65, 0x20, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x20, 0x6f, 0x66, 0x20, 0x69, 0x74, 0x73,
+	0x20, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x6f, 0x72, 0x73, 0x20, 0x6d, 0x61,
+	0x79, 0x20, 0x62, 0x65, 0x20, 0x75, 0x73, 0x65, 0x64, 0x20, 0x74, 0x6f, 0x20, 0x65, 0x6e, 0x64,
+	0x6f, 0x72, 0x73, 0x65, 0x20, 0x6f, 0x72, 0x20, 0x70, 0x72, 0x6f, 0x6d, 0x6f, 0x74, 0x65, 0x20,
+	0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x73, 0x20, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64,
+	0x20, 0x66, 0x72, 0x6f, 0x6d, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x73, 0x6f, 0x66, 0x74, 0x77,
+	0x61, 0x72, 0x65, 0x20, 0x77, 0x69, 0x74, 0x68, 0x6f, 0x75, 0x74, 0x20, 0x73, 0x70, 0x65, 0x63,
+	0x69, 0x66, 0x69, 0x63, 0x20, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x20, 0x77, 0x72, 0x69, 0x74, 0x74,
+	0x65, 0x6e, 0x20, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2e, 0x0a, 0x0a,
+	0x44, 0x49, 0x53, 0x43, 0x4c, 0x41, 0x49, 0x4d, 0x45, 0x52, 0x3a, 0x20, 0x54, 0x48, 0x49, 0x53,
+	0x20, 0x53, 0x4f, 0x46, 0x54, 0x57, 0x41, 0x52, 0x45, 0x20, 0x49, 0x53, 0x20, 0x50, 0x52, 0x4f,
+	0x56, 0x49, 0x44, 0x45, 0x44, 0x20, 0x42, 0x59, 0x20, 0x54, 0x48, 0x45, 0x20, 0x43, 0x4f, 0x50,
+	0x59, 0x52, 0x49, 0x47, 0x48, 0x54, 0x20, 0x48, 0x4f, 0x4c, 0x44, 0x45, 0x52, 0x53, 0x20, 0x41,
+	0x4e, 0x44, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x4f, 0x52, 0x53, 0x20,
+	0x22, 0x41, 0x53, 0x20, 0x49, 0x53, 0x22, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x41, 0x4e, 0x59, 0x20,
+	0x45, 0x58, 0x50, 0x52, 0x45, 0x53, 0x53, 0x20, 0x4f, 0x52, 0x20, 0x49, 0x4d, 0x50, 0x4c, 0x49,
+	0x45, 0x44, 0x20, 0x57, 0x41, 0x52, 0x52, 0x41, 0x4e, 0x54, 0x49, 0x45, 0x53, 0x2c, 0x20, 0x49,
+	0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e, 0x47, 0x2c, 0x20, 0x42, 0x55, 0x54, 0x20, 0x4e, 0x4f,
+	0x54, 0x20, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x45, 0x44, 0x20, 0x54, 0x4f, 0x2c, 0x20, 0x54, 0x48,
+	0x45, 0x20, 0x49, 0x4d, 0x50, 0x4c, 0x49, 0x45, 0x44, 0x20, 0x57, 0x41, 0x52, 0x52, 0x41, 0x4e,
+	0x54, 0x49, 0x45, 0x53, 0x20, 0x4f, 0x46, 0x20, 0x4d, 0x45, 0x52, 0x43, 0x48, 0x41, 0x4e, 0x54,
+	0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x46, 0x49, 0x54, 0x4e,
+	0x45, 0x53, 0x53, 0x20, 0x46, 0x4f, 0x52, 0x20, 0x41, 0x20, 0x50, 0x41, 0x52, 0x54, 0x49, 0x43,
+	0x55, 0x4c, 0x41, 0x52, 0x20, 0x50, 0x55, 0x52, 0x50, 0x4f, 0x53, 0x45, 0x20, 0x41, 0x52, 0x45,
+	0x20, 0x44, 0x49, 0x53, 0x43, 0x4c, 0x41, 0x49, 0x4d, 0x45, 0x44, 0x2e, 0x20, 0x49, 0x4e, 0x20,
+	0x4e, 0x4f, 0x20, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x20, 0x53, 0x48, 0x41, 0x4c, 0x4c, 0x20, 0x54,
+	0x48, 0x45, 0x20, 0x43, 0x4f, 0x50, 0x59, 0x52, 0x49, 0x47, 0x48, 0x54, 0x20, 0x4f, 0x57, 0x4e,
+	0x45, 0x52, 0x20, 0x4f, 0x52, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x4f,
+	0x52, 0x53, 0x20, 0x42, 0x45, 0x20, 0x4c, 0x49, 0x41, 0x42, 0x4c, 0x45, 0x20, 0x46, 0x4f, 0x52,
+	0x20, 0x41, 0x4e, 0x59, 0x20, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x2c, 0x20, 0x49, 0x4e, 0x44,
+	0x49, 0x52, 0x45, 0x43, 0x54, 0x2c, 0x20, 0x49, 0x4e, 0x43, 0x49, 0x44, 0x45, 0x4e, 0x54, 0x41,
+	0x4c, 0x2c, 0x20, 0x53, 0x50, 0x45, 0x43, 0x49, 0x41, 0x4c, 0x2c, 0x20, 0x45, 0x58, 0x45, 0x4d,
+	0x50, 0x4c, 0x41, 0x52, 0x59, 0x2c, 0x20, 0x4f, 0x52, 0x20, 0x43, 0x4f, 0x4e, 0x53, 0x45, 0x51,
+	0x55, 0x45, 0x4e, 0x54, 0x49, 0x41, 0x4c, 0x20, 0x44, 0x41, 0x4d, 0x41, 0x47, 0x45, 0x53, 0x20,
+	0x28, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e, 0x47, 0x2c, 0x20, 0x42, 0x55, 0x54, 0x20,
+	0x4e, 0x4f, 0x54, 0x20, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x45, 0x44, 0x20, 0x54, 0x4f, 0x2c, 0x20,
+	0x50, 0x52, 0x4f, 0x43, 0x55, 0x52, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x20, 0x4f, 0x46, 0x20, 0x53,
+	0x55, 0x42, 0x53, 0x54, 0x49, 0x54, 0x55, 0x54, 0x45, 0x20, 0x47, 0x4f, 0x4f, 0x44, 0x53, 0x20,
+	0x4f, 0x52, 0x20, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43, 0x45, 0x53, 0x3b, 0x20, 0x4c, 0x4f, 0x53,
+	0x53, 0x20, 0x4f, 0x46, 0x20, 0x55, 0x53, 0x45, 0x2c, 0x20, 0x44, 0x41, 0x54, 0x41, 0x2c, 0x20,
+	0x4f, 0x52, 0x20, 0x50, 0x52, 0x4f, 0x46, 0x49, 0x54, 0x53, 0x3b, 0x20, 0x4f, 0x52, 0x20, 0x42,
+	0x55, 0x53, 0x49, 0x4e, 0x45, 0x53, 0x53, 0x20, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x52, 0x55, 0x50,
+	0x54, 0x49, 0x4f, 0x4e, 0x29, 0x20, 0x48, 0x4f, 0x57, 0x45, 0x56, 0x45, 0x52, 0x20, 0x43, 0x41,
+	0x55, 0x53, 0x45, 0x44, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x4f, 0x4e, 0x20, 0x41, 0x4e, 0x59, 0x20,
+	0x54, 0x48, 0x45, 0x4f, 0x52, 0x59, 0x20, 0x4f, 0x46, 0x20, 0x4c, 0x49, 0x41, 0x42, 0x49, 0x4c,
+	0x49, 0x54, 0x59, 0x2c, 0x20, 0x57, 0x48, 0x45, 0x54, 0x48, 0x45, 0x52, 0x20, 0x49, 0x4e, 0x20,
+	0x43, 0x4f, 0x4e, 0x54, 0x52, 0x41, 0x43, 0x54, 0x2c, 0x20, 0x53, 0x54, 0x52, 0x49, 0x43, 0x54,
+	0x20, 0x4c, 0x49, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x2c, 0x20, 0x4f, 0x52, 0x20, 0x54,
+	0x4f, 0x52, 0x54, 0x20, 0x28, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e, 0x47, 0x20, 0x4e,
+	0x45, 0x47, 0x4c, 0x49, 0x47, 0x45, 0x4e, 0x43, 0x45, 0x20, 0x4f, 0x52, 0x20, 0x4f, 0x54, 0x48,
+	0x45, 0x52, 0x57, 0x49, 0x53, 0x45, 0x29, 0x20, 0x41, 0x52, 0x49, 0x53, 0x49, 0x4e, 0x47, 0x20,
+	0x49, 0x4e, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x57, 0x41, 0x59, 0x20, 0x4f, 0x55, 0x54, 0x20, 0x4f,
+	0x46, 0x20, 0x54, 0x48, 0x45, 0x20, 0x55, 0x53, 0x45, 0x20, 0x4f, 0x46, 0x20, 0x54, 0x48, 0x49,
+	0x53, 0x20, 0x53, 0x4f, 0x46, 0x54, 0x57, 0x41, 0x52, 0x45, 0x2c, 0x20, 0x45, 0x56, 0x45, 0x4e,
+	0x20, 0x49, 0x46, 0x20, 0x41, 0x44, 0x56, 0x49, 0x53, 0x45, 0x44, 0x20, 0x4f, 0x46, 0x20, 0x54,
+	0x48, 0x45, 0x20, 0x50, 0x4f, 0x53, 0x53, 0x49, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x20, 0x4f,
+	0x46, 0x20, 0x53, 0x55, 0x43, 0x48, 0x20, 0x44, 0x41, 0x4d, 0x41, 0x47, 0x45, 0x2e, 0x47, 0x6f,
+	0x20, 0x42, 0x6f, 0x6c, 0x64, 0x00, 0x43, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00, 0x28, 0x00, 0x63, 0x00, 0x29, 0x00,
+	0x20, 0x00, 0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x36, 0x00, 0x20, 0x00, 0x62, 0x00, 0x79, 0x00,
+	0x20, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x20, 0x00, 0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x2e, 0x00, 0x20, 0x00,
+	0x41, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00,
+	0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x73, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x76, 0x00, 0x65, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x42, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x64, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00,
+	0x77, 0x00, 0x26, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00,
+	0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00,
+	0x20, 0x00, 0x42, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x64, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x32, 0x00,
+	0x30, 0x00, 0x31, 0x00, 0x36, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x42, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x64, 0x00, 0x56, 0x00, 0x65, 0x00, 0x72, 0x00, 0x73, 0x00, 0x69, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x20, 0x00, 0x32, 0x00, 0x2e, 0x00, 0x30, 0x00, 0x31, 0x00, 0x30, 0x00, 0x3b, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x74, 0x00, 0x66, 0x00, 0x61, 0x00, 0x75, 0x00, 0x74, 0x00, 0x6f, 0x00,
+	0x68, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x20, 0x00, 0x28, 0x00, 0x76, 0x00, 0x31, 0x00,
+	0x2e, 0x00, 0x38, 0x00, 0x2e, 0x00, 0x33, 0x00, 0x29, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x2d, 0x00,
+	0x42, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x64, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00,
+	0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00, 0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00,
+	0x2e, 0x00, 0x4b, 0x00, 0x72, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x43, 0x00, 0x68, 0x00, 0x61, 0x00, 0x72, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x20, 0x00,
+	0x68, 0x00, 0x75, 0x00, 0x6d, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x69, 0x00, 0x63, 0x00, 0x20, 0x00, 0x73, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x2d, 0x00,
+	0x73, 0x00, 0x65, 0x00, 0x72, 0x00, 0x69, 0x00, 0x66, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x74, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x61, 0x00,
+	0x6e, 0x00, 0x67, 0x00, 0x75, 0x00, 0x61, 0x00, 0x67, 0x00, 0x65, 0x00, 0x2e, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x78, 0x00, 0x2d, 0x00, 0x68, 0x00, 0x65, 0x00,
+	0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x65, 0x00, 0x6d, 0x00, 0x20, 0x00, 0x77, 0x00, 0x65, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00,
+	0x74, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x74, 0x00, 0x69, 0x00,
+	0x76, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x7a, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6f, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x63, 0x00, 0x61, 0x00, 0x70, 0x00, 0x69, 0x00, 0x74, 0x00, 0x61, 0x00,
+	0x6c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x65, 0x00, 0x72, 0x00, 0x63, 0x00, 0x61, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6c, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x66, 0x00, 0x69, 0x00, 0x67, 0x00, 0x75, 0x00, 0x72, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00,
+	0x64, 0x00, 0x20, 0x00, 0x63, 0x00, 0x61, 0x00, 0x70, 0x00, 0x69, 0x00, 0x74, 0x00, 0x61, 0x00,
+	0x6c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00,
+	0x6f, 0x00, 0x77, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x44, 0x00,
+	0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x31, 0x00, 0x34, 0x00, 0x35, 0x00, 0x30, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x67, 0x00,
+	0x69, 0x00, 0x62, 0x00, 0x69, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x74, 0x00, 0x79, 0x00, 0x20, 0x00,
+	0x73, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x61, 0x00, 0x72, 0x00, 0x64, 0x00,
+	0x2e, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x27, 0x00, 0x73, 0x00, 0x20, 0x00, 0x57, 0x00,
+	0x47, 0x00, 0x4c, 0x00, 0x20, 0x00, 0x63, 0x00, 0x68, 0x00, 0x61, 0x00, 0x72, 0x00, 0x61, 0x00,
+	0x63, 0x00, 0x74, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x73, 0x00, 0x65, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x64, 0x00, 0x65, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x55, 0x00, 0x6e, 0x00, 0x69, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x64, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x47, 0x00, 0x72, 0x00, 0x65, 0x00, 0x65, 0x00, 0x6b, 0x00, 0x20, 0x00, 0x61, 0x00,
+	0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x43, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x6c, 0x00,
+	0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x70, 0x00, 0x68, 0x00,
+	0x61, 0x00, 0x62, 0x00, 0x65, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x70, 0x00, 0x6c, 0x00,
+	0x75, 0x00, 0x73, 0x00, 0x20, 0x00, 0x73, 0x00, 0x79, 0x00, 0x6d, 0x00, 0x62, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x67, 0x00,
+	0x72, 0x00, 0x61, 0x00, 0x70, 0x00, 0x68, 0x00, 0x69, 0x00, 0x63, 0x00, 0x61, 0x00, 0x6c, 0x00,
+	0x20, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x74, 0x00,
+	0x73, 0x00, 0x2e, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x63, 0x00, 0x69, 0x00, 0x64, 0x00, 0x61, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x73, 0x00, 0x2e, 0x00, 0x63, 0x00, 0x6f, 0x00,
+	0x6d, 0x00, 0x43, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00,
+	0x68, 0x00, 0x74, 0x00, 0x20, 0x00, 0x28, 0x00, 0x63, 0x00, 0x29, 0x00, 0x20, 0x00, 0x32, 0x00,
+	0x30, 0x00, 0x31, 0x00, 0x36, 0x00, 0x20, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00,
+	0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00, 0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00,
+	0x2e, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00,
+	0x73, 0x00, 0x65, 0x00, 0x72, 0x00, 0x76, 0x00, 0x65, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x0a, 0x00,
+	0x0a, 0x00, 0x44, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00,
+	0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x74, 0x00, 0x20, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x67, 0x00, 0x6f, 0x00,
+	0x76, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6e, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x62, 0x00,
+	0x79, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00,
+	0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x65, 0x00, 0x2e, 0x00,
+	0x20, 0x00, 0x49, 0x00, 0x66, 0x00, 0x20, 0x00, 0x79, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x20, 0x00,
+	0x64, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x20, 0x00, 0x61, 0x00,
+	0x67, 0x00, 0x72, 0x00, 0x65, 0x00, 0x65, 0x00, 0x20, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00,
+	0x65, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00,
+	0x63, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x64, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00,
+	0x6c, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x64, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x20, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x6f, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x66, 0x00, 0x79, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00,
+	0x52, 0x00, 0x65, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00,
+	0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x61, 0x00,
+	0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x75, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00, 0x69, 0x00,
+	0x6e, 0x00, 0x20, 0x00, 0x73, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x72, 0x00, 0x63, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x62, 0x00, 0x69, 0x00, 0x6e, 0x00,
+	0x61, 0x00, 0x72, 0x00, 0x79, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00,
+	0x73, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x20, 0x00,
+	0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x6f, 0x00,
+	0x75, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x69, 0x00, 0x66, 0x00,
+	0x69, 0x00, 0x63, 0x00, 0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x70, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x6d, 0x00, 0x69, 0x00, 0x74, 0x00, 0x74, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x70, 0x00,
+	0x72, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x69, 0x00, 0x64, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x61, 0x00, 0x74, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00,
+	0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x72, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x74, 0x00, 0x3a, 0x00, 0x0a, 0x00, 0x0a, 0x00,
+	0x20, 0x00, 0x20, 0x00, 0x20, 0x00, 0x2a, 0x00, 0x20, 0x00, 0x52, 0x00, 0x65, 0x00, 0x64, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00,
+	0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00,
+	0x73, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x72, 0x00, 0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x63, 0x00,
+	0x6f, 0x00, 0x64, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x75, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x74, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x61, 0x00, 0x62, 0x00, 0x6f, 0x00, 0x76, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00,
+	0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x69, 0x00,
+	0x63, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00,
+	0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00,
+	0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00,
+	0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00,
+	0x73, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x20, 0x00, 0x20, 0x00, 0x20, 0x00, 0x2a, 0x00, 0x20, 0x00,
+	0x52, 0x00, 0x65, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00,
+	0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x69, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x62, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x72, 0x00,
+	0x79, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x20, 0x00, 0x6d, 0x00,
+	0x75, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x70, 0x00, 0x72, 0x00,
+	0x6f, 0x00, 0x64, 0x00, 0x75, 0x00, 0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x61, 0x00, 0x62, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x63, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00,
+	0x74, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x63, 0x00,
+	0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00,
+	0x6c, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x69, 0x00,
+	0x6e, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00,
+	0x63, 0x00, 0x75, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x61, 0x00, 0x74, 0x00,
+	0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x2f, 0x00,
+	0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x20, 0x00, 0x6d, 0x00, 0x61, 0x00, 0x74, 0x00, 0x65, 0x00, 0x72, 0x00, 0x69, 0x00, 0x61, 0x00,
+	0x6c, 0x00, 0x73, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x69, 0x00,
+	0x64, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x20, 0x00, 0x20, 0x00, 0x20, 0x00, 0x2a, 0x00,
+	0x20, 0x00, 0x4e, 0x00, 0x65, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x6d, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x6f, 0x00,
+	0x67, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00,
+	0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00,
+	0x66, 0x00, 0x20, 0x00, 0x69, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x6f, 0x00,
+	0x72, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x61, 0x00, 0x79, 0x00, 0x20, 0x00, 0x62, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x75, 0x00, 0x73, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x6f, 0x00, 0x20, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x73, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00,
+	0x6d, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x65, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00,
+	0x64, 0x00, 0x75, 0x00, 0x63, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x64, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x76, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x66, 0x00, 0x72, 0x00,
+	0x6f, 0x00, 0x6d, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x73, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x74, 0x00, 0x77, 0x00, 0x61, 0x00, 0x72, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x73, 0x00, 0x70, 0x00, 0x65, 0x00, 0x63, 0x00, 0x69, 0x00, 0x66, 0x00, 0x69, 0x00,
+	0x63, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00,
+	0x77, 0x00, 0x72, 0x00, 0x69, 0x00, 0x74, 0x00, 0x74, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x20, 0x00,
+	0x70, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x69, 0x00, 0x73, 0x00, 0x73, 0x00, 0x69, 0x00,
+	0x6f, 0x00, 0x6e, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x44, 0x00, 0x49, 0x00, 0x53, 0x00,
+	0x43, 0x00, 0x4c, 0x00, 0x41, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x52, 0x00, 0x3a, 0x00,
+	0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00, 0x53, 0x00, 0x4f, 0x00,
+	0x46, 0x00, 0x54, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x50, 0x00, 0x52, 0x00, 0x4f, 0x00, 0x56, 0x00, 0x49, 0x00, 0x44, 0x00,
+	0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x42, 0x00, 0x59, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00,
+	0x45, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x59, 0x00, 0x52, 0x00, 0x49, 0x00,
+	0x47, 0x00, 0x48, 0x00, 0x54, 0x00, 0x20, 0x00, 0x48, 0x00, 0x4f, 0x00, 0x4c, 0x00, 0x44, 0x00,
+	0x45, 0x00, 0x52, 0x00, 0x53, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00,
+	0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x52, 0x00, 0x49, 0x00, 0x42, 0x00, 0x55, 0x00,
+	0x54, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x53, 0x00, 0x20, 0x00, 0x22, 0x00, 0x41, 0x00, 0x53, 0x00,
+	0x20, 0x00, 0x49, 0x00, 0x53, 0x00, 0x22, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00,
+	0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x45, 0x00, 0x58, 0x00, 0x50, 0x00,
+	0x52, 0x00, 0x45, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x4d, 0x00, 0x50, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00,
+	0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x52, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00,
+	0x45, 0x00, 0x53, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00,
+	0x55, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x42, 0x00,
+	0x55, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00,
+	0x49, 0x00, 0x4d, 0x00, 0x49, 0x00, 0x54, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x54, 0x00,
+	0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x4d, 0x00, 0x50, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x57, 0x00,
+	0x41, 0x00, 0x52, 0x00, 0x52, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00, 0x45, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x52, 0x00,
+	0x43, 0x00, 0x48, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x41, 0x00, 0x42, 0x00, 0x49, 0x00,
+	0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00,
+	0x20, 0x00, 0x46, 0x00, 0x49, 0x00, 0x54, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x53, 0x00, 0x53, 0x00,
+	0x20, 0x00, 0x46, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x41, 0x00, 0x20, 0x00, 0x50, 0x00,
+	0x41, 0x00, 0x52, 0x00, 0x54, 0x00, 0x49, 0x00, 0x43, 0x00, 0x55, 0x00, 0x4c, 0x00, 0x41, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x50, 0x00, 0x55, 0x00, 0x52, 0x00, 0x50, 0x00, 0x4f, 0x00, 0x53, 0x00,
+	0x45, 0x00, 0x20, 0x00, 0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x20, 0x00, 0x44, 0x00, 0x49, 0x00,
+	0x53, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x41, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x44, 0x00,
+	0x2e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x20, 0x00,
+	0x45, 0x00, 0x56, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x20, 0x00, 0x53, 0x00, 0x48, 0x00,
+	0x41, 0x00, 0x4c, 0x00, 0x4c, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00,
+	0x43, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x59, 0x00, 0x52, 0x00, 0x49, 0x00, 0x47, 0x00, 0x48, 0x00,
+	0x54, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x57, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x52, 0x00,
+	0x49, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x53, 0x00, 0x20, 0x00,
+	0x42, 0x00, 0x45, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x41, 0x00, 0x42, 0x00, 0x4c, 0x00,
+	0x45, 0x00, 0x20, 0x00, 0x46, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00,
+	0x59, 0x00, 0x20, 0x00, 0x44, 0x00, 0x49, 0x00, 0x52, 0x00, 0x45, 0x00, 0x43, 0x00, 0x54, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x49, 0x00, 0x52, 0x00, 0x45, 0x00,
+	0x43, 0x00, 0x54, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x49, 0x00,
+	0x44, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x53, 0x00, 0x50, 0x00, 0x45, 0x00, 0x43, 0x00, 0x49, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x45, 0x00, 0x58, 0x00, 0x45, 0x00, 0x4d, 0x00, 0x50, 0x00, 0x4c, 0x00, 0x41, 0x00,
+	0x52, 0x00, 0x59, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x43, 0x00,
+	0x4f, 0x00, 0x4e, 0x00, 0x53, 0x00, 0x45, 0x00, 0x51, 0x00, 0x55, 0x00, 0x45, 0x00, 0x4e, 0x00,
+	0x54, 0x00, 0x49, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x4d, 0x00,
+	0x41, 0x00, 0x47, 0x00, 0x45, 0x00, 0x53, 0x00, 0x20, 0x00, 0x28, 0x00, 0x49, 0x00, 0x4e, 0x00,
+	0x43, 0x00, 0x4c, 0x00, 0x55, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x54, 0x00,
+	0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x49, 0x00, 0x54, 0x00, 0x45, 0x00, 0x44, 0x00,
+	0x20, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x50, 0x00, 0x52, 0x00, 0x4f, 0x00,
+	0x43, 0x00, 0x55, 0x00, 0x52, 0x00, 0x45, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x53, 0x00, 0x55, 0x00, 0x42, 0x00, 0x53, 0x00,
+	0x54, 0x00, 0x49, 0x00, 0x54, 0x00, 0x55, 0x00, 0x54, 0x00, 0x45, 0x00, 0x20, 0x00, 0x47, 0x00,
+	0x4f, 0x00, 0x4f, 0x00, 0x44, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x53, 0x00, 0x45, 0x00, 0x52, 0x00, 0x56, 0x00, 0x49, 0x00, 0x43, 0x00, 0x45, 0x00, 0x53, 0x00,
+	0x3b, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x4f, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x46, 0x00, 0x20, 0x00, 0x55, 0x00, 0x53, 0x00, 0x45, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x44, 0x00,
+	0x41, 0x00, 0x54, 0x00, 0x41, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x50, 0x00, 0x52, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x49, 0x00, 0x54, 0x00, 0x53, 0x00, 0x3b, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x42, 0x00, 0x55, 0x00, 0x53, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x45, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x54, 0x00,
+	0x45, 0x00, 0x52, 0x00, 0x52, 0x00, 0x55, 0x00, 0x50, 0x00, 0x54, 0x00, 0x49, 0x00, 0x4f, 0x00,
+	0x4e, 0x00, 0x29, 0x00, 0x20, 0x00, 0x48, 0x00, 0x4f, 0x00, 0x57, 0x00, 0x45, 0x00, 0x56, 0x00,
+	0x45, 0x00, 0x52, 0x00, 0x20, 0x00, 0x43, 0x00, 0x41, 0x00, 0x55, 0x00, 0x53, 0x00, 0x45, 0x00,
+	0x44, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x4e, 0x00,
+	0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00,
+	0x4f, 0x00, 0x52, 0x00, 0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x4c, 0x00,
+	0x49, 0x00, 0x41, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x57, 0x00, 0x48, 0x00, 0x45, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00,
+	0x54, 0x00, 0x52, 0x00, 0x41, 0x00, 0x43, 0x00, 0x54, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x53, 0x00,
+	0x54, 0x00, 0x52, 0x00, 0x49, 0x00, 0x43, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00,
+	0x41, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x54, 0x00,
+	0x20, 0x00, 0x28, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x55, 0x00, 0x44, 0x00,
+	0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x47, 0x00, 0x4c, 0x00,
+	0x49, 0x00, 0x47, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x45, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x52, 0x00, 0x57, 0x00,
+	0x49, 0x00, 0x53, 0x00, 0x45, 0x00, 0x29, 0x00, 0x20, 0x00, 0x41, 0x00, 0x52, 0x00, 0x49, 0x00,
+	0x53, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00,
+	0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x57, 0x00, 0x41, 0x00, 0x59, 0x00, 0x20, 0x00,
+	0x4f, 0x00, 0x55, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x54, 0x00,
+	0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x55, 0x00, 0x53, 0x00, 0x45, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x46, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00, 0x53, 0x00,
+	0x4f, 0x00, 0x46, 0x00, 0x54, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x45, 0x00, 0x56, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x46, 0x00,
+	0x20, 0x00, 0x41, 0x00, 0x44, 0x00, 0x56, 0x00, 0x49, 0x00, 0x53, 0x00, 0x45, 0x00, 0x44, 0x00,
+	0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00,
+	0x50, 0x00, 0x4f, 0x00, 0x53, 0x00, 0x53, 0x00, 0x49, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00,
+	0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x53, 0x00,
+	0x55, 0x00, 0x43, 0x00, 0x48, 0x00, 0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x4d, 0x00, 0x41, 0x00,
+	0x47, 0x00, 0x45, 0x00, 0x2e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0x06, 0x00, 0x64, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xc8, 0x00, 0x00, 0x02, 0x07, 0x02, 0x08,
+	0x00, 0x03, 0x00, 0x04, 0x00, 0x05, 0x00, 0x06, 0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0a,
+	0x00, 0x0b, 0x00, 0x0c, 0x00, 0x0d, 0x00, 0x0e, 0x00, 0x0f, 0x00, 0x10, 0x00, 0x11, 0x00, 0x12,
+	0x00, 0x13, 0x00, 0x14, 0x00, 0x15, 0x00, 0x16, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19, 0x00, 0x1a,
+	0x00, 0x1b, 0x00, 0x1c, 0x00, 0x1d, 0x00, 0x1e, 0x00, 0x1f, 0x00, 0x20, 0x00, 0x21, 0x00, 0x22,
+	0x00, 0x23, 0x00, 0x24, 0x00, 0x25, 0x00, 0x26, 0x00, 0x27, 0x00, 0x28, 0x00, 0x29, 0x00, 0x2a,
+	0x00, 0x2b, 0x00, 0x2c, 0x00, 0x2d, 0x00, 0x2e, 0x00, 0x2f, 0x00, 0x30, 0x00, 0x31, 0x00, 0x32,
+	0x00, 0x33, 0x00, 0x34, 0x00, 0x35, 0x00, 0x36, 0x00, 0x37, 0x00, 0x38, 0x00, 0x39, 0x00, 0x3a,
+	0x00, 0x3b, 0x00, 0x3c, 0x00, 0x3d, 0x00, 0x3e, 0x00, 0x3f, 0x00, 0x40, 0x00, 0x41, 0x00, 0x42,
+	0x00, 0x43, 0x00, 0x44, 0x00, 0x45, 0x00, 0x46, 0x00, 0x47, 0x00, 0x48, 0x00, 0x49, 0x00, 0x4a,
+	0x00, 0x4b, 0x00, 0x4c, 0x00, 0x4d, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x51, 0x00, 0x52,
+	0x00, 0x53, 0x00, 0x54, 0x00, 0x55, 0x00, 0x56, 0x00, 0x57, 0x00, 0x58, 0x00, 0x59, 0x00, 0x5a,
+	0x00, 0x5b, 0x00, 0x5c, 0x00, 0x5d, 0x00, 0x5e, 0x00, 0x5f, 0x00, 0x60, 0x00, 0x61, 0x02, 0x09,
+	0x00, 0xa3, 0x00, 0x84, 0x00, 0x85, 0x00, 0xbd, 0x00, 0x96, 0x00, 0xe8, 0x00, 0x86, 0x00, 0x8e,
+	0x00, 0x8b, 0x00, 0x9d, 0x00, 0xa9, 0x00, 0xa4, 0x02, 0x0a, 0x00, 0x8a, 0x00, 0xda, 0x00, 0x83,
+	0x00, 0x93, 0x02, 0x0b, 0x02, 0x0c, 0x00, 0x8d, 0x00, 0x97, 0x00, 0x88, 0x00, 0xc3, 0x00, 0xde,
+	0x02, 0x0d, 0x00, 0x9e, 0x00, 0xaa, 0x00, 0xf5, 0x00, 0xf4, 0x00, 0xf6, 0x00, 0xa2, 0x00, 0xad,
+	0x00, 0xc9, 0x00, 0xc7, 0x00, 0xae, 0x00, 0x62, 0x00, 0x63, 0x00, 0x90, 0x00, 0x64, 0x00, 0xcb,
+	0x00, 0x65, 0x00, 0xc8, 0x00, 0xca, 0x00, 0xcf, 0x00, 0xcc, 0x00, 0xcd, 0x00, 0xce, 0x00, 0xe9,
+	0x00, 0x66, 0x00, 0xd3, 0x00, 0xd0, 0x00, 0xd1, 0x00, 0xaf, 0x00, 0x67, 0x00, 0xf0, 0x00, 0x91,
+	0x00, 0xd6, 0x00, 0xd4, 0x00, 0xd5, 0x00, 0x68, 0x00, 0xeb, 0x00, 0xed, 0x00, 0x89, 0x00, 0x6a,
+	0x00, 0x69, 0x00, 0x6b, 0x00, 0x6d, 0x00, 0x6c, 0x00, 0x6e, 0x00, 0xa0, 0x00, 0x6f, 0x00, 0x71,
+	0x00, 0x70, 0x00, 0x72, 0x00, 0x73, 0x00, 0x75, 0x00, 0x74, 0x00, 0x76, 0x00, 0x77, 0x00, 0xea,
+	0x00, 0x78, 0x00, 0x7a, 0x00, 0x79, 0x00, 0x7b, 0x00, 0x7d, 0x00, 0x7c, 0x00, 0xb8, 0x00, 0xa1,
+	0x00, 0x7f, 0x00, 0x7e, 0x00, 0x80, 0x00, 0x81, 0x00, 0xec, 0x00, 0xee, 0x00, 0xba, 0x01, 0x06,
+	0x01, 0x88, 0x01, 0x03, 0x01, 0x84, 0x01, 0x07, 0x01, 0x8a, 0x00, 0xfd, 0x00, 0xfe, 0x01, 0x0a,
+	0x01, 0x95, 0x01, 0x0b, 0x01, 0x96, 0x00, 0xff, 0x01, 0x00, 0x01, 0x0d, 0x01, 0x9a, 0x01, 0x0e,
+	0x01, 0x01, 0x01, 0x12, 0x01, 0xa3, 0x01, 0x0f, 0x01, 0xa0, 0x01, 0x11, 0x01, 0xa2, 0x01, 0x14,
+	0x01, 0xa5, 0x01, 0x10, 0x01, 0xa1, 0x01, 0x1b, 0x01, 0xb2, 0x00, 0xf8, 0x00, 0xf9, 0x01, 0x1c,
+	0x01, 0xb3, 0x02, 0x0e, 0x02, 0x0f, 0x01, 0x22, 0x01, 0xb6, 0x01, 0x21, 0x01, 0xb5, 0x01, 0x2a,
+	0x01, 0xc7, 0x01, 0x25, 0x01, 0xbb, 0x01, 0x24, 0x01, 0xb9, 0x01, 0x26, 0x01, 0xc2, 0x00, 0xfa,
+	0x00, 0xd7, 0x01, 0x23, 0x01, 0xba, 0x01, 0x2b, 0x01, 0xc8, 0x02, 0x10, 0x02, 0x11, 0x01, 0xca,
+	0x01, 0x2d, 0x01, 0xcb, 0x02, 0x12, 0x02, 0x13, 0x01, 0x2f, 0x01, 0xcd, 0x01, 0x30, 0x01, 0xce,
+	0x00, 0xe2, 0x00, 0xe3, 0x01, 0x32, 0x01, 0xd7, 0x02, 0x14, 0x02, 0x15, 0x01, 0x33, 0x01, 0xd9,
+	0x01, 0xd8, 0x01, 0x13, 0x01, 0xa4, 0x01, 0x37, 0x01, 0xdd, 0x01, 0x35, 0x01, 0xdb, 0x01, 0x36,
+	0x01, 0xdc, 0x00, 0xb0, 0x00, 0xb1, 0x01, 0x3f, 0x01, 0xea, 0x02, 0x16, 0x02, 0x17, 0x01, 0x40,
+	0x01, 0xeb, 0x01, 0x6a, 0x01, 0xef, 0x01, 0x6b, 0x01, 0xf0, 0x00, 0xfb, 0x00, 0xfc, 0x00, 0xe4,
+	0x00, 0xe5, 0x02, 0x18, 0x02, 0x19, 0x01, 0x6f, 0x01, 0xfb, 0x01, 0x6e, 0x01, 0xfa, 0x01, 0x79,
+	0x02, 0xc4, 0x01, 0x73, 0x02, 0x05, 0x01, 0x71, 0x02, 0x03, 0x01, 0x78, 0x02, 0xc3, 0x01, 0x72,
+	0x02, 0x04, 0x01, 0x74, 0x02, 0xbd, 0x01, 0x7b, 0x02, 0xc6, 0x01, 0x7f, 0x02, 0xca, 0x00, 0xbb,
+	0x01, 0x81, 0x02, 0xcc, 0x01, 0x82, 0x02, 0xcd, 0x00, 0xe6, 0x00, 0xe7, 0x01, 0xd1, 0x00, 0xa6,
+	0x02, 0x1a, 0x02, 0x1b, 0x02, 0x1c, 0x02, 0x1d, 0x02, 0x1e, 0x02, 0x1f, 0x02, 0x20, 0x02, 0x21,
+	0x02, 0x22, 0x02, 0x23, 0x02, 0x24, 0x02, 0x25, 0x02, 0x26, 0x02, 0x27, 0x02, 0x28, 0x02, 0x29,
+	0x01, 0x08, 0x01, 0x8b, 0x01, 0x02, 0x01, 0x85, 0x01, 0x3b, 0x01, 0xe5, 0x02, 0x2a, 0x02, 0x2b,
+	0x02, 0x2c, 0x02, 0x2d, 0x00, 0xd8, 0x00, 0xe1, 0x02, 0x2e, 0x00, 0xdb, 0x00, 0xdc, 0x00, 0xdd,
+	0x00, 0xe0, 0x00, 0xd9, 0x00, 0xdf, 0x02, 0x2f, 0x01, 0xfe, 0x01, 0x9d, 0x01, 0x05, 0x01, 0x89,
+	0x01, 0x16, 0x01, 0x18, 0x01, 0x29, 0x01, 0x3a, 0x01, 0x77, 0x01, 0x38, 0x01, 0xc5, 0x01, 0x04,
+	0x01, 0x09, 0x01, 0x1a, 0x02, 0x30, 0x01, 0x15, 0x01, 0x83, 0x01, 0x17, 0x01, 0x70, 0x01, 0x27,
+	0x01, 0x2c, 0x01, 0x2e, 0x01, 0x31, 0x01, 0x34, 0x01, 0x7e, 0x01, 0x39, 0x01, 0x3d, 0x01, 0x41,
+	0x01, 0x6c, 0x01, 0x6d, 0x01, 0x75, 0x01, 0x3c, 0x01, 0x0c, 0x01, 0x3e, 0x02, 0x31, 0x01, 0x28,
+	0x01, 0x76, 0x01, 0x87, 0x01, 0xa7, 0x01, 0xab, 0x01, 0xc6, 0x02, 0xc1, 0x01, 0x86, 0x01, 0x93,
+	0x01, 0xb1, 0x01, 0x9b, 0x01, 0xa6, 0x02, 0xd0, 0x01, 0xaa, 0x01, 0xfc, 0x01, 0xc3, 0x01, 0xc9,
+	0x01, 0xcc, 0x02, 0x32, 0x01, 0xda, 0x02, 0xc9, 0x01, 0xe0, 0x00, 0x9b, 0x01, 0xed, 0x01, 0xf5,
+	0x01, 0xf4, 0x01, 0xf9, 0x02, 0xbf, 0x01, 0xe7, 0x01, 0x97, 0x01, 0xe8, 0x01, 0xde, 0x01, 0xc4,
+	0x02, 0xc0, 0x01, 0xe1, 0x02, 0xc2, 0x01, 0xdf, 0x02, 0x33, 0x02, 0x34, 0x02, 0x35, 0x02, 0x36,
+	0x02, 0x37, 0x02, 0x38, 0x02, 0x39, 0x02, 0x3a, 0x02, 0x3b, 0x02, 0x3c, 0x02, 0x3d, 0x02, 0x3e,
+	0x02, 0x3f, 0x02, 0x40, 0x02, 0x41, 0x02, 0x42, 0x02, 0x43, 0x02, 0x44, 0x02, 0x45, 0x02, 0x46,
+	0x02, 0x47, 0x02, 0x48, 0x02, 0x49, 0x02, 0x4a, 0x02, 0x4b, 0x02, 0x4c, 0x02, 0x4d, 0x02, 0x4e,
+	0x02, 0x4f, 0x02, 0x50, 0x02, 0x51, 0x02, 0x52, 0x02, 0x53, 0x02, 0x54, 0x02, 0x55, 0x02, 0x56,
+	0x02, 0x57, 0x02, 0x58, 0x02, 0x59, 0x02, 0x5a, 0x02, 0x5b, 0x02, 0x5c, 0x02, 0x5d, 0x02, 0x5e,
+	0x02, 0x5f, 0x02, 0x60, 0x02, 0x61, 0x02, 0x62, 0x02, 0x63, 0x02, 0x64, 0x02, 0x65, 0x02, 0x66,
+	0x02, 0x67, 0x02, 0x68, 0x02, 0x69, 0x02, 0x6a, 0x02, 0x6b, 0x02, 0x6c, 0x02, 0x6d, 0x02, 0x6e,
+	0x02, 0x6f, 0x02, 0x70, 0x02, 0x71, 0x02, 0x72, 0x02, 0x73, 0x02, 0x74, 0x02, 0x75, 0x02, 0x76,
+	0x02, 0x77, 0x02, 0x78, 0x02, 0x79, 0x02, 0x7a, 0x02, 0x7b, 0x02, 0x7c, 0x02, 0x7d, 0x02, 0x7e,
+	0x02, 0x7f, 0x02, 0x80, 0x02, 0x81, 0x02, 0x82, 0x02, 0x83, 0x02, 0x84, 0x02, 0x85, 0x02, 0x86,
+	0x02, 0x87, 0x02, 0x88, 0x02, 0x89, 0x02, 0x8a, 0x02, 0x8b, 0x02, 0x8c, 0x02, 0x8d, 0x02, 0x8e,
+	0x02, 0x8f, 0x02, 0x90, 0x02, 0x91, 0x02, 0x92, 0x02, 0x93, 0x02, 0x94, 0x01, 0x7d, 0x02, 0xc8,
+	0x01, 0x7a, 0x02, 0xc5, 0x01, 0x7c, 0x02, 0xc7, 0x01, 0x80, 0x02, 0xcb, 0x00, 0xb2, 0x00, 0xb3,
+	0x02, 0x95, 0x02, 0x06, 0x00, 0xb6, 0x00, 0xb7, 0x00, 0xc4, 0x01, 0xe9, 0x00, 0xb4, 0x00, 0xb5,
+	0x00, 0xc5, 0x00, 0x82, 0x00, 0xc2, 0x00, 0x87, 0x00, 0xab, 0x00, 0xc6, 0x01, 0xd4, 0x01, 0xf1,
+	0x00, 0xbe, 0x00, 0xbf, 0x01, 0xac, 0x02, 0x96, 0x00, 0xbc, 0x02, 0x97, 0x02, 0x98, 0x02, 0x99,
+	0x02, 0x9a, 0x02, 0x9b, 0x02, 0x9c, 0x02, 0x9d, 0x02, 0x9e, 0x02, 0x9f, 0x02, 0xa0, 0x02, 0xa1,
+	0x02, 0xa2, 0x02, 0xa3, 0x02, 0xa4, 0x02, 0xa5, 0x02, 0xa6, 0x02, 0xa7, 0x02, 0xa8, 0x02, 0xa9,
+	0x02, 0xaa, 0x02, 0xab, 0x02, 0xac, 0x02, 0xad, 0x02, 0xae, 0x02, 0xaf, 0x02, 0xb0, 0x02, 0xb1,
+	0x02, 0xb2, 0x02, 0xb3, 0x00, 0xf7, 0x01, 0xd0, 0x01, 0xe6, 0x01, 0x19, 0x02, 0xb4, 0x02, 0xb5,
+	0x02, 0xb6, 0x00, 0x8c, 0x00, 0x9f, 0x01, 0xa9, 0x01, 0xe2, 0x01, 0xfd, 0x01, 0xb0, 0x01, 0xf2,
+	0x01, 0x8e, 0x01, 0x90, 0x01, 0x8f, 0x01, 0x8d, 0x01, 0x8c, 0x01, 0x91, 0x01, 0x92, 0x00, 0x98,
+	0x00, 0xa8, 0x00, 0x9a, 0x00, 0x99, 0x00, 0xef, 0x02, 0xb7, 0x02, 0xb8, 0x00, 0xa5, 0x00, 0x92,
+	0x01, 0xe4, 0x01, 0xbe, 0x02, 0xbc, 0x00, 0x9c, 0x00, 0xa7, 0x00, 0x8f, 0x01, 0xa8, 0x00, 0x94,
+	0x00, 0x95, 0x01, 0xb8, 0x01, 0xec, 0x01, 0xbd, 0x01, 0xbc, 0x01, 0x4b, 0x01, 0x4c, 0x01, 0x42,
+	0x01, 0x44, 0x01, 0x43, 0x01, 0x45, 0x01, 0x49, 0x01, 0x4a, 0x01, 0x47, 0x01, 0x48, 0x01, 0x46,
+	0x01, 0x5e, 0x01, 0x52, 0x01, 0x66, 0x01, 0x67, 0x01, 0x5a, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x53,
+	0x01, 0x65, 0x01, 0x64, 0x01, 0x59, 0x01, 0x56, 0x01, 0x55, 0x01, 0x54, 0x01, 0x57, 0x01, 0x58,
+	0x01, 0x5d, 0x01, 0x4d, 0x01, 0x4e, 0x01, 0x51, 0x01, 0x62, 0x01, 0x63, 0x01, 0x5c, 0x01, 0x60,
+	0x01, 0x61, 0x01, 0x5b, 0x01, 0x69, 0x01, 0x68, 0x01, 0x5f, 0x02, 0xbe, 0x01, 0x9f, 0x01, 0x94,
+	0x01, 0xcf, 0x01, 0xee, 0x01, 0xd2, 0x01, 0xf3, 0x01, 0x9e, 0x01, 0xae, 0x01, 0x20, 0x01, 0x1e,
+	0x01, 0x1f, 0x01, 0xaf, 0x02, 0x02, 0x02, 0x01, 0x01, 0xff, 0x02, 0x00, 0x00, 0xb9, 0x01, 0x98,
+	0x01, 0x1d, 0x01, 0xbf, 0x01, 0xc0, 0x01, 0xe3, 0x01, 0xf6, 0x01, 0xc1, 0x01, 0xf8, 0x01, 0xad,
+	0x01, 0xd3, 0x01, 0xf7, 0x01, 0x99, 0x01, 0xb7, 0x01, 0x9c, 0x01, 0xd5, 0x01, 0xd6, 0x01, 0xb4,
+	0x02, 0xb9, 0x02, 0xba, 0x02, 0xbb, 0x02, 0xce, 0x02, 0xcf, 0x07, 0x41, 0x45, 0x61, 0x63, 0x75,
+	0x74, 0x65, 0x06, 0x41, 0x62, 0x72, 0x65, 0x76, 0x65, 0x05, 0x41, 0x6c, 0x70, 0x68, 0x61, 0x0a,
+	0x41, 0x6c, 0x70, 0x68, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x41, 0x6d, 0x61, 0x63, 0x72,
+	0x6f, 0x6e, 0x07, 0x41, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x0a, 0x41, 0x72, 0x69, 0x6e, 0x67,
+	0x61, 0x63, 0x75, 0x74, 0x65, 0x04, 0x42, 0x65, 0x74, 0x61, 0x0b, 0x43, 0x63, 0x69, 0x72, 0x63,
+	0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x43, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e,
+	0x74, 0x03, 0x43, 0x68, 0x69, 0x06, 0x44, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x06, 0x44, 0x63, 0x72,
+	0x6f, 0x61, 0x74, 0x06, 0x45, 0x62, 0x72, 0x65, 0x76, 0x65, 0x06, 0x45, 0x63, 0x61, 0x72, 0x6f,
+	0x6e, 0x0a, 0x45, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x07, 0x45, 0x6d, 0x61,
+	0x63, 0x72, 0x6f, 0x6e, 0x03, 0x45, 0x6e, 0x67, 0x07, 0x45, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b,
+	0x07, 0x45, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0c, 0x45, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e,
+	0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x03, 0x45, 0x74, 0x61, 0x08, 0x45, 0x74, 0x61, 0x74, 0x6f, 0x6e,
+	0x6f, 0x73, 0x04, 0x45, 0x75, 0x72, 0x6f, 0x05, 0x47, 0x61, 0x6d, 0x6d, 0x61, 0x0b, 0x47, 0x63,
+	0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x47, 0x64, 0x6f, 0x74, 0x61, 0x63,
+	0x63, 0x65, 0x6e, 0x74, 0x06, 0x48, 0x31, 0x38, 0x35, 0x33, 0x33, 0x06, 0x48, 0x31, 0x38, 0x35,
+	0x34, 0x33, 0x06, 0x48, 0x31, 0x38, 0x35, 0x35, 0x31, 0x06, 0x48, 0x32, 0x32, 0x30, 0x37, 0x33,
+	0x04, 0x48, 0x62, 0x61, 0x72, 0x0b, 0x48, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65,
+	0x78, 0x02, 0x49, 0x4a, 0x06, 0x49, 0x62, 0x72, 0x65, 0x76, 0x65, 0x07, 0x49, 0x6d, 0x61, 0x63,
+	0x72, 0x6f, 0x6e, 0x07, 0x49, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x04, 0x49, 0x6f, 0x74, 0x61,
+	0x0c, 0x49, 0x6f, 0x74, 0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x09, 0x49, 0x6f,
+	0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x06, 0x49, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x0b, 0x4a,
+	0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x4b, 0x61, 0x70, 0x70, 0x61,
+	0x06, 0x4c, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x4c, 0x61, 0x6d, 0x62, 0x64, 0x61, 0x06, 0x4c,
+	0x63, 0x61, 0x72, 0x6f, 0x6e, 0x04, 0x4c, 0x64, 0x6f, 0x74, 0x02, 0x4d, 0x75, 0x06, 0x4e, 0x61,
+	0x63, 0x75, 0x74, 0x65, 0x06, 0x4e, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x02, 0x4e, 0x75, 0x06, 0x4f,
+	0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x4f, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c,
+	0x61, 0x75, 0x74, 0x07, 0x4f, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x0a, 0x4f, 0x6d, 0x65, 0x67,
+	0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x4f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x0c, 0x4f,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0b, 0x4f, 0x73, 0x6c, 0x61,
+	0x73, 0x68, 0x61, 0x63, 0x75, 0x74, 0x65, 0x03, 0x50, 0x68, 0x69, 0x02, 0x50, 0x69, 0x03, 0x50,
+	0x73, 0x69, 0x06, 0x52, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x52, 0x63, 0x61, 0x72, 0x6f, 0x6e,
+	0x03, 0x52, 0x68, 0x6f, 0x08, 0x53, 0x46, 0x30, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x30, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x30, 0x34, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x35, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x30, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x37, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30,
+	0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x31, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x31, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x31, 0x39, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x32, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x31, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x33,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x34, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x32, 0x35, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x32, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x38, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x33, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x37, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33,
+	0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x34, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x32, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x34, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x34, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x35, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x36,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x34, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x35, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x31, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x35, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x33, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x34, 0x30, 0x30, 0x30, 0x30, 0x06, 0x53, 0x61, 0x63,
+	0x75, 0x74, 0x65, 0x0b, 0x53, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05,
+	0x53, 0x69, 0x67, 0x6d, 0x61, 0x03, 0x54, 0x61, 0x75, 0x04, 0x54, 0x62, 0x61, 0x72, 0x06, 0x54,
+	0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x54, 0x68, 0x65, 0x74, 0x61, 0x06, 0x55, 0x62, 0x72, 0x65,
+	0x76, 0x65, 0x0d, 0x55, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74,
+	0x07, 0x55, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x07, 0x55, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b,
+	0x07, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0f, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e,
+	0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x0c, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e,
+	0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x05, 0x55, 0x72, 0x69, 0x6e, 0x67, 0x06, 0x55, 0x74, 0x69, 0x6c,
+	0x64, 0x65, 0x06, 0x57, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x57, 0x63, 0x69, 0x72, 0x63, 0x75,
+	0x6d, 0x66, 0x6c, 0x65, 0x78, 0x09, 0x57, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x06,
+	0x57, 0x67, 0x72, 0x61, 0x76, 0x65, 0x02, 0x58, 0x69, 0x0b, 0x59, 0x63, 0x69, 0x72, 0x63, 0x75,
+	0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06, 0x59, 0x67, 0x72, 0x61, 0x76, 0x65, 0x06, 0x5a, 0x61, 0x63,
+	0x75, 0x74, 0x65, 0x0a, 0x5a, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x04, 0x5a,
+	0x65, 0x74, 0x61, 0x06, 0x61, 0x62, 0x72, 0x65, 0x76, 0x65, 0x07, 0x61, 0x65, 0x61, 0x63, 0x75,
+	0x74, 0x65, 0x05, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x0a, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x74, 0x6f,
+	0x6e, 0x6f, 0x73, 0x07, 0x61, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x09, 0x61, 0x6e, 0x6f, 0x74,
+	0x65, 0x6c, 0x65, 0x69, 0x61, 0x07, 0x61, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x0a, 0x61, 0x72,
+	0x69, 0x6e, 0x67, 0x61, 0x63, 0x75, 0x74, 0x65, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x62, 0x6f,
+	0x74, 0x68, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x64, 0x6f, 0x77, 0x6e, 0x09, 0x61, 0x72, 0x72,
+	0x6f, 0x77, 0x6c, 0x65, 0x66, 0x74, 0x0a, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x72, 0x69, 0x67, 0x68,
+	0x74, 0x07, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x75, 0x70, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x75,
+	0x70, 0x64, 0x6e, 0x0c, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x75, 0x70, 0x64, 0x6e, 0x62, 0x73, 0x65,
+	0x04, 0x62, 0x65, 0x74, 0x61, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x0b, 0x63, 0x63, 0x69, 0x72,
+	0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x63, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65,
+	0x6e, 0x74, 0x03, 0x63, 0x68, 0x69, 0x06, 0x63, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x04, 0x63, 0x6c,
+	0x75, 0x62, 0x06, 0x64, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x07,
+	0x64, 0x69, 0x61, 0x6d, 0x6f, 0x6e, 0x64, 0x0d, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73,
+	0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x64, 0x6b, 0x73, 0x68, 0x61, 0x64, 0x65, 0x07, 0x64, 0x6e,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x06, 0x65, 0x62, 0x72, 0x65, 0x76, 0x65, 0x06, 0x65, 0x63, 0x61,
+	0x72, 0x6f, 0x6e, 0x0a, 0x65, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x07, 0x65,
+	0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x03, 0x65, 0x6e, 0x67, 0x07, 0x65, 0x6f, 0x67, 0x6f, 0x6e,
+	0x65, 0x6b, 0x07, 0x65, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0c, 0x65, 0x70, 0x73, 0x69, 0x6c,
+	0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0b, 0x65, 0x71, 0x75, 0x69, 0x76, 0x61, 0x6c, 0x65,
+	0x6e, 0x63, 0x65, 0x09, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x03, 0x65, 0x74,
+	0x61, 0x08, 0x65, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09, 0x65, 0x78, 0x63, 0x6c, 0x61,
+	0x6d, 0x64, 0x62, 0x6c, 0x06, 0x66, 0x65, 0x6d, 0x61, 0x6c, 0x65, 0x09, 0x66, 0x69, 0x6c, 0x6c,
+	0x65, 0x64, 0x62, 0x6f, 0x78, 0x0a, 0x66, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x72, 0x65, 0x63, 0x74,
+	0x0b, 0x66, 0x69, 0x76, 0x65, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x73, 0x05, 0x67, 0x61, 0x6d,
+	0x6d, 0x61, 0x0b, 0x67, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x67,
+	0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x06, 0x67, 0x6f, 0x70, 0x68, 0x65, 0x72,
+	0x04, 0x68, 0x62, 0x61, 0x72, 0x0b, 0x68, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65,
+	0x78, 0x05, 0x68, 0x65, 0x61, 0x72, 0x74, 0x05, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x06, 0x69, 0x62,
+	0x72, 0x65, 0x76, 0x65, 0x02, 0x69, 0x6a, 0x07, 0x69, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x0a,
+	0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x61, 0x6c, 0x62, 0x74, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x67,
+	0x72, 0x61, 0x6c, 0x74, 0x70, 0x0c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x73, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x09, 0x69, 0x6e, 0x76, 0x62, 0x75, 0x6c, 0x6c, 0x65, 0x74, 0x09, 0x69, 0x6e, 0x76,
+	0x63, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x0c, 0x69, 0x6e, 0x76, 0x73, 0x6d, 0x69, 0x6c, 0x65, 0x66,
+	0x61, 0x63, 0x65, 0x07, 0x69, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x04, 0x69, 0x6f, 0x74, 0x61,
+	0x0c, 0x69, 0x6f, 0x74, 0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x11, 0x69, 0x6f,
+	0x74, 0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09,
+	0x69, 0x6f, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x06, 0x69, 0x74, 0x69, 0x6c, 0x64, 0x65,
+	0x0b, 0x6a, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x6b, 0x61, 0x70,
+	0x70, 0x61, 0x0c, 0x6b, 0x67, 0x72, 0x65, 0x65, 0x6e, 0x6c, 0x61, 0x6e, 0x64, 0x69, 0x63, 0x06,
+	0x6c, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x6c, 0x61, 0x6d, 0x62, 0x64, 0x61, 0x06, 0x6c, 0x63,
+	0x61, 0x72, 0x6f, 0x6e, 0x04, 0x6c, 0x64, 0x6f, 0x74, 0x07, 0x6c, 0x66, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x04, 0x6c, 0x69, 0x72, 0x61, 0x05, 0x6c, 0x6f, 0x6e, 0x67, 0x73, 0x07, 0x6c, 0x74, 0x73,
+	0x68, 0x61, 0x64, 0x65, 0x04, 0x6d, 0x61, 0x6c, 0x65, 0x06, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65,
+	0x0b, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74, 0x65, 0x0e, 0x6d, 0x75, 0x73,
+	0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74, 0x65, 0x64, 0x62, 0x6c, 0x06, 0x6e, 0x61, 0x63, 0x75,
+	0x74, 0x65, 0x0b, 0x6e, 0x61, 0x70, 0x6f, 0x73, 0x74, 0x72, 0x6f, 0x70, 0x68, 0x65, 0x06, 0x6e,
+	0x63, 0x61, 0x72, 0x6f, 0x6e, 0x02, 0x6e, 0x75, 0x06, 0x6f, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d,
+	0x6f, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x6f, 0x6d,
+	0x61, 0x63, 0x72, 0x6f, 0x6e, 0x05, 0x6f, 0x6d, 0x65, 0x67, 0x61, 0x0a, 0x6f, 0x6d, 0x65, 0x67,
+	0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x6f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x0c, 0x6f,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09, 0x6f, 0x6e, 0x65, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x68, 0x0a, 0x6f, 0x70, 0x65, 0x6e, 0x62, 0x75, 0x6c, 0x6c, 0x65, 0x74,
+	0x0a, 0x6f, 0x72, 0x74, 0x68, 0x6f, 0x67, 0x6f, 0x6e, 0x61, 0x6c, 0x0b, 0x6f, 0x73, 0x6c, 0x61,
+	0x73, 0x68, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x70, 0x65, 0x73, 0x65, 0x74, 0x61, 0x03, 0x70,
+	0x68, 0x69, 0x03, 0x70, 0x73, 0x69, 0x0d, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x72, 0x65, 0x76, 0x65,
+	0x72, 0x73, 0x65, 0x64, 0x06, 0x72, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x72, 0x63, 0x61, 0x72,
+	0x6f, 0x6e, 0x0d, 0x72, 0x65, 0x76, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74,
+	0x03, 0x72, 0x68, 0x6f, 0x07, 0x72, 0x74, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x06, 0x73, 0x61, 0x63,
+	0x75, 0x74, 0x65, 0x0b, 0x73, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06,
+	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x0c, 0x73, 0x65, 0x76, 0x65, 0x6e, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x68, 0x73, 0x05, 0x73, 0x68, 0x61, 0x64, 0x65, 0x05, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x06,
+	0x73, 0x69, 0x67, 0x6d, 0x61, 0x31, 0x09, 0x73, 0x6d, 0x69, 0x6c, 0x65, 0x66, 0x61, 0x63, 0x65,
+	0x05, 0x73, 0x70, 0x61, 0x64, 0x65, 0x03, 0x73, 0x75, 0x6e, 0x03, 0x74, 0x61, 0x75, 0x04, 0x74,
+	0x62, 0x61, 0x72, 0x06, 0x74, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x74, 0x68, 0x65, 0x74, 0x61,
+	0x0c, 0x74, 0x68, 0x72, 0x65, 0x65, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x73, 0x05, 0x74, 0x6f,
+	0x6e, 0x6f, 0x73, 0x07, 0x74, 0x72, 0x69, 0x61, 0x67, 0x64, 0x6e, 0x07, 0x74, 0x72, 0x69, 0x61,
+	0x67, 0x6c, 0x66, 0x07, 0x74, 0x72, 0x69, 0x61, 0x67, 0x72, 0x74, 0x07, 0x74, 0x72, 0x69, 0x61,
+	0x67, 0x75, 0x70, 0x06, 0x75, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x75, 0x68, 0x75, 0x6e, 0x67,
+	0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x75, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e,
+	0x0d, 0x75, 0x6e, 0x64, 0x65, 0x72, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x64, 0x62, 0x6c, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x30, 0x30, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x30, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x30, 0x41, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x41, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x30, 0x42, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x42, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x30, 0x42, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x32, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x32, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x33, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x33, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x33, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x33, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x34, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x34, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x35, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x35, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x36, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x36, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x43, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x43, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x43, 0x46, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x31, 0x44, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x31, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x32, 0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x31, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x32, 0x31, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x43, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x33, 0x37, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33, 0x39, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x33, 0x41, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33, 0x42, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x30, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x46, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x31, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x46, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x32, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x46, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x33, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x46, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x34, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x46, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x33, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x37, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x42, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x44, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x35, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x46, 0x07, 0x75,
+	0x6e, 0x69, 0x30, 0x34, 0x39, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x39, 0x31, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x33, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x37, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x30, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x34, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x38, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x41, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x43, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x38, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x45, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x30, 0x39, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31, 0x30, 0x35, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x31, 0x31, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31, 0x31, 0x36, 0x07, 0x75,
+	0x6e, 0x69, 0x32, 0x32, 0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x32, 0x31, 0x39, 0x07, 0x75,
+	0x6e, 0x69, 0x46, 0x42, 0x30, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x42, 0x30, 0x32, 0x07, 0x75,
+	0x6e, 0x69, 0x46, 0x46, 0x46, 0x44, 0x05, 0x75, 0x6e, 0x69, 0x6f, 0x6e, 0x07, 0x75, 0x6f, 0x67,
+	0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x75, 0x70, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x07, 0x75, 0x70, 0x73,
+	0x69, 0x6c, 0x6f, 0x6e, 0x0f, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72,
+	0x65, 0x73, 0x69, 0x73, 0x14, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72,
+	0x65, 0x73, 0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0c, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f,
+	0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x05, 0x75, 0x72, 0x69, 0x6e, 0x67, 0x06, 0x75, 0x74, 0x69,
+	0x6c, 0x64, 0x65, 0x06, 0x77, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x77, 0x63, 0x69, 0x72, 0x63,
+	0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x09, 0x77, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73,
+	0x06, 0x77, 0x67, 0x72, 0x61, 0x76, 0x65, 0x02, 0x78, 0x69, 0x0b, 0x79, 0x63, 0x69, 0x72, 0x63,
+	0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06, 0x79, 0x67, 0x72, 0x61, 0x76, 0x65, 0x06, 0x7a, 0x61,
+	0x63, 0x75, 0x74, 0x65, 0x0a, 0x7a, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x08,
+	0x7a, 0x65, 0x72, 0x6f, 0x2e, 0x64, 0x6f, 0x74, 0x0a, 0x7a, 0x65, 0x72, 0x6f, 0x2e, 0x65, 0x6d,
+	0x70, 0x74, 0x79, 0x04, 0x7a, 0x65, 0x74, 0x61, 0x00, 0x01, 0x00, 0x01, 0xff, 0xff, 0x00, 0x0f,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x3a, 0x01, 0x3a,
+	0x00, 0xb9, 0x00, 0xb9, 0x05, 0xc8, 0x00, 0x00, 0x04, 0x4a, 0x00, 0x00, 0xfe, 0x75, 0x05, 0xed,
+	0xff, 0xdb, 0x04, 0x63, 0xff, 0xe7, 0xfe, 0x75, 0x01, 0x3a, 0x01, 0x3a, 0x00, 0xb9, 0x00, 0xb9,
+	0x05, 0xc8, 0x00, 0x00, 0x06, 0x4a, 0x04, 0x4a, 0x00, 0x00, 0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb,
+	0x06, 0x4a, 0x04, 0x63, 0xff, 0xe7, 0xfe, 0x75, 0x01, 0x3a, 0x01, 0x3a, 0x00, 0xb9, 0x00, 0xb9,
+	0x05, 0xc8, 0x00, 0x00, 0x06, 0x2b, 0x04, 0x4a, 0x00, 0x00, 0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb,
+	0x06, 0x2b, 0x04, 0x63, 0xff, 0xe7, 0xfe, 0x5d, 0x00, 0xd2, 0x00, 0xd2, 0x00, 0x6e, 0x00, 0x6e,
+	0x02, 0x44, 0xfe, 0xcc, 0x01, 0x6d, 0xfe, 0xcc, 0x02, 0x5a, 0xfe, 0xb6, 0x01, 0x6d, 0xfe, 0xcc,
+	0x00, 0xd2, 0x00, 0xd2, 0x00, 0x6e, 0x00, 0x6e, 0x06, 0x2d, 0x02, 0xb5, 0x06, 0x43, 0x02, 0x9f,
+	0xb0, 0x00, 0x2c, 0x20, 0xb0, 0x00, 0x55, 0x58, 0x45, 0x59, 0x20, 0x20, 0x4b, 0xb8, 0x00, 0x0e,
+	0x51, 0x4b, 0xb0, 0x06, 0x53, 0x5a, 0x58, 0xb0, 0x34, 0x1b, 0xb0, 0x28, 0x59, 0x60, 0x66, 0x20,
+	0x8a, 0x55, 0x58, 0xb0, 0x02, 0x25, 0x61, 0xb9, 0x08, 0x00, 0x08, 0x00, 0x63, 0x63, 0x23, 0x62,
+	0x1b, 0x21, 0x21, 0xb0, 0x00, 0x59, 0xb0, 0x00, 0x43, 0x23, 0x44, 0xb2, 0x00, 0x01, 0x00, 0x43,
+	0x60, 0x42, 0x2d, 0xb0, 0x01, 0x2c, 0xb0, 0x20, 0x60, 0x66, 0x2d, 0xb0, 0x02, 0x2c, 0x23, 0x21,
+	0x23, 0x21, 0x2d, 0xb0, 0x03, 0x2c, 0x20, 0x64, 0xb3, 0x03, 0x14, 0x15, 0x00, 0x42, 0x43, 0xb0,
+	0x13, 0x43, 0x20, 0x60, 0x60, 0x42, 0xb1, 0x02, 0x14, 0x43, 0x42, 0xb1, 0x25, 0x03, 0x43, 0xb0,
+	0x02, 0x43, 0x54, 0x78, 0x20, 0xb0, 0x0c, 0x23, 0xb0, 0x02, 0x43, 0x43, 0x61, 0x64, 0xb0, 0x04,
+	0x50, 0x78, 0xb2, 0x02, 0x02, 0x02, 0x43, 0x60, 0x42, 0xb0, 0x21, 0x65, 0x1c, 0x21, 0xb0, 0x02,
+	0x43, 0x43, 0xb2, 0x0e, 0x15, 0x01, 0x42, 0x1c, 0x20, 0xb0, 0x02, 0x43, 0x23, 0x42, 0xb2, 0x13,
+	0x01, 0x13, 0x43, 0x60, 0x42, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0xb2, 0x16, 0x01, 0x02,
+	0x43, 0x60, 0x42, 0x2d, 0xb0, 0x04, 0x2c, 0xb0, 0x03, 0x2b, 0xb0, 0x15, 0x43, 0x58, 0x23, 0x21,
+	0x23, 0x21, 0xb0, 0x16, 0x43, 0x43, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0x1b, 0x20, 0x64,
+	0x20, 0xb0, 0xc0, 0x50, 0xb0, 0x04, 0x26, 0x5a, 0xb2, 0x28, 0x01, 0x0d, 0x43, 0x45, 0x63, 0x45,
+	0xb0, 0x06, 0x45, 0x58, 0x21, 0xb0, 0x03, 0x25, 0x59, 0x52, 0x5b, 0x58, 0x21, 0x23, 0x21, 0x1b,
+	0x8a, 0x58, 0x20, 0xb0, 0x50, 0x50, 0x58, 0x21, 0xb0, 0x40, 0x59, 0x1b, 0x20, 0xb0, 0x38, 0x50,
+	0x58, 0x21, 0xb0, 0x38, 0x59, 0x59, 0x20, 0xb1, 0x01, 0x0d, 0x43, 0x45, 0x63, 0x45, 0x61, 0x64,
+	0xb0, 0x28, 0x50, 0x58, 0x21, 0xb1, 0x01, 0x0d, 0x43, 0x45, 0x63, 0x45, 0x20, 0xb0, 0x30, 0x50,
+	0x58, 0x21, 0xb0, 0x30, 0x59, 0x1b, 0x20, 0xb0, 0xc0, 0x50, 0x58, 0x20, 0x66, 0x20, 0x8a, 0x8a,
+	0x61, 0x20, 0xb0, 0x0a, 0x50, 0x58, 0x60, 0x1b, 0x20, 0xb0, 0x20, 0x50, 0x58, 0x21, 0xb0, 0x0a,
+	0x60, 0x1b, 0x20, 0xb0, 0x36, 0x50, 0x58, 0x21, 0xb0, 0x36, 0x60, 0x1b, 0x60, 0x59, 0x59, 0x59,
+	0x1b, 0xb0, 0x02, 0x25, 0xb0, 0x0c, 0x43, 0x63, 0xb0, 0x00, 0x52, 0x58, 0xb0, 0x00, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x21, 0xb0, 0x0c, 0x43, 0x1b, 0x4b, 0xb0, 0x1e, 0x50, 0x58, 0x21, 0xb0, 0x1e,
+	0x4b, 0x61, 0xb8, 0x10, 0x00, 0x63, 0xb0, 0x0c, 0x43, 0x63, 0xb8, 0x05, 0x00, 0x62, 0x59, 0x59,
+	0x64, 0x61, 0x59, 0xb0, 0x01, 0x2b, 0x59, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0x59,
+	0x20, 0x64, 0xb0, 0x16, 0x43, 0x23, 0x42, 0x59, 0x2d, 0xb0, 0x05, 0x2c, 0x20, 0x45, 0x20, 0xb0,
+	0x04, 0x25, 0x61, 0x64, 0x20, 0xb0, 0x07, 0x43, 0x50, 0x58, 0xb0, 0x07, 0x23, 0x42, 0xb0, 0x08,
+	0x23, 0x42, 0x1b, 0x21, 0x21, 0x59, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x06, 0x2c, 0x23, 0x21, 0x23,
+	0x21, 0xb0, 0x03, 0x2b, 0x20, 0x64, 0xb1, 0x07, 0x62, 0x42, 0x20, 0xb0, 0x08, 0x23, 0x42, 0xb0,
+	0x06, 0x45, 0x58, 0x1b, 0xb1, 0x01, 0x0d, 0x43, 0x45, 0x63, 0xb1, 0x01, 0x0d, 0x43, 0xb0, 0x05,
+	0x60, 0x45, 0x63, 0xb0, 0x05, 0x2a, 0x21, 0x20, 0xb0, 0x08, 0x43, 0x20, 0x8a, 0x20, 0x8a, 0xb0,
+	0x01, 0x2b, 0xb1, 0x30, 0x05, 0x25, 0xb0, 0x04, 0x26, 0x51, 0x58, 0x60, 0x50, 0x1b, 0x61, 0x52,
+	0x59, 0x58, 0x23, 0x59, 0x21, 0x59, 0x20, 0xb0, 0x40, 0x53, 0x58, 0xb0, 0x01, 0x2b, 0x1b, 0x21,
+	0xb0, 0x40, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0x2d, 0xb0, 0x07, 0x2c, 0xb0, 0x09,
+	0x43, 0x2b, 0xb2, 0x00, 0x02, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x08, 0x2c, 0xb0, 0x09, 0x23,
+	0x42, 0x23, 0x20, 0xb0, 0x00, 0x23, 0x42, 0x61, 0xb0, 0x02, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0,
+	0x01, 0x60, 0xb0, 0x07, 0x2a, 0x2d, 0xb0, 0x09, 0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0, 0x0e, 0x43,
+	0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0,
+	0x01, 0x63, 0x60, 0x44, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x0a, 0x2c, 0xb2, 0x09, 0x0e, 0x00, 0x43,
+	0x45, 0x42, 0x2a, 0x21, 0xb2, 0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x0b, 0x2c, 0xb0,
+	0x00, 0x43, 0x23, 0x44, 0xb2, 0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x0c, 0x2c, 0x20,
+	0x20, 0x45, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0, 0x00, 0x43, 0xb0, 0x04, 0x25, 0x60, 0x20, 0x45,
+	0x8a, 0x23, 0x61, 0x20, 0x64, 0x20, 0xb0, 0x20, 0x50, 0x58, 0x21, 0xb0, 0x00, 0x1b, 0xb0, 0x30,
+	0x50, 0x58, 0xb0, 0x20, 0x1b, 0xb0, 0x40, 0x59, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59,
+	0xb0, 0x03, 0x25, 0x23, 0x61, 0x44, 0x44, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x0d, 0x2c, 0x20, 0x20,
+	0x45, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0, 0x00, 0x43, 0xb0, 0x04, 0x25, 0x60, 0x20, 0x45, 0x8a,
+	0x23, 0x61, 0x20, 0x64, 0xb0, 0x24, 0x50, 0x58, 0xb0, 0x00, 0x1b, 0xb0, 0x40, 0x59, 0x23, 0xb0,
+	0x00, 0x50, 0x58, 0x65, 0x59, 0xb0, 0x03, 0x25, 0x23, 0x61, 0x44, 0x44, 0xb0, 0x01, 0x60, 0x2d,
+	0xb0, 0x0e, 0x2c, 0x20, 0xb0, 0x00, 0x23, 0x42, 0xb3, 0x0d, 0x0c, 0x00, 0x03, 0x45, 0x50, 0x58,
+	0x21, 0x1b, 0x23, 0x21, 0x59, 0x2a, 0x21, 0x2d, 0xb0, 0x0f, 0x2c, 0xb1, 0x02, 0x02, 0x45, 0xb0,
+	0x64, 0x61, 0x44, 0x2d, 0xb0, 0x10, 0x2c, 0xb0, 0x01, 0x60, 0x20, 0x20, 0xb0, 0x0f, 0x43, 0x4a,
+	0xb0, 0x00, 0x50, 0x58, 0x20, 0xb0, 0x0f, 0x23, 0x42, 0x59, 0xb0, 0x10, 0x43, 0x4a, 0xb0, 0x00,
+	0x52, 0x58, 0x20, 0xb0, 0x10, 0x23, 0x42, 0x59, 0x2d, 0xb0, 0x11, 0x2c, 0x20, 0xb0, 0x10, 0x62,
+	0x66, 0xb0, 0x01, 0x63, 0x20, 0xb8, 0x04, 0x00, 0x63, 0x8a, 0x23, 0x61, 0xb0, 0x11, 0x43, 0x60,
+	0x20, 0x8a, 0x60, 0x20, 0xb0, 0x11, 0x23, 0x42, 0x23, 0x2d, 0xb0, 0x12, 0x2c, 0x4b, 0x54, 0x58,
+	0xb1, 0x04, 0x64, 0x44, 0x59, 0x24, 0xb0, 0x0d, 0x65, 0x23, 0x78, 0x2d, 0xb0, 0x13, 0x2c, 0x4b,
+	0x51, 0x58, 0x4b, 0x53, 0x58, 0xb1, 0x04, 0x64, 0x44, 0x59, 0x1b, 0x21, 0x59, 0x24, 0xb0, 0x13,
+	0x65, 0x23, 0x78, 0x2d, 0xb0, 0x14, 0x2c, 0xb1, 0x00, 0x12, 0x43, 0x55, 0x58, 0xb1, 0x12, 0x12,
+	0x43, 0xb0, 0x01, 0x61, 0x42, 0xb0, 0x11, 0x2b, 0x59, 0xb0, 0x00, 0x43, 0xb0, 0x02, 0x25, 0x42,
+	0xb1, 0x0f, 0x02, 0x25, 0x42, 0xb1, 0x10, 0x02, 0x25, 0x42, 0xb0, 0x01, 0x16, 0x23, 0x20, 0xb0,
+	0x03, 0x25, 0x50, 0x58, 0xb1, 0x01, 0x00, 0x43, 0x60, 0xb0, 0x04, 0x25, 0x42, 0x8a, 0x8a, 0x20,
+	0x8a, 0x23, 0x61, 0xb0, 0x10, 0x2a, 0x21, 0x23, 0xb0, 0x01, 0x61, 0x20, 0x8a, 0x23, 0x61, 0xb0,
+	0x10, 0x2a, 0x21, 0x1b, 0xb1, 0x01, 0x00, 0x43, 0x60, 0xb0, 0x02, 0x25, 0x42, 0xb0, 0x02, 0x25,
+	0x61, 0xb0, 0x10, 0x2a, 0x21, 0x59, 0xb0, 0x0f, 0x43, 0x47, 0xb0, 0x10, 0x43, 0x47, 0x60, 0xb0,
+	0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x20,
+	0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60,
+	0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0xb1, 0x00, 0x00, 0x13, 0x23, 0x44, 0xb0, 0x01, 0x43, 0xb0,
+	0x00, 0x3e, 0xb2, 0x01, 0x01, 0x01, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x15, 0x2c, 0x00, 0xb1, 0x00,
+	0x02, 0x45, 0x54, 0x58, 0xb0, 0x12, 0x23, 0x42, 0x20, 0x45, 0xb0, 0x0e, 0x23, 0x42, 0xb0, 0x0d,
+	0x23, 0xb0, 0x05, 0x60, 0x42, 0x20, 0x60, 0xb7, 0x18, 0x18, 0x01, 0x00, 0x11, 0x00, 0x13, 0x00,
+	0x42, 0x42, 0x42, 0x8a, 0x60, 0x20, 0xb0, 0x14, 0x23, 0x42, 0xb0, 0x01, 0x61, 0xb1, 0x14, 0x08,
+	0x2b, 0xb0, 0x8b, 0x2b, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x16, 0x2c, 0xb1, 0x00, 0x15, 0x2b, 0x2d,
+	0xb0, 0x17, 0x2c, 0xb1, 0x01, 0x15, 0x2b, 0x2d, 0xb0, 0x18, 0x2c, 0xb1, 0x02, 0x15, 0x2b, 0x2d,
+	0xb0, 0x19, 0x2c, 0xb1, 0x03, 0x15, 0x2b, 0x2d, 0xb0, 0x1a, 0x2c, 0xb1, 0x04, 0x15, 0x2b, 0x2d,
+	0xb0, 0x1b, 0x2c, 0xb1, 0x05, 0x15, 0x2b, 0x2d, 0xb0, 0x1c, 0x2c, 0xb1, 0x06, 0x15, 0x2b, 0x2d,
+	0xb0, 0x1d, 0x2c, 0xb1, 0x07, 0x15, 0x2b, 0x2d, 0xb0, 0x1e, 0x2c, 0xb1, 0x08, 0x15, 0x2b, 0x2d,
+	0xb0, 0x1f, 0x2c, 0xb1, 0x09, 0x15, 0x2b, 0x2d, 0xb0, 0x2b, 0x2c, 0x23, 0x20, 0xb0, 0x10, 0x62,
+	0x66, 0xb0, 0x01, 0x63, 0xb0, 0x06, 0x60, 0x4b, 0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0, 0x01, 0x5d,
+	0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0, 0x2c, 0x2c, 0x23, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01,
+	0x63, 0xb0, 0x16, 0x60, 0x4b, 0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0, 0x01, 0x71, 0x1b, 0x21, 0x21,
+	0x59, 0x2d, 0xb0, 0x2d, 0x2c, 0x23, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x26,
+	0x60, 0x4b, 0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0, 0x01, 0x72, 0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0,
+	0x20, 0x2c, 0x00, 0xb0, 0x0f, 0x2b, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb0, 0x12, 0x23, 0x42,
+	0x20, 0x45, 0xb0, 0x0e, 0x23, 0x42, 0xb0, 0x0d, 0x23, 0xb0, 0x05, 0x60, 0x42, 0x20, 0x60, 0xb0,
+	0x01, 0x61, 0xb5, 0x18, 0x18, 0x01, 0x00, 0x11, 0x00, 0x42, 0x42, 0x8a, 0x60, 0xb1, 0x14, 0x08,
+	0x2b, 0xb0, 0x8b, 0x2b, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x21, 0x2c, 0xb1, 0x00, 0x20, 0x2b, 0x2d,
+	0xb0, 0x22, 0x2c, 0xb1, 0x01, 0x20, 0x2b, 0x2d, 0xb0, 0x23, 0x2c, 0xb1, 0x02, 0x20, 0x2b, 0x2d,
+	0xb0, 0x24, 0x2c, 0xb1, 0x03, 0x20, 0x2b, 0x2d, 0xb0, 0x25, 0x2c, 0xb1, 0x04, 0x20, 0x2b, 0x2d,
+	0xb0, 0x26, 0x2c, 0xb1, 0x05, 0x20, 0x2b, 0x2d, 0xb0, 0x27, 0x2c, 0xb1, 0x06, 0x20, 0x2b, 0x2d,
+	0xb0, 0x28, 0x2c, 0xb1, 0x07, 0x20, 0x2b, 0x2d, 0xb0, 0x29, 0x2c, 0xb1, 0x08, 0x20, 0x2b, 0x2d,
+	0xb0, 0x2a, 0x2c, 0xb1, 0x09, 0x20, 0x2b, 0x2d, 0xb0, 0x2e, 0x2c, 0x20, 0x3c, 0xb0, 0x01, 0x60,
+	0x2d, 0xb0, 0x2f, 0x2c, 0x20, 0x60, 0xb0, 0x18, 0x60, 0x20, 0x43, 0x23, 0xb0, 0x01, 0x60, 0x43,
+	0xb0, 0x02, 0x25, 0x61, 0xb0, 0x01, 0x60, 0xb0, 0x2e, 0x2a, 0x21, 0x2d, 0xb0, 0x30, 0x2c, 0xb0,
+	0x2f, 0x2b, 0xb0, 0x2f, 0x2a, 0x2d, 0xb0, 0x31, 0x2c, 0x20, 0x20, 0x47, 0x20, 0x20, 0xb0, 0x0e,
+	0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66,
+	0xb0, 0x01, 0x63, 0x60, 0x23, 0x61, 0x38, 0x23, 0x20, 0x8a, 0x55, 0x58, 0x20, 0x47, 0x20, 0x20,
+	0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60,
+	0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x23, 0x61, 0x38, 0x1b, 0x21, 0x59, 0x2d, 0xb0, 0x32, 0x2c,
+	0x00, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb1, 0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x16, 0xb0,
+	0x31, 0x2a, 0xb1, 0x05, 0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x33,
+	0x2c, 0x00, 0xb0, 0x0f, 0x2b, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb1, 0x0e, 0x06, 0x45, 0x42,
+	0xb0, 0x01, 0x16, 0xb0, 0x31, 0x2a, 0xb1, 0x05, 0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x1b, 0x22,
+	0x59, 0x2d, 0xb0, 0x34, 0x2c, 0x20, 0x35, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x35, 0x2c, 0x00, 0xb1,
+	0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x45, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50,
+	0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x01, 0x2b, 0xb0, 0x0e, 0x43, 0x63,
+	0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01,
+	0x63, 0xb0, 0x01, 0x2b, 0xb0, 0x00, 0x16, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x00, 0x44, 0x3e, 0x23,
+	0x38, 0xb1, 0x34, 0x01, 0x15, 0x2a, 0x21, 0x2d, 0xb0, 0x36, 0x2c, 0x20, 0x3c, 0x20, 0x47, 0x20,
+	0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60,
+	0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0xb0, 0x00, 0x43, 0x61, 0x38, 0x2d, 0xb0, 0x37, 0x2c, 0x2e,
+	0x17, 0x3c, 0x2d, 0xb0, 0x38, 0x2c, 0x20, 0x3c, 0x20, 0x47, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8,
+	0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63,
+	0x60, 0xb0, 0x00, 0x43, 0x61, 0xb0, 0x01, 0x43, 0x63, 0x38, 0x2d, 0xb0, 0x39, 0x2c, 0xb1, 0x02,
+	0x00, 0x16, 0x25, 0x20, 0x2e, 0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb0, 0x02, 0x25, 0x49, 0x8a,
+	0x8a, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0x58, 0x62, 0x1b, 0x21, 0x59, 0xb0, 0x01, 0x23, 0x42,
+	0xb2, 0x38, 0x01, 0x01, 0x15, 0x14, 0x2a, 0x2d, 0xb0, 0x3a, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17,
+	0x23, 0x42, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb1, 0x0c, 0x00,
+	0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x65, 0x8a, 0x2e, 0x23, 0x20, 0x20, 0x3c, 0x8a, 0x38, 0x2d, 0xb0,
+	0x3b, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25, 0x20,
+	0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0xb0, 0x06, 0x23, 0x42, 0xb1, 0x0c, 0x00, 0x42, 0xb0,
+	0x0b, 0x43, 0x2b, 0x20, 0xb0, 0x60, 0x50, 0x58, 0x20, 0xb0, 0x40, 0x51, 0x58, 0xb3, 0x04, 0x20,
+	0x05, 0x20, 0x1b, 0xb3, 0x04, 0x26, 0x05, 0x1a, 0x59, 0x42, 0x42, 0x23, 0x20, 0xb0, 0x0a, 0x43,
+	0x20, 0x8a, 0x23, 0x47, 0x23, 0x47, 0x23, 0x61, 0x23, 0x46, 0x60, 0xb0, 0x06, 0x43, 0xb0, 0x02,
+	0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x20,
+	0xb0, 0x01, 0x2b, 0x20, 0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x04, 0x43, 0x60, 0x64, 0x23, 0xb0, 0x05,
+	0x43, 0x61, 0x64, 0x50, 0x58, 0xb0, 0x04, 0x43, 0x61, 0x1b, 0xb0, 0x05, 0x43, 0x60, 0x59, 0xb0,
+	0x03, 0x25, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0,
+	0x01, 0x63, 0x61, 0x23, 0x20, 0x20, 0xb0, 0x04, 0x26, 0x23, 0x46, 0x61, 0x38, 0x1b, 0x23, 0xb0,
+	0x0a, 0x43, 0x46, 0xb0, 0x02, 0x25, 0xb0, 0x0a, 0x43, 0x47, 0x23, 0x47, 0x23, 0x61, 0x60, 0x20,
+	0xb0, 0x06, 0x43, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66,
+	0xb0, 0x01, 0x63, 0x60, 0x23, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0, 0x06, 0x43, 0x60, 0xb0, 0x01,
+	0x2b, 0xb0, 0x05, 0x25, 0x61, 0xb0, 0x05, 0x25, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58,
+	0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x04, 0x26, 0x61, 0x20, 0xb0, 0x04, 0x25,
+	0x60, 0x64, 0x23, 0xb0, 0x03, 0x25, 0x60, 0x64, 0x50, 0x58, 0x21, 0x1b, 0x23, 0x21, 0x59, 0x23,
+	0x20, 0x20, 0xb0, 0x04, 0x26, 0x23, 0x46, 0x61, 0x38, 0x59, 0x2d, 0xb0, 0x3c, 0x2c, 0xb0, 0x00,
+	0x16, 0xb0, 0x17, 0x23, 0x42, 0x20, 0x20, 0x20, 0xb0, 0x05, 0x26, 0x20, 0x2e, 0x47, 0x23, 0x47,
+	0x23, 0x61, 0x23, 0x3c, 0x38, 0x2d, 0xb0, 0x3d, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42,
+	0x20, 0xb0, 0x0a, 0x23, 0x42, 0x20, 0x20, 0x20, 0x46, 0x23, 0x47, 0xb0, 0x01, 0x2b, 0x23, 0x61,
+	0x38, 0x2d, 0xb0, 0x3e, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0xb0, 0x03, 0x25, 0xb0,
+	0x02, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb0, 0x00, 0x54, 0x58, 0x2e, 0x20, 0x3c, 0x23, 0x21,
+	0x1b, 0xb0, 0x02, 0x25, 0xb0, 0x02, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0xb0, 0x05, 0x25,
+	0xb0, 0x04, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb0, 0x06, 0x25, 0xb0, 0x05, 0x25, 0x49, 0xb0,
+	0x02, 0x25, 0x61, 0xb9, 0x08, 0x00, 0x08, 0x00, 0x63, 0x63, 0x23, 0x20, 0x58, 0x62, 0x1b, 0x21,
+	0x59, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66,
+	0xb0, 0x01, 0x63, 0x60, 0x23, 0x2e, 0x23, 0x20, 0x20, 0x3c, 0x8a, 0x38, 0x23, 0x21, 0x59, 0x2d,
+	0xb0, 0x3f, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0x20, 0xb0, 0x0a, 0x43, 0x20, 0x2e,
+	0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0x60, 0xb0, 0x20, 0x60, 0x66, 0xb0, 0x02, 0x62, 0x20, 0xb0,
+	0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x23, 0x20, 0x20, 0x3c, 0x8a,
+	0x38, 0x2d, 0xb0, 0x40, 0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43,
+	0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d,
+	0xb0, 0x41, 0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x52,
+	0x1b, 0x50, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x42,
+	0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52,
+	0x59, 0x58, 0x20, 0x3c, 0x59, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43,
+	0x58, 0x52, 0x1b, 0x50, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d,
+	0xb0, 0x43, 0x2c, 0xb0, 0x3a, 0x2b, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17,
+	0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b,
+	0x2d, 0xb0, 0x44, 0x2c, 0xb0, 0x3b, 0x2b, 0x8a, 0x20, 0x20, 0x3c, 0xb0, 0x06, 0x23, 0x42, 0x8a,
+	0x38, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52,
+	0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0xb0, 0x06, 0x43, 0x2e, 0xb0,
+	0x30, 0x2b, 0x2d, 0xb0, 0x45, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x26, 0x20,
+	0x20, 0x20, 0x46, 0x23, 0x47, 0x61, 0xb0, 0x0c, 0x23, 0x42, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61,
+	0xb0, 0x0b, 0x43, 0x2b, 0x23, 0x20, 0x3c, 0x20, 0x2e, 0x23, 0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b,
+	0x2d, 0xb0, 0x46, 0x2c, 0xb1, 0x0a, 0x04, 0x25, 0x42, 0xb0, 0x00, 0x16, 0xb0, 0x04, 0x25, 0xb0,
+	0x04, 0x25, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0xb0, 0x06, 0x23, 0x42, 0xb1, 0x0c,
+	0x00, 0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x20, 0xb0, 0x60, 0x50, 0x58, 0x20, 0xb0, 0x40, 0x51, 0x58,
+	0xb3, 0x04, 0x20, 0x05, 0x20, 0x1b, 0xb3, 0x04, 0x26, 0x05, 0x1a, 0x59, 0x42, 0x42, 0x23, 0x20,
+	0x47, 0xb0, 0x06, 0x43, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59,
+	0x66, 0xb0, 0x01, 0x63, 0x60, 0x20, 0xb0, 0x01, 0x2b, 0x20, 0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x04,
+	0x43, 0x60, 0x64, 0x23, 0xb0, 0x05, 0x43, 0x61, 0x64, 0x50, 0x58, 0xb0, 0x04, 0x43, 0x61, 0x1b,
+	0xb0, 0x05, 0x43, 0x60, 0x59, 0xb0, 0x03, 0x25, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58,
+	0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x61, 0xb0, 0x02, 0x25, 0x46, 0x61, 0x38, 0x23,
+	0x20, 0x3c, 0x23, 0x38, 0x1b, 0x21, 0x20, 0x20, 0x46, 0x23, 0x47, 0xb0, 0x01, 0x2b, 0x23, 0x61,
+	0x38, 0x21, 0x59, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x47, 0x2c, 0xb1, 0x00, 0x3a, 0x2b,
+	0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x48, 0x2c, 0xb1, 0x00, 0x3b, 0x2b, 0x21, 0x23,
+	0x20, 0x20, 0x3c, 0xb0, 0x06, 0x23, 0x42, 0x23, 0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0xb0, 0x06,
+	0x43, 0x2e, 0xb0, 0x30, 0x2b, 0x2d, 0xb0, 0x49, 0x2c, 0xb0, 0x00, 0x15, 0x20, 0x47, 0xb0, 0x00,
+	0x23, 0x42, 0xb2, 0x00, 0x01, 0x01, 0x15, 0x14, 0x13, 0x2e, 0xb0, 0x36, 0x2a, 0x2d, 0xb0, 0x4a,
+	0x2c, 0xb0, 0x00, 0x15, 0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb2, 0x00, 0x01, 0x01, 0x15, 0x14,
+	0x13, 0x2e, 0xb0, 0x36, 0x2a, 0x2d, 0xb0, 0x4b, 0x2c, 0xb1, 0x00, 0x01, 0x14, 0x13, 0xb0, 0x37,
+	0x2a, 0x2d, 0xb0, 0x4c, 0x2c, 0xb0, 0x39, 0x2a, 0x2d, 0xb0, 0x4d, 0x2c, 0xb0, 0x00, 0x16, 0x45,
+	0x23, 0x20, 0x2e, 0x20, 0x46, 0x8a, 0x23, 0x61, 0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0,
+	0x4e, 0x2c, 0xb0, 0x0a, 0x23, 0x42, 0xb0, 0x4d, 0x2b, 0x2d, 0xb0, 0x4f, 0x2c, 0xb2, 0x00, 0x00,
+	0x46, 0x2b, 0x2d, 0xb0, 0x50, 0x2c, 0xb2, 0x00, 0x01, 0x46, 0x2b, 0x2d, 0xb0, 0x51, 0x2c, 0xb2,
+	0x01, 0x00, 0x46, 0x2b, 0x2d, 0xb0, 0x52, 0x2c, 0xb2, 0x01, 0x01, 0x46, 0x2b, 0x2d, 0xb0, 0x53,
+	0x2c, 0xb2, 0x00, 0x00, 0x47, 0x2b, 0x2d, 0xb0, 0x54, 0x2c, 0xb2, 0x00, 0x01, 0x47, 0x2b, 0x2d,
+	0xb0, 0x55, 0x2c, 0xb2, 0x01, 0x00, 0x47, 0x2b, 0x2d, 0xb0, 0x56, 0x2c, 0xb2, 0x01, 0x01, 0x47,
+	0x2b, 0x2d, 0xb0, 0x57, 0x2c, 0xb3, 0x00, 0x00, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x58, 0x2c, 0xb3,
+	0x00, 0x01, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x59, 0x2c, 0xb3, 0x01, 0x00, 0x00, 0x43, 0x2b, 0x2d,
+	0xb0, 0x5a, 0x2c, 0xb3, 0x01, 0x01, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x5b, 0x2c, 0xb3, 0x00, 0x00,
+	0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5c, 0x2c, 0xb3, 0x00, 0x01, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5d,
+	0x2c, 0xb3, 0x01, 0x00, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5e, 0x2c, 0xb3, 0x01, 0x01, 0x01, 0x43,
+	0x2b, 0x2d, 0xb0, 0x5f, 0x2c, 0xb2, 0x00, 0x00, 0x45, 0x2b, 0x2d, 0xb0, 0x60, 0x2c, 0xb2, 0x00,
+	0x01, 0x45, 0x2b, 0x2d, 0xb0, 0x61, 0x2c, 0xb2, 0x01, 0x00, 0x45, 0x2b, 0x2d, 0xb0, 0x62, 0x2c,
+	0xb2, 0x01, 0x01, 0x45, 0x2b, 0x2d, 0xb0, 0x63, 0x2c, 0xb2, 0x00, 0x00, 0x48, 0x2b, 0x2d, 0xb0,
+	0x64, 0x2c, 0xb2, 0x00, 0x01, 0x48, 0x2b, 0x2d, 0xb0, 0x65, 0x2c, 0xb2, 0x01, 0x00, 0x48, 0x2b,
+	0x2d, 0xb0, 0x66, 0x2c, 0xb2, 0x01, 0x01, 0x48, 0x2b, 0x2d, 0xb0, 0x67, 0x2c, 0xb3, 0x00, 0x00,
+	0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x68, 0x2c, 0xb3, 0x00, 0x01, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x69,
+	0x2c, 0xb3, 0x01, 0x00, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x6a, 0x2c, 0xb3, 0x01, 0x01, 0x00, 0x44,
+	0x2b, 0x2d, 0xb0, 0x6b, 0x2c, 0xb3, 0x00, 0x00, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6c, 0x2c, 0xb3,
+	0x00, 0x01, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6d, 0x2c, 0xb3, 0x01, 0x00, 0x01, 0x44, 0x2b, 0x2d,
+	0xb0, 0x6e, 0x2c, 0xb3, 0x01, 0x01, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6f, 0x2c, 0xb1, 0x00, 0x3c,
+	0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x70, 0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0xb0,
+	0x40, 0x2b, 0x2d, 0xb0, 0x71, 0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x72,
+	0x2c, 0xb0, 0x00, 0x16, 0xb1, 0x00, 0x3c, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x73, 0x2c, 0xb1,
+	0x01, 0x3c, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x74, 0x2c, 0xb1, 0x01, 0x3c, 0x2b, 0xb0, 0x41,
+	0x2b, 0x2d, 0xb0, 0x75, 0x2c, 0xb0, 0x00, 0x16, 0xb1, 0x01, 0x3c, 0x2b, 0xb0, 0x42, 0x2b, 0x2d,
+	0xb0, 0x76, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x77,
+	0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x78, 0x2c, 0xb1, 0x00, 0x3d, 0x2b,
+	0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x79, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0,
+	0x7a, 0x2c, 0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x7b, 0x2c, 0xb1, 0x01, 0x3d,
+	0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x7c, 0x2c, 0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x42, 0x2b, 0x2d,
+	0xb0, 0x7d, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x7e,
+	0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x7f, 0x2c, 0xb1, 0x00, 0x3e, 0x2b,
+	0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x80, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0,
+	0x81, 0x2c, 0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x82, 0x2c, 0xb1, 0x01, 0x3e,
+	0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x83, 0x2c, 0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x42, 0x2b, 0x2d,
+	0xb0, 0x84, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x85,
+	0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x86, 0x2c, 0xb1, 0x00, 0x3f, 0x2b,
+	0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x87, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0,
+	0x88, 0x2c, 0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x89, 0x2c, 0xb1, 0x01, 0x3f,
+	0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x8a, 0x2c, 0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x42, 0x2b, 0x2d,
+	0xb0, 0x8b, 0x2c, 0xb2, 0x0b, 0x00, 0x03, 0x45, 0x50, 0x58, 0xb0, 0x06, 0x1b, 0xb2, 0x04, 0x02,
+	0x03, 0x45, 0x58, 0x23, 0x21, 0x1b, 0x21, 0x59, 0x59, 0x42, 0x2b, 0xb0, 0x08, 0x65, 0xb0, 0x03,
+	0x24, 0x50, 0x78, 0xb1, 0x05, 0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x2d, 0x00, 0x4b, 0xb8, 0x00,
+	0xc8, 0x52, 0x58, 0xb1, 0x01, 0x01, 0x8e, 0x59, 0xb0, 0x01, 0xb9, 0x08, 0x00, 0x08, 0x00, 0x63,
+	0x70, 0xb1, 0x00, 0x07, 0x42, 0xb6, 0x00, 0x4e, 0x41, 0x31, 0x21, 0x05, 0x00, 0x2a, 0xb1, 0x00,
+	0x07, 0x42, 0x40, 0x0c, 0x52, 0x04, 0x46, 0x06, 0x36, 0x08, 0x26, 0x08, 0x18, 0x07, 0x05, 0x0a,
+	0x2a, 0xb1, 0x00, 0x07, 0x42, 0x40, 0x0c, 0x56, 0x02, 0x4c, 0x04, 0x3e, 0x06, 0x2e, 0x06, 0x1f,
+	0x05, 0x05, 0x0a, 0x2a, 0xb1, 0x00, 0x0c, 0x42, 0xbe, 0x14, 0xc0, 0x11, 0xc0, 0x0d, 0xc0, 0x09,
+	0xc0, 0x06, 0x40, 0x00, 0x05, 0x00, 0x0b, 0x2a, 0xb1, 0x00, 0x11, 0x42, 0xbe, 0x00, 0x40, 0x00,
+	0x40, 0x00, 0x40, 0x00, 0x40, 0x00, 0x40, 0x00, 0x05, 0x00, 0x0b, 0x2a, 0xb9, 0x00, 0x03, 0x00,
+	0x00, 0x44, 0xb1, 0x24, 0x01, 0x88, 0x51, 0x58, 0xb0, 0x40, 0x88, 0x58, 0xb9, 0x00, 0x03, 0x00,
+	0x64, 0x44, 0xb1, 0x28, 0x01, 0x88, 0x51, 0x58, 0xb8, 0x08, 0x00, 0x88, 0x58, 0xb9, 0x00, 0x03,
+	0x00, 0x00, 0x44, 0x59, 0x1b, 0xb1, 0x27, 0x01, 0x88, 0x51, 0x58, 0xba, 0x08, 0x80, 0x00, 0x01,
+	0x04, 0x40, 0x88, 0x63, 0x54, 0x58, 0xb9, 0x00, 0x03, 0x00, 0x00, 0x44, 0x59, 0x59, 0x59, 0x59,
+	0x59, 0x40, 0x0c, 0x54, 0x02, 0x48, 0x04, 0x38, 0x06, 0x28, 0x06, 0x1a, 0x05, 0x05, 0x0e, 0x2a,
+	0xb8, 0x01, 0xff, 0x85, 0xb0, 0x04, 0x8d, 0xb1, 0x02, 0x00, 0x44, 0xb3, 0x05, 0x64, 0x06, 0x00,
+	0x44, 0x44, 0x00, 0x00,
+}
@@ -0,0 +1,9303 @@
+// generated by go run gen.go; DO NOT EDIT
+
+// Package goregular provides the "Go Regular" TrueType font
+// from the Go font family. It is a proportional-width, sans-serif font.
+//
+// See https://blog.golang.org/go-fonts for details.
+package goregular
+
+// TTF is the data for the "Go Regular" TrueType font.
+var TTF = []byte{
+	0x00, 0x01, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x80, 0x00, 0x03, 0x00, 0x60, 0x4f, 0x53, 0x2f, 0x32,
+	0xc1, 0xa9, 0x38, 0xc9, 0x00, 0x00, 0x00, 0xec, 0x00, 0x00, 0x00, 0x60, 0x63, 0x6d, 0x61, 0x70,
+	0xbe, 0x92, 0x2d, 0x51, 0x00, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x05, 0x3e, 0x63, 0x76, 0x74, 0x20,
+	0x52, 0x5b, 0x19, 0xdd, 0x00, 0x02, 0x35, 0x2c, 0x00, 0x00, 0x00, 0xb0, 0x66, 0x70, 0x67, 0x6d,
+	0x62, 0x2f, 0x03, 0x7f, 0x00, 0x02, 0x35, 0xdc, 0x00, 0x00, 0x0e, 0x0c, 0x67, 0x61, 0x73, 0x70,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x02, 0x35, 0x24, 0x00, 0x00, 0x00, 0x08, 0x67, 0x6c, 0x79, 0x66,
+	0x5d, 0xd6, 0xf9, 0x96, 0x00, 0x00, 0x06, 0x8c, 0x00, 0x01, 0xed, 0x76, 0x68, 0x65, 0x61, 0x64,
+	0x18, 0xf2, 0x52, 0xd4, 0x00, 0x01, 0xf4, 0x04, 0x00, 0x00, 0x00, 0x36, 0x68, 0x68, 0x65, 0x61,
+	0x0e, 0x4a, 0x08, 0x1f, 0x00, 0x01, 0xf4, 0x3c, 0x00, 0x00, 0x00, 0x24, 0x68, 0x6d, 0x74, 0x78,
+	0xec, 0x97, 0x1a, 0x19, 0x00, 0x01, 0xf4, 0x60, 0x00, 0x00, 0x0b, 0x1e, 0x6c, 0x6f, 0x63, 0x61,
+	0xeb, 0xa4, 0x72, 0xb2, 0x00, 0x01, 0xff, 0x80, 0x00, 0x00, 0x05, 0x92, 0x6d, 0x61, 0x78, 0x70,
+	0x06, 0x46, 0x10, 0xa7, 0x00, 0x02, 0x05, 0x14, 0x00, 0x00, 0x00, 0x20, 0x6e, 0x61, 0x6d, 0x65,
+	0xb6, 0xcd, 0xad, 0x30, 0x00, 0x02, 0x05, 0x34, 0x00, 0x00, 0x1b, 0x3d, 0x70, 0x6f, 0x73, 0x74,
+	0xfc, 0x9f, 0x10, 0xa5, 0x00, 0x02, 0x20, 0x74, 0x00, 0x00, 0x14, 0xb0, 0x70, 0x72, 0x65, 0x70,
+	0x8e, 0xd0, 0xa0, 0x76, 0x00, 0x02, 0x43, 0xe8, 0x00, 0x00, 0x00, 0xd6, 0x00, 0x03, 0x04, 0xb2,
+	0x01, 0x90, 0x00, 0x05, 0x00, 0x00, 0x05, 0x9a, 0x05, 0x33, 0x00, 0x00, 0x01, 0x1b, 0x05, 0x9a,
+	0x05, 0x33, 0x00, 0x00, 0x03, 0xd1, 0x00, 0x66, 0x02, 0x00, 0x08, 0x02, 0x02, 0x0b, 0x06, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xa0, 0x00, 0x02, 0xaf, 0x50, 0x00, 0x79, 0xfb, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20, 0x20, 0x20, 0x20, 0x00, 0x40, 0x00, 0x00, 0xff, 0xfd,
+	0x06, 0x2b, 0xfe, 0x75, 0x01, 0x89, 0x07, 0x8f, 0x01, 0xb0, 0x20, 0x00, 0x00, 0x9f, 0xdf, 0xd7,
+	0x00, 0x00, 0x04, 0x3e, 0x05, 0xc8, 0x00, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x03, 0x34,
+	0x00, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x04, 0x03, 0x18, 0x00, 0x00, 0x00, 0xc2,
+	0x00, 0x80, 0x00, 0x06, 0x00, 0x42, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x7e, 0x01, 0x7f, 0x01, 0x92,
+	0x01, 0xdc, 0x01, 0xff, 0x02, 0x1b, 0x02, 0xc7, 0x02, 0xc9, 0x02, 0xdd, 0x03, 0x7e, 0x03, 0x8a,
+	0x03, 0x8c, 0x03, 0xa1, 0x03, 0xce, 0x04, 0x5f, 0x04, 0x91, 0x1e, 0x85, 0x1e, 0xf3, 0x20, 0x15,
+	0x20, 0x1e, 0x20, 0x22, 0x20, 0x26, 0x20, 0x30, 0x20, 0x33, 0x20, 0x3a, 0x20, 0x3c, 0x20, 0x3e,
+	0x20, 0x44, 0x20, 0x70, 0x20, 0x8e, 0x20, 0x99, 0x20, 0xa4, 0x20, 0xa7, 0x20, 0xac, 0x21, 0x05,
+	0x21, 0x13, 0x21, 0x16, 0x21, 0x22, 0x21, 0x26, 0x21, 0x2e, 0x21, 0x5e, 0x21, 0x95, 0x21, 0xa8,
+	0x22, 0x02, 0x22, 0x06, 0x22, 0x0f, 0x22, 0x12, 0x22, 0x15, 0x22, 0x1a, 0x22, 0x1f, 0x22, 0x2b,
+	0x22, 0x48, 0x22, 0x61, 0x22, 0x65, 0x23, 0x02, 0x23, 0x10, 0x23, 0x21, 0x25, 0x00, 0x25, 0x02,
+	0x25, 0x0c, 0x25, 0x10, 0x25, 0x14, 0x25, 0x18, 0x25, 0x1c, 0x25, 0x24, 0x25, 0x2c, 0x25, 0x34,
+	0x25, 0x3c, 0x25, 0x6c, 0x25, 0x80, 0x25, 0x84, 0x25, 0x88, 0x25, 0x8c, 0x25, 0x93, 0x25, 0xa1,
+	0x25, 0xac, 0x25, 0xb2, 0x25, 0xba, 0x25, 0xbc, 0x25, 0xc4, 0x25, 0xcb, 0x25, 0xcf, 0x25, 0xd9,
+	0x25, 0xe6, 0x26, 0x3c, 0x26, 0x40, 0x26, 0x42, 0x26, 0x60, 0x26, 0x63, 0x26, 0x66, 0x26, 0x6b,
+	0xf8, 0x00, 0xfb, 0x02, 0xff, 0xfd, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x20,
+	0x00, 0xa0, 0x01, 0x92, 0x01, 0xcd, 0x01, 0xfa, 0x02, 0x18, 0x02, 0xc6, 0x02, 0xc9, 0x02, 0xd8,
+	0x03, 0x7e, 0x03, 0x84, 0x03, 0x8c, 0x03, 0x8e, 0x03, 0xa3, 0x04, 0x00, 0x04, 0x90, 0x1e, 0x80,
+	0x1e, 0xf2, 0x20, 0x13, 0x20, 0x17, 0x20, 0x20, 0x20, 0x26, 0x20, 0x30, 0x20, 0x32, 0x20, 0x39,
+	0x20, 0x3c, 0x20, 0x3e, 0x20, 0x44, 0x20, 0x70, 0x20, 0x74, 0x20, 0x99, 0x20, 0xa3, 0x20, 0xa7,
+	0x20, 0xac, 0x21, 0x05, 0x21, 0x13, 0x21, 0x16, 0x21, 0x22, 0x21, 0x26, 0x21, 0x2e, 0x21, 0x5b,
+	0x21, 0x90, 0x21, 0xa8, 0x22, 0x02, 0x22, 0x06, 0x22, 0x0f, 0x22, 0x11, 0x22, 0x15, 0x22, 0x19,
+	0x22, 0x1e, 0x22, 0x29, 0x22, 0x48, 0x22, 0x60, 0x22, 0x64, 0x23, 0x02, 0x23, 0x10, 0x23, 0x20,
+	0x25, 0x00, 0x25, 0x02, 0x25, 0x0c, 0x25, 0x10, 0x25, 0x14, 0x25, 0x18, 0x25, 0x1c, 0x25, 0x24,
+	0x25, 0x2c, 0x25, 0x34, 0x25, 0x3c, 0x25, 0x50, 0x25, 0x80, 0x25, 0x84, 0x25, 0x88, 0x25, 0x8c,
+	0x25, 0x90, 0x25, 0xa0, 0x25, 0xaa, 0x25, 0xb2, 0x25, 0xba, 0x25, 0xbc, 0x25, 0xc4, 0x25, 0xca,
+	0x25, 0xcf, 0x25, 0xd8, 0x25, 0xe6, 0x26, 0x3a, 0x26, 0x40, 0x26, 0x42, 0x26, 0x60, 0x26, 0x63,
+	0x26, 0x65, 0x26, 0x6a, 0xf8, 0x00, 0xfb, 0x01, 0xff, 0xfd, 0xff, 0xff, 0x00, 0x01, 0xff, 0xf5,
+	0xff, 0xe3, 0xff, 0xc2, 0xff, 0xb0, 0xff, 0x76, 0xff, 0x59, 0xff, 0x41, 0xfe, 0x97, 0xfe, 0x96,
+	0xfe, 0x88, 0xfd, 0xe8, 0xfd, 0xe3, 0xfd, 0xe2, 0xfd, 0xe1, 0xfd, 0xe0, 0xfd, 0xaf, 0xfd, 0x7f,
+	0xe3, 0x91, 0xe3, 0x25, 0xe2, 0x06, 0xe2, 0x05, 0xe2, 0x04, 0xe2, 0x01, 0xe1, 0xf8, 0xe1, 0xf7,
+	0xe1, 0xf2, 0xe1, 0xf1, 0xe1, 0xf0, 0xe1, 0xeb, 0xe1, 0xc0, 0xe1, 0xbd, 0xe1, 0xb3, 0xe1, 0xaa,
+	0xe1, 0xa8, 0xe1, 0xa4, 0xe1, 0x4c, 0xe1, 0x3f, 0xe1, 0x3d, 0xe1, 0x32, 0xe1, 0x2f, 0xe1, 0x28,
+	0xe0, 0xfc, 0xe0, 0xcb, 0xe0, 0xb9, 0xe0, 0x60, 0xe0, 0x5d, 0xe0, 0x55, 0xe0, 0x54, 0xe0, 0x52,
+	0xe0, 0x4f, 0xe0, 0x4c, 0xe0, 0x43, 0xe0, 0x27, 0xe0, 0x10, 0xe0, 0x0e, 0xdf, 0x72, 0xdf, 0x65,
+	0xdf, 0x56, 0xdd, 0x78, 0xdd, 0x77, 0xdd, 0x6e, 0xdd, 0x6b, 0xdd, 0x68, 0xdd, 0x65, 0xdd, 0x62,
+	0xdd, 0x5b, 0xdd, 0x54, 0xdd, 0x4d, 0xdd, 0x46, 0xdd, 0x33, 0xdd, 0x20, 0xdd, 0x1d, 0xdd, 0x1a,
+	0xdd, 0x17, 0xdd, 0x14, 0xdd, 0x08, 0xdd, 0x00, 0xdc, 0xfb, 0xdc, 0xf4, 0xdc, 0xf3, 0xdc, 0xec,
+	0xdc, 0xe7, 0xdc, 0xe4, 0xdc, 0xdc, 0xdc, 0xd0, 0xdc, 0x7d, 0xdc, 0x7a, 0xdc, 0x79, 0xdc, 0x5c,
+	0xdc, 0x5a, 0xdc, 0x59, 0xdc, 0x56, 0x0a, 0xc2, 0x07, 0xc2, 0x02, 0xc8, 0x00, 0x01, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x06, 0x02, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x04, 0x00, 0x05,
+	0x00, 0x06, 0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0a, 0x00, 0x0b, 0x00, 0x0c, 0x00, 0x0d,
+	0x00, 0x0e, 0x00, 0x0f, 0x00, 0x10, 0x00, 0x11, 0x00, 0x12, 0x00, 0x13, 0x00, 0x14, 0x00, 0x15,
+	0x00, 0x16, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x1b, 0x00, 0x1c, 0x00, 0x1d,
+	0x00, 0x1e, 0x00, 0x1f, 0x00, 0x20, 0x00, 0x21, 0x00, 0x22, 0x00, 0x23, 0x00, 0x24, 0x00, 0x25,
+	0x00, 0x26, 0x00, 0x27, 0x00, 0x28, 0x00, 0x29, 0x00, 0x2a, 0x00, 0x2b, 0x00, 0x2c, 0x00, 0x2d,
+	0x00, 0x2e, 0x00, 0x2f, 0x00, 0x30, 0x00, 0x31, 0x00, 0x32, 0x00, 0x33, 0x00, 0x34, 0x00, 0x35,
+	0x00, 0x36, 0x00, 0x37, 0x00, 0x38, 0x00, 0x39, 0x00, 0x3a, 0x00, 0x3b, 0x00, 0x3c, 0x00, 0x3d,
+	0x00, 0x3e, 0x00, 0x3f, 0x00, 0x40, 0x00, 0x41, 0x00, 0x42, 0x00, 0x43, 0x00, 0x44, 0x00, 0x45,
+	0x00, 0x46, 0x00, 0x47, 0x00, 0x48, 0x00, 0x49, 0x00, 0x4a, 0x00, 0x4b, 0x00, 0x4c, 0x00, 0x4d,
+	0x00, 0x4e, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x51, 0x00, 0x52, 0x00, 0x53, 0x00, 0x54, 0x00, 0x55,
+	0x00, 0x56, 0x00, 0x57, 0x00, 0x58, 0x00, 0x59, 0x00, 0x5a, 0x00, 0x5b, 0x00, 0x5c, 0x00, 0x5d,
+	0x00, 0x5e, 0x00, 0x5f, 0x00, 0x60, 0x00, 0x61, 0x00, 0x00, 0x00, 0x86, 0x00, 0x87, 0x00, 0x89,
+	0x00, 0x8b, 0x00, 0x93, 0x00, 0x98, 0x00, 0x9e, 0x00, 0xa3, 0x00, 0xa2, 0x00, 0xa4, 0x00, 0xa6,
+	0x00, 0xa5, 0x00, 0xa7, 0x00, 0xa9, 0x00, 0xab, 0x00, 0xaa, 0x00, 0xac, 0x00, 0xad, 0x00, 0xaf,
+	0x00, 0xae, 0x00, 0xb0, 0x00, 0xb1, 0x00, 0xb3, 0x00, 0xb5, 0x00, 0xb4, 0x00, 0xb6, 0x00, 0xb8,
+	0x00, 0xb7, 0x00, 0xbc, 0x00, 0xbb, 0x00, 0xbd, 0x00, 0xbe, 0x02, 0x24, 0x00, 0x72, 0x00, 0x64,
+	0x00, 0x65, 0x00, 0x69, 0x02, 0x26, 0x00, 0x78, 0x00, 0xa1, 0x00, 0x70, 0x00, 0x6b, 0x02, 0x54,
+	0x00, 0x76, 0x00, 0x6a, 0x02, 0x70, 0x00, 0x88, 0x00, 0x9a, 0x02, 0x6a, 0x00, 0x73, 0x02, 0x72,
+	0x02, 0x73, 0x00, 0x67, 0x00, 0x77, 0x02, 0x62, 0x02, 0x65, 0x02, 0x64, 0x01, 0xa0, 0x02, 0x6e,
+	0x00, 0x6c, 0x00, 0x7c, 0x02, 0x55, 0x00, 0xa8, 0x00, 0xba, 0x00, 0x81, 0x00, 0x63, 0x00, 0x6e,
+	0x02, 0x69, 0x01, 0x42, 0x02, 0x6f, 0x02, 0x63, 0x00, 0x6d, 0x00, 0x7d, 0x02, 0x27, 0x00, 0x03,
+	0x00, 0x82, 0x00, 0x85, 0x00, 0x97, 0x01, 0x14, 0x01, 0x15, 0x02, 0x19, 0x02, 0x1a, 0x02, 0x21,
+	0x02, 0x22, 0x02, 0x1d, 0x02, 0x1e, 0x00, 0xb9, 0x02, 0xb1, 0x00, 0xc1, 0x01, 0x3a, 0x02, 0x2f,
+	0x02, 0x50, 0x02, 0x2b, 0x02, 0x2c, 0x02, 0xc3, 0x02, 0xc4, 0x02, 0x25, 0x00, 0x79, 0x02, 0x1f,
+	0x02, 0x23, 0x02, 0x28, 0x00, 0x84, 0x00, 0x8c, 0x00, 0x83, 0x00, 0x8d, 0x00, 0x8a, 0x00, 0x8f,
+	0x00, 0x90, 0x00, 0x91, 0x00, 0x8e, 0x00, 0x95, 0x00, 0x96, 0x00, 0x00, 0x00, 0x94, 0x00, 0x9c,
+	0x00, 0x9d, 0x00, 0x9b, 0x00, 0xf3, 0x01, 0x5d, 0x01, 0x64, 0x00, 0x71, 0x01, 0x60, 0x01, 0x61,
+	0x01, 0x62, 0x00, 0x7a, 0x01, 0x65, 0x01, 0x63, 0x01, 0x5e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x00, 0x00, 0x05, 0x00, 0x05, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00,
+	0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01,
+	0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x21, 0x11, 0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x01, 0x00,
+	0x04, 0x00, 0xfc, 0x40, 0x03, 0x80, 0xfc, 0x80, 0x05, 0x00, 0xfb, 0x00, 0x40, 0x04, 0x80, 0x00,
+	0x00, 0x02, 0x00, 0xc8, 0x00, 0x00, 0x01, 0xa1, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x09, 0x00, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x38, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x09, 0x04,
+	0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x35, 0x33, 0x15,
+	0x03, 0x03, 0x11, 0x33, 0x11, 0x03, 0xc8, 0xd9, 0xb6, 0x19, 0xc5, 0x18, 0xcf, 0xcf, 0x01, 0x97,
+	0x03, 0x09, 0x01, 0x28, 0xfe, 0xd8, 0xfc, 0xf7, 0x00, 0x02, 0x00, 0x5c, 0x04, 0x20, 0x02, 0x7b,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x05, 0x03, 0x04, 0x03, 0x01, 0x01,
+	0x00, 0x5f, 0x02, 0x01, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x13, 0x03, 0x33, 0x03,
+	0x33, 0x03, 0x33, 0x03, 0x75, 0x19, 0xc5, 0x18, 0xc5, 0x19, 0xc6, 0x19, 0x04, 0x20, 0x02, 0x0b,
+	0xfd, 0xf5, 0x02, 0x0b, 0xfd, 0xf5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x19, 0x00, 0x00, 0x04, 0x5a,
+	0x05, 0xc8, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0xa9, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x28, 0x0e,
+	0x09, 0x02, 0x01, 0x0c, 0x0a, 0x02, 0x00, 0x0b, 0x01, 0x00, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38,
+	0x4d, 0x0f, 0x08, 0x02, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x05, 0x02, 0x03, 0x03, 0x3b, 0x4d, 0x10,
+	0x0d, 0x02, 0x0b, 0x0b, 0x39, 0x0b, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x07,
+	0x05, 0x02, 0x03, 0x0f, 0x08, 0x02, 0x02, 0x01, 0x03, 0x02, 0x68, 0x0e, 0x09, 0x02, 0x01, 0x0c,
+	0x0a, 0x02, 0x00, 0x0b, 0x01, 0x00, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x10, 0x0d, 0x02,
+	0x0b, 0x0b, 0x39, 0x0b, 0x4e, 0x1b, 0x40, 0x26, 0x06, 0x01, 0x04, 0x03, 0x04, 0x85, 0x07, 0x05,
+	0x02, 0x03, 0x0f, 0x08, 0x02, 0x02, 0x01, 0x03, 0x02, 0x68, 0x0e, 0x09, 0x02, 0x01, 0x0c, 0x0a,
+	0x02, 0x00, 0x0b, 0x01, 0x00, 0x67, 0x10, 0x0d, 0x02, 0x0b, 0x0b, 0x3c, 0x0b, 0x4e, 0x59, 0x59,
+	0x40, 0x1e, 0x00, 0x00, 0x1f, 0x1e, 0x1d, 0x1c, 0x00, 0x1b, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x17,
+	0x16, 0x15, 0x14, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x1f,
+	0x2b, 0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x23, 0x37, 0x33, 0x13, 0x33, 0x03, 0x21, 0x13, 0x33,
+	0x03, 0x33, 0x07, 0x23, 0x03, 0x33, 0x07, 0x23, 0x03, 0x23, 0x13, 0x21, 0x03, 0x13, 0x21, 0x13,
+	0x21, 0x7d, 0x6f, 0xd3, 0x18, 0xda, 0x57, 0xec, 0x19, 0xf1, 0x70, 0x7f, 0x6f, 0x01, 0x07, 0x6f,
+	0x80, 0x6f, 0xd3, 0x18, 0xda, 0x57, 0xec, 0x18, 0xf2, 0x6f, 0x80, 0x6f, 0xfe, 0xf9, 0x6f, 0x8d,
+	0x01, 0x08, 0x57, 0xfe, 0xf8, 0x01, 0xbc, 0x7c, 0x01, 0x59, 0x7b, 0x01, 0xbc, 0xfe, 0x44, 0x01,
+	0xbc, 0xfe, 0x44, 0x7b, 0xfe, 0xa7, 0x7c, 0xfe, 0x44, 0x01, 0xbc, 0xfe, 0x44, 0x02, 0x38, 0x01,
+	0x59, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x7b, 0xff, 0x85, 0x03, 0xdd, 0x06, 0x44, 0x00, 0x1f,
+	0x00, 0x25, 0x00, 0x2a, 0x00, 0x92, 0x40, 0x1b, 0x27, 0x26, 0x25, 0x20, 0x16, 0x15, 0x13, 0x12,
+	0x07, 0x04, 0x0a, 0x01, 0x03, 0x03, 0x01, 0x00, 0x01, 0x02, 0x4c, 0x0d, 0x01, 0x03, 0x1e, 0x01,
+	0x00, 0x02, 0x4b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1b, 0x05, 0x01, 0x04, 0x00, 0x04, 0x86,
+	0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x02, 0x03,
+	0x02, 0x85, 0x05, 0x01, 0x04, 0x00, 0x04, 0x86, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x01, 0x01,
+	0x00, 0x61, 0x00, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x02, 0x03, 0x02, 0x85,
+	0x00, 0x03, 0x01, 0x03, 0x85, 0x05, 0x01, 0x04, 0x00, 0x04, 0x86, 0x00, 0x01, 0x01, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x3c, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x1f, 0x00, 0x1f,
+	0x11, 0x18, 0x13, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x05, 0x35, 0x22, 0x27, 0x35, 0x16, 0x33, 0x11,
+	0x26, 0x26, 0x35, 0x34, 0x36, 0x37, 0x35, 0x33, 0x15, 0x32, 0x17, 0x15, 0x26, 0x27, 0x11, 0x16,
+	0x17, 0x16, 0x16, 0x15, 0x14, 0x06, 0x07, 0x15, 0x11, 0x36, 0x35, 0x34, 0x26, 0x27, 0x03, 0x11,
+	0x06, 0x15, 0x14, 0x02, 0x10, 0xbb, 0xda, 0xe1, 0xb4, 0xd4, 0x95, 0xc1, 0xa8, 0x63, 0x9a, 0xb1,
+	0xbf, 0x8c, 0x35, 0x1b, 0xaa, 0x70, 0xba, 0xb0, 0xbd, 0x42, 0x7b, 0x63, 0xbd, 0x7b, 0x7b, 0x53,
+	0xaa, 0x69, 0x02, 0x13, 0x7c, 0xbd, 0x85, 0x94, 0xc3, 0x0c, 0x7c, 0x7c, 0x43, 0xa1, 0x53, 0x0a,
+	0xfd, 0xf1, 0x21, 0x10, 0x5d, 0x96, 0x6f, 0x9e, 0xe0, 0x21, 0x7b, 0x01, 0x1b, 0x2a, 0xb7, 0x47,
+	0x5b, 0x4a, 0x01, 0x06, 0x01, 0xc8, 0x2b, 0xa7, 0x83, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0x78,
+	0x00, 0x00, 0x06, 0xaf, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x1b, 0x00, 0x27, 0x00, 0x33,
+	0x00, 0xaf, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x04, 0x00, 0x03, 0x09, 0x04, 0x03,
+	0x69, 0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x6a, 0x00, 0x05, 0x05, 0x00, 0x61, 0x02, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x39, 0x4d, 0x0a, 0x01,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x04, 0x00,
+	0x03, 0x09, 0x04, 0x03, 0x69, 0x00, 0x06, 0x00, 0x09, 0x08, 0x06, 0x09, 0x6a, 0x00, 0x08, 0x00,
+	0x07, 0x01, 0x08, 0x07, 0x69, 0x00, 0x05, 0x05, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x0a, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x28, 0x02, 0x01, 0x00, 0x00, 0x05, 0x04,
+	0x00, 0x05, 0x69, 0x00, 0x04, 0x00, 0x03, 0x09, 0x04, 0x03, 0x69, 0x00, 0x06, 0x00, 0x09, 0x08,
+	0x06, 0x09, 0x6a, 0x00, 0x08, 0x00, 0x07, 0x01, 0x08, 0x07, 0x69, 0x0a, 0x01, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x59, 0x40, 0x1a, 0x00, 0x00, 0x32, 0x30, 0x2c, 0x2a, 0x26, 0x24, 0x20, 0x1e,
+	0x1a, 0x18, 0x14, 0x12, 0x0e, 0x0c, 0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0b, 0x09, 0x17,
+	0x2b, 0x33, 0x01, 0x33, 0x01, 0x01, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22,
+	0x26, 0x37, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x01, 0x34, 0x36,
+	0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x37, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35,
+	0x34, 0x26, 0x23, 0x22, 0x06, 0xfa, 0x04, 0x80, 0x9a, 0xfb, 0x81, 0xfe, 0xe3, 0xac, 0x9b, 0x9d,
+	0xab, 0xab, 0x9c, 0x9f, 0xa9, 0xa4, 0x59, 0x4a, 0x4a, 0x5a, 0x5a, 0x4a, 0x49, 0x5a, 0x03, 0x04,
+	0xb6, 0x92, 0x93, 0xb4, 0xaa, 0x9d, 0x9f, 0xa9, 0xa4, 0x59, 0x4b, 0x49, 0x5a, 0x5a, 0x49, 0x4a,
+	0x5a, 0x05, 0xc8, 0xfa, 0x38, 0x04, 0x5c, 0xa7, 0xc5, 0xc6, 0xac, 0xab, 0xc7, 0xc8, 0xaf, 0x74,
+	0x96, 0x95, 0x70, 0x71, 0x95, 0x94, 0xfc, 0xd5, 0xa7, 0xc5, 0xc7, 0xab, 0xab, 0xc7, 0xc8, 0xa5,
+	0x6a, 0x96, 0x95, 0x66, 0x7b, 0x95, 0x94, 0x00, 0x00, 0x03, 0x00, 0x38, 0xff, 0xdb, 0x05, 0x0d,
+	0x05, 0xed, 0x00, 0x1c, 0x00, 0x25, 0x00, 0x2d, 0x00, 0x6f, 0x40, 0x11, 0x1f, 0x12, 0x08, 0x03,
+	0x02, 0x05, 0x1a, 0x14, 0x02, 0x04, 0x02, 0x01, 0x01, 0x03, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x02,
+	0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x01, 0x00, 0x05, 0x02, 0x01, 0x05, 0x69, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x2b, 0x29, 0x25, 0x23, 0x00, 0x1c,
+	0x00, 0x1c, 0x19, 0x28, 0x22, 0x07, 0x09, 0x19, 0x2b, 0x21, 0x27, 0x06, 0x23, 0x22, 0x00, 0x35,
+	0x10, 0x25, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x05, 0x12, 0x17, 0x36, 0x35,
+	0x35, 0x33, 0x10, 0x07, 0x16, 0x17, 0x25, 0x26, 0x03, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x03,
+	0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x04, 0x01, 0x49, 0xc2, 0xb7, 0xdf, 0xfe, 0xd8, 0x01,
+	0x5d, 0x58, 0xb9, 0x9d, 0x95, 0xae, 0xfe, 0xbf, 0xa7, 0xa7, 0x63, 0xc3, 0xca, 0x58, 0x76, 0xfe,
+	0x55, 0xc4, 0xc3, 0xdd, 0xcc, 0x94, 0x70, 0x83, 0xd2, 0x8b, 0x95, 0x57, 0x7c, 0x01, 0x10, 0xcd,
+	0x01, 0x54, 0x7c, 0x9f, 0x78, 0x9a, 0xb4, 0xa2, 0x8a, 0xf7, 0x8a, 0xfe, 0xcf, 0xc7, 0x7e, 0xa9,
+	0x50, 0xfe, 0xfa, 0xdc, 0x70, 0x6d, 0xca, 0xdf, 0x01, 0x6d, 0x63, 0xd5, 0x9a, 0xd5, 0x03, 0x4d,
+	0x55, 0xac, 0x9c, 0xa4, 0x64, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x48, 0x04, 0x0c, 0x01, 0x3f,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x3a, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13,
+	0x03, 0x33, 0x03, 0x79, 0x31, 0xf7, 0x32, 0x04, 0x0c, 0x02, 0x1f, 0xfd, 0xe1, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x83, 0xfe, 0xd8, 0x02, 0x58, 0x06, 0x2b, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07,
+	0x01, 0x01, 0x32, 0x2b, 0x05, 0x15, 0x26, 0x02, 0x11, 0x10, 0x12, 0x37, 0x15, 0x06, 0x02, 0x11,
+	0x10, 0x12, 0x02, 0x58, 0xd8, 0xfd, 0xfd, 0xd8, 0x93, 0x7d, 0x7d, 0xa0, 0x88, 0x93, 0x01, 0xf9,
+	0x01, 0x1e, 0x01, 0x1d, 0x01, 0xf9, 0x93, 0x88, 0xa0, 0xfe, 0x90, 0xfe, 0xef, 0xfe, 0xee, 0xfe,
+	0x90, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x52, 0xfe, 0xd8, 0x02, 0x27, 0x06, 0x2b, 0x00, 0x0d,
+	0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b, 0x13, 0x35, 0x16, 0x12, 0x11, 0x10, 0x02, 0x07,
+	0x35, 0x36, 0x12, 0x11, 0x10, 0x02, 0x52, 0xd8, 0xfd, 0xfd, 0xd8, 0x93, 0x7c, 0x7c, 0x05, 0xa3,
+	0x88, 0x93, 0xfe, 0x07, 0xfe, 0xe3, 0xfe, 0xe2, 0xfe, 0x07, 0x93, 0x88, 0xa0, 0x01, 0x71, 0x01,
+	0x11, 0x01, 0x11, 0x01, 0x70, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0x8d, 0x01, 0x06, 0x04, 0x1f,
+	0x04, 0x65, 0x00, 0x06, 0x00, 0x0e, 0x00, 0x16, 0x00, 0x1e, 0x00, 0x26, 0x00, 0x45, 0x40, 0x16,
+	0x09, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x26, 0x22, 0x21, 0x1e, 0x1a, 0x19, 0x16, 0x12, 0x11, 0x0e,
+	0x0a, 0x06, 0x02, 0x0d, 0x01, 0x49, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x0b, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x01, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x00, 0x01, 0x01, 0x00,
+	0x57, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x00, 0x01, 0x51, 0x59, 0xb4, 0x22, 0x10, 0x02,
+	0x09, 0x18, 0x2b, 0x01, 0x33, 0x03, 0x26, 0x23, 0x26, 0x07, 0x05, 0x37, 0x37, 0x05, 0x06, 0x07,
+	0x06, 0x17, 0x01, 0x17, 0x17, 0x05, 0x36, 0x27, 0x26, 0x27, 0x01, 0x07, 0x07, 0x03, 0x36, 0x37,
+	0x36, 0x37, 0x01, 0x27, 0x27, 0x13, 0x16, 0x17, 0x16, 0x17, 0x01, 0xef, 0xd0, 0x30, 0x18, 0x20,
+	0x1e, 0x17, 0xfe, 0x6b, 0x20, 0x20, 0x01, 0x48, 0x14, 0x09, 0x0a, 0x05, 0x01, 0xec, 0x20, 0x20,
+	0xfe, 0x9b, 0x03, 0x09, 0x08, 0x13, 0x01, 0x1b, 0x55, 0x53, 0xae, 0x1b, 0x1a, 0x19, 0x0a, 0xfe,
+	0xf0, 0x53, 0x55, 0xfa, 0x0d, 0x1a, 0x17, 0x1a, 0x04, 0x65, 0xfe, 0x98, 0x0d, 0x01, 0x0e, 0x2b,
+	0x61, 0x64, 0x9e, 0x13, 0x1e, 0x1b, 0x1a, 0x01, 0x03, 0x64, 0x62, 0x42, 0x1b, 0x1e, 0x1d, 0x11,
+	0xfe, 0x8a, 0x3e, 0x3b, 0x01, 0x40, 0x04, 0x12, 0x12, 0x16, 0xfe, 0x82, 0x3b, 0x3e, 0x01, 0x07,
+	0x17, 0x13, 0x13, 0x02, 0x00, 0x01, 0x00, 0x68, 0x00, 0x63, 0x04, 0x43, 0x04, 0x3e, 0x00, 0x0b,
+	0x00, 0x27, 0x40, 0x24, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x06, 0x01,
+	0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3b, 0x05, 0x4e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33,
+	0x11, 0x21, 0x15, 0x21, 0x11, 0x02, 0x0b, 0xfe, 0x5d, 0x01, 0xa3, 0x94, 0x01, 0xa4, 0xfe, 0x5c,
+	0x63, 0x01, 0xa3, 0x94, 0x01, 0xa4, 0xfe, 0x5c, 0x94, 0xfe, 0x5d, 0x00, 0x00, 0x01, 0x00, 0xc8,
+	0xfe, 0xa2, 0x01, 0xbf, 0x00, 0xf7, 0x00, 0x09, 0x00, 0x5c, 0xb5, 0x01, 0x01, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x39, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x11, 0x03, 0x01, 0x02, 0x00, 0x02, 0x86, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x39,
+	0x00, 0x4e, 0x1b, 0x40, 0x11, 0x03, 0x01, 0x02, 0x00, 0x02, 0x86, 0x00, 0x01, 0x01, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x3c, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09,
+	0x11, 0x14, 0x04, 0x09, 0x18, 0x2b, 0x13, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x15, 0x10,
+	0xc8, 0x60, 0x60, 0xf7, 0xfe, 0xa2, 0x4a, 0x1b, 0xe5, 0x14, 0xf7, 0xd6, 0xfe, 0x81, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x68, 0x02, 0x06, 0x04, 0x44, 0x02, 0x9a, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15,
+	0x68, 0x03, 0xdc, 0x02, 0x06, 0x94, 0x94, 0x00, 0x00, 0x01, 0x00, 0xc8, 0x00, 0x00, 0x01, 0xc9,
+	0x01, 0x01, 0x00, 0x03, 0x00, 0x30, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x02, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x01,
+	0x5f, 0x02, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0xc8, 0x01, 0x01, 0x01, 0x01, 0xfe,
+	0xff, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xff, 0x74, 0x02, 0x39, 0x05, 0xc8, 0x00, 0x03,
+	0x00, 0x2e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0c, 0x02, 0x01, 0x01, 0x00, 0x01, 0x86, 0x00,
+	0x00, 0x00, 0x38, 0x00, 0x4e, 0x1b, 0x40, 0x0a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0x15, 0x01, 0x33, 0x01, 0x01, 0x9e, 0x9b, 0xfe, 0x62, 0x8c, 0x06, 0x54, 0xf9, 0xac, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x04, 0x22, 0x05, 0xed, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x17,
+	0x00, 0x4f, 0xb6, 0x17, 0x0f, 0x02, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x16, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x61,
+	0x04, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x00, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x0f, 0x01, 0x00, 0x13, 0x11, 0x0b, 0x09, 0x05, 0x03, 0x00, 0x07, 0x01, 0x07, 0x05, 0x09, 0x16,
+	0x2b, 0x05, 0x20, 0x11, 0x10, 0x21, 0x20, 0x13, 0x10, 0x01, 0x12, 0x33, 0x20, 0x11, 0x34, 0x26,
+	0x27, 0x27, 0x02, 0x23, 0x20, 0x11, 0x14, 0x16, 0x17, 0x02, 0x39, 0xfe, 0x17, 0x01, 0xe9, 0x01,
+	0xe3, 0x06, 0xfd, 0x1d, 0x3c, 0xbe, 0x01, 0x1d, 0x06, 0x07, 0x15, 0x3d, 0xbe, 0xfe, 0xe4, 0x06,
+	0x07, 0x25, 0x03, 0x0a, 0x03, 0x08, 0xfc, 0xf8, 0xfc, 0xf6, 0x01, 0xb0, 0xfe, 0xe4, 0x02, 0x72,
+	0x3a, 0x70, 0x36, 0x7d, 0x01, 0x1b, 0xfd, 0x8b, 0x3c, 0x6c, 0x33, 0x00, 0x00, 0x01, 0x00, 0xd2,
+	0x00, 0x00, 0x04, 0x10, 0x05, 0xed, 0x00, 0x09, 0x00, 0x3b, 0xb6, 0x06, 0x05, 0x04, 0x03, 0x04,
+	0x00, 0x4a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03,
+	0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x03,
+	0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x15,
+	0x11, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x35, 0x21, 0x11, 0x05, 0x35, 0x25, 0x11, 0x21, 0x15, 0xd2,
+	0x01, 0x3c, 0xfe, 0xc4, 0x02, 0x02, 0x01, 0x3c, 0x94, 0x04, 0x90, 0x4f, 0x98, 0x80, 0xfa, 0xa7,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x66, 0x00, 0x00, 0x03, 0xad, 0x05, 0xed, 0x00, 0x19,
+	0x00, 0x55, 0x40, 0x0f, 0x0c, 0x01, 0x00, 0x01, 0x0b, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x01, 0x01,
+	0x02, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x69, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19,
+	0x18, 0x23, 0x28, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x35, 0x36, 0x3f, 0x02, 0x36, 0x35, 0x10, 0x23,
+	0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x15,
+	0x66, 0x44, 0xa2, 0x6c, 0x62, 0xc1, 0xf2, 0x8e, 0xd0, 0xc4, 0xb7, 0xc1, 0xe6, 0x76, 0xa5, 0x45,
+	0xd0, 0x29, 0x02, 0x51, 0xad, 0x9f, 0xaa, 0x6e, 0x64, 0xc6, 0xbd, 0x01, 0x0f, 0x78, 0xae, 0x5d,
+	0xe1, 0xbf, 0x82, 0xc9, 0x96, 0x3e, 0xbd, 0xc4, 0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x99,
+	0xff, 0xdb, 0x03, 0xc6, 0x05, 0xed, 0x00, 0x21, 0x00, 0x67, 0x40, 0x16, 0x15, 0x01, 0x03, 0x04,
+	0x14, 0x01, 0x02, 0x03, 0x1b, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x05, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x42, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x27, 0x23, 0x23, 0x21, 0x23, 0x24, 0x06, 0x09, 0x1c,
+	0x2b, 0x37, 0x35, 0x16, 0x17, 0x16, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x23, 0x35, 0x37, 0x32,
+	0x36, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x11, 0x10, 0x05, 0x04, 0x11, 0x14,
+	0x04, 0x23, 0x22, 0x99, 0x1d, 0x0f, 0xa7, 0x5a, 0x01, 0x2d, 0xc9, 0xba, 0x4e, 0x44, 0xa9, 0xc1,
+	0xf3, 0x7c, 0xb4, 0xb0, 0x88, 0x01, 0xb0, 0xfe, 0xcc, 0x01, 0x65, 0xfe, 0xf7, 0xdf, 0x71, 0x0b,
+	0xb8, 0x0c, 0x05, 0x43, 0x01, 0x24, 0x98, 0xa4, 0x85, 0x01, 0x9d, 0x89, 0xde, 0x53, 0xac, 0x3b,
+	0xfe, 0xa7, 0xfe, 0xfd, 0x6f, 0x52, 0xfe, 0xca, 0xcc, 0xf3, 0x00, 0x00, 0x00, 0x02, 0x00, 0x1f,
+	0x00, 0x00, 0x04, 0x2f, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x55, 0x40, 0x0a, 0x0d, 0x01,
+	0x02, 0x01, 0x03, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x05,
+	0x01, 0x02, 0x03, 0x01, 0x00, 0x04, 0x02, 0x00, 0x68, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x06, 0x01,
+	0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x02, 0x01, 0x85, 0x05, 0x01, 0x02,
+	0x03, 0x01, 0x00, 0x04, 0x02, 0x00, 0x68, 0x06, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x40,
+	0x0f, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x11, 0x12, 0x11, 0x07, 0x09, 0x1a,
+	0x2b, 0x21, 0x11, 0x21, 0x35, 0x01, 0x33, 0x11, 0x33, 0x15, 0x23, 0x11, 0x01, 0x21, 0x11, 0x02,
+	0xb0, 0xfd, 0x6f, 0x02, 0x91, 0xb9, 0xc6, 0xc6, 0xfd, 0x77, 0x01, 0xdd, 0x01, 0xa3, 0x95, 0x03,
+	0x90, 0xfc, 0x7c, 0xa1, 0xfe, 0x5d, 0x02, 0x44, 0x02, 0x92, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa3,
+	0xff, 0xdb, 0x03, 0xc6, 0x05, 0xc8, 0x00, 0x20, 0x00, 0x5b, 0x40, 0x0a, 0x01, 0x01, 0x00, 0x01,
+	0x00, 0x01, 0x05, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x04, 0x00,
+	0x01, 0x00, 0x04, 0x01, 0x67, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00,
+	0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x67, 0x00, 0x00, 0x00,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x28, 0x21, 0x11, 0x11, 0x28,
+	0x22, 0x06, 0x09, 0x1c, 0x2b, 0x17, 0x35, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02,
+	0x23, 0x23, 0x11, 0x21, 0x15, 0x21, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23,
+	0x22, 0x26, 0xa3, 0x94, 0x84, 0x52, 0x77, 0x4c, 0x24, 0x2b, 0x5f, 0x97, 0x6d, 0xaa, 0x02, 0xec,
+	0xfd, 0xc1, 0x41, 0x81, 0xc9, 0x8a, 0x48, 0x59, 0x97, 0xc7, 0x6e, 0x38, 0x7e, 0x06, 0xb0, 0x3b,
+	0x31, 0x57, 0x76, 0x45, 0x48, 0x72, 0x50, 0x2a, 0x02, 0xe2, 0xac, 0xfe, 0x61, 0x3c, 0x74, 0xab,
+	0x70, 0x7e, 0xb3, 0x72, 0x34, 0x0f, 0x00, 0x00, 0x00, 0x02, 0x00, 0x54, 0xff, 0xdb, 0x04, 0x03,
+	0x05, 0xee, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x5f, 0x40, 0x0e, 0x10, 0x01, 0x03, 0x02, 0x11, 0x01,
+	0x00, 0x03, 0x00, 0x01, 0x05, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00,
+	0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e,
+	0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x00,
+	0x02, 0x00, 0x03, 0x00, 0x02, 0x03, 0x69, 0x00, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00,
+	0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x22, 0x23,
+	0x24, 0x24, 0x21, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x02, 0x23,
+	0x22, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x01, 0x10, 0x23, 0x22,
+	0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01, 0x2a, 0x83, 0xcc, 0xb8, 0xd2, 0xed, 0xde, 0xe1, 0xfe,
+	0xfd, 0x01, 0x36, 0x01, 0x14, 0x82, 0x93, 0xb1, 0x64, 0xfe, 0x8c, 0x02, 0x17, 0xf7, 0x80, 0x99,
+	0x9e, 0x7b, 0xf7, 0x03, 0x0a, 0xac, 0xf7, 0xd8, 0xfc, 0xfe, 0xf0, 0x01, 0x85, 0x01, 0x52, 0x01,
+	0x86, 0x01, 0xb6, 0x38, 0xac, 0x50, 0xfc, 0x5e, 0x01, 0x70, 0xac, 0x91, 0xa6, 0xd6, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x88, 0x00, 0x00, 0x04, 0x3e, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb4, 0x08,
+	0x01, 0x00, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x38, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0f, 0x00,
+	0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40,
+	0x0b, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x14, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x36, 0x36,
+	0x37, 0x01, 0x21, 0x35, 0x21, 0x15, 0x00, 0x03, 0xed, 0x1d, 0x6b, 0x79, 0x01, 0x94, 0xfd, 0x06,
+	0x03, 0xb6, 0xfd, 0xc6, 0x43, 0xad, 0xfc, 0xdc, 0x02, 0x8a, 0xb9, 0xb9, 0xfc, 0xb8, 0xfe, 0x39,
+	0x00, 0x03, 0x00, 0x63, 0xff, 0xdb, 0x04, 0x41, 0x05, 0xed, 0x00, 0x13, 0x00, 0x1e, 0x00, 0x2b,
+	0x00, 0x43, 0xb5, 0x0a, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15,
+	0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x00, 0x00, 0x02, 0x03, 0x00, 0x02, 0x69,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0xb6, 0x2a, 0x28, 0x28,
+	0x24, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x05,
+	0x04, 0x11, 0x14, 0x04, 0x23, 0x22, 0x24, 0x35, 0x10, 0x25, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22,
+	0x06, 0x15, 0x14, 0x16, 0x17, 0x06, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26,
+	0x27, 0x01, 0x89, 0xd9, 0xf6, 0xc6, 0xb8, 0xe2, 0xfe, 0xec, 0x01, 0x4f, 0xfe, 0xe7, 0xde, 0xdc,
+	0xfe, 0xf5, 0x02, 0x21, 0xcf, 0x88, 0x75, 0x6e, 0x85, 0x7b, 0x16, 0x77, 0x55, 0xa4, 0x86, 0x81,
+	0xa3, 0x64, 0x92, 0x03, 0x26, 0x97, 0xb7, 0xa8, 0xd1, 0xb1, 0x92, 0xd3, 0xb1, 0xa4, 0xfe, 0xfd,
+	0xba, 0xea, 0xde, 0xb9, 0x01, 0x05, 0xed, 0x89, 0x9e, 0x5f, 0x6f, 0x69, 0x58, 0x52, 0x84, 0xec,
+	0x5c, 0x89, 0x65, 0x80, 0x9d, 0x86, 0x6b, 0x56, 0x77, 0x56, 0x00, 0x00, 0x00, 0x02, 0x00, 0x54,
+	0xff, 0xda, 0x04, 0x03, 0x05, 0xee, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x5f, 0x40, 0x0e, 0x00, 0x01,
+	0x04, 0x05, 0x11, 0x01, 0x03, 0x00, 0x10, 0x01, 0x02, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1d, 0x00, 0x04, 0x00, 0x00, 0x03, 0x04, 0x00, 0x69, 0x00, 0x05, 0x05, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3f, 0x02, 0x4e,
+	0x1b, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x69, 0x00, 0x04, 0x00, 0x00, 0x03,
+	0x04, 0x00, 0x69, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40,
+	0x09, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x06, 0x23, 0x22, 0x26,
+	0x35, 0x34, 0x12, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x20,
+	0x01, 0x10, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x03, 0x2d, 0x83, 0xcc, 0xb8, 0xd2,
+	0xed, 0xdd, 0xe1, 0x01, 0x04, 0xfe, 0xca, 0xfe, 0xec, 0x83, 0x93, 0xb2, 0x64, 0x01, 0x74, 0xfd,
+	0xe9, 0xf7, 0x80, 0x99, 0x9f, 0x7b, 0xf6, 0x02, 0xbe, 0xac, 0xf7, 0xd9, 0xfb, 0x01, 0x11, 0xfe,
+	0x7a, 0xfe, 0xae, 0xfe, 0x7a, 0xfe, 0x4a, 0x38, 0xac, 0x4f, 0x03, 0xa1, 0xfe, 0x90, 0xac, 0x91,
+	0xa6, 0xd6, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc8, 0x00, 0x00, 0x01, 0xbf, 0x04, 0x4a, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x4e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3b, 0x4d,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04,
+	0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09,
+	0x17, 0x2b, 0x33, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0xc8, 0xf7, 0xf7, 0xf7, 0xf7, 0xf7,
+	0x03, 0x53, 0xf7, 0xf7, 0x00, 0x02, 0x00, 0xc8, 0xfe, 0xa2, 0x01, 0xbf, 0x04, 0x4a, 0x00, 0x03,
+	0x00, 0x0d, 0x00, 0x86, 0xb5, 0x05, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x17, 0x50, 0x58,
+	0x40, 0x1c, 0x05, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x02, 0x5f, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x02, 0x04, 0x86, 0x05, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x02, 0x04, 0x86, 0x05, 0x01, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0d, 0x04, 0x0d, 0x0b, 0x0a, 0x09,
+	0x08, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35,
+	0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x15, 0x10, 0xc8, 0xf7, 0xf7, 0x60, 0x60, 0xf7, 0x03, 0x53,
+	0xf7, 0xf7, 0xfb, 0x4f, 0x4a, 0x1b, 0xe5, 0x14, 0xf7, 0xd6, 0xfe, 0x81, 0x00, 0x01, 0x00, 0x68,
+	0x00, 0x63, 0x04, 0x43, 0x04, 0x3e, 0x00, 0x06, 0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b,
+	0x25, 0x01, 0x01, 0x15, 0x01, 0x15, 0x01, 0x04, 0x43, 0xfc, 0x25, 0x03, 0xdb, 0xfd, 0x72, 0x02,
+	0x8e, 0x63, 0x01, 0xed, 0x01, 0xee, 0xa6, 0xfe, 0xb9, 0x02, 0xfe, 0xb9, 0x00, 0x02, 0x00, 0x1e,
+	0x01, 0x26, 0x04, 0x8e, 0x03, 0x7a, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02,
+	0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x01,
+	0x35, 0x21, 0x15, 0x1e, 0x04, 0x70, 0xfb, 0x90, 0x04, 0x70, 0x01, 0x26, 0xaa, 0xaa, 0x01, 0xaa,
+	0xaa, 0xaa, 0x00, 0x00, 0x00, 0x01, 0x00, 0x68, 0x00, 0x63, 0x04, 0x43, 0x04, 0x3e, 0x00, 0x06,
+	0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x13, 0x01, 0x01, 0x35, 0x01, 0x35, 0x01, 0x68,
+	0x03, 0xdb, 0xfc, 0x25, 0x02, 0x8e, 0xfd, 0x72, 0x04, 0x3e, 0xfe, 0x12, 0xfe, 0x13, 0xa5, 0x01,
+	0x47, 0x02, 0x01, 0x47, 0x00, 0x02, 0x00, 0xaa, 0x00, 0x00, 0x03, 0xf6, 0x05, 0xed, 0x00, 0x03,
+	0x00, 0x1a, 0x00, 0x6a, 0x40, 0x0a, 0x0f, 0x01, 0x02, 0x03, 0x0e, 0x01, 0x04, 0x02, 0x02, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x02, 0x00, 0x02, 0x04, 0x00, 0x80,
+	0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05,
+	0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x02, 0x00, 0x02, 0x04,
+	0x00, 0x80, 0x00, 0x03, 0x00, 0x02, 0x04, 0x03, 0x02, 0x69, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x1a, 0x04,
+	0x1a, 0x12, 0x10, 0x0d, 0x0b, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x21, 0x35,
+	0x33, 0x15, 0x03, 0x35, 0x34, 0x37, 0x37, 0x36, 0x35, 0x34, 0x21, 0x22, 0x07, 0x35, 0x36, 0x33,
+	0x20, 0x11, 0x14, 0x07, 0x07, 0x06, 0x06, 0x15, 0x15, 0x01, 0x8c, 0xc5, 0xc5, 0x99, 0x4f, 0xb0,
+	0xfe, 0xed, 0xae, 0xb9, 0xb3, 0xc3, 0x01, 0xd6, 0xbf, 0x47, 0x63, 0x3c, 0xc5, 0xc5, 0x01, 0x8b,
+	0x36, 0xf5, 0x80, 0x45, 0x89, 0x90, 0xc5, 0x45, 0xa7, 0x32, 0xfe, 0xa6, 0xb4, 0x78, 0x32, 0x3e,
+	0x82, 0x7c, 0x6e, 0x00, 0x00, 0x02, 0x00, 0xfd, 0xff, 0xdb, 0x07, 0x34, 0x05, 0xed, 0x00, 0x33,
+	0x00, 0x3d, 0x00, 0x8e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0b, 0x35, 0x13, 0x02, 0x05, 0x08,
+	0x33, 0x01, 0x07, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0b, 0x35, 0x13, 0x02, 0x09, 0x08, 0x33, 0x01,
+	0x07, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x04, 0x00, 0x08,
+	0x05, 0x04, 0x08, 0x69, 0x09, 0x01, 0x05, 0x03, 0x01, 0x02, 0x07, 0x05, 0x02, 0x69, 0x00, 0x06,
+	0x06, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x00, 0x04,
+	0x00, 0x08, 0x09, 0x04, 0x08, 0x69, 0x00, 0x09, 0x05, 0x02, 0x09, 0x59, 0x00, 0x05, 0x03, 0x01,
+	0x02, 0x07, 0x05, 0x02, 0x69, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x0e, 0x3d, 0x3b, 0x24, 0x24, 0x24, 0x24, 0x63, 0x26, 0x24, 0x24, 0x21, 0x0a, 0x09,
+	0x1f, 0x2b, 0x25, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x14, 0x00,
+	0x23, 0x22, 0x35, 0x34, 0x37, 0x37, 0x23, 0x02, 0x23, 0x22, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17,
+	0x16, 0x33, 0x33, 0x03, 0x06, 0x15, 0x14, 0x33, 0x32, 0x12, 0x35, 0x34, 0x00, 0x23, 0x20, 0x00,
+	0x11, 0x14, 0x00, 0x33, 0x32, 0x37, 0x13, 0x37, 0x26, 0x23, 0x22, 0x02, 0x15, 0x14, 0x33, 0x32,
+	0x04, 0xe5, 0xb4, 0xad, 0xfe, 0xe7, 0xfe, 0x92, 0x02, 0x34, 0x01, 0x73, 0x01, 0x18, 0x01, 0x78,
+	0xfe, 0xd2, 0xd8, 0xa6, 0x15, 0x28, 0x0c, 0xb5, 0xce, 0xc1, 0x01, 0x43, 0xca, 0x1f, 0x30, 0x31,
+	0x1d, 0x89, 0x7e, 0x06, 0x4b, 0x86, 0xd0, 0xfe, 0xc2, 0xf2, 0xfe, 0xc3, 0xfe, 0x14, 0x01, 0x35,
+	0xf2, 0x9e, 0x90, 0x10, 0x27, 0x5c, 0x44, 0x8d, 0xbc, 0x52, 0x87, 0x2c, 0x51, 0x01, 0x5b, 0x01,
+	0x0a, 0x01, 0x76, 0x02, 0x37, 0xfe, 0x98, 0xfe, 0xf5, 0xf8, 0xfe, 0xa6, 0x73, 0x29, 0x40, 0x7e,
+	0xfe, 0xa6, 0xdd, 0x01, 0x00, 0x01, 0x95, 0x03, 0x03, 0xfd, 0x84, 0x20, 0x1e, 0x43, 0x01, 0x1c,
+	0xb6, 0xe6, 0x01, 0x30, 0xfe, 0x0d, 0xfe, 0xbf, 0xe2, 0xfe, 0xe1, 0x48, 0x02, 0xaf, 0xc3, 0x21,
+	0xfe, 0xe2, 0xd6, 0x8e, 0x00, 0x02, 0x00, 0x13, 0x00, 0x00, 0x05, 0x3e, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00,
+	0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59,
+	0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x09, 0x19,
+	0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x13, 0x02, 0x32, 0xd0,
+	0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0x05, 0xc8, 0xfa, 0x38, 0x01,
+	0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x00, 0x00, 0x03, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xcf,
+	0x05, 0xc8, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x61, 0xb5, 0x07, 0x01, 0x03, 0x04, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x67,
+	0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06,
+	0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05,
+	0x67, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01,
+	0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1f, 0x1d, 0x1a, 0x18, 0x17, 0x15,
+	0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x16,
+	0x15, 0x10, 0x05, 0x04, 0x11, 0x14, 0x07, 0x06, 0x06, 0x23, 0x25, 0x33, 0x20, 0x36, 0x35, 0x34,
+	0x26, 0x23, 0x23, 0x35, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x23, 0xa5, 0x01, 0xda, 0x01, 0x24,
+	0xf1, 0xfe, 0xb8, 0x01, 0x83, 0x51, 0x40, 0xba, 0xd1, 0xfe, 0xc4, 0x9b, 0x01, 0x28, 0xb7, 0xee,
+	0xe1, 0xab, 0xb3, 0x01, 0x92, 0xa0, 0xe3, 0xc2, 0x05, 0xc8, 0x97, 0xb8, 0xfe, 0xf2, 0x68, 0x6a,
+	0xfe, 0xda, 0x8f, 0x61, 0x4e, 0x35, 0x9d, 0x57, 0x8c, 0x98, 0xa1, 0x85, 0x01, 0x19, 0x7c, 0x58,
+	0x00, 0x01, 0x00, 0x74, 0xff, 0xdb, 0x05, 0x48, 0x05, 0xed, 0x00, 0x15, 0x00, 0x4d, 0x40, 0x0f,
+	0x0a, 0x01, 0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01,
+	0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0xb6, 0x24, 0x23, 0x24, 0x21, 0x04, 0x09, 0x1a, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x21, 0x32, 0x05, 0x15, 0x24, 0x23, 0x22, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32,
+	0x37, 0x05, 0x48, 0xdb, 0xfe, 0xf2, 0xfe, 0x92, 0xfe, 0x83, 0x01, 0x84, 0x01, 0x6f, 0xd5, 0x01,
+	0x0a, 0xfe, 0xce, 0xb4, 0xff, 0xfe, 0xf4, 0x01, 0x1e, 0x01, 0x05, 0xdf, 0xf1, 0x4c, 0x71, 0x01,
+	0x8c, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd,
+	0xfe, 0xc1, 0x81, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x6a, 0x05, 0xc8, 0x00, 0x07,
+	0x00, 0x12, 0x00, 0x46, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x03, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x14, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02, 0x02, 0x01,
+	0x5f, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x12, 0x10, 0x0a,
+	0x08, 0x00, 0x07, 0x00, 0x06, 0x21, 0x05, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10,
+	0x00, 0x21, 0x25, 0x33, 0x20, 0x00, 0x11, 0x10, 0x27, 0x26, 0x26, 0x23, 0x23, 0xa5, 0x01, 0xda,
+	0x02, 0xeb, 0xfe, 0x7b, 0xfe, 0x9d, 0xfe, 0xf5, 0xfc, 0x01, 0x0e, 0x01, 0x08, 0x7e, 0x4d, 0xd6,
+	0xd6, 0x9b, 0x05, 0xc8, 0xfd, 0x3f, 0xfe, 0x8f, 0xfe, 0x6a, 0x9d, 0x01, 0x27, 0x01, 0x2f, 0x01,
+	0x05, 0x95, 0x5b, 0x43, 0x00, 0x01, 0x00, 0xbe, 0x00, 0x00, 0x05, 0x1b, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x56, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06,
+	0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0x05,
+	0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x00, 0x01, 0x00, 0xbf, 0x00, 0x00, 0x04, 0xac,
+	0x05, 0xc8, 0x00, 0x09, 0x00, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x02, 0x00,
+	0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01,
+	0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e,
+	0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x09, 0x1a,
+	0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0xbf, 0x03, 0xed, 0xfc, 0xe5,
+	0x02, 0xb7, 0xfd, 0x49, 0x05, 0xc8, 0x9d, 0xfe, 0x10, 0x9b, 0xfd, 0x60, 0x00, 0x01, 0x00, 0x5d,
+	0xff, 0xdb, 0x05, 0x7d, 0x05, 0xed, 0x00, 0x17, 0x00, 0x6a, 0x40, 0x12, 0x0a, 0x01, 0x02, 0x01,
+	0x0b, 0x01, 0x05, 0x02, 0x14, 0x01, 0x03, 0x04, 0x01, 0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1e, 0x06, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x06, 0x01,
+	0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x17, 0x00, 0x17, 0x12, 0x23, 0x23, 0x23, 0x22,
+	0x07, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x21, 0x20, 0x11, 0x10, 0x00, 0x21, 0x20, 0x05, 0x15,
+	0x24, 0x23, 0x20, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23, 0x35, 0x05, 0x7d, 0xfe, 0xf8,
+	0xfe, 0xef, 0xfc, 0xf9, 0x01, 0x92, 0x01, 0x75, 0x01, 0x08, 0x01, 0x0f, 0xfe, 0xc6, 0xdd, 0xfd,
+	0xda, 0x01, 0x2f, 0x01, 0x1b, 0x74, 0xb0, 0xf7, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01,
+	0x78, 0x01, 0x94, 0x43, 0xc2, 0x68, 0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a,
+	0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x21, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01,
+	0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03,
+	0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11,
+	0x21, 0x11, 0xa5, 0xd2, 0x02, 0xd9, 0xd1, 0xd1, 0xfd, 0x27, 0x05, 0xc8, 0xfd, 0x90, 0x02, 0x70,
+	0xfa, 0x38, 0x02, 0xbb, 0xfd, 0x45, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7c, 0x00, 0x00, 0x02, 0xb5,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01,
+	0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x09, 0x1b, 0x2b,
+	0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x7c, 0xb4, 0xb4, 0x02,
+	0x39, 0xb4, 0xb4, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x00, 0x00, 0x01, 0x00, 0x14,
+	0xfe, 0xd8, 0x03, 0x2b, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x4a, 0x40, 0x0a, 0x01, 0x01, 0x00, 0x01,
+	0x00, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x65, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x01, 0x4e, 0x1b,
+	0x40, 0x18, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x03, 0x03, 0x00, 0x59,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6, 0x22, 0x11, 0x13, 0x22,
+	0x04, 0x09, 0x1a, 0x2b, 0x17, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x23, 0x35, 0x21, 0x11,
+	0x10, 0x21, 0x22, 0x14, 0xa6, 0x95, 0x9f, 0x6b, 0xfa, 0x01, 0xcc, 0xfe, 0x1e, 0xa7, 0xe8, 0xb5,
+	0x4d, 0x7d, 0xb7, 0x04, 0x78, 0x9c, 0xfa, 0xf3, 0xfe, 0x1d, 0x00, 0x00, 0x00, 0x01, 0x00, 0xbf,
+	0x00, 0x00, 0x05, 0x25, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02,
+	0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a,
+	0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x01,
+	0x01, 0x21, 0x01, 0x11, 0xbf, 0xc5, 0x02, 0x67, 0xd3, 0xfd, 0xac, 0x02, 0xbb, 0xfe, 0xf6, 0xfd,
+	0x69, 0x05, 0xc8, 0xfd, 0x28, 0x02, 0xd8, 0xfd, 0x3e, 0xfc, 0xfa, 0x02, 0xee, 0xfd, 0x12, 0x00,
+	0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0x4d, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x3b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03,
+	0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01,
+	0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00,
+	0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0xa5,
+	0xd2, 0x02, 0xd6, 0x05, 0xc8, 0xfa, 0xd5, 0x9d, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x06, 0x05,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x50, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00,
+	0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11,
+	0x12, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x01, 0x21, 0x11, 0x23, 0x11, 0x01,
+	0x23, 0x01, 0x11, 0xa5, 0x01, 0x23, 0x01, 0x97, 0x01, 0xa2, 0x01, 0x04, 0xc4, 0xfe, 0x6c, 0xcb,
+	0xfe, 0x78, 0x05, 0xc8, 0xfb, 0x87, 0x04, 0x79, 0xfa, 0x38, 0x04, 0xb3, 0xfb, 0xb0, 0x04, 0x54,
+	0xfb, 0x49, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x21, 0x05, 0xc8, 0x00, 0x09,
+	0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x0e, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x05, 0x09, 0x19, 0x2b,
+	0x33, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11, 0x23, 0x01, 0x11, 0xa5, 0xcd, 0x02, 0xfb, 0xb4, 0xce,
+	0xfd, 0x06, 0x05, 0xc8, 0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23,
+	0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01,
+	0x40, 0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x25, 0x01,
+	0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d,
+	0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6,
+	0x00, 0x02, 0x00, 0xa7, 0x00, 0x00, 0x04, 0xfe, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00,
+	0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e,
+	0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02,
+	0x03, 0x01, 0x67, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13,
+	0x11, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x25, 0x21, 0x06, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x21,
+	0x32, 0x16, 0x17, 0x16, 0x15, 0x10, 0x21, 0x21, 0x11, 0x11, 0x21, 0x20, 0x11, 0x34, 0x26, 0x23,
+	0x21, 0xa7, 0x02, 0x1c, 0xe4, 0xc7, 0x41, 0x4f, 0xfd, 0x87, 0xfe, 0xf4, 0x01, 0x03, 0x01, 0xa4,
+	0xad, 0xf2, 0xfe, 0xf8, 0x05, 0xc8, 0x34, 0x4d, 0x60, 0xad, 0xfd, 0xfe, 0xfd, 0xc8, 0x02, 0xd7,
+	0x01, 0x54, 0x99, 0x67, 0x00, 0x02, 0x00, 0x5d, 0xfe, 0xd8, 0x06, 0x67, 0x05, 0xed, 0x00, 0x11,
+	0x00, 0x1d, 0x00, 0x48, 0x40, 0x0a, 0x10, 0x01, 0x00, 0x03, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x49,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x13, 0x00,
+	0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0xb6, 0x24, 0x28, 0x24, 0x24, 0x04, 0x09, 0x1a, 0x2b, 0x05, 0x07, 0x24, 0x27,
+	0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x05, 0x16, 0x03, 0x10,
+	0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x33, 0x32, 0x00, 0x06, 0x67, 0x85, 0xfe, 0xa3, 0xeb,
+	0x63, 0x36, 0xfe, 0xd6, 0xfe, 0x86, 0x01, 0x7e, 0x01, 0x3e, 0x01, 0x44, 0x01, 0x81, 0xfe, 0x7c,
+	0xf7, 0x55, 0xfc, 0xe8, 0xde, 0xfc, 0xfc, 0xde, 0xe3, 0x01, 0x01, 0x81, 0xa7, 0x72, 0x9b, 0x0b,
+	0x01, 0xb3, 0x01, 0x57, 0x01, 0x61, 0x01, 0xa8, 0xfe, 0x59, 0xfe, 0x9c, 0xfe, 0x04, 0xc8, 0x6f,
+	0x03, 0x2c, 0x01, 0x2d, 0x01, 0x48, 0xfe, 0xb7, 0xfe, 0xdd, 0xfe, 0xdd, 0xfe, 0xb7, 0x01, 0x44,
+	0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x9a, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x57,
+	0xb5, 0x06, 0x01, 0x02, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x06, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x00, 0x00, 0x05, 0x04,
+	0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x06, 0x03, 0x02, 0x01, 0x01,
+	0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x14, 0x21, 0x07, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x01, 0x21,
+	0x01, 0x21, 0x11, 0x11, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x21, 0xa5, 0x02, 0x6a, 0x01,
+	0xc8, 0xfe, 0xd5, 0x01, 0xee, 0xfe, 0xfe, 0xfe, 0x5b, 0xfe, 0x84, 0xeb, 0xd6, 0xc7, 0xa1, 0xbb,
+	0xfe, 0xd4, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xd8, 0x7c, 0xfd, 0x4b, 0x02, 0x72, 0xfd, 0x8e, 0x03,
+	0x0f, 0x94, 0xa1, 0x7c, 0x6b, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x78, 0xff, 0xdb, 0x04, 0xdb,
+	0x05, 0xed, 0x00, 0x1f, 0x00, 0x4d, 0x40, 0x0f, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00,
+	0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0xb6, 0x2a, 0x23, 0x28, 0x22, 0x04,
+	0x09, 0x1a, 0x2b, 0x37, 0x35, 0x04, 0x21, 0x20, 0x35, 0x34, 0x26, 0x27, 0x27, 0x24, 0x11, 0x10,
+	0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x15, 0x14, 0x16, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14,
+	0x04, 0x23, 0x20, 0x78, 0x01, 0x1d, 0x01, 0x31, 0x01, 0x3d, 0x7b, 0xbc, 0xc9, 0xfe, 0x7d, 0x02,
+	0x1c, 0xf4, 0xef, 0xf8, 0xf8, 0xfe, 0xbc, 0x79, 0xa2, 0xce, 0xe9, 0xbe, 0xfe, 0xdd, 0xf9, 0xfe,
+	0xf3, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01, 0x92, 0x3f, 0xc1,
+	0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5, 0x00, 0x00, 0x01, 0x00, 0x14,
+	0x00, 0x00, 0x04, 0xce, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x04, 0x01, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x04,
+	0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x02, 0x08,
+	0xfe, 0x0c, 0x04, 0xba, 0xfe, 0x0c, 0x05, 0x2b, 0x9d, 0x9d, 0xfa, 0xd5, 0x00, 0x01, 0x00, 0xa6,
+	0xff, 0xdb, 0x05, 0x20, 0x05, 0xc8, 0x00, 0x15, 0x00, 0x36, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x11, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f,
+	0x03, 0x4e, 0x1b, 0x40, 0x11, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0xb6, 0x25, 0x13, 0x25, 0x10, 0x04, 0x09, 0x1a, 0x2b,
+	0x13, 0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06,
+	0x07, 0x06, 0x23, 0x20, 0x00, 0x11, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e,
+	0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb,
+	0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x24, 0x00, 0x00, 0x05, 0x4b, 0x05, 0xc8, 0x00, 0x06, 0x00, 0x3a, 0xb5, 0x03,
+	0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x38, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x02,
+	0x00, 0x85, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x06,
+	0x00, 0x06, 0x12, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x02,
+	0x5f, 0xfd, 0xc5, 0xd8, 0x01, 0xd8, 0x01, 0xc4, 0xb3, 0xfd, 0xda, 0x05, 0xc8, 0xfb, 0x41, 0x04,
+	0xbf, 0xfa, 0x38, 0x00, 0x00, 0x01, 0x00, 0x19, 0x00, 0x00, 0x07, 0x74, 0x05, 0xc8, 0x00, 0x0c,
+	0x00, 0x42, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x03, 0x00, 0x85, 0x05, 0x04, 0x02, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11,
+	0x06, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x23, 0x01,
+	0x01, 0x01, 0x96, 0xfe, 0x83, 0xca, 0x01, 0x2f, 0x01, 0x5b, 0xca, 0x01, 0x4d, 0x01, 0x45, 0xab,
+	0xfe, 0x60, 0xd0, 0xfe, 0xb7, 0xfe, 0xab, 0x05, 0xc8, 0xfb, 0x6f, 0x04, 0x91, 0xfb, 0x7a, 0x04,
+	0x86, 0xfa, 0x38, 0x04, 0x75, 0xfb, 0x8b, 0x00, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x00, 0x05, 0x3a,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x04, 0x03,
+	0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04,
+	0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x12, 0x12, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01,
+	0x23, 0x01, 0x01, 0x1c, 0x02, 0x21, 0xfd, 0xf7, 0xf8, 0x01, 0x91, 0x01, 0xab, 0xc7, 0xfd, 0xef,
+	0x02, 0x1c, 0xf8, 0xfe, 0x5c, 0xfe, 0x44, 0x02, 0xdf, 0x02, 0xe9, 0xfd, 0xc1, 0x02, 0x3f, 0xfd,
+	0x3a, 0xfc, 0xfe, 0x02, 0x56, 0xfd, 0xaa, 0x00, 0x00, 0x01, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x39,
+	0x05, 0xc8, 0x00, 0x08, 0x00, 0x3c, 0xb7, 0x07, 0x04, 0x01, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x03, 0x01, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x03, 0x01, 0x02, 0x02,
+	0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x04, 0x09,
+	0x18, 0x2b, 0x21, 0x11, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x11, 0x02, 0x31, 0xfd, 0xed, 0xf0,
+	0x01, 0xa5, 0x01, 0xc3, 0xc3, 0xfd, 0xca, 0x02, 0x69, 0x03, 0x5f, 0xfd, 0x53, 0x02, 0xad, 0xfc,
+	0xa6, 0xfd, 0x92, 0x00, 0x00, 0x01, 0x00, 0x65, 0x00, 0x00, 0x04, 0x7c, 0x05, 0xc8, 0x00, 0x09,
+	0x00, 0x4d, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03,
+	0x5f, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02,
+	0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33,
+	0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x65, 0x03, 0x1b, 0xfd, 0x16, 0x03, 0xe6,
+	0xfc, 0xe5, 0x03, 0x1b, 0xa9, 0x04, 0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x00, 0x01, 0x00, 0x6e,
+	0xfe, 0xd8, 0x01, 0xf9, 0x06, 0x2b, 0x00, 0x07, 0x00, 0x22, 0x40, 0x1f, 0x00, 0x02, 0x04, 0x01,
+	0x03, 0x02, 0x03, 0x63, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3a, 0x01, 0x4e, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x13, 0x11, 0x21, 0x15,
+	0x23, 0x11, 0x33, 0x15, 0x6e, 0x01, 0x8b, 0xde, 0xde, 0xfe, 0xd8, 0x07, 0x53, 0x94, 0xf9, 0xd5,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xff, 0x74, 0x02, 0x39, 0x05, 0x96, 0x00, 0x03,
+	0x00, 0x26, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x0b, 0x00, 0x00, 0x01, 0x00, 0x86, 0x00, 0x01,
+	0x01, 0x38, 0x01, 0x4e, 0x1b, 0x40, 0x09, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00, 0x76,
+	0x59, 0xb4, 0x11, 0x10, 0x02, 0x09, 0x18, 0x2b, 0x05, 0x23, 0x01, 0x33, 0x02, 0x39, 0x9b, 0xfe,
+	0x62, 0x9b, 0x8c, 0x06, 0x22, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x40, 0xfe, 0xd8, 0x01, 0xcb,
+	0x06, 0x2b, 0x00, 0x07, 0x00, 0x22, 0x40, 0x1f, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x63, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3a, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x33, 0x11, 0x23, 0x35,
+	0x01, 0xcb, 0xfe, 0x75, 0xde, 0xde, 0x06, 0x2b, 0xf8, 0xad, 0x94, 0x06, 0x2b, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x46, 0x02, 0xbf, 0x03, 0x7a, 0x05, 0xed, 0x00, 0x05, 0x00, 0x19, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x0e, 0x03, 0x01, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x76, 0x12, 0x11, 0x02,
+	0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x03, 0x23, 0x01, 0x01, 0x23, 0x01, 0xe0, 0xf5,
+	0xa5, 0x01, 0x9a, 0x01, 0x9a, 0xa6, 0x04, 0xa2, 0xfe, 0x1d, 0x03, 0x2e, 0xfc, 0xd2, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xff, 0x6c, 0x04, 0x73, 0x00, 0x00, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x15, 0x35, 0x21, 0x15, 0x04, 0x73, 0x94, 0x94, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x6a, 0x05, 0x03, 0x02, 0x3f, 0x06, 0x44, 0x00, 0x03, 0x00, 0x19, 0xb1, 0x06,
+	0x64, 0x44, 0x40, 0x0e, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00, 0x76, 0x11, 0x10, 0x02,
+	0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x23, 0x01, 0x33, 0x02, 0x3f, 0x94, 0xfe, 0xbf,
+	0xe4, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5f, 0xff, 0xe7, 0x04, 0x4a,
+	0x04, 0x56, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x90, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x12, 0x0e,
+	0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04,
+	0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x07, 0x06,
+	0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x01,
+	0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29,
+	0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04,
+	0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0b, 0x22, 0x22, 0x24, 0x14,
+	0x23, 0x22, 0x23, 0x21, 0x08, 0x09, 0x1e, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21,
+	0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x14, 0x33, 0x32,
+	0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32, 0x03, 0x06, 0xb2,
+	0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d, 0x19,
+	0x0e, 0x44, 0x51, 0x89, 0x43, 0x41, 0xfe, 0x83, 0xb7, 0x81, 0x8a, 0xa3, 0xa6, 0x85, 0x01, 0x70,
+	0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d, 0x20, 0x01, 0x0e, 0x01,
+	0x19, 0x02, 0xdc, 0xac, 0x00, 0x02, 0x00, 0x9a, 0xff, 0xe7, 0x04, 0x1c, 0x06, 0x2b, 0x00, 0x09,
+	0x00, 0x17, 0x00, 0x82, 0xb7, 0x0a, 0x01, 0x00, 0x03, 0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x1b,
+	0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x01,
+	0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x11, 0x11, 0x24, 0x22,
+	0x23, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x11, 0x16, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x22,
+	0x07, 0x36, 0x33, 0x32, 0x12, 0x15, 0x10, 0x00, 0x23, 0x22, 0x27, 0x07, 0x11, 0x33, 0x01, 0x5f,
+	0x88, 0x45, 0x01, 0x1b, 0x6f, 0x60, 0x81, 0x98, 0x76, 0xce, 0xaa, 0xcf, 0xfe, 0xf5, 0xf5, 0x5f,
+	0x5e, 0xc5, 0xc5, 0x02, 0xbf, 0xfd, 0xd6, 0x1a, 0x01, 0xb1, 0xb1, 0xcd, 0x38, 0xe4, 0xfe, 0xda,
+	0xf2, 0xfe, 0xe1, 0xfe, 0xc8, 0x19, 0x0c, 0x06, 0x37, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x56,
+	0xff, 0xe7, 0x03, 0x9e, 0x04, 0x56, 0x00, 0x14, 0x00, 0x2e, 0x40, 0x2b, 0x0a, 0x01, 0x02, 0x01,
+	0x14, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x23, 0x23, 0x24, 0x21, 0x04, 0x09, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x00, 0x35, 0x10, 0x00,
+	0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x03, 0x9e, 0xac,
+	0xb0, 0xda, 0xfe, 0xee, 0x01, 0x17, 0xf8, 0x84, 0xa9, 0xa0, 0x64, 0xfe, 0xa1, 0xb6, 0xa0, 0x7c,
+	0x9d, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5e, 0xc2,
+	0xd5, 0x45, 0x00, 0x00, 0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xd8, 0x06, 0x2b, 0x00, 0x09,
+	0x00, 0x18, 0x00, 0x87, 0x40, 0x0c, 0x14, 0x01, 0x00, 0x03, 0x0a, 0x01, 0x00, 0x03, 0x01, 0x00,
+	0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x05, 0x01, 0x02,
+	0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x04, 0x04, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04,
+	0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05,
+	0x3c, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x59, 0x40,
+	0x09, 0x11, 0x12, 0x24, 0x22, 0x23, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x11, 0x26, 0x23, 0x20,
+	0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17,
+	0x11, 0x33, 0x11, 0x23, 0x03, 0x13, 0x88, 0x44, 0xfe, 0xe4, 0x6f, 0x60, 0x81, 0x98, 0x76, 0xce,
+	0xaa, 0xcf, 0x01, 0x0b, 0xf5, 0x5f, 0x5e, 0xc5, 0xc5, 0x01, 0x7e, 0x02, 0x2b, 0x19, 0xfe, 0x4f,
+	0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0x01, 0xed, 0xf9, 0xd5,
+	0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb, 0x04, 0x56, 0x00, 0x04, 0x00, 0x15, 0x00, 0x3d,
+	0x40, 0x3a, 0x05, 0x01, 0x05, 0x04, 0x06, 0x01, 0x02, 0x05, 0x02, 0x4c, 0x06, 0x01, 0x01, 0x00,
+	0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x00, 0x00, 0x15, 0x13, 0x12, 0x11,
+	0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x07, 0x09, 0x17, 0x2b, 0x01, 0x10, 0x23,
+	0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07, 0x21,
+	0x12, 0x21, 0x32, 0x03, 0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd, 0xc2, 0xb7, 0xfb, 0xfe, 0xd5, 0x01,
+	0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c, 0x02, 0x94, 0x01, 0x2f, 0xfe,
+	0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe,
+	0x7d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x1f, 0x00, 0x00, 0x02, 0x76, 0x06, 0x44, 0x00, 0x14,
+	0x00, 0x63, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02, 0x0a, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x40, 0x4d, 0x05,
+	0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x06, 0x06, 0x39,
+	0x06, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x40, 0x4d, 0x05,
+	0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x06, 0x06, 0x3c,
+	0x06, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x14, 0x00, 0x14, 0x11, 0x13, 0x23, 0x22, 0x11,
+	0x11, 0x08, 0x09, 0x1c, 0x2b, 0x33, 0x11, 0x23, 0x35, 0x33, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15,
+	0x26, 0x23, 0x22, 0x06, 0x15, 0x15, 0x33, 0x15, 0x23, 0x11, 0xaf, 0x90, 0x90, 0x01, 0x37, 0x3f,
+	0x51, 0x49, 0x34, 0x4a, 0x3a, 0xe1, 0xe1, 0x03, 0xaa, 0x94, 0x82, 0x01, 0x84, 0x1a, 0x9d, 0x23,
+	0x61, 0x7a, 0x97, 0x94, 0xfc, 0x56, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5d, 0xfe, 0x5c, 0x03, 0xdf,
+	0x04, 0x56, 0x00, 0x09, 0x00, 0x22, 0x00, 0x99, 0x40, 0x10, 0x0a, 0x01, 0x00, 0x03, 0x01, 0x00,
+	0x1e, 0x01, 0x06, 0x02, 0x1d, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x20, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x22,
+	0x00, 0x01, 0x00, 0x02, 0x06, 0x01, 0x02, 0x69, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43,
+	0x05, 0x4e, 0x59, 0x59, 0x40, 0x0a, 0x23, 0x25, 0x11, 0x24, 0x22, 0x23, 0x22, 0x07, 0x09, 0x1d,
+	0x2b, 0x01, 0x11, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02,
+	0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x33, 0x11, 0x10, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x35,
+	0x16, 0x33, 0x20, 0x11, 0x03, 0x1a, 0x88, 0x43, 0xfe, 0xe3, 0x70, 0x5f, 0x81, 0x98, 0x75, 0xcf,
+	0xa8, 0xd1, 0x01, 0x0b, 0xf3, 0x61, 0x5e, 0xc5, 0x35, 0x48, 0x81, 0xfe, 0xf0, 0xbe, 0xaf, 0xd1,
+	0x99, 0x01, 0x4c, 0x01, 0xb0, 0x01, 0xf9, 0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01, 0x23,
+	0xea, 0x01, 0x0b, 0x01, 0x25, 0x18, 0xfc, 0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab, 0x51,
+	0x01, 0x61, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xe5, 0x06, 0x2b, 0x00, 0x10,
+	0x00, 0x51, 0xb6, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x17, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x05, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3a, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c,
+	0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06,
+	0x09, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x36, 0x33, 0x20, 0x11, 0x11, 0x23, 0x11, 0x34, 0x26,
+	0x23, 0x22, 0x07, 0x11, 0x9a, 0xc5, 0x9d, 0xd2, 0x01, 0x17, 0xc6, 0x37, 0x4c, 0xa7, 0x96, 0x06,
+	0x2b, 0xfd, 0x47, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x00,
+	0x00, 0x02, 0x00, 0x90, 0x00, 0x00, 0x01, 0x69, 0x05, 0xdc, 0x00, 0x03, 0x00, 0x07, 0x00, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04,
+	0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x33, 0x11,
+	0x03, 0x35, 0x33, 0x15, 0x9a, 0xc5, 0xcf, 0xd9, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03, 0xd9, 0xd9,
+	0x00, 0x02, 0xff, 0xac, 0xfe, 0x5d, 0x01, 0x83, 0x05, 0xdc, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x5b,
+	0x40, 0x0a, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1b, 0x05, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x00, 0x01,
+	0x01, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40,
+	0x19, 0x00, 0x03, 0x05, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x0d, 0x0d, 0x0d,
+	0x10, 0x0d, 0x10, 0x12, 0x22, 0x13, 0x22, 0x06, 0x09, 0x1a, 0x2b, 0x03, 0x35, 0x16, 0x33, 0x32,
+	0x36, 0x35, 0x11, 0x33, 0x11, 0x10, 0x21, 0x22, 0x13, 0x35, 0x33, 0x15, 0x54, 0x35, 0x47, 0x55,
+	0x36, 0xc6, 0xfe, 0xc0, 0x5b, 0xcb, 0xda, 0xfe, 0x73, 0x90, 0x12, 0x69, 0xa6, 0x04, 0x3e, 0xfb,
+	0xc2, 0xfe, 0x5d, 0x06, 0xa6, 0xd9, 0xd9, 0x00, 0x00, 0x01, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xee,
+	0x06, 0x2b, 0x00, 0x0a, 0x00, 0x47, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02, 0x01, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x12, 0x00, 0x00, 0x00, 0x3a, 0x4d,
+	0x00, 0x01, 0x01, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c,
+	0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x33,
+	0x11, 0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x11, 0x9a, 0xc5, 0x01, 0x6c, 0xbc, 0xfe, 0xa5, 0x01,
+	0xc2, 0xf0, 0xfe, 0x61, 0x06, 0x2b, 0xfc, 0x04, 0x02, 0x0f, 0xfd, 0xff, 0xfd, 0xc3, 0x02, 0x2d,
+	0xfd, 0xd3, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9a, 0xff, 0xe7, 0x02, 0x0b, 0x06, 0x2b, 0x00, 0x0f,
+	0x00, 0x1f, 0x40, 0x1c, 0x06, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00,
+	0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x15, 0x22, 0x14, 0x03, 0x09, 0x19,
+	0x2b, 0x01, 0x14, 0x1e, 0x02, 0x37, 0x15, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x11, 0x33, 0x01,
+	0x5f, 0x14, 0x2d, 0x41, 0x2a, 0x16, 0x26, 0x41, 0x75, 0x53, 0x2c, 0xc5, 0x01, 0x50, 0x39, 0x51,
+	0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d, 0x04, 0xff, 0x00, 0x00, 0x01, 0x00, 0x9a,
+	0x00, 0x00, 0x06, 0x1c, 0x04, 0x56, 0x00, 0x1c, 0x00, 0x7c, 0x40, 0x09, 0x1b, 0x14, 0x08, 0x03,
+	0x04, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x16, 0x06, 0x01, 0x04, 0x04,
+	0x00, 0x61, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x39,
+	0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06,
+	0x01, 0x04, 0x04, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x04, 0x04,
+	0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x07, 0x05, 0x03, 0x03, 0x03, 0x3c, 0x03,
+	0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x1c, 0x22, 0x12, 0x22, 0x12, 0x23,
+	0x23, 0x11, 0x09, 0x09, 0x1d, 0x2b, 0x33, 0x11, 0x33, 0x15, 0x36, 0x36, 0x33, 0x32, 0x17, 0x36,
+	0x36, 0x33, 0x20, 0x11, 0x11, 0x23, 0x03, 0x34, 0x23, 0x22, 0x07, 0x11, 0x23, 0x11, 0x34, 0x23,
+	0x22, 0x07, 0x11, 0x9a, 0xc5, 0x5b, 0x7c, 0x64, 0xd7, 0x4d, 0x5b, 0x7b, 0x64, 0x01, 0x24, 0xc5,
+	0x01, 0x94, 0x80, 0x84, 0xc6, 0x95, 0x80, 0x84, 0x04, 0x3e, 0xcc, 0x8b, 0x59, 0xe4, 0x8b, 0x59,
+	0xfe, 0xc0, 0xfc, 0xea, 0x02, 0xf7, 0xbb, 0xda, 0xfd, 0x28, 0x02, 0xf7, 0xbb, 0xda, 0xfd, 0x28,
+	0x00, 0x01, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xe5, 0x04, 0x56, 0x00, 0x10, 0x00, 0x6d, 0xb6, 0x0f,
+	0x03, 0x02, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x17, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00,
+	0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x15, 0x36,
+	0x33, 0x20, 0x11, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x9a, 0xc5, 0x9d, 0xd2,
+	0x01, 0x17, 0xc6, 0x37, 0x4c, 0xa7, 0x96, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02,
+	0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c,
+	0x04, 0x56, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x06, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10,
+	0x00, 0x27, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11, 0x10, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03,
+	0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x19, 0x01, 0x34,
+	0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01,
+	0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x00, 0x00, 0x02, 0x00, 0x9a, 0xfe, 0x75, 0x04, 0x1c,
+	0x04, 0x56, 0x00, 0x0e, 0x00, 0x18, 0x00, 0x5f, 0x40, 0x0c, 0x10, 0x0f, 0x04, 0x03, 0x04, 0x05,
+	0x0e, 0x01, 0x03, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x4d, 0x00, 0x00, 0x00, 0x3d, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x3d, 0x00, 0x4e, 0x59, 0x40, 0x09, 0x23, 0x24, 0x24,
+	0x22, 0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x23, 0x11, 0x33, 0x15, 0x36, 0x33, 0x32, 0x12,
+	0x15, 0x10, 0x00, 0x23, 0x22, 0x27, 0x11, 0x11, 0x16, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x22,
+	0x01, 0x5f, 0xc5, 0xc5, 0x76, 0xce, 0xaa, 0xcf, 0xfe, 0xf5, 0xf5, 0x5f, 0x5e, 0x88, 0x45, 0x01,
+	0x1b, 0x6f, 0x60, 0x81, 0xfe, 0x75, 0x05, 0xc9, 0xcc, 0xe4, 0xfe, 0xda, 0xf2, 0xfe, 0xe1, 0xfe,
+	0xc8, 0x19, 0x02, 0xbf, 0xfd, 0xd6, 0x1a, 0x01, 0xb1, 0xb1, 0xcd, 0x00, 0x00, 0x02, 0x00, 0x56,
+	0xfe, 0x75, 0x03, 0xd8, 0x04, 0x56, 0x00, 0x0d, 0x00, 0x17, 0x00, 0x5a, 0xb7, 0x0f, 0x0e, 0x04,
+	0x03, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x00,
+	0x61, 0x03, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42,
+	0x4d, 0x00, 0x01, 0x01, 0x3d, 0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00,
+	0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x42, 0x4d, 0x00, 0x01, 0x01, 0x3d, 0x01, 0x4e, 0x59, 0x40, 0x09, 0x23, 0x23, 0x24, 0x22,
+	0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x33, 0x11, 0x23, 0x11, 0x06, 0x23, 0x22, 0x02, 0x35,
+	0x10, 0x00, 0x33, 0x32, 0x13, 0x11, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x03, 0x13,
+	0xc5, 0xc5, 0x76, 0xce, 0xaa, 0xcf, 0x01, 0x0b, 0xf5, 0x5f, 0x5e, 0x88, 0x44, 0xfe, 0xe4, 0x6f,
+	0x60, 0x81, 0x04, 0x3e, 0xfa, 0x37, 0x02, 0x56, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38,
+	0xfd, 0x28, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9a,
+	0x00, 0x00, 0x02, 0x9c, 0x04, 0x56, 0x00, 0x0d, 0x00, 0x84, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x0c, 0x0c, 0x08, 0x03, 0x03, 0x03, 0x02, 0x01, 0x4c, 0x07, 0x01, 0x00, 0x4a, 0x1b, 0x40, 0x0c,
+	0x07, 0x01, 0x00, 0x01, 0x0c, 0x08, 0x03, 0x03, 0x03, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04,
+	0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x04, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x11,
+	0x33, 0x15, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x07, 0x11, 0x9a, 0xc5, 0x5a, 0xaa,
+	0x17, 0x22, 0x33, 0x20, 0x72, 0x78, 0x04, 0x3e, 0xcc, 0xe4, 0x05, 0xb8, 0x11, 0xde, 0xfd, 0x34,
+	0x00, 0x01, 0x00, 0x74, 0xff, 0xe7, 0x03, 0x8c, 0x04, 0x56, 0x00, 0x1c, 0x00, 0x2e, 0x40, 0x2b,
+	0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x42, 0x03, 0x4e, 0x28, 0x23, 0x27, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x37, 0x35, 0x16, 0x33,
+	0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15,
+	0x14, 0x17, 0x17, 0x04, 0x15, 0x14, 0x06, 0x23, 0x22, 0x74, 0xc2, 0xab, 0xe5, 0x9f, 0xb0, 0xfd,
+	0x01, 0xa1, 0x78, 0xa6, 0x91, 0xa2, 0xc9, 0x8d, 0x9d, 0x01, 0x25, 0xe8, 0xca, 0xa3, 0x26, 0xb5,
+	0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33,
+	0x61, 0xe7, 0x99, 0xb0, 0x00, 0x01, 0x00, 0x19, 0xff, 0xe7, 0x02, 0x4d, 0x05, 0x34, 0x00, 0x14,
+	0x00, 0x32, 0x40, 0x2f, 0x14, 0x01, 0x05, 0x01, 0x00, 0x01, 0x00, 0x05, 0x02, 0x4c, 0x0a, 0x09,
+	0x02, 0x02, 0x4a, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00,
+	0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x23, 0x11, 0x13, 0x11, 0x12, 0x21,
+	0x06, 0x09, 0x1c, 0x2b, 0x05, 0x06, 0x23, 0x20, 0x11, 0x11, 0x23, 0x35, 0x33, 0x35, 0x37, 0x15,
+	0x33, 0x15, 0x23, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x02, 0x12, 0x3a, 0x34, 0xfe, 0xf4, 0x7f,
+	0x7f, 0xc5, 0xf0, 0xf0, 0x2c, 0x45, 0x29, 0x1b, 0x06, 0x13, 0x01, 0x45, 0x02, 0x7e, 0x94, 0xe3,
+	0x13, 0xf6, 0x94, 0xfd, 0xa6, 0x82, 0x53, 0x0b, 0x00, 0x01, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8,
+	0x04, 0x3e, 0x00, 0x10, 0x00, 0x6d, 0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0,
+	0x14, 0x50, 0x58, 0x40, 0x13, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x05, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x17, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x05, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x06,
+	0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x11, 0x33, 0x11, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5,
+	0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x00,
+	0x00, 0x01, 0x00, 0x13, 0x00, 0x00, 0x03, 0xf4, 0x04, 0x3e, 0x00, 0x06, 0x00, 0x3a, 0xb5, 0x03,
+	0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0d, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x06,
+	0x00, 0x06, 0x12, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01,
+	0x94, 0xfe, 0x7f, 0xc7, 0x01, 0x2d, 0x01, 0x3e, 0xaf, 0xfe, 0x65, 0x04, 0x3e, 0xfc, 0xb3, 0x03,
+	0x4d, 0xfb, 0xc2, 0x00, 0x00, 0x01, 0x00, 0x0b, 0x00, 0x00, 0x05, 0xb9, 0x04, 0x3e, 0x00, 0x0c,
+	0x00, 0x42, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x0f, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11,
+	0x06, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x13, 0x13, 0x33, 0x13, 0x13, 0x33, 0x01, 0x23, 0x03,
+	0x01, 0x01, 0x11, 0xfe, 0xfa, 0xc1, 0xc4, 0xfa, 0xc5, 0xdc, 0xe4, 0xaa, 0xfe, 0xcf, 0xc6, 0xe6,
+	0xfe, 0xfc, 0x04, 0x3e, 0xfc, 0xce, 0x03, 0x32, 0xfc, 0xcb, 0x03, 0x35, 0xfb, 0xc2, 0x03, 0x49,
+	0xfc, 0xb7, 0x00, 0x00, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x00, 0x03, 0xe1, 0x04, 0x3e, 0x00, 0x0b,
+	0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05,
+	0x09, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x33, 0x01, 0x13, 0x33, 0x01, 0x01, 0x23, 0x01, 0x01, 0x1c,
+	0x01, 0x61, 0xfe, 0xab, 0xe4, 0x01, 0x10, 0xf4, 0xb6, 0xfe, 0xb4, 0x01, 0x67, 0xe3, 0xfe, 0xda,
+	0xfe, 0xfa, 0x02, 0x3e, 0x02, 0x00, 0xfe, 0x69, 0x01, 0x97, 0xfd, 0xdd, 0xfd, 0xe5, 0x01, 0xb4,
+	0xfe, 0x4c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x13, 0xfe, 0x75, 0x03, 0xf4, 0x04, 0x3e, 0x00, 0x07,
+	0x00, 0x1b, 0x40, 0x18, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x11, 0x12, 0x11, 0x03, 0x09, 0x19, 0x2b, 0x21, 0x01, 0x33,
+	0x01, 0x01, 0x33, 0x01, 0x23, 0x01, 0x94, 0xfe, 0x7f, 0xc8, 0x01, 0x27, 0x01, 0x44, 0xae, 0xfd,
+	0xc2, 0xcd, 0x04, 0x3e, 0xfc, 0xbf, 0x03, 0x41, 0xfa, 0x37, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4a,
+	0x00, 0x00, 0x03, 0xb6, 0x04, 0x3e, 0x00, 0x09, 0x00, 0x4f, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01,
+	0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x16, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09,
+	0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21, 0x15,
+	0x01, 0x21, 0x15, 0x4a, 0x02, 0x6d, 0xfd, 0xb2, 0x03, 0x41, 0xfd, 0x93, 0x02, 0x79, 0x94, 0x03,
+	0x16, 0x94, 0x94, 0xfc, 0xea, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x19, 0xfe, 0xd8, 0x02, 0x38,
+	0x06, 0x2b, 0x00, 0x2e, 0x00, 0x2f, 0x40, 0x2c, 0x17, 0x01, 0x05, 0x00, 0x01, 0x4c, 0x00, 0x00,
+	0x00, 0x05, 0x03, 0x00, 0x05, 0x69, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x3a, 0x02, 0x4e, 0x2e, 0x2c, 0x24, 0x23, 0x22, 0x20, 0x21, 0x18,
+	0x20, 0x06, 0x09, 0x19, 0x2b, 0x13, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x34, 0x36,
+	0x33, 0x15, 0x23, 0x22, 0x06, 0x15, 0x14, 0x17, 0x17, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14,
+	0x07, 0x07, 0x06, 0x15, 0x14, 0x16, 0x33, 0x33, 0x15, 0x22, 0x26, 0x35, 0x34, 0x37, 0x37, 0x36,
+	0x35, 0x34, 0x23, 0x23, 0x19, 0x3d, 0x99, 0x10, 0x13, 0x13, 0xd2, 0xad, 0x35, 0x44, 0x5a, 0x0d,
+	0x11, 0x0b, 0x93, 0x93, 0x0b, 0x11, 0x0d, 0x5b, 0x43, 0x35, 0xad, 0xd2, 0x13, 0x13, 0x10, 0x99,
+	0x3d, 0x02, 0xcc, 0xa1, 0x44, 0x48, 0x57, 0x56, 0x51, 0x8b, 0xa9, 0x94, 0x47, 0x36, 0x16, 0x48,
+	0x66, 0x42, 0x59, 0xbd, 0x7c, 0x7d, 0xbd, 0x59, 0x42, 0x66, 0x48, 0x17, 0x35, 0x47, 0x94, 0xaa,
+	0x8b, 0x51, 0x55, 0x57, 0x48, 0x46, 0xa0, 0x00, 0x00, 0x01, 0x00, 0xbb, 0xfe, 0xd8, 0x01, 0x59,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x00, 0x01, 0x86, 0x00, 0x00,
+	0x00, 0x3a, 0x00, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13,
+	0x11, 0x33, 0x11, 0xbb, 0x9e, 0xfe, 0xd8, 0x07, 0x53, 0xf8, 0xad, 0x00, 0x00, 0x01, 0x00, 0x74,
+	0xfe, 0xd8, 0x02, 0x93, 0x06, 0x2b, 0x00, 0x2e, 0x00, 0x2f, 0x40, 0x2c, 0x17, 0x01, 0x00, 0x05,
+	0x01, 0x4c, 0x00, 0x05, 0x00, 0x00, 0x02, 0x05, 0x00, 0x69, 0x00, 0x02, 0x00, 0x01, 0x02, 0x01,
+	0x65, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x3a, 0x03, 0x4e, 0x2e, 0x2c, 0x24, 0x23,
+	0x22, 0x20, 0x21, 0x18, 0x20, 0x06, 0x09, 0x19, 0x2b, 0x01, 0x23, 0x22, 0x15, 0x14, 0x17, 0x17,
+	0x16, 0x15, 0x14, 0x06, 0x23, 0x35, 0x33, 0x32, 0x36, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x34,
+	0x37, 0x26, 0x35, 0x34, 0x37, 0x37, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x32, 0x16, 0x15,
+	0x14, 0x07, 0x07, 0x06, 0x15, 0x14, 0x33, 0x33, 0x02, 0x93, 0x3e, 0x98, 0x0f, 0x14, 0x13, 0xd3,
+	0xac, 0x34, 0x44, 0x5a, 0x0c, 0x12, 0x0b, 0x93, 0x93, 0x0b, 0x12, 0x0c, 0x5b, 0x43, 0x34, 0xac,
+	0xd3, 0x13, 0x14, 0x0f, 0x98, 0x3e, 0x02, 0x38, 0xa2, 0x44, 0x48, 0x57, 0x55, 0x52, 0x8b, 0xa9,
+	0x94, 0x47, 0x36, 0x16, 0x48, 0x66, 0x43, 0x58, 0xbd, 0x7d, 0x7c, 0xbd, 0x59, 0x42, 0x66, 0x48,
+	0x18, 0x34, 0x47, 0x94, 0xa9, 0x8c, 0x50, 0x56, 0x57, 0x48, 0x45, 0xa0, 0x00, 0x01, 0x00, 0x68,
+	0x01, 0x9c, 0x04, 0x43, 0x03, 0x04, 0x00, 0x15, 0x00, 0x6d, 0xb1, 0x06, 0x64, 0x44, 0x4b, 0xb0,
+	0x0e, 0x50, 0x58, 0x40, 0x26, 0x00, 0x03, 0x01, 0x05, 0x02, 0x03, 0x72, 0x00, 0x00, 0x02, 0x04,
+	0x05, 0x00, 0x72, 0x00, 0x01, 0x00, 0x05, 0x02, 0x01, 0x05, 0x69, 0x00, 0x02, 0x00, 0x04, 0x02,
+	0x59, 0x00, 0x02, 0x02, 0x04, 0x62, 0x00, 0x04, 0x02, 0x04, 0x52, 0x1b, 0x40, 0x28, 0x00, 0x03,
+	0x01, 0x05, 0x01, 0x03, 0x05, 0x80, 0x00, 0x00, 0x02, 0x04, 0x02, 0x00, 0x04, 0x80, 0x00, 0x01,
+	0x00, 0x05, 0x02, 0x01, 0x05, 0x69, 0x00, 0x02, 0x00, 0x04, 0x02, 0x59, 0x00, 0x02, 0x02, 0x04,
+	0x62, 0x00, 0x04, 0x02, 0x04, 0x52, 0x59, 0x40, 0x09, 0x24, 0x21, 0x11, 0x24, 0x21, 0x10, 0x06,
+	0x09, 0x1c, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x23, 0x10, 0x21, 0x32, 0x1f, 0x02, 0x16, 0x33,
+	0x32, 0x35, 0x33, 0x10, 0x21, 0x22, 0x2f, 0x02, 0x26, 0x23, 0x22, 0xfc, 0x94, 0x01, 0x0f, 0x5e,
+	0x64, 0x70, 0x42, 0x22, 0x2b, 0x77, 0x94, 0xfe, 0xf2, 0x5e, 0x64, 0x70, 0x43, 0x21, 0x2b, 0x78,
+	0x01, 0xbc, 0x01, 0x48, 0x45, 0x4d, 0x2e, 0x14, 0xb4, 0xfe, 0xb8, 0x45, 0x4d, 0x2e, 0x14, 0x00,
+	0x00, 0x02, 0x00, 0xf2, 0xfe, 0x75, 0x01, 0xb7, 0x04, 0x3e, 0x00, 0x03, 0x00, 0x09, 0x00, 0x2c,
+	0x40, 0x29, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x03,
+	0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x09, 0x04,
+	0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x15, 0x23, 0x35,
+	0x13, 0x13, 0x11, 0x23, 0x11, 0x13, 0x01, 0xb7, 0xc5, 0xad, 0x18, 0xc5, 0x19, 0x04, 0x3e, 0xc6,
+	0xc6, 0xfe, 0x75, 0xfc, 0xea, 0xfe, 0xd8, 0x01, 0x28, 0x03, 0x16, 0x00, 0x00, 0x02, 0x00, 0xad,
+	0x00, 0x00, 0x03, 0xf6, 0x05, 0xc8, 0x00, 0x16, 0x00, 0x1b, 0x00, 0x6d, 0x40, 0x18, 0x07, 0x01,
+	0x01, 0x00, 0x1b, 0x17, 0x12, 0x0f, 0x0d, 0x0c, 0x06, 0x02, 0x01, 0x13, 0x01, 0x03, 0x02, 0x03,
+	0x4c, 0x01, 0x01, 0x03, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x01, 0x00,
+	0x02, 0x00, 0x01, 0x02, 0x80, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x6a, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x02,
+	0x00, 0x01, 0x02, 0x80, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x6a, 0x00, 0x00, 0x00, 0x04,
+	0x5f, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x16, 0x00,
+	0x16, 0x13, 0x15, 0x11, 0x18, 0x06, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x26, 0x02, 0x35, 0x34, 0x12,
+	0x37, 0x35, 0x33, 0x15, 0x16, 0x17, 0x15, 0x26, 0x27, 0x11, 0x32, 0x37, 0x15, 0x06, 0x23, 0x15,
+	0x03, 0x06, 0x11, 0x10, 0x17, 0x02, 0x77, 0xcb, 0xff, 0xf0, 0xda, 0x63, 0x85, 0x97, 0xb1, 0x6b,
+	0x88, 0x94, 0x95, 0x87, 0x63, 0xf2, 0xf2, 0xad, 0x14, 0x01, 0x3a, 0xe7, 0xec, 0x01, 0x24, 0x1d,
+	0xb9, 0xb9, 0x06, 0x28, 0xa6, 0x3c, 0x0a, 0xfc, 0xb8, 0x43, 0x95, 0x3a, 0xad, 0x04, 0x78, 0x16,
+	0xfe, 0x7a, 0xfe, 0xb6, 0x4e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x79, 0x00, 0x00, 0x03, 0xc6,
+	0x05, 0xed, 0x00, 0x1c, 0x00, 0x6d, 0x40, 0x0f, 0x0d, 0x01, 0x03, 0x02, 0x0e, 0x01, 0x01, 0x03,
+	0x02, 0x4c, 0x01, 0x01, 0x06, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x04, 0x01,
+	0x01, 0x05, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x3e, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40,
+	0x1e, 0x00, 0x02, 0x00, 0x03, 0x01, 0x02, 0x03, 0x69, 0x04, 0x01, 0x01, 0x05, 0x01, 0x00, 0x06,
+	0x01, 0x00, 0x67, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59,
+	0x40, 0x10, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x1c, 0x14, 0x11, 0x12, 0x23, 0x23, 0x11, 0x14, 0x09,
+	0x09, 0x1d, 0x2b, 0x33, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x35, 0x34, 0x36, 0x33, 0x32,
+	0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x11, 0x33, 0x15, 0x23, 0x15, 0x14, 0x06, 0x07, 0x21, 0x15,
+	0x79, 0xd2, 0xb3, 0xb3, 0xca, 0xbf, 0x69, 0x7a, 0x7b, 0x74, 0xb8, 0xd8, 0xd8, 0x48, 0x65, 0x02,
+	0x63, 0xad, 0x43, 0xf9, 0xe3, 0x94, 0xd7, 0xd5, 0xe1, 0x1e, 0xa7, 0x31, 0xe6, 0xfe, 0xed, 0x94,
+	0x7f, 0x9e, 0xae, 0x54, 0xad, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x7a, 0x01, 0x25, 0x03, 0xf8,
+	0x04, 0xa4, 0x00, 0x1b, 0x00, 0x27, 0x00, 0x43, 0x40, 0x40, 0x0e, 0x0a, 0x02, 0x03, 0x00, 0x15,
+	0x11, 0x07, 0x03, 0x04, 0x02, 0x03, 0x18, 0x01, 0x01, 0x02, 0x03, 0x4c, 0x10, 0x0f, 0x09, 0x08,
+	0x04, 0x00, 0x4a, 0x17, 0x16, 0x02, 0x01, 0x04, 0x01, 0x49, 0x04, 0x01, 0x02, 0x00, 0x01, 0x02,
+	0x01, 0x65, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3b, 0x03, 0x4e, 0x1d, 0x1c, 0x23,
+	0x21, 0x1c, 0x27, 0x1d, 0x27, 0x2c, 0x2b, 0x05, 0x09, 0x18, 0x2b, 0x01, 0x07, 0x27, 0x37, 0x26,
+	0x35, 0x34, 0x37, 0x27, 0x37, 0x17, 0x36, 0x33, 0x32, 0x17, 0x37, 0x17, 0x07, 0x16, 0x15, 0x14,
+	0x07, 0x17, 0x07, 0x27, 0x06, 0x23, 0x22, 0x37, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06,
+	0x15, 0x14, 0x16, 0x01, 0x6d, 0x9c, 0x57, 0x9b, 0x3f, 0x40, 0x9c, 0x57, 0x9c, 0x5e, 0x6e, 0x6e,
+	0x5e, 0x9c, 0x57, 0x9c, 0x40, 0x3f, 0x9b, 0x57, 0x9c, 0x5f, 0x6d, 0x6d, 0x6a, 0x64, 0x87, 0x86,
+	0x62, 0x62, 0x86, 0x85, 0x01, 0xc1, 0x9c, 0x57, 0x9c, 0x64, 0x68, 0x68, 0x64, 0x9c, 0x58, 0x9c,
+	0x3f, 0x3f, 0x9c, 0x58, 0x9c, 0x64, 0x68, 0x68, 0x64, 0x9c, 0x57, 0x9c, 0x40, 0x7b, 0x86, 0x63,
+	0x61, 0x86, 0x86, 0x62, 0x61, 0x87, 0x00, 0x00, 0x00, 0x01, 0x00, 0x19, 0x00, 0x00, 0x04, 0x35,
+	0x05, 0xc8, 0x00, 0x17, 0x00, 0x6b, 0xb5, 0x0b, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x21, 0x06, 0x01, 0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x08, 0x01,
+	0x01, 0x09, 0x01, 0x00, 0x0a, 0x01, 0x00, 0x67, 0x05, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0b, 0x01,
+	0x0a, 0x0a, 0x39, 0x0a, 0x4e, 0x1b, 0x40, 0x21, 0x05, 0x01, 0x04, 0x03, 0x04, 0x85, 0x06, 0x01,
+	0x03, 0x07, 0x01, 0x02, 0x01, 0x03, 0x02, 0x68, 0x08, 0x01, 0x01, 0x09, 0x01, 0x00, 0x0a, 0x01,
+	0x00, 0x67, 0x0b, 0x01, 0x0a, 0x0a, 0x3c, 0x0a, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x00, 0x17,
+	0x00, 0x17, 0x16, 0x15, 0x11, 0x11, 0x11, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x1f,
+	0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x35, 0x21, 0x35, 0x21, 0x01, 0x33, 0x01, 0x33, 0x01, 0x33,
+	0x01, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x01, 0xc5, 0xfe, 0xd8, 0x01, 0x28, 0xfe,
+	0xd8, 0x01, 0x28, 0xfe, 0x54, 0xe4, 0x01, 0x42, 0x02, 0x01, 0x43, 0xb1, 0xfe, 0x55, 0x01, 0x28,
+	0xfe, 0xd8, 0x01, 0x28, 0xfe, 0xd8, 0x01, 0x59, 0x72, 0xa8, 0x71, 0x02, 0xe4, 0xfd, 0xd2, 0x02,
+	0x2e, 0xfd, 0x1c, 0x71, 0xa8, 0x72, 0xfe, 0xa7, 0x00, 0x02, 0x00, 0xc0, 0xfe, 0xd8, 0x01, 0x54,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x07, 0x00, 0x29, 0x40, 0x26, 0x00, 0x00, 0x04, 0x01, 0x01, 0x00,
+	0x01, 0x63, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x3a, 0x03, 0x4e, 0x04, 0x04,
+	0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17,
+	0x2b, 0x13, 0x11, 0x33, 0x11, 0x03, 0x11, 0x33, 0x11, 0xc0, 0x94, 0x94, 0x94, 0xfe, 0xd8, 0x02,
+	0xe4, 0xfd, 0x1c, 0x04, 0x6f, 0x02, 0xe4, 0xfd, 0x1c, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x81,
+	0xfe, 0xb2, 0x03, 0xf2, 0x05, 0xed, 0x00, 0x29, 0x00, 0x34, 0x00, 0x52, 0x40, 0x12, 0x15, 0x01,
+	0x02, 0x01, 0x30, 0x23, 0x16, 0x0e, 0x01, 0x05, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x01, 0x00, 0x02,
+	0x00, 0x01, 0x02, 0x69, 0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x00, 0x03, 0x51, 0x59, 0xb6, 0x2e, 0x23, 0x2e, 0x22, 0x04, 0x09, 0x1a, 0x2b, 0x13, 0x35,
+	0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x27, 0x27, 0x24, 0x35, 0x34, 0x37, 0x26, 0x35, 0x34,
+	0x24, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x17, 0x17, 0x16, 0x16, 0x15,
+	0x14, 0x07, 0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x01, 0x36, 0x35, 0x34, 0x26, 0x27, 0x27, 0x06,
+	0x15, 0x14, 0x17, 0x81, 0xf4, 0xa5, 0x82, 0xa2, 0x56, 0x90, 0xb1, 0xfe, 0xdf, 0x92, 0x8d, 0x01,
+	0x03, 0xd3, 0x96, 0xc3, 0xc9, 0x91, 0x82, 0xa0, 0xaf, 0x88, 0xcf, 0x9f, 0x95, 0xa4, 0xfe, 0xf5,
+	0xe3, 0x99, 0x01, 0x81, 0x4a, 0x5f, 0x7d, 0xde, 0x4a, 0xe6, 0xfe, 0xfc, 0xb4, 0x69, 0x64, 0x50,
+	0x43, 0x4d, 0x3e, 0x4c, 0x7d, 0xd3, 0x97, 0x94, 0x5e, 0x92, 0xa5, 0xc8, 0x2f, 0xa0, 0x3b, 0x66,
+	0x53, 0x6c, 0x46, 0x37, 0x53, 0x9e, 0x7d, 0x8e, 0xa6, 0x5f, 0xad, 0x9d, 0xba, 0x02, 0xa3, 0x63,
+	0x5f, 0x48, 0x5d, 0x35, 0x5d, 0x5a, 0x5f, 0x85, 0x61, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x39,
+	0x05, 0x03, 0x02, 0x71, 0x05, 0xb0, 0x00, 0x03, 0x00, 0x07, 0x00, 0x32, 0xb1, 0x06, 0x64, 0x44,
+	0x40, 0x27, 0x02, 0x01, 0x00, 0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x05,
+	0x03, 0x04, 0x03, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x35,
+	0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x39, 0xad, 0xde, 0xad, 0x05, 0x03, 0xad, 0xad, 0xad, 0xad,
+	0x00, 0x03, 0x00, 0x0f, 0x00, 0x00, 0x05, 0xd7, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2e,
+	0x00, 0x60, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x55, 0x23, 0x01, 0x06, 0x05, 0x2e, 0x24, 0x02, 0x07,
+	0x06, 0x18, 0x01, 0x04, 0x07, 0x03, 0x4c, 0x00, 0x01, 0x00, 0x03, 0x05, 0x01, 0x03, 0x69, 0x00,
+	0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x69, 0x00, 0x07, 0x00, 0x04, 0x02, 0x07, 0x04, 0x69, 0x09,
+	0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x02,
+	0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x2d, 0x2b, 0x27, 0x25, 0x21, 0x1f, 0x1b, 0x19, 0x13, 0x11,
+	0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x20,
+	0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x01, 0x06, 0x23, 0x22, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x17, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x02, 0xea, 0xfe, 0xd5, 0xfe, 0x50, 0x01, 0xb2, 0x01, 0x32, 0x01, 0x32, 0x01, 0xb2, 0xfe,
+	0x4d, 0xfe, 0xc6, 0x01, 0x0d, 0x01, 0x77, 0xfe, 0x8b, 0xfe, 0xfa, 0xfe, 0xfa, 0xfe, 0x8c, 0x01,
+	0x72, 0x02, 0x16, 0x87, 0x6b, 0xb5, 0xe5, 0xe0, 0xbc, 0x59, 0x7f, 0x18, 0x7f, 0x69, 0x7d, 0x9a,
+	0x9f, 0x89, 0x6c, 0x6b, 0x01, 0xb5, 0x01, 0x2f, 0x01, 0x33, 0x01, 0xb1, 0xfe, 0x4f, 0xfe, 0xcf,
+	0xfe, 0xc9, 0xfe, 0x51, 0x6a, 0x01, 0x72, 0x01, 0x09, 0x01, 0x05, 0x01, 0x75, 0xfe, 0x8b, 0xfe,
+	0xfa, 0xfe, 0xfd, 0xfe, 0x89, 0x01, 0x02, 0x2f, 0xea, 0xb8, 0xc1, 0xe5, 0x18, 0x05, 0x76, 0x35,
+	0xb2, 0x92, 0x92, 0xaa, 0x3b, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x56, 0x03, 0x36, 0x02, 0xc8,
+	0x05, 0xee, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x92, 0x4b, 0xb0, 0x31, 0x50, 0x58, 0x40, 0x12, 0x0e,
+	0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04, 0x06, 0x18, 0x01, 0x00, 0x04, 0x04,
+	0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x07, 0x06,
+	0x18, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x31, 0x50, 0x58, 0x40, 0x23, 0x00, 0x03,
+	0x00, 0x02, 0x01, 0x03, 0x02, 0x69, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x07, 0x01,
+	0x04, 0x00, 0x00, 0x04, 0x59, 0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x04, 0x00,
+	0x51, 0x1b, 0x40, 0x27, 0x00, 0x03, 0x00, 0x02, 0x01, 0x03, 0x02, 0x69, 0x00, 0x01, 0x00, 0x06,
+	0x07, 0x01, 0x06, 0x69, 0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x00, 0x04, 0x00, 0x00, 0x04, 0x59,
+	0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x04, 0x00, 0x51, 0x59, 0x40, 0x0b, 0x22, 0x23,
+	0x24, 0x13, 0x23, 0x22, 0x23, 0x21, 0x08, 0x0b, 0x1e, 0x2b, 0x01, 0x06, 0x23, 0x22, 0x26, 0x35,
+	0x34, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x15, 0x11, 0x14, 0x33,
+	0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x27, 0x27, 0x35, 0x23, 0x22, 0x15, 0x14, 0x33, 0x32, 0x01,
+	0xe5, 0x64, 0x67, 0x56, 0x6e, 0x01, 0x56, 0x30, 0x77, 0x67, 0x6d, 0x7b, 0x73, 0xf2, 0x39, 0x09,
+	0x0f, 0x06, 0x31, 0x2f, 0x65, 0x19, 0x0e, 0x26, 0xcc, 0x62, 0x45, 0x03, 0x93, 0x5d, 0x6a, 0x51,
+	0xe4, 0x46, 0x6e, 0x3b, 0x6f, 0x31, 0xcf, 0xfe, 0xd6, 0x5b, 0x02, 0x53, 0x13, 0x5d, 0x51, 0x9a,
+	0x79, 0x61, 0x00, 0x00, 0x00, 0x02, 0x00, 0x73, 0x00, 0x63, 0x03, 0xeb, 0x03, 0xdb, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x08, 0xb5, 0x0b, 0x09, 0x05, 0x03, 0x02, 0x32, 0x2b, 0x09, 0x02, 0x07, 0x01,
+	0x01, 0x05, 0x01, 0x01, 0x07, 0x01, 0x01, 0x03, 0xeb, 0xfe, 0xd8, 0x01, 0x28, 0x62, 0xfe, 0x75,
+	0x01, 0x8b, 0xfe, 0xd7, 0xfe, 0xd8, 0x01, 0x28, 0x62, 0xfe, 0x75, 0x01, 0x8b, 0x03, 0x91, 0xfe,
+	0x8e, 0xfe, 0x8e, 0x4a, 0x01, 0xbc, 0x01, 0xbc, 0x4a, 0xfe, 0x8e, 0xfe, 0x8e, 0x4a, 0x01, 0xbc,
+	0x01, 0xbc, 0x00, 0x00, 0x00, 0x01, 0x00, 0x56, 0x01, 0x28, 0x04, 0x31, 0x03, 0x78, 0x00, 0x05,
+	0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00,
+	0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05,
+	0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0x13, 0x35, 0x21, 0x11, 0x23, 0x11, 0x56, 0x03, 0xdb, 0x94,
+	0x02, 0xe4, 0x94, 0xfd, 0xb0, 0x01, 0xbc, 0x00, 0x00, 0x01, 0x00, 0x58, 0x02, 0x06, 0x02, 0x52,
+	0x02, 0x9a, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x58, 0x01, 0xfa, 0x02, 0x06, 0x94, 0x94, 0x00,
+	0x00, 0x04, 0x00, 0x0f, 0x00, 0x00, 0x05, 0xd7, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x2a, 0x00, 0x69, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x5e, 0x1e, 0x01, 0x06, 0x08, 0x01, 0x4c,
+	0x0c, 0x07, 0x02, 0x05, 0x06, 0x02, 0x06, 0x05, 0x02, 0x80, 0x00, 0x01, 0x00, 0x03, 0x04, 0x01,
+	0x03, 0x69, 0x00, 0x04, 0x00, 0x09, 0x08, 0x04, 0x09, 0x69, 0x00, 0x08, 0x00, 0x06, 0x05, 0x08,
+	0x06, 0x67, 0x0b, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x2a, 0x28, 0x26, 0x24, 0x18,
+	0x23, 0x18, 0x23, 0x22, 0x21, 0x20, 0x1f, 0x1b, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0d, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x20, 0x00,
+	0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x37, 0x11, 0x33, 0x32, 0x15, 0x14, 0x07, 0x13, 0x23, 0x03, 0x23,
+	0x11, 0x03, 0x33, 0x32, 0x35, 0x34, 0x23, 0x23, 0x02, 0xea, 0xfe, 0xd5, 0xfe, 0x50, 0x01, 0xb2,
+	0x01, 0x32, 0x01, 0x32, 0x01, 0xb2, 0xfe, 0x4d, 0xfe, 0xc6, 0x01, 0x0d, 0x01, 0x77, 0xfe, 0x8b,
+	0xfe, 0xfa, 0xfe, 0xfa, 0xfe, 0x8c, 0x01, 0x72, 0x14, 0xfc, 0xf2, 0x8c, 0xf1, 0x95, 0xd8, 0x65,
+	0x08, 0x24, 0xd4, 0xb1, 0x47, 0x01, 0xb5, 0x01, 0x2f, 0x01, 0x33, 0x01, 0xb1, 0xfe, 0x4f, 0xfe,
+	0xcf, 0xfe, 0xc9, 0xfe, 0x51, 0x6a, 0x01, 0x72, 0x01, 0x09, 0x01, 0x05, 0x01, 0x75, 0xfe, 0x8b,
+	0xfe, 0xfa, 0xfe, 0xfd, 0xfe, 0x89, 0xe7, 0x03, 0x20, 0xc4, 0x90, 0x58, 0xfe, 0x8c, 0x01, 0x4e,
+	0xfe, 0xb2, 0x01, 0xb1, 0x9d, 0x80, 0x00, 0x00, 0x00, 0x01, 0x00, 0x63, 0x05, 0xb0, 0x04, 0x10,
+	0x06, 0x44, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x35, 0x21, 0x15,
+	0x63, 0x03, 0xad, 0x05, 0xb0, 0x94, 0x94, 0x00, 0x00, 0x02, 0x00, 0x72, 0x03, 0x9d, 0x02, 0xc2,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x39, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2e, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c,
+	0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00,
+	0x44, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x01, 0x96, 0x77, 0xad, 0xae, 0x7a, 0x7a,
+	0xae, 0xae, 0x7c, 0x49, 0x66, 0x66, 0x47, 0x47, 0x66, 0x65, 0x03, 0x9d, 0xaf, 0x79, 0x7b, 0xad,
+	0xad, 0x7a, 0x7c, 0xad, 0x7c, 0x64, 0x49, 0x47, 0x65, 0x65, 0x48, 0x46, 0x66, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x68, 0x00, 0x00, 0x04, 0x43, 0x04, 0xa0, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x62,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00,
+	0x67, 0x00, 0x02, 0x08, 0x01, 0x05, 0x06, 0x02, 0x05, 0x67, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x09,
+	0x01, 0x07, 0x07, 0x39, 0x07, 0x4e, 0x1b, 0x40, 0x1f, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05,
+	0x01, 0x00, 0x67, 0x00, 0x02, 0x08, 0x01, 0x05, 0x06, 0x02, 0x05, 0x67, 0x00, 0x06, 0x06, 0x07,
+	0x5f, 0x09, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c,
+	0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09,
+	0x1b, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x01, 0x35,
+	0x21, 0x15, 0x02, 0x0b, 0xfe, 0x5d, 0x01, 0xa3, 0x95, 0x01, 0xa3, 0xfe, 0x5d, 0xfd, 0xc8, 0x03,
+	0xdb, 0x01, 0x28, 0x01, 0x72, 0x94, 0x01, 0x72, 0xfe, 0x8e, 0x94, 0xfe, 0x8e, 0xfe, 0xd8, 0x94,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4c, 0x02, 0xb5, 0x02, 0xc1, 0x06, 0x43, 0x00, 0x19,
+	0x00, 0x34, 0x40, 0x31, 0x0c, 0x01, 0x00, 0x01, 0x0b, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x01, 0x01,
+	0x02, 0x01, 0x4b, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x56, 0x4d, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x55, 0x03, 0x4e, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x18,
+	0x23, 0x28, 0x05, 0x0b, 0x19, 0x2b, 0x13, 0x35, 0x36, 0x3f, 0x02, 0x36, 0x35, 0x34, 0x23, 0x22,
+	0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x15, 0x4c,
+	0x33, 0x7a, 0x51, 0x49, 0x91, 0xb6, 0x6a, 0x9c, 0x93, 0x89, 0x91, 0xac, 0x58, 0x7c, 0x34, 0x9c,
+	0x1e, 0x01, 0xbc, 0x02, 0xb5, 0x67, 0x60, 0x66, 0x42, 0x3c, 0x77, 0x71, 0xa3, 0x48, 0x68, 0x38,
+	0x87, 0x73, 0x4e, 0x78, 0x5a, 0x26, 0x71, 0x76, 0x67, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x72,
+	0x02, 0x9f, 0x02, 0xd4, 0x06, 0x43, 0x00, 0x21, 0x00, 0x3f, 0x40, 0x3c, 0x15, 0x01, 0x03, 0x04,
+	0x14, 0x01, 0x02, 0x03, 0x1b, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x05, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x56, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x57, 0x05, 0x4e, 0x27,
+	0x23, 0x23, 0x21, 0x23, 0x24, 0x06, 0x0b, 0x1c, 0x2b, 0x13, 0x35, 0x16, 0x17, 0x16, 0x33, 0x32,
+	0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x33, 0x32, 0x36, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36,
+	0x33, 0x20, 0x15, 0x14, 0x07, 0x04, 0x15, 0x14, 0x06, 0x23, 0x22, 0x72, 0x16, 0x0b, 0x7e, 0x43,
+	0xe2, 0x97, 0x8b, 0x3b, 0x33, 0x7f, 0x91, 0xb7, 0x5d, 0x87, 0x84, 0x66, 0x01, 0x44, 0xe7, 0x01,
+	0x0c, 0xc7, 0xa7, 0x55, 0x02, 0xbb, 0x6f, 0x08, 0x03, 0x28, 0xaf, 0x5c, 0x62, 0x50, 0x5f, 0x52,
+	0x85, 0x32, 0x67, 0x24, 0xcf, 0x9c, 0x42, 0x31, 0xba, 0x7b, 0x91, 0x00, 0x00, 0x01, 0x00, 0x6b,
+	0x05, 0x03, 0x02, 0x40, 0x06, 0x44, 0x00, 0x03, 0x00, 0x1f, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x14,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x13, 0x33, 0x01, 0x6b, 0xf1, 0xe4,
+	0xfe, 0xbf, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x01, 0x00, 0x95, 0xfe, 0x75, 0x03, 0xdf,
+	0x04, 0x3e, 0x00, 0x12, 0x00, 0x7a, 0x40, 0x0b, 0x0c, 0x07, 0x02, 0x01, 0x00, 0x10, 0x01, 0x03,
+	0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x17, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x3d, 0x05,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00,
+	0x03, 0x03, 0x39, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x00, 0x05,
+	0x05, 0x3d, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x02, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x3c, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x3d,
+	0x05, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x12, 0x22, 0x11, 0x12, 0x23, 0x10, 0x06, 0x09, 0x1c, 0x2b,
+	0x13, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x23, 0x35, 0x06, 0x23, 0x22,
+	0x27, 0x11, 0x23, 0x95, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0xc5, 0x98, 0xa8, 0x40, 0x40, 0xc5,
+	0x04, 0x3e, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0xcb, 0xde, 0x2c, 0xfe, 0x5c,
+	0x00, 0x01, 0x00, 0x64, 0xfe, 0xd8, 0x03, 0x7e, 0x05, 0xc8, 0x00, 0x0d, 0x00, 0x4a, 0xb5, 0x01,
+	0x01, 0x01, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x04, 0x03, 0x02, 0x01,
+	0x02, 0x01, 0x86, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x02, 0x4e, 0x1b, 0x40,
+	0x17, 0x04, 0x03, 0x02, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x02, 0x5f, 0x00, 0x02, 0x00, 0x02, 0x4f, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0d, 0x00,
+	0x0d, 0x11, 0x11, 0x26, 0x05, 0x09, 0x19, 0x2b, 0x01, 0x11, 0x26, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0xf3, 0xb1, 0xde, 0xc0, 0xe3, 0x01, 0x77, 0x71, 0xa8,
+	0xfe, 0xd8, 0x04, 0x0c, 0x0e, 0xda, 0xb6, 0xb1, 0x95, 0xf9, 0x10, 0x06, 0x75, 0xf9, 0x8b, 0x00,
+	0x00, 0x01, 0x00, 0x96, 0x03, 0x47, 0x01, 0x8d, 0x04, 0x3e, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16,
+	0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x3b, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x33, 0x15, 0x96, 0xf7, 0x03, 0x47, 0xf7,
+	0xf7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa8, 0xfe, 0x50, 0x02, 0x03, 0x00, 0x00, 0x00, 0x11,
+	0x00, 0x68, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0a, 0x0b, 0x01, 0x03, 0x04, 0x0a, 0x01, 0x02, 0x03,
+	0x02, 0x4c, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01, 0x72,
+	0x00, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x69, 0x00, 0x03, 0x02, 0x02, 0x03, 0x59, 0x00, 0x03,
+	0x03, 0x02, 0x61, 0x00, 0x02, 0x03, 0x02, 0x51, 0x1b, 0x40, 0x20, 0x00, 0x01, 0x00, 0x04, 0x00,
+	0x01, 0x04, 0x80, 0x00, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x69, 0x00, 0x03, 0x02, 0x02, 0x03,
+	0x59, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x03, 0x02, 0x51, 0x59, 0xb7, 0x12, 0x23, 0x24,
+	0x11, 0x10, 0x05, 0x09, 0x1b, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x33, 0x07, 0x32, 0x16, 0x15,
+	0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x01, 0x1c, 0x61, 0x3c,
+	0x4e, 0x74, 0x75, 0x54, 0x47, 0x4b, 0x2e, 0x3b, 0x67, 0xbb, 0x6d, 0x5f, 0x40, 0x45, 0x5f, 0x15,
+	0x51, 0x0f, 0x4a, 0x60, 0x00, 0x01, 0x00, 0x9d, 0x02, 0xb5, 0x03, 0x0c, 0x06, 0x43, 0x00, 0x09,
+	0x00, 0x22, 0x40, 0x1f, 0x06, 0x05, 0x04, 0x03, 0x04, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x02,
+	0x5f, 0x03, 0x01, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x15, 0x11,
+	0x04, 0x0b, 0x18, 0x2b, 0x13, 0x35, 0x33, 0x11, 0x07, 0x35, 0x25, 0x11, 0x33, 0x15, 0x9d, 0xed,
+	0xed, 0x01, 0x82, 0xed, 0x02, 0xb5, 0x58, 0x02, 0xbd, 0x2f, 0x5b, 0x4d, 0xfc, 0xca, 0x58, 0x00,
+	0x00, 0x02, 0x00, 0x4a, 0x03, 0x36, 0x02, 0xa1, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x31,
+	0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02,
+	0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01,
+	0x00, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x0b, 0x16,
+	0x2b, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x35,
+	0x34, 0x23, 0x22, 0x15, 0x14, 0x01, 0x72, 0x88, 0xa0, 0xa1, 0x8b, 0x8a, 0xa1, 0xa1, 0x8c, 0x90,
+	0x8e, 0x8f, 0x03, 0x36, 0xbd, 0x9f, 0xa0, 0xbb, 0xba, 0xa0, 0xa3, 0xba, 0x66, 0xf8, 0xf4, 0xf6,
+	0xf6, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x88, 0x00, 0x63, 0x04, 0x00, 0x03, 0xdb, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x08, 0xb5, 0x0b, 0x09, 0x05, 0x03, 0x02, 0x32, 0x2b, 0x37, 0x01, 0x01, 0x37,
+	0x01, 0x01, 0x25, 0x01, 0x01, 0x37, 0x01, 0x01, 0x88, 0x01, 0x28, 0xfe, 0xd8, 0x63, 0x01, 0x8a,
+	0xfe, 0x76, 0x01, 0x28, 0x01, 0x28, 0xfe, 0xd8, 0x62, 0x01, 0x8b, 0xfe, 0x75, 0xad, 0x01, 0x72,
+	0x01, 0x72, 0x4a, 0xfe, 0x44, 0xfe, 0x44, 0x4a, 0x01, 0x72, 0x01, 0x72, 0x4a, 0xfe, 0x44, 0xfe,
+	0x44, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x74, 0xff, 0xdb, 0x06, 0x30, 0x05, 0xed, 0x00, 0x05,
+	0x00, 0x10, 0x00, 0x13, 0x00, 0x17, 0x00, 0x6c, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x61, 0x04, 0x03,
+	0x02, 0x01, 0x04, 0x02, 0x07, 0x13, 0x01, 0x00, 0x02, 0x09, 0x01, 0x01, 0x03, 0x03, 0x4c, 0x00,
+	0x07, 0x02, 0x07, 0x85, 0x09, 0x01, 0x00, 0x02, 0x03, 0x02, 0x00, 0x03, 0x80, 0x0b, 0x01, 0x08,
+	0x05, 0x08, 0x86, 0x00, 0x02, 0x00, 0x05, 0x02, 0x57, 0x06, 0x01, 0x03, 0x04, 0x01, 0x01, 0x05,
+	0x03, 0x01, 0x68, 0x00, 0x02, 0x02, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x02, 0x05, 0x4f, 0x14, 0x14,
+	0x06, 0x06, 0x00, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x12, 0x11, 0x06, 0x10, 0x06, 0x10,
+	0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x0c, 0x09, 0x16, 0x2b,
+	0xb1, 0x06, 0x00, 0x44, 0x01, 0x11, 0x07, 0x35, 0x25, 0x11, 0x01, 0x35, 0x21, 0x35, 0x01, 0x33,
+	0x11, 0x33, 0x15, 0x23, 0x15, 0x01, 0x21, 0x11, 0x01, 0x01, 0x33, 0x01, 0x01, 0x39, 0xc5, 0x01,
+	0x59, 0x03, 0x60, 0xfe, 0x69, 0x01, 0x93, 0x8c, 0x7b, 0x7b, 0xfe, 0x62, 0x01, 0x16, 0xfb, 0x92,
+	0x04, 0x40, 0x87, 0xfb, 0xc0, 0x02, 0x50, 0x02, 0xf7, 0x31, 0x72, 0x57, 0xfc, 0x71, 0xfd, 0xb0,
+	0xf2, 0x71, 0x02, 0x15, 0xfd, 0xef, 0x75, 0xf2, 0x01, 0x67, 0x01, 0x6c, 0xfd, 0x08, 0x06, 0x12,
+	0xf9, 0xee, 0x00, 0x00, 0x00, 0x03, 0x00, 0x74, 0xff, 0xdb, 0x06, 0x30, 0x05, 0xed, 0x00, 0x17,
+	0x00, 0x1b, 0x00, 0x21, 0x00, 0x68, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x5d, 0x20, 0x1f, 0x1e, 0x1d,
+	0x04, 0x01, 0x04, 0x0b, 0x01, 0x00, 0x01, 0x0a, 0x01, 0x06, 0x00, 0x03, 0x4c, 0x01, 0x01, 0x02,
+	0x01, 0x4b, 0x00, 0x04, 0x01, 0x04, 0x85, 0x09, 0x01, 0x06, 0x00, 0x02, 0x00, 0x06, 0x02, 0x80,
+	0x08, 0x01, 0x05, 0x03, 0x05, 0x86, 0x00, 0x01, 0x00, 0x00, 0x06, 0x01, 0x00, 0x6a, 0x00, 0x02,
+	0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x02, 0x03, 0x4f, 0x1c,
+	0x1c, 0x18, 0x18, 0x00, 0x00, 0x1c, 0x21, 0x1c, 0x21, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x00,
+	0x17, 0x00, 0x17, 0x17, 0x23, 0x27, 0x0a, 0x09, 0x19, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x35,
+	0x36, 0x37, 0x37, 0x36, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x07, 0x07, 0x06, 0x07, 0x21, 0x15, 0x05, 0x01, 0x33, 0x01, 0x13, 0x11, 0x07, 0x35, 0x25, 0x11,
+	0x03, 0xfb, 0x40, 0x76, 0x46, 0x9c, 0xa3, 0x5f, 0x80, 0x7f, 0x7d, 0x83, 0xa0, 0xb8, 0x35, 0x85,
+	0x17, 0x01, 0x85, 0xfa, 0x60, 0x04, 0x40, 0x88, 0xfb, 0xc0, 0x25, 0xc5, 0x01, 0x59, 0x7a, 0x71,
+	0x6a, 0x3e, 0x8a, 0x77, 0x95, 0x45, 0x75, 0x35, 0x87, 0x6f, 0x8b, 0x97, 0x2b, 0x6d, 0x64, 0x7a,
+	0x25, 0x06, 0x12, 0xf9, 0xee, 0x02, 0x75, 0x02, 0xf7, 0x31, 0x72, 0x57, 0xfc, 0x71, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x6f, 0xff, 0xdb, 0x06, 0x3d, 0x05, 0xed, 0x00, 0x1d, 0x00, 0x28, 0x00, 0x2b,
+	0x00, 0x2f, 0x00, 0xc9, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x01, 0x01, 0x05, 0x00, 0x00, 0x01,
+	0x04, 0x05, 0x07, 0x01, 0x03, 0x04, 0x2b, 0x0f, 0x02, 0x02, 0x07, 0x0e, 0x01, 0x01, 0x02, 0x21,
+	0x01, 0x06, 0x08, 0x06, 0x4c, 0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40, 0x3a, 0x0f, 0x01, 0x0d, 0x0a,
+	0x0d, 0x86, 0x0c, 0x01, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x69, 0x00, 0x04, 0x00, 0x03, 0x07,
+	0x04, 0x03, 0x69, 0x00, 0x07, 0x02, 0x0a, 0x07, 0x57, 0x00, 0x02, 0x00, 0x01, 0x08, 0x02, 0x01,
+	0x69, 0x0b, 0x01, 0x08, 0x09, 0x01, 0x06, 0x0a, 0x08, 0x06, 0x68, 0x00, 0x07, 0x07, 0x0a, 0x5f,
+	0x0e, 0x01, 0x0a, 0x07, 0x0a, 0x4f, 0x1b, 0x40, 0x3e, 0x00, 0x0c, 0x00, 0x0c, 0x85, 0x0f, 0x01,
+	0x0d, 0x0a, 0x0d, 0x86, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x69, 0x00, 0x04, 0x00, 0x03,
+	0x07, 0x04, 0x03, 0x69, 0x00, 0x07, 0x02, 0x0a, 0x07, 0x57, 0x00, 0x02, 0x00, 0x01, 0x08, 0x02,
+	0x01, 0x69, 0x0b, 0x01, 0x08, 0x09, 0x01, 0x06, 0x0a, 0x08, 0x06, 0x68, 0x00, 0x07, 0x07, 0x0a,
+	0x5f, 0x0e, 0x01, 0x0a, 0x07, 0x0a, 0x4f, 0x59, 0x40, 0x1e, 0x2c, 0x2c, 0x1e, 0x1e, 0x2c, 0x2f,
+	0x2c, 0x2f, 0x2e, 0x2d, 0x2a, 0x29, 0x1e, 0x28, 0x1e, 0x28, 0x27, 0x26, 0x11, 0x12, 0x12, 0x22,
+	0x21, 0x22, 0x23, 0x27, 0x22, 0x10, 0x09, 0x1f, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x35, 0x36,
+	0x33, 0x20, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32,
+	0x35, 0x34, 0x21, 0x23, 0x35, 0x33, 0x32, 0x35, 0x34, 0x23, 0x22, 0x01, 0x35, 0x21, 0x35, 0x01,
+	0x33, 0x11, 0x33, 0x15, 0x23, 0x15, 0x01, 0x21, 0x11, 0x01, 0x01, 0x33, 0x01, 0x7b, 0x6e, 0x74,
+	0x01, 0x1a, 0xbf, 0xdc, 0xad, 0x96, 0x6b, 0x77, 0x83, 0x4e, 0xb8, 0xfe, 0xfc, 0x33, 0x2c, 0xf4,
+	0x9c, 0x5c, 0x04, 0x51, 0xfe, 0x69, 0x01, 0x93, 0x8b, 0x7c, 0x7c, 0xfe, 0x63, 0x01, 0x16, 0xfb,
+	0xe2, 0x04, 0x40, 0x87, 0xfb, 0xc0, 0x05, 0x49, 0x70, 0x26, 0xd2, 0x9d, 0x41, 0x32, 0xbc, 0x7a,
+	0x8d, 0x1d, 0x7a, 0x33, 0xa4, 0xb5, 0x5d, 0xa6, 0x81, 0xfa, 0x85, 0xf2, 0x71, 0x02, 0x15, 0xfd,
+	0xef, 0x75, 0xf2, 0x01, 0x67, 0x01, 0x6c, 0xfd, 0x08, 0x06, 0x12, 0xf9, 0xee, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xb9, 0xfe, 0x50, 0x04, 0x25, 0x04, 0x3e, 0x00, 0x03, 0x00, 0x1a, 0x00, 0x40,
+	0x40, 0x3d, 0x0e, 0x01, 0x02, 0x04, 0x0f, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x06, 0x01, 0x04, 0x00,
+	0x02, 0x00, 0x04, 0x02, 0x80, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x03, 0x62, 0x00, 0x03, 0x03, 0x43, 0x03, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04,
+	0x1a, 0x04, 0x1a, 0x12, 0x10, 0x0d, 0x0b, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b,
+	0x01, 0x15, 0x23, 0x35, 0x13, 0x15, 0x14, 0x07, 0x07, 0x06, 0x15, 0x14, 0x21, 0x32, 0x37, 0x15,
+	0x06, 0x23, 0x20, 0x11, 0x34, 0x37, 0x37, 0x36, 0x36, 0x35, 0x35, 0x03, 0x37, 0xc5, 0xc5, 0xa3,
+	0x59, 0xb0, 0x01, 0x13, 0xae, 0xd9, 0xd3, 0xc3, 0xfe, 0x2a, 0xbf, 0x51, 0x63, 0x46, 0x04, 0x3e,
+	0xc6, 0xc6, 0xfe, 0x75, 0x37, 0xf4, 0x80, 0x45, 0x89, 0x90, 0xc6, 0x4b, 0xa7, 0x38, 0x01, 0x5b,
+	0xb4, 0x78, 0x32, 0x3d, 0x83, 0x7b, 0x6f, 0x00, 0x00, 0x03, 0x00, 0x13, 0x00, 0x00, 0x05, 0x3e,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x65, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00,
+	0x05, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00,
+	0x05, 0x00, 0x05, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02,
+	0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0e, 0x0d,
+	0x0c, 0x0b, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33,
+	0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x13, 0x23, 0x01, 0x33, 0x13, 0x02,
+	0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0xa6, 0x94, 0xfe,
+	0xbf, 0xe4, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0x9e,
+	0x01, 0x41, 0x00, 0x00, 0x00, 0x03, 0x00, 0x13, 0x00, 0x00, 0x05, 0x3e, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6b, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x00,
+	0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00,
+	0x06, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07,
+	0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e,
+	0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19,
+	0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x03, 0x13, 0x33, 0x01,
+	0x13, 0x02, 0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0x82,
+	0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a,
+	0x01, 0x9e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x03, 0x00, 0x13, 0x00, 0x00, 0x05, 0x3e,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x12, 0x00, 0x74, 0x40, 0x0a, 0x10, 0x01, 0x06, 0x05,
+	0x0a, 0x01, 0x04, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x06,
+	0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02,
+	0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40,
+	0x21, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x04,
+	0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x40, 0x18, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x12, 0x0b, 0x12, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01,
+	0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x01, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23,
+	0x07, 0x13, 0x02, 0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed,
+	0xfe, 0xb4, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe,
+	0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0x9e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x13, 0x00, 0x00, 0x05, 0x3e, 0x07, 0x4c, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x1e,
+	0x00, 0x86, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28,
+	0x07, 0x01, 0x05, 0x00, 0x09, 0x08, 0x05, 0x09, 0x69, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x00,
+	0x06, 0x08, 0x6a, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x0b, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x00, 0x08, 0x04, 0x08,
+	0x00, 0x04, 0x80, 0x07, 0x01, 0x05, 0x00, 0x09, 0x08, 0x05, 0x09, 0x69, 0x00, 0x06, 0x0c, 0x0a,
+	0x02, 0x08, 0x00, 0x06, 0x08, 0x6a, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x0b, 0x03,
+	0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1e, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x1e, 0x0b,
+	0x1e, 0x1d, 0x1b, 0x18, 0x16, 0x15, 0x14, 0x13, 0x11, 0x0e, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03,
+	0x13, 0x21, 0x03, 0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23,
+	0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x13, 0x02, 0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd,
+	0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0xfe, 0xc5, 0x0c, 0xad, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44,
+	0x09, 0x7b, 0x0b, 0xae, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x05, 0xc8, 0xfa, 0x38, 0x01,
+	0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0xb2, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27,
+	0x25, 0x22, 0x6e, 0x00, 0x00, 0x04, 0x00, 0x13, 0x00, 0x00, 0x05, 0x3e, 0x07, 0x0f, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x0e, 0x00, 0x12, 0x00, 0x78, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05,
+	0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09,
+	0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x00, 0x06, 0x04, 0x06, 0x00,
+	0x04, 0x80, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40,
+	0x1e, 0x0f, 0x0f, 0x0b, 0x0b, 0x00, 0x00, 0x0f, 0x12, 0x0f, 0x12, 0x11, 0x10, 0x0b, 0x0e, 0x0b,
+	0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0c, 0x09, 0x19, 0x2b,
+	0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x01, 0x35, 0x33, 0x15, 0x33,
+	0x35, 0x33, 0x15, 0x13, 0x02, 0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6, 0x01,
+	0xdc, 0xed, 0xfe, 0xf6, 0xad, 0xde, 0xad, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02,
+	0x36, 0x02, 0x7a, 0x01, 0xb2, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x13,
+	0x00, 0x00, 0x05, 0x3e, 0x07, 0x8f, 0x00, 0x16, 0x00, 0x19, 0x00, 0x25, 0x00, 0x78, 0xb5, 0x19,
+	0x01, 0x06, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x01, 0x00, 0x08,
+	0x07, 0x01, 0x08, 0x69, 0x00, 0x06, 0x00, 0x04, 0x03, 0x06, 0x04, 0x68, 0x0a, 0x01, 0x07, 0x07,
+	0x3a, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x05, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x27, 0x02, 0x01, 0x00, 0x07, 0x06, 0x07, 0x00, 0x06, 0x80, 0x00, 0x01, 0x00, 0x08,
+	0x07, 0x01, 0x08, 0x69, 0x00, 0x06, 0x00, 0x04, 0x03, 0x06, 0x04, 0x68, 0x0a, 0x01, 0x07, 0x07,
+	0x3a, 0x4d, 0x09, 0x05, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x18, 0x1b, 0x1a, 0x00,
+	0x00, 0x21, 0x1f, 0x1a, 0x25, 0x1b, 0x25, 0x18, 0x17, 0x00, 0x16, 0x00, 0x16, 0x11, 0x11, 0x16,
+	0x26, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x01, 0x33, 0x26, 0x27, 0x26, 0x35, 0x34, 0x36, 0x33,
+	0x32, 0x16, 0x15, 0x14, 0x07, 0x06, 0x07, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03,
+	0x13, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x13, 0x02, 0x32, 0x51,
+	0x4e, 0x39, 0x42, 0x84, 0x5f, 0x5e, 0x85, 0x43, 0x3c, 0x54, 0x55, 0x02, 0x29, 0xe2, 0x9a, 0xfd,
+	0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0x13, 0x3c, 0x52, 0x52, 0x3a, 0x3b, 0x51, 0x51, 0x05, 0xc8,
+	0x08, 0x3b, 0x43, 0x5f, 0x5d, 0x85, 0x84, 0x5e, 0x60, 0x42, 0x3c, 0x07, 0xfa, 0x38, 0x01, 0x9a,
+	0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0x6f, 0x52, 0x3c, 0x3a, 0x51, 0x50, 0x3b, 0x3a, 0x54,
+	0x00, 0x02, 0x00, 0x13, 0x00, 0x00, 0x07, 0xc6, 0x05, 0xc8, 0x00, 0x02, 0x00, 0x12, 0x00, 0x72,
+	0xb5, 0x02, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x03,
+	0x00, 0x04, 0x00, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x07, 0x05, 0x00, 0x07, 0x67, 0x00, 0x02,
+	0x02, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x09, 0x08, 0x02,
+	0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x67,
+	0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x07, 0x05, 0x00, 0x07, 0x67,
+	0x00, 0x05, 0x05, 0x06, 0x5f, 0x09, 0x08, 0x02, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x11,
+	0x03, 0x03, 0x03, 0x12, 0x03, 0x12, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x13, 0x10, 0x0a, 0x09,
+	0x1e, 0x2b, 0x01, 0x21, 0x11, 0x01, 0x01, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21,
+	0x15, 0x21, 0x11, 0x21, 0x01, 0x02, 0x55, 0x01, 0xa2, 0xfc, 0x1c, 0x03, 0xac, 0x03, 0xdc, 0xfd,
+	0x2e, 0x02, 0x6e, 0xfd, 0x92, 0x02, 0xfd, 0xfc, 0x31, 0xfd, 0xfb, 0xfe, 0xfa, 0x02, 0x39, 0x02,
+	0x92, 0xfb, 0x35, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x01, 0x9e, 0xfe, 0x62,
+	0x00, 0x01, 0x00, 0x74, 0xfe, 0x50, 0x05, 0x48, 0x05, 0xed, 0x00, 0x28, 0x00, 0x7f, 0x40, 0x18,
+	0x1d, 0x01, 0x06, 0x05, 0x28, 0x1e, 0x02, 0x07, 0x06, 0x14, 0x00, 0x02, 0x00, 0x07, 0x0d, 0x01,
+	0x03, 0x04, 0x0c, 0x01, 0x02, 0x03, 0x05, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00,
+	0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x69, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3e,
+	0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x05, 0x00, 0x06, 0x07, 0x05, 0x06,
+	0x69, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x69, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40,
+	0x0b, 0x24, 0x23, 0x27, 0x12, 0x23, 0x24, 0x11, 0x21, 0x08, 0x09, 0x1e, 0x2b, 0x25, 0x06, 0x21,
+	0x23, 0x07, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34,
+	0x23, 0x37, 0x24, 0x27, 0x26, 0x11, 0x10, 0x00, 0x21, 0x32, 0x05, 0x15, 0x24, 0x23, 0x22, 0x00,
+	0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x05, 0x48, 0xdb, 0xfe, 0xf2, 0x14, 0x27, 0x4e, 0x74, 0x75,
+	0x54, 0x47, 0x4b, 0x2e, 0x3b, 0x67, 0xbb, 0x4d, 0xfe, 0xe5, 0xa0, 0xbe, 0x01, 0x84, 0x01, 0x6f,
+	0xd5, 0x01, 0x0a, 0xfe, 0xce, 0xb4, 0xff, 0xfe, 0xf4, 0x01, 0x1e, 0x01, 0x05, 0xdf, 0xf1, 0x4c,
+	0x71, 0x48, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60, 0x8f, 0x1b, 0xa6, 0xc6, 0x01,
+	0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1,
+	0x81, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xbe, 0x00, 0x00, 0x05, 0x1b, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x6e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x07, 0x06, 0x07, 0x85,
+	0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05,
+	0x39, 0x05, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85,
+	0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67,
+	0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00,
+	0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09,
+	0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x23,
+	0x01, 0x33, 0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0xfe, 0x62, 0x94,
+	0xfe, 0xbf, 0xe4, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x4e, 0x01, 0x41,
+	0x00, 0x02, 0x00, 0xbe, 0x00, 0x00, 0x05, 0x1b, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x74,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00,
+	0x07, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e,
+	0x1b, 0x40, 0x27, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15,
+	0x01, 0x13, 0x33, 0x01, 0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0xfd,
+	0x3a, 0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x4e,
+	0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0xbe, 0x00, 0x00, 0x05, 0x1b, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x7f, 0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x2a, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x00,
+	0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02,
+	0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13,
+	0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b,
+	0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01,
+	0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04,
+	0x03, 0x8b, 0xfc, 0x73, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8, 0x9d, 0xfe, 0x25,
+	0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x03, 0x00, 0xbe,
+	0x00, 0x00, 0x05, 0x1b, 0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x7e, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07,
+	0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b,
+	0x40, 0x28, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c,
+	0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0xfc, 0xa6, 0xad, 0xde, 0xad,
+	0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0x00,
+	0x00, 0x02, 0x00, 0x57, 0x00, 0x00, 0x02, 0xb5, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x62,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02, 0x06,
+	0x85, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00,
+	0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x07, 0x06, 0x07,
+	0x85, 0x00, 0x06, 0x02, 0x06, 0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x68, 0x04,
+	0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00,
+	0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09,
+	0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x03, 0x23,
+	0x01, 0x33, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0x89, 0x94, 0xfe, 0xbf, 0xe4, 0x9d, 0x04,
+	0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x7c,
+	0x00, 0x00, 0x02, 0xd9, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x68, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x23, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x02, 0x07, 0x85, 0x03, 0x01,
+	0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08,
+	0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01,
+	0x07, 0x02, 0x07, 0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15,
+	0x01, 0x13, 0x33, 0x01, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0xfe, 0x4f, 0xf1, 0xe4, 0xfe,
+	0xbf, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0x3b, 0x00, 0x00, 0x02, 0xf7, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x73,
+	0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x06,
+	0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x02, 0x07, 0x85, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f,
+	0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39,
+	0x05, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x02, 0x07,
+	0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f,
+	0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13,
+	0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b,
+	0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01,
+	0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0xfd, 0x86,
+	0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06,
+	0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0x7c, 0x00, 0x00, 0x02, 0xb5,
+	0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x72, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x24, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x02, 0x06, 0x07, 0x67, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01,
+	0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07,
+	0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c,
+	0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11,
+	0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0xfd, 0xc7, 0xad, 0xdf, 0xad, 0x9d, 0x04, 0x8e, 0x9d,
+	0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0f,
+	0x00, 0x00, 0x05, 0x74, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x1a, 0x00, 0x60, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x05, 0x05,
+	0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x01, 0x02, 0x05, 0x67, 0x06, 0x01,
+	0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x08, 0x01, 0x03,
+	0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x1a, 0x19, 0x18, 0x17, 0x16, 0x14, 0x0e,
+	0x0c, 0x00, 0x0b, 0x00, 0x0a, 0x21, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x23, 0x35,
+	0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x00, 0x21, 0x25, 0x33, 0x20, 0x00, 0x11, 0x10, 0x27, 0x26,
+	0x26, 0x23, 0x23, 0x11, 0x21, 0x15, 0x21, 0xaf, 0xa0, 0xa0, 0x01, 0xda, 0x02, 0xeb, 0xfe, 0x7b,
+	0xfe, 0x9d, 0xfe, 0xf5, 0xfc, 0x01, 0x0e, 0x01, 0x08, 0x7e, 0x4d, 0xd6, 0xd6, 0x9b, 0x01, 0x4d,
+	0xfe, 0xb3, 0x02, 0xa7, 0x9d, 0x02, 0x84, 0xfd, 0x3f, 0xfe, 0x8f, 0xfe, 0x6a, 0x9d, 0x01, 0x27,
+	0x01, 0x2f, 0x01, 0x05, 0x95, 0x5b, 0x43, 0xfe, 0x19, 0x9d, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x05, 0x21, 0x07, 0x4c, 0x00, 0x09, 0x00, 0x1d, 0x00, 0x74, 0xb6, 0x08, 0x03, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x06, 0x01, 0x04, 0x00, 0x08,
+	0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0b, 0x09, 0x02, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x0a, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x21, 0x06,
+	0x01, 0x04, 0x00, 0x08, 0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0b, 0x09, 0x02, 0x07, 0x00, 0x05,
+	0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x0a, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x40, 0x1c, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x1d, 0x0a, 0x1d, 0x1c, 0x1a, 0x17, 0x15, 0x14,
+	0x13, 0x12, 0x10, 0x0d, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x0c, 0x09, 0x19, 0x2b,
+	0x33, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11, 0x23, 0x01, 0x11, 0x13, 0x36, 0x33, 0x32, 0x17, 0x17,
+	0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0xa5, 0xcd,
+	0x02, 0xfb, 0xb4, 0xce, 0xfd, 0x06, 0x57, 0x0c, 0xad, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09,
+	0x7b, 0x0b, 0xae, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x05, 0xc8, 0xfb, 0x89, 0x04, 0x77,
+	0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89, 0x06, 0x62, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25,
+	0x22, 0x6e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x1b, 0x00, 0x65, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x05, 0x04,
+	0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x1f, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02,
+	0x01, 0x03, 0x6a, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x17, 0x0d, 0x0c, 0x01, 0x00, 0x1b, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d,
+	0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x08, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02,
+	0x11, 0x10, 0x12, 0x01, 0x23, 0x01, 0x33, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01,
+	0x41, 0x01, 0x40, 0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb,
+	0x01, 0x77, 0x94, 0xfe, 0xbf, 0xe4, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe,
+	0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46,
+	0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xd6, 0x01, 0x41, 0x00, 0x03, 0x00, 0x5d,
+	0xff, 0xdb, 0x05, 0xdd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x6b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61,
+	0x06, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08,
+	0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x07, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d,
+	0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10,
+	0x12, 0x13, 0x13, 0x33, 0x01, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01,
+	0x40, 0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x4f, 0xf1,
+	0xe4, 0xfe, 0xbf, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0,
+	0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe,
+	0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xd6, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0x5d,
+	0xff, 0xdb, 0x05, 0xdd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x76, 0xb5, 0x1d,
+	0x01, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18, 0x1f,
+	0x1c, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b,
+	0x0a, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00,
+	0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x13, 0x33, 0x13,
+	0x23, 0x27, 0x23, 0x07, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01, 0x40,
+	0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x7b, 0xf1, 0xda,
+	0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a,
+	0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe,
+	0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xd6, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00,
+	0x00, 0x03, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd, 0x07, 0x4c, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x2b,
+	0x00, 0x83, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a, 0x06, 0x01, 0x04, 0x00, 0x08, 0x07, 0x04,
+	0x08, 0x69, 0x00, 0x05, 0x0c, 0x09, 0x02, 0x07, 0x01, 0x05, 0x07, 0x6a, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00,
+	0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x28, 0x06, 0x01, 0x04, 0x00, 0x08, 0x07, 0x04, 0x08, 0x69, 0x00,
+	0x05, 0x0c, 0x09, 0x02, 0x07, 0x01, 0x05, 0x07, 0x6a, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x23, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x2b, 0x18, 0x2b, 0x2a, 0x28, 0x25, 0x23, 0x22,
+	0x21, 0x20, 0x1e, 0x1b, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0d, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x36, 0x33,
+	0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22,
+	0x07, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01, 0x40, 0x01, 0x80, 0xfe,
+	0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x6a, 0x0c, 0xad, 0x49, 0x3e, 0x3c,
+	0x38, 0x1e, 0x44, 0x09, 0x7b, 0x0b, 0xae, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x25, 0x01,
+	0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d,
+	0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6,
+	0x05, 0xea, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x04, 0x00, 0x5d,
+	0xff, 0xdb, 0x05, 0xdd, 0x07, 0x0f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x75,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01,
+	0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x09, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x06, 0x01, 0x04,
+	0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x35, 0x33,
+	0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01,
+	0x40, 0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x39, 0xad,
+	0xde, 0xad, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe,
+	0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda,
+	0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xea, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6c,
+	0x00, 0x66, 0x04, 0x40, 0x04, 0x3a, 0x00, 0x0b, 0x00, 0x06, 0xb3, 0x09, 0x03, 0x01, 0x32, 0x2b,
+	0x37, 0x01, 0x01, 0x37, 0x01, 0x01, 0x17, 0x01, 0x01, 0x07, 0x01, 0x01, 0x6c, 0x01, 0x81, 0xfe,
+	0x7f, 0x69, 0x01, 0x81, 0x01, 0x81, 0x69, 0xfe, 0x7f, 0x01, 0x81, 0x69, 0xfe, 0x7f, 0xfe, 0x7f,
+	0xcf, 0x01, 0x81, 0x01, 0x81, 0x69, 0xfe, 0x7f, 0x01, 0x81, 0x69, 0xfe, 0x7f, 0xfe, 0x7f, 0x69,
+	0x01, 0x81, 0xfe, 0x7f, 0x00, 0x03, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd, 0x05, 0xed, 0x00, 0x13,
+	0x00, 0x1b, 0x00, 0x23, 0x00, 0x5f, 0x40, 0x11, 0x08, 0x01, 0x05, 0x00, 0x23, 0x1b, 0x0b, 0x01,
+	0x04, 0x04, 0x05, 0x12, 0x01, 0x02, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x04, 0x04, 0x02, 0x61,
+	0x06, 0x03, 0x02, 0x02, 0x02, 0x3f, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x01, 0x01, 0x00, 0x00, 0x05,
+	0x04, 0x00, 0x05, 0x69, 0x00, 0x04, 0x04, 0x02, 0x61, 0x06, 0x03, 0x02, 0x02, 0x02, 0x42, 0x02,
+	0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x1f, 0x1d, 0x17, 0x15, 0x00, 0x13, 0x00, 0x13, 0x25, 0x12,
+	0x25, 0x07, 0x09, 0x19, 0x2b, 0x17, 0x37, 0x26, 0x11, 0x10, 0x00, 0x21, 0x32, 0x17, 0x37, 0x33,
+	0x07, 0x16, 0x11, 0x10, 0x00, 0x21, 0x22, 0x27, 0x07, 0x13, 0x16, 0x33, 0x32, 0x12, 0x11, 0x34,
+	0x27, 0x27, 0x26, 0x23, 0x22, 0x02, 0x11, 0x14, 0x17, 0x68, 0xae, 0xb9, 0x01, 0x7f, 0x01, 0x40,
+	0xfb, 0xb0, 0x6a, 0xac, 0xb3, 0xb3, 0xfe, 0x81, 0xfe, 0xbf, 0xf2, 0xb1, 0x66, 0xd7, 0x7b, 0xb7,
+	0xe2, 0xfd, 0x52, 0x54, 0x7f, 0xba, 0xe2, 0xfd, 0x57, 0x25, 0xdd, 0xd8, 0x01, 0x55, 0x01, 0x62,
+	0x01, 0xa6, 0x85, 0x85, 0xe3, 0xd9, 0xfe, 0xb3, 0xfe, 0x9d, 0xfe, 0x5a, 0x80, 0x80, 0x01, 0x10,
+	0x73, 0x01, 0x46, 0x01, 0x23, 0xf2, 0x94, 0x71, 0x78, 0xfe, 0xba, 0xfe, 0xde, 0xf6, 0x99, 0x00,
+	0x00, 0x02, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x4d,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04,
+	0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f,
+	0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02,
+	0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e,
+	0x59, 0x40, 0x09, 0x11, 0x13, 0x25, 0x13, 0x25, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x13, 0x33, 0x11,
+	0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23,
+	0x20, 0x00, 0x11, 0x01, 0x23, 0x01, 0x33, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8,
+	0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0x02, 0xde, 0x94, 0xfe, 0xbf, 0xe4, 0x05, 0xc8,
+	0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65,
+	0x01, 0x18, 0x01, 0x31, 0x04, 0x2a, 0x01, 0x41, 0x00, 0x02, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20,
+	0x07, 0x8f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x54, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00,
+	0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00,
+	0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x16, 0x16, 0x16,
+	0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x07, 0x09, 0x1b, 0x2b, 0x13, 0x33, 0x11, 0x14,
+	0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20,
+	0x00, 0x11, 0x01, 0x13, 0x33, 0x01, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e,
+	0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0x01, 0xb6, 0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0xfc,
+	0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01,
+	0x18, 0x01, 0x31, 0x04, 0x2a, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa6,
+	0xff, 0xdb, 0x05, 0x20, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x5e, 0xb5, 0x1b, 0x01, 0x05,
+	0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07,
+	0x06, 0x02, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07,
+	0x06, 0x02, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d,
+	0x11, 0x14, 0x25, 0x13, 0x25, 0x10, 0x08, 0x09, 0x1c, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17,
+	0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11,
+	0x13, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1,
+	0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0xec, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02,
+	0xc9, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6,
+	0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x2a, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00,
+	0x00, 0x03, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x07, 0x0f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d,
+	0x00, 0x61, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03,
+	0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00,
+	0x01, 0x80, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01,
+	0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x1a, 0x1a, 0x16, 0x16,
+	0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x0a,
+	0x09, 0x1b, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33,
+	0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd,
+	0xfe, 0xe6, 0x01, 0x2e, 0xad, 0xde, 0xad, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb,
+	0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xad,
+	0xad, 0xad, 0xad, 0x00, 0x00, 0x02, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x39, 0x07, 0x8f, 0x00, 0x08,
+	0x00, 0x0c, 0x00, 0x5a, 0xb7, 0x07, 0x04, 0x01, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x01,
+	0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00,
+	0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85,
+	0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x09, 0x09, 0x00, 0x00, 0x09, 0x0c,
+	0x09, 0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x07, 0x09, 0x18, 0x2b, 0x21, 0x11,
+	0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x11, 0x03, 0x13, 0x33, 0x01, 0x02, 0x31, 0xfd, 0xed, 0xf0,
+	0x01, 0xa5, 0x01, 0xc3, 0xc3, 0xfd, 0xca, 0xe4, 0xf1, 0xe4, 0xfe, 0xbf, 0x02, 0x69, 0x03, 0x5f,
+	0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa7, 0x00, 0x00, 0x05, 0x26, 0x05, 0xc8, 0x00, 0x0d, 0x00, 0x15, 0x00, 0x56,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x00,
+	0x04, 0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x01, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x00, 0x04,
+	0x00, 0x02, 0x03, 0x04, 0x02, 0x67, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c,
+	0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x15, 0x13, 0x10, 0x0e, 0x00, 0x0d, 0x00, 0x0d, 0x25,
+	0x21, 0x11, 0x07, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15,
+	0x10, 0x21, 0x21, 0x11, 0x11, 0x21, 0x20, 0x11, 0x34, 0x26, 0x23, 0x21, 0xa7, 0xd2, 0x01, 0x72,
+	0xe4, 0xc7, 0x41, 0x4f, 0xfd, 0x87, 0xfe, 0xca, 0x01, 0x2d, 0x01, 0xa4, 0xad, 0xf2, 0xfe, 0xce,
+	0x05, 0xc8, 0xfe, 0xe9, 0x35, 0x4d, 0x5f, 0xa3, 0xfe, 0x07, 0xfe, 0xcc, 0x01, 0xd3, 0x01, 0x4a,
+	0x8f, 0x67, 0x00, 0x00, 0x00, 0x01, 0x00, 0x81, 0xff, 0xe7, 0x04, 0x8d, 0x06, 0x44, 0x00, 0x27,
+	0x00, 0x90, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x0a, 0x14, 0x01, 0x02, 0x03, 0x13, 0x01, 0x01,
+	0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x14, 0x01, 0x02, 0x03, 0x13, 0x01, 0x04, 0x02, 0x02, 0x4c,
+	0x59, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x40, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x05, 0x04, 0x02, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x40,
+	0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42,
+	0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x40, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e,
+	0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x27, 0x00, 0x27, 0x2b, 0x23, 0x2b, 0x23, 0x06, 0x09,
+	0x1a, 0x2b, 0x33, 0x11, 0x34, 0x36, 0x33, 0x20, 0x11, 0x14, 0x07, 0x06, 0x15, 0x14, 0x17, 0x17,
+	0x16, 0x15, 0x10, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35,
+	0x34, 0x37, 0x36, 0x35, 0x34, 0x23, 0x22, 0x06, 0x15, 0x11, 0x81, 0xb7, 0xed, 0x01, 0x7d, 0x82,
+	0x67, 0x6f, 0xb9, 0xac, 0xfe, 0x8d, 0xb0, 0x83, 0xb8, 0x72, 0xc3, 0x73, 0xd7, 0x81, 0x72, 0x62,
+	0xc4, 0x76, 0x5d, 0x04, 0x7f, 0xff, 0xc6, 0xfe, 0xee, 0x82, 0x89, 0x6d, 0x37, 0x43, 0x53, 0x89,
+	0x80, 0xb8, 0xfe, 0xbb, 0x37, 0xac, 0x4f, 0x9b, 0x64, 0x57, 0xa4, 0x62, 0x74, 0x60, 0x91, 0x7d,
+	0x56, 0xa1, 0x62, 0x7c, 0xfb, 0x2e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x5f, 0xff, 0xe7, 0x04, 0x4a,
+	0x06, 0x44, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x28, 0x00, 0xe9, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04, 0x06, 0x19, 0x01, 0x00,
+	0x04, 0x04, 0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01,
+	0x07, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2c,
+	0x00, 0x08, 0x09, 0x03, 0x09, 0x08, 0x03, 0x80, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69,
+	0x00, 0x09, 0x09, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x07,
+	0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x36, 0x00, 0x08, 0x09, 0x03, 0x09, 0x08, 0x03, 0x80, 0x00, 0x01, 0x00, 0x06,
+	0x07, 0x01, 0x06, 0x69, 0x00, 0x09, 0x09, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04,
+	0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x33, 0x00, 0x09, 0x08,
+	0x09, 0x85, 0x00, 0x08, 0x03, 0x08, 0x85, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x00,
+	0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01,
+	0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x40, 0x0e, 0x28, 0x27, 0x11, 0x22, 0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0a,
+	0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22,
+	0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22,
+	0x03, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32, 0x13, 0x23, 0x01, 0x33, 0x03, 0x06, 0xb2, 0xb4,
+	0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d, 0x19, 0x0e,
+	0x44, 0x51, 0x89, 0x43, 0x41, 0xfe, 0x83, 0xb7, 0x81, 0x74, 0x94, 0xfe, 0xbf, 0xe4, 0x8a, 0xa3,
+	0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d,
+	0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x04, 0x7b, 0x01, 0x41, 0x00, 0x03, 0x00, 0x5f,
+	0xff, 0xe7, 0x04, 0x4a, 0x06, 0x44, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x28, 0x00, 0xf0, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04,
+	0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01,
+	0x01, 0x02, 0x1d, 0x01, 0x07, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x2d, 0x0a, 0x01, 0x09, 0x08, 0x03, 0x08, 0x09, 0x03, 0x80, 0x00, 0x01, 0x00,
+	0x06, 0x04, 0x01, 0x06, 0x69, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x41, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x37, 0x0a, 0x01, 0x09, 0x08, 0x03, 0x08, 0x09,
+	0x03, 0x80, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x00,
+	0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01,
+	0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x34, 0x00, 0x08, 0x09, 0x08, 0x85, 0x0a, 0x01, 0x09, 0x03, 0x09, 0x85, 0x00, 0x01,
+	0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61,
+	0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x25, 0x25, 0x25, 0x28, 0x25,
+	0x28, 0x12, 0x22, 0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0b, 0x09, 0x1f, 0x2b, 0x25, 0x06,
+	0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32,
+	0x16, 0x15, 0x11, 0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11, 0x27, 0x20, 0x15,
+	0x14, 0x33, 0x32, 0x03, 0x13, 0x33, 0x01, 0x03, 0x06, 0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e,
+	0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89, 0x43, 0x41,
+	0xfe, 0x83, 0xb7, 0x81, 0xb5, 0xf1, 0xe4, 0xfe, 0xbf, 0x8a, 0xa3, 0xa6, 0x85, 0x01, 0x70, 0x83,
+	0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d, 0x20, 0x01, 0x0e, 0x01, 0x19,
+	0x02, 0xdc, 0xac, 0x04, 0x7b, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x5f,
+	0xff, 0xe7, 0x04, 0x4a, 0x06, 0x44, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x2c, 0x00, 0xfd, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x16, 0x2a, 0x01, 0x09, 0x08, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01,
+	0x02, 0x1d, 0x01, 0x04, 0x06, 0x19, 0x01, 0x00, 0x04, 0x05, 0x4c, 0x1b, 0x40, 0x16, 0x2a, 0x01,
+	0x09, 0x08, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x07, 0x06, 0x19, 0x01,
+	0x00, 0x04, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2e, 0x0b, 0x0a, 0x02, 0x09,
+	0x08, 0x03, 0x08, 0x09, 0x03, 0x80, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x00, 0x08,
+	0x08, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x07, 0x01, 0x04,
+	0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58,
+	0x40, 0x38, 0x0b, 0x0a, 0x02, 0x09, 0x08, 0x03, 0x08, 0x09, 0x03, 0x80, 0x00, 0x01, 0x00, 0x06,
+	0x07, 0x01, 0x06, 0x69, 0x00, 0x08, 0x08, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04,
+	0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x35, 0x00, 0x08, 0x09,
+	0x08, 0x85, 0x0b, 0x0a, 0x02, 0x09, 0x03, 0x09, 0x85, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06,
+	0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61,
+	0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x25, 0x25, 0x25, 0x2c, 0x25, 0x2c, 0x29, 0x28, 0x12, 0x22,
+	0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26,
+	0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11,
+	0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32,
+	0x01, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x06, 0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c,
+	0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89, 0x43,
+	0x41, 0xfe, 0x83, 0xb7, 0x81, 0xfe, 0x82, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x8a, 0xa3,
+	0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d,
+	0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x04, 0x7b, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca,
+	0x00, 0x03, 0x00, 0x5f, 0xff, 0xe7, 0x04, 0x4a, 0x05, 0xf7, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x38,
+	0x01, 0x0e, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01,
+	0x02, 0x1d, 0x01, 0x04, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01,
+	0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x07, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c,
+	0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x34, 0x00, 0x09, 0x0e, 0x0d, 0x02, 0x0b, 0x03, 0x09,
+	0x0b, 0x6a, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x00, 0x0c, 0x0c, 0x08, 0x61, 0x0a,
+	0x01, 0x08, 0x08, 0x3e, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x07,
+	0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x3e, 0x00, 0x09, 0x0e, 0x0d, 0x02, 0x0b, 0x03, 0x09, 0x0b, 0x6a, 0x00, 0x01,
+	0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x00, 0x0c, 0x0c, 0x08, 0x61, 0x0a, 0x01, 0x08, 0x08, 0x3e,
+	0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61,
+	0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x3c, 0x0a, 0x01, 0x08, 0x00, 0x0c, 0x0b, 0x08, 0x0c, 0x69, 0x00, 0x09,
+	0x0e, 0x0d, 0x02, 0x0b, 0x03, 0x09, 0x0b, 0x6a, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69,
+	0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05,
+	0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x59, 0x40, 0x1a, 0x25, 0x25, 0x25, 0x38, 0x25, 0x38, 0x37, 0x35, 0x32, 0x30, 0x2f,
+	0x2e, 0x2d, 0x2b, 0x22, 0x22, 0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0f, 0x09, 0x1f, 0x2b,
+	0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36,
+	0x33, 0x32, 0x16, 0x15, 0x11, 0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11, 0x27,
+	0x20, 0x15, 0x14, 0x33, 0x32, 0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33,
+	0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x03, 0x06, 0xb2, 0xb4, 0x8f, 0xb2, 0x02,
+	0x5c, 0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89,
+	0x43, 0x41, 0xfe, 0x83, 0xb7, 0x81, 0xfe, 0x73, 0x0c, 0xad, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44,
+	0x09, 0x7b, 0x0b, 0xae, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x8a, 0xa3, 0xa6, 0x85, 0x01,
+	0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d, 0x20, 0x01, 0x0e,
+	0x01, 0x19, 0x02, 0xdc, 0xac, 0x04, 0x85, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22,
+	0x6e, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x5f, 0xff, 0xe7, 0x04, 0x4a, 0x05, 0xba, 0x00, 0x1c,
+	0x00, 0x24, 0x00, 0x28, 0x00, 0x2c, 0x00, 0xf9, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x12, 0x0e,
+	0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04,
+	0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x07, 0x06,
+	0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x01,
+	0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x0d, 0x0b, 0x0c, 0x03, 0x09, 0x09, 0x08, 0x5f, 0x0a, 0x01,
+	0x08, 0x08, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x07, 0x01,
+	0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x37, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x0d, 0x0b, 0x0c, 0x03, 0x09,
+	0x09, 0x08, 0x5f, 0x0a, 0x01, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04,
+	0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x35, 0x0a, 0x01, 0x08,
+	0x0d, 0x0b, 0x0c, 0x03, 0x09, 0x03, 0x08, 0x09, 0x67, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06,
+	0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61,
+	0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x59, 0x40, 0x1a, 0x29, 0x29, 0x25, 0x25, 0x29, 0x2c, 0x29, 0x2c, 0x2b, 0x2a,
+	0x25, 0x28, 0x25, 0x28, 0x12, 0x22, 0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0e, 0x09, 0x1f,
+	0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35,
+	0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11,
+	0x27, 0x20, 0x15, 0x14, 0x33, 0x32, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x06,
+	0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d,
+	0x19, 0x0e, 0x44, 0x51, 0x89, 0x43, 0x41, 0xfe, 0x83, 0xb7, 0x81, 0xfe, 0xb1, 0xad, 0xde, 0xad,
+	0x8a, 0xa3, 0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9,
+	0x04, 0x6d, 0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x04, 0x85, 0xad, 0xad, 0xad, 0xad,
+	0x00, 0x04, 0x00, 0x5f, 0xff, 0xe7, 0x04, 0x4a, 0x06, 0xc9, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x30,
+	0x00, 0x3c, 0x00, 0xc4, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d,
+	0x01, 0x01, 0x02, 0x1d, 0x01, 0x04, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x1b, 0x40, 0x12,
+	0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x07, 0x06, 0x19, 0x01, 0x00, 0x04,
+	0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x31, 0x00, 0x09, 0x00, 0x0b, 0x0a, 0x09,
+	0x0b, 0x69, 0x0d, 0x01, 0x0a, 0x0c, 0x01, 0x08, 0x03, 0x0a, 0x08, 0x69, 0x00, 0x01, 0x00, 0x06,
+	0x04, 0x01, 0x06, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x07, 0x01,
+	0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x3b, 0x00, 0x09,
+	0x00, 0x0b, 0x0a, 0x09, 0x0b, 0x69, 0x0d, 0x01, 0x0a, 0x0c, 0x01, 0x08, 0x03, 0x0a, 0x08, 0x69,
+	0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04,
+	0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x32, 0x31, 0x26, 0x25,
+	0x38, 0x36, 0x31, 0x3c, 0x32, 0x3c, 0x2c, 0x2a, 0x25, 0x30, 0x26, 0x30, 0x22, 0x22, 0x24, 0x14,
+	0x23, 0x22, 0x23, 0x21, 0x0e, 0x09, 0x1e, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21,
+	0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x14, 0x33, 0x32,
+	0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32, 0x03, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x06, 0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9,
+	0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89, 0x43, 0x41, 0xfe, 0x83,
+	0xb7, 0x81, 0x23, 0x5c, 0x84, 0x84, 0x5f, 0x5e, 0x85, 0x85, 0x60, 0x3c, 0x53, 0x53, 0x3a, 0x3b,
+	0x52, 0x52, 0x8a, 0xa3, 0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe,
+	0x14, 0xa9, 0x04, 0x6d, 0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x04, 0x7b, 0x85, 0x5e,
+	0x5e, 0x85, 0x84, 0x5e, 0x60, 0x84, 0x56, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00,
+	0x00, 0x03, 0x00, 0x5f, 0xff, 0xe7, 0x06, 0xa5, 0x04, 0x56, 0x00, 0x07, 0x00, 0x2a, 0x00, 0x2f,
+	0x01, 0x46, 0x4b, 0xb0, 0x1c, 0x50, 0x58, 0x40, 0x14, 0x27, 0x01, 0x08, 0x02, 0x26, 0x01, 0x07,
+	0x08, 0x17, 0x12, 0x00, 0x03, 0x01, 0x00, 0x13, 0x01, 0x05, 0x01, 0x04, 0x4c, 0x1b, 0x4b, 0xb0,
+	0x21, 0x50, 0x58, 0x40, 0x14, 0x27, 0x01, 0x08, 0x02, 0x26, 0x01, 0x0a, 0x08, 0x17, 0x12, 0x00,
+	0x03, 0x01, 0x00, 0x13, 0x01, 0x05, 0x01, 0x04, 0x4c, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40,
+	0x14, 0x27, 0x01, 0x08, 0x02, 0x26, 0x01, 0x0a, 0x08, 0x17, 0x12, 0x00, 0x03, 0x01, 0x03, 0x13,
+	0x01, 0x05, 0x01, 0x04, 0x4c, 0x1b, 0x40, 0x14, 0x27, 0x01, 0x08, 0x02, 0x26, 0x01, 0x0a, 0x08,
+	0x17, 0x12, 0x00, 0x03, 0x01, 0x03, 0x13, 0x01, 0x05, 0x04, 0x04, 0x4c, 0x59, 0x59, 0x59, 0x4b,
+	0xb0, 0x1c, 0x50, 0x58, 0x40, 0x23, 0x0a, 0x01, 0x07, 0x03, 0x01, 0x00, 0x01, 0x07, 0x00, 0x69,
+	0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01, 0x01,
+	0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40,
+	0x28, 0x00, 0x0a, 0x07, 0x00, 0x0a, 0x57, 0x00, 0x07, 0x03, 0x01, 0x00, 0x01, 0x07, 0x00, 0x69,
+	0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01, 0x01,
+	0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40,
+	0x29, 0x00, 0x07, 0x00, 0x00, 0x03, 0x07, 0x00, 0x69, 0x00, 0x0a, 0x00, 0x03, 0x01, 0x0a, 0x03,
+	0x67, 0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01,
+	0x01, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40, 0x33, 0x00, 0x07, 0x00,
+	0x00, 0x03, 0x07, 0x00, 0x69, 0x00, 0x0a, 0x00, 0x03, 0x01, 0x0a, 0x03, 0x67, 0x0b, 0x01, 0x08,
+	0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x05, 0x61, 0x06, 0x01,
+	0x05, 0x05, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x12, 0x2f, 0x2d, 0x2c, 0x2b, 0x2a, 0x28, 0x23, 0x23, 0x23, 0x23, 0x21,
+	0x12, 0x22, 0x22, 0x21, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32,
+	0x01, 0x36, 0x33, 0x20, 0x11, 0x15, 0x21, 0x12, 0x21, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x27,
+	0x06, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x26, 0x23, 0x22, 0x07, 0x35,
+	0x36, 0x33, 0x32, 0x13, 0x21, 0x10, 0x23, 0x20, 0x02, 0xff, 0x4b, 0xfe, 0x70, 0xca, 0x8b, 0x01,
+	0x1b, 0x90, 0xc4, 0x01, 0xbd, 0xfd, 0x1c, 0x1b, 0x01, 0x77, 0x9e, 0xaf, 0xc3, 0xbd, 0xfe, 0xd1,
+	0x97, 0x7b, 0xb7, 0x7f, 0x95, 0xb5, 0x02, 0x72, 0x2e, 0x62, 0x7b, 0xb0, 0xb5, 0xc8, 0xc1, 0xe9,
+	0x9c, 0x02, 0x14, 0xfc, 0xff, 0x00, 0xf5, 0x01, 0x19, 0x02, 0xdd, 0xab, 0x03, 0x4f, 0x7f, 0xfd,
+	0xe7, 0x3d, 0xfe, 0x7d, 0x42, 0x9c, 0x3c, 0xe6, 0x85, 0x61, 0xa4, 0x86, 0x01, 0x71, 0x83, 0x69,
+	0x54, 0x60, 0xa3, 0x51, 0xfe, 0x3e, 0x01, 0x2e, 0x00, 0x01, 0x00, 0x56, 0xfe, 0x50, 0x03, 0x9e,
+	0x04, 0x56, 0x00, 0x26, 0x00, 0x50, 0x40, 0x4d, 0x1c, 0x01, 0x06, 0x05, 0x26, 0x1d, 0x02, 0x07,
+	0x06, 0x00, 0x01, 0x00, 0x07, 0x13, 0x01, 0x01, 0x00, 0x0c, 0x01, 0x03, 0x04, 0x0b, 0x01, 0x02,
+	0x03, 0x06, 0x4c, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x69, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00,
+	0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x23, 0x23, 0x27, 0x12, 0x23, 0x24,
+	0x11, 0x11, 0x08, 0x09, 0x1e, 0x2b, 0x25, 0x06, 0x07, 0x07, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x37, 0x26, 0x27, 0x26, 0x35, 0x10, 0x00,
+	0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x03, 0x9e, 0x94,
+	0x96, 0x2f, 0x4e, 0x74, 0x75, 0x54, 0x47, 0x4b, 0x2e, 0x3b, 0x67, 0xbb, 0x52, 0xba, 0x7a, 0x89,
+	0x01, 0x17, 0xf8, 0x84, 0xa9, 0xa0, 0x64, 0xfe, 0xa1, 0xb6, 0xa0, 0x7c, 0x9d, 0x21, 0x32, 0x06,
+	0x56, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60, 0x98, 0x0f, 0x8c, 0x9e, 0xfb, 0x01,
+	0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5e, 0xc2, 0xd5, 0x45, 0x00, 0x00, 0x03, 0x00, 0x56,
+	0xff, 0xe7, 0x03, 0xfb, 0x06, 0x44, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19, 0x00, 0x83, 0x40, 0x0a,
+	0x05, 0x01, 0x05, 0x04, 0x06, 0x01, 0x02, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40,
+	0x2b, 0x00, 0x06, 0x07, 0x03, 0x07, 0x06, 0x03, 0x80, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01,
+	0x04, 0x67, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x28, 0x00,
+	0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x03, 0x06, 0x85, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01,
+	0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x19, 0x18, 0x17, 0x16,
+	0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x09, 0x09, 0x17,
+	0x2b, 0x01, 0x10, 0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00, 0x33,
+	0x20, 0x11, 0x07, 0x21, 0x12, 0x21, 0x32, 0x03, 0x23, 0x01, 0x33, 0x03, 0x32, 0xf5, 0xfd, 0x18,
+	0x02, 0xcd, 0xc2, 0xb7, 0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c,
+	0x01, 0x69, 0x9c, 0x78, 0x94, 0xfe, 0xbf, 0xe4, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b,
+	0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x86,
+	0x01, 0x41, 0x00, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb, 0x06, 0x44, 0x00, 0x04,
+	0x00, 0x15, 0x00, 0x19, 0x00, 0x89, 0x40, 0x0a, 0x05, 0x01, 0x05, 0x04, 0x06, 0x01, 0x02, 0x05,
+	0x02, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2c, 0x09, 0x01, 0x07, 0x06, 0x03, 0x06, 0x07,
+	0x03, 0x80, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x06, 0x06, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07,
+	0x03, 0x07, 0x85, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02,
+	0x4e, 0x59, 0x40, 0x1a, 0x16, 0x16, 0x00, 0x00, 0x16, 0x19, 0x16, 0x19, 0x18, 0x17, 0x15, 0x13,
+	0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0a, 0x09, 0x17, 0x2b, 0x01,
+	0x10, 0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20, 0x11,
+	0x07, 0x21, 0x12, 0x21, 0x32, 0x01, 0x13, 0x33, 0x01, 0x03, 0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd,
+	0xc2, 0xb7, 0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69,
+	0x9c, 0xfe, 0x60, 0xf1, 0xe4, 0xfe, 0xbf, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c,
+	0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x86, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb, 0x06, 0x44, 0x00, 0x04,
+	0x00, 0x15, 0x00, 0x1d, 0x00, 0x91, 0x40, 0x0e, 0x1b, 0x01, 0x07, 0x06, 0x05, 0x01, 0x05, 0x04,
+	0x06, 0x01, 0x02, 0x05, 0x03, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2d, 0x0a, 0x08, 0x02,
+	0x07, 0x06, 0x03, 0x06, 0x07, 0x03, 0x80, 0x09, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67,
+	0x00, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x06, 0x07,
+	0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x03, 0x07, 0x85, 0x09, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01,
+	0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x1c, 0x16, 0x16, 0x00, 0x00, 0x16, 0x1d,
+	0x16, 0x1d, 0x1a, 0x19, 0x18, 0x17, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04,
+	0x00, 0x04, 0x21, 0x0b, 0x09, 0x17, 0x2b, 0x01, 0x10, 0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23,
+	0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07, 0x21, 0x12, 0x21, 0x32, 0x01, 0x13, 0x33,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd, 0xc2, 0xb7, 0xfb, 0xfe,
+	0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c, 0xfd, 0xa1, 0xf1,
+	0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c,
+	0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x86, 0x01, 0x41,
+	0xfe, 0xbf, 0xca, 0xca, 0x00, 0x04, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb, 0x05, 0xba, 0x00, 0x04,
+	0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x92, 0x40, 0x0a, 0x05, 0x01, 0x05, 0x04, 0x06, 0x01,
+	0x02, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x0a, 0x01, 0x01, 0x00, 0x04,
+	0x05, 0x01, 0x04, 0x67, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x07, 0x06, 0x5f, 0x08, 0x01, 0x06, 0x06,
+	0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b,
+	0x03, 0x07, 0x03, 0x06, 0x07, 0x67, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x22, 0x1a, 0x1a, 0x16, 0x16, 0x00, 0x00, 0x1a, 0x1d, 0x1a,
+	0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x18, 0x17, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09,
+	0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0d, 0x09, 0x17, 0x2b, 0x01, 0x10, 0x23, 0x22, 0x03, 0x01,
+	0x15, 0x06, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07, 0x21, 0x12, 0x21, 0x32,
+	0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd, 0xc2,
+	0xb7, 0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c,
+	0xfd, 0xe4, 0xad, 0xde, 0xad, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01,
+	0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x90, 0xad, 0xad, 0xad,
+	0xad, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd8, 0x00, 0x00, 0x01, 0xad, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x6a, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x19, 0x00, 0x02, 0x03, 0x00, 0x03,
+	0x02, 0x00, 0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x02, 0x03,
+	0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x09, 0x17, 0x2b, 0x33, 0x11,
+	0x33, 0x11, 0x13, 0x23, 0x01, 0x33, 0x9a, 0xc5, 0x4e, 0x94, 0xfe, 0xbf, 0xe4, 0x04, 0x3e, 0xfb,
+	0xc2, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x4c, 0x00, 0x00, 0x02, 0x21,
+	0x06, 0x44, 0x00, 0x03, 0x00, 0x07, 0x00, 0x71, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1a, 0x05,
+	0x01, 0x03, 0x02, 0x00, 0x02, 0x03, 0x00, 0x80, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x17, 0x00, 0x02, 0x03, 0x02, 0x85, 0x05, 0x01, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x03, 0x02, 0x85,
+	0x05, 0x01, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x13, 0x33,
+	0x01, 0x9a, 0xc5, 0xfe, 0xed, 0xf1, 0xe4, 0xfe, 0xbf, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0xff, 0x9e, 0x00, 0x00, 0x02, 0x5a, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x0b, 0x00, 0x7d, 0xb5, 0x09, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58,
+	0x40, 0x1b, 0x06, 0x04, 0x02, 0x03, 0x02, 0x00, 0x02, 0x03, 0x00, 0x80, 0x00, 0x02, 0x02, 0x3a,
+	0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x04, 0x02, 0x03, 0x00, 0x03,
+	0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18,
+	0x00, 0x02, 0x03, 0x02, 0x85, 0x06, 0x04, 0x02, 0x03, 0x00, 0x03, 0x85, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x05, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x0b, 0x04, 0x0b, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17,
+	0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x9a, 0xc5, 0xfe,
+	0x3f, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03, 0x01, 0x41,
+	0xfe, 0xbf, 0xca, 0xca, 0x00, 0x03, 0xff, 0xe0, 0x00, 0x00, 0x02, 0x18, 0x05, 0xba, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x0b, 0x00, 0x5a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x05, 0x07,
+	0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x04, 0x01, 0x02, 0x08, 0x05, 0x07,
+	0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x3c,
+	0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a,
+	0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b,
+	0x33, 0x11, 0x33, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x9a, 0xc5, 0xfe, 0x81,
+	0xad, 0xde, 0xad, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x54, 0xff, 0xe7, 0x04, 0x1c, 0x06, 0x68, 0x00, 0x29, 0x00, 0x32, 0x00, 0x45,
+	0x40, 0x42, 0x0e, 0x0b, 0x02, 0x00, 0x01, 0x29, 0x02, 0x01, 0x03, 0x03, 0x00, 0x24, 0x01, 0x05,
+	0x03, 0x03, 0x4c, 0x0d, 0x0c, 0x02, 0x01, 0x4a, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3a, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x31, 0x2f, 0x2d, 0x2b, 0x22, 0x20, 0x1a, 0x18, 0x11,
+	0x15, 0x06, 0x09, 0x18, 0x2b, 0x01, 0x27, 0x37, 0x26, 0x27, 0x26, 0x23, 0x35, 0x32, 0x17, 0x16,
+	0x17, 0x37, 0x17, 0x07, 0x16, 0x17, 0x1e, 0x02, 0x12, 0x15, 0x10, 0x07, 0x06, 0x23, 0x22, 0x27,
+	0x26, 0x37, 0x02, 0x37, 0x36, 0x33, 0x32, 0x16, 0x17, 0x2e, 0x03, 0x27, 0x03, 0x10, 0x21, 0x20,
+	0x11, 0x10, 0x21, 0x22, 0x06, 0x01, 0x65, 0x56, 0x5e, 0x27, 0x27, 0x55, 0x5e, 0x7e, 0x6c, 0x33,
+	0x31, 0x49, 0x55, 0x43, 0x0b, 0x0b, 0x83, 0xbc, 0x79, 0x39, 0x81, 0x80, 0xe2, 0xda, 0x85, 0x86,
+	0x02, 0x02, 0x83, 0x83, 0xdf, 0x37, 0x73, 0x2a, 0x18, 0x48, 0x56, 0x5f, 0x30, 0xa0, 0x01, 0x12,
+	0x01, 0x10, 0xfe, 0xef, 0x82, 0x8f, 0x04, 0xa2, 0x34, 0x99, 0x0e, 0x09, 0x13, 0x93, 0x1d, 0x0d,
+	0x13, 0x79, 0x33, 0x6f, 0x05, 0x06, 0x4c, 0xc7, 0xed, 0xfe, 0xf4, 0x90, 0xfe, 0xfa, 0x99, 0x99,
+	0x97, 0x98, 0xff, 0x01, 0x03, 0x92, 0x94, 0x16, 0x16, 0x30, 0x59, 0x50, 0x43, 0x19, 0xfc, 0xd2,
+	0xfe, 0x62, 0x01, 0x97, 0x01, 0x98, 0xd3, 0x00, 0x00, 0x02, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xe5,
+	0x05, 0xf7, 0x00, 0x10, 0x00, 0x24, 0x00, 0xba, 0xb6, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x01, 0x4c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x28, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x00, 0x06, 0x08,
+	0x6a, 0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01, 0x05, 0x05, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08,
+	0x6a, 0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01, 0x05, 0x05, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x40, 0x2a, 0x07, 0x01, 0x05, 0x00, 0x09, 0x08, 0x05, 0x09, 0x69, 0x00,
+	0x06, 0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08, 0x6a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x0b, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x1d, 0x11, 0x11, 0x00, 0x00, 0x11, 0x24, 0x11, 0x24, 0x23, 0x21, 0x1e, 0x1c,
+	0x1b, 0x1a, 0x19, 0x17, 0x14, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0d, 0x09,
+	0x1a, 0x2b, 0x33, 0x11, 0x33, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23,
+	0x22, 0x07, 0x11, 0x03, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23,
+	0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x9a, 0xc5, 0x9d, 0xd2, 0x01, 0x17, 0xc6, 0x37, 0x4c,
+	0xa7, 0x96, 0x7a, 0x0c, 0xad, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x7b, 0x0b, 0xae, 0x49,
+	0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc,
+	0x83, 0x5e, 0xee, 0xfd, 0x41, 0x05, 0x0d, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22,
+	0x6e, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c, 0x06, 0x44, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x17, 0x00, 0x6a, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x05,
+	0x01, 0x05, 0x04, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x40, 0x21, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0c, 0x01, 0x00, 0x17, 0x16, 0x15, 0x14,
+	0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x08, 0x09, 0x16, 0x2b,
+	0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11, 0x10,
+	0x21, 0x20, 0x11, 0x10, 0x01, 0x23, 0x01, 0x33, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03, 0xe0,
+	0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x01, 0xa2, 0x94, 0xfe,
+	0xbf, 0xe4, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe,
+	0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x88, 0x01, 0x41,
+	0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17,
+	0x00, 0x70, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05,
+	0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40,
+	0x22, 0x00, 0x04, 0x05, 0x04, 0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x17, 0x14, 0x17,
+	0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09,
+	0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20,
+	0x11, 0x10, 0x21, 0x20, 0x11, 0x10, 0x13, 0x13, 0x33, 0x01, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01,
+	0x03, 0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x7a, 0xf1,
+	0xe4, 0xfe, 0xbf, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa,
+	0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x88, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c, 0x06, 0x44, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x1b, 0x00, 0x7b, 0xb5, 0x19, 0x01, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x26, 0x09, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04,
+	0x04, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x04, 0x05,
+	0x04, 0x85, 0x09, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x1d, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x1b, 0x14, 0x1b, 0x18, 0x17, 0x16,
+	0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16,
+	0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11,
+	0x10, 0x21, 0x20, 0x11, 0x10, 0x03, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x02, 0x32, 0xdb,
+	0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe,
+	0xf2, 0x50, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07,
+	0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe,
+	0x5d, 0xfe, 0x5c, 0x04, 0x88, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0x56,
+	0xff, 0xe7, 0x04, 0x1c, 0x05, 0xf7, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x27, 0x00, 0x87, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x05, 0x0c, 0x09, 0x02, 0x07, 0x01, 0x05, 0x07, 0x6a, 0x00,
+	0x08, 0x08, 0x04, 0x61, 0x06, 0x01, 0x04, 0x04, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x41, 0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x1b, 0x40, 0x2a, 0x06, 0x01, 0x04, 0x00, 0x08, 0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0c,
+	0x09, 0x02, 0x07, 0x01, 0x05, 0x07, 0x6a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x0b, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x23, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x27, 0x14, 0x27, 0x26, 0x24, 0x21, 0x1f, 0x1e,
+	0x1d, 0x1c, 0x1a, 0x17, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0d, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10,
+	0x00, 0x27, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11, 0x10, 0x03, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16,
+	0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x02, 0x32, 0xdb,
+	0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe,
+	0xf2, 0x3f, 0x0c, 0xad, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x7b, 0x0b, 0xae, 0x49, 0x3e,
+	0x3c, 0x38, 0x1e, 0x44, 0x09, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1,
+	0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04,
+	0x92, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x00, 0x04, 0x00, 0x56,
+	0xff, 0xe7, 0x04, 0x1c, 0x05, 0xba, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x79,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06,
+	0x01, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09,
+	0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x06,
+	0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x23, 0x18, 0x18, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18,
+	0x1b, 0x1a, 0x19, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33,
+	0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11, 0x10, 0x03, 0x35, 0x33,
+	0x15, 0x33, 0x35, 0x33, 0x15, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04,
+	0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x0e, 0xad, 0xde, 0xad, 0x19, 0x01, 0x34,
+	0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01,
+	0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x92, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x68, 0x00, 0x00, 0x04, 0x43, 0x04, 0xa0, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x64, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02,
+	0x03, 0x67, 0x00, 0x00, 0x06, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00,
+	0x02, 0x03, 0x67, 0x00, 0x00, 0x06, 0x01, 0x01, 0x04, 0x00, 0x01, 0x67, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00,
+	0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35,
+	0x33, 0x15, 0x68, 0x03, 0xdb, 0xfd, 0x97, 0xf7, 0xf7, 0xf7, 0x02, 0x06, 0x94, 0x94, 0x01, 0xa4,
+	0xf6, 0xf6, 0xfc, 0x56, 0xf7, 0xf7, 0x00, 0x00, 0x00, 0x03, 0x00, 0x8f, 0xff, 0xe7, 0x04, 0x55,
+	0x04, 0x56, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23, 0x00, 0x30, 0x40, 0x2d, 0x0a, 0x01, 0x05, 0x01,
+	0x23, 0x1b, 0x0d, 0x03, 0x04, 0x04, 0x05, 0x02, 0x4c, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01,
+	0x01, 0x01, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x26, 0x23, 0x25, 0x12, 0x25, 0x11, 0x06, 0x09, 0x1c, 0x2b, 0x25, 0x07, 0x23, 0x37, 0x26, 0x35,
+	0x10, 0x00, 0x33, 0x32, 0x17, 0x37, 0x33, 0x07, 0x16, 0x15, 0x10, 0x00, 0x23, 0x22, 0x27, 0x17,
+	0x16, 0x33, 0x20, 0x11, 0x34, 0x2f, 0x02, 0x26, 0x23, 0x20, 0x11, 0x14, 0x17, 0x01, 0x5c, 0x3d,
+	0x90, 0x79, 0x79, 0x01, 0x04, 0xdf, 0xaa, 0x6c, 0x3d, 0x90, 0x79, 0x79, 0xfe, 0xfd, 0xe0, 0xa7,
+	0x09, 0x02, 0x43, 0x6b, 0x01, 0x14, 0x24, 0x40, 0x02, 0x4c, 0x62, 0xfe, 0xec, 0x24, 0x3b, 0x54,
+	0xa7, 0x9f, 0xf1, 0x01, 0x0a, 0x01, 0x2e, 0x53, 0x53, 0xa7, 0x9f, 0xf0, 0xfe, 0xf8, 0xfe, 0xcf,
+	0xe2, 0x02, 0x4c, 0x01, 0xa8, 0x7e, 0x66, 0x6e, 0x02, 0x4b, 0xfe, 0x65, 0x96, 0x5b, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xbf,
+	0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x20, 0x00,
+	0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x00, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x00,
+	0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x21, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05,
+	0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x14,
+	0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x08, 0x09, 0x1a, 0x2b, 0x21,
+	0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11,
+	0x01, 0x23, 0x01, 0x33, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5,
+	0xfe, 0xfb, 0x94, 0xfe, 0xbf, 0xe4, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e,
+	0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x8e,
+	0xff, 0xe7, 0x03, 0xd8, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xc7, 0xb6, 0x0d, 0x01, 0x02,
+	0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x21, 0x08, 0x01, 0x06, 0x05, 0x01,
+	0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05,
+	0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06,
+	0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00,
+	0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x09,
+	0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x11, 0x33, 0x11, 0x01, 0x13, 0x33, 0x01, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37,
+	0x4d, 0xa7, 0x95, 0xc5, 0xfd, 0xc7, 0xf1, 0xe4, 0xfe, 0xbf, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c,
+	0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8, 0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xd2,
+	0x40, 0x0b, 0x16, 0x01, 0x06, 0x05, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x14,
+	0x50, 0x58, 0x40, 0x22, 0x09, 0x07, 0x02, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05,
+	0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x08, 0x04,
+	0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x26, 0x09, 0x07,
+	0x02, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05,
+	0x85, 0x09, 0x07, 0x02, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x01, 0x06, 0x85, 0x03, 0x01,
+	0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18,
+	0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09,
+	0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37,
+	0x11, 0x33, 0x11, 0x01, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x13, 0x9c, 0xd3, 0xfe,
+	0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0xfc, 0xfd, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9,
+	0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05,
+	0x03, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8,
+	0x05, 0xba, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0xa5, 0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01,
+	0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x21, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05,
+	0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a,
+	0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04,
+	0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40,
+	0x1d, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11,
+	0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0c, 0x09, 0x1a, 0x2b, 0x21,
+	0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11,
+	0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37,
+	0x4d, 0xa7, 0x95, 0xc5, 0xfd, 0x3f, 0xad, 0xde, 0xad, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd,
+	0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x13, 0xfe, 0x75, 0x03, 0xf4, 0x06, 0x44, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x53,
+	0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01,
+	0x04, 0x03, 0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x03, 0x04, 0x03, 0x85,
+	0x05, 0x01, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d,
+	0x02, 0x4e, 0x59, 0x40, 0x0d, 0x08, 0x08, 0x08, 0x0b, 0x08, 0x0b, 0x12, 0x11, 0x12, 0x11, 0x06,
+	0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x23, 0x13, 0x13, 0x33, 0x01, 0x01,
+	0x94, 0xfe, 0x7f, 0xc8, 0x01, 0x27, 0x01, 0x44, 0xae, 0xfd, 0xc2, 0xcd, 0x85, 0xf1, 0xe4, 0xfe,
+	0xbf, 0x04, 0x3e, 0xfc, 0xbf, 0x03, 0x41, 0xfa, 0x37, 0x06, 0x8e, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0x9a, 0xfe, 0x75, 0x04, 0x1c, 0x06, 0x2b, 0x00, 0x0e, 0x00, 0x18, 0x00, 0x37,
+	0x40, 0x34, 0x10, 0x0f, 0x04, 0x03, 0x04, 0x05, 0x0e, 0x01, 0x03, 0x04, 0x02, 0x4c, 0x00, 0x01,
+	0x01, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x04, 0x04,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x3d, 0x00, 0x4e, 0x23, 0x24, 0x24,
+	0x22, 0x11, 0x10, 0x06, 0x09, 0x1c, 0x2b, 0x01, 0x23, 0x11, 0x33, 0x11, 0x36, 0x33, 0x32, 0x12,
+	0x15, 0x10, 0x00, 0x23, 0x22, 0x27, 0x11, 0x11, 0x16, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x22,
+	0x01, 0x5f, 0xc5, 0xc5, 0x76, 0xce, 0xaa, 0xcf, 0xfe, 0xf5, 0xf5, 0x5f, 0x5e, 0x88, 0x45, 0x01,
+	0x1b, 0x6f, 0x60, 0x81, 0xfe, 0x75, 0x07, 0xb6, 0xfd, 0x47, 0xe4, 0xfe, 0xda, 0xf2, 0xfe, 0xe1,
+	0xfe, 0xc8, 0x19, 0x02, 0xbf, 0xfd, 0xd6, 0x1a, 0x01, 0xb1, 0xb1, 0xcd, 0x00, 0x03, 0x00, 0x13,
+	0xfe, 0x75, 0x03, 0xf4, 0x05, 0xba, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x5c, 0xb5, 0x03,
+	0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x06, 0x07, 0x03,
+	0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x03, 0x38, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x05, 0x01, 0x03, 0x08, 0x06, 0x07, 0x03,
+	0x04, 0x00, 0x03, 0x04, 0x67, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02,
+	0x4e, 0x59, 0x40, 0x15, 0x0c, 0x0c, 0x08, 0x08, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x08, 0x0b,
+	0x08, 0x0b, 0x12, 0x11, 0x12, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33,
+	0x01, 0x23, 0x13, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x94, 0xfe, 0x7f, 0xc8, 0x01,
+	0x27, 0x01, 0x44, 0xae, 0xfd, 0xc2, 0xcd, 0x0c, 0xad, 0xde, 0xad, 0x04, 0x3e, 0xfc, 0xbf, 0x03,
+	0x41, 0xfa, 0x37, 0x06, 0x98, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x15,
+	0x00, 0x00, 0x05, 0x40, 0x07, 0x00, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x0e, 0x00, 0x6a, 0xb5, 0x0a,
+	0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x05, 0x08, 0x01,
+	0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x00, 0x06,
+	0x04, 0x06, 0x00, 0x04, 0x80, 0x00, 0x05, 0x08, 0x01, 0x06, 0x00, 0x05, 0x06, 0x67, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40,
+	0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07, 0x00,
+	0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03,
+	0x13, 0x21, 0x03, 0x01, 0x35, 0x21, 0x15, 0x15, 0x02, 0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd,
+	0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0xfe, 0xd1, 0x02, 0x82, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a,
+	0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x01, 0xbc, 0x94, 0x94, 0x00, 0x00, 0x00, 0x03, 0x00, 0x69,
+	0xff, 0xe7, 0x04, 0x54, 0x05, 0xab, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x28, 0x00, 0xe8, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04,
+	0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01,
+	0x01, 0x02, 0x1d, 0x01, 0x07, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x2a, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x0a, 0x01, 0x09, 0x09,
+	0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41,
+	0x4d, 0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x22, 0x50, 0x58, 0x40, 0x34, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x0a, 0x01,
+	0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04,
+	0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x32, 0x00, 0x08, 0x0a,
+	0x01, 0x09, 0x03, 0x08, 0x09, 0x67, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x00, 0x02,
+	0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00,
+	0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x59, 0x40, 0x12, 0x25, 0x25, 0x25, 0x28, 0x25, 0x28, 0x12, 0x22, 0x22, 0x24, 0x14, 0x23, 0x22,
+	0x23, 0x21, 0x0b, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35,
+	0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x14, 0x33, 0x32, 0x37, 0x17,
+	0x06, 0x23, 0x22, 0x03, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32, 0x01, 0x35, 0x21, 0x15, 0x03,
+	0x10, 0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68,
+	0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89, 0x43, 0x41, 0xfe, 0x83, 0xb7, 0x81, 0xfe, 0x80, 0x02, 0x82,
+	0x8a, 0xa3, 0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9,
+	0x04, 0x6d, 0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x04, 0x8f, 0x94, 0x94, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x15, 0x00, 0x00, 0x05, 0x40, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x16,
+	0x00, 0x74, 0xb5, 0x0a, 0x01, 0x04, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23,
+	0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x06, 0x00, 0x08, 0x00, 0x06, 0x08, 0x69, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x26, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x00, 0x08, 0x04,
+	0x08, 0x00, 0x04, 0x80, 0x00, 0x06, 0x00, 0x08, 0x00, 0x06, 0x08, 0x69, 0x00, 0x04, 0x00, 0x02,
+	0x01, 0x04, 0x02, 0x68, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x00,
+	0x00, 0x15, 0x13, 0x11, 0x10, 0x0f, 0x0d, 0x0c, 0x0b, 0x09, 0x08, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21, 0x03, 0x13, 0x21,
+	0x03, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x15, 0x02, 0x32,
+	0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0xfe, 0xc5, 0x7b, 0x21,
+	0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe,
+	0x66, 0x02, 0x36, 0x02, 0x7a, 0x02, 0xdf, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x03, 0x00, 0x69,
+	0xff, 0xe7, 0x04, 0x54, 0x06, 0x44, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x30, 0x00, 0xf5, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04,
+	0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01,
+	0x01, 0x02, 0x1d, 0x01, 0x07, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x2f, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x6a, 0x0a, 0x01, 0x08, 0x08,
+	0x3a, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x37, 0x00, 0x09, 0x00, 0x0b, 0x03,
+	0x09, 0x0b, 0x69, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x6a, 0x0a, 0x01, 0x08, 0x08, 0x3a,
+	0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61,
+	0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x37, 0x0a, 0x01, 0x08, 0x09, 0x08, 0x85, 0x00, 0x09, 0x00, 0x0b, 0x03,
+	0x09, 0x0b, 0x69, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x6a, 0x00, 0x02, 0x02, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d,
+	0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x12,
+	0x2f, 0x2d, 0x2b, 0x2a, 0x29, 0x27, 0x11, 0x22, 0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0c,
+	0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22,
+	0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22,
+	0x03, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06,
+	0x06, 0x23, 0x22, 0x26, 0x03, 0x10, 0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9, 0xb4,
+	0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89, 0x43, 0x41, 0xfe, 0x83, 0xb7,
+	0x81, 0xfe, 0x93, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x8a, 0xa3,
+	0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d,
+	0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x05, 0xbc, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00,
+	0x00, 0x02, 0x00, 0x13, 0xfe, 0x8e, 0x05, 0x3e, 0x05, 0xc8, 0x00, 0x14, 0x00, 0x17, 0x00, 0x93,
+	0x40, 0x13, 0x17, 0x01, 0x06, 0x00, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01, 0x03, 0x02, 0x03, 0x4c,
+	0x11, 0x01, 0x01, 0x01, 0x4b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x06, 0x00, 0x04,
+	0x01, 0x06, 0x04, 0x68, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x05, 0x02, 0x01, 0x01, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3d, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1c, 0x00, 0x06, 0x00, 0x04, 0x01, 0x06, 0x04, 0x68, 0x00, 0x02, 0x00, 0x03, 0x02,
+	0x03, 0x65, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x05, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06, 0x00, 0x04, 0x01, 0x06, 0x04, 0x68, 0x00,
+	0x02, 0x00, 0x03, 0x02, 0x03, 0x65, 0x07, 0x05, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59,
+	0x40, 0x10, 0x00, 0x00, 0x16, 0x15, 0x00, 0x14, 0x00, 0x14, 0x14, 0x23, 0x23, 0x11, 0x11, 0x08,
+	0x09, 0x1b, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06,
+	0x23, 0x22, 0x35, 0x34, 0x37, 0x03, 0x21, 0x03, 0x13, 0x21, 0x03, 0x13, 0x02, 0x32, 0xd0, 0x02,
+	0x29, 0x77, 0x80, 0x72, 0x38, 0x23, 0x3c, 0x4e, 0xcc, 0x9e, 0x9a, 0xfd, 0xae, 0x9a, 0xd6, 0x01,
+	0xdc, 0xed, 0x05, 0xc8, 0xfa, 0x38, 0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d, 0x55, 0x01,
+	0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x00, 0x00, 0x02, 0x00, 0x5f, 0xfe, 0x8e, 0x04, 0x4a,
+	0x04, 0x56, 0x00, 0x2b, 0x00, 0x33, 0x00, 0xf2, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x0e,
+	0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x2c, 0x01, 0x04, 0x08, 0x2a, 0x19, 0x02, 0x00, 0x04,
+	0x23, 0x01, 0x06, 0x00, 0x24, 0x01, 0x07, 0x06, 0x06, 0x4c, 0x1b, 0x40, 0x1b, 0x0e, 0x01, 0x02,
+	0x03, 0x0d, 0x01, 0x01, 0x02, 0x2c, 0x01, 0x09, 0x08, 0x2a, 0x19, 0x02, 0x00, 0x04, 0x23, 0x01,
+	0x06, 0x00, 0x24, 0x01, 0x07, 0x06, 0x06, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x29,
+	0x00, 0x01, 0x00, 0x08, 0x04, 0x01, 0x08, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x41, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x06,
+	0x06, 0x07, 0x61, 0x00, 0x07, 0x07, 0x3d, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40,
+	0x33, 0x00, 0x01, 0x00, 0x08, 0x09, 0x01, 0x08, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x09, 0x09, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04,
+	0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x61, 0x00, 0x07,
+	0x07, 0x3d, 0x07, 0x4e, 0x1b, 0x40, 0x30, 0x00, 0x01, 0x00, 0x08, 0x09, 0x01, 0x08, 0x69, 0x00,
+	0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x09, 0x09, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61,
+	0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x33, 0x31, 0x26, 0x23, 0x25,
+	0x14, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0a, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35,
+	0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x14,
+	0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x27, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06,
+	0x23, 0x22, 0x35, 0x34, 0x37, 0x26, 0x27, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32, 0x03, 0x06,
+	0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d,
+	0x19, 0x0e, 0x44, 0x51, 0x07, 0x07, 0x5b, 0x72, 0x38, 0x23, 0x3c, 0x4e, 0xcc, 0x99, 0x3e, 0x35,
+	0x41, 0xfe, 0x83, 0xb7, 0x81, 0x8a, 0xa3, 0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51,
+	0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d, 0x20, 0x01, 0x45, 0x56, 0x60, 0x0f, 0x51, 0x1d, 0xa0,
+	0x7b, 0x55, 0x25, 0xd2, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x74,
+	0xff, 0xdb, 0x05, 0x48, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x6b, 0x40, 0x0f, 0x0a, 0x01,
+	0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01,
+	0x05, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x13, 0x24,
+	0x23, 0x24, 0x21, 0x07, 0x09, 0x1b, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x32, 0x05, 0x15, 0x24, 0x23, 0x22, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x01, 0x13, 0x33,
+	0x01, 0x05, 0x48, 0xdb, 0xfe, 0xf2, 0xfe, 0x92, 0xfe, 0x83, 0x01, 0x84, 0x01, 0x6f, 0xd5, 0x01,
+	0x0a, 0xfe, 0xce, 0xb4, 0xff, 0xfe, 0xf4, 0x01, 0x1e, 0x01, 0x05, 0xdf, 0xf1, 0xfd, 0x79, 0xf1,
+	0xe4, 0xfe, 0xbf, 0x4c, 0x71, 0x01, 0x8c, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69,
+	0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x05, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xa0, 0x06, 0x44, 0x00, 0x14, 0x00, 0x18, 0x00, 0x70,
+	0x40, 0x0f, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x03,
+	0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01,
+	0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x0e, 0x15, 0x15, 0x15, 0x18, 0x15, 0x18, 0x13, 0x23, 0x23, 0x24, 0x21, 0x07, 0x09, 0x1b, 0x2b,
+	0x25, 0x06, 0x23, 0x22, 0x00, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x11,
+	0x14, 0x16, 0x33, 0x32, 0x37, 0x01, 0x13, 0x33, 0x01, 0x03, 0x9e, 0xac, 0xb0, 0xda, 0xfe, 0xee,
+	0x01, 0x17, 0xf8, 0x84, 0xa9, 0xa0, 0x64, 0xfe, 0xa6, 0xb1, 0xa0, 0x7c, 0x9d, 0xfe, 0x2d, 0xf1,
+	0xe4, 0xfe, 0xbf, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe,
+	0x5d, 0xc1, 0xd5, 0x45, 0x04, 0x38, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x74,
+	0xff, 0xdb, 0x05, 0x48, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x72, 0x40, 0x13, 0x1b, 0x01,
+	0x05, 0x04, 0x0a, 0x01, 0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x04,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02,
+	0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x16, 0x16,
+	0x16, 0x1d, 0x16, 0x1d, 0x11, 0x13, 0x24, 0x23, 0x24, 0x21, 0x08, 0x09, 0x1c, 0x2b, 0x25, 0x06,
+	0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x05, 0x15, 0x24, 0x23, 0x22, 0x00, 0x11, 0x10,
+	0x00, 0x21, 0x32, 0x37, 0x01, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x05, 0x48, 0xdb, 0xfe,
+	0xf2, 0xfe, 0x92, 0xfe, 0x83, 0x01, 0x84, 0x01, 0x6f, 0xd5, 0x01, 0x0a, 0xfe, 0xce, 0xb4, 0xff,
+	0xfe, 0xf4, 0x01, 0x1e, 0x01, 0x05, 0xdf, 0xf1, 0xfc, 0xaf, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02,
+	0xc9, 0x4c, 0x71, 0x01, 0x8c, 0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1,
+	0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x05, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00,
+	0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xbd, 0x06, 0x44, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x77,
+	0x40, 0x13, 0x1a, 0x01, 0x05, 0x04, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b, 0x02, 0x03, 0x02, 0x00,
+	0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x07, 0x06, 0x02, 0x05,
+	0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x15, 0x15, 0x15, 0x1c, 0x15, 0x1c, 0x11, 0x13, 0x23,
+	0x23, 0x24, 0x21, 0x08, 0x09, 0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x00, 0x35, 0x10, 0x00, 0x33,
+	0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x01, 0x13, 0x33, 0x13,
+	0x23, 0x27, 0x23, 0x07, 0x03, 0x9e, 0xac, 0xb0, 0xda, 0xfe, 0xee, 0x01, 0x17, 0xf8, 0x84, 0xa9,
+	0xa0, 0x64, 0xfe, 0xa6, 0xb1, 0xa0, 0x7c, 0x9d, 0xfd, 0x63, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02,
+	0xc9, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5d, 0xc1,
+	0xd5, 0x45, 0x04, 0x38, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x74,
+	0xff, 0xdb, 0x05, 0x48, 0x07, 0x31, 0x00, 0x15, 0x00, 0x19, 0x00, 0x67, 0x40, 0x0f, 0x0a, 0x01,
+	0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f,
+	0x00, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01,
+	0x00, 0x02, 0x03, 0x01, 0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x40, 0x0e, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x13, 0x24, 0x23, 0x24, 0x21, 0x07,
+	0x09, 0x1b, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x05, 0x15, 0x24,
+	0x23, 0x22, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x01, 0x35, 0x33, 0x15, 0x05, 0x48, 0xdb,
+	0xfe, 0xf2, 0xfe, 0x92, 0xfe, 0x83, 0x01, 0x84, 0x01, 0x6f, 0xd5, 0x01, 0x0a, 0xfe, 0xce, 0xb4,
+	0xff, 0xfe, 0xf4, 0x01, 0x1e, 0x01, 0x05, 0xdf, 0xf1, 0xfd, 0xaa, 0xc5, 0x4c, 0x71, 0x01, 0x8c,
+	0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe,
+	0xc1, 0x81, 0x05, 0x6c, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x03, 0x9e,
+	0x05, 0xdc, 0x00, 0x14, 0x00, 0x18, 0x00, 0x6b, 0x40, 0x0f, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b,
+	0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20,
+	0x06, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x1e, 0x00, 0x04, 0x06, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00,
+	0x4e, 0x59, 0x40, 0x0e, 0x15, 0x15, 0x15, 0x18, 0x15, 0x18, 0x13, 0x23, 0x23, 0x24, 0x21, 0x07,
+	0x09, 0x1b, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x00, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x01, 0x35, 0x33, 0x15, 0x03, 0x9e, 0xac, 0xb0,
+	0xda, 0xfe, 0xee, 0x01, 0x17, 0xf8, 0x84, 0xa9, 0xa0, 0x64, 0xfe, 0xa6, 0xb1, 0xa0, 0x7c, 0x9d,
+	0xfe, 0x5e, 0xc5, 0x21, 0x3a, 0x01, 0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe,
+	0x5d, 0xc1, 0xd5, 0x45, 0x04, 0x4c, 0xc5, 0xc5, 0x00, 0x02, 0x00, 0x74, 0xff, 0xdb, 0x05, 0x48,
+	0x07, 0x8f, 0x00, 0x15, 0x00, 0x1d, 0x00, 0x72, 0x40, 0x13, 0x1b, 0x01, 0x04, 0x05, 0x0a, 0x01,
+	0x02, 0x01, 0x15, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x6a, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x0f, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d,
+	0x11, 0x13, 0x24, 0x23, 0x24, 0x21, 0x08, 0x09, 0x1c, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00, 0x11,
+	0x10, 0x00, 0x21, 0x32, 0x05, 0x15, 0x24, 0x23, 0x22, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37,
+	0x03, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x05, 0x48, 0xdb, 0xfe, 0xf2, 0xfe, 0x92, 0xfe,
+	0x83, 0x01, 0x84, 0x01, 0x6f, 0xd5, 0x01, 0x0a, 0xfe, 0xce, 0xb4, 0xff, 0xfe, 0xf4, 0x01, 0x1e,
+	0x01, 0x05, 0xdf, 0xf1, 0x95, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x4c, 0x71, 0x01, 0x8c,
+	0x01, 0x7c, 0x01, 0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe,
+	0xc1, 0x81, 0x06, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x56,
+	0xff, 0xe7, 0x03, 0xbd, 0x06, 0x44, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x77, 0x40, 0x13, 0x1a, 0x01,
+	0x04, 0x05, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x04,
+	0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80,
+	0x07, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x07,
+	0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x0f, 0x15, 0x15, 0x15, 0x1c, 0x15, 0x1c, 0x11, 0x13, 0x23, 0x23, 0x24, 0x21, 0x08,
+	0x09, 0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x00, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x13, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x03, 0x9e, 0xac, 0xb0, 0xda, 0xfe, 0xee, 0x01, 0x17, 0xf8, 0x84, 0xa9, 0xa0, 0x64, 0xfe, 0xa6,
+	0xb1, 0xa0, 0x7c, 0x9d, 0x1f, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x21, 0x3a, 0x01, 0x3b,
+	0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5d, 0xc1, 0xd5, 0x45, 0x05, 0x79, 0xfe,
+	0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x6a,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x12, 0x00, 0x1a, 0x00, 0x6f, 0xb5, 0x18, 0x01, 0x04, 0x05, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x00, 0x04, 0x85, 0x00, 0x03, 0x03, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x5f, 0x07, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x20, 0x08, 0x06, 0x02,
+	0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03,
+	0x68, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x07, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18,
+	0x13, 0x13, 0x00, 0x00, 0x13, 0x1a, 0x13, 0x1a, 0x17, 0x16, 0x15, 0x14, 0x12, 0x10, 0x0a, 0x08,
+	0x00, 0x07, 0x00, 0x06, 0x21, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x00,
+	0x21, 0x25, 0x33, 0x20, 0x00, 0x11, 0x10, 0x27, 0x26, 0x26, 0x23, 0x23, 0x01, 0x03, 0x23, 0x03,
+	0x33, 0x17, 0x33, 0x37, 0xa5, 0x01, 0xda, 0x02, 0xeb, 0xfe, 0x7b, 0xfe, 0x9d, 0xfe, 0xf5, 0xfc,
+	0x01, 0x0e, 0x01, 0x08, 0x7e, 0x4d, 0xd6, 0xd6, 0x9b, 0x02, 0x91, 0xf1, 0xda, 0xf1, 0x94, 0xc9,
+	0x02, 0xc9, 0x05, 0xc8, 0xfd, 0x3f, 0xfe, 0x8f, 0xfe, 0x6a, 0x9d, 0x01, 0x27, 0x01, 0x2f, 0x01,
+	0x05, 0x95, 0x5b, 0x43, 0x02, 0x64, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x03, 0x00, 0x56,
+	0xff, 0xe7, 0x05, 0x34, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x18, 0x00, 0x22, 0x00, 0x92, 0x40, 0x13,
+	0x1e, 0x1c, 0x1a, 0x19, 0x04, 0x03, 0x04, 0x14, 0x01, 0x00, 0x03, 0x0a, 0x01, 0x00, 0x03, 0x01,
+	0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x04, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x05,
+	0x01, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01,
+	0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05,
+	0x05, 0x39, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40,
+	0x20, 0x06, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02,
+	0x4e, 0x59, 0x59, 0x40, 0x0a, 0x17, 0x11, 0x12, 0x24, 0x22, 0x23, 0x22, 0x07, 0x09, 0x1d, 0x2b,
+	0x01, 0x11, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x35,
+	0x10, 0x00, 0x33, 0x32, 0x17, 0x11, 0x33, 0x11, 0x23, 0x01, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35,
+	0x33, 0x15, 0x10, 0x03, 0x13, 0x88, 0x44, 0xfe, 0xe4, 0x6f, 0x60, 0x81, 0x98, 0x76, 0xce, 0xaa,
+	0xcf, 0x01, 0x0b, 0xf5, 0x5f, 0x5e, 0xc5, 0xc5, 0x01, 0x5b, 0x4d, 0x4d, 0xc6, 0x01, 0x7e, 0x02,
+	0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd, 0x37, 0xe4, 0x01, 0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18,
+	0x01, 0xed, 0xf9, 0xd5, 0x04, 0x65, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x0f, 0x00, 0x00, 0x05, 0x74, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x1a, 0x00, 0x60,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00,
+	0x67, 0x00, 0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f,
+	0x08, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x01, 0x02,
+	0x05, 0x67, 0x06, 0x01, 0x01, 0x07, 0x01, 0x00, 0x04, 0x01, 0x00, 0x67, 0x00, 0x04, 0x04, 0x03,
+	0x5f, 0x08, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x1a, 0x19, 0x18,
+	0x17, 0x16, 0x14, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0a, 0x21, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b,
+	0x33, 0x11, 0x23, 0x35, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x00, 0x21, 0x25, 0x33, 0x20, 0x00,
+	0x11, 0x10, 0x27, 0x26, 0x26, 0x23, 0x23, 0x11, 0x21, 0x15, 0x21, 0xaf, 0xa0, 0xa0, 0x01, 0xda,
+	0x02, 0xeb, 0xfe, 0x7b, 0xfe, 0x9d, 0xfe, 0xf5, 0xfc, 0x01, 0x0e, 0x01, 0x08, 0x7e, 0x4d, 0xd6,
+	0xd6, 0x9b, 0x01, 0x4d, 0xfe, 0xb3, 0x02, 0xa7, 0x9d, 0x02, 0x84, 0xfd, 0x3f, 0xfe, 0x8f, 0xfe,
+	0x6a, 0x9d, 0x01, 0x27, 0x01, 0x2f, 0x01, 0x05, 0x95, 0x5b, 0x43, 0xfe, 0x19, 0x9d, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x6c, 0x06, 0x2b, 0x00, 0x16, 0x00, 0x20, 0x00, 0xaa,
+	0x40, 0x0c, 0x16, 0x01, 0x08, 0x07, 0x18, 0x17, 0x0c, 0x03, 0x09, 0x08, 0x02, 0x4c, 0x4b, 0xb0,
+	0x14, 0x50, 0x58, 0x40, 0x25, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x00,
+	0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x09,
+	0x09, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x29, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x3a,
+	0x4d, 0x00, 0x08, 0x08, 0x07, 0x61, 0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x39, 0x4d,
+	0x00, 0x09, 0x09, 0x06, 0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x1b, 0x40, 0x29, 0x03, 0x01,
+	0x01, 0x04, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x08, 0x08,
+	0x07, 0x61, 0x00, 0x07, 0x07, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x09, 0x09, 0x06,
+	0x61, 0x00, 0x06, 0x06, 0x42, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x20, 0x1e, 0x24, 0x24, 0x22,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x0a, 0x09, 0x1f, 0x2b, 0x01, 0x21, 0x35, 0x21, 0x35, 0x33,
+	0x15, 0x33, 0x15, 0x23, 0x11, 0x23, 0x35, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32,
+	0x17, 0x11, 0x11, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x03, 0x13, 0xfe, 0xbf, 0x01,
+	0x41, 0xc5, 0x94, 0x94, 0xc5, 0x76, 0xce, 0xaa, 0xcf, 0x01, 0x0b, 0xf5, 0x5f, 0x5e, 0x88, 0x44,
+	0xfe, 0xe4, 0x6f, 0x60, 0x81, 0x04, 0xea, 0x7c, 0xc5, 0xc5, 0x7c, 0xfb, 0x16, 0xcb, 0xe4, 0x01,
+	0x27, 0xf2, 0x01, 0x1e, 0x01, 0x38, 0x18, 0xfd, 0x40, 0x02, 0x2b, 0x19, 0xfe, 0x4f, 0xb0, 0xcd,
+	0x00, 0x02, 0x00, 0xbe, 0x00, 0x00, 0x05, 0x1b, 0x07, 0x00, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x70,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67,
+	0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40,
+	0x25, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00,
+	0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08,
+	0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c,
+	0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b,
+	0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15,
+	0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0xfc, 0x75, 0x02, 0x82, 0x05,
+	0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x6c, 0x94, 0x94, 0x00, 0x03, 0x00, 0x56,
+	0xff, 0xe7, 0x03, 0xfb, 0x05, 0xab, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19, 0x00, 0x84, 0x40, 0x0a,
+	0x05, 0x01, 0x05, 0x04, 0x06, 0x01, 0x02, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40,
+	0x29, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x09, 0x01, 0x07, 0x07, 0x06, 0x5f,
+	0x00, 0x06, 0x06, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x06, 0x09,
+	0x01, 0x07, 0x03, 0x06, 0x07, 0x67, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x1a, 0x16, 0x16, 0x00, 0x00, 0x16, 0x19, 0x16, 0x19, 0x18,
+	0x17, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0a, 0x09,
+	0x17, 0x2b, 0x01, 0x10, 0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00,
+	0x33, 0x20, 0x11, 0x07, 0x21, 0x12, 0x21, 0x32, 0x01, 0x35, 0x21, 0x15, 0x03, 0x32, 0xf5, 0xfd,
+	0x18, 0x02, 0xcd, 0xc2, 0xb7, 0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b,
+	0x1c, 0x01, 0x69, 0x9c, 0xfd, 0xbc, 0x02, 0x82, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b,
+	0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x9a,
+	0x94, 0x94, 0x00, 0x00, 0x00, 0x02, 0x00, 0xbe, 0x00, 0x00, 0x05, 0x1b, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x7a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x08, 0x01, 0x06, 0x07, 0x06,
+	0x85, 0x00, 0x07, 0x00, 0x09, 0x00, 0x07, 0x09, 0x69, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03,
+	0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85,
+	0x00, 0x07, 0x00, 0x09, 0x00, 0x07, 0x09, 0x69, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68,
+	0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05,
+	0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x16, 0x14, 0x12, 0x11, 0x10, 0x0e, 0x0d,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x11,
+	0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37,
+	0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03,
+	0x8b, 0xfc, 0x78, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x05, 0xc8,
+	0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x07, 0x8f, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb, 0x06, 0x44, 0x00, 0x04, 0x00, 0x15, 0x00, 0x21,
+	0x00, 0xc3, 0x40, 0x0a, 0x05, 0x01, 0x05, 0x04, 0x06, 0x01, 0x02, 0x05, 0x02, 0x4c, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x2e, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x08, 0x01,
+	0x06, 0x06, 0x3a, 0x4d, 0x00, 0x09, 0x09, 0x07, 0x61, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x42, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x07, 0x00, 0x09, 0x03,
+	0x07, 0x09, 0x69, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x08, 0x01, 0x06, 0x06,
+	0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x2c, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85,
+	0x00, 0x07, 0x00, 0x09, 0x03, 0x07, 0x09, 0x69, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04,
+	0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x1a, 0x00, 0x00, 0x20, 0x1e, 0x1c, 0x1b,
+	0x1a, 0x18, 0x17, 0x16, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04,
+	0x21, 0x0b, 0x09, 0x17, 0x2b, 0x01, 0x10, 0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00,
+	0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07, 0x21, 0x12, 0x21, 0x32, 0x01, 0x33, 0x16, 0x33, 0x32,
+	0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd, 0xc2, 0xb7,
+	0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c, 0xfd,
+	0xa6, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x02, 0x94, 0x01, 0x2f,
+	0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d,
+	0xfe, 0x7d, 0x05, 0xc7, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xbe,
+	0x00, 0x00, 0x05, 0x1b, 0x07, 0x31, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x70, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x02, 0x00, 0x03,
+	0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09,
+	0x01, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c,
+	0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x35, 0x33, 0x15, 0xbe, 0x04, 0x31, 0xfc,
+	0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0xfd, 0x5f, 0xc5, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b,
+	0xfd, 0xe8, 0x9d, 0x06, 0x6c, 0xc5, 0xc5, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb,
+	0x05, 0xdc, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19, 0x00, 0x84, 0x40, 0x0a, 0x05, 0x01, 0x05, 0x04,
+	0x06, 0x01, 0x02, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x08, 0x01, 0x01,
+	0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x09, 0x01, 0x07, 0x07, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x38,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x06, 0x09, 0x01, 0x07, 0x03, 0x06,
+	0x07, 0x67, 0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e,
+	0x59, 0x40, 0x1a, 0x16, 0x16, 0x00, 0x00, 0x16, 0x19, 0x16, 0x19, 0x18, 0x17, 0x15, 0x13, 0x12,
+	0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0a, 0x09, 0x17, 0x2b, 0x01, 0x10,
+	0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07,
+	0x21, 0x12, 0x21, 0x32, 0x01, 0x35, 0x33, 0x15, 0x03, 0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd, 0xc2,
+	0xb7, 0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c,
+	0xfe, 0x90, 0xc5, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01,
+	0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04, 0x9a, 0xc5, 0xc5, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xbe, 0xfe, 0x8e, 0x05, 0x1b, 0x05, 0xc8, 0x00, 0x19, 0x00, 0xa7, 0x40, 0x0a,
+	0x12, 0x01, 0x06, 0x05, 0x13, 0x01, 0x07, 0x06, 0x02, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40,
+	0x29, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x4d, 0x00,
+	0x06, 0x06, 0x07, 0x61, 0x00, 0x07, 0x07, 0x3d, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x26, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x06, 0x00, 0x07, 0x06, 0x07,
+	0x65, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x00, 0x00, 0x01, 0x02,
+	0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x06, 0x00, 0x07, 0x06,
+	0x07, 0x65, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59,
+	0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x23, 0x23, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21,
+	0x15, 0x23, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x35, 0x34, 0x37, 0xbe,
+	0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0x7e, 0x80, 0x72, 0x38, 0x23, 0x3c,
+	0x4e, 0xcc, 0x9e, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x4d, 0x66, 0x60, 0x0f,
+	0x51, 0x1d, 0xa0, 0x7d, 0x55, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x56, 0xfe, 0x8e, 0x03, 0xfb,
+	0x04, 0x56, 0x00, 0x1e, 0x00, 0x23, 0x00, 0x7f, 0x40, 0x12, 0x00, 0x01, 0x05, 0x04, 0x01, 0x01,
+	0x02, 0x05, 0x09, 0x01, 0x00, 0x02, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x28, 0x50,
+	0x58, 0x40, 0x28, 0x08, 0x01, 0x07, 0x00, 0x04, 0x05, 0x07, 0x04, 0x67, 0x00, 0x06, 0x06, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x4d,
+	0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3d, 0x01, 0x4e, 0x1b, 0x40, 0x25, 0x08, 0x01,
+	0x07, 0x00, 0x04, 0x05, 0x07, 0x04, 0x67, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x65, 0x00, 0x06,
+	0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x42, 0x02, 0x4e, 0x59, 0x40, 0x10, 0x1f, 0x1f, 0x1f, 0x23, 0x1f, 0x23, 0x22, 0x21, 0x12, 0x24,
+	0x23, 0x23, 0x26, 0x09, 0x09, 0x1d, 0x2b, 0x25, 0x15, 0x06, 0x07, 0x06, 0x15, 0x14, 0x33, 0x32,
+	0x37, 0x15, 0x06, 0x23, 0x22, 0x35, 0x34, 0x37, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20,
+	0x11, 0x07, 0x21, 0x12, 0x21, 0x32, 0x03, 0x10, 0x23, 0x22, 0x03, 0x03, 0xf5, 0x7f, 0x7b, 0x64,
+	0x72, 0x38, 0x23, 0x3c, 0x4e, 0xcc, 0x74, 0x06, 0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb,
+	0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c, 0x14, 0xf5, 0xfd, 0x18, 0xbf, 0x9c, 0x27, 0x0d, 0x47,
+	0x5b, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x6c, 0x4d, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd,
+	0xe7, 0x3d, 0xfe, 0x7d, 0x02, 0x17, 0x01, 0x2f, 0xfe, 0xd1, 0x00, 0x00, 0x00, 0x02, 0x00, 0xbf,
+	0x00, 0x00, 0x05, 0x1c, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x7f, 0xb5, 0x11, 0x01, 0x06,
+	0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2a, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07,
+	0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01,
+	0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05,
+	0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x28, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06,
+	0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04,
+	0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59,
+	0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b,
+	0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21,
+	0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x03, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0xbf,
+	0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0xe2, 0xf1, 0xda, 0xf1, 0x94, 0xc9,
+	0x02, 0xc9, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x07, 0x8f, 0xfe, 0xbf, 0x01,
+	0x41, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb, 0x06, 0x44, 0x00, 0x04,
+	0x00, 0x15, 0x00, 0x1d, 0x00, 0x91, 0x40, 0x0e, 0x1b, 0x01, 0x06, 0x07, 0x05, 0x01, 0x05, 0x04,
+	0x06, 0x01, 0x02, 0x05, 0x03, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x06, 0x07,
+	0x03, 0x07, 0x06, 0x03, 0x80, 0x09, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x0a, 0x08,
+	0x02, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x1b, 0x40, 0x2a, 0x0a, 0x08, 0x02,
+	0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x03, 0x06, 0x85, 0x09, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01,
+	0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x1c, 0x16, 0x16, 0x00, 0x00, 0x16, 0x1d,
+	0x16, 0x1d, 0x1a, 0x19, 0x18, 0x17, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04,
+	0x00, 0x04, 0x21, 0x0b, 0x09, 0x17, 0x2b, 0x01, 0x10, 0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23,
+	0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07, 0x21, 0x12, 0x21, 0x32, 0x13, 0x03, 0x23,
+	0x03, 0x33, 0x17, 0x33, 0x37, 0x03, 0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd, 0xc2, 0xb7, 0xfb, 0xfe,
+	0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c, 0x51, 0xf1, 0xda,
+	0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01,
+	0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x05, 0xc7, 0xfe, 0xbf, 0x01,
+	0x41, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0x7d, 0x07, 0x8f, 0x00, 0x17,
+	0x00, 0x1f, 0x00, 0x90, 0x40, 0x16, 0x1d, 0x01, 0x07, 0x06, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01,
+	0x05, 0x02, 0x14, 0x01, 0x03, 0x04, 0x01, 0x01, 0x00, 0x03, 0x05, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2a, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x01, 0x07, 0x85, 0x09,
+	0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x28,
+	0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x01, 0x07, 0x85, 0x00, 0x01, 0x00, 0x02,
+	0x05, 0x01, 0x02, 0x6a, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x18, 0x18, 0x18, 0x00, 0x00, 0x18,
+	0x1f, 0x18, 0x1f, 0x1c, 0x1b, 0x1a, 0x19, 0x00, 0x17, 0x00, 0x17, 0x12, 0x23, 0x23, 0x23, 0x22,
+	0x0b, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x21, 0x20, 0x11, 0x10, 0x00, 0x21, 0x20, 0x05, 0x15,
+	0x24, 0x23, 0x20, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23, 0x35, 0x01, 0x13, 0x33, 0x13,
+	0x23, 0x27, 0x23, 0x07, 0x05, 0x7d, 0xfe, 0xf8, 0xfe, 0xef, 0xfc, 0xf9, 0x01, 0x92, 0x01, 0x75,
+	0x01, 0x08, 0x01, 0x0f, 0xfe, 0xc6, 0xdd, 0xfd, 0xda, 0x01, 0x2f, 0x01, 0x1b, 0x74, 0xb0, 0xf7,
+	0xfe, 0x50, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06,
+	0x01, 0x78, 0x01, 0x94, 0x43, 0xc2, 0x68, 0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79,
+	0x9a, 0x03, 0x9e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x5d,
+	0xfe, 0x5c, 0x03, 0xdf, 0x06, 0x44, 0x00, 0x09, 0x00, 0x22, 0x00, 0x2a, 0x00, 0xcf, 0x40, 0x14,
+	0x28, 0x01, 0x08, 0x07, 0x0a, 0x01, 0x00, 0x03, 0x01, 0x00, 0x1e, 0x01, 0x06, 0x02, 0x1d, 0x01,
+	0x05, 0x06, 0x04, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2f, 0x0a, 0x09, 0x02, 0x08, 0x07,
+	0x03, 0x07, 0x08, 0x03, 0x80, 0x00, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04,
+	0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00,
+	0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58,
+	0x40, 0x33, 0x0a, 0x09, 0x02, 0x08, 0x07, 0x03, 0x07, 0x08, 0x03, 0x80, 0x00, 0x07, 0x07, 0x3a,
+	0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d,
+	0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00,
+	0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x2e, 0x00, 0x07, 0x08, 0x07, 0x85, 0x0a, 0x09, 0x02,
+	0x08, 0x03, 0x08, 0x85, 0x00, 0x01, 0x00, 0x02, 0x06, 0x01, 0x02, 0x69, 0x00, 0x04, 0x04, 0x3b,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x23, 0x23, 0x23, 0x2a, 0x23, 0x2a,
+	0x11, 0x13, 0x23, 0x25, 0x11, 0x24, 0x22, 0x23, 0x22, 0x0b, 0x09, 0x1f, 0x2b, 0x01, 0x11, 0x26,
+	0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33,
+	0x32, 0x17, 0x33, 0x11, 0x10, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x20, 0x11,
+	0x01, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x03, 0x1a, 0x88, 0x43, 0xfe, 0xe3, 0x70, 0x5f,
+	0x81, 0x98, 0x75, 0xcf, 0xa8, 0xd1, 0x01, 0x0b, 0xf3, 0x61, 0x5e, 0xc5, 0x35, 0x48, 0x81, 0xfe,
+	0xf0, 0xbe, 0xaf, 0xd1, 0x99, 0x01, 0x4c, 0xfd, 0xdf, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9,
+	0x01, 0xb0, 0x01, 0xf9, 0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01, 0x23, 0xea, 0x01, 0x0b,
+	0x01, 0x25, 0x18, 0xfc, 0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab, 0x51, 0x01, 0x61, 0x04,
+	0xb1, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0x7d,
+	0x07, 0x8f, 0x00, 0x17, 0x00, 0x23, 0x00, 0x8e, 0x40, 0x12, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01,
+	0x05, 0x02, 0x14, 0x01, 0x03, 0x04, 0x01, 0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2c, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x01, 0x07, 0x09,
+	0x69, 0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b,
+	0x40, 0x2a, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x01, 0x07, 0x09, 0x69,
+	0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x6a, 0x0a, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04,
+	0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x16, 0x00,
+	0x00, 0x22, 0x20, 0x1e, 0x1d, 0x1c, 0x1a, 0x19, 0x18, 0x00, 0x17, 0x00, 0x17, 0x12, 0x23, 0x23,
+	0x23, 0x22, 0x0b, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x21, 0x20, 0x11, 0x10, 0x00, 0x21, 0x20,
+	0x05, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23, 0x35, 0x01, 0x33,
+	0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x05, 0x7d, 0xfe, 0xf8, 0xfe, 0xef,
+	0xfc, 0xf9, 0x01, 0x92, 0x01, 0x75, 0x01, 0x08, 0x01, 0x0f, 0xfe, 0xc6, 0xdd, 0xfd, 0xda, 0x01,
+	0x2f, 0x01, 0x1b, 0x74, 0xb0, 0xf7, 0xfe, 0x61, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6,
+	0x88, 0x88, 0xb5, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01, 0x78, 0x01, 0x94, 0x43, 0xc2,
+	0x68, 0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a, 0x04, 0xdf, 0xad, 0xad, 0x92,
+	0xaf, 0xae, 0x00, 0x00, 0x00, 0x03, 0x00, 0x5d, 0xfe, 0x5c, 0x03, 0xdf, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x22, 0x00, 0x2e, 0x00, 0xcb, 0x40, 0x10, 0x0a, 0x01, 0x00, 0x03, 0x01, 0x00, 0x1e, 0x01,
+	0x06, 0x02, 0x1d, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x30, 0x09,
+	0x01, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x0a, 0x0a, 0x08, 0x61, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x32, 0x00, 0x08, 0x00, 0x0a, 0x03, 0x08, 0x0a, 0x69, 0x09,
+	0x01, 0x07, 0x07, 0x3a, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06,
+	0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x30, 0x09, 0x01, 0x07, 0x08,
+	0x07, 0x85, 0x00, 0x08, 0x00, 0x0a, 0x03, 0x08, 0x0a, 0x69, 0x00, 0x01, 0x00, 0x02, 0x06, 0x01,
+	0x02, 0x69, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x10,
+	0x2d, 0x2b, 0x29, 0x28, 0x21, 0x12, 0x23, 0x25, 0x11, 0x24, 0x22, 0x23, 0x22, 0x0b, 0x09, 0x1f,
+	0x2b, 0x01, 0x11, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02,
+	0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x33, 0x11, 0x10, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x35,
+	0x16, 0x33, 0x20, 0x11, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26,
+	0x03, 0x1a, 0x88, 0x43, 0xfe, 0xe3, 0x70, 0x5f, 0x81, 0x98, 0x75, 0xcf, 0xa8, 0xd1, 0x01, 0x0b,
+	0xf3, 0x61, 0x5e, 0xc5, 0x35, 0x48, 0x81, 0xfe, 0xf0, 0xbe, 0xaf, 0xd1, 0x99, 0x01, 0x4c, 0xfd,
+	0xf0, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x01, 0xb0, 0x01, 0xf9,
+	0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01, 0x23, 0xea, 0x01, 0x0b, 0x01, 0x25, 0x18, 0xfc,
+	0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab, 0x51, 0x01, 0x61, 0x05, 0xf2, 0xad, 0xad, 0x92,
+	0xaf, 0xae, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0x7d, 0x07, 0x31, 0x00, 0x17,
+	0x00, 0x1b, 0x00, 0x84, 0x40, 0x12, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01, 0x05, 0x02, 0x14, 0x01,
+	0x03, 0x04, 0x01, 0x01, 0x00, 0x03, 0x04, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00,
+	0x06, 0x09, 0x01, 0x07, 0x01, 0x06, 0x07, 0x67, 0x08, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04,
+	0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x06, 0x09, 0x01, 0x07, 0x01, 0x06,
+	0x07, 0x67, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01, 0x02, 0x69, 0x08, 0x01, 0x05, 0x00, 0x04, 0x03,
+	0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x16, 0x18, 0x18, 0x00, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x00, 0x17, 0x00, 0x17, 0x12,
+	0x23, 0x23, 0x23, 0x22, 0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x21, 0x20, 0x11, 0x10, 0x00,
+	0x21, 0x20, 0x05, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23, 0x35,
+	0x03, 0x35, 0x33, 0x15, 0x05, 0x7d, 0xfe, 0xf8, 0xfe, 0xef, 0xfc, 0xf9, 0x01, 0x92, 0x01, 0x75,
+	0x01, 0x08, 0x01, 0x0f, 0xfe, 0xc6, 0xdd, 0xfd, 0xda, 0x01, 0x2f, 0x01, 0x1b, 0x74, 0xb0, 0xf7,
+	0xb5, 0xc5, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01, 0x78, 0x01, 0x94, 0x43, 0xc2, 0x68,
+	0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a, 0x03, 0xbc, 0xc5, 0xc5, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x5d, 0xfe, 0x5c, 0x03, 0xdf, 0x05, 0xdc, 0x00, 0x09, 0x00, 0x22, 0x00, 0x26,
+	0x00, 0xf5, 0x40, 0x10, 0x0a, 0x01, 0x00, 0x03, 0x01, 0x00, 0x1e, 0x01, 0x06, 0x02, 0x1d, 0x01,
+	0x05, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2b, 0x09, 0x01, 0x08, 0x08, 0x07,
+	0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41,
+	0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x2f, 0x09, 0x01,
+	0x08, 0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00,
+	0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x2d, 0x00, 0x01, 0x00, 0x02, 0x06, 0x01, 0x02, 0x69, 0x09, 0x01, 0x08,
+	0x08, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43,
+	0x05, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x07, 0x09, 0x01, 0x08, 0x03, 0x07, 0x08, 0x67, 0x00, 0x01,
+	0x00, 0x02, 0x06, 0x01, 0x02, 0x69, 0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x11, 0x23, 0x23, 0x23, 0x26, 0x23, 0x26, 0x13, 0x23, 0x25, 0x11, 0x24,
+	0x22, 0x23, 0x22, 0x0a, 0x09, 0x1e, 0x2b, 0x01, 0x11, 0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33,
+	0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x33, 0x11, 0x10, 0x06,
+	0x07, 0x06, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x20, 0x11, 0x01, 0x35, 0x33, 0x15, 0x03, 0x1a,
+	0x88, 0x43, 0xfe, 0xe3, 0x70, 0x5f, 0x81, 0x98, 0x75, 0xcf, 0xa8, 0xd1, 0x01, 0x0b, 0xf3, 0x61,
+	0x5e, 0xc5, 0x35, 0x48, 0x81, 0xfe, 0xf0, 0xbe, 0xaf, 0xd1, 0x99, 0x01, 0x4c, 0xfe, 0xce, 0xc5,
+	0x01, 0xb0, 0x01, 0xf9, 0x19, 0xfe, 0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01, 0x23, 0xea, 0x01, 0x0b,
+	0x01, 0x25, 0x18, 0xfc, 0xea, 0xff, 0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab, 0x51, 0x01, 0x61, 0x04,
+	0xc5, 0xc5, 0xc5, 0x00, 0x00, 0x02, 0x00, 0x5d, 0xfe, 0x50, 0x05, 0x7d, 0x05, 0xed, 0x00, 0x17,
+	0x00, 0x25, 0x00, 0xd5, 0x40, 0x1b, 0x0a, 0x01, 0x02, 0x01, 0x0b, 0x01, 0x05, 0x02, 0x14, 0x01,
+	0x03, 0x04, 0x01, 0x01, 0x00, 0x03, 0x1f, 0x19, 0x02, 0x06, 0x07, 0x18, 0x01, 0x08, 0x06, 0x06,
+	0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x2f, 0x00, 0x07, 0x00, 0x06, 0x06, 0x07, 0x72, 0x09,
+	0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x4d, 0x00, 0x06, 0x06, 0x08,
+	0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x30, 0x00,
+	0x07, 0x00, 0x06, 0x00, 0x07, 0x06, 0x80, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x3f, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b,
+	0x40, 0x2e, 0x00, 0x07, 0x00, 0x06, 0x00, 0x07, 0x06, 0x80, 0x00, 0x01, 0x00, 0x02, 0x05, 0x01,
+	0x02, 0x69, 0x09, 0x01, 0x05, 0x00, 0x04, 0x03, 0x05, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e,
+	0x59, 0x59, 0x40, 0x14, 0x00, 0x00, 0x25, 0x23, 0x21, 0x20, 0x1c, 0x1a, 0x00, 0x17, 0x00, 0x17,
+	0x12, 0x23, 0x23, 0x23, 0x22, 0x0a, 0x09, 0x1b, 0x2b, 0x01, 0x11, 0x04, 0x21, 0x20, 0x11, 0x10,
+	0x00, 0x21, 0x20, 0x05, 0x15, 0x24, 0x23, 0x20, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x11, 0x23,
+	0x35, 0x03, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0x05,
+	0x7d, 0xfe, 0xf8, 0xfe, 0xef, 0xfc, 0xf9, 0x01, 0x92, 0x01, 0x75, 0x01, 0x08, 0x01, 0x0f, 0xfe,
+	0xc6, 0xdd, 0xfd, 0xda, 0x01, 0x2f, 0x01, 0x1b, 0x74, 0xb0, 0xf7, 0xf9, 0x32, 0x30, 0x6d, 0x9e,
+	0x01, 0x25, 0xd9, 0x3e, 0x02, 0xb0, 0xfd, 0x78, 0x4d, 0x03, 0x06, 0x01, 0x78, 0x01, 0x94, 0x43,
+	0xc2, 0x68, 0xfd, 0x94, 0xfe, 0xd4, 0xfe, 0xc0, 0x25, 0x01, 0x79, 0x9a, 0xfb, 0xab, 0x55, 0x09,
+	0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x00, 0x03, 0x00, 0x5d, 0xfe, 0x5c, 0x03, 0xdf,
+	0x06, 0xc9, 0x00, 0x09, 0x00, 0x22, 0x00, 0x2c, 0x00, 0xb0, 0x40, 0x17, 0x0a, 0x01, 0x00, 0x03,
+	0x01, 0x00, 0x1e, 0x01, 0x06, 0x02, 0x1d, 0x01, 0x05, 0x06, 0x03, 0x4c, 0x28, 0x26, 0x24, 0x23,
+	0x04, 0x07, 0x4a, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x25, 0x00, 0x07, 0x03, 0x07, 0x85, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x29, 0x00, 0x07, 0x03, 0x07, 0x85, 0x00, 0x04, 0x04, 0x3b,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e,
+	0x1b, 0x40, 0x27, 0x00, 0x07, 0x03, 0x07, 0x85, 0x00, 0x01, 0x00, 0x02, 0x06, 0x01, 0x02, 0x69,
+	0x00, 0x04, 0x04, 0x3b, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00,
+	0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x18, 0x23,
+	0x25, 0x11, 0x24, 0x22, 0x23, 0x22, 0x08, 0x09, 0x1e, 0x2b, 0x01, 0x11, 0x26, 0x23, 0x20, 0x11,
+	0x14, 0x16, 0x33, 0x32, 0x37, 0x06, 0x23, 0x22, 0x02, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x33,
+	0x11, 0x10, 0x06, 0x07, 0x06, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x20, 0x11, 0x03, 0x15, 0x06,
+	0x15, 0x15, 0x33, 0x15, 0x23, 0x35, 0x10, 0x03, 0x1a, 0x88, 0x43, 0xfe, 0xe3, 0x70, 0x5f, 0x81,
+	0x98, 0x75, 0xcf, 0xa8, 0xd1, 0x01, 0x0b, 0xf3, 0x61, 0x5e, 0xc5, 0x35, 0x48, 0x81, 0xfe, 0xf0,
+	0xbe, 0xaf, 0xd1, 0x99, 0x01, 0x4c, 0x6c, 0x4d, 0x4d, 0xc6, 0x01, 0xb0, 0x01, 0xf9, 0x19, 0xfe,
+	0x7c, 0xad, 0xcc, 0x38, 0xe4, 0x01, 0x23, 0xea, 0x01, 0x0b, 0x01, 0x25, 0x18, 0xfc, 0xea, 0xff,
+	0x00, 0xf4, 0x4e, 0x8a, 0x3b, 0xab, 0x51, 0x01, 0x61, 0x06, 0x77, 0x3b, 0x15, 0xa0, 0x11, 0xc5,
+	0xab, 0x01, 0x07, 0x00, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x21, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x71, 0xb5, 0x11, 0x01, 0x07, 0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07, 0x00, 0x07, 0x85, 0x00, 0x01,
+	0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x05, 0x02, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06, 0x07, 0x06, 0x85, 0x0a, 0x08, 0x02, 0x07,
+	0x00, 0x07, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x68, 0x02, 0x01, 0x00, 0x00, 0x03,
+	0x5f, 0x09, 0x05, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00,
+	0x0c, 0x13, 0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21,
+	0x11, 0x13, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0xa5, 0xd2, 0x02, 0xd9, 0xd1, 0xd1, 0xfd,
+	0x27, 0x0e, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8, 0xfd, 0x90, 0x02, 0x70, 0xfa,
+	0x38, 0x02, 0xbb, 0xfd, 0x45, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xe5, 0x07, 0xcf, 0x00, 0x10, 0x00, 0x18, 0x00, 0x78,
+	0x40, 0x0b, 0x16, 0x01, 0x06, 0x05, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85,
+	0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08,
+	0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09,
+	0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x17,
+	0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10,
+	0x23, 0x12, 0x22, 0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x36, 0x33, 0x20, 0x11,
+	0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x03, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23,
+	0x07, 0x9a, 0xc5, 0x9d, 0xd2, 0x01, 0x17, 0xc6, 0x37, 0x4c, 0xa7, 0x96, 0x7b, 0xf1, 0xda, 0xf1,
+	0x94, 0xc9, 0x02, 0xc9, 0x06, 0x2b, 0xfd, 0x47, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83,
+	0x5e, 0xee, 0xfd, 0x41, 0x06, 0x8e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x02, 0x00, 0x11,
+	0x00, 0x00, 0x05, 0xb5, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x17, 0x00, 0x68, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x22, 0x07, 0x05, 0x02, 0x03, 0x08, 0x02, 0x02, 0x01, 0x00, 0x03, 0x01, 0x67, 0x00,
+	0x00, 0x00, 0x0a, 0x09, 0x00, 0x0a, 0x67, 0x06, 0x01, 0x04, 0x04, 0x38, 0x4d, 0x0c, 0x0b, 0x02,
+	0x09, 0x09, 0x39, 0x09, 0x4e, 0x1b, 0x40, 0x22, 0x07, 0x05, 0x02, 0x03, 0x08, 0x02, 0x02, 0x01,
+	0x00, 0x03, 0x01, 0x67, 0x00, 0x00, 0x00, 0x0a, 0x09, 0x00, 0x0a, 0x67, 0x06, 0x01, 0x04, 0x04,
+	0x09, 0x5f, 0x0c, 0x0b, 0x02, 0x09, 0x09, 0x3c, 0x09, 0x4e, 0x59, 0x40, 0x16, 0x04, 0x04, 0x04,
+	0x17, 0x04, 0x17, 0x16, 0x15, 0x14, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x12, 0x11, 0x10,
+	0x0d, 0x09, 0x1f, 0x2b, 0x01, 0x21, 0x35, 0x21, 0x03, 0x11, 0x23, 0x35, 0x33, 0x35, 0x33, 0x15,
+	0x21, 0x35, 0x33, 0x15, 0x33, 0x15, 0x23, 0x11, 0x23, 0x11, 0x21, 0x11, 0x01, 0x77, 0x02, 0xd9,
+	0xfd, 0x27, 0xd2, 0x94, 0x94, 0xd2, 0x02, 0xd9, 0xd1, 0x94, 0x94, 0xd1, 0xfd, 0x27, 0x03, 0x58,
+	0xfe, 0xfb, 0xaa, 0x04, 0x56, 0x7c, 0xf6, 0xf6, 0xf6, 0xf6, 0x7c, 0xfb, 0xaa, 0x02, 0xbb, 0xfd,
+	0x45, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x06, 0x00, 0x00, 0x03, 0xe5, 0x06, 0x2b, 0x00, 0x18,
+	0x00, 0x69, 0xb6, 0x17, 0x0b, 0x02, 0x06, 0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x21, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x3a, 0x4d,
+	0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x09, 0x08, 0x02, 0x06, 0x06, 0x39,
+	0x06, 0x4e, 0x1b, 0x40, 0x21, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00,
+	0x02, 0x02, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x41, 0x4d, 0x09, 0x08,
+	0x02, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x18, 0x00, 0x18, 0x23,
+	0x12, 0x22, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33, 0x11, 0x23, 0x35, 0x33,
+	0x35, 0x33, 0x15, 0x21, 0x15, 0x21, 0x11, 0x36, 0x33, 0x20, 0x11, 0x11, 0x23, 0x11, 0x34, 0x26,
+	0x23, 0x22, 0x07, 0x11, 0x9a, 0x94, 0x94, 0xc5, 0x01, 0x28, 0xfe, 0xd8, 0x9d, 0xd2, 0x01, 0x17,
+	0xc6, 0x37, 0x4c, 0xa7, 0x96, 0x04, 0xea, 0x7c, 0xc5, 0xc5, 0x7c, 0xfe, 0x88, 0xe4, 0xfe, 0xb6,
+	0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x4c,
+	0x00, 0x00, 0x02, 0xe6, 0x07, 0x4c, 0x00, 0x0b, 0x00, 0x1f, 0x00, 0x80, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2b, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x09, 0x06, 0x0a, 0x69, 0x00, 0x07, 0x0d, 0x0b,
+	0x02, 0x09, 0x02, 0x07, 0x09, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38,
+	0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0c, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40,
+	0x29, 0x08, 0x01, 0x06, 0x00, 0x0a, 0x09, 0x06, 0x0a, 0x69, 0x00, 0x07, 0x0d, 0x0b, 0x02, 0x09,
+	0x02, 0x07, 0x09, 0x6a, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00,
+	0x00, 0x05, 0x5f, 0x0c, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x0c, 0x0c, 0x00,
+	0x00, 0x0c, 0x1f, 0x0c, 0x1f, 0x1e, 0x1c, 0x19, 0x17, 0x16, 0x15, 0x14, 0x12, 0x0f, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0e, 0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11,
+	0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33,
+	0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x7c, 0xb4, 0xb4, 0x02,
+	0x39, 0xb4, 0xb4, 0xfd, 0x97, 0x0c, 0xad, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x7b, 0x0b,
+	0xae, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d,
+	0x06, 0x62, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x02, 0xff, 0xaf,
+	0x00, 0x00, 0x02, 0x49, 0x05, 0xed, 0x00, 0x03, 0x00, 0x17, 0x00, 0x68, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x21, 0x00, 0x03, 0x09, 0x07, 0x02, 0x05, 0x00, 0x03, 0x05, 0x6a, 0x00, 0x06, 0x06,
+	0x02, 0x61, 0x04, 0x01, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x01, 0x01,
+	0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x04, 0x01, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69,
+	0x00, 0x03, 0x09, 0x07, 0x02, 0x05, 0x00, 0x03, 0x05, 0x6a, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x08,
+	0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x17, 0x04,
+	0x17, 0x16, 0x14, 0x11, 0x0f, 0x0e, 0x0d, 0x0c, 0x0a, 0x07, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x0a, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33,
+	0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x9a, 0xc5, 0xfe, 0x50,
+	0x0c, 0xad, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x7b, 0x0b, 0xae, 0x49, 0x3e, 0x3c, 0x38,
+	0x1e, 0x44, 0x09, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27,
+	0x25, 0x22, 0x6e, 0x00, 0x00, 0x02, 0x00, 0x58, 0x00, 0x00, 0x02, 0xda, 0x07, 0x00, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x64, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06, 0x09, 0x01, 0x07,
+	0x02, 0x06, 0x07, 0x67, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04,
+	0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1f, 0x00,
+	0x06, 0x09, 0x01, 0x07, 0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15,
+	0x23, 0x11, 0x33, 0x15, 0x01, 0x35, 0x21, 0x15, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0xfd,
+	0xa3, 0x02, 0x82, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x6c, 0x94, 0x94, 0x00,
+	0x00, 0x02, 0xff, 0xbb, 0x00, 0x00, 0x02, 0x3d, 0x05, 0xab, 0x00, 0x03, 0x00, 0x07, 0x00, 0x6a,
+	0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02,
+	0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x04, 0x01, 0x01, 0x01,
+	0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x35,
+	0x21, 0x15, 0x9a, 0xc5, 0xfe, 0x5c, 0x02, 0x82, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x17, 0x94, 0x94,
+	0x00, 0x02, 0x00, 0x4c, 0x00, 0x00, 0x02, 0xe6, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x6e,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00,
+	0x09, 0x02, 0x07, 0x09, 0x69, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d,
+	0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x24,
+	0x08, 0x01, 0x06, 0x07, 0x06, 0x85, 0x00, 0x07, 0x00, 0x09, 0x02, 0x07, 0x09, 0x69, 0x00, 0x02,
+	0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x68, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05,
+	0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x16, 0x14, 0x12, 0x11, 0x10, 0x0e, 0x0d,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x35,
+	0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37,
+	0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0xfd, 0x97, 0x7b,
+	0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb,
+	0x72, 0x9d, 0x07, 0x8f, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xaf,
+	0x00, 0x00, 0x02, 0x49, 0x06, 0x44, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x9c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x1c, 0x04, 0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x69, 0x04,
+	0x01, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x04, 0x01, 0x02, 0x03, 0x02, 0x85, 0x00,
+	0x03, 0x00, 0x05, 0x00, 0x03, 0x05, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1a, 0x04, 0x01, 0x02, 0x03, 0x02, 0x85, 0x00, 0x03, 0x00, 0x05,
+	0x00, 0x03, 0x05, 0x69, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0e, 0x0c, 0x0a, 0x09, 0x08, 0x06, 0x05, 0x04, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x16, 0x33,
+	0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x9a, 0xc5, 0xfe, 0x50, 0x7b, 0x21, 0xb1, 0xb2,
+	0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x04, 0x3e, 0xfb, 0xc2, 0x06, 0x44, 0xad, 0xad, 0x92,
+	0xaf, 0xae, 0x00, 0x00, 0x00, 0x01, 0x00, 0x7c, 0xfe, 0x8e, 0x02, 0xb5, 0x05, 0xc8, 0x00, 0x19,
+	0x00, 0x95, 0x40, 0x0a, 0x12, 0x01, 0x06, 0x05, 0x13, 0x01, 0x07, 0x06, 0x02, 0x4c, 0x4b, 0xb0,
+	0x28, 0x50, 0x58, 0x40, 0x23, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d,
+	0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x06, 0x06,
+	0x07, 0x61, 0x00, 0x07, 0x07, 0x3d, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20,
+	0x00, 0x06, 0x00, 0x07, 0x06, 0x07, 0x65, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e,
+	0x1b, 0x40, 0x1e, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x06, 0x00, 0x07,
+	0x06, 0x07, 0x65, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x08, 0x02, 0x05, 0x05, 0x3c, 0x05,
+	0x4e, 0x59, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x23, 0x23, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23,
+	0x11, 0x33, 0x15, 0x23, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x35, 0x34,
+	0x37, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0x7e, 0x80, 0x72, 0x38, 0x23, 0x3c, 0x4e, 0xcc,
+	0x9e, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0,
+	0x7d, 0x55, 0x00, 0x00, 0x00, 0x02, 0x00, 0x56, 0xfe, 0x8e, 0x01, 0xac, 0x05, 0xdc, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x8e, 0x40, 0x0f, 0x06, 0x01, 0x00, 0x02, 0x07, 0x01, 0x01, 0x00, 0x02, 0x4c,
+	0x00, 0x01, 0x02, 0x01, 0x4b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01, 0x05, 0x05,
+	0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x39,
+	0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3d, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1d, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x65, 0x06, 0x01, 0x05, 0x05, 0x04,
+	0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x39, 0x02,
+	0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x06, 0x01, 0x05, 0x03, 0x04, 0x05, 0x67, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x65, 0x00, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59,
+	0x59, 0x40, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11, 0x14, 0x12, 0x11, 0x13, 0x23, 0x23, 0x07, 0x09,
+	0x1b, 0x2b, 0x21, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x35, 0x34, 0x37,
+	0x23, 0x11, 0x33, 0x27, 0x35, 0x33, 0x15, 0x01, 0x5f, 0x80, 0x72, 0x38, 0x23, 0x3c, 0x4e, 0xcc,
+	0x9e, 0x5a, 0xc5, 0xcf, 0xd9, 0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d, 0x55, 0x04, 0x3e,
+	0xc5, 0xd9, 0xd9, 0x00, 0x00, 0x02, 0x00, 0x7c, 0x00, 0x00, 0x02, 0xb5, 0x07, 0x45, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x64, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06, 0x09, 0x01, 0x07,
+	0x02, 0x06, 0x07, 0x67, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x4d, 0x04,
+	0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1f, 0x00,
+	0x06, 0x09, 0x01, 0x07, 0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15,
+	0x23, 0x11, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0xfe,
+	0x77, 0xd9, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x6c, 0xd9, 0xd9, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x9a, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x3e, 0x00, 0x03, 0x00, 0x30, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e,
+	0x59, 0x40, 0x0a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x33, 0x11,
+	0x33, 0x11, 0x9a, 0xc5, 0x04, 0x3e, 0xfb, 0xc2, 0x00, 0x02, 0x00, 0x7c, 0xfe, 0xd8, 0x05, 0xa6,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x1a, 0x00, 0x70, 0x40, 0x0a, 0x0d, 0x01, 0x06, 0x05, 0x0c, 0x01,
+	0x09, 0x06, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x06, 0x00, 0x09, 0x06,
+	0x09, 0x65, 0x07, 0x03, 0x02, 0x01, 0x01, 0x02, 0x5f, 0x08, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x04,
+	0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x1f, 0x08,
+	0x01, 0x02, 0x07, 0x03, 0x02, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x06, 0x00, 0x09, 0x06, 0x09,
+	0x65, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40,
+	0x16, 0x00, 0x00, 0x1a, 0x18, 0x16, 0x15, 0x14, 0x13, 0x10, 0x0e, 0x00, 0x0b, 0x00, 0x0b, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x0b, 0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15,
+	0x23, 0x11, 0x33, 0x15, 0x07, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x23, 0x35, 0x21, 0x11,
+	0x10, 0x21, 0x22, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0x26, 0xa6, 0x95, 0x9f, 0x6b, 0xf0,
+	0x01, 0xc2, 0xfe, 0x1e, 0xa7, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0xe8, 0xb5, 0x4d,
+	0x7d, 0xb7, 0x04, 0x78, 0x9c, 0xfa, 0xf3, 0xfe, 0x1d, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x9a,
+	0xfe, 0x5d, 0x03, 0x2b, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x18, 0x00, 0x82,
+	0x40, 0x0a, 0x0d, 0x01, 0x06, 0x01, 0x0c, 0x01, 0x08, 0x06, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x25, 0x0b, 0x05, 0x0a, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01, 0x02, 0x02, 0x38,
+	0x4d, 0x07, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x01, 0x01, 0x01, 0x39, 0x4d, 0x00, 0x06, 0x06,
+	0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b, 0x40, 0x23, 0x04, 0x01, 0x02, 0x0b, 0x05,
+	0x0a, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x07, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x09, 0x01, 0x01,
+	0x01, 0x3c, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x59, 0x40,
+	0x20, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x18, 0x16, 0x14, 0x13, 0x10, 0x0e, 0x08, 0x0b, 0x08,
+	0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0c, 0x09,
+	0x17, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x03, 0x35, 0x33, 0x15, 0x21, 0x35, 0x33, 0x15, 0x01, 0x35,
+	0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x10, 0x21, 0x22, 0x9a, 0xc5, 0xc5, 0xc5, 0x01,
+	0x07, 0xc5, 0xfe, 0x34, 0x35, 0x47, 0x55, 0x36, 0xc5, 0xfe, 0xc1, 0x5b, 0x04, 0x3e, 0xfb, 0xc2,
+	0x05, 0x03, 0xc5, 0xc5, 0xc5, 0xc5, 0xf9, 0x70, 0x90, 0x12, 0x69, 0xa6, 0x04, 0x3e, 0xfb, 0xc2,
+	0xfe, 0x5d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x31, 0xfe, 0xd8, 0x03, 0xf3, 0x07, 0x8f, 0x00, 0x0e,
+	0x00, 0x16, 0x00, 0x6f, 0x40, 0x0e, 0x14, 0x01, 0x05, 0x04, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01,
+	0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85,
+	0x07, 0x06, 0x02, 0x05, 0x02, 0x05, 0x85, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x38, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x07, 0x06, 0x02, 0x05, 0x02, 0x05, 0x85, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x68,
+	0x00, 0x00, 0x03, 0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51,
+	0x59, 0x40, 0x0f, 0x0f, 0x0f, 0x0f, 0x16, 0x0f, 0x16, 0x11, 0x12, 0x22, 0x11, 0x13, 0x22, 0x08,
+	0x09, 0x1c, 0x2b, 0x17, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x23, 0x35, 0x21, 0x11, 0x10,
+	0x21, 0x22, 0x13, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x31, 0xa6, 0x95, 0x9f, 0x6b, 0xfa,
+	0x01, 0xcc, 0xfe, 0x1e, 0xa7, 0x78, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0xe8, 0xb5, 0x4d,
+	0x7d, 0xb7, 0x04, 0x78, 0x9c, 0xfa, 0xf3, 0xfe, 0x1d, 0x07, 0x76, 0x01, 0x41, 0xfe, 0xbf, 0xca,
+	0xca, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0xfe, 0x5d, 0x02, 0x6b, 0x06, 0x44, 0x00, 0x0c,
+	0x00, 0x14, 0x00, 0x67, 0x40, 0x0e, 0x12, 0x01, 0x04, 0x03, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01,
+	0x02, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x05, 0x02, 0x04, 0x03,
+	0x01, 0x03, 0x04, 0x01, 0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x04,
+	0x03, 0x85, 0x06, 0x05, 0x02, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x00,
+	0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0e, 0x0d, 0x0d, 0x0d, 0x14,
+	0x0d, 0x14, 0x11, 0x12, 0x22, 0x13, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x03, 0x35, 0x16, 0x33, 0x32,
+	0x36, 0x35, 0x11, 0x33, 0x11, 0x10, 0x21, 0x22, 0x03, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07,
+	0x54, 0x35, 0x47, 0x55, 0x36, 0xc6, 0xfe, 0xc0, 0x5b, 0x2e, 0xf0, 0xdb, 0xf0, 0x94, 0xc8, 0x03,
+	0xc8, 0xfe, 0x73, 0x90, 0x12, 0x69, 0xa6, 0x04, 0x3e, 0xfb, 0xc2, 0xfe, 0x5d, 0x06, 0xa6, 0x01,
+	0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xbf, 0xfe, 0x50, 0x05, 0x25,
+	0x05, 0xc8, 0x00, 0x0a, 0x00, 0x18, 0x00, 0x9b, 0x40, 0x11, 0x09, 0x06, 0x03, 0x03, 0x02, 0x00,
+	0x12, 0x0c, 0x02, 0x04, 0x05, 0x0b, 0x01, 0x06, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58,
+	0x40, 0x1f, 0x00, 0x05, 0x02, 0x04, 0x04, 0x05, 0x72, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07,
+	0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x02, 0x04, 0x02, 0x05, 0x04,
+	0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04,
+	0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x02, 0x04,
+	0x02, 0x05, 0x04, 0x80, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x07, 0x03, 0x02, 0x02, 0x02, 0x3c,
+	0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x18, 0x16, 0x14, 0x13, 0x0f, 0x0d, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x08,
+	0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x01, 0x01, 0x21, 0x01, 0x11, 0x13, 0x35,
+	0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0xbf, 0xc5, 0x02, 0x67,
+	0xd3, 0xfd, 0xac, 0x02, 0xbb, 0xfe, 0xf6, 0xfd, 0x69, 0x63, 0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25,
+	0xd9, 0x3e, 0x05, 0xc8, 0xfd, 0x28, 0x02, 0xd8, 0xfd, 0x3e, 0xfc, 0xfa, 0x02, 0xee, 0xfd, 0x12,
+	0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9a,
+	0xfe, 0x50, 0x03, 0xee, 0x06, 0x2b, 0x00, 0x0a, 0x00, 0x18, 0x00, 0xa7, 0x40, 0x11, 0x09, 0x06,
+	0x03, 0x03, 0x02, 0x01, 0x12, 0x0c, 0x02, 0x04, 0x05, 0x0b, 0x01, 0x06, 0x04, 0x03, 0x4c, 0x4b,
+	0xb0, 0x0a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x02, 0x04, 0x04, 0x05, 0x72, 0x00, 0x00, 0x00,
+	0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04,
+	0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x24, 0x00, 0x05, 0x02, 0x04, 0x02, 0x05, 0x04, 0x80, 0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01,
+	0x01, 0x3b, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00,
+	0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x24, 0x00, 0x05, 0x02, 0x04, 0x02, 0x05, 0x04, 0x80,
+	0x00, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x3c,
+	0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x18, 0x16, 0x14, 0x13, 0x0f, 0x0d, 0x00, 0x0a, 0x00, 0x0a, 0x12, 0x12, 0x11, 0x08,
+	0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x11, 0x13, 0x35,
+	0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0x9a, 0xc5, 0x01, 0x6c,
+	0xbc, 0xfe, 0xa5, 0x01, 0xc2, 0xf0, 0xfe, 0x61, 0x25, 0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9,
+	0x3e, 0x06, 0x2b, 0xfc, 0x04, 0x02, 0x0f, 0xfd, 0xff, 0xfd, 0xc3, 0x02, 0x2d, 0xfd, 0xd3, 0xfe,
+	0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9a,
+	0x00, 0x00, 0x03, 0xee, 0x04, 0x3e, 0x00, 0x0a, 0x00, 0x3f, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x3b,
+	0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a,
+	0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x01,
+	0x01, 0x23, 0x01, 0x11, 0x9a, 0xc5, 0x01, 0x80, 0xbc, 0xfe, 0x91, 0x01, 0xc2, 0xf0, 0xfe, 0x61,
+	0x04, 0x3e, 0xfd, 0xf1, 0x02, 0x0f, 0xfd, 0xff, 0xfd, 0xc3, 0x02, 0x2d, 0xfd, 0xd3, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x04, 0x4d, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x09, 0x00, 0x59,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00,
+	0x04, 0x85, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04,
+	0x85, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x3c,
+	0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00, 0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00,
+	0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01,
+	0x13, 0x33, 0x01, 0xa5, 0xd2, 0x02, 0xd6, 0xfc, 0x64, 0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0xfa,
+	0xd5, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x4f, 0xff, 0xe7, 0x02, 0x24,
+	0x07, 0xcf, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x31, 0x40, 0x2e, 0x06, 0x01, 0x01, 0x00, 0x01, 0x4c,
+	0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x02, 0x04, 0x85, 0x00, 0x02, 0x02, 0x3a, 0x4d,
+	0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x10, 0x10, 0x10, 0x13, 0x10,
+	0x13, 0x12, 0x15, 0x22, 0x14, 0x06, 0x09, 0x1a, 0x2b, 0x01, 0x14, 0x1e, 0x02, 0x37, 0x15, 0x06,
+	0x23, 0x22, 0x2e, 0x02, 0x35, 0x11, 0x33, 0x25, 0x13, 0x33, 0x01, 0x01, 0x5f, 0x14, 0x2d, 0x41,
+	0x2a, 0x16, 0x26, 0x41, 0x75, 0x53, 0x2c, 0xc5, 0xfe, 0xf0, 0xf1, 0xe4, 0xfe, 0xbf, 0x01, 0x50,
+	0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d, 0x04, 0xff, 0x63, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5, 0xfe, 0x50, 0x04, 0x4d, 0x05, 0xc8, 0x00, 0x05,
+	0x00, 0x13, 0x00, 0x9d, 0x40, 0x0b, 0x0d, 0x07, 0x02, 0x03, 0x04, 0x06, 0x01, 0x05, 0x03, 0x02,
+	0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x04, 0x02, 0x03, 0x03, 0x04, 0x72, 0x00,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x06, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x00,
+	0x03, 0x03, 0x05, 0x62, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x23, 0x00, 0x04, 0x02, 0x03, 0x02, 0x04, 0x03, 0x80, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x01, 0x01, 0x02, 0x60, 0x06, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x05, 0x62, 0x00,
+	0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x04, 0x02,
+	0x03, 0x02, 0x04, 0x03, 0x80, 0x00, 0x01, 0x01, 0x02, 0x60, 0x06, 0x01, 0x02, 0x02, 0x3c, 0x4d,
+	0x00, 0x03, 0x03, 0x05, 0x62, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x00,
+	0x00, 0x13, 0x11, 0x0f, 0x0e, 0x0a, 0x08, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18,
+	0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35,
+	0x20, 0x15, 0x14, 0x23, 0x22, 0xa5, 0xd2, 0x02, 0xd6, 0xfd, 0x74, 0x32, 0x30, 0x6d, 0x9e, 0x01,
+	0x25, 0xd9, 0x3e, 0x05, 0xc8, 0xfa, 0xd5, 0x9d, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d,
+	0xa8, 0x99, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9a, 0xfe, 0x50, 0x02, 0x0b, 0x06, 0x2b, 0x00, 0x0d,
+	0x00, 0x1d, 0x00, 0x6b, 0x40, 0x0f, 0x14, 0x01, 0x04, 0x03, 0x07, 0x01, 0x02, 0x00, 0x01, 0x00,
+	0x01, 0x02, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x01, 0x04, 0x00,
+	0x00, 0x01, 0x72, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04,
+	0x42, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x22,
+	0x00, 0x01, 0x04, 0x00, 0x04, 0x01, 0x00, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x42, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x43,
+	0x02, 0x4e, 0x59, 0x40, 0x09, 0x15, 0x22, 0x15, 0x22, 0x14, 0x22, 0x06, 0x09, 0x1c, 0x2b, 0x13,
+	0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0x13, 0x14, 0x1e,
+	0x02, 0x37, 0x15, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x11, 0x33, 0xab, 0x32, 0x30, 0x6d, 0x9e,
+	0x01, 0x25, 0xd9, 0x3e, 0x75, 0x14, 0x2d, 0x41, 0x2a, 0x16, 0x26, 0x41, 0x75, 0x53, 0x2c, 0xc5,
+	0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x03, 0x00, 0x39, 0x51, 0x33, 0x18,
+	0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d, 0x04, 0xff, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0x4d, 0x05, 0xc9, 0x00, 0x05, 0x00, 0x0f, 0x00, 0x4a, 0x40, 0x09, 0x0b, 0x09,
+	0x07, 0x06, 0x04, 0x01, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x03, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x04, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e,
+	0x1b, 0x40, 0x12, 0x03, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x04, 0x01,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x0d, 0x0c, 0x00, 0x05, 0x00, 0x05,
+	0x11, 0x11, 0x05, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x35, 0x36, 0x35,
+	0x35, 0x23, 0x35, 0x33, 0x15, 0x10, 0xa5, 0xd2, 0x02, 0xd6, 0xfe, 0x52, 0x4c, 0x4c, 0xc5, 0x05,
+	0xc8, 0xfa, 0xd5, 0x9d, 0x04, 0x03, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x9a, 0xff, 0xe7, 0x02, 0xa1, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x19, 0x00, 0x28,
+	0x40, 0x25, 0x05, 0x03, 0x01, 0x00, 0x04, 0x01, 0x00, 0x10, 0x01, 0x02, 0x01, 0x02, 0x4c, 0x03,
+	0x01, 0x00, 0x00, 0x3a, 0x4d, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x02, 0x42, 0x02, 0x4e,
+	0x15, 0x22, 0x17, 0x16, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33,
+	0x15, 0x10, 0x01, 0x14, 0x1e, 0x02, 0x37, 0x15, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x11, 0x33,
+	0x01, 0xdc, 0x4c, 0x4c, 0xc5, 0xfe, 0xbe, 0x14, 0x2d, 0x41, 0x2a, 0x16, 0x26, 0x41, 0x75, 0x53,
+	0x2c, 0xc5, 0x04, 0x65, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0xfc, 0xd7, 0x39, 0x51,
+	0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d, 0x04, 0xff, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0x4d, 0x05, 0xc8, 0x00, 0x05, 0x00, 0x09, 0x00, 0x55, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1a, 0x00, 0x03, 0x06, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x38,
+	0x4d, 0x00, 0x01, 0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1a,
+	0x00, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x06, 0x01, 0x04, 0x01, 0x03, 0x04, 0x67, 0x00, 0x01,
+	0x01, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00,
+	0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x09, 0x18,
+	0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x35, 0x33, 0x15, 0xa5, 0xd2, 0x02, 0xd6, 0xfe,
+	0x9a, 0xc5, 0x05, 0xc8, 0xfa, 0xd5, 0x9d, 0x02, 0x83, 0xc5, 0xc5, 0x00, 0x00, 0x02, 0x00, 0x9a,
+	0xff, 0xe7, 0x02, 0xb7, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x13, 0x00, 0x32, 0x40, 0x2f, 0x0a, 0x01,
+	0x03, 0x02, 0x01, 0x4c, 0x00, 0x00, 0x05, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x04, 0x04,
+	0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x00, 0x00, 0x13,
+	0x12, 0x0d, 0x0b, 0x09, 0x08, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x01, 0x35,
+	0x33, 0x15, 0x01, 0x14, 0x1e, 0x02, 0x37, 0x15, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x11, 0x33,
+	0x01, 0xf1, 0xc6, 0xfe, 0xa8, 0x14, 0x2d, 0x41, 0x2a, 0x16, 0x26, 0x41, 0x75, 0x53, 0x2c, 0xc5,
+	0x02, 0x82, 0xc5, 0xc5, 0xfe, 0xce, 0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79,
+	0x4d, 0x04, 0xff, 0x00, 0x00, 0x01, 0x00, 0x11, 0x00, 0x00, 0x04, 0x4c, 0x05, 0xc8, 0x00, 0x0d,
+	0x00, 0x4a, 0x40, 0x0d, 0x0a, 0x09, 0x08, 0x07, 0x04, 0x03, 0x02, 0x01, 0x08, 0x01, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01,
+	0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x01, 0x01, 0x02, 0x60, 0x03, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0b,
+	0x00, 0x00, 0x00, 0x0d, 0x00, 0x0d, 0x15, 0x15, 0x04, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x07, 0x35,
+	0x37, 0x11, 0x33, 0x11, 0x37, 0x15, 0x07, 0x11, 0x21, 0x15, 0xa5, 0x94, 0x94, 0xd2, 0xf6, 0xf6,
+	0x02, 0xd5, 0x02, 0xb4, 0x50, 0xa8, 0x52, 0x02, 0x6a, 0xfe, 0x08, 0x86, 0xa9, 0x86, 0xfd, 0x76,
+	0x9d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0a, 0xff, 0xe7, 0x02, 0x3c, 0x06, 0x2b, 0x00, 0x17,
+	0x00, 0x2a, 0x40, 0x27, 0x17, 0x16, 0x15, 0x14, 0x11, 0x10, 0x0f, 0x0e, 0x08, 0x00, 0x02, 0x06,
+	0x01, 0x01, 0x00, 0x02, 0x4c, 0x00, 0x02, 0x02, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x42, 0x01, 0x4e, 0x19, 0x22, 0x14, 0x03, 0x09, 0x19, 0x2b, 0x01, 0x14, 0x1e, 0x02,
+	0x37, 0x15, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x11, 0x07, 0x35, 0x37, 0x11, 0x33, 0x11, 0x37,
+	0x15, 0x07, 0x01, 0x85, 0x14, 0x2d, 0x41, 0x2a, 0x16, 0x26, 0x41, 0x75, 0x53, 0x2c, 0xb6, 0xb6,
+	0xc5, 0xb7, 0xb7, 0x01, 0x50, 0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d,
+	0x01, 0xa5, 0x62, 0xa6, 0x64, 0x02, 0xb2, 0xfd, 0xbb, 0x5c, 0xa3, 0x61, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x05, 0x21, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x5c, 0xb6, 0x08, 0x03, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x04, 0x05, 0x04, 0x85,
+	0x07, 0x01, 0x05, 0x00, 0x05, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x06, 0x03, 0x02, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x00,
+	0x05, 0x85, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x06, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e,
+	0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x12, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11, 0x23,
+	0x01, 0x11, 0x01, 0x13, 0x33, 0x01, 0xa5, 0xcd, 0x02, 0xfb, 0xb4, 0xce, 0xfd, 0x06, 0x01, 0x0a,
+	0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89,
+	0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xe5,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0xc7, 0xb6, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x01, 0x4c,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x21, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80,
+	0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25,
+	0x08, 0x01, 0x06, 0x05, 0x01, 0x05, 0x06, 0x01, 0x80, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02,
+	0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06,
+	0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x04, 0x02, 0x02,
+	0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x11, 0x11, 0x00, 0x00, 0x11, 0x14, 0x11,
+	0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x33,
+	0x11, 0x33, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x13, 0x13, 0x33, 0x01, 0x9a, 0xc5, 0x9d, 0xd2, 0x01, 0x17, 0xc6, 0x37, 0x4c, 0xa7, 0x96, 0x3e,
+	0xf1, 0xe4, 0xfe, 0xbf, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e,
+	0xee, 0xfd, 0x41, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0xfe, 0x50, 0x05, 0x21, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x17, 0x00, 0x9a, 0x40, 0x10, 0x08, 0x03,
+	0x02, 0x02, 0x00, 0x11, 0x0b, 0x02, 0x04, 0x05, 0x0a, 0x01, 0x06, 0x04, 0x03, 0x4c, 0x4b, 0xb0,
+	0x0a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x02, 0x04, 0x04, 0x05, 0x72, 0x01, 0x01, 0x00, 0x00,
+	0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06,
+	0x06, 0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x02, 0x04,
+	0x02, 0x05, 0x04, 0x80, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39,
+	0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x20, 0x00,
+	0x05, 0x02, 0x04, 0x02, 0x05, 0x04, 0x80, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x07, 0x03, 0x02,
+	0x02, 0x02, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59,
+	0x59, 0x40, 0x12, 0x00, 0x00, 0x17, 0x15, 0x13, 0x12, 0x0e, 0x0c, 0x00, 0x09, 0x00, 0x09, 0x11,
+	0x12, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11, 0x23, 0x01, 0x11,
+	0x13, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0xa5, 0xcd,
+	0x02, 0xfb, 0xb4, 0xce, 0xfd, 0x06, 0xad, 0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9, 0x3e, 0x05,
+	0xc8, 0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89, 0xfe, 0x5b, 0x55, 0x09, 0x43,
+	0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9a, 0xfe, 0x50, 0x03, 0xe5,
+	0x04, 0x56, 0x00, 0x10, 0x00, 0x1e, 0x00, 0xe0, 0x40, 0x10, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x18,
+	0x12, 0x02, 0x05, 0x06, 0x11, 0x01, 0x07, 0x05, 0x03, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x24, 0x00, 0x06, 0x02, 0x05, 0x05, 0x06, 0x72, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x07, 0x62, 0x00,
+	0x07, 0x07, 0x43, 0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x25, 0x00, 0x06, 0x02,
+	0x05, 0x02, 0x06, 0x05, 0x80, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x07, 0x62, 0x00, 0x07, 0x07, 0x43,
+	0x07, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x06, 0x02, 0x05, 0x02, 0x06,
+	0x05, 0x80, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x4d, 0x00, 0x05, 0x05, 0x07, 0x62, 0x00, 0x07, 0x07,
+	0x43, 0x07, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x02, 0x05, 0x02, 0x06, 0x05, 0x80, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02,
+	0x02, 0x02, 0x3c, 0x4d, 0x00, 0x05, 0x05, 0x07, 0x62, 0x00, 0x07, 0x07, 0x43, 0x07, 0x4e, 0x59,
+	0x59, 0x59, 0x40, 0x13, 0x00, 0x00, 0x1e, 0x1c, 0x1a, 0x19, 0x15, 0x13, 0x00, 0x10, 0x00, 0x10,
+	0x23, 0x12, 0x22, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x15, 0x36, 0x33, 0x20, 0x11,
+	0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x13, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34,
+	0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0x9a, 0xc5, 0x9d, 0xd2, 0x01, 0x17, 0xc6, 0x37, 0x4c,
+	0xa7, 0x96, 0x3e, 0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9, 0x3e, 0x04, 0x3e, 0xcc, 0xe4, 0xfe,
+	0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c,
+	0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x21, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x11, 0x00, 0x65, 0x40, 0x0b, 0x0f, 0x01, 0x04, 0x05, 0x08, 0x03, 0x02, 0x02, 0x00, 0x02,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x03, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x1a, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04,
+	0x85, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x07, 0x03, 0x02, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59,
+	0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x11, 0x0a, 0x11, 0x0e, 0x0d, 0x0c, 0x0b, 0x00, 0x09,
+	0x00, 0x09, 0x11, 0x12, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11,
+	0x23, 0x01, 0x11, 0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0xa5, 0xcd, 0x02, 0xfb, 0xb4,
+	0xce, 0xfd, 0x06, 0x02, 0xe8, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8, 0xfb, 0x89,
+	0x04, 0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89, 0x07, 0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca,
+	0x00, 0x02, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xe5, 0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xd2,
+	0x40, 0x0b, 0x16, 0x01, 0x05, 0x06, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x15,
+	0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80, 0x09, 0x07, 0x02, 0x06,
+	0x06, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04,
+	0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05,
+	0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02,
+	0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06,
+	0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b,
+	0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00,
+	0x00, 0x3b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x04, 0x02,
+	0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18,
+	0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x0a, 0x09,
+	0x1a, 0x2b, 0x33, 0x11, 0x33, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23,
+	0x22, 0x07, 0x11, 0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x9a, 0xc5, 0x9d, 0xd2, 0x01,
+	0x17, 0xc6, 0x37, 0x4c, 0xa7, 0x96, 0x02, 0x47, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x04,
+	0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x06, 0x44,
+	0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x01, 0x00, 0x00, 0x04, 0x47,
+	0x06, 0x2b, 0x00, 0x10, 0x00, 0x1a, 0x00, 0x9d, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0e, 0x16,
+	0x14, 0x12, 0x11, 0x04, 0x00, 0x05, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x02, 0x4c, 0x1b, 0x40, 0x0e,
+	0x16, 0x14, 0x12, 0x11, 0x04, 0x01, 0x05, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x02, 0x4c, 0x59, 0x4b,
+	0xb0, 0x15, 0x50, 0x58, 0x40, 0x18, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x00, 0x61,
+	0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x04, 0x02, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x04, 0x02, 0x02, 0x02, 0x39,
+	0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x04, 0x02, 0x02, 0x02, 0x3c, 0x02,
+	0x4e, 0x59, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x18, 0x17, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22,
+	0x11, 0x07, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x15, 0x36, 0x33, 0x20, 0x11, 0x11, 0x23, 0x11,
+	0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x01, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x15, 0x10,
+	0xfc, 0xc5, 0x9d, 0xd2, 0x01, 0x17, 0xc6, 0x37, 0x4c, 0xa7, 0x96, 0xfe, 0x40, 0x4c, 0x4c, 0xc5,
+	0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0xf4, 0x02, 0xcc, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x04,
+	0x65, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5,
+	0xfe, 0x5c, 0x05, 0x21, 0x05, 0xc8, 0x00, 0x12, 0x00, 0x5b, 0x40, 0x10, 0x11, 0x03, 0x02, 0x04,
+	0x00, 0x0f, 0x0b, 0x02, 0x03, 0x04, 0x0a, 0x01, 0x02, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x17, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00,
+	0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x01, 0x01, 0x00,
+	0x00, 0x04, 0x5f, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x43, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x23, 0x22, 0x12,
+	0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11, 0x10, 0x21, 0x22, 0x27,
+	0x35, 0x16, 0x33, 0x32, 0x35, 0x35, 0x01, 0x11, 0xa5, 0xcd, 0x02, 0xfb, 0xb4, 0xfe, 0xbf, 0x49,
+	0x4b, 0x3d, 0x55, 0x8f, 0xfc, 0xec, 0x05, 0xc8, 0xfb, 0x89, 0x04, 0x77, 0xf9, 0xdc, 0xfe, 0xb8,
+	0x15, 0x9a, 0x1b, 0xd9, 0x0f, 0x04, 0x9f, 0xfb, 0x89, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9a,
+	0xfe, 0x5c, 0x03, 0xe5, 0x04, 0x56, 0x00, 0x18, 0x00, 0x92, 0x40, 0x0f, 0x17, 0x03, 0x02, 0x05,
+	0x04, 0x0d, 0x01, 0x03, 0x05, 0x0c, 0x01, 0x02, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x1c, 0x00, 0x04, 0x04, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x05,
+	0x05, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x39, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61,
+	0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x04,
+	0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x3c, 0x4d, 0x00, 0x03,
+	0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x43, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00,
+	0x18, 0x00, 0x18, 0x24, 0x23, 0x23, 0x22, 0x11, 0x07, 0x09, 0x1b, 0x2b, 0x33, 0x11, 0x33, 0x15,
+	0x36, 0x33, 0x20, 0x11, 0x11, 0x10, 0x21, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x11, 0x34,
+	0x26, 0x23, 0x22, 0x07, 0x11, 0x9a, 0xc5, 0x9d, 0xd2, 0x01, 0x17, 0xfe, 0xbf, 0x49, 0x4b, 0x3e,
+	0x3f, 0x92, 0x37, 0x4c, 0xa7, 0x96, 0x04, 0x3e, 0xcc, 0xe4, 0xfe, 0xb6, 0xfc, 0x98, 0xfe, 0xb8,
+	0x15, 0x9a, 0x1b, 0xd9, 0x03, 0x03, 0x83, 0x5e, 0xee, 0xfd, 0x41, 0x00, 0x00, 0x03, 0x00, 0x5d,
+	0xff, 0xdb, 0x05, 0xdd, 0x07, 0x00, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x67, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01,
+	0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05,
+	0x67, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06,
+	0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18,
+	0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x09, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x35, 0x21,
+	0x15, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01, 0x40, 0x01, 0x80, 0xfe,
+	0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x5d, 0x02, 0x82, 0x25, 0x01, 0xaa,
+	0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01,
+	0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05,
+	0xf4, 0x94, 0x94, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c, 0x05, 0xab, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x17, 0x00, 0x6b, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x05,
+	0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x1b, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16,
+	0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x09, 0x16,
+	0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11,
+	0x10, 0x21, 0x20, 0x11, 0x10, 0x03, 0x35, 0x21, 0x15, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03,
+	0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x33, 0x02, 0x82,
+	0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe,
+	0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x9c, 0x94, 0x94, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x71, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00,
+	0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x23, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00,
+	0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x6a, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x0d, 0x0c, 0x01, 0x00, 0x22, 0x20, 0x1e, 0x1d, 0x1c,
+	0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a,
+	0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25,
+	0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x33, 0x16, 0x33, 0x32,
+	0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01,
+	0x41, 0x01, 0x40, 0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb,
+	0x69, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x25, 0x01, 0xaa, 0x01,
+	0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45,
+	0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x07, 0x17,
+	0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x1f, 0x00, 0xa3, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x27, 0x06, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x38,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00,
+	0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25,
+	0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x06, 0x01, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x03,
+	0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05,
+	0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40,
+	0x1b, 0x0d, 0x0c, 0x01, 0x00, 0x1e, 0x1c, 0x1a, 0x19, 0x18, 0x16, 0x15, 0x14, 0x11, 0x0f, 0x0c,
+	0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00,
+	0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11,
+	0x10, 0x03, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x02, 0x32, 0xdb,
+	0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe,
+	0xf2, 0x3f, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x19, 0x01, 0x34,
+	0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01,
+	0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x05, 0xc9, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0x75, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07,
+	0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e,
+	0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40,
+	0x21, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x69, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x40, 0x23, 0x1c, 0x1c, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x1c, 0x1f, 0x1c,
+	0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10,
+	0x12, 0x03, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33, 0x01, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01,
+	0x7f, 0x01, 0x41, 0x01, 0x40, 0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3,
+	0xfc, 0xfb, 0x0d, 0xf1, 0xbf, 0xfe, 0xbf, 0xf1, 0xf0, 0xbf, 0xfe, 0xc0, 0x25, 0x01, 0xaa, 0x01,
+	0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45,
+	0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x05, 0xd6,
+	0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x04, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x61,
+	0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x79, 0x4b, 0xb0, 0x28, 0x50,
+	0x58, 0x40, 0x25, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x3a,
+	0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x09, 0x01, 0x02, 0x02, 0x00,
+	0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x06, 0x01, 0x04, 0x0b, 0x07,
+	0x0a, 0x03, 0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x09, 0x01, 0x02, 0x02, 0x00, 0x61, 0x08, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40,
+	0x23, 0x18, 0x18, 0x14, 0x14, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x14,
+	0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0c, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10,
+	0x00, 0x27, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11, 0x10, 0x13, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33,
+	0x01, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01,
+	0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x27, 0xf1, 0xbf, 0xfe, 0xbf, 0xf1, 0xf0, 0xbf, 0xfe, 0xc0, 0x19,
+	0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2,
+	0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x88, 0x01, 0x41, 0xfe, 0xbf, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x5d, 0xff, 0xdb, 0x07, 0xc7, 0x05, 0xed, 0x00, 0x16,
+	0x00, 0x22, 0x00, 0x8e, 0x40, 0x0a, 0x0b, 0x01, 0x08, 0x02, 0x01, 0x01, 0x07, 0x09, 0x02, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x32, 0x00, 0x04, 0x00, 0x05, 0x06, 0x04, 0x05, 0x67, 0x00,
+	0x08, 0x08, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02,
+	0x02, 0x38, 0x4d, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x0a, 0x01, 0x07, 0x07, 0x39, 0x4d, 0x00, 0x09,
+	0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x08,
+	0x03, 0x01, 0x08, 0x69, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x00, 0x05,
+	0x06, 0x04, 0x05, 0x67, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x0a, 0x01, 0x07, 0x07, 0x3c, 0x4d, 0x00,
+	0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x22,
+	0x20, 0x1c, 0x1a, 0x00, 0x16, 0x00, 0x16, 0x11, 0x11, 0x11, 0x11, 0x12, 0x24, 0x22, 0x0b, 0x09,
+	0x1d, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x17, 0x35, 0x21,
+	0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x11, 0x34, 0x26, 0x23, 0x22, 0x02,
+	0x11, 0x10, 0x12, 0x33, 0x20, 0x04, 0x69, 0x9d, 0xc3, 0xfe, 0xcc, 0xfe, 0x88, 0x01, 0x7a, 0x01,
+	0x3e, 0xb9, 0x9b, 0x03, 0x32, 0xfd, 0xa0, 0x01, 0xfd, 0xfe, 0x03, 0x02, 0x8c, 0xfc, 0xa2, 0xa2,
+	0xb3, 0xdf, 0xf9, 0xfa, 0xe2, 0x01, 0x51, 0x3a, 0x5f, 0x01, 0xab, 0x01, 0x5e, 0x01, 0x64, 0x01,
+	0xa5, 0x5e, 0x39, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x02, 0x39, 0x01, 0x56, 0xec, 0xd5,
+	0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xda, 0xfe, 0xba, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x07, 0x14,
+	0x04, 0x56, 0x00, 0x1a, 0x00, 0x22, 0x00, 0x27, 0x00, 0x3d, 0x40, 0x3a, 0x11, 0x0b, 0x02, 0x02,
+	0x01, 0x0c, 0x01, 0x03, 0x02, 0x02, 0x4c, 0x00, 0x08, 0x00, 0x01, 0x02, 0x08, 0x01, 0x67, 0x09,
+	0x01, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x41, 0x4d, 0x06, 0x01, 0x02, 0x02, 0x03,
+	0x61, 0x04, 0x01, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x27, 0x25, 0x11, 0x22, 0x22, 0x24, 0x23, 0x23,
+	0x22, 0x12, 0x21, 0x0a, 0x09, 0x1f, 0x2b, 0x01, 0x36, 0x33, 0x32, 0x12, 0x11, 0x21, 0x16, 0x16,
+	0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x26, 0x27, 0x06, 0x21, 0x22, 0x00, 0x11, 0x10, 0x00,
+	0x33, 0x32, 0x01, 0x10, 0x21, 0x20, 0x11, 0x10, 0x21, 0x20, 0x01, 0x21, 0x10, 0x23, 0x22, 0x03,
+	0xde, 0x87, 0xf6, 0xe8, 0xd1, 0xfd, 0x37, 0x10, 0xba, 0xc2, 0xa1, 0x9c, 0xbc, 0xb2, 0xa1, 0xc7,
+	0x5b, 0x82, 0xfe, 0xfe, 0xef, 0xfe, 0xe6, 0x01, 0x1b, 0xf2, 0xfb, 0xfd, 0xc9, 0x01, 0x41, 0x01,
+	0x1a, 0xfe, 0xe5, 0xfe, 0xc0, 0x03, 0x2a, 0x01, 0xf1, 0xe4, 0xf3, 0x03, 0x8e, 0xc8, 0xfe, 0xe5,
+	0xfe, 0xc5, 0xce, 0xb7, 0x47, 0x9d, 0x3e, 0x58, 0x6f, 0xc7, 0x01, 0x34, 0x01, 0x04, 0x01, 0x06,
+	0x01, 0x31, 0xfd, 0xd0, 0xfe, 0x55, 0x01, 0xa5, 0x01, 0xa2, 0xfe, 0xc6, 0x01, 0x3a, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x9a, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x18,
+	0x00, 0x75, 0xb5, 0x06, 0x01, 0x02, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25,
+	0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x03, 0x02,
+	0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07,
+	0x00, 0x07, 0x85, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x01,
+	0x04, 0x02, 0x67, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x15, 0x15,
+	0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x14, 0x21, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x01, 0x21,
+	0x01, 0x21, 0x11, 0x11, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x21, 0x13, 0x13, 0x33, 0x01,
+	0xa5, 0x02, 0x6a, 0x01, 0xc8, 0xfe, 0xd5, 0x01, 0xee, 0xfe, 0xfe, 0xfe, 0x5b, 0xfe, 0x84, 0xeb,
+	0xd6, 0xc7, 0xa1, 0xbb, 0xfe, 0xd4, 0x94, 0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0xfe, 0x91, 0xfe,
+	0xd8, 0x7c, 0xfd, 0x4b, 0x02, 0x72, 0xfd, 0x8e, 0x03, 0x0f, 0x94, 0xa1, 0x7c, 0x6b, 0x01, 0x23,
+	0x01, 0x41, 0xfe, 0xbf, 0x00, 0x02, 0x00, 0x9a, 0x00, 0x00, 0x02, 0xd2, 0x06, 0x44, 0x00, 0x0d,
+	0x00, 0x11, 0x00, 0xdd, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x0c, 0x07, 0x01, 0x00, 0x05, 0x0c,
+	0x08, 0x03, 0x03, 0x03, 0x02, 0x02, 0x4c, 0x1b, 0x40, 0x0c, 0x07, 0x01, 0x00, 0x01, 0x0c, 0x08,
+	0x03, 0x03, 0x03, 0x02, 0x02, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x20, 0x07, 0x01,
+	0x05, 0x04, 0x00, 0x04, 0x05, 0x00, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x28, 0x50, 0x58, 0x40, 0x24, 0x07, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00,
+	0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x06, 0x01, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05,
+	0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d,
+	0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x14, 0x0e, 0x0e, 0x00, 0x00,
+	0x0e, 0x11, 0x0e, 0x11, 0x10, 0x0f, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x08, 0x09, 0x19,
+	0x2b, 0x33, 0x11, 0x33, 0x15, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x07, 0x11, 0x03,
+	0x13, 0x33, 0x01, 0x9a, 0xc5, 0x5a, 0xaa, 0x17, 0x22, 0x33, 0x20, 0x72, 0x78, 0x62, 0xf1, 0xe4,
+	0xfe, 0xbf, 0x04, 0x3e, 0xcc, 0xe4, 0x05, 0xb8, 0x11, 0xde, 0xfd, 0x34, 0x05, 0x03, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x03, 0x00, 0xa5, 0xfe, 0x50, 0x05, 0x9a, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x14, 0x00, 0x22, 0x00, 0xbf, 0x40, 0x0f, 0x06, 0x01, 0x02, 0x04, 0x1c, 0x16, 0x02, 0x06,
+	0x07, 0x15, 0x01, 0x08, 0x06, 0x03, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x07,
+	0x01, 0x06, 0x06, 0x07, 0x72, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x00, 0x05, 0x05,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01, 0x39, 0x4d, 0x00, 0x06,
+	0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x2c, 0x00, 0x07, 0x01, 0x06, 0x01, 0x07, 0x06, 0x80, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02,
+	0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01,
+	0x39, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b, 0x40, 0x2a,
+	0x00, 0x07, 0x01, 0x06, 0x01, 0x07, 0x06, 0x80, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67,
+	0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x67, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x4d, 0x00,
+	0x06, 0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x00, 0x00,
+	0x22, 0x20, 0x1e, 0x1d, 0x19, 0x17, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x14,
+	0x21, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x01, 0x21, 0x01, 0x21,
+	0x11, 0x11, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x21, 0x13, 0x35, 0x16, 0x33, 0x32, 0x35,
+	0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0xa5, 0x02, 0x6a, 0x01, 0xc8, 0xfe, 0xd5, 0x01,
+	0xee, 0xfe, 0xfe, 0xfe, 0x5b, 0xfe, 0x84, 0xeb, 0xd6, 0xc7, 0xa1, 0xbb, 0xfe, 0xd4, 0x94, 0x32,
+	0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9, 0x3e, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xd8, 0x7c, 0xfd, 0x4b,
+	0x02, 0x72, 0xfd, 0x8e, 0x03, 0x0f, 0x94, 0xa1, 0x7c, 0x6b, 0xf9, 0x30, 0x55, 0x09, 0x43, 0x4c,
+	0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x02, 0x00, 0x9a, 0xfe, 0x50, 0x02, 0x9c, 0x04, 0x56, 0x00, 0x0d,
+	0x00, 0x1b, 0x00, 0xfe, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x15, 0x0c, 0x08, 0x03, 0x03, 0x03,
+	0x02, 0x15, 0x0f, 0x02, 0x04, 0x05, 0x0e, 0x01, 0x06, 0x04, 0x03, 0x4c, 0x07, 0x01, 0x00, 0x4a,
+	0x1b, 0x40, 0x15, 0x07, 0x01, 0x00, 0x01, 0x0c, 0x08, 0x03, 0x03, 0x03, 0x02, 0x15, 0x0f, 0x02,
+	0x04, 0x05, 0x0e, 0x01, 0x06, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x23,
+	0x00, 0x05, 0x03, 0x04, 0x04, 0x05, 0x72, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00,
+	0x3b, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06,
+	0x43, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x24, 0x00, 0x05, 0x03, 0x04, 0x03,
+	0x05, 0x04, 0x80, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x01,
+	0x03, 0x03, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00,
+	0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01,
+	0x03, 0x03, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b,
+	0x40, 0x28, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00,
+	0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x12, 0x00,
+	0x00, 0x1b, 0x19, 0x17, 0x16, 0x12, 0x10, 0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x08, 0x09,
+	0x19, 0x2b, 0x33, 0x11, 0x33, 0x15, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x03, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0x9a, 0xc5,
+	0x5a, 0xaa, 0x17, 0x22, 0x33, 0x20, 0x72, 0x78, 0xc4, 0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9,
+	0x3e, 0x04, 0x3e, 0xcc, 0xe4, 0x05, 0xb8, 0x11, 0xde, 0xfd, 0x34, 0xfe, 0x5b, 0x55, 0x09, 0x43,
+	0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x9a,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x1c, 0x00, 0x7e, 0x40, 0x0a, 0x1a, 0x01, 0x06, 0x07,
+	0x06, 0x01, 0x02, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x0a, 0x08, 0x02,
+	0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02,
+	0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x03, 0x02, 0x01, 0x01,
+	0x39, 0x01, 0x4e, 0x1b, 0x40, 0x24, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00,
+	0x06, 0x85, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x68, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x67, 0x09, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x15, 0x15, 0x00,
+	0x00, 0x15, 0x1c, 0x15, 0x1c, 0x19, 0x18, 0x17, 0x16, 0x14, 0x12, 0x0e, 0x0c, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x14, 0x21, 0x0b, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x11, 0x10, 0x05, 0x01,
+	0x21, 0x01, 0x21, 0x11, 0x11, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x21, 0x01, 0x03, 0x23,
+	0x03, 0x33, 0x17, 0x33, 0x37, 0xa5, 0x02, 0x6a, 0x01, 0xc8, 0xfe, 0xd5, 0x01, 0xee, 0xfe, 0xfe,
+	0xfe, 0x5b, 0xfe, 0x84, 0xeb, 0xd6, 0xc7, 0xa1, 0xbb, 0xfe, 0xd4, 0x02, 0x5f, 0xf1, 0xda, 0xf1,
+	0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8, 0xfe, 0x91, 0xfe, 0xd8, 0x7c, 0xfd, 0x4b, 0x02, 0x72, 0xfd,
+	0x8e, 0x03, 0x0f, 0x94, 0xa1, 0x7c, 0x6b, 0x02, 0x64, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00,
+	0x00, 0x02, 0x00, 0x02, 0x00, 0x00, 0x02, 0xbe, 0x06, 0x44, 0x00, 0x0d, 0x00, 0x15, 0x00, 0xeb,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x10, 0x13, 0x01, 0x04, 0x05, 0x07, 0x01, 0x00, 0x04, 0x0c,
+	0x08, 0x03, 0x03, 0x03, 0x02, 0x03, 0x4c, 0x1b, 0x40, 0x10, 0x13, 0x01, 0x04, 0x05, 0x07, 0x01,
+	0x00, 0x01, 0x0c, 0x08, 0x03, 0x03, 0x03, 0x02, 0x03, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x21, 0x00, 0x04, 0x05, 0x00, 0x05, 0x04, 0x00, 0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x01, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x00, 0x04, 0x05, 0x01, 0x05,
+	0x04, 0x01, 0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01,
+	0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x08, 0x06, 0x02,
+	0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x59, 0x59, 0x40, 0x16, 0x0e, 0x0e, 0x00, 0x00, 0x0e, 0x15, 0x0e, 0x15, 0x12, 0x11, 0x10, 0x0f,
+	0x00, 0x0d, 0x00, 0x0d, 0x23, 0x22, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x15, 0x36,
+	0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x07, 0x11, 0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33,
+	0x37, 0x9b, 0xc5, 0x5a, 0xaa, 0x17, 0x22, 0x33, 0x20, 0x72, 0x78, 0x01, 0x5e, 0xf1, 0xda, 0xf1,
+	0x94, 0xc9, 0x02, 0xc9, 0x04, 0x3e, 0xcc, 0xe4, 0x05, 0xb8, 0x11, 0xde, 0xfd, 0x34, 0x06, 0x44,
+	0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x78, 0xff, 0xdb, 0x04, 0xdb,
+	0x07, 0x8f, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x6b, 0x40, 0x0f, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01,
+	0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e,
+	0x1b, 0x40, 0x1e, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01,
+	0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03,
+	0x4e, 0x59, 0x40, 0x0e, 0x20, 0x20, 0x20, 0x23, 0x20, 0x23, 0x12, 0x2a, 0x23, 0x28, 0x22, 0x07,
+	0x09, 0x1b, 0x2b, 0x37, 0x35, 0x04, 0x21, 0x20, 0x35, 0x34, 0x26, 0x27, 0x27, 0x24, 0x11, 0x10,
+	0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x15, 0x14, 0x16, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14,
+	0x04, 0x23, 0x20, 0x13, 0x13, 0x33, 0x01, 0x78, 0x01, 0x1d, 0x01, 0x31, 0x01, 0x3d, 0x7b, 0xbc,
+	0xc9, 0xfe, 0x7d, 0x02, 0x1c, 0xf4, 0xef, 0xf8, 0xf8, 0xfe, 0xbc, 0x79, 0xa2, 0xce, 0xe9, 0xbe,
+	0xfe, 0xdd, 0xf9, 0xfe, 0xf3, 0x59, 0xf1, 0xe4, 0xfe, 0xbf, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f,
+	0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c,
+	0xc3, 0xa3, 0xc6, 0xe5, 0x06, 0x73, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x74,
+	0xff, 0xe7, 0x03, 0x8c, 0x06, 0x44, 0x00, 0x1c, 0x00, 0x20, 0x00, 0x70, 0x40, 0x0f, 0x0e, 0x01,
+	0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x23, 0x06, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04,
+	0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x04, 0x05, 0x04, 0x85, 0x06,
+	0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00,
+	0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x1d, 0x1d, 0x1d,
+	0x20, 0x1d, 0x20, 0x12, 0x28, 0x23, 0x27, 0x22, 0x07, 0x09, 0x1b, 0x2b, 0x37, 0x35, 0x16, 0x33,
+	0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15,
+	0x14, 0x17, 0x17, 0x04, 0x15, 0x14, 0x06, 0x23, 0x22, 0x13, 0x13, 0x33, 0x01, 0x74, 0xc2, 0xab,
+	0xe5, 0x9f, 0xb0, 0xfd, 0x01, 0xa1, 0x78, 0xa6, 0x91, 0xa2, 0xc9, 0x8d, 0x9d, 0x01, 0x25, 0xe8,
+	0xca, 0xa3, 0x5a, 0xf1, 0xe4, 0xfe, 0xbf, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda,
+	0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0xb0, 0x05, 0x1c, 0x01,
+	0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x78, 0xff, 0xdb, 0x04, 0xdb, 0x07, 0x8f, 0x00, 0x1f,
+	0x00, 0x27, 0x00, 0x72, 0x40, 0x13, 0x25, 0x01, 0x05, 0x04, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01,
+	0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x20, 0x20, 0x20, 0x27, 0x20, 0x27, 0x11, 0x12, 0x2a, 0x23,
+	0x28, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x35, 0x04, 0x21, 0x20, 0x35, 0x34, 0x26, 0x27, 0x27,
+	0x24, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x15, 0x14, 0x16, 0x17, 0x17, 0x16,
+	0x16, 0x15, 0x14, 0x04, 0x23, 0x20, 0x03, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x78, 0x01,
+	0x1d, 0x01, 0x31, 0x01, 0x3d, 0x7b, 0xbc, 0xc9, 0xfe, 0x7d, 0x02, 0x1c, 0xf4, 0xef, 0xf8, 0xf8,
+	0xfe, 0xbc, 0x79, 0xa2, 0xce, 0xe9, 0xbe, 0xfe, 0xdd, 0xf9, 0xfe, 0xf3, 0x71, 0xf1, 0xda, 0xf1,
+	0x94, 0xc9, 0x02, 0xc9, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01,
+	0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5, 0x06, 0x73,
+	0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x74, 0xff, 0xe7, 0x03, 0x8c,
+	0x06, 0x44, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x77, 0x40, 0x13, 0x22, 0x01, 0x05, 0x04, 0x0e, 0x01,
+	0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x24, 0x07, 0x06, 0x02, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04,
+	0x04, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85,
+	0x07, 0x06, 0x02, 0x05, 0x01, 0x05, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x1d,
+	0x1d, 0x1d, 0x24, 0x1d, 0x24, 0x11, 0x12, 0x28, 0x23, 0x27, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37,
+	0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x04, 0x15, 0x14, 0x06, 0x23, 0x22, 0x03, 0x13, 0x33, 0x13,
+	0x23, 0x27, 0x23, 0x07, 0x74, 0xc2, 0xab, 0xe5, 0x9f, 0xb0, 0xfd, 0x01, 0xa1, 0x78, 0xa6, 0x91,
+	0xa2, 0xc9, 0x8d, 0x9d, 0x01, 0x25, 0xe8, 0xca, 0xa3, 0x70, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02,
+	0xc9, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a,
+	0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0xb0, 0x05, 0x1c, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00,
+	0x00, 0x01, 0x00, 0x78, 0xfe, 0x50, 0x04, 0xdb, 0x05, 0xed, 0x00, 0x30, 0x00, 0x81, 0x40, 0x17,
+	0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x28, 0x01, 0x06,
+	0x07, 0x27, 0x01, 0x05, 0x06, 0x05, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x04,
+	0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d,
+	0x00, 0x00, 0x00, 0x03, 0x61, 0x08, 0x01, 0x03, 0x03, 0x3f, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02,
+	0x69, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x08, 0x01,
+	0x03, 0x03, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59,
+	0x40, 0x0c, 0x11, 0x12, 0x23, 0x24, 0x11, 0x1a, 0x23, 0x28, 0x22, 0x09, 0x09, 0x1f, 0x2b, 0x37,
+	0x35, 0x04, 0x21, 0x20, 0x35, 0x34, 0x26, 0x27, 0x27, 0x24, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15,
+	0x26, 0x23, 0x20, 0x15, 0x14, 0x16, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x07, 0x32,
+	0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x37, 0x26,
+	0x78, 0x01, 0x1d, 0x01, 0x31, 0x01, 0x3d, 0x7b, 0xbc, 0xc9, 0xfe, 0x7d, 0x02, 0x1c, 0xf4, 0xef,
+	0xf8, 0xf8, 0xfe, 0xbc, 0x79, 0xa2, 0xce, 0xe9, 0xbe, 0xfe, 0xdd, 0xf9, 0x27, 0x4e, 0x74, 0x75,
+	0x54, 0x47, 0x4b, 0x2e, 0x3b, 0x67, 0xbb, 0x4c, 0xe4, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d,
+	0x42, 0x80, 0x01, 0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3,
+	0xa3, 0xc6, 0xe5, 0x48, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60, 0x8d, 0x0d, 0x00,
+	0x00, 0x01, 0x00, 0x74, 0xfe, 0x50, 0x03, 0x8c, 0x04, 0x56, 0x00, 0x2e, 0x00, 0x4e, 0x40, 0x4b,
+	0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x26, 0x01, 0x06,
+	0x07, 0x25, 0x01, 0x05, 0x06, 0x05, 0x4c, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x08, 0x01,
+	0x03, 0x03, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x11,
+	0x12, 0x23, 0x24, 0x11, 0x19, 0x23, 0x27, 0x22, 0x09, 0x09, 0x1f, 0x2b, 0x37, 0x35, 0x16, 0x33,
+	0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15,
+	0x14, 0x17, 0x17, 0x04, 0x15, 0x14, 0x07, 0x06, 0x07, 0x07, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x37, 0x26, 0x74, 0xc2, 0xab, 0xe5, 0x9f,
+	0xb0, 0xfd, 0x01, 0xa1, 0x78, 0xa6, 0x91, 0xa2, 0xc9, 0x8d, 0x9d, 0x01, 0x25, 0x74, 0x63, 0xa1,
+	0x2f, 0x4e, 0x74, 0x75, 0x54, 0x47, 0x4b, 0x2e, 0x3b, 0x67, 0xbb, 0x52, 0x93, 0x26, 0xb5, 0x60,
+	0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61,
+	0xe7, 0x99, 0x58, 0x4b, 0x0b, 0x56, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60, 0x97,
+	0x07, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x78, 0xff, 0xdb, 0x04, 0xdb, 0x07, 0x8f, 0x00, 0x1f,
+	0x00, 0x27, 0x00, 0x72, 0x40, 0x13, 0x25, 0x01, 0x04, 0x05, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01,
+	0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21,
+	0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01,
+	0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x1f, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x6a, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03,
+	0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x20, 0x20, 0x20, 0x27, 0x20, 0x27, 0x11, 0x12, 0x2a, 0x23,
+	0x28, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37, 0x35, 0x04, 0x21, 0x20, 0x35, 0x34, 0x26, 0x27, 0x27,
+	0x24, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x15, 0x14, 0x16, 0x17, 0x17, 0x16,
+	0x16, 0x15, 0x14, 0x04, 0x23, 0x20, 0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x78, 0x01,
+	0x1d, 0x01, 0x31, 0x01, 0x3d, 0x7b, 0xbc, 0xc9, 0xfe, 0x7d, 0x02, 0x1c, 0xf4, 0xef, 0xf8, 0xf8,
+	0xfe, 0xbc, 0x79, 0xa2, 0xce, 0xe9, 0xbe, 0xfe, 0xdd, 0xf9, 0xfe, 0xf3, 0x02, 0x4b, 0xf1, 0xda,
+	0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01, 0x1c,
+	0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5, 0x07,
+	0xb4, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0x74, 0xff, 0xe7, 0x03, 0x8c,
+	0x06, 0x44, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x77, 0x40, 0x13, 0x22, 0x01, 0x04, 0x05, 0x0e, 0x01,
+	0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x24, 0x00, 0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x07, 0x06, 0x02, 0x05,
+	0x05, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x07, 0x06, 0x02, 0x05, 0x04,
+	0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0f, 0x1d,
+	0x1d, 0x1d, 0x24, 0x1d, 0x24, 0x11, 0x12, 0x28, 0x23, 0x27, 0x22, 0x08, 0x09, 0x1c, 0x2b, 0x37,
+	0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x04, 0x15, 0x14, 0x06, 0x23, 0x22, 0x01, 0x03, 0x23, 0x03,
+	0x33, 0x17, 0x33, 0x37, 0x74, 0xc2, 0xab, 0xe5, 0x9f, 0xb0, 0xfd, 0x01, 0xa1, 0x78, 0xa6, 0x91,
+	0xa2, 0xc9, 0x8d, 0x9d, 0x01, 0x25, 0xe8, 0xca, 0xa3, 0x02, 0x4c, 0xf1, 0xda, 0xf1, 0x94, 0xc9,
+	0x02, 0xc9, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01, 0x31, 0x20, 0xa5, 0x31,
+	0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0xb0, 0x06, 0x5d, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca,
+	0x00, 0x01, 0x00, 0x14, 0xfe, 0x50, 0x04, 0xce, 0x05, 0xc8, 0x00, 0x19, 0x00, 0x73, 0x40, 0x0a,
+	0x12, 0x01, 0x06, 0x07, 0x11, 0x01, 0x05, 0x06, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x25, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x01, 0x38, 0x4d, 0x09, 0x08, 0x02, 0x03, 0x03, 0x39, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x1b, 0x40, 0x23, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01,
+	0x00, 0x67, 0x00, 0x04, 0x00, 0x07, 0x06, 0x04, 0x07, 0x69, 0x09, 0x08, 0x02, 0x03, 0x03, 0x3c,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x43, 0x05, 0x4e, 0x59, 0x40, 0x11, 0x00,
+	0x00, 0x00, 0x19, 0x00, 0x19, 0x12, 0x23, 0x24, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x1e,
+	0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x23, 0x07, 0x32, 0x16, 0x15, 0x14, 0x06,
+	0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x37, 0x02, 0x08, 0xfe, 0x0c, 0x04,
+	0xba, 0xfe, 0x0c, 0x2f, 0x3c, 0x4e, 0x74, 0x75, 0x54, 0x47, 0x4b, 0x2e, 0x3b, 0x67, 0xbb, 0x5f,
+	0x05, 0x2b, 0x9d, 0x9d, 0xfa, 0xd5, 0x6d, 0x5f, 0x40, 0x45, 0x5f, 0x15, 0x51, 0x0f, 0x4a, 0x60,
+	0xaf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x19, 0xfe, 0x50, 0x02, 0x4d, 0x05, 0x34, 0x00, 0x27,
+	0x00, 0x52, 0x40, 0x4f, 0x27, 0x01, 0x09, 0x05, 0x15, 0x00, 0x02, 0x00, 0x09, 0x0e, 0x01, 0x03,
+	0x04, 0x0d, 0x01, 0x02, 0x03, 0x04, 0x4c, 0x1d, 0x1c, 0x02, 0x06, 0x4a, 0x00, 0x01, 0x00, 0x04,
+	0x03, 0x01, 0x04, 0x69, 0x08, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x07, 0x01, 0x06, 0x06, 0x3b, 0x4d,
+	0x00, 0x09, 0x09, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x43, 0x02, 0x4e, 0x26, 0x24, 0x11, 0x13, 0x11, 0x14, 0x12, 0x23, 0x24, 0x11, 0x31,
+	0x0a, 0x09, 0x1f, 0x2b, 0x05, 0x06, 0x23, 0x22, 0x27, 0x07, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x23, 0x37, 0x26, 0x35, 0x11, 0x23, 0x35, 0x33,
+	0x35, 0x37, 0x15, 0x33, 0x15, 0x23, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x02, 0x12, 0x3a, 0x34,
+	0x13, 0x12, 0x2f, 0x4e, 0x74, 0x75, 0x54, 0x47, 0x4b, 0x2e, 0x3b, 0x67, 0xbb, 0x5d, 0x91, 0x7f,
+	0x7f, 0xc5, 0xf0, 0xf0, 0x2c, 0x45, 0x29, 0x1b, 0x06, 0x13, 0x02, 0x56, 0x5f, 0x40, 0x45, 0x5f,
+	0x15, 0x51, 0x0f, 0x4a, 0x60, 0xad, 0x3e, 0xf0, 0x02, 0x7e, 0x94, 0xe3, 0x13, 0xf6, 0x94, 0xfd,
+	0xa6, 0x82, 0x53, 0x0b, 0x00, 0x02, 0x00, 0x14, 0x00, 0x00, 0x04, 0xce, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0f, 0x00, 0x65, 0xb5, 0x0d, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x1e, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x02, 0x01,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x40, 0x1c, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x68, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59,
+	0x40, 0x16, 0x08, 0x08, 0x00, 0x00, 0x08, 0x0f, 0x08, 0x0f, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x09, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21,
+	0x11, 0x13, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x02, 0x08, 0xfe, 0x0c, 0x04, 0xba, 0xfe,
+	0x0c, 0xf5, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0x2b, 0x9d, 0x9d, 0xfa, 0xd5, 0x07,
+	0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0x19, 0xff, 0xe7, 0x02, 0xcf,
+	0x06, 0x98, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x3c, 0x40, 0x39, 0x1a, 0x18, 0x16, 0x15, 0x0a, 0x09,
+	0x06, 0x02, 0x06, 0x14, 0x01, 0x05, 0x01, 0x00, 0x01, 0x00, 0x05, 0x03, 0x4c, 0x00, 0x06, 0x02,
+	0x06, 0x85, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05,
+	0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x18, 0x23, 0x11, 0x13, 0x11, 0x12, 0x21,
+	0x07, 0x09, 0x1d, 0x2b, 0x05, 0x06, 0x23, 0x20, 0x11, 0x11, 0x23, 0x35, 0x33, 0x35, 0x37, 0x15,
+	0x33, 0x15, 0x23, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x03, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35,
+	0x33, 0x15, 0x10, 0x02, 0x12, 0x3a, 0x34, 0xfe, 0xf4, 0x7f, 0x7f, 0xc5, 0xf0, 0xf0, 0x2c, 0x45,
+	0x29, 0x1b, 0x08, 0x4c, 0x4c, 0xc5, 0x06, 0x13, 0x01, 0x45, 0x02, 0x7e, 0x94, 0xe3, 0x13, 0xf6,
+	0x94, 0xfd, 0xa6, 0x82, 0x53, 0x0b, 0x04, 0x4c, 0x3b, 0x15, 0xa0, 0x11, 0xc5, 0xab, 0xfe, 0xf9,
+	0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x04, 0xce, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x54, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1c, 0x05, 0x01, 0x01, 0x06, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x04,
+	0x01, 0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x38, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x39, 0x07,
+	0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x03, 0x04, 0x01, 0x02, 0x01, 0x03, 0x02, 0x67, 0x05, 0x01, 0x01,
+	0x06, 0x01, 0x00, 0x07, 0x01, 0x00, 0x67, 0x08, 0x01, 0x07, 0x07, 0x3c, 0x07, 0x4e, 0x59, 0x40,
+	0x10, 0x00, 0x00, 0x00, 0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x09,
+	0x1d, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x02, 0x08, 0xfe, 0xd1, 0x01, 0x2f, 0xfe, 0x0c, 0x04, 0xba, 0xfe, 0x0c, 0x01, 0x2f,
+	0xfe, 0xd1, 0x02, 0xcb, 0x94, 0x01, 0xcc, 0x9d, 0x9d, 0xfe, 0x34, 0x94, 0xfd, 0x35, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x19, 0xff, 0xe7, 0x02, 0x4d, 0x05, 0x34, 0x00, 0x1c, 0x00, 0x41, 0x40, 0x3e,
+	0x17, 0x01, 0x08, 0x00, 0x18, 0x01, 0x09, 0x08, 0x02, 0x4c, 0x09, 0x08, 0x02, 0x03, 0x4a, 0x06,
+	0x01, 0x01, 0x07, 0x01, 0x00, 0x08, 0x01, 0x00, 0x67, 0x05, 0x01, 0x02, 0x02, 0x03, 0x5f, 0x04,
+	0x01, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x08, 0x08, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42, 0x09, 0x4e,
+	0x1b, 0x19, 0x23, 0x11, 0x11, 0x11, 0x13, 0x11, 0x11, 0x11, 0x10, 0x0a, 0x09, 0x1f, 0x2b, 0x13,
+	0x23, 0x35, 0x33, 0x35, 0x23, 0x35, 0x33, 0x35, 0x37, 0x15, 0x33, 0x15, 0x23, 0x15, 0x33, 0x15,
+	0x23, 0x15, 0x14, 0x16, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x11, 0x98, 0x7b, 0x7b, 0x7f,
+	0x7f, 0xc5, 0xf0, 0xf0, 0xd2, 0xd2, 0x2c, 0x45, 0x29, 0x1b, 0x3a, 0x34, 0xfe, 0xf4, 0x02, 0x38,
+	0x7b, 0xf7, 0x94, 0xe3, 0x13, 0xf6, 0x94, 0xf7, 0x7b, 0xe8, 0x82, 0x53, 0x0b, 0x8c, 0x13, 0x01,
+	0x45, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x07, 0x4c, 0x00, 0x15,
+	0x00, 0x29, 0x00, 0x6b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x06, 0x01, 0x04, 0x00, 0x08,
+	0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0a, 0x09, 0x02, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x02, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b,
+	0x40, 0x27, 0x02, 0x01, 0x00, 0x07, 0x01, 0x07, 0x00, 0x01, 0x80, 0x06, 0x01, 0x04, 0x00, 0x08,
+	0x07, 0x04, 0x08, 0x69, 0x00, 0x05, 0x0a, 0x09, 0x02, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x00, 0x01,
+	0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x12, 0x16, 0x16, 0x16, 0x29,
+	0x16, 0x29, 0x23, 0x21, 0x11, 0x23, 0x24, 0x25, 0x13, 0x25, 0x10, 0x0b, 0x09, 0x1f, 0x2b, 0x13,
+	0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07,
+	0x06, 0x23, 0x20, 0x00, 0x11, 0x13, 0x36, 0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33,
+	0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8,
+	0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0xfd, 0x0c, 0xad, 0x49, 0x3e, 0x3c,
+	0x38, 0x1e, 0x44, 0x09, 0x7b, 0x0b, 0xae, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x05, 0xc8,
+	0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65,
+	0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e,
+	0x00, 0x02, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8, 0x05, 0xf7, 0x00, 0x10, 0x00, 0x24, 0x00, 0xba,
+	0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x28, 0x00,
+	0x06, 0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08, 0x6a, 0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01,
+	0x05, 0x05, 0x3e, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b,
+	0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x00,
+	0x06, 0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08, 0x6a, 0x00, 0x09, 0x09, 0x05, 0x61, 0x07, 0x01,
+	0x05, 0x05, 0x3e, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x07, 0x01,
+	0x05, 0x00, 0x09, 0x08, 0x05, 0x09, 0x69, 0x00, 0x06, 0x0c, 0x0a, 0x02, 0x08, 0x01, 0x06, 0x08,
+	0x6a, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1d, 0x11, 0x11, 0x00, 0x00,
+	0x11, 0x24, 0x11, 0x24, 0x23, 0x21, 0x1e, 0x1c, 0x1b, 0x1a, 0x19, 0x17, 0x14, 0x12, 0x00, 0x10,
+	0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0d, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11,
+	0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x01, 0x36, 0x33, 0x32, 0x17,
+	0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23, 0x22, 0x07, 0x03,
+	0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0xfd, 0x0e, 0x0c, 0xad, 0x49,
+	0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x7b, 0x0b, 0xae, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09,
+	0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05,
+	0x0d, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25, 0x22, 0x6e, 0x00, 0x00, 0x02, 0x00, 0xa6,
+	0xff, 0xdb, 0x05, 0x20, 0x07, 0x00, 0x00, 0x15, 0x00, 0x19, 0x00, 0x53, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1a, 0x00, 0x04, 0x06, 0x01, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x1d,
+	0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x00, 0x04, 0x06, 0x01, 0x05, 0x00, 0x04,
+	0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0e,
+	0x16, 0x16, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x07, 0x09, 0x1b, 0x2b, 0x13,
+	0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07,
+	0x06, 0x23, 0x20, 0x00, 0x11, 0x01, 0x35, 0x21, 0x15, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8,
+	0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0x01, 0x09, 0x02, 0x82, 0x05, 0xc8,
+	0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65,
+	0x01, 0x18, 0x01, 0x31, 0x04, 0x48, 0x94, 0x94, 0x00, 0x02, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8,
+	0x05, 0xab, 0x00, 0x10, 0x00, 0x14, 0x00, 0xbd, 0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c,
+	0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x1e, 0x08, 0x01, 0x06, 0x06, 0x05, 0x5f, 0x00, 0x05, 0x05,
+	0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x07, 0x04, 0x02,
+	0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40, 0x22, 0x08, 0x01, 0x06,
+	0x06, 0x05, 0x5f, 0x00, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x08, 0x01, 0x06, 0x01, 0x05, 0x06, 0x67,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x05, 0x08, 0x01, 0x06, 0x01,
+	0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x15, 0x11,
+	0x11, 0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12,
+	0x22, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16,
+	0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x01, 0x35, 0x21, 0x15, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea,
+	0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0xfd, 0x1a, 0x02, 0x82, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c,
+	0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x17, 0x94, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x21, 0x00, 0x5a,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00,
+	0x07, 0x00, 0x05, 0x07, 0x69, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x02,
+	0x01, 0x00, 0x07, 0x01, 0x07, 0x00, 0x01, 0x80, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x69,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x0b, 0x22, 0x11,
+	0x21, 0x13, 0x25, 0x13, 0x25, 0x10, 0x08, 0x09, 0x1e, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17,
+	0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11,
+	0x13, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0xa6, 0xd2, 0x33, 0x48,
+	0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0xfd, 0x7b, 0x21,
+	0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33,
+	0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x05,
+	0x6b, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x00, 0x00, 0x02, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x1c, 0x00, 0xff, 0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c,
+	0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08,
+	0x06, 0x61, 0x00, 0x06, 0x06, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x27, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x08, 0x08, 0x06, 0x61, 0x00, 0x06, 0x06,
+	0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40,
+	0x25, 0x00, 0x06, 0x00, 0x08, 0x01, 0x06, 0x08, 0x69, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62,
+	0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x07, 0x01,
+	0x05, 0x06, 0x05, 0x85, 0x00, 0x06, 0x00, 0x08, 0x01, 0x06, 0x08, 0x69, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x1b, 0x40, 0x25, 0x07, 0x01, 0x05, 0x06, 0x05, 0x85, 0x00, 0x06, 0x00, 0x08,
+	0x01, 0x06, 0x08, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d,
+	0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40,
+	0x15, 0x00, 0x00, 0x1b, 0x19, 0x17, 0x16, 0x15, 0x13, 0x12, 0x11, 0x00, 0x10, 0x00, 0x10, 0x12,
+	0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11,
+	0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x01, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06,
+	0x06, 0x23, 0x22, 0x26, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5,
+	0xfd, 0x0e, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0xcb, 0xe4, 0x01,
+	0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x06, 0x44, 0xad, 0xad,
+	0x92, 0xaf, 0xae, 0x00, 0x00, 0x03, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x07, 0xf1, 0x00, 0x15,
+	0x00, 0x21, 0x00, 0x2d, 0x00, 0x6e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x00,
+	0x07, 0x06, 0x05, 0x07, 0x69, 0x09, 0x01, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x69, 0x02,
+	0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e,
+	0x1b, 0x40, 0x26, 0x02, 0x01, 0x00, 0x04, 0x01, 0x04, 0x00, 0x01, 0x80, 0x00, 0x05, 0x00, 0x07,
+	0x06, 0x05, 0x07, 0x69, 0x09, 0x01, 0x06, 0x08, 0x01, 0x04, 0x00, 0x06, 0x04, 0x69, 0x00, 0x01,
+	0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x17, 0x23, 0x22, 0x17, 0x16,
+	0x29, 0x27, 0x22, 0x2d, 0x23, 0x2d, 0x1d, 0x1b, 0x16, 0x21, 0x17, 0x21, 0x25, 0x13, 0x25, 0x10,
+	0x0a, 0x09, 0x1a, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11,
+	0x33, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36,
+	0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15,
+	0x14, 0x16, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe,
+	0xcd, 0xfe, 0xe6, 0x02, 0x47, 0x5c, 0x84, 0x84, 0x5f, 0x5e, 0x85, 0x85, 0x60, 0x3c, 0x53, 0x53,
+	0x3a, 0x3b, 0x52, 0x52, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad,
+	0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x07, 0x85, 0x5e, 0x5e, 0x85,
+	0x84, 0x5e, 0x60, 0x84, 0x56, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8, 0x06, 0xc9, 0x00, 0x10, 0x00, 0x1c, 0x00, 0x28,
+	0x00, 0xb3, 0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40,
+	0x25, 0x00, 0x06, 0x00, 0x08, 0x07, 0x06, 0x08, 0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01,
+	0x07, 0x05, 0x69, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04,
+	0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x06,
+	0x00, 0x08, 0x07, 0x06, 0x08, 0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01, 0x07, 0x05, 0x69,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x06, 0x00, 0x08, 0x07, 0x06,
+	0x08, 0x69, 0x0b, 0x01, 0x07, 0x0a, 0x01, 0x05, 0x01, 0x07, 0x05, 0x69, 0x03, 0x01, 0x01, 0x01,
+	0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x1d, 0x1e, 0x1d, 0x12, 0x11, 0x00, 0x00, 0x24, 0x22, 0x1d,
+	0x28, 0x1e, 0x28, 0x18, 0x16, 0x11, 0x1c, 0x12, 0x1c, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12,
+	0x22, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16,
+	0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15,
+	0x14, 0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x13,
+	0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0xfe, 0x58, 0x5c, 0x84, 0x84, 0x5f,
+	0x5e, 0x85, 0x85, 0x60, 0x3c, 0x53, 0x53, 0x3a, 0x3b, 0x52, 0x52, 0xcb, 0xe4, 0x01, 0x4b, 0x03,
+	0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x03, 0x85, 0x5e, 0x5e, 0x85,
+	0x84, 0x5e, 0x60, 0x84, 0x56, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x07, 0x8f, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d,
+	0x00, 0x61, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03,
+	0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00,
+	0x01, 0x80, 0x06, 0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x00, 0x01,
+	0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x1a, 0x1a, 0x16, 0x16,
+	0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x0a,
+	0x09, 0x1b, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33,
+	0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11, 0x01, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33,
+	0x01, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd,
+	0xfe, 0xe6, 0x01, 0x43, 0xf1, 0xbf, 0xfe, 0xbf, 0xf1, 0xf0, 0xbf, 0xfe, 0xc0, 0x05, 0xc8, 0xfc,
+	0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01,
+	0x18, 0x01, 0x31, 0x04, 0x2a, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x8e, 0xff, 0xe7, 0x04, 0x31, 0x06, 0x44, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18,
+	0x00, 0xd1, 0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40,
+	0x21, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x09, 0x04, 0x02, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x06,
+	0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x01,
+	0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x07, 0x01, 0x05,
+	0x0b, 0x08, 0x0a, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x09,
+	0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x1d, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17,
+	0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0c,
+	0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x11, 0x33, 0x11, 0x01, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33, 0x01, 0x03, 0x13, 0x9c, 0xd3,
+	0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0xfd, 0x4a, 0xf1, 0xbf, 0xfe, 0xbf, 0xf1, 0xf0,
+	0xbf, 0xfe, 0xc0, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0,
+	0xfb, 0xc2, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x01, 0x00, 0xa6,
+	0xfe, 0x8e, 0x05, 0x20, 0x05, 0xc8, 0x00, 0x23, 0x00, 0x77, 0x40, 0x0a, 0x18, 0x01, 0x03, 0x05,
+	0x19, 0x01, 0x04, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1b, 0x02, 0x01, 0x00,
+	0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x05, 0x61, 0x00, 0x05, 0x05, 0x3f, 0x4d, 0x00, 0x03, 0x03,
+	0x04, 0x61, 0x00, 0x04, 0x04, 0x3d, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18,
+	0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x3f, 0x05, 0x4e, 0x1b, 0x40, 0x18, 0x02, 0x01, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x03, 0x00, 0x04, 0x03, 0x04, 0x65, 0x00, 0x01, 0x01, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x42, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x23, 0x23, 0x29, 0x13, 0x25, 0x10, 0x06, 0x09, 0x1c,
+	0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14,
+	0x06, 0x07, 0x06, 0x07, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x35, 0x34,
+	0x37, 0x23, 0x20, 0x00, 0x11, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e, 0x69,
+	0x5d, 0x87, 0x59, 0x72, 0x38, 0x23, 0x3c, 0x4e, 0xcc, 0x63, 0x13, 0xfe, 0xcd, 0xfe, 0xe6, 0x05,
+	0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c,
+	0x43, 0x16, 0x44, 0x56, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x63, 0x4a, 0x01, 0x18, 0x01, 0x31, 0x00,
+	0x00, 0x01, 0x00, 0x8e, 0xfe, 0x8e, 0x03, 0xd8, 0x04, 0x3e, 0x00, 0x1e, 0x00, 0xd2, 0x4b, 0xb0,
+	0x14, 0x50, 0x58, 0x40, 0x13, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x1e, 0x01, 0x00, 0x02, 0x17, 0x01,
+	0x05, 0x00, 0x18, 0x01, 0x06, 0x05, 0x04, 0x4c, 0x1b, 0x40, 0x14, 0x0d, 0x01, 0x02, 0x02, 0x01,
+	0x17, 0x01, 0x05, 0x00, 0x18, 0x01, 0x06, 0x05, 0x03, 0x4c, 0x1e, 0x01, 0x04, 0x01, 0x4b, 0x59,
+	0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x1c, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x62, 0x04, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06,
+	0x3d, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x20, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x4d,
+	0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06, 0x3d, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x06, 0x05, 0x06, 0x65, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x1b, 0x40, 0x1d, 0x00, 0x05, 0x00, 0x06, 0x05, 0x06, 0x65, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x00, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x59, 0x40, 0x0a, 0x23, 0x23, 0x11, 0x12, 0x23, 0x12, 0x22, 0x07, 0x09, 0x1d, 0x2b,
+	0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33,
+	0x11, 0x23, 0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x35, 0x34, 0x37, 0x03,
+	0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0x54, 0x80, 0x72, 0x38, 0x23,
+	0x3c, 0x4e, 0xcc, 0x9e, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02,
+	0xc0, 0xfb, 0xc2, 0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d, 0x55, 0x00, 0x02, 0x00, 0x19,
+	0x00, 0x00, 0x07, 0x74, 0x07, 0x8f, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x69, 0x40, 0x0c, 0x12, 0x01,
+	0x06, 0x05, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1b, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02,
+	0x00, 0x00, 0x38, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00,
+	0x05, 0x06, 0x05, 0x85, 0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x03,
+	0x00, 0x85, 0x08, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x17, 0x0d, 0x0d, 0x00,
+	0x00, 0x0d, 0x14, 0x0d, 0x14, 0x11, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12,
+	0x11, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x23,
+	0x01, 0x01, 0x13, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0x96, 0xfe, 0x83, 0xca, 0x01,
+	0x2f, 0x01, 0x5b, 0xca, 0x01, 0x4d, 0x01, 0x45, 0xab, 0xfe, 0x60, 0xd0, 0xfe, 0xb7, 0xfe, 0xab,
+	0x0e, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8, 0xfb, 0x6f, 0x04, 0x91, 0xfb, 0x7a,
+	0x04, 0x86, 0xfa, 0x38, 0x04, 0x75, 0xfb, 0x8b, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca,
+	0x00, 0x02, 0x00, 0x0b, 0x00, 0x00, 0x05, 0xb9, 0x06, 0x44, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x90,
+	0x40, 0x0c, 0x12, 0x01, 0x06, 0x05, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x28, 0x50, 0x58, 0x40, 0x1e, 0x09, 0x07, 0x02, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80, 0x00,
+	0x05, 0x05, 0x3a, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x06, 0x05, 0x85,
+	0x09, 0x07, 0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04,
+	0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x05, 0x06, 0x05, 0x85, 0x09, 0x07,
+	0x02, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x08, 0x04, 0x02, 0x03,
+	0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x17, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x14, 0x0d, 0x14,
+	0x11, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0a, 0x09, 0x1a, 0x2b,
+	0x21, 0x01, 0x33, 0x13, 0x13, 0x33, 0x13, 0x13, 0x33, 0x01, 0x23, 0x03, 0x01, 0x03, 0x13, 0x33,
+	0x13, 0x23, 0x27, 0x23, 0x07, 0x01, 0x11, 0xfe, 0xfa, 0xc1, 0xc4, 0xfa, 0xc5, 0xdc, 0xe4, 0xaa,
+	0xfe, 0xcf, 0xc6, 0xe6, 0xfe, 0xfc, 0x4a, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x04, 0x3e,
+	0xfc, 0xce, 0x03, 0x32, 0xfc, 0xcb, 0x03, 0x35, 0xfb, 0xc2, 0x03, 0x49, 0xfc, 0xb7, 0x05, 0x03,
+	0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x39,
+	0x07, 0x8f, 0x00, 0x08, 0x00, 0x10, 0x00, 0x63, 0x40, 0x0c, 0x0e, 0x01, 0x04, 0x03, 0x07, 0x04,
+	0x01, 0x03, 0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03, 0x04,
+	0x03, 0x85, 0x07, 0x05, 0x02, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x06,
+	0x01, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x03, 0x04, 0x03, 0x85, 0x07, 0x05,
+	0x02, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x06, 0x01, 0x02, 0x02, 0x3c,
+	0x02, 0x4e, 0x59, 0x40, 0x15, 0x09, 0x09, 0x00, 0x00, 0x09, 0x10, 0x09, 0x10, 0x0d, 0x0c, 0x0b,
+	0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x08, 0x09, 0x18, 0x2b, 0x21, 0x11, 0x01, 0x33, 0x01,
+	0x01, 0x33, 0x01, 0x11, 0x01, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x02, 0x31, 0xfd, 0xed,
+	0xf0, 0x01, 0xa5, 0x01, 0xc3, 0xc3, 0xfd, 0xca, 0xfe, 0x61, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02,
+	0xc9, 0x02, 0x69, 0x03, 0x5f, 0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01,
+	0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x13, 0xfe, 0x75, 0x03, 0xf4,
+	0x06, 0x44, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x5b, 0x40, 0x0a, 0x0d, 0x01, 0x04, 0x03, 0x03, 0x01,
+	0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1b, 0x06, 0x05, 0x02, 0x04, 0x03,
+	0x00, 0x03, 0x04, 0x00, 0x80, 0x00, 0x03, 0x03, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d,
+	0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x05,
+	0x02, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02,
+	0x4e, 0x59, 0x40, 0x0e, 0x08, 0x08, 0x08, 0x0f, 0x08, 0x0f, 0x11, 0x12, 0x11, 0x12, 0x11, 0x07,
+	0x09, 0x1b, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x23, 0x03, 0x13, 0x33, 0x13, 0x23,
+	0x27, 0x23, 0x07, 0x01, 0x94, 0xfe, 0x7f, 0xc8, 0x01, 0x27, 0x01, 0x44, 0xae, 0xfd, 0xc2, 0xcd,
+	0x37, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x04, 0x3e, 0xfc, 0xbf, 0x03, 0x41, 0xfa, 0x37,
+	0x06, 0x8e, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x03, 0x00, 0x1e, 0x00, 0x00, 0x05, 0x39,
+	0x07, 0x0f, 0x00, 0x08, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x67, 0xb7, 0x07, 0x04, 0x01, 0x03, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x05, 0x01, 0x03, 0x09, 0x06, 0x08,
+	0x03, 0x04, 0x00, 0x03, 0x04, 0x67, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x01, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x01, 0x01, 0x00, 0x04, 0x02, 0x04, 0x00, 0x02, 0x80, 0x05,
+	0x01, 0x03, 0x09, 0x06, 0x08, 0x03, 0x04, 0x00, 0x03, 0x04, 0x67, 0x07, 0x01, 0x02, 0x02, 0x3c,
+	0x02, 0x4e, 0x59, 0x40, 0x1b, 0x0d, 0x0d, 0x09, 0x09, 0x00, 0x00, 0x0d, 0x10, 0x0d, 0x10, 0x0f,
+	0x0e, 0x09, 0x0c, 0x09, 0x0c, 0x0b, 0x0a, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x0a, 0x09, 0x18,
+	0x2b, 0x21, 0x11, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35,
+	0x33, 0x15, 0x02, 0x31, 0xfd, 0xed, 0xf0, 0x01, 0xa5, 0x01, 0xc3, 0xc3, 0xfd, 0xca, 0xfe, 0xa3,
+	0xad, 0xde, 0xad, 0x02, 0x69, 0x03, 0x5f, 0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x06,
+	0x62, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x65, 0x00, 0x00, 0x04, 0x7c,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x6b, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02,
+	0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05,
+	0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x68, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a,
+	0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08,
+	0x09, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x01, 0x13, 0x33,
+	0x01, 0x65, 0x03, 0x1b, 0xfd, 0x16, 0x03, 0xe6, 0xfc, 0xe5, 0x03, 0x1b, 0xfd, 0x61, 0xf1, 0xe4,
+	0xfe, 0xbf, 0xa9, 0x04, 0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf,
+	0x00, 0x02, 0x00, 0x4a, 0x00, 0x00, 0x03, 0xb6, 0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x9a,
+	0xb7, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x24,
+	0x07, 0x01, 0x05, 0x04, 0x01, 0x04, 0x05, 0x01, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x21,
+	0x00, 0x04, 0x05, 0x04, 0x85, 0x07, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03,
+	0x4e, 0x59, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00,
+	0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21,
+	0x15, 0x01, 0x21, 0x15, 0x01, 0x13, 0x33, 0x01, 0x4a, 0x02, 0x6d, 0xfd, 0xb2, 0x03, 0x41, 0xfd,
+	0x93, 0x02, 0x79, 0xfd, 0xb6, 0xf1, 0xe4, 0xfe, 0xbf, 0x94, 0x03, 0x16, 0x94, 0x94, 0xfc, 0xea,
+	0x94, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x65, 0x00, 0x00, 0x04, 0x7c,
+	0x07, 0x31, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x67, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02,
+	0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01, 0x04, 0x05,
+	0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x00, 0x04, 0x07, 0x01, 0x05, 0x01,
+	0x04, 0x05, 0x67, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f,
+	0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x0d,
+	0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08, 0x09, 0x19, 0x2b, 0x33,
+	0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x01, 0x35, 0x33, 0x15, 0x65, 0x03, 0x1b,
+	0xfd, 0x16, 0x03, 0xe6, 0xfc, 0xe5, 0x03, 0x1b, 0xfd, 0x9e, 0xc5, 0xa9, 0x04, 0x82, 0x9d, 0x9d,
+	0xfb, 0x7e, 0xa9, 0x06, 0x6c, 0xc5, 0xc5, 0x00, 0x00, 0x02, 0x00, 0x4a, 0x00, 0x00, 0x03, 0xb6,
+	0x05, 0xdc, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x6b, 0xb7, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02,
+	0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x07, 0x01, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04,
+	0x04, 0x38, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x04, 0x07, 0x01,
+	0x05, 0x01, 0x04, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x14, 0x0a, 0x0a,
+	0x00, 0x00, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x08,
+	0x09, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x01, 0x35, 0x33,
+	0x15, 0x4a, 0x02, 0x6d, 0xfd, 0xb2, 0x03, 0x41, 0xfd, 0x93, 0x02, 0x79, 0xfd, 0xe4, 0xc5, 0x94,
+	0x03, 0x16, 0x94, 0x94, 0xfc, 0xea, 0x94, 0x05, 0x17, 0xc5, 0xc5, 0x00, 0x00, 0x02, 0x00, 0x65,
+	0x00, 0x00, 0x04, 0x7c, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x11, 0x00, 0x76, 0x40, 0x0e, 0x0f, 0x01,
+	0x04, 0x05, 0x01, 0x4c, 0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x20, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x68, 0x00, 0x02, 0x02, 0x03,
+	0x5f, 0x07, 0x01, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a,
+	0x11, 0x0a, 0x11, 0x0e, 0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x09, 0x09,
+	0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x03, 0x03, 0x23, 0x03,
+	0x33, 0x17, 0x33, 0x37, 0x65, 0x03, 0x1b, 0xfd, 0x16, 0x03, 0xe6, 0xfc, 0xe5, 0x03, 0x1b, 0xa1,
+	0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0xa9, 0x04, 0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x07,
+	0x8f, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x02, 0x00, 0x4a, 0x00, 0x00, 0x03, 0xb6,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x11, 0x00, 0xa6, 0x40, 0x0e, 0x0f, 0x01, 0x04, 0x05, 0x01, 0x4c,
+	0x06, 0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x00,
+	0x04, 0x05, 0x01, 0x05, 0x04, 0x01, 0x80, 0x08, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03,
+	0x03, 0x39, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x22, 0x08, 0x06, 0x02, 0x05,
+	0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01,
+	0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40,
+	0x22, 0x08, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03,
+	0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x0a, 0x0a, 0x00, 0x00, 0x0a, 0x11, 0x0a, 0x11, 0x0e,
+	0x0d, 0x0c, 0x0b, 0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x09, 0x09, 0x19, 0x2b, 0x33, 0x35,
+	0x01, 0x21, 0x35, 0x21, 0x15, 0x01, 0x21, 0x15, 0x03, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x4a, 0x02, 0x6d, 0xfd, 0xb2, 0x03, 0x41, 0xfd, 0x93, 0x02, 0x79, 0x55, 0xf1, 0xda, 0xf1, 0x94,
+	0xc9, 0x02, 0xc9, 0x94, 0x03, 0x16, 0x94, 0x94, 0xfc, 0xea, 0x94, 0x06, 0x44, 0xfe, 0xbf, 0x01,
+	0x41, 0xca, 0xca, 0x00, 0x00, 0x01, 0x00, 0x08, 0x00, 0x00, 0x02, 0x00, 0x06, 0x44, 0x00, 0x10,
+	0x00, 0x5d, 0x40, 0x0a, 0x0a, 0x01, 0x03, 0x02, 0x0b, 0x01, 0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x40, 0x4d, 0x00,
+	0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x39, 0x04, 0x4e,
+	0x1b, 0x40, 0x1b, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x40, 0x4d, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x3b, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x23, 0x11, 0x11, 0x06, 0x09, 0x1a, 0x2b, 0x33,
+	0x11, 0x23, 0x35, 0x33, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x11,
+	0x90, 0x88, 0x88, 0x9f, 0x8f, 0x18, 0x2a, 0x1b, 0x11, 0x7f, 0x03, 0xaa, 0x94, 0x82, 0xb7, 0xcd,
+	0x05, 0x93, 0x04, 0xdb, 0xfb, 0x2b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x31, 0xfe, 0xd8, 0x04, 0x01,
+	0x05, 0xed, 0x00, 0x13, 0x00, 0x65, 0x40, 0x0a, 0x09, 0x01, 0x03, 0x02, 0x0a, 0x01, 0x01, 0x03,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x07, 0x01, 0x06, 0x00, 0x06, 0x86, 0x04,
+	0x01, 0x01, 0x05, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02,
+	0x02, 0x3e, 0x03, 0x4e, 0x1b, 0x40, 0x21, 0x07, 0x01, 0x06, 0x00, 0x06, 0x86, 0x00, 0x02, 0x00,
+	0x03, 0x01, 0x02, 0x03, 0x69, 0x04, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x04, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x05, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x13, 0x00,
+	0x13, 0x11, 0x12, 0x23, 0x22, 0x11, 0x11, 0x08, 0x09, 0x1c, 0x2b, 0x13, 0x13, 0x23, 0x35, 0x33,
+	0x37, 0x12, 0x21, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22, 0x03, 0x07, 0x33, 0x15, 0x23, 0x03, 0x31,
+	0xc9, 0xa6, 0xc4, 0x15, 0x6d, 0x01, 0x83, 0x6e, 0x76, 0x1e, 0x6a, 0x5d, 0xd6, 0x3e, 0x27, 0xbd,
+	0xdb, 0xc9, 0xfe, 0xd8, 0x03, 0xf4, 0x94, 0x69, 0x02, 0x24, 0x1c, 0x9d, 0x26, 0xfe, 0xca, 0xc4,
+	0x94, 0xfc, 0x0c, 0x00, 0x00, 0x03, 0x00, 0x13, 0x00, 0x00, 0x05, 0x3e, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x12, 0x00, 0x74, 0x40, 0x0a, 0x10, 0x01, 0x05, 0x06, 0x0a, 0x01, 0x04, 0x00,
+	0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85,
+	0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x08, 0x03, 0x02, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x21, 0x09, 0x07, 0x02,
+	0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04,
+	0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x08, 0x03, 0x02, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40,
+	0x18, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x12, 0x0b, 0x12, 0x0f, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x0a, 0x09, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03,
+	0x21, 0x03, 0x13, 0x21, 0x03, 0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x13, 0x02, 0x32,
+	0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0x01, 0x6b, 0xf1, 0xda,
+	0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02,
+	0x7a, 0x02, 0xdf, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x5f,
+	0xff, 0xe7, 0x04, 0x4a, 0x06, 0x44, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x2c, 0x00, 0xfd, 0x4b, 0xb0,
+	0x15, 0x50, 0x58, 0x40, 0x16, 0x2a, 0x01, 0x08, 0x09, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01,
+	0x02, 0x1d, 0x01, 0x04, 0x06, 0x19, 0x01, 0x00, 0x04, 0x05, 0x4c, 0x1b, 0x40, 0x16, 0x2a, 0x01,
+	0x08, 0x09, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x07, 0x06, 0x19, 0x01,
+	0x00, 0x04, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x2e, 0x00, 0x08, 0x09, 0x03,
+	0x09, 0x08, 0x03, 0x80, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x0b, 0x0a, 0x02, 0x09,
+	0x09, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x07, 0x01, 0x04,
+	0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58,
+	0x40, 0x38, 0x00, 0x08, 0x09, 0x03, 0x09, 0x08, 0x03, 0x80, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01,
+	0x06, 0x69, 0x0b, 0x0a, 0x02, 0x09, 0x09, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04,
+	0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x35, 0x0b, 0x0a, 0x02,
+	0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x03, 0x08, 0x85, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06,
+	0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61,
+	0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x25, 0x25, 0x25, 0x2c, 0x25, 0x2c, 0x29, 0x28, 0x12, 0x22,
+	0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0c, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26,
+	0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11,
+	0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32,
+	0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x03, 0x06, 0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c,
+	0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2, 0xb0, 0x68, 0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89, 0x43,
+	0x41, 0xfe, 0x83, 0xb7, 0x81, 0x01, 0x4f, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x8a, 0xa3,
+	0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d,
+	0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x05, 0xbc, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca,
+	0x00, 0x02, 0x00, 0x3a, 0x00, 0x00, 0x02, 0xf6, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x73,
+	0xb5, 0x11, 0x01, 0x06, 0x07, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x0a, 0x08,
+	0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02, 0x06, 0x85, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f,
+	0x00, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x09, 0x01, 0x05, 0x05, 0x39,
+	0x05, 0x4e, 0x1b, 0x40, 0x22, 0x0a, 0x08, 0x02, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x02, 0x06,
+	0x85, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01, 0x68, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f,
+	0x09, 0x01, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x40, 0x18, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x13,
+	0x0c, 0x13, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b,
+	0x09, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x13,
+	0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0x41, 0xf1,
+	0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x07, 0x8f,
+	0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9e, 0x00, 0x00, 0x02, 0x5a,
+	0x06, 0x44, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x7d, 0xb5, 0x09, 0x01, 0x02, 0x03, 0x01, 0x4c, 0x4b,
+	0xb0, 0x28, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x06, 0x04,
+	0x02, 0x03, 0x03, 0x3a, 0x4d, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x06, 0x04, 0x02, 0x03, 0x02, 0x03, 0x85,
+	0x00, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x39, 0x01,
+	0x4e, 0x1b, 0x40, 0x18, 0x06, 0x04, 0x02, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x00, 0x02, 0x85,
+	0x00, 0x00, 0x00, 0x3b, 0x4d, 0x05, 0x01, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x14,
+	0x04, 0x04, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x07, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x13, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33,
+	0x37, 0x9a, 0xc5, 0xfb, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x04, 0x3e, 0xfb, 0xc2, 0x06,
+	0x44, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x03, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x76, 0xb5, 0x1d, 0x01, 0x04, 0x05, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x09, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00,
+	0x04, 0x01, 0x04, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x08, 0x01,
+	0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x3f, 0x00, 0x4e, 0x1b, 0x40, 0x21, 0x09, 0x06,
+	0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04, 0x85, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x6a, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59,
+	0x40, 0x1d, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1f, 0x18, 0x1f, 0x1c, 0x1b, 0x1a, 0x19,
+	0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b,
+	0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11,
+	0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01, 0x40, 0x01, 0x80, 0xfe, 0x80,
+	0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x02, 0x42, 0xf1, 0xda, 0xf1, 0x94, 0xc9,
+	0x02, 0xc9, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe,
+	0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda,
+	0xfe, 0xde, 0xfe, 0xb6, 0x07, 0x17, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x03, 0x00, 0x56,
+	0xff, 0xe7, 0x04, 0x1c, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x7b, 0xb5, 0x19,
+	0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x26, 0x00, 0x04, 0x05, 0x01,
+	0x05, 0x04, 0x01, 0x80, 0x09, 0x06, 0x02, 0x05, 0x05, 0x3a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61,
+	0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00, 0x61, 0x07, 0x01, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x23, 0x09, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x01, 0x04,
+	0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x08, 0x01, 0x02, 0x02, 0x00,
+	0x61, 0x07, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x14, 0x14, 0x0d, 0x0c, 0x01,
+	0x00, 0x14, 0x1b, 0x14, 0x1b, 0x18, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x0a, 0x09, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33,
+	0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11, 0x10, 0x01, 0x03, 0x23,
+	0x03, 0x33, 0x17, 0x33, 0x37, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04,
+	0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x02, 0x6c, 0xf1, 0xda, 0xf1, 0x94, 0xc9,
+	0x02, 0xc9, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe,
+	0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x05, 0xc9, 0xfe, 0xbf,
+	0x01, 0x41, 0xca, 0xca, 0x00, 0x02, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x07, 0x8f, 0x00, 0x15,
+	0x00, 0x1d, 0x00, 0x5e, 0xb5, 0x1b, 0x01, 0x04, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x1d, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01,
+	0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b,
+	0x40, 0x1d, 0x07, 0x06, 0x02, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x02, 0x01,
+	0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59,
+	0x40, 0x0f, 0x16, 0x16, 0x16, 0x1d, 0x16, 0x1d, 0x11, 0x14, 0x25, 0x13, 0x25, 0x10, 0x08, 0x09,
+	0x1c, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11,
+	0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11, 0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe,
+	0xe6, 0x03, 0x9b, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93,
+	0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31,
+	0x05, 0x6b, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x02, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8,
+	0x06, 0x44, 0x00, 0x10, 0x00, 0x18, 0x00, 0xd2, 0x40, 0x0b, 0x16, 0x01, 0x05, 0x06, 0x0d, 0x01,
+	0x02, 0x02, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x01,
+	0x06, 0x05, 0x01, 0x80, 0x09, 0x07, 0x02, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x08, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x28, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06, 0x01, 0x06, 0x05, 0x01, 0x80, 0x09, 0x07,
+	0x02, 0x06, 0x06, 0x3a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x01, 0x05, 0x85,
+	0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x23, 0x09, 0x07, 0x02, 0x06, 0x05, 0x06,
+	0x85, 0x00, 0x05, 0x01, 0x05, 0x85, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x08, 0x01, 0x04, 0x04,
+	0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x17, 0x11, 0x11, 0x00, 0x00, 0x11, 0x18, 0x11, 0x18, 0x15, 0x14, 0x13, 0x12, 0x00, 0x10,
+	0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0a, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11,
+	0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x03, 0x03, 0x23, 0x03, 0x33,
+	0x17, 0x33, 0x37, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0x47,
+	0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83,
+	0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x08, 0x64, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d,
+	0x00, 0x21, 0x00, 0x7b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x08, 0x0c, 0x01, 0x09,
+	0x04, 0x08, 0x09, 0x67, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67,
+	0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03,
+	0x4e, 0x1b, 0x40, 0x29, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x00, 0x08, 0x0c,
+	0x01, 0x09, 0x04, 0x08, 0x09, 0x67, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04,
+	0x05, 0x67, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1e,
+	0x1e, 0x1e, 0x1a, 0x1a, 0x16, 0x16, 0x1e, 0x21, 0x1e, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a, 0x1d,
+	0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x0d, 0x09, 0x1b, 0x2b, 0x13,
+	0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07,
+	0x06, 0x23, 0x20, 0x00, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x21,
+	0x15, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd,
+	0xfe, 0xe6, 0x01, 0x2e, 0xad, 0xde, 0xad, 0xfd, 0x96, 0x02, 0x82, 0x05, 0xc8, 0xfc, 0x5a, 0x9e,
+	0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01,
+	0x31, 0x04, 0x3e, 0xad, 0xad, 0xad, 0xad, 0x01, 0x6e, 0x94, 0x94, 0x00, 0x00, 0x04, 0x00, 0x8e,
+	0xff, 0xe7, 0x03, 0xd8, 0x07, 0x0f, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0xc8,
+	0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x2a, 0x00,
+	0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f,
+	0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x2e, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06,
+	0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x2c, 0x00, 0x09, 0x0e, 0x01, 0x0a, 0x05, 0x09, 0x0a, 0x67, 0x07, 0x01, 0x05, 0x0d, 0x08,
+	0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x67, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04,
+	0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59,
+	0x40, 0x25, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x1c, 0x19, 0x1c, 0x1b, 0x1a,
+	0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10,
+	0x12, 0x23, 0x12, 0x22, 0x0f, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33,
+	0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x21, 0x15, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95,
+	0xc5, 0xfd, 0x3f, 0xad, 0xde, 0xad, 0xfd, 0xa3, 0x02, 0x82, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c,
+	0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x01,
+	0x6e, 0x94, 0x94, 0x00, 0x00, 0x04, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20, 0x08, 0xf3, 0x00, 0x15,
+	0x00, 0x19, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x7f, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00,
+	0x08, 0x09, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x09, 0x85, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a,
+	0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2b, 0x00, 0x08, 0x09, 0x08, 0x85, 0x0c,
+	0x01, 0x09, 0x04, 0x09, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06, 0x01,
+	0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1e, 0x1e, 0x1e, 0x1a, 0x1a, 0x16, 0x16, 0x1e, 0x21,
+	0x1e, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25,
+	0x13, 0x25, 0x10, 0x0d, 0x09, 0x1b, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32,
+	0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11, 0x01, 0x35, 0x33,
+	0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x13, 0x33, 0x01, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8,
+	0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0x01, 0x2e, 0xad, 0xde, 0xad, 0xfe,
+	0x8d, 0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03,
+	0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xad, 0xad, 0xad,
+	0xad, 0x01, 0x50, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x04, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8,
+	0x07, 0xa8, 0x00, 0x10, 0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0xce, 0xb6, 0x0d, 0x01, 0x02,
+	0x02, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x09, 0x0a, 0x09, 0x85,
+	0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01,
+	0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b,
+	0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x30, 0x00,
+	0x09, 0x0a, 0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06,
+	0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01,
+	0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b,
+	0x40, 0x2e, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0e, 0x01, 0x0a, 0x05, 0x0a, 0x85, 0x07, 0x01, 0x05,
+	0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b,
+	0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x59, 0x40, 0x25, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00, 0x19, 0x1c, 0x19, 0x1c,
+	0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x00, 0x10,
+	0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0f, 0x09, 0x1a, 0x2b, 0x21, 0x35, 0x06, 0x23, 0x20, 0x11,
+	0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33,
+	0x35, 0x33, 0x15, 0x01, 0x13, 0x33, 0x01, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d,
+	0xa7, 0x95, 0xc5, 0xfd, 0x3f, 0xad, 0xde, 0xad, 0xfe, 0x9a, 0xf1, 0xe4, 0xfe, 0xbf, 0xcb, 0xe4,
+	0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x0d, 0xad,
+	0xad, 0xad, 0xad, 0x01, 0x5a, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0xa6,
+	0xff, 0xdb, 0x05, 0x20, 0x08, 0xf3, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x25, 0x00, 0x8a,
+	0xb5, 0x23, 0x01, 0x08, 0x09, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x0d, 0x0a,
+	0x02, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x06, 0x01, 0x04, 0x0c, 0x07, 0x0b,
+	0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2c, 0x0d, 0x0a, 0x02, 0x09, 0x08, 0x09,
+	0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80, 0x06,
+	0x01, 0x04, 0x0c, 0x07, 0x0b, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x00, 0x01, 0x01, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x20, 0x1e, 0x1e, 0x1a, 0x1a, 0x16, 0x16, 0x1e,
+	0x25, 0x1e, 0x25, 0x22, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16,
+	0x19, 0x14, 0x25, 0x13, 0x25, 0x10, 0x0e, 0x09, 0x1b, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17,
+	0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11,
+	0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x13, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37,
+	0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8, 0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe,
+	0xe6, 0x01, 0x2e, 0xad, 0xde, 0xad, 0x35, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0x05, 0xc8,
+	0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56, 0xc6, 0xcc, 0x4c, 0x65,
+	0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xad, 0xad, 0xad, 0xad, 0x02, 0x91, 0xfe, 0xbf, 0x01, 0x41,
+	0xca, 0xca, 0x00, 0x00, 0x00, 0x04, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8, 0x07, 0xa8, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x20, 0x00, 0xd8, 0x40, 0x0b, 0x1e, 0x01, 0x09, 0x0a, 0x0d, 0x01,
+	0x02, 0x02, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x2d, 0x0f, 0x0b, 0x02, 0x0a,
+	0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x06, 0x05, 0x5f,
+	0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x0c, 0x04, 0x02, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x31, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x0e, 0x08, 0x0d,
+	0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b,
+	0x4d, 0x0c, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x40, 0x2f, 0x0f, 0x0b, 0x02, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09,
+	0x85, 0x07, 0x01, 0x05, 0x0e, 0x08, 0x0d, 0x03, 0x06, 0x01, 0x05, 0x06, 0x68, 0x03, 0x01, 0x01,
+	0x01, 0x3b, 0x4d, 0x0c, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x27, 0x19, 0x19, 0x15, 0x15, 0x11, 0x11, 0x00, 0x00,
+	0x19, 0x20, 0x19, 0x20, 0x1d, 0x1c, 0x1b, 0x1a, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14,
+	0x11, 0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x10, 0x09, 0x1a, 0x2b,
+	0x21, 0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33,
+	0x11, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x13, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33,
+	0x37, 0x03, 0x13, 0x9c, 0xd3, 0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0xfd, 0x3f, 0xad,
+	0xde, 0xad, 0x42, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02, 0xc9, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c,
+	0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb, 0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x02,
+	0x9b, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x04, 0x00, 0xa6, 0xff, 0xdb, 0x05, 0x20,
+	0x08, 0xf3, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x79, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x27, 0x00, 0x09, 0x08, 0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x06, 0x01, 0x04,
+	0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x3f, 0x03, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x09, 0x08,
+	0x09, 0x85, 0x00, 0x08, 0x04, 0x08, 0x85, 0x02, 0x01, 0x00, 0x05, 0x01, 0x05, 0x00, 0x01, 0x80,
+	0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x40, 0x1a, 0x1a, 0x1a, 0x16, 0x16, 0x21, 0x20,
+	0x1f, 0x1e, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x14, 0x25, 0x13, 0x25,
+	0x10, 0x0c, 0x09, 0x1b, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x17, 0x16, 0x33, 0x32, 0x36, 0x35,
+	0x11, 0x33, 0x11, 0x14, 0x06, 0x07, 0x06, 0x23, 0x20, 0x00, 0x11, 0x01, 0x35, 0x33, 0x15, 0x33,
+	0x35, 0x33, 0x15, 0x03, 0x23, 0x01, 0x33, 0xa6, 0xd2, 0x33, 0x48, 0x62, 0xaa, 0xc8, 0xa1, 0xb8,
+	0x4e, 0x69, 0x8c, 0xea, 0xfe, 0xcd, 0xfe, 0xe6, 0x01, 0x2e, 0xad, 0xde, 0xad, 0xdf, 0x94, 0xfe,
+	0xbf, 0xe4, 0x05, 0xc8, 0xfc, 0x5a, 0x9e, 0x93, 0x33, 0x46, 0xbb, 0xe8, 0x03, 0xad, 0xfc, 0x56,
+	0xc6, 0xcc, 0x4c, 0x65, 0x01, 0x18, 0x01, 0x31, 0x04, 0x3e, 0xad, 0xad, 0xad, 0xad, 0x01, 0x50,
+	0x01, 0x41, 0x00, 0x00, 0x00, 0x04, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xd8, 0x07, 0xa8, 0x00, 0x10,
+	0x00, 0x14, 0x00, 0x18, 0x00, 0x1c, 0x00, 0xc7, 0xb6, 0x0d, 0x01, 0x02, 0x02, 0x01, 0x01, 0x4c,
+	0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x2b, 0x00, 0x0a, 0x09, 0x0a, 0x85, 0x00, 0x09, 0x05, 0x09,
+	0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x0b, 0x04, 0x02, 0x00, 0x00, 0x42,
+	0x00, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2f, 0x00, 0x0a, 0x09, 0x0a, 0x85, 0x00,
+	0x09, 0x05, 0x09, 0x85, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05,
+	0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x39, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x2d, 0x00, 0x0a, 0x09, 0x0a,
+	0x85, 0x00, 0x09, 0x05, 0x09, 0x85, 0x07, 0x01, 0x05, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x01, 0x05,
+	0x06, 0x68, 0x03, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0b, 0x01, 0x04, 0x04, 0x3c, 0x4d, 0x00, 0x02,
+	0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x59, 0x59, 0x40, 0x21, 0x15, 0x15, 0x11,
+	0x11, 0x00, 0x00, 0x1c, 0x1b, 0x1a, 0x19, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x11, 0x14, 0x11,
+	0x14, 0x13, 0x12, 0x00, 0x10, 0x00, 0x10, 0x12, 0x23, 0x12, 0x22, 0x0e, 0x09, 0x1a, 0x2b, 0x21,
+	0x35, 0x06, 0x23, 0x20, 0x11, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11,
+	0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x23, 0x01, 0x33, 0x03, 0x13, 0x9c, 0xd3,
+	0xfe, 0xea, 0xc5, 0x37, 0x4d, 0xa7, 0x95, 0xc5, 0xfd, 0x3f, 0xad, 0xde, 0xad, 0xd2, 0x94, 0xfe,
+	0xbf, 0xe4, 0xcb, 0xe4, 0x01, 0x4b, 0x03, 0x0c, 0xfd, 0x34, 0x83, 0x5e, 0xed, 0x02, 0xc0, 0xfb,
+	0xc2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x01, 0x5a, 0x01, 0x41, 0x00, 0x00, 0x03, 0x00, 0x13,
+	0x00, 0x00, 0x05, 0x3e, 0x08, 0x46, 0x00, 0x1b, 0x00, 0x1e, 0x00, 0x2c, 0x00, 0x6a, 0x40, 0x0c,
+	0x03, 0x01, 0x06, 0x00, 0x1e, 0x13, 0x0c, 0x03, 0x04, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1f, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x04, 0x00,
+	0x02, 0x01, 0x04, 0x02, 0x68, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x39,
+	0x01, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x00, 0x06, 0x00, 0x85, 0x00, 0x06, 0x05, 0x06, 0x85, 0x07,
+	0x01, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x03, 0x01, 0x01,
+	0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x10, 0x21, 0x1f, 0x28, 0x26, 0x1f, 0x2c, 0x21, 0x2c, 0x1a,
+	0x11, 0x11, 0x1b, 0x11, 0x08, 0x09, 0x1b, 0x2b, 0x01, 0x13, 0x33, 0x01, 0x23, 0x16, 0x17, 0x16,
+	0x15, 0x14, 0x07, 0x06, 0x07, 0x01, 0x23, 0x03, 0x21, 0x03, 0x23, 0x01, 0x26, 0x27, 0x26, 0x35,
+	0x34, 0x37, 0x36, 0x37, 0x03, 0x21, 0x03, 0x13, 0x33, 0x36, 0x37, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x02, 0x66, 0xc9, 0xe4, 0xfe, 0xe7, 0x02, 0x31, 0x27, 0x43, 0x43,
+	0x12, 0x16, 0x02, 0x16, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xc3, 0x02, 0x1f, 0x12, 0x11, 0x42, 0x42,
+	0x27, 0x31, 0xbb, 0x01, 0xdc, 0xed, 0x13, 0x09, 0x36, 0x26, 0x2a, 0x53, 0x3a, 0x3b, 0x52, 0x52,
+	0x07, 0x2d, 0x01, 0x19, 0xfe, 0xe7, 0x10, 0x27, 0x42, 0x5e, 0x60, 0x42, 0x13, 0x0d, 0xfa, 0x6c,
+	0x01, 0x9a, 0xfe, 0x66, 0x05, 0x97, 0x0c, 0x11, 0x43, 0x5e, 0x5e, 0x42, 0x28, 0x10, 0xfb, 0x09,
+	0x02, 0x7a, 0x01, 0x18, 0x03, 0x26, 0x29, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x5f, 0xff, 0xe7, 0x04, 0x4a, 0x07, 0xbb, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x37,
+	0x00, 0x43, 0x00, 0xc9, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x16, 0x28, 0x01, 0x0b, 0x08, 0x0e,
+	0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04, 0x06, 0x19, 0x01, 0x00, 0x04, 0x05,
+	0x4c, 0x1b, 0x40, 0x16, 0x28, 0x01, 0x0b, 0x08, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02,
+	0x1d, 0x01, 0x07, 0x06, 0x19, 0x01, 0x00, 0x04, 0x05, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x32, 0x00, 0x08, 0x0b, 0x08, 0x85, 0x00, 0x0b, 0x0a, 0x0b, 0x85, 0x0c, 0x01, 0x0a, 0x00,
+	0x09, 0x03, 0x0a, 0x09, 0x69, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x00, 0x02, 0x02,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00,
+	0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x3c, 0x00, 0x08, 0x0b, 0x08, 0x85, 0x00, 0x0b, 0x0a, 0x0b,
+	0x85, 0x0c, 0x01, 0x0a, 0x00, 0x09, 0x03, 0x0a, 0x09, 0x69, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01,
+	0x06, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x41, 0x4d, 0x00, 0x07, 0x07, 0x00,
+	0x61, 0x05, 0x01, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00,
+	0x42, 0x00, 0x4e, 0x59, 0x40, 0x16, 0x39, 0x38, 0x3f, 0x3d, 0x38, 0x43, 0x39, 0x43, 0x31, 0x2f,
+	0x12, 0x22, 0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x0d, 0x09, 0x1f, 0x2b, 0x25, 0x06, 0x23,
+	0x22, 0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16,
+	0x15, 0x11, 0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11, 0x27, 0x20, 0x15, 0x14,
+	0x33, 0x32, 0x03, 0x13, 0x33, 0x01, 0x23, 0x16, 0x17, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26,
+	0x35, 0x34, 0x37, 0x36, 0x37, 0x13, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x03, 0x06, 0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2,
+	0xb0, 0x68, 0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89, 0x43, 0x41, 0xfe, 0x83, 0xb7, 0x81, 0x6e, 0xf1,
+	0xe4, 0xfe, 0xbf, 0x02, 0x31, 0x27, 0x43, 0x85, 0x61, 0x5c, 0x84, 0x42, 0x27, 0x31, 0x47, 0x3c,
+	0x53, 0x53, 0x3a, 0x3b, 0x52, 0x52, 0x8a, 0xa3, 0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3,
+	0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d, 0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac,
+	0x05, 0xf2, 0x01, 0x41, 0xfe, 0xbf, 0x10, 0x27, 0x42, 0x5e, 0x60, 0x84, 0x85, 0x5e, 0x5e, 0x42,
+	0x28, 0x10, 0xfe, 0x9b, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00, 0x03, 0x00, 0x13,
+	0x00, 0x00, 0x07, 0xc6, 0x07, 0x8f, 0x00, 0x02, 0x00, 0x12, 0x00, 0x16, 0x00, 0x90, 0xb5, 0x02,
+	0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x32, 0x00, 0x09, 0x0a, 0x09,
+	0x85, 0x0c, 0x01, 0x0a, 0x01, 0x0a, 0x85, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x07, 0x05, 0x00, 0x07, 0x67, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38,
+	0x4d, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x0b, 0x08, 0x02, 0x06, 0x06, 0x39, 0x06, 0x4e, 0x1b, 0x40,
+	0x30, 0x00, 0x09, 0x0a, 0x09, 0x85, 0x0c, 0x01, 0x0a, 0x01, 0x0a, 0x85, 0x00, 0x01, 0x00, 0x02,
+	0x03, 0x01, 0x02, 0x68, 0x00, 0x03, 0x00, 0x04, 0x00, 0x03, 0x04, 0x67, 0x00, 0x00, 0x00, 0x07,
+	0x05, 0x00, 0x07, 0x67, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x0b, 0x08, 0x02, 0x06, 0x06, 0x3c, 0x06,
+	0x4e, 0x59, 0x40, 0x19, 0x13, 0x13, 0x03, 0x03, 0x13, 0x16, 0x13, 0x16, 0x15, 0x14, 0x03, 0x12,
+	0x03, 0x12, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x13, 0x10, 0x0d, 0x09, 0x1e, 0x2b, 0x01, 0x21,
+	0x11, 0x01, 0x01, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21,
+	0x01, 0x01, 0x13, 0x33, 0x01, 0x02, 0x55, 0x01, 0xa2, 0xfc, 0x1c, 0x03, 0xac, 0x03, 0xdc, 0xfd,
+	0x2e, 0x02, 0x6e, 0xfd, 0x92, 0x02, 0xfd, 0xfc, 0x31, 0xfd, 0xfb, 0xfe, 0xfa, 0x03, 0x25, 0xf1,
+	0xe4, 0xfe, 0xbf, 0x02, 0x39, 0x02, 0x92, 0xfb, 0x35, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd,
+	0xe8, 0x9d, 0x01, 0x9e, 0xfe, 0x62, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x04, 0x00, 0x5f,
+	0xff, 0xe7, 0x06, 0xa5, 0x06, 0x44, 0x00, 0x07, 0x00, 0x2a, 0x00, 0x2f, 0x00, 0x33, 0x01, 0xc0,
+	0x4b, 0xb0, 0x1c, 0x50, 0x58, 0x40, 0x14, 0x27, 0x01, 0x08, 0x02, 0x26, 0x01, 0x07, 0x08, 0x17,
+	0x12, 0x00, 0x03, 0x01, 0x00, 0x13, 0x01, 0x05, 0x01, 0x04, 0x4c, 0x1b, 0x4b, 0xb0, 0x21, 0x50,
+	0x58, 0x40, 0x14, 0x27, 0x01, 0x08, 0x02, 0x26, 0x01, 0x0a, 0x08, 0x17, 0x12, 0x00, 0x03, 0x01,
+	0x00, 0x13, 0x01, 0x05, 0x01, 0x04, 0x4c, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x14, 0x27,
+	0x01, 0x08, 0x02, 0x26, 0x01, 0x0a, 0x08, 0x17, 0x12, 0x00, 0x03, 0x01, 0x03, 0x13, 0x01, 0x05,
+	0x01, 0x04, 0x4c, 0x1b, 0x40, 0x14, 0x27, 0x01, 0x08, 0x02, 0x26, 0x01, 0x0a, 0x08, 0x17, 0x12,
+	0x00, 0x03, 0x01, 0x03, 0x13, 0x01, 0x05, 0x04, 0x04, 0x4c, 0x59, 0x59, 0x59, 0x4b, 0xb0, 0x1c,
+	0x50, 0x58, 0x40, 0x31, 0x0e, 0x01, 0x0d, 0x0c, 0x02, 0x0c, 0x0d, 0x02, 0x80, 0x0a, 0x01, 0x07,
+	0x03, 0x01, 0x00, 0x01, 0x07, 0x00, 0x69, 0x00, 0x0c, 0x0c, 0x3a, 0x4d, 0x0b, 0x01, 0x08, 0x08,
+	0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x05, 0x61, 0x06, 0x01,
+	0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40, 0x36, 0x0e, 0x01, 0x0d,
+	0x0c, 0x02, 0x0c, 0x0d, 0x02, 0x80, 0x00, 0x0a, 0x07, 0x00, 0x0a, 0x57, 0x00, 0x07, 0x03, 0x01,
+	0x00, 0x01, 0x07, 0x00, 0x69, 0x00, 0x0c, 0x0c, 0x3a, 0x4d, 0x0b, 0x01, 0x08, 0x08, 0x02, 0x61,
+	0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05,
+	0x42, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x37, 0x0e, 0x01, 0x0d, 0x0c, 0x02,
+	0x0c, 0x0d, 0x02, 0x80, 0x00, 0x07, 0x00, 0x00, 0x03, 0x07, 0x00, 0x69, 0x00, 0x0a, 0x00, 0x03,
+	0x01, 0x0a, 0x03, 0x67, 0x00, 0x0c, 0x0c, 0x3a, 0x4d, 0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09,
+	0x01, 0x02, 0x02, 0x41, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42,
+	0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2d, 0x50, 0x58, 0x40, 0x34, 0x00, 0x0c, 0x0d, 0x0c, 0x85, 0x0e,
+	0x01, 0x0d, 0x02, 0x0d, 0x85, 0x00, 0x07, 0x00, 0x00, 0x03, 0x07, 0x00, 0x69, 0x00, 0x0a, 0x00,
+	0x03, 0x01, 0x0a, 0x03, 0x67, 0x0b, 0x01, 0x08, 0x08, 0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41,
+	0x4d, 0x04, 0x01, 0x01, 0x01, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40,
+	0x3e, 0x00, 0x0c, 0x0d, 0x0c, 0x85, 0x0e, 0x01, 0x0d, 0x02, 0x0d, 0x85, 0x00, 0x07, 0x00, 0x00,
+	0x03, 0x07, 0x00, 0x69, 0x00, 0x0a, 0x00, 0x03, 0x01, 0x0a, 0x03, 0x67, 0x0b, 0x01, 0x08, 0x08,
+	0x02, 0x61, 0x09, 0x01, 0x02, 0x02, 0x41, 0x4d, 0x00, 0x01, 0x01, 0x05, 0x61, 0x06, 0x01, 0x05,
+	0x05, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x61, 0x06, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x59,
+	0x59, 0x59, 0x59, 0x40, 0x1a, 0x30, 0x30, 0x30, 0x33, 0x30, 0x33, 0x32, 0x31, 0x2f, 0x2d, 0x2c,
+	0x2b, 0x2a, 0x28, 0x23, 0x23, 0x23, 0x23, 0x21, 0x12, 0x22, 0x22, 0x21, 0x0f, 0x09, 0x1f, 0x2b,
+	0x25, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33, 0x32, 0x01, 0x36, 0x33, 0x20, 0x11, 0x15, 0x21, 0x12,
+	0x21, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x27, 0x06, 0x06, 0x23, 0x22, 0x26, 0x35, 0x10, 0x21,
+	0x33, 0x35, 0x34, 0x26, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x13, 0x21, 0x10, 0x23, 0x20,
+	0x03, 0x13, 0x33, 0x01, 0x02, 0xff, 0x4b, 0xfe, 0x70, 0xca, 0x8b, 0x01, 0x1b, 0x90, 0xc4, 0x01,
+	0xbd, 0xfd, 0x1c, 0x1b, 0x01, 0x77, 0x9e, 0xaf, 0xc3, 0xbd, 0xfe, 0xd1, 0x97, 0x7b, 0xb7, 0x7f,
+	0x95, 0xb5, 0x02, 0x72, 0x2e, 0x62, 0x7b, 0xb0, 0xb5, 0xc8, 0xc1, 0xe9, 0x9c, 0x02, 0x14, 0xfc,
+	0xff, 0x00, 0x99, 0xf1, 0xe4, 0xfe, 0xbf, 0xf5, 0x01, 0x19, 0x02, 0xdd, 0xab, 0x03, 0x4f, 0x7f,
+	0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x42, 0x9c, 0x3c, 0xe6, 0x85, 0x61, 0xa4, 0x86, 0x01, 0x71, 0x83,
+	0x69, 0x54, 0x60, 0xa3, 0x51, 0xfe, 0x3e, 0x01, 0x2e, 0x01, 0x41, 0x01, 0x41, 0xfe, 0xbf, 0x00,
+	0x00, 0x04, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23,
+	0x00, 0x27, 0x00, 0x7d, 0x40, 0x11, 0x08, 0x01, 0x05, 0x00, 0x23, 0x1b, 0x0b, 0x01, 0x04, 0x04,
+	0x05, 0x12, 0x01, 0x02, 0x04, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x06,
+	0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x00, 0x05, 0x05, 0x00, 0x61, 0x01, 0x01,
+	0x00, 0x00, 0x3e, 0x4d, 0x00, 0x04, 0x04, 0x02, 0x61, 0x08, 0x03, 0x02, 0x02, 0x02, 0x3f, 0x02,
+	0x4e, 0x1b, 0x40, 0x21, 0x00, 0x06, 0x07, 0x06, 0x85, 0x09, 0x01, 0x07, 0x00, 0x07, 0x85, 0x01,
+	0x01, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x6a, 0x00, 0x04, 0x04, 0x02, 0x61, 0x08, 0x03, 0x02,
+	0x02, 0x02, 0x42, 0x02, 0x4e, 0x59, 0x40, 0x18, 0x24, 0x24, 0x00, 0x00, 0x24, 0x27, 0x24, 0x27,
+	0x26, 0x25, 0x1f, 0x1d, 0x17, 0x15, 0x00, 0x13, 0x00, 0x13, 0x25, 0x12, 0x25, 0x0a, 0x09, 0x19,
+	0x2b, 0x17, 0x37, 0x26, 0x11, 0x10, 0x00, 0x21, 0x32, 0x17, 0x37, 0x33, 0x07, 0x16, 0x11, 0x10,
+	0x00, 0x21, 0x22, 0x27, 0x07, 0x13, 0x16, 0x33, 0x32, 0x12, 0x11, 0x34, 0x27, 0x27, 0x26, 0x23,
+	0x22, 0x02, 0x11, 0x14, 0x17, 0x13, 0x13, 0x33, 0x01, 0x68, 0xae, 0xb9, 0x01, 0x7f, 0x01, 0x40,
+	0xfb, 0xb0, 0x6a, 0xac, 0xb3, 0xb3, 0xfe, 0x81, 0xfe, 0xbf, 0xf2, 0xb1, 0x66, 0xd7, 0x7b, 0xb7,
+	0xe2, 0xfd, 0x52, 0x54, 0x7f, 0xba, 0xe2, 0xfd, 0x57, 0xf3, 0xf1, 0xe4, 0xfe, 0xbf, 0x25, 0xdd,
+	0xd8, 0x01, 0x55, 0x01, 0x62, 0x01, 0xa6, 0x85, 0x85, 0xe3, 0xd9, 0xfe, 0xb3, 0xfe, 0x9d, 0xfe,
+	0x5a, 0x80, 0x80, 0x01, 0x10, 0x73, 0x01, 0x46, 0x01, 0x23, 0xf2, 0x94, 0x71, 0x78, 0xfe, 0xba,
+	0xfe, 0xde, 0xf6, 0x99, 0x04, 0xf5, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x04, 0x00, 0x8f,
+	0xff, 0xe7, 0x04, 0x55, 0x06, 0x44, 0x00, 0x13, 0x00, 0x1b, 0x00, 0x23, 0x00, 0x27, 0x00, 0x74,
+	0x40, 0x0d, 0x0a, 0x01, 0x05, 0x01, 0x23, 0x1b, 0x0d, 0x03, 0x04, 0x04, 0x05, 0x02, 0x4c, 0x4b,
+	0xb0, 0x28, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x07, 0x06, 0x01, 0x06, 0x07, 0x01, 0x80, 0x00,
+	0x06, 0x06, 0x3a, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x01, 0x41, 0x4d, 0x00,
+	0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x06,
+	0x07, 0x06, 0x85, 0x08, 0x01, 0x07, 0x01, 0x07, 0x85, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01,
+	0x01, 0x01, 0x41, 0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x42, 0x00, 0x4e,
+	0x59, 0x40, 0x10, 0x24, 0x24, 0x24, 0x27, 0x24, 0x27, 0x15, 0x26, 0x23, 0x25, 0x12, 0x25, 0x11,
+	0x09, 0x09, 0x1d, 0x2b, 0x25, 0x07, 0x23, 0x37, 0x26, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x37,
+	0x33, 0x07, 0x16, 0x15, 0x10, 0x00, 0x23, 0x22, 0x27, 0x17, 0x16, 0x33, 0x20, 0x11, 0x34, 0x2f,
+	0x02, 0x26, 0x23, 0x20, 0x11, 0x14, 0x17, 0x13, 0x13, 0x33, 0x01, 0x01, 0x5c, 0x3d, 0x90, 0x79,
+	0x79, 0x01, 0x04, 0xdf, 0xaa, 0x6c, 0x3d, 0x90, 0x79, 0x79, 0xfe, 0xfd, 0xe0, 0xa7, 0x09, 0x02,
+	0x43, 0x6b, 0x01, 0x14, 0x24, 0x40, 0x02, 0x4c, 0x62, 0xfe, 0xec, 0x24, 0x66, 0xf1, 0xe4, 0xfe,
+	0xbf, 0x3b, 0x54, 0xa7, 0x9f, 0xf1, 0x01, 0x0a, 0x01, 0x2e, 0x53, 0x53, 0xa7, 0x9f, 0xf0, 0xfe,
+	0xf8, 0xfe, 0xcf, 0xe2, 0x02, 0x4c, 0x01, 0xa8, 0x7e, 0x66, 0x6e, 0x02, 0x4b, 0xfe, 0x65, 0x96,
+	0x5b, 0x03, 0xcd, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x02, 0x00, 0x78, 0xfe, 0x50, 0x04, 0xdb,
+	0x05, 0xed, 0x00, 0x1f, 0x00, 0x2d, 0x00, 0xad, 0x40, 0x18, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01,
+	0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x27, 0x21, 0x02, 0x04, 0x05, 0x20, 0x01, 0x06, 0x04,
+	0x05, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x03, 0x04, 0x04, 0x05, 0x72,
+	0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x3f, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00,
+	0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x3f, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40,
+	0x25, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02,
+	0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62,
+	0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x59, 0x59, 0x40, 0x0a, 0x22, 0x14, 0x23, 0x2a, 0x23, 0x28,
+	0x22, 0x07, 0x09, 0x1d, 0x2b, 0x37, 0x35, 0x04, 0x21, 0x20, 0x35, 0x34, 0x26, 0x27, 0x27, 0x24,
+	0x11, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x15, 0x14, 0x16, 0x17, 0x17, 0x16, 0x16,
+	0x15, 0x14, 0x04, 0x23, 0x20, 0x13, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15,
+	0x14, 0x23, 0x22, 0x78, 0x01, 0x1d, 0x01, 0x31, 0x01, 0x3d, 0x7b, 0xbc, 0xc9, 0xfe, 0x7d, 0x02,
+	0x1c, 0xf4, 0xef, 0xf8, 0xf8, 0xfe, 0xbc, 0x79, 0xa2, 0xce, 0xe9, 0xbe, 0xfe, 0xdd, 0xf9, 0xfe,
+	0xf3, 0x5a, 0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9, 0x3e, 0x34, 0xd0, 0x8c, 0xef, 0x6a, 0x6f,
+	0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a, 0x36, 0x43, 0x4c,
+	0xc3, 0xa3, 0xc6, 0xe5, 0xfe, 0x80, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x74, 0xfe, 0x50, 0x03, 0x8c, 0x04, 0x56, 0x00, 0x1c, 0x00, 0x2a, 0x00, 0x7f,
+	0x40, 0x18, 0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03, 0x00, 0x24,
+	0x1e, 0x02, 0x04, 0x05, 0x1d, 0x01, 0x06, 0x04, 0x05, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x26, 0x00, 0x05, 0x03, 0x04, 0x04, 0x05, 0x72, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x41, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x06,
+	0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05,
+	0x04, 0x80, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x4d, 0x00, 0x00, 0x00, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06,
+	0x4e, 0x59, 0x40, 0x0a, 0x22, 0x14, 0x23, 0x28, 0x23, 0x27, 0x22, 0x07, 0x09, 0x1d, 0x2b, 0x37,
+	0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26,
+	0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x04, 0x15, 0x14, 0x06, 0x23, 0x22, 0x13, 0x35, 0x16, 0x33,
+	0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0x74, 0xc2, 0xab, 0xe5, 0x9f, 0xb0,
+	0xfd, 0x01, 0xa1, 0x78, 0xa6, 0x91, 0xa2, 0xc9, 0x8d, 0x9d, 0x01, 0x25, 0xe8, 0xca, 0xa3, 0x38,
+	0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9, 0x3e, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54,
+	0xda, 0x01, 0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0xb0, 0xfe, 0x74,
+	0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x02, 0x00, 0x14, 0xfe, 0x50, 0x04, 0xce,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x15, 0x00, 0x9f, 0x40, 0x0b, 0x0f, 0x09, 0x02, 0x04, 0x05, 0x08,
+	0x01, 0x06, 0x04, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05, 0x03, 0x04,
+	0x04, 0x05, 0x72, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x07, 0x01,
+	0x03, 0x03, 0x39, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x00, 0x05, 0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x02,
+	0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x07, 0x01, 0x03, 0x03, 0x39, 0x4d,
+	0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e, 0x1b, 0x40, 0x22, 0x00, 0x05,
+	0x03, 0x04, 0x03, 0x05, 0x04, 0x80, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x07,
+	0x01, 0x03, 0x03, 0x3c, 0x4d, 0x00, 0x04, 0x04, 0x06, 0x62, 0x00, 0x06, 0x06, 0x43, 0x06, 0x4e,
+	0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x15, 0x13, 0x11, 0x10, 0x0c, 0x0a, 0x00, 0x07, 0x00, 0x07,
+	0x11, 0x11, 0x11, 0x08, 0x09, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x01,
+	0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0x02, 0x08, 0xfe,
+	0x0c, 0x04, 0xba, 0xfe, 0x0c, 0xfe, 0xfd, 0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9, 0x3e, 0x05,
+	0x2b, 0x9d, 0x9d, 0xfa, 0xd5, 0xfe, 0x5b, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00,
+	0x00, 0x02, 0x00, 0x19, 0xfe, 0x50, 0x02, 0x4d, 0x05, 0x34, 0x00, 0x14, 0x00, 0x22, 0x00, 0x85,
+	0x40, 0x18, 0x14, 0x01, 0x05, 0x01, 0x00, 0x01, 0x00, 0x05, 0x1c, 0x16, 0x02, 0x06, 0x07, 0x15,
+	0x01, 0x08, 0x06, 0x04, 0x4c, 0x0a, 0x09, 0x02, 0x02, 0x4a, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x28, 0x00, 0x07, 0x00, 0x06, 0x06, 0x07, 0x72, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01,
+	0x02, 0x02, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x06,
+	0x06, 0x08, 0x62, 0x00, 0x08, 0x08, 0x43, 0x08, 0x4e, 0x1b, 0x40, 0x29, 0x00, 0x07, 0x00, 0x06,
+	0x00, 0x07, 0x06, 0x80, 0x04, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x3b, 0x4d,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x42, 0x4d, 0x00, 0x06, 0x06, 0x08, 0x62, 0x00,
+	0x08, 0x08, 0x43, 0x08, 0x4e, 0x59, 0x40, 0x0c, 0x22, 0x14, 0x24, 0x23, 0x11, 0x13, 0x11, 0x12,
+	0x21, 0x09, 0x09, 0x1f, 0x2b, 0x05, 0x06, 0x23, 0x20, 0x11, 0x11, 0x23, 0x35, 0x33, 0x35, 0x37,
+	0x15, 0x33, 0x15, 0x23, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x01, 0x35, 0x16, 0x33, 0x32, 0x35,
+	0x34, 0x27, 0x35, 0x20, 0x15, 0x14, 0x23, 0x22, 0x02, 0x12, 0x3a, 0x34, 0xfe, 0xf4, 0x7f, 0x7f,
+	0xc5, 0xf0, 0xf0, 0x2c, 0x45, 0x29, 0x1b, 0xfe, 0x9f, 0x32, 0x30, 0x6d, 0x9e, 0x01, 0x25, 0xd9,
+	0x3e, 0x06, 0x13, 0x01, 0x45, 0x02, 0x7e, 0x94, 0xe3, 0x13, 0xf6, 0x94, 0xfd, 0xa6, 0x82, 0x53,
+	0x0b, 0xfd, 0xd5, 0x55, 0x09, 0x43, 0x4c, 0x0e, 0x4d, 0xa8, 0x99, 0x00, 0x00, 0x01, 0xff, 0xf7,
+	0x05, 0x03, 0x02, 0xb3, 0x06, 0x44, 0x00, 0x07, 0x00, 0x27, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1c,
+	0x05, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x03, 0x02, 0x02, 0x01, 0x01,
+	0x76, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00,
+	0x44, 0x03, 0x13, 0x33, 0x13, 0x23, 0x27, 0x23, 0x07, 0x09, 0xf1, 0xda, 0xf1, 0x94, 0xc9, 0x02,
+	0xc9, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0xca, 0xca, 0x00, 0x00, 0x00, 0x00, 0x01, 0xff, 0xf7,
+	0x05, 0x03, 0x02, 0xb3, 0x06, 0x44, 0x00, 0x07, 0x00, 0x27, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1c,
+	0x05, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x03, 0x02, 0x02, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00,
+	0x76, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x09, 0x18, 0x2b, 0xb1, 0x06, 0x00,
+	0x44, 0x01, 0x03, 0x23, 0x03, 0x33, 0x17, 0x33, 0x37, 0x02, 0xb3, 0xf1, 0xda, 0xf1, 0x94, 0xc9,
+	0x02, 0xc9, 0x06, 0x44, 0xfe, 0xbf, 0x01, 0x41, 0xca, 0xca, 0x00, 0x00, 0x00, 0x01, 0x00, 0x14,
+	0x05, 0x17, 0x02, 0x96, 0x05, 0xab, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44,
+	0x13, 0x35, 0x21, 0x15, 0x14, 0x02, 0x82, 0x05, 0x17, 0x94, 0x94, 0x00, 0x00, 0x01, 0x00, 0x08,
+	0x05, 0x03, 0x02, 0xa2, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x28, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1d,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01, 0x03,
+	0x61, 0x00, 0x03, 0x01, 0x03, 0x51, 0x22, 0x11, 0x21, 0x10, 0x04, 0x09, 0x1a, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x13, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06, 0x23, 0x22, 0x26, 0x08, 0x7b,
+	0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88, 0xb5, 0x06, 0x44, 0xad, 0xad, 0x92, 0xaf,
+	0xae, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xf2, 0x05, 0x17, 0x01, 0xb7, 0x05, 0xdc, 0x00, 0x03,
+	0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x35, 0x33, 0x15, 0xf2, 0xc5, 0x05, 0x17,
+	0xc5, 0xc5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x72, 0x05, 0x03, 0x02, 0x38, 0x06, 0xc9, 0x00, 0x0b,
+	0x00, 0x17, 0x00, 0x39, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x09, 0x16, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x01, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x01, 0x52, 0x5c, 0x84, 0x84, 0x5f, 0x5e, 0x85, 0x85, 0x60, 0x3c,
+	0x53, 0x53, 0x3a, 0x3b, 0x52, 0x52, 0x05, 0x03, 0x85, 0x5e, 0x5e, 0x85, 0x84, 0x5e, 0x60, 0x84,
+	0x56, 0x52, 0x3c, 0x3a, 0x51, 0x51, 0x3b, 0x3a, 0x53, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xaa,
+	0xfe, 0x8e, 0x02, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x00, 0x52, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x0a,
+	0x07, 0x01, 0x01, 0x00, 0x08, 0x01, 0x02, 0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x16, 0x00, 0x00, 0x01, 0x01, 0x00, 0x70, 0x00, 0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01,
+	0x02, 0x62, 0x00, 0x02, 0x01, 0x02, 0x52, 0x1b, 0x40, 0x15, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00,
+	0x01, 0x02, 0x02, 0x01, 0x59, 0x00, 0x01, 0x01, 0x02, 0x62, 0x00, 0x02, 0x01, 0x02, 0x52, 0x59,
+	0xb5, 0x23, 0x23, 0x10, 0x03, 0x09, 0x19, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x21, 0x33, 0x06, 0x15,
+	0x14, 0x33, 0x32, 0x37, 0x15, 0x06, 0x23, 0x22, 0x35, 0x34, 0x01, 0x48, 0x6b, 0x80, 0x72, 0x38,
+	0x23, 0x3c, 0x4e, 0xcc, 0x4d, 0x66, 0x60, 0x0f, 0x51, 0x1d, 0xa0, 0x7d, 0x00, 0x01, 0x00, 0x08,
+	0x05, 0x0d, 0x02, 0xa2, 0x05, 0xf7, 0x00, 0x13, 0x00, 0x34, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x29,
+	0x00, 0x01, 0x04, 0x03, 0x01, 0x59, 0x02, 0x01, 0x00, 0x00, 0x04, 0x03, 0x00, 0x04, 0x69, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x06, 0x05, 0x02, 0x03, 0x01, 0x03, 0x52, 0x00, 0x00, 0x00, 0x13, 0x00,
+	0x13, 0x23, 0x21, 0x11, 0x23, 0x21, 0x07, 0x09, 0x1b, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x36,
+	0x33, 0x32, 0x17, 0x17, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x23, 0x22, 0x27, 0x27, 0x26, 0x23,
+	0x22, 0x07, 0x08, 0x0c, 0xad, 0x49, 0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x7b, 0x0b, 0xae, 0x49,
+	0x3e, 0x3c, 0x38, 0x1e, 0x44, 0x09, 0x05, 0x0d, 0xea, 0x26, 0x25, 0x23, 0x6e, 0xea, 0x27, 0x25,
+	0x22, 0x6e, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcd, 0x05, 0x03, 0x02, 0xdc, 0x06, 0x44, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x32, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x27, 0x02, 0x01, 0x00, 0x01, 0x01, 0x00,
+	0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x05, 0x03, 0x04, 0x03, 0x01, 0x00, 0x01, 0x4f, 0x04,
+	0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x09,
+	0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x03, 0x13, 0x33, 0x01, 0x33, 0x13, 0x33, 0x01, 0x33, 0xf1,
+	0xbf, 0xfe, 0xbf, 0xf1, 0xf0, 0xbf, 0xfe, 0xc0, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0xc8, 0xfe, 0xa2, 0x01, 0xbf, 0x04, 0x4a, 0x00, 0x03,
+	0x00, 0x0d, 0x00, 0x86, 0xb5, 0x05, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x17, 0x50, 0x58,
+	0x40, 0x1c, 0x05, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03,
+	0x02, 0x5f, 0x00, 0x02, 0x02, 0x29, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x2d, 0x04, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x02, 0x04, 0x86, 0x05, 0x01, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x29,
+	0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x02, 0x04, 0x86, 0x05, 0x01, 0x01, 0x01, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x2c, 0x02,
+	0x4e, 0x59, 0x59, 0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0d, 0x04, 0x0d, 0x0b, 0x0a, 0x09,
+	0x08, 0x00, 0x03, 0x00, 0x03, 0x11, 0x07, 0x08, 0x17, 0x2b, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35,
+	0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x15, 0x10, 0xc8, 0xf7, 0xf7, 0x60, 0x60, 0xf7, 0x03, 0x53,
+	0xf7, 0xf7, 0xfb, 0x4f, 0x4a, 0x1b, 0xe5, 0x14, 0xf7, 0xd6, 0xfe, 0x81, 0x00, 0x01, 0x00, 0xb4,
+	0x05, 0x03, 0x02, 0x60, 0x06, 0xa6, 0x00, 0x03, 0x00, 0x1f, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x14,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03,
+	0x11, 0x03, 0x08, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x13, 0x13, 0x33, 0x01, 0xb4, 0xc8, 0xe4,
+	0xfe, 0xdc, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x03, 0xff, 0xea, 0x05, 0x0d, 0x02, 0xc2,
+	0x07, 0x07, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x48, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x3d,
+	0x00, 0x04, 0x00, 0x04, 0x85, 0x08, 0x01, 0x05, 0x00, 0x01, 0x00, 0x05, 0x01, 0x80, 0x02, 0x01,
+	0x00, 0x05, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x60, 0x07, 0x03, 0x06, 0x03, 0x01,
+	0x00, 0x01, 0x50, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04,
+	0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0xb1, 0x06,
+	0x00, 0x44, 0x03, 0x35, 0x33, 0x15, 0x21, 0x35, 0x33, 0x15, 0x25, 0x13, 0x33, 0x01, 0x16, 0xac,
+	0x01, 0x7f, 0xad, 0xfe, 0x23, 0xd2, 0xda, 0xfe, 0xd2, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x56,
+	0x01, 0xa4, 0xfe, 0x5c, 0x00, 0x03, 0x00, 0x16, 0x00, 0x00, 0x05, 0x41, 0x06, 0x2b, 0x00, 0x07,
+	0x00, 0x0a, 0x00, 0x0e, 0x00, 0x73, 0xb5, 0x0a, 0x01, 0x06, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x23, 0x08, 0x01, 0x06, 0x00, 0x04, 0x00, 0x06, 0x04, 0x80, 0x00, 0x04, 0x00,
+	0x02, 0x01, 0x04, 0x02, 0x68, 0x00, 0x05, 0x05, 0x2a, 0x4d, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x07,
+	0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x25, 0x00, 0x00, 0x05, 0x06, 0x05, 0x00,
+	0x06, 0x80, 0x08, 0x01, 0x06, 0x04, 0x05, 0x06, 0x04, 0x7e, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x05, 0x05, 0x2a, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59,
+	0x40, 0x16, 0x0b, 0x0b, 0x00, 0x00, 0x0b, 0x0e, 0x0b, 0x0e, 0x0d, 0x0c, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x09, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21,
+	0x03, 0x13, 0x21, 0x03, 0x05, 0x13, 0x33, 0x01, 0x16, 0x02, 0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a,
+	0xfd, 0xae, 0x9a, 0xd6, 0x01, 0xdc, 0xed, 0xfd, 0x78, 0xc7, 0xe5, 0xfe, 0xdb, 0x05, 0xc8, 0xfa,
+	0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x28, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xa1, 0x03, 0x47, 0x01, 0x98, 0x04, 0x3e, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16,
+	0x02, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x2b, 0x01, 0x4e, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x03, 0x08, 0x17, 0x2b, 0x13, 0x35, 0x33, 0x15, 0xa1, 0xf7, 0x03, 0x47, 0xf7,
+	0xf7, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x06, 0x0c, 0x06, 0x2b, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x7a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x09, 0x01, 0x07, 0x01, 0x02,
+	0x01, 0x07, 0x02, 0x80, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x06, 0x06, 0x2a,
+	0x4d, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f,
+	0x08, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x2a, 0x09, 0x01, 0x07, 0x01, 0x02, 0x01,
+	0x07, 0x02, 0x80, 0x00, 0x00, 0x00, 0x01, 0x07, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04,
+	0x02, 0x03, 0x67, 0x00, 0x06, 0x06, 0x2a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05,
+	0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e,
+	0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x21, 0x11,
+	0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x13, 0x33, 0x01, 0x01, 0xeb,
+	0x03, 0xf5, 0xfc, 0xdd, 0x02, 0xc0, 0xfd, 0x40, 0x03, 0x4f, 0xf9, 0xf4, 0xc8, 0xe4, 0xfe, 0xdc,
+	0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x06, 0x0e, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x70,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x08, 0x01, 0x01, 0x02, 0x03, 0x02, 0x01, 0x03, 0x80,
+	0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x67, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x04, 0x01, 0x02,
+	0x02, 0x28, 0x4d, 0x09, 0x07, 0x02, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x24, 0x08, 0x01,
+	0x01, 0x02, 0x03, 0x02, 0x01, 0x03, 0x80, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x67, 0x00,
+	0x00, 0x00, 0x2a, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x05, 0x5f, 0x09, 0x07, 0x02, 0x05, 0x05, 0x2c,
+	0x05, 0x4e, 0x59, 0x40, 0x1a, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0f, 0x04, 0x0f, 0x0e, 0x0d, 0x0c,
+	0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x08, 0x17, 0x2b,
+	0x11, 0x13, 0x33, 0x01, 0x01, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x11,
+	0xc8, 0xe4, 0xfe, 0xdc, 0x01, 0x46, 0xd2, 0x02, 0x9d, 0xd1, 0xd1, 0xfd, 0x63, 0x04, 0x88, 0x01,
+	0xa3, 0xfe, 0x5d, 0xfb, 0x78, 0x05, 0xc8, 0xfd, 0x90, 0x02, 0x70, 0xfa, 0x38, 0x02, 0xbb, 0xfd,
+	0x45, 0x00, 0x00, 0x00, 0x00, 0x02, 0xfe, 0xd4, 0x00, 0x00, 0x02, 0xb1, 0x06, 0x2b, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x6e, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x09, 0x01, 0x07, 0x01, 0x00,
+	0x01, 0x07, 0x00, 0x80, 0x00, 0x06, 0x06, 0x2a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00,
+	0x02, 0x02, 0x28, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x29, 0x05,
+	0x4e, 0x1b, 0x40, 0x24, 0x09, 0x01, 0x07, 0x01, 0x00, 0x01, 0x07, 0x00, 0x80, 0x00, 0x02, 0x03,
+	0x01, 0x01, 0x07, 0x02, 0x01, 0x68, 0x00, 0x06, 0x06, 0x2a, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x16, 0x0c, 0x0c, 0x00, 0x00, 0x0c,
+	0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x08,
+	0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x13,
+	0x33, 0x01, 0x78, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0xfc, 0x23, 0xc8, 0xe4, 0xfe, 0xdc, 0x9d,
+	0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0xff, 0x83, 0xff, 0xdb, 0x05, 0xd6, 0x06, 0x2b, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x71, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x08, 0x01, 0x05, 0x01, 0x00, 0x01, 0x05,
+	0x00, 0x80, 0x00, 0x04, 0x04, 0x2a, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x03, 0x2e,
+	0x4d, 0x06, 0x01, 0x00, 0x00, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x2f, 0x02, 0x4e, 0x1b, 0x40,
+	0x23, 0x08, 0x01, 0x05, 0x01, 0x00, 0x01, 0x05, 0x00, 0x80, 0x00, 0x03, 0x00, 0x01, 0x05, 0x03,
+	0x01, 0x69, 0x00, 0x04, 0x04, 0x2a, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x02, 0x61, 0x07, 0x01, 0x02,
+	0x02, 0x32, 0x02, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18,
+	0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09,
+	0x08, 0x16, 0x2b, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x17,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x01, 0x13, 0x33, 0x01, 0x03,
+	0x30, 0xd9, 0xea, 0xea, 0xd2, 0xd3, 0xe9, 0xe8, 0xcc, 0xfe, 0xd7, 0xfe, 0x96, 0x01, 0x6c, 0x01,
+	0x31, 0x01, 0x30, 0x01, 0x6d, 0xfe, 0x93, 0xfb, 0x1a, 0xc8, 0xe4, 0xfe, 0xdc, 0x78, 0x01, 0x45,
+	0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x9d, 0x01,
+	0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x04,
+	0xad, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x01, 0x00, 0x00, 0x06, 0x9f,
+	0x06, 0x2b, 0x00, 0x03, 0x00, 0x15, 0x00, 0x6f, 0x40, 0x0f, 0x11, 0x01, 0x02, 0x03, 0x0d, 0x01,
+	0x04, 0x01, 0x02, 0x4c, 0x10, 0x01, 0x03, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f,
+	0x05, 0x01, 0x01, 0x02, 0x04, 0x02, 0x01, 0x04, 0x80, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x00, 0x02,
+	0x02, 0x03, 0x61, 0x00, 0x03, 0x03, 0x28, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x29, 0x04, 0x4e, 0x1b,
+	0x40, 0x1d, 0x05, 0x01, 0x01, 0x02, 0x04, 0x02, 0x01, 0x04, 0x80, 0x00, 0x03, 0x00, 0x02, 0x01,
+	0x03, 0x02, 0x69, 0x00, 0x00, 0x00, 0x2a, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x2c, 0x04, 0x4e, 0x59,
+	0x40, 0x14, 0x04, 0x04, 0x00, 0x00, 0x04, 0x15, 0x04, 0x15, 0x0b, 0x09, 0x08, 0x07, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x07, 0x08, 0x17, 0x2b, 0x13, 0x13, 0x33, 0x01, 0x01, 0x11, 0x10, 0x02, 0x23,
+	0x35, 0x33, 0x32, 0x00, 0x13, 0x36, 0x00, 0x37, 0x15, 0x06, 0x00, 0x11, 0x11, 0x01, 0xd2, 0xe4,
+	0xfe, 0xd2, 0x03, 0x64, 0xff, 0xcf, 0x0f, 0xcb, 0x01, 0x2f, 0x44, 0x5b, 0x01, 0x26, 0xb2, 0xdd,
+	0xfe, 0xf1, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0xfb, 0x78, 0x01, 0xdf, 0x01, 0x60, 0x01, 0xdd,
+	0xac, 0xfe, 0xd5, 0xfe, 0xd6, 0xf4, 0x01, 0x45, 0x1c, 0x94, 0x42, 0xfe, 0x16, 0xfe, 0xd7, 0xfe,
+	0x21, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x93, 0x00, 0x00, 0x05, 0x88, 0x06, 0x2b, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0x75, 0xb5, 0x1a, 0x10, 0x02, 0x00, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x26, 0x09, 0x01, 0x07, 0x04, 0x00, 0x04, 0x07, 0x00, 0x80, 0x00, 0x06, 0x06, 0x2a, 0x4d,
+	0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f,
+	0x08, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x24, 0x09, 0x01, 0x07, 0x04, 0x00,
+	0x04, 0x07, 0x00, 0x80, 0x00, 0x01, 0x00, 0x04, 0x07, 0x01, 0x04, 0x69, 0x00, 0x06, 0x06, 0x2a,
+	0x4d, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59,
+	0x40, 0x16, 0x1c, 0x1c, 0x00, 0x00, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x00, 0x1b, 0x00, 0x1b,
+	0x25, 0x11, 0x14, 0x24, 0x11, 0x0a, 0x08, 0x1b, 0x2b, 0x33, 0x35, 0x21, 0x00, 0x11, 0x10, 0x00,
+	0x21, 0x20, 0x00, 0x11, 0x10, 0x01, 0x21, 0x15, 0x21, 0x35, 0x24, 0x11, 0x34, 0x02, 0x23, 0x22,
+	0x02, 0x11, 0x10, 0x05, 0x15, 0x01, 0x13, 0x33, 0x01, 0xb0, 0x01, 0x52, 0xfe, 0xae, 0x01, 0x63,
+	0x01, 0x09, 0x01, 0x09, 0x01, 0x63, 0xfe, 0xae, 0x01, 0x52, 0xfe, 0x03, 0x01, 0x1f, 0xe0, 0xae,
+	0xad, 0xe1, 0x01, 0x1f, 0xfc, 0xe6, 0xc8, 0xe4, 0xfe, 0xdc, 0x9a, 0x01, 0x0e, 0x01, 0x98, 0x01,
+	0x2c, 0x01, 0x81, 0xfe, 0x80, 0xfe, 0xd3, 0xfe, 0x67, 0xfe, 0xf3, 0x9a, 0x9a, 0xe5, 0x01, 0xb3,
+	0xff, 0x01, 0x22, 0xfe, 0xde, 0xff, 0x00, 0xfe, 0x4f, 0xe6, 0x9a, 0x04, 0x88, 0x01, 0xa3, 0xfe,
+	0x5d, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0xff, 0xe7, 0x02, 0xd8, 0x07, 0x07, 0x00, 0x0d,
+	0x00, 0x11, 0x00, 0x15, 0x00, 0x19, 0x00, 0x8d, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x00, 0x01,
+	0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x00, 0x07, 0x03, 0x07, 0x85,
+	0x0b, 0x01, 0x08, 0x03, 0x04, 0x03, 0x08, 0x04, 0x80, 0x0a, 0x06, 0x09, 0x03, 0x04, 0x04, 0x03,
+	0x5f, 0x05, 0x01, 0x03, 0x03, 0x28, 0x4d, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00,
+	0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x1b, 0x40, 0x2a, 0x00, 0x07, 0x03, 0x07, 0x85, 0x0b,
+	0x01, 0x08, 0x03, 0x04, 0x03, 0x08, 0x04, 0x80, 0x05, 0x01, 0x03, 0x0a, 0x06, 0x09, 0x03, 0x04,
+	0x01, 0x03, 0x04, 0x68, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00,
+	0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x1d, 0x16, 0x16, 0x12, 0x12, 0x0e, 0x0e, 0x16, 0x19, 0x16,
+	0x19, 0x18, 0x17, 0x12, 0x15, 0x12, 0x15, 0x14, 0x13, 0x0e, 0x11, 0x0e, 0x11, 0x13, 0x23, 0x13,
+	0x21, 0x0c, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x11, 0x33, 0x11, 0x14, 0x16,
+	0x33, 0x32, 0x37, 0x01, 0x35, 0x33, 0x15, 0x21, 0x35, 0x33, 0x15, 0x25, 0x13, 0x33, 0x01, 0x02,
+	0xbf, 0x5c, 0x65, 0xa8, 0x91, 0xc5, 0x47, 0x56, 0x49, 0x4f, 0xfd, 0x41, 0xac, 0x01, 0x7f, 0xad,
+	0xfe, 0x23, 0xd2, 0xda, 0xfe, 0xd2, 0x11, 0x2a, 0xbd, 0xda, 0x02, 0xc0, 0xfd, 0x53, 0x98, 0x7e,
+	0x2a, 0x04, 0x68, 0xad, 0xad, 0xad, 0xad, 0x56, 0x01, 0xa4, 0xfe, 0x5c, 0x00, 0x02, 0x00, 0x13,
+	0x00, 0x00, 0x05, 0x3e, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x08, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21,
+	0x03, 0x13, 0x21, 0x03, 0x13, 0x02, 0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6,
+	0x01, 0xdc, 0xed, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x00,
+	0x00, 0x03, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xcf, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x1f,
+	0x00, 0x61, 0xb5, 0x07, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e,
+	0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00,
+	0x28, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40,
+	0x1c, 0x00, 0x00, 0x00, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x67, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x12,
+	0x00, 0x00, 0x1f, 0x1d, 0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x07,
+	0x08, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x20, 0x16, 0x15, 0x10, 0x05, 0x04, 0x11, 0x14, 0x07, 0x06,
+	0x06, 0x23, 0x25, 0x33, 0x20, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x33, 0x20, 0x11, 0x34,
+	0x26, 0x23, 0x23, 0xa5, 0x01, 0xda, 0x01, 0x24, 0xf1, 0xfe, 0xb8, 0x01, 0x83, 0x51, 0x40, 0xba,
+	0xd1, 0xfe, 0xc4, 0x9b, 0x01, 0x28, 0xb7, 0xee, 0xe1, 0xab, 0xb3, 0x01, 0x92, 0xa0, 0xe3, 0xc2,
+	0x05, 0xc8, 0x97, 0xb8, 0xfe, 0xf2, 0x68, 0x6a, 0xfe, 0xda, 0x8f, 0x61, 0x4e, 0x35, 0x9d, 0x57,
+	0x8c, 0x98, 0xa1, 0x85, 0x01, 0x19, 0x7c, 0x58, 0x00, 0x01, 0x00, 0xb4, 0x00, 0x00, 0x04, 0x36,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x39, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40,
+	0x0f, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e,
+	0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x04, 0x08, 0x18, 0x2b, 0x33,
+	0x11, 0x21, 0x15, 0x21, 0x11, 0x15, 0x11, 0xb4, 0x03, 0x82, 0xfd, 0x50, 0x05, 0xc8, 0x9d, 0xfe,
+	0x10, 0x9b, 0xfd, 0x60, 0x00, 0x02, 0x00, 0x24, 0x00, 0x00, 0x05, 0x34, 0x05, 0xc8, 0x00, 0x05,
+	0x00, 0x08, 0x00, 0x4a, 0x40, 0x0c, 0x08, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x04, 0x01, 0x02, 0x02,
+	0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x60, 0x03, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x02,
+	0x00, 0x85, 0x00, 0x02, 0x02, 0x01, 0x60, 0x03, 0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x12, 0x04, 0x08, 0x17, 0x2b, 0x33, 0x35,
+	0x01, 0x33, 0x01, 0x15, 0x25, 0x21, 0x01, 0x24, 0x02, 0x21, 0xd0, 0x02, 0x1f, 0xfb, 0xa4, 0x03,
+	0x7a, 0xfe, 0x44, 0xb9, 0x05, 0x0f, 0xfa, 0xf1, 0xb9, 0xb9, 0x04, 0x28, 0x00, 0x01, 0x00, 0xbe,
+	0x00, 0x00, 0x05, 0x1b, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x28, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b,
+	0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02,
+	0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b,
+	0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0xbe, 0x04, 0x31, 0xfc,
+	0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d,
+	0x00, 0x01, 0x00, 0x65, 0x00, 0x00, 0x04, 0x7c, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x4d, 0xb7, 0x06,
+	0x01, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03,
+	0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x67, 0x00,
+	0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00,
+	0x00, 0x09, 0x00, 0x09, 0x12, 0x11, 0x12, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x35, 0x01, 0x21, 0x35,
+	0x21, 0x15, 0x01, 0x21, 0x15, 0x65, 0x03, 0x1b, 0xfd, 0x16, 0x03, 0xe6, 0xfc, 0xe5, 0x03, 0x1b,
+	0xa9, 0x04, 0x82, 0x9d, 0x9d, 0xfb, 0x7e, 0xa9, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x21,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00,
+	0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03,
+	0x29, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01,
+	0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x33, 0x11,
+	0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x11, 0xa5, 0xd2, 0x02, 0xd9, 0xd1, 0xd1,
+	0xfd, 0x27, 0x05, 0xc8, 0xfd, 0x90, 0x02, 0x70, 0xfa, 0x38, 0x02, 0xbb, 0xfd, 0x45, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x67, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04,
+	0x05, 0x67, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x07, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x01, 0x00, 0x03,
+	0x04, 0x01, 0x03, 0x69, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x07, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40, 0x1b, 0x18, 0x18, 0x0d,
+	0x0c, 0x01, 0x00, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10,
+	0x12, 0x03, 0x35, 0x21, 0x15, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01,
+	0x40, 0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x32, 0x02,
+	0x2c, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98,
+	0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe,
+	0xde, 0xfe, 0xb6, 0x02, 0x35, 0xa0, 0xa0, 0x00, 0x00, 0x01, 0x00, 0x7c, 0x00, 0x00, 0x02, 0xb5,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01,
+	0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x08, 0x1b, 0x2b,
+	0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x7c, 0xb4, 0xb4, 0x02,
+	0x39, 0xb4, 0xb4, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x00, 0x00, 0x01, 0x00, 0xbf,
+	0x00, 0x00, 0x05, 0x25, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x3f, 0xb7, 0x09, 0x06, 0x03, 0x03, 0x02,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x28, 0x4d,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02,
+	0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0a,
+	0x00, 0x0a, 0x12, 0x12, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x01,
+	0x01, 0x21, 0x01, 0x11, 0xbf, 0xc5, 0x02, 0x67, 0xd3, 0xfd, 0xac, 0x02, 0xbb, 0xfe, 0xf6, 0xfd,
+	0x69, 0x05, 0xc8, 0xfd, 0x28, 0x02, 0xd8, 0xfd, 0x3e, 0xfc, 0xfa, 0x02, 0xee, 0xfd, 0x12, 0x00,
+	0x00, 0x01, 0x00, 0x15, 0x00, 0x00, 0x05, 0x3f, 0x05, 0xc8, 0x00, 0x06, 0x00, 0x2b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x0c, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x29, 0x00,
+	0x4e, 0x1b, 0x40, 0x0c, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x00, 0x2c, 0x00, 0x4e,
+	0x59, 0xb5, 0x11, 0x11, 0x11, 0x03, 0x08, 0x19, 0x2b, 0x01, 0x01, 0x23, 0x01, 0x33, 0x01, 0x23,
+	0x02, 0x9c, 0xfe, 0x3c, 0xc3, 0x02, 0x31, 0xd0, 0x02, 0x29, 0xe2, 0x04, 0xb0, 0xfb, 0x50, 0x05,
+	0xc8, 0xfa, 0x38, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x06, 0x05, 0x05, 0xc8, 0x00, 0x0c,
+	0x00, 0x50, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x28, 0x4d,
+	0x05, 0x04, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00,
+	0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02, 0x02, 0x02, 0x2c, 0x02,
+	0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11, 0x12, 0x11, 0x06, 0x08,
+	0x1a, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x01, 0x21, 0x11, 0x23, 0x11, 0x01, 0x23, 0x01, 0x11, 0xa5,
+	0x01, 0x23, 0x01, 0x97, 0x01, 0xa2, 0x01, 0x04, 0xc4, 0xfe, 0x6c, 0xcb, 0xfe, 0x78, 0x05, 0xc8,
+	0xfb, 0x87, 0x04, 0x79, 0xfa, 0x38, 0x04, 0xb3, 0xfb, 0xb0, 0x04, 0x54, 0xfb, 0x49, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x21, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08,
+	0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00,
+	0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01,
+	0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x05, 0x08, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x01,
+	0x11, 0x33, 0x11, 0x23, 0x01, 0x11, 0xa5, 0xcd, 0x02, 0xfb, 0xb4, 0xce, 0xfd, 0x06, 0x05, 0xc8,
+	0xfb, 0x89, 0x04, 0x77, 0xfa, 0x38, 0x04, 0x77, 0xfb, 0x89, 0x00, 0x00, 0x00, 0x03, 0x00, 0x50,
+	0x00, 0x00, 0x04, 0xe3, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x66, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x08, 0x01,
+	0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x28, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x01,
+	0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05,
+	0x67, 0x00, 0x02, 0x07, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x06,
+	0x01, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08,
+	0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x09, 0x08, 0x17, 0x2b, 0x33, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15,
+	0x50, 0x04, 0x93, 0xfc, 0x07, 0x03, 0x5f, 0xfc, 0x3f, 0x04, 0x24, 0xbf, 0xbf, 0x02, 0xa3, 0xc0,
+	0xc0, 0x02, 0x66, 0xbf, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0xdd,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00,
+	0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x00, 0x2f, 0x00, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03,
+	0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x59, 0x40,
+	0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x06, 0x08, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x12, 0x03, 0x13, 0xfe,
+	0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01, 0x40, 0x01, 0x80, 0xfe, 0x80, 0xfe, 0xb9, 0xe9,
+	0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01, 0x63, 0x01, 0xa6, 0xfe,
+	0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a, 0x01, 0x23, 0x01, 0x46,
+	0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x21,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b,
+	0x40, 0x10, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x67, 0x04, 0x03, 0x02, 0x01, 0x01, 0x2c,
+	0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x08,
+	0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x23, 0x11, 0x21, 0x11, 0xa5, 0x04, 0x7c, 0xd1, 0xfd, 0x27,
+	0x05, 0xc8, 0xfa, 0x38, 0x05, 0x13, 0xfa, 0xed, 0x00, 0x02, 0x00, 0xa7, 0x00, 0x00, 0x04, 0xfe,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00,
+	0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28,
+	0x4d, 0x05, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x04, 0x03,
+	0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05, 0x01, 0x02, 0x02, 0x2c,
+	0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x25,
+	0x21, 0x06, 0x08, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15, 0x10, 0x21, 0x21,
+	0x11, 0x11, 0x21, 0x20, 0x11, 0x34, 0x26, 0x23, 0x21, 0xa7, 0x02, 0x1c, 0xe4, 0xc7, 0x41, 0x4f,
+	0xfd, 0x87, 0xfe, 0xf4, 0x01, 0x03, 0x01, 0xa4, 0xad, 0xf2, 0xfe, 0xf8, 0x05, 0xc8, 0x34, 0x4d,
+	0x60, 0xad, 0xfd, 0xfe, 0xfd, 0xc8, 0x02, 0xd7, 0x01, 0x54, 0x99, 0x67, 0x00, 0x01, 0x00, 0x70,
+	0x00, 0x00, 0x04, 0x84, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x55, 0x40, 0x0f, 0x08, 0x02, 0x02, 0x02,
+	0x01, 0x01, 0x4c, 0x03, 0x01, 0x01, 0x01, 0x01, 0x02, 0x02, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x16, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x28, 0x4d, 0x00, 0x02, 0x02, 0x03,
+	0x5f, 0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x00, 0x00, 0x00, 0x01, 0x02,
+	0x00, 0x01, 0x67, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59,
+	0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x11, 0x14, 0x05, 0x08, 0x19, 0x2b, 0x33,
+	0x35, 0x01, 0x01, 0x35, 0x21, 0x15, 0x21, 0x01, 0x01, 0x21, 0x15, 0x70, 0x02, 0x22, 0xfd, 0xf6,
+	0x03, 0xde, 0xfd, 0x2c, 0x01, 0xf1, 0xfd, 0xc4, 0x03, 0x3d, 0xbc, 0x02, 0x3e, 0x02, 0x31, 0x9d,
+	0x9d, 0xfd, 0xea, 0xfd, 0xa7, 0xbc, 0x00, 0x00, 0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x04, 0xce,
+	0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b,
+	0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03, 0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x2c,
+	0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x08,
+	0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x11, 0x02, 0x08, 0xfe, 0x0c, 0x04, 0xba,
+	0xfe, 0x0c, 0x05, 0x2b, 0x9d, 0x9d, 0xfa, 0xd5, 0x00, 0x01, 0x00, 0x39, 0x00, 0x00, 0x05, 0x1d,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x49, 0x40, 0x0e, 0x0d, 0x01, 0x00, 0x01, 0x09, 0x01, 0x02, 0x00,
+	0x02, 0x4c, 0x0c, 0x01, 0x01, 0x4a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x28, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40,
+	0x0f, 0x00, 0x01, 0x00, 0x00, 0x02, 0x01, 0x00, 0x69, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e,
+	0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x21, 0x13, 0x04, 0x08, 0x18, 0x2b, 0x21,
+	0x11, 0x10, 0x00, 0x23, 0x35, 0x33, 0x32, 0x00, 0x13, 0x36, 0x00, 0x37, 0x15, 0x06, 0x00, 0x11,
+	0x11, 0x02, 0x39, 0xfe, 0xd9, 0xd9, 0x0f, 0xf4, 0x01, 0x38, 0x44, 0x5b, 0x01, 0x4e, 0xbc, 0xe7,
+	0xfe, 0xc9, 0x01, 0xdf, 0x01, 0x60, 0x01, 0xdd, 0xac, 0xfe, 0xd5, 0xfe, 0xd6, 0xf4, 0x01, 0x45,
+	0x1c, 0x94, 0x42, 0xfe, 0x16, 0xfe, 0xd7, 0xfe, 0x21, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0xad,
+	0x00, 0x00, 0x06, 0x59, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x18, 0x00, 0x1f, 0x00, 0x6a, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x21, 0x03, 0x01, 0x01, 0x09, 0x01, 0x06, 0x07, 0x01, 0x06, 0x69, 0x08,
+	0x0b, 0x02, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x00, 0x02, 0x02, 0x28, 0x4d, 0x0a,
+	0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x21, 0x03, 0x01, 0x01, 0x09, 0x01, 0x06, 0x07,
+	0x01, 0x06, 0x69, 0x08, 0x0b, 0x02, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x00, 0x02,
+	0x02, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x2c, 0x05, 0x4e, 0x59, 0x40, 0x1a, 0x12, 0x12, 0x00,
+	0x00, 0x1f, 0x1e, 0x1a, 0x19, 0x12, 0x18, 0x12, 0x18, 0x14, 0x13, 0x00, 0x11, 0x00, 0x11, 0x14,
+	0x11, 0x11, 0x14, 0x11, 0x0c, 0x08, 0x1b, 0x2b, 0x21, 0x35, 0x20, 0x00, 0x35, 0x34, 0x00, 0x21,
+	0x35, 0x33, 0x15, 0x20, 0x00, 0x15, 0x14, 0x00, 0x21, 0x15, 0x03, 0x11, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x03, 0x26, 0xfe, 0xe0, 0xfe, 0xa7, 0x01, 0x59,
+	0x01, 0x20, 0xb9, 0x01, 0x21, 0x01, 0x59, 0xfe, 0xa7, 0xfe, 0xdf, 0xb9, 0xc4, 0xcf, 0xcf, 0x01,
+	0x7d, 0xc5, 0xce, 0xce, 0xc5, 0xde, 0x01, 0x1f, 0xe7, 0xe8, 0x01, 0x1e, 0xde, 0xde, 0xfe, 0xe2,
+	0xe8, 0xe7, 0xfe, 0xe1, 0xde, 0x01, 0x77, 0x02, 0xda, 0xbf, 0xae, 0xae, 0xbf, 0xbf, 0xae, 0xae,
+	0xbf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x00, 0x05, 0x3a, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x28, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x29,
+	0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05,
+	0x08, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x23, 0x01, 0x01, 0x1c,
+	0x02, 0x21, 0xfd, 0xf7, 0xf8, 0x01, 0x91, 0x01, 0xab, 0xc7, 0xfd, 0xef, 0x02, 0x1c, 0xf8, 0xfe,
+	0x5c, 0xfe, 0x44, 0x02, 0xdf, 0x02, 0xe9, 0xfd, 0xc1, 0x02, 0x3f, 0xfd, 0x3a, 0xfc, 0xfe, 0x02,
+	0x56, 0xfd, 0xaa, 0x00, 0x00, 0x01, 0x00, 0x7e, 0x00, 0x00, 0x06, 0x2e, 0x05, 0xc8, 0x00, 0x2b,
+	0x00, 0x61, 0xb6, 0x2a, 0x01, 0x02, 0x07, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1d, 0x04, 0x01, 0x02, 0x00, 0x07, 0x00, 0x02, 0x07, 0x80, 0x06, 0x01, 0x00, 0x00, 0x01, 0x61,
+	0x05, 0x03, 0x02, 0x01, 0x01, 0x28, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x29, 0x07, 0x4e, 0x1b, 0x40,
+	0x1e, 0x04, 0x01, 0x02, 0x00, 0x07, 0x00, 0x02, 0x07, 0x80, 0x06, 0x01, 0x00, 0x02, 0x01, 0x00,
+	0x59, 0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x5f, 0x08, 0x01, 0x07, 0x07, 0x2c, 0x07, 0x4e, 0x59,
+	0x40, 0x10, 0x00, 0x00, 0x00, 0x2b, 0x00, 0x2b, 0x22, 0x15, 0x31, 0x13, 0x15, 0x22, 0x17, 0x09,
+	0x08, 0x1d, 0x2b, 0x21, 0x11, 0x26, 0x26, 0x27, 0x27, 0x26, 0x26, 0x23, 0x23, 0x35, 0x33, 0x32,
+	0x16, 0x17, 0x17, 0x16, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33, 0x11, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x37, 0x36, 0x36, 0x33, 0x33, 0x15, 0x23, 0x22, 0x06, 0x07, 0x07, 0x06, 0x06, 0x07, 0x11, 0x02,
+	0xf3, 0xbc, 0xc1, 0x1e, 0x14, 0x15, 0x41, 0x62, 0x0e, 0x11, 0xaf, 0x8f, 0x20, 0x14, 0x1e, 0x61,
+	0x62, 0x05, 0x0c, 0xc6, 0x0b, 0x06, 0x62, 0x61, 0x1d, 0x15, 0x20, 0x8f, 0xaf, 0x11, 0x0e, 0x63,
+	0x41, 0x14, 0x14, 0x1e, 0xc1, 0xbc, 0x02, 0x6f, 0x0e, 0xb2, 0xbd, 0x7e, 0x7f, 0x45, 0x9a, 0x79,
+	0xb1, 0x73, 0xa3, 0x7c, 0x01, 0x02, 0xbb, 0xfd, 0x45, 0x01, 0x7b, 0xa4, 0x73, 0xb1, 0x79, 0x9a,
+	0x45, 0x7f, 0x7e, 0xbd, 0xb2, 0x0e, 0xfd, 0x91, 0x00, 0x01, 0x00, 0x45, 0x00, 0x00, 0x05, 0x59,
+	0x05, 0xed, 0x00, 0x1b, 0x00, 0x51, 0xb5, 0x1a, 0x10, 0x02, 0x00, 0x01, 0x4b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2e, 0x4d, 0x02, 0x01,
+	0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00,
+	0x01, 0x00, 0x04, 0x00, 0x01, 0x04, 0x69, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06, 0x05, 0x02,
+	0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x1b, 0x25, 0x11,
+	0x14, 0x24, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x33, 0x35, 0x21, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20,
+	0x00, 0x11, 0x10, 0x01, 0x21, 0x15, 0x21, 0x35, 0x24, 0x11, 0x34, 0x02, 0x23, 0x22, 0x02, 0x11,
+	0x10, 0x05, 0x15, 0x45, 0x01, 0x52, 0xfe, 0xae, 0x01, 0x6d, 0x01, 0x1d, 0x01, 0x1d, 0x01, 0x6d,
+	0xfe, 0xae, 0x01, 0x52, 0xfd, 0xef, 0x01, 0x33, 0xea, 0xc2, 0xc1, 0xeb, 0x01, 0x33, 0x9a, 0x01,
+	0x0e, 0x01, 0x98, 0x01, 0x2c, 0x01, 0x81, 0xfe, 0x80, 0xfe, 0xd3, 0xfe, 0x67, 0xfe, 0xf3, 0x9a,
+	0x9a, 0xe5, 0x01, 0xb3, 0xff, 0x01, 0x22, 0xfe, 0xde, 0xff, 0x00, 0xfe, 0x4f, 0xe6, 0x9a, 0x00,
+	0x00, 0x03, 0x00, 0x7c, 0x00, 0x00, 0x02, 0xc9, 0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13,
+	0x00, 0x72, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x24, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03,
+	0x07, 0x02, 0x06, 0x07, 0x67, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x28, 0x4d,
+	0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x29, 0x05, 0x4e, 0x1b, 0x40, 0x22,
+	0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x2c,
+	0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c, 0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12,
+	0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0d, 0x08, 0x1b, 0x2b, 0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15,
+	0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x7c, 0xbe, 0xbe, 0x02, 0x4d, 0xbe, 0xbe, 0xfd,
+	0xb3, 0xad, 0xf3, 0xad, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x62, 0xad, 0xad,
+	0xad, 0xad, 0x00, 0x00, 0x00, 0x03, 0x00, 0x39, 0x00, 0x00, 0x05, 0x1d, 0x07, 0x0f, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x19, 0x00, 0x73, 0x40, 0x0f, 0x15, 0x01, 0x04, 0x05, 0x11, 0x01, 0x06, 0x04,
+	0x02, 0x4c, 0x14, 0x01, 0x05, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x02, 0x01,
+	0x00, 0x08, 0x03, 0x07, 0x03, 0x01, 0x05, 0x00, 0x01, 0x67, 0x00, 0x04, 0x04, 0x05, 0x61, 0x00,
+	0x05, 0x05, 0x28, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x29, 0x06, 0x4e, 0x1b, 0x40, 0x1b, 0x02, 0x01,
+	0x00, 0x08, 0x03, 0x07, 0x03, 0x01, 0x05, 0x00, 0x01, 0x67, 0x00, 0x05, 0x00, 0x04, 0x06, 0x05,
+	0x04, 0x69, 0x09, 0x01, 0x06, 0x06, 0x2c, 0x06, 0x4e, 0x59, 0x40, 0x1c, 0x08, 0x08, 0x04, 0x04,
+	0x00, 0x00, 0x08, 0x19, 0x08, 0x19, 0x0f, 0x0d, 0x0c, 0x0b, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x08, 0x17, 0x2b, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0x01, 0x11, 0x10, 0x00, 0x23, 0x35, 0x33, 0x32, 0x00, 0x13, 0x36, 0x00, 0x37, 0x15, 0x06,
+	0x00, 0x11, 0x11, 0x01, 0xbd, 0xad, 0xde, 0xad, 0xfe, 0x44, 0xfe, 0xd9, 0xd9, 0x0f, 0xf4, 0x01,
+	0x38, 0x44, 0x5b, 0x01, 0x4e, 0xbc, 0xe7, 0xfe, 0xc9, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0xf9,
+	0x9e, 0x01, 0xdf, 0x01, 0x60, 0x01, 0xdd, 0xac, 0xfe, 0xd5, 0xfe, 0xd6, 0xf4, 0x01, 0x45, 0x1c,
+	0x94, 0x42, 0xfe, 0x16, 0xfe, 0xd7, 0xfe, 0x21, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x6f,
+	0x06, 0xa6, 0x00, 0x03, 0x00, 0x30, 0x00, 0x4b, 0x00, 0xac, 0xb7, 0x4b, 0x18, 0x0f, 0x03, 0x07,
+	0x06, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x22, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08,
+	0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x06, 0x06, 0x02, 0x61, 0x05, 0x01, 0x02, 0x02, 0x2b, 0x4d,
+	0x00, 0x07, 0x07, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x2a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x05, 0x01, 0x85, 0x00,
+	0x02, 0x02, 0x2b, 0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x31, 0x4d, 0x00, 0x03,
+	0x03, 0x29, 0x4d, 0x00, 0x07, 0x07, 0x04, 0x61, 0x00, 0x04, 0x04, 0x32, 0x04, 0x4e, 0x1b, 0x40,
+	0x2a, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x05, 0x01, 0x85, 0x00, 0x02, 0x02, 0x2b,
+	0x4d, 0x00, 0x06, 0x06, 0x05, 0x61, 0x00, 0x05, 0x05, 0x31, 0x4d, 0x00, 0x03, 0x03, 0x2c, 0x4d,
+	0x00, 0x07, 0x07, 0x04, 0x61, 0x00, 0x04, 0x04, 0x32, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x16, 0x00,
+	0x00, 0x45, 0x43, 0x37, 0x35, 0x2c, 0x2a, 0x1e, 0x1c, 0x13, 0x12, 0x0a, 0x09, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x09, 0x08, 0x17, 0x2b, 0x01, 0x13, 0x33, 0x01, 0x01, 0x3e, 0x03, 0x37, 0x33, 0x0e,
+	0x03, 0x07, 0x16, 0x12, 0x17, 0x23, 0x2e, 0x03, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x04, 0x35,
+	0x34, 0x3e, 0x04, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x07, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x04, 0x15,
+	0x14, 0x1e, 0x04, 0x33, 0x32, 0x3e, 0x04, 0x37, 0x01, 0xaa, 0xd1, 0xdb, 0xfe, 0xd1, 0x01, 0x1c,
+	0x0f, 0x1f, 0x19, 0x11, 0x02, 0xb8, 0x07, 0x27, 0x37, 0x42, 0x23, 0x3a, 0x6f, 0x3b, 0xce, 0x0e,
+	0x1e, 0x21, 0x23, 0x13, 0x1d, 0x40, 0x54, 0x71, 0x4d, 0x47, 0x69, 0x4d, 0x32, 0x1e, 0x0c, 0x0d,
+	0x20, 0x37, 0x53, 0x73, 0x4d, 0x49, 0x62, 0x49, 0x3b, 0x23, 0x97, 0x1f, 0x29, 0x27, 0x2e, 0x24,
+	0x25, 0x33, 0x24, 0x15, 0x0b, 0x03, 0x03, 0x0b, 0x15, 0x25, 0x36, 0x26, 0x22, 0x3c, 0x33, 0x2c,
+	0x25, 0x1f, 0x0d, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfd, 0xc3, 0x26, 0x60, 0x66, 0x63, 0x29,
+	0x3c, 0x89, 0x90, 0x91, 0x44, 0x96, 0xfe, 0xfb, 0x79, 0x19, 0x48, 0x55, 0x5d, 0x2d, 0x3f, 0x7b,
+	0x62, 0x3d, 0x2e, 0x4d, 0x68, 0x74, 0x7a, 0x39, 0x46, 0x92, 0x89, 0x78, 0x59, 0x34, 0x24, 0x4c,
+	0x77, 0x54, 0x67, 0x4a, 0x67, 0x40, 0x1c, 0x2b, 0x48, 0x5b, 0x60, 0x5e, 0x25, 0x21, 0x55, 0x5a,
+	0x57, 0x45, 0x2a, 0x27, 0x3f, 0x50, 0x54, 0x4f, 0x1e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x4e,
+	0xff, 0xe7, 0x03, 0x35, 0x06, 0xa6, 0x00, 0x03, 0x00, 0x23, 0x00, 0x57, 0x40, 0x54, 0x12, 0x01,
+	0x04, 0x03, 0x13, 0x01, 0x05, 0x04, 0x0c, 0x01, 0x06, 0x05, 0x04, 0x01, 0x07, 0x06, 0x05, 0x01,
+	0x02, 0x07, 0x05, 0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x08, 0x01, 0x01, 0x03, 0x01, 0x85, 0x00,
+	0x05, 0x00, 0x06, 0x07, 0x05, 0x06, 0x69, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31,
+	0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x00, 0x00, 0x23, 0x21,
+	0x1d, 0x1b, 0x1a, 0x18, 0x16, 0x14, 0x11, 0x0f, 0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09,
+	0x08, 0x17, 0x2b, 0x01, 0x13, 0x33, 0x01, 0x01, 0x15, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x37,
+	0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x21, 0x33, 0x15, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x01, 0x89, 0xd1, 0xdb, 0xfe, 0xd1, 0x01, 0x2c, 0xa2,
+	0x90, 0xbf, 0xf3, 0xf7, 0xd2, 0x01, 0x86, 0x9a, 0x7a, 0x7e, 0x79, 0xe4, 0x01, 0x6a, 0x27, 0x8c,
+	0x7d, 0x9a, 0x8c, 0x71, 0x7b, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0xfb, 0xbe, 0x9f, 0x3b, 0xb8,
+	0x91, 0xcd, 0x5f, 0x48, 0xab, 0x01, 0x07, 0x23, 0x94, 0x23, 0x82, 0xaf, 0x9a, 0x6e, 0x58, 0x51,
+	0x65, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x57, 0xfe, 0x75, 0x03, 0xe5, 0x06, 0xa6, 0x00, 0x14,
+	0x00, 0x18, 0x00, 0xa2, 0xb6, 0x13, 0x06, 0x02, 0x04, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x22, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x29, 0x4d, 0x00,
+	0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x05, 0x06,
+	0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06, 0x85, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02,
+	0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x01, 0x06,
+	0x85, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d,
+	0x07, 0x01, 0x04, 0x04, 0x2c, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x15,
+	0x15, 0x15, 0x00, 0x00, 0x15, 0x18, 0x15, 0x18, 0x17, 0x16, 0x00, 0x14, 0x00, 0x14, 0x23, 0x13,
+	0x23, 0x13, 0x09, 0x08, 0x1a, 0x2b, 0x33, 0x11, 0x34, 0x27, 0x33, 0x16, 0x17, 0x36, 0x33, 0x32,
+	0x16, 0x15, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x13, 0x13, 0x33, 0x01, 0x99,
+	0x42, 0xdc, 0x1b, 0x10, 0x96, 0xd0, 0x86, 0x9b, 0xc5, 0x4e, 0x59, 0x8d, 0x8e, 0x5d, 0xd1, 0xdb,
+	0xfe, 0xd1, 0x02, 0xf1, 0xb6, 0x97, 0x58, 0x76, 0xe6, 0xc9, 0xc8, 0xfb, 0xb0, 0x04, 0x38, 0x78,
+	0x78, 0xd8, 0xfd, 0x3b, 0x05, 0x03, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x02, 0x00, 0xb9,
+	0xff, 0xe7, 0x02, 0xbf, 0x06, 0xa6, 0x00, 0x0d, 0x00, 0x11, 0x00, 0x35, 0x40, 0x32, 0x0d, 0x01,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04,
+	0x01, 0x04, 0x85, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x32, 0x00, 0x4e, 0x0e, 0x0e, 0x0e, 0x11, 0x0e, 0x11, 0x13, 0x23, 0x13, 0x21, 0x06, 0x08, 0x1a,
+	0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x01,
+	0x13, 0x33, 0x01, 0x02, 0xbf, 0x5c, 0x65, 0xa8, 0x91, 0xc5, 0x47, 0x56, 0x49, 0x4f, 0xfd, 0xfa,
+	0xd1, 0xdb, 0xfe, 0xd1, 0x11, 0x2a, 0xbd, 0xda, 0x02, 0xc0, 0xfd, 0x53, 0x98, 0x7e, 0x2a, 0x04,
+	0x5e, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xfb,
+	0x07, 0x07, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x21, 0x00, 0x84, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2d, 0x00, 0x08, 0x04, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x05, 0x04, 0x09, 0x05,
+	0x80, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x28, 0x4d, 0x02,
+	0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e,
+	0x1b, 0x40, 0x2b, 0x00, 0x08, 0x04, 0x08, 0x85, 0x0c, 0x01, 0x09, 0x04, 0x05, 0x04, 0x09, 0x05,
+	0x80, 0x06, 0x01, 0x04, 0x0b, 0x07, 0x0a, 0x03, 0x05, 0x00, 0x04, 0x05, 0x68, 0x02, 0x01, 0x00,
+	0x00, 0x2b, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x59, 0x40,
+	0x1e, 0x1e, 0x1e, 0x1a, 0x1a, 0x16, 0x16, 0x1e, 0x21, 0x1e, 0x21, 0x20, 0x1f, 0x1a, 0x1d, 0x1a,
+	0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x16, 0x24, 0x14, 0x23, 0x10, 0x0d, 0x08, 0x1b, 0x2b,
+	0x13, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x10, 0x03, 0x33, 0x12, 0x11, 0x14, 0x00,
+	0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x13, 0x35, 0x33, 0x15, 0x21, 0x35, 0x33, 0x15, 0x25, 0x13,
+	0x33, 0x01, 0x8e, 0xc5, 0x65, 0x87, 0x6e, 0x9c, 0x9a, 0xd6, 0x76, 0xfe, 0xf4, 0xc1, 0xbb, 0x6f,
+	0x44, 0x32, 0x10, 0xac, 0x01, 0x7f, 0xad, 0xfe, 0x23, 0xd2, 0xda, 0xfe, 0xd2, 0x04, 0x3e, 0xfd,
+	0xe1, 0xf6, 0xae, 0xc8, 0xb3, 0x01, 0x39, 0x01, 0x0f, 0xfe, 0xf5, 0xfe, 0xdd, 0xfb, 0xfe, 0xd2,
+	0x6b, 0x41, 0xb3, 0xaf, 0x03, 0x18, 0xad, 0xad, 0xad, 0xad, 0x56, 0x01, 0xa4, 0xfe, 0x5c, 0x00,
+	0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x68, 0x04, 0x57, 0x00, 0x2c, 0x00, 0x47, 0x00, 0x7e,
+	0xb7, 0x47, 0x14, 0x0b, 0x03, 0x05, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x17,
+	0x00, 0x04, 0x04, 0x00, 0x61, 0x03, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61,
+	0x02, 0x01, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00,
+	0x00, 0x00, 0x2b, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x01,
+	0x01, 0x29, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x1b, 0x40,
+	0x1f, 0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d,
+	0x00, 0x01, 0x01, 0x2c, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e,
+	0x59, 0x59, 0x40, 0x09, 0x2c, 0x29, 0x2c, 0x29, 0x18, 0x15, 0x06, 0x08, 0x1c, 0x2b, 0x01, 0x3e,
+	0x03, 0x37, 0x33, 0x0e, 0x03, 0x07, 0x16, 0x12, 0x17, 0x23, 0x2e, 0x03, 0x27, 0x0e, 0x03, 0x23,
+	0x22, 0x2e, 0x04, 0x35, 0x34, 0x3e, 0x04, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x07, 0x2e, 0x03, 0x23,
+	0x22, 0x0e, 0x04, 0x15, 0x14, 0x1e, 0x04, 0x33, 0x32, 0x3e, 0x04, 0x37, 0x03, 0x3f, 0x10, 0x20,
+	0x1a, 0x12, 0x02, 0xb8, 0x08, 0x26, 0x37, 0x44, 0x26, 0x39, 0x6d, 0x3c, 0xce, 0x09, 0x1c, 0x21,
+	0x24, 0x12, 0x1d, 0x40, 0x54, 0x71, 0x4d, 0x46, 0x6a, 0x4d, 0x32, 0x1e, 0x0c, 0x0d, 0x20, 0x37,
+	0x53, 0x73, 0x4d, 0x44, 0x5e, 0x49, 0x3d, 0x23, 0x90, 0x1f, 0x29, 0x27, 0x2e, 0x24, 0x25, 0x33,
+	0x24, 0x15, 0x0b, 0x03, 0x03, 0x0b, 0x15, 0x25, 0x36, 0x26, 0x22, 0x3c, 0x33, 0x2c, 0x25, 0x1f,
+	0x0d, 0x02, 0xbf, 0x28, 0x62, 0x66, 0x65, 0x2a, 0x3c, 0x89, 0x91, 0x95, 0x48, 0x8f, 0xfe, 0xfd,
+	0x79, 0x19, 0x48, 0x55, 0x5d, 0x2d, 0x3f, 0x7b, 0x62, 0x3d, 0x2e, 0x4d, 0x68, 0x74, 0x7a, 0x39,
+	0x46, 0x92, 0x89, 0x78, 0x59, 0x34, 0x23, 0x4c, 0x78, 0x54, 0x67, 0x4a, 0x67, 0x40, 0x1c, 0x2b,
+	0x48, 0x5b, 0x60, 0x5e, 0x25, 0x21, 0x55, 0x5a, 0x57, 0x45, 0x2a, 0x27, 0x3f, 0x50, 0x54, 0x4f,
+	0x1e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9a, 0xfe, 0x75, 0x04, 0x44, 0x06, 0x44, 0x00, 0x13,
+	0x00, 0x28, 0x00, 0x47, 0x40, 0x44, 0x0a, 0x01, 0x06, 0x03, 0x1f, 0x01, 0x05, 0x06, 0x12, 0x01,
+	0x01, 0x05, 0x03, 0x4c, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x69, 0x00, 0x04, 0x04, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x30, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x32, 0x4d,
+	0x07, 0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x00, 0x00, 0x28, 0x26, 0x22, 0x20, 0x1c, 0x1a, 0x16,
+	0x14, 0x00, 0x13, 0x00, 0x13, 0x2a, 0x23, 0x08, 0x08, 0x18, 0x2b, 0x13, 0x11, 0x10, 0x12, 0x33,
+	0x32, 0x16, 0x15, 0x14, 0x06, 0x07, 0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x22, 0x27, 0x11, 0x13,
+	0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x11, 0x16, 0x33, 0x32, 0x36, 0x35,
+	0x34, 0x26, 0x23, 0x23, 0x9a, 0xea, 0xd1, 0xac, 0xd4, 0x88, 0x87, 0xb3, 0xcb, 0xfe, 0xed, 0xd7,
+	0x81, 0x7a, 0x4a, 0x24, 0x81, 0xc1, 0x64, 0x63, 0x75, 0x74, 0x8f, 0x6e, 0x79, 0xa9, 0xea, 0xc4,
+	0x27, 0xfe, 0x75, 0x05, 0xa9, 0x01, 0x04, 0x01, 0x22, 0xb4, 0x93, 0x76, 0xc7, 0x51, 0x39, 0xef,
+	0x9a, 0xc7, 0xff, 0x2a, 0xfe, 0x64, 0x05, 0x1c, 0xd1, 0x8b, 0x61, 0x62, 0xba, 0xb9, 0xfc, 0x7f,
+	0x41, 0xaf, 0x7b, 0x9c, 0xbc, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0d, 0xfe, 0x75, 0x03, 0xf4,
+	0x04, 0x3e, 0x00, 0x23, 0x00, 0x1b, 0x40, 0x18, 0x0d, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x01, 0x01,
+	0x00, 0x00, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x1a, 0x17, 0x03, 0x08, 0x19,
+	0x2b, 0x25, 0x2e, 0x05, 0x27, 0x33, 0x1e, 0x03, 0x17, 0x3e, 0x03, 0x37, 0x33, 0x0e, 0x05, 0x07,
+	0x16, 0x15, 0x14, 0x07, 0x23, 0x27, 0x26, 0x35, 0x34, 0x01, 0x9d, 0x12, 0x32, 0x3d, 0x47, 0x4d,
+	0x52, 0x29, 0xe3, 0x30, 0x4f, 0x44, 0x3d, 0x1e, 0x1e, 0x45, 0x4d, 0x54, 0x2d, 0xb5, 0x25, 0x4c,
+	0x4b, 0x49, 0x46, 0x40, 0x1d, 0x26, 0x3f, 0x96, 0x0b, 0x2a, 0x6a, 0x3b, 0xa2, 0xb6, 0xc0, 0xb2,
+	0x9a, 0x35, 0x5a, 0xb4, 0xb8, 0xc0, 0x65, 0x4e, 0xb8, 0xc4, 0xc6, 0x5b, 0x42, 0x9c, 0xa9, 0xb0,
+	0xaa, 0x9e, 0x43, 0x6a, 0x80, 0x80, 0x9d, 0x21, 0x85, 0x50, 0x54, 0x00, 0x00, 0x02, 0x00, 0x56,
+	0xff, 0xe7, 0x04, 0x1e, 0x06, 0x44, 0x00, 0x33, 0x00, 0x47, 0x00, 0x2c, 0x40, 0x29, 0x10, 0x01,
+	0x01, 0x00, 0x33, 0x11, 0x02, 0x03, 0x01, 0x02, 0x4c, 0x00, 0x01, 0x01, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x30, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x3f, 0x3d,
+	0x2c, 0x2a, 0x37, 0x39, 0x04, 0x08, 0x18, 0x2b, 0x01, 0x2e, 0x03, 0x35, 0x34, 0x3e, 0x02, 0x33,
+	0x32, 0x1e, 0x02, 0x17, 0x17, 0x15, 0x26, 0x26, 0x23, 0x22, 0x0e, 0x04, 0x15, 0x14, 0x1e, 0x02,
+	0x17, 0x17, 0x1e, 0x03, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x12, 0x37,
+	0x17, 0x0e, 0x03, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x01,
+	0x98, 0x48, 0x65, 0x3f, 0x1d, 0x35, 0x6d, 0xaa, 0x75, 0x18, 0x3d, 0x43, 0x47, 0x22, 0x27, 0x51,
+	0x9f, 0x49, 0x10, 0x2f, 0x35, 0x34, 0x2a, 0x1a, 0x3b, 0x5f, 0x76, 0x3c, 0x39, 0x43, 0x77, 0x59,
+	0x33, 0x36, 0x76, 0xba, 0x85, 0x7e, 0xb4, 0x74, 0x37, 0xb5, 0xba, 0x7d, 0x52, 0x6c, 0x41, 0x1b,
+	0x1f, 0x42, 0x68, 0x49, 0x4a, 0x68, 0x42, 0x1e, 0x27, 0x46, 0x62, 0x04, 0x06, 0x30, 0x4f, 0x4b,
+	0x4f, 0x31, 0x40, 0x5c, 0x3c, 0x1c, 0x03, 0x06, 0x08, 0x06, 0x06, 0xa4, 0x17, 0x16, 0x01, 0x06,
+	0x0b, 0x14, 0x20, 0x16, 0x1c, 0x43, 0x4a, 0x4f, 0x29, 0x27, 0x2e, 0x69, 0x7f, 0x99, 0x5f, 0x69,
+	0xc2, 0x94, 0x58, 0x4f, 0x88, 0xb9, 0x69, 0xb6, 0x01, 0x05, 0x4d, 0x53, 0x23, 0x5d, 0x6f, 0x81,
+	0x48, 0x44, 0x7f, 0x63, 0x3c, 0x45, 0x6f, 0x88, 0x44, 0x4b, 0x77, 0x62, 0x51, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x4e, 0xff, 0xe7, 0x03, 0x32, 0x04, 0x56, 0x00, 0x1f, 0x00, 0x3f, 0x40, 0x3c,
+	0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x03, 0x02, 0x08, 0x01, 0x04, 0x03, 0x00, 0x01, 0x05, 0x04,
+	0x01, 0x01, 0x00, 0x05, 0x05, 0x4c, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x69, 0x00, 0x02,
+	0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00,
+	0x32, 0x00, 0x4e, 0x24, 0x21, 0x22, 0x23, 0x27, 0x22, 0x06, 0x08, 0x1c, 0x2b, 0x25, 0x15, 0x06,
+	0x23, 0x22, 0x26, 0x35, 0x34, 0x37, 0x26, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22,
+	0x15, 0x14, 0x21, 0x33, 0x15, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x03, 0x32, 0xa2,
+	0x90, 0xbf, 0xf3, 0xf7, 0xd2, 0x01, 0x86, 0x9a, 0x7a, 0x7e, 0x79, 0xe4, 0x01, 0x6a, 0x27, 0x8c,
+	0x7d, 0x9a, 0x8c, 0x71, 0x7b, 0xc1, 0x9f, 0x3b, 0xb8, 0x91, 0xcd, 0x5f, 0x48, 0xab, 0x01, 0x07,
+	0x23, 0x94, 0x23, 0x82, 0xaf, 0x9a, 0x6e, 0x58, 0x51, 0x65, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0b,
+	0xfe, 0x5c, 0x03, 0x92, 0x06, 0x31, 0x00, 0x3d, 0x00, 0x8d, 0x40, 0x14, 0x1a, 0x01, 0x02, 0x03,
+	0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00, 0x03, 0x4c, 0x23, 0x22, 0x1b, 0x03, 0x03, 0x4a,
+	0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x03, 0x02, 0x03, 0x85, 0x00, 0x02, 0x04, 0x02,
+	0x85, 0x00, 0x04, 0x04, 0x01, 0x62, 0x00, 0x01, 0x01, 0x29, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03,
+	0x02, 0x03, 0x85, 0x00, 0x02, 0x04, 0x02, 0x85, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x65, 0x00,
+	0x04, 0x04, 0x01, 0x62, 0x00, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x02,
+	0x03, 0x85, 0x00, 0x02, 0x04, 0x02, 0x85, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x65, 0x00, 0x04,
+	0x04, 0x01, 0x62, 0x00, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x28, 0x3f, 0x19,
+	0x19, 0x34, 0x23, 0x06, 0x08, 0x1c, 0x2b, 0x01, 0x35, 0x16, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34,
+	0x26, 0x23, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e, 0x02, 0x37, 0x22, 0x2e, 0x02, 0x27, 0x35,
+	0x1e, 0x03, 0x33, 0x36, 0x37, 0x17, 0x06, 0x06, 0x07, 0x0e, 0x03, 0x15, 0x14, 0x1e, 0x02, 0x33,
+	0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x01, 0x58, 0x3e, 0x63, 0x26,
+	0x58, 0x67, 0x4f, 0x42, 0x36, 0x77, 0xa7, 0x69, 0x31, 0x31, 0x51, 0x6b, 0x3b, 0x3d, 0x60, 0x57,
+	0x55, 0x33, 0x39, 0x5f, 0x69, 0x80, 0x5a, 0x70, 0xef, 0x4d, 0x31, 0xaf, 0x78, 0x44, 0x64, 0x41,
+	0x20, 0x20, 0x44, 0x67, 0x48, 0x27, 0x3f, 0x66, 0x48, 0x27, 0x24, 0x5e, 0xa2, 0x7f, 0x1e, 0x42,
+	0xfe, 0x66, 0xa1, 0x0b, 0x0c, 0x50, 0x4f, 0x36, 0x3b, 0x3f, 0x7d, 0xbc, 0x7e, 0x5f, 0xc8, 0xbd,
+	0xaa, 0x42, 0x07, 0x10, 0x1a, 0x12, 0xaf, 0x1e, 0x2d, 0x1e, 0x10, 0xa5, 0x4d, 0x68, 0x45, 0x7c,
+	0x27, 0x5b, 0xbe, 0xbd, 0xb7, 0x54, 0x61, 0x82, 0x4f, 0x21, 0x27, 0x45, 0x5e, 0x36, 0x38, 0x77,
+	0x63, 0x3f, 0x05, 0x00, 0x00, 0x01, 0x00, 0x57, 0xfe, 0x75, 0x03, 0xe5, 0x04, 0x56, 0x00, 0x14,
+	0x00, 0x79, 0xb6, 0x13, 0x06, 0x02, 0x04, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40,
+	0x17, 0x00, 0x03, 0x03, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05, 0x01, 0x04, 0x04,
+	0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b,
+	0x00, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x00,
+	0x00, 0x2b, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x05, 0x01, 0x04,
+	0x04, 0x2c, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00,
+	0x14, 0x00, 0x14, 0x23, 0x13, 0x23, 0x13, 0x06, 0x08, 0x1a, 0x2b, 0x33, 0x11, 0x34, 0x27, 0x33,
+	0x16, 0x17, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11,
+	0x99, 0x42, 0xdc, 0x1b, 0x10, 0x96, 0xd0, 0x86, 0x9b, 0xc5, 0x4e, 0x59, 0x8d, 0x8e, 0x02, 0xf1,
+	0xb6, 0x97, 0x58, 0x76, 0xe6, 0xc9, 0xc8, 0xfb, 0xb0, 0x04, 0x38, 0x78, 0x78, 0xd8, 0xfd, 0x3b,
+	0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1d, 0x06, 0x44, 0x00, 0x0b, 0x00, 0x12, 0x00, 0x19,
+	0x00, 0x29, 0x40, 0x26, 0x00, 0x02, 0x00, 0x04, 0x05, 0x02, 0x04, 0x67, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x30, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x32, 0x01,
+	0x4e, 0x22, 0x12, 0x22, 0x12, 0x24, 0x22, 0x06, 0x08, 0x1c, 0x2b, 0x13, 0x10, 0x12, 0x33, 0x32,
+	0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x13, 0x21, 0x10, 0x02, 0x23, 0x22, 0x02, 0x01, 0x21,
+	0x10, 0x12, 0x33, 0x32, 0x12, 0x56, 0xfe, 0xe6, 0xe5, 0xfe, 0xfe, 0xe5, 0xed, 0xf7, 0xc5, 0x02,
+	0x3d, 0xa2, 0x7d, 0x7c, 0xa2, 0x02, 0x3d, 0xfd, 0xc3, 0x9e, 0x7f, 0x7d, 0xa3, 0x03, 0x15, 0x01,
+	0x8b, 0x01, 0xa4, 0xfe, 0x5c, 0xfe, 0x76, 0xfe, 0x75, 0xfe, 0x5c, 0x01, 0x97, 0x01, 0xea, 0x01,
+	0x02, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0x72, 0xfe, 0xea, 0xfe, 0xb5, 0x01, 0x53, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xc5, 0xff, 0xe7, 0x02, 0xbf, 0x04, 0x3e, 0x00, 0x0d, 0x00, 0x23, 0x40, 0x20,
+	0x0d, 0x01, 0x02, 0x01, 0x00, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00,
+	0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x23, 0x13, 0x21, 0x03, 0x08, 0x19,
+	0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x02,
+	0xbf, 0x5c, 0x65, 0xa8, 0x91, 0xc5, 0x47, 0x56, 0x49, 0x4f, 0x11, 0x2a, 0xbd, 0xda, 0x02, 0xc0,
+	0xfd, 0x53, 0x98, 0x7e, 0x2a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xf4,
+	0x04, 0x3e, 0x00, 0x12, 0x00, 0x4c, 0x40, 0x09, 0x11, 0x0e, 0x08, 0x03, 0x04, 0x03, 0x02, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x13, 0x00, 0x02, 0x02, 0x00, 0x61, 0x01, 0x01, 0x00,
+	0x00, 0x2b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x02,
+	0x02, 0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x05, 0x04, 0x02, 0x03, 0x03, 0x2c, 0x03,
+	0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x14, 0x22, 0x14, 0x11, 0x06, 0x08,
+	0x1a, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x37, 0x36, 0x36, 0x33, 0x15, 0x26, 0x23, 0x22, 0x06, 0x07,
+	0x07, 0x01, 0x23, 0x01, 0x11, 0x9a, 0xc5, 0xc5, 0x8d, 0xa2, 0x6f, 0x16, 0x0d, 0x33, 0x7a, 0x7f,
+	0x3e, 0x01, 0xbf, 0xee, 0xfe, 0x59, 0x04, 0x3e, 0xfd, 0xec, 0xf4, 0xb0, 0x70, 0xb2, 0x03, 0x68,
+	0x97, 0x49, 0xfd, 0xb9, 0x02, 0x2a, 0xfd, 0xd6, 0x00, 0x01, 0x00, 0x18, 0x00, 0x00, 0x03, 0xeb,
+	0x06, 0x2b, 0x00, 0x1d, 0x00, 0x3d, 0xb5, 0x1b, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2a, 0x4d, 0x03, 0x01,
+	0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x11, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x2a, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0xb6, 0x17, 0x1a, 0x21, 0x25, 0x04,
+	0x08, 0x1a, 0x2b, 0x01, 0x27, 0x2e, 0x03, 0x23, 0x23, 0x35, 0x33, 0x32, 0x1e, 0x04, 0x17, 0x01,
+	0x16, 0x16, 0x17, 0x23, 0x2e, 0x03, 0x27, 0x03, 0x01, 0x23, 0x01, 0x7e, 0x4a, 0x14, 0x29, 0x35,
+	0x49, 0x34, 0x1d, 0x25, 0x3d, 0x5d, 0x47, 0x36, 0x30, 0x2d, 0x1a, 0x01, 0x4c, 0x2e, 0x5d, 0x39,
+	0xcc, 0x17, 0x27, 0x26, 0x25, 0x15, 0x9d, 0xfe, 0xf3, 0xbf, 0x03, 0xe5, 0xb9, 0x32, 0x53, 0x3b,
+	0x20, 0xad, 0x0a, 0x1a, 0x2d, 0x46, 0x62, 0x41, 0xfc, 0xc7, 0x74, 0xd3, 0x71, 0x2e, 0x53, 0x53,
+	0x59, 0x34, 0x01, 0x84, 0xfd, 0x1b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x9a, 0xfe, 0x75, 0x04, 0x36,
+	0x04, 0x3e, 0x00, 0x15, 0x00, 0x82, 0x40, 0x0b, 0x10, 0x08, 0x02, 0x01, 0x00, 0x14, 0x01, 0x03,
+	0x01, 0x02, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d,
+	0x00, 0x01, 0x01, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x29, 0x4d, 0x06, 0x01, 0x05, 0x05, 0x2d,
+	0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d,
+	0x00, 0x03, 0x03, 0x29, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x32, 0x4d, 0x06,
+	0x01, 0x05, 0x05, 0x2d, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00,
+	0x03, 0x03, 0x2c, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x32, 0x4d, 0x06, 0x01,
+	0x05, 0x05, 0x2d, 0x05, 0x4e, 0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x15, 0x00, 0x15, 0x23,
+	0x13, 0x12, 0x23, 0x11, 0x07, 0x08, 0x1b, 0x2b, 0x13, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x37, 0x11, 0x33, 0x11, 0x14, 0x17, 0x23, 0x26, 0x27, 0x06, 0x23, 0x22, 0x27, 0x11, 0x9a, 0xc5,
+	0x5b, 0x5b, 0x8e, 0x93, 0xc5, 0x3b, 0xd8, 0x15, 0x13, 0x8c, 0x99, 0x66, 0x4c, 0xfe, 0x75, 0x05,
+	0xc9, 0xfd, 0x34, 0x69, 0x69, 0xde, 0x02, 0xc0, 0xfd, 0x0d, 0xb9, 0x92, 0x4b, 0x81, 0xe5, 0x32,
+	0xfe, 0x5c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x03, 0xce, 0x04, 0x3e, 0x00, 0x1a,
+	0x00, 0x3a, 0xb5, 0x0d, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x29, 0x02, 0x4e, 0x1b, 0x40, 0x0d,
+	0x01, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2c, 0x02, 0x4e, 0x59, 0x40, 0x0b,
+	0x00, 0x00, 0x00, 0x1a, 0x00, 0x1a, 0x1c, 0x15, 0x04, 0x08, 0x18, 0x2b, 0x21, 0x26, 0x0a, 0x02,
+	0x27, 0x33, 0x1e, 0x05, 0x17, 0x36, 0x12, 0x35, 0x34, 0x27, 0x33, 0x16, 0x15, 0x14, 0x03, 0x06,
+	0x02, 0x07, 0x01, 0x85, 0x2b, 0x54, 0x5c, 0x6a, 0x40, 0xd0, 0x26, 0x45, 0x3e, 0x36, 0x2c, 0x21,
+	0x0b, 0x88, 0x86, 0x19, 0xc3, 0x0f, 0x70, 0x38, 0x89, 0x52, 0x92, 0x01, 0x11, 0x01, 0x09, 0x01,
+	0x08, 0x8a, 0x52, 0xad, 0xac, 0xa4, 0x92, 0x7b, 0x2b, 0xf8, 0x01, 0x7c, 0x7c, 0x59, 0x3e, 0x33,
+	0x3f, 0x79, 0xfe, 0xe9, 0x8d, 0xfe, 0xe4, 0x93, 0x00, 0x01, 0xff, 0xfe, 0xfe, 0x5c, 0x03, 0x89,
+	0x06, 0x46, 0x00, 0x55, 0x00, 0xb8, 0x40, 0x16, 0x2e, 0x28, 0x23, 0x22, 0x1d, 0x05, 0x03, 0x02,
+	0x15, 0x01, 0x05, 0x04, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x07, 0x00, 0x04, 0x4c, 0x4b, 0xb0,
+	0x28, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x03, 0x02, 0x04, 0x02, 0x03, 0x04, 0x80, 0x00, 0x04, 0x00,
+	0x05, 0x06, 0x04, 0x05, 0x6a, 0x00, 0x02, 0x02, 0x30, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x29, 0x4d, 0x00, 0x00, 0x00, 0x07, 0x61, 0x00, 0x07, 0x07, 0x2d, 0x07, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x03, 0x02, 0x04, 0x02, 0x03, 0x04, 0x80, 0x00,
+	0x04, 0x00, 0x05, 0x06, 0x04, 0x05, 0x6a, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x65, 0x00, 0x02,
+	0x02, 0x30, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x01, 0x4e, 0x1b, 0x40,
+	0x27, 0x00, 0x03, 0x02, 0x04, 0x02, 0x03, 0x04, 0x80, 0x00, 0x04, 0x00, 0x05, 0x06, 0x04, 0x05,
+	0x6a, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x65, 0x00, 0x02, 0x02, 0x30, 0x4d, 0x00, 0x06, 0x06,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x2c, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x11, 0x54, 0x52, 0x4a, 0x47,
+	0x41, 0x3f, 0x3e, 0x3c, 0x33, 0x32, 0x2d, 0x2c, 0x34, 0x23, 0x08, 0x08, 0x18, 0x2b, 0x01, 0x35,
+	0x16, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e,
+	0x02, 0x37, 0x2e, 0x03, 0x35, 0x34, 0x36, 0x37, 0x2e, 0x03, 0x27, 0x35, 0x1e, 0x03, 0x17, 0x3e,
+	0x03, 0x37, 0x17, 0x0e, 0x03, 0x07, 0x0e, 0x03, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x33, 0x15, 0x23,
+	0x22, 0x0e, 0x02, 0x15, 0x14, 0x16, 0x33, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23,
+	0x22, 0x26, 0x01, 0x62, 0x3e, 0x60, 0x1f, 0x60, 0x69, 0x5f, 0x4f, 0x23, 0x69, 0xac, 0x7c, 0x44,
+	0x3a, 0x60, 0x7d, 0x43, 0x3e, 0x61, 0x42, 0x23, 0x2e, 0x25, 0x1f, 0x35, 0x36, 0x3d, 0x26, 0x2a,
+	0x47, 0x54, 0x6c, 0x4e, 0x2d, 0x56, 0x5a, 0x65, 0x3c, 0x2b, 0x1f, 0x3e, 0x56, 0x79, 0x5a, 0x11,
+	0x1c, 0x14, 0x0b, 0x3a, 0x60, 0x7f, 0x45, 0x41, 0x79, 0x3b, 0x81, 0x6d, 0x47, 0x90, 0x9a, 0x23,
+	0x45, 0x70, 0x51, 0x2c, 0x24, 0x5e, 0xa2, 0x7f, 0x1e, 0x42, 0xfe, 0x66, 0xa1, 0x0b, 0x0c, 0x50,
+	0x4f, 0x36, 0x3b, 0x2d, 0x61, 0x99, 0x6c, 0x51, 0x87, 0x6d, 0x51, 0x1a, 0x10, 0x3b, 0x52, 0x65,
+	0x3a, 0x41, 0x6e, 0x33, 0x03, 0x0a, 0x0d, 0x12, 0x0c, 0xa1, 0x14, 0x1f, 0x19, 0x13, 0x07, 0x23,
+	0x2c, 0x18, 0x0a, 0x01, 0x6b, 0x1b, 0x2d, 0x21, 0x16, 0x06, 0x14, 0x29, 0x2f, 0x37, 0x22, 0x4c,
+	0x6b, 0x43, 0x1f, 0x94, 0x1d, 0x4b, 0x7f, 0x62, 0x77, 0x77, 0x27, 0x45, 0x5e, 0x36, 0x38, 0x77,
+	0x63, 0x3f, 0x05, 0x00, 0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c, 0x04, 0x56, 0x00, 0x0b,
+	0x00, 0x13, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d,
+	0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x0d, 0x0c, 0x01,
+	0x00, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x08, 0x16,
+	0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11,
+	0x10, 0x21, 0x20, 0x11, 0x10, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04,
+	0xfe, 0xfc, 0xe3, 0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07,
+	0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe,
+	0x5d, 0xfe, 0x5c, 0x00, 0x00, 0x01, 0x00, 0x2b, 0x00, 0x00, 0x05, 0x5d, 0x04, 0x3e, 0x00, 0x13,
+	0x00, 0x50, 0x40, 0x0a, 0x05, 0x01, 0x00, 0x01, 0x04, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b,
+	0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x2c, 0x03, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x13, 0x13, 0x11, 0x23, 0x21, 0x07, 0x08,
+	0x1b, 0x2b, 0x21, 0x11, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x21, 0x15, 0x23, 0x11, 0x14, 0x17,
+	0x23, 0x26, 0x35, 0x11, 0x21, 0x11, 0x01, 0x45, 0x2c, 0x6c, 0x82, 0x75, 0x8f, 0x04, 0x2e, 0xf7,
+	0x4d, 0xd1, 0x42, 0xfe, 0x6b, 0x03, 0x9d, 0x46, 0xb5, 0x32, 0xa1, 0xfd, 0x8a, 0xa8, 0x7f, 0x90,
+	0x9f, 0x02, 0x6e, 0xfc, 0x63, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x81, 0xfe, 0x75, 0x04, 0x37,
+	0x04, 0x56, 0x00, 0x0d, 0x00, 0x19, 0x00, 0x5f, 0x40, 0x0a, 0x0e, 0x01, 0x03, 0x04, 0x0c, 0x01,
+	0x01, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x31, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x29, 0x4d, 0x05,
+	0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x04, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x31, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x2c, 0x4d, 0x05, 0x01, 0x02,
+	0x02, 0x2d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0d, 0x00,
+	0x0d, 0x24, 0x23, 0x06, 0x08, 0x18, 0x2b, 0x13, 0x11, 0x10, 0x12, 0x33, 0x32, 0x16, 0x15, 0x10,
+	0x00, 0x23, 0x22, 0x27, 0x11, 0x11, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06,
+	0x15, 0x81, 0xfa, 0xfa, 0xd6, 0xec, 0xfe, 0xc7, 0xef, 0x6b, 0x5e, 0x5f, 0x76, 0x9a, 0xbd, 0x96,
+	0x80, 0x8b, 0x8b, 0xfe, 0x75, 0x03, 0x2c, 0x01, 0x64, 0x01, 0x51, 0xf5, 0xde, 0xfe, 0xea, 0xfe,
+	0x93, 0x23, 0xfe, 0x52, 0x02, 0x6d, 0x4e, 0xfe, 0xcf, 0xa3, 0xbe, 0xe5, 0xe4, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x56, 0xfe, 0x5c, 0x03, 0xd7, 0x04, 0x55, 0x00, 0x35, 0x00, 0x8b, 0x40, 0x12,
+	0x00, 0x01, 0x00, 0x05, 0x01, 0x01, 0x01, 0x00, 0x1b, 0x01, 0x03, 0x04, 0x1a, 0x01, 0x02, 0x03,
+	0x04, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x31, 0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x29, 0x4d, 0x00, 0x03, 0x03,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c,
+	0x00, 0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x31,
+	0x4d, 0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x29, 0x04, 0x4e, 0x1b, 0x40, 0x1c, 0x00,
+	0x03, 0x00, 0x02, 0x03, 0x02, 0x65, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x31, 0x4d,
+	0x00, 0x01, 0x01, 0x04, 0x61, 0x00, 0x04, 0x04, 0x2c, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x09, 0x36,
+	0x38, 0x25, 0x26, 0x38, 0x23, 0x06, 0x08, 0x1c, 0x2b, 0x01, 0x15, 0x26, 0x26, 0x23, 0x22, 0x0e,
+	0x02, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26,
+	0x27, 0x35, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x22, 0x26,
+	0x35, 0x34, 0x3e, 0x02, 0x33, 0x32, 0x1e, 0x02, 0x03, 0xc5, 0x34, 0x81, 0x3a, 0x6c, 0xa3, 0x6e,
+	0x38, 0x1f, 0x47, 0x75, 0x56, 0x1c, 0x52, 0x86, 0x5e, 0x33, 0xca, 0xd7, 0x1d, 0x3c, 0x24, 0x3d,
+	0x5d, 0x26, 0x3a, 0x49, 0x2a, 0x10, 0x2b, 0x41, 0x4e, 0x22, 0x28, 0xec, 0xf0, 0x65, 0xac, 0xe6,
+	0x81, 0x31, 0x48, 0x39, 0x2e, 0x04, 0x39, 0x99, 0x0e, 0x14, 0x4a, 0x82, 0xb0, 0x65, 0x51, 0x75,
+	0x4b, 0x24, 0x1f, 0x42, 0x66, 0x47, 0xa1, 0xa1, 0x05, 0x05, 0xa1, 0x0b, 0x0c, 0x1a, 0x2a, 0x34,
+	0x1a, 0x25, 0x30, 0x1d, 0x0b, 0xe2, 0xf2, 0x9d, 0xf0, 0xa2, 0x53, 0x05, 0x07, 0x0a, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x04, 0xd9, 0x04, 0x56, 0x00, 0x07, 0x00, 0x17, 0x00, 0x69,
+	0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x23, 0x00, 0x01, 0x01, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03,
+	0x31, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x04, 0x01, 0x03, 0x03, 0x31, 0x4d, 0x06, 0x01, 0x00,
+	0x00, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x1b, 0x40, 0x21, 0x00, 0x01, 0x01,
+	0x03, 0x61, 0x00, 0x03, 0x03, 0x31, 0x4d, 0x00, 0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x2b,
+	0x4d, 0x06, 0x01, 0x00, 0x00, 0x02, 0x61, 0x07, 0x01, 0x02, 0x02, 0x32, 0x02, 0x4e, 0x59, 0x40,
+	0x17, 0x09, 0x08, 0x01, 0x00, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0d, 0x08, 0x17, 0x09, 0x17, 0x05,
+	0x03, 0x00, 0x07, 0x01, 0x07, 0x08, 0x08, 0x16, 0x2b, 0x25, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11,
+	0x10, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00, 0x33, 0x32, 0x17, 0x21, 0x15, 0x21, 0x16, 0x15, 0x10,
+	0x00, 0x02, 0x35, 0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x01, 0x07, 0xdb, 0xfe, 0xff, 0x01, 0x03,
+	0xe0, 0x59, 0x4a, 0x01, 0xfd, 0xfe, 0xe3, 0x60, 0xfe, 0xfc, 0x7b, 0x01, 0xa9, 0x01, 0x9e, 0xfe,
+	0x5d, 0xfe, 0x5c, 0x94, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0x18, 0xad, 0x90, 0xe0,
+	0xfe, 0xf4, 0xfe, 0xd2, 0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x03, 0x15, 0x04, 0x3e, 0x00, 0x0f,
+	0x00, 0x4a, 0x40, 0x0a, 0x07, 0x01, 0x00, 0x01, 0x06, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x2b, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x29, 0x03, 0x4e, 0x1b, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x2c, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x0f, 0x00, 0x0f, 0x11, 0x23, 0x23, 0x05, 0x08, 0x19, 0x2b, 0x21, 0x26, 0x35, 0x11,
+	0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x21, 0x15, 0x21, 0x11, 0x14, 0x17, 0x01, 0x80, 0x44, 0x43,
+	0x76, 0x6f, 0x69, 0x8a, 0x02, 0x0e, 0xfe, 0xed, 0x4f, 0x86, 0xb6, 0x02, 0x55, 0x30, 0xb7, 0x26,
+	0xad, 0xfd, 0xac, 0xc4, 0x79, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xfb,
+	0x04, 0x3e, 0x00, 0x15, 0x00, 0x1b, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00, 0x01,
+	0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x24, 0x14, 0x23, 0x10, 0x04, 0x08, 0x1a,
+	0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x10, 0x03, 0x33, 0x12, 0x11, 0x14,
+	0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x8e, 0xc5, 0x65, 0x87, 0x6e, 0x9c, 0x9a, 0xd6, 0x76,
+	0xfe, 0xf4, 0xc1, 0xbb, 0x6f, 0x44, 0x32, 0x04, 0x3e, 0xfd, 0xe1, 0xf6, 0xae, 0xc8, 0xb3, 0x01,
+	0x39, 0x01, 0x0f, 0xfe, 0xf5, 0xfe, 0xdd, 0xfb, 0xfe, 0xd2, 0x6b, 0x41, 0xb3, 0xaf, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x57, 0xfe, 0x75, 0x04, 0xd9, 0x04, 0x57, 0x00, 0x29, 0x00, 0x3b, 0x00, 0x7d,
+	0x40, 0x09, 0x2a, 0x20, 0x1d, 0x0b, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x14, 0x50, 0x58,
+	0x40, 0x13, 0x04, 0x01, 0x00, 0x00, 0x01, 0x61, 0x05, 0x03, 0x02, 0x01, 0x01, 0x31, 0x4d, 0x00,
+	0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1d, 0x04, 0x01, 0x00,
+	0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x03, 0x61, 0x05, 0x01,
+	0x03, 0x03, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x04,
+	0x01, 0x61, 0x00, 0x01, 0x01, 0x31, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x05, 0x01, 0x03, 0x03,
+	0x2b, 0x4d, 0x00, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x37, 0x35,
+	0x00, 0x29, 0x00, 0x29, 0x1f, 0x1e, 0x14, 0x12, 0x11, 0x06, 0x08, 0x17, 0x2b, 0x01, 0x15, 0x22,
+	0x0e, 0x02, 0x15, 0x14, 0x1e, 0x02, 0x17, 0x11, 0x34, 0x3e, 0x04, 0x33, 0x32, 0x1e, 0x02, 0x15,
+	0x14, 0x0e, 0x02, 0x07, 0x11, 0x23, 0x11, 0x2e, 0x03, 0x35, 0x34, 0x3e, 0x02, 0x01, 0x3e, 0x05,
+	0x35, 0x34, 0x2e, 0x02, 0x23, 0x22, 0x0e, 0x02, 0x15, 0x01, 0xf7, 0x40, 0x52, 0x36, 0x1a, 0x1a,
+	0x3e, 0x70, 0x4f, 0x04, 0x12, 0x27, 0x48, 0x6d, 0x4f, 0x65, 0x8b, 0x56, 0x26, 0x32, 0x73, 0xbb,
+	0x88, 0xc5, 0x8a, 0xb6, 0x6a, 0x2b, 0x35, 0x69, 0x9c, 0x01, 0x60, 0x4a, 0x5f, 0x41, 0x26, 0x14,
+	0x06, 0x0a, 0x21, 0x3f, 0x3b, 0x27, 0x33, 0x1e, 0x0c, 0x04, 0x3e, 0x94, 0x2e, 0x58, 0x7f, 0x51,
+	0x61, 0x97, 0x75, 0x4e, 0x0f, 0x01, 0x10, 0x54, 0xaa, 0x9b, 0x87, 0x64, 0x39, 0x4b, 0x82, 0xb0,
+	0x65, 0x72, 0xd8, 0xac, 0x72, 0x0d, 0xfe, 0x75, 0x01, 0x8b, 0x10, 0x62, 0x9b, 0xcc, 0x7b, 0x75,
+	0xb7, 0x7d, 0x41, 0xfc, 0x4c, 0x07, 0x33, 0x4b, 0x5e, 0x67, 0x75, 0x32, 0x3c, 0x75, 0x5d, 0x39,
+	0x2a, 0x62, 0x9e, 0x73, 0x00, 0x01, 0x00, 0x08, 0xfe, 0x75, 0x04, 0x33, 0x04, 0x3e, 0x00, 0x19,
+	0x00, 0x1f, 0x40, 0x1c, 0x17, 0x0e, 0x0b, 0x03, 0x02, 0x00, 0x01, 0x4c, 0x01, 0x01, 0x00, 0x00,
+	0x2b, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x2d, 0x02, 0x4e, 0x15, 0x15, 0x15, 0x16, 0x04, 0x08, 0x1a,
+	0x2b, 0x01, 0x03, 0x2e, 0x03, 0x27, 0x33, 0x16, 0x16, 0x17, 0x17, 0x01, 0x33, 0x01, 0x13, 0x16,
+	0x16, 0x17, 0x23, 0x26, 0x26, 0x27, 0x03, 0x01, 0x23, 0x01, 0xaa, 0xba, 0x39, 0x4c, 0x32, 0x1f,
+	0x0c, 0xea, 0x2d, 0x50, 0x30, 0x76, 0x01, 0x1b, 0xaa, 0xfe, 0x8f, 0xec, 0x52, 0x6a, 0x1c, 0xe3,
+	0x4b, 0x54, 0x1a, 0x99, 0xfe, 0xb6, 0xac, 0x01, 0x6c, 0x01, 0x55, 0x69, 0x85, 0x53, 0x2c, 0x10,
+	0x3d, 0x99, 0x57, 0xd6, 0x02, 0x03, 0xfd, 0x61, 0xfe, 0x4c, 0x98, 0xb4, 0x2a, 0x73, 0x9e, 0x30,
+	0x01, 0x19, 0xfd, 0xa6, 0x00, 0x01, 0x00, 0x3d, 0xfe, 0x75, 0x05, 0x3f, 0x05, 0x03, 0x00, 0x2b,
+	0x00, 0x5a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x03, 0x01, 0x03, 0x85, 0x05, 0x01,
+	0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x29, 0x4d,
+	0x08, 0x01, 0x07, 0x07, 0x2d, 0x07, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x03, 0x01, 0x03, 0x85, 0x05,
+	0x01, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x2c,
+	0x4d, 0x08, 0x01, 0x07, 0x07, 0x2d, 0x07, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x00, 0x2b, 0x00,
+	0x2b, 0x18, 0x16, 0x11, 0x11, 0x1a, 0x18, 0x11, 0x09, 0x08, 0x1d, 0x2b, 0x01, 0x11, 0x22, 0x2e,
+	0x02, 0x35, 0x35, 0x34, 0x26, 0x27, 0x33, 0x16, 0x16, 0x15, 0x15, 0x14, 0x1e, 0x04, 0x33, 0x11,
+	0x33, 0x11, 0x3e, 0x03, 0x35, 0x34, 0x27, 0x33, 0x16, 0x15, 0x14, 0x0e, 0x04, 0x07, 0x11, 0x02,
+	0x78, 0xa2, 0xcc, 0x74, 0x2a, 0x16, 0x19, 0xcc, 0x1b, 0x14, 0x07, 0x16, 0x2b, 0x47, 0x68, 0x49,
+	0xc5, 0x64, 0x7c, 0x45, 0x18, 0x4f, 0xc5, 0x4f, 0x15, 0x2f, 0x4e, 0x73, 0x99, 0x64, 0xfe, 0x75,
+	0x01, 0x8b, 0x53, 0xa2, 0xf2, 0x9f, 0x99, 0x57, 0x8f, 0x39, 0x2f, 0x88, 0x5b, 0x99, 0x3d, 0x7c,
+	0x71, 0x63, 0x48, 0x2a, 0x04, 0x6f, 0xfb, 0x91, 0x05, 0x4f, 0x87, 0xba, 0x70, 0xfa, 0xab, 0xcb,
+	0xfd, 0x53, 0x9a, 0x88, 0x73, 0x54, 0x34, 0x06, 0xfe, 0x75, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6b,
+	0xff, 0xe7, 0x05, 0xd4, 0x04, 0x3e, 0x00, 0x48, 0x00, 0x2f, 0x40, 0x2c, 0x2a, 0x1f, 0x02, 0x02,
+	0x03, 0x01, 0x4c, 0x00, 0x03, 0x01, 0x02, 0x01, 0x03, 0x02, 0x80, 0x05, 0x01, 0x01, 0x01, 0x2b,
+	0x4d, 0x04, 0x01, 0x02, 0x02, 0x00, 0x62, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x29, 0x1b,
+	0x29, 0x19, 0x29, 0x19, 0x24, 0x07, 0x08, 0x1d, 0x2b, 0x25, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x04,
+	0x35, 0x34, 0x12, 0x37, 0x33, 0x06, 0x02, 0x15, 0x14, 0x1e, 0x04, 0x33, 0x32, 0x3e, 0x02, 0x37,
+	0x26, 0x26, 0x35, 0x34, 0x37, 0x33, 0x16, 0x15, 0x14, 0x06, 0x07, 0x1e, 0x03, 0x33, 0x32, 0x3e,
+	0x04, 0x35, 0x34, 0x2e, 0x02, 0x27, 0x33, 0x16, 0x12, 0x15, 0x14, 0x0e, 0x04, 0x23, 0x22, 0x26,
+	0x03, 0x1b, 0x18, 0x3c, 0x4b, 0x5d, 0x3b, 0x50, 0x76, 0x54, 0x35, 0x1f, 0x0b, 0x4a, 0x4c, 0xcd,
+	0x50, 0x55, 0x04, 0x0c, 0x18, 0x28, 0x3c, 0x2a, 0x37, 0x51, 0x39, 0x23, 0x09, 0x17, 0x1f, 0x2f,
+	0xb5, 0x2e, 0x1e, 0x17, 0x10, 0x28, 0x38, 0x4d, 0x35, 0x29, 0x3b, 0x27, 0x17, 0x0d, 0x03, 0x17,
+	0x2c, 0x3d, 0x26, 0xcd, 0x50, 0x46, 0x15, 0x2b, 0x3f, 0x54, 0x67, 0x3e, 0x70, 0x9f, 0xfe, 0x3a,
+	0x65, 0x4c, 0x2c, 0x2f, 0x51, 0x6d, 0x7d, 0x85, 0x41, 0x98, 0x01, 0x15, 0x7a, 0x7a, 0xfe, 0xe0,
+	0x8f, 0x28, 0x59, 0x59, 0x51, 0x3d, 0x25, 0x3e, 0x5f, 0x6e, 0x30, 0x3e, 0x8c, 0x40, 0x8a, 0x7e,
+	0x7b, 0x8d, 0x40, 0x95, 0x42, 0x39, 0x6d, 0x54, 0x34, 0x2c, 0x47, 0x5b, 0x5d, 0x57, 0x20, 0x47,
+	0x8e, 0x88, 0x7f, 0x38, 0x80, 0xfe, 0xeb, 0x92, 0x39, 0x80, 0x7c, 0x72, 0x56, 0x33, 0x8c, 0x00,
+	0x00, 0x03, 0x00, 0x1e, 0xff, 0xe7, 0x02, 0xbf, 0x05, 0xba, 0x00, 0x0d, 0x00, 0x11, 0x00, 0x15,
+	0x00, 0x69, 0x40, 0x0a, 0x0d, 0x01, 0x02, 0x01, 0x00, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1e, 0x08, 0x06, 0x07, 0x03, 0x04, 0x04, 0x03, 0x5f, 0x05, 0x01, 0x03,
+	0x03, 0x28, 0x4d, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00,
+	0x32, 0x00, 0x4e, 0x1b, 0x40, 0x1c, 0x05, 0x01, 0x03, 0x08, 0x06, 0x07, 0x03, 0x04, 0x01, 0x03,
+	0x04, 0x67, 0x00, 0x01, 0x01, 0x2b, 0x4d, 0x00, 0x02, 0x02, 0x00, 0x62, 0x00, 0x00, 0x00, 0x32,
+	0x00, 0x4e, 0x59, 0x40, 0x15, 0x12, 0x12, 0x0e, 0x0e, 0x12, 0x15, 0x12, 0x15, 0x14, 0x13, 0x0e,
+	0x11, 0x0e, 0x11, 0x13, 0x23, 0x13, 0x21, 0x09, 0x08, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26,
+	0x35, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0x02, 0xbf, 0x5c, 0x65, 0xa8, 0x91, 0xc5, 0x47, 0x56, 0x49, 0x4f, 0xfd, 0x5f, 0xad, 0xe2,
+	0xad, 0x11, 0x2a, 0xbd, 0xda, 0x02, 0xc0, 0xfd, 0x53, 0x98, 0x7e, 0x2a, 0x04, 0x68, 0xad, 0xad,
+	0xad, 0xad, 0x00, 0x00, 0x00, 0x03, 0x00, 0x8e, 0xff, 0xe7, 0x03, 0xfb, 0x05, 0xba, 0x00, 0x15,
+	0x00, 0x19, 0x00, 0x1d, 0x00, 0x60, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x09, 0x07, 0x08,
+	0x03, 0x05, 0x05, 0x04, 0x5f, 0x06, 0x01, 0x04, 0x04, 0x28, 0x4d, 0x02, 0x01, 0x00, 0x00, 0x2b,
+	0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x1b, 0x40, 0x1d, 0x06,
+	0x01, 0x04, 0x09, 0x07, 0x08, 0x03, 0x05, 0x00, 0x04, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x2b,
+	0x4d, 0x00, 0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x1a,
+	0x1a, 0x16, 0x16, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x16, 0x24, 0x14,
+	0x23, 0x10, 0x0a, 0x08, 0x1b, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x10,
+	0x03, 0x33, 0x12, 0x11, 0x14, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x13, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x8e, 0xc5, 0x65, 0x87, 0x6e, 0x9c, 0x9a, 0xd6, 0x76, 0xfe, 0xf4, 0xc1,
+	0xbb, 0x6f, 0x44, 0x32, 0x4e, 0xad, 0xe2, 0xad, 0x04, 0x3e, 0xfd, 0xe1, 0xf6, 0xae, 0xc8, 0xb3,
+	0x01, 0x39, 0x01, 0x0f, 0xfe, 0xf5, 0xfe, 0xdd, 0xfb, 0xfe, 0xd2, 0x6b, 0x41, 0xb3, 0xaf, 0x03,
+	0x18, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c,
+	0x06, 0xa6, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x17, 0x00, 0x40, 0x40, 0x3d, 0x00, 0x04, 0x05, 0x04,
+	0x85, 0x08, 0x01, 0x05, 0x01, 0x05, 0x85, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x31,
+	0x4d, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x14, 0x14,
+	0x0d, 0x0c, 0x01, 0x00, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x11, 0x0f, 0x0c, 0x13, 0x0d, 0x13,
+	0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x09, 0x08, 0x16, 0x2b, 0x05, 0x22, 0x00, 0x11, 0x10, 0x00,
+	0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11, 0x10, 0x13, 0x13,
+	0x33, 0x01, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3,
+	0x01, 0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x91, 0xd1, 0xdb, 0xfe, 0xd1, 0x19, 0x01, 0x34, 0x01, 0x04,
+	0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1, 0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01,
+	0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x04, 0x88, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x02, 0x00, 0x8e,
+	0xff, 0xe7, 0x03, 0xfb, 0x06, 0xa6, 0x00, 0x15, 0x00, 0x19, 0x00, 0x2d, 0x40, 0x2a, 0x00, 0x04,
+	0x05, 0x04, 0x85, 0x06, 0x01, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01, 0x00, 0x00, 0x2b, 0x4d, 0x00,
+	0x01, 0x01, 0x03, 0x62, 0x00, 0x03, 0x03, 0x32, 0x03, 0x4e, 0x16, 0x16, 0x16, 0x19, 0x16, 0x19,
+	0x16, 0x24, 0x14, 0x23, 0x10, 0x07, 0x08, 0x1b, 0x2b, 0x13, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32,
+	0x36, 0x35, 0x10, 0x03, 0x33, 0x12, 0x11, 0x14, 0x00, 0x23, 0x22, 0x27, 0x26, 0x26, 0x35, 0x01,
+	0x13, 0x33, 0x01, 0x8e, 0xc5, 0x65, 0x87, 0x6e, 0x9c, 0x9a, 0xd6, 0x76, 0xfe, 0xf4, 0xc1, 0xbb,
+	0x6f, 0x44, 0x32, 0x01, 0x10, 0xd1, 0xdb, 0xfe, 0xd1, 0x04, 0x3e, 0xfd, 0xe1, 0xf6, 0xae, 0xc8,
+	0xb3, 0x01, 0x39, 0x01, 0x0f, 0xfe, 0xf5, 0xfe, 0xdd, 0xfb, 0xfe, 0xd2, 0x6b, 0x41, 0xb3, 0xaf,
+	0x03, 0x0e, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00, 0x00, 0x02, 0x00, 0x6b, 0xff, 0xe7, 0x05, 0xd4,
+	0x06, 0xa6, 0x00, 0x48, 0x00, 0x4c, 0x00, 0x41, 0x40, 0x3e, 0x2a, 0x1f, 0x02, 0x02, 0x03, 0x01,
+	0x4c, 0x00, 0x07, 0x08, 0x07, 0x85, 0x09, 0x01, 0x08, 0x01, 0x08, 0x85, 0x00, 0x03, 0x01, 0x02,
+	0x01, 0x03, 0x02, 0x80, 0x05, 0x01, 0x01, 0x01, 0x2b, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x00, 0x62,
+	0x06, 0x01, 0x00, 0x00, 0x32, 0x00, 0x4e, 0x49, 0x49, 0x49, 0x4c, 0x49, 0x4c, 0x13, 0x29, 0x1b,
+	0x29, 0x19, 0x29, 0x19, 0x24, 0x0a, 0x08, 0x1e, 0x2b, 0x25, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x04,
+	0x35, 0x34, 0x12, 0x37, 0x33, 0x06, 0x02, 0x15, 0x14, 0x1e, 0x04, 0x33, 0x32, 0x3e, 0x02, 0x37,
+	0x26, 0x26, 0x35, 0x34, 0x37, 0x33, 0x16, 0x15, 0x14, 0x06, 0x07, 0x1e, 0x03, 0x33, 0x32, 0x3e,
+	0x04, 0x35, 0x34, 0x2e, 0x02, 0x27, 0x33, 0x16, 0x12, 0x15, 0x14, 0x0e, 0x04, 0x23, 0x22, 0x26,
+	0x03, 0x13, 0x33, 0x01, 0x03, 0x1b, 0x18, 0x3c, 0x4b, 0x5d, 0x3b, 0x50, 0x76, 0x54, 0x35, 0x1f,
+	0x0b, 0x4a, 0x4c, 0xcd, 0x50, 0x55, 0x04, 0x0c, 0x18, 0x28, 0x3c, 0x2a, 0x37, 0x51, 0x39, 0x23,
+	0x09, 0x17, 0x1f, 0x2f, 0xb5, 0x2e, 0x1e, 0x17, 0x10, 0x28, 0x38, 0x4d, 0x35, 0x29, 0x3b, 0x27,
+	0x17, 0x0d, 0x03, 0x17, 0x2c, 0x3d, 0x26, 0xcd, 0x50, 0x46, 0x15, 0x2b, 0x3f, 0x54, 0x67, 0x3e,
+	0x70, 0x9f, 0xc9, 0xd1, 0xdb, 0xfe, 0xd1, 0xfe, 0x3a, 0x65, 0x4c, 0x2c, 0x2f, 0x51, 0x6d, 0x7d,
+	0x85, 0x41, 0x98, 0x01, 0x15, 0x7a, 0x7a, 0xfe, 0xe0, 0x8f, 0x28, 0x59, 0x59, 0x51, 0x3d, 0x25,
+	0x3e, 0x5f, 0x6e, 0x30, 0x3e, 0x8c, 0x40, 0x8a, 0x7e, 0x7b, 0x8d, 0x40, 0x95, 0x42, 0x39, 0x6d,
+	0x54, 0x34, 0x2c, 0x47, 0x5b, 0x5d, 0x57, 0x20, 0x47, 0x8e, 0x88, 0x7f, 0x38, 0x80, 0xfe, 0xeb,
+	0x92, 0x39, 0x80, 0x7c, 0x72, 0x56, 0x33, 0x8c, 0x04, 0x90, 0x01, 0xa3, 0xfe, 0x5d, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xbe, 0x00, 0x00, 0x05, 0x1b, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x6e,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06,
+	0x85, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b,
+	0x40, 0x26, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x00, 0x06, 0x85, 0x00, 0x00, 0x00, 0x01,
+	0x02, 0x00, 0x01, 0x68, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05,
+	0x5f, 0x08, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x33, 0x11,
+	0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x23, 0x01, 0x33, 0xbe, 0x04,
+	0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0xfe, 0x62, 0x94, 0xfe, 0xbf, 0xe4, 0x05,
+	0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x03, 0x00, 0xbe,
+	0x00, 0x00, 0x05, 0x1b, 0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x7e, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07,
+	0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b,
+	0x40, 0x28, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x00, 0x06, 0x07, 0x67, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04,
+	0x04, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40, 0x1e, 0x10, 0x10, 0x0c,
+	0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15,
+	0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0xfc, 0xa6, 0xad, 0xde, 0xad,
+	0x05, 0xc8, 0x9d, 0xfe, 0x25, 0x9b, 0xfd, 0xe8, 0x9d, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0x00,
+	0x00, 0x01, 0x00, 0x1e, 0xff, 0xf4, 0x06, 0x8f, 0x05, 0xc8, 0x00, 0x29, 0x00, 0x83, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x0f, 0x00, 0x01, 0x03, 0x00, 0x21, 0x11, 0x02, 0x02, 0x03, 0x10, 0x01,
+	0x01, 0x02, 0x03, 0x4c, 0x1b, 0x40, 0x10, 0x00, 0x01, 0x03, 0x00, 0x21, 0x11, 0x02, 0x02, 0x03,
+	0x02, 0x4c, 0x10, 0x01, 0x04, 0x01, 0x4b, 0x59, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x00,
+	0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x69, 0x07, 0x01, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06,
+	0x1a, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40,
+	0x21, 0x00, 0x06, 0x07, 0x01, 0x05, 0x00, 0x06, 0x05, 0x67, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00,
+	0x03, 0x69, 0x00, 0x04, 0x04, 0x1d, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1d,
+	0x01, 0x4e, 0x59, 0x40, 0x0b, 0x11, 0x11, 0x11, 0x13, 0x28, 0x25, 0x28, 0x22, 0x08, 0x07, 0x1e,
+	0x2b, 0x01, 0x36, 0x36, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x27,
+	0x35, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x22, 0x06, 0x07, 0x11,
+	0x23, 0x11, 0x21, 0x35, 0x21, 0x15, 0x21, 0x02, 0xd6, 0x58, 0xdb, 0x76, 0x81, 0xc6, 0x85, 0x44,
+	0x48, 0x81, 0xb5, 0x6c, 0x2a, 0x53, 0x19, 0x0f, 0x40, 0x1e, 0x4c, 0x74, 0x4e, 0x27, 0x2a, 0x52,
+	0x7c, 0x52, 0x7a, 0xc6, 0x51, 0xd1, 0xfe, 0x19, 0x04, 0x8b, 0xfe, 0x2d, 0x03, 0x4c, 0x42, 0x4c,
+	0x47, 0x7f, 0xaf, 0x69, 0x68, 0xbd, 0x8f, 0x54, 0x08, 0x04, 0x9d, 0x04, 0x0b, 0x3c, 0x65, 0x84,
+	0x47, 0x3d, 0x6d, 0x52, 0x31, 0x51, 0x48, 0xfd, 0x72, 0x05, 0x2b, 0x9d, 0x9d, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xb4, 0x00, 0x00, 0x04, 0x3e, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x09, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01,
+	0x04, 0x85, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b,
+	0x00, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x03, 0x04, 0x03, 0x85, 0x05, 0x01, 0x04, 0x01, 0x04, 0x85,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x68, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0x40,
+	0x0d, 0x06, 0x06, 0x06, 0x09, 0x06, 0x09, 0x12, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1a, 0x2b, 0x21,
+	0x23, 0x11, 0x21, 0x15, 0x21, 0x13, 0x13, 0x33, 0x01, 0x01, 0x86, 0xd2, 0x03, 0x8a, 0xfd, 0x48,
+	0x4d, 0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0x9d, 0x01, 0x23, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x5d, 0xff, 0xdb, 0x05, 0x52, 0x05, 0xed, 0x00, 0x22, 0x00, 0x63, 0x40, 0x12,
+	0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x03, 0x02, 0x00, 0x01, 0x05, 0x04, 0x01, 0x01, 0x00, 0x05,
+	0x04, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04,
+	0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x05, 0x05, 0x00, 0x61,
+	0x00, 0x00, 0x00, 0x20, 0x00, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02,
+	0x69, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00,
+	0x00, 0x22, 0x00, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x11, 0x14, 0x27, 0x26, 0x22, 0x06, 0x07, 0x1c,
+	0x2b, 0x01, 0x15, 0x06, 0x21, 0x20, 0x00, 0x11, 0x34, 0x12, 0x36, 0x24, 0x33, 0x32, 0x16, 0x17,
+	0x15, 0x2e, 0x03, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x21, 0x15, 0x21, 0x14, 0x1e, 0x02, 0x33, 0x32,
+	0x05, 0x52, 0xdc, 0xfe, 0xfe, 0xfe, 0x6f, 0xfe, 0x7a, 0x6c, 0xd0, 0x01, 0x30, 0xc4, 0x67, 0xd1,
+	0x7f, 0x3c, 0x6e, 0x68, 0x64, 0x33, 0x7b, 0xd0, 0x9c, 0x63, 0x0e, 0x03, 0x1b, 0xfc, 0xde, 0x52,
+	0x99, 0xdd, 0x8b, 0xd3, 0x01, 0x00, 0xb4, 0x71, 0x01, 0x80, 0x01, 0x88, 0xc7, 0x01, 0x25, 0xc0,
+	0x5e, 0x1f, 0x1f, 0xc0, 0x18, 0x23, 0x17, 0x0c, 0x3f, 0x7f, 0xbe, 0x7e, 0x9a, 0x8f, 0xd6, 0x8e,
+	0x47, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x78, 0xff, 0xdb, 0x04, 0xdb, 0x05, 0xed, 0x00, 0x1f,
+	0x00, 0x4d, 0x40, 0x0f, 0x0f, 0x01, 0x02, 0x01, 0x10, 0x01, 0x02, 0x00, 0x02, 0x00, 0x01, 0x03,
+	0x00, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x1f, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x20, 0x03, 0x4e, 0x1b,
+	0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x00, 0x01, 0x02, 0x69, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x22, 0x03, 0x4e, 0x59, 0xb6, 0x2a, 0x23, 0x28, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x37,
+	0x35, 0x04, 0x21, 0x20, 0x35, 0x34, 0x26, 0x27, 0x27, 0x24, 0x11, 0x10, 0x21, 0x32, 0x17, 0x15,
+	0x26, 0x23, 0x20, 0x15, 0x14, 0x16, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x04, 0x23, 0x20, 0x78,
+	0x01, 0x1d, 0x01, 0x31, 0x01, 0x3d, 0x7b, 0xbc, 0xc9, 0xfe, 0x7d, 0x02, 0x1c, 0xf4, 0xef, 0xf8,
+	0xf8, 0xfe, 0xbc, 0x79, 0xa2, 0xce, 0xe9, 0xbe, 0xfe, 0xdd, 0xf9, 0xfe, 0xf3, 0x34, 0xd0, 0x8c,
+	0xef, 0x6a, 0x6f, 0x3d, 0x42, 0x80, 0x01, 0x1c, 0x01, 0x92, 0x3f, 0xc1, 0x63, 0xdc, 0x59, 0x6a,
+	0x36, 0x43, 0x4c, 0xc3, 0xa3, 0xc6, 0xe5, 0x00, 0x00, 0x01, 0x00, 0x7c, 0x00, 0x00, 0x02, 0xb5,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x03, 0x01, 0x01,
+	0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01,
+	0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40,
+	0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b,
+	0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x7c, 0xb4, 0xb4, 0x02,
+	0x39, 0xb4, 0xb4, 0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x00, 0x00, 0x03, 0x00, 0x7c,
+	0x00, 0x00, 0x02, 0xb5, 0x07, 0x0f, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x72, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x24, 0x08, 0x01, 0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x02, 0x06, 0x07,
+	0x67, 0x03, 0x01, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x04, 0x01, 0x00, 0x00,
+	0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x22, 0x08, 0x01, 0x06, 0x0c,
+	0x09, 0x0b, 0x03, 0x07, 0x02, 0x06, 0x07, 0x67, 0x00, 0x02, 0x03, 0x01, 0x01, 0x00, 0x02, 0x01,
+	0x67, 0x04, 0x01, 0x00, 0x00, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40,
+	0x1e, 0x10, 0x10, 0x0c, 0x0c, 0x00, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f, 0x0c,
+	0x0f, 0x0e, 0x0d, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0d, 0x07, 0x1b, 0x2b,
+	0x33, 0x35, 0x33, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x7c, 0xb4, 0xb4, 0x02, 0x39, 0xb4, 0xb4, 0xfd, 0xc7, 0xad, 0xdf, 0xad,
+	0x9d, 0x04, 0x8e, 0x9d, 0x9d, 0xfb, 0x72, 0x9d, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x50, 0xfe, 0xd8, 0x03, 0x67, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x4a, 0x40, 0x0a,
+	0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x03, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x12, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x65, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x02,
+	0x1a, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00,
+	0x03, 0x03, 0x00, 0x59, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x00, 0x03, 0x51, 0x59, 0xb6,
+	0x22, 0x11, 0x13, 0x22, 0x04, 0x07, 0x1a, 0x2b, 0x17, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11,
+	0x23, 0x35, 0x21, 0x11, 0x10, 0x21, 0x22, 0x50, 0xa6, 0x95, 0x9f, 0x6b, 0xe6, 0x01, 0xb8, 0xfe,
+	0x1e, 0xa7, 0xe8, 0xb5, 0x4d, 0x7d, 0xb7, 0x04, 0x78, 0x9c, 0xfa, 0xf3, 0xfe, 0x1d, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x18, 0x00, 0x00, 0x08, 0x19, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x2f, 0x00, 0x5c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x05, 0x00, 0x01, 0x00, 0x05, 0x01, 0x67, 0x08,
+	0x01, 0x07, 0x07, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1a, 0x4d, 0x03, 0x01, 0x00, 0x00, 0x02, 0x61,
+	0x06, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x08, 0x01, 0x07, 0x05,
+	0x04, 0x07, 0x67, 0x00, 0x05, 0x00, 0x01, 0x00, 0x05, 0x01, 0x67, 0x03, 0x01, 0x00, 0x00, 0x02,
+	0x61, 0x06, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x10, 0x0d, 0x0d, 0x0d, 0x2f, 0x0d,
+	0x2f, 0x28, 0x21, 0x17, 0x21, 0x28, 0x28, 0x20, 0x09, 0x07, 0x1d, 0x2b, 0x25, 0x33, 0x32, 0x3e,
+	0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x01, 0x15, 0x14, 0x02, 0x02, 0x0e, 0x02, 0x23, 0x23,
+	0x35, 0x33, 0x32, 0x3e, 0x02, 0x12, 0x12, 0x35, 0x35, 0x21, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15,
+	0x14, 0x0e, 0x02, 0x23, 0x21, 0x11, 0x04, 0xdc, 0xd5, 0x6a, 0x9a, 0x63, 0x2f, 0x2e, 0x63, 0x99,
+	0x6c, 0xd5, 0xfd, 0x54, 0x0d, 0x27, 0x45, 0x71, 0xa2, 0x6f, 0x1d, 0x19, 0x3f, 0x61, 0x48, 0x32,
+	0x1f, 0x0d, 0x03, 0x65, 0xc5, 0x8b, 0xe8, 0xa8, 0x5d, 0x62, 0xab, 0xe7, 0x84, 0xfe, 0x69, 0x9a,
+	0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x02, 0x7b, 0x6e, 0xcb, 0xfe, 0xae, 0xfe, 0xf2,
+	0xcb, 0x87, 0x43, 0x9a, 0x24, 0x60, 0xa6, 0x01, 0x05, 0x01, 0x6e, 0xf8, 0x99, 0xfd, 0x85, 0x28,
+	0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x05, 0x2e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x07, 0xb9, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x23, 0x00, 0x58, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x1c, 0x05, 0x01, 0x03, 0x07, 0x01, 0x01, 0x00, 0x03, 0x01, 0x67, 0x04, 0x01, 0x02,
+	0x02, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x60, 0x08, 0x01, 0x06, 0x06, 0x1b, 0x06, 0x4e, 0x1b,
+	0x40, 0x22, 0x05, 0x01, 0x03, 0x07, 0x01, 0x01, 0x00, 0x03, 0x01, 0x67, 0x04, 0x01, 0x02, 0x02,
+	0x06, 0x5f, 0x08, 0x01, 0x06, 0x06, 0x1d, 0x4d, 0x00, 0x00, 0x00, 0x06, 0x60, 0x08, 0x01, 0x06,
+	0x06, 0x1d, 0x06, 0x4e, 0x59, 0x40, 0x0c, 0x11, 0x11, 0x28, 0x21, 0x11, 0x11, 0x11, 0x28, 0x20,
+	0x09, 0x07, 0x1f, 0x2b, 0x25, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x01,
+	0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x21,
+	0x11, 0x21, 0x11, 0x23, 0x04, 0x90, 0xc1, 0x6a, 0x9a, 0x63, 0x2f, 0x2e, 0x63, 0x99, 0x6c, 0xc1,
+	0xfc, 0x15, 0xd2, 0x02, 0x47, 0xd2, 0xb1, 0x8b, 0xe8, 0xa8, 0x5d, 0x62, 0xab, 0xe7, 0x84, 0xfe,
+	0x7d, 0xfd, 0xb9, 0xd2, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x03, 0x15, 0xfd,
+	0x85, 0x02, 0x7b, 0xfd, 0x85, 0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x02, 0xb3, 0xfd,
+	0x4d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x1b, 0x00, 0x00, 0x06, 0x33, 0x05, 0xc8, 0x00, 0x1b,
+	0x00, 0x5d, 0x40, 0x0a, 0x03, 0x01, 0x03, 0x01, 0x16, 0x01, 0x02, 0x03, 0x02, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x00,
+	0x00, 0x06, 0x5f, 0x07, 0x01, 0x06, 0x06, 0x1a, 0x4d, 0x04, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x19, 0x07, 0x01, 0x06, 0x05, 0x01, 0x00, 0x01, 0x06, 0x00, 0x67, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x69, 0x04, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x00, 0x1b, 0x00, 0x1b, 0x11, 0x13, 0x25, 0x15, 0x23, 0x11, 0x08, 0x07, 0x1c, 0x2b, 0x01,
+	0x15, 0x21, 0x11, 0x36, 0x36, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x11, 0x23, 0x11, 0x34, 0x2e, 0x02,
+	0x23, 0x22, 0x06, 0x07, 0x11, 0x23, 0x11, 0x21, 0x35, 0x04, 0xa9, 0xfe, 0x30, 0x52, 0xd2, 0x6f,
+	0x70, 0xaa, 0x73, 0x3a, 0xd2, 0x21, 0x46, 0x6e, 0x4c, 0x61, 0xbd, 0x49, 0xd2, 0xfe, 0x14, 0x05,
+	0xc8, 0x9d, 0xfe, 0x18, 0x46, 0x46, 0x34, 0x74, 0xb9, 0x84, 0xfe, 0x16, 0x01, 0xe5, 0x5a, 0x79,
+	0x4a, 0x20, 0x4c, 0x4e, 0xfd, 0x78, 0x05, 0x2b, 0x9d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0x81, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x35, 0x00, 0x7e, 0xb5, 0x22, 0x01, 0x07,
+	0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x26, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09,
+	0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x03, 0x00, 0x07, 0x06, 0x03, 0x07, 0x67, 0x00, 0x05, 0x05,
+	0x02, 0x61, 0x04, 0x01, 0x02, 0x02, 0x1a, 0x4d, 0x0a, 0x08, 0x02, 0x06, 0x06, 0x1b, 0x06, 0x4e,
+	0x1b, 0x40, 0x27, 0x00, 0x00, 0x01, 0x00, 0x85, 0x09, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x05,
+	0x03, 0x02, 0x05, 0x59, 0x00, 0x03, 0x00, 0x07, 0x06, 0x03, 0x07, 0x67, 0x04, 0x01, 0x02, 0x02,
+	0x06, 0x5f, 0x0a, 0x08, 0x02, 0x06, 0x06, 0x1d, 0x06, 0x4e, 0x59, 0x40, 0x1c, 0x04, 0x04, 0x00,
+	0x00, 0x04, 0x35, 0x04, 0x35, 0x34, 0x33, 0x2c, 0x2b, 0x18, 0x17, 0x16, 0x12, 0x09, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0b, 0x07, 0x17, 0x2b, 0x01, 0x13, 0x33, 0x01, 0x01, 0x11,
+	0x33, 0x11, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x32, 0x37, 0x15, 0x22,
+	0x0e, 0x02, 0x07, 0x07, 0x0e, 0x03, 0x07, 0x1e, 0x03, 0x17, 0x17, 0x16, 0x16, 0x17, 0x23, 0x2e,
+	0x05, 0x27, 0x23, 0x11, 0x01, 0xe7, 0xf1, 0xe4, 0xfe, 0xbf, 0xfe, 0x2a, 0xd2, 0x1e, 0x29, 0x42,
+	0x3b, 0x36, 0x1c, 0x4e, 0x27, 0x40, 0x48, 0x5d, 0x43, 0x01, 0x0d, 0x0d, 0x2a, 0x3b, 0x2f, 0x27,
+	0x15, 0x41, 0x1a, 0x2f, 0x37, 0x44, 0x30, 0x48, 0x63, 0x4c, 0x42, 0x28, 0x36, 0x2a, 0x55, 0x2b,
+	0xdc, 0x21, 0x45, 0x49, 0x4d, 0x52, 0x57, 0x2f, 0x5a, 0x06, 0x4e, 0x01, 0x41, 0xfe, 0xbf, 0xf9,
+	0xb2, 0x05, 0xc8, 0xfd, 0x85, 0x26, 0x42, 0x57, 0x32, 0x89, 0x44, 0x61, 0x3e, 0x1d, 0x01, 0x9a,
+	0x17, 0x2a, 0x3c, 0x25, 0x73, 0x2e, 0x4d, 0x42, 0x39, 0x1a, 0x14, 0x36, 0x52, 0x73, 0x4f, 0x6c,
+	0x55, 0x9c, 0x4e, 0x3a, 0x89, 0x8d, 0x88, 0x71, 0x53, 0x11, 0xfd, 0x53, 0x00, 0x02, 0x00, 0xaa,
+	0x00, 0x00, 0x05, 0x16, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x56, 0xb6, 0x08, 0x03, 0x02,
+	0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x05, 0x04, 0x05, 0x85,
+	0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x06, 0x03, 0x02, 0x02, 0x02,
+	0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85,
+	0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x06, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40,
+	0x10, 0x00, 0x00, 0x0d, 0x0c, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x07, 0x07,
+	0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x11, 0x23, 0x11, 0x01, 0x01, 0x23, 0x01, 0x33,
+	0xaa, 0xd2, 0x02, 0xc8, 0xd2, 0xd2, 0xfd, 0x38, 0x01, 0xda, 0x94, 0xfe, 0xbf, 0xe4, 0x05, 0xc8,
+	0xfb, 0x66, 0x04, 0x9a, 0xfa, 0x38, 0x04, 0x9a, 0xfb, 0x66, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x2c, 0xff, 0xdb, 0x05, 0x02, 0x07, 0x8f, 0x00, 0x10, 0x00, 0x22, 0x00, 0x8a,
+	0xb5, 0x03, 0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x0c, 0x50, 0x58, 0x40, 0x20, 0x06, 0x01,
+	0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00,
+	0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1f, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07,
+	0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00,
+	0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x01, 0x01,
+	0x00, 0x07, 0x03, 0x07, 0x00, 0x03, 0x80, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x00,
+	0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x0b, 0x23, 0x13,
+	0x23, 0x13, 0x21, 0x23, 0x13, 0x11, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x01, 0x33, 0x01, 0x33, 0x01,
+	0x33, 0x01, 0x06, 0x06, 0x23, 0x23, 0x35, 0x33, 0x32, 0x36, 0x37, 0x03, 0x33, 0x14, 0x17, 0x16,
+	0x33, 0x32, 0x37, 0x36, 0x35, 0x33, 0x06, 0x07, 0x06, 0x21, 0x20, 0x27, 0x26, 0x02, 0x37, 0xfd,
+	0xf5, 0xea, 0x01, 0x94, 0x04, 0x01, 0x93, 0xc1, 0xfd, 0xc2, 0x6b, 0xec, 0xdd, 0x26, 0x29, 0x9e,
+	0x9f, 0x48, 0xb8, 0xa1, 0x07, 0x20, 0x85, 0x85, 0x20, 0x07, 0xa1, 0x01, 0x09, 0x29, 0xfe, 0xe6,
+	0xfe, 0xe6, 0x29, 0x09, 0x01, 0xb3, 0x04, 0x15, 0xfc, 0xd9, 0x03, 0x27, 0xfb, 0x83, 0xd6, 0x9a,
+	0xad, 0x61, 0x8c, 0x06, 0x1a, 0x48, 0x22, 0x73, 0x73, 0x22, 0x48, 0x47, 0x1e, 0xdc, 0xcf, 0x2b,
+	0x00, 0x01, 0x00, 0xa5, 0xfe, 0x75, 0x05, 0x1b, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x40, 0x18,
+	0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03,
+	0x1d, 0x4d, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x11,
+	0x33, 0x11, 0x21, 0x11, 0x23, 0x11, 0xa5, 0xd2, 0x02, 0xd3, 0xd1, 0xfe, 0x27, 0xc3, 0x05, 0xc8,
+	0xfa, 0xd4, 0x05, 0x2c, 0xfa, 0x38, 0xfe, 0x75, 0x01, 0x8b, 0x00, 0x00, 0x00, 0x02, 0x00, 0x13,
+	0x00, 0x00, 0x05, 0x3e, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x0a, 0x00, 0x4d, 0xb5, 0x0a, 0x01, 0x04,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x15, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04,
+	0x02, 0x68, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x15, 0x00, 0x00, 0x04, 0x00, 0x85, 0x00, 0x04, 0x00, 0x02, 0x01, 0x04, 0x02, 0x68, 0x05,
+	0x03, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x09, 0x08, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x06, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x33, 0x01, 0x23, 0x03, 0x21,
+	0x03, 0x13, 0x21, 0x03, 0x13, 0x02, 0x32, 0xd0, 0x02, 0x29, 0xe2, 0x9a, 0xfd, 0xae, 0x9a, 0xd6,
+	0x01, 0xdc, 0xed, 0x05, 0xc8, 0xfa, 0x38, 0x01, 0x9a, 0xfe, 0x66, 0x02, 0x36, 0x02, 0x7a, 0x00,
+	0x00, 0x02, 0x00, 0xa5, 0x00, 0x00, 0x04, 0xe4, 0x05, 0xc8, 0x00, 0x0c, 0x00, 0x1d, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00,
+	0x05, 0x05, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x00, 0x03,
+	0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00,
+	0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x00, 0x03, 0x03, 0x1d,
+	0x03, 0x4e, 0x59, 0x40, 0x09, 0x11, 0x11, 0x28, 0x21, 0x28, 0x20, 0x06, 0x07, 0x1c, 0x2b, 0x25,
+	0x21, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x21, 0x35, 0x33, 0x32, 0x1e, 0x02, 0x15,
+	0x14, 0x0e, 0x02, 0x23, 0x21, 0x11, 0x21, 0x15, 0x21, 0x01, 0x77, 0x01, 0x05, 0x6a, 0x9a, 0x63,
+	0x2f, 0x2e, 0x63, 0x99, 0x6c, 0xfe, 0xfb, 0xf5, 0x8b, 0xe8, 0xa8, 0x5d, 0x62, 0xab, 0xe7, 0x84,
+	0xfe, 0x39, 0x03, 0xb5, 0xfd, 0x1d, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x9a,
+	0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x05, 0xc8, 0x9d, 0x00, 0x00, 0x03, 0x00, 0xa5,
+	0x00, 0x00, 0x04, 0xcf, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x61, 0xb5, 0x07,
+	0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x00, 0x03,
+	0x02, 0x04, 0x03, 0x67, 0x00, 0x05, 0x05, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x02,
+	0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00,
+	0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x67, 0x00, 0x02, 0x02,
+	0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1f, 0x1d,
+	0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x07, 0x07, 0x17, 0x2b, 0x33,
+	0x11, 0x21, 0x20, 0x16, 0x15, 0x10, 0x05, 0x04, 0x11, 0x14, 0x07, 0x06, 0x06, 0x23, 0x25, 0x33,
+	0x20, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x23, 0xa5,
+	0x01, 0xda, 0x01, 0x24, 0xf1, 0xfe, 0xb8, 0x01, 0x83, 0x51, 0x40, 0xba, 0xd1, 0xfe, 0xc4, 0x9b,
+	0x01, 0x28, 0xb7, 0xee, 0xe1, 0xab, 0xb3, 0x01, 0x92, 0xa0, 0xe3, 0xc2, 0x05, 0xc8, 0x97, 0xb8,
+	0xfe, 0xf2, 0x68, 0x6a, 0xfe, 0xda, 0x8f, 0x61, 0x4e, 0x35, 0x9d, 0x57, 0x8c, 0x98, 0xa1, 0x85,
+	0x01, 0x19, 0x7c, 0x58, 0x00, 0x01, 0x00, 0xb4, 0x00, 0x00, 0x04, 0x41, 0x05, 0xc8, 0x00, 0x05,
+	0x00, 0x31, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x10, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x00, 0x01,
+	0x01, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b, 0x40, 0x0e, 0x00, 0x01, 0x00, 0x02,
+	0x00, 0x01, 0x02, 0x67, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e, 0x59, 0xb5, 0x11, 0x11, 0x10, 0x03,
+	0x07, 0x19, 0x2b, 0x21, 0x23, 0x11, 0x21, 0x15, 0x21, 0x01, 0x86, 0xd2, 0x03, 0x8d, 0xfd, 0x45,
+	0x05, 0xc8, 0x9d, 0x00, 0x00, 0x02, 0x00, 0x3c, 0xfe, 0x75, 0x05, 0x2f, 0x05, 0xc8, 0x00, 0x0e,
+	0x00, 0x15, 0x00, 0x68, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x25, 0x00, 0x07, 0x07, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x1a, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b,
+	0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e,
+	0x1b, 0x40, 0x23, 0x00, 0x01, 0x00, 0x07, 0x00, 0x01, 0x07, 0x67, 0x06, 0x02, 0x02, 0x00, 0x00,
+	0x04, 0x5f, 0x00, 0x04, 0x04, 0x1d, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05,
+	0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x12, 0x11, 0x10, 0x0f, 0x00,
+	0x0e, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x13, 0x11, 0x33, 0x12,
+	0x12, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x11, 0x13, 0x21, 0x11, 0x21, 0x15,
+	0x10, 0x02, 0x3c, 0x39, 0xb0, 0x8b, 0x02, 0xd0, 0xaf, 0xc3, 0xfc, 0x93, 0x49, 0x02, 0x67, 0xfe,
+	0xc0, 0x94, 0xfe, 0x75, 0x02, 0x28, 0x01, 0x10, 0x02, 0x0a, 0x01, 0x88, 0x89, 0xfa, 0xd5, 0xfd,
+	0xd8, 0x01, 0x8b, 0xfe, 0x75, 0x02, 0x28, 0x04, 0x91, 0x18, 0xfe, 0xbe, 0xfd, 0xc4, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xbe, 0x00, 0x00, 0x05, 0x1b, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x56, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05,
+	0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x00, 0x03, 0x04, 0x02, 0x03, 0x67, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x05, 0x1d,
+	0x05, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x21, 0x11, 0x21, 0x15,
+	0xbe, 0x04, 0x31, 0xfc, 0xa1, 0x02, 0xfc, 0xfd, 0x04, 0x03, 0x8b, 0x05, 0xc8, 0x9d, 0xfe, 0x25,
+	0x9b, 0xfd, 0xe8, 0x9d, 0x00, 0x01, 0x00, 0x7d, 0x00, 0x00, 0x06, 0xe7, 0x05, 0xc9, 0x00, 0x46,
+	0x00, 0x6a, 0xb7, 0x38, 0x26, 0x12, 0x03, 0x01, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x1f, 0x00, 0x05, 0x0b, 0x0a, 0x02, 0x01, 0x00, 0x05, 0x01, 0x67, 0x08, 0x01, 0x03, 0x03,
+	0x04, 0x61, 0x07, 0x06, 0x02, 0x04, 0x04, 0x1a, 0x4d, 0x09, 0x02, 0x02, 0x00, 0x00, 0x1b, 0x00,
+	0x4e, 0x1b, 0x40, 0x20, 0x08, 0x01, 0x03, 0x05, 0x04, 0x03, 0x59, 0x00, 0x05, 0x0b, 0x0a, 0x02,
+	0x01, 0x00, 0x05, 0x01, 0x67, 0x07, 0x06, 0x02, 0x04, 0x04, 0x00, 0x5f, 0x09, 0x02, 0x02, 0x00,
+	0x00, 0x1d, 0x00, 0x4e, 0x59, 0x40, 0x14, 0x00, 0x00, 0x00, 0x46, 0x00, 0x46, 0x40, 0x3f, 0x11,
+	0x29, 0x11, 0x16, 0x21, 0x1d, 0x16, 0x11, 0x11, 0x0c, 0x07, 0x1f, 0x2b, 0x01, 0x11, 0x23, 0x11,
+	0x23, 0x06, 0x07, 0x06, 0x03, 0x06, 0x07, 0x23, 0x37, 0x36, 0x37, 0x37, 0x36, 0x36, 0x37, 0x26,
+	0x26, 0x27, 0x27, 0x26, 0x26, 0x23, 0x35, 0x37, 0x32, 0x16, 0x1f, 0x02, 0x16, 0x16, 0x17, 0x11,
+	0x33, 0x11, 0x36, 0x36, 0x37, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x17, 0x15, 0x22, 0x06, 0x07,
+	0x07, 0x06, 0x06, 0x07, 0x16, 0x16, 0x17, 0x17, 0x16, 0x17, 0x17, 0x23, 0x26, 0x27, 0x02, 0x27,
+	0x26, 0x27, 0x04, 0x15, 0xc6, 0x7e, 0x5c, 0x34, 0x47, 0x6b, 0x2a, 0x10, 0xd8, 0x16, 0x3c, 0x31,
+	0x2f, 0x4a, 0x7c, 0x69, 0x37, 0x46, 0x42, 0x25, 0x3f, 0x48, 0x3b, 0x15, 0x67, 0x8b, 0x55, 0x1f,
+	0x28, 0x3c, 0x4b, 0x6d, 0xc6, 0x6e, 0x50, 0x36, 0x1b, 0x0d, 0x1f, 0x55, 0x8b, 0x67, 0x15, 0x3b,
+	0x48, 0x3f, 0x25, 0x42, 0x46, 0x37, 0x69, 0x7c, 0x4a, 0x2e, 0x32, 0x3c, 0x16, 0xd8, 0x11, 0x2a,
+	0x69, 0x48, 0x34, 0x84, 0x02, 0xb9, 0xfd, 0x47, 0x02, 0xb9, 0x2e, 0x5f, 0x82, 0xfe, 0xea, 0x6f,
+	0x25, 0x32, 0x87, 0x78, 0x70, 0xb4, 0x94, 0x21, 0x20, 0x61, 0x88, 0x4e, 0x81, 0x4c, 0x9a, 0x01,
+	0x7f, 0xab, 0x40, 0x51, 0x78, 0x49, 0x03, 0x02, 0x7e, 0xfd, 0x82, 0x08, 0x4c, 0x70, 0x36, 0x1b,
+	0x40, 0xab, 0x7f, 0x01, 0x9a, 0x4c, 0x81, 0x4e, 0x88, 0x61, 0x20, 0x21, 0x94, 0xb4, 0x70, 0x78,
+	0x87, 0x32, 0x26, 0x6e, 0x01, 0x14, 0x84, 0x5f, 0x2e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x6e,
+	0xff, 0xdb, 0x04, 0x3f, 0x05, 0xed, 0x00, 0x23, 0x00, 0x67, 0x40, 0x16, 0x15, 0x01, 0x03, 0x04,
+	0x14, 0x01, 0x02, 0x03, 0x1c, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x05, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01,
+	0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x1f, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61,
+	0x00, 0x05, 0x05, 0x20, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03,
+	0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05,
+	0x05, 0x22, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x29, 0x23, 0x24, 0x21, 0x24, 0x22, 0x06, 0x07, 0x1c,
+	0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x33, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x04, 0x15, 0x10, 0x05, 0x16, 0x16,
+	0x15, 0x14, 0x00, 0x23, 0x22, 0x6e, 0xe9, 0xbe, 0x97, 0xb5, 0xee, 0xe5, 0x33, 0x31, 0xcd, 0xe2,
+	0x99, 0x98, 0xb3, 0xd0, 0xbf, 0xd0, 0xf3, 0x01, 0x0a, 0xfe, 0xbe, 0xad, 0xc1, 0xfe, 0xc3, 0xea,
+	0xe6, 0x19, 0xb9, 0x56, 0x98, 0x7e, 0x98, 0x9f, 0x94, 0x95, 0x88, 0x6c, 0x6c, 0x4d, 0xaa, 0x3e,
+	0xb9, 0xaa, 0xfe, 0xf9, 0x5f, 0x1c, 0xcb, 0x98, 0xc3, 0xfe, 0xf9, 0x00, 0x00, 0x01, 0x00, 0xaa,
+	0x00, 0x00, 0x05, 0x16, 0x05, 0xc8, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00,
+	0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x04,
+	0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f,
+	0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00,
+	0x09, 0x11, 0x12, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x11, 0x23,
+	0x11, 0x01, 0xaa, 0xd2, 0x02, 0xc8, 0xd2, 0xd2, 0xfd, 0x38, 0x05, 0xc8, 0xfb, 0x66, 0x04, 0x9a,
+	0xfa, 0x38, 0x04, 0x9a, 0xfb, 0x66, 0x00, 0x00, 0x00, 0x02, 0x00, 0xaa, 0x00, 0x00, 0x05, 0x16,
+	0x07, 0x8f, 0x00, 0x09, 0x00, 0x1b, 0x00, 0x88, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c,
+	0x4b, 0xb0, 0x0c, 0x50, 0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05,
+	0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x08, 0x03, 0x02, 0x02,
+	0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05,
+	0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d,
+	0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x04,
+	0x85, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x08,
+	0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x00, 0x00, 0x19, 0x17, 0x14,
+	0x13, 0x10, 0x0e, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x09, 0x07, 0x19, 0x2b,
+	0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x11, 0x23, 0x11, 0x01, 0x13, 0x33, 0x14, 0x17, 0x16, 0x33,
+	0x32, 0x37, 0x36, 0x35, 0x33, 0x06, 0x07, 0x06, 0x21, 0x20, 0x27, 0x26, 0xaa, 0xd2, 0x02, 0xc8,
+	0xd2, 0xd2, 0xfd, 0x38, 0x0f, 0xa1, 0x07, 0x20, 0x85, 0x85, 0x20, 0x07, 0xa1, 0x01, 0x09, 0x29,
+	0xfe, 0xe6, 0xfe, 0xe6, 0x29, 0x09, 0x05, 0xc8, 0xfb, 0x66, 0x04, 0x9a, 0xfa, 0x38, 0x04, 0x9a,
+	0xfb, 0x66, 0x07, 0x8f, 0x48, 0x22, 0x73, 0x73, 0x22, 0x48, 0x47, 0x1e, 0xdc, 0xcf, 0x2b, 0x00,
+	0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x04, 0x81, 0x05, 0xc8, 0x00, 0x31, 0x00, 0x5d, 0xb5, 0x1e,
+	0x01, 0x05, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x05,
+	0x04, 0x01, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x07,
+	0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x01, 0x00, 0x03, 0x59,
+	0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x02, 0x01, 0x00, 0x00, 0x04, 0x5f, 0x07, 0x06,
+	0x02, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x31, 0x00, 0x31, 0x30,
+	0x2f, 0x28, 0x27, 0x11, 0x49, 0x21, 0x11, 0x08, 0x07, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x33,
+	0x32, 0x3e, 0x02, 0x37, 0x37, 0x3e, 0x03, 0x33, 0x32, 0x32, 0x37, 0x15, 0x22, 0x0e, 0x02, 0x07,
+	0x07, 0x0e, 0x03, 0x07, 0x1e, 0x03, 0x17, 0x17, 0x16, 0x16, 0x17, 0x23, 0x2e, 0x05, 0x27, 0x23,
+	0x11, 0xa5, 0xd2, 0x1e, 0x29, 0x42, 0x3b, 0x36, 0x1c, 0x4e, 0x27, 0x40, 0x49, 0x5c, 0x43, 0x01,
+	0x0d, 0x0d, 0x2a, 0x3b, 0x2f, 0x27, 0x15, 0x41, 0x1a, 0x2f, 0x37, 0x44, 0x30, 0x49, 0x62, 0x4d,
+	0x41, 0x28, 0x36, 0x2a, 0x55, 0x2b, 0xdc, 0x21, 0x44, 0x49, 0x4e, 0x52, 0x58, 0x2e, 0x5a, 0x05,
+	0xc8, 0xfd, 0x85, 0x26, 0x42, 0x57, 0x32, 0x89, 0x44, 0x61, 0x3e, 0x1d, 0x01, 0x9a, 0x16, 0x2a,
+	0x3c, 0x26, 0x73, 0x2e, 0x4d, 0x42, 0x39, 0x1a, 0x13, 0x37, 0x52, 0x73, 0x4f, 0x6c, 0x54, 0x9e,
+	0x4d, 0x3a, 0x89, 0x8d, 0x88, 0x71, 0x53, 0x11, 0xfd, 0x53, 0x00, 0x00, 0x00, 0x01, 0x00, 0x13,
+	0x00, 0x00, 0x04, 0x9a, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x45, 0x40, 0x0a, 0x0b, 0x01, 0x00, 0x01,
+	0x01, 0x4c, 0x0a, 0x01, 0x00, 0x49, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01,
+	0x02, 0x5f, 0x03, 0x01, 0x02, 0x02, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x4e, 0x1b, 0x40,
+	0x0f, 0x03, 0x01, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x00, 0x1d, 0x00, 0x4e,
+	0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x11, 0x11, 0x04, 0x07, 0x18, 0x2b, 0x01,
+	0x11, 0x23, 0x11, 0x21, 0x15, 0x07, 0x07, 0x10, 0x02, 0x05, 0x35, 0x36, 0x36, 0x37, 0x36, 0x11,
+	0x35, 0x04, 0x9a, 0xd2, 0xfe, 0x64, 0x01, 0x08, 0xe3, 0xfe, 0xd3, 0x85, 0x86, 0x1f, 0x36, 0x05,
+	0xc8, 0xfa, 0x38, 0x05, 0x2e, 0x21, 0x82, 0xf8, 0xfe, 0x0e, 0xfe, 0x77, 0x18, 0x9a, 0x10, 0x6f,
+	0x7a, 0xce, 0x03, 0x09, 0x5e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x06, 0x05,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x50, 0xb7, 0x0b, 0x08, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01,
+	0x00, 0x00, 0x1a, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00,
+	0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02,
+	0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x12, 0x11,
+	0x12, 0x11, 0x06, 0x07, 0x1a, 0x2b, 0x33, 0x11, 0x21, 0x01, 0x01, 0x21, 0x11, 0x23, 0x11, 0x01,
+	0x23, 0x01, 0x11, 0xa5, 0x01, 0x23, 0x01, 0x97, 0x01, 0xa2, 0x01, 0x04, 0xc4, 0xfe, 0x6c, 0xcb,
+	0xfe, 0x78, 0x05, 0xc8, 0xfb, 0x87, 0x04, 0x79, 0xfa, 0x38, 0x04, 0xb3, 0xfb, 0xb0, 0x04, 0x54,
+	0xfb, 0x49, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa5, 0x00, 0x00, 0x05, 0x22, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x48, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04,
+	0x67, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b,
+	0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f,
+	0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x11,
+	0x33, 0x11, 0x23, 0x11, 0x21, 0x11, 0xa5, 0xd2, 0x02, 0xda, 0xd1, 0xd1, 0xfd, 0x26, 0x05, 0xc8,
+	0xfd, 0x90, 0x02, 0x70, 0xfa, 0x38, 0x02, 0xbb, 0xfd, 0x45, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5d,
+	0xff, 0xdb, 0x05, 0xdd, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x4d, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x17, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x20, 0x00, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00,
+	0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x22,
+	0x00, 0x4e, 0x59, 0x40, 0x13, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x07, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10,
+	0x12, 0x03, 0x13, 0xfe, 0xc7, 0xfe, 0x83, 0x01, 0x7f, 0x01, 0x41, 0x01, 0x40, 0x01, 0x80, 0xfe,
+	0x80, 0xfe, 0xb9, 0xe9, 0xfd, 0xfd, 0xe2, 0xe3, 0xfc, 0xfb, 0x25, 0x01, 0xaa, 0x01, 0x5f, 0x01,
+	0x63, 0x01, 0xa6, 0xfe, 0x5a, 0xfe, 0xa0, 0xfe, 0x98, 0xfe, 0x5c, 0x9d, 0x01, 0x45, 0x01, 0x2a,
+	0x01, 0x23, 0x01, 0x46, 0xfe, 0xba, 0xfe, 0xda, 0xfe, 0xde, 0xfe, 0xb6, 0x00, 0x01, 0x00, 0xa5,
+	0x00, 0x00, 0x05, 0x1b, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x34, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x11, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x1b,
+	0x01, 0x4e, 0x1b, 0x40, 0x0f, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x67, 0x03, 0x01, 0x01,
+	0x01, 0x1d, 0x01, 0x4e, 0x59, 0xb6, 0x11, 0x11, 0x11, 0x10, 0x04, 0x07, 0x1a, 0x2b, 0x13, 0x21,
+	0x11, 0x23, 0x11, 0x21, 0x11, 0x23, 0xa5, 0x04, 0x76, 0xd1, 0xfd, 0x2d, 0xd2, 0x05, 0xc8, 0xfa,
+	0x38, 0x05, 0x2b, 0xfa, 0xd5, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0xa7, 0x00, 0x00, 0x04, 0xfe,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x4d, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00,
+	0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a,
+	0x4d, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x04, 0x03,
+	0x00, 0x04, 0x67, 0x00, 0x03, 0x00, 0x01, 0x02, 0x03, 0x01, 0x67, 0x05, 0x01, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00, 0x00, 0x13, 0x11, 0x0e, 0x0c, 0x00, 0x0b, 0x00, 0x0b, 0x25,
+	0x21, 0x06, 0x07, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x32, 0x16, 0x17, 0x16, 0x15, 0x10, 0x21, 0x21,
+	0x11, 0x11, 0x21, 0x20, 0x11, 0x34, 0x26, 0x23, 0x21, 0xa7, 0x02, 0x1c, 0xe4, 0xc7, 0x41, 0x4f,
+	0xfd, 0x87, 0xfe, 0xf4, 0x01, 0x03, 0x01, 0xa4, 0xad, 0xf2, 0xfe, 0xf8, 0x05, 0xc8, 0x34, 0x4d,
+	0x60, 0xad, 0xfd, 0xfe, 0xfd, 0xc8, 0x02, 0xd7, 0x01, 0x54, 0x99, 0x67, 0x00, 0x01, 0x00, 0x74,
+	0xff, 0xdb, 0x05, 0x48, 0x05, 0xed, 0x00, 0x15, 0x00, 0x4d, 0x40, 0x0f, 0x0a, 0x01, 0x02, 0x01,
+	0x15, 0x0b, 0x02, 0x03, 0x02, 0x00, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x15, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x1f, 0x4d, 0x00, 0x03, 0x03, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x20, 0x00, 0x4e, 0x1b, 0x40, 0x13, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01,
+	0x02, 0x69, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0xb6, 0x24,
+	0x23, 0x24, 0x21, 0x04, 0x07, 0x1a, 0x2b, 0x25, 0x06, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21,
+	0x32, 0x05, 0x15, 0x24, 0x23, 0x22, 0x00, 0x11, 0x10, 0x00, 0x21, 0x32, 0x37, 0x05, 0x48, 0xdb,
+	0xfe, 0xf2, 0xfe, 0x92, 0xfe, 0x83, 0x01, 0x84, 0x01, 0x6f, 0xd5, 0x01, 0x0a, 0xfe, 0xce, 0xb4,
+	0xff, 0xfe, 0xf4, 0x01, 0x1e, 0x01, 0x05, 0xdf, 0xf1, 0x4c, 0x71, 0x01, 0x8c, 0x01, 0x7c, 0x01,
+	0x7a, 0x01, 0x90, 0x41, 0xc5, 0x69, 0xfe, 0xc1, 0xfe, 0xd0, 0xfe, 0xdd, 0xfe, 0xc1, 0x81, 0x00,
+	0x00, 0x01, 0x00, 0x14, 0x00, 0x00, 0x04, 0xce, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x3c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x10, 0x00, 0x01, 0x02, 0x01, 0x00, 0x03,
+	0x01, 0x00, 0x67, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00,
+	0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x15,
+	0x21, 0x11, 0x02, 0x08, 0xfe, 0x0c, 0x04, 0xba, 0xfe, 0x0c, 0x05, 0x2b, 0x9d, 0x9d, 0xfa, 0xd5,
+	0x00, 0x01, 0x00, 0x2c, 0xff, 0xdb, 0x05, 0x02, 0x05, 0xc8, 0x00, 0x10, 0x00, 0x3d, 0xb5, 0x03,
+	0x01, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x01, 0x01, 0x00, 0x00,
+	0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x20, 0x02, 0x4e, 0x1b, 0x40, 0x11,
+	0x01, 0x01, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x03, 0x02, 0x62, 0x00, 0x02, 0x02, 0x22, 0x02,
+	0x4e, 0x59, 0xb6, 0x21, 0x23, 0x13, 0x11, 0x04, 0x07, 0x1a, 0x2b, 0x01, 0x01, 0x33, 0x01, 0x33,
+	0x01, 0x33, 0x01, 0x06, 0x06, 0x23, 0x23, 0x35, 0x33, 0x32, 0x36, 0x37, 0x02, 0x37, 0xfd, 0xf5,
+	0xea, 0x01, 0x94, 0x04, 0x01, 0x93, 0xc1, 0xfd, 0xc2, 0x6b, 0xec, 0xdd, 0x26, 0x29, 0x9e, 0x9f,
+	0x48, 0x01, 0xb3, 0x04, 0x15, 0xfc, 0xd9, 0x03, 0x27, 0xfb, 0x83, 0xd6, 0x9a, 0xad, 0x61, 0x8c,
+	0x00, 0x03, 0x00, 0x46, 0x00, 0x00, 0x05, 0xcf, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x18, 0x00, 0x1f,
+	0x00, 0x6a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x21, 0x03, 0x01, 0x01, 0x09, 0x01, 0x06, 0x07,
+	0x01, 0x06, 0x69, 0x08, 0x0b, 0x02, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07, 0x00, 0x69, 0x00, 0x02,
+	0x02, 0x1a, 0x4d, 0x0a, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x21, 0x03, 0x01, 0x01,
+	0x09, 0x01, 0x06, 0x07, 0x01, 0x06, 0x69, 0x08, 0x0b, 0x02, 0x07, 0x04, 0x01, 0x00, 0x05, 0x07,
+	0x00, 0x69, 0x00, 0x02, 0x02, 0x05, 0x5f, 0x0a, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x40,
+	0x1a, 0x12, 0x12, 0x00, 0x00, 0x1f, 0x1e, 0x1a, 0x19, 0x12, 0x18, 0x12, 0x18, 0x14, 0x13, 0x00,
+	0x11, 0x00, 0x11, 0x14, 0x11, 0x11, 0x14, 0x11, 0x0c, 0x07, 0x1b, 0x2b, 0x21, 0x35, 0x20, 0x00,
+	0x35, 0x34, 0x00, 0x21, 0x35, 0x33, 0x15, 0x20, 0x00, 0x15, 0x14, 0x00, 0x21, 0x15, 0x03, 0x11,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x02, 0xae, 0xfe, 0xe7,
+	0xfe, 0xb1, 0x01, 0x4f, 0x01, 0x19, 0xb9, 0x01, 0x19, 0x01, 0x4f, 0xfe, 0xb1, 0xfe, 0xe7, 0xb9,
+	0xbd, 0xc5, 0xc5, 0x01, 0x76, 0xbd, 0xc4, 0xc4, 0xbd, 0xde, 0x01, 0x1f, 0xe7, 0xe8, 0x01, 0x1e,
+	0xde, 0xde, 0xfe, 0xe2, 0xe8, 0xe7, 0xfe, 0xe1, 0xde, 0x01, 0x77, 0x02, 0xda, 0xbf, 0xae, 0xae,
+	0xbf, 0xbf, 0xae, 0xae, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x1c, 0x00, 0x00, 0x05, 0x3a,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09, 0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x03,
+	0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x04,
+	0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x12, 0x12, 0x12, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01,
+	0x23, 0x01, 0x01, 0x1c, 0x02, 0x21, 0xfd, 0xf7, 0xf8, 0x01, 0x91, 0x01, 0xab, 0xc7, 0xfd, 0xef,
+	0x02, 0x1c, 0xf8, 0xfe, 0x5c, 0xfe, 0x44, 0x02, 0xdf, 0x02, 0xe9, 0xfd, 0xc1, 0x02, 0x3f, 0xfd,
+	0x3a, 0xfc, 0xfe, 0x02, 0x56, 0xfd, 0xaa, 0x00, 0x00, 0x01, 0x00, 0xa5, 0xfe, 0x75, 0x05, 0x9b,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x58, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x02, 0x01, 0x00,
+	0x00, 0x1a, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x03,
+	0x01, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x40, 0x1e, 0x02, 0x01,
+	0x00, 0x01, 0x00, 0x85, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11,
+	0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x23, 0x11, 0xa5, 0xd2, 0x02, 0xd3, 0xd1, 0x80,
+	0xc3, 0x05, 0xc8, 0xfa, 0xd4, 0x05, 0x2c, 0xfa, 0xd4, 0xfd, 0xd9, 0x01, 0x8b, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x5a, 0x00, 0x00, 0x04, 0xab, 0x05, 0xc8, 0x00, 0x11, 0x00, 0x51, 0x40, 0x0a,
+	0x0e, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00, 0x69, 0x03, 0x01, 0x01, 0x01, 0x1a, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00,
+	0x69, 0x03, 0x01, 0x01, 0x01, 0x04, 0x5f, 0x05, 0x01, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x12, 0x23, 0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b, 0x21,
+	0x11, 0x06, 0x23, 0x22, 0x26, 0x35, 0x11, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33,
+	0x11, 0x03, 0xd9, 0xbc, 0xe4, 0xf6, 0xe9, 0xd2, 0x94, 0xad, 0xc2, 0xaa, 0xd2, 0x02, 0x54, 0x5a,
+	0xeb, 0xf9, 0x01, 0xea, 0xfe, 0x1c, 0xb2, 0x8c, 0x59, 0x02, 0xc9, 0xfa, 0x38, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xaa, 0x00, 0x00, 0x06, 0xab, 0x05, 0xc8, 0x00, 0x0b, 0x00, 0x3d, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x13, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1a, 0x4d, 0x05, 0x01, 0x01, 0x01,
+	0x03, 0x60, 0x00, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x13, 0x04, 0x02, 0x02, 0x00, 0x01,
+	0x00, 0x85, 0x05, 0x01, 0x01, 0x01, 0x03, 0x60, 0x00, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40,
+	0x09, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x11,
+	0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x03, 0x44, 0xcd, 0x01, 0xcd, 0xcd, 0xf9, 0xff, 0xcd,
+	0x01, 0xcd, 0x05, 0xc8, 0xfa, 0xd5, 0x05, 0x2b, 0xfa, 0x38, 0x05, 0xc8, 0xfa, 0xd5, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xaa, 0xfe, 0x75, 0x07, 0x2f, 0x05, 0xc8, 0x00, 0x0f, 0x00, 0x59, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x20, 0x06, 0x02, 0x02, 0x00, 0x00, 0x1a, 0x4d, 0x07, 0x03, 0x02, 0x01,
+	0x01, 0x05, 0x60, 0x00, 0x05, 0x05, 0x1b, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01, 0x04, 0x60, 0x00,
+	0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x40, 0x20, 0x06, 0x02, 0x02, 0x00, 0x01, 0x00, 0x85, 0x07,
+	0x03, 0x02, 0x01, 0x01, 0x05, 0x60, 0x00, 0x05, 0x05, 0x1d, 0x4d, 0x07, 0x03, 0x02, 0x01, 0x01,
+	0x04, 0x60, 0x00, 0x04, 0x04, 0x1e, 0x04, 0x4e, 0x59, 0x40, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x10, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11,
+	0x23, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x03, 0x42, 0xcd, 0x01, 0xcb, 0xcd, 0x88, 0xc3, 0xfa,
+	0x3e, 0xcd, 0x01, 0xcb, 0x05, 0xc8, 0xfa, 0xd5, 0x05, 0x2b, 0xfa, 0xd4, 0xfd, 0xd9, 0x01, 0x8b,
+	0x05, 0xc8, 0xfa, 0xd5, 0x00, 0x02, 0x00, 0x1e, 0x00, 0x00, 0x06, 0x0f, 0x05, 0xc8, 0x00, 0x10,
+	0x00, 0x1d, 0x00, 0x58, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00, 0x02, 0x00, 0x05, 0x04,
+	0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1a, 0x4d, 0x00, 0x04, 0x04,
+	0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0x01, 0x00, 0x67, 0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x04, 0x04, 0x03,
+	0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x1d, 0x1b, 0x13,
+	0x11, 0x00, 0x10, 0x00, 0x0f, 0x21, 0x11, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35,
+	0x21, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x27, 0x21, 0x32, 0x3e, 0x02,
+	0x35, 0x34, 0x2e, 0x02, 0x23, 0x21, 0x01, 0xd1, 0xfe, 0x4d, 0x02, 0x85, 0xf4, 0x8b, 0xe8, 0xa8,
+	0x5d, 0x62, 0xab, 0xe7, 0x84, 0xf4, 0x01, 0x04, 0x6a, 0x9a, 0x63, 0x2f, 0x2e, 0x63, 0x99, 0x6c,
+	0xfe, 0xfc, 0x05, 0x2b, 0x9d, 0xfd, 0x85, 0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x9a,
+	0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x00, 0x03, 0x00, 0xa5, 0x00, 0x00, 0x06, 0x70,
+	0x05, 0xc8, 0x00, 0x03, 0x00, 0x12, 0x00, 0x1f, 0x00, 0x66, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1c, 0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x67, 0x02, 0x01, 0x00, 0x00, 0x1a, 0x4d, 0x00,
+	0x05, 0x05, 0x01, 0x60, 0x08, 0x04, 0x07, 0x03, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x24,
+	0x00, 0x03, 0x00, 0x06, 0x05, 0x03, 0x06, 0x67, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x04,
+	0x07, 0x03, 0x01, 0x01, 0x1d, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x60, 0x08, 0x04, 0x07, 0x03, 0x01,
+	0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x18, 0x04, 0x04, 0x00, 0x00, 0x1f, 0x1d, 0x15, 0x13, 0x04,
+	0x12, 0x04, 0x11, 0x09, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x07, 0x17, 0x2b,
+	0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02,
+	0x23, 0x27, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x05, 0x9e, 0xd2, 0xfa,
+	0x35, 0xd2, 0xbf, 0x8b, 0xe8, 0xa8, 0x5d, 0x62, 0xab, 0xe7, 0x84, 0xbf, 0xcf, 0x6a, 0x9a, 0x63,
+	0x2f, 0x2e, 0x63, 0x99, 0x6c, 0xcf, 0x05, 0xc8, 0xfa, 0x38, 0x05, 0xc8, 0xfd, 0x85, 0x28, 0x61,
+	0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x9a, 0x1c, 0x40, 0x66, 0x4a, 0x48, 0x66, 0x41, 0x1e, 0x00,
+	0x00, 0x02, 0x00, 0xa6, 0x00, 0x00, 0x04, 0xe4, 0x05, 0xc8, 0x00, 0x0e, 0x00, 0x1b, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x1a, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x19, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67,
+	0x00, 0x03, 0x03, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x1b, 0x19, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x11, 0x06, 0x07, 0x18, 0x2b, 0x33,
+	0x11, 0x33, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x27, 0x21, 0x32, 0x3e,
+	0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x21, 0xa6, 0xd2, 0xf4, 0x8b, 0xe8, 0xa8, 0x5d, 0x62, 0xab,
+	0xe7, 0x84, 0xf5, 0x01, 0x05, 0x6a, 0x9a, 0x63, 0x2f, 0x2e, 0x63, 0x99, 0x6c, 0xfe, 0xfb, 0x05,
+	0xc8, 0xfd, 0x85, 0x28, 0x61, 0xa3, 0x7b, 0x81, 0xa4, 0x5e, 0x23, 0x9a, 0x1c, 0x40, 0x66, 0x4a,
+	0x48, 0x66, 0x41, 0x1e, 0x00, 0x01, 0x00, 0xb4, 0xff, 0xdb, 0x05, 0x63, 0x05, 0xed, 0x00, 0x18,
+	0x00, 0x63, 0x40, 0x12, 0x0f, 0x01, 0x03, 0x04, 0x0e, 0x01, 0x02, 0x03, 0x01, 0x01, 0x00, 0x01,
+	0x00, 0x01, 0x05, 0x00, 0x04, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x00, 0x02, 0x00,
+	0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x1f, 0x4d, 0x00,
+	0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x20, 0x05, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x04, 0x00,
+	0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x00, 0x00,
+	0x05, 0x61, 0x00, 0x05, 0x05, 0x22, 0x05, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x23, 0x22, 0x11, 0x12,
+	0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x00, 0x35, 0x21, 0x35, 0x21, 0x26,
+	0x26, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0xb4, 0xf0,
+	0xd3, 0xea, 0x01, 0x23, 0xfd, 0x24, 0x02, 0xd5, 0x1c, 0xff, 0xe3, 0xcc, 0xf1, 0xfd, 0xce, 0x01,
+	0x58, 0x01, 0x7e, 0xfe, 0x88, 0xfe, 0xa6, 0xfe, 0xfe, 0x4c, 0xb4, 0x81, 0x01, 0x3c, 0xfe, 0x9a,
+	0xfd, 0xfd, 0x5e, 0xc0, 0x3e, 0xfe, 0x67, 0xfe, 0x8f, 0xfe, 0x8c, 0xfe, 0x6c, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0xa6, 0xff, 0xdb, 0x07, 0xb8, 0x05, 0xed, 0x00, 0x12, 0x00, 0x1e, 0x00, 0x74,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00, 0x01, 0x00, 0x04, 0x06, 0x01, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x1a, 0x4d, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x1f, 0x4d, 0x08, 0x01,
+	0x05, 0x05, 0x1b, 0x4d, 0x09, 0x01, 0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x20, 0x03, 0x4e,
+	0x1b, 0x40, 0x27, 0x00, 0x02, 0x00, 0x07, 0x01, 0x02, 0x07, 0x69, 0x00, 0x01, 0x00, 0x04, 0x06,
+	0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x1d, 0x4d, 0x09, 0x01,
+	0x06, 0x06, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x03, 0x4e, 0x59, 0x40, 0x16, 0x14, 0x13, 0x00,
+	0x00, 0x1a, 0x18, 0x13, 0x1e, 0x14, 0x1e, 0x00, 0x12, 0x00, 0x12, 0x12, 0x24, 0x22, 0x11, 0x11,
+	0x0a, 0x07, 0x1b, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x12, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x21, 0x20, 0x00, 0x03, 0x21, 0x11, 0x25, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02,
+	0x11, 0x10, 0x12, 0xa6, 0xd2, 0x01, 0x71, 0x17, 0x01, 0x41, 0x01, 0x0e, 0x01, 0x1e, 0x01, 0x4b,
+	0xfe, 0xb5, 0xfe, 0xe2, 0xfe, 0xf3, 0xfe, 0xb9, 0x12, 0xfe, 0x8f, 0x03, 0xd2, 0xbe, 0xd2, 0xd2,
+	0xb9, 0xb9, 0xd1, 0xd0, 0x05, 0xc8, 0xfd, 0x6b, 0x01, 0x4d, 0x01, 0x6d, 0xfe, 0x5f, 0xfe, 0x98,
+	0xfe, 0x98, 0xfe, 0x5f, 0x01, 0x75, 0x01, 0x46, 0xfd, 0x6a, 0x75, 0x01, 0x49, 0x01, 0x29, 0x01,
+	0x22, 0x01, 0x4a, 0xfe, 0xb5, 0xfe, 0xdc, 0xfe, 0xdf, 0xfe, 0xb2, 0x00, 0x00, 0x02, 0x00, 0x63,
+	0x00, 0x00, 0x05, 0x21, 0x05, 0xc8, 0x00, 0x18, 0x00, 0x21, 0x00, 0x4e, 0xb5, 0x0e, 0x01, 0x00,
+	0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05,
+	0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x02, 0x00, 0x04, 0x05, 0x02, 0x04, 0x67, 0x00, 0x05,
+	0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x09,
+	0x24, 0x21, 0x11, 0x2d, 0x15, 0x10, 0x06, 0x07, 0x1c, 0x2b, 0x01, 0x21, 0x06, 0x03, 0x06, 0x07,
+	0x07, 0x21, 0x36, 0x3f, 0x03, 0x36, 0x37, 0x26, 0x26, 0x35, 0x34, 0x37, 0x36, 0x21, 0x21, 0x11,
+	0x23, 0x11, 0x21, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x33, 0x04, 0x4f, 0xfe, 0xe4, 0x97, 0xc6,
+	0x1b, 0x2e, 0x1a, 0xfe, 0xf0, 0x55, 0x50, 0x2b, 0x1b, 0x3b, 0x73, 0x7b, 0x9b, 0xcd, 0x8f, 0x6c,
+	0x01, 0x27, 0x01, 0xf0, 0xd2, 0xfe, 0xe4, 0xa3, 0xa3, 0xc7, 0xbe, 0xdd, 0x02, 0x75, 0x8d, 0xfe,
+	0xba, 0x2d, 0x4b, 0x2a, 0x63, 0x7e, 0x43, 0x29, 0x5a, 0xaf, 0x46, 0x1f, 0xe0, 0x93, 0xc1, 0x7c,
+	0x5d, 0xfa, 0x38, 0x05, 0x2e, 0x83, 0x82, 0x8d, 0x8d, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x5f,
+	0xff, 0xe7, 0x04, 0x4a, 0x04, 0x56, 0x00, 0x1c, 0x00, 0x24, 0x00, 0x90, 0x4b, 0xb0, 0x15, 0x50,
+	0x58, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02, 0x1d, 0x01, 0x04, 0x06, 0x19,
+	0x01, 0x00, 0x04, 0x04, 0x4c, 0x1b, 0x40, 0x12, 0x0e, 0x01, 0x02, 0x03, 0x0d, 0x01, 0x01, 0x02,
+	0x1d, 0x01, 0x07, 0x06, 0x19, 0x01, 0x00, 0x04, 0x04, 0x4c, 0x59, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x1f, 0x00, 0x01, 0x00, 0x06, 0x04, 0x01, 0x06, 0x69, 0x00, 0x02, 0x02, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x21, 0x4d, 0x07, 0x01, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x22, 0x00,
+	0x4e, 0x1b, 0x40, 0x29, 0x00, 0x01, 0x00, 0x06, 0x07, 0x01, 0x06, 0x69, 0x00, 0x02, 0x02, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x21, 0x4d, 0x00, 0x07, 0x07, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x22,
+	0x4d, 0x00, 0x04, 0x04, 0x00, 0x61, 0x05, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x59, 0x40, 0x0b,
+	0x22, 0x22, 0x24, 0x14, 0x23, 0x22, 0x23, 0x21, 0x08, 0x07, 0x1e, 0x2b, 0x25, 0x06, 0x23, 0x22,
+	0x26, 0x35, 0x10, 0x21, 0x33, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15,
+	0x11, 0x14, 0x33, 0x32, 0x37, 0x17, 0x06, 0x23, 0x22, 0x03, 0x11, 0x27, 0x20, 0x15, 0x14, 0x33,
+	0x32, 0x03, 0x06, 0xb2, 0xb4, 0x8f, 0xb2, 0x02, 0x5c, 0x2e, 0xcf, 0xa9, 0xb4, 0xc7, 0xb8, 0xc2,
+	0xb0, 0x68, 0x0d, 0x19, 0x0e, 0x44, 0x51, 0x89, 0x43, 0x41, 0xfe, 0x83, 0xb7, 0x81, 0x8a, 0xa3,
+	0xa6, 0x85, 0x01, 0x70, 0x83, 0xbd, 0x60, 0xa3, 0x51, 0xa1, 0xb0, 0xfe, 0x14, 0xa9, 0x04, 0x6d,
+	0x20, 0x01, 0x0e, 0x01, 0x19, 0x02, 0xdc, 0xac, 0x00, 0x02, 0x00, 0x57, 0xff, 0xe7, 0x04, 0x3e,
+	0x06, 0x60, 0x00, 0x17, 0x00, 0x23, 0x00, 0x37, 0x40, 0x34, 0x12, 0x01, 0x03, 0x02, 0x18, 0x01,
+	0x04, 0x05, 0x02, 0x4c, 0x11, 0x01, 0x02, 0x4a, 0x00, 0x02, 0x00, 0x03, 0x00, 0x02, 0x03, 0x69,
+	0x00, 0x05, 0x05, 0x00, 0x61, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x04, 0x04, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x22, 0x01, 0x4e, 0x24, 0x25, 0x33, 0x34, 0x24, 0x21, 0x06, 0x07, 0x1c, 0x2b, 0x01,
+	0x36, 0x33, 0x32, 0x12, 0x15, 0x10, 0x00, 0x23, 0x22, 0x02, 0x11, 0x10, 0x00, 0x21, 0x33, 0x32,
+	0x37, 0x15, 0x06, 0x23, 0x23, 0x22, 0x06, 0x03, 0x07, 0x10, 0x12, 0x33, 0x32, 0x36, 0x35, 0x34,
+	0x26, 0x23, 0x22, 0x01, 0x1c, 0x98, 0xed, 0xba, 0xe3, 0xfe, 0xe8, 0xe4, 0xf2, 0xf9, 0x01, 0x15,
+	0x01, 0x2a, 0x2a, 0x93, 0x75, 0x5d, 0x94, 0x18, 0xd2, 0xb9, 0x16, 0x01, 0x9c, 0x94, 0x84, 0x9b,
+	0x81, 0x80, 0xad, 0x03, 0x4f, 0xef, 0xfe, 0xda, 0xf0, 0xfe, 0xfd, 0xfe, 0xc2, 0x01, 0x6d, 0x01,
+	0x6d, 0x01, 0xcb, 0x01, 0x9f, 0x35, 0x9f, 0x2a, 0xfd, 0xfe, 0x2c, 0x23, 0xfe, 0xec, 0xfe, 0xec,
+	0xe2, 0xbf, 0xb7, 0xb8, 0x00, 0x03, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xcf, 0x04, 0x3e, 0x00, 0x0e,
+	0x00, 0x17, 0x00, 0x1f, 0x00, 0x63, 0xb5, 0x08, 0x01, 0x03, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x1e, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01, 0x1b,
+	0x01, 0x4e, 0x1b, 0x40, 0x1e, 0x00, 0x04, 0x00, 0x03, 0x02, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x06, 0x01, 0x01, 0x01,
+	0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x1f, 0x1d, 0x1a, 0x18, 0x17, 0x15, 0x11, 0x0f,
+	0x00, 0x0e, 0x00, 0x0d, 0x21, 0x07, 0x07, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x32, 0x16, 0x15, 0x14,
+	0x06, 0x07, 0x16, 0x16, 0x15, 0x14, 0x06, 0x23, 0x25, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x23, 0x35, 0x33, 0x32, 0x35, 0x34, 0x26, 0x23, 0x23, 0x9a, 0x01, 0x9d, 0xb6, 0xb6, 0x72, 0x68,
+	0x7e, 0x88, 0xc4, 0xad, 0xff, 0x00, 0x75, 0xa7, 0x7c, 0x9f, 0x79, 0x80, 0x86, 0xeb, 0x75, 0x6a,
+	0x92, 0x04, 0x3e, 0x76, 0x76, 0x64, 0x8d, 0x2a, 0x29, 0x92, 0x6a, 0x81, 0x91, 0x94, 0x3b, 0x55,
+	0x5b, 0x71, 0x82, 0xb7, 0x40, 0x41, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8c, 0x00, 0x00, 0x02, 0xeb,
+	0x04, 0x3e, 0x00, 0x05, 0x00, 0x3b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x11, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40,
+	0x11, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x0b, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x07, 0x18,
+	0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x8c, 0x02, 0x5f, 0xfe, 0x66, 0x04, 0x3e, 0xad, 0xfc,
+	0x6f, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x28, 0xfe, 0xa7, 0x04, 0x79, 0x04, 0x3e, 0x00, 0x0e,
+	0x00, 0x15, 0x00, 0x8a, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x25, 0x00, 0x07, 0x07, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x1c, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x04, 0x5f, 0x00, 0x04, 0x04, 0x1b,
+	0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x03, 0x5f, 0x08, 0x05, 0x02, 0x03, 0x03, 0x1e, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x08, 0x05, 0x02, 0x03, 0x00, 0x03, 0x53, 0x00,
+	0x07, 0x07, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x04, 0x5f,
+	0x00, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x1e, 0x08, 0x05, 0x02, 0x03, 0x00, 0x03, 0x53,
+	0x00, 0x07, 0x07, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x06, 0x02, 0x02, 0x00, 0x00, 0x04,
+	0x5f, 0x00, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x12, 0x00, 0x00, 0x12, 0x11, 0x10,
+	0x0f, 0x00, 0x0e, 0x00, 0x0e, 0x11, 0x11, 0x11, 0x14, 0x11, 0x09, 0x07, 0x1b, 0x2b, 0x13, 0x11,
+	0x33, 0x36, 0x12, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x11, 0x13, 0x21, 0x11,
+	0x21, 0x15, 0x14, 0x02, 0x28, 0x59, 0x5b, 0x6f, 0x02, 0x87, 0xa7, 0xb4, 0xfd, 0x17, 0x5b, 0x01,
+	0xe9, 0xfe, 0xd7, 0x60, 0xfe, 0xa7, 0x01, 0xf6, 0xa5, 0x01, 0xa5, 0x01, 0x01, 0x56, 0xfc, 0x5f,
+	0xfe, 0x0a, 0x01, 0x59, 0xfe, 0xa7, 0x01, 0xf6, 0x03, 0x0d, 0x13, 0xcc, 0xfe, 0x83, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb, 0x04, 0x56, 0x00, 0x04, 0x00, 0x15, 0x00, 0x3d,
+	0x40, 0x3a, 0x05, 0x01, 0x05, 0x04, 0x06, 0x01, 0x02, 0x05, 0x02, 0x4c, 0x06, 0x01, 0x01, 0x00,
+	0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x21, 0x4d, 0x00,
+	0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x00, 0x00, 0x15, 0x13, 0x12, 0x11,
+	0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x07, 0x07, 0x17, 0x2b, 0x01, 0x10, 0x23,
+	0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07, 0x21,
+	0x12, 0x21, 0x32, 0x03, 0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd, 0xc2, 0xb7, 0xfb, 0xfe, 0xd5, 0x01,
+	0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c, 0x02, 0x94, 0x01, 0x2f, 0xfe,
+	0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe,
+	0x7d, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x05, 0x00, 0x00, 0x05, 0x55, 0x04, 0x3e, 0x00, 0x4b,
+	0x00, 0x68, 0x40, 0x0b, 0x1a, 0x01, 0x03, 0x02, 0x38, 0x13, 0x02, 0x00, 0x03, 0x02, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x05, 0x01, 0x03, 0x08, 0x01, 0x00, 0x01, 0x03, 0x00, 0x67,
+	0x06, 0x04, 0x02, 0x02, 0x02, 0x1c, 0x4d, 0x0a, 0x09, 0x07, 0x03, 0x01, 0x01, 0x1b, 0x01, 0x4e,
+	0x1b, 0x40, 0x1a, 0x05, 0x01, 0x03, 0x08, 0x01, 0x00, 0x01, 0x03, 0x00, 0x67, 0x06, 0x04, 0x02,
+	0x02, 0x02, 0x1c, 0x4d, 0x0a, 0x09, 0x07, 0x03, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x19,
+	0x00, 0x00, 0x00, 0x4b, 0x00, 0x4b, 0x4a, 0x49, 0x41, 0x40, 0x2f, 0x2e, 0x28, 0x27, 0x26, 0x25,
+	0x24, 0x23, 0x1d, 0x1b, 0x18, 0x11, 0x0b, 0x07, 0x18, 0x2b, 0x21, 0x11, 0x23, 0x06, 0x07, 0x06,
+	0x07, 0x06, 0x0f, 0x02, 0x23, 0x36, 0x37, 0x36, 0x3f, 0x02, 0x36, 0x37, 0x26, 0x27, 0x27, 0x26,
+	0x27, 0x26, 0x27, 0x35, 0x33, 0x32, 0x16, 0x17, 0x17, 0x16, 0x16, 0x17, 0x17, 0x11, 0x33, 0x11,
+	0x37, 0x36, 0x36, 0x37, 0x37, 0x36, 0x36, 0x33, 0x33, 0x15, 0x06, 0x07, 0x06, 0x07, 0x07, 0x06,
+	0x07, 0x16, 0x1f, 0x02, 0x16, 0x17, 0x16, 0x17, 0x23, 0x27, 0x27, 0x26, 0x27, 0x26, 0x27, 0x26,
+	0x27, 0x23, 0x11, 0x02, 0x4a, 0x4b, 0x2f, 0x31, 0x03, 0x47, 0x19, 0x27, 0x2d, 0x0f, 0xd4, 0x2d,
+	0x25, 0x26, 0x06, 0x35, 0x28, 0x3b, 0x70, 0x52, 0x41, 0x14, 0x07, 0x10, 0x2f, 0x49, 0x12, 0x5b,
+	0x65, 0x3d, 0x17, 0x31, 0x48, 0x2f, 0x27, 0xc6, 0x27, 0x2f, 0x48, 0x30, 0x18, 0x3d, 0x65, 0x5c,
+	0x11, 0x49, 0x2f, 0x10, 0x07, 0x14, 0x41, 0x53, 0x71, 0x3b, 0x27, 0x36, 0x06, 0x26, 0x25, 0x2d,
+	0xd5, 0x0e, 0x2e, 0x26, 0x19, 0x47, 0x03, 0x31, 0x2f, 0x4b, 0x01, 0xf1, 0x2d, 0x5e, 0x06, 0x78,
+	0x2b, 0x4a, 0x58, 0x1b, 0x48, 0x48, 0x4c, 0x0b, 0x62, 0x4c, 0x6b, 0x26, 0x25, 0x8a, 0x2a, 0x0f,
+	0x26, 0x6e, 0x08, 0x94, 0x5c, 0x88, 0x35, 0x6d, 0x4e, 0x0a, 0x01, 0x01, 0xdf, 0xfe, 0x21, 0x01,
+	0x0a, 0x4e, 0x6d, 0x35, 0x88, 0x5c, 0x94, 0x08, 0x6e, 0x26, 0x0f, 0x2a, 0x8a, 0x25, 0x26, 0x6b,
+	0x4c, 0x62, 0x0b, 0x4c, 0x48, 0x48, 0x1b, 0x58, 0x4a, 0x2b, 0x78, 0x06, 0x5e, 0x2d, 0xfe, 0x0f,
+	0x00, 0x01, 0x00, 0x56, 0xff, 0xe7, 0x03, 0x54, 0x04, 0x56, 0x00, 0x24, 0x00, 0x3f, 0x40, 0x3c,
+	0x15, 0x01, 0x03, 0x04, 0x14, 0x01, 0x02, 0x03, 0x1d, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x00, 0x01, 0x05, 0x00, 0x05, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03,
+	0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x21, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x22, 0x05, 0x4e, 0x2a, 0x23, 0x24, 0x21, 0x24, 0x22, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x35, 0x16,
+	0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x35, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x07, 0x35, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x07, 0x16, 0x16, 0x15, 0x14, 0x04,
+	0x23, 0x22, 0x56, 0x9c, 0x91, 0x73, 0x8f, 0x9e, 0x9e, 0x2e, 0x35, 0x8c, 0x8d, 0x7e, 0x7e, 0x6a,
+	0x9a, 0x8f, 0x99, 0xc4, 0xdd, 0x64, 0x63, 0x74, 0x75, 0xfe, 0xf3, 0xcb, 0x70, 0x16, 0xa6, 0x3c,
+	0x69, 0x54, 0x63, 0x63, 0x82, 0x4f, 0x4f, 0x4e, 0x4f, 0x37, 0x9f, 0x2e, 0x8c, 0x7d, 0x4f, 0x83,
+	0x35, 0x24, 0x85, 0x61, 0x93, 0xc2, 0x00, 0x00, 0x00, 0x01, 0x00, 0x91, 0x00, 0x00, 0x03, 0xe7,
+	0x04, 0x3e, 0x00, 0x09, 0x00, 0x3e, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02,
+	0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02,
+	0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11,
+	0x05, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x33, 0x11, 0x23, 0x11, 0x01, 0x91, 0xb9,
+	0x01, 0xd8, 0xc5, 0xb9, 0xfe, 0x29, 0x04, 0x3e, 0xfc, 0xca, 0x03, 0x36, 0xfb, 0xc2, 0x03, 0x36,
+	0xfc, 0xca, 0x00, 0x00, 0x00, 0x02, 0x00, 0x91, 0x00, 0x00, 0x03, 0xe7, 0x06, 0x44, 0x00, 0x09,
+	0x00, 0x1b, 0x00, 0x88, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x0c, 0x50,
+	0x58, 0x40, 0x1d, 0x06, 0x01, 0x04, 0x05, 0x05, 0x04, 0x70, 0x00, 0x05, 0x00, 0x07, 0x00, 0x05,
+	0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05,
+	0x00, 0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02,
+	0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00,
+	0x07, 0x00, 0x05, 0x07, 0x6a, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x08, 0x03, 0x02, 0x02, 0x02,
+	0x1d, 0x02, 0x4e, 0x59, 0x59, 0x40, 0x14, 0x00, 0x00, 0x19, 0x17, 0x14, 0x13, 0x10, 0x0e, 0x0b,
+	0x0a, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x09, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x33, 0x11,
+	0x01, 0x33, 0x11, 0x23, 0x11, 0x01, 0x03, 0x33, 0x14, 0x17, 0x16, 0x33, 0x32, 0x37, 0x36, 0x35,
+	0x33, 0x06, 0x07, 0x06, 0x21, 0x20, 0x27, 0x26, 0x91, 0xb9, 0x01, 0xd8, 0xc5, 0xb9, 0xfe, 0x29,
+	0x6b, 0xa1, 0x07, 0x20, 0x85, 0x85, 0x20, 0x07, 0xa1, 0x01, 0x09, 0x29, 0xfe, 0xe6, 0xfe, 0xe6,
+	0x29, 0x09, 0x04, 0x3e, 0xfc, 0xca, 0x03, 0x36, 0xfb, 0xc2, 0x03, 0x36, 0xfc, 0xca, 0x06, 0x44,
+	0x48, 0x22, 0x73, 0x73, 0x22, 0x48, 0x47, 0x1e, 0xdc, 0xcf, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x9a,
+	0x00, 0x00, 0x03, 0x80, 0x04, 0x3e, 0x00, 0x2e, 0x00, 0x5c, 0xb5, 0x1b, 0x01, 0x05, 0x01, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x67,
+	0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x07, 0x06, 0x02, 0x04, 0x04,
+	0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x07, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x04,
+	0x4e, 0x59, 0x40, 0x11, 0x00, 0x00, 0x00, 0x2e, 0x00, 0x2e, 0x2d, 0x2c, 0x27, 0x26, 0x21, 0x19,
+	0x11, 0x11, 0x08, 0x07, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x32, 0x3e, 0x02, 0x37, 0x37, 0x3e,
+	0x03, 0x33, 0x15, 0x23, 0x22, 0x0e, 0x02, 0x07, 0x07, 0x0e, 0x03, 0x07, 0x1e, 0x03, 0x17, 0x17,
+	0x1e, 0x03, 0x17, 0x23, 0x2e, 0x03, 0x27, 0x23, 0x11, 0x9a, 0xbb, 0x2f, 0x42, 0x2f, 0x21, 0x0f,
+	0x09, 0x18, 0x2f, 0x41, 0x5c, 0x45, 0x0b, 0x1d, 0x2a, 0x20, 0x19, 0x0c, 0x0c, 0x13, 0x2b, 0x33,
+	0x3b, 0x22, 0x25, 0x37, 0x2f, 0x2e, 0x1b, 0x18, 0x0d, 0x2b, 0x31, 0x31, 0x14, 0xd1, 0x1e, 0x4a,
+	0x4d, 0x48, 0x1c, 0x41, 0x04, 0x3e, 0xfe, 0x2e, 0x20, 0x36, 0x48, 0x29, 0x19, 0x41, 0x5c, 0x3a,
+	0x1b, 0x94, 0x11, 0x23, 0x32, 0x21, 0x20, 0x32, 0x45, 0x2d, 0x1a, 0x08, 0x0c, 0x37, 0x49, 0x58,
+	0x2d, 0x28, 0x16, 0x44, 0x4a, 0x47, 0x19, 0x34, 0x86, 0x88, 0x7e, 0x2c, 0xfe, 0x14, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x28, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x3e, 0x00, 0x12, 0x00, 0x45, 0xb5, 0x01,
+	0x01, 0x01, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00,
+	0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40,
+	0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01,
+	0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x12, 0x00, 0x12, 0x11, 0x11, 0x17, 0x05,
+	0x07, 0x19, 0x2b, 0x33, 0x35, 0x36, 0x36, 0x35, 0x37, 0x37, 0x35, 0x21, 0x11, 0x23, 0x11, 0x21,
+	0x15, 0x14, 0x07, 0x03, 0x02, 0x02, 0x28, 0x82, 0x7b, 0x08, 0x04, 0x02, 0xda, 0xc5, 0xfe, 0x9e,
+	0x04, 0x0d, 0x10, 0xd5, 0x94, 0x0a, 0xea, 0xf0, 0xdb, 0x80, 0x6b, 0xfb, 0xc2, 0x03, 0xa0, 0x12,
+	0x18, 0x45, 0xff, 0x00, 0xfe, 0xe7, 0xfe, 0xe8, 0x00, 0x01, 0x00, 0xa0, 0x00, 0x00, 0x04, 0xe0,
+	0x04, 0x3e, 0x00, 0x0e, 0x00, 0x50, 0xb7, 0x0d, 0x09, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b,
+	0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01,
+	0x00, 0x00, 0x1c, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00,
+	0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x80, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x04, 0x02,
+	0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00, 0x0e, 0x00, 0x0e, 0x13, 0x11,
+	0x13, 0x11, 0x06, 0x07, 0x1a, 0x2b, 0x33, 0x11, 0x33, 0x01, 0x33, 0x01, 0x33, 0x11, 0x23, 0x03,
+	0x37, 0x01, 0x23, 0x01, 0x03, 0xa0, 0xd7, 0x01, 0x50, 0x02, 0x01, 0x32, 0xe5, 0xc4, 0x02, 0x02,
+	0xfe, 0xf0, 0xbe, 0xfe, 0xee, 0x02, 0x04, 0x3e, 0xfc, 0xaf, 0x03, 0x51, 0xfb, 0xc2, 0x03, 0x39,
+	0x06, 0xfd, 0x0b, 0x02, 0xdb, 0xfc, 0xdb, 0x00, 0x00, 0x01, 0x00, 0x91, 0x00, 0x00, 0x03, 0xda,
+	0x04, 0x3e, 0x00, 0x0b, 0x00, 0x48, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00,
+	0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03,
+	0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x02, 0x01,
+	0x00, 0x00, 0x1c, 0x4d, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0e, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b, 0x2b, 0x33, 0x11,
+	0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x23, 0x11, 0x21, 0x11, 0x91, 0xc5, 0x01, 0xbf, 0xc5, 0xc5,
+	0xfe, 0x41, 0x04, 0x3e, 0xfe, 0x47, 0x01, 0xb9, 0xfb, 0xc2, 0x01, 0xf1, 0xfe, 0x0f, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x56, 0xff, 0xe7, 0x04, 0x1c, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x13, 0x00, 0x2d,
+	0x40, 0x2a, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x05, 0x01, 0x02, 0x02,
+	0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x0d, 0x0c, 0x01, 0x00, 0x11, 0x0f, 0x0c,
+	0x13, 0x0d, 0x13, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x07, 0x16, 0x2b, 0x05, 0x22, 0x00,
+	0x11, 0x10, 0x00, 0x33, 0x32, 0x00, 0x11, 0x10, 0x00, 0x27, 0x20, 0x11, 0x10, 0x21, 0x20, 0x11,
+	0x10, 0x02, 0x32, 0xdb, 0xfe, 0xff, 0x01, 0x03, 0xe0, 0xdf, 0x01, 0x04, 0xfe, 0xfc, 0xe3, 0x01,
+	0x12, 0xfe, 0xf2, 0xfe, 0xf2, 0x19, 0x01, 0x34, 0x01, 0x04, 0x01, 0x07, 0x01, 0x30, 0xfe, 0xd1,
+	0xfe, 0xfa, 0xfe, 0xf4, 0xfe, 0xd2, 0x94, 0x01, 0xa9, 0x01, 0x9e, 0xfe, 0x5d, 0xfe, 0x5c, 0x00,
+	0x00, 0x01, 0x00, 0x91, 0x00, 0x00, 0x03, 0xc4, 0x04, 0x3e, 0x00, 0x07, 0x00, 0x3e, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04,
+	0x03, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x12, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11,
+	0x23, 0x11, 0x21, 0x11, 0x91, 0x03, 0x33, 0xc5, 0xfe, 0x57, 0x04, 0x3e, 0xfb, 0xc2, 0x03, 0xa0,
+	0xfc, 0x60, 0x00, 0x00, 0x00, 0x02, 0x00, 0x96, 0xfe, 0x75, 0x04, 0x18, 0x04, 0x56, 0x00, 0x0e,
+	0x00, 0x18, 0x00, 0x5f, 0x40, 0x0c, 0x10, 0x0f, 0x04, 0x03, 0x04, 0x05, 0x0e, 0x01, 0x03, 0x04,
+	0x02, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01,
+	0x01, 0x01, 0x1c, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x4d, 0x00, 0x00,
+	0x00, 0x1e, 0x00, 0x4e, 0x1b, 0x40, 0x1f, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x05, 0x05, 0x02,
+	0x61, 0x00, 0x02, 0x02, 0x21, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x4d,
+	0x00, 0x00, 0x00, 0x1e, 0x00, 0x4e, 0x59, 0x40, 0x09, 0x23, 0x24, 0x24, 0x22, 0x11, 0x10, 0x06,
+	0x07, 0x1c, 0x2b, 0x01, 0x23, 0x11, 0x33, 0x15, 0x36, 0x33, 0x32, 0x12, 0x15, 0x10, 0x00, 0x23,
+	0x22, 0x27, 0x11, 0x11, 0x16, 0x33, 0x20, 0x11, 0x34, 0x26, 0x23, 0x22, 0x01, 0x5b, 0xc5, 0xc5,
+	0x76, 0xce, 0xaa, 0xcf, 0xfe, 0xf5, 0xf5, 0x5f, 0x5e, 0x88, 0x45, 0x01, 0x1b, 0x6f, 0x60, 0x81,
+	0xfe, 0x75, 0x05, 0xc9, 0xcc, 0xe4, 0xfe, 0xda, 0xf2, 0xfe, 0xe1, 0xfe, 0xc8, 0x19, 0x02, 0xbf,
+	0xfd, 0xd6, 0x1a, 0x01, 0xb1, 0xb1, 0xcd, 0x00, 0x00, 0x01, 0x00, 0x5f, 0xff, 0xe7, 0x03, 0xa7,
+	0x04, 0x56, 0x00, 0x14, 0x00, 0x2e, 0x40, 0x2b, 0x0a, 0x01, 0x02, 0x01, 0x14, 0x0b, 0x02, 0x03,
+	0x02, 0x00, 0x01, 0x00, 0x03, 0x03, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21,
+	0x4d, 0x00, 0x03, 0x03, 0x00, 0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x23, 0x23, 0x24, 0x21,
+	0x04, 0x07, 0x1a, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x00, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x15,
+	0x26, 0x23, 0x20, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x03, 0xa7, 0xac, 0xb0, 0xda, 0xfe, 0xee,
+	0x01, 0x17, 0xf8, 0x84, 0xa9, 0xa0, 0x64, 0xfe, 0xa1, 0xb6, 0xa0, 0x7c, 0x9d, 0x21, 0x3a, 0x01,
+	0x3b, 0xfb, 0x01, 0x0c, 0x01, 0x2d, 0x24, 0xa4, 0x31, 0xfe, 0x5e, 0xc2, 0xd5, 0x45, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x29, 0x00, 0x00, 0x03, 0x82, 0x04, 0x3e, 0x00, 0x07, 0x00, 0x3e, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c, 0x4d,
+	0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x12, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x01, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x21, 0x11, 0x21, 0x35,
+	0x21, 0x15, 0x21, 0x11, 0x01, 0x73, 0xfe, 0xb6, 0x03, 0x59, 0xfe, 0xb6, 0x03, 0xa0, 0x9e, 0x9e,
+	0xfc, 0x60, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0b, 0xfe, 0x75, 0x03, 0xf3, 0x04, 0x3e, 0x00, 0x0e,
+	0x00, 0x28, 0x40, 0x25, 0x08, 0x05, 0x02, 0x00, 0x01, 0x01, 0x4c, 0x02, 0x01, 0x01, 0x01, 0x1c,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x62, 0x04, 0x01, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x00, 0x00, 0x00,
+	0x0e, 0x00, 0x0d, 0x12, 0x13, 0x21, 0x05, 0x07, 0x19, 0x2b, 0x13, 0x35, 0x33, 0x32, 0x36, 0x37,
+	0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x02, 0x06, 0x23, 0x50, 0x1c, 0x9e, 0x64, 0x32, 0xfe, 0x6b,
+	0xd4, 0x01, 0x2d, 0x01, 0x2d, 0xba, 0xfe, 0x72, 0x6e, 0xa6, 0xea, 0xfe, 0x75, 0xad, 0x4f, 0x8f,
+	0x04, 0x3e, 0xfc, 0xdc, 0x03, 0x24, 0xfb, 0xdb, 0xfe, 0xed, 0x91, 0x00, 0x00, 0x03, 0x00, 0x56,
+	0xfe, 0x75, 0x06, 0x3f, 0x06, 0x2b, 0x00, 0x2d, 0x00, 0x3e, 0x00, 0x4f, 0x00, 0x77, 0x40, 0x13,
+	0x19, 0x16, 0x02, 0x06, 0x02, 0x4f, 0x3f, 0x3e, 0x2e, 0x04, 0x07, 0x06, 0x2d, 0x02, 0x02, 0x01,
+	0x07, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x23, 0x00, 0x03, 0x02, 0x03, 0x85, 0x09,
+	0x01, 0x06, 0x06, 0x02, 0x61, 0x04, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x08, 0x01, 0x07, 0x07, 0x01,
+	0x61, 0x05, 0x01, 0x01, 0x01, 0x1b, 0x4d, 0x00, 0x00, 0x00, 0x1e, 0x00, 0x4e, 0x1b, 0x40, 0x23,
+	0x00, 0x03, 0x02, 0x03, 0x85, 0x09, 0x01, 0x06, 0x06, 0x02, 0x61, 0x04, 0x01, 0x02, 0x02, 0x1c,
+	0x4d, 0x08, 0x01, 0x07, 0x07, 0x01, 0x61, 0x05, 0x01, 0x01, 0x01, 0x1d, 0x4d, 0x00, 0x00, 0x00,
+	0x1e, 0x00, 0x4e, 0x59, 0x40, 0x0e, 0x4d, 0x4b, 0x25, 0x28, 0x27, 0x28, 0x25, 0x15, 0x28, 0x25,
+	0x10, 0x0a, 0x07, 0x1f, 0x2b, 0x01, 0x23, 0x11, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34,
+	0x3e, 0x02, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x11, 0x33, 0x11, 0x3e, 0x03, 0x33, 0x32, 0x1e, 0x02,
+	0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x2e, 0x02, 0x27, 0x03, 0x26, 0x26, 0x23, 0x22, 0x0e, 0x02,
+	0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x36, 0x37, 0x33, 0x16, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35,
+	0x34, 0x2e, 0x02, 0x23, 0x22, 0x06, 0x07, 0x03, 0xaa, 0xbf, 0x12, 0x2e, 0x3a, 0x47, 0x2a, 0x75,
+	0xa2, 0x65, 0x2e, 0x31, 0x69, 0xa5, 0x75, 0x2a, 0x44, 0x36, 0x2b, 0x12, 0xbf, 0x12, 0x2e, 0x3a,
+	0x47, 0x2a, 0x75, 0xa2, 0x65, 0x2e, 0x31, 0x69, 0xa5, 0x75, 0x2a, 0x44, 0x36, 0x2b, 0x12, 0xbf,
+	0x2c, 0x60, 0x43, 0x48, 0x64, 0x3f, 0x1c, 0x19, 0x3b, 0x61, 0x48, 0x43, 0x69, 0x2d, 0xbf, 0x2c,
+	0x60, 0x43, 0x48, 0x64, 0x3f, 0x1c, 0x19, 0x3b, 0x61, 0x48, 0x43, 0x69, 0x2d, 0xfe, 0x75, 0x01,
+	0xfd, 0x18, 0x2d, 0x24, 0x15, 0x61, 0x9e, 0xc6, 0x66, 0x66, 0xc6, 0x9e, 0x61, 0x15, 0x24, 0x2d,
+	0x18, 0x02, 0x5f, 0xfd, 0xa1, 0x18, 0x2d, 0x24, 0x15, 0x61, 0x9e, 0xc6, 0x66, 0x66, 0xc6, 0x9e,
+	0x61, 0x15, 0x24, 0x2d, 0x18, 0x02, 0xd0, 0x2f, 0x3c, 0x49, 0x73, 0x8e, 0x44, 0x44, 0x8e, 0x73,
+	0x49, 0x3b, 0x30, 0x2f, 0x3c, 0x49, 0x73, 0x8e, 0x44, 0x44, 0x8e, 0x73, 0x49, 0x3b, 0x30, 0x00,
+	0x00, 0x01, 0x00, 0x1c, 0x00, 0x00, 0x03, 0xe1, 0x04, 0x3e, 0x00, 0x0b, 0x00, 0x41, 0x40, 0x09,
+	0x0a, 0x07, 0x04, 0x01, 0x04, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0e,
+	0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b, 0x40,
+	0x0e, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59,
+	0x40, 0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x12, 0x12, 0x12, 0x05, 0x07, 0x19, 0x2b, 0x33,
+	0x01, 0x01, 0x33, 0x01, 0x13, 0x33, 0x01, 0x01, 0x23, 0x01, 0x01, 0x1c, 0x01, 0x61, 0xfe, 0xab,
+	0xe4, 0x01, 0x10, 0xf4, 0xb6, 0xfe, 0xb4, 0x01, 0x67, 0xe3, 0xfe, 0xda, 0xfe, 0xfa, 0x02, 0x3e,
+	0x02, 0x00, 0xfe, 0x69, 0x01, 0x97, 0xfd, 0xdd, 0xfd, 0xe5, 0x01, 0xb4, 0xfe, 0x4c, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x91, 0xfe, 0xa7, 0x04, 0x95, 0x04, 0x3e, 0x00, 0x0b, 0x00, 0x73, 0x4b, 0xb0,
+	0x10, 0x50, 0x58, 0x40, 0x1e, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05,
+	0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x04, 0x60, 0x00, 0x04, 0x04,
+	0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x01, 0x04, 0x54,
+	0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05,
+	0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x04, 0x01, 0x04, 0x54, 0x02, 0x01, 0x00, 0x00, 0x1c,
+	0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e, 0x59, 0x59,
+	0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07, 0x1b,
+	0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x23, 0x11, 0x91, 0xc5, 0x01,
+	0xda, 0xc5, 0xa0, 0xb4, 0x04, 0x3e, 0xfc, 0x5f, 0x03, 0xa1, 0xfc, 0x5f, 0xfe, 0x0a, 0x01, 0x59,
+	0x00, 0x01, 0x00, 0x60, 0x00, 0x00, 0x03, 0x91, 0x04, 0x3e, 0x00, 0x11, 0x00, 0x51, 0x40, 0x0a,
+	0x0e, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00, 0x6a, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x05,
+	0x01, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x00, 0x00, 0x04, 0x02, 0x00,
+	0x6a, 0x03, 0x01, 0x01, 0x01, 0x1c, 0x4d, 0x05, 0x01, 0x04, 0x04, 0x1d, 0x04, 0x4e, 0x59, 0x40,
+	0x0d, 0x00, 0x00, 0x00, 0x11, 0x00, 0x11, 0x12, 0x23, 0x13, 0x22, 0x06, 0x07, 0x1a, 0x2b, 0x21,
+	0x11, 0x06, 0x23, 0x22, 0x26, 0x11, 0x35, 0x33, 0x11, 0x14, 0x16, 0x33, 0x32, 0x37, 0x11, 0x33,
+	0x11, 0x02, 0xcb, 0x75, 0x8e, 0xca, 0x9e, 0xc5, 0x56, 0x75, 0x83, 0x58, 0xc6, 0x01, 0xa9, 0x31,
+	0xcb, 0x01, 0x05, 0xf6, 0xfe, 0xe4, 0xa3, 0x77, 0x32, 0x02, 0x04, 0xfb, 0xc2, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xa0, 0x00, 0x00, 0x05, 0xcb, 0x04, 0x3e, 0x00, 0x0b, 0x00, 0x44, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1b, 0x05, 0x4e, 0x1b, 0x40, 0x14, 0x04, 0x02, 0x02, 0x00,
+	0x00, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01, 0x05, 0x60, 0x06, 0x01, 0x05, 0x05, 0x1d, 0x05, 0x4e,
+	0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x07,
+	0x1b, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0xa0, 0xc3,
+	0x01, 0x6d, 0xc3, 0x01, 0x75, 0xc3, 0x04, 0x3e, 0xfc, 0x5f, 0x03, 0xa1, 0xfc, 0x5f, 0x03, 0xa1,
+	0xfb, 0xc2, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa0, 0xfe, 0xa7, 0x06, 0x86, 0x04, 0x3e, 0x00, 0x0f,
+	0x00, 0x7c, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x21, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d,
+	0x05, 0x03, 0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b, 0x4d, 0x05, 0x03, 0x02,
+	0x01, 0x01, 0x06, 0x60, 0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x1a, 0x00, 0x06, 0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03,
+	0x02, 0x01, 0x01, 0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1b, 0x07, 0x4e, 0x1b, 0x40, 0x1a, 0x00,
+	0x06, 0x01, 0x06, 0x54, 0x04, 0x02, 0x02, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x03, 0x02, 0x01, 0x01,
+	0x07, 0x60, 0x08, 0x01, 0x07, 0x07, 0x1d, 0x07, 0x4e, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x00,
+	0x0f, 0x00, 0x0f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x09, 0x07, 0x1d, 0x2b, 0x33, 0x11,
+	0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x23, 0x11, 0xa0, 0xc3,
+	0x01, 0x76, 0xc3, 0x01, 0x73, 0xc3, 0xb4, 0xb4, 0x04, 0x3e, 0xfc, 0x5f, 0x03, 0xa1, 0xfc, 0x5f,
+	0x03, 0xa1, 0xfc, 0x5f, 0xfe, 0x0a, 0x01, 0x59, 0x00, 0x02, 0x00, 0x26, 0x00, 0x00, 0x04, 0xaa,
+	0x04, 0x3e, 0x00, 0x10, 0x00, 0x1b, 0x00, 0x5a, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1e, 0x00,
+	0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x1c,
+	0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x1e,
+	0x00, 0x02, 0x00, 0x05, 0x04, 0x02, 0x05, 0x67, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01,
+	0x1c, 0x4d, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x06, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40,
+	0x10, 0x00, 0x00, 0x1b, 0x19, 0x13, 0x11, 0x00, 0x10, 0x00, 0x0f, 0x21, 0x11, 0x11, 0x07, 0x07,
+	0x19, 0x2b, 0x21, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02,
+	0x23, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x01, 0x4e, 0xfe, 0xd8, 0x01,
+	0xed, 0xea, 0x70, 0xa2, 0x69, 0x32, 0x33, 0x6b, 0xa6, 0x73, 0xe0, 0xda, 0x7f, 0x6c, 0x19, 0x38,
+	0x58, 0x3f, 0xdd, 0x03, 0xa0, 0x9e, 0xfe, 0x5e, 0x2d, 0x56, 0x7c, 0x4f, 0x53, 0x7d, 0x54, 0x2a,
+	0x94, 0x61, 0x59, 0x2a, 0x45, 0x31, 0x1a, 0x00, 0x00, 0x03, 0x00, 0x9a, 0x00, 0x00, 0x05, 0x26,
+	0x04, 0x3e, 0x00, 0x0e, 0x00, 0x12, 0x00, 0x1d, 0x00, 0x55, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1b, 0x00, 0x00, 0x00, 0x06, 0x05, 0x00, 0x06, 0x67, 0x03, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x00,
+	0x05, 0x05, 0x01, 0x60, 0x07, 0x04, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x00,
+	0x00, 0x00, 0x06, 0x05, 0x00, 0x06, 0x67, 0x03, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x00, 0x05, 0x05,
+	0x01, 0x60, 0x07, 0x04, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x11, 0x0f, 0x0f, 0x1d,
+	0x1b, 0x15, 0x13, 0x0f, 0x12, 0x0f, 0x12, 0x12, 0x11, 0x28, 0x20, 0x08, 0x07, 0x1a, 0x2b, 0x01,
+	0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x21, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11,
+	0x25, 0x33, 0x32, 0x36, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x01, 0x5f, 0xae, 0x70, 0xa2, 0x69,
+	0x32, 0x33, 0x6b, 0xa6, 0x73, 0xfe, 0x97, 0xc5, 0x03, 0x01, 0xc6, 0xfc, 0x39, 0x9e, 0x7f, 0x6c,
+	0x19, 0x38, 0x58, 0x3f, 0xa1, 0x02, 0x9c, 0x2d, 0x56, 0x7c, 0x4f, 0x53, 0x7d, 0x54, 0x2a, 0x04,
+	0x3e, 0xfb, 0xc2, 0x04, 0x3e, 0xfb, 0xc2, 0x94, 0x61, 0x59, 0x2a, 0x45, 0x31, 0x1a, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x9a, 0x00, 0x00, 0x03, 0xd5, 0x04, 0x3e, 0x00, 0x0e, 0x00, 0x19, 0x00, 0x4f,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x00,
+	0x00, 0x00, 0x1c, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x1b, 0x02, 0x4e,
+	0x1b, 0x40, 0x19, 0x00, 0x01, 0x00, 0x04, 0x03, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d,
+	0x00, 0x03, 0x03, 0x02, 0x60, 0x05, 0x01, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x0f, 0x00,
+	0x00, 0x19, 0x17, 0x11, 0x0f, 0x00, 0x0e, 0x00, 0x0d, 0x21, 0x11, 0x06, 0x07, 0x18, 0x2b, 0x33,
+	0x11, 0x33, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x27, 0x33, 0x32, 0x36,
+	0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x9a, 0xc5, 0xc9, 0x70, 0xa2, 0x69, 0x32, 0x33, 0x6b, 0xa6,
+	0x73, 0xbf, 0xb9, 0x7f, 0x6c, 0x19, 0x38, 0x58, 0x3f, 0xbc, 0x04, 0x3e, 0xfe, 0x5e, 0x2d, 0x56,
+	0x7c, 0x4f, 0x53, 0x7d, 0x54, 0x2a, 0x94, 0x61, 0x59, 0x2a, 0x45, 0x31, 0x1a, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x5e, 0xff, 0xe7, 0x03, 0xbf, 0x04, 0x57, 0x00, 0x20, 0x00, 0x3b, 0x40, 0x38,
+	0x11, 0x01, 0x03, 0x04, 0x10, 0x01, 0x02, 0x03, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00,
+	0x04, 0x4c, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02, 0x01, 0x67, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00,
+	0x04, 0x04, 0x21, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x22, 0x05, 0x4e, 0x28,
+	0x25, 0x22, 0x11, 0x12, 0x23, 0x06, 0x07, 0x1c, 0x2b, 0x37, 0x35, 0x16, 0x16, 0x33, 0x32, 0x36,
+	0x37, 0x21, 0x35, 0x21, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x35, 0x36, 0x36, 0x33, 0x32, 0x1e,
+	0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x5e, 0x4b, 0x98, 0x44, 0xac, 0xb2, 0x0c, 0xfe,
+	0x41, 0x01, 0xbf, 0x0c, 0xa9, 0x95, 0x3e, 0xa2, 0x5b, 0x4b, 0x9a, 0x47, 0x8f, 0xd0, 0x88, 0x42,
+	0x44, 0x87, 0xc8, 0x85, 0x4e, 0xa8, 0x1f, 0x99, 0x1f, 0x1e, 0xb9, 0xb9, 0x95, 0x99, 0xa7, 0x18,
+	0x19, 0xa1, 0x13, 0x12, 0x4e, 0x93, 0xd2, 0x83, 0x7d, 0xd1, 0x98, 0x54, 0x19, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x9a, 0xff, 0xe7, 0x05, 0xaa, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x1e, 0x00, 0xbb,
+	0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x1f, 0x00, 0x05, 0x00, 0x02, 0x00, 0x05, 0x02, 0x67, 0x00,
+	0x01, 0x01, 0x04, 0x61, 0x06, 0x01, 0x04, 0x04, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x07,
+	0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x23, 0x00, 0x05,
+	0x00, 0x02, 0x00, 0x05, 0x02, 0x67, 0x00, 0x01, 0x01, 0x04, 0x61, 0x06, 0x01, 0x04, 0x04, 0x1c,
+	0x4d, 0x00, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x00, 0x00, 0x07, 0x61, 0x00, 0x07, 0x07, 0x22, 0x07,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x27, 0x00, 0x05, 0x00, 0x02, 0x00, 0x05, 0x02,
+	0x67, 0x00, 0x04, 0x04, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x06, 0x61, 0x00, 0x06, 0x06, 0x21, 0x4d,
+	0x00, 0x03, 0x03, 0x1b, 0x4d, 0x00, 0x00, 0x00, 0x07, 0x61, 0x00, 0x07, 0x07, 0x22, 0x07, 0x4e,
+	0x1b, 0x40, 0x27, 0x00, 0x05, 0x00, 0x02, 0x00, 0x05, 0x02, 0x67, 0x00, 0x04, 0x04, 0x1c, 0x4d,
+	0x00, 0x01, 0x01, 0x06, 0x61, 0x00, 0x06, 0x06, 0x21, 0x4d, 0x00, 0x03, 0x03, 0x1d, 0x4d, 0x00,
+	0x00, 0x00, 0x07, 0x61, 0x00, 0x07, 0x07, 0x22, 0x07, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x0b, 0x24,
+	0x22, 0x11, 0x11, 0x11, 0x12, 0x24, 0x22, 0x08, 0x07, 0x1e, 0x2b, 0x01, 0x14, 0x16, 0x33, 0x32,
+	0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x03, 0x23, 0x11, 0x23, 0x11, 0x33, 0x11, 0x33, 0x36,
+	0x12, 0x33, 0x32, 0x12, 0x11, 0x10, 0x02, 0x23, 0x22, 0x02, 0x02, 0xe1, 0x7e, 0x7e, 0x7d, 0x7e,
+	0x7e, 0x7e, 0x7e, 0x7d, 0xce, 0xb4, 0xc5, 0xc5, 0xb4, 0x13, 0xeb, 0xcb, 0xdc, 0xf2, 0xf2, 0xdc,
+	0xcc, 0xe8, 0x02, 0x24, 0xd5, 0xd4, 0xd2, 0xd2, 0xd1, 0xd2, 0xcf, 0xfe, 0xe2, 0xfe, 0x2b, 0x04,
+	0x3e, 0xfe, 0x2b, 0xe6, 0x01, 0x07, 0xfe, 0xcc, 0xfe, 0xfd, 0xfe, 0xfc, 0xfe, 0xcc, 0x01, 0x05,
+	0x00, 0x02, 0x00, 0x40, 0x00, 0x00, 0x03, 0xbb, 0x04, 0x3e, 0x00, 0x16, 0x00, 0x1f, 0x00, 0x50,
+	0xb5, 0x0c, 0x01, 0x00, 0x05, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x05,
+	0x00, 0x00, 0x01, 0x05, 0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1c, 0x4d,
+	0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x05, 0x00, 0x00, 0x01, 0x05,
+	0x00, 0x67, 0x00, 0x04, 0x04, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1c, 0x4d, 0x03, 0x01, 0x01, 0x01,
+	0x1d, 0x01, 0x4e, 0x59, 0x40, 0x09, 0x24, 0x21, 0x11, 0x2b, 0x15, 0x10, 0x06, 0x07, 0x1c, 0x2b,
+	0x01, 0x23, 0x06, 0x07, 0x07, 0x06, 0x07, 0x23, 0x36, 0x37, 0x37, 0x36, 0x37, 0x26, 0x26, 0x35,
+	0x34, 0x37, 0x36, 0x33, 0x21, 0x11, 0x23, 0x11, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x33,
+	0x02, 0xf5, 0xc0, 0x46, 0x4c, 0x3f, 0x29, 0x1e, 0xdd, 0x46, 0x38, 0x1a, 0x5e, 0x5a, 0x66, 0x79,
+	0xa7, 0x55, 0xee, 0x01, 0x20, 0xc6, 0x7d, 0x80, 0x75, 0x7b, 0x85, 0x72, 0x01, 0xb6, 0x45, 0x83,
+	0x6e, 0x49, 0x37, 0x6a, 0x6a, 0x31, 0xb3, 0x34, 0x25, 0xa0, 0x62, 0xb1, 0x50, 0x2a, 0xfb, 0xc2,
+	0x03, 0xaa, 0x56, 0x56, 0x5a, 0x5a, 0x00, 0x00, 0x00, 0x03, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb,
+	0x06, 0x44, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19, 0x00, 0x4b, 0x40, 0x48, 0x05, 0x01, 0x05, 0x04,
+	0x06, 0x01, 0x02, 0x05, 0x02, 0x4c, 0x00, 0x07, 0x06, 0x07, 0x85, 0x00, 0x06, 0x03, 0x06, 0x85,
+	0x08, 0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03,
+	0x03, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x00, 0x00,
+	0x19, 0x18, 0x17, 0x16, 0x15, 0x13, 0x12, 0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04,
+	0x21, 0x09, 0x07, 0x17, 0x2b, 0x01, 0x10, 0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00,
+	0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07, 0x21, 0x12, 0x21, 0x32, 0x03, 0x23, 0x01, 0x33, 0x03,
+	0x32, 0xf5, 0xfd, 0x18, 0x02, 0xcd, 0xc2, 0xb7, 0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb,
+	0x01, 0xfd, 0x2b, 0x1c, 0x01, 0x69, 0x9c, 0x78, 0x94, 0xfe, 0xbf, 0xe4, 0x02, 0x94, 0x01, 0x2f,
+	0xfe, 0xd1, 0xfe, 0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d,
+	0xfe, 0x7d, 0x04, 0x86, 0x01, 0x41, 0x00, 0x00, 0x00, 0x04, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xfb,
+	0x05, 0xba, 0x00, 0x04, 0x00, 0x15, 0x00, 0x19, 0x00, 0x1d, 0x00, 0x92, 0x40, 0x0a, 0x05, 0x01,
+	0x05, 0x04, 0x06, 0x01, 0x02, 0x05, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x2c, 0x0a,
+	0x01, 0x01, 0x00, 0x04, 0x05, 0x01, 0x04, 0x67, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x07, 0x06, 0x5f,
+	0x08, 0x01, 0x06, 0x06, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x21, 0x4d,
+	0x00, 0x05, 0x05, 0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x1b, 0x40, 0x2a, 0x08, 0x01,
+	0x06, 0x0c, 0x09, 0x0b, 0x03, 0x07, 0x03, 0x06, 0x07, 0x67, 0x0a, 0x01, 0x01, 0x00, 0x04, 0x05,
+	0x01, 0x04, 0x67, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x21, 0x4d, 0x00, 0x05, 0x05,
+	0x02, 0x61, 0x00, 0x02, 0x02, 0x22, 0x02, 0x4e, 0x59, 0x40, 0x22, 0x1a, 0x1a, 0x16, 0x16, 0x00,
+	0x00, 0x1a, 0x1d, 0x1a, 0x1d, 0x1c, 0x1b, 0x16, 0x19, 0x16, 0x19, 0x18, 0x17, 0x15, 0x13, 0x12,
+	0x11, 0x0f, 0x0d, 0x09, 0x07, 0x00, 0x04, 0x00, 0x04, 0x21, 0x0d, 0x07, 0x17, 0x2b, 0x01, 0x10,
+	0x23, 0x22, 0x03, 0x01, 0x15, 0x06, 0x23, 0x22, 0x00, 0x11, 0x34, 0x00, 0x33, 0x20, 0x11, 0x07,
+	0x21, 0x12, 0x21, 0x32, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x03, 0x32, 0xf5, 0xfd,
+	0x18, 0x02, 0xcd, 0xc2, 0xb7, 0xfb, 0xfe, 0xd5, 0x01, 0x09, 0xe1, 0x01, 0xbb, 0x01, 0xfd, 0x2b,
+	0x1c, 0x01, 0x69, 0x9c, 0xfd, 0xe4, 0xad, 0xde, 0xad, 0x02, 0x94, 0x01, 0x2f, 0xfe, 0xd1, 0xfe,
+	0x2b, 0x9c, 0x3c, 0x01, 0x3c, 0x01, 0x09, 0xfe, 0x01, 0x2c, 0xfd, 0xe7, 0x3d, 0xfe, 0x7d, 0x04,
+	0x90, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x0a, 0xfe, 0x75, 0x03, 0xe5,
+	0x06, 0x2b, 0x00, 0x23, 0x00, 0x81, 0x40, 0x0f, 0x22, 0x0b, 0x02, 0x09, 0x08, 0x17, 0x01, 0x07,
+	0x09, 0x16, 0x01, 0x06, 0x07, 0x03, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x28, 0x00, 0x02,
+	0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00, 0x05, 0x00,
+	0x08, 0x09, 0x05, 0x08, 0x69, 0x0a, 0x01, 0x09, 0x09, 0x1b, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x1e, 0x06, 0x4e, 0x1b, 0x40, 0x28, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03, 0x01,
+	0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x00, 0x05, 0x00, 0x08, 0x09, 0x05, 0x08, 0x69,
+	0x0a, 0x01, 0x09, 0x09, 0x1d, 0x4d, 0x00, 0x07, 0x07, 0x06, 0x61, 0x00, 0x06, 0x06, 0x1e, 0x06,
+	0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x00, 0x23, 0x00, 0x23, 0x25, 0x23, 0x25, 0x22, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x0b, 0x07, 0x1f, 0x2b, 0x33, 0x11, 0x23, 0x35, 0x33, 0x35, 0x33, 0x15, 0x21,
+	0x15, 0x21, 0x11, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x14, 0x06, 0x23, 0x22, 0x27, 0x35, 0x16,
+	0x33, 0x32, 0x36, 0x35, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x9a, 0x90, 0x90, 0xc5, 0x01,
+	0x6e, 0xfe, 0x92, 0x7c, 0xd5, 0x8d, 0xa8, 0xa9, 0x98, 0x49, 0x4b, 0x3f, 0x3e, 0x49, 0x4a, 0x47,
+	0x52, 0xa8, 0x80, 0x04, 0xb0, 0x88, 0xf3, 0xf3, 0x88, 0xfe, 0x54, 0xe4, 0xb3, 0x98, 0xfd, 0x20,
+	0x9a, 0xae, 0x15, 0x9a, 0x1b, 0x6d, 0x6c, 0x02, 0x7b, 0x7a, 0x67, 0xed, 0xfd, 0xaf, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x8c, 0x00, 0x00, 0x02, 0xeb, 0x06, 0x44, 0x00, 0x05, 0x00, 0x09, 0x00, 0x59,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00,
+	0x04, 0x85, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x01, 0x02, 0x02,
+	0x1b, 0x02, 0x4e, 0x1b, 0x40, 0x1c, 0x00, 0x03, 0x04, 0x03, 0x85, 0x06, 0x01, 0x04, 0x00, 0x04,
+	0x85, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x05, 0x01, 0x02, 0x02, 0x1d,
+	0x02, 0x4e, 0x59, 0x40, 0x13, 0x06, 0x06, 0x00, 0x00, 0x06, 0x09, 0x06, 0x09, 0x08, 0x07, 0x00,
+	0x05, 0x00, 0x05, 0x11, 0x11, 0x07, 0x07, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x03,
+	0x13, 0x33, 0x01, 0x8c, 0x02, 0x5f, 0xfe, 0x66, 0x3e, 0xf1, 0xe4, 0xfe, 0xbf, 0x04, 0x3e, 0xad,
+	0xfc, 0x6f, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x01, 0x00, 0x56, 0xff, 0xe7, 0x03, 0xb7,
+	0x04, 0x57, 0x00, 0x20, 0x00, 0x3b, 0x40, 0x38, 0x10, 0x01, 0x02, 0x01, 0x11, 0x01, 0x03, 0x02,
+	0x20, 0x01, 0x05, 0x04, 0x00, 0x01, 0x00, 0x05, 0x04, 0x4c, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03,
+	0x04, 0x67, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21, 0x4d, 0x00, 0x05, 0x05, 0x00,
+	0x61, 0x00, 0x00, 0x00, 0x22, 0x00, 0x4e, 0x22, 0x11, 0x12, 0x25, 0x28, 0x22, 0x06, 0x07, 0x1c,
+	0x2b, 0x25, 0x06, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e, 0x02, 0x33, 0x32, 0x16, 0x17,
+	0x15, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x21, 0x15, 0x21, 0x16, 0x16, 0x33, 0x32, 0x36, 0x37,
+	0x03, 0xb7, 0x53, 0xa8, 0x4e, 0x85, 0xc8, 0x87, 0x44, 0x42, 0x88, 0xd0, 0x8f, 0x47, 0x9a, 0x4b,
+	0x5b, 0xa2, 0x3e, 0x95, 0xa9, 0x0c, 0x01, 0xbf, 0xfe, 0x41, 0x0c, 0xb1, 0xad, 0x44, 0x98, 0x4b,
+	0x1f, 0x1f, 0x19, 0x54, 0x98, 0xd1, 0x7d, 0x83, 0xd2, 0x93, 0x4e, 0x12, 0x13, 0xa1, 0x19, 0x18,
+	0xa7, 0x99, 0x95, 0xb9, 0xb9, 0x1e, 0x1f, 0x00, 0x00, 0x01, 0x00, 0x74, 0xff, 0xe7, 0x03, 0x8c,
+	0x04, 0x56, 0x00, 0x1c, 0x00, 0x2e, 0x40, 0x2b, 0x0e, 0x01, 0x02, 0x01, 0x0f, 0x01, 0x02, 0x00,
+	0x02, 0x00, 0x01, 0x03, 0x00, 0x03, 0x4c, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x01, 0x21,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x61, 0x00, 0x03, 0x03, 0x22, 0x03, 0x4e, 0x28, 0x23, 0x27, 0x22,
+	0x04, 0x07, 0x1a, 0x2b, 0x37, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x27, 0x27, 0x26, 0x35, 0x10,
+	0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x15, 0x14, 0x17, 0x17, 0x04, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x74, 0xc2, 0xab, 0xe5, 0x9f, 0xb0, 0xfd, 0x01, 0xa1, 0x78, 0xa6, 0x91, 0xa2, 0xc9, 0x8d,
+	0x9d, 0x01, 0x25, 0xe8, 0xca, 0xa3, 0x26, 0xb5, 0x60, 0xa5, 0x68, 0x35, 0x3a, 0x54, 0xda, 0x01,
+	0x31, 0x20, 0xa5, 0x31, 0x8a, 0x5e, 0x2f, 0x33, 0x61, 0xe7, 0x99, 0xb0, 0x00, 0x02, 0x00, 0x90,
+	0x00, 0x00, 0x01, 0x69, 0x05, 0xdc, 0x00, 0x03, 0x00, 0x07, 0x00, 0x4c, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x17, 0x05, 0x01, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x1a, 0x4d, 0x00, 0x00,
+	0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x02, 0x05,
+	0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x01, 0x01, 0x1d,
+	0x01, 0x4e, 0x59, 0x40, 0x12, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x06, 0x07, 0x17, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x03, 0x35, 0x33, 0x15,
+	0x9a, 0xc5, 0xcf, 0xd9, 0x04, 0x3e, 0xfb, 0xc2, 0x05, 0x03, 0xd9, 0xd9, 0x00, 0x03, 0xff, 0xe0,
+	0x00, 0x00, 0x02, 0x19, 0x05, 0xba, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x5a, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x1a, 0x08, 0x05, 0x07, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x04, 0x01, 0x02,
+	0x02, 0x1a, 0x4d, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x18, 0x04, 0x01, 0x02, 0x08, 0x05, 0x07, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00,
+	0x00, 0x1c, 0x4d, 0x06, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08, 0x08, 0x04,
+	0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x09, 0x07, 0x17, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x01, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x9a, 0xc5, 0xfe, 0x81, 0xad, 0xdf, 0xad, 0x04, 0x3e, 0xfb, 0xc2, 0x05,
+	0x0d, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa3, 0xfe, 0x75, 0x01, 0x4b,
+	0x05, 0xc8, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x5b, 0x40, 0x0a, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01,
+	0x02, 0x00, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x05, 0x01, 0x04, 0x04, 0x03,
+	0x5f, 0x00, 0x03, 0x03, 0x1a, 0x4d, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62,
+	0x00, 0x02, 0x02, 0x1e, 0x02, 0x4e, 0x1b, 0x40, 0x19, 0x00, 0x03, 0x05, 0x01, 0x04, 0x01, 0x03,
+	0x04, 0x67, 0x00, 0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x02, 0x62, 0x00, 0x02, 0x02, 0x1e,
+	0x02, 0x4e, 0x59, 0x40, 0x0d, 0x0d, 0x0d, 0x0d, 0x10, 0x0d, 0x10, 0x12, 0x22, 0x13, 0x22, 0x06,
+	0x07, 0x1a, 0x2b, 0x03, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x11, 0x33, 0x11, 0x10, 0x21, 0x22,
+	0x13, 0x35, 0x33, 0x15, 0x5d, 0x10, 0x47, 0x55, 0x36, 0xc6, 0xfe, 0xc0, 0x5b, 0xd5, 0xc6, 0xfe,
+	0x80, 0x90, 0x07, 0x69, 0x8e, 0x04, 0x3e, 0xfb, 0xc2, 0xfe, 0x75, 0x06, 0x8e, 0xc5, 0xc5, 0x00,
+	0x00, 0x02, 0x00, 0x41, 0x00, 0x00, 0x06, 0xea, 0x04, 0x3e, 0x00, 0x22, 0x00, 0x2d, 0x00, 0x60,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x20, 0x00, 0x00, 0x00, 0x07, 0x04, 0x00, 0x07, 0x67, 0x00,
+	0x02, 0x02, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x1c, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x01, 0x61,
+	0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x00, 0x00, 0x07, 0x04, 0x00,
+	0x07, 0x67, 0x00, 0x02, 0x02, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x05, 0x1c, 0x4d, 0x06, 0x01, 0x04,
+	0x04, 0x01, 0x61, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x12, 0x00, 0x00, 0x2d,
+	0x2b, 0x25, 0x23, 0x00, 0x22, 0x00, 0x22, 0x21, 0x27, 0x11, 0x28, 0x21, 0x09, 0x07, 0x1b, 0x2b,
+	0x01, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x21, 0x11, 0x21, 0x15, 0x14,
+	0x0e, 0x04, 0x23, 0x23, 0x35, 0x33, 0x32, 0x3e, 0x03, 0x12, 0x35, 0x35, 0x01, 0x33, 0x32, 0x36,
+	0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x04, 0x60, 0xdd, 0x70, 0xa2, 0x69, 0x32, 0x33, 0x6b, 0xa6,
+	0x73, 0xfe, 0x68, 0xfe, 0xa8, 0x03, 0x18, 0x37, 0x69, 0xa3, 0x79, 0x2b, 0x21, 0x34, 0x56, 0x43,
+	0x31, 0x20, 0x10, 0x02, 0xd0, 0xcd, 0x7f, 0x6c, 0x19, 0x38, 0x58, 0x3f, 0xd0, 0x04, 0x3e, 0xfe,
+	0x5e, 0x2d, 0x56, 0x7c, 0x4f, 0x53, 0x7d, 0x54, 0x2a, 0x03, 0xa0, 0x34, 0x63, 0xcf, 0xc3, 0xac,
+	0x80, 0x4b, 0x94, 0x15, 0x3e, 0x71, 0xb9, 0x01, 0x0a, 0xb8, 0x6b, 0xfc, 0x56, 0x61, 0x59, 0x2a,
+	0x45, 0x31, 0x1a, 0x00, 0x00, 0x02, 0x00, 0x9a, 0x00, 0x00, 0x06, 0x2c, 0x04, 0x3e, 0x00, 0x16,
+	0x00, 0x21, 0x00, 0x5b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x08, 0x01,
+	0x00, 0x07, 0x03, 0x00, 0x67, 0x04, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x60,
+	0x09, 0x06, 0x02, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b, 0x40, 0x1d, 0x05, 0x01, 0x03, 0x08, 0x01,
+	0x00, 0x07, 0x03, 0x00, 0x67, 0x04, 0x01, 0x02, 0x02, 0x1c, 0x4d, 0x00, 0x07, 0x07, 0x01, 0x60,
+	0x09, 0x06, 0x02, 0x01, 0x01, 0x1d, 0x01, 0x4e, 0x59, 0x40, 0x13, 0x00, 0x00, 0x21, 0x1f, 0x19,
+	0x17, 0x00, 0x16, 0x00, 0x15, 0x21, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0a, 0x07, 0x1c, 0x2b, 0x21,
+	0x11, 0x21, 0x11, 0x23, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x33, 0x32, 0x1e, 0x02, 0x15,
+	0x14, 0x0e, 0x02, 0x23, 0x27, 0x33, 0x32, 0x36, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x03, 0x13,
+	0xfe, 0x4d, 0xc6, 0xc6, 0x01, 0xb3, 0xc5, 0xa7, 0x70, 0xa2, 0x69, 0x32, 0x33, 0x6b, 0xa6, 0x73,
+	0x9d, 0x97, 0x7f, 0x6c, 0x19, 0x38, 0x58, 0x3f, 0x9a, 0x02, 0x09, 0xfd, 0xf7, 0x04, 0x3e, 0xfe,
+	0x5f, 0x01, 0xa1, 0xfe, 0x5e, 0x2d, 0x56, 0x7c, 0x4f, 0x53, 0x7d, 0x54, 0x2a, 0x94, 0x61, 0x59,
+	0x2a, 0x45, 0x31, 0x1a, 0x00, 0x01, 0x00, 0x0a, 0x00, 0x00, 0x03, 0xe5, 0x06, 0x12, 0x00, 0x19,
+	0x00, 0x5e, 0xb6, 0x0d, 0x00, 0x02, 0x01, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40,
+	0x1e, 0x07, 0x01, 0x05, 0x08, 0x01, 0x04, 0x00, 0x05, 0x04, 0x67, 0x00, 0x00, 0x00, 0x02, 0x01,
+	0x00, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x01, 0x1b, 0x01, 0x4e, 0x1b,
+	0x40, 0x1e, 0x07, 0x01, 0x05, 0x08, 0x01, 0x04, 0x00, 0x05, 0x04, 0x67, 0x00, 0x00, 0x00, 0x02,
+	0x01, 0x00, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x01, 0x1d, 0x01, 0x4e,
+	0x59, 0x40, 0x0c, 0x11, 0x11, 0x11, 0x11, 0x11, 0x12, 0x23, 0x13, 0x21, 0x09, 0x07, 0x1f, 0x2b,
+	0x01, 0x36, 0x33, 0x32, 0x16, 0x15, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x23,
+	0x11, 0x23, 0x35, 0x33, 0x35, 0x33, 0x15, 0x21, 0x15, 0x21, 0x01, 0x5f, 0x7e, 0xd3, 0x8d, 0xa8,
+	0xc5, 0x47, 0x52, 0xa6, 0x82, 0xc5, 0x90, 0x90, 0xc5, 0x01, 0x5a, 0xfe, 0xa6, 0x03, 0x04, 0xe4,
+	0xb3, 0x97, 0xfd, 0x62, 0x02, 0x5d, 0x7a, 0x67, 0xed, 0xfd, 0xaf, 0x04, 0xb0, 0x88, 0xda, 0xda,
+	0x88, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x9a, 0x00, 0x00, 0x03, 0x80, 0x06, 0x44, 0x00, 0x2e,
+	0x00, 0x32, 0x00, 0x7a, 0xb5, 0x1b, 0x01, 0x05, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58,
+	0x40, 0x26, 0x00, 0x07, 0x08, 0x07, 0x85, 0x0a, 0x01, 0x08, 0x00, 0x08, 0x85, 0x00, 0x01, 0x00,
+	0x05, 0x04, 0x01, 0x05, 0x67, 0x00, 0x03, 0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d,
+	0x09, 0x06, 0x02, 0x04, 0x04, 0x1b, 0x04, 0x4e, 0x1b, 0x40, 0x26, 0x00, 0x07, 0x08, 0x07, 0x85,
+	0x0a, 0x01, 0x08, 0x00, 0x08, 0x85, 0x00, 0x01, 0x00, 0x05, 0x04, 0x01, 0x05, 0x67, 0x00, 0x03,
+	0x03, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x09, 0x06, 0x02, 0x04, 0x04, 0x1d, 0x04,
+	0x4e, 0x59, 0x40, 0x19, 0x2f, 0x2f, 0x00, 0x00, 0x2f, 0x32, 0x2f, 0x32, 0x31, 0x30, 0x00, 0x2e,
+	0x00, 0x2e, 0x2d, 0x2c, 0x27, 0x26, 0x21, 0x19, 0x11, 0x11, 0x0b, 0x07, 0x1a, 0x2b, 0x33, 0x11,
+	0x33, 0x11, 0x32, 0x3e, 0x02, 0x37, 0x37, 0x3e, 0x03, 0x33, 0x15, 0x23, 0x22, 0x0e, 0x02, 0x07,
+	0x07, 0x0e, 0x03, 0x07, 0x1e, 0x03, 0x17, 0x17, 0x1e, 0x03, 0x17, 0x23, 0x2e, 0x03, 0x27, 0x23,
+	0x11, 0x13, 0x13, 0x33, 0x01, 0x9a, 0xbb, 0x2f, 0x42, 0x2f, 0x21, 0x0f, 0x09, 0x18, 0x2f, 0x41,
+	0x5c, 0x45, 0x0b, 0x1d, 0x2a, 0x20, 0x19, 0x0c, 0x0c, 0x13, 0x2b, 0x33, 0x3b, 0x22, 0x25, 0x37,
+	0x2f, 0x2e, 0x1b, 0x18, 0x0d, 0x2b, 0x31, 0x31, 0x14, 0xd1, 0x1e, 0x4a, 0x4d, 0x48, 0x1c, 0x41,
+	0x19, 0xf1, 0xe4, 0xfe, 0xbf, 0x04, 0x3e, 0xfe, 0x2e, 0x20, 0x36, 0x48, 0x29, 0x19, 0x41, 0x5c,
+	0x3a, 0x1b, 0x94, 0x11, 0x23, 0x32, 0x21, 0x20, 0x32, 0x45, 0x2d, 0x1a, 0x08, 0x0c, 0x37, 0x49,
+	0x58, 0x2d, 0x28, 0x16, 0x44, 0x4a, 0x47, 0x19, 0x34, 0x86, 0x88, 0x7e, 0x2c, 0xfe, 0x14, 0x05,
+	0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x91, 0x00, 0x00, 0x03, 0xe7,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x56, 0xb6, 0x08, 0x03, 0x02, 0x02, 0x00, 0x01, 0x4c,
+	0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04,
+	0x85, 0x01, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x06, 0x03, 0x02, 0x02, 0x02, 0x1b, 0x02, 0x4e, 0x1b,
+	0x40, 0x18, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x04, 0x00, 0x04, 0x85, 0x01, 0x01, 0x00, 0x00,
+	0x1c, 0x4d, 0x06, 0x03, 0x02, 0x02, 0x02, 0x1d, 0x02, 0x4e, 0x59, 0x40, 0x10, 0x00, 0x00, 0x0d,
+	0x0c, 0x0b, 0x0a, 0x00, 0x09, 0x00, 0x09, 0x11, 0x12, 0x11, 0x07, 0x07, 0x19, 0x2b, 0x33, 0x11,
+	0x33, 0x11, 0x01, 0x33, 0x11, 0x23, 0x11, 0x01, 0x01, 0x23, 0x01, 0x33, 0x91, 0xb9, 0x01, 0xd8,
+	0xc5, 0xb9, 0xfe, 0x29, 0x01, 0x59, 0x94, 0xfe, 0xbf, 0xe5, 0x04, 0x3e, 0xfc, 0xca, 0x03, 0x36,
+	0xfb, 0xc2, 0x03, 0x36, 0xfc, 0xca, 0x05, 0x03, 0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0b,
+	0xfe, 0x75, 0x03, 0xf3, 0x06, 0x44, 0x00, 0x0e, 0x00, 0x1a, 0x00, 0x6c, 0xb6, 0x08, 0x05, 0x02,
+	0x00, 0x01, 0x01, 0x4c, 0x4b, 0xb0, 0x15, 0x50, 0x58, 0x40, 0x22, 0x06, 0x01, 0x04, 0x05, 0x04,
+	0x85, 0x00, 0x07, 0x07, 0x05, 0x61, 0x00, 0x05, 0x05, 0x1a, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x1c,
+	0x4d, 0x00, 0x00, 0x00, 0x03, 0x62, 0x08, 0x01, 0x03, 0x03, 0x1e, 0x03, 0x4e, 0x1b, 0x40, 0x20,
+	0x06, 0x01, 0x04, 0x05, 0x04, 0x85, 0x00, 0x05, 0x00, 0x07, 0x01, 0x05, 0x07, 0x69, 0x02, 0x01,
+	0x01, 0x01, 0x1c, 0x4d, 0x00, 0x00, 0x00, 0x03, 0x62, 0x08, 0x01, 0x03, 0x03, 0x1e, 0x03, 0x4e,
+	0x59, 0x40, 0x14, 0x00, 0x00, 0x19, 0x17, 0x15, 0x14, 0x13, 0x11, 0x10, 0x0f, 0x00, 0x0e, 0x00,
+	0x0d, 0x12, 0x13, 0x21, 0x09, 0x07, 0x19, 0x2b, 0x13, 0x35, 0x33, 0x32, 0x36, 0x37, 0x01, 0x33,
+	0x01, 0x01, 0x33, 0x01, 0x02, 0x06, 0x23, 0x13, 0x33, 0x16, 0x33, 0x32, 0x37, 0x33, 0x06, 0x06,
+	0x23, 0x22, 0x26, 0x50, 0x1c, 0x9e, 0x64, 0x32, 0xfe, 0x6b, 0xd4, 0x01, 0x2d, 0x01, 0x2d, 0xba,
+	0xfe, 0x72, 0x6e, 0xa6, 0xea, 0x71, 0x7b, 0x21, 0xb1, 0xb2, 0x20, 0x7b, 0x0f, 0xb6, 0x88, 0x88,
+	0xb5, 0xfe, 0x75, 0xad, 0x4f, 0x8f, 0x04, 0x3e, 0xfc, 0xdc, 0x03, 0x24, 0xfb, 0xdb, 0xfe, 0xed,
+	0x91, 0x07, 0xcf, 0xad, 0xad, 0x92, 0xaf, 0xae, 0x00, 0x01, 0x00, 0x91, 0xfe, 0xa7, 0x03, 0xda,
+	0x04, 0x3e, 0x00, 0x0b, 0x00, 0x6d, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x18, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1b, 0x4d, 0x00,
+	0x04, 0x04, 0x1e, 0x04, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x18, 0x00, 0x04, 0x03,
+	0x04, 0x86, 0x02, 0x01, 0x00, 0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02,
+	0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x04, 0x03, 0x04, 0x86, 0x02, 0x01, 0x00,
+	0x00, 0x1c, 0x4d, 0x00, 0x01, 0x01, 0x03, 0x60, 0x06, 0x05, 0x02, 0x03, 0x03, 0x1d, 0x03, 0x4e,
+	0x59, 0x59, 0x40, 0x0e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07,
+	0x07, 0x1b, 0x2b, 0x33, 0x11, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x23, 0x11, 0x91,
+	0xc5, 0x01, 0xbf, 0xc5, 0xfe, 0xb5, 0xb4, 0x04, 0x3e, 0xfc, 0x5f, 0x03, 0xa1, 0xfb, 0xc2, 0xfe,
+	0xa7, 0x01, 0x59, 0x00, 0x00, 0x01, 0x00, 0xb4, 0x00, 0x00, 0x03, 0xd5, 0x06, 0xf1, 0x00, 0x07,
+	0x00, 0x44, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02,
+	0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1a, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b,
+	0x40, 0x14, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x00, 0x02, 0x03, 0x00, 0x02, 0x68, 0x04,
+	0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x05, 0x07, 0x19, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0xb4, 0x02,
+	0x6d, 0xb4, 0xfd, 0xb1, 0x05, 0xc8, 0x01, 0x29, 0xfe, 0x2b, 0xfa, 0xe4, 0x00, 0x01, 0x00, 0xaa,
+	0x00, 0x00, 0x03, 0x36, 0x05, 0x34, 0x00, 0x07, 0x00, 0x66, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40,
+	0x17, 0x00, 0x01, 0x00, 0x00, 0x01, 0x70, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c,
+	0x4d, 0x04, 0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x16,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04,
+	0x01, 0x03, 0x03, 0x1b, 0x03, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02,
+	0x02, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x1c, 0x4d, 0x04, 0x01, 0x03, 0x03, 0x1d, 0x03, 0x4e, 0x59,
+	0x59, 0x40, 0x0c, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x07, 0x19, 0x2b,
+	0x33, 0x11, 0x21, 0x35, 0x33, 0x11, 0x21, 0x11, 0xaa, 0x01, 0xd8, 0xb4, 0xfe, 0x39, 0x04, 0x3e,
+	0xf6, 0xfe, 0x5d, 0xfc, 0x6f, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x19, 0x00, 0x00, 0x07, 0x74,
+	0x07, 0x8f, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x5a, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01,
+	0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00,
+	0x05, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x19, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01,
+	0x02, 0x00, 0x03, 0x00, 0x85, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x11,
+	0x00, 0x00, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x08, 0x09,
+	0x1a, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x23, 0x09, 0x02, 0x23,
+	0x01, 0x33, 0x01, 0x96, 0xfe, 0x83, 0xca, 0x01, 0x2f, 0x01, 0x5b, 0xca, 0x01, 0x4d, 0x01, 0x45,
+	0xab, 0xfe, 0x60, 0xd0, 0xfe, 0xb7, 0xfe, 0xab, 0x01, 0xd1, 0x94, 0xfe, 0xbf, 0xe5, 0x05, 0xc8,
+	0xfb, 0x6f, 0x04, 0x91, 0xfb, 0x7a, 0x04, 0x86, 0xfa, 0x38, 0x04, 0x75, 0xfb, 0x8b, 0x06, 0x4e,
+	0x01, 0x41, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0b, 0x00, 0x00, 0x05, 0xb9, 0x06, 0x44, 0x00, 0x0c,
+	0x00, 0x10, 0x00, 0x7f, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x1c, 0x00, 0x05, 0x06, 0x00, 0x06, 0x05, 0x00, 0x80, 0x00, 0x06, 0x06, 0x3a,
+	0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x19, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00,
+	0x05, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x40, 0x19, 0x00, 0x06, 0x05, 0x06, 0x85, 0x00, 0x05, 0x00, 0x05, 0x85, 0x02, 0x01,
+	0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x59, 0x40,
+	0x11, 0x00, 0x00, 0x10, 0x0f, 0x0e, 0x0d, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x08,
+	0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x13, 0x13, 0x33, 0x13, 0x13, 0x33, 0x01, 0x23, 0x03, 0x01,
+	0x01, 0x23, 0x01, 0x33, 0x01, 0x11, 0xfe, 0xfa, 0xc1, 0xc4, 0xfa, 0xc5, 0xdc, 0xe4, 0xaa, 0xfe,
+	0xcf, 0xc6, 0xe6, 0xfe, 0xfc, 0x01, 0x77, 0x94, 0xfe, 0xbf, 0xe4, 0x04, 0x3e, 0xfc, 0xce, 0x03,
+	0x32, 0xfc, 0xcb, 0x03, 0x35, 0xfb, 0xc2, 0x03, 0x49, 0xfc, 0xb7, 0x05, 0x03, 0x01, 0x41, 0x00,
+	0x00, 0x02, 0x00, 0x19, 0x00, 0x00, 0x07, 0x74, 0x07, 0x8f, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x60,
+	0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a,
+	0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00,
+	0x38, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x05, 0x06,
+	0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x03, 0x00, 0x85, 0x07,
+	0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x15, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x10,
+	0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x09, 0x09, 0x1a, 0x2b,
+	0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x23, 0x09, 0x02, 0x13, 0x33, 0x01,
+	0x01, 0x96, 0xfe, 0x83, 0xca, 0x01, 0x2f, 0x01, 0x5b, 0xca, 0x01, 0x4d, 0x01, 0x45, 0xab, 0xfe,
+	0x60, 0xd0, 0xfe, 0xb7, 0xfe, 0xab, 0x01, 0x07, 0xf1, 0xe4, 0xfe, 0xbf, 0x05, 0xc8, 0xfb, 0x6f,
+	0x04, 0x91, 0xfb, 0x7a, 0x04, 0x86, 0xfa, 0x38, 0x04, 0x75, 0xfb, 0x8b, 0x06, 0x4e, 0x01, 0x41,
+	0xfe, 0xbf, 0x00, 0x00, 0x00, 0x02, 0x00, 0x0b, 0x00, 0x00, 0x05, 0xb9, 0x06, 0x44, 0x00, 0x0c,
+	0x00, 0x10, 0x00, 0x86, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x28,
+	0x50, 0x58, 0x40, 0x1d, 0x08, 0x01, 0x06, 0x05, 0x00, 0x05, 0x06, 0x00, 0x80, 0x00, 0x05, 0x05,
+	0x3a, 0x4d, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1a, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01,
+	0x06, 0x00, 0x06, 0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03,
+	0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1a, 0x00, 0x05, 0x06, 0x05, 0x85, 0x08, 0x01, 0x06, 0x00, 0x06,
+	0x85, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b, 0x4d, 0x07, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e,
+	0x59, 0x59, 0x40, 0x15, 0x0d, 0x0d, 0x00, 0x00, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c,
+	0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x09, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x13, 0x13, 0x33,
+	0x13, 0x13, 0x33, 0x01, 0x23, 0x03, 0x01, 0x13, 0x13, 0x33, 0x01, 0x01, 0x11, 0xfe, 0xfa, 0xc1,
+	0xc4, 0xfa, 0xc5, 0xdc, 0xe4, 0xaa, 0xfe, 0xcf, 0xc6, 0xe6, 0xfe, 0xfc, 0xb2, 0xf1, 0xe4, 0xfe,
+	0xbf, 0x04, 0x3e, 0xfc, 0xce, 0x03, 0x32, 0xfc, 0xcb, 0x03, 0x35, 0xfb, 0xc2, 0x03, 0x49, 0xfc,
+	0xb7, 0x05, 0x03, 0x01, 0x41, 0xfe, 0xbf, 0x00, 0x00, 0x03, 0x00, 0x19, 0x00, 0x00, 0x07, 0x74,
+	0x07, 0x0f, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x14, 0x00, 0x6d, 0xb7, 0x0b, 0x06, 0x03, 0x03, 0x03,
+	0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1b, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a,
+	0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x38, 0x4d, 0x09, 0x04, 0x02,
+	0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1e, 0x02, 0x01, 0x02, 0x00, 0x06, 0x03, 0x06, 0x00,
+	0x03, 0x80, 0x07, 0x01, 0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x09, 0x04,
+	0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x1d, 0x11, 0x11, 0x0d, 0x0d, 0x00, 0x00, 0x11,
+	0x14, 0x11, 0x14, 0x13, 0x12, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c, 0x00, 0x0c, 0x11,
+	0x12, 0x12, 0x11, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x01, 0x33,
+	0x01, 0x23, 0x01, 0x01, 0x13, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x96, 0xfe, 0x83,
+	0xca, 0x01, 0x2f, 0x01, 0x5b, 0xca, 0x01, 0x4d, 0x01, 0x45, 0xab, 0xfe, 0x60, 0xd0, 0xfe, 0xb7,
+	0xfe, 0xab, 0x50, 0xad, 0xde, 0xad, 0x05, 0xc8, 0xfb, 0x6f, 0x04, 0x91, 0xfb, 0x7a, 0x04, 0x86,
+	0xfa, 0x38, 0x04, 0x75, 0xfb, 0x8b, 0x06, 0x62, 0xad, 0xad, 0xad, 0xad, 0x00, 0x03, 0x00, 0x0b,
+	0x00, 0x00, 0x05, 0xb9, 0x05, 0xba, 0x00, 0x0c, 0x00, 0x10, 0x00, 0x14, 0x00, 0x6c, 0xb7, 0x0b,
+	0x06, 0x03, 0x03, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x0b, 0x08,
+	0x0a, 0x03, 0x06, 0x06, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x05, 0x38, 0x4d, 0x02, 0x01, 0x02, 0x00,
+	0x00, 0x3b, 0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x39, 0x03, 0x4e, 0x1b, 0x40, 0x1b, 0x07, 0x01,
+	0x05, 0x0b, 0x08, 0x0a, 0x03, 0x06, 0x00, 0x05, 0x06, 0x67, 0x02, 0x01, 0x02, 0x00, 0x00, 0x3b,
+	0x4d, 0x09, 0x04, 0x02, 0x03, 0x03, 0x3c, 0x03, 0x4e, 0x59, 0x40, 0x1d, 0x11, 0x11, 0x0d, 0x0d,
+	0x00, 0x00, 0x11, 0x14, 0x11, 0x14, 0x13, 0x12, 0x0d, 0x10, 0x0d, 0x10, 0x0f, 0x0e, 0x00, 0x0c,
+	0x00, 0x0c, 0x11, 0x12, 0x12, 0x11, 0x0c, 0x09, 0x1a, 0x2b, 0x21, 0x01, 0x33, 0x13, 0x13, 0x33,
+	0x13, 0x13, 0x33, 0x01, 0x23, 0x03, 0x01, 0x03, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01,
+	0x11, 0xfe, 0xfa, 0xc1, 0xc4, 0xfa, 0xc5, 0xdc, 0xe4, 0xaa, 0xfe, 0xcf, 0xc6, 0xe6, 0xfe, 0xfc,
+	0x07, 0xad, 0xde, 0xad, 0x04, 0x3e, 0xfc, 0xce, 0x03, 0x32, 0xfc, 0xcb, 0x03, 0x35, 0xfb, 0xc2,
+	0x03, 0x49, 0xfc, 0xb7, 0x05, 0x0d, 0xad, 0xad, 0xad, 0xad, 0x00, 0x00, 0x00, 0x02, 0x00, 0x1e,
+	0x00, 0x00, 0x05, 0x39, 0x07, 0x8f, 0x00, 0x08, 0x00, 0x0c, 0x00, 0x54, 0xb7, 0x07, 0x04, 0x01,
+	0x03, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x17, 0x00, 0x04, 0x03, 0x04,
+	0x85, 0x00, 0x03, 0x00, 0x03, 0x85, 0x01, 0x01, 0x00, 0x00, 0x38, 0x4d, 0x05, 0x01, 0x02, 0x02,
+	0x39, 0x02, 0x4e, 0x1b, 0x40, 0x17, 0x00, 0x04, 0x03, 0x04, 0x85, 0x00, 0x03, 0x00, 0x03, 0x85,
+	0x01, 0x01, 0x00, 0x02, 0x00, 0x85, 0x05, 0x01, 0x02, 0x02, 0x3c, 0x02, 0x4e, 0x59, 0x40, 0x0f,
+	0x00, 0x00, 0x0c, 0x0b, 0x0a, 0x09, 0x00, 0x08, 0x00, 0x08, 0x12, 0x12, 0x06, 0x09, 0x18, 0x2b,
+	0x21, 0x11, 0x01, 0x33, 0x01, 0x01, 0x33, 0x01, 0x11, 0x13, 0x23, 0x01, 0x33, 0x02, 0x31, 0xfd,
+	0xed, 0xf0, 0x01, 0xa5, 0x01, 0xc3, 0xc3, 0xfd, 0xca, 0x44, 0x94, 0xfe, 0xbf, 0xe5, 0x02, 0x69,
+	0x03, 0x5f, 0xfd, 0x53, 0x02, 0xad, 0xfc, 0xa6, 0xfd, 0x92, 0x06, 0x4e, 0x01, 0x41, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x13, 0xfe, 0x75, 0x03, 0xf4, 0x06, 0x44, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x4b,
+	0xb5, 0x03, 0x01, 0x02, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x28, 0x50, 0x58, 0x40, 0x19, 0x00, 0x03,
+	0x04, 0x00, 0x04, 0x03, 0x00, 0x80, 0x00, 0x04, 0x04, 0x3a, 0x4d, 0x01, 0x01, 0x00, 0x00, 0x3b,
+	0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e, 0x1b, 0x40, 0x16, 0x00, 0x04, 0x03, 0x04, 0x85, 0x00,
+	0x03, 0x00, 0x03, 0x85, 0x01, 0x01, 0x00, 0x00, 0x3b, 0x4d, 0x00, 0x02, 0x02, 0x3d, 0x02, 0x4e,
+	0x59, 0xb7, 0x11, 0x11, 0x11, 0x12, 0x11, 0x05, 0x09, 0x1b, 0x2b, 0x21, 0x01, 0x33, 0x01, 0x01,
+	0x33, 0x01, 0x23, 0x01, 0x23, 0x01, 0x33, 0x01, 0x94, 0xfe, 0x7f, 0xc8, 0x01, 0x27, 0x01, 0x44,
+	0xae, 0xfd, 0xc2, 0xcd, 0x01, 0xad, 0x94, 0xfe, 0xbf, 0xe4, 0x04, 0x3e, 0xfc, 0xbf, 0x03, 0x41,
+	0xfa, 0x37, 0x06, 0x8e, 0x01, 0x41, 0x00, 0x00, 0x00, 0x01, 0x00, 0x80, 0x02, 0x1f, 0x03, 0x80,
+	0x02, 0xb3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x80, 0x03, 0x00, 0x02, 0x1f, 0x94, 0x94, 0x00,
+	0x00, 0x01, 0x00, 0x80, 0x02, 0x1f, 0x07, 0x80, 0x02, 0xb3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15,
+	0x80, 0x07, 0x00, 0x02, 0x1f, 0x94, 0x94, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0x1f, 0x08, 0x00,
+	0x02, 0xb3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x09, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x08, 0x00, 0x02, 0x1f, 0x94, 0x94, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0x6b, 0x00, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x37,
+	0xb1, 0x06, 0x64, 0x44, 0x40, 0x2c, 0x00, 0x00, 0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00,
+	0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x05, 0x01, 0x03, 0x02, 0x03, 0x4f,
+	0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06,
+	0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44, 0x15, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x04,
+	0x6b, 0xfb, 0x95, 0x04, 0x6b, 0x7c, 0x7c, 0x7c, 0xfe, 0xcc, 0x7c, 0x7c, 0x00, 0x01, 0x00, 0x5c,
+	0x03, 0xf4, 0x01, 0x53, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x1d, 0x40, 0x1a, 0x01, 0x00, 0x02, 0x00,
+	0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x00, 0x01,
+	0x4f, 0x11, 0x14, 0x02, 0x09, 0x18, 0x2b, 0x01, 0x15, 0x06, 0x15, 0x15, 0x33, 0x15, 0x23, 0x35,
+	0x10, 0x01, 0x53, 0x60, 0x60, 0xf7, 0x06, 0x2b, 0x4a, 0x1b, 0xc7, 0x15, 0xf6, 0xd6, 0x01, 0x46,
+	0x00, 0x01, 0x00, 0x74, 0x03, 0xf4, 0x01, 0x6b, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x18, 0x40, 0x15,
+	0x01, 0x00, 0x02, 0x00, 0x49, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x3a, 0x00, 0x4e,
+	0x11, 0x14, 0x02, 0x09, 0x18, 0x2b, 0x13, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x15, 0x10,
+	0x74, 0x60, 0x60, 0xf7, 0x03, 0xf4, 0x4a, 0x1b, 0xc7, 0x14, 0xf7, 0xd6, 0xfe, 0xb7, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x68, 0xfe, 0xd8, 0x01, 0x5f, 0x00, 0xf7, 0x00, 0x09, 0x00, 0x2e, 0xb4, 0x01,
+	0x00, 0x02, 0x00, 0x49, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0b, 0x00, 0x01, 0x01, 0x00, 0x5f,
+	0x00, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x40, 0x0b, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x3c, 0x00, 0x4e, 0x59, 0xb4, 0x11, 0x14, 0x02, 0x09, 0x18, 0x2b, 0x13, 0x35, 0x36, 0x35,
+	0x35, 0x23, 0x35, 0x33, 0x15, 0x10, 0x68, 0x60, 0x60, 0xf7, 0xfe, 0xd8, 0x4a, 0x1b, 0xaf, 0x14,
+	0xf7, 0xd6, 0xfe, 0xd1, 0x00, 0x01, 0x00, 0x60, 0x03, 0xf4, 0x01, 0x57, 0x06, 0x2b, 0x00, 0x09,
+	0x00, 0x18, 0x40, 0x15, 0x09, 0x00, 0x02, 0x01, 0x49, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x3a, 0x01, 0x4e, 0x11, 0x13, 0x02, 0x09, 0x18, 0x2b, 0x01, 0x26, 0x11, 0x35, 0x33, 0x15,
+	0x23, 0x15, 0x14, 0x17, 0x01, 0x57, 0xf7, 0xf7, 0x60, 0x60, 0x03, 0xf4, 0x18, 0x01, 0x49, 0xd6,
+	0xf7, 0x14, 0xc7, 0x1b, 0x00, 0x02, 0x00, 0x3c, 0x03, 0xf4, 0x02, 0xf2, 0x06, 0x2b, 0x00, 0x09,
+	0x00, 0x13, 0x00, 0x24, 0x40, 0x21, 0x0b, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x4a, 0x02, 0x01, 0x00,
+	0x01, 0x01, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x00, 0x01, 0x4f,
+	0x11, 0x17, 0x11, 0x14, 0x04, 0x09, 0x1a, 0x2b, 0x01, 0x15, 0x06, 0x15, 0x15, 0x33, 0x15, 0x23,
+	0x35, 0x10, 0x25, 0x15, 0x06, 0x15, 0x15, 0x33, 0x15, 0x23, 0x35, 0x10, 0x01, 0x33, 0x60, 0x60,
+	0xf7, 0x02, 0xb6, 0x60, 0x60, 0xf7, 0x06, 0x2b, 0x4a, 0x1b, 0xc7, 0x15, 0xf6, 0xd6, 0x01, 0x46,
+	0x1b, 0x4a, 0x1b, 0xc7, 0x15, 0xf6, 0xd6, 0x01, 0x46, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x64,
+	0x03, 0xf4, 0x03, 0x1a, 0x06, 0x2b, 0x00, 0x09, 0x00, 0x13, 0x00, 0x1e, 0x40, 0x1b, 0x0b, 0x0a,
+	0x01, 0x00, 0x04, 0x00, 0x49, 0x02, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x01, 0x3a,
+	0x00, 0x4e, 0x11, 0x17, 0x11, 0x14, 0x04, 0x09, 0x1a, 0x2b, 0x13, 0x35, 0x36, 0x35, 0x35, 0x23,
+	0x35, 0x33, 0x15, 0x10, 0x17, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x15, 0x10, 0x64, 0x60,
+	0x60, 0xf7, 0xc8, 0x60, 0x60, 0xf7, 0x03, 0xf4, 0x4a, 0x1b, 0xc7, 0x14, 0xf7, 0xd6, 0xfe, 0xb7,
+	0x18, 0x4a, 0x1b, 0xc7, 0x14, 0xf7, 0xd6, 0xfe, 0xb7, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x64,
+	0xfe, 0xc0, 0x03, 0x1a, 0x00, 0xf7, 0x00, 0x09, 0x00, 0x13, 0x00, 0x36, 0xb6, 0x0b, 0x0a, 0x01,
+	0x00, 0x04, 0x00, 0x49, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x0d, 0x03, 0x01, 0x01, 0x01, 0x00,
+	0x5f, 0x02, 0x01, 0x00, 0x00, 0x39, 0x00, 0x4e, 0x1b, 0x40, 0x0d, 0x03, 0x01, 0x01, 0x01, 0x00,
+	0x5f, 0x02, 0x01, 0x00, 0x00, 0x3c, 0x00, 0x4e, 0x59, 0xb6, 0x11, 0x17, 0x11, 0x14, 0x04, 0x09,
+	0x1a, 0x2b, 0x13, 0x35, 0x36, 0x35, 0x35, 0x23, 0x35, 0x33, 0x15, 0x10, 0x17, 0x35, 0x36, 0x35,
+	0x35, 0x23, 0x35, 0x33, 0x15, 0x10, 0x64, 0x60, 0x60, 0xf7, 0xc8, 0x60, 0x60, 0xf7, 0xfe, 0xc0,
+	0x4a, 0x1b, 0xc7, 0x14, 0xf7, 0xd6, 0xfe, 0xb7, 0x18, 0x4a, 0x1b, 0xc7, 0x14, 0xf7, 0xd6, 0xfe,
+	0xb7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x96, 0xfe, 0xd8, 0x03, 0xdd, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x4c, 0x40, 0x09, 0x0a, 0x09, 0x02, 0x01, 0x04, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x0f, 0x02, 0x01, 0x00, 0x04, 0x01, 0x03, 0x00, 0x03, 0x63, 0x00, 0x01, 0x01,
+	0x38, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x03, 0x03,
+	0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x13, 0x05, 0x09, 0x19, 0x2b, 0x01, 0x13,
+	0x05, 0x35, 0x05, 0x03, 0x33, 0x03, 0x25, 0x15, 0x25, 0x13, 0x01, 0xd7, 0x18, 0xfe, 0xa7, 0x01,
+	0x59, 0x18, 0xc5, 0x19, 0x01, 0x5a, 0xfe, 0xa6, 0x19, 0xfe, 0xd8, 0x04, 0x6f, 0x19, 0x94, 0x18,
+	0x02, 0x1e, 0xfd, 0xe2, 0x18, 0x94, 0x19, 0xfb, 0x91, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x96,
+	0xfe, 0xd8, 0x03, 0xdd, 0x05, 0xc8, 0x00, 0x13, 0x00, 0x54, 0x40, 0x11, 0x12, 0x11, 0x10, 0x0f,
+	0x0e, 0x0d, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01, 0x0c, 0x03, 0x00, 0x01, 0x4c, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x0f, 0x02, 0x01, 0x00, 0x04, 0x01, 0x03, 0x00, 0x03, 0x63, 0x00, 0x01, 0x01,
+	0x38, 0x01, 0x4e, 0x1b, 0x40, 0x18, 0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x03, 0x03,
+	0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f, 0x59, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11, 0x17, 0x05, 0x09, 0x19, 0x2b, 0x01, 0x13,
+	0x05, 0x35, 0x05, 0x11, 0x05, 0x35, 0x05, 0x03, 0x33, 0x03, 0x25, 0x15, 0x25, 0x11, 0x25, 0x15,
+	0x25, 0x13, 0x01, 0xd7, 0x18, 0xfe, 0xa7, 0x01, 0x59, 0xfe, 0xa7, 0x01, 0x59, 0x18, 0xc5, 0x19,
+	0x01, 0x5a, 0xfe, 0xa6, 0x01, 0x5a, 0xfe, 0xa6, 0x19, 0xfe, 0xd8, 0x02, 0x1f, 0x19, 0x94, 0x19,
+	0x01, 0xee, 0x19, 0x94, 0x18, 0x02, 0x1e, 0xfd, 0xe2, 0x18, 0x94, 0x19, 0xfe, 0x12, 0x19, 0x94,
+	0x19, 0xfd, 0xe1, 0x00, 0x00, 0x01, 0x00, 0x51, 0x02, 0x2b, 0x02, 0x7c, 0x04, 0x56, 0x00, 0x0b,
+	0x00, 0x1a, 0x40, 0x17, 0x02, 0x01, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x41, 0x00, 0x4e,
+	0x01, 0x00, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x03, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x01, 0x62, 0x6f, 0xa2, 0xa3, 0x73, 0x73, 0xa2,
+	0xa4, 0x02, 0x2b, 0xa4, 0x72, 0x73, 0xa2, 0xa3, 0x74, 0x73, 0xa1, 0x00, 0x00, 0x03, 0x00, 0xbc,
+	0x00, 0x00, 0x07, 0x43, 0x01, 0x01, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x12, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01, 0x5f, 0x08, 0x05, 0x07, 0x03,
+	0x06, 0x05, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x12, 0x04, 0x02, 0x02, 0x00, 0x00, 0x01,
+	0x5f, 0x08, 0x05, 0x07, 0x03, 0x06, 0x05, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x1a, 0x08,
+	0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06,
+	0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11,
+	0x21, 0x11, 0x21, 0x11, 0x21, 0x11, 0xbc, 0x01, 0x01, 0x01, 0xc2, 0x01, 0x01, 0x01, 0xc2, 0x01,
+	0x01, 0x01, 0x01, 0xfe, 0xff, 0x01, 0x01, 0xfe, 0xff, 0x01, 0x01, 0xfe, 0xff, 0x00, 0x00, 0x00,
+	0x00, 0x07, 0x00, 0x19, 0xff, 0xdb, 0x07, 0xe8, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x14, 0x00, 0x20,
+	0x00, 0x29, 0x00, 0x35, 0x00, 0x3e, 0x00, 0x42, 0x00, 0xfe, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40,
+	0x3a, 0x0f, 0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01,
+	0x07, 0x06, 0x05, 0x07, 0x69, 0x00, 0x0c, 0x0c, 0x38, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x38, 0x4d, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x61, 0x12, 0x08, 0x10, 0x03,
+	0x04, 0x04, 0x39, 0x4d, 0x14, 0x01, 0x0d, 0x0d, 0x39, 0x0d, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x3a, 0x00, 0x0c, 0x01, 0x0c, 0x85, 0x14, 0x01, 0x0d, 0x04, 0x0d, 0x86, 0x0f, 0x01,
+	0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05, 0x0b, 0x01, 0x07, 0x06, 0x05,
+	0x07, 0x69, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x38, 0x4d, 0x13, 0x0a, 0x11, 0x03,
+	0x06, 0x06, 0x04, 0x61, 0x12, 0x08, 0x10, 0x03, 0x04, 0x04, 0x39, 0x04, 0x4e, 0x1b, 0x40, 0x38,
+	0x00, 0x0c, 0x01, 0x0c, 0x85, 0x14, 0x01, 0x0d, 0x04, 0x0d, 0x86, 0x00, 0x01, 0x00, 0x03, 0x02,
+	0x01, 0x03, 0x69, 0x0f, 0x01, 0x02, 0x0e, 0x01, 0x00, 0x05, 0x02, 0x00, 0x69, 0x09, 0x01, 0x05,
+	0x0b, 0x01, 0x07, 0x06, 0x05, 0x07, 0x69, 0x13, 0x0a, 0x11, 0x03, 0x06, 0x06, 0x04, 0x61, 0x12,
+	0x08, 0x10, 0x03, 0x04, 0x04, 0x3c, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x3b, 0x3f, 0x3f, 0x37, 0x36,
+	0x2b, 0x2a, 0x22, 0x21, 0x16, 0x15, 0x0d, 0x0c, 0x01, 0x00, 0x3f, 0x42, 0x3f, 0x42, 0x41, 0x40,
+	0x3c, 0x3a, 0x36, 0x3e, 0x37, 0x3e, 0x31, 0x2f, 0x2a, 0x35, 0x2b, 0x35, 0x27, 0x25, 0x21, 0x29,
+	0x22, 0x29, 0x1c, 0x1a, 0x15, 0x20, 0x16, 0x20, 0x12, 0x10, 0x0c, 0x14, 0x0d, 0x14, 0x07, 0x05,
+	0x00, 0x0b, 0x01, 0x0b, 0x15, 0x09, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32,
+	0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x11, 0x34, 0x26, 0x23, 0x22, 0x11, 0x10, 0x01, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32, 0x11, 0x34, 0x26, 0x23, 0x22,
+	0x11, 0x10, 0x05, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x27, 0x32,
+	0x11, 0x34, 0x26, 0x23, 0x22, 0x11, 0x10, 0x05, 0x01, 0x33, 0x01, 0x01, 0x4f, 0x8d, 0xa9, 0xaa,
+	0x91, 0x90, 0xaa, 0xaa, 0x92, 0xa8, 0x5a, 0x4c, 0xa7, 0x03, 0x3d, 0x8e, 0xa9, 0xaa, 0x91, 0x90,
+	0xaa, 0xaa, 0x92, 0xa8, 0x5a, 0x4c, 0xa7, 0x03, 0x62, 0x8e, 0xa9, 0xaa, 0x91, 0x90, 0xab, 0xab,
+	0x92, 0xa9, 0x5b, 0x4c, 0xa7, 0xfa, 0x37, 0x04, 0x40, 0x87, 0xfb, 0xc0, 0x02, 0xe4, 0xca, 0xa8,
+	0xaa, 0xc8, 0xc7, 0xa9, 0xae, 0xc6, 0x63, 0x01, 0x11, 0x7b, 0x93, 0xfe, 0xf1, 0xfe, 0xf0, 0xfc,
+	0xb9, 0xc9, 0xa9, 0xaa, 0xc8, 0xc7, 0xa9, 0xae, 0xc6, 0x63, 0x01, 0x11, 0x7b, 0x93, 0xfe, 0xf0,
+	0xfe, 0xf1, 0x63, 0xca, 0xa8, 0xaa, 0xc8, 0xc7, 0xa9, 0xae, 0xc6, 0x63, 0x01, 0x11, 0x7b, 0x93,
+	0xfe, 0xf0, 0xfe, 0xf1, 0x88, 0x06, 0x12, 0xf9, 0xee, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x16,
+	0x03, 0xdb, 0x01, 0x6b, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x19, 0x40, 0x16, 0x02, 0x01, 0x01, 0x00,
+	0x01, 0x86, 0x00, 0x00, 0x00, 0x3a, 0x00, 0x4e, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03,
+	0x09, 0x17, 0x2b, 0x13, 0x13, 0x33, 0x03, 0x16, 0x77, 0xde, 0xda, 0x03, 0xdb, 0x02, 0x50, 0xfd,
+	0xb0, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x15, 0x03, 0xdb, 0x02, 0xc1, 0x06, 0x2b, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x05, 0x03, 0x04, 0x03, 0x01, 0x00, 0x01, 0x86, 0x02, 0x01,
+	0x00, 0x00, 0x3a, 0x00, 0x4e, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x06, 0x09, 0x17, 0x2b, 0x13, 0x13, 0x33, 0x03, 0x33, 0x13, 0x33, 0x03,
+	0x15, 0x76, 0xde, 0xd9, 0xdd, 0x76, 0xde, 0xd9, 0x03, 0xdb, 0x02, 0x50, 0xfd, 0xb0, 0x02, 0x50,
+	0xfd, 0xb0, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4a, 0x00, 0x63, 0x02, 0x37, 0x03, 0xdb, 0x00, 0x05,
+	0x00, 0x06, 0xb3, 0x05, 0x03, 0x01, 0x32, 0x2b, 0x09, 0x02, 0x07, 0x01, 0x01, 0x02, 0x37, 0xfe,
+	0xd8, 0x01, 0x28, 0x62, 0xfe, 0x75, 0x01, 0x8b, 0x03, 0x91, 0xfe, 0x8e, 0xfe, 0x8e, 0x4a, 0x01,
+	0xbc, 0x01, 0xbc, 0x00, 0x00, 0x01, 0x00, 0x72, 0x00, 0x63, 0x02, 0x5f, 0x03, 0xdb, 0x00, 0x05,
+	0x00, 0x06, 0xb3, 0x05, 0x03, 0x01, 0x32, 0x2b, 0x37, 0x01, 0x01, 0x37, 0x01, 0x01, 0x72, 0x01,
+	0x28, 0xfe, 0xd8, 0x63, 0x01, 0x8a, 0xfe, 0x76, 0xad, 0x01, 0x72, 0x01, 0x72, 0x4a, 0xfe, 0x44,
+	0xfe, 0x44, 0x00, 0x00, 0x00, 0x04, 0x00, 0xd2, 0x00, 0x00, 0x03, 0x56, 0x05, 0xc8, 0x00, 0x03,
+	0x00, 0x09, 0x00, 0x0d, 0x00, 0x13, 0x00, 0x68, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x1d, 0x0b,
+	0x07, 0x09, 0x03, 0x03, 0x03, 0x02, 0x5f, 0x06, 0x01, 0x02, 0x02, 0x38, 0x4d, 0x04, 0x01, 0x00,
+	0x00, 0x01, 0x5f, 0x0a, 0x05, 0x08, 0x03, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x1b, 0x06,
+	0x01, 0x02, 0x0b, 0x07, 0x09, 0x03, 0x03, 0x00, 0x02, 0x03, 0x67, 0x04, 0x01, 0x00, 0x00, 0x01,
+	0x5f, 0x0a, 0x05, 0x08, 0x03, 0x01, 0x01, 0x3c, 0x01, 0x4e, 0x59, 0x40, 0x22, 0x0e, 0x0e, 0x0a,
+	0x0a, 0x04, 0x04, 0x00, 0x00, 0x0e, 0x13, 0x0e, 0x13, 0x11, 0x10, 0x0a, 0x0d, 0x0a, 0x0d, 0x0c,
+	0x0b, 0x04, 0x09, 0x04, 0x09, 0x07, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0c, 0x09, 0x17, 0x2b,
+	0x33, 0x35, 0x33, 0x15, 0x03, 0x03, 0x11, 0x33, 0x11, 0x03, 0x01, 0x35, 0x33, 0x15, 0x03, 0x03,
+	0x11, 0x33, 0x11, 0x03, 0xd2, 0xc5, 0xac, 0x19, 0xc5, 0x18, 0x01, 0x12, 0xc5, 0xad, 0x18, 0xc5,
+	0x19, 0xc5, 0xc5, 0x01, 0x8b, 0x03, 0x15, 0x01, 0x28, 0xfe, 0xd8, 0xfc, 0xeb, 0xfe, 0x75, 0xc5,
+	0xc5, 0x01, 0x8b, 0x03, 0x15, 0x01, 0x28, 0xfe, 0xd8, 0xfc, 0xeb, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x06, 0x44, 0x02, 0xaa, 0x06, 0xda, 0x00, 0x03, 0x00, 0x26, 0xb1, 0x06, 0x64, 0x44, 0x40, 0x1b,
+	0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01,
+	0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0xb1, 0x06, 0x00, 0x44,
+	0x11, 0x35, 0x21, 0x15, 0x02, 0xaa, 0x06, 0x44, 0x96, 0x96, 0x00, 0x00, 0x00, 0x01, 0xfe, 0x48,
+	0xff, 0xdb, 0x03, 0x0f, 0x05, 0xed, 0x00, 0x03, 0x00, 0x2e, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40,
+	0x0c, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x02, 0x01, 0x01, 0x01, 0x39, 0x01, 0x4e, 0x1b, 0x40, 0x0a,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x59, 0x40, 0x0a, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x09, 0x17, 0x2b, 0x05, 0x01, 0x33, 0x01, 0xfe, 0x48, 0x04, 0x40,
+	0x87, 0xfb, 0xc1, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x00, 0x03, 0x00, 0x3c, 0x02, 0x9f, 0x03, 0x19,
+	0x06, 0x43, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x17, 0x00, 0x2f, 0x40, 0x2c, 0x17, 0x0f, 0x02, 0x02,
+	0x03, 0x01, 0x4c, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x56, 0x4d, 0x00, 0x02, 0x02,
+	0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x57, 0x00, 0x4e, 0x01, 0x00, 0x13, 0x11, 0x0b, 0x09, 0x05,
+	0x03, 0x00, 0x07, 0x01, 0x07, 0x05, 0x0b, 0x16, 0x2b, 0x01, 0x20, 0x11, 0x10, 0x21, 0x20, 0x13,
+	0x10, 0x01, 0x16, 0x33, 0x32, 0x11, 0x34, 0x26, 0x27, 0x27, 0x26, 0x23, 0x22, 0x11, 0x14, 0x16,
+	0x17, 0x01, 0xaa, 0xfe, 0x92, 0x01, 0x6e, 0x01, 0x6b, 0x04, 0xfd, 0xd6, 0x2d, 0x8e, 0xd6, 0x04,
+	0x06, 0x0f, 0x2e, 0x8f, 0xd5, 0x05, 0x05, 0x02, 0x9f, 0x01, 0xd2, 0x01, 0xd2, 0xfe, 0x2e, 0xfe,
+	0x2e, 0x01, 0x03, 0xab, 0x01, 0x78, 0x23, 0x43, 0x20, 0x4b, 0xaa, 0xfe, 0x87, 0x24, 0x41, 0x1f,
+	0x00, 0x02, 0x00, 0x17, 0x02, 0xb5, 0x03, 0x23, 0x06, 0x2d, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x32,
+	0x40, 0x2f, 0x0d, 0x01, 0x02, 0x01, 0x03, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x05, 0x01, 0x02, 0x03,
+	0x01, 0x00, 0x04, 0x02, 0x00, 0x68, 0x00, 0x01, 0x01, 0x54, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x55,
+	0x04, 0x4e, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x11, 0x12, 0x11, 0x07, 0x0b,
+	0x1a, 0x2b, 0x01, 0x35, 0x21, 0x35, 0x01, 0x33, 0x11, 0x33, 0x15, 0x23, 0x15, 0x01, 0x21, 0x11,
+	0x02, 0x04, 0xfe, 0x13, 0x01, 0xed, 0x8a, 0x95, 0x95, 0xfe, 0x1a, 0x01, 0x65, 0x02, 0xb5, 0xfb,
+	0x59, 0x02, 0x24, 0xfd, 0xe4, 0x61, 0xfb, 0x01, 0x5c, 0x01, 0x8a, 0x00, 0x00, 0x01, 0x00, 0x7a,
+	0x02, 0x9f, 0x02, 0xd4, 0x06, 0x2d, 0x00, 0x20, 0x00, 0x33, 0x40, 0x30, 0x01, 0x01, 0x00, 0x01,
+	0x00, 0x01, 0x05, 0x00, 0x02, 0x4c, 0x00, 0x04, 0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x03,
+	0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x54, 0x4d, 0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05,
+	0x57, 0x05, 0x4e, 0x28, 0x21, 0x11, 0x11, 0x28, 0x22, 0x06, 0x0b, 0x1c, 0x2b, 0x13, 0x35, 0x16,
+	0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02, 0x23, 0x23, 0x11, 0x21, 0x15, 0x21, 0x15, 0x33,
+	0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x7a, 0x6f, 0x63, 0x3d, 0x5a, 0x39,
+	0x1b, 0x21, 0x47, 0x71, 0x52, 0x7f, 0x02, 0x31, 0xfe, 0x50, 0x31, 0x61, 0x97, 0x67, 0x36, 0x43,
+	0x71, 0x95, 0x53, 0x2a, 0x5e, 0x02, 0xb2, 0x69, 0x24, 0x1e, 0x34, 0x47, 0x29, 0x2b, 0x45, 0x30,
+	0x19, 0x01, 0xbb, 0x68, 0xf9, 0x24, 0x45, 0x67, 0x43, 0x4c, 0x6b, 0x44, 0x1f, 0x09, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x3f, 0x02, 0x9f, 0x03, 0x02, 0x06, 0x43, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x37,
+	0x40, 0x34, 0x10, 0x01, 0x03, 0x02, 0x11, 0x01, 0x00, 0x03, 0x00, 0x01, 0x05, 0x04, 0x03, 0x4c,
+	0x00, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02,
+	0x56, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x57, 0x01, 0x4e, 0x24, 0x22, 0x23,
+	0x24, 0x24, 0x21, 0x06, 0x0b, 0x1c, 0x2b, 0x13, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23,
+	0x22, 0x26, 0x35, 0x34, 0x12, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x01, 0x34, 0x23, 0x22,
+	0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0xdf, 0x62, 0x99, 0x8a, 0x9e, 0xb2, 0xa6, 0xa9, 0xc2, 0xe8,
+	0xcf, 0x62, 0x6e, 0x85, 0x4b, 0xfe, 0xe9, 0x01, 0x91, 0xb9, 0x60, 0x73, 0x77, 0x5c, 0xb9, 0x04,
+	0x87, 0x68, 0x95, 0x81, 0x97, 0xa3, 0xe9, 0xcb, 0xea, 0x01, 0x06, 0x21, 0x67, 0x30, 0xfd, 0xd2,
+	0xdc, 0x67, 0x57, 0x63, 0x81, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x66, 0x02, 0xb5, 0x03, 0x2e,
+	0x06, 0x2d, 0x00, 0x0a, 0x00, 0x24, 0x40, 0x21, 0x08, 0x01, 0x00, 0x01, 0x4b, 0x00, 0x00, 0x00,
+	0x01, 0x5f, 0x00, 0x01, 0x01, 0x54, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x00, 0x00,
+	0x00, 0x0a, 0x00, 0x0a, 0x11, 0x14, 0x04, 0x0b, 0x18, 0x2b, 0x13, 0x36, 0x36, 0x37, 0x01, 0x21,
+	0x35, 0x21, 0x15, 0x00, 0x03, 0xb1, 0x16, 0x50, 0x5b, 0x01, 0x2f, 0xfd, 0xc5, 0x02, 0xc8, 0xfe,
+	0x55, 0x33, 0x02, 0xb5, 0x67, 0x98, 0x84, 0x01, 0x86, 0x6f, 0x6f, 0xfe, 0x08, 0xfe, 0xef, 0x00,
+	0x00, 0x03, 0x00, 0x4a, 0x02, 0x9f, 0x03, 0x30, 0x06, 0x43, 0x00, 0x13, 0x00, 0x1e, 0x00, 0x2b,
+	0x00, 0x25, 0x40, 0x22, 0x0a, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00,
+	0x00, 0x00, 0x56, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x57, 0x01, 0x4e, 0x2a,
+	0x28, 0x28, 0x24, 0x04, 0x0b, 0x1a, 0x2b, 0x01, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15,
+	0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x25, 0x36, 0x35, 0x34, 0x26,
+	0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x17, 0x06, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35,
+	0x34, 0x26, 0x27, 0x01, 0x26, 0xa2, 0xb8, 0x95, 0x8a, 0xa9, 0xcf, 0xfb, 0xd2, 0xa7, 0xa5, 0xc8,
+	0x01, 0x99, 0x9b, 0x66, 0x58, 0x52, 0x64, 0x5c, 0x11, 0x5a, 0x3f, 0x7b, 0x64, 0x61, 0x7a, 0x4b,
+	0x6d, 0x04, 0x98, 0x5b, 0x6e, 0x64, 0x7e, 0x6a, 0x58, 0x7f, 0x6a, 0x62, 0x9c, 0x6f, 0x8c, 0x85,
+	0x6f, 0x9c, 0x8e, 0x53, 0x5e, 0x39, 0x43, 0x3f, 0x35, 0x31, 0x4f, 0x8e, 0x37, 0x52, 0x3d, 0x4d,
+	0x5e, 0x51, 0x40, 0x33, 0x48, 0x33, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3f, 0x02, 0x9f, 0x03, 0x02,
+	0x06, 0x43, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x37, 0x40, 0x34, 0x00, 0x01, 0x04, 0x05, 0x11, 0x01,
+	0x03, 0x00, 0x10, 0x01, 0x02, 0x03, 0x03, 0x4c, 0x00, 0x04, 0x00, 0x00, 0x03, 0x04, 0x00, 0x69,
+	0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x56, 0x4d, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00,
+	0x02, 0x02, 0x57, 0x02, 0x4e, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06, 0x0b, 0x1c, 0x2b, 0x01,
+	0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x02, 0x23, 0x22, 0x27,
+	0x35, 0x16, 0x33, 0x20, 0x01, 0x14, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x02, 0x61,
+	0x62, 0x99, 0x8a, 0x9d, 0xb1, 0xa6, 0xa9, 0xc3, 0xe9, 0xcf, 0x62, 0x6e, 0x85, 0x4b, 0x01, 0x17,
+	0xfe, 0x6f, 0xb9, 0x60, 0x73, 0x77, 0x5c, 0xb9, 0x04, 0x5a, 0x67, 0x94, 0x82, 0x97, 0xa3, 0xea,
+	0xca, 0xea, 0xfe, 0xfa, 0x20, 0x68, 0x30, 0x02, 0x2e, 0xdd, 0x67, 0x57, 0x64, 0x80, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x4e, 0x02, 0xf0, 0x03, 0x32, 0x05, 0x40, 0x00, 0x0b, 0x00, 0x2c, 0x40, 0x29,
+	0x00, 0x02, 0x01, 0x05, 0x02, 0x57, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67,
+	0x00, 0x02, 0x02, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x02, 0x05, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x0b, 0x1b, 0x2b, 0x01, 0x35, 0x21, 0x35, 0x21, 0x35,
+	0x33, 0x15, 0x21, 0x15, 0x21, 0x15, 0x01, 0x88, 0xfe, 0xc6, 0x01, 0x3a, 0x6f, 0x01, 0x3b, 0xfe,
+	0xc5, 0x02, 0xf0, 0xfb, 0x59, 0xfc, 0xfc, 0x59, 0xfb, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4b,
+	0x03, 0xeb, 0x03, 0x36, 0x04, 0x44, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x0b, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x4b, 0x02, 0xeb, 0x03,
+	0xeb, 0x59, 0x59, 0x00, 0x00, 0x02, 0x00, 0x16, 0x03, 0x65, 0x03, 0x6a, 0x04, 0xcb, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f,
+	0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06,
+	0x0b, 0x17, 0x2b, 0x13, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x16, 0x03, 0x54, 0xfc, 0xac,
+	0x03, 0x54, 0x03, 0x65, 0x66, 0x66, 0x01, 0x00, 0x66, 0x66, 0x00, 0x00, 0x00, 0x01, 0x00, 0xbf,
+	0x02, 0x04, 0x02, 0x1f, 0x06, 0x68, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b,
+	0x01, 0x15, 0x26, 0x02, 0x35, 0x34, 0x12, 0x37, 0x15, 0x06, 0x06, 0x15, 0x14, 0x16, 0x02, 0x1f,
+	0xa2, 0xbe, 0xbe, 0xa2, 0x6f, 0x5d, 0x5d, 0x02, 0x55, 0x51, 0x58, 0x01, 0x2e, 0xac, 0xab, 0x01,
+	0x2f, 0x58, 0x52, 0x60, 0xdc, 0xa4, 0xa5, 0xdc, 0x00, 0x01, 0x00, 0x9a, 0x02, 0x04, 0x01, 0xfa,
+	0x06, 0x68, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b, 0x13, 0x35, 0x16, 0x12,
+	0x15, 0x14, 0x02, 0x07, 0x35, 0x36, 0x36, 0x35, 0x34, 0x26, 0x9a, 0xa2, 0xbe, 0xbe, 0xa2, 0x6e,
+	0x5d, 0x5d, 0x06, 0x16, 0x52, 0x58, 0xfe, 0xd1, 0xab, 0xac, 0xfe, 0xd2, 0x58, 0x51, 0x60, 0xdd,
+	0xa4, 0xa4, 0xdc, 0x00, 0x00, 0x01, 0x00, 0x73, 0x02, 0xb5, 0x02, 0xeb, 0x05, 0x4f, 0x00, 0x10,
+	0x00, 0x4c, 0xb6, 0x0f, 0x03, 0x02, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x22, 0x50, 0x58, 0x40,
+	0x14, 0x00, 0x03, 0x02, 0x00, 0x03, 0x59, 0x01, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02,
+	0x02, 0x02, 0x55, 0x02, 0x4e, 0x1b, 0x40, 0x15, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01, 0x03, 0x69,
+	0x00, 0x00, 0x00, 0x02, 0x5f, 0x05, 0x04, 0x02, 0x02, 0x02, 0x55, 0x02, 0x4e, 0x59, 0x40, 0x0d,
+	0x00, 0x00, 0x00, 0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x0b, 0x1a, 0x2b, 0x13, 0x11,
+	0x33, 0x15, 0x36, 0x33, 0x32, 0x15, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x73,
+	0x94, 0x76, 0x9d, 0xd1, 0x94, 0x29, 0x39, 0x7e, 0x70, 0x02, 0xb5, 0x02, 0x8b, 0x7a, 0x89, 0xc6,
+	0xfe, 0x2c, 0x01, 0xad, 0x4f, 0x38, 0x8f, 0xfe, 0x5b, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x3c,
+	0xfe, 0xb6, 0x03, 0x19, 0x02, 0x5a, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x17, 0x00, 0x2f, 0x40, 0x2c,
+	0x17, 0x0f, 0x02, 0x02, 0x03, 0x01, 0x4c, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4c,
+	0x4d, 0x00, 0x02, 0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x00, 0x4d, 0x00, 0x4e, 0x01, 0x00, 0x13,
+	0x11, 0x0b, 0x09, 0x05, 0x03, 0x00, 0x07, 0x01, 0x07, 0x05, 0x0a, 0x16, 0x2b, 0x01, 0x20, 0x11,
+	0x10, 0x21, 0x20, 0x13, 0x10, 0x01, 0x16, 0x33, 0x32, 0x11, 0x34, 0x26, 0x27, 0x27, 0x26, 0x23,
+	0x22, 0x11, 0x14, 0x16, 0x17, 0x01, 0xaa, 0xfe, 0x92, 0x01, 0x6e, 0x01, 0x6b, 0x04, 0xfd, 0xd6,
+	0x2d, 0x8e, 0xd6, 0x04, 0x06, 0x0f, 0x2e, 0x8f, 0xd5, 0x05, 0x05, 0xfe, 0xb6, 0x01, 0xd2, 0x01,
+	0xd2, 0xfe, 0x2e, 0xfe, 0x2e, 0x01, 0x03, 0xab, 0x01, 0x78, 0x23, 0x43, 0x20, 0x4b, 0xaa, 0xfe,
+	0x87, 0x24, 0x41, 0x1f, 0x00, 0x01, 0x00, 0x9d, 0xfe, 0xcc, 0x03, 0x0c, 0x02, 0x5a, 0x00, 0x09,
+	0x00, 0x22, 0x40, 0x1f, 0x06, 0x05, 0x04, 0x03, 0x04, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x02,
+	0x5f, 0x03, 0x01, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x15, 0x11,
+	0x04, 0x0a, 0x18, 0x2b, 0x13, 0x35, 0x33, 0x11, 0x07, 0x35, 0x25, 0x11, 0x33, 0x15, 0x9d, 0xed,
+	0xed, 0x01, 0x82, 0xed, 0xfe, 0xcc, 0x58, 0x02, 0xbd, 0x2f, 0x5b, 0x4d, 0xfc, 0xca, 0x58, 0x00,
+	0x00, 0x01, 0x00, 0x4c, 0xfe, 0xcc, 0x02, 0xc1, 0x02, 0x5a, 0x00, 0x19, 0x00, 0x34, 0x40, 0x31,
+	0x0c, 0x01, 0x00, 0x01, 0x0b, 0x01, 0x02, 0x00, 0x02, 0x4c, 0x01, 0x01, 0x02, 0x01, 0x4b, 0x00,
+	0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4c, 0x4d, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01,
+	0x03, 0x03, 0x49, 0x03, 0x4e, 0x00, 0x00, 0x00, 0x19, 0x00, 0x19, 0x18, 0x23, 0x28, 0x05, 0x0a,
+	0x19, 0x2b, 0x13, 0x35, 0x36, 0x3f, 0x02, 0x36, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33,
+	0x32, 0x16, 0x15, 0x14, 0x06, 0x07, 0x07, 0x06, 0x07, 0x21, 0x15, 0x4c, 0x33, 0x7a, 0x51, 0x49,
+	0x91, 0xb6, 0x6a, 0x9c, 0x93, 0x89, 0x91, 0xac, 0x58, 0x7c, 0x34, 0x9c, 0x1e, 0x01, 0xbc, 0xfe,
+	0xcc, 0x67, 0x60, 0x66, 0x42, 0x3c, 0x77, 0x71, 0xa3, 0x48, 0x68, 0x38, 0x87, 0x73, 0x4e, 0x78,
+	0x5a, 0x26, 0x71, 0x76, 0x67, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x72, 0xfe, 0xb6, 0x02, 0xd4,
+	0x02, 0x5a, 0x00, 0x21, 0x00, 0x3f, 0x40, 0x3c, 0x15, 0x01, 0x03, 0x04, 0x14, 0x01, 0x02, 0x03,
+	0x1b, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00, 0x05, 0x4c, 0x00, 0x02,
+	0x00, 0x01, 0x00, 0x02, 0x01, 0x69, 0x00, 0x03, 0x03, 0x04, 0x61, 0x00, 0x04, 0x04, 0x4c, 0x4d,
+	0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x4d, 0x05, 0x4e, 0x27, 0x23, 0x23, 0x21, 0x23,
+	0x24, 0x06, 0x0a, 0x1c, 0x2b, 0x13, 0x35, 0x16, 0x17, 0x16, 0x33, 0x32, 0x35, 0x34, 0x26, 0x23,
+	0x23, 0x35, 0x33, 0x32, 0x36, 0x35, 0x34, 0x23, 0x22, 0x07, 0x35, 0x36, 0x33, 0x20, 0x15, 0x14,
+	0x07, 0x04, 0x15, 0x14, 0x06, 0x23, 0x22, 0x72, 0x16, 0x0b, 0x7e, 0x43, 0xe2, 0x97, 0x8b, 0x3b,
+	0x33, 0x7f, 0x91, 0xb7, 0x5d, 0x87, 0x84, 0x66, 0x01, 0x44, 0xe7, 0x01, 0x0c, 0xc7, 0xa7, 0x55,
+	0xfe, 0xd2, 0x6f, 0x08, 0x03, 0x28, 0xaf, 0x5c, 0x62, 0x50, 0x5f, 0x52, 0x85, 0x32, 0x67, 0x24,
+	0xcf, 0x9c, 0x42, 0x31, 0xba, 0x7b, 0x91, 0x00, 0x00, 0x02, 0x00, 0x17, 0xfe, 0xcc, 0x03, 0x23,
+	0x02, 0x44, 0x00, 0x0a, 0x00, 0x0d, 0x00, 0x32, 0x40, 0x2f, 0x0d, 0x01, 0x02, 0x01, 0x03, 0x01,
+	0x00, 0x02, 0x02, 0x4c, 0x05, 0x01, 0x02, 0x03, 0x01, 0x00, 0x04, 0x02, 0x00, 0x68, 0x00, 0x01,
+	0x01, 0x48, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x49, 0x04, 0x4e, 0x00, 0x00, 0x0c, 0x0b, 0x00, 0x0a,
+	0x00, 0x0a, 0x11, 0x11, 0x12, 0x11, 0x07, 0x0a, 0x1a, 0x2b, 0x01, 0x35, 0x21, 0x35, 0x01, 0x33,
+	0x11, 0x33, 0x15, 0x23, 0x15, 0x01, 0x21, 0x11, 0x02, 0x04, 0xfe, 0x13, 0x01, 0xed, 0x8a, 0x95,
+	0x95, 0xfe, 0x1a, 0x01, 0x65, 0xfe, 0xcc, 0xfb, 0x59, 0x02, 0x24, 0xfd, 0xe4, 0x61, 0xfb, 0x01,
+	0x5c, 0x01, 0x8a, 0x00, 0x00, 0x01, 0x00, 0x7a, 0xfe, 0xb6, 0x02, 0xd4, 0x02, 0x44, 0x00, 0x20,
+	0x00, 0x33, 0x40, 0x30, 0x01, 0x01, 0x00, 0x01, 0x00, 0x01, 0x05, 0x00, 0x02, 0x4c, 0x00, 0x04,
+	0x00, 0x01, 0x00, 0x04, 0x01, 0x69, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x48, 0x4d,
+	0x00, 0x00, 0x00, 0x05, 0x61, 0x00, 0x05, 0x05, 0x4d, 0x05, 0x4e, 0x28, 0x21, 0x11, 0x11, 0x28,
+	0x22, 0x06, 0x0a, 0x1c, 0x2b, 0x13, 0x35, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x02,
+	0x23, 0x23, 0x11, 0x21, 0x15, 0x21, 0x15, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23,
+	0x22, 0x26, 0x7a, 0x6f, 0x63, 0x3d, 0x5a, 0x39, 0x1b, 0x21, 0x47, 0x71, 0x52, 0x7f, 0x02, 0x31,
+	0xfe, 0x50, 0x31, 0x61, 0x97, 0x67, 0x36, 0x43, 0x71, 0x95, 0x53, 0x2a, 0x5e, 0xfe, 0xc9, 0x69,
+	0x24, 0x1e, 0x34, 0x47, 0x29, 0x2b, 0x45, 0x30, 0x19, 0x01, 0xbb, 0x68, 0xf9, 0x24, 0x45, 0x67,
+	0x43, 0x4c, 0x6b, 0x44, 0x1f, 0x09, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3f, 0xfe, 0xb6, 0x03, 0x02,
+	0x02, 0x5a, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x37, 0x40, 0x34, 0x10, 0x01, 0x03, 0x02, 0x11, 0x01,
+	0x00, 0x03, 0x00, 0x01, 0x05, 0x04, 0x03, 0x4c, 0x00, 0x00, 0x00, 0x04, 0x05, 0x00, 0x04, 0x69,
+	0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x4c, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00,
+	0x01, 0x01, 0x4d, 0x01, 0x4e, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06, 0x0a, 0x1c, 0x2b, 0x37,
+	0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x12, 0x33, 0x32, 0x17,
+	0x15, 0x26, 0x23, 0x20, 0x01, 0x34, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0xdf, 0x62,
+	0x99, 0x8a, 0x9e, 0xb2, 0xa6, 0xa9, 0xc2, 0xe8, 0xcf, 0x62, 0x6e, 0x85, 0x4b, 0xfe, 0xe9, 0x01,
+	0x91, 0xb9, 0x60, 0x73, 0x77, 0x5c, 0xb9, 0x9e, 0x68, 0x95, 0x81, 0x97, 0xa3, 0xe9, 0xcb, 0xea,
+	0x01, 0x06, 0x21, 0x67, 0x30, 0xfd, 0xd2, 0xdc, 0x67, 0x57, 0x63, 0x81, 0x00, 0x01, 0x00, 0x66,
+	0xfe, 0xcc, 0x03, 0x2e, 0x02, 0x44, 0x00, 0x0a, 0x00, 0x24, 0x40, 0x21, 0x08, 0x01, 0x00, 0x01,
+	0x4b, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00, 0x01, 0x01, 0x48, 0x4d, 0x03, 0x01, 0x02, 0x02, 0x49,
+	0x02, 0x4e, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x0a, 0x11, 0x14, 0x04, 0x0a, 0x18, 0x2b, 0x13, 0x36,
+	0x36, 0x37, 0x01, 0x21, 0x35, 0x21, 0x15, 0x00, 0x03, 0xb1, 0x16, 0x50, 0x5b, 0x01, 0x2f, 0xfd,
+	0xc5, 0x02, 0xc8, 0xfe, 0x55, 0x33, 0xfe, 0xcc, 0x67, 0x98, 0x84, 0x01, 0x86, 0x6f, 0x6f, 0xfe,
+	0x08, 0xfe, 0xef, 0x00, 0x00, 0x03, 0x00, 0x4a, 0xfe, 0xb6, 0x03, 0x30, 0x02, 0x5a, 0x00, 0x13,
+	0x00, 0x1e, 0x00, 0x2b, 0x00, 0x25, 0x40, 0x22, 0x0a, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x00, 0x02,
+	0x02, 0x00, 0x61, 0x00, 0x00, 0x00, 0x4c, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x4d, 0x01, 0x4e, 0x2a, 0x28, 0x28, 0x24, 0x04, 0x0a, 0x1a, 0x2b, 0x25, 0x26, 0x35, 0x34, 0x36,
+	0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x25,
+	0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x17, 0x06, 0x06, 0x15, 0x14, 0x16,
+	0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x27, 0x01, 0x26, 0xa2, 0xb8, 0x95, 0x8a, 0xa9, 0xcf, 0xfb,
+	0xd2, 0xa7, 0xa5, 0xc8, 0x01, 0x99, 0x9b, 0x66, 0x58, 0x52, 0x64, 0x5c, 0x11, 0x5a, 0x3f, 0x7b,
+	0x64, 0x61, 0x7a, 0x4b, 0x6d, 0xaf, 0x5b, 0x6e, 0x64, 0x7e, 0x6a, 0x58, 0x7f, 0x6a, 0x62, 0x9c,
+	0x6f, 0x8c, 0x85, 0x6f, 0x9c, 0x8e, 0x53, 0x5e, 0x39, 0x43, 0x3f, 0x35, 0x31, 0x4f, 0x8e, 0x37,
+	0x52, 0x3d, 0x4d, 0x5e, 0x51, 0x40, 0x33, 0x48, 0x33, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3f,
+	0xfe, 0xb6, 0x03, 0x02, 0x02, 0x5a, 0x00, 0x14, 0x00, 0x1e, 0x00, 0x37, 0x40, 0x34, 0x00, 0x01,
+	0x04, 0x05, 0x11, 0x01, 0x03, 0x00, 0x10, 0x01, 0x02, 0x03, 0x03, 0x4c, 0x00, 0x04, 0x00, 0x00,
+	0x03, 0x04, 0x00, 0x69, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x01, 0x4c, 0x4d, 0x00, 0x03,
+	0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x4d, 0x02, 0x4e, 0x24, 0x22, 0x23, 0x24, 0x24, 0x21, 0x06,
+	0x0a, 0x1c, 0x2b, 0x25, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x02, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x20, 0x01, 0x14, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26,
+	0x23, 0x22, 0x02, 0x61, 0x62, 0x99, 0x8a, 0x9d, 0xb1, 0xa6, 0xa9, 0xc3, 0xe9, 0xcf, 0x62, 0x6e,
+	0x85, 0x4b, 0x01, 0x17, 0xfe, 0x6f, 0xb9, 0x60, 0x73, 0x77, 0x5c, 0xb9, 0x71, 0x67, 0x94, 0x82,
+	0x97, 0xa3, 0xea, 0xca, 0xea, 0xfe, 0xfa, 0x20, 0x68, 0x30, 0x02, 0x2e, 0xdd, 0x67, 0x57, 0x64,
+	0x80, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x4e, 0xff, 0x07, 0x03, 0x32, 0x01, 0x57, 0x00, 0x0b,
+	0x00, 0x27, 0x40, 0x24, 0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x05, 0x01, 0x00, 0x67, 0x06, 0x01,
+	0x05, 0x05, 0x02, 0x5f, 0x00, 0x02, 0x02, 0x4a, 0x05, 0x4e, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x0a, 0x1b, 0x2b, 0x05, 0x35, 0x21, 0x35, 0x21, 0x35, 0x33,
+	0x15, 0x21, 0x15, 0x21, 0x15, 0x01, 0x88, 0xfe, 0xc6, 0x01, 0x3a, 0x6f, 0x01, 0x3b, 0xfe, 0xc5,
+	0xf9, 0xfb, 0x59, 0xfc, 0xfc, 0x59, 0xfb, 0x00, 0x00, 0x01, 0x00, 0x4b, 0x00, 0x02, 0x03, 0x36,
+	0x00, 0x5b, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x0a, 0x17, 0x2b, 0x37, 0x35, 0x21, 0x15, 0x4b, 0x02, 0xeb, 0x02, 0x59, 0x59, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x16, 0xff, 0x7c, 0x03, 0x6a, 0x00, 0xe2, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f,
+	0x40, 0x2c, 0x00, 0x02, 0x05, 0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00,
+	0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x04, 0x04, 0x00, 0x00,
+	0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x0a, 0x17, 0x2b, 0x17,
+	0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x16, 0x03, 0x54, 0xfc, 0xac, 0x03, 0x54, 0x84, 0x66,
+	0x66, 0x01, 0x00, 0x66, 0x66, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xbf, 0xfe, 0x1b, 0x02, 0x1f,
+	0x02, 0x7f, 0x00, 0x0d, 0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b, 0x01, 0x15, 0x26, 0x02,
+	0x35, 0x34, 0x12, 0x37, 0x15, 0x06, 0x06, 0x15, 0x14, 0x16, 0x02, 0x1f, 0xa2, 0xbe, 0xbe, 0xa2,
+	0x6f, 0x5d, 0x5d, 0xfe, 0x6c, 0x51, 0x58, 0x01, 0x2e, 0xac, 0xab, 0x01, 0x2f, 0x58, 0x52, 0x60,
+	0xdc, 0xa4, 0xa5, 0xdc, 0x00, 0x01, 0x00, 0x9a, 0xfe, 0x1b, 0x01, 0xfa, 0x02, 0x7f, 0x00, 0x0d,
+	0x00, 0x06, 0xb3, 0x07, 0x01, 0x01, 0x32, 0x2b, 0x13, 0x35, 0x16, 0x12, 0x15, 0x14, 0x02, 0x07,
+	0x35, 0x36, 0x36, 0x35, 0x34, 0x26, 0x9a, 0xa2, 0xbe, 0xbe, 0xa2, 0x6e, 0x5d, 0x5d, 0x02, 0x2d,
+	0x52, 0x58, 0xfe, 0xd1, 0xab, 0xac, 0xfe, 0xd2, 0x58, 0x51, 0x60, 0xdd, 0xa4, 0xa4, 0xdc, 0x00,
+	0x00, 0x01, 0x00, 0x73, 0xfe, 0xcc, 0x02, 0xeb, 0x01, 0x66, 0x00, 0x10, 0x00, 0x4d, 0xb6, 0x0f,
+	0x03, 0x02, 0x02, 0x03, 0x01, 0x4c, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40, 0x13, 0x00, 0x03, 0x03,
+	0x00, 0x61, 0x01, 0x01, 0x00, 0x00, 0x4a, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x49, 0x02, 0x4e,
+	0x1b, 0x40, 0x17, 0x00, 0x00, 0x00, 0x4a, 0x4d, 0x00, 0x03, 0x03, 0x01, 0x61, 0x00, 0x01, 0x01,
+	0x4a, 0x4d, 0x05, 0x04, 0x02, 0x02, 0x02, 0x49, 0x02, 0x4e, 0x59, 0x40, 0x0d, 0x00, 0x00, 0x00,
+	0x10, 0x00, 0x10, 0x23, 0x12, 0x22, 0x11, 0x06, 0x0a, 0x1a, 0x2b, 0x13, 0x11, 0x33, 0x15, 0x36,
+	0x33, 0x32, 0x15, 0x11, 0x23, 0x11, 0x34, 0x26, 0x23, 0x22, 0x07, 0x11, 0x73, 0x94, 0x76, 0x9d,
+	0xd1, 0x94, 0x29, 0x39, 0x7e, 0x70, 0xfe, 0xcc, 0x02, 0x8b, 0x7a, 0x89, 0xc6, 0xfe, 0x2c, 0x01,
+	0xad, 0x4f, 0x38, 0x8f, 0xfe, 0x5b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8c, 0x00, 0x00, 0x04, 0x73,
+	0x05, 0xc8, 0x00, 0x13, 0x00, 0xb8, 0xb7, 0x0e, 0x0a, 0x07, 0x03, 0x05, 0x04, 0x01, 0x4c, 0x4b,
+	0xb0, 0x1b, 0x50, 0x58, 0x40, 0x1c, 0x03, 0x01, 0x02, 0x06, 0x01, 0x04, 0x05, 0x02, 0x04, 0x69,
+	0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x07, 0x02, 0x05, 0x05, 0x39,
+	0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40, 0x21, 0x00, 0x03, 0x02, 0x04, 0x03, 0x59,
+	0x00, 0x02, 0x06, 0x01, 0x04, 0x05, 0x02, 0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00,
+	0x00, 0x38, 0x4d, 0x08, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x22, 0x00, 0x02, 0x00, 0x06, 0x04, 0x02, 0x06, 0x67, 0x00, 0x03, 0x00, 0x04, 0x05,
+	0x03, 0x04, 0x69, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x08, 0x07, 0x02,
+	0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x20, 0x00, 0x00, 0x00, 0x01, 0x03, 0x00, 0x01, 0x67,
+	0x00, 0x02, 0x00, 0x06, 0x04, 0x02, 0x06, 0x67, 0x00, 0x03, 0x00, 0x04, 0x05, 0x03, 0x04, 0x69,
+	0x08, 0x07, 0x02, 0x05, 0x05, 0x3c, 0x05, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x10, 0x00, 0x00, 0x00,
+	0x13, 0x00, 0x13, 0x11, 0x12, 0x22, 0x12, 0x11, 0x11, 0x11, 0x09, 0x09, 0x1d, 0x2b, 0x33, 0x11,
+	0x21, 0x15, 0x21, 0x11, 0x21, 0x15, 0x36, 0x33, 0x15, 0x26, 0x23, 0x22, 0x07, 0x11, 0x23, 0x11,
+	0x21, 0x11, 0x8c, 0x03, 0x2f, 0xfd, 0x96, 0x01, 0xd5, 0x8c, 0xc1, 0x18, 0x0e, 0xa4, 0x83, 0xc5,
+	0xfe, 0xf0, 0x05, 0xc8, 0x9d, 0xfe, 0x35, 0xb1, 0xc4, 0xbe, 0x02, 0xb7, 0xfe, 0x00, 0x02, 0xc5,
+	0xfd, 0x3b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8c, 0x00, 0x00, 0x03, 0xe7, 0x05, 0xed, 0x00, 0x26,
+	0x00, 0x81, 0x40, 0x0f, 0x00, 0x01, 0x00, 0x0b, 0x01, 0x01, 0x01, 0x00, 0x02, 0x4c, 0x16, 0x01,
+	0x05, 0x01, 0x4b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x0a, 0x01, 0x01, 0x09, 0x01, 0x02,
+	0x03, 0x01, 0x02, 0x67, 0x08, 0x01, 0x03, 0x07, 0x01, 0x04, 0x05, 0x03, 0x04, 0x67, 0x00, 0x00,
+	0x00, 0x0b, 0x61, 0x00, 0x0b, 0x0b, 0x3e, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06,
+	0x39, 0x06, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x0b, 0x00, 0x00, 0x01, 0x0b, 0x00, 0x69, 0x0a, 0x01,
+	0x01, 0x09, 0x01, 0x02, 0x03, 0x01, 0x02, 0x67, 0x08, 0x01, 0x03, 0x07, 0x01, 0x04, 0x05, 0x03,
+	0x04, 0x67, 0x00, 0x05, 0x05, 0x06, 0x5f, 0x00, 0x06, 0x06, 0x3c, 0x06, 0x4e, 0x59, 0x40, 0x12,
+	0x26, 0x24, 0x21, 0x20, 0x1f, 0x1e, 0x11, 0x15, 0x11, 0x14, 0x11, 0x11, 0x11, 0x13, 0x22, 0x0c,
+	0x09, 0x1f, 0x2b, 0x01, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x15, 0x33, 0x15, 0x23, 0x15, 0x33,
+	0x15, 0x23, 0x15, 0x14, 0x06, 0x07, 0x21, 0x15, 0x21, 0x35, 0x36, 0x36, 0x35, 0x35, 0x23, 0x35,
+	0x33, 0x35, 0x23, 0x35, 0x33, 0x35, 0x34, 0x36, 0x33, 0x32, 0x03, 0xca, 0x7c, 0x73, 0x5c, 0x5c,
+	0xec, 0xec, 0xec, 0xec, 0x57, 0x56, 0x02, 0x71, 0xfc, 0xa5, 0x69, 0x69, 0xc6, 0xc6, 0xc6, 0xc6,
+	0xcb, 0xbe, 0x68, 0x05, 0xcf, 0xa7, 0x31, 0x73, 0x73, 0x8e, 0x7c, 0xac, 0x7c, 0x10, 0x7a, 0xc2,
+	0x48, 0xad, 0xad, 0x21, 0x9e, 0x7d, 0x58, 0x7c, 0xac, 0x7c, 0x52, 0xd5, 0xe1, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x64, 0xff, 0xe7, 0x08, 0x70, 0x05, 0xc8, 0x00, 0x0a, 0x00, 0x13, 0x00, 0x29,
+	0x00, 0x4d, 0x01, 0x16, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x1c, 0x1f, 0x1e, 0x02, 0x07, 0x04,
+	0x3c, 0x01, 0x03, 0x07, 0x3d, 0x01, 0x01, 0x06, 0x2b, 0x29, 0x02, 0x0a, 0x01, 0x2a, 0x01, 0x02,
+	0x0a, 0x05, 0x4c, 0x14, 0x01, 0x02, 0x49, 0x1b, 0x40, 0x1c, 0x1f, 0x1e, 0x02, 0x0c, 0x04, 0x3c,
+	0x01, 0x0d, 0x07, 0x3d, 0x01, 0x01, 0x06, 0x2b, 0x29, 0x02, 0x0a, 0x01, 0x2a, 0x01, 0x02, 0x0a,
+	0x14, 0x01, 0x05, 0x02, 0x06, 0x4c, 0x59, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x2d, 0x0c, 0x08,
+	0x02, 0x07, 0x0d, 0x09, 0x02, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03,
+	0x01, 0x69, 0x00, 0x04, 0x04, 0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0b, 0x01, 0x0a, 0x0a,
+	0x02, 0x61, 0x0e, 0x05, 0x0f, 0x03, 0x02, 0x02, 0x39, 0x02, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x37, 0x00, 0x0c, 0x00, 0x0d, 0x03, 0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07, 0x09, 0x01,
+	0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x00, 0x04, 0x04,
+	0x00, 0x5f, 0x00, 0x00, 0x00, 0x38, 0x4d, 0x0f, 0x01, 0x02, 0x02, 0x39, 0x4d, 0x0b, 0x01, 0x0a,
+	0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42, 0x05, 0x4e, 0x1b, 0x40, 0x35, 0x00, 0x00, 0x00,
+	0x04, 0x0c, 0x00, 0x04, 0x69, 0x00, 0x0c, 0x00, 0x0d, 0x03, 0x0c, 0x0d, 0x69, 0x08, 0x01, 0x07,
+	0x09, 0x01, 0x06, 0x01, 0x07, 0x06, 0x67, 0x00, 0x03, 0x00, 0x01, 0x0a, 0x03, 0x01, 0x69, 0x0f,
+	0x01, 0x02, 0x02, 0x3c, 0x4d, 0x0b, 0x01, 0x0a, 0x0a, 0x05, 0x61, 0x0e, 0x01, 0x05, 0x05, 0x42,
+	0x05, 0x4e, 0x59, 0x59, 0x40, 0x23, 0x00, 0x00, 0x4d, 0x4b, 0x40, 0x3e, 0x3b, 0x39, 0x2e, 0x2c,
+	0x28, 0x26, 0x23, 0x22, 0x21, 0x20, 0x1d, 0x1c, 0x1b, 0x1a, 0x17, 0x15, 0x13, 0x11, 0x0d, 0x0b,
+	0x00, 0x0a, 0x00, 0x0a, 0x24, 0x21, 0x10, 0x09, 0x18, 0x2b, 0x33, 0x11, 0x21, 0x32, 0x16, 0x15,
+	0x14, 0x04, 0x21, 0x23, 0x11, 0x11, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x23, 0x01, 0x06,
+	0x23, 0x22, 0x26, 0x35, 0x11, 0x23, 0x35, 0x33, 0x35, 0x37, 0x15, 0x33, 0x15, 0x23, 0x11, 0x14,
+	0x16, 0x33, 0x32, 0x37, 0x17, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x27, 0x27, 0x26,
+	0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x17,
+	0x17, 0x16, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x64, 0x01, 0x62, 0xed, 0xd8, 0xfe, 0xdc, 0xfe,
+	0xf9, 0x2c, 0x24, 0xaa, 0xab, 0x85, 0xa4, 0x50, 0x04, 0x66, 0x4f, 0x35, 0x8c, 0x8b, 0x68, 0x68,
+	0xc5, 0xcf, 0xcf, 0x45, 0x46, 0x1e, 0x2d, 0x59, 0xa5, 0x78, 0x4c, 0x4c, 0x3e, 0x3d, 0x56, 0x70,
+	0x6f, 0xaf, 0x9c, 0x5b, 0x8f, 0x8a, 0x56, 0x4b, 0x4a, 0x38, 0x38, 0x49, 0x8d, 0x72, 0xbf, 0x95,
+	0x8c, 0x05, 0xc8, 0xc2, 0xd5, 0xe6, 0xff, 0xfd, 0xb4, 0x02, 0xeb, 0x96, 0x97, 0x98, 0x7b, 0xfa,
+	0xd2, 0x16, 0x89, 0x89, 0x01, 0xe6, 0x85, 0x99, 0x15, 0xae, 0x85, 0xfe, 0x38, 0x53, 0x53, 0x0b,
+	0x5f, 0x9f, 0x4a, 0x38, 0x39, 0x24, 0x3e, 0x19, 0x23, 0x2e, 0x7f, 0x52, 0x77, 0x86, 0x1d, 0x94,
+	0x2c, 0x33, 0x32, 0x21, 0x38, 0x16, 0x1d, 0x38, 0x79, 0x5c, 0x76, 0x98, 0x00, 0x01, 0x00, 0x00,
+	0xff, 0xdb, 0x04, 0x38, 0x05, 0xeb, 0x00, 0x23, 0x00, 0x8a, 0x40, 0x12, 0x16, 0x01, 0x07, 0x06,
+	0x17, 0x01, 0x05, 0x07, 0x04, 0x01, 0x00, 0x02, 0x05, 0x01, 0x01, 0x00, 0x04, 0x4c, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x2a, 0x08, 0x01, 0x05, 0x09, 0x01, 0x04, 0x03, 0x05, 0x04, 0x67, 0x0a,
+	0x01, 0x03, 0x0c, 0x0b, 0x02, 0x02, 0x00, 0x03, 0x02, 0x67, 0x00, 0x07, 0x07, 0x06, 0x61, 0x00,
+	0x06, 0x06, 0x3e, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b,
+	0x40, 0x28, 0x00, 0x06, 0x00, 0x07, 0x05, 0x06, 0x07, 0x69, 0x08, 0x01, 0x05, 0x09, 0x01, 0x04,
+	0x03, 0x05, 0x04, 0x67, 0x0a, 0x01, 0x03, 0x0c, 0x0b, 0x02, 0x02, 0x00, 0x03, 0x02, 0x67, 0x00,
+	0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x40, 0x16, 0x00, 0x00, 0x00,
+	0x23, 0x00, 0x23, 0x22, 0x21, 0x1e, 0x1d, 0x11, 0x23, 0x21, 0x11, 0x13, 0x11, 0x11, 0x23, 0x21,
+	0x0d, 0x09, 0x1f, 0x2b, 0x01, 0x12, 0x21, 0x32, 0x37, 0x15, 0x06, 0x23, 0x20, 0x03, 0x23, 0x37,
+	0x33, 0x27, 0x34, 0x37, 0x23, 0x37, 0x33, 0x12, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x20, 0x03,
+	0x21, 0x07, 0x21, 0x06, 0x15, 0x17, 0x21, 0x07, 0x01, 0x79, 0x5a, 0x01, 0x33, 0x81, 0xb1, 0xc0,
+	0x88, 0xfe, 0x1b, 0x5a, 0xb1, 0x33, 0x6f, 0x02, 0x05, 0xa5, 0x33, 0x84, 0x6e, 0x01, 0xeb, 0x80,
+	0xa7, 0xa3, 0x83, 0xfe, 0xd3, 0x65, 0x02, 0x37, 0x33, 0xfd, 0xe7, 0x05, 0x02, 0x01, 0xcb, 0x33,
+	0x02, 0x19, 0xfe, 0x66, 0x48, 0xac, 0x40, 0x02, 0x3e, 0x7b, 0x4b, 0x28, 0x52, 0x7c, 0x02, 0x16,
+	0x2c, 0xb6, 0x47, 0xfe, 0x85, 0x7c, 0x51, 0x28, 0x4c, 0x7b, 0x00, 0x00, 0x00, 0x04, 0x00, 0x57,
+	0x00, 0x00, 0x06, 0x44, 0x05, 0xc8, 0x00, 0x03, 0x00, 0x17, 0x00, 0x21, 0x00, 0x2b, 0x00, 0x5e,
+	0x40, 0x5b, 0x0d, 0x01, 0x04, 0x00, 0x17, 0x0e, 0x02, 0x05, 0x04, 0x02, 0x4c, 0x03, 0x01, 0x00,
+	0x00, 0x04, 0x05, 0x00, 0x04, 0x69, 0x00, 0x05, 0x00, 0x02, 0x07, 0x05, 0x02, 0x69, 0x00, 0x07,
+	0x00, 0x09, 0x08, 0x07, 0x09, 0x69, 0x0c, 0x01, 0x08, 0x01, 0x01, 0x08, 0x59, 0x0c, 0x01, 0x08,
+	0x08, 0x01, 0x61, 0x0b, 0x06, 0x0a, 0x03, 0x01, 0x08, 0x01, 0x51, 0x23, 0x22, 0x19, 0x18, 0x00,
+	0x00, 0x28, 0x26, 0x22, 0x2b, 0x23, 0x2b, 0x1e, 0x1c, 0x18, 0x21, 0x19, 0x21, 0x16, 0x14, 0x11,
+	0x0f, 0x0c, 0x0a, 0x07, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0d, 0x06, 0x17, 0x2b, 0x33, 0x01,
+	0x33, 0x01, 0x01, 0x06, 0x23, 0x22, 0x35, 0x34, 0x00, 0x33, 0x32, 0x17, 0x07, 0x26, 0x23, 0x22,
+	0x06, 0x15, 0x14, 0x33, 0x32, 0x37, 0x01, 0x22, 0x35, 0x34, 0x12, 0x33, 0x32, 0x15, 0x14, 0x02,
+	0x27, 0x32, 0x36, 0x35, 0x34, 0x23, 0x22, 0x06, 0x15, 0x14, 0x57, 0x05, 0x4c, 0xa1, 0xfa, 0xb3,
+	0x01, 0x79, 0x89, 0x72, 0xdf, 0x01, 0x03, 0xa6, 0x40, 0x5a, 0x16, 0x52, 0x3d, 0x68, 0x98, 0x75,
+	0x65, 0x7c, 0x01, 0xfb, 0xe7, 0xf3, 0xa7, 0xea, 0xf3, 0x89, 0x5b, 0x85, 0x6d, 0x59, 0x86, 0x05,
+	0xc8, 0xfa, 0x38, 0x03, 0x56, 0x3a, 0xe1, 0xb4, 0x01, 0x17, 0x19, 0x6f, 0x24, 0xca, 0x8a, 0x82,
+	0x47, 0xfc, 0x2b, 0xdb, 0xbe, 0x01, 0x14, 0xda, 0xc0, 0xfe, 0xed, 0x66, 0xc9, 0x88, 0x90, 0xc9,
+	0x86, 0x92, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0xff, 0xe7, 0x02, 0x78, 0x06, 0x44, 0x00, 0x2d,
+	0x00, 0x3f, 0x00, 0x30, 0x40, 0x2d, 0x2e, 0x23, 0x22, 0x17, 0x07, 0x04, 0x03, 0x00, 0x08, 0x01,
+	0x03, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x03, 0x01, 0x00, 0x03, 0x69, 0x00, 0x01, 0x02, 0x02, 0x01,
+	0x59, 0x00, 0x01, 0x01, 0x02, 0x61, 0x00, 0x02, 0x01, 0x02, 0x51, 0x3b, 0x39, 0x29, 0x2e, 0x2c,
+	0x04, 0x06, 0x19, 0x2b, 0x13, 0x06, 0x06, 0x07, 0x35, 0x36, 0x36, 0x37, 0x11, 0x34, 0x3e, 0x02,
+	0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x07, 0x15, 0x14, 0x1e, 0x02, 0x33, 0x32, 0x3e,
+	0x02, 0x37, 0x17, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x27, 0x13, 0x3e, 0x03, 0x35, 0x34, 0x2e,
+	0x04, 0x23, 0x22, 0x0e, 0x02, 0x15, 0x7e, 0x13, 0x46, 0x25, 0x21, 0x47, 0x17, 0x22, 0x40, 0x5c,
+	0x39, 0x3f, 0x54, 0x33, 0x15, 0x1e, 0x4a, 0x7a, 0x5c, 0x03, 0x0f, 0x22, 0x20, 0x1b, 0x35, 0x30,
+	0x28, 0x0e, 0x5b, 0x12, 0x34, 0x4d, 0x67, 0x43, 0x40, 0x48, 0x24, 0x0c, 0x04, 0x94, 0x33, 0x45,
+	0x2b, 0x12, 0x01, 0x05, 0x0a, 0x14, 0x1e, 0x16, 0x21, 0x25, 0x12, 0x05, 0x02, 0x04, 0x0c, 0x17,
+	0x0e, 0x72, 0x0e, 0x1c, 0x0d, 0x01, 0x87, 0xa8, 0xde, 0x84, 0x37, 0x2c, 0x56, 0x7e, 0x51, 0x54,
+	0xb1, 0xad, 0xa4, 0x46, 0x77, 0x48, 0x8a, 0x6d, 0x42, 0x31, 0x50, 0x62, 0x32, 0x22, 0x3b, 0x80,
+	0x6b, 0x45, 0x3d, 0x7e, 0xc1, 0x84, 0x01, 0x00, 0x33, 0x71, 0x81, 0x91, 0x53, 0x09, 0x28, 0x32,
+	0x36, 0x2c, 0x1c, 0x3d, 0x7b, 0xb7, 0x7b, 0x00, 0x00, 0x04, 0x00, 0x96, 0x00, 0x00, 0x08, 0x10,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x25, 0x00, 0x5b, 0x40, 0x58, 0x1c, 0x01,
+	0x01, 0x07, 0x21, 0x01, 0x00, 0x02, 0x02, 0x4c, 0x08, 0x01, 0x07, 0x01, 0x07, 0x85, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x02, 0x0a, 0x01, 0x00, 0x04, 0x02, 0x00, 0x69,
+	0x00, 0x04, 0x05, 0x05, 0x04, 0x57, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x09, 0x06, 0x0c, 0x03, 0x05,
+	0x04, 0x05, 0x4f, 0x18, 0x18, 0x0d, 0x0c, 0x01, 0x00, 0x25, 0x24, 0x23, 0x22, 0x20, 0x1f, 0x1e,
+	0x1d, 0x18, 0x1b, 0x18, 0x1b, 0x1a, 0x19, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00,
+	0x0b, 0x01, 0x0b, 0x0d, 0x06, 0x16, 0x2b, 0x01, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16,
+	0x15, 0x14, 0x06, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03,
+	0x35, 0x21, 0x15, 0x01, 0x11, 0x23, 0x11, 0x33, 0x01, 0x11, 0x33, 0x11, 0x23, 0x06, 0xa1, 0xa9,
+	0xc6, 0xc7, 0xa8, 0xa8, 0xc7, 0xc7, 0xa8, 0x56, 0x65, 0x63, 0x58, 0x58, 0x63, 0x61, 0xd1, 0x02,
+	0x56, 0xf9, 0x82, 0xb8, 0xc5, 0x02, 0x7e, 0xb6, 0xc4, 0x01, 0x59, 0xcb, 0xa8, 0xa9, 0xc9, 0xc8,
+	0xa9, 0xac, 0xc8, 0x7c, 0x7c, 0x7c, 0x7a, 0x7b, 0x7b, 0x7b, 0x7c, 0x7b, 0xfe, 0x2b, 0x94, 0x94,
+	0x04, 0x68, 0xfb, 0x98, 0x05, 0xc8, 0xfb, 0x9f, 0x04, 0x61, 0xfa, 0x38, 0x00, 0x02, 0x00, 0xdc,
+	0x02, 0xe4, 0x06, 0xe2, 0x05, 0xc8, 0x00, 0x07, 0x00, 0x14, 0x00, 0x4a, 0x40, 0x47, 0x13, 0x10,
+	0x0b, 0x03, 0x07, 0x00, 0x01, 0x4c, 0x00, 0x07, 0x00, 0x03, 0x00, 0x07, 0x03, 0x80, 0x0a, 0x08,
+	0x06, 0x09, 0x04, 0x03, 0x03, 0x84, 0x05, 0x04, 0x02, 0x01, 0x00, 0x00, 0x01, 0x57, 0x05, 0x04,
+	0x02, 0x01, 0x01, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x08, 0x08, 0x00, 0x00, 0x08,
+	0x14, 0x08, 0x14, 0x12, 0x11, 0x0f, 0x0e, 0x0d, 0x0c, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11,
+	0x11, 0x11, 0x0b, 0x06, 0x19, 0x2b, 0x01, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x21, 0x11,
+	0x33, 0x13, 0x13, 0x33, 0x11, 0x23, 0x11, 0x03, 0x23, 0x03, 0x11, 0x01, 0xd3, 0xf7, 0x02, 0x9a,
+	0xf7, 0x01, 0x73, 0xe9, 0x9c, 0x96, 0xd5, 0xa3, 0xad, 0x6c, 0xad, 0x02, 0xe4, 0x02, 0x69, 0x7b,
+	0x7b, 0xfd, 0x97, 0x02, 0xe4, 0xfe, 0x55, 0x01, 0xab, 0xfd, 0x1c, 0x02, 0x23, 0xfe, 0x1b, 0x01,
+	0xce, 0xfd, 0xf4, 0x00, 0x00, 0x01, 0x00, 0x88, 0x00, 0x00, 0x05, 0x9c, 0x05, 0xed, 0x00, 0x1b,
+	0x00, 0x33, 0x40, 0x30, 0x1a, 0x10, 0x02, 0x00, 0x01, 0x4b, 0x00, 0x01, 0x00, 0x04, 0x00, 0x01,
+	0x04, 0x69, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00, 0x03, 0x5f, 0x06,
+	0x05, 0x02, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x1b, 0x00, 0x1b, 0x25, 0x11, 0x14, 0x24,
+	0x11, 0x07, 0x06, 0x1b, 0x2b, 0x33, 0x35, 0x21, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11,
+	0x10, 0x01, 0x21, 0x15, 0x21, 0x35, 0x24, 0x11, 0x34, 0x02, 0x23, 0x22, 0x02, 0x11, 0x10, 0x05,
+	0x15, 0x88, 0x01, 0x52, 0xfe, 0xae, 0x01, 0x6d, 0x01, 0x1d, 0x01, 0x1d, 0x01, 0x6d, 0xfe, 0xae,
+	0x01, 0x52, 0xfd, 0xef, 0x01, 0x33, 0xea, 0xc2, 0xc1, 0xeb, 0x01, 0x33, 0x9a, 0x01, 0x0e, 0x01,
+	0x98, 0x01, 0x2c, 0x01, 0x81, 0xfe, 0x80, 0xfe, 0xd3, 0xfe, 0x67, 0xfe, 0xf3, 0x9a, 0x9a, 0xe5,
+	0x01, 0xb3, 0xff, 0x01, 0x22, 0xfe, 0xde, 0xff, 0x00, 0xfe, 0x4f, 0xe6, 0x9a, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x64, 0xff, 0xe7, 0x05, 0x52, 0x03, 0x8b, 0x00, 0x1f, 0x00, 0x30, 0x00, 0x40,
+	0x40, 0x3d, 0x2f, 0x23, 0x02, 0x05, 0x06, 0x18, 0x01, 0x00, 0x03, 0x02, 0x4c, 0x00, 0x00, 0x03,
+	0x04, 0x03, 0x00, 0x04, 0x80, 0x00, 0x02, 0x00, 0x06, 0x05, 0x02, 0x06, 0x69, 0x00, 0x05, 0x00,
+	0x03, 0x00, 0x05, 0x03, 0x67, 0x00, 0x04, 0x01, 0x01, 0x04, 0x59, 0x00, 0x04, 0x04, 0x01, 0x61,
+	0x00, 0x01, 0x04, 0x01, 0x51, 0x27, 0x11, 0x27, 0x24, 0x28, 0x23, 0x10, 0x07, 0x06, 0x1d, 0x2b,
+	0x25, 0x33, 0x06, 0x07, 0x06, 0x23, 0x22, 0x26, 0x27, 0x26, 0x35, 0x34, 0x37, 0x36, 0x36, 0x33,
+	0x32, 0x16, 0x17, 0x16, 0x15, 0x15, 0x21, 0x22, 0x15, 0x15, 0x14, 0x17, 0x16, 0x16, 0x33, 0x32,
+	0x01, 0x21, 0x32, 0x35, 0x35, 0x34, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x15, 0x15,
+	0x14, 0x04, 0x70, 0x5e, 0x55, 0x55, 0x9a, 0xaf, 0x8b, 0xfb, 0x59, 0x98, 0x98, 0x59, 0xfb, 0x8b,
+	0x8b, 0xfb, 0x5a, 0x97, 0xfc, 0x09, 0x0f, 0x19, 0x34, 0xda, 0x6a, 0xeb, 0xfd, 0x93, 0x03, 0x00,
+	0x11, 0x1a, 0x36, 0xd8, 0x69, 0x69, 0xd9, 0x34, 0x19, 0x9b, 0x4b, 0x25, 0x44, 0x56, 0x4d, 0x83,
+	0xac, 0xac, 0x84, 0x4d, 0x55, 0x55, 0x4d, 0x84, 0xac, 0x0d, 0x0d, 0xe4, 0x20, 0x1a, 0x35, 0x49,
+	0x01, 0xc3, 0x0d, 0xe5, 0x1f, 0x1a, 0x35, 0x4a, 0x4a, 0x35, 0x1a, 0x1f, 0xe5, 0x0d, 0x00, 0x00,
+	0x00, 0x05, 0x00, 0x50, 0xff, 0xdb, 0x06, 0x26, 0x05, 0xed, 0x00, 0x03, 0x00, 0x09, 0x00, 0x1d,
+	0x00, 0x25, 0x00, 0x30, 0x00, 0xab, 0x40, 0x0d, 0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x00, 0x14,
+	0x01, 0x06, 0x02, 0x02, 0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x23, 0x08, 0x01, 0x02, 0x05,
+	0x06, 0x05, 0x02, 0x06, 0x80, 0x00, 0x03, 0x00, 0x05, 0x02, 0x03, 0x05, 0x6a, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x06, 0x06, 0x01, 0x61, 0x04, 0x07, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b,
+	0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40, 0x23, 0x00, 0x00, 0x03, 0x00, 0x85, 0x08, 0x01, 0x02, 0x05,
+	0x06, 0x05, 0x02, 0x06, 0x80, 0x00, 0x03, 0x00, 0x05, 0x02, 0x03, 0x05, 0x6a, 0x00, 0x06, 0x06,
+	0x01, 0x61, 0x04, 0x07, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x00, 0x03,
+	0x00, 0x85, 0x08, 0x01, 0x02, 0x05, 0x06, 0x05, 0x02, 0x06, 0x80, 0x07, 0x01, 0x01, 0x04, 0x01,
+	0x86, 0x00, 0x03, 0x00, 0x05, 0x02, 0x03, 0x05, 0x6a, 0x00, 0x06, 0x06, 0x04, 0x61, 0x00, 0x04,
+	0x04, 0x42, 0x04, 0x4e, 0x59, 0x59, 0x40, 0x18, 0x04, 0x04, 0x00, 0x00, 0x2c, 0x2a, 0x23, 0x21,
+	0x1a, 0x18, 0x10, 0x0e, 0x04, 0x09, 0x04, 0x09, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x09, 0x17,
+	0x2b, 0x17, 0x01, 0x33, 0x01, 0x03, 0x11, 0x07, 0x35, 0x25, 0x11, 0x05, 0x26, 0x35, 0x34, 0x36,
+	0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x25,
+	0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x17, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35,
+	0x34, 0x27, 0x99, 0x04, 0x40, 0x87, 0xfb, 0xc0, 0x0a, 0xc6, 0x01, 0x5a, 0x02, 0xbd, 0x82, 0x9b,
+	0x7d, 0x75, 0x8e, 0x9e, 0xc4, 0xb1, 0x8d, 0x89, 0xa9, 0x01, 0x5d, 0x6b, 0x8e, 0x88, 0x4b, 0x6e,
+	0x62, 0x4d, 0x48, 0x5d, 0x93, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x02, 0x75, 0x02, 0xf7, 0x31, 0x72,
+	0x57, 0xfc, 0x71, 0x70, 0x58, 0x72, 0x66, 0x7e, 0x6b, 0x59, 0x7b, 0x69, 0x63, 0x99, 0x72, 0x8e,
+	0x84, 0x6c, 0x9f, 0x99, 0x4b, 0x5a, 0x74, 0x6b, 0x50, 0xc6, 0x58, 0x61, 0x48, 0x5c, 0x4c, 0x3a,
+	0x52, 0x55, 0x00, 0x00, 0x00, 0x05, 0x00, 0x3c, 0xff, 0xdb, 0x06, 0x3d, 0x05, 0xed, 0x00, 0x03,
+	0x00, 0x22, 0x00, 0x36, 0x00, 0x3e, 0x00, 0x49, 0x01, 0x57, 0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40,
+	0x1a, 0x05, 0x01, 0x07, 0x00, 0x04, 0x01, 0x06, 0x07, 0x0b, 0x01, 0x05, 0x06, 0x13, 0x01, 0x04,
+	0x0a, 0x12, 0x01, 0x03, 0x04, 0x2d, 0x01, 0x0b, 0x03, 0x06, 0x4c, 0x1b, 0x40, 0x1a, 0x05, 0x01,
+	0x07, 0x02, 0x04, 0x01, 0x06, 0x07, 0x0b, 0x01, 0x05, 0x06, 0x13, 0x01, 0x04, 0x0a, 0x12, 0x01,
+	0x03, 0x04, 0x2d, 0x01, 0x0b, 0x03, 0x06, 0x4c, 0x59, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40, 0x32,
+	0x00, 0x08, 0x00, 0x0a, 0x04, 0x08, 0x0a, 0x6a, 0x00, 0x04, 0x00, 0x03, 0x0b, 0x04, 0x03, 0x69,
+	0x00, 0x07, 0x07, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x01, 0x61, 0x09, 0x0c, 0x02, 0x01, 0x01, 0x3f,
+	0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x24, 0x50, 0x58, 0x40, 0x36, 0x0c, 0x01, 0x01, 0x09, 0x01, 0x86,
+	0x00, 0x08, 0x00, 0x0a, 0x04, 0x08, 0x0a, 0x6a, 0x00, 0x04, 0x00, 0x03, 0x0b, 0x04, 0x03, 0x69,
+	0x00, 0x07, 0x07, 0x00, 0x61, 0x02, 0x01, 0x00, 0x00, 0x3e, 0x4d, 0x00, 0x05, 0x05, 0x06, 0x61,
+	0x00, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42, 0x09, 0x4e,
+	0x1b, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x3a, 0x00, 0x00, 0x02, 0x00, 0x85, 0x0c, 0x01, 0x01,
+	0x09, 0x01, 0x86, 0x00, 0x08, 0x00, 0x0a, 0x04, 0x08, 0x0a, 0x6a, 0x00, 0x04, 0x00, 0x03, 0x0b,
+	0x04, 0x03, 0x69, 0x00, 0x07, 0x07, 0x02, 0x61, 0x00, 0x02, 0x02, 0x3e, 0x4d, 0x00, 0x05, 0x05,
+	0x06, 0x61, 0x00, 0x06, 0x06, 0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42,
+	0x09, 0x4e, 0x1b, 0x40, 0x38, 0x00, 0x00, 0x02, 0x00, 0x85, 0x0c, 0x01, 0x01, 0x09, 0x01, 0x86,
+	0x00, 0x02, 0x00, 0x07, 0x06, 0x02, 0x07, 0x69, 0x00, 0x08, 0x00, 0x0a, 0x04, 0x08, 0x0a, 0x6a,
+	0x00, 0x04, 0x00, 0x03, 0x0b, 0x04, 0x03, 0x69, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00, 0x06, 0x06,
+	0x41, 0x4d, 0x00, 0x0b, 0x0b, 0x09, 0x61, 0x00, 0x09, 0x09, 0x42, 0x09, 0x4e, 0x59, 0x59, 0x59,
+	0x40, 0x1e, 0x00, 0x00, 0x45, 0x43, 0x3c, 0x3a, 0x33, 0x31, 0x29, 0x27, 0x22, 0x20, 0x1e, 0x1c,
+	0x1b, 0x19, 0x16, 0x14, 0x11, 0x0f, 0x08, 0x06, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0d, 0x09, 0x17,
+	0x2b, 0x17, 0x01, 0x33, 0x01, 0x01, 0x35, 0x36, 0x33, 0x20, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14,
+	0x06, 0x23, 0x22, 0x27, 0x35, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x21, 0x23, 0x35, 0x33, 0x32,
+	0x35, 0x34, 0x23, 0x22, 0x01, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16,
+	0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x25, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14,
+	0x17, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x27, 0xc1, 0x04, 0x40, 0x88, 0xfb,
+	0xc0, 0xfe, 0xff, 0x6f, 0x73, 0x01, 0x1a, 0xbf, 0xdc, 0xad, 0x96, 0x6b, 0x77, 0x82, 0x50, 0x51,
+	0x66, 0xfe, 0xfc, 0x33, 0x2c, 0xf4, 0x9c, 0x5d, 0x03, 0xc9, 0x82, 0x9b, 0x7d, 0x75, 0x8e, 0x9e,
+	0xc4, 0xb1, 0x8d, 0x89, 0xa9, 0x01, 0x5d, 0x6b, 0x8e, 0x88, 0x4b, 0x6e, 0x62, 0x4d, 0x48, 0x5c,
+	0x92, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x05, 0x6e, 0x70, 0x26, 0xd1, 0x9d, 0x42, 0x32, 0xbc, 0x7a,
+	0x8d, 0x1d, 0x7a, 0x33, 0x5a, 0x49, 0xb6, 0x5d, 0xa6, 0x81, 0xfc, 0x65, 0x57, 0x73, 0x66, 0x7e,
+	0x6b, 0x59, 0x7b, 0x69, 0x63, 0x99, 0x72, 0x8e, 0x84, 0x6c, 0x9f, 0x99, 0x4b, 0x59, 0x75, 0x6b,
+	0x50, 0xc6, 0x57, 0x61, 0x49, 0x5c, 0x4b, 0x3b, 0x52, 0x55, 0x00, 0x00, 0x00, 0x05, 0x00, 0x5a,
+	0xff, 0xdb, 0x06, 0x44, 0x05, 0xed, 0x00, 0x03, 0x00, 0x17, 0x00, 0x1f, 0x00, 0x2a, 0x00, 0x40,
+	0x01, 0x69, 0x40, 0x12, 0x34, 0x01, 0x02, 0x07, 0x2c, 0x01, 0x06, 0x04, 0x2b, 0x01, 0x0b, 0x06,
+	0x0e, 0x01, 0x05, 0x0b, 0x04, 0x4c, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x36, 0x00, 0x02, 0x00,
+	0x04, 0x06, 0x02, 0x04, 0x6a, 0x00, 0x06, 0x00, 0x0b, 0x05, 0x06, 0x0b, 0x69, 0x00, 0x00, 0x00,
+	0x38, 0x4d, 0x00, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x07, 0x07, 0x0a,
+	0x61, 0x00, 0x0a, 0x0a, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x03, 0x0c, 0x02, 0x01, 0x01,
+	0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x1d, 0x50, 0x58, 0x40, 0x36, 0x00, 0x00, 0x08, 0x00, 0x85,
+	0x00, 0x02, 0x00, 0x04, 0x06, 0x02, 0x04, 0x6a, 0x00, 0x06, 0x00, 0x0b, 0x05, 0x06, 0x0b, 0x69,
+	0x00, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d, 0x00, 0x07, 0x07, 0x0a, 0x61, 0x00,
+	0x0a, 0x0a, 0x41, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x03, 0x0c, 0x02, 0x01, 0x01, 0x3f, 0x01,
+	0x4e, 0x1b, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40, 0x34, 0x00, 0x00, 0x08, 0x00, 0x85, 0x00, 0x0a,
+	0x00, 0x07, 0x02, 0x0a, 0x07, 0x69, 0x00, 0x02, 0x00, 0x04, 0x06, 0x02, 0x04, 0x6a, 0x00, 0x06,
+	0x00, 0x0b, 0x05, 0x06, 0x0b, 0x69, 0x00, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08, 0x38, 0x4d,
+	0x00, 0x05, 0x05, 0x01, 0x61, 0x03, 0x0c, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0,
+	0x2a, 0x50, 0x58, 0x40, 0x38, 0x00, 0x00, 0x08, 0x00, 0x85, 0x0c, 0x01, 0x01, 0x03, 0x01, 0x86,
+	0x00, 0x0a, 0x00, 0x07, 0x02, 0x0a, 0x07, 0x69, 0x00, 0x02, 0x00, 0x04, 0x06, 0x02, 0x04, 0x6a,
+	0x00, 0x06, 0x00, 0x0b, 0x05, 0x06, 0x0b, 0x69, 0x00, 0x09, 0x09, 0x08, 0x5f, 0x00, 0x08, 0x08,
+	0x38, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x36,
+	0x00, 0x00, 0x08, 0x00, 0x85, 0x0c, 0x01, 0x01, 0x03, 0x01, 0x86, 0x00, 0x08, 0x00, 0x09, 0x0a,
+	0x08, 0x09, 0x67, 0x00, 0x0a, 0x00, 0x07, 0x02, 0x0a, 0x07, 0x69, 0x00, 0x02, 0x00, 0x04, 0x06,
+	0x02, 0x04, 0x6a, 0x00, 0x06, 0x00, 0x0b, 0x05, 0x06, 0x0b, 0x69, 0x00, 0x05, 0x05, 0x03, 0x61,
+	0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x59, 0x59, 0x59, 0x40, 0x1e, 0x00, 0x00, 0x40, 0x3e,
+	0x3a, 0x39, 0x38, 0x37, 0x36, 0x35, 0x33, 0x31, 0x2f, 0x2d, 0x26, 0x24, 0x1d, 0x1b, 0x14, 0x12,
+	0x0a, 0x08, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0d, 0x09, 0x17, 0x2b, 0x17, 0x01, 0x33, 0x01, 0x01,
+	0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22,
+	0x26, 0x35, 0x34, 0x25, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x17, 0x06, 0x15, 0x14, 0x16,
+	0x33, 0x32, 0x36, 0x35, 0x34, 0x27, 0x25, 0x35, 0x16, 0x33, 0x32, 0x35, 0x34, 0x21, 0x22, 0x07,
+	0x11, 0x21, 0x15, 0x21, 0x15, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0xc8, 0x04, 0x40, 0x87,
+	0xfb, 0xc1, 0x03, 0x34, 0x81, 0x9b, 0x7d, 0x75, 0x8d, 0x9d, 0xc4, 0xb1, 0x8e, 0x89, 0xa9, 0x01,
+	0x5e, 0x6a, 0x8d, 0x89, 0x4b, 0x6d, 0x61, 0x4e, 0x47, 0x5d, 0x92, 0xfb, 0x36, 0x65, 0x4e, 0xb1,
+	0xfe, 0xed, 0x20, 0x21, 0x01, 0xdf, 0xfe, 0x96, 0xb1, 0xcb, 0xb5, 0x9e, 0x47, 0x25, 0x06, 0x12,
+	0xf9, 0xee, 0x02, 0x05, 0x58, 0x72, 0x66, 0x7e, 0x6b, 0x59, 0x7b, 0x69, 0x63, 0x99, 0x72, 0x8e,
+	0x84, 0x6c, 0x9f, 0x99, 0x4b, 0x5a, 0x74, 0x6c, 0x4f, 0xc6, 0x57, 0x61, 0x49, 0x5c, 0x4c, 0x3a,
+	0x52, 0x55, 0xd6, 0x75, 0x27, 0xb4, 0xc4, 0x05, 0x01, 0xb7, 0x7a, 0xd4, 0x9f, 0x8a, 0x82, 0x95,
+	0x00, 0x05, 0x00, 0x5a, 0xff, 0xdb, 0x06, 0x31, 0x05, 0xed, 0x00, 0x03, 0x00, 0x17, 0x00, 0x1f,
+	0x00, 0x2a, 0x00, 0x34, 0x01, 0x03, 0x40, 0x0b, 0x0e, 0x01, 0x05, 0x08, 0x01, 0x4c, 0x32, 0x01,
+	0x06, 0x01, 0x4b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x2d, 0x0a, 0x01, 0x08, 0x04, 0x05, 0x04,
+	0x08, 0x05, 0x80, 0x00, 0x02, 0x00, 0x04, 0x08, 0x02, 0x04, 0x6a, 0x00, 0x00, 0x00, 0x38, 0x4d,
+	0x00, 0x06, 0x06, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x01, 0x61, 0x03,
+	0x09, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x21, 0x50, 0x58, 0x40, 0x2d, 0x00,
+	0x00, 0x07, 0x00, 0x85, 0x0a, 0x01, 0x08, 0x04, 0x05, 0x04, 0x08, 0x05, 0x80, 0x00, 0x02, 0x00,
+	0x04, 0x08, 0x02, 0x04, 0x6a, 0x00, 0x06, 0x06, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00,
+	0x05, 0x05, 0x01, 0x61, 0x03, 0x09, 0x02, 0x01, 0x01, 0x3f, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a,
+	0x50, 0x58, 0x40, 0x31, 0x00, 0x00, 0x07, 0x00, 0x85, 0x0a, 0x01, 0x08, 0x04, 0x05, 0x04, 0x08,
+	0x05, 0x80, 0x09, 0x01, 0x01, 0x03, 0x01, 0x86, 0x00, 0x02, 0x00, 0x04, 0x08, 0x02, 0x04, 0x6a,
+	0x00, 0x06, 0x06, 0x07, 0x5f, 0x00, 0x07, 0x07, 0x38, 0x4d, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00,
+	0x03, 0x03, 0x42, 0x03, 0x4e, 0x1b, 0x40, 0x2f, 0x00, 0x00, 0x07, 0x00, 0x85, 0x0a, 0x01, 0x08,
+	0x04, 0x05, 0x04, 0x08, 0x05, 0x80, 0x09, 0x01, 0x01, 0x03, 0x01, 0x86, 0x00, 0x07, 0x00, 0x06,
+	0x02, 0x07, 0x06, 0x67, 0x00, 0x02, 0x00, 0x04, 0x08, 0x02, 0x04, 0x6a, 0x00, 0x05, 0x05, 0x03,
+	0x61, 0x00, 0x03, 0x03, 0x42, 0x03, 0x4e, 0x59, 0x59, 0x59, 0x40, 0x1c, 0x2b, 0x2b, 0x00, 0x00,
+	0x2b, 0x34, 0x2b, 0x34, 0x31, 0x30, 0x2f, 0x2e, 0x26, 0x24, 0x1d, 0x1b, 0x14, 0x12, 0x0a, 0x08,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x0b, 0x09, 0x17, 0x2b, 0x17, 0x01, 0x33, 0x01, 0x01, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x35,
+	0x34, 0x25, 0x36, 0x35, 0x34, 0x23, 0x22, 0x15, 0x14, 0x17, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32,
+	0x36, 0x35, 0x34, 0x27, 0x25, 0x36, 0x13, 0x13, 0x21, 0x35, 0x21, 0x15, 0x00, 0x03, 0x86, 0x04,
+	0x40, 0x87, 0xfb, 0xc0, 0x03, 0x65, 0x82, 0x9b, 0x7d, 0x75, 0x8e, 0x9e, 0xc4, 0xb1, 0x8e, 0x89,
+	0xa9, 0x01, 0x5e, 0x6a, 0x8d, 0x89, 0x4c, 0x6e, 0x62, 0x4d, 0x47, 0x5d, 0x92, 0xfb, 0x7f, 0x16,
+	0xa8, 0xe1, 0xfe, 0x2b, 0x02, 0x56, 0xfe, 0x9f, 0x18, 0x25, 0x06, 0x12, 0xf9, 0xee, 0x02, 0x05,
+	0x57, 0x73, 0x66, 0x7e, 0x6b, 0x59, 0x7b, 0x69, 0x63, 0x99, 0x72, 0x8e, 0x84, 0x6c, 0x9f, 0x99,
+	0x4b, 0x5a, 0x74, 0x6c, 0x4f, 0xc6, 0x57, 0x62, 0x48, 0x5c, 0x4c, 0x3a, 0x52, 0x55, 0xd6, 0x9c,
+	0x01, 0x02, 0x01, 0x5b, 0x7f, 0x7f, 0xfe, 0x1e, 0xfe, 0xe9, 0x00, 0x00, 0x00, 0x01, 0x00, 0xa0,
+	0x00, 0xdd, 0x07, 0x60, 0x03, 0xc2, 0x00, 0x06, 0x00, 0x20, 0x40, 0x1d, 0x01, 0x01, 0x00, 0x4a,
+	0x06, 0x01, 0x01, 0x49, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x00,
+	0x01, 0x00, 0x01, 0x4f, 0x11, 0x12, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x01, 0x03, 0x21, 0x15, 0x21,
+	0x13, 0xa0, 0x02, 0x81, 0x94, 0x04, 0xd3, 0xfb, 0x2d, 0x94, 0x02, 0x50, 0x01, 0x72, 0xfe, 0xd8,
+	0x94, 0xfe, 0xd7, 0x00, 0x00, 0x01, 0x00, 0x8d, 0xfe, 0x75, 0x03, 0x72, 0x06, 0x44, 0x00, 0x06,
+	0x00, 0x12, 0x40, 0x0f, 0x06, 0x05, 0x02, 0x01, 0x04, 0x00, 0x4a, 0x00, 0x00, 0x00, 0x76, 0x13,
+	0x01, 0x06, 0x17, 0x2b, 0x01, 0x01, 0x25, 0x11, 0x23, 0x11, 0x05, 0x02, 0x00, 0x01, 0x72, 0xfe,
+	0xd8, 0x94, 0xfe, 0xd7, 0x06, 0x44, 0xfd, 0x7f, 0x94, 0xfa, 0x1e, 0x05, 0xe2, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0xa0, 0x00, 0xdd, 0x07, 0x60, 0x03, 0xc2, 0x00, 0x06, 0x00, 0x22, 0x40, 0x1f,
+	0x06, 0x01, 0x00, 0x01, 0x01, 0x4c, 0x05, 0x01, 0x01, 0x4a, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57,
+	0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x11, 0x11, 0x02, 0x06, 0x18, 0x2b,
+	0x25, 0x13, 0x21, 0x35, 0x21, 0x03, 0x01, 0x04, 0xdf, 0x94, 0xfb, 0x2d, 0x04, 0xd3, 0x94, 0x02,
+	0x81, 0xdd, 0x01, 0x29, 0x94, 0x01, 0x28, 0xfe, 0x8e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x8d,
+	0xfe, 0x75, 0x03, 0x72, 0x06, 0x44, 0x00, 0x06, 0x00, 0x12, 0x40, 0x0f, 0x06, 0x05, 0x02, 0x01,
+	0x04, 0x00, 0x49, 0x00, 0x00, 0x00, 0x76, 0x13, 0x01, 0x06, 0x17, 0x2b, 0x01, 0x01, 0x05, 0x11,
+	0x33, 0x11, 0x25, 0x01, 0xff, 0xfe, 0x8e, 0x01, 0x28, 0x94, 0x01, 0x29, 0xfe, 0x75, 0x02, 0x81,
+	0x94, 0x05, 0xe2, 0xfa, 0x1e, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x50, 0x00, 0xdd, 0x07, 0xb0,
+	0x03, 0xc2, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x05, 0x01, 0x01, 0x00, 0x01, 0x4c, 0x04, 0x01,
+	0x02, 0x00, 0x4a, 0x09, 0x06, 0x02, 0x01, 0x49, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x00, 0x01, 0x00, 0x01, 0x4f, 0x14, 0x12, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x01,
+	0x03, 0x21, 0x03, 0x01, 0x01, 0x13, 0x21, 0x13, 0x50, 0x02, 0x81, 0x94, 0x03, 0x86, 0x94, 0x02,
+	0x81, 0xfd, 0x7f, 0x94, 0xfc, 0x7a, 0x94, 0x02, 0x50, 0x01, 0x72, 0xfe, 0xd8, 0x01, 0x28, 0xfe,
+	0x8e, 0xfe, 0x8d, 0x01, 0x29, 0xfe, 0xd7, 0x00, 0x00, 0x01, 0x00, 0x8e, 0xfe, 0x75, 0x03, 0x72,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x06, 0xb3, 0x05, 0x00, 0x01, 0x32, 0x2b, 0x01, 0x01, 0x25, 0x11,
+	0x25, 0x01, 0x01, 0x05, 0x11, 0x05, 0x02, 0x00, 0x01, 0x72, 0xfe, 0xd8, 0x01, 0x28, 0xfe, 0x8e,
+	0xfe, 0x8e, 0x01, 0x28, 0xfe, 0xd8, 0x06, 0x44, 0xfd, 0x7f, 0x94, 0xfc, 0x0b, 0x94, 0xfd, 0x7f,
+	0x02, 0x81, 0x94, 0x03, 0xf5, 0x94, 0x00, 0x00, 0x00, 0x02, 0x00, 0x8e, 0xfd, 0xe1, 0x03, 0x72,
+	0x06, 0x44, 0x00, 0x09, 0x00, 0x0d, 0x00, 0x24, 0x40, 0x21, 0x09, 0x08, 0x07, 0x06, 0x05, 0x04,
+	0x03, 0x02, 0x01, 0x09, 0x00, 0x4a, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01,
+	0x5f, 0x00, 0x01, 0x00, 0x01, 0x4f, 0x11, 0x1a, 0x02, 0x06, 0x18, 0x2b, 0x01, 0x01, 0x25, 0x11,
+	0x25, 0x01, 0x01, 0x05, 0x11, 0x05, 0x11, 0x21, 0x15, 0x21, 0x02, 0x00, 0x01, 0x72, 0xfe, 0xd8,
+	0x01, 0x28, 0xfe, 0x8e, 0xfe, 0x8e, 0x01, 0x28, 0xfe, 0xd8, 0x02, 0xe4, 0xfd, 0x1c, 0x06, 0x44,
+	0xfd, 0x7f, 0x94, 0xfc, 0xd3, 0x94, 0xfd, 0x7f, 0x02, 0x81, 0x94, 0x03, 0x2d, 0x94, 0xfa, 0xb2,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x3a, 0xff, 0xe7, 0x03, 0xba, 0x06, 0x44, 0x00, 0x15,
+	0x00, 0x20, 0x00, 0x32, 0x40, 0x2f, 0x10, 0x01, 0x04, 0x02, 0x01, 0x4c, 0x00, 0x00, 0x00, 0x03,
+	0x02, 0x00, 0x03, 0x69, 0x00, 0x02, 0x00, 0x04, 0x05, 0x02, 0x04, 0x69, 0x00, 0x05, 0x01, 0x01,
+	0x05, 0x59, 0x00, 0x05, 0x05, 0x01, 0x61, 0x00, 0x01, 0x05, 0x01, 0x51, 0x24, 0x22, 0x24, 0x24,
+	0x24, 0x21, 0x06, 0x06, 0x1c, 0x2b, 0x13, 0x12, 0x21, 0x32, 0x12, 0x11, 0x10, 0x00, 0x21, 0x22,
+	0x26, 0x35, 0x10, 0x00, 0x33, 0x32, 0x17, 0x35, 0x34, 0x02, 0x23, 0x22, 0x01, 0x26, 0x23, 0x22,
+	0x02, 0x15, 0x14, 0x16, 0x33, 0x32, 0x12, 0x66, 0x93, 0x01, 0x0b, 0xd0, 0xe6, 0xfe, 0xad, 0xff,
+	0x00, 0x88, 0xa5, 0x01, 0x59, 0xcf, 0x54, 0x6b, 0xc5, 0x94, 0xc3, 0x02, 0x1c, 0x62, 0x6a, 0x84,
+	0xdd, 0x63, 0x51, 0x89, 0xd7, 0x05, 0x12, 0x01, 0x32, 0xfe, 0x93, 0xfe, 0xb7, 0xfe, 0x6e, 0xfd,
+	0xeb, 0xbe, 0x9c, 0x01, 0x06, 0x01, 0xb5, 0x45, 0x1e, 0xc3, 0x01, 0x03, 0xfd, 0x6b, 0x67, 0xfe,
+	0xd3, 0xb4, 0x79, 0x94, 0x01, 0x72, 0x00, 0x00, 0x00, 0x02, 0x00, 0x46, 0x00, 0x00, 0x04, 0x9f,
+	0x05, 0xc8, 0x00, 0x05, 0x00, 0x08, 0x00, 0x31, 0x40, 0x2e, 0x08, 0x01, 0x02, 0x00, 0x01, 0x4c,
+	0x04, 0x01, 0x02, 0x02, 0x01, 0x4b, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x01, 0x01, 0x02,
+	0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x03, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00, 0x00, 0x07, 0x06,
+	0x00, 0x05, 0x00, 0x05, 0x12, 0x04, 0x06, 0x17, 0x2b, 0x33, 0x35, 0x01, 0x33, 0x01, 0x15, 0x25,
+	0x21, 0x01, 0x46, 0x01, 0xd7, 0xad, 0x01, 0xd5, 0xfc, 0x3e, 0x03, 0x05, 0xfe, 0x7e, 0xb9, 0x05,
+	0x0f, 0xfa, 0xf1, 0xb9, 0xb9, 0x04, 0x28, 0x00, 0x00, 0x01, 0x00, 0xb6, 0xfe, 0x75, 0x05, 0xdf,
+	0x05, 0xc8, 0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27, 0x06, 0x05, 0x02, 0x03, 0x00, 0x03, 0x86, 0x00,
+	0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x04, 0x02, 0x02, 0x00, 0x01, 0x00,
+	0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b,
+	0x01, 0x11, 0x23, 0x35, 0x21, 0x15, 0x23, 0x11, 0x23, 0x11, 0x21, 0x11, 0x01, 0x19, 0x63, 0x05,
+	0x29, 0x63, 0xd1, 0xfd, 0x3f, 0xfe, 0x75, 0x06, 0xb6, 0x9d, 0x9d, 0xf9, 0x4a, 0x06, 0xb6, 0xf9,
+	0x4a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x71, 0xfe, 0x74, 0x05, 0x4d, 0x05, 0xc8, 0x00, 0x0b,
+	0x00, 0x37, 0x40, 0x34, 0x08, 0x02, 0x02, 0x02, 0x01, 0x01, 0x4c, 0x03, 0x01, 0x01, 0x01, 0x01,
+	0x02, 0x02, 0x4b, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02,
+	0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x02, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x0b,
+	0x00, 0x0b, 0x12, 0x11, 0x14, 0x05, 0x06, 0x19, 0x2b, 0x13, 0x35, 0x01, 0x01, 0x35, 0x21, 0x15,
+	0x21, 0x01, 0x01, 0x21, 0x15, 0x71, 0x02, 0xa5, 0xfd, 0x8e, 0x04, 0x77, 0xfc, 0xa8, 0x02, 0x59,
+	0xfd, 0x43, 0x03, 0xee, 0xfe, 0x74, 0xbb, 0x02, 0xed, 0x03, 0x0f, 0x9d, 0x9d, 0xfd, 0x08, 0xfc,
+	0xfc, 0xbb, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64, 0x02, 0x06, 0x04, 0x48, 0x02, 0x9a, 0x00, 0x03,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02,
+	0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b,
+	0x13, 0x35, 0x21, 0x15, 0x64, 0x03, 0xe4, 0x02, 0x06, 0x94, 0x94, 0x00, 0x00, 0x01, 0xff, 0x25,
+	0xfe, 0xd8, 0x02, 0x32, 0x06, 0x2b, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17,
+	0x2b, 0x03, 0x01, 0x33, 0x01, 0xdb, 0x02, 0x71, 0x9c, 0xfd, 0x8f, 0xfe, 0xd8, 0x07, 0x53, 0xf8,
+	0xad, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x41, 0x01, 0x75, 0x01, 0xf8, 0x03, 0x2c, 0x00, 0x0b,
+	0x00, 0x18, 0x40, 0x15, 0x00, 0x00, 0x01, 0x01, 0x00, 0x59, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00,
+	0x01, 0x00, 0x01, 0x51, 0x24, 0x22, 0x02, 0x06, 0x18, 0x2b, 0x13, 0x34, 0x36, 0x33, 0x32, 0x16,
+	0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x41, 0x82, 0x5a, 0x5b, 0x80, 0x80, 0x5b, 0x5c, 0x80, 0x02,
+	0x53, 0x59, 0x80, 0x81, 0x5b, 0x5a, 0x81, 0x81, 0x00, 0x01, 0x00, 0x00, 0xff, 0x3a, 0x04, 0x64,
+	0x07, 0x2e, 0x00, 0x08, 0x00, 0x1a, 0x40, 0x17, 0x08, 0x03, 0x02, 0x01, 0x04, 0x01, 0x00, 0x01,
+	0x4c, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x14, 0x02, 0x06, 0x18, 0x2b,
+	0x13, 0x27, 0x25, 0x01, 0x01, 0x33, 0x01, 0x23, 0x01, 0x2a, 0x2a, 0x01, 0x35, 0x01, 0x46, 0x01,
+	0x7b, 0x6e, 0xfe, 0x4b, 0x58, 0xfe, 0x83, 0x01, 0xdc, 0x52, 0x9a, 0xfd, 0x72, 0x06, 0xf4, 0xf8,
+	0x0c, 0x02, 0xfa, 0x00, 0x00, 0x03, 0x00, 0x70, 0x01, 0x39, 0x05, 0x43, 0x04, 0x2b, 0x00, 0x17,
+	0x00, 0x24, 0x00, 0x31, 0x01, 0xbd, 0xb5, 0x0c, 0x01, 0x06, 0x04, 0x01, 0x4c, 0x4b, 0xb0, 0x0b,
+	0x50, 0x58, 0x40, 0x26, 0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00, 0x00, 0x04, 0x06,
+	0x00, 0x04, 0x69, 0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00,
+	0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x05, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x0c, 0x50, 0x58,
+	0x40, 0x28, 0x00, 0x00, 0x00, 0x07, 0x04, 0x00, 0x07, 0x69, 0x00, 0x03, 0x00, 0x04, 0x06, 0x03,
+	0x04, 0x69, 0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x00, 0x02, 0x01, 0x05, 0x02, 0x69,
+	0x00, 0x06, 0x06, 0x01, 0x61, 0x00, 0x01, 0x06, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x0e, 0x50, 0x58,
+	0x40, 0x26, 0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00, 0x00, 0x04, 0x06, 0x00, 0x04,
+	0x69, 0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05, 0x05,
+	0x01, 0x61, 0x02, 0x01, 0x01, 0x05, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x0f, 0x50, 0x58, 0x40, 0x28,
+	0x00, 0x00, 0x00, 0x07, 0x04, 0x00, 0x07, 0x69, 0x00, 0x03, 0x00, 0x04, 0x06, 0x03, 0x04, 0x69,
+	0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x00, 0x02, 0x01, 0x05, 0x02, 0x69, 0x00, 0x06,
+	0x06, 0x01, 0x61, 0x00, 0x01, 0x06, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x11, 0x50, 0x58, 0x40, 0x26,
+	0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00, 0x00, 0x04, 0x06, 0x00, 0x04, 0x69, 0x00,
+	0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05, 0x05, 0x01, 0x61,
+	0x02, 0x01, 0x01, 0x05, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x28, 0x00, 0x00,
+	0x00, 0x07, 0x04, 0x00, 0x07, 0x69, 0x00, 0x03, 0x00, 0x04, 0x06, 0x03, 0x04, 0x69, 0x00, 0x06,
+	0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x00, 0x02, 0x01, 0x05, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01,
+	0x61, 0x00, 0x01, 0x06, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x1b, 0x50, 0x58, 0x40, 0x26, 0x00, 0x07,
+	0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00, 0x00, 0x04, 0x06, 0x00, 0x04, 0x69, 0x00, 0x06, 0x05,
+	0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01, 0x05, 0x59, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01,
+	0x01, 0x05, 0x01, 0x51, 0x1b, 0x4b, 0xb0, 0x1c, 0x50, 0x58, 0x40, 0x28, 0x00, 0x00, 0x00, 0x07,
+	0x04, 0x00, 0x07, 0x69, 0x00, 0x03, 0x00, 0x04, 0x06, 0x03, 0x04, 0x69, 0x00, 0x06, 0x05, 0x01,
+	0x06, 0x59, 0x00, 0x05, 0x00, 0x02, 0x01, 0x05, 0x02, 0x69, 0x00, 0x06, 0x06, 0x01, 0x61, 0x00,
+	0x01, 0x06, 0x01, 0x51, 0x1b, 0x40, 0x26, 0x00, 0x07, 0x04, 0x00, 0x07, 0x59, 0x03, 0x01, 0x00,
+	0x00, 0x04, 0x06, 0x00, 0x04, 0x69, 0x00, 0x06, 0x05, 0x01, 0x06, 0x59, 0x00, 0x05, 0x01, 0x01,
+	0x05, 0x59, 0x00, 0x05, 0x05, 0x01, 0x61, 0x02, 0x01, 0x01, 0x05, 0x01, 0x51, 0x59, 0x59, 0x59,
+	0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x0b, 0x24, 0x25, 0x24, 0x25, 0x24, 0x24, 0x24, 0x22, 0x08,
+	0x06, 0x1e, 0x2b, 0x01, 0x36, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26, 0x27,
+	0x06, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x03, 0x27, 0x26, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x33, 0x32, 0x36, 0x37, 0x17, 0x16, 0x16, 0x33, 0x32, 0x36, 0x35,
+	0x34, 0x26, 0x23, 0x22, 0x06, 0x02, 0xef, 0x3f, 0x97, 0x57, 0x7c, 0xab, 0xb9, 0x80, 0x4d, 0xa3,
+	0x56, 0x40, 0x97, 0x56, 0x7b, 0xac, 0xb9, 0x80, 0x4c, 0xa3, 0x09, 0x13, 0x61, 0x5d, 0x2e, 0x43,
+	0x5e, 0x67, 0x4e, 0x3a, 0x76, 0xd0, 0x14, 0x4f, 0x76, 0x26, 0x44, 0x5d, 0x67, 0x4e, 0x3b, 0x76,
+	0x03, 0x1e, 0x82, 0x82, 0xce, 0x93, 0xa0, 0xe8, 0x86, 0x87, 0x82, 0x82, 0xce, 0x93, 0xa0, 0xe8,
+	0x87, 0xfe, 0xea, 0x1b, 0x83, 0x55, 0x8a, 0x63, 0x5e, 0x7e, 0x6b, 0xb3, 0x1b, 0x6c, 0x6c, 0x8a,
+	0x63, 0x5e, 0x7e, 0x6c, 0x00, 0x01, 0x01, 0x68, 0x00, 0x00, 0x06, 0x4a, 0x04, 0xe2, 0x00, 0x05,
+	0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00,
+	0x01, 0x01, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x01, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05,
+	0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x68, 0x94, 0x04,
+	0x4e, 0x04, 0xe2, 0xfb, 0xb2, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x90, 0x00, 0x00, 0x05, 0x30,
+	0x05, 0xc8, 0x00, 0x11, 0x00, 0x26, 0x40, 0x23, 0x04, 0x03, 0x02, 0x01, 0x00, 0x01, 0x86, 0x00,
+	0x02, 0x00, 0x00, 0x02, 0x59, 0x00, 0x02, 0x02, 0x00, 0x61, 0x00, 0x00, 0x02, 0x00, 0x51, 0x00,
+	0x00, 0x00, 0x11, 0x00, 0x11, 0x23, 0x13, 0x23, 0x05, 0x06, 0x19, 0x2b, 0x21, 0x11, 0x34, 0x00,
+	0x23, 0x22, 0x00, 0x15, 0x11, 0x23, 0x11, 0x34, 0x00, 0x33, 0x32, 0x00, 0x15, 0x11, 0x04, 0x9c,
+	0xfe, 0xfd, 0xb9, 0xb8, 0xfe, 0xfc, 0x94, 0x01, 0x5b, 0xf5, 0xf6, 0x01, 0x5a, 0x03, 0x78, 0xb9,
+	0x01, 0x03, 0xfe, 0xfd, 0xb9, 0xfc, 0x88, 0x03, 0x78, 0xf6, 0x01, 0x5a, 0xfe, 0xa6, 0xf6, 0xfc,
+	0x88, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x90, 0x00, 0x00, 0x05, 0x30, 0x05, 0xc8, 0x00, 0x11,
+	0x00, 0x26, 0x40, 0x23, 0x04, 0x03, 0x02, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x02, 0x02, 0x00,
+	0x59, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0x02, 0x00, 0x02, 0x51, 0x00, 0x00, 0x00, 0x11, 0x00,
+	0x11, 0x23, 0x13, 0x23, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x11, 0x14, 0x00, 0x23, 0x22, 0x00, 0x35,
+	0x11, 0x23, 0x11, 0x14, 0x00, 0x33, 0x32, 0x00, 0x35, 0x11, 0x04, 0x9c, 0xfe, 0xfd, 0xb9, 0xb8,
+	0xfe, 0xfc, 0x94, 0x01, 0x5b, 0xf5, 0xf6, 0x01, 0x5a, 0x05, 0xc8, 0xfc, 0x88, 0xb9, 0xfe, 0xfd,
+	0x01, 0x03, 0xb9, 0x03, 0x78, 0xfc, 0x88, 0xf6, 0xfe, 0xa6, 0x01, 0x5a, 0xf6, 0x03, 0x78, 0x00,
+	0x00, 0x01, 0x00, 0x0c, 0xfe, 0xd8, 0x02, 0x25, 0x07, 0x87, 0x00, 0x5d, 0x00, 0x41, 0x40, 0x3e,
+	0x1d, 0x01, 0x01, 0x02, 0x4c, 0x42, 0x02, 0x05, 0x04, 0x02, 0x4c, 0x00, 0x01, 0x02, 0x04, 0x02,
+	0x01, 0x04, 0x80, 0x00, 0x04, 0x05, 0x02, 0x04, 0x05, 0x7e, 0x00, 0x00, 0x00, 0x02, 0x01, 0x00,
+	0x02, 0x69, 0x00, 0x05, 0x03, 0x03, 0x05, 0x59, 0x00, 0x05, 0x05, 0x03, 0x61, 0x00, 0x03, 0x05,
+	0x03, 0x51, 0x52, 0x51, 0x48, 0x46, 0x3e, 0x3c, 0x19, 0x28, 0x2d, 0x06, 0x06, 0x19, 0x2b, 0x13,
+	0x2e, 0x05, 0x35, 0x34, 0x3e, 0x04, 0x33, 0x32, 0x1e, 0x02, 0x15, 0x14, 0x0e, 0x02, 0x23, 0x22,
+	0x2e, 0x02, 0x35, 0x34, 0x36, 0x37, 0x26, 0x23, 0x22, 0x0e, 0x02, 0x15, 0x14, 0x1e, 0x06, 0x17,
+	0x17, 0x1e, 0x03, 0x15, 0x14, 0x0e, 0x04, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x34, 0x3e, 0x02, 0x33,
+	0x32, 0x1e, 0x02, 0x15, 0x14, 0x06, 0x07, 0x16, 0x33, 0x32, 0x3e, 0x02, 0x35, 0x34, 0x2e, 0x04,
+	0x27, 0xc0, 0x01, 0x04, 0x04, 0x04, 0x04, 0x02, 0x08, 0x15, 0x23, 0x35, 0x4a, 0x31, 0x1b, 0x32,
+	0x25, 0x16, 0x08, 0x12, 0x1b, 0x13, 0x0a, 0x14, 0x11, 0x0b, 0x06, 0x04, 0x09, 0x09, 0x18, 0x1f,
+	0x12, 0x07, 0x03, 0x05, 0x06, 0x07, 0x07, 0x05, 0x04, 0x01, 0x06, 0x02, 0x04, 0x04, 0x03, 0x08,
+	0x15, 0x23, 0x35, 0x4a, 0x31, 0x1b, 0x32, 0x25, 0x16, 0x08, 0x12, 0x1b, 0x13, 0x0a, 0x14, 0x11,
+	0x0b, 0x06, 0x04, 0x09, 0x09, 0x18, 0x1f, 0x12, 0x07, 0x04, 0x07, 0x07, 0x07, 0x06, 0x01, 0x03,
+	0x91, 0x1d, 0x51, 0x5f, 0x66, 0x64, 0x5d, 0x26, 0x31, 0x6c, 0x6a, 0x60, 0x4a, 0x2b, 0x11, 0x20,
+	0x2f, 0x1d, 0x14, 0x24, 0x1d, 0x11, 0x05, 0x0f, 0x1a, 0x15, 0x08, 0x21, 0x08, 0x05, 0x40, 0x5e,
+	0x6b, 0x2b, 0x0a, 0x3d, 0x56, 0x6a, 0x6e, 0x6c, 0x5b, 0x45, 0x0f, 0x8b, 0x2f, 0x89, 0x96, 0x93,
+	0x39, 0x31, 0x6c, 0x6a, 0x60, 0x4a, 0x2b, 0x11, 0x20, 0x2f, 0x1d, 0x13, 0x25, 0x1d, 0x11, 0x05,
+	0x0f, 0x1a, 0x15, 0x08, 0x21, 0x08, 0x05, 0x40, 0x5e, 0x6b, 0x2b, 0x0e, 0x5f, 0x83, 0x95, 0x89,
+	0x6b, 0x17, 0x00, 0x00, 0x00, 0x02, 0x00, 0x45, 0x01, 0x03, 0x04, 0x1f, 0x04, 0x19, 0x00, 0x1a,
+	0x00, 0x35, 0x00, 0x4c, 0x40, 0x49, 0x0d, 0x0b, 0x02, 0x03, 0x00, 0x19, 0x00, 0x02, 0x02, 0x01,
+	0x28, 0x26, 0x02, 0x07, 0x04, 0x34, 0x1b, 0x02, 0x06, 0x05, 0x04, 0x4c, 0x00, 0x00, 0x00, 0x03,
+	0x01, 0x00, 0x03, 0x69, 0x00, 0x01, 0x00, 0x02, 0x04, 0x01, 0x02, 0x69, 0x00, 0x04, 0x00, 0x07,
+	0x05, 0x04, 0x07, 0x69, 0x00, 0x05, 0x06, 0x06, 0x05, 0x59, 0x00, 0x05, 0x05, 0x06, 0x61, 0x00,
+	0x06, 0x05, 0x06, 0x51, 0x26, 0x24, 0x25, 0x24, 0x26, 0x24, 0x25, 0x21, 0x08, 0x06, 0x1e, 0x2b,
+	0x13, 0x10, 0x33, 0x32, 0x1f, 0x03, 0x16, 0x33, 0x32, 0x35, 0x35, 0x33, 0x10, 0x23, 0x22, 0x2f,
+	0x02, 0x26, 0x27, 0x26, 0x23, 0x22, 0x15, 0x15, 0x03, 0x10, 0x33, 0x32, 0x1f, 0x03, 0x16, 0x33,
+	0x32, 0x35, 0x35, 0x33, 0x10, 0x23, 0x22, 0x2f, 0x02, 0x26, 0x27, 0x26, 0x23, 0x22, 0x15, 0x15,
+	0x45, 0xe3, 0x55, 0x73, 0x41, 0x4d, 0x4d, 0x5c, 0x2d, 0x66, 0x65, 0xe3, 0x55, 0x73, 0x40, 0x4d,
+	0x39, 0x15, 0x5b, 0x2e, 0x65, 0x66, 0xe3, 0x55, 0x73, 0x41, 0x4d, 0x4d, 0x5c, 0x2d, 0x66, 0x65,
+	0xe3, 0x55, 0x73, 0x40, 0x4d, 0x39, 0x15, 0x5b, 0x2e, 0x65, 0x02, 0xd8, 0x01, 0x41, 0x38, 0x20,
+	0x24, 0x24, 0x2c, 0xaa, 0x09, 0xfe, 0xbf, 0x38, 0x20, 0x24, 0x1a, 0x0b, 0x2b, 0xaa, 0x09, 0xfe,
+	0x44, 0x01, 0x41, 0x38, 0x20, 0x24, 0x24, 0x2c, 0xaa, 0x09, 0xfe, 0xbf, 0x38, 0x20, 0x24, 0x1a,
+	0x0b, 0x2b, 0xaa, 0x09, 0x00, 0x01, 0x00, 0x72, 0x00, 0x18, 0x04, 0x3a, 0x04, 0x87, 0x00, 0x13,
+	0x00, 0x72, 0x4b, 0xb0, 0x09, 0x50, 0x58, 0x40, 0x2a, 0x00, 0x05, 0x04, 0x04, 0x05, 0x70, 0x00,
+	0x00, 0x01, 0x01, 0x00, 0x71, 0x06, 0x01, 0x04, 0x07, 0x01, 0x03, 0x02, 0x04, 0x03, 0x68, 0x08,
+	0x01, 0x02, 0x01, 0x01, 0x02, 0x57, 0x08, 0x01, 0x02, 0x02, 0x01, 0x5f, 0x0a, 0x09, 0x02, 0x01,
+	0x02, 0x01, 0x4f, 0x1b, 0x40, 0x28, 0x00, 0x05, 0x04, 0x05, 0x85, 0x00, 0x00, 0x01, 0x00, 0x86,
+	0x06, 0x01, 0x04, 0x07, 0x01, 0x03, 0x02, 0x04, 0x03, 0x68, 0x08, 0x01, 0x02, 0x01, 0x01, 0x02,
+	0x57, 0x08, 0x01, 0x02, 0x02, 0x01, 0x5f, 0x0a, 0x09, 0x02, 0x01, 0x02, 0x01, 0x4f, 0x59, 0x40,
+	0x12, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x0b, 0x06, 0x1f, 0x2b, 0x01, 0x03, 0x23, 0x13, 0x21, 0x35, 0x21, 0x37, 0x21, 0x35, 0x21, 0x13,
+	0x33, 0x03, 0x21, 0x15, 0x21, 0x07, 0x21, 0x15, 0x02, 0x44, 0x67, 0x8f, 0x6d, 0xfe, 0xb7, 0x01,
+	0x74, 0x4a, 0xfe, 0x42, 0x01, 0xef, 0x67, 0x8f, 0x67, 0x01, 0x4a, 0xfe, 0x85, 0x4a, 0x01, 0xc5,
+	0x01, 0x4d, 0xfe, 0xcb, 0x01, 0x35, 0x94, 0xde, 0x94, 0x01, 0x34, 0xfe, 0xcc, 0x94, 0xde, 0x94,
+	0x00, 0x03, 0x00, 0x72, 0x00, 0x94, 0x04, 0x39, 0x04, 0x0c, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x40, 0x40, 0x3d, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x00, 0x02, 0x07,
+	0x01, 0x03, 0x00, 0x02, 0x03, 0x67, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01,
+	0x5f, 0x06, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x08, 0x0b, 0x08,
+	0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x09, 0x06,
+	0x17, 0x2b, 0x37, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x72, 0x03,
+	0xc7, 0xfc, 0x39, 0x03, 0xc7, 0xfc, 0x39, 0x03, 0xc7, 0x94, 0x94, 0x94, 0x01, 0x72, 0x94, 0x94,
+	0x01, 0x72, 0x94, 0x94, 0x00, 0x02, 0x00, 0x32, 0x00, 0x00, 0x04, 0x1e, 0x04, 0x58, 0x00, 0x03,
+	0x00, 0x0a, 0x00, 0x27, 0x40, 0x24, 0x0a, 0x08, 0x07, 0x06, 0x05, 0x04, 0x06, 0x00, 0x4a, 0x00,
+	0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f,
+	0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x35, 0x21, 0x15, 0x11,
+	0x01, 0x01, 0x15, 0x05, 0x15, 0x05, 0x46, 0x03, 0xd8, 0xfc, 0x14, 0x03, 0xec, 0xfd, 0xa5, 0x02,
+	0x5b, 0x94, 0x94, 0x01, 0x35, 0x01, 0x92, 0x01, 0x91, 0x9f, 0xf1, 0x02, 0xf2, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x46, 0x00, 0x00, 0x04, 0x32, 0x04, 0x58, 0x00, 0x03, 0x00, 0x0a, 0x00, 0x27,
+	0x40, 0x24, 0x0a, 0x09, 0x08, 0x07, 0x05, 0x04, 0x06, 0x00, 0x4a, 0x00, 0x00, 0x01, 0x01, 0x00,
+	0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03,
+	0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x35, 0x21, 0x15, 0x01, 0x25, 0x35, 0x25, 0x35,
+	0x01, 0x01, 0x46, 0x03, 0xd8, 0xfc, 0x28, 0x02, 0x5b, 0xfd, 0xa5, 0x03, 0xec, 0xfc, 0x14, 0x94,
+	0x94, 0x01, 0xd4, 0xf2, 0x02, 0xf1, 0x9f, 0xfe, 0x6f, 0xfe, 0x6e, 0x00, 0x00, 0x02, 0x00, 0x8a,
+	0x00, 0x00, 0x04, 0x4c, 0x04, 0xa0, 0x00, 0x04, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x08, 0x07,
+	0x06, 0x04, 0x03, 0x02, 0x06, 0x01, 0x4a, 0x02, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x02, 0x01,
+	0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x05, 0x05, 0x05, 0x09, 0x05, 0x09, 0x10,
+	0x03, 0x06, 0x17, 0x2b, 0x21, 0x21, 0x11, 0x01, 0x01, 0x03, 0x11, 0x01, 0x01, 0x11, 0x04, 0x4c,
+	0xfc, 0x3e, 0x01, 0xe1, 0x01, 0xe1, 0x94, 0xfe, 0xb3, 0xfe, 0xb3, 0x02, 0xbf, 0x01, 0xe1, 0xfe,
+	0x1f, 0xfd, 0xd5, 0x01, 0xef, 0x01, 0x4d, 0xfe, 0xb3, 0xfe, 0x11, 0x00, 0x00, 0x01, 0x00, 0x68,
+	0x01, 0x28, 0x04, 0x44, 0x03, 0x78, 0x00, 0x05, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x01, 0x00, 0x01,
+	0x86, 0x00, 0x02, 0x00, 0x00, 0x02, 0x57, 0x00, 0x02, 0x02, 0x00, 0x5f, 0x00, 0x00, 0x02, 0x00,
+	0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b, 0x01, 0x21, 0x11, 0x23, 0x11, 0x21, 0x04, 0x44,
+	0xfc, 0xb8, 0x94, 0x03, 0xdc, 0x02, 0xe4, 0xfe, 0x44, 0x02, 0x50, 0x00, 0x00, 0x01, 0x02, 0x03,
+	0xfe, 0x50, 0x03, 0xe2, 0x06, 0x50, 0x00, 0x14, 0x00, 0x52, 0xb5, 0x0d, 0x01, 0x02, 0x03, 0x01,
+	0x4c, 0x4b, 0xb0, 0x18, 0x50, 0x58, 0x40, 0x1b, 0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x72, 0x00,
+	0x00, 0x00, 0x84, 0x00, 0x01, 0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03,
+	0x01, 0x03, 0x51, 0x1b, 0x40, 0x1c, 0x00, 0x02, 0x03, 0x00, 0x03, 0x02, 0x00, 0x80, 0x00, 0x00,
+	0x00, 0x84, 0x00, 0x01, 0x03, 0x03, 0x01, 0x59, 0x00, 0x01, 0x01, 0x03, 0x61, 0x00, 0x03, 0x01,
+	0x03, 0x51, 0x59, 0xb6, 0x33, 0x24, 0x23, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x23, 0x11, 0x10,
+	0x12, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x35, 0x34, 0x37, 0x26, 0x23, 0x22, 0x11,
+	0x13, 0x02, 0xc8, 0xc5, 0x97, 0xaf, 0x41, 0x58, 0x3b, 0x28, 0x54, 0x05, 0x08, 0x04, 0x65, 0x09,
+	0xfe, 0x50, 0x04, 0xa4, 0x01, 0xcd, 0x01, 0x8f, 0x48, 0x36, 0x2a, 0x3e, 0x53, 0x08, 0x11, 0x02,
+	0xfe, 0x93, 0xfe, 0x80, 0x00, 0x01, 0x00, 0xea, 0xfe, 0x50, 0x02, 0xc9, 0x07, 0x8f, 0x00, 0x14,
+	0x00, 0x50, 0xb5, 0x0d, 0x01, 0x03, 0x02, 0x01, 0x4c, 0x4b, 0xb0, 0x17, 0x50, 0x58, 0x40, 0x1b,
+	0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x03, 0x03, 0x02, 0x70, 0x00, 0x03, 0x01, 0x01, 0x03,
+	0x59, 0x00, 0x03, 0x03, 0x01, 0x62, 0x00, 0x01, 0x03, 0x01, 0x52, 0x1b, 0x40, 0x1a, 0x00, 0x00,
+	0x02, 0x00, 0x85, 0x00, 0x02, 0x03, 0x02, 0x85, 0x00, 0x03, 0x01, 0x01, 0x03, 0x59, 0x00, 0x03,
+	0x03, 0x01, 0x62, 0x00, 0x01, 0x03, 0x01, 0x52, 0x59, 0xb6, 0x33, 0x24, 0x23, 0x10, 0x04, 0x06,
+	0x1a, 0x2b, 0x01, 0x33, 0x11, 0x10, 0x02, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x15,
+	0x14, 0x07, 0x16, 0x33, 0x32, 0x11, 0x03, 0x02, 0x03, 0xc6, 0x98, 0xae, 0x41, 0x58, 0x3a, 0x28,
+	0x54, 0x04, 0x08, 0x04, 0x64, 0x09, 0x07, 0x8f, 0xfa, 0x1d, 0xfe, 0x33, 0xfe, 0x71, 0x48, 0x36,
+	0x2b, 0x3e, 0x54, 0x08, 0x11, 0x01, 0x01, 0x6c, 0x01, 0x80, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0xa6, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01,
+	0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x04, 0xcd, 0x02, 0xa6,
+	0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02,
+	0x06, 0x18, 0x2b, 0x01, 0x33, 0x11, 0x23, 0x02, 0x1d, 0x94, 0x94, 0x07, 0x8f, 0xf6, 0xc1, 0x00,
+	0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x05, 0x00, 0x1e, 0x40, 0x1b,
+	0x00, 0x02, 0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f,
+	0x00, 0x01, 0x00, 0x01, 0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b, 0x01, 0x21, 0x15, 0x21,
+	0x11, 0x23, 0x02, 0x1d, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x03, 0x3a, 0x94, 0xfb, 0xaa, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1, 0x03, 0x3a, 0x00, 0x05, 0x00, 0x24, 0x40, 0x21,
+	0x00, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f,
+	0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x04, 0x06,
+	0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x02, 0xb1, 0x94, 0x02, 0xa6, 0x94, 0xfb, 0x16,
+	0x04, 0x56, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00,
+	0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x01, 0x02, 0x4f, 0x11, 0x11, 0x10, 0x03, 0x06, 0x19, 0x2b,
+	0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0x50, 0x07, 0x8f, 0xfb,
+	0xab, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00,
+	0x00, 0x00, 0x02, 0x5f, 0x03, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x05, 0x00, 0x05,
+	0x11, 0x11, 0x04, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x02, 0x1d, 0x94, 0x02,
+	0xa6, 0x94, 0x04, 0x55, 0xfb, 0x17, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x07, 0x00, 0x24, 0x40, 0x21, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x03, 0x02,
+	0x03, 0x86, 0x00, 0x01, 0x02, 0x02, 0x01, 0x57, 0x00, 0x01, 0x01, 0x02, 0x5f, 0x00, 0x02, 0x01,
+	0x02, 0x4f, 0x11, 0x11, 0x11, 0x10, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21,
+	0x11, 0x23, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x07, 0x8f, 0xfb, 0xab, 0x94, 0xfb,
+	0xaa, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x07,
+	0x00, 0x2a, 0x40, 0x27, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x03, 0x02, 0x86, 0x00, 0x00,
+	0x03, 0x03, 0x00, 0x57, 0x00, 0x00, 0x00, 0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f, 0x00,
+	0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11,
+	0x33, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0x94, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xf6, 0xc1, 0x04,
+	0x56, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x07,
+	0x00, 0x27, 0x40, 0x24, 0x00, 0x01, 0x00, 0x01, 0x86, 0x04, 0x01, 0x03, 0x00, 0x00, 0x03, 0x57,
+	0x04, 0x01, 0x03, 0x03, 0x00, 0x5f, 0x02, 0x01, 0x00, 0x03, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x07,
+	0x00, 0x07, 0x11, 0x11, 0x11, 0x05, 0x06, 0x19, 0x2b, 0x01, 0x15, 0x21, 0x11, 0x23, 0x11, 0x21,
+	0x35, 0x04, 0xcd, 0xfd, 0xe3, 0x94, 0xfd, 0xe4, 0x03, 0x3a, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0x94,
+	0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x27, 0x40, 0x24,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00, 0x00,
+	0x03, 0x5f, 0x04, 0x01, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11,
+	0x11, 0x05, 0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x02, 0x1d, 0x94,
+	0x02, 0x1c, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x00, 0x04, 0x03, 0x04, 0x86, 0x02, 0x01, 0x00, 0x03, 0x03, 0x00, 0x57, 0x02, 0x01, 0x00,
+	0x00, 0x03, 0x5f, 0x06, 0x05, 0x02, 0x03, 0x00, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00, 0x0b,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21,
+	0x15, 0x21, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x02, 0xa6, 0x94,
+	0x04, 0x55, 0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0x02, 0x12, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x03, 0x00, 0x07, 0x00, 0x2f, 0x40, 0x2c, 0x00, 0x00,
+	0x04, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02,
+	0x03, 0x5f, 0x05, 0x01, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07,
+	0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x06, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x21, 0x15, 0x01,
+	0x35, 0x21, 0x15, 0x04, 0xcd, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8, 0x94,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x22, 0x40, 0x1f, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x05, 0x03, 0x04, 0x03,
+	0x01, 0x01, 0x76, 0x04, 0x04, 0x00, 0x00, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00,
+	0x03, 0x11, 0x06, 0x06, 0x17, 0x2b, 0x01, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x01, 0x89,
+	0x94, 0x94, 0x94, 0xfe, 0x50, 0x09, 0x3f, 0xf6, 0xc1, 0x09, 0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x09, 0x00, 0x2e, 0x40, 0x2b,
+	0x05, 0x01, 0x04, 0x03, 0x04, 0x86, 0x00, 0x00, 0x00, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02,
+	0x03, 0x03, 0x02, 0x57, 0x00, 0x02, 0x02, 0x03, 0x5f, 0x00, 0x03, 0x02, 0x03, 0x4f, 0x00, 0x00,
+	0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x21, 0x15,
+	0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x02, 0x1d, 0x02, 0xb0, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4,
+	0xfe, 0x50, 0x05, 0x7e, 0x94, 0x94, 0x94, 0xfc, 0x3e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x01, 0x89,
+	0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25, 0x05, 0x04, 0x02, 0x02,
+	0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00, 0x00, 0x01, 0x5f, 0x03, 0x01,
+	0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06,
+	0x1a, 0x2b, 0x01, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0x89, 0x03, 0x44,
+	0xfe, 0x78, 0x94, 0x94, 0xfe, 0x50, 0x04, 0xea, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x00,
+	0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x33,
+	0x40, 0x30, 0x04, 0x01, 0x01, 0x03, 0x01, 0x86, 0x06, 0x01, 0x02, 0x00, 0x00, 0x05, 0x02, 0x00,
+	0x67, 0x00, 0x05, 0x03, 0x03, 0x05, 0x57, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x00, 0x03, 0x05, 0x03,
+	0x4f, 0x00, 0x00, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x07,
+	0x06, 0x18, 0x2b, 0x01, 0x15, 0x21, 0x11, 0x23, 0x11, 0x01, 0x21, 0x11, 0x23, 0x11, 0x21, 0x04,
+	0xcd, 0xfd, 0x50, 0x94, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x03, 0xce, 0x94, 0xfb, 0x16,
+	0x05, 0x7e, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1,
+	0x03, 0xce, 0x00, 0x09, 0x00, 0x2e, 0x40, 0x2b, 0x00, 0x01, 0x02, 0x01, 0x86, 0x00, 0x00, 0x05,
+	0x01, 0x04, 0x03, 0x00, 0x04, 0x67, 0x00, 0x03, 0x02, 0x02, 0x03, 0x57, 0x00, 0x03, 0x03, 0x02,
+	0x5f, 0x00, 0x02, 0x03, 0x02, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11,
+	0x06, 0x06, 0x1a, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0xb1,
+	0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03, 0x3a, 0x94, 0xfa, 0x82, 0x03, 0xc2, 0x94, 0x94, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x03, 0x3a, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25,
+	0x05, 0x04, 0x02, 0x02, 0x00, 0x02, 0x86, 0x00, 0x01, 0x00, 0x00, 0x01, 0x57, 0x00, 0x01, 0x01,
+	0x00, 0x5f, 0x03, 0x01, 0x00, 0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11,
+	0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11,
+	0x01, 0x89, 0xfe, 0x77, 0x03, 0x45, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0xfb, 0x16, 0x04,
+	0x56, 0xfb, 0xaa, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x03, 0xce, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x38, 0x40, 0x35, 0x04, 0x01, 0x01, 0x02, 0x01, 0x86, 0x00, 0x03, 0x07, 0x01,
+	0x05, 0x00, 0x03, 0x05, 0x67, 0x00, 0x00, 0x02, 0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f,
+	0x06, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x06, 0x06, 0x00, 0x00, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09,
+	0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x08, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11,
+	0x23, 0x11, 0x01, 0x35, 0x21, 0x11, 0x23, 0x11, 0x02, 0x1d, 0x94, 0xfe, 0x77, 0x03, 0x45, 0x94,
+	0x02, 0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0xfa, 0x82, 0x04, 0xea, 0x00, 0x00,
+	0x00, 0x01, 0x02, 0x1d, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x28, 0x40, 0x25,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x67, 0x00, 0x03, 0x04,
+	0x04, 0x03, 0x57, 0x00, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x11, 0x11, 0x11,
+	0x11, 0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21,
+	0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0x50, 0x07, 0x8f, 0xfc, 0x3f, 0x94,
+	0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x01, 0x89, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x23, 0x40, 0x20, 0x02, 0x01, 0x00, 0x01, 0x00, 0x85, 0x03, 0x01, 0x01, 0x04, 0x04, 0x01,
+	0x57, 0x03, 0x01, 0x01, 0x01, 0x04, 0x5f, 0x00, 0x04, 0x01, 0x04, 0x4f, 0x11, 0x11, 0x11, 0x11,
+	0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x01,
+	0x89, 0x94, 0x94, 0x94, 0x01, 0x88, 0xfc, 0xbc, 0x07, 0x8f, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab,
+	0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x2a, 0x40, 0x27, 0x04, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x02, 0x00, 0x00,
+	0x05, 0x02, 0x00, 0x67, 0x00, 0x05, 0x03, 0x03, 0x05, 0x57, 0x00, 0x05, 0x05, 0x03, 0x5f, 0x00,
+	0x03, 0x05, 0x03, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10, 0x06, 0x06, 0x1c, 0x2b, 0x01, 0x21,
+	0x11, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x33, 0x11, 0x21, 0x04, 0xcd, 0xfd, 0xe4, 0x94, 0x01,
+	0x88, 0xfc, 0xbc, 0x94, 0x02, 0xb0, 0x03, 0x3a, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0x05, 0x7d,
+	0xfb, 0x17, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0x12, 0x02, 0xb1, 0x07, 0x8f, 0x00, 0x09,
+	0x00, 0x2e, 0x40, 0x2b, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x05, 0x01, 0x04, 0x03, 0x00,
+	0x04, 0x67, 0x00, 0x03, 0x02, 0x02, 0x03, 0x57, 0x00, 0x03, 0x03, 0x02, 0x5f, 0x00, 0x02, 0x03,
+	0x02, 0x4f, 0x00, 0x00, 0x00, 0x09, 0x00, 0x09, 0x11, 0x11, 0x11, 0x11, 0x06, 0x06, 0x1a, 0x2b,
+	0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0x1d, 0x94, 0xfd, 0x4f, 0x02,
+	0x1d, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfa, 0x83, 0x94, 0x94, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0xa6, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x09, 0x00, 0x23, 0x40, 0x20, 0x02, 0x01, 0x00, 0x01,
+	0x00, 0x85, 0x04, 0x01, 0x01, 0x03, 0x03, 0x01, 0x57, 0x04, 0x01, 0x01, 0x01, 0x03, 0x5f, 0x00,
+	0x03, 0x01, 0x03, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x10, 0x05, 0x06, 0x1b, 0x2b, 0x01, 0x33, 0x11,
+	0x33, 0x11, 0x33, 0x11, 0x21, 0x35, 0x21, 0x01, 0x89, 0x94, 0x94, 0x94, 0xfc, 0xbb, 0x01, 0x89,
+	0x07, 0x8f, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0x17, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0x02, 0x12, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x38, 0x40, 0x35, 0x04, 0x01,
+	0x01, 0x00, 0x01, 0x85, 0x00, 0x00, 0x06, 0x01, 0x02, 0x03, 0x00, 0x02, 0x67, 0x00, 0x03, 0x05,
+	0x05, 0x03, 0x57, 0x00, 0x03, 0x03, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x03, 0x05, 0x4f, 0x06, 0x06,
+	0x00, 0x00, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11,
+	0x08, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x01, 0x35, 0x21, 0x11, 0x33, 0x11,
+	0x01, 0x89, 0x94, 0xfd, 0xe3, 0x02, 0xb1, 0x94, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfb, 0xab, 0xfe,
+	0xd8, 0x94, 0x04, 0xe9, 0xfa, 0x83, 0x00, 0x00, 0x00, 0x01, 0x02, 0x1d, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x2e, 0x40, 0x2b, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x05, 0x04,
+	0x05, 0x86, 0x00, 0x01, 0x00, 0x02, 0x03, 0x01, 0x02, 0x67, 0x00, 0x03, 0x04, 0x04, 0x03, 0x57,
+	0x00, 0x03, 0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x10,
+	0x06, 0x06, 0x1c, 0x2b, 0x01, 0x33, 0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x23,
+	0x02, 0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0x07, 0x8f, 0xfc, 0x3f,
+	0x94, 0x94, 0x94, 0xfc, 0x3e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x37, 0x40, 0x34, 0x02, 0x01, 0x00, 0x03, 0x00, 0x85,
+	0x07, 0x05, 0x06, 0x03, 0x01, 0x04, 0x01, 0x86, 0x00, 0x03, 0x04, 0x04, 0x03, 0x57, 0x00, 0x03,
+	0x03, 0x04, 0x5f, 0x00, 0x04, 0x03, 0x04, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0b, 0x04, 0x0b,
+	0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x08, 0x06, 0x17, 0x2b, 0x01,
+	0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x01, 0x89, 0x94, 0x94, 0x94,
+	0x01, 0x88, 0xfe, 0x78, 0xfe, 0x50, 0x09, 0x3f, 0xf6, 0xc1, 0x09, 0x3f, 0xfb, 0xab, 0x94, 0xfb,
+	0xaa, 0x00, 0x00, 0x00, 0x00, 0x03, 0x01, 0x89, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x09, 0x00, 0x0f, 0x00, 0x32, 0x40, 0x2f, 0x03, 0x01, 0x00, 0x04, 0x00, 0x85, 0x06, 0x01,
+	0x01, 0x05, 0x01, 0x86, 0x00, 0x04, 0x00, 0x02, 0x07, 0x04, 0x02, 0x67, 0x00, 0x07, 0x05, 0x05,
+	0x07, 0x57, 0x00, 0x07, 0x07, 0x05, 0x5f, 0x00, 0x05, 0x07, 0x05, 0x4f, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x10, 0x08, 0x06, 0x1e, 0x2b, 0x01, 0x33, 0x11, 0x23, 0x01, 0x21, 0x11, 0x33,
+	0x11, 0x21, 0x11, 0x21, 0x11, 0x23, 0x11, 0x21, 0x01, 0x89, 0x94, 0x94, 0x03, 0x44, 0xfd, 0xe4,
+	0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x07, 0x8f, 0xf6, 0xc1, 0x04, 0xea, 0x04, 0x55,
+	0xfc, 0x3f, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0xb1,
+	0x07, 0x8f, 0x00, 0x0b, 0x00, 0x34, 0x40, 0x31, 0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x02, 0x03,
+	0x02, 0x86, 0x00, 0x00, 0x06, 0x01, 0x05, 0x04, 0x00, 0x05, 0x67, 0x00, 0x04, 0x03, 0x03, 0x04,
+	0x57, 0x00, 0x04, 0x04, 0x03, 0x5f, 0x00, 0x03, 0x04, 0x03, 0x4f, 0x00, 0x00, 0x00, 0x0b, 0x00,
+	0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11,
+	0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02, 0x1d, 0x94, 0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03, 0x3a,
+	0x94, 0x03, 0xc1, 0xf6, 0xc1, 0x03, 0xc2, 0x94, 0x94, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x35, 0x40, 0x32, 0x04, 0x01,
+	0x02, 0x01, 0x02, 0x85, 0x07, 0x05, 0x06, 0x03, 0x03, 0x00, 0x03, 0x86, 0x00, 0x01, 0x00, 0x00,
+	0x01, 0x57, 0x00, 0x01, 0x01, 0x00, 0x5f, 0x00, 0x00, 0x01, 0x00, 0x4f, 0x08, 0x08, 0x00, 0x00,
+	0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08, 0x06, 0x19,
+	0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x01, 0x89, 0xfe,
+	0x77, 0x01, 0x89, 0x94, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0x04, 0x55, 0xf6, 0xc1, 0x09,
+	0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x03, 0x45, 0x07, 0x8f, 0x00, 0x05,
+	0x00, 0x0b, 0x00, 0x0f, 0x00, 0x42, 0x40, 0x3f, 0x06, 0x01, 0x04, 0x03, 0x04, 0x85, 0x07, 0x01,
+	0x01, 0x02, 0x01, 0x86, 0x00, 0x03, 0x09, 0x01, 0x05, 0x00, 0x03, 0x05, 0x67, 0x00, 0x00, 0x02,
+	0x02, 0x00, 0x57, 0x00, 0x00, 0x00, 0x02, 0x5f, 0x08, 0x01, 0x02, 0x00, 0x02, 0x4f, 0x06, 0x06,
+	0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05,
+	0x00, 0x05, 0x11, 0x11, 0x0a, 0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35,
+	0x21, 0x11, 0x33, 0x11, 0x13, 0x33, 0x11, 0x23, 0x02, 0x1d, 0x94, 0xfe, 0x77, 0x01, 0x89, 0x94,
+	0x94, 0x94, 0x94, 0x02, 0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0x03, 0xc1, 0xfb,
+	0xab, 0x04, 0x55, 0xf6, 0xc1, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x03, 0xce, 0x00, 0x03, 0x00, 0x0b, 0x00, 0x39, 0x40, 0x36, 0x00, 0x04, 0x03, 0x04, 0x86, 0x00,
+	0x00, 0x06, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x00, 0x02, 0x03, 0x03, 0x02, 0x57, 0x00, 0x02,
+	0x02, 0x03, 0x5f, 0x07, 0x05, 0x02, 0x03, 0x02, 0x03, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x04, 0x0b,
+	0x04, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x08, 0x06, 0x17,
+	0x2b, 0x11, 0x35, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x04, 0xcd, 0xfb,
+	0x33, 0x04, 0xcd, 0xfd, 0xe4, 0x94, 0x03, 0x3a, 0x94, 0x94, 0xfe, 0xd8, 0x94, 0x94, 0xfc, 0x3e,
+	0x03, 0xc2, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x03, 0x3a, 0x00, 0x0b,
+	0x00, 0x2a, 0x40, 0x27, 0x04, 0x01, 0x02, 0x01, 0x02, 0x86, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57,
+	0x00, 0x00, 0x00, 0x01, 0x5f, 0x06, 0x05, 0x03, 0x03, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00,
+	0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35, 0x21, 0x15,
+	0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x23, 0x11, 0x04, 0xcd, 0xfe, 0x78, 0x94, 0x94, 0x94, 0x02,
+	0xa6, 0x94, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x04, 0x56, 0x00, 0x00, 0x03, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x03, 0xce, 0x00, 0x03, 0x00, 0x09, 0x00, 0x0f, 0x00, 0x40, 0x40, 0x3d,
+	0x06, 0x01, 0x03, 0x04, 0x03, 0x86, 0x00, 0x00, 0x08, 0x01, 0x01, 0x02, 0x00, 0x01, 0x67, 0x07,
+	0x01, 0x02, 0x04, 0x04, 0x02, 0x57, 0x07, 0x01, 0x02, 0x02, 0x04, 0x5f, 0x05, 0x09, 0x02, 0x04,
+	0x02, 0x04, 0x4f, 0x04, 0x04, 0x00, 0x00, 0x0f, 0x0e, 0x0d, 0x0c, 0x0b, 0x0a, 0x04, 0x09, 0x04,
+	0x09, 0x08, 0x07, 0x06, 0x05, 0x00, 0x03, 0x00, 0x03, 0x11, 0x0a, 0x06, 0x17, 0x2b, 0x11, 0x35,
+	0x21, 0x15, 0x01, 0x35, 0x21, 0x11, 0x23, 0x11, 0x21, 0x21, 0x11, 0x23, 0x11, 0x21, 0x04, 0xcd,
+	0xfb, 0x33, 0x02, 0x1d, 0x94, 0x03, 0x44, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x03, 0x3a, 0x94, 0x94,
+	0xfe, 0xd8, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x00, 0x02, 0x00, 0x00,
+	0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x37, 0x40, 0x34, 0x00, 0x01,
+	0x00, 0x01, 0x85, 0x02, 0x01, 0x00, 0x06, 0x01, 0x03, 0x04, 0x00, 0x03, 0x67, 0x00, 0x04, 0x05,
+	0x05, 0x04, 0x57, 0x00, 0x04, 0x04, 0x05, 0x5f, 0x07, 0x01, 0x05, 0x04, 0x05, 0x4f, 0x08, 0x08,
+	0x00, 0x00, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x00, 0x07, 0x00, 0x07, 0x11, 0x11, 0x11, 0x08,
+	0x06, 0x19, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x35, 0x21, 0x15, 0x02,
+	0x1d, 0x94, 0x02, 0x1c, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a, 0x94, 0x03, 0xc1, 0xfc, 0x3f, 0x94,
+	0xfe, 0xd8, 0x94, 0x94, 0x00, 0x01, 0x00, 0x00, 0x02, 0xa6, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x0b,
+	0x00, 0x2c, 0x40, 0x29, 0x03, 0x01, 0x01, 0x00, 0x01, 0x85, 0x04, 0x02, 0x02, 0x00, 0x05, 0x05,
+	0x00, 0x57, 0x04, 0x02, 0x02, 0x00, 0x00, 0x05, 0x5f, 0x06, 0x01, 0x05, 0x00, 0x05, 0x4f, 0x00,
+	0x00, 0x00, 0x0b, 0x00, 0x0b, 0x11, 0x11, 0x11, 0x11, 0x11, 0x07, 0x06, 0x1b, 0x2b, 0x11, 0x35,
+	0x21, 0x11, 0x33, 0x11, 0x33, 0x11, 0x33, 0x11, 0x21, 0x15, 0x01, 0x89, 0x94, 0x94, 0x94, 0x01,
+	0x88, 0x02, 0xa6, 0x94, 0x04, 0x55, 0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x00, 0x02, 0x12, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x0f,
+	0x00, 0x3e, 0x40, 0x3b, 0x04, 0x01, 0x01, 0x00, 0x01, 0x85, 0x05, 0x01, 0x00, 0x03, 0x08, 0x02,
+	0x02, 0x06, 0x00, 0x02, 0x67, 0x00, 0x06, 0x07, 0x07, 0x06, 0x57, 0x00, 0x06, 0x06, 0x07, 0x5f,
+	0x09, 0x01, 0x07, 0x06, 0x07, 0x4f, 0x0c, 0x0c, 0x00, 0x00, 0x0c, 0x0f, 0x0c, 0x0f, 0x0e, 0x0d,
+	0x0b, 0x0a, 0x09, 0x08, 0x07, 0x06, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x0a, 0x06, 0x18, 0x2b,
+	0x11, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21, 0x21, 0x11, 0x33, 0x11, 0x21, 0x01, 0x35, 0x21, 0x15,
+	0x01, 0x89, 0x94, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfb, 0x33, 0x04, 0xcd, 0x03, 0x3a,
+	0x94, 0x03, 0xc1, 0xfb, 0xab, 0x04, 0x55, 0xfc, 0x3f, 0xfe, 0x44, 0x94, 0x94, 0x00, 0x00, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x3d, 0x40, 0x3a,
+	0x00, 0x01, 0x00, 0x01, 0x85, 0x00, 0x06, 0x05, 0x06, 0x86, 0x02, 0x01, 0x00, 0x0a, 0x09, 0x02,
+	0x03, 0x04, 0x00, 0x03, 0x67, 0x08, 0x01, 0x04, 0x05, 0x05, 0x04, 0x57, 0x08, 0x01, 0x04, 0x04,
+	0x05, 0x5f, 0x07, 0x01, 0x05, 0x04, 0x05, 0x4f, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11,
+	0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0b, 0x06, 0x1f, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x33,
+	0x11, 0x21, 0x15, 0x21, 0x15, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x21, 0x35, 0x21, 0x35, 0x02,
+	0x1d, 0x94, 0x02, 0x1c, 0xfd, 0xe4, 0x02, 0x1c, 0xfd, 0xe4, 0x94, 0xfd, 0xe3, 0x02, 0x1d, 0x03,
+	0x3a, 0x94, 0x03, 0xc1, 0xfc, 0x3f, 0x94, 0x94, 0x94, 0xfc, 0x3e, 0x03, 0xc2, 0x94, 0x94, 0x00,
+	0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x13, 0x00, 0x38, 0x40, 0x35,
+	0x04, 0x01, 0x02, 0x01, 0x02, 0x85, 0x0a, 0x09, 0x02, 0x07, 0x00, 0x07, 0x86, 0x05, 0x03, 0x02,
+	0x01, 0x00, 0x00, 0x01, 0x57, 0x05, 0x03, 0x02, 0x01, 0x01, 0x00, 0x5f, 0x08, 0x06, 0x02, 0x00,
+	0x01, 0x00, 0x4f, 0x00, 0x00, 0x00, 0x13, 0x00, 0x13, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x11,
+	0x11, 0x11, 0x0b, 0x06, 0x1f, 0x2b, 0x01, 0x11, 0x21, 0x35, 0x21, 0x11, 0x33, 0x11, 0x33, 0x11,
+	0x33, 0x11, 0x21, 0x15, 0x21, 0x11, 0x23, 0x11, 0x23, 0x11, 0x01, 0x89, 0xfe, 0x77, 0x01, 0x89,
+	0x94, 0x94, 0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x94, 0xfe, 0x50, 0x04, 0x56, 0x94, 0x04, 0x55,
+	0xfb, 0xab, 0x04, 0x55, 0xfb, 0xab, 0x94, 0xfb, 0xaa, 0x04, 0x56, 0xfb, 0xaa, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x05, 0x00, 0x0b, 0x00, 0x11,
+	0x00, 0x17, 0x00, 0x4f, 0x40, 0x4c, 0x07, 0x01, 0x04, 0x03, 0x04, 0x85, 0x0a, 0x01, 0x01, 0x02,
+	0x01, 0x86, 0x08, 0x01, 0x03, 0x06, 0x0d, 0x02, 0x05, 0x00, 0x03, 0x05, 0x67, 0x0b, 0x01, 0x00,
+	0x02, 0x02, 0x00, 0x57, 0x0b, 0x01, 0x00, 0x00, 0x02, 0x5f, 0x09, 0x0c, 0x02, 0x02, 0x00, 0x02,
+	0x4f, 0x06, 0x06, 0x00, 0x00, 0x17, 0x16, 0x15, 0x14, 0x13, 0x12, 0x11, 0x10, 0x0f, 0x0e, 0x0d,
+	0x0c, 0x06, 0x0b, 0x06, 0x0b, 0x0a, 0x09, 0x08, 0x07, 0x00, 0x05, 0x00, 0x05, 0x11, 0x11, 0x0e,
+	0x06, 0x18, 0x2b, 0x11, 0x35, 0x21, 0x11, 0x23, 0x11, 0x01, 0x35, 0x21, 0x11, 0x33, 0x11, 0x21,
+	0x21, 0x11, 0x33, 0x11, 0x21, 0x11, 0x21, 0x11, 0x23, 0x11, 0x21, 0x02, 0x1d, 0x94, 0xfe, 0x77,
+	0x01, 0x89, 0x94, 0x02, 0xb0, 0xfd, 0xe4, 0x94, 0x01, 0x88, 0xfe, 0x78, 0x94, 0x02, 0x1c, 0x02,
+	0x12, 0x94, 0xfb, 0xaa, 0x03, 0xc2, 0x01, 0x28, 0x94, 0x03, 0xc1, 0xfb, 0xab, 0x04, 0x55, 0xfc,
+	0x3f, 0xfe, 0x44, 0xfc, 0x3e, 0x04, 0x56, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xf0, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01,
+	0x01, 0x76, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x11, 0x11, 0x21,
+	0x11, 0x04, 0xcd, 0x02, 0xf0, 0x04, 0x9f, 0xfb, 0x61, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0xfe, 0x50, 0x04, 0xcd, 0x02, 0xf0, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00,
+	0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x04,
+	0xcd, 0xfb, 0x33, 0x02, 0xf0, 0xfb, 0x60, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01,
+	0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x04, 0xcd, 0xfb, 0x33, 0x07,
+	0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x00, 0x00, 0xfe, 0x50, 0x02, 0x67, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x11, 0x40, 0x0e, 0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02,
+	0x06, 0x18, 0x2b, 0x11, 0x21, 0x11, 0x21, 0x02, 0x67, 0xfd, 0x99, 0x07, 0x8f, 0xf6, 0xc1, 0x00,
+	0x00, 0x01, 0x02, 0x66, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x11, 0x40, 0x0e,
+	0x00, 0x00, 0x01, 0x00, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x02, 0x06, 0x18, 0x2b, 0x01,
+	0x21, 0x11, 0x21, 0x02, 0x66, 0x02, 0x67, 0xfd, 0x99, 0x07, 0x8f, 0xf6, 0xc1, 0x00, 0x00, 0x00,
+	0x00, 0x12, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x06, 0xcb, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b,
+	0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0xf9,
+	0x40, 0xf6, 0x14, 0x0a, 0x02, 0x00, 0x2e, 0x15, 0x29, 0x0b, 0x24, 0x05, 0x01, 0x02, 0x00, 0x01,
+	0x67, 0x16, 0x0c, 0x02, 0x02, 0x2f, 0x17, 0x2a, 0x0d, 0x25, 0x05, 0x03, 0x04, 0x02, 0x03, 0x67,
+	0x18, 0x0e, 0x02, 0x04, 0x30, 0x19, 0x2b, 0x0f, 0x26, 0x05, 0x05, 0x06, 0x04, 0x05, 0x67, 0x1a,
+	0x10, 0x02, 0x06, 0x31, 0x1b, 0x2c, 0x11, 0x27, 0x05, 0x07, 0x08, 0x06, 0x07, 0x67, 0x1c, 0x12,
+	0x02, 0x08, 0x32, 0x1d, 0x2d, 0x13, 0x28, 0x05, 0x09, 0x1e, 0x08, 0x09, 0x67, 0x22, 0x20, 0x02,
+	0x1e, 0x1f, 0x1f, 0x1e, 0x57, 0x22, 0x20, 0x02, 0x1e, 0x1e, 0x1f, 0x5f, 0x35, 0x23, 0x34, 0x21,
+	0x33, 0x05, 0x1f, 0x1e, 0x1f, 0x4f, 0x44, 0x44, 0x40, 0x40, 0x3c, 0x3c, 0x38, 0x38, 0x34, 0x34,
+	0x30, 0x30, 0x2c, 0x2c, 0x28, 0x28, 0x24, 0x24, 0x20, 0x20, 0x1c, 0x1c, 0x18, 0x18, 0x14, 0x14,
+	0x10, 0x10, 0x0c, 0x0c, 0x08, 0x08, 0x04, 0x04, 0x00, 0x00, 0x44, 0x47, 0x44, 0x47, 0x46, 0x45,
+	0x40, 0x43, 0x40, 0x43, 0x42, 0x41, 0x3c, 0x3f, 0x3c, 0x3f, 0x3e, 0x3d, 0x38, 0x3b, 0x38, 0x3b,
+	0x3a, 0x39, 0x34, 0x37, 0x34, 0x37, 0x36, 0x35, 0x30, 0x33, 0x30, 0x33, 0x32, 0x31, 0x2c, 0x2f,
+	0x2c, 0x2f, 0x2e, 0x2d, 0x28, 0x2b, 0x28, 0x2b, 0x2a, 0x29, 0x24, 0x27, 0x24, 0x27, 0x26, 0x25,
+	0x20, 0x23, 0x20, 0x23, 0x22, 0x21, 0x1c, 0x1f, 0x1c, 0x1f, 0x1e, 0x1d, 0x18, 0x1b, 0x18, 0x1b,
+	0x1a, 0x19, 0x14, 0x17, 0x14, 0x17, 0x16, 0x15, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0c, 0x0f,
+	0x0c, 0x0f, 0x0e, 0x0d, 0x08, 0x0b, 0x08, 0x0b, 0x0a, 0x09, 0x04, 0x07, 0x04, 0x07, 0x06, 0x05,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x36, 0x06, 0x17, 0x2b, 0x11, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33,
+	0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33,
+	0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33,
+	0x15, 0x01, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33,
+	0x15, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0xcb, 0xce, 0x01, 0xce,
+	0xfe, 0x65, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0xcb, 0xce, 0x01, 0xce, 0xfe, 0x65, 0xce, 0x01,
+	0xce, 0xfe, 0x65, 0xce, 0xfc, 0xce, 0xcd, 0xcb, 0xce, 0xcb, 0xce, 0x06, 0x06, 0xc5, 0xc5, 0xfe,
+	0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06,
+	0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe,
+	0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x75, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0x00,
+	0x00, 0x24, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b,
+	0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b, 0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b,
+	0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b, 0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0x4b,
+	0x00, 0x4f, 0x00, 0x53, 0x00, 0x57, 0x00, 0x5b, 0x00, 0x5f, 0x00, 0x63, 0x00, 0x67, 0x00, 0x6b,
+	0x00, 0x6f, 0x00, 0x73, 0x00, 0x77, 0x00, 0x7b, 0x00, 0x7f, 0x00, 0x83, 0x00, 0x87, 0x00, 0x8b,
+	0x00, 0x8f, 0x00, 0x00, 0x11, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x13, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15,
+	0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x03, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15, 0x01, 0x35, 0x33, 0x15, 0x33, 0x35, 0x33, 0x15,
+	0x33, 0x35, 0x33, 0x15, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc,
+	0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc,
+	0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xcc, 0xcc, 0xcc, 0xcc,
+	0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0x02, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xc7, 0xfb,
+	0x33, 0xcc, 0xd0, 0xcc, 0xd0, 0xcc, 0xfc, 0xca, 0xcc, 0xd0, 0xcc, 0xd0, 0xc7, 0x05, 0x41, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4,
+	0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x05, 0x67, 0xc3, 0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3,
+	0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x05, 0x67, 0xc3,
+	0xc3, 0xfe, 0x75, 0xc4, 0xc4, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4,
+	0xc4, 0x06, 0xf3, 0xc3, 0xc3, 0xfe, 0x75, 0xc3, 0xc3, 0xfe, 0x74, 0xc4, 0xc4, 0xfe, 0x75, 0xc4,
+	0xc4, 0xfe, 0x75, 0xc4, 0xc4, 0x06, 0xf1, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xf7, 0x85, 0xc3,
+	0xc3, 0xc3, 0xc3, 0xc3, 0xc3, 0x00, 0x00, 0x00, 0x00, 0x13, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x07, 0x00, 0x0b, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x17, 0x00, 0x1b,
+	0x00, 0x1f, 0x00, 0x23, 0x00, 0x27, 0x00, 0x2b, 0x00, 0x2f, 0x00, 0x33, 0x00, 0x37, 0x00, 0x3b,
+	0x00, 0x3f, 0x00, 0x43, 0x00, 0x47, 0x00, 0x4b, 0x00, 0x00, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35,
+	0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35,
+	0x23, 0x15, 0x13, 0x35, 0x23, 0x15, 0x01, 0x35, 0x23, 0x15, 0x21, 0x35, 0x23, 0x15, 0x21, 0x35,
+	0x23, 0x15, 0x01, 0x21, 0x11, 0x21, 0xce, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce,
+	0x01, 0xce, 0x02, 0x67, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x02,
+	0x67, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0x01, 0x9b, 0xce, 0x01, 0xce, 0xfe, 0x69, 0xcd, 0x02,
+	0x66, 0xce, 0x02, 0x67, 0xce, 0xfc, 0x01, 0x04, 0xcd, 0xfb, 0x33, 0x06, 0x06, 0xc5, 0xc5, 0xfe,
+	0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0x06,
+	0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x74, 0xc5, 0xc5, 0xfe,
+	0x76, 0xc5, 0xc5, 0x06, 0x2b, 0xc5, 0xc5, 0xfe, 0x75, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe,
+	0x74, 0xc5, 0xc5, 0xfe, 0x76, 0xc5, 0xc5, 0xfe, 0x75, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0xc4, 0x09,
+	0x3f, 0xf6, 0xc1, 0x00, 0x00, 0x01, 0x00, 0x64, 0x00, 0x00, 0x04, 0x71, 0x04, 0x0d, 0x00, 0x03,
+	0x00, 0x17, 0x40, 0x14, 0x00, 0x00, 0x01, 0x00, 0x85, 0x02, 0x01, 0x01, 0x01, 0x76, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x03, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x33, 0x11, 0x21, 0x11, 0x64, 0x04, 0x0d,
+	0x04, 0x0d, 0xfb, 0xf3, 0x00, 0x02, 0x00, 0x64, 0x00, 0x00, 0x04, 0x71, 0x04, 0x0d, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02,
+	0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00,
+	0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x33, 0x11,
+	0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x64, 0x04, 0x0d, 0xfc, 0x56, 0x03, 0x48, 0xfc, 0xb8, 0x04,
+	0x0d, 0xfb, 0xf3, 0x63, 0x03, 0x48, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64, 0x01, 0x95, 0x02, 0x72,
+	0x03, 0xa3, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x06, 0x17, 0x2b, 0x13, 0x11, 0x21, 0x11, 0x64, 0x02, 0x0e, 0x01, 0x95, 0x02, 0x0e, 0xfd,
+	0xf2, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x64, 0x01, 0x9f, 0x02, 0x72, 0x03, 0xad, 0x00, 0x03,
+	0x00, 0x07, 0x00, 0x2a, 0x40, 0x27, 0x00, 0x00, 0x00, 0x03, 0x02, 0x00, 0x03, 0x67, 0x00, 0x02,
+	0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x02, 0x01, 0x4f, 0x00,
+	0x00, 0x07, 0x06, 0x05, 0x04, 0x00, 0x03, 0x00, 0x03, 0x11, 0x05, 0x06, 0x17, 0x2b, 0x13, 0x11,
+	0x21, 0x11, 0x25, 0x21, 0x11, 0x21, 0x64, 0x02, 0x0e, 0xfe, 0x55, 0x01, 0x49, 0xfe, 0xb7, 0x01,
+	0x9f, 0x02, 0x0e, 0xfd, 0xf2, 0x63, 0x01, 0x48, 0x00, 0x01, 0x00, 0x00, 0x02, 0x00, 0x08, 0x00,
+	0x04, 0x00, 0x00, 0x03, 0x00, 0x1e, 0x40, 0x1b, 0x00, 0x00, 0x01, 0x01, 0x00, 0x57, 0x00, 0x00,
+	0x00, 0x01, 0x5f, 0x02, 0x01, 0x01, 0x00, 0x01, 0x4f, 0x00, 0x00, 0x00, 0x03, 0x00, 0x03, 0x11,
+	0x03, 0x06, 0x17, 0x2b, 0x11, 0x11, 0x21, 0x11, 0x08, 0x00, 0x02, 0x00, 0x02, 0x00, 0xfe, 0x00,
+	0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x15, 0x40, 0x12,
+	0x01, 0x01, 0x00, 0x4a, 0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00, 0x02, 0x00, 0x02, 0x02,
+	0x06, 0x16, 0x2b, 0x33, 0x01, 0x01, 0xfa, 0x02, 0xfc, 0x02, 0xfb, 0x05, 0xf7, 0xfa, 0x09, 0x00,
+	0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02, 0x00, 0x06, 0xb3, 0x02,
+	0x00, 0x01, 0x32, 0x2b, 0x13, 0x01, 0x01, 0xfa, 0x05, 0xf7, 0xfa, 0x09, 0x05, 0xf7, 0xfd, 0x04,
+	0xfd, 0x05, 0x00, 0x00, 0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1, 0x05, 0xf7, 0x00, 0x02,
+	0x00, 0x15, 0x40, 0x12, 0x01, 0x01, 0x00, 0x49, 0x01, 0x01, 0x00, 0x00, 0x76, 0x00, 0x00, 0x00,
+	0x02, 0x00, 0x02, 0x02, 0x06, 0x16, 0x2b, 0x09, 0x02, 0x06, 0xf1, 0xfd, 0x04, 0xfd, 0x05, 0x05,
+	0xf7, 0xfa, 0x09, 0x05, 0xf7, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0xfa, 0x00, 0x00, 0x06, 0xf1,
+	0x05, 0xf7, 0x00, 0x02, 0x00, 0x06, 0xb3, 0x02, 0x00, 0x01, 0x32, 0x2b, 0x21, 0x01, 0x01, 0x06,
+	0xf1, 0xfa, 0x09, 0x05, 0xf7, 0x02, 0xfc, 0x02, 0xfb, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x20,
+	0x01, 0x22, 0x03, 0xd3, 0x04, 0xd5, 0x00, 0x03, 0x00, 0x07, 0x00, 0x08, 0xb5, 0x07, 0x05, 0x03,
+	0x01, 0x02, 0x32, 0x2b, 0x09, 0x07, 0x03, 0xd3, 0xfe, 0x26, 0xfe, 0x27, 0x01, 0xd9, 0x01, 0x33,
+	0xfe, 0xcd, 0xfe, 0xce, 0x01, 0x32, 0x02, 0xfc, 0xfe, 0x26, 0x01, 0xda, 0x01, 0xd9, 0xfe, 0x27,
+	0x01, 0x32, 0xfe, 0xce, 0xfe, 0xcd, 0x00, 0x00, 0x00, 0x02, 0x00, 0xae, 0x00, 0xde, 0x04, 0x26,
+	0x04, 0x56, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01, 0x00, 0x03, 0x02, 0x01,
+	0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02, 0x02, 0x00, 0x61, 0x04,
+	0x01, 0x00, 0x02, 0x00, 0x51, 0x0d, 0x0c, 0x01, 0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07,
+	0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16, 0x2b, 0x25, 0x22, 0x00, 0x35, 0x34, 0x00, 0x33,
+	0x32, 0x00, 0x15, 0x14, 0x00, 0x27, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14,
+	0x16, 0x02, 0x63, 0xb2, 0xfe, 0xfd, 0x01, 0x04, 0xb8, 0xb9, 0x01, 0x03, 0xfe, 0xf9, 0xba, 0x92,
+	0xcd, 0xca, 0x90, 0x8f, 0xca, 0xc9, 0xde, 0x01, 0x07, 0xb5, 0xb8, 0x01, 0x04, 0xfe, 0xfb, 0xba,
+	0xb8, 0xfe, 0xff, 0x63, 0xc8, 0x8e, 0x92, 0xcb, 0xcb, 0x8f, 0x8d, 0xcc, 0x00, 0x01, 0x00, 0xae,
+	0x00, 0xde, 0x04, 0x26, 0x04, 0x56, 0x00, 0x0b, 0x00, 0x18, 0x40, 0x15, 0x00, 0x01, 0x00, 0x01,
+	0x85, 0x02, 0x01, 0x00, 0x00, 0x76, 0x01, 0x00, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x03, 0x06,
+	0x16, 0x2b, 0x25, 0x22, 0x00, 0x35, 0x34, 0x00, 0x33, 0x32, 0x00, 0x15, 0x14, 0x00, 0x02, 0x63,
+	0xb2, 0xfe, 0xfd, 0x01, 0x04, 0xb8, 0xb9, 0x01, 0x03, 0xfe, 0xf9, 0xde, 0x01, 0x07, 0xb5, 0xb8,
+	0x01, 0x04, 0xfe, 0xfb, 0xba, 0xb8, 0xfe, 0xff, 0x00, 0x02, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd,
+	0x07, 0x8f, 0x00, 0x03, 0x00, 0x0f, 0x00, 0x24, 0x40, 0x21, 0x00, 0x01, 0x03, 0x01, 0x85, 0x00,
+	0x03, 0x02, 0x03, 0x85, 0x04, 0x01, 0x02, 0x00, 0x02, 0x85, 0x00, 0x00, 0x00, 0x76, 0x05, 0x04,
+	0x0b, 0x09, 0x04, 0x0f, 0x05, 0x0f, 0x11, 0x10, 0x05, 0x06, 0x18, 0x2b, 0x01, 0x21, 0x11, 0x21,
+	0x01, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x04, 0xcd, 0xfb, 0x33,
+	0x04, 0xcd, 0xfd, 0x93, 0xbc, 0x01, 0x07, 0xfe, 0xfd, 0xb9, 0xb8, 0xfe, 0xfc, 0x01, 0x02, 0xfe,
+	0x50, 0x09, 0x3f, 0xf9, 0xa5, 0x01, 0x01, 0xb8, 0xba, 0x01, 0x05, 0xfe, 0xfc, 0xb8, 0xb5, 0xfe,
+	0xf9, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0xfe, 0x50, 0x04, 0xcd, 0x07, 0x8f, 0x00, 0x03,
+	0x00, 0x0f, 0x00, 0x1b, 0x00, 0x37, 0x40, 0x34, 0x00, 0x00, 0x03, 0x00, 0x85, 0x00, 0x03, 0x05,
+	0x03, 0x85, 0x00, 0x05, 0x04, 0x05, 0x85, 0x07, 0x01, 0x04, 0x02, 0x04, 0x85, 0x06, 0x01, 0x02,
+	0x01, 0x02, 0x85, 0x00, 0x01, 0x01, 0x76, 0x11, 0x10, 0x05, 0x04, 0x17, 0x15, 0x10, 0x1b, 0x11,
+	0x1b, 0x0b, 0x09, 0x04, 0x0f, 0x05, 0x0f, 0x11, 0x10, 0x08, 0x06, 0x18, 0x2b, 0x11, 0x21, 0x11,
+	0x21, 0x01, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x37, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x04, 0xcd, 0xfb, 0x33, 0x02, 0x60, 0xec,
+	0x01, 0x46, 0xfe, 0xba, 0xe5, 0xe6, 0xfe, 0xbb, 0x01, 0x43, 0xe2, 0xae, 0xfc, 0xfd, 0xb3, 0xb2,
+	0xfe, 0xfe, 0x07, 0x8f, 0xf6, 0xc1, 0x02, 0x75, 0x01, 0x42, 0xea, 0xe5, 0x01, 0x45, 0xfe, 0xbb,
+	0xe6, 0xe4, 0xfe, 0xb9, 0x7b, 0xff, 0xb1, 0xb3, 0xfd, 0xfd, 0xb2, 0xb6, 0xfb, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x42, 0x01, 0x71, 0x02, 0x94, 0x03, 0xc3, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x31,
+	0x40, 0x2e, 0x00, 0x03, 0x00, 0x01, 0x00, 0x03, 0x01, 0x69, 0x04, 0x01, 0x00, 0x02, 0x02, 0x00,
+	0x59, 0x04, 0x01, 0x00, 0x00, 0x02, 0x61, 0x05, 0x01, 0x02, 0x00, 0x02, 0x51, 0x0d, 0x0c, 0x01,
+	0x00, 0x13, 0x11, 0x0c, 0x17, 0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x06, 0x06, 0x16,
+	0x2b, 0x01, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x17, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x01, 0x69, 0x52, 0x75, 0x73, 0x52, 0x52,
+	0x72, 0x72, 0x4d, 0x77, 0xad, 0xae, 0x7b, 0x7c, 0xad, 0xb0, 0x01, 0xd6, 0x72, 0x50, 0x54, 0x73,
+	0x73, 0x52, 0x50, 0x74, 0x65, 0xb0, 0x79, 0x7b, 0xae, 0xae, 0x7d, 0x7b, 0xac, 0x00, 0x00, 0x00,
+	0x00, 0x05, 0x01, 0x0c, 0xff, 0xdb, 0x07, 0x1e, 0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23,
+	0x00, 0x2f, 0x00, 0x3b, 0x00, 0x66, 0x40, 0x63, 0x06, 0x01, 0x04, 0x08, 0x05, 0x08, 0x04, 0x05,
+	0x80, 0x00, 0x01, 0x00, 0x03, 0x09, 0x01, 0x03, 0x69, 0x0b, 0x01, 0x09, 0x0f, 0x0a, 0x0e, 0x03,
+	0x08, 0x04, 0x09, 0x08, 0x69, 0x00, 0x05, 0x00, 0x07, 0x02, 0x05, 0x07, 0x69, 0x0d, 0x01, 0x02,
+	0x00, 0x00, 0x02, 0x59, 0x0d, 0x01, 0x02, 0x02, 0x00, 0x61, 0x0c, 0x01, 0x00, 0x02, 0x00, 0x51,
+	0x31, 0x30, 0x25, 0x24, 0x0d, 0x0c, 0x01, 0x00, 0x37, 0x35, 0x30, 0x3b, 0x31, 0x3b, 0x2b, 0x29,
+	0x24, 0x2f, 0x25, 0x2f, 0x22, 0x20, 0x1e, 0x1d, 0x1c, 0x1a, 0x19, 0x18, 0x13, 0x11, 0x0c, 0x17,
+	0x0d, 0x17, 0x07, 0x05, 0x00, 0x0b, 0x01, 0x0b, 0x10, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11,
+	0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x25, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20,
+	0x00, 0x11, 0x10, 0x00, 0x03, 0x33, 0x12, 0x21, 0x20, 0x13, 0x33, 0x06, 0x04, 0x23, 0x22, 0x24,
+	0x13, 0x22, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x21, 0x22, 0x26, 0x35,
+	0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x04, 0x0c, 0xfe, 0xc5, 0xfe, 0x3b, 0x01, 0xc7,
+	0x01, 0x42, 0x01, 0x42, 0x01, 0xc7, 0xfe, 0x37, 0xfe, 0xb8, 0x01, 0x0b, 0x01, 0x72, 0xfe, 0x90,
+	0xfe, 0xfb, 0xfe, 0xfb, 0xfe, 0x90, 0x01, 0x6e, 0xda, 0x6f, 0x49, 0x01, 0x29, 0x01, 0x29, 0x49,
+	0x6f, 0x1f, 0xfe, 0xfc, 0xbe, 0xbe, 0xfe, 0xfc, 0xca, 0x32, 0x48, 0x48, 0x33, 0x33, 0x49, 0x49,
+	0x01, 0xb9, 0x32, 0x48, 0x49, 0x33, 0x33, 0x48, 0x48, 0x25, 0x01, 0xca, 0x01, 0x3f, 0x01, 0x42,
+	0x01, 0xc7, 0xfe, 0x3a, 0xfe, 0xbf, 0xfe, 0xb9, 0xfe, 0x3c, 0x94, 0x01, 0x6e, 0x01, 0x08, 0x01,
+	0x04, 0x01, 0x70, 0xfe, 0x90, 0xfe, 0xfb, 0xfe, 0xfe, 0xfe, 0x8d, 0x02, 0x4a, 0xfe, 0xd2, 0x01,
+	0x2e, 0xd4, 0xfb, 0xfb, 0x01, 0x7b, 0x48, 0x33, 0x33, 0x48, 0x48, 0x33, 0x34, 0x47, 0x48, 0x33,
+	0x33, 0x48, 0x48, 0x33, 0x34, 0x47, 0x00, 0x00, 0x00, 0x04, 0x01, 0x2d, 0xff, 0xdb, 0x07, 0x3f,
+	0x05, 0xed, 0x00, 0x0b, 0x00, 0x17, 0x00, 0x23, 0x00, 0x2f, 0x00, 0x59, 0x40, 0x56, 0x0b, 0x05,
+	0x02, 0x03, 0x06, 0x04, 0x06, 0x03, 0x04, 0x80, 0x00, 0x01, 0x09, 0x01, 0x07, 0x06, 0x01, 0x07,
+	0x69, 0x0d, 0x08, 0x0c, 0x03, 0x06, 0x00, 0x04, 0x02, 0x06, 0x04, 0x69, 0x00, 0x02, 0x00, 0x00,
+	0x02, 0x59, 0x00, 0x02, 0x02, 0x00, 0x61, 0x0a, 0x01, 0x00, 0x02, 0x00, 0x51, 0x25, 0x24, 0x19,
+	0x18, 0x0c, 0x0c, 0x01, 0x00, 0x2b, 0x29, 0x24, 0x2f, 0x25, 0x2f, 0x1f, 0x1d, 0x18, 0x23, 0x19,
+	0x23, 0x0c, 0x17, 0x0c, 0x17, 0x16, 0x14, 0x13, 0x12, 0x10, 0x0e, 0x07, 0x05, 0x00, 0x0b, 0x01,
+	0x0b, 0x0e, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10,
+	0x00, 0x01, 0x16, 0x04, 0x33, 0x32, 0x24, 0x37, 0x23, 0x02, 0x21, 0x20, 0x03, 0x37, 0x32, 0x36,
+	0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x21, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x14, 0x16, 0x04, 0x2d, 0xfe, 0xc5, 0xfe, 0x3b, 0x01, 0xc7, 0x01, 0x42, 0x01,
+	0x42, 0x01, 0xc7, 0xfe, 0x37, 0xfc, 0xdf, 0x1f, 0x01, 0x04, 0xbe, 0xbe, 0x01, 0x04, 0x1f, 0x6f,
+	0x49, 0xfe, 0xd7, 0xfe, 0xd7, 0x49, 0x7a, 0x34, 0x49, 0x49, 0x33, 0x33, 0x48, 0x48, 0x02, 0x1f,
+	0x35, 0x48, 0x48, 0x33, 0x33, 0x49, 0x48, 0x25, 0x01, 0xca, 0x01, 0x3f, 0x01, 0x42, 0x01, 0xc7,
+	0xfe, 0x3a, 0xfe, 0xbf, 0xfe, 0xb9, 0xfe, 0x3c, 0x02, 0xde, 0xd4, 0xfb, 0xfb, 0xd4, 0xfe, 0xd2,
+	0x01, 0x2e, 0xa7, 0x47, 0x34, 0x33, 0x48, 0x48, 0x33, 0x33, 0x48, 0x47, 0x34, 0x33, 0x48, 0x48,
+	0x33, 0x33, 0x48, 0x00, 0x00, 0x02, 0x00, 0xad, 0xff, 0xe7, 0x06, 0xa7, 0x05, 0xe1, 0x00, 0x27,
+	0x00, 0x33, 0x00, 0x60, 0x40, 0x5d, 0x19, 0x18, 0x17, 0x15, 0x12, 0x10, 0x0f, 0x0e, 0x08, 0x07,
+	0x02, 0x1a, 0x0d, 0x02, 0x01, 0x07, 0x21, 0x06, 0x02, 0x06, 0x00, 0x26, 0x24, 0x23, 0x22, 0x05,
+	0x04, 0x03, 0x01, 0x08, 0x05, 0x06, 0x04, 0x4c, 0x00, 0x02, 0x00, 0x07, 0x01, 0x02, 0x07, 0x69,
+	0x03, 0x01, 0x01, 0x04, 0x01, 0x00, 0x06, 0x01, 0x00, 0x67, 0x09, 0x01, 0x06, 0x05, 0x05, 0x06,
+	0x59, 0x09, 0x01, 0x06, 0x06, 0x05, 0x5f, 0x08, 0x01, 0x05, 0x06, 0x05, 0x4f, 0x29, 0x28, 0x00,
+	0x00, 0x2f, 0x2d, 0x28, 0x33, 0x29, 0x33, 0x00, 0x27, 0x00, 0x27, 0x11, 0x18, 0x18, 0x11, 0x18,
+	0x0a, 0x06, 0x1b, 0x2b, 0x05, 0x35, 0x26, 0x27, 0x07, 0x27, 0x37, 0x26, 0x27, 0x23, 0x35, 0x33,
+	0x36, 0x37, 0x27, 0x37, 0x17, 0x36, 0x37, 0x35, 0x33, 0x15, 0x16, 0x17, 0x37, 0x17, 0x07, 0x16,
+	0x17, 0x33, 0x15, 0x23, 0x06, 0x07, 0x17, 0x07, 0x27, 0x06, 0x07, 0x15, 0x03, 0x32, 0x36, 0x35,
+	0x34, 0x26, 0x23, 0x22, 0x06, 0x15, 0x14, 0x16, 0x03, 0x60, 0x7b, 0x71, 0xb1, 0x69, 0xb1, 0x4a,
+	0x18, 0xfc, 0xfc, 0x18, 0x4a, 0xb1, 0x69, 0xb1, 0x71, 0x7b, 0x94, 0x7b, 0x71, 0xb1, 0x68, 0xb0,
+	0x4a, 0x18, 0xfc, 0xfc, 0x18, 0x4a, 0xb0, 0x68, 0xb1, 0x71, 0x7b, 0x4f, 0x9e, 0xd9, 0xd9, 0x99,
+	0x9a, 0xd8, 0xd7, 0x19, 0xfc, 0x15, 0x4d, 0xb1, 0x69, 0xb0, 0x69, 0x84, 0x94, 0x84, 0x69, 0xb0,
+	0x69, 0xb1, 0x4d, 0x15, 0xfc, 0xfc, 0x15, 0x4d, 0xb1, 0x69, 0xb0, 0x69, 0x84, 0x94, 0x84, 0x69,
+	0xb0, 0x69, 0xb1, 0x4d, 0x15, 0xfc, 0x01, 0x8b, 0xd7, 0x9c, 0x99, 0xd8, 0xd8, 0x9a, 0x98, 0xda,
+	0x00, 0x02, 0x00, 0x66, 0xfe, 0x75, 0x05, 0x9a, 0x06, 0x44, 0x00, 0x16, 0x00, 0x22, 0x00, 0x4a,
+	0x40, 0x47, 0x11, 0x05, 0x02, 0x01, 0x06, 0x01, 0x4c, 0x09, 0x01, 0x06, 0x07, 0x01, 0x07, 0x06,
+	0x01, 0x80, 0x08, 0x01, 0x05, 0x00, 0x05, 0x86, 0x00, 0x02, 0x00, 0x07, 0x06, 0x02, 0x07, 0x69,
+	0x03, 0x01, 0x01, 0x00, 0x00, 0x01, 0x57, 0x03, 0x01, 0x01, 0x01, 0x00, 0x5f, 0x04, 0x01, 0x00,
+	0x01, 0x00, 0x4f, 0x18, 0x17, 0x00, 0x00, 0x1e, 0x1c, 0x17, 0x22, 0x18, 0x22, 0x00, 0x16, 0x00,
+	0x16, 0x11, 0x16, 0x26, 0x11, 0x11, 0x0a, 0x06, 0x1b, 0x2b, 0x01, 0x35, 0x21, 0x35, 0x21, 0x11,
+	0x24, 0x00, 0x11, 0x10, 0x00, 0x21, 0x20, 0x00, 0x11, 0x10, 0x00, 0x05, 0x11, 0x21, 0x15, 0x21,
+	0x15, 0x03, 0x32, 0x00, 0x35, 0x34, 0x00, 0x23, 0x22, 0x00, 0x15, 0x14, 0x00, 0x02, 0xb6, 0xfe,
+	0x3e, 0x01, 0xc2, 0xfe, 0xfa, 0xfe, 0xb6, 0x01, 0x86, 0x01, 0x14, 0x01, 0x14, 0x01, 0x86, 0xfe,
+	0xb6, 0xfe, 0xfa, 0x01, 0xc2, 0xfe, 0x3e, 0x50, 0xdc, 0x01, 0x30, 0xfe, 0xd1, 0xd7, 0xd7, 0xfe,
+	0xd1, 0x01, 0x2e, 0xfe, 0x75, 0xf7, 0x94, 0x01, 0x14, 0x25, 0x01, 0x71, 0x01, 0x00, 0x01, 0x14,
+	0x01, 0x86, 0xfe, 0x7a, 0xfe, 0xec, 0xff, 0x00, 0xfe, 0x8f, 0x25, 0xfe, 0xec, 0x94, 0xf7, 0x03,
+	0x2f, 0x01, 0x2d, 0xda, 0xd6, 0x01, 0x2f, 0xfe, 0xd1, 0xd7, 0xd4, 0xfe, 0xce, 0x00, 0x00, 0x00,
+	0x00, 0x02, 0x00, 0x2b, 0xff, 0xb5, 0x06, 0x57, 0x07, 0x2e, 0x00, 0x14, 0x00, 0x20, 0x00, 0x08,
+	0xb5, 0x1d, 0x17, 0x0e, 0x04, 0x02, 0x32, 0x2b, 0x01, 0x13, 0x05, 0x27, 0x25, 0x13, 0x07, 0x03,
+	0x03, 0x16, 0x17, 0x12, 0x00, 0x05, 0x04, 0x00, 0x03, 0x02, 0x00, 0x25, 0x36, 0x01, 0x16, 0x04,
+	0x37, 0x36, 0x12, 0x27, 0x26, 0x24, 0x07, 0x06, 0x02, 0x04, 0x0c, 0xdb, 0xfe, 0x95, 0x26, 0x02,
+	0x5e, 0xa3, 0x8f, 0x61, 0xdb, 0xb6, 0x36, 0x48, 0xfe, 0xeb, 0xfe, 0xf5, 0xfe, 0xf6, 0xfe, 0x24,
+	0x48, 0x47, 0x01, 0x15, 0x01, 0x0c, 0xdb, 0xfd, 0xda, 0x39, 0x01, 0x71, 0xd3, 0xcf, 0xd5, 0x37,
+	0x38, 0xfe, 0x8d, 0xd0, 0xcd, 0xd9, 0x04, 0xe2, 0x01, 0x7c, 0x61, 0x8f, 0xa2, 0xfd, 0xa1, 0x26,
+	0x01, 0x6a, 0xfe, 0x85, 0x99, 0xcd, 0xfe, 0xf5, 0xfe, 0x1d, 0x47, 0x48, 0x01, 0x17, 0x01, 0x0c,
+	0x01, 0x0b, 0x01, 0xd9, 0x48, 0x3b, 0xfc, 0xc1, 0xd4, 0xd8, 0x39, 0x37, 0x01, 0x74, 0xcf, 0xcf,
+	0xd7, 0x38, 0x37, 0xfe, 0x8e, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x32, 0x00, 0x00, 0x04, 0x0d,
+	0x05, 0x36, 0x00, 0x18, 0x00, 0x20, 0x40, 0x1d, 0x17, 0x0c, 0x01, 0x03, 0x00, 0x4a, 0x01, 0x01,
+	0x00, 0x02, 0x00, 0x85, 0x03, 0x01, 0x02, 0x02, 0x76, 0x00, 0x00, 0x00, 0x18, 0x00, 0x18, 0x16,
+	0x14, 0x22, 0x04, 0x06, 0x17, 0x2b, 0x21, 0x13, 0x06, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x37,
+	0x37, 0x36, 0x37, 0x16, 0x17, 0x17, 0x16, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x27, 0x13, 0x01,
+	0xa4, 0x5b, 0x68, 0x90, 0x5d, 0x78, 0x48, 0x6c, 0x71, 0x73, 0x55, 0x55, 0x74, 0x71, 0x6c, 0x48,
+	0x78, 0x5e, 0x8f, 0x68, 0x5b, 0x01, 0x64, 0x4a, 0x89, 0x83, 0x6e, 0x95, 0x73, 0x79, 0x7b, 0xa6,
+	0xa6, 0x7b, 0x79, 0x73, 0x95, 0x6f, 0x82, 0x89, 0x4a, 0xfe, 0x9c, 0x00, 0x00, 0x01, 0x00, 0x32,
+	0x00, 0x00, 0x05, 0x0d, 0x04, 0xfb, 0x00, 0x20, 0x00, 0x30, 0x40, 0x2d, 0x1f, 0x15, 0x0b, 0x01,
+	0x04, 0x00, 0x01, 0x01, 0x4c, 0x00, 0x02, 0x01, 0x02, 0x85, 0x03, 0x01, 0x01, 0x00, 0x01, 0x85,
+	0x04, 0x01, 0x00, 0x05, 0x00, 0x85, 0x06, 0x01, 0x05, 0x05, 0x76, 0x00, 0x00, 0x00, 0x20, 0x00,
+	0x20, 0x24, 0x25, 0x25, 0x24, 0x22, 0x07, 0x06, 0x1b, 0x2b, 0x21, 0x13, 0x02, 0x23, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x07,
+	0x36, 0x33, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x03, 0x13, 0x02, 0x19, 0x59, 0x71, 0xc6,
+	0x71, 0x98, 0xa2, 0x85, 0x32, 0x3a, 0x34, 0x9c, 0x73, 0x72, 0x9b, 0x33, 0x39, 0x32, 0x86, 0xa2,
+	0x98, 0x70, 0xc7, 0x72, 0x5a, 0x02, 0x02, 0xfe, 0xef, 0xa0, 0x75, 0x83, 0x9e, 0x11, 0x66, 0x59,
+	0x7d, 0xa9, 0xa9, 0x7d, 0x59, 0x66, 0x11, 0x9e, 0x83, 0x75, 0xa0, 0x01, 0x11, 0xfd, 0xfe, 0x00,
+	0x00, 0x01, 0x00, 0x4a, 0xff, 0xe2, 0x04, 0x75, 0x04, 0xbe, 0x00, 0x19, 0x00, 0x11, 0x40, 0x0e,
+	0x0d, 0x01, 0x00, 0x49, 0x01, 0x01, 0x00, 0x00, 0x76, 0x22, 0x2a, 0x02, 0x06, 0x18, 0x2b, 0x05,
+	0x26, 0x2f, 0x04, 0x26, 0x35, 0x34, 0x36, 0x33, 0x32, 0x13, 0x12, 0x33, 0x32, 0x16, 0x15, 0x14,
+	0x0f, 0x04, 0x06, 0x02, 0x5f, 0x34, 0x13, 0x5a, 0x42, 0x37, 0x43, 0xb8, 0x95, 0x73, 0xd7, 0x36,
+	0x36, 0xd8, 0x73, 0x95, 0xb8, 0x42, 0x38, 0x42, 0x5a, 0x13, 0x1e, 0x57, 0x19, 0x7f, 0x5f, 0x47,
+	0x54, 0xe9, 0xbe, 0x91, 0xbb, 0xfe, 0xb4, 0x01, 0x4c, 0xbb, 0x91, 0xbe, 0xe9, 0x54, 0x47, 0x5f,
+	0x7f, 0x19, 0x00, 0x00, 0x00, 0x01, 0x00, 0x28, 0xff, 0xde, 0x03, 0xed, 0x05, 0x3b, 0x00, 0x07,
+	0x00, 0x06, 0xb3, 0x04, 0x00, 0x01, 0x32, 0x2b, 0x05, 0x02, 0x01, 0x00, 0x13, 0x12, 0x01, 0x00,
+	0x02, 0x0b, 0xc3, 0xfe, 0xe0, 0x01, 0x20, 0xc3, 0xc5, 0x01, 0x1d, 0xfe, 0xe3, 0x22, 0x01, 0x99,
+	0x01, 0x16, 0x01, 0x14, 0x01, 0x9a, 0xfe, 0x67, 0xfe, 0xeb, 0xfe, 0xea, 0x00, 0x01, 0x00, 0x31,
+	0xff, 0xdb, 0x03, 0xcf, 0x05, 0xc8, 0x00, 0x1e, 0x00, 0x2c, 0x40, 0x29, 0x14, 0x0b, 0x0a, 0x03,
+	0x02, 0x00, 0x00, 0x01, 0x01, 0x02, 0x02, 0x4c, 0x00, 0x00, 0x02, 0x00, 0x85, 0x00, 0x02, 0x01,
+	0x01, 0x02, 0x59, 0x00, 0x02, 0x02, 0x01, 0x61, 0x00, 0x01, 0x02, 0x01, 0x51, 0x1e, 0x1c, 0x18,
+	0x16, 0x11, 0x03, 0x06, 0x17, 0x2b, 0x01, 0x11, 0x33, 0x15, 0x14, 0x17, 0x17, 0x16, 0x15, 0x14,
+	0x07, 0x27, 0x36, 0x35, 0x34, 0x27, 0x27, 0x26, 0x27, 0x26, 0x27, 0x11, 0x10, 0x21, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x33, 0x32, 0x01, 0xca, 0x63, 0x83, 0x46, 0xd9, 0x6b, 0x45, 0x3e, 0x58, 0x4a,
+	0x16, 0x34, 0x1d, 0x27, 0xfe, 0xab, 0x49, 0x5e, 0xae, 0x75, 0x3c, 0x01, 0x2d, 0x04, 0x9b, 0x1a,
+	0x83, 0x64, 0x35, 0xa5, 0x8c, 0x68, 0x87, 0x34, 0x54, 0x3d, 0x3d, 0x4e, 0x43, 0x13, 0x25, 0x13,
+	0x2d, 0xfd, 0x2d, 0xfe, 0x31, 0x4c, 0x3c, 0x5a, 0x87, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x64,
+	0xfe, 0xeb, 0x05, 0x29, 0x05, 0xed, 0x00, 0x1a, 0x00, 0x33, 0x40, 0x30, 0x19, 0x01, 0x01, 0x03,
+	0x0b, 0x01, 0x02, 0x01, 0x02, 0x4c, 0x1a, 0x0d, 0x0c, 0x00, 0x04, 0x03, 0x4a, 0x00, 0x01, 0x02,
+	0x00, 0x01, 0x59, 0x00, 0x03, 0x00, 0x02, 0x00, 0x03, 0x02, 0x69, 0x00, 0x01, 0x01, 0x00, 0x61,
+	0x00, 0x00, 0x01, 0x00, 0x51, 0x23, 0x27, 0x23, 0x23, 0x04, 0x06, 0x1a, 0x2b, 0x01, 0x11, 0x14,
+	0x06, 0x23, 0x22, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x11, 0x01, 0x11, 0x14, 0x07, 0x06, 0x23,
+	0x22, 0x35, 0x34, 0x36, 0x33, 0x32, 0x17, 0x11, 0x02, 0x5c, 0xa9, 0xa3, 0xac, 0xac, 0x76, 0x40,
+	0x33, 0x03, 0x30, 0x5e, 0x62, 0x8b, 0xaa, 0xac, 0x7b, 0x33, 0x38, 0x03, 0xf7, 0xfc, 0xc6, 0xe5,
+	0xed, 0x8c, 0x5c, 0x85, 0x18, 0x04, 0x67, 0x01, 0x46, 0xfc, 0x0f, 0xff, 0x63, 0x69, 0x87, 0x5b,
+	0x82, 0x16, 0x03, 0x6f, 0x00, 0x0d, 0x00, 0xfd, 0xff, 0x33, 0x07, 0x03, 0x06, 0x44, 0x00, 0x1a,
+	0x00, 0x26, 0x00, 0x32, 0x00, 0x4b, 0x00, 0x64, 0x00, 0x72, 0x00, 0x7e, 0x00, 0x8a, 0x00, 0xa4,
+	0x00, 0xfe, 0x01, 0x20, 0x01, 0x2e, 0x01, 0x3c, 0x08, 0xa4, 0x41, 0x22, 0x00, 0xfc, 0x00, 0xa8,
+	0x00, 0x02, 0x00, 0x01, 0x00, 0x10, 0x00, 0xef, 0x00, 0xb5, 0x00, 0x02, 0x00, 0x02, 0x00, 0x01,
+	0x00, 0x6e, 0x00, 0x01, 0x00, 0x08, 0x00, 0x09, 0x01, 0x05, 0x00, 0x01, 0x00, 0x04, 0x00, 0x08,
+	0x01, 0x2f, 0x01, 0x24, 0x00, 0x02, 0x00, 0x1a, 0x00, 0x16, 0x00, 0x56, 0x00, 0x01, 0x00, 0x0c,
+	0x00, 0x0e, 0x00, 0xe5, 0x00, 0xbf, 0x00, 0x02, 0x00, 0x12, 0x00, 0x07, 0x00, 0x07, 0x00, 0x4c,
+	0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10,
+	0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08,
+	0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17,
+	0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a,
+	0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e,
+	0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12,
+	0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14,
+	0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69,
+	0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16,
+	0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b,
+	0xb0, 0x0c, 0x50, 0x58, 0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01,
+	0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80,
+	0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00,
+	0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b,
+	0x0d, 0x80, 0x00, 0x0d, 0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e,
+	0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00,
+	0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11,
+	0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01,
+	0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25,
+	0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a,
+	0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x0d, 0x50, 0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f,
+	0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03,
+	0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01,
+	0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01,
+	0x1b, 0x1a, 0x06, 0x1a, 0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00,
+	0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07,
+	0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a,
+	0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03,
+	0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02,
+	0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16,
+	0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x0f, 0x50, 0x58, 0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85,
+	0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08,
+	0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00,
+	0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b,
+	0x1a, 0x0d, 0x1a, 0x1b, 0x0d, 0x80, 0x00, 0x0d, 0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e,
+	0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a,
+	0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13,
+	0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f,
+	0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08,
+	0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a,
+	0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x10, 0x50, 0x58, 0x40, 0x97, 0x24,
+	0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01,
+	0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04,
+	0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19,
+	0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a, 0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a,
+	0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c,
+	0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e,
+	0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03,
+	0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17,
+	0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61,
+	0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x12, 0x50, 0x58, 0x40, 0x9d, 0x24, 0x01,
+	0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85,
+	0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17,
+	0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16,
+	0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b, 0x0d, 0x80, 0x00, 0x0d, 0x06, 0x1a, 0x0d, 0x06,
+	0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23,
+	0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12,
+	0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11,
+	0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08,
+	0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25,
+	0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x13, 0x50,
+	0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05,
+	0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04,
+	0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00,
+	0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a, 0x1b, 0x06, 0x80, 0x0d,
+	0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01,
+	0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13,
+	0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84,
+	0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00,
+	0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02,
+	0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x15, 0x50, 0x58,
+	0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01,
+	0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08,
+	0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19,
+	0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b, 0x0d, 0x80, 0x00, 0x0d,
+	0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a,
+	0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07,
+	0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e,
+	0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03,
+	0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a,
+	0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b,
+	0x4b, 0xb0, 0x16, 0x50, 0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10,
+	0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08,
+	0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17,
+	0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x06, 0x1a,
+	0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00, 0x0e, 0x0c, 0x1a, 0x0e,
+	0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07, 0x12, 0x1a, 0x07, 0x12,
+	0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a, 0x13, 0x11, 0x7e, 0x14,
+	0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03, 0x09, 0x02, 0x03, 0x69,
+	0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02, 0x16, 0x1a, 0x1a, 0x16,
+	0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16, 0x1a, 0x51, 0x1b, 0x4b,
+	0xb0, 0x18, 0x50, 0x58, 0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01,
+	0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80,
+	0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00,
+	0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b,
+	0x0d, 0x80, 0x00, 0x0d, 0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e,
+	0x00, 0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00,
+	0x07, 0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11,
+	0x1a, 0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01,
+	0x03, 0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25,
+	0x02, 0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a,
+	0x16, 0x1a, 0x51, 0x1b, 0x4b, 0xb0, 0x19, 0x50, 0x58, 0x40, 0x97, 0x24, 0x01, 0x0f, 0x10, 0x0f,
+	0x85, 0x15, 0x01, 0x10, 0x01, 0x10, 0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03,
+	0x08, 0x03, 0x09, 0x08, 0x80, 0x20, 0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01,
+	0x00, 0x17, 0x19, 0x17, 0x00, 0x19, 0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01,
+	0x1b, 0x1a, 0x06, 0x1a, 0x1b, 0x06, 0x80, 0x0d, 0x01, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00,
+	0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07,
+	0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a,
+	0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03,
+	0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02,
+	0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16,
+	0x1a, 0x51, 0x1b, 0x40, 0x9d, 0x24, 0x01, 0x0f, 0x10, 0x0f, 0x85, 0x15, 0x01, 0x10, 0x01, 0x10,
+	0x85, 0x05, 0x01, 0x01, 0x02, 0x01, 0x85, 0x00, 0x09, 0x03, 0x08, 0x03, 0x09, 0x08, 0x80, 0x20,
+	0x01, 0x04, 0x08, 0x17, 0x08, 0x04, 0x17, 0x80, 0x1e, 0x01, 0x00, 0x17, 0x19, 0x17, 0x00, 0x19,
+	0x80, 0x00, 0x19, 0x16, 0x17, 0x19, 0x16, 0x7e, 0x1d, 0x01, 0x1b, 0x1a, 0x0d, 0x1a, 0x1b, 0x0d,
+	0x80, 0x00, 0x0d, 0x06, 0x1a, 0x0d, 0x06, 0x7e, 0x00, 0x06, 0x0e, 0x1a, 0x06, 0x0e, 0x7e, 0x00,
+	0x0e, 0x0c, 0x1a, 0x0e, 0x0c, 0x7e, 0x23, 0x01, 0x0c, 0x07, 0x1a, 0x0c, 0x07, 0x7e, 0x00, 0x07,
+	0x12, 0x1a, 0x07, 0x12, 0x7e, 0x00, 0x12, 0x13, 0x1a, 0x12, 0x13, 0x7e, 0x00, 0x13, 0x11, 0x1a,
+	0x13, 0x11, 0x7e, 0x14, 0x01, 0x11, 0x11, 0x84, 0x22, 0x0a, 0x1f, 0x03, 0x02, 0x0b, 0x01, 0x03,
+	0x09, 0x02, 0x03, 0x69, 0x21, 0x01, 0x08, 0x00, 0x17, 0x00, 0x08, 0x17, 0x69, 0x18, 0x25, 0x02,
+	0x16, 0x1a, 0x1a, 0x16, 0x59, 0x18, 0x25, 0x02, 0x16, 0x16, 0x1a, 0x61, 0x1c, 0x01, 0x1a, 0x16,
+	0x1a, 0x51, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x59, 0x41, 0x5f, 0x01,
+	0x00, 0x00, 0xff, 0x00, 0xa6, 0x00, 0xa5, 0x00, 0x8c, 0x00, 0x8b, 0x00, 0x74, 0x00, 0x73, 0x00,
+	0x66, 0x00, 0x65, 0x00, 0x34, 0x00, 0x33, 0x00, 0x1c, 0x00, 0x1b, 0x00, 0x01, 0x00, 0x00, 0x01,
+	0x38, 0x01, 0x36, 0x01, 0x32, 0x01, 0x31, 0x01, 0x2a, 0x01, 0x28, 0x01, 0x23, 0x01, 0x21, 0x01,
+	0x1d, 0x01, 0x1b, 0x01, 0x18, 0x01, 0x16, 0x01, 0x0b, 0x01, 0x09, 0x00, 0xff, 0x01, 0x20, 0x01,
+	0x00, 0x01, 0x20, 0x00, 0xf8, 0x00, 0xf6, 0x00, 0xe0, 0x00, 0xde, 0x00, 0xd9, 0x00, 0xd6, 0x00,
+	0xd3, 0x00, 0xce, 0x00, 0xc8, 0x00, 0xc6, 0x00, 0xae, 0x00, 0xac, 0x00, 0xa5, 0x00, 0xfe, 0x00,
+	0xa6, 0x00, 0xfe, 0x00, 0xa1, 0x00, 0x9f, 0x00, 0x99, 0x00, 0x97, 0x00, 0x8b, 0x00, 0xa4, 0x00,
+	0x8c, 0x00, 0xa4, 0x00, 0x7a, 0x00, 0x78, 0x00, 0x73, 0x00, 0x7e, 0x00, 0x74, 0x00, 0x7e, 0x00,
+	0x6c, 0x00, 0x6a, 0x00, 0x65, 0x00, 0x72, 0x00, 0x66, 0x00, 0x72, 0x00, 0x5c, 0x00, 0x5a, 0x00,
+	0x52, 0x00, 0x50, 0x00, 0x40, 0x00, 0x3e, 0x00, 0x33, 0x00, 0x4b, 0x00, 0x34, 0x00, 0x4b, 0x00,
+	0x22, 0x00, 0x20, 0x00, 0x1b, 0x00, 0x26, 0x00, 0x1c, 0x00, 0x26, 0x00, 0x0d, 0x00, 0x0b, 0x00,
+	0x00, 0x00, 0x1a, 0x00, 0x01, 0x00, 0x1a, 0x00, 0x26, 0x00, 0x06, 0x00, 0x16, 0x2b, 0x01, 0x32,
+	0x36, 0x37, 0x36, 0x36, 0x35, 0x34, 0x26, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07, 0x06, 0x06,
+	0x15, 0x14, 0x1e, 0x02, 0x17, 0x16, 0x17, 0x16, 0x03, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22,
+	0x26, 0x35, 0x34, 0x36, 0x17, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06,
+	0x05, 0x32, 0x36, 0x37, 0x36, 0x36, 0x35, 0x34, 0x26, 0x27, 0x26, 0x26, 0x23, 0x22, 0x06, 0x07,
+	0x06, 0x06, 0x15, 0x14, 0x17, 0x1e, 0x03, 0x01, 0x34, 0x2e, 0x02, 0x23, 0x22, 0x0e, 0x02, 0x15,
+	0x14, 0x1e, 0x02, 0x33, 0x32, 0x3e, 0x02, 0x37, 0x3e, 0x03, 0x01, 0x32, 0x36, 0x35, 0x34, 0x26,
+	0x23, 0x22, 0x06, 0x15, 0x14, 0x1e, 0x02, 0x01, 0x32, 0x16, 0x15, 0x14, 0x06, 0x23, 0x22, 0x26,
+	0x35, 0x34, 0x36, 0x17, 0x14, 0x16, 0x33, 0x32, 0x36, 0x35, 0x34, 0x26, 0x23, 0x22, 0x06, 0x01,
+	0x32, 0x36, 0x35, 0x34, 0x2e, 0x02, 0x27, 0x2e, 0x03, 0x23, 0x22, 0x06, 0x15, 0x14, 0x1e, 0x02,
+	0x33, 0x32, 0x1e, 0x02, 0x01, 0x32, 0x16, 0x17, 0x3e, 0x03, 0x33, 0x32, 0x16, 0x15, 0x14, 0x0e,
+	0x02, 0x07, 0x1e, 0x03, 0x15, 0x14, 0x0e, 0x02, 0x07, 0x1e, 0x03, 0x15, 0x14, 0x06, 0x23, 0x22,
+	0x26, 0x27, 0x2e, 0x03, 0x27, 0x06, 0x06, 0x23, 0x22, 0x0e, 0x02, 0x23, 0x22, 0x26, 0x27, 0x0e,
+	0x03, 0x23, 0x22, 0x26, 0x35, 0x34, 0x36, 0x37, 0x2e, 0x03, 0x35, 0x34, 0x3e, 0x02, 0x37, 0x2e,
+	0x03, 0x35, 0x34, 0x36, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x36, 0x36, 0x01, 0x32, 0x36, 0x35, 0x34,
+	0x26, 0x27, 0x0e, 0x03, 0x23, 0x22, 0x2e, 0x02, 0x27, 0x0e, 0x03, 0x15, 0x14, 0x16, 0x33, 0x32,
+	0x3e, 0x02, 0x33, 0x32, 0x1e, 0x02, 0x17, 0x06, 0x26, 0x27, 0x14, 0x1e, 0x02, 0x33, 0x33, 0x32,
+	0x3e, 0x02, 0x27, 0x06, 0x06, 0x07, 0x14, 0x1e, 0x02, 0x33, 0x37, 0x32, 0x3e, 0x02, 0x02, 0xad,
+	0x29, 0x56, 0x22, 0x26, 0x26, 0x29, 0x2a, 0x26, 0x56, 0x21, 0x2f, 0x55, 0x22, 0x22, 0x26, 0x03,
+	0x0a, 0x13, 0x0f, 0x1d, 0x2f, 0x34, 0x22, 0x21, 0x27, 0x2a, 0x1e, 0x23, 0x29, 0x27, 0x13, 0x0c,
+	0x08, 0x08, 0x0e, 0x07, 0x0c, 0x06, 0x11, 0x03, 0x1c, 0x30, 0x56, 0x20, 0x20, 0x22, 0x2b, 0x29,
+	0x20, 0x4e, 0x2a, 0x3d, 0x4e, 0x17, 0x1d, 0x24, 0x34, 0x0b, 0x22, 0x2d, 0x38, 0x01, 0x1a, 0x03,
+	0x0c, 0x17, 0x14, 0x1c, 0x47, 0x3e, 0x2a, 0x0b, 0x11, 0x12, 0x07, 0x14, 0x0f, 0x09, 0x0a, 0x0f,
+	0x23, 0x34, 0x23, 0x11, 0xfd, 0xbe, 0x25, 0x24, 0x21, 0x28, 0x28, 0x28, 0x05, 0x10, 0x20, 0x01,
+	0xc9, 0x21, 0x26, 0x2a, 0x1d, 0x24, 0x27, 0x25, 0x15, 0x0b, 0x08, 0x08, 0x0d, 0x06, 0x0d, 0x06,
+	0x0f, 0xfc, 0xe2, 0x14, 0x1b, 0x1c, 0x30, 0x3f, 0x22, 0x04, 0x0b, 0x0f, 0x13, 0x0b, 0x17, 0x26,
+	0x23, 0x2f, 0x30, 0x0d, 0x11, 0x15, 0x13, 0x19, 0x01, 0x93, 0x9f, 0xf0, 0x52, 0x30, 0x3c, 0x2c,
+	0x28, 0x1d, 0x20, 0x1f, 0x0f, 0x27, 0x41, 0x33, 0x1a, 0x1c, 0x0e, 0x02, 0x0f, 0x28, 0x46, 0x36,
+	0x0c, 0x16, 0x12, 0x0b, 0x19, 0x22, 0x31, 0x4c, 0x0f, 0x02, 0x05, 0x07, 0x07, 0x02, 0x2f, 0x6b,
+	0x3f, 0x34, 0x42, 0x39, 0x3f, 0x32, 0x15, 0x27, 0x13, 0x0c, 0x21, 0x28, 0x2c, 0x18, 0x23, 0x28,
+	0x1a, 0x09, 0x5d, 0x6a, 0x35, 0x0d, 0x08, 0x15, 0x22, 0x1b, 0x1b, 0x36, 0x2b, 0x1c, 0x21, 0x27,
+	0x17, 0x20, 0x27, 0x36, 0x2e, 0x52, 0xfb, 0x01, 0x16, 0x17, 0x16, 0x1c, 0x1a, 0x04, 0x15, 0x1a,
+	0x1e, 0x0d, 0x0b, 0x19, 0x18, 0x13, 0x04, 0x09, 0x14, 0x11, 0x0b, 0x1c, 0x13, 0x0d, 0x16, 0x17,
+	0x16, 0x0d, 0x0c, 0x1b, 0x1b, 0x1a, 0x1c, 0x0e, 0x34, 0x23, 0x01, 0x07, 0x0e, 0x0e, 0x26, 0x0a,
+	0x0b, 0x05, 0x01, 0x7c, 0x14, 0x32, 0x1d, 0x02, 0x07, 0x0c, 0x0b, 0x2f, 0x07, 0x08, 0x04, 0x01,
+	0x03, 0x8c, 0x20, 0x1d, 0x22, 0x5b, 0x38, 0x39, 0x5f, 0x1f, 0x1d, 0x11, 0x24, 0x24, 0x24, 0x5c,
+	0x2e, 0x0c, 0x21, 0x26, 0x2a, 0x13, 0x26, 0x14, 0x17, 0x01, 0x33, 0x2a, 0x19, 0x1d, 0x27, 0x25,
+	0x1b, 0x1c, 0x2b, 0x2e, 0x0a, 0x0b, 0x0d, 0x08, 0x05, 0x0e, 0x0a, 0xf8, 0x24, 0x20, 0x20, 0x52,
+	0x2d, 0x32, 0x55, 0x20, 0x1a, 0x1d, 0x29, 0x1a, 0x1d, 0x56, 0x31, 0x49, 0x42, 0x0e, 0x1d, 0x16,
+	0x0e, 0xfe, 0xb0, 0x09, 0x11, 0x0d, 0x08, 0x23, 0x33, 0x3c, 0x18, 0x0e, 0x15, 0x0f, 0x08, 0x0f,
+	0x16, 0x19, 0x0b, 0x1a, 0x1c, 0x13, 0x13, 0x01, 0x6d, 0x1a, 0x14, 0x17, 0x19, 0x16, 0x1b, 0x07,
+	0x10, 0x0d, 0x09, 0x01, 0x10, 0x2a, 0x17, 0x1d, 0x28, 0x25, 0x1b, 0x1b, 0x2b, 0x2d, 0x0a, 0x0c,
+	0x0e, 0x08, 0x05, 0x0e, 0x0a, 0xfd, 0x0e, 0x1c, 0x19, 0x1a, 0x1b, 0x16, 0x1b, 0x1b, 0x03, 0x0e,
+	0x0f, 0x0c, 0x22, 0x20, 0x18, 0x28, 0x1d, 0x10, 0x10, 0x13, 0x10, 0x04, 0x9b, 0x42, 0x50, 0x15,
+	0x29, 0x21, 0x14, 0x1f, 0x19, 0x1a, 0x37, 0x37, 0x35, 0x19, 0x35, 0x76, 0x81, 0x8b, 0x4b, 0xa3,
+	0xf5, 0xb1, 0x75, 0x24, 0x15, 0x2f, 0x30, 0x30, 0x16, 0x20, 0x1f, 0x35, 0x35, 0x04, 0x14, 0x1a,
+	0x1a, 0x0a, 0x08, 0x07, 0x05, 0x06, 0x05, 0x02, 0x02, 0x1c, 0x3c, 0x33, 0x21, 0x26, 0x26, 0x26,
+	0x48, 0x26, 0x2f, 0x9b, 0xbb, 0xcb, 0x5e, 0x4c, 0x97, 0x8d, 0x83, 0x39, 0x1b, 0x3a, 0x3a, 0x3c,
+	0x1d, 0x23, 0x2d, 0x1b, 0x26, 0x28, 0x0c, 0x4d, 0x47, 0xfc, 0xe6, 0x15, 0x10, 0x1a, 0x28, 0x18,
+	0x03, 0x06, 0x06, 0x04, 0x04, 0x06, 0x07, 0x04, 0x07, 0x14, 0x18, 0x1b, 0x0e, 0x14, 0x13, 0x08,
+	0x09, 0x08, 0x07, 0x09, 0x07, 0x1c, 0x03, 0x07, 0x0f, 0x1a, 0x33, 0x28, 0x1a, 0x16, 0x24, 0x2c,
+	0x2e, 0x0c, 0x0c, 0x02, 0x1a, 0x2c, 0x21, 0x12, 0x01, 0x1d, 0x2c, 0x33, 0x00, 0x02, 0x00, 0x1f,
+	0x00, 0x00, 0x03, 0x80, 0x06, 0x44, 0x00, 0x16, 0x00, 0x1a, 0x00, 0x82, 0x40, 0x0a, 0x09, 0x01,
+	0x08, 0x02, 0x0a, 0x01, 0x09, 0x03, 0x02, 0x4c, 0x4b, 0xb0, 0x2a, 0x50, 0x58, 0x40, 0x29, 0x00,
+	0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x40, 0x4d, 0x0b, 0x01, 0x09, 0x09, 0x08, 0x5f, 0x00,
+	0x08, 0x08, 0x38, 0x4d, 0x06, 0x01, 0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d,
+	0x0a, 0x07, 0x02, 0x05, 0x05, 0x39, 0x05, 0x4e, 0x1b, 0x40, 0x27, 0x00, 0x08, 0x0b, 0x01, 0x09,
+	0x01, 0x08, 0x09, 0x67, 0x00, 0x03, 0x03, 0x02, 0x61, 0x00, 0x02, 0x02, 0x40, 0x4d, 0x06, 0x01,
+	0x00, 0x00, 0x01, 0x5f, 0x04, 0x01, 0x01, 0x01, 0x3b, 0x4d, 0x0a, 0x07, 0x02, 0x05, 0x05, 0x3c,
+	0x05, 0x4e, 0x59, 0x40, 0x18, 0x17, 0x17, 0x00, 0x00, 0x17, 0x1a, 0x17, 0x1a, 0x19, 0x18, 0x00,
+	0x16, 0x00, 0x16, 0x11, 0x11, 0x13, 0x23, 0x22, 0x11, 0x11, 0x0c, 0x09, 0x1d, 0x2b, 0x33, 0x11,
+	0x23, 0x35, 0x33, 0x35, 0x10, 0x21, 0x32, 0x17, 0x15, 0x26, 0x23, 0x22, 0x06, 0x15, 0x15, 0x21,
+	0x11, 0x23, 0x11, 0x21, 0x11, 0x01, 0x35, 0x33, 0x15, 0xaf, 0x90, 0x90, 0x01, 0x37, 0x3f, 0x51,
+	0x49, 0x34, 0x4a, 0x3a, 0x02, 0x0b, 0xc5, 0xfe, 0xba, 0x01, 0x46, 0xc5, 0x03, 0xaa, 0x94, 0x82,
+	0x01, 0x84, 0x1a, 0x87, 0x0d, 0x61, 0x7a, 0x97, 0xfb, 0xc2, 0x03, 0xaa, 0xfc, 0x56, 0x05, 0x03,
+	0xc5, 0xc5, 0x00, 0x00, 0x00, 0x01, 0x00, 0x1f, 0xff, 0xe7, 0x04, 0x2c, 0x06, 0x44, 0x00, 0x25,
+	0x00, 0xbd, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x0a, 0x0e, 0x01, 0x03, 0x02, 0x06, 0x01, 0x01,
+	0x00, 0x02, 0x4c, 0x1b, 0x40, 0x0a, 0x0e, 0x01, 0x03, 0x02, 0x06, 0x01, 0x01, 0x05, 0x02, 0x4c,
+	0x59, 0x4b, 0xb0, 0x14, 0x50, 0x58, 0x40, 0x23, 0x00, 0x02, 0x02, 0x08, 0x61, 0x09, 0x01, 0x08,
+	0x08, 0x40, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x3b, 0x4d, 0x00,
+	0x00, 0x00, 0x01, 0x61, 0x05, 0x01, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b, 0x4b, 0xb0, 0x2a, 0x50,
+	0x58, 0x40, 0x2b, 0x00, 0x09, 0x09, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x08, 0x61, 0x00, 0x08, 0x08,
+	0x40, 0x4d, 0x06, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x05,
+	0x05, 0x39, 0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x1b, 0x40,
+	0x2b, 0x00, 0x09, 0x09, 0x3a, 0x4d, 0x00, 0x02, 0x02, 0x08, 0x61, 0x00, 0x08, 0x08, 0x40, 0x4d,
+	0x06, 0x01, 0x04, 0x04, 0x03, 0x5f, 0x07, 0x01, 0x03, 0x03, 0x3b, 0x4d, 0x00, 0x05, 0x05, 0x3c,
+	0x4d, 0x00, 0x00, 0x00, 0x01, 0x61, 0x00, 0x01, 0x01, 0x42, 0x01, 0x4e, 0x59, 0x59, 0x40, 0x0e,
+	0x25, 0x24, 0x22, 0x11, 0x11, 0x11, 0x11, 0x13, 0x28, 0x22, 0x14, 0x0a, 0x09, 0x1f, 0x2b, 0x01,
+	0x14, 0x1e, 0x02, 0x37, 0x15, 0x06, 0x23, 0x22, 0x2e, 0x02, 0x35, 0x11, 0x27, 0x27, 0x26, 0x23,
+	0x22, 0x06, 0x15, 0x15, 0x33, 0x15, 0x23, 0x11, 0x23, 0x11, 0x23, 0x35, 0x33, 0x35, 0x10, 0x21,
+	0x32, 0x17, 0x33, 0x03, 0x80, 0x14, 0x2d, 0x41, 0x2a, 0x16, 0x26, 0x41, 0x75, 0x53, 0x2c, 0x01,
+	0x29, 0x5e, 0x33, 0x50, 0x3c, 0xb2, 0xb2, 0xc5, 0x90, 0x90, 0x01, 0x31, 0x37, 0xa3, 0xc6, 0x01,
+	0x50, 0x39, 0x51, 0x33, 0x18, 0x01, 0x8f, 0x06, 0x2c, 0x53, 0x79, 0x4d, 0x04, 0x59, 0x12, 0x08,
+	0x11, 0x5e, 0x7d, 0x97, 0x94, 0xfc, 0x56, 0x03, 0xaa, 0x94, 0x82, 0x01, 0x84, 0x19, 0x00, 0x00,
+	0x00, 0x03, 0x00, 0x00, 0xff, 0x00, 0x08, 0x00, 0x07, 0x00, 0x00, 0x03, 0x00, 0x07, 0x00, 0x1f,
+	0x00, 0x3d, 0x40, 0x3a, 0x15, 0x01, 0x04, 0x03, 0x16, 0x02, 0x02, 0x02, 0x04, 0x02, 0x4c, 0x01,
+	0x01, 0x03, 0x4a, 0x03, 0x01, 0x00, 0x49, 0x00, 0x03, 0x04, 0x03, 0x85, 0x00, 0x04, 0x02, 0x04,
+	0x85, 0x00, 0x00, 0x01, 0x00, 0x86, 0x00, 0x02, 0x01, 0x01, 0x02, 0x57, 0x00, 0x02, 0x02, 0x01,
+	0x5f, 0x00, 0x01, 0x02, 0x01, 0x4f, 0x23, 0x29, 0x11, 0x11, 0x14, 0x05, 0x06, 0x1b, 0x2b, 0x11,
+	0x09, 0x02, 0x03, 0x21, 0x35, 0x21, 0x35, 0x21, 0x35, 0x34, 0x36, 0x37, 0x37, 0x36, 0x35, 0x34,
+	0x26, 0x23, 0x22, 0x07, 0x15, 0x36, 0x33, 0x32, 0x15, 0x14, 0x07, 0x07, 0x06, 0x15, 0x04, 0x00,
+	0x04, 0x00, 0xfc, 0x00, 0x88, 0x01, 0x10, 0xfe, 0xf0, 0x01, 0x10, 0x20, 0x48, 0x3e, 0x96, 0xfb,
+	0xd9, 0xae, 0xb7, 0xb3, 0x8a, 0xd6, 0x84, 0x40, 0x62, 0x03, 0x00, 0x04, 0x00, 0xfc, 0x00, 0xfc,
+	0x00, 0x01, 0x00, 0xe2, 0x9e, 0x4e, 0x7f, 0x59, 0x44, 0x3b, 0x8f, 0x84, 0x90, 0xa7, 0x38, 0xcf,
+	0x52, 0xab, 0x72, 0x92, 0x47, 0x6c, 0xbe, 0x00, 0x00, 0x03, 0x00, 0x50, 0xff, 0xdb, 0x04, 0x22,
+	0x05, 0xed, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x13, 0x00, 0x42, 0x40, 0x3f, 0x00, 0x01, 0x00, 0x03,
+	0x04, 0x01, 0x03, 0x69, 0x00, 0x04, 0x08, 0x01, 0x05, 0x02, 0x04, 0x05, 0x67, 0x07, 0x01, 0x02,
+	0x00, 0x00, 0x02, 0x59, 0x07, 0x01, 0x02, 0x02, 0x00, 0x61, 0x06, 0x01, 0x00, 0x02, 0x00, 0x51,
+	0x10, 0x10, 0x09, 0x08, 0x01, 0x00, 0x10, 0x13, 0x10, 0x13, 0x12, 0x11, 0x0d, 0x0b, 0x08, 0x0f,
+	0x09, 0x0f, 0x05, 0x03, 0x00, 0x07, 0x01, 0x07, 0x09, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x11, 0x10,
+	0x21, 0x20, 0x13, 0x10, 0x25, 0x20, 0x11, 0x02, 0x21, 0x20, 0x11, 0x10, 0x13, 0x35, 0x33, 0x15,
+	0x02, 0x39, 0xfe, 0x17, 0x01, 0xe9, 0x01, 0xe3, 0x06, 0xfe, 0x17, 0x01, 0x1d, 0x01, 0xfe, 0xe4,
+	0xfe, 0xe4, 0xb9, 0xc6, 0x25, 0x03, 0x0a, 0x03, 0x08, 0xfc, 0xf8, 0xfc, 0xf6, 0x94, 0x02, 0x76,
+	0x02, 0x74, 0xfd, 0x8c, 0xfd, 0x8a, 0x02, 0x2b, 0xc5, 0xc5, 0x00, 0x00, 0x00, 0x02, 0x00, 0x50,
+	0xff, 0xdb, 0x04, 0x22, 0x05, 0xed, 0x00, 0x07, 0x00, 0x0f, 0x00, 0x31, 0x40, 0x2e, 0x00, 0x01,
+	0x00, 0x03, 0x02, 0x01, 0x03, 0x69, 0x05, 0x01, 0x02, 0x00, 0x00, 0x02, 0x59, 0x05, 0x01, 0x02,
+	0x02, 0x00, 0x61, 0x04, 0x01, 0x00, 0x02, 0x00, 0x51, 0x09, 0x08, 0x01, 0x00, 0x0d, 0x0b, 0x08,
+	0x0f, 0x09, 0x0f, 0x05, 0x03, 0x00, 0x07, 0x01, 0x07, 0x06, 0x06, 0x16, 0x2b, 0x05, 0x20, 0x11,
+	0x10, 0x21, 0x20, 0x13, 0x10, 0x25, 0x20, 0x11, 0x02, 0x21, 0x20, 0x11, 0x10, 0x02, 0x39, 0xfe,
+	0x17, 0x01, 0xe9, 0x01, 0xe3, 0x06, 0xfe, 0x17, 0x01, 0x1d, 0x01, 0xfe, 0xe4, 0xfe, 0xe4, 0x25,
+	0x03, 0x0a, 0x03, 0x08, 0xfc, 0xf8, 0xfc, 0xf6, 0x94, 0x02, 0x76, 0x02, 0x74, 0xfd, 0x8c, 0xfd,
+	0x8a, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x02, 0x8f, 0x4e, 0x91, 0x19, 0x6d,
+	0x5f, 0x0f, 0x3c, 0xf5, 0x00, 0x0f, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0xd4, 0x49, 0x69, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0xde, 0xcc, 0x9b, 0x73, 0xfe, 0x48, 0xfd, 0xe1, 0x08, 0x70, 0x08, 0xf3,
+	0x00, 0x00, 0x00, 0x09, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00,
+	0x07, 0x8f, 0xfe, 0x50, 0x00, 0x00, 0x08, 0xc0, 0xfe, 0x48, 0xfe, 0x47, 0x08, 0x70, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xc7,
+	0x06, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x39, 0x00, 0x00, 0x02, 0x39, 0x00, 0x00,
+	0x02, 0x39, 0x00, 0xc8, 0x02, 0xd7, 0x00, 0x5c, 0x04, 0x73, 0x00, 0x19, 0x04, 0x73, 0x00, 0x7b,
+	0x07, 0x1d, 0x00, 0x78, 0x05, 0x56, 0x00, 0x38, 0x01, 0x87, 0x00, 0x48, 0x02, 0xaa, 0x00, 0x83,
+	0x02, 0xaa, 0x00, 0x52, 0x04, 0xac, 0x00, 0x8d, 0x04, 0xac, 0x00, 0x68, 0x02, 0x88, 0x00, 0xc8,
+	0x04, 0xac, 0x00, 0x68, 0x02, 0x88, 0x00, 0xc8, 0x02, 0x39, 0x00, 0x00, 0x04, 0x73, 0x00, 0x50,
+	0x04, 0x73, 0x00, 0xd2, 0x04, 0x73, 0x00, 0x66, 0x04, 0x73, 0x00, 0x99, 0x04, 0x73, 0x00, 0x1f,
+	0x04, 0x73, 0x00, 0xa3, 0x04, 0x73, 0x00, 0x54, 0x04, 0x73, 0x00, 0x88, 0x04, 0x73, 0x00, 0x63,
+	0x04, 0x73, 0x00, 0x54, 0x02, 0x73, 0x00, 0xc8, 0x02, 0x73, 0x00, 0xc8, 0x04, 0xac, 0x00, 0x68,
+	0x04, 0xac, 0x00, 0x1e, 0x04, 0xac, 0x00, 0x68, 0x04, 0x73, 0x00, 0xaa, 0x08, 0x1f, 0x00, 0xfd,
+	0x05, 0x56, 0x00, 0x13, 0x05, 0x56, 0x00, 0xa5, 0x05, 0xc7, 0x00, 0x74, 0x05, 0xc7, 0x00, 0xa5,
+	0x05, 0x56, 0x00, 0xbe, 0x04, 0xe3, 0x00, 0xbf, 0x06, 0x39, 0x00, 0x5d, 0x05, 0xc7, 0x00, 0xa5,
+	0x03, 0x31, 0x00, 0x7c, 0x03, 0xf7, 0x00, 0x14, 0x05, 0x56, 0x00, 0xbf, 0x04, 0x73, 0x00, 0xa5,
+	0x06, 0xaa, 0x00, 0xa5, 0x05, 0xc7, 0x00, 0xa5, 0x06, 0x39, 0x00, 0x5d, 0x05, 0x56, 0x00, 0xa7,
+	0x06, 0x39, 0x00, 0x5d, 0x05, 0xc7, 0x00, 0xa5, 0x05, 0x56, 0x00, 0x78, 0x04, 0xe3, 0x00, 0x14,
+	0x05, 0xc7, 0x00, 0xa6, 0x05, 0x56, 0x00, 0x24, 0x07, 0x8d, 0x00, 0x19, 0x05, 0x56, 0x00, 0x1c,
+	0x05, 0x56, 0x00, 0x1e, 0x04, 0xe3, 0x00, 0x65, 0x02, 0x39, 0x00, 0x6e, 0x02, 0x39, 0x00, 0x00,
+	0x02, 0x39, 0x00, 0x40, 0x03, 0xc0, 0x00, 0x46, 0x04, 0x73, 0x00, 0x00, 0x02, 0xaa, 0x00, 0x6a,
+	0x04, 0x73, 0x00, 0x5f, 0x04, 0x73, 0x00, 0x9a, 0x04, 0x00, 0x00, 0x56, 0x04, 0x73, 0x00, 0x56,
+	0x04, 0x73, 0x00, 0x56, 0x02, 0x39, 0x00, 0x1f, 0x04, 0x73, 0x00, 0x5d, 0x04, 0x73, 0x00, 0x9a,
+	0x01, 0xf9, 0x00, 0x90, 0x02, 0x07, 0xff, 0xac, 0x04, 0x00, 0x00, 0x9a, 0x02, 0x24, 0x00, 0x9a,
+	0x06, 0xaa, 0x00, 0x9a, 0x04, 0x73, 0x00, 0x9a, 0x04, 0x73, 0x00, 0x56, 0x04, 0x73, 0x00, 0x9a,
+	0x04, 0x73, 0x00, 0x56, 0x02, 0xaa, 0x00, 0x9a, 0x04, 0x00, 0x00, 0x74, 0x02, 0x43, 0x00, 0x19,
+	0x04, 0x73, 0x00, 0x8e, 0x04, 0x00, 0x00, 0x13, 0x05, 0xc7, 0x00, 0x0b, 0x04, 0x00, 0x00, 0x1c,
+	0x04, 0x00, 0x00, 0x13, 0x04, 0x00, 0x00, 0x4a, 0x02, 0xac, 0x00, 0x19, 0x02, 0x14, 0x00, 0xbb,
+	0x02, 0xac, 0x00, 0x74, 0x04, 0xac, 0x00, 0x68, 0x02, 0x39, 0x00, 0x00, 0x02, 0xaa, 0x00, 0xf2,
+	0x04, 0x73, 0x00, 0xad, 0x04, 0x73, 0x00, 0x79, 0x04, 0x73, 0x00, 0x7a, 0x04, 0x73, 0x00, 0x19,
+	0x02, 0x14, 0x00, 0xc0, 0x04, 0x73, 0x00, 0x81, 0x02, 0xaa, 0x00, 0x39, 0x05, 0xe5, 0x00, 0x0f,
+	0x02, 0xf6, 0x00, 0x56, 0x04, 0x73, 0x00, 0x73, 0x04, 0xac, 0x00, 0x56, 0x02, 0xaa, 0x00, 0x58,
+	0x05, 0xe5, 0x00, 0x0f, 0x04, 0x73, 0x00, 0x63, 0x03, 0x33, 0x00, 0x72, 0x04, 0xac, 0x00, 0x68,
+	0x03, 0xa5, 0x00, 0x4c, 0x03, 0xa5, 0x00, 0x72, 0x02, 0xaa, 0x00, 0x6b, 0x04, 0x73, 0x00, 0x95,
+	0x04, 0x4c, 0x00, 0x64, 0x02, 0x23, 0x00, 0x96, 0x02, 0xaa, 0x00, 0xa8, 0x03, 0xa5, 0x00, 0x9d,
+	0x02, 0xec, 0x00, 0x4a, 0x04, 0x73, 0x00, 0x88, 0x06, 0xac, 0x00, 0x74, 0x06, 0xac, 0x00, 0x74,
+	0x06, 0xac, 0x00, 0x6f, 0x04, 0xe3, 0x00, 0xb9, 0x05, 0x56, 0x00, 0x13, 0x05, 0x56, 0x00, 0x13,
+	0x05, 0x56, 0x00, 0x13, 0x05, 0x56, 0x00, 0x13, 0x05, 0x56, 0x00, 0x13, 0x05, 0x56, 0x00, 0x13,
+	0x08, 0x00, 0x00, 0x13, 0x05, 0xc7, 0x00, 0x74, 0x05, 0x56, 0x00, 0xbe, 0x05, 0x56, 0x00, 0xbe,
+	0x05, 0x56, 0x00, 0xbe, 0x05, 0x56, 0x00, 0xbe, 0x03, 0x31, 0x00, 0x57, 0x03, 0x31, 0x00, 0x7c,
+	0x03, 0x31, 0x00, 0x3b, 0x03, 0x31, 0x00, 0x7c, 0x05, 0xd1, 0x00, 0x0f, 0x05, 0xc7, 0x00, 0xa5,
+	0x06, 0x39, 0x00, 0x5d, 0x06, 0x39, 0x00, 0x5d, 0x06, 0x39, 0x00, 0x5d, 0x06, 0x39, 0x00, 0x5d,
+	0x06, 0x39, 0x00, 0x5d, 0x04, 0xac, 0x00, 0x6c, 0x06, 0x39, 0x00, 0x5d, 0x05, 0xc7, 0x00, 0xa6,
+	0x05, 0xc7, 0x00, 0xa6, 0x05, 0xc7, 0x00, 0xa6, 0x05, 0xc7, 0x00, 0xa6, 0x05, 0x56, 0x00, 0x1e,
+	0x05, 0x56, 0x00, 0xa7, 0x04, 0xe3, 0x00, 0x81, 0x04, 0x73, 0x00, 0x5f, 0x04, 0x73, 0x00, 0x5f,
+	0x04, 0x73, 0x00, 0x5f, 0x04, 0x73, 0x00, 0x5f, 0x04, 0x73, 0x00, 0x5f, 0x04, 0x73, 0x00, 0x5f,
+	0x07, 0x1d, 0x00, 0x5f, 0x04, 0x00, 0x00, 0x56, 0x04, 0x73, 0x00, 0x56, 0x04, 0x73, 0x00, 0x56,
+	0x04, 0x73, 0x00, 0x56, 0x04, 0x73, 0x00, 0x56, 0x01, 0xf9, 0xff, 0xd8, 0x01, 0xf9, 0x00, 0x4c,
+	0x01, 0xf9, 0xff, 0x9e, 0x01, 0xf9, 0xff, 0xe0, 0x04, 0x73, 0x00, 0x54, 0x04, 0x73, 0x00, 0x9a,
+	0x04, 0x73, 0x00, 0x56, 0x04, 0x73, 0x00, 0x56, 0x04, 0x73, 0x00, 0x56, 0x04, 0x73, 0x00, 0x56,
+	0x04, 0x73, 0x00, 0x56, 0x04, 0xac, 0x00, 0x68, 0x04, 0xe3, 0x00, 0x8f, 0x04, 0x73, 0x00, 0x8e,
+	0x04, 0x73, 0x00, 0x8e, 0x04, 0x73, 0x00, 0x8e, 0x04, 0x73, 0x00, 0x8e, 0x04, 0x00, 0x00, 0x13,
+	0x04, 0x73, 0x00, 0x9a, 0x04, 0x00, 0x00, 0x13, 0x05, 0x5b, 0x00, 0x15, 0x04, 0x81, 0x00, 0x69,
+	0x05, 0x5b, 0x00, 0x15, 0x04, 0x81, 0x00, 0x69, 0x05, 0x56, 0x00, 0x13, 0x04, 0x73, 0x00, 0x5f,
+	0x05, 0xc7, 0x00, 0x74, 0x04, 0x00, 0x00, 0x56, 0x05, 0xc7, 0x00, 0x74, 0x04, 0x00, 0x00, 0x56,
+	0x05, 0xc7, 0x00, 0x74, 0x04, 0x00, 0x00, 0x56, 0x05, 0xc7, 0x00, 0x74, 0x04, 0x00, 0x00, 0x56,
+	0x05, 0xc7, 0x00, 0xa5, 0x05, 0x34, 0x00, 0x56, 0x05, 0xd1, 0x00, 0x0f, 0x04, 0x73, 0x00, 0x56,
+	0x05, 0x56, 0x00, 0xbe, 0x04, 0x73, 0x00, 0x56, 0x05, 0x56, 0x00, 0xbe, 0x04, 0x73, 0x00, 0x56,
+	0x05, 0x56, 0x00, 0xbe, 0x04, 0x73, 0x00, 0x56, 0x05, 0x56, 0x00, 0xbe, 0x04, 0x73, 0x00, 0x56,
+	0x05, 0x56, 0x00, 0xbf, 0x04, 0x73, 0x00, 0x56, 0x06, 0x39, 0x00, 0x5d, 0x04, 0x73, 0x00, 0x5d,
+	0x06, 0x39, 0x00, 0x5d, 0x04, 0x73, 0x00, 0x5d, 0x06, 0x39, 0x00, 0x5d, 0x04, 0x73, 0x00, 0x5d,
+	0x06, 0x39, 0x00, 0x5d, 0x04, 0x73, 0x00, 0x5d, 0x05, 0xc7, 0x00, 0xa5, 0x04, 0x73, 0x00, 0x9a,
+	0x05, 0xc7, 0x00, 0x11, 0x04, 0x73, 0x00, 0x06, 0x03, 0x31, 0x00, 0x4c, 0x01, 0xf9, 0xff, 0xaf,
+	0x03, 0x31, 0x00, 0x58, 0x01, 0xf9, 0xff, 0xbb, 0x03, 0x31, 0x00, 0x4c, 0x01, 0xf9, 0xff, 0xaf,
+	0x03, 0x31, 0x00, 0x7c, 0x01, 0xf9, 0x00, 0x56, 0x03, 0x31, 0x00, 0x7c, 0x01, 0xf9, 0x00, 0x9a,
+	0x06, 0x6e, 0x00, 0x7c, 0x03, 0xb9, 0x00, 0x9a, 0x04, 0x00, 0x00, 0x31, 0x02, 0x07, 0xff, 0xac,
+	0x05, 0x56, 0x00, 0xbf, 0x04, 0x00, 0x00, 0x9a, 0x04, 0x00, 0x00, 0x9a, 0x04, 0x73, 0x00, 0xa5,
+	0x02, 0x24, 0x00, 0x4f, 0x04, 0x73, 0x00, 0xa5, 0x02, 0x24, 0x00, 0x9a, 0x04, 0x73, 0x00, 0xa5,
+	0x02, 0xa2, 0x00, 0x9a, 0x04, 0x73, 0x00, 0xa5, 0x02, 0xbc, 0x00, 0x9a, 0x04, 0x73, 0x00, 0x11,
+	0x02, 0x50, 0x00, 0x0a, 0x05, 0xc7, 0x00, 0xa5, 0x04, 0x73, 0x00, 0x9a, 0x05, 0xc7, 0x00, 0xa5,
+	0x04, 0x73, 0x00, 0x9a, 0x05, 0xc7, 0x00, 0xa5, 0x04, 0x73, 0x00, 0x9a, 0x04, 0xd5, 0x00, 0x01,
+	0x05, 0xc7, 0x00, 0xa5, 0x04, 0x73, 0x00, 0x9a, 0x06, 0x39, 0x00, 0x5d, 0x04, 0x73, 0x00, 0x56,
+	0x06, 0x39, 0x00, 0x5d, 0x04, 0x73, 0x00, 0x56, 0x06, 0x39, 0x00, 0x5d, 0x04, 0x73, 0x00, 0x56,
+	0x08, 0x00, 0x00, 0x5d, 0x07, 0x8d, 0x00, 0x56, 0x05, 0xc7, 0x00, 0xa5, 0x02, 0xaa, 0x00, 0x9a,
+	0x05, 0xc7, 0x00, 0xa5, 0x02, 0xaa, 0x00, 0x9a, 0x05, 0xc7, 0x00, 0xa5, 0x02, 0xaa, 0x00, 0x02,
+	0x05, 0x56, 0x00, 0x78, 0x04, 0x00, 0x00, 0x74, 0x05, 0x56, 0x00, 0x78, 0x04, 0x00, 0x00, 0x74,
+	0x05, 0x56, 0x00, 0x78, 0x04, 0x00, 0x00, 0x74, 0x05, 0x56, 0x00, 0x78, 0x04, 0x00, 0x00, 0x74,
+	0x04, 0xe3, 0x00, 0x14, 0x02, 0x39, 0x00, 0x19, 0x04, 0xe3, 0x00, 0x14, 0x03, 0x00, 0x00, 0x19,
+	0x04, 0xe3, 0x00, 0x14, 0x02, 0x39, 0x00, 0x19, 0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e,
+	0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e, 0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e,
+	0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e, 0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e,
+	0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e, 0x07, 0x8d, 0x00, 0x19, 0x05, 0xc7, 0x00, 0x0b,
+	0x05, 0x56, 0x00, 0x1e, 0x04, 0x00, 0x00, 0x13, 0x05, 0x56, 0x00, 0x1e, 0x04, 0xe3, 0x00, 0x65,
+	0x04, 0x00, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x65, 0x04, 0x00, 0x00, 0x4a, 0x04, 0xe3, 0x00, 0x65,
+	0x04, 0x00, 0x00, 0x4a, 0x01, 0xc7, 0x00, 0x08, 0x04, 0x73, 0x00, 0x31, 0x05, 0x56, 0x00, 0x13,
+	0x04, 0x73, 0x00, 0x5f, 0x03, 0x31, 0x00, 0x3a, 0x01, 0xf9, 0xff, 0x9e, 0x06, 0x39, 0x00, 0x5d,
+	0x04, 0x73, 0x00, 0x56, 0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e, 0x05, 0xc7, 0x00, 0xa6,
+	0x04, 0x73, 0x00, 0x8e, 0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e, 0x05, 0xc7, 0x00, 0xa6,
+	0x04, 0x73, 0x00, 0x8e, 0x05, 0xc7, 0x00, 0xa6, 0x04, 0x73, 0x00, 0x8e, 0x05, 0x56, 0x00, 0x13,
+	0x04, 0x73, 0x00, 0x5f, 0x08, 0x00, 0x00, 0x13, 0x07, 0x1d, 0x00, 0x5f, 0x06, 0x39, 0x00, 0x5d,
+	0x04, 0xe3, 0x00, 0x8f, 0x05, 0x56, 0x00, 0x78, 0x04, 0x00, 0x00, 0x74, 0x04, 0xe3, 0x00, 0x14,
+	0x02, 0x39, 0x00, 0x19, 0x02, 0xaa, 0xff, 0xf7, 0x02, 0xaa, 0xff, 0xf7, 0x02, 0xaa, 0x00, 0x14,
+	0x02, 0xaa, 0x00, 0x08, 0x02, 0xaa, 0x00, 0xf2, 0x02, 0xaa, 0x00, 0x72, 0x02, 0xaa, 0x00, 0xaa,
+	0x02, 0xaa, 0x00, 0x08, 0x02, 0xaa, 0xff, 0xcd, 0x02, 0x73, 0x00, 0xc8, 0x02, 0xaa, 0x00, 0xb4,
+	0x02, 0xaa, 0xff, 0xea, 0x05, 0x57, 0x00, 0x16, 0x02, 0x39, 0x00, 0xa1, 0x06, 0x46, 0x00, 0x00,
+	0x06, 0xb4, 0x00, 0x00, 0x03, 0x2d, 0xfe, 0xd4, 0x06, 0x32, 0xff, 0x83, 0x06, 0xd8, 0x00, 0x01,
+	0x06, 0x05, 0xff, 0x93, 0x02, 0xf2, 0x00, 0x00, 0x05, 0x56, 0x00, 0x13, 0x05, 0x56, 0x00, 0xa5,
+	0x04, 0x68, 0x00, 0xb4, 0x05, 0x58, 0x00, 0x24, 0x05, 0x56, 0x00, 0xbe, 0x04, 0xe3, 0x00, 0x65,
+	0x05, 0xc7, 0x00, 0xa5, 0x06, 0x39, 0x00, 0x5d, 0x03, 0x31, 0x00, 0x7c, 0x05, 0x56, 0x00, 0xbf,
+	0x05, 0x58, 0x00, 0x15, 0x06, 0xaa, 0x00, 0xa5, 0x05, 0xc7, 0x00, 0xa5, 0x05, 0x33, 0x00, 0x50,
+	0x06, 0x39, 0x00, 0x5d, 0x05, 0xc7, 0x00, 0xa5, 0x05, 0x56, 0x00, 0xa7, 0x04, 0xb3, 0x00, 0x70,
+	0x04, 0xe3, 0x00, 0x14, 0x05, 0x56, 0x00, 0x39, 0x07, 0x06, 0x00, 0xad, 0x05, 0x56, 0x00, 0x1c,
+	0x06, 0xaf, 0x00, 0x7e, 0x05, 0x9f, 0x00, 0x45, 0x03, 0x45, 0x00, 0x7c, 0x05, 0x56, 0x00, 0x39,
+	0x04, 0xa0, 0x00, 0x56, 0x03, 0x91, 0x00, 0x4e, 0x04, 0x73, 0x00, 0x57, 0x02, 0xf2, 0x00, 0xb9,
+	0x04, 0x60, 0x00, 0x8e, 0x04, 0xa0, 0x00, 0x56, 0x04, 0x9a, 0x00, 0x9a, 0x04, 0x00, 0x00, 0x0d,
+	0x04, 0x74, 0x00, 0x56, 0x03, 0x91, 0x00, 0x4e, 0x03, 0x87, 0x00, 0x0b, 0x04, 0x73, 0x00, 0x57,
+	0x04, 0x73, 0x00, 0x56, 0x02, 0xf2, 0x00, 0xc5, 0x04, 0x00, 0x00, 0x9a, 0x04, 0x00, 0x00, 0x18,
+	0x04, 0x9c, 0x00, 0x9a, 0x04, 0x00, 0x00, 0x00, 0x03, 0x95, 0xff, 0xfe, 0x04, 0x73, 0x00, 0x56,
+	0x05, 0x85, 0x00, 0x2b, 0x04, 0x8d, 0x00, 0x81, 0x03, 0xdb, 0x00, 0x56, 0x04, 0xf0, 0x00, 0x56,
+	0x03, 0x29, 0x00, 0x14, 0x04, 0x60, 0x00, 0x8e, 0x05, 0x30, 0x00, 0x57, 0x04, 0x33, 0x00, 0x08,
+	0x05, 0xb4, 0x00, 0x3d, 0x06, 0x3f, 0x00, 0x6b, 0x02, 0xf2, 0x00, 0x1e, 0x04, 0x60, 0x00, 0x8e,
+	0x04, 0x73, 0x00, 0x56, 0x04, 0x60, 0x00, 0x8e, 0x06, 0x3f, 0x00, 0x6b, 0x05, 0x56, 0x00, 0xbe,
+	0x05, 0x57, 0x00, 0xbe, 0x06, 0xeb, 0x00, 0x1e, 0x04, 0x55, 0x00, 0xb4, 0x05, 0xc0, 0x00, 0x5d,
+	0x05, 0x56, 0x00, 0x78, 0x03, 0x31, 0x00, 0x7c, 0x03, 0x31, 0x00, 0x7c, 0x04, 0x00, 0x00, 0x50,
+	0x08, 0x75, 0x00, 0x18, 0x08, 0x15, 0x00, 0xa5, 0x06, 0xd5, 0x00, 0x1b, 0x04, 0xa9, 0x00, 0xa5,
+	0x05, 0xc0, 0x00, 0xaa, 0x05, 0x15, 0x00, 0x2c, 0x05, 0xc0, 0x00, 0xa5, 0x05, 0x56, 0x00, 0x13,
+	0x05, 0x40, 0x00, 0xa5, 0x05, 0x56, 0x00, 0xa5, 0x04, 0x55, 0x00, 0xb4, 0x05, 0x6b, 0x00, 0x3c,
+	0x05, 0x56, 0x00, 0xbe, 0x07, 0x63, 0x00, 0x7d, 0x04, 0xd5, 0x00, 0x6e, 0x05, 0xc0, 0x00, 0xaa,
+	0x05, 0xc0, 0x00, 0xaa, 0x04, 0xa9, 0x00, 0xa5, 0x05, 0x40, 0x00, 0x13, 0x06, 0xaa, 0x00, 0xa5,
+	0x05, 0xc7, 0x00, 0xa5, 0x06, 0x39, 0x00, 0x5d, 0x05, 0xc0, 0x00, 0xa5, 0x05, 0x56, 0x00, 0xa7,
+	0x05, 0xc7, 0x00, 0x74, 0x04, 0xe3, 0x00, 0x14, 0x05, 0x15, 0x00, 0x2c, 0x06, 0x15, 0x00, 0x46,
+	0x05, 0x56, 0x00, 0x1c, 0x05, 0xeb, 0x00, 0xa5, 0x05, 0x55, 0x00, 0x5a, 0x07, 0x55, 0x00, 0xaa,
+	0x07, 0x80, 0x00, 0xaa, 0x06, 0x55, 0x00, 0x1e, 0x07, 0x15, 0x00, 0xa5, 0x05, 0x40, 0x00, 0xa6,
+	0x05, 0xc0, 0x00, 0xb4, 0x08, 0x15, 0x00, 0xa6, 0x05, 0xc7, 0x00, 0x63, 0x04, 0x73, 0x00, 0x5f,
+	0x04, 0x95, 0x00, 0x57, 0x04, 0x40, 0x00, 0x9a, 0x02, 0xeb, 0x00, 0x8c, 0x04, 0xab, 0x00, 0x28,
+	0x04, 0x73, 0x00, 0x56, 0x05, 0x5a, 0x00, 0x05, 0x03, 0xab, 0x00, 0x56, 0x04, 0x78, 0x00, 0x91,
+	0x04, 0x78, 0x00, 0x91, 0x03, 0x80, 0x00, 0x9a, 0x04, 0xab, 0x00, 0x28, 0x05, 0x80, 0x00, 0xa0,
+	0x04, 0x6b, 0x00, 0x91, 0x04, 0x73, 0x00, 0x56, 0x04, 0x55, 0x00, 0x91, 0x04, 0x73, 0x00, 0x96,
+	0x04, 0x00, 0x00, 0x5f, 0x03, 0xaa, 0x00, 0x29, 0x04, 0x00, 0x00, 0x0b, 0x06, 0x95, 0x00, 0x56,
+	0x04, 0x00, 0x00, 0x1c, 0x04, 0x95, 0x00, 0x91, 0x04, 0x2b, 0x00, 0x60, 0x06, 0x6b, 0x00, 0xa0,
+	0x06, 0x95, 0x00, 0xa0, 0x05, 0x00, 0x00, 0x26, 0x05, 0xc0, 0x00, 0x9a, 0x04, 0x2b, 0x00, 0x9a,
+	0x04, 0x15, 0x00, 0x5e, 0x06, 0x00, 0x00, 0x9a, 0x04, 0x55, 0x00, 0x40, 0x04, 0x73, 0x00, 0x56,
+	0x04, 0x73, 0x00, 0x56, 0x04, 0x73, 0x00, 0x0a, 0x02, 0xeb, 0x00, 0x8c, 0x04, 0x15, 0x00, 0x56,
+	0x04, 0x00, 0x00, 0x74, 0x01, 0xf9, 0x00, 0x90, 0x01, 0xf9, 0xff, 0xe0, 0x01, 0xd7, 0xff, 0xa3,
+	0x07, 0x40, 0x00, 0x41, 0x06, 0x80, 0x00, 0x9a, 0x04, 0x73, 0x00, 0x0a, 0x03, 0x80, 0x00, 0x9a,
+	0x04, 0x78, 0x00, 0x91, 0x04, 0x00, 0x00, 0x0b, 0x04, 0x6b, 0x00, 0x91, 0x03, 0xe9, 0x00, 0xb4,
+	0x03, 0x4a, 0x00, 0xaa, 0x07, 0x8d, 0x00, 0x19, 0x05, 0xc7, 0x00, 0x0b, 0x07, 0x8d, 0x00, 0x19,
+	0x05, 0xc7, 0x00, 0x0b, 0x07, 0x8d, 0x00, 0x19, 0x05, 0xc7, 0x00, 0x0b, 0x05, 0x56, 0x00, 0x1e,
+	0x04, 0x00, 0x00, 0x13, 0x04, 0x00, 0x00, 0x80, 0x08, 0x00, 0x00, 0x80, 0x08, 0x00, 0x00, 0x00,
+	0x04, 0x6b, 0x00, 0x00, 0x01, 0xc7, 0x00, 0x5c, 0x01, 0xc7, 0x00, 0x74, 0x01, 0xc7, 0x00, 0x68,
+	0x01, 0xc7, 0x00, 0x60, 0x03, 0x56, 0x00, 0x3c, 0x03, 0x56, 0x00, 0x64, 0x03, 0x56, 0x00, 0x64,
+	0x04, 0x73, 0x00, 0x96, 0x04, 0x73, 0x00, 0x96, 0x02, 0xcd, 0x00, 0x51, 0x08, 0x00, 0x00, 0xbc,
+	0x08, 0x00, 0x00, 0x19, 0x01, 0x80, 0x00, 0x16, 0x02, 0xd5, 0x00, 0x15, 0x02, 0xaa, 0x00, 0x4a,
+	0x02, 0xaa, 0x00, 0x72, 0x04, 0x00, 0x00, 0xd2, 0x02, 0xaa, 0x00, 0x00, 0x01, 0x56, 0xfe, 0x48,
+	0x03, 0xa5, 0x00, 0x3c, 0x03, 0xa5, 0x00, 0x17, 0x03, 0xa5, 0x00, 0x7a, 0x03, 0xa5, 0x00, 0x3f,
+	0x03, 0xa5, 0x00, 0x66, 0x03, 0xa5, 0x00, 0x4a, 0x03, 0xa5, 0x00, 0x3f, 0x03, 0xa5, 0x00, 0x4e,
+	0x03, 0xa5, 0x00, 0x4b, 0x03, 0xa5, 0x00, 0x16, 0x02, 0xeb, 0x00, 0xbf, 0x02, 0xeb, 0x00, 0x9a,
+	0x03, 0xa5, 0x00, 0x73, 0x03, 0xa5, 0x00, 0x3c, 0x03, 0xa5, 0x00, 0x9d, 0x03, 0xa5, 0x00, 0x4c,
+	0x03, 0xa5, 0x00, 0x72, 0x03, 0xa5, 0x00, 0x17, 0x03, 0xa5, 0x00, 0x7a, 0x03, 0xa5, 0x00, 0x3f,
+	0x03, 0xa5, 0x00, 0x66, 0x03, 0xa5, 0x00, 0x4a, 0x03, 0xa5, 0x00, 0x3f, 0x03, 0xa5, 0x00, 0x4e,
+	0x03, 0xa5, 0x00, 0x4b, 0x03, 0xa5, 0x00, 0x16, 0x02, 0xeb, 0x00, 0xbf, 0x02, 0xeb, 0x00, 0x9a,
+	0x03, 0xa5, 0x00, 0x73, 0x04, 0x73, 0x00, 0x8c, 0x04, 0x73, 0x00, 0x8c, 0x08, 0xc0, 0x00, 0x64,
+	0x04, 0x73, 0x00, 0x00, 0x07, 0x15, 0x00, 0x57, 0x02, 0x96, 0x00, 0x00, 0x08, 0x95, 0x00, 0x96,
+	0x08, 0x00, 0x00, 0xdc, 0x06, 0x25, 0x00, 0x88, 0x05, 0xb6, 0x00, 0x64, 0x06, 0xac, 0x00, 0x50,
+	0x06, 0xac, 0x00, 0x3c, 0x06, 0xac, 0x00, 0x5a, 0x06, 0xac, 0x00, 0x5a, 0x08, 0x00, 0x00, 0xa0,
+	0x04, 0x00, 0x00, 0x8d, 0x08, 0x00, 0x00, 0xa0, 0x04, 0x00, 0x00, 0x8d, 0x08, 0x00, 0x00, 0x50,
+	0x04, 0x00, 0x00, 0x8e, 0x04, 0x00, 0x00, 0x8e, 0x03, 0xf4, 0x00, 0x3a, 0x04, 0xe5, 0x00, 0x46,
+	0x06, 0x96, 0x00, 0xb6, 0x05, 0xb4, 0x00, 0x71, 0x04, 0xac, 0x00, 0x64, 0x01, 0x56, 0xff, 0x25,
+	0x02, 0x39, 0x00, 0x41, 0x04, 0x64, 0x00, 0x00, 0x05, 0xb4, 0x00, 0x70, 0x07, 0xd5, 0x01, 0x68,
+	0x05, 0xc0, 0x00, 0x90, 0x05, 0xc0, 0x00, 0x90, 0x02, 0x31, 0x00, 0x0c, 0x04, 0x64, 0x00, 0x45,
+	0x04, 0xac, 0x00, 0x72, 0x04, 0xab, 0x00, 0x72, 0x04, 0x64, 0x00, 0x32, 0x04, 0x64, 0x00, 0x46,
+	0x04, 0xd5, 0x00, 0x8a, 0x04, 0xac, 0x00, 0x68, 0x04, 0xcd, 0x02, 0x03, 0x04, 0xcd, 0x00, 0xea,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d,
+	0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x02, 0x1d, 0x04, 0xcd, 0x01, 0x89, 0x04, 0xcd, 0x01, 0x89,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xcd, 0x02, 0x66, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00,
+	0x04, 0xd5, 0x00, 0x64, 0x04, 0xd5, 0x00, 0x64, 0x02, 0xd6, 0x00, 0x64, 0x02, 0xd6, 0x00, 0x64,
+	0x08, 0x00, 0x00, 0x00, 0x07, 0xeb, 0x00, 0xfa, 0x07, 0xeb, 0x00, 0xfa, 0x07, 0xeb, 0x00, 0xfa,
+	0x07, 0xeb, 0x00, 0xfa, 0x03, 0xf4, 0x00, 0x20, 0x04, 0xd5, 0x00, 0xae, 0x04, 0xd5, 0x00, 0xae,
+	0x04, 0xcd, 0x00, 0x00, 0x04, 0xcd, 0x00, 0x00, 0x02, 0xd6, 0x00, 0x42, 0x08, 0x2b, 0x01, 0x0c,
+	0x08, 0x6b, 0x01, 0x2d, 0x07, 0x55, 0x00, 0xad, 0x06, 0x00, 0x00, 0x66, 0x06, 0x00, 0x00, 0x2b,
+	0x04, 0x40, 0x00, 0x32, 0x05, 0x40, 0x00, 0x32, 0x04, 0xc0, 0x00, 0x4a, 0x04, 0x15, 0x00, 0x28,
+	0x04, 0x00, 0x00, 0x31, 0x05, 0xfe, 0x00, 0x64, 0x08, 0x00, 0x00, 0xfd, 0x04, 0x1a, 0x00, 0x1f,
+	0x04, 0x45, 0x00, 0x1f, 0x08, 0x00, 0x00, 0x00, 0x04, 0x73, 0x00, 0x50, 0x00, 0x50, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x2a, 0x00, 0x2a, 0x00, 0x2a, 0x00, 0x2a, 0x00, 0x66, 0x00, 0x8e, 0x01, 0x1c,
+	0x01, 0xa8, 0x02, 0x4e, 0x02, 0xce, 0x02, 0xea, 0x03, 0x0c, 0x03, 0x2e, 0x03, 0x9c, 0x03, 0xc8,
+	0x04, 0x0a, 0x04, 0x26, 0x04, 0x4c, 0x04, 0x72, 0x04, 0xc8, 0x04, 0xfc, 0x05, 0x50, 0x05, 0xb8,
+	0x06, 0x00, 0x06, 0x5e, 0x06, 0xc2, 0x06, 0xfa, 0x07, 0x60, 0x07, 0xc4, 0x07, 0xfc, 0x08, 0x58,
+	0x08, 0x70, 0x08, 0x9c, 0x08, 0xb4, 0x09, 0x14, 0x09, 0xbc, 0x09, 0xfe, 0x0a, 0x62, 0x0a, 0xb4,
+	0x0a, 0xfc, 0x0b, 0x3e, 0x0b, 0x78, 0x0b, 0xda, 0x0c, 0x16, 0x0c, 0x50, 0x0c, 0x90, 0x0c, 0xca,
+	0x0c, 0xf6, 0x0d, 0x3c, 0x0d, 0x72, 0x0d, 0xca, 0x0e, 0x14, 0x0e, 0x72, 0x0e, 0xc6, 0x0f, 0x20,
+	0x0f, 0x50, 0x0f, 0x92, 0x0f, 0xc4, 0x10, 0x06, 0x10, 0x46, 0x10, 0x7c, 0x10, 0xb8, 0x10, 0xdc,
+	0x10, 0xfe, 0x11, 0x22, 0x11, 0x42, 0x11, 0x62, 0x11, 0x7e, 0x11, 0xfc, 0x12, 0x68, 0x12, 0xa4,
+	0x13, 0x12, 0x13, 0x5c, 0x13, 0xae, 0x14, 0x34, 0x14, 0x7a, 0x14, 0xb2, 0x14, 0xfe, 0x15, 0x3c,
+	0x15, 0x68, 0x15, 0xd2, 0x16, 0x26, 0x16, 0x66, 0x16, 0xc0, 0x17, 0x18, 0x17, 0x72, 0x17, 0xb4,
+	0x17, 0xee, 0x18, 0x42, 0x18, 0x74, 0x18, 0xb4, 0x18, 0xf4, 0x19, 0x18, 0x19, 0x56, 0x19, 0xae,
+	0x19, 0xc8, 0x1a, 0x20, 0x1a, 0x7a, 0x1a, 0x7a, 0x1a, 0xa8, 0x1b, 0x0e, 0x1b, 0x6e, 0x1b, 0xce,
+	0x1c, 0x2e, 0x1c, 0x58, 0x1c, 0xd0, 0x1c, 0xfa, 0x1d, 0x7e, 0x1d, 0xfc, 0x1e, 0x24, 0x1e, 0x46,
+	0x1e, 0x62, 0x1e, 0xe6, 0x1f, 0x06, 0x1f, 0x4a, 0x1f, 0x9c, 0x1f, 0xe0, 0x20, 0x30, 0x20, 0x4e,
+	0x20, 0xaa, 0x20, 0xea, 0x21, 0x04, 0x21, 0x54, 0x21, 0x7a, 0x21, 0xb4, 0x21, 0xdc, 0x22, 0x44,
+	0x22, 0xb2, 0x23, 0x62, 0x23, 0xae, 0x24, 0x04, 0x24, 0x5e, 0x24, 0xc2, 0x25, 0x3c, 0x25, 0xa0,
+	0x26, 0x1a, 0x26, 0x7a, 0x26, 0xfc, 0x27, 0x52, 0x27, 0xac, 0x28, 0x10, 0x28, 0x72, 0x28, 0xc0,
+	0x29, 0x12, 0x29, 0x6e, 0x29, 0xc8, 0x2a, 0x28, 0x2a, 0x94, 0x2b, 0x00, 0x2b, 0x70, 0x2b, 0xea,
+	0x2c, 0x78, 0x2c, 0xf0, 0x2d, 0x14, 0x2d, 0x82, 0x2d, 0xd6, 0x2e, 0x30, 0x2e, 0x92, 0x2e, 0xf4,
+	0x2f, 0x42, 0x2f, 0x94, 0x30, 0x16, 0x30, 0xc8, 0x31, 0x80, 0x32, 0x42, 0x33, 0x1c, 0x33, 0xda,
+	0x34, 0x92, 0x35, 0x7e, 0x35, 0xe0, 0x36, 0x54, 0x36, 0xcc, 0x37, 0x4c, 0x37, 0xcc, 0x38, 0x16,
+	0x38, 0x64, 0x38, 0xbc, 0x39, 0x02, 0x39, 0x76, 0x3a, 0x0c, 0x3a, 0x72, 0x3a, 0xdc, 0x3b, 0x50,
+	0x3b, 0xd8, 0x3c, 0x4a, 0x3c, 0x96, 0x3c, 0xea, 0x3d, 0x70, 0x3d, 0xfa, 0x3e, 0x8e, 0x3f, 0x0a,
+	0x3f, 0x52, 0x3f, 0x98, 0x3f, 0xe8, 0x40, 0x40, 0x40, 0xf2, 0x41, 0x58, 0x42, 0x1a, 0x42, 0x8e,
+	0x43, 0x50, 0x43, 0xba, 0x44, 0x20, 0x44, 0x92, 0x45, 0x00, 0x45, 0x66, 0x45, 0xc6, 0x46, 0x38,
+	0x46, 0xa6, 0x47, 0x10, 0x47, 0x92, 0x47, 0xf2, 0x48, 0x7a, 0x48, 0xd0, 0x49, 0x44, 0x49, 0xaa,
+	0x4a, 0x48, 0x4a, 0x9e, 0x4b, 0x12, 0x4b, 0x8e, 0x4c, 0x08, 0x4c, 0x6c, 0x4c, 0xec, 0x4d, 0x70,
+	0x4e, 0x1e, 0x4e, 0xa4, 0x4f, 0x54, 0x4f, 0xca, 0x50, 0x84, 0x51, 0x2e, 0x51, 0xcc, 0x52, 0x2a,
+	0x52, 0x90, 0x52, 0xec, 0x53, 0x48, 0x53, 0xb8, 0x54, 0x14, 0x54, 0x62, 0x54, 0xaa, 0x55, 0x08,
+	0x55, 0x74, 0x55, 0xe4, 0x56, 0x4c, 0x56, 0x9a, 0x56, 0xbe, 0x57, 0x20, 0x57, 0x8c, 0x57, 0xec,
+	0x58, 0x46, 0x58, 0xc0, 0x59, 0x40, 0x59, 0x7a, 0x59, 0xbe, 0x59, 0xfc, 0x5a, 0x6c, 0x5a, 0xd0,
+	0x5b, 0x12, 0x5b, 0x50, 0x5b, 0x90, 0x5b, 0xcc, 0x5c, 0x0c, 0x5c, 0x48, 0x5c, 0x96, 0x5d, 0x20,
+	0x5d, 0x96, 0x5e, 0x34, 0x5e, 0x8a, 0x5f, 0x1e, 0x5f, 0x98, 0x5f, 0xe8, 0x60, 0x58, 0x60, 0xc4,
+	0x61, 0x2a, 0x61, 0xa6, 0x62, 0x32, 0x62, 0xae, 0x63, 0x24, 0x63, 0xa6, 0x64, 0x0a, 0x64, 0x74,
+	0x65, 0x04, 0x65, 0x9c, 0x66, 0x46, 0x66, 0xba, 0x67, 0x56, 0x67, 0xc8, 0x68, 0x34, 0x68, 0xae,
+	0x69, 0x22, 0x69, 0xaa, 0x6a, 0x14, 0x6a, 0x8e, 0x6b, 0x02, 0x6b, 0x64, 0x6b, 0xc4, 0x6c, 0x16,
+	0x6c, 0x62, 0x6c, 0xaa, 0x6c, 0xf4, 0x6d, 0x6a, 0x6e, 0x00, 0x6e, 0x56, 0x6e, 0xda, 0x6f, 0x3e,
+	0x6f, 0xec, 0x70, 0x6a, 0x71, 0x02, 0x71, 0x6a, 0x72, 0x00, 0x72, 0x72, 0x73, 0x08, 0x73, 0x6a,
+	0x73, 0xde, 0x74, 0x36, 0x74, 0x86, 0x74, 0xde, 0x75, 0x32, 0x75, 0x9e, 0x75, 0xee, 0x76, 0x40,
+	0x76, 0x9e, 0x77, 0x14, 0x77, 0x5e, 0x77, 0xb4, 0x78, 0x18, 0x78, 0xda, 0x79, 0x36, 0x79, 0x8e,
+	0x7a, 0x08, 0x7a, 0x7c, 0x7a, 0xde, 0x7b, 0x72, 0x7b, 0xe8, 0x7c, 0x7c, 0x7c, 0xf6, 0x7d, 0x90,
+	0x7e, 0x14, 0x7e, 0xb6, 0x7f, 0x2c, 0x7f, 0xc0, 0x80, 0x42, 0x81, 0x08, 0x81, 0x80, 0x82, 0xb2,
+	0x83, 0x38, 0x83, 0xb6, 0x84, 0x52, 0x84, 0xce, 0x85, 0x42, 0x85, 0xb8, 0x85, 0xe0, 0x86, 0x08,
+	0x86, 0x28, 0x86, 0x54, 0x86, 0x74, 0x86, 0xb8, 0x86, 0xf8, 0x87, 0x34, 0x87, 0x64, 0x87, 0xc0,
+	0x87, 0xde, 0x88, 0x1c, 0x88, 0x7a, 0x88, 0x94, 0x88, 0xf2, 0x89, 0x4c, 0x89, 0xa2, 0x8a, 0x16,
+	0x8a, 0x7c, 0x8a, 0xf4, 0x8b, 0x68, 0x8b, 0xaa, 0x8c, 0x0e, 0x8c, 0x3c, 0x8c, 0x78, 0x8c, 0xba,
+	0x8c, 0xf6, 0x8d, 0x32, 0x8d, 0x9e, 0x8d, 0xd8, 0x8e, 0x12, 0x8e, 0x3c, 0x8e, 0x82, 0x8e, 0xb8,
+	0x8f, 0x06, 0x8f, 0x5e, 0x8f, 0x8e, 0x8f, 0xd8, 0x90, 0x1e, 0x90, 0x4e, 0x90, 0x98, 0x91, 0x04,
+	0x91, 0x44, 0x91, 0xb6, 0x92, 0x12, 0x92, 0x6c, 0x92, 0xd6, 0x93, 0x98, 0x93, 0xfc, 0x94, 0x78,
+	0x94, 0xb6, 0x95, 0x32, 0x95, 0xd4, 0x96, 0x36, 0x96, 0x78, 0x96, 0xf2, 0x97, 0x40, 0x97, 0xdc,
+	0x98, 0x3a, 0x98, 0x82, 0x98, 0xae, 0x98, 0xf6, 0x99, 0x46, 0x99, 0xac, 0x99, 0xf6, 0x9a, 0xc4,
+	0x9b, 0x04, 0x9b, 0x4e, 0x9b, 0xaa, 0x9c, 0x3a, 0x9c, 0x9c, 0x9c, 0xde, 0x9d, 0x12, 0x9d, 0xa4,
+	0x9d, 0xe4, 0x9e, 0x50, 0x9e, 0xca, 0x9f, 0x24, 0x9f, 0x86, 0x9f, 0xd8, 0xa0, 0x1e, 0xa0, 0xaa,
+	0xa1, 0x00, 0xa1, 0x62, 0xa1, 0xe2, 0xa2, 0x22, 0xa2, 0x8c, 0xa2, 0xe6, 0xa3, 0x20, 0xa3, 0x7a,
+	0xa3, 0xba, 0xa4, 0x30, 0xa4, 0x94, 0xa4, 0xf0, 0xa5, 0x80, 0xa5, 0xca, 0xa6, 0x4a, 0xa6, 0x88,
+	0xa6, 0xca, 0xa7, 0x20, 0xa7, 0x84, 0xa7, 0xac, 0xa8, 0x0a, 0xa8, 0x4c, 0xa8, 0xf0, 0xa9, 0x58,
+	0xa9, 0x8e, 0xaa, 0x02, 0xaa, 0x78, 0xaa, 0xbe, 0xab, 0x04, 0xab, 0x40, 0xab, 0x98, 0xab, 0xc6,
+	0xac, 0x10, 0xac, 0x62, 0xac, 0x92, 0xac, 0xd2, 0xad, 0x3e, 0xad, 0x7e, 0xad, 0xc2, 0xae, 0x0a,
+	0xae, 0x42, 0xae, 0x8c, 0xae, 0xe6, 0xaf, 0x4a, 0xaf, 0x9c, 0xaf, 0xfa, 0xb0, 0x70, 0xb0, 0xd0,
+	0xb1, 0x4e, 0xb1, 0xa4, 0xb2, 0x06, 0xb2, 0x34, 0xb2, 0xa2, 0xb2, 0xec, 0xb3, 0x92, 0xb3, 0xe6,
+	0xb4, 0x1c, 0xb4, 0x90, 0xb5, 0x02, 0xb5, 0x46, 0xb5, 0x8e, 0xb5, 0xca, 0xb6, 0x0a, 0xb6, 0x3c,
+	0xb6, 0x96, 0xb6, 0xd2, 0xb7, 0x04, 0xb7, 0x38, 0xb7, 0xe2, 0xb8, 0x22, 0xb8, 0x72, 0xb8, 0xba,
+	0xb8, 0xf4, 0xb9, 0x4e, 0xb9, 0xa6, 0xba, 0x02, 0xba, 0x52, 0xba, 0xa2, 0xbb, 0x32, 0xbb, 0x8e,
+	0xbb, 0xe6, 0xbc, 0x66, 0xbc, 0xda, 0xbd, 0x1e, 0xbd, 0x6e, 0xbd, 0xb0, 0xbd, 0xe8, 0xbe, 0x2e,
+	0xbe, 0x7a, 0xbe, 0xec, 0xbf, 0x4c, 0xbf, 0xa4, 0xc0, 0x2e, 0xc0, 0x78, 0xc0, 0xde, 0xc1, 0x2c,
+	0xc1, 0x60, 0xc1, 0xa6, 0xc1, 0xfc, 0xc2, 0x62, 0xc2, 0xbc, 0xc3, 0x26, 0xc3, 0x88, 0xc3, 0xe8,
+	0xc4, 0x32, 0xc4, 0x76, 0xc4, 0x92, 0xc4, 0xae, 0xc4, 0xca, 0xc4, 0xf8, 0xc5, 0x1a, 0xc5, 0x3a,
+	0xc5, 0x64, 0xc5, 0x84, 0xc5, 0xb8, 0xc5, 0xe8, 0xc6, 0x24, 0xc6, 0x68, 0xc6, 0xbc, 0xc6, 0xe0,
+	0xc7, 0x22, 0xc8, 0x08, 0xc8, 0x24, 0xc8, 0x4c, 0xc8, 0x64, 0xc8, 0x7c, 0xc8, 0xd8, 0xc8, 0xf8,
+	0xc9, 0x1e, 0xc9, 0x62, 0xc9, 0x98, 0xc9, 0xe2, 0xca, 0x2e, 0xca, 0x5a, 0xca, 0xae, 0xca, 0xfa,
+	0xcb, 0x28, 0xcb, 0x44, 0xcb, 0x70, 0xcb, 0x8e, 0xcb, 0xac, 0xcb, 0xf0, 0xcc, 0x34, 0xcc, 0x5a,
+	0xcc, 0x9e, 0xcc, 0xee, 0xcd, 0x24, 0xcd, 0x6e, 0xcd, 0xb8, 0xcd, 0xe4, 0xce, 0x38, 0xce, 0x84,
+	0xce, 0xae, 0xce, 0xca, 0xce, 0xf6, 0xcf, 0x14, 0xcf, 0x32, 0xcf, 0x76, 0xcf, 0xf4, 0xd0, 0x6a,
+	0xd1, 0x60, 0xd1, 0xe0, 0xd2, 0x54, 0xd2, 0xc6, 0xd3, 0x30, 0xd3, 0x7c, 0xd3, 0xca, 0xd4, 0x32,
+	0xd4, 0xd4, 0xd5, 0xe8, 0xd6, 0xfa, 0xd7, 0xd0, 0xd7, 0xf4, 0xd8, 0x12, 0xd8, 0x38, 0xd8, 0x56,
+	0xd8, 0x86, 0xd8, 0xa6, 0xd8, 0xdc, 0xd9, 0x2e, 0xd9, 0x5e, 0xd9, 0x8c, 0xd9, 0xc4, 0xd9, 0xe0,
+	0xd9, 0xfc, 0xda, 0x1e, 0xda, 0x44, 0xdb, 0x6c, 0xdb, 0x8e, 0xdb, 0xc4, 0xdb, 0xfa, 0xdc, 0x94,
+	0xdd, 0x04, 0xdd, 0x62, 0xdd, 0x9c, 0xdd, 0xca, 0xdd, 0xf8, 0xde, 0x28, 0xde, 0x48, 0xde, 0x94,
+	0xde, 0xe0, 0xde, 0xfc, 0xdf, 0x12, 0xdf, 0x32, 0xdf, 0x54, 0xdf, 0x74, 0xdf, 0x96, 0xdf, 0xbc,
+	0xdf, 0xe4, 0xe0, 0x0a, 0xe0, 0x30, 0xe0, 0x60, 0xe0, 0x8c, 0xe0, 0xb2, 0xe0, 0xe0, 0xe1, 0x0a,
+	0xe1, 0x3e, 0xe1, 0x6a, 0xe1, 0x94, 0xe1, 0xca, 0xe1, 0xf4, 0xe2, 0x1c, 0xe2, 0x4c, 0xe2, 0x78,
+	0xe2, 0xa0, 0xe2, 0xd6, 0xe3, 0x06, 0xe3, 0x3c, 0xe3, 0x76, 0xe3, 0xa8, 0xe3, 0xdc, 0xe4, 0x1e,
+	0xe4, 0x54, 0xe4, 0x80, 0xe4, 0xc0, 0xe4, 0xf4, 0xe5, 0x22, 0xe5, 0x62, 0xe5, 0xa2, 0xe5, 0xe2,
+	0xe6, 0x36, 0xe6, 0x50, 0xe6, 0x66, 0xe6, 0x7c, 0xe6, 0x92, 0xe6, 0xaa, 0xe7, 0x9a, 0xe8, 0x76,
+	0xe8, 0xf4, 0xe9, 0x0c, 0xe9, 0x36, 0xe9, 0x54, 0xe9, 0x7e, 0xe9, 0x9a, 0xe9, 0xb2, 0xe9, 0xc4,
+	0xe9, 0xde, 0xe9, 0xf0, 0xea, 0x0e, 0xea, 0x50, 0xea, 0x76, 0xea, 0xac, 0xea, 0xfa, 0xeb, 0x3a,
+	0xeb, 0xd6, 0xec, 0x54, 0xec, 0xd2, 0xed, 0x3a, 0xed, 0x86, 0xed, 0xc0, 0xee, 0x0a, 0xee, 0x3c,
+	0xee, 0x58, 0xee, 0xa0, 0xee, 0xe4, 0xf4, 0xe0, 0xf5, 0x4c, 0xf5, 0xe2, 0xf6, 0x36, 0xf6, 0x80,
+	0xf6, 0xbb, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x02, 0xc8, 0x01, 0x3d, 0x00, 0x24, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x02, 0x00, 0xd8, 0x01, 0x5c, 0x00, 0x8d, 0x00, 0x00, 0x01, 0xf4, 0x0e, 0x0c,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x19, 0x01, 0x32, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x41, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02,
+	0x00, 0x41, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x07, 0x00, 0x43, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x24, 0x00, 0x4a, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x04, 0x00, 0x0a, 0x00, 0x6e, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x00, 0x23,
+	0x00, 0x78, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06, 0x00, 0x09, 0x00, 0x9b, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x08, 0x00, 0x15, 0x00, 0xa4, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x09, 0x00, 0x1f, 0x00, 0xb9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0a, 0x01, 0x42,
+	0x00, 0xd8, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0c, 0x00, 0x0f, 0x02, 0x1a, 0x00, 0x01,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x0d, 0x06, 0x82, 0x02, 0x29, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x12, 0x00, 0x0a, 0x08, 0xab, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x00, 0x00, 0x82,
+	0x08, 0xb5, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x01, 0x00, 0x04, 0x09, 0x37, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x02, 0x00, 0x0e, 0x09, 0x3b, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x03, 0x00, 0x48, 0x09, 0x49, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x04, 0x00, 0x14,
+	0x09, 0x91, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x05, 0x00, 0x46, 0x09, 0xa5, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x06, 0x00, 0x12, 0x09, 0xeb, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x08, 0x00, 0x2a, 0x09, 0xfd, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x09, 0x00, 0x3e,
+	0x0a, 0x27, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09, 0x00, 0x0a, 0x02, 0x84, 0x0a, 0x65, 0x00, 0x03,
+	0x00, 0x01, 0x04, 0x09, 0x00, 0x0c, 0x00, 0x1e, 0x0c, 0xe9, 0x00, 0x03, 0x00, 0x01, 0x04, 0x09,
+	0x00, 0x0d, 0x0d, 0x04, 0x0d, 0x07, 0x43, 0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20,
+	0x28, 0x63, 0x29, 0x20, 0x32, 0x30, 0x31, 0x36, 0x20, 0x62, 0x79, 0x20, 0x42, 0x69, 0x67, 0x65,
+	0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x49, 0x6e, 0x63,
+	0x2e, 0x2e, 0x20, 0x41, 0x6c, 0x6c, 0x20, 0x72, 0x69, 0x67, 0x68, 0x74, 0x73, 0x20, 0x72, 0x65,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x2e, 0x47, 0x6f, 0x52, 0x65, 0x67, 0x75, 0x6c, 0x61, 0x72,
+	0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x26, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x49, 0x6e,
+	0x63, 0x2e, 0x3a, 0x20, 0x47, 0x6f, 0x20, 0x52, 0x65, 0x67, 0x75, 0x6c, 0x61, 0x72, 0x3a, 0x20,
+	0x32, 0x30, 0x31, 0x36, 0x47, 0x6f, 0x20, 0x52, 0x65, 0x67, 0x75, 0x6c, 0x61, 0x72, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x20, 0x32, 0x2e, 0x30, 0x31, 0x30, 0x3b, 0x20, 0x74, 0x74, 0x66,
+	0x61, 0x75, 0x74, 0x6f, 0x68, 0x69, 0x6e, 0x74, 0x20, 0x28, 0x76, 0x31, 0x2e, 0x38, 0x2e, 0x33,
+	0x29, 0x47, 0x6f, 0x52, 0x65, 0x67, 0x75, 0x6c, 0x61, 0x72, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f,
+	0x77, 0x20, 0x26, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x4b,
+	0x72, 0x69, 0x73, 0x20, 0x48, 0x6f, 0x6c, 0x6d, 0x65, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x43,
+	0x68, 0x61, 0x72, 0x6c, 0x65, 0x73, 0x20, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x47, 0x6f,
+	0x20, 0x69, 0x73, 0x20, 0x61, 0x20, 0x68, 0x75, 0x6d, 0x61, 0x6e, 0x69, 0x73, 0x74, 0x69, 0x63,
+	0x20, 0x73, 0x61, 0x6e, 0x73, 0x2d, 0x73, 0x65, 0x72, 0x69, 0x66, 0x20, 0x66, 0x6f, 0x6e, 0x74,
+	0x20, 0x66, 0x6f, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x47, 0x6f, 0x20, 0x6c, 0x61, 0x6e, 0x67,
+	0x75, 0x61, 0x67, 0x65, 0x2e, 0x20, 0x49, 0x74, 0x73, 0x20, 0x78, 0x2d, 0x68, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x2c, 0x20, 0x73, 0x74, 0x65, 0x6d, 0x20, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x2c,
+	0x20, 0x61, 0x6e, 0x64, 0x20, 0x64, 0x69, 0x73, 0x74, 0x69, 0x6e, 0x63, 0x74, 0x69, 0x76, 0x65,
+	0x20, 0x66, 0x6f, 0x72, 0x6d, 0x73, 0x20, 0x6f, 0x66, 0x20, 0x7a, 0x65, 0x72, 0x6f, 0x2c, 0x20,
+	0x63, 0x61, 0x70, 0x69, 0x74, 0x61, 0x6c, 0x20, 0x4f, 0x2c, 0x20, 0x6c, 0x6f, 0x77, 0x65, 0x72,
+	0x63, 0x61, 0x73, 0x65, 0x20, 0x6c, 0x2c, 0x20, 0x66, 0x69, 0x67, 0x75, 0x72, 0x65, 0x20, 0x6f,
+	0x6e, 0x65, 0x2c, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x63, 0x61, 0x70, 0x69, 0x74, 0x61, 0x6c, 0x20,
+	0x49, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x20, 0x74, 0x68, 0x65, 0x20, 0x44, 0x49, 0x4e,
+	0x20, 0x31, 0x34, 0x35, 0x30, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x6c, 0x65, 0x67, 0x69, 0x62,
+	0x69, 0x6c, 0x69, 0x74, 0x79, 0x20, 0x73, 0x74, 0x61, 0x6e, 0x64, 0x61, 0x72, 0x64, 0x2e, 0x20,
+	0x47, 0x6f, 0x27, 0x73, 0x20, 0x57, 0x47, 0x4c, 0x20, 0x63, 0x68, 0x61, 0x72, 0x61, 0x63, 0x74,
+	0x65, 0x72, 0x20, 0x73, 0x65, 0x74, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x20,
+	0x55, 0x6e, 0x69, 0x63, 0x6f, 0x64, 0x65, 0x20, 0x4c, 0x61, 0x74, 0x69, 0x6e, 0x2c, 0x20, 0x47,
+	0x72, 0x65, 0x65, 0x6b, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x43, 0x79, 0x72, 0x69, 0x6c, 0x6c, 0x69,
+	0x63, 0x20, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x62, 0x65, 0x74, 0x73, 0x20, 0x70, 0x6c, 0x75, 0x73,
+	0x20, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x67, 0x72, 0x61,
+	0x70, 0x68, 0x69, 0x63, 0x61, 0x6c, 0x20, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x2e,
+	0x6c, 0x75, 0x63, 0x69, 0x64, 0x61, 0x66, 0x6f, 0x6e, 0x74, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x43,
+	0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x28, 0x63, 0x29, 0x20, 0x32, 0x30, 0x31,
+	0x36, 0x20, 0x42, 0x69, 0x67, 0x65, 0x6c, 0x6f, 0x77, 0x20, 0x26, 0x20, 0x48, 0x6f, 0x6c, 0x6d,
+	0x65, 0x73, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x2e, 0x20, 0x41, 0x6c, 0x6c, 0x20, 0x72, 0x69, 0x67,
+	0x68, 0x74, 0x73, 0x20, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x2e, 0x0a, 0x0a, 0x44,
+	0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x6f, 0x66, 0x20, 0x74,
+	0x68, 0x69, 0x73, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x20, 0x69, 0x73, 0x20, 0x67, 0x6f, 0x76, 0x65,
+	0x72, 0x6e, 0x65, 0x64, 0x20, 0x62, 0x79, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c,
+	0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x2e, 0x20, 0x49,
+	0x66, 0x20, 0x79, 0x6f, 0x75, 0x20, 0x64, 0x6f, 0x20, 0x6e, 0x6f, 0x74, 0x20, 0x61, 0x67, 0x72,
+	0x65, 0x65, 0x20, 0x74, 0x6f, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x63, 0x65, 0x6e,
+	0x73, 0x65, 0x2c, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x69, 0x6e, 0x67, 0x20, 0x74, 0x68,
+	0x65, 0x20, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72, 0x2c, 0x20, 0x64, 0x6f,
+	0x20, 0x6e, 0x6f, 0x74, 0x20, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x20,
+	0x6f, 0x72, 0x20, 0x6d, 0x6f, 0x64, 0x69, 0x66, 0x79, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x66,
+	0x6f, 0x6e, 0x74, 0x2e, 0x0a, 0x0a, 0x52, 0x65, 0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75,
+	0x74, 0x69, 0x6f, 0x6e, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x75, 0x73, 0x65, 0x20, 0x69, 0x6e, 0x20,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x62, 0x69, 0x6e, 0x61, 0x72,
+	0x79, 0x20, 0x66, 0x6f, 0x72, 0x6d, 0x73, 0x2c, 0x20, 0x77, 0x69, 0x74, 0x68, 0x20, 0x6f, 0x72,
+	0x20, 0x77, 0x69, 0x74, 0x68, 0x6f, 0x75, 0x74, 0x20, 0x6d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2c, 0x20, 0x61, 0x72, 0x65, 0x20, 0x70, 0x65, 0x72, 0x6d, 0x69,
+	0x74, 0x74, 0x65, 0x64, 0x20, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x64, 0x20, 0x74, 0x68,
+	0x61, 0x74, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67,
+	0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x61, 0x72, 0x65, 0x20,
+	0x6d, 0x65, 0x74, 0x3a, 0x0a, 0x0a, 0x20, 0x20, 0x20, 0x2a, 0x20, 0x52, 0x65, 0x64, 0x69, 0x73,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x6f, 0x66, 0x20, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x20, 0x63, 0x6f, 0x64, 0x65, 0x20, 0x6d, 0x75, 0x73, 0x74, 0x20, 0x72,
+	0x65, 0x74, 0x61, 0x69, 0x6e, 0x20, 0x74, 0x68, 0x65, 0x20, 0x61, 0x62, 0x6f, 0x76, 0x65, 0x20,
+	0x63, 0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65,
+	0x2c, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x73, 0x74, 0x20, 0x6f, 0x66, 0x20, 0x63,
+	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x61, 0x6e, 0x64, 0x20, 0x74, 0x68,
+	0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e, 0x67, 0x20, 0x64, 0x69, 0x73, 0x63,
+	0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72, 0x2e, 0x0a, 0x0a, 0x20, 0x20, 0x20, 0x2a, 0x20, 0x52, 0x65,
+	0x64, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20, 0x69, 0x6e,
+	0x20, 0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x20, 0x66, 0x6f, 0x72, 0x6d, 0x20, 0x6d, 0x75, 0x73,
+	0x74, 0x20, 0x72, 0x65, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x65, 0x20, 0x74, 0x68, 0x65, 0x20,
+	0x61, 0x62, 0x6f, 0x76, 0x65, 0x20, 0x63, 0x6f, 0x70, 0x79, 0x72, 0x69, 0x67, 0x68, 0x74, 0x20,
+	0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x2c, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x73,
+	0x74, 0x20, 0x6f, 0x66, 0x20, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x20,
+	0x61, 0x6e, 0x64, 0x20, 0x74, 0x68, 0x65, 0x20, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x69, 0x6e,
+	0x67, 0x20, 0x64, 0x69, 0x73, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x72, 0x20, 0x69, 0x6e, 0x20,
+	0x74, 0x68, 0x65, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x20, 0x61, 0x6e, 0x64, 0x2f, 0x6f, 0x72, 0x20, 0x6f, 0x74, 0x68, 0x65, 0x72, 0x20, 0x6d,
+	0x61, 0x74, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x73, 0x20, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65,
+	0x64, 0x20, 0x77, 0x69, 0x74, 0x68, 0x20, 0x74, 0x68, 0x65, 0x20, 0x64, 0x69, 0x73, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x0a, 0x0a, 0x20, 0x20, 0x20, 0x2a, 0x20, 0x4e,
+	0x65, 0x69, 0x74, 0x68, 0x65, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x6e, 0x61, 0x6d, 0x65, 0x20,
+	0x6f, 0x66, 0x20, 0x47, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x20, 0x49, 0x6e, 0x63, 0x2e, 0x20, 0x6e,
+	0x6f, 0x72, 0x20, 0x74, 0x68, 0x65, 0x20, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x20, 0x6f, 0x66, 0x20,
+	0x69, 0x74, 0x73, 0x20, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x6f, 0x72, 0x73,
+	0x20, 0x6d, 0x61, 0x79, 0x20, 0x62, 0x65, 0x20, 0x75, 0x73, 0x65, 0x64, 0x20, 0x74, 0x6f, 0x20,
+	0x65, 0x6e, 0x64, 0x6f, 0x72, 0x73, 0x65, 0x20, 0x6f, 0x72, 0x20, 0x70, 0x72, 0x6f, 0x6d, 0x6f,
+	0x74, 0x65, 0x20, 0x70, 0x72, 0x6f, 0x64, 0x75, 0x63, 0x74, 0x73, 0x20, 0x64, 0x65, 0x72, 0x69,
+	0x76, 0x65, 0x64, 0x20, 0x66, 0x72, 0x6f, 0x6d, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x73, 0x6f,
+	0x66, 0x74, 0x77, 0x61, 0x72, 0x65, 0x20, 0x77, 0x69, 0x74, 0x68, 0x6f, 0x75, 0x74, 0x20, 0x73,
+	0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x63, 0x20, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x20, 0x77, 0x72,
+	0x69, 0x74, 0x74, 0x65, 0x6e, 0x20, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x2e, 0x0a, 0x0a, 0x44, 0x49, 0x53, 0x43, 0x4c, 0x41, 0x49, 0x4d, 0x45, 0x52, 0x3a, 0x20, 0x54,
+	0x48, 0x49, 0x53, 0x20, 0x53, 0x4f, 0x46, 0x54, 0x57, 0x41, 0x52, 0x45, 0x20, 0x49, 0x53, 0x20,
+	0x50, 0x52, 0x4f, 0x56, 0x49, 0x44, 0x45, 0x44, 0x20, 0x42, 0x59, 0x20, 0x54, 0x48, 0x45, 0x20,
+	0x43, 0x4f, 0x50, 0x59, 0x52, 0x49, 0x47, 0x48, 0x54, 0x20, 0x48, 0x4f, 0x4c, 0x44, 0x45, 0x52,
+	0x53, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x4f,
+	0x52, 0x53, 0x20, 0x22, 0x41, 0x53, 0x20, 0x49, 0x53, 0x22, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x41,
+	0x4e, 0x59, 0x20, 0x45, 0x58, 0x50, 0x52, 0x45, 0x53, 0x53, 0x20, 0x4f, 0x52, 0x20, 0x49, 0x4d,
+	0x50, 0x4c, 0x49, 0x45, 0x44, 0x20, 0x57, 0x41, 0x52, 0x52, 0x41, 0x4e, 0x54, 0x49, 0x45, 0x53,
+	0x2c, 0x20, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e, 0x47, 0x2c, 0x20, 0x42, 0x55, 0x54,
+	0x20, 0x4e, 0x4f, 0x54, 0x20, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x45, 0x44, 0x20, 0x54, 0x4f, 0x2c,
+	0x20, 0x54, 0x48, 0x45, 0x20, 0x49, 0x4d, 0x50, 0x4c, 0x49, 0x45, 0x44, 0x20, 0x57, 0x41, 0x52,
+	0x52, 0x41, 0x4e, 0x54, 0x49, 0x45, 0x53, 0x20, 0x4f, 0x46, 0x20, 0x4d, 0x45, 0x52, 0x43, 0x48,
+	0x41, 0x4e, 0x54, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x46,
+	0x49, 0x54, 0x4e, 0x45, 0x53, 0x53, 0x20, 0x46, 0x4f, 0x52, 0x20, 0x41, 0x20, 0x50, 0x41, 0x52,
+	0x54, 0x49, 0x43, 0x55, 0x4c, 0x41, 0x52, 0x20, 0x50, 0x55, 0x52, 0x50, 0x4f, 0x53, 0x45, 0x20,
+	0x41, 0x52, 0x45, 0x20, 0x44, 0x49, 0x53, 0x43, 0x4c, 0x41, 0x49, 0x4d, 0x45, 0x44, 0x2e, 0x20,
+	0x49, 0x4e, 0x20, 0x4e, 0x4f, 0x20, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x20, 0x53, 0x48, 0x41, 0x4c,
+	0x4c, 0x20, 0x54, 0x48, 0x45, 0x20, 0x43, 0x4f, 0x50, 0x59, 0x52, 0x49, 0x47, 0x48, 0x54, 0x20,
+	0x4f, 0x57, 0x4e, 0x45, 0x52, 0x20, 0x4f, 0x52, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52, 0x49, 0x42,
+	0x55, 0x54, 0x4f, 0x52, 0x53, 0x20, 0x42, 0x45, 0x20, 0x4c, 0x49, 0x41, 0x42, 0x4c, 0x45, 0x20,
+	0x46, 0x4f, 0x52, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x2c, 0x20,
+	0x49, 0x4e, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x2c, 0x20, 0x49, 0x4e, 0x43, 0x49, 0x44, 0x45,
+	0x4e, 0x54, 0x41, 0x4c, 0x2c, 0x20, 0x53, 0x50, 0x45, 0x43, 0x49, 0x41, 0x4c, 0x2c, 0x20, 0x45,
+	0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x59, 0x2c, 0x20, 0x4f, 0x52, 0x20, 0x43, 0x4f, 0x4e,
+	0x53, 0x45, 0x51, 0x55, 0x45, 0x4e, 0x54, 0x49, 0x41, 0x4c, 0x20, 0x44, 0x41, 0x4d, 0x41, 0x47,
+	0x45, 0x53, 0x20, 0x28, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e, 0x47, 0x2c, 0x20, 0x42,
+	0x55, 0x54, 0x20, 0x4e, 0x4f, 0x54, 0x20, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x45, 0x44, 0x20, 0x54,
+	0x4f, 0x2c, 0x20, 0x50, 0x52, 0x4f, 0x43, 0x55, 0x52, 0x45, 0x4d, 0x45, 0x4e, 0x54, 0x20, 0x4f,
+	0x46, 0x20, 0x53, 0x55, 0x42, 0x53, 0x54, 0x49, 0x54, 0x55, 0x54, 0x45, 0x20, 0x47, 0x4f, 0x4f,
+	0x44, 0x53, 0x20, 0x4f, 0x52, 0x20, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43, 0x45, 0x53, 0x3b, 0x20,
+	0x4c, 0x4f, 0x53, 0x53, 0x20, 0x4f, 0x46, 0x20, 0x55, 0x53, 0x45, 0x2c, 0x20, 0x44, 0x41, 0x54,
+	0x41, 0x2c, 0x20, 0x4f, 0x52, 0x20, 0x50, 0x52, 0x4f, 0x46, 0x49, 0x54, 0x53, 0x3b, 0x20, 0x4f,
+	0x52, 0x20, 0x42, 0x55, 0x53, 0x49, 0x4e, 0x45, 0x53, 0x53, 0x20, 0x49, 0x4e, 0x54, 0x45, 0x52,
+	0x52, 0x55, 0x50, 0x54, 0x49, 0x4f, 0x4e, 0x29, 0x20, 0x48, 0x4f, 0x57, 0x45, 0x56, 0x45, 0x52,
+	0x20, 0x43, 0x41, 0x55, 0x53, 0x45, 0x44, 0x20, 0x41, 0x4e, 0x44, 0x20, 0x4f, 0x4e, 0x20, 0x41,
+	0x4e, 0x59, 0x20, 0x54, 0x48, 0x45, 0x4f, 0x52, 0x59, 0x20, 0x4f, 0x46, 0x20, 0x4c, 0x49, 0x41,
+	0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x2c, 0x20, 0x57, 0x48, 0x45, 0x54, 0x48, 0x45, 0x52, 0x20,
+	0x49, 0x4e, 0x20, 0x43, 0x4f, 0x4e, 0x54, 0x52, 0x41, 0x43, 0x54, 0x2c, 0x20, 0x53, 0x54, 0x52,
+	0x49, 0x43, 0x54, 0x20, 0x4c, 0x49, 0x41, 0x42, 0x49, 0x4c, 0x49, 0x54, 0x59, 0x2c, 0x20, 0x4f,
+	0x52, 0x20, 0x54, 0x4f, 0x52, 0x54, 0x20, 0x28, 0x49, 0x4e, 0x43, 0x4c, 0x55, 0x44, 0x49, 0x4e,
+	0x47, 0x20, 0x4e, 0x45, 0x47, 0x4c, 0x49, 0x47, 0x45, 0x4e, 0x43, 0x45, 0x20, 0x4f, 0x52, 0x20,
+	0x4f, 0x54, 0x48, 0x45, 0x52, 0x57, 0x49, 0x53, 0x45, 0x29, 0x20, 0x41, 0x52, 0x49, 0x53, 0x49,
+	0x4e, 0x47, 0x20, 0x49, 0x4e, 0x20, 0x41, 0x4e, 0x59, 0x20, 0x57, 0x41, 0x59, 0x20, 0x4f, 0x55,
+	0x54, 0x20, 0x4f, 0x46, 0x20, 0x54, 0x48, 0x45, 0x20, 0x55, 0x53, 0x45, 0x20, 0x4f, 0x46, 0x20,
+	0x54, 0x48, 0x49, 0x53, 0x20, 0x53, 0x4f, 0x46, 0x54, 0x57, 0x41, 0x52, 0x45, 0x2c, 0x20, 0x45,
+	0x56, 0x45, 0x4e, 0x20, 0x49, 0x46, 0x20, 0x41, 0x44, 0x56, 0x49, 0x53, 0x45, 0x44, 0x20, 0x4f,
+	0x46, 0x20, 0x54, 0x48, 0x45, 0x20, 0x50, 0x4f, 0x53, 0x53, 0x49, 0x42, 0x49, 0x4c, 0x49, 0x54,
+	0x59, 0x20, 0x4f, 0x46, 0x20, 0x53, 0x55, 0x43, 0x48, 0x20, 0x44, 0x41, 0x4d, 0x41, 0x47, 0x45,
+	0x2e, 0x47, 0x6f, 0x20, 0x52, 0x65, 0x67, 0x75, 0x6c, 0x61, 0x72, 0x00, 0x43, 0x00, 0x6f, 0x00,
+	0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00,
+	0x28, 0x00, 0x63, 0x00, 0x29, 0x00, 0x20, 0x00, 0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x36, 0x00,
+	0x20, 0x00, 0x62, 0x00, 0x79, 0x00, 0x20, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00,
+	0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x20, 0x00, 0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00,
+	0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00,
+	0x2e, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00,
+	0x73, 0x00, 0x65, 0x00, 0x72, 0x00, 0x76, 0x00, 0x65, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x47, 0x00,
+	0x6f, 0x00, 0x52, 0x00, 0x65, 0x00, 0x67, 0x00, 0x75, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x72, 0x00,
+	0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x26, 0x00,
+	0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x49, 0x00, 0x6e, 0x00,
+	0x63, 0x00, 0x2e, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x52, 0x00,
+	0x65, 0x00, 0x67, 0x00, 0x75, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x72, 0x00, 0x3a, 0x00, 0x20, 0x00,
+	0x32, 0x00, 0x30, 0x00, 0x31, 0x00, 0x36, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x52, 0x00,
+	0x65, 0x00, 0x67, 0x00, 0x75, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x72, 0x00, 0x56, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x73, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x32, 0x00, 0x2e, 0x00,
+	0x30, 0x00, 0x31, 0x00, 0x30, 0x00, 0x3b, 0x00, 0x20, 0x00, 0x74, 0x00, 0x74, 0x00, 0x66, 0x00,
+	0x61, 0x00, 0x75, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x68, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x28, 0x00, 0x76, 0x00, 0x31, 0x00, 0x2e, 0x00, 0x38, 0x00, 0x2e, 0x00, 0x33, 0x00,
+	0x29, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x52, 0x00, 0x65, 0x00, 0x67, 0x00, 0x75, 0x00, 0x6c, 0x00,
+	0x61, 0x00, 0x72, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00,
+	0x77, 0x00, 0x20, 0x00, 0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00,
+	0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x4b, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00,
+	0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x43, 0x00,
+	0x68, 0x00, 0x61, 0x00, 0x72, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x42, 0x00,
+	0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x47, 0x00, 0x6f, 0x00,
+	0x20, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x20, 0x00, 0x68, 0x00, 0x75, 0x00,
+	0x6d, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x69, 0x00, 0x63, 0x00,
+	0x20, 0x00, 0x73, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x2d, 0x00, 0x73, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x66, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x67, 0x00,
+	0x75, 0x00, 0x61, 0x00, 0x67, 0x00, 0x65, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x74, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x78, 0x00, 0x2d, 0x00, 0x68, 0x00, 0x65, 0x00, 0x69, 0x00, 0x67, 0x00,
+	0x68, 0x00, 0x74, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x73, 0x00, 0x74, 0x00, 0x65, 0x00, 0x6d, 0x00,
+	0x20, 0x00, 0x77, 0x00, 0x65, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x74, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x74, 0x00, 0x69, 0x00, 0x76, 0x00, 0x65, 0x00,
+	0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00,
+	0x66, 0x00, 0x20, 0x00, 0x7a, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x63, 0x00, 0x61, 0x00, 0x70, 0x00, 0x69, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x20, 0x00,
+	0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x65, 0x00, 0x72, 0x00,
+	0x63, 0x00, 0x61, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x69, 0x00, 0x67, 0x00, 0x75, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00,
+	0x63, 0x00, 0x61, 0x00, 0x70, 0x00, 0x69, 0x00, 0x74, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00,
+	0x20, 0x00, 0x31, 0x00, 0x34, 0x00, 0x35, 0x00, 0x30, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x65, 0x00, 0x67, 0x00, 0x69, 0x00, 0x62, 0x00,
+	0x69, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x74, 0x00, 0x79, 0x00, 0x20, 0x00, 0x73, 0x00, 0x74, 0x00,
+	0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x61, 0x00, 0x72, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x20, 0x00,
+	0x47, 0x00, 0x6f, 0x00, 0x27, 0x00, 0x73, 0x00, 0x20, 0x00, 0x57, 0x00, 0x47, 0x00, 0x4c, 0x00,
+	0x20, 0x00, 0x63, 0x00, 0x68, 0x00, 0x61, 0x00, 0x72, 0x00, 0x61, 0x00, 0x63, 0x00, 0x74, 0x00,
+	0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x73, 0x00, 0x65, 0x00, 0x74, 0x00, 0x20, 0x00, 0x69, 0x00,
+	0x6e, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x64, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x55, 0x00, 0x6e, 0x00, 0x69, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x4c, 0x00, 0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x47, 0x00,
+	0x72, 0x00, 0x65, 0x00, 0x65, 0x00, 0x6b, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x43, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x69, 0x00,
+	0x63, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x70, 0x00, 0x68, 0x00, 0x61, 0x00, 0x62, 0x00,
+	0x65, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x70, 0x00, 0x6c, 0x00, 0x75, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x73, 0x00, 0x79, 0x00, 0x6d, 0x00, 0x62, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x67, 0x00, 0x72, 0x00, 0x61, 0x00,
+	0x70, 0x00, 0x68, 0x00, 0x69, 0x00, 0x63, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x65, 0x00,
+	0x6c, 0x00, 0x65, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x73, 0x00, 0x2e, 0x00,
+	0x6c, 0x00, 0x75, 0x00, 0x63, 0x00, 0x69, 0x00, 0x64, 0x00, 0x61, 0x00, 0x66, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x74, 0x00, 0x73, 0x00, 0x2e, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x43, 0x00,
+	0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x28, 0x00, 0x63, 0x00, 0x29, 0x00, 0x20, 0x00, 0x32, 0x00, 0x30, 0x00, 0x31, 0x00,
+	0x36, 0x00, 0x20, 0x00, 0x42, 0x00, 0x69, 0x00, 0x67, 0x00, 0x65, 0x00, 0x6c, 0x00, 0x6f, 0x00,
+	0x77, 0x00, 0x20, 0x00, 0x26, 0x00, 0x20, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6d, 0x00,
+	0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x2e, 0x00,
+	0x20, 0x00, 0x41, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x20, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00,
+	0x68, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x73, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x76, 0x00, 0x65, 0x00, 0x64, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x44, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00,
+	0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x67, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x6e, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x62, 0x00, 0x79, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00,
+	0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00,
+	0x63, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x65, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x66, 0x00, 0x20, 0x00, 0x79, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00,
+	0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x20, 0x00, 0x61, 0x00, 0x67, 0x00, 0x72, 0x00,
+	0x65, 0x00, 0x65, 0x00, 0x20, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x6e, 0x00,
+	0x73, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x6c, 0x00,
+	0x75, 0x00, 0x64, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x61, 0x00,
+	0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00,
+	0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x69, 0x00, 0x66, 0x00,
+	0x79, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x66, 0x00,
+	0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x52, 0x00, 0x65, 0x00,
+	0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00,
+	0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00,
+	0x20, 0x00, 0x75, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00,
+	0x73, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x72, 0x00, 0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x61, 0x00,
+	0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x62, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x72, 0x00,
+	0x79, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x73, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x72, 0x00,
+	0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x74, 0x00,
+	0x20, 0x00, 0x6d, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x69, 0x00, 0x66, 0x00, 0x69, 0x00, 0x63, 0x00,
+	0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x61, 0x00,
+	0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x70, 0x00, 0x65, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x69, 0x00,
+	0x74, 0x00, 0x74, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00,
+	0x76, 0x00, 0x69, 0x00, 0x64, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x61, 0x00, 0x74, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x66, 0x00,
+	0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x67, 0x00,
+	0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00,
+	0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x6d, 0x00, 0x65, 0x00, 0x74, 0x00, 0x3a, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x20, 0x00, 0x20, 0x00,
+	0x20, 0x00, 0x2a, 0x00, 0x20, 0x00, 0x52, 0x00, 0x65, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x73, 0x00, 0x6f, 0x00,
+	0x75, 0x00, 0x72, 0x00, 0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x64, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x75, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x72, 0x00,
+	0x65, 0x00, 0x74, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x61, 0x00, 0x62, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x63, 0x00, 0x6f, 0x00, 0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00,
+	0x74, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00,
+	0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x63, 0x00,
+	0x6f, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x73, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00,
+	0x69, 0x00, 0x6e, 0x00, 0x67, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00,
+	0x6c, 0x00, 0x61, 0x00, 0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00, 0x2e, 0x00, 0x0a, 0x00,
+	0x0a, 0x00, 0x20, 0x00, 0x20, 0x00, 0x20, 0x00, 0x2a, 0x00, 0x20, 0x00, 0x52, 0x00, 0x65, 0x00,
+	0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00,
+	0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00,
+	0x20, 0x00, 0x62, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x72, 0x00, 0x79, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x6d, 0x00, 0x20, 0x00, 0x6d, 0x00, 0x75, 0x00, 0x73, 0x00,
+	0x74, 0x00, 0x20, 0x00, 0x72, 0x00, 0x65, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x64, 0x00,
+	0x75, 0x00, 0x63, 0x00, 0x65, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x61, 0x00, 0x62, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x65, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00,
+	0x70, 0x00, 0x79, 0x00, 0x72, 0x00, 0x69, 0x00, 0x67, 0x00, 0x68, 0x00, 0x74, 0x00, 0x20, 0x00,
+	0x6e, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x69, 0x00, 0x63, 0x00, 0x65, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6c, 0x00, 0x69, 0x00, 0x73, 0x00,
+	0x74, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x64, 0x00, 0x69, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x73, 0x00, 0x20, 0x00,
+	0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x66, 0x00, 0x6f, 0x00, 0x6c, 0x00, 0x6c, 0x00, 0x6f, 0x00, 0x77, 0x00, 0x69, 0x00, 0x6e, 0x00,
+	0x67, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x63, 0x00, 0x6c, 0x00, 0x61, 0x00,
+	0x69, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x69, 0x00, 0x6e, 0x00, 0x20, 0x00,
+	0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x63, 0x00, 0x75, 0x00,
+	0x6d, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x74, 0x00, 0x61, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00,
+	0x6e, 0x00, 0x20, 0x00, 0x61, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x2f, 0x00, 0x6f, 0x00, 0x72, 0x00,
+	0x20, 0x00, 0x6f, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x6d, 0x00,
+	0x61, 0x00, 0x74, 0x00, 0x65, 0x00, 0x72, 0x00, 0x69, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x76, 0x00, 0x69, 0x00, 0x64, 0x00, 0x65, 0x00,
+	0x64, 0x00, 0x20, 0x00, 0x77, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x64, 0x00, 0x69, 0x00, 0x73, 0x00, 0x74, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x2e, 0x00,
+	0x0a, 0x00, 0x0a, 0x00, 0x20, 0x00, 0x20, 0x00, 0x20, 0x00, 0x2a, 0x00, 0x20, 0x00, 0x4e, 0x00,
+	0x65, 0x00, 0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00,
+	0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6e, 0x00, 0x61, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x6f, 0x00, 0x66, 0x00, 0x20, 0x00, 0x47, 0x00, 0x6f, 0x00, 0x6f, 0x00, 0x67, 0x00, 0x6c, 0x00,
+	0x65, 0x00, 0x20, 0x00, 0x49, 0x00, 0x6e, 0x00, 0x63, 0x00, 0x2e, 0x00, 0x20, 0x00, 0x6e, 0x00,
+	0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x65, 0x00, 0x20, 0x00, 0x6e, 0x00,
+	0x61, 0x00, 0x6d, 0x00, 0x65, 0x00, 0x73, 0x00, 0x20, 0x00, 0x6f, 0x00, 0x66, 0x00, 0x20, 0x00,
+	0x69, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x63, 0x00, 0x6f, 0x00, 0x6e, 0x00, 0x74, 0x00,
+	0x72, 0x00, 0x69, 0x00, 0x62, 0x00, 0x75, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x73, 0x00,
+	0x20, 0x00, 0x6d, 0x00, 0x61, 0x00, 0x79, 0x00, 0x20, 0x00, 0x62, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x75, 0x00, 0x73, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x74, 0x00, 0x6f, 0x00, 0x20, 0x00,
+	0x65, 0x00, 0x6e, 0x00, 0x64, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x73, 0x00, 0x65, 0x00, 0x20, 0x00,
+	0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x6d, 0x00, 0x6f, 0x00,
+	0x74, 0x00, 0x65, 0x00, 0x20, 0x00, 0x70, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x64, 0x00, 0x75, 0x00,
+	0x63, 0x00, 0x74, 0x00, 0x73, 0x00, 0x20, 0x00, 0x64, 0x00, 0x65, 0x00, 0x72, 0x00, 0x69, 0x00,
+	0x76, 0x00, 0x65, 0x00, 0x64, 0x00, 0x20, 0x00, 0x66, 0x00, 0x72, 0x00, 0x6f, 0x00, 0x6d, 0x00,
+	0x20, 0x00, 0x74, 0x00, 0x68, 0x00, 0x69, 0x00, 0x73, 0x00, 0x20, 0x00, 0x73, 0x00, 0x6f, 0x00,
+	0x66, 0x00, 0x74, 0x00, 0x77, 0x00, 0x61, 0x00, 0x72, 0x00, 0x65, 0x00, 0x20, 0x00, 0x77, 0x00,
+	0x69, 0x00, 0x74, 0x00, 0x68, 0x00, 0x6f, 0x00, 0x75, 0x00, 0x74, 0x00, 0x20, 0x00, 0x73, 0x00,
+	0x70, 0x00, 0x65, 0x00, 0x63, 0x00, 0x69, 0x00, 0x66, 0x00, 0x69, 0x00, 0x63, 0x00, 0x20, 0x00,
+	0x70, 0x00, 0x72, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x72, 0x00, 0x20, 0x00, 0x77, 0x00, 0x72, 0x00,
+	0x69, 0x00, 0x74, 0x00, 0x74, 0x00, 0x65, 0x00, 0x6e, 0x00, 0x20, 0x00, 0x70, 0x00, 0x65, 0x00,
+	0x72, 0x00, 0x6d, 0x00, 0x69, 0x00, 0x73, 0x00, 0x73, 0x00, 0x69, 0x00, 0x6f, 0x00, 0x6e, 0x00,
+	0x2e, 0x00, 0x0a, 0x00, 0x0a, 0x00, 0x44, 0x00, 0x49, 0x00, 0x53, 0x00, 0x43, 0x00, 0x4c, 0x00,
+	0x41, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x52, 0x00, 0x3a, 0x00, 0x20, 0x00, 0x54, 0x00,
+	0x48, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00, 0x53, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x54, 0x00,
+	0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x20, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00,
+	0x50, 0x00, 0x52, 0x00, 0x4f, 0x00, 0x56, 0x00, 0x49, 0x00, 0x44, 0x00, 0x45, 0x00, 0x44, 0x00,
+	0x20, 0x00, 0x42, 0x00, 0x59, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00,
+	0x43, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x59, 0x00, 0x52, 0x00, 0x49, 0x00, 0x47, 0x00, 0x48, 0x00,
+	0x54, 0x00, 0x20, 0x00, 0x48, 0x00, 0x4f, 0x00, 0x4c, 0x00, 0x44, 0x00, 0x45, 0x00, 0x52, 0x00,
+	0x53, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00,
+	0x4e, 0x00, 0x54, 0x00, 0x52, 0x00, 0x49, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x53, 0x00, 0x20, 0x00, 0x22, 0x00, 0x41, 0x00, 0x53, 0x00, 0x20, 0x00, 0x49, 0x00,
+	0x53, 0x00, 0x22, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x45, 0x00, 0x58, 0x00, 0x50, 0x00, 0x52, 0x00, 0x45, 0x00,
+	0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4d, 0x00,
+	0x50, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x57, 0x00, 0x41, 0x00,
+	0x52, 0x00, 0x52, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00, 0x45, 0x00, 0x53, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x55, 0x00, 0x44, 0x00,
+	0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x42, 0x00, 0x55, 0x00, 0x54, 0x00,
+	0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x4d, 0x00,
+	0x49, 0x00, 0x54, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x2c, 0x00,
+	0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x50, 0x00,
+	0x4c, 0x00, 0x49, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00,
+	0x52, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00, 0x45, 0x00, 0x53, 0x00, 0x20, 0x00,
+	0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x52, 0x00, 0x43, 0x00, 0x48, 0x00,
+	0x41, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x41, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00,
+	0x54, 0x00, 0x59, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x46, 0x00,
+	0x49, 0x00, 0x54, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x46, 0x00,
+	0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x41, 0x00, 0x20, 0x00, 0x50, 0x00, 0x41, 0x00, 0x52, 0x00,
+	0x54, 0x00, 0x49, 0x00, 0x43, 0x00, 0x55, 0x00, 0x4c, 0x00, 0x41, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x50, 0x00, 0x55, 0x00, 0x52, 0x00, 0x50, 0x00, 0x4f, 0x00, 0x53, 0x00, 0x45, 0x00, 0x20, 0x00,
+	0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x20, 0x00, 0x44, 0x00, 0x49, 0x00, 0x53, 0x00, 0x43, 0x00,
+	0x4c, 0x00, 0x41, 0x00, 0x49, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x44, 0x00, 0x2e, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x20, 0x00, 0x45, 0x00, 0x56, 0x00,
+	0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x20, 0x00, 0x53, 0x00, 0x48, 0x00, 0x41, 0x00, 0x4c, 0x00,
+	0x4c, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00,
+	0x50, 0x00, 0x59, 0x00, 0x52, 0x00, 0x49, 0x00, 0x47, 0x00, 0x48, 0x00, 0x54, 0x00, 0x20, 0x00,
+	0x4f, 0x00, 0x57, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x52, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00,
+	0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x52, 0x00, 0x49, 0x00, 0x42, 0x00,
+	0x55, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x53, 0x00, 0x20, 0x00, 0x42, 0x00, 0x45, 0x00,
+	0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x41, 0x00, 0x42, 0x00, 0x4c, 0x00, 0x45, 0x00, 0x20, 0x00,
+	0x46, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00, 0x59, 0x00, 0x20, 0x00,
+	0x44, 0x00, 0x49, 0x00, 0x52, 0x00, 0x45, 0x00, 0x43, 0x00, 0x54, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x49, 0x00, 0x52, 0x00, 0x45, 0x00, 0x43, 0x00, 0x54, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x49, 0x00, 0x44, 0x00, 0x45, 0x00,
+	0x4e, 0x00, 0x54, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x53, 0x00, 0x50, 0x00,
+	0x45, 0x00, 0x43, 0x00, 0x49, 0x00, 0x41, 0x00, 0x4c, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x45, 0x00,
+	0x58, 0x00, 0x45, 0x00, 0x4d, 0x00, 0x50, 0x00, 0x4c, 0x00, 0x41, 0x00, 0x52, 0x00, 0x59, 0x00,
+	0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00,
+	0x53, 0x00, 0x45, 0x00, 0x51, 0x00, 0x55, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x49, 0x00,
+	0x41, 0x00, 0x4c, 0x00, 0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x4d, 0x00, 0x41, 0x00, 0x47, 0x00,
+	0x45, 0x00, 0x53, 0x00, 0x20, 0x00, 0x28, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00,
+	0x55, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x47, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x42, 0x00,
+	0x55, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00,
+	0x49, 0x00, 0x4d, 0x00, 0x49, 0x00, 0x54, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x54, 0x00,
+	0x4f, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x50, 0x00, 0x52, 0x00, 0x4f, 0x00, 0x43, 0x00, 0x55, 0x00,
+	0x52, 0x00, 0x45, 0x00, 0x4d, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x46, 0x00, 0x20, 0x00, 0x53, 0x00, 0x55, 0x00, 0x42, 0x00, 0x53, 0x00, 0x54, 0x00, 0x49, 0x00,
+	0x54, 0x00, 0x55, 0x00, 0x54, 0x00, 0x45, 0x00, 0x20, 0x00, 0x47, 0x00, 0x4f, 0x00, 0x4f, 0x00,
+	0x44, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x53, 0x00, 0x45, 0x00,
+	0x52, 0x00, 0x56, 0x00, 0x49, 0x00, 0x43, 0x00, 0x45, 0x00, 0x53, 0x00, 0x3b, 0x00, 0x20, 0x00,
+	0x4c, 0x00, 0x4f, 0x00, 0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00,
+	0x55, 0x00, 0x53, 0x00, 0x45, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x54, 0x00,
+	0x41, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00, 0x50, 0x00, 0x52, 0x00,
+	0x4f, 0x00, 0x46, 0x00, 0x49, 0x00, 0x54, 0x00, 0x53, 0x00, 0x3b, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x42, 0x00, 0x55, 0x00, 0x53, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x45, 0x00,
+	0x53, 0x00, 0x53, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x45, 0x00, 0x52, 0x00,
+	0x52, 0x00, 0x55, 0x00, 0x50, 0x00, 0x54, 0x00, 0x49, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x29, 0x00,
+	0x20, 0x00, 0x48, 0x00, 0x4f, 0x00, 0x57, 0x00, 0x45, 0x00, 0x56, 0x00, 0x45, 0x00, 0x52, 0x00,
+	0x20, 0x00, 0x43, 0x00, 0x41, 0x00, 0x55, 0x00, 0x53, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00,
+	0x41, 0x00, 0x4e, 0x00, 0x44, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x4e, 0x00, 0x59, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x4f, 0x00, 0x52, 0x00,
+	0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x41, 0x00,
+	0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x2c, 0x00, 0x20, 0x00,
+	0x57, 0x00, 0x48, 0x00, 0x45, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x43, 0x00, 0x4f, 0x00, 0x4e, 0x00, 0x54, 0x00, 0x52, 0x00,
+	0x41, 0x00, 0x43, 0x00, 0x54, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x53, 0x00, 0x54, 0x00, 0x52, 0x00,
+	0x49, 0x00, 0x43, 0x00, 0x54, 0x00, 0x20, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x41, 0x00, 0x42, 0x00,
+	0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00, 0x59, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x52, 0x00, 0x20, 0x00, 0x54, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x54, 0x00, 0x20, 0x00, 0x28, 0x00,
+	0x49, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x4c, 0x00, 0x55, 0x00, 0x44, 0x00, 0x49, 0x00, 0x4e, 0x00,
+	0x47, 0x00, 0x20, 0x00, 0x4e, 0x00, 0x45, 0x00, 0x47, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x47, 0x00,
+	0x45, 0x00, 0x4e, 0x00, 0x43, 0x00, 0x45, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x52, 0x00, 0x20, 0x00,
+	0x4f, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x52, 0x00, 0x57, 0x00, 0x49, 0x00, 0x53, 0x00,
+	0x45, 0x00, 0x29, 0x00, 0x20, 0x00, 0x41, 0x00, 0x52, 0x00, 0x49, 0x00, 0x53, 0x00, 0x49, 0x00,
+	0x4e, 0x00, 0x47, 0x00, 0x20, 0x00, 0x49, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x41, 0x00, 0x4e, 0x00,
+	0x59, 0x00, 0x20, 0x00, 0x57, 0x00, 0x41, 0x00, 0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x55, 0x00,
+	0x54, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00,
+	0x20, 0x00, 0x55, 0x00, 0x53, 0x00, 0x45, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00,
+	0x54, 0x00, 0x48, 0x00, 0x49, 0x00, 0x53, 0x00, 0x20, 0x00, 0x53, 0x00, 0x4f, 0x00, 0x46, 0x00,
+	0x54, 0x00, 0x57, 0x00, 0x41, 0x00, 0x52, 0x00, 0x45, 0x00, 0x2c, 0x00, 0x20, 0x00, 0x45, 0x00,
+	0x56, 0x00, 0x45, 0x00, 0x4e, 0x00, 0x20, 0x00, 0x49, 0x00, 0x46, 0x00, 0x20, 0x00, 0x41, 0x00,
+	0x44, 0x00, 0x56, 0x00, 0x49, 0x00, 0x53, 0x00, 0x45, 0x00, 0x44, 0x00, 0x20, 0x00, 0x4f, 0x00,
+	0x46, 0x00, 0x20, 0x00, 0x54, 0x00, 0x48, 0x00, 0x45, 0x00, 0x20, 0x00, 0x50, 0x00, 0x4f, 0x00,
+	0x53, 0x00, 0x53, 0x00, 0x49, 0x00, 0x42, 0x00, 0x49, 0x00, 0x4c, 0x00, 0x49, 0x00, 0x54, 0x00,
+	0x59, 0x00, 0x20, 0x00, 0x4f, 0x00, 0x46, 0x00, 0x20, 0x00, 0x53, 0x00, 0x55, 0x00, 0x43, 0x00,
+	0x48, 0x00, 0x20, 0x00, 0x44, 0x00, 0x41, 0x00, 0x4d, 0x00, 0x41, 0x00, 0x47, 0x00, 0x45, 0x00,
+	0x2e, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xfe, 0xed, 0x00, 0x32,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x02, 0xc8, 0x00, 0x00, 0x02, 0x07, 0x02, 0x08, 0x00, 0x03, 0x00, 0x04,
+	0x00, 0x05, 0x00, 0x06, 0x00, 0x07, 0x00, 0x08, 0x00, 0x09, 0x00, 0x0a, 0x00, 0x0b, 0x00, 0x0c,
+	0x00, 0x0d, 0x00, 0x0e, 0x00, 0x0f, 0x00, 0x10, 0x00, 0x11, 0x00, 0x12, 0x00, 0x13, 0x00, 0x14,
+	0x00, 0x15, 0x00, 0x16, 0x00, 0x17, 0x00, 0x18, 0x00, 0x19, 0x00, 0x1a, 0x00, 0x1b, 0x00, 0x1c,
+	0x00, 0x1d, 0x00, 0x1e, 0x00, 0x1f, 0x00, 0x20, 0x00, 0x21, 0x00, 0x22, 0x00, 0x23, 0x00, 0x24,
+	0x00, 0x25, 0x00, 0x26, 0x00, 0x27, 0x00, 0x28, 0x00, 0x29, 0x00, 0x2a, 0x00, 0x2b, 0x00, 0x2c,
+	0x00, 0x2d, 0x00, 0x2e, 0x00, 0x2f, 0x00, 0x30, 0x00, 0x31, 0x00, 0x32, 0x00, 0x33, 0x00, 0x34,
+	0x00, 0x35, 0x00, 0x36, 0x00, 0x37, 0x00, 0x38, 0x00, 0x39, 0x00, 0x3a, 0x00, 0x3b, 0x00, 0x3c,
+	0x00, 0x3d, 0x00, 0x3e, 0x00, 0x3f, 0x00, 0x40, 0x00, 0x41, 0x00, 0x42, 0x00, 0x43, 0x00, 0x44,
+	0x00, 0x45, 0x00, 0x46, 0x00, 0x47, 0x00, 0x48, 0x00, 0x49, 0x00, 0x4a, 0x00, 0x4b, 0x00, 0x4c,
+	0x00, 0x4d, 0x00, 0x4e, 0x00, 0x4f, 0x00, 0x50, 0x00, 0x51, 0x00, 0x52, 0x00, 0x53, 0x00, 0x54,
+	0x00, 0x55, 0x00, 0x56, 0x00, 0x57, 0x00, 0x58, 0x00, 0x59, 0x00, 0x5a, 0x00, 0x5b, 0x00, 0x5c,
+	0x00, 0x5d, 0x00, 0x5e, 0x00, 0x5f, 0x00, 0x60, 0x00, 0x61, 0x02, 0x09, 0x00, 0xa3, 0x00, 0x84,
+	0x00, 0x85, 0x00, 0xbd, 0x00, 0x96, 0x00, 0xe8, 0x00, 0x86, 0x00, 0x8e, 0x00, 0x8b, 0x00, 0x9d,
+	0x00, 0xa9, 0x00, 0xa4, 0x02, 0x0a, 0x00, 0x8a, 0x00, 0xda, 0x00, 0x83, 0x00, 0x93, 0x02, 0x0b,
+	0x02, 0x0c, 0x00, 0x8d, 0x00, 0x97, 0x00, 0x88, 0x00, 0xc3, 0x00, 0xde, 0x02, 0x0d, 0x00, 0x9e,
+	0x00, 0xaa, 0x00, 0xf5, 0x00, 0xf4, 0x00, 0xf6, 0x00, 0xa2, 0x00, 0xad, 0x00, 0xc9, 0x00, 0xc7,
+	0x00, 0xae, 0x00, 0x62, 0x00, 0x63, 0x00, 0x90, 0x00, 0x64, 0x00, 0xcb, 0x00, 0x65, 0x00, 0xc8,
+	0x00, 0xca, 0x00, 0xcf, 0x00, 0xcc, 0x00, 0xcd, 0x00, 0xce, 0x00, 0xe9, 0x00, 0x66, 0x00, 0xd3,
+	0x00, 0xd0, 0x00, 0xd1, 0x00, 0xaf, 0x00, 0x67, 0x00, 0xf0, 0x00, 0x91, 0x00, 0xd6, 0x00, 0xd4,
+	0x00, 0xd5, 0x00, 0x68, 0x00, 0xeb, 0x00, 0xed, 0x00, 0x89, 0x00, 0x6a, 0x00, 0x69, 0x00, 0x6b,
+	0x00, 0x6d, 0x00, 0x6c, 0x00, 0x6e, 0x00, 0xa0, 0x00, 0x6f, 0x00, 0x71, 0x00, 0x70, 0x00, 0x72,
+	0x00, 0x73, 0x00, 0x75, 0x00, 0x74, 0x00, 0x76, 0x00, 0x77, 0x00, 0xea, 0x00, 0x78, 0x00, 0x7a,
+	0x00, 0x79, 0x00, 0x7b, 0x00, 0x7d, 0x00, 0x7c, 0x00, 0xb8, 0x00, 0xa1, 0x00, 0x7f, 0x00, 0x7e,
+	0x00, 0x80, 0x00, 0x81, 0x00, 0xec, 0x00, 0xee, 0x00, 0xba, 0x01, 0x06, 0x01, 0x88, 0x01, 0x03,
+	0x01, 0x84, 0x01, 0x07, 0x01, 0x8a, 0x00, 0xfd, 0x00, 0xfe, 0x01, 0x0a, 0x01, 0x95, 0x01, 0x0b,
+	0x01, 0x96, 0x00, 0xff, 0x01, 0x00, 0x01, 0x0d, 0x01, 0x9a, 0x01, 0x0e, 0x01, 0x01, 0x01, 0x12,
+	0x01, 0xa3, 0x01, 0x0f, 0x01, 0xa0, 0x01, 0x11, 0x01, 0xa2, 0x01, 0x14, 0x01, 0xa5, 0x01, 0x10,
+	0x01, 0xa1, 0x01, 0x1b, 0x01, 0xb2, 0x00, 0xf8, 0x00, 0xf9, 0x01, 0x1c, 0x01, 0xb3, 0x02, 0x0e,
+	0x02, 0x0f, 0x01, 0x22, 0x01, 0xb6, 0x01, 0x21, 0x01, 0xb5, 0x01, 0x2a, 0x01, 0xc7, 0x01, 0x25,
+	0x01, 0xbb, 0x01, 0x24, 0x01, 0xb9, 0x01, 0x26, 0x01, 0xc2, 0x00, 0xfa, 0x00, 0xd7, 0x01, 0x23,
+	0x01, 0xba, 0x01, 0x2b, 0x01, 0xc8, 0x02, 0x10, 0x02, 0x11, 0x01, 0xca, 0x01, 0x2d, 0x01, 0xcb,
+	0x02, 0x12, 0x02, 0x13, 0x01, 0x2f, 0x01, 0xcd, 0x01, 0x30, 0x01, 0xce, 0x00, 0xe2, 0x00, 0xe3,
+	0x01, 0x32, 0x01, 0xd7, 0x02, 0x14, 0x02, 0x15, 0x01, 0x33, 0x01, 0xd9, 0x01, 0xd8, 0x01, 0x13,
+	0x01, 0xa4, 0x01, 0x37, 0x01, 0xdd, 0x01, 0x35, 0x01, 0xdb, 0x01, 0x36, 0x01, 0xdc, 0x00, 0xb0,
+	0x00, 0xb1, 0x01, 0x3f, 0x01, 0xea, 0x02, 0x16, 0x02, 0x17, 0x01, 0x40, 0x01, 0xeb, 0x01, 0x6a,
+	0x01, 0xef, 0x01, 0x6b, 0x01, 0xf0, 0x00, 0xfb, 0x00, 0xfc, 0x00, 0xe4, 0x00, 0xe5, 0x02, 0x18,
+	0x02, 0x19, 0x01, 0x6f, 0x01, 0xfb, 0x01, 0x6e, 0x01, 0xfa, 0x01, 0x79, 0x02, 0xc4, 0x01, 0x73,
+	0x02, 0x05, 0x01, 0x71, 0x02, 0x03, 0x01, 0x78, 0x02, 0xc3, 0x01, 0x72, 0x02, 0x04, 0x01, 0x74,
+	0x02, 0xbd, 0x01, 0x7b, 0x02, 0xc6, 0x01, 0x7f, 0x02, 0xca, 0x00, 0xbb, 0x01, 0x81, 0x02, 0xcc,
+	0x01, 0x82, 0x02, 0xcd, 0x00, 0xe6, 0x00, 0xe7, 0x01, 0xd1, 0x00, 0xa6, 0x02, 0x1a, 0x02, 0x1b,
+	0x02, 0x1c, 0x02, 0x1d, 0x02, 0x1e, 0x02, 0x1f, 0x02, 0x20, 0x02, 0x21, 0x02, 0x22, 0x02, 0x23,
+	0x02, 0x24, 0x02, 0x25, 0x02, 0x26, 0x02, 0x27, 0x02, 0x28, 0x02, 0x29, 0x01, 0x08, 0x01, 0x8b,
+	0x01, 0x02, 0x01, 0x85, 0x01, 0x3b, 0x01, 0xe5, 0x02, 0x2a, 0x02, 0x2b, 0x02, 0x2c, 0x02, 0x2d,
+	0x00, 0xd8, 0x00, 0xe1, 0x02, 0x2e, 0x00, 0xdb, 0x00, 0xdc, 0x00, 0xdd, 0x00, 0xe0, 0x00, 0xd9,
+	0x00, 0xdf, 0x02, 0x2f, 0x01, 0xfe, 0x01, 0x9d, 0x01, 0x05, 0x01, 0x89, 0x01, 0x16, 0x01, 0x18,
+	0x01, 0x29, 0x01, 0x3a, 0x01, 0x77, 0x01, 0x38, 0x01, 0xc5, 0x01, 0x04, 0x01, 0x09, 0x01, 0x1a,
+	0x02, 0x30, 0x01, 0x15, 0x01, 0x83, 0x01, 0x17, 0x01, 0x70, 0x01, 0x27, 0x01, 0x2c, 0x01, 0x2e,
+	0x01, 0x31, 0x01, 0x34, 0x01, 0x7e, 0x01, 0x39, 0x01, 0x3d, 0x01, 0x41, 0x01, 0x6c, 0x01, 0x6d,
+	0x01, 0x75, 0x01, 0x3c, 0x01, 0x0c, 0x01, 0x3e, 0x02, 0x31, 0x01, 0x28, 0x01, 0x76, 0x01, 0x87,
+	0x01, 0xa7, 0x01, 0xab, 0x01, 0xc6, 0x02, 0xc1, 0x01, 0x86, 0x01, 0x93, 0x01, 0xb1, 0x01, 0x9b,
+	0x01, 0xa6, 0x02, 0xd0, 0x01, 0xaa, 0x01, 0xfc, 0x01, 0xc3, 0x01, 0xc9, 0x01, 0xcc, 0x02, 0x32,
+	0x01, 0xda, 0x02, 0xc9, 0x01, 0xe0, 0x00, 0x9b, 0x01, 0xed, 0x01, 0xf5, 0x01, 0xf4, 0x01, 0xf9,
+	0x02, 0xbf, 0x01, 0xe7, 0x01, 0x97, 0x01, 0xe8, 0x01, 0xde, 0x01, 0xc4, 0x02, 0xc0, 0x01, 0xe1,
+	0x02, 0xc2, 0x01, 0xdf, 0x02, 0x33, 0x02, 0x34, 0x02, 0x35, 0x02, 0x36, 0x02, 0x37, 0x02, 0x38,
+	0x02, 0x39, 0x02, 0x3a, 0x02, 0x3b, 0x02, 0x3c, 0x02, 0x3d, 0x02, 0x3e, 0x02, 0x3f, 0x02, 0x40,
+	0x02, 0x41, 0x02, 0x42, 0x02, 0x43, 0x02, 0x44, 0x02, 0x45, 0x02, 0x46, 0x02, 0x47, 0x02, 0x48,
+	0x02, 0x49, 0x02, 0x4a, 0x02, 0x4b, 0x02, 0x4c, 0x02, 0x4d, 0x02, 0x4e, 0x02, 0x4f, 0x02, 0x50,
+	0x02, 0x51, 0x02, 0x52, 0x02, 0x53, 0x02, 0x54, 0x02, 0x55, 0x02, 0x56, 0x02, 0x57, 0x02, 0x58,
+	0x02, 0x59, 0x02, 0x5a, 0x02, 0x5b, 0x02, 0x5c, 0x02, 0x5d, 0x02, 0x5e, 0x02, 0x5f, 0x02, 0x60,
+	0x02, 0x61, 0x02, 0x62, 0x02, 0x63, 0x02, 0x64, 0x02, 0x65, 0x02, 0x66, 0x02, 0x67, 0x02, 0x68,
+	0x02, 0x69, 0x02, 0x6a, 0x02, 0x6b, 0x02, 0x6c, 0x02, 0x6d, 0x02, 0x6e, 0x02, 0x6f, 0x02, 0x70,
+	0x02, 0x71, 0x02, 0x72, 0x02, 0x73, 0x02, 0x74, 0x02, 0x75, 0x02, 0x76, 0x02, 0x77, 0x02, 0x78,
+	0x02, 0x79, 0x02, 0x7a, 0x02, 0x7b, 0x02, 0x7c, 0x02, 0x7d, 0x02, 0x7e, 0x02, 0x7f, 0x02, 0x80,
+	0x02, 0x81, 0x02, 0x82, 0x02, 0x83, 0x02, 0x84, 0x02, 0x85, 0x02, 0x86, 0x02, 0x87, 0x02, 0x88,
+	0x02, 0x89, 0x02, 0x8a, 0x02, 0x8b, 0x02, 0x8c, 0x02, 0x8d, 0x02, 0x8e, 0x02, 0x8f, 0x02, 0x90,
+	0x02, 0x91, 0x02, 0x92, 0x02, 0x93, 0x02, 0x94, 0x01, 0x7d, 0x02, 0xc8, 0x01, 0x7a, 0x02, 0xc5,
+	0x01, 0x7c, 0x02, 0xc7, 0x01, 0x80, 0x02, 0xcb, 0x00, 0xb2, 0x00, 0xb3, 0x02, 0x95, 0x02, 0x06,
+	0x00, 0xb6, 0x00, 0xb7, 0x00, 0xc4, 0x01, 0xe9, 0x00, 0xb4, 0x00, 0xb5, 0x00, 0xc5, 0x00, 0x82,
+	0x00, 0xc2, 0x00, 0x87, 0x00, 0xab, 0x00, 0xc6, 0x01, 0xd4, 0x01, 0xf1, 0x00, 0xbe, 0x00, 0xbf,
+	0x01, 0xac, 0x02, 0x96, 0x00, 0xbc, 0x02, 0x97, 0x02, 0x98, 0x02, 0x99, 0x02, 0x9a, 0x02, 0x9b,
+	0x02, 0x9c, 0x02, 0x9d, 0x02, 0x9e, 0x02, 0x9f, 0x02, 0xa0, 0x02, 0xa1, 0x02, 0xa2, 0x02, 0xa3,
+	0x02, 0xa4, 0x02, 0xa5, 0x02, 0xa6, 0x02, 0xa7, 0x02, 0xa8, 0x02, 0xa9, 0x02, 0xaa, 0x02, 0xab,
+	0x02, 0xac, 0x02, 0xad, 0x02, 0xae, 0x02, 0xaf, 0x02, 0xb0, 0x02, 0xb1, 0x02, 0xb2, 0x02, 0xb3,
+	0x00, 0xf7, 0x01, 0xd0, 0x01, 0xe6, 0x01, 0x19, 0x02, 0xb4, 0x02, 0xb5, 0x02, 0xb6, 0x00, 0x8c,
+	0x00, 0x9f, 0x01, 0xa9, 0x01, 0xe2, 0x01, 0xfd, 0x01, 0xb0, 0x01, 0xf2, 0x01, 0x8e, 0x01, 0x90,
+	0x01, 0x8f, 0x01, 0x8d, 0x01, 0x8c, 0x01, 0x91, 0x01, 0x92, 0x00, 0x98, 0x00, 0xa8, 0x00, 0x9a,
+	0x00, 0x99, 0x00, 0xef, 0x02, 0xb7, 0x02, 0xb8, 0x00, 0xa5, 0x00, 0x92, 0x01, 0xe4, 0x01, 0xbe,
+	0x02, 0xbc, 0x00, 0x9c, 0x00, 0xa7, 0x00, 0x8f, 0x01, 0xa8, 0x00, 0x94, 0x00, 0x95, 0x01, 0xb8,
+	0x01, 0xec, 0x01, 0xbd, 0x01, 0xbc, 0x01, 0x4b, 0x01, 0x4c, 0x01, 0x42, 0x01, 0x44, 0x01, 0x43,
+	0x01, 0x45, 0x01, 0x49, 0x01, 0x4a, 0x01, 0x47, 0x01, 0x48, 0x01, 0x46, 0x01, 0x5e, 0x01, 0x52,
+	0x01, 0x66, 0x01, 0x67, 0x01, 0x5a, 0x01, 0x50, 0x01, 0x4f, 0x01, 0x53, 0x01, 0x65, 0x01, 0x64,
+	0x01, 0x59, 0x01, 0x56, 0x01, 0x55, 0x01, 0x54, 0x01, 0x57, 0x01, 0x58, 0x01, 0x5d, 0x01, 0x4d,
+	0x01, 0x4e, 0x01, 0x51, 0x01, 0x62, 0x01, 0x63, 0x01, 0x5c, 0x01, 0x60, 0x01, 0x61, 0x01, 0x5b,
+	0x01, 0x69, 0x01, 0x68, 0x01, 0x5f, 0x02, 0xbe, 0x01, 0x9f, 0x01, 0x94, 0x01, 0xcf, 0x01, 0xee,
+	0x01, 0xd2, 0x01, 0xf3, 0x01, 0x9e, 0x01, 0xae, 0x01, 0x20, 0x01, 0x1e, 0x01, 0x1f, 0x01, 0xaf,
+	0x02, 0x02, 0x02, 0x01, 0x01, 0xff, 0x02, 0x00, 0x00, 0xb9, 0x01, 0x98, 0x01, 0x1d, 0x01, 0xbf,
+	0x01, 0xc0, 0x01, 0xe3, 0x01, 0xf6, 0x01, 0xc1, 0x01, 0xf8, 0x01, 0xad, 0x01, 0xd3, 0x01, 0xf7,
+	0x01, 0x99, 0x01, 0xb7, 0x01, 0x9c, 0x01, 0xd5, 0x01, 0xd6, 0x01, 0xb4, 0x02, 0xb9, 0x02, 0xba,
+	0x02, 0xbb, 0x02, 0xce, 0x02, 0xcf, 0x07, 0x41, 0x45, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x41,
+	0x62, 0x72, 0x65, 0x76, 0x65, 0x05, 0x41, 0x6c, 0x70, 0x68, 0x61, 0x0a, 0x41, 0x6c, 0x70, 0x68,
+	0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07, 0x41, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x07, 0x41,
+	0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x0a, 0x41, 0x72, 0x69, 0x6e, 0x67, 0x61, 0x63, 0x75, 0x74,
+	0x65, 0x04, 0x42, 0x65, 0x74, 0x61, 0x0b, 0x43, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c,
+	0x65, 0x78, 0x0a, 0x43, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x03, 0x43, 0x68,
+	0x69, 0x06, 0x44, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x06, 0x44, 0x63, 0x72, 0x6f, 0x61, 0x74, 0x06,
+	0x45, 0x62, 0x72, 0x65, 0x76, 0x65, 0x06, 0x45, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0a, 0x45, 0x64,
+	0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x07, 0x45, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e,
+	0x03, 0x45, 0x6e, 0x67, 0x07, 0x45, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x45, 0x70, 0x73,
+	0x69, 0x6c, 0x6f, 0x6e, 0x0c, 0x45, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f,
+	0x73, 0x03, 0x45, 0x74, 0x61, 0x08, 0x45, 0x74, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x04, 0x45,
+	0x75, 0x72, 0x6f, 0x05, 0x47, 0x61, 0x6d, 0x6d, 0x61, 0x0b, 0x47, 0x63, 0x69, 0x72, 0x63, 0x75,
+	0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x47, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74,
+	0x06, 0x48, 0x31, 0x38, 0x35, 0x33, 0x33, 0x06, 0x48, 0x31, 0x38, 0x35, 0x34, 0x33, 0x06, 0x48,
+	0x31, 0x38, 0x35, 0x35, 0x31, 0x06, 0x48, 0x32, 0x32, 0x30, 0x37, 0x33, 0x04, 0x48, 0x62, 0x61,
+	0x72, 0x0b, 0x48, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x02, 0x49, 0x4a,
+	0x06, 0x49, 0x62, 0x72, 0x65, 0x76, 0x65, 0x07, 0x49, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x07,
+	0x49, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x04, 0x49, 0x6f, 0x74, 0x61, 0x0c, 0x49, 0x6f, 0x74,
+	0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x09, 0x49, 0x6f, 0x74, 0x61, 0x74, 0x6f,
+	0x6e, 0x6f, 0x73, 0x06, 0x49, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x0b, 0x4a, 0x63, 0x69, 0x72, 0x63,
+	0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x4b, 0x61, 0x70, 0x70, 0x61, 0x06, 0x4c, 0x61, 0x63,
+	0x75, 0x74, 0x65, 0x06, 0x4c, 0x61, 0x6d, 0x62, 0x64, 0x61, 0x06, 0x4c, 0x63, 0x61, 0x72, 0x6f,
+	0x6e, 0x04, 0x4c, 0x64, 0x6f, 0x74, 0x02, 0x4d, 0x75, 0x06, 0x4e, 0x61, 0x63, 0x75, 0x74, 0x65,
+	0x06, 0x4e, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x02, 0x4e, 0x75, 0x06, 0x4f, 0x62, 0x72, 0x65, 0x76,
+	0x65, 0x0d, 0x4f, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07,
+	0x4f, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x0a, 0x4f, 0x6d, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x6e,
+	0x6f, 0x73, 0x07, 0x4f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x0c, 0x4f, 0x6d, 0x69, 0x63, 0x72,
+	0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0b, 0x4f, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x61, 0x63,
+	0x75, 0x74, 0x65, 0x03, 0x50, 0x68, 0x69, 0x02, 0x50, 0x69, 0x03, 0x50, 0x73, 0x69, 0x06, 0x52,
+	0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x52, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x03, 0x52, 0x68, 0x6f,
+	0x08, 0x53, 0x46, 0x30, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x32, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x34,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x35, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x30, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x30, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x30, 0x39, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x31, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x31, 0x31, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x31, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32,
+	0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x32, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x33, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x32, 0x34, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x35, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x37,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x32, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x33, 0x36, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x33, 0x38, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x33, 0x39, 0x30, 0x30, 0x30,
+	0x30, 0x08, 0x53, 0x46, 0x34, 0x30, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x31, 0x30,
+	0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34,
+	0x33, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x34, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53,
+	0x46, 0x34, 0x35, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x36, 0x30, 0x30, 0x30, 0x30,
+	0x08, 0x53, 0x46, 0x34, 0x37, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x38, 0x30, 0x30,
+	0x30, 0x30, 0x08, 0x53, 0x46, 0x34, 0x39, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x30,
+	0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x31, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46,
+	0x35, 0x32, 0x30, 0x30, 0x30, 0x30, 0x08, 0x53, 0x46, 0x35, 0x33, 0x30, 0x30, 0x30, 0x30, 0x08,
+	0x53, 0x46, 0x35, 0x34, 0x30, 0x30, 0x30, 0x30, 0x06, 0x53, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b,
+	0x53, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x53, 0x69, 0x67, 0x6d,
+	0x61, 0x03, 0x54, 0x61, 0x75, 0x04, 0x54, 0x62, 0x61, 0x72, 0x06, 0x54, 0x63, 0x61, 0x72, 0x6f,
+	0x6e, 0x05, 0x54, 0x68, 0x65, 0x74, 0x61, 0x06, 0x55, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x55,
+	0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x55, 0x6d, 0x61,
+	0x63, 0x72, 0x6f, 0x6e, 0x07, 0x55, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x55, 0x70, 0x73,
+	0x69, 0x6c, 0x6f, 0x6e, 0x0f, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72,
+	0x65, 0x73, 0x69, 0x73, 0x0c, 0x55, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f,
+	0x73, 0x05, 0x55, 0x72, 0x69, 0x6e, 0x67, 0x06, 0x55, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x06, 0x57,
+	0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x57, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65,
+	0x78, 0x09, 0x57, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x06, 0x57, 0x67, 0x72, 0x61,
+	0x76, 0x65, 0x02, 0x58, 0x69, 0x0b, 0x59, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65,
+	0x78, 0x06, 0x59, 0x67, 0x72, 0x61, 0x76, 0x65, 0x06, 0x5a, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0a,
+	0x5a, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x04, 0x5a, 0x65, 0x74, 0x61, 0x06,
+	0x61, 0x62, 0x72, 0x65, 0x76, 0x65, 0x07, 0x61, 0x65, 0x61, 0x63, 0x75, 0x74, 0x65, 0x05, 0x61,
+	0x6c, 0x70, 0x68, 0x61, 0x0a, 0x61, 0x6c, 0x70, 0x68, 0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07,
+	0x61, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x09, 0x61, 0x6e, 0x6f, 0x74, 0x65, 0x6c, 0x65, 0x69,
+	0x61, 0x07, 0x61, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x0a, 0x61, 0x72, 0x69, 0x6e, 0x67, 0x61,
+	0x63, 0x75, 0x74, 0x65, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x62, 0x6f, 0x74, 0x68, 0x09, 0x61,
+	0x72, 0x72, 0x6f, 0x77, 0x64, 0x6f, 0x77, 0x6e, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x6c, 0x65,
+	0x66, 0x74, 0x0a, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x72, 0x69, 0x67, 0x68, 0x74, 0x07, 0x61, 0x72,
+	0x72, 0x6f, 0x77, 0x75, 0x70, 0x09, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x75, 0x70, 0x64, 0x6e, 0x0c,
+	0x61, 0x72, 0x72, 0x6f, 0x77, 0x75, 0x70, 0x64, 0x6e, 0x62, 0x73, 0x65, 0x04, 0x62, 0x65, 0x74,
+	0x61, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x0b, 0x63, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66,
+	0x6c, 0x65, 0x78, 0x0a, 0x63, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x03, 0x63,
+	0x68, 0x69, 0x06, 0x63, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x04, 0x63, 0x6c, 0x75, 0x62, 0x06, 0x64,
+	0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x07, 0x64, 0x69, 0x61, 0x6d,
+	0x6f, 0x6e, 0x64, 0x0d, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f,
+	0x73, 0x07, 0x64, 0x6b, 0x73, 0x68, 0x61, 0x64, 0x65, 0x07, 0x64, 0x6e, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x06, 0x65, 0x62, 0x72, 0x65, 0x76, 0x65, 0x06, 0x65, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0a,
+	0x65, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x07, 0x65, 0x6d, 0x61, 0x63, 0x72,
+	0x6f, 0x6e, 0x03, 0x65, 0x6e, 0x67, 0x07, 0x65, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x07, 0x65,
+	0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x0c, 0x65, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f,
+	0x6e, 0x6f, 0x73, 0x0b, 0x65, 0x71, 0x75, 0x69, 0x76, 0x61, 0x6c, 0x65, 0x6e, 0x63, 0x65, 0x09,
+	0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x03, 0x65, 0x74, 0x61, 0x08, 0x65, 0x74,
+	0x61, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09, 0x65, 0x78, 0x63, 0x6c, 0x61, 0x6d, 0x64, 0x62, 0x6c,
+	0x06, 0x66, 0x65, 0x6d, 0x61, 0x6c, 0x65, 0x09, 0x66, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x62, 0x6f,
+	0x78, 0x0a, 0x66, 0x69, 0x6c, 0x6c, 0x65, 0x64, 0x72, 0x65, 0x63, 0x74, 0x0b, 0x66, 0x69, 0x76,
+	0x65, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x73, 0x05, 0x67, 0x61, 0x6d, 0x6d, 0x61, 0x0b, 0x67,
+	0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x0a, 0x67, 0x64, 0x6f, 0x74, 0x61,
+	0x63, 0x63, 0x65, 0x6e, 0x74, 0x06, 0x67, 0x6f, 0x70, 0x68, 0x65, 0x72, 0x04, 0x68, 0x62, 0x61,
+	0x72, 0x0b, 0x68, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x68, 0x65,
+	0x61, 0x72, 0x74, 0x05, 0x68, 0x6f, 0x75, 0x73, 0x65, 0x06, 0x69, 0x62, 0x72, 0x65, 0x76, 0x65,
+	0x02, 0x69, 0x6a, 0x07, 0x69, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x0a, 0x69, 0x6e, 0x74, 0x65,
+	0x67, 0x72, 0x61, 0x6c, 0x62, 0x74, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x67, 0x72, 0x61, 0x6c, 0x74,
+	0x70, 0x0c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x09, 0x69,
+	0x6e, 0x76, 0x62, 0x75, 0x6c, 0x6c, 0x65, 0x74, 0x09, 0x69, 0x6e, 0x76, 0x63, 0x69, 0x72, 0x63,
+	0x6c, 0x65, 0x0c, 0x69, 0x6e, 0x76, 0x73, 0x6d, 0x69, 0x6c, 0x65, 0x66, 0x61, 0x63, 0x65, 0x07,
+	0x69, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b, 0x04, 0x69, 0x6f, 0x74, 0x61, 0x0c, 0x69, 0x6f, 0x74,
+	0x61, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x11, 0x69, 0x6f, 0x74, 0x61, 0x64, 0x69,
+	0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09, 0x69, 0x6f, 0x74, 0x61,
+	0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x06, 0x69, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x0b, 0x6a, 0x63, 0x69,
+	0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x05, 0x6b, 0x61, 0x70, 0x70, 0x61, 0x0c, 0x6b,
+	0x67, 0x72, 0x65, 0x65, 0x6e, 0x6c, 0x61, 0x6e, 0x64, 0x69, 0x63, 0x06, 0x6c, 0x61, 0x63, 0x75,
+	0x74, 0x65, 0x06, 0x6c, 0x61, 0x6d, 0x62, 0x64, 0x61, 0x06, 0x6c, 0x63, 0x61, 0x72, 0x6f, 0x6e,
+	0x04, 0x6c, 0x64, 0x6f, 0x74, 0x07, 0x6c, 0x66, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x04, 0x6c, 0x69,
+	0x72, 0x61, 0x05, 0x6c, 0x6f, 0x6e, 0x67, 0x73, 0x07, 0x6c, 0x74, 0x73, 0x68, 0x61, 0x64, 0x65,
+	0x04, 0x6d, 0x61, 0x6c, 0x65, 0x06, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x0b, 0x6d, 0x75, 0x73,
+	0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74, 0x65, 0x0e, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x61, 0x6c,
+	0x6e, 0x6f, 0x74, 0x65, 0x64, 0x62, 0x6c, 0x06, 0x6e, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x6e,
+	0x61, 0x70, 0x6f, 0x73, 0x74, 0x72, 0x6f, 0x70, 0x68, 0x65, 0x06, 0x6e, 0x63, 0x61, 0x72, 0x6f,
+	0x6e, 0x02, 0x6e, 0x75, 0x06, 0x6f, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x6f, 0x68, 0x75, 0x6e,
+	0x67, 0x61, 0x72, 0x75, 0x6d, 0x6c, 0x61, 0x75, 0x74, 0x07, 0x6f, 0x6d, 0x61, 0x63, 0x72, 0x6f,
+	0x6e, 0x05, 0x6f, 0x6d, 0x65, 0x67, 0x61, 0x0a, 0x6f, 0x6d, 0x65, 0x67, 0x61, 0x74, 0x6f, 0x6e,
+	0x6f, 0x73, 0x07, 0x6f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x6e, 0x0c, 0x6f, 0x6d, 0x69, 0x63, 0x72,
+	0x6f, 0x6e, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x09, 0x6f, 0x6e, 0x65, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x68, 0x0a, 0x6f, 0x70, 0x65, 0x6e, 0x62, 0x75, 0x6c, 0x6c, 0x65, 0x74, 0x0a, 0x6f, 0x72, 0x74,
+	0x68, 0x6f, 0x67, 0x6f, 0x6e, 0x61, 0x6c, 0x0b, 0x6f, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x61, 0x63,
+	0x75, 0x74, 0x65, 0x06, 0x70, 0x65, 0x73, 0x65, 0x74, 0x61, 0x03, 0x70, 0x68, 0x69, 0x03, 0x70,
+	0x73, 0x69, 0x0d, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x72, 0x65, 0x76, 0x65, 0x72, 0x73, 0x65, 0x64,
+	0x06, 0x72, 0x61, 0x63, 0x75, 0x74, 0x65, 0x06, 0x72, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x0d, 0x72,
+	0x65, 0x76, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x61, 0x6c, 0x6e, 0x6f, 0x74, 0x03, 0x72, 0x68, 0x6f,
+	0x07, 0x72, 0x74, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x06, 0x73, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b,
+	0x73, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c, 0x65, 0x78, 0x06, 0x73, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x0c, 0x73, 0x65, 0x76, 0x65, 0x6e, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x73, 0x05,
+	0x73, 0x68, 0x61, 0x64, 0x65, 0x05, 0x73, 0x69, 0x67, 0x6d, 0x61, 0x06, 0x73, 0x69, 0x67, 0x6d,
+	0x61, 0x31, 0x09, 0x73, 0x6d, 0x69, 0x6c, 0x65, 0x66, 0x61, 0x63, 0x65, 0x05, 0x73, 0x70, 0x61,
+	0x64, 0x65, 0x03, 0x73, 0x75, 0x6e, 0x03, 0x74, 0x61, 0x75, 0x04, 0x74, 0x62, 0x61, 0x72, 0x06,
+	0x74, 0x63, 0x61, 0x72, 0x6f, 0x6e, 0x05, 0x74, 0x68, 0x65, 0x74, 0x61, 0x0c, 0x74, 0x68, 0x72,
+	0x65, 0x65, 0x65, 0x69, 0x67, 0x68, 0x74, 0x68, 0x73, 0x05, 0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x07,
+	0x74, 0x72, 0x69, 0x61, 0x67, 0x64, 0x6e, 0x07, 0x74, 0x72, 0x69, 0x61, 0x67, 0x6c, 0x66, 0x07,
+	0x74, 0x72, 0x69, 0x61, 0x67, 0x72, 0x74, 0x07, 0x74, 0x72, 0x69, 0x61, 0x67, 0x75, 0x70, 0x06,
+	0x75, 0x62, 0x72, 0x65, 0x76, 0x65, 0x0d, 0x75, 0x68, 0x75, 0x6e, 0x67, 0x61, 0x72, 0x75, 0x6d,
+	0x6c, 0x61, 0x75, 0x74, 0x07, 0x75, 0x6d, 0x61, 0x63, 0x72, 0x6f, 0x6e, 0x0d, 0x75, 0x6e, 0x64,
+	0x65, 0x72, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x64, 0x62, 0x6c, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30,
+	0x30, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x30, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30,
+	0x41, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x41, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30,
+	0x42, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30, 0x42, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x30,
+	0x42, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x32, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x32, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x33, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x33, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x33, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x33, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x34, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x34, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x35, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x35, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x36, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x36, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x43, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x43, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x43, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31, 0x44, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x31,
+	0x44, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x31, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32,
+	0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x31, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32,
+	0x31, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x32, 0x43, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33,
+	0x37, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33, 0x39, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33,
+	0x41, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x33, 0x42, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x30, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x30, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x31, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x31, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x32, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x32, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x33, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x33, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x34, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x34, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x35, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x35, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34,
+	0x39, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x30, 0x34, 0x39, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x33, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x37, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x37, 0x46, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x30, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x34, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x37, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x38, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x41, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x42, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x43, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x38, 0x44, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30, 0x38, 0x45, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x30,
+	0x39, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31, 0x30, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31,
+	0x31, 0x33, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x31, 0x31, 0x36, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x32,
+	0x31, 0x35, 0x07, 0x75, 0x6e, 0x69, 0x32, 0x32, 0x31, 0x39, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x42,
+	0x30, 0x31, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x42, 0x30, 0x32, 0x07, 0x75, 0x6e, 0x69, 0x46, 0x46,
+	0x46, 0x44, 0x05, 0x75, 0x6e, 0x69, 0x6f, 0x6e, 0x07, 0x75, 0x6f, 0x67, 0x6f, 0x6e, 0x65, 0x6b,
+	0x07, 0x75, 0x70, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x07, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e,
+	0x0f, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73,
+	0x14, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73,
+	0x74, 0x6f, 0x6e, 0x6f, 0x73, 0x0c, 0x75, 0x70, 0x73, 0x69, 0x6c, 0x6f, 0x6e, 0x74, 0x6f, 0x6e,
+	0x6f, 0x73, 0x05, 0x75, 0x72, 0x69, 0x6e, 0x67, 0x06, 0x75, 0x74, 0x69, 0x6c, 0x64, 0x65, 0x06,
+	0x77, 0x61, 0x63, 0x75, 0x74, 0x65, 0x0b, 0x77, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c,
+	0x65, 0x78, 0x09, 0x77, 0x64, 0x69, 0x65, 0x72, 0x65, 0x73, 0x69, 0x73, 0x06, 0x77, 0x67, 0x72,
+	0x61, 0x76, 0x65, 0x02, 0x78, 0x69, 0x0b, 0x79, 0x63, 0x69, 0x72, 0x63, 0x75, 0x6d, 0x66, 0x6c,
+	0x65, 0x78, 0x06, 0x79, 0x67, 0x72, 0x61, 0x76, 0x65, 0x06, 0x7a, 0x61, 0x63, 0x75, 0x74, 0x65,
+	0x0a, 0x7a, 0x64, 0x6f, 0x74, 0x61, 0x63, 0x63, 0x65, 0x6e, 0x74, 0x08, 0x7a, 0x65, 0x72, 0x6f,
+	0x2e, 0x64, 0x6f, 0x74, 0x0a, 0x7a, 0x65, 0x72, 0x6f, 0x2e, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x04,
+	0x7a, 0x65, 0x74, 0x61, 0x00, 0x01, 0x00, 0x01, 0xff, 0xff, 0x00, 0x0f, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xd5, 0x00, 0xd5, 0x00, 0x94, 0x00, 0x94,
+	0x05, 0xc8, 0x00, 0x00, 0x04, 0x3e, 0x00, 0x00, 0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb, 0x04, 0x56,
+	0xff, 0xe7, 0xfe, 0x75, 0x00, 0xd5, 0x00, 0xd5, 0x00, 0x94, 0x00, 0x94, 0x05, 0xc8, 0x00, 0x00,
+	0x06, 0x31, 0x04, 0x3e, 0x00, 0x00, 0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb, 0x06, 0x44, 0x04, 0x56,
+	0xff, 0xe7, 0xfe, 0x75, 0x00, 0xd5, 0x00, 0xd5, 0x00, 0x94, 0x00, 0x94, 0x05, 0xc8, 0x00, 0x00,
+	0x06, 0x2b, 0x04, 0x3e, 0x00, 0x00, 0xfe, 0x75, 0x05, 0xed, 0xff, 0xdb, 0x06, 0x44, 0x04, 0x56,
+	0xff, 0xe7, 0xfe, 0x5d, 0x00, 0x99, 0x00, 0x99, 0x00, 0x58, 0x00, 0x58, 0x02, 0x44, 0xfe, 0xcc,
+	0x01, 0x66, 0xfe, 0xcc, 0x02, 0x5a, 0xfe, 0xb6, 0x01, 0x66, 0xfe, 0xcc, 0x00, 0x99, 0x00, 0x99,
+	0x00, 0x58, 0x00, 0x58, 0x06, 0x2d, 0x02, 0xb5, 0x06, 0x43, 0x02, 0x9f, 0xb0, 0x00, 0x2c, 0x20,
+	0xb0, 0x00, 0x55, 0x58, 0x45, 0x59, 0x20, 0x20, 0x4b, 0xb8, 0x00, 0x0e, 0x51, 0x4b, 0xb0, 0x06,
+	0x53, 0x5a, 0x58, 0xb0, 0x34, 0x1b, 0xb0, 0x28, 0x59, 0x60, 0x66, 0x20, 0x8a, 0x55, 0x58, 0xb0,
+	0x02, 0x25, 0x61, 0xb9, 0x08, 0x00, 0x08, 0x00, 0x63, 0x63, 0x23, 0x62, 0x1b, 0x21, 0x21, 0xb0,
+	0x00, 0x59, 0xb0, 0x00, 0x43, 0x23, 0x44, 0xb2, 0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0,
+	0x01, 0x2c, 0xb0, 0x20, 0x60, 0x66, 0x2d, 0xb0, 0x02, 0x2c, 0x23, 0x21, 0x23, 0x21, 0x2d, 0xb0,
+	0x03, 0x2c, 0x20, 0x64, 0xb3, 0x03, 0x14, 0x15, 0x00, 0x42, 0x43, 0xb0, 0x13, 0x43, 0x20, 0x60,
+	0x60, 0x42, 0xb1, 0x02, 0x14, 0x43, 0x42, 0xb1, 0x25, 0x03, 0x43, 0xb0, 0x02, 0x43, 0x54, 0x78,
+	0x20, 0xb0, 0x0c, 0x23, 0xb0, 0x02, 0x43, 0x43, 0x61, 0x64, 0xb0, 0x04, 0x50, 0x78, 0xb2, 0x02,
+	0x02, 0x02, 0x43, 0x60, 0x42, 0xb0, 0x21, 0x65, 0x1c, 0x21, 0xb0, 0x02, 0x43, 0x43, 0xb2, 0x0e,
+	0x15, 0x01, 0x42, 0x1c, 0x20, 0xb0, 0x02, 0x43, 0x23, 0x42, 0xb2, 0x13, 0x01, 0x13, 0x43, 0x60,
+	0x42, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0xb2, 0x16, 0x01, 0x02, 0x43, 0x60, 0x42, 0x2d,
+	0xb0, 0x04, 0x2c, 0xb0, 0x03, 0x2b, 0xb0, 0x15, 0x43, 0x58, 0x23, 0x21, 0x23, 0x21, 0xb0, 0x16,
+	0x43, 0x43, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0x1b, 0x20, 0x64, 0x20, 0xb0, 0xc0, 0x50,
+	0xb0, 0x04, 0x26, 0x5a, 0xb2, 0x28, 0x01, 0x0d, 0x43, 0x45, 0x63, 0x45, 0xb0, 0x06, 0x45, 0x58,
+	0x21, 0xb0, 0x03, 0x25, 0x59, 0x52, 0x5b, 0x58, 0x21, 0x23, 0x21, 0x1b, 0x8a, 0x58, 0x20, 0xb0,
+	0x50, 0x50, 0x58, 0x21, 0xb0, 0x40, 0x59, 0x1b, 0x20, 0xb0, 0x38, 0x50, 0x58, 0x21, 0xb0, 0x38,
+	0x59, 0x59, 0x20, 0xb1, 0x01, 0x0d, 0x43, 0x45, 0x63, 0x45, 0x61, 0x64, 0xb0, 0x28, 0x50, 0x58,
+	0x21, 0xb1, 0x01, 0x0d, 0x43, 0x45, 0x63, 0x45, 0x20, 0xb0, 0x30, 0x50, 0x58, 0x21, 0xb0, 0x30,
+	0x59, 0x1b, 0x20, 0xb0, 0xc0, 0x50, 0x58, 0x20, 0x66, 0x20, 0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x0a,
+	0x50, 0x58, 0x60, 0x1b, 0x20, 0xb0, 0x20, 0x50, 0x58, 0x21, 0xb0, 0x0a, 0x60, 0x1b, 0x20, 0xb0,
+	0x36, 0x50, 0x58, 0x21, 0xb0, 0x36, 0x60, 0x1b, 0x60, 0x59, 0x59, 0x59, 0x1b, 0xb0, 0x02, 0x25,
+	0xb0, 0x0c, 0x43, 0x63, 0xb0, 0x00, 0x52, 0x58, 0xb0, 0x00, 0x4b, 0xb0, 0x0a, 0x50, 0x58, 0x21,
+	0xb0, 0x0c, 0x43, 0x1b, 0x4b, 0xb0, 0x1e, 0x50, 0x58, 0x21, 0xb0, 0x1e, 0x4b, 0x61, 0xb8, 0x10,
+	0x00, 0x63, 0xb0, 0x0c, 0x43, 0x63, 0xb8, 0x05, 0x00, 0x62, 0x59, 0x59, 0x64, 0x61, 0x59, 0xb0,
+	0x01, 0x2b, 0x59, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0x59, 0x20, 0x64, 0xb0, 0x16,
+	0x43, 0x23, 0x42, 0x59, 0x2d, 0xb0, 0x05, 0x2c, 0x20, 0x45, 0x20, 0xb0, 0x04, 0x25, 0x61, 0x64,
+	0x20, 0xb0, 0x07, 0x43, 0x50, 0x58, 0xb0, 0x07, 0x23, 0x42, 0xb0, 0x08, 0x23, 0x42, 0x1b, 0x21,
+	0x21, 0x59, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x06, 0x2c, 0x23, 0x21, 0x23, 0x21, 0xb0, 0x03, 0x2b,
+	0x20, 0x64, 0xb1, 0x07, 0x62, 0x42, 0x20, 0xb0, 0x08, 0x23, 0x42, 0xb0, 0x06, 0x45, 0x58, 0x1b,
+	0xb1, 0x01, 0x0d, 0x43, 0x45, 0x63, 0xb1, 0x01, 0x0d, 0x43, 0xb0, 0x05, 0x60, 0x45, 0x63, 0xb0,
+	0x05, 0x2a, 0x21, 0x20, 0xb0, 0x08, 0x43, 0x20, 0x8a, 0x20, 0x8a, 0xb0, 0x01, 0x2b, 0xb1, 0x30,
+	0x05, 0x25, 0xb0, 0x04, 0x26, 0x51, 0x58, 0x60, 0x50, 0x1b, 0x61, 0x52, 0x59, 0x58, 0x23, 0x59,
+	0x21, 0x59, 0x20, 0xb0, 0x40, 0x53, 0x58, 0xb0, 0x01, 0x2b, 0x1b, 0x21, 0xb0, 0x40, 0x59, 0x23,
+	0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0x2d, 0xb0, 0x07, 0x2c, 0xb0, 0x09, 0x43, 0x2b, 0xb2, 0x00,
+	0x02, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x08, 0x2c, 0xb0, 0x09, 0x23, 0x42, 0x23, 0x20, 0xb0,
+	0x00, 0x23, 0x42, 0x61, 0xb0, 0x02, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x01, 0x60, 0xb0, 0x07,
+	0x2a, 0x2d, 0xb0, 0x09, 0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00,
+	0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x44,
+	0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x0a, 0x2c, 0xb2, 0x09, 0x0e, 0x00, 0x43, 0x45, 0x42, 0x2a, 0x21,
+	0xb2, 0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x0b, 0x2c, 0xb0, 0x00, 0x43, 0x23, 0x44,
+	0xb2, 0x00, 0x01, 0x00, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x0c, 0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0,
+	0x01, 0x2b, 0x23, 0xb0, 0x00, 0x43, 0xb0, 0x04, 0x25, 0x60, 0x20, 0x45, 0x8a, 0x23, 0x61, 0x20,
+	0x64, 0x20, 0xb0, 0x20, 0x50, 0x58, 0x21, 0xb0, 0x00, 0x1b, 0xb0, 0x30, 0x50, 0x58, 0xb0, 0x20,
+	0x1b, 0xb0, 0x40, 0x59, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65, 0x59, 0xb0, 0x03, 0x25, 0x23,
+	0x61, 0x44, 0x44, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x0d, 0x2c, 0x20, 0x20, 0x45, 0x20, 0xb0, 0x01,
+	0x2b, 0x23, 0xb0, 0x00, 0x43, 0xb0, 0x04, 0x25, 0x60, 0x20, 0x45, 0x8a, 0x23, 0x61, 0x20, 0x64,
+	0xb0, 0x24, 0x50, 0x58, 0xb0, 0x00, 0x1b, 0xb0, 0x40, 0x59, 0x23, 0xb0, 0x00, 0x50, 0x58, 0x65,
+	0x59, 0xb0, 0x03, 0x25, 0x23, 0x61, 0x44, 0x44, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x0e, 0x2c, 0x20,
+	0xb0, 0x00, 0x23, 0x42, 0xb3, 0x0d, 0x0c, 0x00, 0x03, 0x45, 0x50, 0x58, 0x21, 0x1b, 0x23, 0x21,
+	0x59, 0x2a, 0x21, 0x2d, 0xb0, 0x0f, 0x2c, 0xb1, 0x02, 0x02, 0x45, 0xb0, 0x64, 0x61, 0x44, 0x2d,
+	0xb0, 0x10, 0x2c, 0xb0, 0x01, 0x60, 0x20, 0x20, 0xb0, 0x0f, 0x43, 0x4a, 0xb0, 0x00, 0x50, 0x58,
+	0x20, 0xb0, 0x0f, 0x23, 0x42, 0x59, 0xb0, 0x10, 0x43, 0x4a, 0xb0, 0x00, 0x52, 0x58, 0x20, 0xb0,
+	0x10, 0x23, 0x42, 0x59, 0x2d, 0xb0, 0x11, 0x2c, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63,
+	0x20, 0xb8, 0x04, 0x00, 0x63, 0x8a, 0x23, 0x61, 0xb0, 0x11, 0x43, 0x60, 0x20, 0x8a, 0x60, 0x20,
+	0xb0, 0x11, 0x23, 0x42, 0x23, 0x2d, 0xb0, 0x12, 0x2c, 0x4b, 0x54, 0x58, 0xb1, 0x04, 0x64, 0x44,
+	0x59, 0x24, 0xb0, 0x0d, 0x65, 0x23, 0x78, 0x2d, 0xb0, 0x13, 0x2c, 0x4b, 0x51, 0x58, 0x4b, 0x53,
+	0x58, 0xb1, 0x04, 0x64, 0x44, 0x59, 0x1b, 0x21, 0x59, 0x24, 0xb0, 0x13, 0x65, 0x23, 0x78, 0x2d,
+	0xb0, 0x14, 0x2c, 0xb1, 0x00, 0x12, 0x43, 0x55, 0x58, 0xb1, 0x12, 0x12, 0x43, 0xb0, 0x01, 0x61,
+	0x42, 0xb0, 0x11, 0x2b, 0x59, 0xb0, 0x00, 0x43, 0xb0, 0x02, 0x25, 0x42, 0xb1, 0x0f, 0x02, 0x25,
+	0x42, 0xb1, 0x10, 0x02, 0x25, 0x42, 0xb0, 0x01, 0x16, 0x23, 0x20, 0xb0, 0x03, 0x25, 0x50, 0x58,
+	0xb1, 0x01, 0x00, 0x43, 0x60, 0xb0, 0x04, 0x25, 0x42, 0x8a, 0x8a, 0x20, 0x8a, 0x23, 0x61, 0xb0,
+	0x10, 0x2a, 0x21, 0x23, 0xb0, 0x01, 0x61, 0x20, 0x8a, 0x23, 0x61, 0xb0, 0x10, 0x2a, 0x21, 0x1b,
+	0xb1, 0x01, 0x00, 0x43, 0x60, 0xb0, 0x02, 0x25, 0x42, 0xb0, 0x02, 0x25, 0x61, 0xb0, 0x10, 0x2a,
+	0x21, 0x59, 0xb0, 0x0f, 0x43, 0x47, 0xb0, 0x10, 0x43, 0x47, 0x60, 0xb0, 0x02, 0x62, 0x20, 0xb0,
+	0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x20, 0xb0, 0x0e, 0x43, 0x63,
+	0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01,
+	0x63, 0x60, 0xb1, 0x00, 0x00, 0x13, 0x23, 0x44, 0xb0, 0x01, 0x43, 0xb0, 0x00, 0x3e, 0xb2, 0x01,
+	0x01, 0x01, 0x43, 0x60, 0x42, 0x2d, 0xb0, 0x15, 0x2c, 0x00, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58,
+	0xb0, 0x12, 0x23, 0x42, 0x20, 0x45, 0xb0, 0x0e, 0x23, 0x42, 0xb0, 0x0d, 0x23, 0xb0, 0x05, 0x60,
+	0x42, 0x20, 0x60, 0xb7, 0x18, 0x18, 0x01, 0x00, 0x11, 0x00, 0x13, 0x00, 0x42, 0x42, 0x42, 0x8a,
+	0x60, 0x20, 0xb0, 0x14, 0x23, 0x42, 0xb0, 0x01, 0x61, 0xb1, 0x14, 0x08, 0x2b, 0xb0, 0x8b, 0x2b,
+	0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x16, 0x2c, 0xb1, 0x00, 0x15, 0x2b, 0x2d, 0xb0, 0x17, 0x2c, 0xb1,
+	0x01, 0x15, 0x2b, 0x2d, 0xb0, 0x18, 0x2c, 0xb1, 0x02, 0x15, 0x2b, 0x2d, 0xb0, 0x19, 0x2c, 0xb1,
+	0x03, 0x15, 0x2b, 0x2d, 0xb0, 0x1a, 0x2c, 0xb1, 0x04, 0x15, 0x2b, 0x2d, 0xb0, 0x1b, 0x2c, 0xb1,
+	0x05, 0x15, 0x2b, 0x2d, 0xb0, 0x1c, 0x2c, 0xb1, 0x06, 0x15, 0x2b, 0x2d, 0xb0, 0x1d, 0x2c, 0xb1,
+	0x07, 0x15, 0x2b, 0x2d, 0xb0, 0x1e, 0x2c, 0xb1, 0x08, 0x15, 0x2b, 0x2d, 0xb0, 0x1f, 0x2c, 0xb1,
+	0x09, 0x15, 0x2b, 0x2d, 0xb0, 0x2b, 0x2c, 0x23, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63,
+	0xb0, 0x06, 0x60, 0x4b, 0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0, 0x01, 0x5d, 0x1b, 0x21, 0x21, 0x59,
+	0x2d, 0xb0, 0x2c, 0x2c, 0x23, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x16, 0x60,
+	0x4b, 0x54, 0x58, 0x23, 0x20, 0x2e, 0xb0, 0x01, 0x71, 0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0, 0x2d,
+	0x2c, 0x23, 0x20, 0xb0, 0x10, 0x62, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x26, 0x60, 0x4b, 0x54, 0x58,
+	0x23, 0x20, 0x2e, 0xb0, 0x01, 0x72, 0x1b, 0x21, 0x21, 0x59, 0x2d, 0xb0, 0x20, 0x2c, 0x00, 0xb0,
+	0x0f, 0x2b, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb0, 0x12, 0x23, 0x42, 0x20, 0x45, 0xb0, 0x0e,
+	0x23, 0x42, 0xb0, 0x0d, 0x23, 0xb0, 0x05, 0x60, 0x42, 0x20, 0x60, 0xb0, 0x01, 0x61, 0xb5, 0x18,
+	0x18, 0x01, 0x00, 0x11, 0x00, 0x42, 0x42, 0x8a, 0x60, 0xb1, 0x14, 0x08, 0x2b, 0xb0, 0x8b, 0x2b,
+	0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x21, 0x2c, 0xb1, 0x00, 0x20, 0x2b, 0x2d, 0xb0, 0x22, 0x2c, 0xb1,
+	0x01, 0x20, 0x2b, 0x2d, 0xb0, 0x23, 0x2c, 0xb1, 0x02, 0x20, 0x2b, 0x2d, 0xb0, 0x24, 0x2c, 0xb1,
+	0x03, 0x20, 0x2b, 0x2d, 0xb0, 0x25, 0x2c, 0xb1, 0x04, 0x20, 0x2b, 0x2d, 0xb0, 0x26, 0x2c, 0xb1,
+	0x05, 0x20, 0x2b, 0x2d, 0xb0, 0x27, 0x2c, 0xb1, 0x06, 0x20, 0x2b, 0x2d, 0xb0, 0x28, 0x2c, 0xb1,
+	0x07, 0x20, 0x2b, 0x2d, 0xb0, 0x29, 0x2c, 0xb1, 0x08, 0x20, 0x2b, 0x2d, 0xb0, 0x2a, 0x2c, 0xb1,
+	0x09, 0x20, 0x2b, 0x2d, 0xb0, 0x2e, 0x2c, 0x20, 0x3c, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x2f, 0x2c,
+	0x20, 0x60, 0xb0, 0x18, 0x60, 0x20, 0x43, 0x23, 0xb0, 0x01, 0x60, 0x43, 0xb0, 0x02, 0x25, 0x61,
+	0xb0, 0x01, 0x60, 0xb0, 0x2e, 0x2a, 0x21, 0x2d, 0xb0, 0x30, 0x2c, 0xb0, 0x2f, 0x2b, 0xb0, 0x2f,
+	0x2a, 0x2d, 0xb0, 0x31, 0x2c, 0x20, 0x20, 0x47, 0x20, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04,
+	0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60,
+	0x23, 0x61, 0x38, 0x23, 0x20, 0x8a, 0x55, 0x58, 0x20, 0x47, 0x20, 0x20, 0xb0, 0x0e, 0x43, 0x63,
+	0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01,
+	0x63, 0x60, 0x23, 0x61, 0x38, 0x1b, 0x21, 0x59, 0x2d, 0xb0, 0x32, 0x2c, 0x00, 0xb1, 0x00, 0x02,
+	0x45, 0x54, 0x58, 0xb1, 0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x16, 0xb0, 0x31, 0x2a, 0xb1, 0x05,
+	0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x33, 0x2c, 0x00, 0xb0, 0x0f,
+	0x2b, 0xb1, 0x00, 0x02, 0x45, 0x54, 0x58, 0xb1, 0x0e, 0x06, 0x45, 0x42, 0xb0, 0x01, 0x16, 0xb0,
+	0x31, 0x2a, 0xb1, 0x05, 0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x1b, 0x22, 0x59, 0x2d, 0xb0, 0x34,
+	0x2c, 0x20, 0x35, 0xb0, 0x01, 0x60, 0x2d, 0xb0, 0x35, 0x2c, 0x00, 0xb1, 0x0e, 0x06, 0x45, 0x42,
+	0xb0, 0x01, 0x45, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60,
+	0x59, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x01, 0x2b, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62,
+	0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0xb0, 0x01, 0x2b,
+	0xb0, 0x00, 0x16, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x00, 0x44, 0x3e, 0x23, 0x38, 0xb1, 0x34, 0x01,
+	0x15, 0x2a, 0x21, 0x2d, 0xb0, 0x36, 0x2c, 0x20, 0x3c, 0x20, 0x47, 0x20, 0xb0, 0x0e, 0x43, 0x63,
+	0xb8, 0x04, 0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01,
+	0x63, 0x60, 0xb0, 0x00, 0x43, 0x61, 0x38, 0x2d, 0xb0, 0x37, 0x2c, 0x2e, 0x17, 0x3c, 0x2d, 0xb0,
+	0x38, 0x2c, 0x20, 0x3c, 0x20, 0x47, 0x20, 0xb0, 0x0e, 0x43, 0x63, 0xb8, 0x04, 0x00, 0x62, 0x20,
+	0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0xb0, 0x00, 0x43,
+	0x61, 0xb0, 0x01, 0x43, 0x63, 0x38, 0x2d, 0xb0, 0x39, 0x2c, 0xb1, 0x02, 0x00, 0x16, 0x25, 0x20,
+	0x2e, 0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb0, 0x02, 0x25, 0x49, 0x8a, 0x8a, 0x47, 0x23, 0x47,
+	0x23, 0x61, 0x20, 0x58, 0x62, 0x1b, 0x21, 0x59, 0xb0, 0x01, 0x23, 0x42, 0xb2, 0x38, 0x01, 0x01,
+	0x15, 0x14, 0x2a, 0x2d, 0xb0, 0x3a, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0xb0, 0x04,
+	0x25, 0xb0, 0x04, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb1, 0x0c, 0x00, 0x42, 0xb0, 0x0b, 0x43,
+	0x2b, 0x65, 0x8a, 0x2e, 0x23, 0x20, 0x20, 0x3c, 0x8a, 0x38, 0x2d, 0xb0, 0x3b, 0x2c, 0xb0, 0x00,
+	0x16, 0xb0, 0x17, 0x23, 0x42, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25, 0x20, 0x2e, 0x47, 0x23, 0x47,
+	0x23, 0x61, 0x20, 0xb0, 0x06, 0x23, 0x42, 0xb1, 0x0c, 0x00, 0x42, 0xb0, 0x0b, 0x43, 0x2b, 0x20,
+	0xb0, 0x60, 0x50, 0x58, 0x20, 0xb0, 0x40, 0x51, 0x58, 0xb3, 0x04, 0x20, 0x05, 0x20, 0x1b, 0xb3,
+	0x04, 0x26, 0x05, 0x1a, 0x59, 0x42, 0x42, 0x23, 0x20, 0xb0, 0x0a, 0x43, 0x20, 0x8a, 0x23, 0x47,
+	0x23, 0x47, 0x23, 0x61, 0x23, 0x46, 0x60, 0xb0, 0x06, 0x43, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00,
+	0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60, 0x20, 0xb0, 0x01, 0x2b, 0x20,
+	0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x04, 0x43, 0x60, 0x64, 0x23, 0xb0, 0x05, 0x43, 0x61, 0x64, 0x50,
+	0x58, 0xb0, 0x04, 0x43, 0x61, 0x1b, 0xb0, 0x05, 0x43, 0x60, 0x59, 0xb0, 0x03, 0x25, 0xb0, 0x02,
+	0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x61, 0x23,
+	0x20, 0x20, 0xb0, 0x04, 0x26, 0x23, 0x46, 0x61, 0x38, 0x1b, 0x23, 0xb0, 0x0a, 0x43, 0x46, 0xb0,
+	0x02, 0x25, 0xb0, 0x0a, 0x43, 0x47, 0x23, 0x47, 0x23, 0x61, 0x60, 0x20, 0xb0, 0x06, 0x43, 0xb0,
+	0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60,
+	0x23, 0x20, 0xb0, 0x01, 0x2b, 0x23, 0xb0, 0x06, 0x43, 0x60, 0xb0, 0x01, 0x2b, 0xb0, 0x05, 0x25,
+	0x61, 0xb0, 0x05, 0x25, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59,
+	0x66, 0xb0, 0x01, 0x63, 0xb0, 0x04, 0x26, 0x61, 0x20, 0xb0, 0x04, 0x25, 0x60, 0x64, 0x23, 0xb0,
+	0x03, 0x25, 0x60, 0x64, 0x50, 0x58, 0x21, 0x1b, 0x23, 0x21, 0x59, 0x23, 0x20, 0x20, 0xb0, 0x04,
+	0x26, 0x23, 0x46, 0x61, 0x38, 0x59, 0x2d, 0xb0, 0x3c, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23,
+	0x42, 0x20, 0x20, 0x20, 0xb0, 0x05, 0x26, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0x23, 0x3c,
+	0x38, 0x2d, 0xb0, 0x3d, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0x20, 0xb0, 0x0a, 0x23,
+	0x42, 0x20, 0x20, 0x20, 0x46, 0x23, 0x47, 0xb0, 0x01, 0x2b, 0x23, 0x61, 0x38, 0x2d, 0xb0, 0x3e,
+	0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0xb0, 0x03, 0x25, 0xb0, 0x02, 0x25, 0x47, 0x23,
+	0x47, 0x23, 0x61, 0xb0, 0x00, 0x54, 0x58, 0x2e, 0x20, 0x3c, 0x23, 0x21, 0x1b, 0xb0, 0x02, 0x25,
+	0xb0, 0x02, 0x25, 0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0xb0, 0x05, 0x25, 0xb0, 0x04, 0x25, 0x47,
+	0x23, 0x47, 0x23, 0x61, 0xb0, 0x06, 0x25, 0xb0, 0x05, 0x25, 0x49, 0xb0, 0x02, 0x25, 0x61, 0xb9,
+	0x08, 0x00, 0x08, 0x00, 0x63, 0x63, 0x23, 0x20, 0x58, 0x62, 0x1b, 0x21, 0x59, 0x63, 0xb8, 0x04,
+	0x00, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x60,
+	0x23, 0x2e, 0x23, 0x20, 0x20, 0x3c, 0x8a, 0x38, 0x23, 0x21, 0x59, 0x2d, 0xb0, 0x3f, 0x2c, 0xb0,
+	0x00, 0x16, 0xb0, 0x17, 0x23, 0x42, 0x20, 0xb0, 0x0a, 0x43, 0x20, 0x2e, 0x47, 0x23, 0x47, 0x23,
+	0x61, 0x20, 0x60, 0xb0, 0x20, 0x60, 0x66, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0,
+	0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63, 0x23, 0x20, 0x20, 0x3c, 0x8a, 0x38, 0x2d, 0xb0, 0x40,
+	0x2c, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52,
+	0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x41, 0x2c, 0x23,
+	0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x52, 0x1b, 0x50, 0x59, 0x58,
+	0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x42, 0x2c, 0x23, 0x20, 0x2e,
+	0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c,
+	0x59, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x52, 0x1b, 0x50,
+	0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x43, 0x2c, 0xb0,
+	0x3a, 0x2b, 0x23, 0x20, 0x2e, 0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b,
+	0x52, 0x59, 0x58, 0x20, 0x3c, 0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x44, 0x2c,
+	0xb0, 0x3b, 0x2b, 0x8a, 0x20, 0x20, 0x3c, 0xb0, 0x06, 0x23, 0x42, 0x8a, 0x38, 0x23, 0x20, 0x2e,
+	0x46, 0xb0, 0x02, 0x25, 0x46, 0xb0, 0x17, 0x43, 0x58, 0x50, 0x1b, 0x52, 0x59, 0x58, 0x20, 0x3c,
+	0x59, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0xb0, 0x06, 0x43, 0x2e, 0xb0, 0x30, 0x2b, 0x2d, 0xb0,
+	0x45, 0x2c, 0xb0, 0x00, 0x16, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x26, 0x20, 0x20, 0x20, 0x46, 0x23,
+	0x47, 0x61, 0xb0, 0x0c, 0x23, 0x42, 0x2e, 0x47, 0x23, 0x47, 0x23, 0x61, 0xb0, 0x0b, 0x43, 0x2b,
+	0x23, 0x20, 0x3c, 0x20, 0x2e, 0x23, 0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x46, 0x2c,
+	0xb1, 0x0a, 0x04, 0x25, 0x42, 0xb0, 0x00, 0x16, 0xb0, 0x04, 0x25, 0xb0, 0x04, 0x25, 0x20, 0x2e,
+	0x47, 0x23, 0x47, 0x23, 0x61, 0x20, 0xb0, 0x06, 0x23, 0x42, 0xb1, 0x0c, 0x00, 0x42, 0xb0, 0x0b,
+	0x43, 0x2b, 0x20, 0xb0, 0x60, 0x50, 0x58, 0x20, 0xb0, 0x40, 0x51, 0x58, 0xb3, 0x04, 0x20, 0x05,
+	0x20, 0x1b, 0xb3, 0x04, 0x26, 0x05, 0x1a, 0x59, 0x42, 0x42, 0x23, 0x20, 0x47, 0xb0, 0x06, 0x43,
+	0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59, 0x66, 0xb0, 0x01, 0x63,
+	0x60, 0x20, 0xb0, 0x01, 0x2b, 0x20, 0x8a, 0x8a, 0x61, 0x20, 0xb0, 0x04, 0x43, 0x60, 0x64, 0x23,
+	0xb0, 0x05, 0x43, 0x61, 0x64, 0x50, 0x58, 0xb0, 0x04, 0x43, 0x61, 0x1b, 0xb0, 0x05, 0x43, 0x60,
+	0x59, 0xb0, 0x03, 0x25, 0xb0, 0x02, 0x62, 0x20, 0xb0, 0x00, 0x50, 0x58, 0xb0, 0x40, 0x60, 0x59,
+	0x66, 0xb0, 0x01, 0x63, 0x61, 0xb0, 0x02, 0x25, 0x46, 0x61, 0x38, 0x23, 0x20, 0x3c, 0x23, 0x38,
+	0x1b, 0x21, 0x20, 0x20, 0x46, 0x23, 0x47, 0xb0, 0x01, 0x2b, 0x23, 0x61, 0x38, 0x21, 0x59, 0xb1,
+	0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x47, 0x2c, 0xb1, 0x00, 0x3a, 0x2b, 0x2e, 0xb1, 0x30, 0x01,
+	0x14, 0x2b, 0x2d, 0xb0, 0x48, 0x2c, 0xb1, 0x00, 0x3b, 0x2b, 0x21, 0x23, 0x20, 0x20, 0x3c, 0xb0,
+	0x06, 0x23, 0x42, 0x23, 0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0xb0, 0x06, 0x43, 0x2e, 0xb0, 0x30,
+	0x2b, 0x2d, 0xb0, 0x49, 0x2c, 0xb0, 0x00, 0x15, 0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb2, 0x00,
+	0x01, 0x01, 0x15, 0x14, 0x13, 0x2e, 0xb0, 0x36, 0x2a, 0x2d, 0xb0, 0x4a, 0x2c, 0xb0, 0x00, 0x15,
+	0x20, 0x47, 0xb0, 0x00, 0x23, 0x42, 0xb2, 0x00, 0x01, 0x01, 0x15, 0x14, 0x13, 0x2e, 0xb0, 0x36,
+	0x2a, 0x2d, 0xb0, 0x4b, 0x2c, 0xb1, 0x00, 0x01, 0x14, 0x13, 0xb0, 0x37, 0x2a, 0x2d, 0xb0, 0x4c,
+	0x2c, 0xb0, 0x39, 0x2a, 0x2d, 0xb0, 0x4d, 0x2c, 0xb0, 0x00, 0x16, 0x45, 0x23, 0x20, 0x2e, 0x20,
+	0x46, 0x8a, 0x23, 0x61, 0x38, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x4e, 0x2c, 0xb0, 0x0a,
+	0x23, 0x42, 0xb0, 0x4d, 0x2b, 0x2d, 0xb0, 0x4f, 0x2c, 0xb2, 0x00, 0x00, 0x46, 0x2b, 0x2d, 0xb0,
+	0x50, 0x2c, 0xb2, 0x00, 0x01, 0x46, 0x2b, 0x2d, 0xb0, 0x51, 0x2c, 0xb2, 0x01, 0x00, 0x46, 0x2b,
+	0x2d, 0xb0, 0x52, 0x2c, 0xb2, 0x01, 0x01, 0x46, 0x2b, 0x2d, 0xb0, 0x53, 0x2c, 0xb2, 0x00, 0x00,
+	0x47, 0x2b, 0x2d, 0xb0, 0x54, 0x2c, 0xb2, 0x00, 0x01, 0x47, 0x2b, 0x2d, 0xb0, 0x55, 0x2c, 0xb2,
+	0x01, 0x00, 0x47, 0x2b, 0x2d, 0xb0, 0x56, 0x2c, 0xb2, 0x01, 0x01, 0x47, 0x2b, 0x2d, 0xb0, 0x57,
+	0x2c, 0xb3, 0x00, 0x00, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x58, 0x2c, 0xb3, 0x00, 0x01, 0x00, 0x43,
+	0x2b, 0x2d, 0xb0, 0x59, 0x2c, 0xb3, 0x01, 0x00, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x5a, 0x2c, 0xb3,
+	0x01, 0x01, 0x00, 0x43, 0x2b, 0x2d, 0xb0, 0x5b, 0x2c, 0xb3, 0x00, 0x00, 0x01, 0x43, 0x2b, 0x2d,
+	0xb0, 0x5c, 0x2c, 0xb3, 0x00, 0x01, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5d, 0x2c, 0xb3, 0x01, 0x00,
+	0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5e, 0x2c, 0xb3, 0x01, 0x01, 0x01, 0x43, 0x2b, 0x2d, 0xb0, 0x5f,
+	0x2c, 0xb2, 0x00, 0x00, 0x45, 0x2b, 0x2d, 0xb0, 0x60, 0x2c, 0xb2, 0x00, 0x01, 0x45, 0x2b, 0x2d,
+	0xb0, 0x61, 0x2c, 0xb2, 0x01, 0x00, 0x45, 0x2b, 0x2d, 0xb0, 0x62, 0x2c, 0xb2, 0x01, 0x01, 0x45,
+	0x2b, 0x2d, 0xb0, 0x63, 0x2c, 0xb2, 0x00, 0x00, 0x48, 0x2b, 0x2d, 0xb0, 0x64, 0x2c, 0xb2, 0x00,
+	0x01, 0x48, 0x2b, 0x2d, 0xb0, 0x65, 0x2c, 0xb2, 0x01, 0x00, 0x48, 0x2b, 0x2d, 0xb0, 0x66, 0x2c,
+	0xb2, 0x01, 0x01, 0x48, 0x2b, 0x2d, 0xb0, 0x67, 0x2c, 0xb3, 0x00, 0x00, 0x00, 0x44, 0x2b, 0x2d,
+	0xb0, 0x68, 0x2c, 0xb3, 0x00, 0x01, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x69, 0x2c, 0xb3, 0x01, 0x00,
+	0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x6a, 0x2c, 0xb3, 0x01, 0x01, 0x00, 0x44, 0x2b, 0x2d, 0xb0, 0x6b,
+	0x2c, 0xb3, 0x00, 0x00, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6c, 0x2c, 0xb3, 0x00, 0x01, 0x01, 0x44,
+	0x2b, 0x2d, 0xb0, 0x6d, 0x2c, 0xb3, 0x01, 0x00, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6e, 0x2c, 0xb3,
+	0x01, 0x01, 0x01, 0x44, 0x2b, 0x2d, 0xb0, 0x6f, 0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0x2e, 0xb1, 0x30,
+	0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x70, 0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0,
+	0x71, 0x2c, 0xb1, 0x00, 0x3c, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x72, 0x2c, 0xb0, 0x00, 0x16,
+	0xb1, 0x00, 0x3c, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x73, 0x2c, 0xb1, 0x01, 0x3c, 0x2b, 0xb0,
+	0x40, 0x2b, 0x2d, 0xb0, 0x74, 0x2c, 0xb1, 0x01, 0x3c, 0x2b, 0xb0, 0x41, 0x2b, 0x2d, 0xb0, 0x75,
+	0x2c, 0xb0, 0x00, 0x16, 0xb1, 0x01, 0x3c, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x76, 0x2c, 0xb1,
+	0x00, 0x3d, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x77, 0x2c, 0xb1, 0x00, 0x3d,
+	0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x78, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0xb0, 0x41, 0x2b, 0x2d,
+	0xb0, 0x79, 0x2c, 0xb1, 0x00, 0x3d, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x7a, 0x2c, 0xb1, 0x01,
+	0x3d, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x7b, 0x2c, 0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x41, 0x2b,
+	0x2d, 0xb0, 0x7c, 0x2c, 0xb1, 0x01, 0x3d, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x7d, 0x2c, 0xb1,
+	0x00, 0x3e, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x7e, 0x2c, 0xb1, 0x00, 0x3e,
+	0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x7f, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0xb0, 0x41, 0x2b, 0x2d,
+	0xb0, 0x80, 0x2c, 0xb1, 0x00, 0x3e, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x81, 0x2c, 0xb1, 0x01,
+	0x3e, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x82, 0x2c, 0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x41, 0x2b,
+	0x2d, 0xb0, 0x83, 0x2c, 0xb1, 0x01, 0x3e, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x84, 0x2c, 0xb1,
+	0x00, 0x3f, 0x2b, 0x2e, 0xb1, 0x30, 0x01, 0x14, 0x2b, 0x2d, 0xb0, 0x85, 0x2c, 0xb1, 0x00, 0x3f,
+	0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x86, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0xb0, 0x41, 0x2b, 0x2d,
+	0xb0, 0x87, 0x2c, 0xb1, 0x00, 0x3f, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x88, 0x2c, 0xb1, 0x01,
+	0x3f, 0x2b, 0xb0, 0x40, 0x2b, 0x2d, 0xb0, 0x89, 0x2c, 0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x41, 0x2b,
+	0x2d, 0xb0, 0x8a, 0x2c, 0xb1, 0x01, 0x3f, 0x2b, 0xb0, 0x42, 0x2b, 0x2d, 0xb0, 0x8b, 0x2c, 0xb2,
+	0x0b, 0x00, 0x03, 0x45, 0x50, 0x58, 0xb0, 0x06, 0x1b, 0xb2, 0x04, 0x02, 0x03, 0x45, 0x58, 0x23,
+	0x21, 0x1b, 0x21, 0x59, 0x59, 0x42, 0x2b, 0xb0, 0x08, 0x65, 0xb0, 0x03, 0x24, 0x50, 0x78, 0xb1,
+	0x05, 0x01, 0x15, 0x45, 0x58, 0x30, 0x59, 0x2d, 0x00, 0x4b, 0xb8, 0x00, 0xc8, 0x52, 0x58, 0xb1,
+	0x01, 0x01, 0x8e, 0x59, 0xb0, 0x01, 0xb9, 0x08, 0x00, 0x08, 0x00, 0x63, 0x70, 0xb1, 0x00, 0x07,
+	0x42, 0xb6, 0x00, 0x4e, 0x41, 0x31, 0x21, 0x05, 0x00, 0x2a, 0xb1, 0x00, 0x07, 0x42, 0x40, 0x0c,
+	0x52, 0x04, 0x46, 0x06, 0x36, 0x08, 0x26, 0x08, 0x18, 0x07, 0x05, 0x0a, 0x2a, 0xb1, 0x00, 0x07,
+	0x42, 0x40, 0x0c, 0x56, 0x02, 0x4c, 0x04, 0x3e, 0x06, 0x2e, 0x06, 0x1f, 0x05, 0x05, 0x0a, 0x2a,
+	0xb1, 0x00, 0x0c, 0x42, 0xbe, 0x14, 0xc0, 0x11, 0xc0, 0x0d, 0xc0, 0x09, 0xc0, 0x06, 0x40, 0x00,
+	0x05, 0x00, 0x0b, 0x2a, 0xb1, 0x00, 0x11, 0x42, 0xbe, 0x00, 0x40, 0x00, 0x40, 0x00, 0x40, 0x00,
+	0x40, 0x00, 0x40, 0x00, 0x05, 0x00, 0x0b, 0x2a, 0xb9, 0x00, 0x03, 0x00, 0x00, 0x44, 0xb1, 0x24,
+	0x01, 0x88, 0x51, 0x58, 0xb0, 0x40, 0x88, 0x58, 0xb9, 0x00, 0x03, 0x00, 0x64, 0x44, 0xb1, 0x28,
+	0x01, 0x88, 0x51, 0x58, 0xb8, 0x08, 0x00, 0x88, 0x58, 0xb9, 0x00, 0x03, 0x00, 0x00, 0x44, 0x59,
+	0x1b, 0xb1, 0x27, 0x01, 0x88, 0x51, 0x58, 0xba, 0x08, 0x80, 0x00, 0x01, 0x04, 0x40, 0x88, 0x63,
+	0x54, 0x58, 0xb9, 0x00, 0x03, 0x00, 0x00, 0x44, 0x59, 0x59, 0x59, 0x59, 0x59, 0x40, 0x0c, 0x54,
+	0x02, 0x48, 0x04, 0x38, 0x06, 0x28, 0x06, 0x1a, 0x05, 0x05, 0x0e, 0x2a, 0xb8, 0x01, 0xff, 0x85,
+	0xb0, 0x04, 0x8d, 0xb1, 0x02, 0x00, 0x44, 0xb3, 0x05, 0x64, 0x06, 0x00, 0x44, 0x44, 0x00, 0x00,
+}
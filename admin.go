@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// maxStatsTop is the most rejected names the stats can list.
+var maxStatsTop = 100
+
+// adminMux serves what's only for the people running the site. It's meant
+// for a listener that isn't exposed to the public.
+func adminMux(db *DB, templates templateLoader, reqs *requestMetrics) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", methods(map[string]http.HandlerFunc{"GET": metricsHandler(db, reqs)}))
+	mux.Handle("/stats", methods(map[string]http.HandlerFunc{"GET": adminStats(db)}))
+	mux.Handle("/history", methods(map[string]http.HandlerFunc{"GET": adminHistory(db), "DELETE": adminPurge(db)}))
+	mux.Handle("/", methods(map[string]http.HandlerFunc{"GET": adminDashboard(db, templates)}))
+	return mux
+}
+
+// statsTop reads how many rejected names to list from the query.
+func statsTop(r *http.Request) (int, *apiError) {
+	s := r.URL.Query().Get("top")
+	if s == "" {
+		return 10, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > maxStatsTop {
+		return 0, errInvalid(fmt.Sprintf("top must be a number between 1 and %d.", maxStatsTop))
+	}
+	return n, nil
+}
+
+func adminStats(db *DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		top, apiErr := statsTop(r)
+		if apiErr != nil {
+			writeAPIError(w, apiErr)
+			return
+		}
+		writeJSON(w, http.StatusOK, db.Stats(top))
+	}
+}
+
+// adminHistory lists the whole history, even when it's private.
+func adminHistory(db *DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		goods, bads := db.Last(queueSize)
+		writeJSON(w, http.StatusOK, historyResponse{Goods: goods, Bads: bads})
+	}
+}
+
+type purgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// adminPurge forgets the pkgname of the query from the history, or the
+// whole history if there's none.
+func adminPurge(db *DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("pkgname")
+		all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+		if (name == "") == !all {
+			writeAPIError(w, errInvalid("Give the pkgname to forget, or all=true to forget them all."))
+			return
+		}
+		n := db.Purge(name)
+		slog.InfoContext(r.Context(), "Purged history", "all", name == "", "count", n)
+		writeJSON(w, http.StatusOK, purgeResponse{Purged: n})
+	}
+}
+
+type dashboardData struct {
+	Stats statsResponse
+	// Nonce lets the inline style of the page apply.
+	Nonce string
+}
+
+func adminDashboard(db *DB, templates templateLoader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		top, apiErr := statsTop(r)
+		if apiErr != nil {
+			http.Error(w, apiErr.Message, apiErr.Status)
+			return
+		}
+
+		nonce, err := newNonce()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't make a nonce", "err", err)
+			http.Error(w, "Something went wrong on our side.", http.StatusInternalServerError)
+			return
+		}
+
+		tmpl, err := templates()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't load templates", "err", err)
+			http.Error(w, "Something went wrong on our side.", http.StatusInternalServerError)
+			return
+		}
+
+		buf := bytes.NewBuffer(nil)
+		data := dashboardData{Stats: db.Stats(top), Nonce: nonce}
+		if err := tmpl.ExecuteTemplate(buf, "admin.html", data); err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't render dashboard", "err", err)
+			http.Error(w, "Something went wrong on our side.", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'nonce-"+nonce+"'; base-uri 'none'; frame-ancestors 'none'")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "HEAD" {
+			return
+		}
+		if _, err := buf.WriteTo(w); err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't send dashboard to client", "err", err)
+		}
+	}
+}
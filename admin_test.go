@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestAdmin(t *testing.T, db *DB) http.Handler {
+	templates, err := onceTemplates(templatesFS(), func(name string) string { return "/" + name })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return adminMux(db, templates, newRequestMetrics())
+}
+
+func TestAdminStats(t *testing.T) {
+	db := NewDB()
+	db.Validate(context.Background(), "", "go-lime", true)
+	db.Validate(context.Background(), "", "go-lime", true)
+	db.Validate(context.Background(), "", "lime", true)
+	h := newTestAdmin(t, db)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/stats?top=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var got statsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Validations != 3 || got.Passed != 1 || len(got.Days) != 1 {
+		t.Errorf("want 3 validations today, got %+v", got)
+	}
+	if len(got.TopRejected) != 1 || got.TopRejected[0] != (nameStats{"go-lime", 2}) {
+		t.Errorf("want go-lime on top, got %v", got.TopRejected)
+	}
+
+	for _, top := range []string{"0", "x", "101"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/stats?top="+top, nil))
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("top=%s: want 422, got %d", top, rr.Code)
+		}
+	}
+}
+
+func TestAdminHistory(t *testing.T) {
+	defer func(private bool) { privateHistory = private }(privateHistory)
+	privateHistory = true
+
+	db := NewDB()
+	for _, name := range []string{"lime", "go-lime", "lime", "kiwi"} {
+		db.Validate(context.Background(), "", name, true)
+	}
+	h := newTestAdmin(t, db)
+
+	history := func() historyResponse {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/history", nil))
+		var res historyResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+			t.Fatalf("decoding %q: %v", rr.Body.String(), err)
+		}
+		return res
+	}
+	purge := func(target string) int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("DELETE", target, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: want 200, got %d", target, rr.Code)
+		}
+		var res purgeResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+			t.Fatalf("decoding %q: %v", rr.Body.String(), err)
+		}
+		return res.Purged
+	}
+
+	if res := history(); len(res.Goods) != 3 || len(res.Bads) != 1 {
+		t.Fatalf("want the private history shown, got %+v", res)
+	}
+	if n := purge("/history?pkgname=lime"); n != 2 {
+		t.Errorf("want lime purged twice, got %d", n)
+	}
+	if res := history(); len(res.Goods) != 1 || res.Goods[0] != "kiwi" {
+		t.Errorf("want only kiwi left in goods, got %q", res.Goods)
+	}
+	for _, target := range []string{"/history", "/history?name=kiwi", "/history?all=false", "/history?pkgname=kiwi&all=true"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("DELETE", target, nil))
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: want 422, got %d", target, rr.Code)
+		}
+	}
+	if n := purge("/history?all=true"); n != 2 {
+		t.Errorf("want the rest purged, got %d", n)
+	}
+	if res := history(); len(res.Goods)+len(res.Bads) != 0 {
+		t.Errorf("want nothing left, got %+v", res)
+	}
+}
+
+func TestAdminDashboard(t *testing.T) {
+	db := NewDB()
+	db.Validate(context.Background(), "", "<b>go-lime</b>", true)
+	h := newTestAdmin(t, db)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("want a page, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{"1 validations", "0.0% of them passed", "no-reference-to-go", "&lt;b&gt;go-lime&lt;/b&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("want %q in dashboard, got %s", want, body)
+		}
+	}
+	if csp := rr.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "style-src 'nonce-") {
+		t.Errorf("want a CSP allowing the style, got %q", csp)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/nope", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("want 404, got %d", rr.Code)
+	}
+}
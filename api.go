@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aybabtme/pkgname/pkgname"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const apiPrefix = "/api/v1"
+
+const (
+	historyPrivateMsg = "History is private on this server."
+	invalidRecordMsg  = "record must be true or false."
+)
+
+// apiError is the single error model of the API. Code is stable and meant
+// for programs, Message is meant for humans.
+type apiError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string { return e.Message }
+
+type apiErrorBody struct {
+	Error *apiError `json:"error"`
+}
+
+type validateRequest struct {
+	Pkgname string `json:"pkgname"`
+	// Record is whether to record the name in the history, which it is
+	// unless told otherwise.
+	Record *bool `json:"record,omitempty"`
+	// Profile is the rule profile to check the name with, the default one
+	// if empty. It can also be given in the query.
+	Profile string `json:"profile,omitempty"`
+	// ImportPath is the import path of the package, for the rules that look
+	// at it.
+	ImportPath string `json:"import_path,omitempty"`
+	// Tone is the tone of the messages, snarky or professional, the one of
+	// the profile if empty. It can also be given in the query.
+	Tone string `json:"tone,omitempty"`
+	// Lang is the language of the messages, like fr or de, the one of the
+	// Accept-Language header if empty. It can also be given in the query.
+	Lang string `json:"lang,omitempty"`
+}
+
+type validateResponse struct {
+	Pkgname string   `json:"pkgname"`
+	Success bool     `json:"success"`
+	Causes  []string `json:"causes"`
+	// Violations are the causes along with their rule and severity.
+	Violations []violation `json:"violations"`
+}
+
+type historyResponse struct {
+	Goods []string `json:"goods"`
+	Bads  []string `json:"bads"`
+}
+
+type generateResponse struct {
+	Pkgname string `json:"pkgname"`
+}
+
+func errMethodNotAllowed(method string) *apiError {
+	return &apiError{http.StatusMethodNotAllowed, "method_not_allowed", fmt.Sprintf("Method %s is not allowed on this endpoint.", method)}
+}
+
+func errNotAcceptable(offers ...string) *apiError {
+	return &apiError{http.StatusNotAcceptable, "not_acceptable", "Can only respond with " + strings.Join(offers, " or ") + "."}
+}
+
+func errUnsupportedMediaType(accepted ...string) *apiError {
+	return &apiError{http.StatusUnsupportedMediaType, "unsupported_media_type", "Request body must be " + strings.Join(accepted, " or ") + "."}
+}
+
+func errMalformed(err error) *apiError {
+	return &apiError{http.StatusBadRequest, "malformed_request", "Malformed request: " + err.Error() + "."}
+}
+
+func errInvalid(msg string) *apiError {
+	return &apiError{http.StatusUnprocessableEntity, "invalid_input", msg}
+}
+
+func errHistoryPrivate() *apiError {
+	return &apiError{http.StatusForbidden, "history_private", historyPrivateMsg}
+}
+
+func errNotFound() *apiError {
+	return &apiError{http.StatusNotFound, "not_found", "No such endpoint."}
+}
+
+func errInternal() *apiError {
+	return &apiError{http.StatusInternalServerError, "internal", "Something went wrong on our side."}
+}
+
+// writeJSON sends v as a JSON document with the given status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("Couldn't encode response", "type", fmt.Sprintf("%T", v), "err", err)
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(apiErrorBody{errInternal()})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := w.Write(data); err != nil {
+		slog.Error("Couldn't send response to client", "type", fmt.Sprintf("%T", v), "err", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, e *apiError) {
+	writeJSON(w, e.Status, apiErrorBody{e})
+}
+
+// methods dispatches a request according to its method, answering 405 with
+// the list of allowed methods for the others. HEAD is served by GET.
+func methods(handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	var allowed []string
+	for m := range handlers {
+		allowed = append(allowed, m)
+	}
+	if _, ok := handlers["GET"]; ok {
+		if _, ok := handlers["HEAD"]; !ok {
+			allowed = append(allowed, "HEAD")
+		}
+	}
+	sort.Strings(allowed)
+	allow := strings.Join(allowed, ", ")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		h, ok := handlers[r.Method]
+		if !ok && r.Method == "HEAD" {
+			h, ok = handlers["GET"]
+		}
+		if !ok {
+			w.Header().Set("Allow", allow)
+			writeAPIError(w, errMethodNotAllowed(r.Method))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// produces rejects requests whose Accept header can't be satisfied by any of
+// the offered media types.
+func produces(f http.HandlerFunc, offers ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		if negotiate(r.Header.Get("Accept"), offers...) == "" {
+			writeAPIError(w, errNotAcceptable(offers...))
+			return
+		}
+		f(w, r)
+	}
+}
+
+// negotiate picks the offer the client prefers according to an Accept
+// header, or "" if none is acceptable. An empty header accepts anything.
+func negotiate(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediatype, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		for _, offer := range offers {
+			specificity := mediaMatch(mediatype, offer)
+			if specificity < 0 {
+				continue
+			}
+			if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+				best, bestQ, bestSpecificity = offer, q, specificity
+			}
+		}
+	}
+	return best
+}
+
+// mediaMatch tells how specifically pattern (which can have wildcards)
+// matches mediatype, or -1 if it doesn't.
+func mediaMatch(pattern, mediatype string) int {
+	switch {
+	case pattern == mediatype:
+		return 2
+	case pattern == "*/*":
+		return 0
+	case strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediatype, strings.TrimSuffix(pattern, "*")):
+		return 1
+	}
+	return -1
+}
+
+func apiV1(db *DB, batch http.HandlerFunc) http.Handler {
+	routes := apiRoutes(db, batch)
+	spec := openAPI(routes)
+	routes = append(routes, apiRoute{
+		Method:   "GET",
+		Path:     "/openapi.json",
+		Produces: []string{"application/json"},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, spec)
+		},
+	})
+
+	byPath := make(map[string]map[string]http.HandlerFunc)
+	var paths []string
+	for _, route := range routes {
+		if byPath[route.Path] == nil {
+			byPath[route.Path] = make(map[string]http.HandlerFunc)
+			paths = append(paths, route.Path)
+		}
+		byPath[route.Path][route.Method] = produces(route.Handler, route.Produces...)
+	}
+
+	mux := http.NewServeMux()
+	for _, path := range paths {
+		mux.HandleFunc(apiPrefix+path, methods(byPath[path]))
+	}
+	mux.HandleFunc(apiPrefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, errNotFound())
+	})
+	return mux
+}
+
+func apiValidate(db *DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req validateRequest
+
+		mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediatype {
+		case "application/json":
+			body := http.MaxBytesReader(w, r.Body, 1<<16)
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				writeAPIError(w, errMalformed(err))
+				return
+			}
+		case "application/x-www-form-urlencoded", "multipart/form-data":
+			req.Pkgname = r.FormValue("pkgname")
+			if s := r.FormValue("record"); s != "" {
+				record, err := strconv.ParseBool(s)
+				if err != nil {
+					writeAPIError(w, errInvalid(invalidRecordMsg))
+					return
+				}
+				req.Record = &record
+			}
+			req.Profile = r.FormValue("profile")
+			req.ImportPath = r.FormValue("import_path")
+			req.Tone = r.FormValue("tone")
+			req.Lang = r.FormValue("lang")
+		default:
+			writeAPIError(w, errUnsupportedMediaType("application/json", "application/x-www-form-urlencoded"))
+			return
+		}
+
+		if err := checkName(req.Pkgname); err != nil {
+			writeAPIError(w, errInvalid(err.Error()))
+			return
+		}
+		if req.Profile == "" {
+			req.Profile = r.URL.Query().Get("profile")
+		}
+		if req.Tone == "" {
+			req.Tone = r.URL.Query().Get("tone")
+		}
+		if req.Lang == "" {
+			req.Lang = r.URL.Query().Get("lang")
+		}
+		q := query{Profile: req.Profile, ImportPath: req.ImportPath, Tone: req.Tone, Lang: requestLang(w, r, req.Lang)}
+		if err := db.checkQuery(q); err != nil {
+			writeAPIError(w, errInvalid(err.Error()))
+			return
+		}
+
+		violations := db.Assess(r.Context(), req.Pkgname, q, req.Record == nil || *req.Record)
+		writeJSON(w, http.StatusOK, validateResponse{
+			Pkgname:    req.Pkgname,
+			Success:    pkgname.Passed(violations),
+			Causes:     causes(violations),
+			Violations: toViolations(violations),
+		})
+	}
+}
+
+func apiHistory(db *DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if privateHistory {
+			writeAPIError(w, errHistoryPrivate())
+			return
+		}
+		last := 10
+		if s := r.URL.Query().Get("last"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > queueSize {
+				writeAPIError(w, errInvalid(fmt.Sprintf("last must be a number between 1 and %d.", queueSize)))
+				return
+			}
+			last = n
+		}
+
+		goods, bads := db.Last(last)
+		writeJSON(w, http.StatusOK, historyResponse{Goods: goods, Bads: bads})
+	}
+}
+
+func apiGenerate(db *DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, generateResponse{Pkgname: db.Get()})
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/aybabtme/pkgname/pkgname"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "text/html"}
+
+	tests := []struct {
+		accept, want string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/html", "text/html"},
+		{"text/*", "text/html"},
+		{"text/html;q=0.5, application/json", "application/json"},
+		{"text/html, application/json;q=0.9", "text/html"},
+		{"*/*;q=0.1, text/html;q=0.2", "text/html"},
+		{"application/json;q=0", ""},
+		{"image/png", ""},
+		{"garbage;;", ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiate(tt.accept, offers...); got != tt.want {
+			t.Errorf("Accept %q: want %q, got %q", tt.accept, tt.want, got)
+		}
+	}
+}
+
+func doAPI(t *testing.T, h http.Handler, r *http.Request, v interface{}) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, r)
+	if v != nil {
+		if err := json.Unmarshal(rr.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: decoding %q: %v", r.Method, r.URL, rr.Body.String(), err)
+		}
+	}
+	return rr
+}
+
+func TestAPIValidate(t *testing.T) {
+	h := apiV1(NewDB(), validateBatch(NewDB(), nil))
+
+	r := httptest.NewRequest("POST", "/api/v1/validate", strings.NewReader(`{"pkgname": "go-lime"}`))
+	r.Header.Set("Content-Type", "application/json")
+	var res validateResponse
+	rr := doAPI(t, h, r, &res)
+	if rr.Code != http.StatusOK || res.Success || res.Pkgname != "go-lime" || len(res.Causes) != 2 {
+		t.Errorf("want go-lime to fail twice, got %d %+v", rr.Code, res)
+	}
+}
+
+func TestAPIValidateRecord(t *testing.T) {
+	db := NewDB()
+	h := apiV1(db, validateBatch(db, nil))
+
+	for _, body := range []string{`{"pkgname": "lime", "record": false}`, `{"pkgname": "lime"}`} {
+		r := httptest.NewRequest("POST", "/api/v1/validate", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		doAPI(t, h, r, &validateResponse{})
+	}
+	if goods, _ := db.Last(10); len(goods) != 1 {
+		t.Errorf("want a single name recorded, got %q", goods)
+	}
+}
+
+func TestAPIValidateProfile(t *testing.T) {
+	h := apiV1(NewDB(), validateBatch(NewDB(), nil))
+
+	tests := []struct {
+		target, body string
+		success      bool
+	}{
+		{"/api/v1/validate", `{"pkgname": "utils"}`, true},
+		{"/api/v1/validate", `{"pkgname": "utils", "profile": "strict"}`, false},
+		{"/api/v1/validate?profile=strict", `{"pkgname": "utils"}`, false},
+		{"/api/v1/validate?profile=strict", `{"pkgname": "utils", "profile": "default"}`, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/json")
+		var res validateResponse
+		rr := doAPI(t, h, r, &res)
+		if rr.Code != http.StatusOK || res.Success != tt.success {
+			t.Errorf("%s %s: want success=%v, got %d %+v", tt.target, tt.body, tt.success, rr.Code, res)
+		}
+	}
+
+	r := httptest.NewRequest("POST", "/api/v1/validate?profile=initech", strings.NewReader(`{"pkgname": "utils"}`))
+	r.Header.Set("Content-Type", "application/json")
+	var res apiErrorBody
+	if rr := doAPI(t, h, r, &res); rr.Code != http.StatusUnprocessableEntity || res.Error == nil || res.Error.Code != "invalid_input" {
+		t.Errorf("want 422 for an unknown profile, got %d %+v", rr.Code, res.Error)
+	}
+}
+
+func TestAPIValidateTone(t *testing.T) {
+	h := apiV1(NewDB(), validateBatch(NewDB(), nil))
+
+	for _, target := range []string{"/api/v1/validate?tone=professional", "/api/v1/validate"} {
+		body := `{"pkgname": "lime-green", "tone": "professional"}`
+		if strings.Contains(target, "?") {
+			body = `{"pkgname": "lime-green"}`
+		}
+		r := httptest.NewRequest("POST", target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		var res validateResponse
+		doAPI(t, h, r, &res)
+		if len(res.Causes) != 1 || res.Causes[0] != "Package names shouldn't contain hyphens." {
+			t.Errorf("%s %s: want a professional message, got %+v", target, body, res)
+		}
+	}
+
+	r := httptest.NewRequest("POST", "/api/v1/validate?tone=rude", strings.NewReader(`{"pkgname": "lime"}`))
+	r.Header.Set("Content-Type", "application/json")
+	var res apiErrorBody
+	if rr := doAPI(t, h, r, &res); rr.Code != http.StatusUnprocessableEntity || res.Error == nil || res.Error.Code != "invalid_input" {
+		t.Errorf("want 422 for an unknown tone, got %d %+v", rr.Code, res.Error)
+	}
+}
+
+func TestAPIValidateViolations(t *testing.T) {
+	defer func(defs []pkgname.RuleDef) { customRules = defs }(customRules)
+	customRules = []pkgname.RuleDef{{ID: "short", MaxLength: 6, Severity: "warning"}}
+	h := apiV1(NewDB(), validateBatch(NewDB(), nil))
+
+	tests := []struct {
+		pkgname string
+		success bool
+		want    []violation
+	}{
+		{"lime", true, nil},
+		{"limelight", true, []violation{
+			{"short", "This package name is too long, make it at most 6 characters long.", "warning"},
+		}},
+		{"lime-light", false, []violation{
+			{pkgname.RuleNoHyphens, "Don't put hyphens, that's ugly.", "error"},
+			{"short", "This package name is too long, make it at most 6 characters long.", "warning"},
+		}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/api/v1/validate", strings.NewReader(`{"pkgname": "`+tt.pkgname+`"}`))
+		r.Header.Set("Content-Type", "application/json")
+		var res validateResponse
+		doAPI(t, h, r, &res)
+		if res.Success != tt.success || !reflect.DeepEqual(res.Violations, tt.want) {
+			t.Errorf("%s: want success=%v %+v, got %+v", tt.pkgname, tt.success, tt.want, res)
+		}
+	}
+}
+
+func TestAPIValidateLang(t *testing.T) {
+	h := apiV1(NewDB(), validateBatch(NewDB(), nil))
+
+	tests := []struct {
+		target, body, acceptLanguage string
+		want                         string
+	}{
+		{"/api/v1/validate", `{"pkgname": "lime-green"}`, "", "Don't put hyphens, that's ugly."},
+		{"/api/v1/validate", `{"pkgname": "lime-green"}`, "de-DE,de;q=0.9", "Keine Bindestriche, das ist hässlich."},
+		{"/api/v1/validate", `{"pkgname": "lime-green", "lang": "fr"}`, "de", "Pas de tirets, c'est moche."},
+		{"/api/v1/validate?lang=fr&tone=professional", `{"pkgname": "lime-green"}`, "", "Les noms de paquets ne devraient pas contenir de tirets."},
+		{"/api/v1/validate?lang=es", `{"pkgname": "lime-green"}`, "", "Don't put hyphens, that's ugly."},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept-Language", tt.acceptLanguage)
+		var res validateResponse
+		rr := doAPI(t, h, r, &res)
+		if len(res.Causes) != 1 || res.Causes[0] != tt.want {
+			t.Errorf("%s %s %q: want %q, got %+v", tt.target, tt.body, tt.acceptLanguage, tt.want, res)
+		}
+		if rr.Header().Get("Content-Language") == "" {
+			t.Errorf("%s %s %q: want the language told", tt.target, tt.body, tt.acceptLanguage)
+		}
+	}
+}
+
+func TestAPIHistoryPrivate(t *testing.T) {
+	defer func(private bool) { privateHistory = private }(privateHistory)
+	privateHistory = true
+
+	db := NewDB()
+	h := apiV1(db, validateBatch(db, nil))
+	var res apiErrorBody
+	rr := doAPI(t, h, httptest.NewRequest("GET", "/api/v1/history", nil), &res)
+	if rr.Code != http.StatusForbidden || res.Error == nil || res.Error.Code != "history_private" {
+		t.Errorf("want 403 history_private, got %d %+v", rr.Code, res.Error)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	h := apiV1(NewDB(), validateBatch(NewDB(), nil))
+
+	jsonReq := func(method, path, body string) *http.Request {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+	notAcceptable := jsonReq("GET", "/api/v1/generate", "")
+	notAcceptable.Header.Set("Accept", "text/html")
+
+	tests := []struct {
+		r      *http.Request
+		status int
+		code   string
+	}{
+		{jsonReq("GET", "/api/v1/validate", ""), 405, "method_not_allowed"},
+		{jsonReq("POST", "/api/v1/validate", `{"pkgname": ""}`), 422, "invalid_input"},
+		{jsonReq("POST", "/api/v1/validate", `{"pkgname": "lime", "import_path": "example.com/my lime"}`), 422, "invalid_input"},
+		{jsonReq("POST", "/api/v1/validate", `{"pkgname": `), 400, "malformed_request"},
+		{httptest.NewRequest("POST", "/api/v1/validate", strings.NewReader("lime")), 415, "unsupported_media_type"},
+		{jsonReq("GET", "/api/v1/history?last=0", ""), 422, "invalid_input"},
+		{notAcceptable, 406, "not_acceptable"},
+		{jsonReq("GET", "/api/v1/nope", ""), 404, "not_found"},
+	}
+
+	for _, tt := range tests {
+		var res apiErrorBody
+		rr := doAPI(t, h, tt.r, &res)
+		if rr.Code != tt.status || res.Error == nil || res.Error.Status != tt.status || res.Error.Code != tt.code {
+			t.Errorf("%s %s: want %d %q, got %d %s", tt.r.Method, tt.r.URL, tt.status, tt.code, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestAPIMethodNotAllowedLists(t *testing.T) {
+	h := apiV1(NewDB(), validateBatch(NewDB(), nil))
+
+	rr := doAPI(t, h, httptest.NewRequest("DELETE", "/api/v1/history", nil), nil)
+	if got := rr.Header().Get("Allow"); got != "GET, HEAD" {
+		t.Errorf("want Allow: GET, HEAD, got %q", got)
+	}
+}
+
+func TestAPIOpenAPI(t *testing.T) {
+	h := apiV1(NewDB(), validateBatch(NewDB(), nil))
+
+	var spec struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}
+	rr := doAPI(t, h, httptest.NewRequest("GET", "/api/v1/openapi.json", nil), &spec)
+	if rr.Code != http.StatusOK || spec.OpenAPI == "" {
+		t.Fatalf("want a document, got %d", rr.Code)
+	}
+
+	for _, route := range apiRoutes(nil, nil) {
+		if _, ok := spec.Paths[route.Path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s isn't documented", route.Method, route.Path)
+		}
+	}
+}
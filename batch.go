@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/aybabtme/pkgname/pkgname"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// maxBatchNames is the most names a single batch can carry.
+	maxBatchNames = 10000
+	// maxBatchBytes bounds the size of a batch request body.
+	maxBatchBytes int64 = 1 << 20
+	// maxBatches is how many batches can be processed at once, after which
+	// clients are told to come back later.
+	maxBatches = 4
+	// batchFlushEvery is how many results are written between flushes.
+	batchFlushEvery = 100
+)
+
+type batchResult struct {
+	// Err tells why Pkgname couldn't be checked, or why the batch stops
+	// there if Pkgname is empty.
+	Err        *apiError   `json:"error,omitempty"`
+	Success    bool        `json:"success"`
+	Pkgname    string      `json:"pkgname"`
+	Causes     []string    `json:"causes"`
+	Violations []violation `json:"violations"`
+}
+
+// batchDecoder yields the names of a batch one at a time, whether they come
+// as a JSON array or as a stream of newline delimited JSON strings.
+type batchDecoder struct {
+	dec   *json.Decoder
+	first *string
+	array bool
+	done  bool
+}
+
+func newBatchDecoder(r io.Reader) (*batchDecoder, error) {
+	dec := json.NewDecoder(r)
+	b := &batchDecoder{dec: dec}
+
+	tok, err := dec.Token()
+	if err == io.EOF {
+		b.done = true
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok != '[' {
+			return nil, errors.New("batch must be a JSON array or a stream of JSON strings")
+		}
+		b.array = true
+		return b, nil
+	case string:
+		// NDJSON; the first name was consumed as a token, so hand it
+		// back on the first call to Next.
+		b.first = &tok
+		return b, nil
+	default:
+		return nil, errors.New("batch entries must be JSON strings")
+	}
+}
+
+func (b *batchDecoder) Next() (string, error) {
+	if b.first != nil {
+		name := *b.first
+		b.first = nil
+		return name, nil
+	}
+	if b.done {
+		return "", io.EOF
+	}
+
+	if b.array && !b.dec.More() {
+		b.done = true
+		if _, err := b.dec.Token(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+
+	var name string
+	if err := b.dec.Decode(&name); err != nil {
+		if err == io.EOF {
+			b.done = true
+		}
+		return "", err
+	}
+	return name, nil
+}
+
+// validateBatch validates a batch of names. Past the first, each name takes
+// a token of lim from the client like a request would, and waits for it
+// when there's none left.
+func validateBatch(db *DB, lim *limiter) http.HandlerFunc {
+	batches := make(chan struct{}, maxBatches)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case batches <- struct{}{}:
+			defer func() { <-batches }()
+		default:
+			w.Header().Set("Retry-After", "1")
+			writeAPIError(w, &apiError{http.StatusServiceUnavailable, "busy", "Too many batches in progress, try again later."})
+			return
+		}
+
+		var record bool
+		if s := r.URL.Query().Get("record"); s != "" {
+			var err error
+			if record, err = strconv.ParseBool(s); err != nil {
+				writeAPIError(w, errInvalid(invalidRecordMsg))
+				return
+			}
+		}
+		q := query{
+			Profile: r.URL.Query().Get("profile"),
+			Tone:    r.URL.Query().Get("tone"),
+			Lang:    requestLang(w, r, r.URL.Query().Get("lang")),
+		}
+		if err := db.checkQuery(q); err != nil {
+			writeAPIError(w, errInvalid(err.Error()))
+			return
+		}
+
+		if ct := r.Header.Get("Content-Type"); ct != "" {
+			mediatype, _, err := mime.ParseMediaType(ct)
+			switch {
+			case err != nil:
+			case mediatype == "application/json":
+			case mediatype == "application/x-ndjson":
+			case mediatype == "text/plain":
+			default:
+				err = errors.New("unsupported media type")
+			}
+			if err != nil {
+				writeAPIError(w, errUnsupportedMediaType("application/json", "application/x-ndjson"))
+				return
+			}
+		}
+
+		body := http.MaxBytesReader(w, r.Body, maxBatchBytes)
+		dec, err := newBatchDecoder(body)
+		if err != nil {
+			writeAPIError(w, errMalformed(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+
+		flusher, _ := w.(http.Flusher)
+		enc := json.NewEncoder(w)
+
+		for i := 0; ; i++ {
+			name, err := dec.Next()
+			switch {
+			case err == io.EOF:
+				return
+			case err != nil:
+				// Whatever follows can't be trusted, stop here.
+				writeBatchError(enc, "", errMalformed(err))
+				return
+			case i >= maxBatchNames:
+				writeBatchError(enc, "", &apiError{http.StatusRequestEntityTooLarge, "too_many_names", "Batch is limited to " + strconv.Itoa(maxBatchNames) + " names."})
+				return
+			}
+			if lim != nil && i > 0 {
+				// Hand over the results so far while waiting.
+				if ok, _ := lim.allow(clientIP(r)); !ok {
+					if flusher != nil {
+						flusher.Flush()
+					}
+					if err := lim.wait(r.Context(), clientIP(r)); err != nil {
+						return
+					}
+					extendDeadlines(w)
+				}
+			}
+			if err := checkName(name); err != nil {
+				writeBatchError(enc, name, errInvalid(err.Error()))
+				continue
+			}
+
+			errs := db.Assess(r.Context(), name, q, record)
+
+			err = enc.Encode(batchResult{
+				Success:    pkgname.Passed(errs),
+				Pkgname:    name,
+				Causes:     causes(errs),
+				Violations: toViolations(errs),
+			})
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Couldn't send batch result to client", "err", err)
+				return
+			}
+
+			if flusher != nil && (i+1)%batchFlushEvery == 0 {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// extendDeadlines gives a batch held back by the rate limit as long to go
+// on as a request gets, since it's still making progress.
+func extendDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	if readTimeout > 0 {
+		_ = rc.SetReadDeadline(time.Now().Add(readTimeout))
+	}
+	if writeTimeout > 0 {
+		_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	}
+}
+
+// writeBatchError tells of e in the results, about name if it's not empty.
+func writeBatchError(enc *json.Encoder, name string, e *apiError) {
+	if err := enc.Encode(batchResult{Err: e, Pkgname: name}); err != nil {
+		slog.Error("Couldn't send batch error to client", "err", err)
+	}
+}
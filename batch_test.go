@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postBatch(t *testing.T, h http.HandlerFunc, target, contentType, body string) (*httptest.ResponseRecorder, []batchResult) {
+	r := httptest.NewRequest("POST", target, strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	rr := httptest.NewRecorder()
+	h(rr, r)
+
+	var results []batchResult
+	scan := bufio.NewScanner(rr.Body)
+	for scan.Scan() {
+		var res batchResult
+		if err := json.Unmarshal(scan.Bytes(), &res); err != nil {
+			t.Fatalf("decoding %q: %v", scan.Text(), err)
+		}
+		results = append(results, res)
+	}
+	return rr, results
+}
+
+func TestBatchFormats(t *testing.T) {
+	h := validateBatch(NewDB(), nil)
+
+	bodies := map[string]string{
+		"application/json":     `["lime", "go-lime"]`,
+		"application/x-ndjson": "\"lime\"\n\"go-lime\"\n",
+	}
+
+	for contentType, body := range bodies {
+		rr, results := postBatch(t, h, "/validate/batch", contentType, body)
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("%s: want 200 NDJSON, got %d %q", contentType, rr.Code, rr.Header().Get("Content-Type"))
+		}
+		if len(results) != 2 || !results[0].Success || results[1].Success || results[1].Pkgname != "go-lime" {
+			t.Errorf("%s: unexpected results %+v", contentType, results)
+		}
+	}
+}
+
+func TestBatchRecording(t *testing.T) {
+	db := NewDB()
+	h := validateBatch(db, nil)
+
+	postBatch(t, h, "/validate/batch", "application/json", `["lime"]`)
+	if goods, _ := db.Last(1); len(goods) != 0 {
+		t.Fatalf("want nothing recorded, got %q", goods)
+	}
+
+	postBatch(t, h, "/validate/batch?record=true", "application/json", `["lime"]`)
+	if goods, _ := db.Last(1); len(goods) != 1 || goods[0] != "lime" {
+		t.Fatalf("want lime recorded, got %q", goods)
+	}
+
+	rr, results := postBatch(t, h, "/validate/batch?record=yes", "application/json", `["kiwi"]`)
+	if rr.Code != http.StatusUnprocessableEntity || len(results) != 1 || results[0].Err == nil || results[0].Err.Message != invalidRecordMsg {
+		t.Errorf("want a bad record turned down, got %d %+v", rr.Code, results)
+	}
+	if goods, _ := db.Last(1); goods[0] != "lime" {
+		t.Errorf("want kiwi not checked, got %q", goods)
+	}
+}
+
+func TestBatchRateLimit(t *testing.T) {
+	db := NewDB()
+	l, _ := newTestLimiter(100, 2, 10)
+	l.now = time.Now
+	h := limit(l, validateBatch(db, l), tooManyAPI)
+
+	start := time.Now()
+	_, results := postBatch(t, h.ServeHTTP, "/validate/batch?record=true", "application/json", `["lime", "lemon", "orange", "kiwi"]`)
+	if len(results) != 4 || results[3].Err != nil {
+		t.Fatalf("want the batch to wait for tokens, got %+v", results)
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("want the last names held back, took %v", elapsed)
+	}
+	if goods, _ := db.Last(queueSize); len(goods) != 4 {
+		t.Errorf("want all the names recorded, got %q", goods)
+	}
+
+	l, _ = newTestLimiter(0.001, 1, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest("POST", "/validate/batch", strings.NewReader(`["lime", "lemon", "orange"]`)).WithContext(ctx)
+	rr := httptest.NewRecorder()
+	validateBatch(NewDB(), l)(rr, r)
+	if lines := strings.Count(rr.Body.String(), "\n"); lines != 2 {
+		t.Errorf("want the batch to stop when the client gives up, got %q", rr.Body.String())
+	}
+}
+
+func TestBatchRateLimitDeadlines(t *testing.T) {
+	defer func(read, write time.Duration) { readTimeout, writeTimeout = read, write }(readTimeout, writeTimeout)
+	readTimeout, writeTimeout = 100*time.Millisecond, 100*time.Millisecond
+
+	l, _ := newTestLimiter(20, 1, 10)
+	l.now = time.Now
+	srv := httptest.NewUnstartedServer(validateBatch(NewDB(), l))
+	srv.Config.ReadTimeout, srv.Config.WriteTimeout = readTimeout, writeTimeout
+	srv.Start()
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL, "application/json", strings.NewReader(`["a", "b", "c", "d", "e", "f", "g"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	lines := 0
+	for scan := bufio.NewScanner(res.Body); scan.Scan(); lines++ {
+	}
+	if lines != 7 {
+		t.Errorf("want the batch held back past the timeouts, got %d results", lines)
+	}
+}
+
+func TestBatchLimits(t *testing.T) {
+	defer func(n int) { maxBatchNames = n }(maxBatchNames)
+	maxBatchNames = 2
+	h := validateBatch(NewDB(), nil)
+
+	_, results := postBatch(t, h, "/validate/batch", "application/json", `["a", "b", "c", "d"]`)
+	if len(results) != 3 || results[2].Err == nil || results[2].Err.Code != "too_many_names" {
+		t.Errorf("want the batch cut after 2 names, got %+v", results)
+	}
+
+	_, results = postBatch(t, h, "/validate/batch", "application/json", `["a", 2]`)
+	if len(results) != 2 || results[1].Err == nil || results[1].Err.Code != "malformed_request" {
+		t.Errorf("want the batch to stop at the bad entry, got %+v", results)
+	}
+
+	long := strings.Repeat("a", maxNameLength+1)
+	_, results = postBatch(t, h, "/validate/batch", "application/json", `["`+long+`", "b"]`)
+	if len(results) != 2 || results[0].Err == nil || results[0].Err.Code != "invalid_input" || results[0].Pkgname != long || !results[1].Success {
+		t.Errorf("want the bad name told apart and the batch to go on, got %+v", results)
+	}
+
+	rr, _ := postBatch(t, h, "/validate/batch", "text/csv", `a,b`)
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("want 415, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"github.com/aybabtme/pkgname/pkgname"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var (
+	// shareMaxAge is how long badges and cards can be cached, in seconds.
+	// The verdict on a name only changes when the server restarts with
+	// another corpus.
+	shareMaxAge = 86400
+	// shareCacheSize is how many of the latest badges and cards are kept
+	// rendered, each.
+	shareCacheSize = 256
+)
+
+// The size of cards, as advertised in the page.
+const (
+	cardWidth  = 1200
+	cardHeight = 630
+)
+
+var (
+	colorBackground = color.RGBA{0xf5, 0xf5, 0xf9, 0xff}
+	colorText       = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	colorMuted      = color.RGBA{0x77, 0x77, 0x77, 0xff}
+	colorValid      = color.RGBA{0x40, 0xd3, 0xb7, 0xff}
+	colorInvalid    = color.RGBA{0xf8, 0x6b, 0x4f, 0xff}
+	colorLabel      = color.RGBA{0x55, 0x55, 0x55, 0xff}
+)
+
+// The fonts are parsed once, but faces aren't safe for concurrent use so
+// each drawing gets its own.
+var (
+	fontRegular = mustParseFont(goregular.TTF)
+	fontBold    = mustParseFont(gobold.TTF)
+)
+
+func mustParseFont(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func newFace(f *opentype.Font, size float64) font.Face {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return face
+}
+
+// verdict is what badges and cards say about a name.
+type verdict struct {
+	Pkgname string
+	Success bool
+	// Causes are the messages of the rules the name broke, and Warnings
+	// those of the rules that only warn.
+	Causes   []string
+	Warnings []string
+	// Lang is the language of the card. Badges are always in English.
+	Lang string
+}
+
+func (v verdict) word() string {
+	if v.Success {
+		return "approved"
+	}
+	return "shit"
+}
+
+func (v verdict) color() color.RGBA {
+	if v.Success {
+		return colorValid
+	}
+	return colorInvalid
+}
+
+// shareName extracts the name from a path like /prefix/<name><ext>.
+func shareName(urlPath, prefix, ext string) (string, bool) {
+	if !strings.HasPrefix(urlPath, prefix) || !strings.HasSuffix(urlPath, ext) {
+		return "", false
+	}
+	name := urlPath[len(prefix) : len(urlPath)-len(ext)]
+	return name, shareable(name)
+}
+
+// shareable tells if badges and cards can be made for name.
+func shareable(name string) bool {
+	return checkName(name) == nil && !strings.Contains(name, "/") && utf8.ValidString(name)
+}
+
+// renderCache keeps the latest images rendered, so that a verdict shared
+// around isn't drawn again for each of its viewers.
+type renderCache struct {
+	max int
+
+	lock  sync.Mutex
+	order *list.List // of *rendered, the most recently used first
+	items map[string]*list.Element
+}
+
+type rendered struct {
+	key  string
+	data []byte
+}
+
+func newRenderCache(max int) *renderCache {
+	return &renderCache{max: max, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *renderCache) get(key string) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*rendered).data, true
+}
+
+// add keeps data under key, forgetting the least recently used image if
+// there are too many.
+func (c *renderCache) add(key string, data []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.items[key]; ok {
+		e.Value.(*rendered).data = data
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.order.PushFront(&rendered{key: key, data: data})
+	if c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*rendered).key)
+	}
+}
+
+// share serves an image of the verdict on the name in the path, without
+// recording it.
+func share(db *DB, prefix, ext, mimetype string, render func(verdict) ([]byte, error)) http.HandlerFunc {
+	cache := newRenderCache(shareCacheSize)
+
+	return methods(map[string]http.HandlerFunc{"GET": func(w http.ResponseWriter, r *http.Request) {
+		name, ok := shareName(r.URL.Path, prefix, ext)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Only the query picks the language, so that caches don't have to
+		// vary on the headers.
+		lang := negotiateLang(r.URL.Query().Get("lang"), "")
+		tone := r.URL.Query().Get("tone")
+		key := name + "\x00" + tone + "\x00" + lang
+
+		data, ok := cache.get(key)
+		if !ok {
+			violations := db.Check(name, query{Tone: tone, Lang: lang})
+			v := verdict{Pkgname: name, Success: pkgname.Passed(violations), Lang: lang}
+			v.Causes, v.Warnings = bySeverity(violations)
+
+			var err error
+			if data, err = render(v); err != nil {
+				slog.ErrorContext(r.Context(), "Couldn't render verdict", "ext", ext, "pkgname", name, "err", err)
+				http.Error(w, "Something went wrong on our side.", http.StatusInternalServerError)
+				return
+			}
+			cache.add(key, data)
+		}
+
+		w.Header().Set("Content-Type", mimetype)
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", shareMaxAge))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "HEAD" {
+			return
+		}
+		if _, err := w.Write(data); err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't send verdict to client", "ext", ext, "err", err)
+		}
+	}})
+}
+
+func badge(db *DB) http.HandlerFunc {
+	return share(db, "/badge/", ".svg", "image/svg+xml; charset=utf-8", renderBadge)
+}
+
+func card(db *DB) http.HandlerFunc {
+	return share(db, "/card/", ".png", "image/png", renderCard)
+}
+
+// renderBadge makes a badge in the style of the usual README badges, with
+// the name on the left and the verdict on the right.
+func renderBadge(v verdict) ([]byte, error) {
+	const (
+		size    = 11
+		padding = 6
+		height  = 20
+	)
+	face := newFace(fontRegular, size)
+	defer face.Close()
+
+	// The text is measured with the font we embed, and the SVG is told to
+	// stretch whatever font the viewer has to the same width.
+	label, message := v.Pkgname, v.word()
+	labelText := font.MeasureString(face, label).Ceil()
+	messageText := font.MeasureString(face, message).Ceil()
+	labelWidth := labelText + 2*padding
+	messageWidth := messageText + 2*padding
+	width := labelWidth + messageWidth
+
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="%s: %s">`,
+		width, height, html.EscapeString(label), message)
+	fmt.Fprintf(buf, `<title>%s: %s</title>`, html.EscapeString(label), message)
+	fmt.Fprintf(buf, `<rect width="%d" height="%d" rx="3" fill="%s"/>`, width, height, hexColor(colorLabel))
+	fmt.Fprintf(buf, `<rect x="%d" width="%d" height="%d" rx="3" fill="%s"/>`, labelWidth, messageWidth, height, hexColor(v.color()))
+	fmt.Fprintf(buf, `<rect x="%d" width="4" height="%d" fill="%s"/>`, labelWidth, height, hexColor(v.color()))
+	buf.WriteString(`<g fill="#fff" font-family="Go,DejaVu Sans,Verdana,Geneva,sans-serif" font-size="11">`)
+	fmt.Fprintf(buf, `<text x="%d" y="14" textLength="%d" lengthAdjust="spacingAndGlyphs">%s</text>`,
+		padding, labelText, html.EscapeString(label))
+	fmt.Fprintf(buf, `<text x="%d" y="14" textLength="%d" lengthAdjust="spacingAndGlyphs">%s</text>`,
+		labelWidth+padding, messageText, message)
+	buf.WriteString(`</g></svg>`)
+	return buf.Bytes(), nil
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// renderCard makes the picture shown when a verdict is shared: the name,
+// the verdict and the first of the causes, or else of the warnings.
+func renderCard(v verdict) ([]byte, error) {
+	const margin = 80
+
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+	band := image.Rect(0, cardHeight-40, cardWidth, cardHeight)
+	draw.Draw(img, band, image.NewUniform(v.color()), image.Point{}, draw.Src)
+
+	maxWidth := fixed.I(cardWidth - 2*margin)
+
+	title := newFace(fontBold, 96)
+	defer title.Close()
+	drawText(img, title, colorText, margin, 200, fitText(title, v.Pkgname, maxWidth))
+
+	// The Go fonts have no check marks, so the verdict is spelled out.
+	said := text(v.Lang)["bad"]
+	if v.Success {
+		said = text(v.Lang)["good"]
+	}
+	subtitle := newFace(fontBold, 56)
+	defer subtitle.Close()
+	drawText(img, subtitle, v.color(), margin, 300, said)
+
+	body := newFace(fontRegular, 32)
+	defer body.Close()
+	said, c := "", colorText
+	switch {
+	case len(v.Causes) > 0:
+		said = v.Causes[0]
+	case len(v.Warnings) > 0:
+		said, c = v.Warnings[0], colorMuted
+	}
+	y := 380
+	for _, line := range wrapText(body, said, maxWidth, 3) {
+		drawText(img, body, c, margin, y, line)
+		y += 44
+	}
+	drawText(img, body, colorMuted, margin, cardHeight-70, "pkgname")
+
+	buf := bytes.NewBuffer(nil)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawText(dst draw.Image, face font.Face, c color.Color, x, y int, text string) {
+	d := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
+
+// fitText cuts text short with an ellipsis if it's wider than max.
+func fitText(face font.Face, text string, max fixed.Int26_6) string {
+	if font.MeasureString(face, text) <= max {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if s := string(runes) + "…"; font.MeasureString(face, s) <= max {
+			return s
+		}
+	}
+	return ""
+}
+
+// wrapText breaks text in at most maxLines lines no wider than max, the
+// last one being cut short if need be.
+func wrapText(face font.Face, text string, max fixed.Int26_6, maxLines int) []string {
+	var lines []string
+	var line string
+	words := strings.Fields(text)
+	for i, word := range words {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if line == "" || font.MeasureString(face, next) <= max {
+			line = next
+			continue
+		}
+		if len(lines) == maxLines-1 {
+			line = strings.Join(append([]string{line}, words[i:]...), " ")
+			break
+		}
+		lines = append(lines, fitText(face, line, max))
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, fitText(face, line, max))
+	}
+	return lines
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/xml"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShareName(t *testing.T) {
+	tests := []struct {
+		path string
+		name string
+		ok   bool
+	}{
+		{"/badge/lime.svg", "lime", true},
+		{"/badge/go-lime.svg", "go-lime", true},
+		{"/badge/a b.svg", "a b", true},
+		{"/badge/.svg", "", false},
+		{"/badge/lime.png", "", false},
+		{"/badge/a/b.svg", "", false},
+		{"/badge/" + strings.Repeat("a", maxNameLength+1) + ".svg", "", false},
+		{"/badge/\xff.svg", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := shareName(tt.path, "/badge/", ".svg")
+		if ok != tt.ok || (ok && name != tt.name) {
+			t.Errorf("%q: want %q %v, got %q %v", tt.path, tt.name, tt.ok, name, ok)
+		}
+	}
+}
+
+func TestBadge(t *testing.T) {
+	db := NewDB()
+	h := badge(db)
+
+	tests := []struct {
+		path  string
+		color string
+		word  string
+	}{
+		{"/badge/lime.svg", hexColor(colorValid), "approved"},
+		{"/badge/go-lime.svg", hexColor(colorInvalid), "shit"},
+		{"/badge/%3Cb%3E.svg", hexColor(colorInvalid), "shit"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", tt.path, nil))
+		body := rr.Body.String()
+
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/svg+xml; charset=utf-8" {
+			t.Errorf("%s: want a badge, got %d %q", tt.path, rr.Code, rr.Header().Get("Content-Type"))
+			continue
+		}
+		if err := xml.Unmarshal(rr.Body.Bytes(), new(struct{})); err != nil {
+			t.Errorf("%s: want valid XML, %v", tt.path, err)
+		}
+		if !strings.Contains(body, tt.color) || !strings.Contains(body, ">"+tt.word+"<") {
+			t.Errorf("%s: want %s %s, got %s", tt.path, tt.color, tt.word, body)
+		}
+	}
+
+	if goods, bads := db.Last(1); len(goods)+len(bads) != 0 {
+		t.Errorf("want badges not to record, got %q %q", goods, bads)
+	}
+}
+
+func TestBadgeNotFound(t *testing.T) {
+	h := badge(NewDB())
+	for _, path := range []string{"/badge/", "/badge/lime", "/badge/a/b.svg"} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: want 404, got %d", path, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("POST", "/badge/lime.svg", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("want 405, got %d", rr.Code)
+	}
+}
+
+func TestCard(t *testing.T) {
+	h := card(NewDB())
+
+	for _, name := range []string{"lime", "go-lime", strings.Repeat("W", maxNameLength)} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", "/card/"+name+".png", nil))
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("%s: want a card, got %d %q", name, rr.Code, rr.Header().Get("Content-Type"))
+		}
+		img, err := png.Decode(rr.Body)
+		if err != nil {
+			t.Fatalf("%s: want a PNG, %v", name, err)
+		}
+		if b := img.Bounds(); b.Dx() != cardWidth || b.Dy() != cardHeight {
+			t.Errorf("%s: want %dx%d, got %v", name, cardWidth, cardHeight, b)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("HEAD", "/card/lime.png", nil))
+	if rr.Code != http.StatusOK || rr.Body.Len() != 0 {
+		t.Errorf("want headers only, got %d with %d bytes", rr.Code, rr.Body.Len())
+	}
+}
+
+func TestShareCache(t *testing.T) {
+	renders := 0
+	h := share(NewDB(), "/card/", ".png", "image/png", func(v verdict) ([]byte, error) {
+		renders++
+		return []byte(v.Pkgname + " " + v.Lang), nil
+	})
+
+	for _, target := range []string{"/card/lime.png", "/card/lime.png", "/card/lime.png?lang=fr", "/card/lime.png?lang=fr", "/card/go-lime.png"} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", target, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: want a card, got %d", target, rr.Code)
+		}
+	}
+	if renders != 3 {
+		t.Errorf("want each card drawn once, got %d drawings", renders)
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	c := newRenderCache(2)
+	c.add("a", []byte("a"))
+	c.add("b", []byte("b"))
+	c.get("a")
+	c.add("c", []byte("c"))
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.get(key); ok != want {
+			t.Errorf("%s: want kept %v, got %v", key, want, ok)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	face := newFace(fontRegular, 32)
+	defer face.Close()
+	max := fixed.I(400)
+
+	text := "Don't mention 'go' in your package name. Go is implicit in any package. Go is absolute and infinitesimal."
+	lines := wrapText(face, text, max, 3)
+	if len(lines) != 3 {
+		t.Fatalf("want 3 lines, got %q", lines)
+	}
+	for _, line := range lines {
+		if font.MeasureString(face, line) > max {
+			t.Errorf("want %q to fit", line)
+		}
+		if strings.TrimSpace(line) == "" {
+			t.Errorf("want no blank line in %q", lines)
+		}
+	}
+	if !strings.HasSuffix(lines[2], "…") {
+		t.Errorf("want the last line cut short, got %q", lines[2])
+	}
+
+	if lines := wrapText(face, "short", max, 3); len(lines) != 1 || lines[0] != "short" {
+		t.Errorf("want a single line, got %q", lines)
+	}
+}
+
+func TestIndexShareTags(t *testing.T) {
+	h := newTestIndex(t, NewDB())
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/?pkgname=go-lime", nil)
+	r.Host = "pkgname.example"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	h(rr, r)
+	body := rr.Body.String()
+
+	for _, want := range []string{
+		`<meta property="og:url" content="https://pkgname.example/?pkgname=go-lime">`,
+		`<meta property="og:image" content="https://pkgname.example/card/go-lime.png">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<meta property="og:description" content="go-lime is a shit pkg name. Don&#39;t put hyphens, that&#39;s ugly.">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("want %s in page", want)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(rr.Body.String(), "og:image") {
+		t.Errorf("want no card without a verdict")
+	}
+}
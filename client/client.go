@@ -0,0 +1,185 @@
+// Package client talks to a pkgname server over gRPC.
+//
+//	c, err := client.Dial("localhost:5001", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer c.Close()
+//
+//	verdict, err := c.Validate(ctx, "go-libPkgNameLib")
+package client
+
+import (
+	"context"
+	"github.com/aybabtme/pkgname/pkgnamepb"
+	"google.golang.org/grpc"
+	"io"
+)
+
+// Verdict tells if a package name is shit, and why.
+type Verdict struct {
+	Pkgname string
+	Success bool
+	Causes  []string
+	// Violations are the causes along with their rule and severity.
+	Violations []Violation
+	// Error is why the name couldn't be checked by ValidateAll, which goes
+	// on with the other names.
+	Error string
+}
+
+// Violation is a rule a package name broke.
+type Violation struct {
+	Rule    string
+	Message string
+	// Severity is error, or warning for the rules that don't make the name
+	// shit.
+	Severity string
+}
+
+// Client is a pkgname client. It's safe for concurrent use.
+type Client struct {
+	conn *grpc.ClientConn
+	rpc  pkgnamepb.PkgnameClient
+
+	// Record makes the server publish the names this client validates in
+	// its history.
+	Record bool
+	// Profile is the rule profile the server checks names with, its
+	// default one if empty.
+	Profile string
+	// Tone is the tone of the messages, snarky or professional, the one of
+	// the profile if empty.
+	Tone string
+	// Lang is the language of the messages, like fr or de, English if
+	// empty.
+	Lang string
+}
+
+// Dial connects to the pkgname server at target.
+func Dial(target string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c := New(conn)
+	c.conn = conn
+	return c, nil
+}
+
+// New makes a client out of an existing connection. Closing the client
+// won't close the connection.
+func New(conn grpc.ClientConnInterface) *Client {
+	return &Client{rpc: pkgnamepb.NewPkgnameClient(conn)}
+}
+
+// Close closes the connection made by Dial.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Validate checks a single package name.
+func (c *Client) Validate(ctx context.Context, pkgname string) (*Verdict, error) {
+	return c.ValidatePath(ctx, pkgname, "")
+}
+
+// ValidatePath checks a single package name, telling the server the import
+// path of the package for the rules that look at it.
+func (c *Client) ValidatePath(ctx context.Context, pkgname, importPath string) (*Verdict, error) {
+	res, err := c.rpc.Validate(ctx, &pkgnamepb.ValidateRequest{
+		Pkgname:    pkgname,
+		Record:     c.Record,
+		Profile:    c.Profile,
+		ImportPath: importPath,
+		Tone:       c.Tone,
+		Lang:       c.Lang,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toVerdict(res), nil
+}
+
+// ValidateAll checks many package names over a single stream, returning
+// the verdicts in the order of pkgnames.
+func (c *Client) ValidateAll(ctx context.Context, pkgnames []string) ([]*Verdict, error) {
+	stream, err := c.rpc.ValidateStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sent := make(chan error, 1)
+	go func() {
+		for _, pkgname := range pkgnames {
+			err := stream.Send(&pkgnamepb.ValidateRequest{
+				Pkgname: pkgname,
+				Record:  c.Record,
+				Profile: c.Profile,
+				Tone:    c.Tone,
+				Lang:    c.Lang,
+			})
+			if err != nil {
+				sent <- err
+				return
+			}
+		}
+		sent <- stream.CloseSend()
+	}()
+
+	verdicts := make([]*Verdict, 0, len(pkgnames))
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		verdicts = append(verdicts, toVerdict(res))
+	}
+
+	// A failed send is reported by Recv, unless it was io.EOF.
+	if err := <-sent; err != nil && err != io.EOF {
+		return nil, err
+	}
+	return verdicts, nil
+}
+
+// Generate picks a good package name at random.
+func (c *Client) Generate(ctx context.Context) (string, error) {
+	res, err := c.rpc.Generate(ctx, &pkgnamepb.GenerateRequest{})
+	if err != nil {
+		return "", err
+	}
+	return res.GetPkgname(), nil
+}
+
+// History lists the last good and bad names recorded by the server, newest
+// first.
+func (c *Client) History(ctx context.Context, last int) (goods, bads []string, err error) {
+	res, err := c.rpc.History(ctx, &pkgnamepb.HistoryRequest{Last: int32(last)})
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.GetGoods(), res.GetBads(), nil
+}
+
+func toVerdict(res *pkgnamepb.ValidateResponse) *Verdict {
+	v := &Verdict{
+		Pkgname: res.GetPkgname(),
+		Success: res.GetSuccess(),
+		Causes:  res.GetCauses(),
+		Error:   res.GetError(),
+	}
+	for _, violation := range res.GetViolations() {
+		v.Violations = append(v.Violations, Violation{
+			Rule:     violation.GetRule(),
+			Message:  violation.GetMessage(),
+			Severity: violation.GetSeverity(),
+		})
+	}
+	return v
+}
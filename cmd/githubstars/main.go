@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"github.com/aybabtme/pkgname/pkgname"
 	"github.com/google/go-github/github"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -17,8 +19,20 @@ func main() {
 	token := flag.String("access-token", "", "oauth access token to github")
 	filename := flag.String("out", "", "filename to write output")
 	stars := flag.Int("stars", 10, "minimum number of starts a repo must have to be considered")
+	names := flag.Bool("names", false, "write the names that pass pkgname's builtin rules, one per line, instead of the repos as JSON")
+	profile := flag.String("profile", "", "builtin profile the names must pass with -names instead, like strict or stdlib-style")
 	flag.Parse()
 
+	rules := pkgname.BuiltinRules()
+	if *profile != "" {
+		p, ok := pkgname.BuiltinProfiles()[*profile]
+		if !ok {
+			log.Printf("No profile %q.", *profile)
+			os.Exit(2)
+		}
+		rules = p.Build(pkgname.Corpus(), nil)
+	}
+
 	if *filename == "" {
 		log.Println("Need an output filename.")
 		flag.PrintDefaults()
@@ -38,7 +52,6 @@ func main() {
 		log.Fatalf("[ERROR] Opening output file: %v.", err)
 	}
 	defer out.Close()
-	enc := json.NewEncoder(out)
 
 	var repos []github.Repository
 
@@ -63,8 +76,34 @@ func main() {
 		opts.Page = resp.NextPage
 	}
 
-	if err := enc.Encode(repos); err != nil {
+	if *names {
+		if err := writeNames(out, repos, rules); err != nil {
+			log.Fatalf("[ERROR] Writing names to output: %v.", err)
+		}
+		return
+	}
+
+	if err := json.NewEncoder(out).Encode(repos); err != nil {
 		log.Fatalf("[ERROR] Encoding JSON to output: %v.", err)
 	}
 
 }
+
+func writeNames(w io.Writer, repos []github.Repository, rules []pkgname.Rule) error {
+	var all []string
+	for _, repo := range repos {
+		if repo.Name != nil {
+			all = append(all, *repo.Name)
+		}
+	}
+
+	goods, bads := pkgname.Clean(all, rules)
+	log.Printf("[INFO] Kept %d names, rejected %d.", len(goods), len(bads))
+
+	for _, name := range goods {
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
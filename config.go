@@ -0,0 +1,570 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/aybabtme/pkgname/pkgname"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envPrefix starts the names of the environment variables that override
+// the config file, like PKGNAME_RATE_LIMIT_BURST for rate_limit.burst.
+const envPrefix = "PKGNAME_"
+
+// config is everything the server is set up with. It's read from a TOML
+// file, then from the environment, then from the flags, each overriding the
+// ones before.
+type config struct {
+	Listen string `toml:"listen"`
+	CPU    int    `toml:"cpu"`
+	Dev    bool   `toml:"dev"`
+
+	TLS struct {
+		Cert           string        `toml:"cert"`
+		Key            string        `toml:"key"`
+		ReloadInterval time.Duration `toml:"reload_interval"`
+		RedirectListen string        `toml:"redirect_listen"`
+		HSTSMaxAge     time.Duration `toml:"hsts_max_age"`
+		HSTSSubdomains bool          `toml:"hsts_include_subdomains"`
+	} `toml:"tls"`
+
+	GRPC struct {
+		Listen string `toml:"listen"`
+	} `toml:"grpc"`
+
+	Admin struct {
+		Listen string `toml:"listen"`
+	} `toml:"admin"`
+
+	Log struct {
+		Level  string `toml:"level"`
+		Format string `toml:"format"`
+	} `toml:"log"`
+
+	Names struct {
+		Sources   []string `toml:"sources"`
+		MaxLength int      `toml:"max_length"`
+	} `toml:"names"`
+
+	Rules struct {
+		Enabled     []string `toml:"enabled"`
+		CloseToMean struct {
+			MaxDist float64 `toml:"max_dist"`
+		} `toml:"close_to_mean"`
+		Profile string `toml:"profile"`
+		// Tone of the messages of the profiles that don't pick one.
+		Tone string `toml:"tone"`
+		// Custom rules are declared in the file, and checked by the
+		// default profile along with the enabled ones.
+		Custom []pkgname.RuleDef `toml:"custom"`
+		// Scripts are rules written in Starlark, checked like the custom
+		// ones.
+		Scripts []scriptRule `toml:"scripts"`
+	} `toml:"rules"`
+
+	// Profiles are added to the builtin ones, or replace them. The rules
+	// section is the default profile.
+	Profiles map[string]pkgname.Profile `toml:"profiles"`
+
+	History struct {
+		Backend  string        `toml:"backend"`
+		Size     int           `toml:"size"`
+		TTL      time.Duration `toml:"ttl"`
+		Private  bool          `toml:"private"`
+		DenyList string        `toml:"deny_list"`
+	} `toml:"history"`
+
+	RateLimit struct {
+		Rate       float64 `toml:"rate"`
+		Burst      int     `toml:"burst"`
+		Clients    int     `toml:"clients"`
+		TrustProxy bool    `toml:"trust_proxy"`
+	} `toml:"rate_limit"`
+
+	Server struct {
+		ReadHeaderTimeout time.Duration `toml:"read_header_timeout"`
+		ReadTimeout       time.Duration `toml:"read_timeout"`
+		WriteTimeout      time.Duration `toml:"write_timeout"`
+		IdleTimeout       time.Duration `toml:"idle_timeout"`
+		ShutdownDelay     time.Duration `toml:"shutdown_delay"`
+		ShutdownTimeout   time.Duration `toml:"shutdown_timeout"`
+	} `toml:"server"`
+
+	Stats struct {
+		Days      int  `toml:"days"`
+		Rejected  int  `toml:"rejected"`
+		Anonymize bool `toml:"anonymize"`
+	} `toml:"stats"`
+
+	Analytics analyticsConfig `toml:"analytics"`
+}
+
+// historyBackends are where the history can be kept.
+var historyBackends = []string{"memory"}
+
+// defaultConfig is the config of a server started without any settings.
+func defaultConfig() *config {
+	c := &config{
+		Listen: ":5000",
+		CPU:    runtime.NumCPU(),
+	}
+	c.TLS.ReloadInterval = certReloadInterval
+	c.TLS.HSTSMaxAge = hstsMaxAge
+	c.TLS.HSTSSubdomains = hstsSubdomains
+	c.Log.Level = "info"
+	c.Log.Format = "text"
+	c.Names.Sources = nameSources
+	c.Names.MaxLength = maxNameLength
+	c.Rules.Enabled = allRuleIDs()
+	c.Rules.CloseToMean.MaxDist = maxDist
+	c.Rules.Profile = defaultProfile
+	c.Rules.Tone = string(defaultTone)
+	c.Profiles = profiles
+	c.History.Backend = "memory"
+	c.History.Size = queueSize
+	c.History.TTL = historyTTL
+	c.History.Private = privateHistory
+	c.History.DenyList = denyListSource
+	c.RateLimit.Rate = rateLimit
+	c.RateLimit.Burst = rateBurst
+	c.RateLimit.Clients = rateClients
+	c.RateLimit.TrustProxy = trustProxy
+	c.Server.ReadHeaderTimeout = readHeaderTimeout
+	c.Server.ReadTimeout = readTimeout
+	c.Server.WriteTimeout = writeTimeout
+	c.Server.IdleTimeout = idleTimeout
+	c.Server.ShutdownDelay = shutdownDelay
+	c.Server.ShutdownTimeout = shutdownTimeout
+	c.Stats.Days = statsDays
+	c.Stats.Rejected = statsRejected
+	c.Stats.Anonymize = statsAnonymize
+	c.Analytics = analytics
+	return c
+}
+
+// flags binds fs to the settings that can be given on the command line.
+func (c *config) flags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Listen, "listen", c.Listen, "address to serve the site and API on, like :5000 or unix:/run/pkgname.sock")
+	fs.Func("port", "port to serve the site and API on, like -listen :port", func(port string) error {
+		c.Listen = ":" + port
+		return nil
+	})
+	fs.IntVar(&c.CPU, "cpu", c.CPU, "number of cpus to use")
+	fs.BoolVar(&c.Dev, "dev", c.Dev, "dev mode uses a static file handler that reads from the FS at each request")
+	fs.StringVar(&c.TLS.Cert, "tls-cert", c.TLS.Cert, "certificate file to serve HTTPS and gRPC over TLS with, along with -tls-key")
+	fs.StringVar(&c.TLS.Key, "tls-key", c.TLS.Key, "private key file of -tls-cert")
+	fs.StringVar(&c.TLS.RedirectListen, "tls-redirect-addr", c.TLS.RedirectListen, "address to redirect plain HTTP to HTTPS from, like :80, disabled if empty")
+	fs.DurationVar(&c.TLS.HSTSMaxAge, "hsts-max-age", c.TLS.HSTSMaxAge, "time browsers are told to only use HTTPS, no HSTS if 0")
+	fs.BoolVar(&c.TLS.HSTSSubdomains, "hsts-include-subdomains", c.TLS.HSTSSubdomains, "tell browsers to only use HTTPS for the subdomains too")
+	fs.Func("grpc-port", "port to serve the gRPC API on, disabled if empty", func(port string) error {
+		c.GRPC.Listen = ""
+		if port != "" {
+			c.GRPC.Listen = ":" + port
+		}
+		return nil
+	})
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "least level of the logs, one of debug, info, warn and error")
+	fs.StringVar(&c.Log.Format, "log-format", c.Log.Format, "format of the logs, text or json")
+	fs.StringVar(&c.Admin.Listen, "admin-addr", c.Admin.Listen, "address to serve the admin dashboard and metrics on, like localhost:5002, disabled if empty")
+	fs.BoolVar(&c.Stats.Anonymize, "stats-anonymize", c.Stats.Anonymize, "count rejected names in the stats by a hash of them rather than by name")
+	fs.StringVar(&c.Analytics.ID, "analytics-id", c.Analytics.ID, "ID of the site for the analytics, disabled if empty")
+	fs.StringVar(&c.Analytics.Template, "analytics-template", c.Analytics.Template, "file replacing the builtin analytics template")
+	fs.Func("analytics-origins", "comma separated origins the analytics load from and report to (default "+strings.Join(c.Analytics.Origins, ",")+")", func(origins string) error {
+		c.Analytics.Origins = splitList(origins)
+		return nil
+	})
+	fs.DurationVar(&c.Server.ReadHeaderTimeout, "read-header-timeout", c.Server.ReadHeaderTimeout, "time clients have to send the headers of a request")
+	fs.DurationVar(&c.Server.ReadTimeout, "read-timeout", c.Server.ReadTimeout, "time clients have to send a whole request")
+	fs.DurationVar(&c.Server.WriteTimeout, "write-timeout", c.Server.WriteTimeout, "time to send a whole response")
+	fs.DurationVar(&c.Server.IdleTimeout, "idle-timeout", c.Server.IdleTimeout, "time idle keep-alive connections are kept")
+	fs.DurationVar(&c.Server.ShutdownDelay, "shutdown-delay", c.Server.ShutdownDelay, "time to keep serving after SIGTERM while /readyz fails")
+	fs.DurationVar(&c.Server.ShutdownTimeout, "shutdown-timeout", c.Server.ShutdownTimeout, "time requests in flight get to finish when stopping")
+	fs.Float64Var(&c.RateLimit.Rate, "rate-limit", c.RateLimit.Rate, "requests a second each client can make to validate or list names, disabled if 0")
+	fs.IntVar(&c.RateLimit.Burst, "rate-burst", c.RateLimit.Burst, "requests a client can make at once before being rate limited")
+	fs.BoolVar(&c.RateLimit.TrustProxy, "trust-proxy", c.RateLimit.TrustProxy, "rate limit clients by the last X-Forwarded-For entry rather than the remote address")
+	fs.BoolVar(&c.History.Private, "private-history", c.History.Private, "only show the history on the admin listener")
+	fs.DurationVar(&c.History.TTL, "history-ttl", c.History.TTL, "time recorded names are kept in the history, until pushed out by newer ones if 0")
+	fs.IntVar(&c.Names.MaxLength, "max-name-length", c.Names.MaxLength, "longest package name accepted, in bytes")
+	fs.StringVar(&c.Rules.Profile, "profile", c.Rules.Profile, "rule profile of the requests that don't pick one, like default, strict or stdlib-style")
+	fs.StringVar(&c.Rules.Tone, "tone", c.Rules.Tone, "tone of the messages of the profiles that don't pick one, snarky or professional")
+	fs.StringVar(&c.History.DenyList, "deny-list", c.History.DenyList, "file of terms that keep names out of the public history, one per line")
+}
+
+// load reads the TOML file at path over c, if path isn't empty, then the
+// environment variables lookup finds. Keys the file has that c doesn't are
+// errors, they're most likely typos.
+func (c *config) load(path string, lookup func(string) (string, bool)) error {
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := c.decode(f); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return c.env(lookup)
+}
+
+func (c *config) decode(r io.Reader) error {
+	md, err := toml.NewDecoder(r).Decode(c)
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	for _, key := range md.Undecoded() {
+		unknown = append(unknown, key.String())
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown keys %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// env overrides the settings of c with the environment variables lookup
+// finds. Lists are comma separated.
+func (c *config) env(lookup func(string) (string, bool)) error {
+	var errs []error
+	walkConfig(reflect.ValueOf(c).Elem(), "", func(key string, v reflect.Value) {
+		name := envName(key)
+		s, ok := lookup(name)
+		if !ok {
+			return
+		}
+		if err := setConfigValue(v, s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// walkConfig calls fn with every setting of v and its key, like
+// "rate_limit.burst".
+func walkConfig(v reflect.Value, prefix string, fn func(key string, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if field.Type.Kind() == reflect.Struct {
+			walkConfig(v.Field(i), key, fn)
+			continue
+		}
+		fn(key, v.Field(i))
+	}
+}
+
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+func setConfigValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q isn't a duration, like 1m30s", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q isn't true or false", s)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q isn't a whole number", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%q isn't a number", s)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can't be set from the environment")
+		}
+		v.Set(reflect.ValueOf(splitList(s)))
+	default:
+		return fmt.Errorf("can't be set from the environment")
+	}
+	return nil
+}
+
+// splitList splits a comma separated list, dropping the blanks. It's never
+// nil, an empty list means none rather than the default.
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// validate checks the whole of c, so that every mistake can be fixed at
+// once rather than one start at a time.
+func (c *config) validate() error {
+	var errs []error
+	fail := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	address := func(key, addr string, optional bool) {
+		if addr == "" && optional {
+			return
+		}
+		if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+			if path == "" {
+				fail(key, "%q lacks the path of the socket", addr)
+			}
+			return
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			fail(key, "%q isn't an address like :5000, localhost:5000 or unix:/run/pkgname.sock", addr)
+		}
+	}
+	file := func(key, path string) {
+		if path == "" {
+			return
+		}
+		if _, err := os.Stat(path); err != nil {
+			fail(key, "%v", err)
+		}
+	}
+	atLeast := func(key string, n, min int) {
+		if n < min {
+			fail(key, "must be at least %d, not %d", min, n)
+		}
+	}
+	notNegative := func(key string, d time.Duration) {
+		if d < 0 {
+			fail(key, "can't be negative")
+		}
+	}
+
+	address("listen", c.Listen, false)
+	atLeast("cpu", c.CPU, 1)
+
+	switch {
+	case (c.TLS.Cert == "") != (c.TLS.Key == ""):
+		fail("tls", "cert and key go together, set both or neither")
+	case c.TLS.Cert != "":
+		if _, err := newCertReloader(c.TLS.Cert, c.TLS.Key); err != nil {
+			fail("tls", "%v", err)
+		}
+	case c.TLS.RedirectListen != "":
+		fail("tls.redirect_listen", "there's nothing to redirect to without a cert and key")
+	}
+	notNegative("tls.reload_interval", c.TLS.ReloadInterval)
+	address("tls.redirect_listen", c.TLS.RedirectListen, true)
+	notNegative("tls.hsts_max_age", c.TLS.HSTSMaxAge)
+
+	address("grpc.listen", c.GRPC.Listen, true)
+	address("admin.listen", c.Admin.Listen, true)
+
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		fail("log.level", "%q isn't one of debug, info, warn and error", c.Log.Level)
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		fail("log.format", "%q isn't text or json", c.Log.Format)
+	}
+
+	for _, source := range c.Names.Sources {
+		file("names.sources", source)
+	}
+	atLeast("names.max_length", c.Names.MaxLength, 1)
+
+	var custom []string
+	for i, def := range c.Rules.Custom {
+		key := fmt.Sprintf("rules.custom[%d]", i)
+		if def.ID != "" {
+			key = "rules.custom." + def.ID
+		}
+		if _, err := def.Compile(); err != nil {
+			for _, err := range unjoin(err) {
+				fail(key, "%v", err)
+			}
+		}
+		if contains(custom, def.ID) {
+			fail(key, "rule %q is declared twice", def.ID)
+		}
+		custom = append(custom, def.ID)
+	}
+	for i, s := range c.Rules.Scripts {
+		key := fmt.Sprintf("rules.scripts[%d]", i)
+		if s.ID != "" {
+			key = "rules.scripts." + s.ID
+		}
+		if _, err := s.compile(0, 0); err != nil {
+			for _, err := range unjoin(err) {
+				fail(key, "%v", err)
+			}
+		}
+		if contains(custom, s.ID) {
+			fail(key, "rule %q is declared twice", s.ID)
+		}
+		custom = append(custom, s.ID)
+	}
+
+	known := append(allRuleIDs(), custom...)
+	seen := make(map[string]bool)
+	for _, id := range c.Rules.Enabled {
+		switch {
+		case !contains(known, id):
+			fail("rules.enabled", "unknown rule %q, the rules are %s", id, strings.Join(known, ", "))
+		case seen[id]:
+			fail("rules.enabled", "rule %q is listed twice", id)
+		}
+		seen[id] = true
+	}
+	if len(c.Rules.Enabled) == 0 {
+		fail("rules.enabled", "enable at least one rule")
+	}
+	if c.Rules.CloseToMean.MaxDist <= 0 {
+		fail("rules.close_to_mean.max_dist", "must be more than 0")
+	}
+
+	names := []string{"default"}
+	for name := range pkgname.BuiltinProfiles() {
+		names = append(names, name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Profiles)) {
+		p, key := c.Profiles[name], "profiles."+name
+		switch {
+		case name == "default":
+			fail(key, "the default profile is the rules section")
+		case !slug.MatchString(name):
+			fail(key, "%q isn't a name of lowercase letters, digits and hyphens", name)
+		}
+		if err := p.Check(custom...); err != nil {
+			for _, err := range unjoin(err) {
+				fail(key, "%v", err)
+			}
+		}
+		names = append(names, name)
+	}
+	if !contains(names, c.Rules.Profile) {
+		slices.Sort(names)
+		fail("rules.profile", "unknown profile %q, the profiles are %s", c.Rules.Profile, strings.Join(slices.Compact(names), ", "))
+	}
+	if _, err := pkgname.ParseTone(c.Rules.Tone); err != nil || c.Rules.Tone == "" {
+		fail("rules.tone", "unknown tone %q, the tones are %s", c.Rules.Tone, toneNames())
+	}
+
+	if !contains(historyBackends, c.History.Backend) {
+		fail("history.backend", "unknown backend %q, the backends are %s", c.History.Backend, strings.Join(historyBackends, ", "))
+	}
+	atLeast("history.size", c.History.Size, 1)
+	notNegative("history.ttl", c.History.TTL)
+	file("history.deny_list", c.History.DenyList)
+
+	if c.RateLimit.Rate < 0 {
+		fail("rate_limit.rate", "can't be negative, set it to 0 to disable rate limiting")
+	}
+	atLeast("rate_limit.burst", c.RateLimit.Burst, 1)
+	atLeast("rate_limit.clients", c.RateLimit.Clients, 1)
+
+	notNegative("server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	notNegative("server.read_timeout", c.Server.ReadTimeout)
+	notNegative("server.write_timeout", c.Server.WriteTimeout)
+	notNegative("server.idle_timeout", c.Server.IdleTimeout)
+	notNegative("server.shutdown_delay", c.Server.ShutdownDelay)
+	notNegative("server.shutdown_timeout", c.Server.ShutdownTimeout)
+
+	atLeast("stats.days", c.Stats.Days, 1)
+	atLeast("stats.rejected", c.Stats.Rejected, 0)
+
+	file("analytics.template", c.Analytics.Template)
+	for _, origin := range c.Analytics.Origins {
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("analytics.origins", "%q isn't an origin like https://example.com", origin)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// apply sets up the package with c. The settings that main uses directly
+// are left to it.
+func (c *config) apply() {
+	certReloadInterval = c.TLS.ReloadInterval
+	hstsMaxAge = c.TLS.HSTSMaxAge
+	hstsSubdomains = c.TLS.HSTSSubdomains
+	nameSources = c.Names.Sources
+	maxNameLength = c.Names.MaxLength
+	enabledRules = c.Rules.Enabled
+	maxDist = c.Rules.CloseToMean.MaxDist
+	customRules = c.Rules.Custom
+	scriptRules = c.Rules.Scripts
+	defaultProfile = c.Rules.Profile
+	defaultTone = pkgname.Tone(c.Rules.Tone)
+	profiles = c.Profiles
+	queueSize = c.History.Size
+	historyTTL = c.History.TTL
+	privateHistory = c.History.Private
+	denyListSource = c.History.DenyList
+	rateLimit = c.RateLimit.Rate
+	rateBurst = c.RateLimit.Burst
+	rateClients = c.RateLimit.Clients
+	trustProxy = c.RateLimit.TrustProxy
+	readHeaderTimeout = c.Server.ReadHeaderTimeout
+	readTimeout = c.Server.ReadTimeout
+	writeTimeout = c.Server.WriteTimeout
+	idleTimeout = c.Server.IdleTimeout
+	shutdownDelay = c.Server.ShutdownDelay
+	shutdownTimeout = c.Server.ShutdownTimeout
+	statsDays = c.Stats.Days
+	statsRejected = c.Stats.Rejected
+	statsAnonymize = c.Stats.Anonymize
+	analytics = c.Analytics
+}
+
+// slug is what the names of profiles and rules are like.
+var slug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// unjoin are the errors joined in err.
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
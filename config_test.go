@@ -0,0 +1,262 @@
+package main
+
+import (
+	"flag"
+	"github.com/aybabtme/pkgname/pkgname"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func noEnv(string) (string, bool) { return "", false }
+
+func mapEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestConfigExample(t *testing.T) {
+	got := defaultConfig()
+	if err := got.load("config.example.toml", noEnv); err != nil {
+		t.Fatal(err)
+	}
+	if err := got.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := defaultConfig()
+	want.Names.Sources = []string{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want the example to have the defaults\nwant %+v\ngot  %+v", want, got)
+	}
+}
+
+func TestConfigDecode(t *testing.T) {
+	c := defaultConfig()
+	err := c.decode(strings.NewReader(`
+listen = "localhost:8080"
+
+[history]
+ttl = "1h"
+
+[rules]
+enabled = ["no-hyphens"]
+
+[rules.close_to_mean]
+max_dist = 3.5
+
+[[rules.custom]]
+id = "no-lib"
+banned_prefixes = ["lib"]
+severity = "warning"
+
+[profiles.acme]
+allow_suffixes = ["_test"]
+banned_prefixes = ["acme"]
+
+[profiles.acme.messages]
+no-banned-prefix = "That's ours."
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Listen != "localhost:8080" || c.History.TTL != time.Hour || c.Rules.CloseToMean.MaxDist != 3.5 {
+		t.Errorf("want the file read, got %+v", c)
+	}
+	if !reflect.DeepEqual(c.Rules.Enabled, []string{"no-hyphens"}) {
+		t.Errorf("want the enabled rules replaced, got %q", c.Rules.Enabled)
+	}
+	if want := []pkgname.RuleDef{{ID: "no-lib", BannedPrefixes: []string{"lib"}, Severity: "warning"}}; !reflect.DeepEqual(c.Rules.Custom, want) {
+		t.Errorf("want custom rules %+v, got %+v", want, c.Rules.Custom)
+	}
+	if want := (pkgname.Profile{
+		AllowSuffixes:  []string{"_test"},
+		BannedPrefixes: []string{"acme"},
+		Messages:       map[string]string{"no-banned-prefix": "That's ours."},
+	}); !reflect.DeepEqual(c.Profiles["acme"], want) {
+		t.Errorf("want profile %+v, got %+v", want, c.Profiles["acme"])
+	}
+	if c.RateLimit.Burst != rateBurst {
+		t.Errorf("want what the file doesn't say left alone, got %d", c.RateLimit.Burst)
+	}
+
+	err = defaultConfig().decode(strings.NewReader("[history]\nbackned = \"memory\"\n"))
+	if err == nil || !strings.Contains(err.Error(), "history.backned") {
+		t.Errorf("want unknown keys reported, got %v", err)
+	}
+	err = defaultConfig().decode(strings.NewReader("listen = 5000\n"))
+	if err == nil {
+		t.Errorf("want a mistyped value reported")
+	}
+}
+
+func TestConfigEnv(t *testing.T) {
+	c := defaultConfig()
+	err := c.env(mapEnv(map[string]string{
+		"PKGNAME_LISTEN":                       ":6000",
+		"PKGNAME_RATE_LIMIT_BURST":             "7",
+		"PKGNAME_RATE_LIMIT_TRUST_PROXY":       "true",
+		"PKGNAME_HISTORY_TTL":                  "90m",
+		"PKGNAME_RULES_ENABLED":                "no-hyphens, close-to-mean",
+		"PKGNAME_RULES_CLOSE_TO_MEAN_MAX_DIST": "1.5",
+		"PKGNAME_ANALYTICS_ID":                 "UA-1",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Listen != ":6000" || c.RateLimit.Burst != 7 || !c.RateLimit.TrustProxy || c.History.TTL != 90*time.Minute ||
+		c.Rules.CloseToMean.MaxDist != 1.5 || c.Analytics.ID != "UA-1" {
+		t.Errorf("want the environment read, got %+v", c)
+	}
+	if want := []string{"no-hyphens", "close-to-mean"}; !reflect.DeepEqual(c.Rules.Enabled, want) {
+		t.Errorf("want %q, got %q", want, c.Rules.Enabled)
+	}
+
+	err = defaultConfig().env(mapEnv(map[string]string{
+		"PKGNAME_RATE_LIMIT_BURST": "lots",
+		"PKGNAME_HISTORY_TTL":      "a day",
+		"PKGNAME_RULES_CUSTOM":     "no-lib",
+	}))
+	for _, name := range []string{"PKGNAME_RATE_LIMIT_BURST", "PKGNAME_HISTORY_TTL", "PKGNAME_RULES_CUSTOM"} {
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("want %s reported, got %v", name, err)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		key    string
+		change func(c *config)
+	}{
+		{"listen", func(c *config) { c.Listen = "5000" }},
+		{"cpu", func(c *config) { c.CPU = 0 }},
+		{"tls", func(c *config) { c.TLS.Cert = "cert.pem" }},
+		{"tls", func(c *config) { c.TLS.Cert, c.TLS.Key = "missing.pem", "missing.key" }},
+		{"tls.redirect_listen", func(c *config) { c.TLS.RedirectListen = ":80" }},
+		{"tls.hsts_max_age", func(c *config) { c.TLS.HSTSMaxAge = -time.Second }},
+		{"admin.listen", func(c *config) { c.Admin.Listen = "localhost" }},
+		{"grpc.listen", func(c *config) { c.GRPC.Listen = "unix:" }},
+		{"log.level", func(c *config) { c.Log.Level = "loud" }},
+		{"log.format", func(c *config) { c.Log.Format = "xml" }},
+		{"names.sources", func(c *config) { c.Names.Sources = []string{"missing.txt"} }},
+		{"names.max_length", func(c *config) { c.Names.MaxLength = 0 }},
+		{"rules.enabled", func(c *config) { c.Rules.Enabled = []string{"no-hyphens", "no-fun"} }},
+		{"rules.enabled", func(c *config) { c.Rules.Enabled = []string{"no-hyphens", "no-hyphens"} }},
+		{"rules.enabled", func(c *config) { c.Rules.Enabled = []string{} }},
+		{"rules.close_to_mean.max_dist", func(c *config) { c.Rules.CloseToMean.MaxDist = 0 }},
+		{"rules.profile", func(c *config) { c.Rules.Profile = "initech" }},
+		{"rules.tone", func(c *config) { c.Rules.Tone = "rude" }},
+		{"rules.tone", func(c *config) { c.Rules.Tone = "" }},
+		{"rules.custom.no-lib", func(c *config) { c.Rules.Custom = []pkgname.RuleDef{{ID: "no-lib", Match: "("}} }},
+		{"rules.custom.no-lib", func(c *config) {
+			c.Rules.Custom = []pkgname.RuleDef{{ID: "no-lib", MaxLength: 3}, {ID: "no-lib", MaxLength: 4}}
+		}},
+		{"rules.custom[0]", func(c *config) { c.Rules.Custom = []pkgname.RuleDef{{MaxLength: 3}} }},
+		{"rules.scripts.matches-path", func(c *config) { c.Rules.Scripts = []scriptRule{{ID: "matches-path"}} }},
+		{"rules.scripts.no-lib", func(c *config) {
+			c.Rules.Custom = []pkgname.RuleDef{{ID: "no-lib", MaxLength: 3}}
+			c.Rules.Scripts = []scriptRule{{ID: "no-lib", Source: "def check(name, import_path, ctx):\n    return None\n"}}
+		}},
+		{"rules.scripts[0]", func(c *config) { c.Rules.Scripts = []scriptRule{{File: "missing.star"}} }},
+		{"profiles.default", func(c *config) { c.Profiles = map[string]pkgname.Profile{"default": {}} }},
+		{"profiles.Acme", func(c *config) { c.Profiles = map[string]pkgname.Profile{"Acme": {}} }},
+		{"profiles.acme", func(c *config) { c.Profiles = map[string]pkgname.Profile{"acme": {Rules: []string{"no-fun"}}} }},
+		{"profiles.acme", func(c *config) { c.Profiles = map[string]pkgname.Profile{"acme": {Tone: "rude"}} }},
+		{"history.backend", func(c *config) { c.History.Backend = "redis" }},
+		{"history.size", func(c *config) { c.History.Size = 0 }},
+		{"history.ttl", func(c *config) { c.History.TTL = -time.Second }},
+		{"rate_limit.rate", func(c *config) { c.RateLimit.Rate = -1 }},
+		{"rate_limit.burst", func(c *config) { c.RateLimit.Burst = 0 }},
+		{"server.write_timeout", func(c *config) { c.Server.WriteTimeout = -time.Second }},
+		{"stats.days", func(c *config) { c.Stats.Days = 0 }},
+		{"analytics.origins", func(c *config) { c.Analytics.Origins = []string{"google-analytics.com"} }},
+	}
+
+	if err := defaultConfig().validate(); err != nil {
+		t.Fatalf("want the defaults valid, got %v", err)
+	}
+	for _, tt := range tests {
+		c := defaultConfig()
+		tt.change(c)
+		err := c.validate()
+		if err == nil || !strings.HasPrefix(err.Error(), tt.key+":") {
+			t.Errorf("want %s reported, got %v", tt.key, err)
+		}
+	}
+
+	c := defaultConfig()
+	c.TLS.Cert, c.TLS.Key = writeTestCert(t, t.TempDir(), "pkgname", time.Now())
+	c.TLS.RedirectListen = ":80"
+	if err := c.validate(); err != nil {
+		t.Errorf("want TLS with a good certificate valid, got %v", err)
+	}
+
+	c = defaultConfig()
+	c.Rules.Custom = []pkgname.RuleDef{{ID: "no-lib", BannedPrefixes: []string{"lib"}}}
+	c.Rules.Enabled = []string{"no-hyphens", "no-lib"}
+	c.Profiles = map[string]pkgname.Profile{"acme": {Rules: []string{"no-lib"}}}
+	if err := c.validate(); err != nil {
+		t.Errorf("want custom rules picked like the others, got %v", err)
+	}
+
+	c = defaultConfig()
+	c.Rules.Scripts = []scriptRule{{ID: "matches-path", Source: "def check(name, import_path, ctx):\n    return None\n"}}
+	c.Rules.Enabled = []string{"no-hyphens", "matches-path"}
+	c.Profiles = map[string]pkgname.Profile{"acme": {Rules: []string{"matches-path"}}}
+	if err := c.validate(); err != nil {
+		t.Errorf("want script rules picked like the others, got %v", err)
+	}
+
+	c = defaultConfig()
+	c.Rules.Profile = "acme"
+	c.Profiles = map[string]pkgname.Profile{"acme": {BannedPrefixes: []string{"acme"}}}
+	if err := c.validate(); err != nil {
+		t.Errorf("want a profile of the config as the default valid, got %v", err)
+	}
+
+	c = defaultConfig()
+	c.Listen = "unix:/run/pkgname/http.sock"
+	if err := c.validate(); err != nil {
+		t.Errorf("want a Unix socket valid, got %v", err)
+	}
+
+	c = defaultConfig()
+	c.Listen, c.History.Backend = "nope", "redis"
+	if err := c.validate(); err == nil || strings.Count(err.Error(), "\n") != 1 {
+		t.Errorf("want both mistakes reported, got %v", err)
+	}
+}
+
+func TestConfigFlagsWin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pkgname.toml")
+	if err := os.WriteFile(path, []byte("listen = \":6000\"\n[rate_limit]\nburst = 3\nrate = 2.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := defaultConfig()
+	fs := flag.NewFlagSet("pkgname", flag.ContinueOnError)
+	c.flags(fs)
+	args := []string{"-port", "7000", "-rate-burst", "9"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.load(path, mapEnv(map[string]string{"PKGNAME_RATE_LIMIT_BURST": "5", "PKGNAME_RATE_LIMIT_RATE": "4"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Listen != ":7000" || c.RateLimit.Burst != 9 {
+		t.Errorf("want the flags to win, got %q and %d", c.Listen, c.RateLimit.Burst)
+	}
+	if c.RateLimit.Rate != 4 {
+		t.Errorf("want the environment to win over the file, got %v", c.RateLimit.Rate)
+	}
+}
@@ -1,68 +1,180 @@
 package main
 
 import (
-	"bufio"
-	"compress/gzip"
-	"io"
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aybabtme/pkgname/pkgname"
+	"log/slog"
 	"math/rand"
-	"os"
-	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 var (
-	maxDist   = 2.0
+	maxDist   = pkgname.MaxDist
 	queueSize = 100
+	// maxNameLength is the longest name, in bytes, that's run through the
+	// rules.
+	maxNameLength = 100
+	// historyTTL is how long recorded names are kept in the history. They're
+	// kept until newer ones push them out if it's zero.
+	historyTTL = 24 * time.Hour
+	// maxImportPathLength is the longest import path, in bytes, that's
+	// handed to the rules.
+	maxImportPathLength = 1000
+	// privateHistory keeps the history off the public endpoints, so that
+	// only the admin listener shows it.
+	privateHistory = false
 )
 
-var nameSources = []string{
-	"seed/names.flatfile",
+// nameSources are files of names added to the builtin corpus.
+var nameSources []string
+
+// enabledRules are the IDs of the rules names are checked against, all of
+// them if it's nil.
+var enabledRules []string
+
+// allRuleIDs are the IDs of the rules a server can check, including the
+// length one that's only made once the names are loaded.
+func allRuleIDs() []string {
+	var ids []string
+	for _, rule := range pkgname.BuiltinRules() {
+		ids = append(ids, rule.ID)
+	}
+	return append(ids, pkgname.RuleCloseToMean)
+}
+
+// ruleEnabled tells if the rule of id is to be checked.
+func ruleEnabled(id string) bool {
+	return enabledRules == nil || contains(enabledRules, id)
 }
 
-type Filter func(string) error
+// customRules are the rules declared in the config, checked on top of the
+// enabled ones by the default profile.
+var customRules []pkgname.RuleDef
+
+// compileRules makes the rules defs declare.
+func compileRules(defs []pkgname.RuleDef) ([]pkgname.Rule, error) {
+	var rules []pkgname.Rule
+	for _, def := range defs {
+		rule, err := def.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %v", def.ID, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+var (
+	// profiles are the rule profiles defined on top of the builtin ones, by
+	// name.
+	profiles map[string]pkgname.Profile
+	// defaultProfile is the profile of the requests that don't pick one.
+	defaultProfile = "default"
+	// defaultTone is the tone of the profiles that don't pick one.
+	defaultTone = pkgname.ToneSnarky
+)
+
+// allProfiles are the profiles names can be checked with, the default one
+// being made of enabledRules, customRules and maxDist.
+func allProfiles() map[string]pkgname.Profile {
+	all := pkgname.BuiltinProfiles()
+	def := pkgname.Profile{MaxDist: maxDist}
+	if enabledRules != nil {
+		def.Rules = append([]string(nil), enabledRules...)
+		for _, rule := range customRules {
+			def.Rules = append(def.Rules, rule.ID)
+		}
+		for _, rule := range scriptRules {
+			def.Rules = append(def.Rules, rule.ID)
+		}
+	}
+	all["default"] = def
+	for name, p := range profiles {
+		all[name] = p
+	}
+	return all
+}
 
 type DB struct {
-	lock    sync.RWMutex
-	names   []string
-	r       *rand.Rand
-	filters []Filter
+	lock  sync.RWMutex
+	names []string
+	// profiles are the rules of each profile, by name.
+	profiles map[string][]pkgname.Rule
+	// tones are the tones of each profile, by name.
+	tones map[string]pkgname.Tone
+	// The parameters of the length rule.
+	lengthMean  float64
+	lengthStdev float64
 
 	goods *leakingQueue
 	bads  *leakingQueue
+	stats *usageStats
+	// moderator keeps names out of the history.
+	moderator *moderator
 }
 
 func NewDB() *DB {
 
 	db := &DB{
-		r:     rand.New(rand.NewSource(time.Now().UnixNano())),
-		goods: newQueue(queueSize),
-		bads:  newQueue(queueSize),
-		filters: []Filter{
-			noHyphens,
-			noUnderscore,
-			notCapitalized,
-			noReferenceToGo,
-			noReferenceToGolang,
-			validPackageNames,
-		},
-	}
-
-	var goodNames []string
-	for _, name := range loadNames(nameSources) {
-		errs := db.Validate(name)
-		if len(errs) != 0 {
-			log.Printf("[DB] Rejecting %q from source: \n%s", name, strings.Join(errs, "\n"))
-		} else {
-			goodNames = append(goodNames, name)
+		profiles: make(map[string][]pkgname.Rule),
+		tones:    make(map[string]pkgname.Tone),
+		goods:    newQueue(queueSize),
+		bads:     newQueue(queueSize),
+		stats:    newUsageStats(time.Now()),
+	}
+	var builtins []pkgname.Rule
+	for _, rule := range pkgname.BuiltinRules() {
+		if ruleEnabled(rule.ID) {
+			builtins = append(builtins, rule)
+		}
+	}
+
+	var err error
+	if db.moderator, err = newModerator(denyListSource); err != nil {
+		fatal("Couldn't load deny list", "err", err)
+	}
+
+	extra, err := pkgname.LoadNames(nameSources...)
+	if err != nil {
+		fatal("Couldn't load names", "err", err)
+	}
+	corpusRejects := pkgname.CorpusRejects()
+	for name, violations := range corpusRejects {
+		slog.Debug("Rejecting name from corpus", "pkgname", name, "rules", ruleIDs(violations))
+	}
+	goodNames, badNames := pkgname.Clean(extra, builtins)
+	for name, violations := range badNames {
+		slog.Debug("Rejecting name from source", "pkgname", name, "rules", ruleIDs(violations))
+	}
+
+	db.names = append(pkgname.Corpus(), goodNames...)
+	_, mean, stdev := pkgname.LengthRule(db.names, maxDist)
+	slog.Info("Loaded names", "corpus", len(db.names), "rejected", len(corpusRejects)+len(badNames), "mean_length", mean, "stdev_length", stdev)
+	db.lengthMean, db.lengthStdev = mean, stdev
+
+	custom, err := compileRules(customRules)
+	if err != nil {
+		fatal("Couldn't compile custom rules", "err", err)
+	}
+	scripts, err := compileScripts(scriptRules, mean, stdev)
+	if err != nil {
+		fatal("Couldn't compile script rules", "err", err)
+	}
+	custom = append(custom, scripts...)
+	for name, p := range allProfiles() {
+		db.profiles[name] = p.Build(db.names, custom)
+		db.tones[name] = p.Tone
+		if p.Tone == "" {
+			db.tones[name] = defaultTone
 		}
 	}
-	db.names = goodNames
-	lengthFilter, mean, stdev := closeToMean(goodNames, maxDist)
-	log.Printf("[DB] Mean name length=%f, stdev=%f.", mean, stdev)
-	db.filters = append(db.filters, lengthFilter)
 
 	return db
 }
@@ -70,80 +182,308 @@ func NewDB() *DB {
 func (db *DB) Get() string {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
-	max := len(db.names)
-	index := db.r.Intn(max)
-	return db.names[index]
+	// The functions of math/rand are safe to call at once, unlike a Rand.
+	return db.names[rand.Intn(len(db.names))]
 }
 
-func (db *DB) Validate(pkgname string) []string {
-	db.lock.RLock()
-	var errStrs []string
-	for _, filter := range db.filters {
-		err := filter(pkgname)
-		if err != nil {
-			errStrs = append(errStrs, err.Error())
-		}
+// Profiles are the names of the profiles names can be checked with, sorted.
+func (db *DB) Profiles() []string {
+	var names []string
+	for name := range db.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkProfile rejects the profiles db doesn't have. The empty one is the
+// default.
+func (db *DB) checkProfile(profile string) error {
+	if _, ok := db.rules(profile); !ok {
+		return fmt.Errorf("Unknown profile %q, the profiles are %s.", profile, strings.Join(db.Profiles(), ", "))
+	}
+	return nil
+}
+
+// checkQuery rejects the queries db can't answer.
+func (db *DB) checkQuery(q query) error {
+	if err := checkImportPath(q.ImportPath); err != nil {
+		return err
+	}
+	if err := db.checkProfile(q.Profile); err != nil {
+		return err
+	}
+	return checkTone(q.Tone)
+}
+
+// rules are the rules of profile, or of the default profile if it's empty.
+func (db *DB) rules(profile string) ([]pkgname.Rule, bool) {
+	if profile == "" {
+		profile = defaultProfile
 	}
-	db.lock.RUnlock()
+	rules, ok := db.profiles[profile]
+	return rules, ok
+}
+
+// query tells how to check a name. Each of its fields can be left empty.
+type query struct {
+	// Profile of the rules, the default one if empty.
+	Profile string
+	// ImportPath of the package named, for the rules that look at it.
+	ImportPath string
+	// Tone of the messages, the one of the profile if empty.
+	Tone string
+	// Lang is the language of the messages, English if empty or if they
+	// aren't told in it.
+	Lang string
+}
+
+// options are the options to validate names with as q asks.
+func (db *DB) options(q query) *pkgname.Options {
+	profile := q.Profile
+	if _, ok := db.profiles[profile]; !ok {
+		profile = defaultProfile
+	}
+	tone, err := pkgname.ParseTone(q.Tone)
+	if q.Tone == "" || err != nil {
+		tone = db.tones[profile]
+	}
+	return &pkgname.Options{Rules: db.profiles[profile], ImportPath: q.ImportPath, Tone: tone, Lang: q.Lang}
+}
+
+// Validate checks name against the rules of profile and records it in the
+// history.
+func (db *DB) Validate(ctx context.Context, profile, name string, record bool) []string {
+	return causes(db.Assess(ctx, name, query{Profile: profile}, record))
+}
 
+// Assess checks name as q asks for someone who asked, so it counts in the
+// stats. It's also recorded in the history if record is set. Otherwise the
+// name is kept secret: it's neither logged nor counted among the rejected
+// names, only its verdict is.
+func (db *DB) Assess(ctx context.Context, name string, q query, record bool) []pkgname.Violation {
+	violations := db.Check(name, q)
+	attrs := []any{"profile", q.Profile, "tone", q.Tone, "lang", q.Lang, "rules", ruleIDs(violations), "record", record}
+	if record {
+		attrs = append(attrs, "pkgname", name, "import_path", q.ImportPath)
+	}
+	slog.DebugContext(ctx, "Assessed name", attrs...)
+
+	now := time.Now()
 	db.lock.Lock()
-	if len(errStrs) == 0 {
-		db.goods.Enqueue(pkgname)
-	} else {
-		db.bads.Enqueue(pkgname)
+	defer db.lock.Unlock()
+	if !record {
+		db.stats.add("", violations, now)
+		return violations
+	}
+	db.stats.add(name, violations, now)
+	db.expire(now)
+	if ok, why := db.enqueue(name, pkgname.Passed(violations), now); !ok {
+		slog.DebugContext(ctx, "Held back from history", "pkgname", name, "reason", why)
 	}
-	db.lock.Unlock()
+	return violations
+}
 
-	return errStrs
+// Check runs name through the rules as q asks, without recording it, nor
+// counting it in the stats. Unknown profiles and tones are the default
+// ones, checkQuery tells them apart.
+func (db *DB) Check(name string, q query) []pkgname.Violation {
+	return pkgname.Validate(name, db.options(q))
 }
 
+func (db *DB) enqueue(name string, good bool, now time.Time) (bool, string) {
+	if ok, why := db.moderator.allow(name); !ok {
+		return false, why
+	}
+	if good {
+		db.goods.Enqueue(name, now)
+	} else {
+		db.bads.Enqueue(name, now)
+	}
+	return true, ""
+}
+
+// Last lists the last names of the history, good and bad, newest first.
 func (db *DB) Last(last int) ([]string, []string) {
-	db.lock.RLock()
-	defer db.lock.RUnlock()
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.expire(time.Now())
 	return reverse(db.goods.Last(last)), reverse(db.bads.Last(last))
 }
 
-func loadNames(sources []string) []string {
-	var names []string
-	for _, filename := range sources {
-		names = append(names, loadSource(filename)...)
+// Expire forgets the names recorded longer than historyTTL before now.
+func (db *DB) Expire(now time.Time) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	if n := db.expire(now); n > 0 {
+		slog.Debug("Expired names from history", "count", n)
 	}
-	return names
 }
 
-func loadSource(filename string) []string {
-	file, err := os.Open(filename)
-	if err != nil {
-		log.Fatalf("opening %q: %v", filename, err)
+func (db *DB) expire(now time.Time) int {
+	if historyTTL <= 0 {
+		return 0
 	}
-	defer func() { _ = file.Close() }()
+	before := now.Add(-historyTTL)
+	db.stats.expire(before)
+	return db.goods.Expire(before) + db.bads.Expire(before)
+}
 
-	var r io.Reader
-	if filepath.Ext(filename) == ".gz" {
-		reader, err := gzip.NewReader(file)
-		if err != nil {
-			log.Fatalf("[ERROR] %q is not a valid GZIP file: %v", filename, err)
+// Purge forgets name from the history and the rejected names of the
+// stats, or every name if it's empty, and tells how many entries of the
+// history it forgot.
+func (db *DB) Purge(name string) int {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.stats.forget(name)
+	if name == "" {
+		return db.goods.Clear() + db.bads.Clear()
+	}
+	return db.goods.Remove(name) + db.bads.Remove(name)
+}
+
+// Stats reports the usage of the rules, with the top most rejected names.
+func (db *DB) Stats(top int) statsResponse {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	return db.stats.snapshot(db.allRules(), top)
+}
+
+// allRules are the rules of every profile, those of the default one first,
+// each only once.
+func (db *DB) allRules() []pkgname.Rule {
+	defaults, _ := db.rules("")
+	lists := [][]pkgname.Rule{defaults}
+	for _, name := range db.Profiles() {
+		lists = append(lists, db.profiles[name])
+	}
+
+	var all []pkgname.Rule
+	seen := make(map[string]bool)
+	for _, rules := range lists {
+		for _, rule := range rules {
+			if !seen[rule.ID] {
+				seen[rule.ID] = true
+				all = append(all, rule)
+			}
 		}
-		defer func() { _ = reader.Close() }()
-		r = reader
-	} else {
-		r = file
 	}
+	return all
+}
+
+// dbInfo describes the content of a DB.
+type dbInfo struct {
+	Corpus      int
+	Rules       int
+	Goods       int
+	Bads        int
+	LengthMean  float64
+	LengthStdev float64
+	MaxDist     float64
+}
 
-	scan := bufio.NewScanner(r)
-	scan.Split(bufio.ScanLines)
+func (db *DB) Info() dbInfo {
+	rules, _ := db.rules("")
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	return dbInfo{
+		Corpus:      len(db.names),
+		Rules:       len(rules),
+		Goods:       db.goods.Len(),
+		Bads:        db.bads.Len(),
+		LengthMean:  db.lengthMean,
+		LengthStdev: db.lengthStdev,
+		MaxDist:     maxDist,
+	}
+}
 
+// checkName rejects names that aren't worth running through the rules.
+func checkName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return errors.New("Need a package name.")
+	case len(name) > maxNameLength:
+		return fmt.Errorf("Package names are limited to %d bytes.", maxNameLength)
+	}
+	return nil
+}
+
+// checkImportPath rejects import paths that can't be one. The empty one is
+// unknown.
+func checkImportPath(importPath string) error {
+	switch {
+	case len(importPath) > maxImportPathLength:
+		return fmt.Errorf("Import paths are limited to %d bytes.", maxImportPathLength)
+	case strings.ContainsFunc(importPath, unicode.IsSpace), !utf8.ValidString(importPath):
+		return fmt.Errorf("%q isn't an import path.", importPath)
+	}
+	return nil
+}
+
+// checkTone rejects the tones messages can't be told in. The empty one is
+// the tone of the profile.
+func checkTone(tone string) error {
+	if _, err := pkgname.ParseTone(tone); err != nil {
+		return fmt.Errorf("Unknown tone %q, the tones are %s.", tone, toneNames())
+	}
+	return nil
+}
+
+// toneNames lists the tones messages can be told in.
+func toneNames() string {
 	var names []string
+	for _, tone := range pkgname.Tones() {
+		names = append(names, string(tone))
+	}
+	return strings.Join(names, ", ")
+}
+
+// causes are the messages of violations.
+func causes(violations []pkgname.Violation) []string {
+	var msgs []string
+	for _, v := range violations {
+		msgs = append(msgs, v.Message)
+	}
+	return msgs
+}
+
+// violation is a rule a name broke, as told to clients.
+type violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	// Severity is error, or warning for the rules that don't make the name
+	// shit.
+	Severity string `json:"severity"`
+}
 
-	for scan.Scan() {
-		names = append(names, strings.TrimSpace(scan.Text()))
+func toViolations(violations []pkgname.Violation) []violation {
+	var vs []violation
+	for _, v := range violations {
+		vs = append(vs, violation{Rule: v.Rule, Message: v.Message, Severity: v.Severity.String()})
 	}
+	return vs
+}
 
-	if err := scan.Err(); err != nil {
-		log.Fatalf("scanning %q: %v", filename, err)
+// bySeverity splits the messages of violations into those of errors and
+// those of warnings.
+func bySeverity(violations []pkgname.Violation) (errs, warnings []string) {
+	for _, v := range violations {
+		if v.Severity == pkgname.SeverityWarning {
+			warnings = append(warnings, v.Message)
+		} else {
+			errs = append(errs, v.Message)
+		}
 	}
+	return errs, warnings
+}
 
-	return names
+// ruleIDs are the rules of violations.
+func ruleIDs(violations []pkgname.Violation) []string {
+	var ids []string
+	for _, v := range violations {
+		ids = append(ids, v.Rule)
+	}
+	return ids
 }
 
 func reverse(str []string) []string {
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"github.com/aybabtme/pkgname/pkgname"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReverse(t *testing.T) {
+	tests := []struct {
+		in, want []string
+	}{
+		{nil, []string{}},
+		{[]string{"a"}, []string{"a"}},
+		{[]string{"a", "b"}, []string{"b", "a"}},
+		{[]string{"a", "b", "c"}, []string{"c", "b", "a"}},
+	}
+
+	for _, tt := range tests {
+		if got := reverse(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("reverse(%q): want %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestDBValidateRecords(t *testing.T) {
+	db := NewDB()
+
+	if errs := db.Validate(context.Background(), "", "lime", true); len(errs) != 0 {
+		t.Fatalf("want lime to be fine, got %q", errs)
+	}
+	if errs := db.Validate(context.Background(), "", "go-lime", true); len(errs) == 0 {
+		t.Fatalf("want go-lime to be shit")
+	}
+
+	goods, bads := db.Last(1)
+	if !reflect.DeepEqual(goods, []string{"lime"}) {
+		t.Errorf("want lime in goods, got %q", goods)
+	}
+	if !reflect.DeepEqual(bads, []string{"go-lime"}) {
+		t.Errorf("want go-lime in bads, got %q", bads)
+	}
+}
+
+func TestDBCheckDoesntRecord(t *testing.T) {
+	db := NewDB()
+
+	db.Check("lime", query{})
+	db.Check("go-lime", query{})
+
+	goods, bads := db.Last(queueSize)
+	if len(goods) != 0 || len(bads) != 0 {
+		t.Fatalf("want no history, got goods=%q bads=%q", goods, bads)
+	}
+}
+
+func TestDBLastNewestFirst(t *testing.T) {
+	db := NewDB()
+	for _, name := range []string{"one", "two", "three"} {
+		db.Validate(context.Background(), "", name, true)
+	}
+
+	goods, _ := db.Last(2)
+	if want := []string{"three", "two"}; !reflect.DeepEqual(goods, want) {
+		t.Fatalf("want %q, got %q", want, goods)
+	}
+}
+
+func TestDBGet(t *testing.T) {
+	db := NewDB()
+	for i := 0; i < 100; i++ {
+		name := db.Get()
+		opts := &pkgname.Options{Rules: pkgname.BuiltinRules()}
+		if errs := pkgname.Validate(name, opts); len(errs) != 0 {
+			t.Fatalf("generated %q is a shit name: %v", name, errs)
+		}
+	}
+
+	// Run with -race, the page and the APIs pick names at once.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				db.Get()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func FuzzDBValidate(f *testing.F) {
+	db := NewDB()
+	for _, seed := range []string{"", "lime", "go-Lime", "golang", "_", "é", "a\x00b", "日本語", "averyveryverylongname"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, name string) {
+		errs := db.Validate(context.Background(), "", name, true)
+
+		goods, bads := db.Last(1)
+		if ok, _ := db.moderator.allow(name); !ok {
+			if (len(goods) == 1 && goods[0] == name) || (len(bads) == 1 && bads[0] == name) {
+				t.Fatalf("%q was held back but is in the history", name)
+			}
+			return
+		}
+		switch {
+		case len(errs) == 0 && (len(goods) != 1 || goods[0] != name):
+			t.Fatalf("%q passed but isn't the last good name: %q", name, goods)
+		case len(errs) != 0 && (len(bads) != 1 || bads[0] != name):
+			t.Fatalf("%q failed but isn't the last bad name: %q", name, bads)
+		}
+		for _, cause := range errs {
+			if cause == "" {
+				t.Fatalf("%q failed without a cause", name)
+			}
+		}
+		if name == "" && len(errs) == 0 {
+			t.Fatalf("blank name passed")
+		}
+	})
+}
+
+func TestDBValidateDoesntRecord(t *testing.T) {
+	db := NewDB()
+	db.Validate(context.Background(), "", "lime", false)
+	if goods, bads := db.Last(1); len(goods)+len(bads) != 0 {
+		t.Errorf("want nothing recorded, got %q %q", goods, bads)
+	}
+}
+
+func TestDBExpire(t *testing.T) {
+	defer func(ttl time.Duration) { historyTTL = ttl }(historyTTL)
+	historyTTL = time.Hour
+
+	db := NewDB()
+	db.Validate(context.Background(), "", "lime", true)
+
+	db.Expire(time.Now().Add(historyTTL / 2))
+	if goods, _ := db.Last(1); len(goods) != 1 {
+		t.Fatalf("want lime kept within the TTL, got %q", goods)
+	}
+	db.Expire(time.Now().Add(historyTTL + time.Second))
+	if goods, _ := db.Last(1); len(goods) != 0 {
+		t.Fatalf("want lime forgotten past the TTL, got %q", goods)
+	}
+
+	historyTTL = 0
+	db.Validate(context.Background(), "", "lime", true)
+	db.Expire(time.Now().Add(24 * 365 * time.Hour))
+	if goods, _ := db.Last(1); len(goods) != 1 {
+		t.Fatalf("want lime kept without a TTL, got %q", goods)
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"lime", true},
+		{"", false},
+		{"  \t", false},
+		{strings.Repeat("a", maxNameLength), true},
+		{strings.Repeat("a", maxNameLength+1), false},
+	}
+	for _, tt := range tests {
+		if err := checkName(tt.name); (err == nil) != tt.ok {
+			t.Errorf("%q: want ok=%v, got %v", tt.name, tt.ok, err)
+		}
+	}
+}
+
+func TestDBModeratesHistory(t *testing.T) {
+	db := NewDB()
+	db.moderator = &moderator{terms: []string{"spam"}}
+
+	for _, name := range []string{"lime", "spamlime", "http://lime.example", "go-lime"} {
+		db.Validate(context.Background(), "", name, true)
+	}
+
+	goods, bads := db.Last(10)
+	if want := []string{"lime"}; !reflect.DeepEqual(goods, want) {
+		t.Errorf("want goods %q, got %q", want, goods)
+	}
+	if want := []string{"go-lime"}; !reflect.DeepEqual(bads, want) {
+		t.Errorf("want bads %q, got %q", want, bads)
+	}
+	if stats := db.Stats(0); stats.Validations != 4 {
+		t.Errorf("want held back names still counted, got %d", stats.Validations)
+	}
+}
+
+func TestDBProfiles(t *testing.T) {
+	defer func(p map[string]pkgname.Profile, def string) { profiles, defaultProfile = p, def }(profiles, defaultProfile)
+	profiles = map[string]pkgname.Profile{
+		"acme": {AllowSuffixes: []string{"_test"}, BannedPrefixes: []string{"acme"}},
+	}
+	defaultProfile = "strict"
+
+	db := NewDB()
+	if want := []string{"acme", "default", "stdlib-style", "strict"}; !reflect.DeepEqual(db.Profiles(), want) {
+		t.Errorf("want profiles %q, got %q", want, db.Profiles())
+	}
+
+	tests := []struct {
+		profile, name string
+		want          []string
+	}{
+		{"", "utils", []string{pkgname.RuleNoBannedPrefix}},
+		{"default", "utils", nil},
+		{"acme", "lime_test", nil},
+		{"acme", "acmeauth", []string{pkgname.RuleNoBannedPrefix}},
+		{"default", "lime_test", []string{pkgname.RuleNoUnderscore}},
+	}
+	for _, tt := range tests {
+		if got := ruleIDs(db.Check(tt.name, query{Profile: tt.profile})); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %q: want %q, got %q", tt.profile, tt.name, tt.want, got)
+		}
+	}
+
+	for _, profile := range []string{"", "acme", "strict"} {
+		if err := db.checkProfile(profile); err != nil {
+			t.Errorf("want profile %q known, got %v", profile, err)
+		}
+	}
+	if err := db.checkProfile("initech"); err == nil || !strings.Contains(err.Error(), "acme, default") {
+		t.Errorf("want the profiles listed, got %v", err)
+	}
+}
+
+func TestDBCustomRules(t *testing.T) {
+	defer func(defs []pkgname.RuleDef, p map[string]pkgname.Profile) { customRules, profiles = defs, p }(customRules, profiles)
+	customRules = []pkgname.RuleDef{
+		{ID: "no-lib", BannedPrefixes: []string{"lib"}},
+		{ID: "short", MaxLength: 6, Severity: "warning"},
+	}
+	profiles = map[string]pkgname.Profile{"hyphens-only": {Rules: []string{pkgname.RuleNoHyphens}}}
+
+	db := NewDB()
+	tests := []struct {
+		profile, name string
+		want          []string
+		passed        bool
+	}{
+		{"", "libweb", []string{"no-lib"}, false},
+		{"", "limelight", []string{"short"}, true},
+		{"strict", "libweb", []string{"no-lib"}, false},
+		{"hyphens-only", "libweb", nil, true},
+	}
+	for _, tt := range tests {
+		violations := db.Check(tt.name, query{Profile: tt.profile})
+		if got := ruleIDs(violations); !reflect.DeepEqual(got, tt.want) || pkgname.Passed(violations) != tt.passed {
+			t.Errorf("%s %q: want %q passed=%v, got %q", tt.profile, tt.name, tt.want, tt.passed, got)
+		}
+	}
+
+	db.Validate(context.Background(), "", "limelight", true)
+	if goods, _ := db.Last(10); !reflect.DeepEqual(goods, []string{"limelight"}) {
+		t.Errorf("want names with warnings recorded as good, got %q", goods)
+	}
+}
+
+func TestDBScriptRules(t *testing.T) {
+	defer func(s []scriptRule) { scriptRules = s }(scriptRules)
+	scriptRules = []scriptRule{{ID: "matches-path", Source: `
+def check(name, import_path, ctx):
+    if import_path and not import_path.endswith("/" + name):
+        return "Name it like its directory."
+`}}
+
+	db := NewDB()
+	if got := ruleIDs(db.Check("lime", query{ImportPath: "example.com/lime"})); got != nil {
+		t.Errorf("want a matching path fine, got %q", got)
+	}
+	if got := ruleIDs(db.Check("lime", query{ImportPath: "example.com/citrus"})); !reflect.DeepEqual(got, []string{"matches-path"}) {
+		t.Errorf("want the script rule checked, got %q", got)
+	}
+	if got := ruleIDs(db.Check("lime", query{Profile: "strict", ImportPath: "example.com/citrus"})); !reflect.DeepEqual(got, []string{"matches-path"}) {
+		t.Errorf("want the script rule checked by builtin profiles, got %q", got)
+	}
+}
+
+func TestDBTones(t *testing.T) {
+	defer func(p map[string]pkgname.Profile, tone pkgname.Tone) { profiles, defaultTone = p, tone }(profiles, defaultTone)
+	profiles = map[string]pkgname.Profile{"acme": {Tone: pkgname.ToneProfessional}}
+	defaultTone = pkgname.ToneSnarky
+
+	db := NewDB()
+	snarky, professional := "Don't put hyphens, that's ugly.", "Package names shouldn't contain hyphens."
+	tests := []struct {
+		q    query
+		want string
+	}{
+		{query{}, snarky},
+		{query{Tone: "professional"}, professional},
+		{query{Profile: "acme"}, professional},
+		{query{Profile: "acme", Tone: "snarky"}, snarky},
+		{query{Tone: "rude"}, snarky},
+	}
+	for _, tt := range tests {
+		if got := causes(db.Check("lime-green", tt.q)); !reflect.DeepEqual(got, []string{tt.want}) {
+			t.Errorf("%+v: want %q, got %q", tt.q, tt.want, got)
+		}
+	}
+	if err := db.checkQuery(query{Tone: "rude"}); err == nil {
+		t.Error("want an unknown tone rejected")
+	}
+
+	defaultTone = pkgname.ToneProfessional
+	if got := causes(NewDB().Check("lime-green", query{})); !reflect.DeepEqual(got, []string{professional}) {
+		t.Errorf("want the default tone of the config, got %q", got)
+	}
+}
+
+func TestDBEnabledRules(t *testing.T) {
+	defer func(enabled []string) { enabledRules = enabled }(enabledRules)
+	enabledRules = []string{pkgname.RuleNoHyphens}
+
+	db := NewDB()
+	if info := db.Info(); info.Rules != 1 {
+		t.Fatalf("want a single rule, got %d", info.Rules)
+	}
+	if errs := db.Validate(context.Background(), "", "Go_lime", false); len(errs) != 0 {
+		t.Errorf("want the disabled rules not checked, got %q", errs)
+	}
+	if errs := db.Validate(context.Background(), "", "go-lime", false); len(errs) != 1 {
+		t.Errorf("want the enabled rule checked, got %q", errs)
+	}
+}
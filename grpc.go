@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"github.com/aybabtme/pkgname/pkgname"
+	"github.com/aybabtme/pkgname/pkgnamepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+)
+
+// rpcServer serves the gRPC flavor of the API off the same DB as the HTTP
+// handlers.
+type rpcServer struct {
+	pkgnamepb.UnimplementedPkgnameServer
+	db *DB
+}
+
+func newGRPCServer(db *DB, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryRequestID),
+		grpc.ChainStreamInterceptor(streamRequestID),
+	}, opts...)
+	srv := grpc.NewServer(opts...)
+	pkgnamepb.RegisterPkgnameServer(srv, &rpcServer{db: db})
+	return srv
+}
+
+func (s *rpcServer) validate(ctx context.Context, req *pkgnamepb.ValidateRequest) (*pkgnamepb.ValidateResponse, error) {
+	if err := checkName(req.GetPkgname()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	q := query{Profile: req.GetProfile(), ImportPath: req.GetImportPath(), Tone: req.GetTone(), Lang: req.GetLang()}
+	if err := s.db.checkQuery(q); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	errs := s.db.Assess(ctx, req.GetPkgname(), q, req.GetRecord())
+
+	return &pkgnamepb.ValidateResponse{
+		Pkgname:    req.GetPkgname(),
+		Success:    pkgname.Passed(errs),
+		Causes:     causes(errs),
+		Violations: pbViolations(errs),
+	}, nil
+}
+
+func pbViolations(violations []pkgname.Violation) []*pkgnamepb.Violation {
+	var vs []*pkgnamepb.Violation
+	for _, v := range toViolations(violations) {
+		vs = append(vs, &pkgnamepb.Violation{Rule: v.Rule, Message: v.Message, Severity: v.Severity})
+	}
+	return vs
+}
+
+func (s *rpcServer) Validate(ctx context.Context, req *pkgnamepb.ValidateRequest) (*pkgnamepb.ValidateResponse, error) {
+	return s.validate(ctx, req)
+}
+
+func (s *rpcServer) ValidateStream(stream pkgnamepb.Pkgname_ValidateStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := s.validate(stream.Context(), req)
+		if st, ok := status.FromError(err); ok && st.Code() == codes.InvalidArgument {
+			// A bad name doesn't spoil the ones after it, like in a batch.
+			res, err = &pkgnamepb.ValidateResponse{Pkgname: req.GetPkgname(), Error: st.Message()}, nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *rpcServer) Generate(ctx context.Context, req *pkgnamepb.GenerateRequest) (*pkgnamepb.GenerateResponse, error) {
+	return &pkgnamepb.GenerateResponse{Pkgname: s.db.Get()}, nil
+}
+
+func (s *rpcServer) History(ctx context.Context, req *pkgnamepb.HistoryRequest) (*pkgnamepb.HistoryResponse, error) {
+	last := int(req.GetLast())
+	switch {
+	case last == 0:
+		last = 10
+	case last < 0 || last > queueSize:
+		return nil, status.Errorf(codes.InvalidArgument, "last must be between 1 and %d.", queueSize)
+	}
+
+	if privateHistory {
+		return nil, status.Error(codes.PermissionDenied, historyPrivateMsg)
+	}
+
+	goods, bads := s.db.Last(last)
+	return &pkgnamepb.HistoryResponse{Goods: goods, Bads: bads}, nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"github.com/aybabtme/pkgname/client"
+	"github.com/aybabtme/pkgname/pkgname"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, db *DB, opts ...grpc.ServerOption) *client.Client {
+	l := bufconn.Listen(1 << 20)
+	srv := newGRPCServer(db, opts...)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	c, err := client.Dial("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dialing bufconn: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestGRPCValidate(t *testing.T) {
+	c := newTestClient(t, NewDB())
+	ctx := context.Background()
+
+	tests := []struct {
+		pkgname string
+		success bool
+	}{
+		{"docker", true},
+		{"go-Docker", false},
+		{"golang_thing", false},
+	}
+
+	for _, tt := range tests {
+		got, err := c.Validate(ctx, tt.pkgname)
+		if err != nil {
+			t.Fatalf("validating %q: %v", tt.pkgname, err)
+		}
+		if got.Pkgname != tt.pkgname {
+			t.Errorf("want pkgname %q, got %q", tt.pkgname, got.Pkgname)
+		}
+		if got.Success != tt.success {
+			t.Errorf("%q: want success=%v, got %v (causes %q)", tt.pkgname, tt.success, got.Success, got.Causes)
+		}
+		if !got.Success && len(got.Causes) == 0 {
+			t.Errorf("%q: want causes for failure", tt.pkgname)
+		}
+	}
+}
+
+func TestGRPCValidateViolations(t *testing.T) {
+	defer func(defs []pkgname.RuleDef) { customRules = defs }(customRules)
+	customRules = []pkgname.RuleDef{{ID: "short", MaxLength: 6, Severity: "warning"}}
+	c := newTestClient(t, NewDB())
+
+	got, err := c.Validate(context.Background(), "lime-light")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []client.Violation{
+		{Rule: pkgname.RuleNoHyphens, Message: "Don't put hyphens, that's ugly.", Severity: "error"},
+		{Rule: "short", Message: "This package name is too long, make it at most 6 characters long.", Severity: "warning"},
+	}
+	if !reflect.DeepEqual(got.Violations, want) {
+		t.Errorf("want %+v, got %+v", want, got.Violations)
+	}
+}
+
+func TestGRPCValidateNeedsName(t *testing.T) {
+	c := newTestClient(t, NewDB())
+
+	_, err := c.Validate(context.Background(), "")
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("want InvalidArgument, got %v", err)
+	}
+}
+
+func TestGRPCValidateProfile(t *testing.T) {
+	c := newTestClient(t, NewDB())
+	ctx := context.Background()
+
+	c.Profile = "strict"
+	got, err := c.Validate(ctx, "utils")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Success {
+		t.Errorf("want utils to fail the strict profile")
+	}
+
+	c.Profile = "initech"
+	if _, err := c.Validate(ctx, "utils"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("want InvalidArgument for an unknown profile, got %v", err)
+	}
+}
+
+func TestGRPCValidateLang(t *testing.T) {
+	c := newTestClient(t, NewDB())
+
+	c.Lang = "de"
+	got, err := c.Validate(context.Background(), "lime-green")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Causes) != 1 || got.Causes[0] != "Keine Bindestriche, das ist hässlich." {
+		t.Errorf("want a German message, got %+v", got)
+	}
+}
+
+func TestGRPCValidateAll(t *testing.T) {
+	c := newTestClient(t, NewDB())
+
+	names := []string{"docker", "go-Docker", "", "etcd", "Bad_Name"}
+	verdicts, err := c.ValidateAll(context.Background(), names)
+	if err != nil {
+		t.Fatalf("validating stream: %v", err)
+	}
+	if len(verdicts) != len(names) {
+		t.Fatalf("want %d verdicts, got %d", len(names), len(verdicts))
+	}
+	for i, v := range verdicts {
+		if v.Pkgname != names[i] {
+			t.Errorf("verdict %d: want %q, got %q", i, names[i], v.Pkgname)
+		}
+		if (v.Error != "") != (names[i] == "") {
+			t.Errorf("verdict %d: want an error only for the empty name, got %q", i, v.Error)
+		}
+	}
+}
+
+func TestGRPCValidateAllRateLimit(t *testing.T) {
+	l, _ := newTestLimiter(100, 3, 10)
+	l.now = time.Now
+	c := newTestClient(t, NewDB(), l.grpcOptions()...)
+
+	start := time.Now()
+	got, err := c.ValidateAll(context.Background(), []string{"lime", "lemon", "orange", "kiwi", "melon"})
+	if err != nil || len(got) != 5 {
+		t.Fatalf("want the stream to wait for tokens, got %v %v", got, err)
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("want the last names held back, took %v", elapsed)
+	}
+
+	l, _ = newTestLimiter(0.001, 2, 10)
+	c = newTestClient(t, NewDB(), l.grpcOptions()...)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ValidateAll(ctx, []string{"lime", "lemon", "orange"}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("want the stream to wait until the client gives up, got %v", err)
+	}
+}
+
+func TestGRPCRecording(t *testing.T) {
+	db := NewDB()
+	c := newTestClient(t, db)
+	ctx := context.Background()
+
+	goodsBefore, badsBefore := db.Last(queueSize)
+
+	if _, err := c.ValidateAll(ctx, []string{"lost", "Lost_Name"}); err != nil {
+		t.Fatal(err)
+	}
+	goods, bads := db.Last(queueSize)
+	if !reflect.DeepEqual(goods, goodsBefore) || !reflect.DeepEqual(bads, badsBefore) {
+		t.Fatalf("names were recorded without asking")
+	}
+
+	c.Record = true
+	if _, err := c.Validate(ctx, "kept"); err != nil {
+		t.Fatal(err)
+	}
+	goods, _, err := c.History(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"kept"}; !reflect.DeepEqual(goods, want) {
+		t.Fatalf("want history %q, got %q", want, goods)
+	}
+}
+
+func TestGRPCGenerate(t *testing.T) {
+	db := NewDB()
+	c := newTestClient(t, db)
+
+	name, err := c.Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := pkgname.Validate(name, &pkgname.Options{Rules: pkgname.BuiltinRules()}); len(errs) != 0 {
+		t.Fatalf("generated %q is a shit name: %q", name, errs)
+	}
+}
+
+func TestGRPCHistoryBounds(t *testing.T) {
+	c := newTestClient(t, NewDB())
+
+	for _, last := range []int{-1, queueSize + 1} {
+		_, _, err := c.History(context.Background(), last)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("last=%d: want InvalidArgument, got %v", last, err)
+		}
+	}
+}
+
+func TestGRPCHistoryPrivate(t *testing.T) {
+	defer func(private bool) { privateHistory = private }(privateHistory)
+	privateHistory = true
+
+	c := newTestClient(t, NewDB())
+	if _, _, err := c.History(context.Background(), 10); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("want PermissionDenied, got %v", err)
+	}
+}
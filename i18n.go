@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aybabtme/pkgname/pkgname"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// negotiateLang picks the language of a response: lang if messages are told
+// in it, or else the one the Accept-Language header prefers, or English.
+func negotiateLang(lang, acceptLanguage string) string {
+	if l, ok := pkgname.MatchLang(lang); ok {
+		return l
+	}
+
+	best, bestQ := pkgname.LangEnglish, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if qs, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(qs, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		if l, ok := pkgname.MatchLang(tag); ok {
+			best, bestQ = l, q
+		} else if strings.TrimSpace(tag) == "*" {
+			best, bestQ = pkgname.LangEnglish, q
+		}
+	}
+	return best
+}
+
+// requestLang is the language to answer r in, lang if it's given, and says
+// so in the headers of w.
+func requestLang(w http.ResponseWriter, r *http.Request, lang string) string {
+	w.Header().Add("Vary", "Accept-Language")
+	lang = negotiateLang(lang, r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", lang)
+	return lang
+}
+
+// uiText are the words of the pages by language, then by key. Those missing
+// in a language are told in English.
+var uiText = map[string]map[string]string{
+	pkgname.LangEnglish: {
+		"title":             "What is your pkg name?",
+		"description":       "Find out if your pkg name is shit",
+		"submit":            "Is it shit?",
+		"record.public":     "Show it in the public history",
+		"record.private":    "Keep it in the history",
+		"tone":              "Tone",
+		"tone.default":      "default",
+		"tone.snarky":       "snarky",
+		"tone.professional": "professional",
+		"example":           "Example",
+		"history":           "History",
+		"good":              "is not a shit pkg name",
+		"bad":               "is a shit pkg name",
+		"goods":             "These are not shit names",
+		"bads":              "These are shit names",
+		"again":             "Try again with a different pkg name",
+		"by":                "by",
+		"error.blank":       "Need a package name.",
+		"error.too-long":    "Package names are limited to %d bytes.",
+	},
+	pkgname.LangFrench: {
+		"title":             "Quel est le nom de ton paquet?",
+		"description":       "Découvre si le nom de ton paquet est merdique",
+		"submit":            "C'est merdique?",
+		"record.public":     "L'afficher dans l'historique public",
+		"record.private":    "Le garder dans l'historique",
+		"tone":              "Ton",
+		"tone.default":      "par défaut",
+		"tone.snarky":       "sarcastique",
+		"tone.professional": "professionnel",
+		"example":           "Exemple",
+		"history":           "Historique",
+		"good":              "n'est pas un nom de paquet merdique",
+		"bad":               "est un nom de paquet merdique",
+		"goods":             "Ces noms ne sont pas merdiques",
+		"bads":              "Ces noms sont merdiques",
+		"again":             "Réessaie avec un autre nom de paquet",
+		"by":                "par",
+		"error.blank":       "Il faut un nom de paquet.",
+		"error.too-long":    "Les noms de paquets sont limités à %d octets.",
+	},
+	pkgname.LangGerman: {
+		"title":             "Wie heißt dein Paket?",
+		"description":       "Finde heraus, ob dein Paketname scheiße ist",
+		"submit":            "Ist er scheiße?",
+		"record.public":     "Im öffentlichen Verlauf zeigen",
+		"record.private":    "Im Verlauf behalten",
+		"tone":              "Ton",
+		"tone.default":      "Standard",
+		"tone.snarky":       "schnippisch",
+		"tone.professional": "professionell",
+		"example":           "Beispiel",
+		"history":           "Verlauf",
+		"good":              "ist kein scheiß Paketname",
+		"bad":               "ist ein scheiß Paketname",
+		"goods":             "Diese Namen sind nicht scheiße",
+		"bads":              "Diese Namen sind scheiße",
+		"again":             "Versuch es mit einem anderen Paketnamen",
+		"by":                "von",
+		"error.blank":       "Gib einen Paketnamen an.",
+		"error.too-long":    "Paketnamen sind auf %d Bytes begrenzt.",
+	},
+}
+
+// langNames are the names of the languages, in themselves.
+var langNames = map[string]string{
+	pkgname.LangEnglish: "English",
+	pkgname.LangFrench:  "Français",
+	pkgname.LangGerman:  "Deutsch",
+}
+
+// text are the words of the pages in lang, English where it's missing.
+func text(lang string) map[string]string {
+	words := make(map[string]string, len(uiText[pkgname.LangEnglish]))
+	for key, word := range uiText[pkgname.LangEnglish] {
+		words[key] = word
+	}
+	for key, word := range uiText[lang] {
+		words[key] = word
+	}
+	return words
+}
+
+// nameError tells why checkName rejects name in the words of lang, or "" if
+// it doesn't. checkName tells it in English, for the APIs.
+func nameError(name, lang string) string {
+	switch err := checkName(name); {
+	case err == nil:
+		return ""
+	case len(name) > maxNameLength:
+		return fmt.Sprintf(text(lang)["error.too-long"], maxNameLength)
+	default:
+		return text(lang)["error.blank"]
+	}
+}
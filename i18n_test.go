@@ -0,0 +1,83 @@
+package main
+
+import (
+	"github.com/aybabtme/pkgname/pkgname"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateLang(t *testing.T) {
+	tests := []struct {
+		lang, acceptLanguage string
+		want                 string
+	}{
+		{"", "", "en"},
+		{"fr", "", "fr"},
+		{"de-AT", "fr", "de"},
+		{"es", "fr-CA, fr;q=0.9, en;q=0.8", "fr"},
+		{"", "es, de;q=0.5, fr;q=0.7", "fr"},
+		{"", "es, pt;q=0.5", "en"},
+		{"", "de;q=0, fr;q=0.1", "fr"},
+		{"", "*;q=0.9, de;q=0.8", "en"},
+		{"", "de;q=nope, fr;q=0.2", "fr"},
+	}
+	for _, tt := range tests {
+		if got := negotiateLang(tt.lang, tt.acceptLanguage); got != tt.want {
+			t.Errorf("%q %q: want %q, got %q", tt.lang, tt.acceptLanguage, tt.want, got)
+		}
+	}
+}
+
+func TestRequestLang(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Language", "de-CH")
+	rr := httptest.NewRecorder()
+	if got := requestLang(rr, r, ""); got != "de" {
+		t.Errorf("want the language of the header, got %q", got)
+	}
+	if rr.Header().Get("Content-Language") != "de" || rr.Header().Get("Vary") != "Accept-Language" {
+		t.Errorf("want the language in the headers, got %v", rr.Header())
+	}
+}
+
+func TestUIText(t *testing.T) {
+	for _, lang := range pkgname.Langs() {
+		words, ok := uiText[lang]
+		if !ok {
+			t.Errorf("%s: want the words of the pages", lang)
+			continue
+		}
+		for key := range uiText[pkgname.LangEnglish] {
+			if words[key] == "" {
+				t.Errorf("%s: want a word for %s", lang, key)
+			}
+		}
+		if langNames[lang] == "" {
+			t.Errorf("%s: want the name of the language", lang)
+		}
+		for _, tone := range pkgname.Tones() {
+			if words["tone."+string(tone)] == "" {
+				t.Errorf("%s: want a word for the %s tone", lang, tone)
+			}
+		}
+	}
+	if got := text("es")["submit"]; got != "Is it shit?" {
+		t.Errorf("want English for the languages without words, got %q", got)
+	}
+}
+
+func TestNameError(t *testing.T) {
+	for _, name := range []string{"lime", "", "  ", strings.Repeat("a", maxNameLength+1)} {
+		want := ""
+		if err := checkName(name); err != nil {
+			want = err.Error()
+		}
+		if got := nameError(name, pkgname.LangEnglish); got != want {
+			t.Errorf("%q: want the words of checkName, %q, got %q", name, want, got)
+		}
+	}
+	if got := nameError(strings.Repeat("a", maxNameLength+1), pkgname.LangFrench); got != "Les noms de paquets sont limités à 100 octets." {
+		t.Errorf("want the error told in French, got %q", got)
+	}
+}
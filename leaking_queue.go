@@ -1,26 +1,76 @@
 package main
 
+import "time"
+
+// queued is a name and when it was queued.
+type queued struct {
+	name string
+	at   time.Time
+}
+
 type leakingQueue struct {
 	max int
-	vec []string
+	vec []queued
 }
 
 func newQueue(size int) *leakingQueue {
 	return &leakingQueue{
 		max: size,
-		vec: make([]string, 0, size),
+		vec: make([]queued, 0, size),
 	}
 }
 
-func (l *leakingQueue) Enqueue(s string) {
-	if len(l.vec) >= l.max-1 {
+func (l *leakingQueue) Enqueue(s string, at time.Time) {
+	if l.max <= 0 {
+		return
+	}
+	if len(l.vec) >= l.max {
 		l.vec = l.vec[1:]
 	}
-	l.vec = append(l.vec, s)
+	l.vec = append(l.vec, queued{name: s, at: at})
+}
+
+func (l *leakingQueue) Len() int {
+	return len(l.vec)
 }
 
 func (l *leakingQueue) Last(size int) []string {
-	return l.vec[max(len(l.vec)-size, 0):]
+	last := l.vec[max(len(l.vec)-size, 0):]
+	names := make([]string, len(last))
+	for i, q := range last {
+		names[i] = q.name
+	}
+	return names
+}
+
+// Expire drops the names queued before t, and tells how many there were.
+func (l *leakingQueue) Expire(t time.Time) int {
+	n := 0
+	for n < len(l.vec) && l.vec[n].at.Before(t) {
+		n++
+	}
+	l.vec = append(l.vec[:0], l.vec[n:]...)
+	return n
+}
+
+// Remove drops every occurrence of s, and tells how many there were.
+func (l *leakingQueue) Remove(s string) int {
+	kept := l.vec[:0]
+	for _, q := range l.vec {
+		if q.name != s {
+			kept = append(kept, q)
+		}
+	}
+	n := len(l.vec) - len(kept)
+	l.vec = kept
+	return n
+}
+
+// Clear drops every name, and tells how many there were.
+func (l *leakingQueue) Clear() int {
+	n := len(l.vec)
+	l.vec = l.vec[:0]
+	return n
 }
 
 func max(a, b int) int {
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLeakingQueueHoldsMax(t *testing.T) {
+	q := newQueue(3)
+	for i := 0; i < 3; i++ {
+		q.Enqueue(strconv.Itoa(i), time.Time{})
+	}
+
+	want := []string{"0", "1", "2"}
+	if got := q.Last(10); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestLeakingQueueEvictsOldest(t *testing.T) {
+	q := newQueue(3)
+	for i := 0; i < 5; i++ {
+		q.Enqueue(strconv.Itoa(i), time.Time{})
+	}
+
+	want := []string{"2", "3", "4"}
+	if got := q.Last(10); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func TestLeakingQueueLast(t *testing.T) {
+	q := newQueue(10)
+	for i := 0; i < 5; i++ {
+		q.Enqueue(strconv.Itoa(i), time.Time{})
+	}
+
+	tests := []struct {
+		size int
+		want []string
+	}{
+		{0, []string{}},
+		{1, []string{"4"}},
+		{3, []string{"2", "3", "4"}},
+		{5, []string{"0", "1", "2", "3", "4"}},
+		{50, []string{"0", "1", "2", "3", "4"}},
+	}
+
+	for _, tt := range tests {
+		if got := q.Last(tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Last(%d): want %q, got %q", tt.size, tt.want, got)
+		}
+	}
+}
+
+func TestLeakingQueueEmpty(t *testing.T) {
+	if got := newQueue(3).Last(3); len(got) != 0 {
+		t.Fatalf("want nothing, got %q", got)
+	}
+
+	q := newQueue(0)
+	q.Enqueue("a", time.Time{})
+	if got := q.Last(3); len(got) != 0 {
+		t.Fatalf("want nothing in a queue of size 0, got %q", got)
+	}
+}
+
+func TestLeakingQueueExpire(t *testing.T) {
+	q := newQueue(10)
+	start := time.Unix(0, 0)
+	for i := 0; i < 5; i++ {
+		q.Enqueue(strconv.Itoa(i), start.Add(time.Duration(i)*time.Minute))
+	}
+
+	if n := q.Expire(start.Add(2 * time.Minute)); n != 2 {
+		t.Errorf("want 2 expired, got %d", n)
+	}
+	want := []string{"2", "3", "4"}
+	if got := q.Last(10); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if n := q.Expire(start); n != 0 {
+		t.Errorf("want none expired, got %d", n)
+	}
+}
+
+func TestLeakingQueueRemove(t *testing.T) {
+	q := newQueue(10)
+	for _, s := range []string{"a", "b", "a", "c"} {
+		q.Enqueue(s, time.Time{})
+	}
+
+	if n := q.Remove("a"); n != 2 {
+		t.Errorf("want 2 removed, got %d", n)
+	}
+	want := []string{"b", "c"}
+	if got := q.Last(10); !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if n := q.Clear(); n != 2 || q.Len() != 0 {
+		t.Fatalf("want 2 cleared and nothing left, got %d and %d", n, q.Len())
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// unixPrefix marks the addresses that are Unix domain sockets, like
+// unix:/run/pkgname/http.sock.
+const unixPrefix = "unix:"
+
+// socketMode is the permissions of the Unix sockets the server makes, so
+// that a reverse proxy in the same group can connect.
+var socketMode os.FileMode = 0o660
+
+// parentPIDEnv tells a server started by a handover which process to stop
+// once it's ready.
+const parentPIDEnv = envPrefix + "PARENT_PID"
+
+// listenFDsStart is the first file descriptor of inherited listeners, right
+// after stdin, stdout and stderr.
+const listenFDsStart = 3
+
+// listeners opens the listeners of the server, each by a name like http or
+// grpc. Listeners passed in by systemd socket activation or by a handover
+// are used first, under the name they're passed with.
+type listeners struct {
+	lock      sync.Mutex
+	inherited map[string]net.Listener
+	open      []namedListener
+}
+
+type namedListener struct {
+	name string
+	net.Listener
+}
+
+// newListeners picks up the listeners passed in the LISTEN_FDS way of
+// systemd. A single listener passed without a name is taken as http.
+func newListeners() (*listeners, error) {
+	ls := &listeners{inherited: make(map[string]net.Listener)}
+	defer func() {
+		for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return ls, nil
+	}
+	n, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < n; i++ {
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		if n == 1 && name == "unknown" {
+			name = "http"
+		}
+
+		f := os.NewFile(uintptr(listenFDsStart+i), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherited listener %d (%s) isn't a listening socket, %v", i, name, err)
+		}
+		ls.inherited[name] = l
+	}
+	return ls, nil
+}
+
+// listen opens the listener of name on addr, unless one was passed in
+// under that name.
+func (ls *listeners) listen(name, addr string) (net.Listener, error) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	l, ok := ls.inherited[name]
+	if ok {
+		delete(ls.inherited, name)
+		slog.Info("Using inherited listener", "name", name, "addr", l.Addr())
+	} else {
+		var err error
+		if l, err = listenAddr(addr); err != nil {
+			return nil, err
+		}
+	}
+	ls.open = append(ls.open, namedListener{name, l})
+	return l, nil
+}
+
+func listenAddr(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	// A socket left behind by a server that's gone is in the way, but one
+	// that still answers is someone else's.
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, socketMode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// handover starts a new server with the listeners of this one, so that it
+// can take over without refusing a single connection. The new server stops
+// this one once it's ready.
+func (ls *listeners) handover() (*os.Process, error) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*os.File
+	var names []string
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, l := range ls.open {
+		fl, ok := l.Listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			return nil, fmt.Errorf("can't hand over listener %s", l.name)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		names = append(names, l.name)
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		"LISTEN_FDS="+strconv.Itoa(len(files)),
+		"LISTEN_FDNAMES="+strings.Join(names, ":"),
+		parentPIDEnv+"="+strconv.Itoa(os.Getpid()),
+	)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	// The socket files have to outlive this server for the next one, until
+	// it exits before taking over.
+	ls.setUnlinkOnClose(false)
+	return cmd.Process, nil
+}
+
+// unlinkOnClose sets whether the socket files of the Unix listeners are
+// removed once they're closed, as they are unless handed over.
+func (ls *listeners) unlinkOnClose(unlink bool) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	ls.setUnlinkOnClose(unlink)
+}
+
+func (ls *listeners) setUnlinkOnClose(unlink bool) {
+	for _, l := range ls.open {
+		if ul, ok := l.Listener.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(unlink)
+		}
+	}
+}
+
+// stopParent stops the server this one took over from, if any, now that
+// it's ready to take its place.
+func stopParent() error {
+	s := os.Getenv(parentPIDEnv)
+	if s == "" {
+		return nil
+	}
+	os.Unsetenv(parentPIDEnv)
+	pid, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("bad %s %q", parentPIDEnv, s)
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	slog.Info("Taking over", "from", pid)
+	return p.Signal(syscall.SIGTERM)
+}
+
+// sdNotify tells systemd about the state of the server, when it runs as a
+// notify service.
+func sdNotify(state string) error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+	if strings.HasPrefix(addr, "@") {
+		addr = "\x00" + addr[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
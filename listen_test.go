@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pkgname.sock")
+
+	l, err := listenAddr(unixPrefix + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 || fi.Mode().Perm() != socketMode {
+		t.Errorf("want a socket with mode %v, got %v", socketMode, fi.Mode())
+	}
+
+	if _, err := listenAddr(unixPrefix + path); err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("want a socket in use left alone, got %v", err)
+	}
+
+	// Leave the socket behind, like a server that crashed would.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	l, err = listenAddr(unixPrefix + path)
+	if err != nil {
+		t.Fatalf("want a stale socket replaced, got %v", err)
+	}
+	l.Close()
+}
+
+func TestListenersUnlinkOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pkgname.sock")
+	ls := &listeners{}
+	l, err := ls.listen("http", unixPrefix+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A handover that failed puts things back as they were.
+	ls.unlinkOnClose(false)
+	ls.unlinkOnClose(true)
+	l.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("want the socket removed, got %v", err)
+	}
+
+	if l, err = ls.listen("http", unixPrefix+path); err != nil {
+		t.Fatal(err)
+	}
+	ls.unlinkOnClose(false)
+	l.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("want the socket left for the next server, got %v", err)
+	}
+}
+
+func TestListenersIgnoreOthers(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	ls, err := newListeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ls.inherited) != 0 {
+		t.Errorf("want the listeners of another process ignored, got %v", ls.inherited)
+	}
+	if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+		t.Errorf("want LISTEN_FDS unset so that it isn't passed on")
+	}
+}
+
+// TestListenersHelper serves as the process inheriting a listener in
+// TestListenersInherit.
+func TestListenersHelper(t *testing.T) {
+	if os.Getenv("PKGNAME_TEST_HELPER") == "" {
+		t.Skip("only run by TestListenersInherit")
+	}
+	ls, err := newListeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := ls.listen("grpc", "not an address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("pid " + strconv.Itoa(os.Getpid()) + "\n"))
+	conn.Close()
+}
+
+func TestListenersInherit(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestListenersHelper$")
+	cmd.Env = append(os.Environ(), "PKGNAME_TEST_HELPER=1", "LISTEN_FDS=1", "LISTEN_FDNAMES=grpc")
+	cmd.ExtraFiles = []*os.File{f}
+	out := &strings.Builder{}
+	cmd.Stdout, cmd.Stderr = out, out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// Only the helper accepts from now on.
+	l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading from the helper: %v\n%s", err, out)
+	}
+	if want := "pid " + strconv.Itoa(cmd.Process.Pid) + "\n"; line != want {
+		t.Errorf("want %q, got %q", want, line)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("helper failed: %v\n%s", err, out)
+	}
+}
+
+func TestStopParent(t *testing.T) {
+	cmd := exec.Command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Skip("can't start a process to stop:", err)
+	}
+	t.Setenv(parentPIDEnv, strconv.Itoa(cmd.Process.Pid))
+
+	if err := stopParent(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err == nil || !strings.Contains(err.Error(), "terminated") {
+		t.Errorf("want the parent terminated, got %v", err)
+	}
+	if err := stopParent(); err != nil {
+		t.Errorf("want nothing to stop a second time, got %v", err)
+	}
+}
+
+func TestSdNotify(t *testing.T) {
+	if err := sdNotify("READY=1"); err != nil {
+		t.Errorf("want nothing done outside of systemd, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "READY=1" {
+		t.Errorf("want READY=1, got %q", got)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"google.golang.org/grpc/metadata"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs sends the default logs to a buffer, as JSON, until the test
+// is over.
+func captureLogs(t *testing.T, level string) *bytes.Buffer {
+	buf := bytes.NewBuffer(nil)
+	logger, err := newLogger(buf, level, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return buf
+}
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("malformed log %q: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestNewLogger(t *testing.T) {
+	for _, tt := range []struct {
+		level, format string
+		ok            bool
+	}{
+		{"debug", "text", true},
+		{"INFO", "json", true},
+		{"warn", "text", true},
+		{"error", "json", true},
+		{"loud", "text", false},
+		{"info", "xml", false},
+	} {
+		if _, err := newLogger(bytes.NewBuffer(nil), tt.level, tt.format); (err == nil) != tt.ok {
+			t.Errorf("%s %s: want ok=%v, got %v", tt.level, tt.format, tt.ok, err)
+		}
+	}
+
+	buf := bytes.NewBuffer(nil)
+	logger, _ := newLogger(buf, "warn", "text")
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("want only warnings and up, got %q", buf)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"abc-123_x.y":           true,
+		"":                      false,
+		strings.Repeat("a", 65): false,
+		"a b":                   false,
+		"a\nb":                  false,
+		"<script>":              false,
+	} {
+		if got := validRequestID(id); got != want {
+			t.Errorf("%q: want %v, got %v", id, want, got)
+		}
+	}
+	if id := newRequestID(); !validRequestID(id) || id == newRequestID() {
+		t.Errorf("want new request IDs to be valid and different, got %q", id)
+	}
+}
+
+func TestRequestIDPropagates(t *testing.T) {
+	logs := captureLogs(t, "debug")
+	db := NewDB()
+	logs.Reset()
+
+	h := requestIDs(accessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		db.Validate(r.Context(), "", "go-lime", true)
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short"))
+	})))
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/validate?pkgname=secret", nil)
+	r.Header.Set(requestIDHeader, "from-proxy")
+	h(rr, r)
+	if rr.Header().Get(requestIDHeader) != "from-proxy" {
+		t.Errorf("want the request ID sent back, got %q", rr.Header().Get(requestIDHeader))
+	}
+
+	records := logRecords(t, logs)
+	if len(records) != 2 {
+		t.Fatalf("want the DB and access logs, got %v", records)
+	}
+	for _, rec := range records {
+		if rec["request_id"] != "from-proxy" {
+			t.Errorf("want the request ID in %v", rec)
+		}
+	}
+	if records[0]["msg"] != "Assessed name" || records[0]["pkgname"] != "go-lime" {
+		t.Errorf("want the DB call logged, got %v", records[0])
+	}
+	access := records[1]
+	if access["status"] != float64(http.StatusTeapot) || access["bytes"] != float64(5) || access["path"] != "/validate" {
+		t.Errorf("want the access logged, got %v", access)
+	}
+	if strings.Contains(logs.String(), "secret") {
+		t.Errorf("want no query in the logs")
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	var seen string
+	h := requestIDs(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestIDFrom(r.Context())
+	}))
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(requestIDHeader, "not valid")
+	h(rr, r)
+	if seen == "" || seen == "not valid" || rr.Header().Get(requestIDHeader) != seen {
+		t.Errorf("want a new request ID, got %q and %q", seen, rr.Header().Get(requestIDHeader))
+	}
+}
+
+func TestGRPCRequestID(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDHeader, "from-client"))
+	if id := requestIDFrom(grpcRequestID(ctx)); id != "from-client" {
+		t.Errorf("want the client's request ID, got %q", id)
+	}
+	if id := requestIDFrom(grpcRequestID(context.Background())); !validRequestID(id) {
+		t.Errorf("want a new request ID, got %q", id)
+	}
+}
+
+func TestLevelsQuietInProduction(t *testing.T) {
+	logs := captureLogs(t, "info")
+	NewDB().Validate(context.Background(), "", "go-lime", true)
+	if strings.Contains(logs.String(), "go-lime") {
+		t.Errorf("want names only logged at debug, got %s", logs)
+	}
+}
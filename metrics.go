@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestBuckets are the upper bounds of the request duration histograms,
+// in seconds.
+var requestBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var startTime = time.Now()
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(buckets []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, le := range buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type requestKey struct {
+	route  string
+	method string
+	code   int
+}
+
+// requestMetrics measures how long the requests take, by route, method and
+// status code.
+type requestMetrics struct {
+	lock      sync.Mutex
+	durations map[requestKey]*histogram
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{durations: make(map[requestKey]*histogram)}
+}
+
+func (m *requestMetrics) observe(key requestKey, d time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{}
+		m.durations[key] = h
+	}
+	h.observe(requestBuckets, d.Seconds())
+}
+
+// instrument measures the requests h serves under route, which should be
+// the pattern h is registered with so that there's a bounded number of
+// them.
+func (m *requestMetrics) instrument(route string, h http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+		if rec.code == 0 {
+			rec.code = http.StatusOK
+		}
+		m.observe(requestKey{route: route, method: metricMethod(r.Method), code: rec.code}, time.Since(start))
+	}
+}
+
+// metricMethod keeps the methods we don't know of from making up new
+// series.
+func metricMethod(method string) string {
+	switch method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		return method
+	}
+	return "other"
+}
+
+// statusRecorder remembers the status code and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code  int
+	bytes int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.code == 0 {
+		s.code = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.code == 0 {
+		s.code = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += n
+	return n, err
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// metricsHandler exposes the metrics in the Prometheus text format.
+func metricsHandler(db *DB, reqs *requestMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "HEAD" {
+			return
+		}
+		if err := writeMetrics(w, db, reqs); err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't send metrics to client", "err", err)
+		}
+	}
+}
+
+func writeMetrics(w io.Writer, db *DB, reqs *requestMetrics) error {
+	e := &exposition{w: bufio.NewWriter(w)}
+
+	if reqs != nil {
+		reqs.write(e)
+	}
+
+	stats := db.Stats(0)
+	e.family("pkgname_validations_total", "counter", "Validations asked for, by outcome.")
+	e.sample("pkgname_validations_total", labels{"outcome", "pass"}, float64(stats.Passed))
+	e.sample("pkgname_validations_total", labels{"outcome", "fail"}, float64(stats.Validations-stats.Passed))
+
+	e.family("pkgname_rule_violations_total", "counter", "Violations of each rule, warnings included.")
+	rules := append([]ruleStats(nil), stats.Rules...)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Rule < rules[j].Rule })
+	for _, rule := range rules {
+		e.sample("pkgname_rule_violations_total", labels{"rule", rule.Rule}, float64(rule.Hits))
+	}
+
+	info := db.Info()
+	e.family("pkgname_history_names", "gauge", "Names in the history, by verdict.")
+	e.sample("pkgname_history_names", labels{"verdict", "bad"}, float64(info.Bads))
+	e.sample("pkgname_history_names", labels{"verdict", "good"}, float64(info.Goods))
+
+	e.gauge("pkgname_history_capacity", "Names the history keeps for each verdict.", float64(queueSize))
+	e.gauge("pkgname_corpus_names", "Names in the corpus examples come from.", float64(info.Corpus))
+	e.gauge("pkgname_close_to_mean_length_mean", "Mean length of the names in the corpus.", info.LengthMean)
+	e.gauge("pkgname_close_to_mean_length_stdev", "Standard deviation of the length of the names in the corpus.", info.LengthStdev)
+	e.gauge("pkgname_close_to_mean_max_dist", "Standard deviations a name can be longer than the mean.", info.MaxDist)
+
+	e.gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()))
+	e.gauge("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", float64(startTime.UnixNano())/1e9)
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func (m *requestMetrics) write(e *exposition) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	keys := make([]requestKey, 0, len(m.durations))
+	for key := range m.durations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+
+	const name = "pkgname_http_request_duration_seconds"
+	e.family(name, "histogram", "Time taken to serve HTTP requests, by route, method and status code.")
+	for _, key := range keys {
+		h := m.durations[key]
+		l := labels{"route", key.route, "method", key.method, "code", strconv.Itoa(key.code)}
+		for i, le := range requestBuckets {
+			e.sample(name+"_bucket", append(l, "le", formatFloat(le)), float64(h.counts[i]))
+		}
+		e.sample(name+"_bucket", append(l, "le", "+Inf"), float64(h.count))
+		e.sample(name+"_sum", l, h.sum)
+		e.sample(name+"_count", l, float64(h.count))
+	}
+}
+
+// labels are pairs of label names and values.
+type labels []string
+
+// exposition writes metrics in the Prometheus text format, remembering the
+// first error.
+type exposition struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *exposition) printf(format string, args ...interface{}) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, args...)
+	}
+}
+
+func (e *exposition) family(name, typ, help string) {
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (e *exposition) sample(name string, l labels, v float64) {
+	pairs := make([]string, 0, len(l)/2)
+	for i := 0; i+1 < len(l); i += 2 {
+		pairs = append(pairs, l[i]+`="`+escapeLabel(l[i+1])+`"`)
+	}
+	if len(pairs) > 0 {
+		name += "{" + strings.Join(pairs, ",") + "}"
+	}
+	e.printf("%s %s\n", name, formatFloat(v))
+}
+
+func (e *exposition) gauge(name, help string, v float64) {
+	e.family(name, "gauge", help)
+	e.sample(name, nil, v)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/aybabtme/pkgname/pkgname"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sampleLine = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{([a-zA-Z_][a-zA-Z0-9_]*="([^"\\]|\\.)*",?)*\})? \S+$`)
+
+func TestInstrument(t *testing.T) {
+	reqs := newRequestMetrics()
+	h := reqs.instrument("/things/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/things/missing":
+			http.NotFound(w, r)
+		case "/things/flushed":
+			w.(http.Flusher).Flush()
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+
+	for _, path := range []string{"/things/a", "/things/b", "/things/missing", "/things/flushed"} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", path, nil))
+	}
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("BREW", "/things/a", nil))
+
+	tests := []struct {
+		key  requestKey
+		want uint64
+	}{
+		{requestKey{"/things/", "GET", 200}, 3},
+		{requestKey{"/things/", "GET", 404}, 1},
+		{requestKey{"/things/", "other", 200}, 1},
+	}
+	for _, tt := range tests {
+		if h, ok := reqs.durations[tt.key]; !ok || h.count != tt.want {
+			t.Errorf("%v: want %d requests, got %v", tt.key, tt.want, h)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	buckets := []float64{1, 2, 5}
+	var h histogram
+	for _, v := range []float64{0.5, 1, 3, 10} {
+		h.observe(buckets, v)
+	}
+	if h.counts[0] != 2 || h.counts[1] != 2 || h.counts[2] != 3 || h.count != 4 || h.sum != 14.5 {
+		t.Errorf("want cumulative buckets, got %+v", h)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	db := NewDB()
+	db.Validate(context.Background(), "", "go-lime", true)
+	db.Validate(context.Background(), "", "lime", true)
+
+	reqs := newRequestMetrics()
+	reqs.observe(requestKey{"/a \"quoted\"\n", "GET", 200}, 30*time.Millisecond)
+
+	buf := bytes.NewBuffer(nil)
+	if err := writeMetrics(buf, db, reqs); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE pkgname_http_request_duration_seconds histogram\n",
+		`pkgname_http_request_duration_seconds_bucket{route="/a \"quoted\"\n",method="GET",code="200",le="0.025"} 0` + "\n",
+		`pkgname_http_request_duration_seconds_bucket{route="/a \"quoted\"\n",method="GET",code="200",le="0.05"} 1` + "\n",
+		`pkgname_http_request_duration_seconds_bucket{route="/a \"quoted\"\n",method="GET",code="200",le="+Inf"} 1` + "\n",
+		`pkgname_http_request_duration_seconds_count{route="/a \"quoted\"\n",method="GET",code="200"} 1` + "\n",
+		`pkgname_validations_total{outcome="pass"} 1` + "\n",
+		`pkgname_validations_total{outcome="fail"} 1` + "\n",
+		`pkgname_rule_violations_total{rule="no-hyphens"} 1` + "\n",
+		`pkgname_rule_violations_total{rule="close-to-mean"} 0` + "\n",
+		`pkgname_history_names{verdict="good"} 1` + "\n",
+		"pkgname_close_to_mean_max_dist 2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("want %q in metrics", want)
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if !strings.HasPrefix(line, "# ") && !sampleLine.MatchString(line) {
+			t.Errorf("malformed line %q", line)
+		}
+	}
+}
+
+func TestWriteMetricsWarnings(t *testing.T) {
+	defer func(defs []pkgname.RuleDef) { customRules = defs }(customRules)
+	customRules = []pkgname.RuleDef{{ID: "short", MaxLength: 6, Severity: "warning"}}
+	db := NewDB()
+	db.Validate(context.Background(), "", "limelight", false)
+
+	buf := bytes.NewBuffer(nil)
+	if err := writeMetrics(buf, db, newRequestMetrics()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`pkgname_validations_total{outcome="pass"} 1` + "\n",
+		`pkgname_rule_violations_total{rule="short"} 1` + "\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in metrics, got %s", want, buf)
+		}
+	}
+}
+
+func TestAdminMetrics(t *testing.T) {
+	h := newTestAdmin(t, NewDB())
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("want metrics, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), "pkgname_corpus_names ") {
+		t.Errorf("want the corpus size, got %s", rr.Body)
+	}
+}
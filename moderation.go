@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// denyListSource is a file of terms that keep names out of the public
+// history, one per line. Moderation only holds back links and unprintable
+// names when it's empty.
+var denyListSource string
+
+// moderator decides which names can be published in the history. Names it
+// holds back are still validated and counted, just not shown to others.
+type moderator struct {
+	terms []string
+}
+
+func newModerator(source string) (*moderator, error) {
+	m := &moderator{}
+	if source == "" {
+		return m, nil
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if m.terms, err = readDenyList(f); err != nil {
+		return nil, fmt.Errorf("reading deny list %q, %v", source, err)
+	}
+	return m, nil
+}
+
+// readDenyList reads terms one per line, skipping blank lines and those
+// starting with #.
+func readDenyList(r io.Reader) ([]string, error) {
+	var terms []string
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if term := normalizeForModeration(line); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms, scan.Err()
+}
+
+// leet undoes the usual substitutions used to sneak words past filters.
+var leet = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s")
+
+// normalizeForModeration lowercases s, undoes the leet speak and drops
+// what's neither a letter nor a digit, so that "B@d-W0rd" matches "badword".
+func normalizeForModeration(s string) string {
+	s = leet.Replace(strings.ToLower(s))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// allow tells if name can be published, or else why not.
+func (m *moderator) allow(name string) (bool, string) {
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return false, "unprintable"
+		}
+	}
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "://") || strings.Contains(lower, "www.") {
+		return false, "link"
+	}
+
+	normalized := normalizeForModeration(name)
+	for _, term := range m.terms {
+		if strings.Contains(normalized, term) {
+			return false, "denied"
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadDenyList(t *testing.T) {
+	terms, err := readDenyList(strings.NewReader("# comment\n\nBad-Word\n  sp@m  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(terms) != 2 || terms[0] != "badword" || terms[1] != "spam" {
+		t.Fatalf("want the terms normalized, got %q", terms)
+	}
+}
+
+func TestModeratorAllow(t *testing.T) {
+	m := &moderator{terms: []string{"badword", "spam"}}
+
+	tests := []struct {
+		name string
+		ok   bool
+		why  string
+	}{
+		{"lime", true, ""},
+		{"日本語", true, ""},
+		{"a\x00b", false, "unprintable"},
+		{"a\u200bb", false, "unprintable"},
+		{"http://example.com", false, "link"},
+		{"WWW.example.com", false, "link"},
+		{"badword", false, "denied"},
+		{"my_B@D-W0RD_pkg", false, "denied"},
+		{"5pam", false, "denied"},
+		{"sp", true, ""},
+	}
+	for _, tt := range tests {
+		ok, why := m.allow(tt.name)
+		if ok != tt.ok || why != tt.why {
+			t.Errorf("%q: want %v %q, got %v %q", tt.name, tt.ok, tt.why, ok, why)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// apiRoute describes an endpoint of the API. The OpenAPI document is
+// generated from these, so it can't drift away from what is served.
+type apiRoute struct {
+	Method   string
+	Path     string
+	Summary  string
+	Query    []apiParam
+	Consumes []string
+	Produces []string
+	Request  interface{}
+	Response interface{}
+	Errors   []int
+	Handler  http.HandlerFunc
+}
+
+type apiParam struct {
+	Name        string
+	Description string
+	Type        string
+}
+
+func apiRoutes(db *DB, batch http.HandlerFunc) []apiRoute {
+	return []apiRoute{
+		{
+			Method:   "POST",
+			Path:     "/validate",
+			Summary:  "Tells if a package name is shit, and why. The name is recorded in the history unless record is false.",
+			Consumes: []string{"application/json", "application/x-www-form-urlencoded"},
+			Produces: []string{"application/json"},
+			Request:  validateRequest{},
+			Response: validateResponse{},
+			Errors:   []int{400, 405, 406, 415, 422},
+			Handler:  apiValidate(db),
+		},
+		{
+			Method:  "POST",
+			Path:    "/validate/batch",
+			Summary: "Validates many names at once, streaming one result per line. Names are only recorded in the history if record is true.",
+			Query: []apiParam{
+				{Name: "record", Type: "boolean", Description: "Record the names in the history."},
+				{Name: "profile", Type: "string", Description: "Rule profile to check the names with."},
+				{Name: "tone", Type: "string", Description: "Tone of the messages, snarky or professional."},
+				{Name: "lang", Type: "string", Description: "Language of the messages, like fr or de, the one of the Accept-Language header if left out."},
+			},
+			Consumes: []string{"application/json", "application/x-ndjson"},
+			Produces: []string{"application/x-ndjson"},
+			Request:  []string{},
+			Response: batchResult{},
+			Errors:   []int{400, 405, 406, 415, 422, 429, 503},
+			Handler:  batch,
+		},
+		{
+			Method:  "GET",
+			Path:    "/history",
+			Summary: "Lists the most recently validated names, newest first.",
+			Query: []apiParam{
+				{Name: "last", Type: "integer", Description: "How many names of each kind to list."},
+			},
+			Produces: []string{"application/json"},
+			Response: historyResponse{},
+			Errors:   []int{403, 405, 406, 422},
+			Handler:  apiHistory(db),
+		},
+		{
+			Method:   "GET",
+			Path:     "/generate",
+			Summary:  "Picks a good package name at random.",
+			Produces: []string{"application/json"},
+			Response: generateResponse{},
+			Errors:   []int{405, 406},
+			Handler:  apiGenerate(db),
+		},
+	}
+}
+
+// openAPI generates an OpenAPI 3 document describing routes.
+func openAPI(routes []apiRoute) map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]interface{})
+
+	errorRef := schemaOf(reflect.TypeOf(apiErrorBody{}), schemas)
+
+	for _, route := range routes {
+		op := map[string]interface{}{
+			"summary":     route.Summary,
+			"operationId": strings.ToLower(route.Method) + camel(route.Path),
+		}
+
+		var params []interface{}
+		for _, p := range route.Query {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          "query",
+				"description": p.Description,
+				"schema":      map[string]interface{}{"type": p.Type},
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		if route.Request != nil {
+			schema := schemaOf(reflect.TypeOf(route.Request), schemas)
+			content := make(map[string]interface{})
+			for _, mediatype := range route.Consumes {
+				content[mediatype] = map[string]interface{}{"schema": schema}
+			}
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  content,
+			}
+		}
+
+		responses := make(map[string]interface{})
+		content := make(map[string]interface{})
+		schema := schemaOf(reflect.TypeOf(route.Response), schemas)
+		for _, mediatype := range route.Produces {
+			content[mediatype] = map[string]interface{}{"schema": schema}
+		}
+		responses["200"] = map[string]interface{}{
+			"description": "OK",
+			"content":     content,
+		}
+		for _, status := range route.Errors {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": errorRef},
+				},
+			}
+		}
+		op["responses"] = responses
+
+		item, ok := paths[route.Path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "pkgname",
+			"description": "Is the name of my package shit?",
+			"version":     "1",
+		},
+		"servers":    []interface{}{map[string]interface{}{"url": apiPrefix}},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// schemaOf builds the JSON schema of t, registering named structs in
+// schemas and referring to them.
+func schemaOf(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+	default:
+		return map[string]interface{}{}
+	}
+
+	name := camel(t.Name())
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	if _, ok := schemas[name]; ok {
+		return ref
+	}
+	// Placeholder, in case the type refers to itself.
+	schemas[name] = nil
+
+	props := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		fieldName := field.Name
+		if tag[0] != "" {
+			fieldName = tag[0]
+		}
+		props[fieldName] = schemaOf(field.Type, schemas)
+		if len(tag) == 1 || tag[1] != "omitempty" {
+			required = append(required, fieldName)
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+	if required != nil {
+		schema["required"] = required
+	}
+	schemas[name] = schema
+	return ref
+}
+
+// camel turns "/validate/batch" and "validateResponse" into
+// "ValidateBatch" and "ValidateResponse".
+func camel(s string) string {
+	var out []string
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return r == '/' || r == '_' || r == '.' }) {
+		out = append(out, strings.ToUpper(word[:1])+word[1:])
+	}
+	return strings.Join(out, "")
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"github.com/aybabtme/pkgname/pkgname"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// embeddedTemplates holds the templates of the pages rendered by the server.
+//
+//go:embed templates
+var embeddedTemplates embed.FS
+
+func templatesFS() fs.FS {
+	sub, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// analyticsConfig tells how pages are tracked. They aren't unless ID is
+// set.
+type analyticsConfig struct {
+	ID string
+	// Template is a file replacing the builtin analytics.html template.
+	Template string
+	// Origins are where the analytics load from and report to, which the
+	// Content-Security-Policy has to allow.
+	Origins []string
+}
+
+var analytics = analyticsConfig{
+	Origins: []string{"https://www.google-analytics.com"},
+}
+
+// analyticsData is what the analytics.html template is rendered with.
+type analyticsData struct {
+	ID string
+	// Nonce lets the inline script of the template run.
+	Nonce string
+}
+
+type pageData struct {
+	Pkgname string
+	// Checked is set when there's a verdict on Pkgname to show.
+	Checked bool
+	// Error tells why there's no verdict on Pkgname.
+	Error   string
+	Success bool
+	// Causes are the messages of the rules Pkgname broke, and Warnings
+	// those of the rules that only warn.
+	Causes   []string
+	Warnings []string
+	// Tone is the tone picked for the causes, and Tones those to pick from.
+	Tone    string
+	Tones   []pkgname.Tone
+	Example string
+	History *historyResponse
+	// PrivateHistory is set when the history isn't shown to the public.
+	PrivateHistory bool
+	// Lang is the language of the page, T its words by key, and Langs the
+	// languages it can be read in.
+	Lang  string
+	T     map[string]string
+	Langs []langLink
+	// Analytics is nil when pages aren't tracked.
+	Analytics *analyticsData
+
+	// URL, Description and Image describe the page for when it's shared.
+	URL         string
+	Description string
+	Image       string
+}
+
+// langLink links to the page in a language, with the same query.
+type langLink struct {
+	Lang string
+	Name string
+	URL  string
+}
+
+// templateLoader gives the templates to render a page with.
+type templateLoader func() (*template.Template, error)
+
+// loadTemplates parses the templates of fsys, where asset maps the name of
+// a static asset to the URL to refer to it by.
+func loadTemplates(fsys fs.FS, asset func(string) string) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"asset":   asset,
+		"percent": func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+	}
+	tmpl, err := template.New("").Funcs(funcs).ParseFS(fsys, "*.html")
+	if err != nil || analytics.Template == "" {
+		return tmpl, err
+	}
+
+	custom, err := os.ReadFile(analytics.Template)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.New("analytics.html").Parse(string(custom))
+}
+
+// onceTemplates loads the templates once and for all.
+func onceTemplates(fsys fs.FS, asset func(string) string) (templateLoader, error) {
+	tmpl, err := loadTemplates(fsys, asset)
+	return func() (*template.Template, error) { return tmpl, nil }, err
+}
+
+// devTemplates loads the templates from the FS at each request.
+func devTemplates(dir string) templateLoader {
+	return func() (*template.Template, error) {
+		return loadTemplates(os.DirFS(dir), func(name string) string { return "/" + name })
+	}
+}
+
+// baseURL is the scheme and host the request was made to, for the links
+// that need to be absolute.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// root sends requests for / to page, and the others to files. The page is
+// still found at /index.html, where it used to be a file.
+func root(page, files http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" || r.URL.Path == "/index.html" {
+			page.ServeHTTP(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}
+}
+
+// index renders the main page, with the verdict on the pkgname in the query
+// if there's one, so that it works without scripts.
+func index(db *DB, templates templateLoader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET", "HEAD":
+		case "POST":
+			// The form posts here when scripts don't run. Record the name
+			// like /validate would if it's ticked to be, then show the
+			// verdict at its permalink.
+			name, tone, lang := r.FormValue("pkgname"), r.FormValue("tone"), r.FormValue("lang")
+			target := "/"
+			if name != "" {
+				if checkName(name) == nil {
+					record, _ := strconv.ParseBool(r.FormValue("record"))
+					db.Validate(r.Context(), "", name, record)
+				}
+				target += "?pkgname=" + url.QueryEscape(name)
+				if tone != "" && checkTone(tone) == nil {
+					target += "&tone=" + url.QueryEscape(tone)
+				}
+				if lang != "" && negotiateLang(lang, "") != negotiateLang("", r.Header.Get("Accept-Language")) {
+					target += "&lang=" + url.QueryEscape(negotiateLang(lang, ""))
+				}
+			}
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "Method not allowed.", http.StatusMethodNotAllowed)
+			return
+		}
+
+		params := r.URL.Query()
+		data := pageData{
+			Pkgname:        params.Get("pkgname"),
+			Tones:          pkgname.Tones(),
+			Example:        db.Get(),
+			PrivateHistory: privateHistory,
+			Lang:           requestLang(w, r, params.Get("lang")),
+		}
+		data.T = text(data.Lang)
+		link := r.URL.Query()
+		for _, lang := range pkgname.Langs() {
+			link.Set("lang", lang)
+			data.Langs = append(data.Langs, langLink{Lang: lang, Name: langNames[lang], URL: "/?" + link.Encode()})
+		}
+		if tone := params.Get("tone"); checkTone(tone) == nil {
+			data.Tone = tone
+		}
+		base := baseURL(r)
+		data.URL = base + "/"
+		data.Description = data.T["description"]
+		if msg := nameError(data.Pkgname, data.Lang); data.Pkgname != "" && msg != "" {
+			data.Error = msg
+		} else if data.Pkgname != "" {
+			violations := db.Check(data.Pkgname, query{Tone: data.Tone, Lang: data.Lang})
+			data.Causes, data.Warnings = bySeverity(violations)
+			data.Checked = true
+			data.Success = pkgname.Passed(violations)
+
+			data.URL += "?pkgname=" + url.QueryEscape(data.Pkgname)
+			card := url.Values{}
+			if data.Tone != "" {
+				card.Set("tone", data.Tone)
+				data.URL += "&tone=" + url.QueryEscape(data.Tone)
+			}
+			if data.Lang != pkgname.LangEnglish {
+				card.Set("lang", data.Lang)
+			}
+			if data.Success {
+				data.Description = data.Pkgname + " " + data.T["good"] + "."
+			} else {
+				data.Description = data.Pkgname + " " + data.T["bad"] + ". " + data.Causes[0]
+			}
+			if shareable(data.Pkgname) {
+				data.Image = base + "/card/" + url.PathEscape(data.Pkgname) + ".png"
+				if len(card) > 0 {
+					data.Image += "?" + card.Encode()
+				}
+			}
+		}
+		if params.Get("history") != "" && !privateHistory {
+			goods, bads := db.Last(10)
+			data.History = &historyResponse{Goods: goods, Bads: bads}
+		}
+
+		csp := contentSecurityPolicy("")
+		if analytics.ID != "" {
+			nonce, err := newNonce()
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Couldn't make a nonce", "err", err)
+				http.Error(w, "Something went wrong on our side.", http.StatusInternalServerError)
+				return
+			}
+			data.Analytics = &analyticsData{ID: analytics.ID, Nonce: nonce}
+			csp = contentSecurityPolicy(nonce)
+		}
+
+		tmpl, err := templates()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't load templates", "err", err)
+			http.Error(w, "Something went wrong on our side.", http.StatusInternalServerError)
+			return
+		}
+
+		// Render fully before sending anything, so that a failure can
+		// still be reported properly.
+		buf := bytes.NewBuffer(nil)
+		if err := tmpl.ExecuteTemplate(buf, "index.html", data); err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't render index", "err", err)
+			http.Error(w, "Something went wrong on our side.", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Content-Security-Policy", csp)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "HEAD" {
+			return
+		}
+		if _, err := buf.WriteTo(w); err != nil {
+			slog.ErrorContext(r.Context(), "Couldn't send index to client", "err", err)
+		}
+	}
+}
+
+// contentSecurityPolicy only lets pages load what we serve, save for the
+// analytics when they're on. Scripts also need the nonce to run inline.
+func contentSecurityPolicy(nonce string) string {
+	self := "'self'"
+	if analytics.ID != "" && len(analytics.Origins) > 0 {
+		self += " " + strings.Join(analytics.Origins, " ")
+	}
+	scripts := self
+	if nonce != "" {
+		scripts += " 'nonce-" + nonce + "'"
+	}
+	return strings.Join([]string{
+		"default-src 'none'",
+		"script-src " + scripts,
+		"style-src 'self'",
+		"font-src 'self'",
+		"img-src " + self,
+		"connect-src " + self,
+		"form-action 'self'",
+		"base-uri 'none'",
+		"frame-ancestors 'none'",
+	}, "; ")
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// secure sets the Content-Security-Policy on everything h serves, unless h
+// sets its own.
+func secure(h http.Handler) http.HandlerFunc {
+	csp := contentSecurityPolicy("")
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", csp)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		h.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"github.com/aybabtme/pkgname/pkgname"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func newTestIndex(t *testing.T, db *DB) http.HandlerFunc {
+	templates, err := onceTemplates(templatesFS(), func(name string) string { return "/" + name })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index(db, templates)
+}
+
+func TestIndexForm(t *testing.T) {
+	h := newTestIndex(t, NewDB())
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/", nil))
+	body := rr.Body.String()
+
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("want a page, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, `<section class="main shown">`) {
+		t.Errorf("want the form shown")
+	}
+	if !strings.Contains(body, `method="post" action="/"`) {
+		t.Errorf("want the form to post back")
+	}
+	if strings.Contains(body, `message shown`) {
+		t.Errorf("want no message shown")
+	}
+}
+
+func TestIndexVerdict(t *testing.T) {
+	db := NewDB()
+	h := newTestIndex(t, db)
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?pkgname=go-lime", nil))
+	body := rr.Body.String()
+
+	if !strings.Contains(body, `id="invalidmessage" class="message shown"`) {
+		t.Errorf("want the verdict shown, got %s", body)
+	}
+	if !strings.Contains(body, "<li>Don&#39;t put hyphens, that&#39;s ugly.</li>") {
+		t.Errorf("want the causes listed, got %s", body)
+	}
+	if !strings.Contains(body, `<section class="main">`) {
+		t.Errorf("want the form hidden")
+	}
+	if goods, bads := db.Last(1); len(goods)+len(bads) != 0 {
+		t.Errorf("want permalinks not to record, got %q %q", goods, bads)
+	}
+
+	rr = httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?pkgname=lime", nil))
+	if !strings.Contains(rr.Body.String(), `id="validmessage" class="message shown"`) {
+		t.Errorf("want lime to pass")
+	}
+}
+
+func TestIndexWarnings(t *testing.T) {
+	defer func(defs []pkgname.RuleDef) { customRules = defs }(customRules)
+	customRules = []pkgname.RuleDef{{ID: "short", MaxLength: 6, Severity: "warning"}}
+	h := newTestIndex(t, NewDB())
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?pkgname=limelight", nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, `id="validmessage" class="message shown"`) {
+		t.Errorf("want a warning not to fail the name, got %s", body)
+	}
+	if !regexp.MustCompile(`<ul class="warnings">\s*<li>This package name is too long`).MatchString(body) {
+		t.Errorf("want the warning listed apart, got %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?pkgname=lime-light", nil))
+	body = rr.Body.String()
+	if !regexp.MustCompile(`<ul class="causes">\s*<li>Don&#39;t put hyphens, that&#39;s ugly.</li>\s*</ul>`).MatchString(body) {
+		t.Errorf("want only the error in the causes, got %s", body)
+	}
+	if !regexp.MustCompile(`<ul class="warnings">\s*<li>This package name is too long`).MatchString(body) {
+		t.Errorf("want the warning listed apart, got %s", body)
+	}
+}
+
+func TestIndexEscapes(t *testing.T) {
+	h := newTestIndex(t, NewDB())
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?pkgname="+url.QueryEscape(`<script>alert("x")</script>`), nil))
+	if strings.Contains(rr.Body.String(), `<script>alert`) {
+		t.Errorf("want the name escaped")
+	}
+}
+
+func TestIndexPost(t *testing.T) {
+	db := NewDB()
+	h := newTestIndex(t, db)
+
+	rr := httptest.NewRecorder()
+	h(rr, postForm("/", url.Values{"pkgname": {"a b"}, "record": {"true"}}))
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/?pkgname=a+b" {
+		t.Errorf("want a redirect to the permalink, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+	if _, bads := db.Last(1); len(bads) != 1 || bads[0] != "a b" {
+		t.Errorf("want the name recorded, got %q", bads)
+	}
+
+	rr = httptest.NewRecorder()
+	h(rr, postForm("/", url.Values{"pkgname": {"a c"}}))
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != "/?pkgname=a+c" {
+		t.Errorf("want a redirect to the permalink, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+	if _, bads := db.Last(1); len(bads) != 1 || bads[0] != "a b" {
+		t.Errorf("want the name not recorded when not ticked, got %q", bads)
+	}
+}
+
+func TestIndexTone(t *testing.T) {
+	h := newTestIndex(t, NewDB())
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?pkgname=go-lime&tone=professional", nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, "<li>Package names shouldn&#39;t contain hyphens.</li>") {
+		t.Errorf("want the causes told professionally, got %s", body)
+	}
+	if !strings.Contains(body, `<option value="professional" selected>`) {
+		t.Errorf("want the tone picked in the form, got %s", body)
+	}
+	if !strings.Contains(body, `/card/go-lime.png?tone=professional`) {
+		t.Errorf("want the card told in the tone, got %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	h(rr, postForm("/", url.Values{"pkgname": {"go-lime"}, "tone": {"professional"}}))
+	if loc := rr.Header().Get("Location"); loc != "/?pkgname=go-lime&tone=professional" {
+		t.Errorf("want the tone kept in the permalink, got %q", loc)
+	}
+}
+
+func TestIndexLang(t *testing.T) {
+	h := newTestIndex(t, NewDB())
+
+	r := httptest.NewRequest("GET", "/?pkgname=lime-green", nil)
+	r.Header.Set("Accept-Language", "fr-CA, en;q=0.5")
+	rr := httptest.NewRecorder()
+	h(rr, r)
+	body := rr.Body.String()
+	if rr.Header().Get("Content-Language") != "fr" || !strings.Contains(body, `<html lang="fr">`) {
+		t.Errorf("want the page in French, got %q %s", rr.Header().Get("Content-Language"), body)
+	}
+	for _, want := range []string{
+		"<h1>Quel est le nom de ton paquet?</h1>",
+		"<li>Pas de tirets, c&#39;est moche.</li>",
+		`<input type="hidden" name="lang" id="lang" value="fr">`,
+		`/card/lime-green.png?lang=fr`,
+		`<a href="/?lang=de&amp;pkgname=lime-green" hreflang="de"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("want %s in the page, got %s", want, body)
+		}
+	}
+
+	r = httptest.NewRequest("GET", "/?lang=de&tone=professional", nil)
+	r.Header.Set("Accept-Language", "fr")
+	rr = httptest.NewRecorder()
+	h(rr, r)
+	body = rr.Body.String()
+	if !strings.Contains(body, "<h1>Wie heißt dein Paket?</h1>") || !strings.Contains(body, ">professionell</option>") {
+		t.Errorf("want the lang of the query to win, got %s", body)
+	}
+	if !strings.Contains(body, `<a href="/?lang=fr&amp;tone=professional" hreflang="fr"`) {
+		t.Errorf("want the tone kept when switching languages, got %s", body)
+	}
+
+	r = postForm("/", url.Values{"pkgname": {"lime"}, "lang": {"de"}})
+	r.Header.Set("Accept-Language", "fr")
+	rr = httptest.NewRecorder()
+	h(rr, r)
+	if loc := rr.Header().Get("Location"); loc != "/?pkgname=lime&lang=de" {
+		t.Errorf("want the language kept in the permalink, got %q", loc)
+	}
+}
+
+func TestIndexPrivateHistory(t *testing.T) {
+	defer func(private bool) { privateHistory = private }(privateHistory)
+	privateHistory = true
+
+	db := NewDB()
+	db.Validate(context.Background(), "", "lime", true)
+	h := newTestIndex(t, db)
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?history=1", nil))
+	body := rr.Body.String()
+	if strings.Contains(body, `id="history"`) || strings.Contains(body, "<li>lime</li>") {
+		t.Errorf("want no history shown, got %s", body)
+	}
+}
+
+func TestIndexTooLong(t *testing.T) {
+	db := NewDB()
+	h := newTestIndex(t, db)
+	name := strings.Repeat("a", maxNameLength+1)
+
+	rr := httptest.NewRecorder()
+	h(rr, postForm("/", url.Values{"pkgname": {name}}))
+	if rr.Code != http.StatusSeeOther {
+		t.Errorf("want a redirect to the permalink, got %d", rr.Code)
+	}
+	if goods, bads := db.Last(1); len(goods)+len(bads) != 0 {
+		t.Errorf("want the name not recorded, got %q %q", goods, bads)
+	}
+
+	rr = httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?pkgname="+name, nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, `id="errormessage" class="message shown"`) || !strings.Contains(body, "limited to 100 bytes") {
+		t.Errorf("want the error shown, got %s", body)
+	}
+	if strings.Contains(body, `id="invalidmessage" class="message shown"`) {
+		t.Errorf("want no verdict shown")
+	}
+
+	rr = httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?lang=de&pkgname="+name, nil))
+	if body := rr.Body.String(); !strings.Contains(body, "Paketnamen sind auf 100 Bytes begrenzt.") {
+		t.Errorf("want the error told in German, got %s", body)
+	}
+}
+
+func TestIndexHistory(t *testing.T) {
+	db := NewDB()
+	db.Validate(context.Background(), "", "lime", true)
+	h := newTestIndex(t, db)
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/?history=1", nil))
+	body := rr.Body.String()
+	if !strings.Contains(body, `id="historymessage" class="message shown"`) || !strings.Contains(body, "<li>lime</li>") {
+		t.Errorf("want the history shown, got %s", body)
+	}
+}
+
+func TestRoot(t *testing.T) {
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("page")) })
+	files := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("files")) })
+	h := root(page, files)
+
+	for path, want := range map[string]string{"/": "page", "/index.html": "page", "/application.js": "files", "/sub/": "files", "/sub/index.html": "files"} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Body.String() != want {
+			t.Errorf("%s: want %s, got %s", path, want, rr.Body.String())
+		}
+	}
+}
+
+func TestIndexSelfHosted(t *testing.T) {
+	h := newTestIndex(t, NewDB())
+
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/", nil))
+
+	// Links to other sites are fine, loading anything from them isn't.
+	loads := regexp.MustCompile(`<(script|link|img)\b[^>]*\b(src|href)="([^"]*)"`)
+	for _, m := range loads.FindAllStringSubmatch(rr.Body.String(), -1) {
+		if !strings.HasPrefix(m[3], "/") || strings.HasPrefix(m[3], "//") {
+			t.Errorf("want %s to be self-hosted", m[0])
+		}
+		if _, err := fs.Stat(staticFS(), strings.TrimPrefix(m[3], "/")); err != nil {
+			t.Errorf("want %s to be embedded, %v", m[3], err)
+		}
+	}
+	if strings.Contains(rr.Body.String(), "<script>") {
+		t.Errorf("want no inline script without analytics")
+	}
+
+	csp := rr.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "default-src 'none'") || strings.Contains(csp, "nonce") || strings.Contains(csp, "google") {
+		t.Errorf("want a strict CSP, got %q", csp)
+	}
+}
+
+func TestIndexAnalytics(t *testing.T) {
+	defer func(old analyticsConfig) { analytics = old }(analytics)
+	analytics.ID = "UA-1234-1"
+
+	h := newTestIndex(t, NewDB())
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/", nil))
+	body := rr.Body.String()
+
+	nonce := regexp.MustCompile(`<script nonce="([^"]+)">`).FindStringSubmatch(body)
+	if nonce == nil {
+		t.Fatalf("want the analytics snippet, got %s", body)
+	}
+	if !strings.Contains(body, `ga('create', 'UA-1234-1', 'auto')`) {
+		t.Errorf("want the analytics ID in the snippet")
+	}
+	csp := rr.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "'nonce-"+nonce[1]+"'") || !strings.Contains(csp, "https://www.google-analytics.com") {
+		t.Errorf("want the CSP to allow the snippet, got %q", csp)
+	}
+
+	rr = httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(rr.Body.String(), nonce[0]) {
+		t.Errorf("want a new nonce at each request")
+	}
+}
+
+func TestIndexAnalyticsTemplate(t *testing.T) {
+	defer func(old analyticsConfig) { analytics = old }(analytics)
+	custom := filepath.Join(t.TempDir(), "matomo.html")
+	if err := os.WriteFile(custom, []byte(`<script nonce="{{.Nonce}}">track({{.ID}})</script>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	analytics = analyticsConfig{ID: "42", Template: custom, Origins: []string{"https://stats.internal"}}
+
+	h := newTestIndex(t, NewDB())
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/", nil))
+	if body := rr.Body.String(); !strings.Contains(body, `track("42")`) || strings.Contains(body, "google") {
+		t.Errorf("want the custom snippet, got %s", body)
+	}
+	if csp := rr.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "connect-src 'self' https://stats.internal") {
+		t.Errorf("want the custom origins allowed, got %q", csp)
+	}
+}
+
+func TestSecure(t *testing.T) {
+	h := secure(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rr := httptest.NewRecorder()
+	h(rr, httptest.NewRequest("GET", "/404.html", nil))
+	if rr.Header().Get("Content-Security-Policy") != contentSecurityPolicy("") {
+		t.Errorf("want the default CSP, got %q", rr.Header().Get("Content-Security-Policy"))
+	}
+	if rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("want nosniff")
+	}
+}
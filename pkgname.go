@@ -1,53 +1,258 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"github.com/aybabtme/httpgzip"
-	"log"
+	"github.com/aybabtme/pkgname/pkgname"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"runtime"
-	"strings"
-	"text/template"
+	"strconv"
+	"syscall"
+	"time"
 )
 
 func main() {
 
-	port := flag.String("port", "5000", "port to listen on")
-	numCPU := flag.Int("cpu", runtime.NumCPU(), "number of cpus to use")
-	dev := flag.Bool("dev", false, "dev mode uses a static file handler that reads from the FS at each request")
-
+	cfg := defaultConfig()
+	configFile := flag.String("config", os.Getenv(envPrefix+"CONFIG"), "TOML file to read the settings from, which the environment and flags override")
+	cfg.flags(flag.CommandLine)
 	flag.Parse()
 
-	runtime.GOMAXPROCS(*numCPU)
+	// The flags are parsed again once the file and the environment are
+	// read, so that they win over them.
+	err := cfg.load(*configFile, os.LookupEnv)
+	if err == nil {
+		flag.Parse()
+		err = cfg.validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+	cfg.apply()
+
+	runtime.GOMAXPROCS(cfg.CPU)
 
-	log.SetFlags(log.Flags() | log.Lshortfile | log.Lmicroseconds)
+	logger, err := newLogger(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var tlsConfig *tls.Config
+	if cfg.TLS.Cert != "" {
+		certs, err := newCertReloader(cfg.TLS.Cert, cfg.TLS.Key)
+		if err != nil {
+			fatal("Failed to load TLS certificate", "err", err)
+		}
+		go certs.watch(ctx, certReloadInterval)
+		tlsConfig = certs.config()
+	}
+
+	ls, err := newListeners()
+	if err != nil {
+		fatal("Failed to use inherited listeners", "err", err)
+	}
+
+	// Listen right away so that probes can tell we're starting, rather
+	// than being refused.
+	l, err := ls.listen("http", cfg.Listen)
+	if err != nil {
+		fatal("Failed to listen", "err", err)
+	}
+	if tlsConfig != nil {
+		l = tls.NewListener(l, tlsConfig)
+	}
+	slog.Info("Listening", "addr", cfg.Listen, "tls", tlsConfig != nil, "cores", cfg.CPU)
+
+	rd := &readiness{}
+	app := &pending{}
+	srv := newHTTPServer(health(rd, requestIDs(accessLog(hsts(hstsMaxAge, hstsSubdomains, secure(app))))))
+	servers := []*http.Server{srv}
+	go serveHTTP(srv, l, "Failed to listen and serve")
+
+	if cfg.TLS.RedirectListen != "" {
+		l, err := ls.listen("redirect", cfg.TLS.RedirectListen)
+		if err != nil {
+			fatal("Failed to listen for redirects to HTTPS", "err", err)
+		}
+		slog.Info("Redirecting to HTTPS", "addr", cfg.TLS.RedirectListen)
+		redirect := newHTTPServer(requestIDs(accessLog(redirectHTTPS(cfg.Listen))))
+		servers = append(servers, redirect)
+		go serveHTTP(redirect, l, "Failed to serve redirects to HTTPS")
+	}
 
 	db := NewDB()
+	reqs := newRequestMetrics()
+	lim := newLimiter()
+	mux, templates := routes(db, reqs, lim, cfg.Dev)
+	app.set(mux)
+
+	var rpc *grpc.Server
+	if cfg.GRPC.Listen != "" {
+		l, err := ls.listen("grpc", cfg.GRPC.Listen)
+		if err != nil {
+			fatal("Failed to listen for gRPC", "err", err)
+		}
+		slog.Info("Serving gRPC", "addr", cfg.GRPC.Listen, "tls", tlsConfig != nil)
+		opts := lim.grpcOptions()
+		if tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		rpc = newGRPCServer(db, opts...)
+		go func() {
+			if err := rpc.Serve(l); err != nil {
+				fatal("Failed to serve gRPC", "err", err)
+			}
+		}()
+	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/validate", jsontype(validate(db)))
-	mux.HandleFunc("/history", jsontype(history(db)))
-	mux.HandleFunc("/generate", jsontype(generate(db)))
-
-	if *dev {
-		mux.Handle("/", http.FileServer(http.Dir("static/")))
-	} else {
-		static, err := staticHandler("static/")
+	if cfg.Admin.Listen != "" {
+		l, err := ls.listen("admin", cfg.Admin.Listen)
 		if err != nil {
-			log.Fatalf("[ERROR] Failed to prepare static assets, %v", err)
+			fatal("Failed to listen for admin", "err", err)
+		}
+		slog.Info("Serving admin", "addr", cfg.Admin.Listen)
+		admin := newHTTPServer(requestIDs(accessLog(httpgzip.NewHandler(adminMux(db, templates, reqs)))))
+		servers = append(servers, admin)
+		go serveHTTP(admin, l, "Failed to serve admin")
+	}
+
+	rd.setDB(db)
+	slog.Info("Ready")
+	if err := stopParent(); err != nil {
+		slog.Error("Couldn't stop the server taken over from", "err", err)
+	}
+	if err := sdNotify("READY=1\nMAINPID=" + strconv.Itoa(os.Getpid())); err != nil {
+		slog.Warn("Couldn't notify systemd", "err", err)
+	}
+	go expireHistory(ctx, db)
+	go handovers(ctx, ls)
+
+	<-ctx.Done()
+	stop()
+	rd.drain()
+	slog.Info("Shutting down", "delay", shutdownDelay, "timeout", shutdownTimeout)
+	time.Sleep(shutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	shutdown(ctx, servers, rpc)
+	slog.Info("Stopped")
+}
+
+// handovers start a new server to take over from this one on SIGHUP, for
+// restarts that don't refuse connections. This one goes on serving if the
+// new one doesn't make it.
+func handovers(ctx context.Context, ls *listeners) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-hup:
+			p, err := ls.handover()
+			if err != nil {
+				slog.Error("Couldn't hand over to a new server", "err", err)
+				continue
+			}
+			slog.Info("Handing over to a new server", "pid", p.Pid)
+			go func() {
+				state, err := p.Wait()
+				if ctx.Err() == nil {
+					slog.Error("New server exited before taking over", "state", state, "err", err)
+					ls.unlinkOnClose(true)
+				}
+			}()
+		case <-ctx.Done():
+			return
 		}
-		mux.HandleFunc("/", static)
 	}
+}
 
-	laddr := ":" + *port
-	log.Printf("Listening on %q with %d cores", laddr, *numCPU)
+// expireHistory forgets the names past their time in the history, even when
+// nobody looks at it, until ctx is done.
+func expireHistory(ctx context.Context, db *DB) {
+	if historyTTL <= 0 {
+		return
+	}
+	tick := time.NewTicker(min(historyTTL, time.Minute))
+	defer tick.Stop()
+	for {
+		select {
+		case now := <-tick.C:
+			db.Expire(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
 
-	gziper := httpgzip.NewHandler(mux)
+func serveHTTP(srv *http.Server, l net.Listener, msg string) {
+	if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+		fatal(msg, "err", err)
+	}
+}
 
-	if err := http.ListenAndServe(laddr, gziper); err != nil {
-		log.Fatalf("[ERROR] Failed to listen and serve: %v", err)
+// routes are the handlers of the site, with the templates the pages are
+// rendered with.
+func routes(db *DB, reqs *requestMetrics, lim *limiter, dev bool) (*http.ServeMux, templateLoader) {
+	// Dynamic responses are compressed on the fly, static assets come
+	// compressed ahead of time.
+	mux := http.NewServeMux()
+	handle := func(pattern string, h http.Handler) {
+		mux.Handle(pattern, reqs.instrument(pattern, h))
+	}
+	// Clients are rate limited where names are validated or shown.
+	handle("/validate", limit(lim, httpgzip.NewHandler(jsontype(validate(db))), tooManyLegacy))
+	batch := validateBatch(db, lim)
+	handle("/validate/batch", limit(lim, httpgzip.NewHandler(methods(map[string]http.HandlerFunc{"POST": batch})), tooManyAPI))
+	handle(apiPrefix+"/", limit(lim, httpgzip.NewHandler(apiV1(db, batch)), tooManyAPI))
+	handle("/history", limit(lim, httpgzip.NewHandler(jsontype(history(db))), tooManyLegacy))
+	handle("/generate", httpgzip.NewHandler(jsontype(generate(db))))
+	handle("/badge/", httpgzip.NewHandler(badge(db)))
+	// Cards are drawn, which takes too long to let anyone ask for many.
+	handle("/card/", limit(lim, card(db), tooManyPage))
+
+	if dev {
+		templates := devTemplates("templates/")
+		page := limit(lim, index(db, templates), tooManyPage)
+		files := http.FileServer(noListing{http.Dir("static/")})
+		handle("/", httpgzip.NewHandler(root(page, files)))
+		return mux, templates
 	}
+
+	static, err := loadStatic(staticFS())
+	if err != nil {
+		fatal("Failed to prepare static assets", "err", err)
+	}
+	templates, err := onceTemplates(templatesFS(), func(name string) string {
+		if url, ok := static.URL(name); ok {
+			return url
+		}
+		return "/" + name
+	})
+	if err != nil {
+		fatal("Failed to prepare templates", "err", err)
+	}
+	// Only the page is compressed on the fly, the assets already are.
+	page := limit(lim, httpgzip.NewHandler(index(db, templates)), tooManyPage)
+	handle("/", root(page, http.HandlerFunc(static.serve)))
+	return mux, templates
 }
 
 func jsontype(f http.HandlerFunc) http.HandlerFunc {
@@ -61,39 +266,64 @@ func validate(db *DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		if r.Method != "POST" {
-			http.Error(w, `{"error": "Can only POST on this endpoint."}`, http.StatusTeapot)
+			w.Header().Set("Allow", "POST")
+			writeError(w, http.StatusMethodNotAllowed, "Can only POST on this endpoint.")
 			return
 		}
 
-		pkgname := clean(r.FormValue("pkgname"))
-		if pkgname == "" {
-			http.Error(w, `{"error": "Need a package name."}`, http.StatusBadRequest)
+		// The page shows the errors about the name, so they're told in
+		// its language.
+		name, lang := r.FormValue("pkgname"), requestLang(w, r, r.FormValue("lang"))
+		if msg := nameError(name, lang); msg != "" {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+		record := true
+		if s := r.FormValue("record"); s != "" {
+			var err error
+			if record, err = strconv.ParseBool(s); err != nil {
+				writeError(w, http.StatusBadRequest, invalidRecordMsg)
+				return
+			}
+		}
+
+		q := query{
+			Profile:    r.FormValue("profile"),
+			ImportPath: r.FormValue("import_path"),
+			Tone:       r.FormValue("tone"),
+			Lang:       lang,
+		}
+		if err := db.checkQuery(q); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		errs := db.Validate(pkgname)
+		violations := db.Assess(r.Context(), name, q, record)
 
 		data, err := json.Marshal(struct {
-			Err     string   `json:"error"`
-			Success bool     `json:"success"`
-			Pkgname string   `json:"pkgname"`
-			Causes  []string `json:"causes"`
+			Err        string      `json:"error"`
+			Success    bool        `json:"success"`
+			Pkgname    string      `json:"pkgname"`
+			Causes     []string    `json:"causes"`
+			Violations []violation `json:"violations"`
 		}{
-			Err:     "",
-			Success: len(errs) == 0,
-			Pkgname: pkgname,
-			Causes:  errs,
+			Err:        "",
+			Success:    pkgname.Passed(violations),
+			Pkgname:    name,
+			Causes:     causes(violations),
+			Violations: toViolations(violations),
 		})
 
 		if err != nil {
-			writeError(w, err)
+			slog.ErrorContext(r.Context(), "Couldn't encode response", "err", err)
+			writeError(w, http.StatusInternalServerError, "Something went wrong on our side.")
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
 		_, err = w.Write(data)
 		if err != nil {
-			log.Printf("[ERROR] Couldn't send validation to client: %v", err)
+			slog.ErrorContext(r.Context(), "Couldn't send validation to client", "err", err)
 		}
 	}
 }
@@ -101,7 +331,12 @@ func validate(db *DB) http.HandlerFunc {
 func history(db *DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "GET" {
-			http.Error(w, `{"error": "Can only GET on this endpoint."}`, http.StatusTeapot)
+			w.Header().Set("Allow", "GET")
+			writeError(w, http.StatusMethodNotAllowed, "Can only GET on this endpoint.")
+			return
+		}
+		if privateHistory {
+			writeError(w, http.StatusForbidden, historyPrivateMsg)
 			return
 		}
 
@@ -116,14 +351,15 @@ func history(db *DB) http.HandlerFunc {
 		})
 
 		if err != nil {
-			writeError(w, err)
+			slog.ErrorContext(r.Context(), "Couldn't encode response", "err", err)
+			writeError(w, http.StatusInternalServerError, "Something went wrong on our side.")
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
 		_, err = w.Write(data)
 		if err != nil {
-			log.Printf("[ERROR] Couldn't send list of bad names to client: %v", err)
+			slog.ErrorContext(r.Context(), "Couldn't send list of bad names to client", "err", err)
 		}
 	}
 }
@@ -132,7 +368,8 @@ func generate(db *DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		if r.Method != "GET" {
-			http.Error(w, `{"error": "Can only GET on this endpoint."}`, http.StatusTeapot)
+			w.Header().Set("Allow", "GET")
+			writeError(w, http.StatusMethodNotAllowed, "Can only GET on this endpoint.")
 			return
 		}
 
@@ -145,24 +382,27 @@ func generate(db *DB) http.HandlerFunc {
 		})
 
 		if err != nil {
-			writeError(w, err)
+			slog.ErrorContext(r.Context(), "Couldn't encode response", "err", err)
+			writeError(w, http.StatusInternalServerError, "Something went wrong on our side.")
 			return
 		}
 
 		w.WriteHeader(http.StatusOK)
 		_, err = w.Write(data)
 		if err != nil {
-			log.Printf("[ERROR] Couldn't send generated name to client: %v", err)
+			slog.ErrorContext(r.Context(), "Couldn't send generated name to client", "err", err)
 		}
 	}
 }
 
-func writeError(w http.ResponseWriter, err error) {
-	titled := strings.Title(err.Error())
-	escaped := clean(titled)
-	http.Error(w, `{"error": "`+escaped+`."}`, http.StatusInternalServerError)
-}
-
-func clean(str string) string {
-	return template.JSEscapeString(template.HTMLEscapeString(str))
+// writeError sends msg in the error format of the original endpoints. The
+// versioned API uses writeAPIError instead.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	data, _ := json.Marshal(struct {
+		Err string `json:"error"`
+	}{msg})
+	w.WriteHeader(status)
+	if _, err := w.Write(data); err != nil {
+		slog.Error("Couldn't send error to client", "err", err)
+	}
 }
@@ -0,0 +1,121 @@
+package pkgname
+
+import (
+	"bufio"
+	"compress/gzip"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// seedNames are the names of Go repositories with many stars on Github.
+//
+//go:embed seed/names.flatfile
+var seedNames string
+
+var corpus struct {
+	once    sync.Once
+	names   []string
+	rejects map[string][]Violation
+}
+
+func loadCorpus() {
+	corpus.once.Do(func() {
+		names, err := ReadNames(strings.NewReader(seedNames))
+		if err != nil {
+			panic(err)
+		}
+		corpus.names, corpus.rejects = Clean(names, BuiltinRules())
+	})
+}
+
+// Corpus returns the names of popular Go packages that pass the builtin
+// rules.
+func Corpus() []string {
+	loadCorpus()
+	return append([]string(nil), corpus.names...)
+}
+
+// CorpusRejects returns the names of popular Go packages left out of
+// Corpus, along with the rules they break.
+func CorpusRejects() map[string][]Violation {
+	loadCorpus()
+	rejects := make(map[string][]Violation, len(corpus.rejects))
+	for name, violations := range corpus.rejects {
+		rejects[name] = violations
+	}
+	return rejects
+}
+
+// Clean splits names into those that break none of rules, and those that
+// do along with the violations.
+func Clean(names []string, rules []Rule) (goods []string, bads map[string][]Violation) {
+	bads = make(map[string][]Violation)
+	opts := &Options{Rules: rules}
+	for _, name := range names {
+		if violations := Validate(name, opts); len(violations) != 0 {
+			bads[name] = violations
+		} else {
+			goods = append(goods, name)
+		}
+	}
+	return goods, bads
+}
+
+// LoadNames reads the names in each of the sources, which are files of one
+// name per line, gzipped if they end in .gz.
+func LoadNames(sources ...string) ([]string, error) {
+	var names []string
+	for _, filename := range sources {
+		fileNames, err := loadSource(filename)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, fileNames...)
+	}
+	return names, nil
+}
+
+func loadSource(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening %q: %v", filename, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var r io.Reader
+	if filepath.Ext(filename) == ".gz" {
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a valid GZIP file: %v", filename, err)
+		}
+		defer func() { _ = reader.Close() }()
+		r = reader
+	} else {
+		r = file
+	}
+
+	names, err := ReadNames(r)
+	if err != nil {
+		return nil, fmt.Errorf("scanning %q: %v", filename, err)
+	}
+	return names, nil
+}
+
+// ReadNames reads one name per line, skipping blank lines.
+func ReadNames(r io.Reader) ([]string, error) {
+	scan := bufio.NewScanner(r)
+	scan.Split(bufio.ScanLines)
+
+	var names []string
+	for scan.Scan() {
+		if name := strings.TrimSpace(scan.Text()); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, scan.Err()
+}
@@ -0,0 +1,105 @@
+package pkgname
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCorpus(t *testing.T) {
+	names := Corpus()
+	if len(names) < 100 {
+		t.Fatalf("want a sizeable corpus, got %d names", len(names))
+	}
+
+	opts := &Options{Rules: BuiltinRules()}
+	for _, name := range names {
+		if v := Validate(name, opts); len(v) != 0 {
+			t.Errorf("%q is in the corpus but breaks %q", name, ruleIDs(v))
+		}
+	}
+
+	names[0] = "mutated"
+	if Corpus()[0] == "mutated" {
+		t.Errorf("want Corpus to return a copy")
+	}
+}
+
+func TestCorpusRejects(t *testing.T) {
+	rejects := CorpusRejects()
+	if len(rejects) == 0 {
+		t.Fatalf("want the seed to have names that break the rules")
+	}
+	for _, name := range Corpus() {
+		if _, ok := rejects[name]; ok {
+			t.Errorf("%q is both in the corpus and rejected", name)
+		}
+	}
+	for name, violations := range rejects {
+		if len(violations) == 0 {
+			t.Errorf("%q is rejected without breaking a rule", name)
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	goods, bads := Clean([]string{"docker", "go-docker", "etcd"}, BuiltinRules())
+
+	if want := []string{"docker", "etcd"}; !reflect.DeepEqual(goods, want) {
+		t.Errorf("want goods %q, got %q", want, goods)
+	}
+	if len(bads) != 1 || len(bads["go-docker"]) != 2 {
+		t.Errorf("want go-docker to break two rules, got %v", bads)
+	}
+}
+
+func TestReadNames(t *testing.T) {
+	names, err := ReadNames(strings.NewReader("docker\n\n  etcd  \r\nlime"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"docker", "etcd", "lime"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("want %q, got %q", want, names)
+	}
+}
+
+func TestLoadNames(t *testing.T) {
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "names.flatfile")
+	if err := os.WriteFile(plain, []byte("docker\netcd\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte("lime\n"))
+	_ = gz.Close()
+	zipped := filepath.Join(dir, "names.flatfile.gz")
+	if err := os.WriteFile(zipped, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := LoadNames(plain, zipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"docker", "etcd", "lime"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("want %q, got %q", want, names)
+	}
+
+	if _, err := LoadNames(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("want an error for a missing file")
+	}
+	notZipped := filepath.Join(dir, "plain.gz")
+	if err := os.WriteFile(notZipped, []byte("docker\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadNames(notZipped); err == nil {
+		t.Errorf("want an error for a file that isn't gzipped")
+	}
+}
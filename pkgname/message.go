@@ -0,0 +1,268 @@
+package pkgname
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Tone is the register the messages of the rules are told in.
+type Tone string
+
+const (
+	// ToneSnarky is how pkgname has always talked, and the default.
+	ToneSnarky Tone = "snarky"
+	// ToneProfessional says the same, in words fit for CI logs and interns.
+	ToneProfessional Tone = "professional"
+)
+
+// Tones returns the tones messages can be told in.
+func Tones() []Tone {
+	return []Tone{ToneSnarky, ToneProfessional}
+}
+
+// ParseTone reads a tone by its name. Empty is snarky.
+func ParseTone(s string) (Tone, error) {
+	if s == "" {
+		return ToneSnarky, nil
+	}
+	if !slices.Contains(Tones(), Tone(s)) {
+		return ToneSnarky, fmt.Errorf("tone %q isn't one of %s", s, joinTones(Tones()))
+	}
+	return Tone(s), nil
+}
+
+func joinTones(tones []Tone) string {
+	var names []string
+	for _, tone := range tones {
+		names = append(names, string(tone))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Languages messages are told in, by their ISO 639-1 code.
+const (
+	// LangEnglish is the language of the messages missing in the others.
+	LangEnglish = "en"
+	LangFrench  = "fr"
+	LangGerman  = "de"
+)
+
+// Langs returns the languages messages can be told in, English first.
+func Langs() []string {
+	return []string{LangEnglish, LangFrench, LangGerman}
+}
+
+// MatchLang is the language messages are told in for a language tag like
+// fr or de-CH, if there's one.
+func MatchLang(tag string) (string, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	base, _, _ = strings.Cut(base, "_")
+	if slices.Contains(Langs(), base) {
+		return base, true
+	}
+	return "", false
+}
+
+// Keys of the messages that aren't those of a rule: the reasons a name
+// isn't valid, and the checks of declared rules that don't have their own.
+const (
+	msgBlank           = "valid-package-name.blank"
+	msgFirstLetter     = "valid-package-name.first-letter"
+	msgCharacters      = "valid-package-name.characters"
+	msgMatch           = "custom.match"
+	msgNoMatch         = "custom.no-match"
+	msgBannedPrefix    = "custom.banned-prefix"
+	msgBannedSuffix    = "custom.banned-suffix"
+	msgBannedSubstring = "custom.banned-substring"
+	msgTooShort        = "custom.too-short"
+	msgTooLong         = "custom.too-long"
+)
+
+// catalogs have the templates of the messages by language, then by key,
+// which is the ID of the rule that tells them or one of the keys above,
+// then by tone. They're rendered with a messageData, and each language and
+// tone of a message has the same placeholders.
+var catalogs = map[string]map[string]map[Tone]string{
+	LangEnglish: catalogEN,
+	LangFrench:  catalogFR,
+	LangGerman:  catalogDE,
+}
+
+var catalogEN = map[string]map[Tone]string{
+	RuleNoHyphens: {
+		ToneSnarky:       "Don't put hyphens, that's ugly.",
+		ToneProfessional: "Package names shouldn't contain hyphens.",
+	},
+	RuleNoUnderscore: {
+		ToneSnarky:       "Don't put underscores, that's ugly.",
+		ToneProfessional: "Package names shouldn't contain underscores.",
+	},
+	RuleNotCapitalized: {
+		ToneSnarky:       "Don't put uppercase characters, it's too enterprisey.",
+		ToneProfessional: "Package names should be all lowercase.",
+	},
+	RuleNoReferenceToGo: {
+		ToneSnarky: "Don't mention 'go' in your package name. Go is implicit in any package. Go is absolute and infinitesimal." +
+			" Other languages should rename their packages; for instance 'rails-ruby' and 'python-django' would remove any ambiguity.",
+		ToneProfessional: "Package names shouldn't start or end with 'go', every package is a Go package.",
+	},
+	RuleNoReferenceToGolang: {
+		ToneSnarky:       "The name of Go is Go, not Golang. You don't say Javalang, or Rubylang, or Pythonlang, do you?",
+		ToneProfessional: "Package names shouldn't contain 'golang', the language is called Go.",
+	},
+	RuleValidPackageName: {
+		ToneSnarky:       "That's not even a valid package name: {{.Reason}}! Read the spec: http://golang.org/ref/spec#Package_clause",
+		ToneProfessional: "This isn't a valid package name: {{.Reason}}. See http://golang.org/ref/spec#Package_clause",
+	},
+	msgBlank: {
+		ToneSnarky:       "the name can't be blank",
+		ToneProfessional: "the name can't be blank",
+	},
+	msgFirstLetter: {
+		ToneSnarky:       "the first character must be a letter",
+		ToneProfessional: "the first character must be a letter",
+	},
+	msgCharacters: {
+		ToneSnarky:       "all the characters (but the first) must be either letters or digits",
+		ToneProfessional: "all the characters but the first must be letters or digits",
+	},
+	RuleCloseToMean: {
+		ToneSnarky:       "This package name is {{printf \"%.1f\" .Dist}} std.dev. longer than normal. It should be at most {{.MaxLength}} characters long.",
+		ToneProfessional: "This package name is {{printf \"%.1f\" .Dist}} standard deviations longer than usual, keep it to {{.MaxLength}} characters or fewer.",
+	},
+	RuleNoBannedPrefix: {
+		ToneSnarky:       "Don't start your package name with '{{.Match}}', that one's taken.",
+		ToneProfessional: "Package names shouldn't start with '{{.Match}}', it's reserved.",
+	},
+	msgMatch: {
+		ToneSnarky:       "This package name doesn't match {{.Match}}, house rules.",
+		ToneProfessional: "Package names should match {{.Match}}.",
+	},
+	msgNoMatch: {
+		ToneSnarky:       "This package name matches {{.Match}}, that's against house rules.",
+		ToneProfessional: "Package names shouldn't match {{.Match}}.",
+	},
+	msgBannedPrefix: {
+		ToneSnarky:       "Don't start your package name with '{{.Match}}', house rules.",
+		ToneProfessional: "Package names shouldn't start with '{{.Match}}'.",
+	},
+	msgBannedSuffix: {
+		ToneSnarky:       "Don't end your package name with '{{.Match}}', house rules.",
+		ToneProfessional: "Package names shouldn't end with '{{.Match}}'.",
+	},
+	msgBannedSubstring: {
+		ToneSnarky:       "Don't put '{{.Match}}' in your package name, house rules.",
+		ToneProfessional: "Package names shouldn't contain '{{.Match}}'.",
+	},
+	msgTooShort: {
+		ToneSnarky:       "This package name is too short, make it at least {{.MinLength}} characters long.",
+		ToneProfessional: "Package names should be at least {{.MinLength}} characters long.",
+	},
+	msgTooLong: {
+		ToneSnarky:       "This package name is too long, make it at most {{.MaxLength}} characters long.",
+		ToneProfessional: "Package names should be at most {{.MaxLength}} characters long.",
+	},
+}
+
+// templates are the parsed templates of catalogs.
+var templates = func() map[string]map[string]map[Tone]*template.Template {
+	parsed := make(map[string]map[string]map[Tone]*template.Template)
+	for lang, catalog := range catalogs {
+		parsed[lang] = make(map[string]map[Tone]*template.Template)
+		for key, tones := range catalog {
+			parsed[lang][key] = make(map[Tone]*template.Template)
+			for tone, text := range tones {
+				parsed[lang][key][tone] = template.Must(template.New(key).Parse(text))
+			}
+		}
+	}
+	return parsed
+}()
+
+// messageData is what messages are rendered with, those of the catalog and
+// those of declared rules.
+type messageData struct {
+	Name string
+	// Match is the pattern a name didn't match, or the part of it that's
+	// banned.
+	Match string
+	// Length is the number of characters of the name, which should be
+	// between MinLength and MaxLength.
+	Length    int
+	MinLength int
+	MaxLength int
+	// Dist is how many standard deviations longer than the mean the name
+	// is.
+	Dist float64
+	// Reason is the message of the reason key, told in the same tone.
+	Reason string
+	reason string
+}
+
+// message is an error told from the catalogs, so that it can be told in
+// any language and tone. It's told in snarky English as an error.
+type message struct {
+	key  string
+	data messageData
+}
+
+func (m *message) Error() string { return m.in(LangEnglish, ToneSnarky) }
+
+// told is an error that can be told in any language and tone.
+type told interface {
+	error
+	in(lang string, tone Tone) string
+}
+
+// override is err told with the messages a profile has for its rule.
+type override struct {
+	rule     string
+	messages map[string]string
+	err      error
+}
+
+func (o *override) Error() string { return o.in(LangEnglish, ToneSnarky) }
+
+// in tells the message of o for lang and tone, or for either, or for any,
+// or else err in them.
+func (o *override) in(lang string, tone Tone) string {
+	for _, key := range []string{
+		o.rule + "." + lang + "." + string(tone),
+		o.rule + "." + lang,
+		o.rule + "." + string(tone),
+		o.rule,
+	} {
+		if msg, ok := o.messages[key]; ok {
+			return msg
+		}
+	}
+	var t told
+	if errors.As(o.err, &t) {
+		return t.in(lang, tone)
+	}
+	return o.err.Error()
+}
+
+// in tells m in lang and tone, or in English if the catalog of lang doesn't
+// have it, or snarky if the English one doesn't have it in tone either.
+func (m *message) in(lang string, tone Tone) string {
+	data := m.data
+	if data.reason != "" {
+		data.Reason = (&message{key: data.reason}).in(lang, tone)
+	}
+	tmpl, ok := templates[lang][m.key][tone]
+	if !ok {
+		tmpl, ok = templates[LangEnglish][m.key][tone]
+	}
+	if !ok {
+		tmpl = templates[LangEnglish][m.key][ToneSnarky]
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return fmt.Sprintf("Breaks %s.", m.key)
+	}
+	return b.String()
+}
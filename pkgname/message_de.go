@@ -0,0 +1,77 @@
+package pkgname
+
+var catalogDE = map[string]map[Tone]string{
+	RuleNoHyphens: {
+		ToneSnarky:       "Keine Bindestriche, das ist hässlich.",
+		ToneProfessional: "Paketnamen sollten keine Bindestriche enthalten.",
+	},
+	RuleNoUnderscore: {
+		ToneSnarky:       "Keine Unterstriche, das ist hässlich.",
+		ToneProfessional: "Paketnamen sollten keine Unterstriche enthalten.",
+	},
+	RuleNotCapitalized: {
+		ToneSnarky:       "Keine Großbuchstaben, das ist zu sehr Konzern.",
+		ToneProfessional: "Paketnamen sollten nur aus Kleinbuchstaben bestehen.",
+	},
+	RuleNoReferenceToGo: {
+		ToneSnarky: "Erwähne 'go' nicht in deinem Paketnamen. Go ist in jedem Paket implizit. Go ist absolut und infinitesimal." +
+			" Andere Sprachen sollten ihre Pakete umbenennen; 'rails-ruby' und 'python-django' etwa würden jede Mehrdeutigkeit beseitigen.",
+		ToneProfessional: "Paketnamen sollten nicht mit 'go' beginnen oder enden, jedes Paket ist ein Go-Paket.",
+	},
+	RuleNoReferenceToGolang: {
+		ToneSnarky:       "Go heißt Go, nicht Golang. Du sagst ja auch nicht Javalang, Rubylang oder Pythonlang, oder?",
+		ToneProfessional: "Paketnamen sollten nicht 'golang' enthalten, die Sprache heißt Go.",
+	},
+	RuleValidPackageName: {
+		ToneSnarky:       "Das ist nicht mal ein gültiger Paketname: {{.Reason}}! Lies die Spezifikation: http://golang.org/ref/spec#Package_clause",
+		ToneProfessional: "Das ist kein gültiger Paketname: {{.Reason}}. Siehe http://golang.org/ref/spec#Package_clause",
+	},
+	msgBlank: {
+		ToneSnarky:       "der Name darf nicht leer sein",
+		ToneProfessional: "der Name darf nicht leer sein",
+	},
+	msgFirstLetter: {
+		ToneSnarky:       "das erste Zeichen muss ein Buchstabe sein",
+		ToneProfessional: "das erste Zeichen muss ein Buchstabe sein",
+	},
+	msgCharacters: {
+		ToneSnarky:       "alle Zeichen (außer dem ersten) müssen Buchstaben oder Ziffern sein",
+		ToneProfessional: "alle Zeichen außer dem ersten müssen Buchstaben oder Ziffern sein",
+	},
+	RuleCloseToMean: {
+		ToneSnarky:       "Dieser Paketname ist {{printf \"%.1f\" .Dist}} Standardabweichungen länger als normal. Er sollte höchstens {{.MaxLength}} Zeichen lang sein.",
+		ToneProfessional: "Dieser Paketname ist {{printf \"%.1f\" .Dist}} Standardabweichungen länger als üblich, bitte höchstens {{.MaxLength}} Zeichen.",
+	},
+	RuleNoBannedPrefix: {
+		ToneSnarky:       "Fang deinen Paketnamen nicht mit '{{.Match}}' an, der ist schon vergeben.",
+		ToneProfessional: "Paketnamen sollten nicht mit '{{.Match}}' beginnen, das ist reserviert.",
+	},
+	msgMatch: {
+		ToneSnarky:       "Dieser Paketname passt nicht auf {{.Match}}, Hausregel.",
+		ToneProfessional: "Paketnamen sollten auf {{.Match}} passen.",
+	},
+	msgNoMatch: {
+		ToneSnarky:       "Dieser Paketname passt auf {{.Match}}, das verstößt gegen die Hausregeln.",
+		ToneProfessional: "Paketnamen sollten nicht auf {{.Match}} passen.",
+	},
+	msgBannedPrefix: {
+		ToneSnarky:       "Fang deinen Paketnamen nicht mit '{{.Match}}' an, Hausregel.",
+		ToneProfessional: "Paketnamen sollten nicht mit '{{.Match}}' beginnen.",
+	},
+	msgBannedSuffix: {
+		ToneSnarky:       "Lass deinen Paketnamen nicht auf '{{.Match}}' enden, Hausregel.",
+		ToneProfessional: "Paketnamen sollten nicht auf '{{.Match}}' enden.",
+	},
+	msgBannedSubstring: {
+		ToneSnarky:       "Pack kein '{{.Match}}' in deinen Paketnamen, Hausregel.",
+		ToneProfessional: "Paketnamen sollten kein '{{.Match}}' enthalten.",
+	},
+	msgTooShort: {
+		ToneSnarky:       "Dieser Paketname ist zu kurz, mach ihn mindestens {{.MinLength}} Zeichen lang.",
+		ToneProfessional: "Paketnamen sollten mindestens {{.MinLength}} Zeichen lang sein.",
+	},
+	msgTooLong: {
+		ToneSnarky:       "Dieser Paketname ist zu lang, mach ihn höchstens {{.MaxLength}} Zeichen lang.",
+		ToneProfessional: "Paketnamen sollten höchstens {{.MaxLength}} Zeichen lang sein.",
+	},
+}
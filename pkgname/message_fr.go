@@ -0,0 +1,77 @@
+package pkgname
+
+var catalogFR = map[string]map[Tone]string{
+	RuleNoHyphens: {
+		ToneSnarky:       "Pas de tirets, c'est moche.",
+		ToneProfessional: "Les noms de paquets ne devraient pas contenir de tirets.",
+	},
+	RuleNoUnderscore: {
+		ToneSnarky:       "Pas de soulignés, c'est moche.",
+		ToneProfessional: "Les noms de paquets ne devraient pas contenir de traits de soulignement.",
+	},
+	RuleNotCapitalized: {
+		ToneSnarky:       "Pas de majuscules, ça fait trop entreprise.",
+		ToneProfessional: "Les noms de paquets devraient être tout en minuscules.",
+	},
+	RuleNoReferenceToGo: {
+		ToneSnarky: "Ne mentionne pas 'go' dans le nom de ton paquet. Go est implicite dans tout paquet. Go est absolu et infinitésimal." +
+			" Les autres langages devraient renommer leurs paquets; par exemple 'rails-ruby' et 'python-django' lèveraient toute ambiguïté.",
+		ToneProfessional: "Les noms de paquets ne devraient ni commencer ni finir par 'go', tout paquet est un paquet Go.",
+	},
+	RuleNoReferenceToGolang: {
+		ToneSnarky:       "Le nom de Go, c'est Go, pas Golang. Tu ne dis pas Javalang, ni Rubylang, ni Pythonlang, non?",
+		ToneProfessional: "Les noms de paquets ne devraient pas contenir 'golang', le langage s'appelle Go.",
+	},
+	RuleValidPackageName: {
+		ToneSnarky:       "Ce n'est même pas un nom de paquet valide: {{.Reason}}! Lis la spec: http://golang.org/ref/spec#Package_clause",
+		ToneProfessional: "Ce n'est pas un nom de paquet valide: {{.Reason}}. Voir http://golang.org/ref/spec#Package_clause",
+	},
+	msgBlank: {
+		ToneSnarky:       "le nom ne peut pas être vide",
+		ToneProfessional: "le nom ne peut pas être vide",
+	},
+	msgFirstLetter: {
+		ToneSnarky:       "le premier caractère doit être une lettre",
+		ToneProfessional: "le premier caractère doit être une lettre",
+	},
+	msgCharacters: {
+		ToneSnarky:       "tous les caractères (sauf le premier) doivent être des lettres ou des chiffres",
+		ToneProfessional: "tous les caractères sauf le premier doivent être des lettres ou des chiffres",
+	},
+	RuleCloseToMean: {
+		ToneSnarky:       "Ce nom de paquet est {{printf \"%.1f\" .Dist}} écarts-types plus long que la normale. Il devrait faire au plus {{.MaxLength}} caractères.",
+		ToneProfessional: "Ce nom de paquet est {{printf \"%.1f\" .Dist}} écarts-types plus long que d'habitude, limitez-le à {{.MaxLength}} caractères.",
+	},
+	RuleNoBannedPrefix: {
+		ToneSnarky:       "Ne commence pas le nom de ton paquet par '{{.Match}}', il est déjà pris.",
+		ToneProfessional: "Les noms de paquets ne devraient pas commencer par '{{.Match}}', il est réservé.",
+	},
+	msgMatch: {
+		ToneSnarky:       "Ce nom de paquet ne correspond pas à {{.Match}}, règle maison.",
+		ToneProfessional: "Les noms de paquets devraient correspondre à {{.Match}}.",
+	},
+	msgNoMatch: {
+		ToneSnarky:       "Ce nom de paquet correspond à {{.Match}}, c'est contraire aux règles maison.",
+		ToneProfessional: "Les noms de paquets ne devraient pas correspondre à {{.Match}}.",
+	},
+	msgBannedPrefix: {
+		ToneSnarky:       "Ne commence pas le nom de ton paquet par '{{.Match}}', règle maison.",
+		ToneProfessional: "Les noms de paquets ne devraient pas commencer par '{{.Match}}'.",
+	},
+	msgBannedSuffix: {
+		ToneSnarky:       "Ne finis pas le nom de ton paquet par '{{.Match}}', règle maison.",
+		ToneProfessional: "Les noms de paquets ne devraient pas finir par '{{.Match}}'.",
+	},
+	msgBannedSubstring: {
+		ToneSnarky:       "Ne mets pas '{{.Match}}' dans le nom de ton paquet, règle maison.",
+		ToneProfessional: "Les noms de paquets ne devraient pas contenir '{{.Match}}'.",
+	},
+	msgTooShort: {
+		ToneSnarky:       "Ce nom de paquet est trop court, fais-le d'au moins {{.MinLength}} caractères.",
+		ToneProfessional: "Les noms de paquets devraient faire au moins {{.MinLength}} caractères.",
+	},
+	msgTooLong: {
+		ToneSnarky:       "Ce nom de paquet est trop long, fais-le d'au plus {{.MaxLength}} caractères.",
+		ToneProfessional: "Les noms de paquets devraient faire au plus {{.MaxLength}} caractères.",
+	},
+}
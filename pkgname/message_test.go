@@ -0,0 +1,192 @@
+package pkgname
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"text/template/parse"
+)
+
+// fields are the fields of the template that text refers to, sorted.
+func fields(t *testing.T, text string) []string {
+	trees, err := parse.Parse("", text, "{{", "}}", map[string]any{"printf": fmt.Sprintf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	var walk func(parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			for _, n := range n.Nodes {
+				walk(n)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			found = append(found, strings.Join(n.Ident, "."))
+		}
+	}
+	walk(trees[""].Root)
+	slices.Sort(found)
+	return slices.Compact(found)
+}
+
+func TestCatalogs(t *testing.T) {
+	keys := append(RuleIDs(), msgBlank, msgFirstLetter, msgCharacters,
+		msgMatch, msgNoMatch, msgBannedPrefix, msgBannedSuffix, msgBannedSubstring, msgTooShort, msgTooLong)
+	for key := range catalogEN {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, lang := range Langs() {
+		catalog, ok := catalogs[lang]
+		if !ok {
+			t.Errorf("%s: want a catalog", lang)
+			continue
+		}
+		for _, key := range keys {
+			want := fields(t, catalogEN[key][ToneSnarky])
+			for _, tone := range Tones() {
+				text, ok := catalog[key][tone]
+				if !ok {
+					t.Errorf("%s %s: want a %s message", lang, key, tone)
+					continue
+				}
+				if got := fields(t, text); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s %s: want the %s message to use %q like the English snarky one, got %q", lang, key, tone, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestBuiltinProfilesLang(t *testing.T) {
+	for name, p := range BuiltinProfiles() {
+		if len(p.Messages) > 0 {
+			t.Errorf("%s: want the messages of the catalogs, not ones told in a single language, got %q", name, p.Messages)
+		}
+	}
+
+	rules := BuiltinProfiles()["strict"].Build(Corpus(), nil)
+	tests := []struct {
+		lang string
+		tone Tone
+		want string
+	}{
+		{"", "", "Don't start your package name with 'util', that one's taken."},
+		{LangFrench, "", "Ne commence pas le nom de ton paquet par 'util', il est déjà pris."},
+		{LangGerman, ToneProfessional, catalogDE[RuleNoBannedPrefix][ToneProfessional]},
+	}
+	for _, tt := range tests {
+		got := Validate("utils", &Options{Rules: rules, Lang: tt.lang, Tone: tt.tone})
+		want := strings.ReplaceAll(tt.want, "{{.Match}}", "util")
+		if len(got) != 1 || got[0].Message != want {
+			t.Errorf("%q %q: want %q, got %v", tt.lang, tt.tone, want, got)
+		}
+	}
+}
+
+func TestValidateTone(t *testing.T) {
+	noLib, err := RuleDef{ID: "no-lib", BannedPrefixes: []string{"lib"}}.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{Rules: append(DefaultRules(), noLib)}
+
+	snarky := Validate("libGo-Pkg", opts)
+	opts.Tone = ToneProfessional
+	professional := Validate("libGo-Pkg", opts)
+	if !reflect.DeepEqual(ruleIDs(snarky), ruleIDs(professional)) || len(snarky) == 0 {
+		t.Fatalf("want the same rules broken in each tone, got %q and %q", ruleIDs(snarky), ruleIDs(professional))
+	}
+	for i := range snarky {
+		if snarky[i].Message == professional[i].Message {
+			t.Errorf("%s: want the message told differently, got %q twice", snarky[i].Rule, snarky[i].Message)
+		}
+	}
+	if got := professional[len(professional)-1].Message; got != "Package names shouldn't start with 'lib'." {
+		t.Errorf("want the default messages of declared rules toned, got %q", got)
+	}
+
+	got := Validate("2pkg", &Options{Rules: []Rule{{ID: RuleValidPackageName, Filter: ValidPackageName}}, Tone: ToneProfessional})
+	if len(got) != 1 || !strings.HasPrefix(got[0].Message, "This isn't a valid package name: the first character must be a letter.") {
+		t.Errorf("want the reason in the message, got %v", got)
+	}
+}
+
+func TestParseTone(t *testing.T) {
+	for s, want := range map[string]Tone{"": ToneSnarky, "snarky": ToneSnarky, "professional": ToneProfessional} {
+		if got, err := ParseTone(s); err != nil || got != want {
+			t.Errorf("%q: want %v, got %v %v", s, want, got, err)
+		}
+	}
+	if _, err := ParseTone("rude"); err == nil {
+		t.Error("want an unknown tone rejected")
+	}
+}
+
+func TestValidateLang(t *testing.T) {
+	rules := []Rule{{ID: RuleNoHyphens, Filter: NoHyphens}, {ID: RuleNoUnderscore, Filter: NoUnderscore}}
+	tests := []struct {
+		lang string
+		tone Tone
+		want string
+	}{
+		{"", "", "Don't put hyphens, that's ugly."},
+		{"fr", "", "Pas de tirets, c'est moche."},
+		{"fr-CA", ToneProfessional, "Les noms de paquets ne devraient pas contenir de tirets."},
+		{"de_CH", "", "Keine Bindestriche, das ist hässlich."},
+		{"es", "", "Don't put hyphens, that's ugly."},
+	}
+	for _, tt := range tests {
+		got := Validate("lime-green", &Options{Rules: rules, Lang: tt.lang, Tone: tt.tone})
+		if len(got) != 1 || got[0].Message != tt.want {
+			t.Errorf("%q %q: want %q, got %v", tt.lang, tt.tone, tt.want, got)
+		}
+	}
+
+	missing := templates[LangGerman][RuleNoUnderscore]
+	delete(templates[LangGerman], RuleNoUnderscore)
+	defer func() { templates[LangGerman][RuleNoUnderscore] = missing }()
+	got := Validate("lime_green", &Options{Rules: rules, Lang: LangGerman, Tone: ToneProfessional})
+	if len(got) != 1 || got[0].Message != "Package names shouldn't contain underscores." {
+		t.Errorf("want a missing message told in English, got %v", got)
+	}
+
+	got = Validate("2pkg", &Options{Rules: []Rule{{ID: RuleValidPackageName, Filter: ValidPackageName}}, Lang: LangFrench})
+	if len(got) != 1 || !strings.HasPrefix(got[0].Message, "Ce n'est même pas un nom de paquet valide: le premier caractère doit être une lettre!") {
+		t.Errorf("want the reason told in the language, got %v", got)
+	}
+}
+
+func TestMatchLang(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+		ok   bool
+	}{
+		{"fr", "fr", true},
+		{"FR-ca", "fr", true},
+		{"de_AT", "de", true},
+		{" en-GB ", "en", true},
+		{"es", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := MatchLang(tt.tag); got != tt.want || ok != tt.ok {
+			t.Errorf("%q: want %q %v, got %q %v", tt.tag, tt.want, tt.ok, got, ok)
+		}
+	}
+}
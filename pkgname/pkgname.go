@@ -0,0 +1,161 @@
+// Package pkgname tells if the name of a Go package is shit.
+//
+//	for _, v := range pkgname.Validate("go-libPkgNameLib", nil) {
+//	    fmt.Printf("%s: %s\n", v.Rule, v.Message)
+//	}
+package pkgname
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MaxDist is how many standard deviations longer than the average a name
+// can be under the default rules.
+const MaxDist = 2.0
+
+// Filter returns an error explaining why name is shit, or nil. Each of the
+// errors joined by errors.Join is a violation of its own.
+type Filter func(name string) error
+
+// PathFilter is a Filter that's also told the import path of the package,
+// which is empty when it isn't known.
+type PathFilter func(name, importPath string) error
+
+// Severity tells how bad it is to break a rule.
+type Severity int
+
+const (
+	// SeverityError rules make the names that break them shit.
+	SeverityError Severity = iota
+	// SeverityWarning rules only frown upon the names that break them.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// ParseSeverity reads a severity as its String. Empty is an error.
+func ParseSeverity(s string) (Severity, error) {
+	switch s {
+	case "", "error":
+		return SeverityError, nil
+	case "warning":
+		return SeverityWarning, nil
+	}
+	return SeverityError, fmt.Errorf("severity %q isn't error or warning", s)
+}
+
+// Rule is a Filter known by a stable ID.
+type Rule struct {
+	ID     string
+	Filter Filter
+	// PathFilter is used rather than Filter when it's set, by the rules
+	// that look at the import path.
+	PathFilter PathFilter
+	Severity   Severity
+}
+
+func (r Rule) check(name, importPath string) error {
+	if r.PathFilter != nil {
+		return r.PathFilter(name, importPath)
+	}
+	return r.Filter(name)
+}
+
+// Violation is a rule broken by a name.
+type Violation struct {
+	Rule     string
+	Message  string
+	Severity Severity
+}
+
+func (v Violation) Error() string { return v.Message }
+
+// Passed tells if a name that broke violations isn't shit, which is when
+// they're all warnings.
+func Passed(violations []Violation) bool {
+	for _, v := range violations {
+		if v.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// Options of a validation.
+type Options struct {
+	// Rules to validate with, DefaultRules() if nil.
+	Rules []Rule
+	// ImportPath is the import path of the package named, for the rules
+	// that look at it.
+	ImportPath string
+	// Tone the messages are told in, snarky if empty.
+	Tone Tone
+	// Lang is the language the messages are told in, like fr or de-CH. It's
+	// English if empty, or if the messages aren't told in it.
+	Lang string
+}
+
+// Validate tells which rules name breaks. A name that breaks none isn't
+// shit.
+func Validate(name string, opts *Options) []Violation {
+	var rules []Rule
+	var importPath string
+	tone, lang := ToneSnarky, LangEnglish
+	if opts != nil && opts.Rules != nil {
+		rules = opts.Rules
+	} else {
+		rules = DefaultRules()
+	}
+	if opts != nil {
+		importPath = opts.ImportPath
+		if opts.Tone != "" {
+			tone = opts.Tone
+		}
+		if l, ok := MatchLang(opts.Lang); ok {
+			lang = l
+		}
+	}
+
+	var violations []Violation
+	for _, rule := range rules {
+		err := rule.check(name, importPath)
+		if err == nil {
+			continue
+		}
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, err := range errs {
+			msg := err.Error()
+			var t told
+			if errors.As(err, &t) {
+				msg = t.in(lang, tone)
+			}
+			violations = append(violations, Violation{Rule: rule.ID, Message: msg, Severity: rule.Severity})
+		}
+	}
+	return violations
+}
+
+var defaults struct {
+	once  sync.Once
+	rules []Rule
+}
+
+// DefaultRules returns the builtin rules, plus a length rule derived from
+// the names of Corpus.
+func DefaultRules() []Rule {
+	defaults.once.Do(func() {
+		length, _, _ := LengthRule(Corpus(), MaxDist)
+		defaults.rules = append(BuiltinRules(), length)
+	})
+	return append([]Rule(nil), defaults.rules...)
+}
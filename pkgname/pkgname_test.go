@@ -0,0 +1,91 @@
+package pkgname
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func ruleIDs(violations []Violation) []string {
+	var ids []string
+	for _, v := range violations {
+		ids = append(ids, v.Rule)
+	}
+	return ids
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"docker", nil},
+		{"go-Docker", []string{RuleNoHyphens, RuleNotCapitalized, RuleNoReferenceToGo}},
+		{"web_golang", []string{RuleNoUnderscore, RuleNoReferenceToGolang}},
+		{"averyveryverylongname", []string{RuleCloseToMean}},
+		{"", []string{RuleValidPackageName}},
+	}
+
+	for _, tt := range tests {
+		got := ruleIDs(Validate(tt.name, nil))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: want %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestPassed(t *testing.T) {
+	warning := Violation{Rule: "no-lib", Severity: SeverityWarning}
+	error := Violation{Rule: RuleNoHyphens}
+
+	if !Passed(nil) || !Passed([]Violation{warning}) {
+		t.Errorf("want names with warnings at most to pass")
+	}
+	if Passed([]Violation{warning, error}) {
+		t.Errorf("want names with errors to fail")
+	}
+}
+
+func TestValidateWithRules(t *testing.T) {
+	opts := &Options{Rules: []Rule{{ID: RuleNoHyphens, Filter: NoHyphens}}}
+
+	if got := Validate("Go_Docker", opts); len(got) != 0 {
+		t.Errorf("want only the given rules to apply, got %v", got)
+	}
+	got := Validate("web-app", opts)
+	if len(got) != 1 || got[0].Rule != RuleNoHyphens || got[0].Message == "" {
+		t.Errorf("want a no-hyphens violation, got %v", got)
+	}
+}
+
+func TestValidateImportPath(t *testing.T) {
+	rule := Rule{ID: "matches-path", PathFilter: func(name, importPath string) error {
+		if importPath != "" && !strings.HasSuffix(importPath, "/"+name) {
+			return errors.Join(errors.New("doesn't match its path"), errors.New("really doesn't"))
+		}
+		return nil
+	}}
+
+	if got := Validate("web", &Options{Rules: []Rule{rule}}); len(got) != 0 {
+		t.Errorf("want no violation without an import path, got %v", got)
+	}
+	if got := Validate("web", &Options{Rules: []Rule{rule}, ImportPath: "example.com/web"}); len(got) != 0 {
+		t.Errorf("want no violation for a matching path, got %v", got)
+	}
+	got := Validate("web", &Options{Rules: []Rule{rule}, ImportPath: "example.com/server"})
+	if len(got) != 2 || got[0].Message != "doesn't match its path" || got[1].Rule != "matches-path" {
+		t.Errorf("want a violation for each joined error, got %v", got)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for s, want := range map[string]Severity{"": SeverityError, "error": SeverityError, "warning": SeverityWarning} {
+		if got, err := ParseSeverity(s); err != nil || got != want {
+			t.Errorf("%q: want %v, got %v %v", s, want, got, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("want an unknown severity rejected")
+	}
+}
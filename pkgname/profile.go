@@ -0,0 +1,197 @@
+package pkgname
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Profile picks the rules names are checked against for the conventions of
+// a team, along with their parameters and messages.
+type Profile struct {
+	// Rules are the IDs of the rules to check, all of them if nil.
+	Rules []string `toml:"rules"`
+	// MaxDist is how many standard deviations longer than the average a
+	// name can be, MaxDist if zero.
+	MaxDist float64 `toml:"max_dist"`
+	// Allow are names that pass whatever the rules say.
+	Allow []string `toml:"allow"`
+	// AllowSuffixes are cut off the names before they're checked, like
+	// _test for the packages of external tests.
+	AllowSuffixes []string `toml:"allow_suffixes"`
+	// BannedPrefixes are what names can't start with, like the names of
+	// products. The no-banned-prefix rule is only checked if there are some.
+	BannedPrefixes []string `toml:"banned_prefixes"`
+	// Messages replace the messages of the rules, by rule ID. The ID can be
+	// followed by a language, a tone or both, like no-hyphens.fr or
+	// no-hyphens.fr.professional, for the requests told in them. The most
+	// precise one is told, or else the message of the rule.
+	Messages map[string]string `toml:"messages"`
+	// Tone is the tone of the messages of the rules, unless the request
+	// asks for another.
+	Tone Tone `toml:"tone"`
+}
+
+// BuiltinProfiles returns ready made profiles by name. The default one
+// checks the same rules as DefaultRules.
+func BuiltinProfiles() map[string]Profile {
+	return map[string]Profile{
+		"default": {},
+		"strict": {
+			MaxDist:        1,
+			BannedPrefixes: []string{"util", "common", "misc", "helper"},
+		},
+		"stdlib-style": {
+			MaxDist:       1.5,
+			AllowSuffixes: []string{"_test"},
+		},
+	}
+}
+
+// RuleIDs returns the IDs of all the rules a Profile can pick.
+func RuleIDs() []string {
+	var ids []string
+	for _, rule := range BuiltinRules() {
+		ids = append(ids, rule.ID)
+	}
+	return append(ids, RuleCloseToMean, RuleNoBannedPrefix)
+}
+
+// Check tells everything that's wrong with p, if anything, custom being the
+// IDs of the rules declared besides the builtin ones.
+func (p Profile) Check(custom ...string) error {
+	var errs []error
+	known := append(RuleIDs(), custom...)
+	for _, id := range p.Rules {
+		if !slices.Contains(known, id) {
+			errs = append(errs, fmt.Errorf("unknown rule %q, the rules are %s", id, strings.Join(known, ", ")))
+		}
+	}
+	if p.Rules != nil && len(p.Rules) == 0 {
+		errs = append(errs, errors.New("pick at least one rule"))
+	}
+	if slices.Contains(p.Rules, RuleNoBannedPrefix) && len(p.BannedPrefixes) == 0 {
+		errs = append(errs, fmt.Errorf("rule %s needs banned prefixes", RuleNoBannedPrefix))
+	}
+	if p.MaxDist < 0 {
+		errs = append(errs, errors.New("max_dist can't be negative"))
+	}
+	if _, err := ParseTone(string(p.Tone)); err != nil {
+		errs = append(errs, err)
+	}
+	for key := range p.Messages {
+		id, ok := messageRule(key)
+		if !ok {
+			errs = append(errs, fmt.Errorf("message %q isn't for a rule, a language then a tone", key))
+		} else if !slices.Contains(known, id) {
+			errs = append(errs, fmt.Errorf("message for unknown rule %q", id))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checks tells if p checks the rule of id.
+func (p Profile) checks(id string) bool {
+	if id == RuleNoBannedPrefix && len(p.BannedPrefixes) == 0 {
+		return false
+	}
+	return p.Rules == nil || slices.Contains(p.Rules, id)
+}
+
+// Build makes the rules of p, with a length rule derived from the names of
+// corpus and the custom rules it picks. Profiles without a list of rules
+// pick all of them.
+func (p Profile) Build(corpus []string, custom []Rule) []Rule {
+	var rules []Rule
+	for _, rule := range BuiltinRules() {
+		if p.checks(rule.ID) {
+			rules = append(rules, rule)
+		}
+	}
+	if p.checks(RuleCloseToMean) {
+		maxDist := p.MaxDist
+		if maxDist == 0 {
+			maxDist = MaxDist
+		}
+		length, _, _ := LengthRule(corpus, maxDist)
+		rules = append(rules, length)
+	}
+	if p.checks(RuleNoBannedPrefix) {
+		rules = append(rules, Rule{ID: RuleNoBannedPrefix, Filter: BannedPrefixes(p.BannedPrefixes)})
+	}
+	for _, rule := range custom {
+		if p.checks(rule.ID) {
+			rules = append(rules, rule)
+		}
+	}
+
+	for i := range rules {
+		rules[i] = p.bend(rules[i])
+	}
+	return rules
+}
+
+// messageRule is the ID of the rule the message of key is for, if the rest
+// of it is a language then a tone, each optional.
+func messageRule(key string) (string, bool) {
+	id, rest, _ := strings.Cut(key, ".")
+	if rest == "" {
+		return id, !strings.HasSuffix(key, ".")
+	}
+	if lang, tone, ok := strings.Cut(rest, "."); ok {
+		return id, slices.Contains(Langs(), lang) && slices.Contains(Tones(), Tone(tone))
+	}
+	return id, slices.Contains(Langs(), rest) || slices.Contains(Tones(), Tone(rest))
+}
+
+// messages are those of p for the rule of id.
+func (p Profile) messages(id string) map[string]string {
+	var msgs map[string]string
+	for key, msg := range p.Messages {
+		if rule, _ := messageRule(key); rule == id {
+			if msgs == nil {
+				msgs = make(map[string]string)
+			}
+			msgs[key] = msg
+		}
+	}
+	return msgs
+}
+
+// bend makes rule follow the allowances and messages of p.
+func (p Profile) bend(rule Rule) Rule {
+	msgs := p.messages(rule.ID)
+	if msgs == nil && len(p.Allow) == 0 && len(p.AllowSuffixes) == 0 {
+		return rule
+	}
+	check := rule.check
+	filter := func(name, importPath string) error {
+		if slices.Contains(p.Allow, name) {
+			return nil
+		}
+		for _, suffix := range p.AllowSuffixes {
+			if trimmed, cut := strings.CutSuffix(name, suffix); cut && trimmed != "" {
+				name = trimmed
+				break
+			}
+		}
+		err := check(name, importPath)
+		if err == nil || msgs == nil {
+			return err
+		}
+		// Each of the errors is told with the messages, so that there are
+		// as many violations.
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for i, err := range errs {
+			errs[i] = &override{rule: rule.ID, messages: msgs, err: err}
+		}
+		return errors.Join(errs...)
+	}
+	rule.PathFilter = filter
+	rule.Filter = func(name string) error { return filter(name, "") }
+	return rule
+}
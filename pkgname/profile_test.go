@@ -0,0 +1,196 @@
+package pkgname
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProfileBuild(t *testing.T) {
+	corpus := []string{"ab", "abcd", "abcdef"}
+	acme := Profile{
+		Rules:          []string{RuleNoUnderscore, RuleNoHyphens, RuleCloseToMean, RuleNoBannedPrefix},
+		MaxDist:        2,
+		Allow:          []string{"acme_internal"},
+		AllowSuffixes:  []string{"_test"},
+		BannedPrefixes: []string{"acme"},
+		Messages:       map[string]string{RuleNoHyphens: "ACME names have no hyphens."},
+	}
+
+	tests := []struct {
+		profile Profile
+		name    string
+		want    []string
+	}{
+		{Profile{}, "Go_Dock", []string{RuleNoUnderscore, RuleNotCapitalized, RuleNoReferenceToGo}},
+		{Profile{}, "abcdefg", nil},
+		{Profile{MaxDist: 1}, "abcdefg", []string{RuleCloseToMean}},
+		{Profile{Rules: []string{RuleNoHyphens}}, "Go_Dock", nil},
+		{acme, "Go_Dock", []string{RuleNoUnderscore}},
+		{acme, "web_test", nil},
+		{acme, "_test", []string{RuleNoUnderscore}},
+		{acme, "web_app_test", []string{RuleNoUnderscore}},
+		{acme, "acme_internal", nil},
+		{acme, "acmeauth", []string{RuleNoBannedPrefix}},
+		{acme, "web-app", []string{RuleNoHyphens}},
+	}
+	for _, tt := range tests {
+		got := ruleIDs(Validate(tt.name, &Options{Rules: tt.profile.Build(corpus, nil)}))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v, %q: want %q, got %q", tt.profile, tt.name, tt.want, got)
+		}
+	}
+
+	got := Validate("web-app", &Options{Rules: acme.Build(corpus, nil)})
+	if len(got) != 1 || got[0].Message != "ACME names have no hyphens." {
+		t.Errorf("want the message of the profile, got %v", got)
+	}
+}
+
+func TestProfileBuildCustom(t *testing.T) {
+	noLib, err := RuleDef{ID: "no-lib", BannedPrefixes: []string{"lib"}}.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := []Rule{noLib}
+
+	tests := []struct {
+		profile Profile
+		want    []string
+	}{
+		{Profile{}, []string{"no-lib"}},
+		{Profile{Rules: []string{RuleNoHyphens}}, nil},
+		{Profile{Rules: []string{"no-lib"}}, []string{"no-lib"}},
+	}
+	for _, tt := range tests {
+		got := ruleIDs(Validate("libweb", &Options{Rules: tt.profile.Build(Corpus(), custom)}))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: want %q, got %q", tt.profile, tt.want, got)
+		}
+	}
+
+	p := Profile{Rules: []string{"no-lib"}, Messages: map[string]string{"no-lib": "No libs."}}
+	if err := p.Check(); err == nil {
+		t.Errorf("want the custom rule unknown without its ID")
+	}
+	if err := p.Check("no-lib"); err != nil {
+		t.Errorf("want the custom rule known, got %v", err)
+	}
+}
+
+func TestProfileCheck(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		want    []string
+	}{
+		{Profile{}, nil},
+		{Profile{Rules: []string{RuleNoHyphens}, Messages: map[string]string{RuleNoHyphens: "No."}}, nil},
+		{Profile{Rules: []string{"no-vowels"}}, []string{`unknown rule "no-vowels"`}},
+		{Profile{Rules: []string{}}, []string{"at least one rule"}},
+		{Profile{Rules: []string{RuleNoBannedPrefix}}, []string{"needs banned prefixes"}},
+		{Profile{MaxDist: -1}, []string{"negative"}},
+		{Profile{Tone: ToneProfessional}, nil},
+		{Profile{Tone: "rude"}, []string{`tone "rude"`}},
+		{Profile{Messages: map[string]string{"no-vowels": "No."}}, []string{`message for unknown rule "no-vowels"`}},
+		{Profile{Messages: map[string]string{"no-hyphens.fr": "Non.", "no-hyphens.professional": "No.", "no-hyphens.de.snarky": "Nein."}}, nil},
+		{Profile{Messages: map[string]string{"no-hyphens.es": "No."}}, []string{`message "no-hyphens.es"`}},
+		{Profile{Messages: map[string]string{"no-hyphens.snarky.fr": "Non."}}, []string{`message "no-hyphens.snarky.fr"`}},
+		{Profile{Messages: map[string]string{"no-hyphens.": "No."}}, []string{`message "no-hyphens."`}},
+	}
+	for _, tt := range tests {
+		err := tt.profile.Check()
+		if tt.want == nil && err != nil {
+			t.Errorf("%+v: want no error, got %v", tt.profile, err)
+		}
+		for _, want := range tt.want {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%+v: want an error about %q, got %v", tt.profile, want, err)
+			}
+		}
+	}
+}
+
+func TestBuiltinProfiles(t *testing.T) {
+	profiles := BuiltinProfiles()
+	for name, p := range profiles {
+		if err := p.Check(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	var defaults, builtin []string
+	for _, rule := range DefaultRules() {
+		defaults = append(defaults, rule.ID)
+	}
+	for _, rule := range profiles["default"].Build(Corpus(), nil) {
+		builtin = append(builtin, rule.ID)
+	}
+	if !reflect.DeepEqual(defaults, builtin) {
+		t.Errorf("want the default profile to check %q, got %q", defaults, builtin)
+	}
+}
+
+func TestProfileMessages(t *testing.T) {
+	// Like a script rule, it can break more than once.
+	noLib := Rule{ID: "no-lib", Filter: func(name string) error {
+		var errs []error
+		if strings.HasPrefix(name, "lib") {
+			errs = append(errs, errors.New("lib"))
+		}
+		if strings.HasSuffix(name, "kit") {
+			errs = append(errs, errors.New("kit"))
+		}
+		return errors.Join(errs...)
+	}}
+	p := Profile{Messages: map[string]string{
+		"no-lib":                 "No libs, no kits.",
+		"no-lib.fr":              "Ni lib, ni kit.",
+		"no-lib.fr.professional": "Pas de lib ni de kit.",
+		RuleNoHyphens + ".de":    "Keine Striche.",
+	}}
+	rules := p.Build(Corpus(), []Rule{noLib})
+
+	tests := []struct {
+		name string
+		lang string
+		tone Tone
+		want []string
+	}{
+		{"libkit", "", "", []string{"No libs, no kits.", "No libs, no kits."}},
+		{"libkit", LangGerman, ToneProfessional, []string{"No libs, no kits.", "No libs, no kits."}},
+		{"libkit", LangFrench, "", []string{"Ni lib, ni kit.", "Ni lib, ni kit."}},
+		{"libkit", LangFrench, ToneProfessional, []string{"Pas de lib ni de kit.", "Pas de lib ni de kit."}},
+		{"lib-web", LangGerman, "", []string{"Keine Striche.", "No libs, no kits."}},
+		{"lib-web", LangFrench, "", []string{"Pas de tirets, c'est moche.", "Ni lib, ni kit."}},
+	}
+	for _, tt := range tests {
+		got := Validate(tt.name, &Options{Rules: rules, Lang: tt.lang, Tone: tt.tone})
+		var msgs []string
+		for _, v := range got {
+			msgs = append(msgs, v.Message)
+		}
+		if !reflect.DeepEqual(msgs, tt.want) {
+			t.Errorf("%s in %q %q: want %q, got %q", tt.name, tt.lang, tt.tone, tt.want, msgs)
+		}
+	}
+}
+
+func TestProfileBuildPathFilter(t *testing.T) {
+	rule := Rule{ID: "no-internal", PathFilter: func(name, importPath string) error {
+		if strings.Contains(importPath, "/internal/") {
+			return errors.New("internal")
+		}
+		return nil
+	}}
+	p := Profile{Rules: []string{"no-internal"}, Allow: []string{"web"}, Messages: map[string]string{"no-internal": "Keep it out."}}
+	rules := p.Build(Corpus(), []Rule{rule})
+
+	got := Validate("server", &Options{Rules: rules, ImportPath: "example.com/internal/server"})
+	if len(got) != 1 || got[0].Message != "Keep it out." {
+		t.Errorf("want the import path handed through the profile, got %v", got)
+	}
+	if got := Validate("web", &Options{Rules: rules, ImportPath: "example.com/internal/web"}); len(got) != 0 {
+		t.Errorf("want allowed names to pass, got %v", got)
+	}
+}
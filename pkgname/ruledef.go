@@ -0,0 +1,178 @@
+package pkgname
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// RuleDef declares a rule in data rather than code, for the house rules of
+// a team. A name breaks it if it fails any of its checks.
+type RuleDef struct {
+	ID string `toml:"id"`
+	// Match is a regular expression names must match.
+	Match string `toml:"match"`
+	// NoMatch is a regular expression names can't match.
+	NoMatch string `toml:"no_match"`
+	// BannedPrefixes, BannedSuffixes and BannedSubstrings are what names
+	// can't start with, end with and contain.
+	BannedPrefixes   []string `toml:"banned_prefixes"`
+	BannedSuffixes   []string `toml:"banned_suffixes"`
+	BannedSubstrings []string `toml:"banned_substrings"`
+	// IgnoreCase makes the banned prefixes, suffixes and substrings match
+	// whatever the case.
+	IgnoreCase bool `toml:"ignore_case"`
+	// MinLength and MaxLength bound the number of characters of names,
+	// unbounded if zero.
+	MinLength int `toml:"min_length"`
+	MaxLength int `toml:"max_length"`
+	// Message is a template of the message of the violations. It gets the
+	// .Name, its .Length, the .MinLength and .MaxLength, and as .Match the
+	// pattern it didn't match, or the part of it that's banned. Each check
+	// has its own message if it's empty, told in the language and tone asked
+	// for; this one is told whatever the language and tone.
+	Message string `toml:"message"`
+	// Severity is error, the default, or warning.
+	Severity string `toml:"severity"`
+}
+
+var ruleID = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Compile makes the rule d declares, or tells everything that's wrong with
+// it.
+func (d RuleDef) Compile() (Rule, error) {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch {
+	case d.ID == "":
+		fail("id is missing")
+	case !ruleID.MatchString(d.ID):
+		fail("id %q isn't made of lowercase letters, digits and hyphens", d.ID)
+	case slices.Contains(RuleIDs(), d.ID):
+		fail("id %q is taken by a builtin rule", d.ID)
+	}
+
+	severity, err := ParseSeverity(d.Severity)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	compile := func(key, expr string) *regexp.Regexp {
+		if expr == "" {
+			return nil
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			fail("%s: %v", key, err)
+		}
+		return re
+	}
+	match := compile("match", d.Match)
+	noMatch := compile("no_match", d.NoMatch)
+
+	for _, list := range []struct {
+		key   string
+		items []string
+	}{
+		{"banned_prefixes", d.BannedPrefixes},
+		{"banned_suffixes", d.BannedSuffixes},
+		{"banned_substrings", d.BannedSubstrings},
+	} {
+		if slices.Contains(list.items, "") {
+			fail("%s can't have blanks, they'd ban every name", list.key)
+		}
+	}
+
+	switch {
+	case d.MinLength < 0 || d.MaxLength < 0:
+		fail("lengths can't be negative")
+	case d.MaxLength > 0 && d.MinLength > d.MaxLength:
+		fail("min_length %d is more than max_length %d", d.MinLength, d.MaxLength)
+	}
+
+	checks := d.Match != "" || d.NoMatch != "" || d.MinLength > 0 || d.MaxLength > 0 ||
+		len(d.BannedPrefixes) > 0 || len(d.BannedSuffixes) > 0 || len(d.BannedSubstrings) > 0
+	if !checks {
+		fail("checks nothing, give it match, no_match, banned_prefixes, banned_suffixes, banned_substrings, min_length or max_length")
+	}
+
+	var own *template.Template
+	if d.Message != "" {
+		var err error
+		own, err = template.New(d.ID).Parse(d.Message)
+		if err == nil {
+			err = own.Execute(&strings.Builder{}, messageData{})
+		}
+		if err != nil {
+			fail("message: %v", err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Rule{}, err
+	}
+
+	// violation is the message of key, unless d has its own.
+	violation := func(key string, data messageData) error {
+		data.MinLength, data.MaxLength = d.MinLength, d.MaxLength
+		if own == nil {
+			return &message{key: key, data: data}
+		}
+		var msg strings.Builder
+		if err := own.Execute(&msg, data); err != nil {
+			return fmt.Errorf("Breaks %s.", d.ID)
+		}
+		return errors.New(msg.String())
+	}
+	fold := func(s string) string {
+		if d.IgnoreCase {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	find := func(name string, list []string, has func(s, substr string) bool) (string, bool) {
+		for _, s := range list {
+			if has(fold(name), fold(s)) {
+				return s, true
+			}
+		}
+		return "", false
+	}
+
+	filter := func(name string) error {
+		data := messageData{Name: name, Length: utf8.RuneCountInString(name)}
+		switch {
+		case d.MinLength > 0 && data.Length < d.MinLength:
+			return violation(msgTooShort, data)
+		case d.MaxLength > 0 && data.Length > d.MaxLength:
+			return violation(msgTooLong, data)
+		case match != nil && !match.MatchString(name):
+			data.Match = d.Match
+			return violation(msgMatch, data)
+		case noMatch != nil && noMatch.MatchString(name):
+			data.Match = noMatch.FindString(name)
+			return violation(msgNoMatch, data)
+		}
+		if s, ok := find(name, d.BannedPrefixes, strings.HasPrefix); ok {
+			data.Match = s
+			return violation(msgBannedPrefix, data)
+		}
+		if s, ok := find(name, d.BannedSuffixes, strings.HasSuffix); ok {
+			data.Match = s
+			return violation(msgBannedSuffix, data)
+		}
+		if s, ok := find(name, d.BannedSubstrings, strings.Contains); ok {
+			data.Match = s
+			return violation(msgBannedSubstring, data)
+		}
+		return nil
+	}
+	return Rule{ID: d.ID, Filter: filter, Severity: severity}, nil
+}
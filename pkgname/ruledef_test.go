@@ -0,0 +1,114 @@
+package pkgname
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleDefCompile(t *testing.T) {
+	tests := []struct {
+		def   RuleDef
+		tests []filterTest
+	}{
+		{RuleDef{ID: "lowercase-ascii", Match: `^[a-z][a-z0-9]*$`}, []filterTest{
+			{"docker", true},
+			{"équipe", false},
+			{"2docker", false},
+		}},
+		{RuleDef{ID: "no-versions", NoMatch: `v[0-9]+$`}, []filterTest{
+			{"docker", true},
+			{"dockerv2", false},
+		}},
+		{RuleDef{ID: "no-lib", BannedPrefixes: []string{"lib"}, BannedSuffixes: []string{"lib", "kit"}}, []filterTest{
+			{"docker", true},
+			{"libdocker", false},
+			{"dockerkit", false},
+			{"Libdocker", true},
+		}},
+		{RuleDef{ID: "no-lib", BannedPrefixes: []string{"lib"}, IgnoreCase: true}, []filterTest{
+			{"Libdocker", false},
+		}},
+		{RuleDef{ID: "no-util", BannedSubstrings: []string{"util", "helper"}}, []filterTest{
+			{"docker", true},
+			{"stringutils", false},
+			{"myhelpers", false},
+		}},
+		{RuleDef{ID: "length", MinLength: 2, MaxLength: 4}, []filterTest{
+			{"ab", true},
+			{"abcd", true},
+			{"éèàù", true},
+			{"a", false},
+			{"abcde", false},
+		}},
+	}
+	for _, tt := range tests {
+		rule, err := tt.def.Compile()
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.def, err)
+		}
+		if rule.ID != tt.def.ID || rule.Severity != SeverityError {
+			t.Errorf("%+v: want an error rule of ID %q, got %+v", tt.def, tt.def.ID, rule)
+		}
+		testFilter(t, rule.Filter, tt.tests)
+	}
+}
+
+func TestRuleDefMessages(t *testing.T) {
+	tests := []struct {
+		def  RuleDef
+		name string
+		want string
+	}{
+		{RuleDef{ID: "no-lib", BannedPrefixes: []string{"lib"}}, "libdocker", "Don't start your package name with 'lib', house rules."},
+		{RuleDef{ID: "no-versions", NoMatch: `v[0-9]+$`}, "dockerv2", "This package name matches v2, that's against house rules."},
+		{RuleDef{ID: "length", MaxLength: 4}, "docker", "This package name is too long, make it at most 4 characters long."},
+		{RuleDef{ID: "no-lib", BannedSuffixes: []string{"lib"}, Message: "{{.Name}} ends with {{.Match}}."}, "dockerlib", "dockerlib ends with lib."},
+		{RuleDef{ID: "length", MinLength: 3, Message: "{{.Length}} < {{.MinLength}}"}, "ab", "2 < 3"},
+	}
+	for _, tt := range tests {
+		rule, err := tt.def.Compile()
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.def, err)
+		}
+		if err := rule.Filter(tt.name); err == nil || err.Error() != tt.want {
+			t.Errorf("%q: want %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestRuleDefSeverity(t *testing.T) {
+	rule, err := RuleDef{ID: "no-lib", BannedPrefixes: []string{"lib"}, Severity: "warning"}.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Validate("libdocker", &Options{Rules: []Rule{rule}})
+	if len(got) != 1 || got[0].Severity != SeverityWarning || !Passed(got) {
+		t.Errorf("want a warning that lets the name pass, got %+v", got)
+	}
+}
+
+func TestRuleDefErrors(t *testing.T) {
+	tests := []struct {
+		def  RuleDef
+		want []string
+	}{
+		{RuleDef{BannedPrefixes: []string{"lib"}}, []string{"id is missing"}},
+		{RuleDef{ID: "No Lib", BannedPrefixes: []string{"lib"}}, []string{`id "No Lib"`}},
+		{RuleDef{ID: RuleNoHyphens, BannedPrefixes: []string{"lib"}}, []string{"builtin rule"}},
+		{RuleDef{ID: "nothing"}, []string{"checks nothing"}},
+		{RuleDef{ID: "bad", Match: "(", NoMatch: "[", BannedSuffixes: []string{""}}, []string{"match:", "no_match:", "banned_suffixes can't have blanks"}},
+		{RuleDef{ID: "bad", MinLength: 5, MaxLength: 3}, []string{"min_length 5 is more than max_length 3"}},
+		{RuleDef{ID: "bad", MaxLength: -1}, []string{"negative"}},
+		{RuleDef{ID: "bad", MaxLength: 3, Severity: "fatal"}, []string{`severity "fatal"`}},
+		{RuleDef{ID: "bad", MaxLength: 3, Message: "{{.Nme}}"}, []string{"message:"}},
+		{RuleDef{ID: "bad", MaxLength: 3, Message: "{{.Name"}, []string{"message:"}},
+	}
+	for _, tt := range tests {
+		_, err := tt.def.Compile()
+		for _, want := range tt.want {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%+v: want an error about %q, got %v", tt.def, want, err)
+			}
+		}
+	}
+}
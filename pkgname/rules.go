@@ -0,0 +1,151 @@
+package pkgname
+
+import (
+	"github.com/grd/stat"
+	"strings"
+	"unicode"
+)
+
+// IDs of the builtin rules.
+const (
+	RuleNoHyphens           = "no-hyphens"
+	RuleNoUnderscore        = "no-underscore"
+	RuleNotCapitalized      = "not-capitalized"
+	RuleNoReferenceToGo     = "no-reference-to-go"
+	RuleNoReferenceToGolang = "no-reference-to-golang"
+	RuleValidPackageName    = "valid-package-name"
+	RuleCloseToMean         = "close-to-mean"
+	RuleNoBannedPrefix      = "no-banned-prefix"
+)
+
+// BuiltinRules returns the rules that don't depend on a corpus of names.
+func BuiltinRules() []Rule {
+	return []Rule{
+		{ID: RuleNoHyphens, Filter: NoHyphens},
+		{ID: RuleNoUnderscore, Filter: NoUnderscore},
+		{ID: RuleNotCapitalized, Filter: NotCapitalized},
+		{ID: RuleNoReferenceToGo, Filter: NoReferenceToGo},
+		{ID: RuleNoReferenceToGolang, Filter: NoReferenceToGolang},
+		{ID: RuleValidPackageName, Filter: ValidPackageName},
+	}
+}
+
+// NoHyphens rejects names with hyphens.
+func NoHyphens(name string) error {
+	if strings.Contains(name, "-") {
+		return &message{key: RuleNoHyphens, data: messageData{Name: name}}
+	}
+	return nil
+}
+
+// NoUnderscore rejects names with underscores.
+func NoUnderscore(name string) error {
+	if strings.Contains(name, "_") {
+		return &message{key: RuleNoUnderscore, data: messageData{Name: name}}
+	}
+	return nil
+}
+
+// NotCapitalized rejects names with uppercase characters.
+func NotCapitalized(name string) error {
+	for _, r := range []rune(name) {
+		if unicode.IsUpper(r) {
+			return &message{key: RuleNotCapitalized, data: messageData{Name: name}}
+		}
+	}
+	return nil
+}
+
+// NoReferenceToGo rejects names starting or ending with "go".
+func NoReferenceToGo(name string) error {
+	lowerName := strings.ToLower(name)
+	if strings.HasPrefix(lowerName, "go") || strings.HasSuffix(lowerName, "go") {
+		return &message{key: RuleNoReferenceToGo, data: messageData{Name: name}}
+	}
+	return nil
+}
+
+// NoReferenceToGolang rejects names containing "golang".
+func NoReferenceToGolang(name string) error {
+	if strings.Contains(strings.ToLower(name), "golang") {
+		return &message{key: RuleNoReferenceToGolang, data: messageData{Name: name}}
+	}
+	return nil
+}
+
+// ValidPackageName rejects names that the Go spec doesn't allow, save for
+// hyphens, underscores and dots which are left to other rules.
+func ValidPackageName(name string) error {
+	invalid := func(reason string) error {
+		return &message{key: RuleValidPackageName, data: messageData{Name: name, reason: reason}}
+	}
+	if len(name) < 1 {
+		return invalid(msgBlank)
+	}
+
+	for i, r := range []rune(name) {
+		if i == 0 {
+			if !unicode.IsLetter(r) {
+				return invalid(msgFirstLetter)
+			}
+		}
+
+		switch {
+		case unicode.IsLetter(r):
+		case unicode.IsDigit(r):
+		case r == '-':
+		case r == '_':
+		case r == '.':
+			// ok
+		default:
+			return invalid(msgCharacters)
+		}
+	}
+
+	return nil
+}
+
+// CloseToMean makes a filter rejecting names that are more than maxDist
+// standard deviations longer than the average name of allnames.
+func CloseToMean(allnames []string, maxDist float64) (f Filter, mean, stdev float64) {
+	data := make(stat.IntSlice, len(allnames))
+	for i, name := range allnames {
+		data[i] = int64(len(name))
+	}
+
+	mean = stat.Mean(data)
+	stdev = stat.SdMean(data, mean)
+
+	maxMean := int(mean + stdev*maxDist)
+
+	f = func(name string) error {
+		diff := float64(len(name)) - mean
+		dist := diff / stdev
+
+		if dist > maxDist {
+			return &message{key: RuleCloseToMean, data: messageData{Name: name, Length: len(name), MaxLength: maxMean, Dist: dist}}
+		}
+		return nil
+	}
+	return
+}
+
+// BannedPrefixes makes a filter rejecting names that start with any of
+// prefixes, whatever their case.
+func BannedPrefixes(prefixes []string) Filter {
+	return func(name string) error {
+		lowerName := strings.ToLower(name)
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(lowerName, strings.ToLower(prefix)) {
+				return &message{key: RuleNoBannedPrefix, data: messageData{Name: name, Match: prefix}}
+			}
+		}
+		return nil
+	}
+}
+
+// LengthRule is the rule of CloseToMean.
+func LengthRule(allnames []string, maxDist float64) (r Rule, mean, stdev float64) {
+	f, mean, stdev := CloseToMean(allnames, maxDist)
+	return Rule{ID: RuleCloseToMean, Filter: f}, mean, stdev
+}
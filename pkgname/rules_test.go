@@ -0,0 +1,149 @@
+package pkgname
+
+import (
+	"strings"
+	"testing"
+)
+
+type filterTest struct {
+	name string
+	ok   bool
+}
+
+func testFilter(t *testing.T, f Filter, tests []filterTest) {
+	for _, tt := range tests {
+		err := f(tt.name)
+		switch {
+		case tt.ok && err != nil:
+			t.Errorf("%q: want no error, got %v", tt.name, err)
+		case !tt.ok && err == nil:
+			t.Errorf("%q: want an error", tt.name)
+		}
+	}
+}
+
+func TestNoHyphens(t *testing.T) {
+	testFilter(t, NoHyphens, []filterTest{
+		{"docker", true},
+		{"", true},
+		{"web-app", false},
+		{"-", false},
+	})
+}
+
+func TestNoUnderscore(t *testing.T) {
+	testFilter(t, NoUnderscore, []filterTest{
+		{"docker", true},
+		{"web_app", false},
+		{"_", false},
+	})
+}
+
+func TestNotCapitalized(t *testing.T) {
+	testFilter(t, NotCapitalized, []filterTest{
+		{"docker", true},
+		{"docker2", true},
+		{"Docker", false},
+		{"dockeR", false},
+		{"équipe", true},
+		{"Équipe", false},
+	})
+}
+
+func TestNoReferenceToGo(t *testing.T) {
+	testFilter(t, NoReferenceToGo, []filterTest{
+		{"docker", true},
+		{"cargo", false},
+		{"gopher", false},
+		{"Gopher", false},
+		{"mongodb", true},
+		{"go", false},
+	})
+}
+
+func TestNoReferenceToGolang(t *testing.T) {
+	testFilter(t, NoReferenceToGolang, []filterTest{
+		{"docker", true},
+		{"golang", false},
+		{"webGoLangtools", false},
+		{"gol", true},
+	})
+}
+
+func TestValidPackageName(t *testing.T) {
+	testFilter(t, ValidPackageName, []filterTest{
+		{"docker", true},
+		{"docker2", true},
+		{"équipe", true},
+		{"web-app", true},
+		{"web_app", true},
+		{"go.dbus", true},
+		{"", false},
+		{"2docker", false},
+		{"_docker", false},
+		{"doc ker", false},
+		{"doc/ker", false},
+	})
+}
+
+func TestBannedPrefixes(t *testing.T) {
+	testFilter(t, BannedPrefixes([]string{"acme", "Rocket"}), []filterTest{
+		{"docker", true},
+		{"acmeauth", false},
+		{"Acmeauth", false},
+		{"rocketship", false},
+		{"myacme", true},
+	})
+	testFilter(t, BannedPrefixes(nil), []filterTest{
+		{"acmeauth", true},
+	})
+}
+
+func TestCloseToMean(t *testing.T) {
+	names := []string{"ab", "abcd", "abcdef"}
+
+	f, mean, stdev := CloseToMean(names, 1)
+	if mean != 4 {
+		t.Errorf("want mean 4, got %f", mean)
+	}
+	if stdev != 2 {
+		t.Errorf("want stdev 2, got %f", stdev)
+	}
+
+	testFilter(t, f, []filterTest{
+		{"", true},
+		{"abcdef", true},
+		{"abcdefg", false},
+	})
+
+	err := f("abcdefghij")
+	if err == nil || !strings.Contains(err.Error(), "at most 6 characters") {
+		t.Errorf("want the limit in the error, got %v", err)
+	}
+}
+
+func TestLengthRule(t *testing.T) {
+	rule, mean, stdev := LengthRule([]string{"ab", "abcd", "abcdef"}, 1)
+	if rule.ID != RuleCloseToMean {
+		t.Errorf("want ID %q, got %q", RuleCloseToMean, rule.ID)
+	}
+	if mean != 4 || stdev != 2 {
+		t.Errorf("want mean=4 stdev=2, got mean=%f stdev=%f", mean, stdev)
+	}
+	if rule.Filter("abcdefg") == nil {
+		t.Errorf("want the rule to use the filter of CloseToMean")
+	}
+}
+
+func TestBuiltinRulesHaveIDs(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, rule := range BuiltinRules() {
+		if rule.ID == "" || rule.Filter == nil {
+			t.Errorf("incomplete rule %+v", rule)
+		}
+		if seen[rule.ID] {
+			t.Errorf("duplicate rule ID %q", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+}
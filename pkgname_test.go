@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type legacyResponse struct {
+	Err     string   `json:"error"`
+	Success bool     `json:"success"`
+	Pkgname string   `json:"pkgname"`
+	Causes  []string `json:"causes"`
+	Goods   []string `json:"goods"`
+	Bads    []string `json:"bads"`
+}
+
+func serve(t *testing.T, h http.HandlerFunc, r *http.Request) (*httptest.ResponseRecorder, legacyResponse) {
+	rr := httptest.NewRecorder()
+	jsontype(h)(rr, r)
+
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("want JSON, got %q", ct)
+	}
+	var res legacyResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+		t.Fatalf("decoding %q: %v", rr.Body.String(), err)
+	}
+	return rr, res
+}
+
+func postForm(target string, form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestValidateHandler(t *testing.T) {
+	h := validate(NewDB())
+
+	rr, res := serve(t, h, postForm("/validate", url.Values{"pkgname": {"lime"}}))
+	if rr.Code != http.StatusOK || !res.Success || res.Pkgname != "lime" || res.Err != "" {
+		t.Errorf("want lime to pass, got %d %+v", rr.Code, res)
+	}
+
+	rr, res = serve(t, h, postForm("/validate", url.Values{"pkgname": {"go-lime"}}))
+	if rr.Code != http.StatusOK || res.Success || len(res.Causes) != 2 {
+		t.Errorf("want go-lime to fail twice, got %d %+v", rr.Code, res)
+	}
+}
+
+func TestValidateHandlerDoesntMangle(t *testing.T) {
+	h := validate(NewDB())
+
+	_, res := serve(t, h, postForm("/validate", url.Values{"pkgname": {"a<b&c"}}))
+	if res.Pkgname != "a<b&c" {
+		t.Errorf("want the name untouched, got %q", res.Pkgname)
+	}
+}
+
+func TestValidateHandlerErrors(t *testing.T) {
+	h := validate(NewDB())
+
+	rr, res := serve(t, h, httptest.NewRequest("GET", "/validate?pkgname=lime", nil))
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "POST" || res.Err == "" {
+		t.Errorf("want 405 allowing POST, got %d %q %+v", rr.Code, rr.Header().Get("Allow"), res)
+	}
+
+	rr, res = serve(t, h, postForm("/validate", url.Values{}))
+	if rr.Code != http.StatusBadRequest || res.Err == "" {
+		t.Errorf("want 400 without a name, got %d %+v", rr.Code, res)
+	}
+}
+
+func TestValidateHandlerProfile(t *testing.T) {
+	h := validate(NewDB())
+
+	_, res := serve(t, h, postForm("/validate?profile=strict", url.Values{"pkgname": {"utils"}}))
+	if res.Success || len(res.Causes) != 1 {
+		t.Errorf("want utils to fail the strict profile, got %+v", res)
+	}
+	_, res = serve(t, h, postForm("/validate", url.Values{"pkgname": {"utils"}, "profile": {"stdlib-style"}}))
+	if !res.Success {
+		t.Errorf("want utils to pass the stdlib-style profile, got %+v", res)
+	}
+
+	rr, res := serve(t, h, postForm("/validate", url.Values{"pkgname": {"utils"}, "profile": {"initech"}}))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(res.Err, "initech") {
+		t.Errorf("want 400 for an unknown profile, got %d %+v", rr.Code, res)
+	}
+}
+
+func TestValidateHandlerTone(t *testing.T) {
+	h := validate(NewDB())
+
+	_, res := serve(t, h, postForm("/validate", url.Values{"pkgname": {"lime-green"}, "tone": {"professional"}}))
+	if res.Success || !reflect.DeepEqual(res.Causes, []string{"Package names shouldn't contain hyphens."}) {
+		t.Errorf("want a professional message, got %+v", res)
+	}
+
+	rr, res := serve(t, h, postForm("/validate", url.Values{"pkgname": {"go-lime"}, "tone": {"rude"}}))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(res.Err, "snarky, professional") {
+		t.Errorf("want 400 for an unknown tone, got %d %+v", rr.Code, res)
+	}
+}
+
+func TestValidateHandlerLang(t *testing.T) {
+	h := validate(NewDB())
+
+	r := postForm("/validate", url.Values{"pkgname": {"lime-green"}})
+	r.Header.Set("Accept-Language", "fr")
+	rr, res := serve(t, h, r)
+	if !reflect.DeepEqual(res.Causes, []string{"Pas de tirets, c'est moche."}) || rr.Header().Get("Content-Language") != "fr" {
+		t.Errorf("want a French message, got %q %+v", rr.Header().Get("Content-Language"), res)
+	}
+	_, res = serve(t, h, postForm("/validate", url.Values{"pkgname": {"lime-green"}, "lang": {"de"}}))
+	if !reflect.DeepEqual(res.Causes, []string{"Keine Bindestriche, das ist hässlich."}) {
+		t.Errorf("want a German message, got %+v", res)
+	}
+	rr, res = serve(t, h, postForm("/validate", url.Values{"pkgname": {" "}, "lang": {"fr"}}))
+	if rr.Code != http.StatusBadRequest || res.Err != "Il faut un nom de paquet." {
+		t.Errorf("want the error told in French, got %d %+v", rr.Code, res)
+	}
+}
+
+func TestValidateHandlerRecord(t *testing.T) {
+	db := NewDB()
+	h := validate(db)
+
+	serve(t, h, postForm("/validate", url.Values{"pkgname": {"lime"}, "record": {"false"}}))
+	if goods, _ := db.Last(1); len(goods) != 0 {
+		t.Errorf("want nothing recorded with record=false, got %q", goods)
+	}
+	serve(t, h, postForm("/validate", url.Values{"pkgname": {"lime"}}))
+	if goods, _ := db.Last(1); len(goods) != 1 {
+		t.Errorf("want the name recorded by default, got %q", goods)
+	}
+
+	rr, res := serve(t, h, postForm("/validate", url.Values{"pkgname": {"lime"}, "record": {"maybe"}}))
+	if rr.Code != http.StatusBadRequest || res.Err != invalidRecordMsg {
+		t.Errorf("want 400 with a bad record, got %d %+v", rr.Code, res)
+	}
+}
+
+func TestHistoryHandlerPrivate(t *testing.T) {
+	defer func(private bool) { privateHistory = private }(privateHistory)
+	privateHistory = true
+
+	db := NewDB()
+	db.Validate(context.Background(), "", "lime", true)
+
+	rr, res := serve(t, history(db), httptest.NewRequest("GET", "/history", nil))
+	if rr.Code != http.StatusForbidden || len(res.Goods) != 0 || res.Err != historyPrivateMsg {
+		t.Errorf("want 403 without names, got %d %+v", rr.Code, res)
+	}
+}
+
+func TestHistoryHandler(t *testing.T) {
+	db := NewDB()
+	db.Validate(context.Background(), "", "lime", true)
+	db.Validate(context.Background(), "", "go-lime", true)
+	h := history(db)
+
+	rr, res := serve(t, h, httptest.NewRequest("GET", "/history", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", rr.Code)
+	}
+	if len(res.Goods) != 1 || res.Goods[0] != "lime" {
+		t.Errorf("want lime in goods, got %q", res.Goods)
+	}
+	if len(res.Bads) != 1 || res.Bads[0] != "go-lime" {
+		t.Errorf("want go-lime in bads, got %q", res.Bads)
+	}
+
+	rr, _ = serve(t, h, httptest.NewRequest("POST", "/history", nil))
+	if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != "GET" {
+		t.Errorf("want 405 allowing GET, got %d", rr.Code)
+	}
+}
+
+func TestGenerateHandler(t *testing.T) {
+	h := generate(NewDB())
+
+	rr, res := serve(t, h, httptest.NewRequest("GET", "/generate", nil))
+	if rr.Code != http.StatusOK || res.Pkgname == "" || res.Err != "" {
+		t.Errorf("want a name, got %d %+v", rr.Code, res)
+	}
+
+	rr, _ = serve(t, h, httptest.NewRequest("POST", "/generate", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("want 405, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,5 @@
+// Package pkgnamepb holds the protocol buffer and gRPC definitions of the
+// pkgname service.
+package pkgnamepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pkgname.proto
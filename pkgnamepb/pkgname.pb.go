@@ -0,0 +1,532 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.28.3
+// source: pkgname.proto
+
+package pkgnamepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ValidateRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Pkgname string                 `protobuf:"bytes,1,opt,name=pkgname,proto3" json:"pkgname,omitempty"`
+	// Record the name in the public history.
+	Record bool `protobuf:"varint,2,opt,name=record,proto3" json:"record,omitempty"`
+	// Rule profile to check the name with, the default one if empty.
+	Profile string `protobuf:"bytes,3,opt,name=profile,proto3" json:"profile,omitempty"`
+	// Import path of the package, for the rules that look at it.
+	ImportPath string `protobuf:"bytes,4,opt,name=import_path,json=importPath,proto3" json:"import_path,omitempty"`
+	// Tone of the messages, snarky or professional, the one of the profile
+	// if empty.
+	Tone string `protobuf:"bytes,5,opt,name=tone,proto3" json:"tone,omitempty"`
+	// Language of the messages, like fr or de, English if empty.
+	Lang          string `protobuf:"bytes,6,opt,name=lang,proto3" json:"lang,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateRequest) Reset() {
+	*x = ValidateRequest{}
+	mi := &file_pkgname_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateRequest) ProtoMessage() {}
+
+func (x *ValidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkgname_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateRequest.ProtoReflect.Descriptor instead.
+func (*ValidateRequest) Descriptor() ([]byte, []int) {
+	return file_pkgname_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ValidateRequest) GetPkgname() string {
+	if x != nil {
+		return x.Pkgname
+	}
+	return ""
+}
+
+func (x *ValidateRequest) GetRecord() bool {
+	if x != nil {
+		return x.Record
+	}
+	return false
+}
+
+func (x *ValidateRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *ValidateRequest) GetImportPath() string {
+	if x != nil {
+		return x.ImportPath
+	}
+	return ""
+}
+
+func (x *ValidateRequest) GetTone() string {
+	if x != nil {
+		return x.Tone
+	}
+	return ""
+}
+
+func (x *ValidateRequest) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+type ValidateResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Pkgname string                 `protobuf:"bytes,1,opt,name=pkgname,proto3" json:"pkgname,omitempty"`
+	Success bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Causes  []string               `protobuf:"bytes,3,rep,name=causes,proto3" json:"causes,omitempty"`
+	// Why the name couldn't be checked, in a stream. The verdict is unset
+	// then.
+	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// The causes along with their rule and severity.
+	Violations    []*Violation `protobuf:"bytes,5,rep,name=violations,proto3" json:"violations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateResponse) Reset() {
+	*x = ValidateResponse{}
+	mi := &file_pkgname_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateResponse) ProtoMessage() {}
+
+func (x *ValidateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkgname_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateResponse.ProtoReflect.Descriptor instead.
+func (*ValidateResponse) Descriptor() ([]byte, []int) {
+	return file_pkgname_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ValidateResponse) GetPkgname() string {
+	if x != nil {
+		return x.Pkgname
+	}
+	return ""
+}
+
+func (x *ValidateResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ValidateResponse) GetCauses() []string {
+	if x != nil {
+		return x.Causes
+	}
+	return nil
+}
+
+func (x *ValidateResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ValidateResponse) GetViolations() []*Violation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+type Violation struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Rule    string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// error, or warning for the rules that don't make the name shit.
+	Severity      string `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Violation) Reset() {
+	*x = Violation{}
+	mi := &file_pkgname_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Violation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Violation) ProtoMessage() {}
+
+func (x *Violation) ProtoReflect() protoreflect.Message {
+	mi := &file_pkgname_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Violation.ProtoReflect.Descriptor instead.
+func (*Violation) Descriptor() ([]byte, []int) {
+	return file_pkgname_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Violation) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Violation) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Violation) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+type GenerateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateRequest) Reset() {
+	*x = GenerateRequest{}
+	mi := &file_pkgname_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateRequest) ProtoMessage() {}
+
+func (x *GenerateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkgname_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateRequest.ProtoReflect.Descriptor instead.
+func (*GenerateRequest) Descriptor() ([]byte, []int) {
+	return file_pkgname_proto_rawDescGZIP(), []int{3}
+}
+
+type GenerateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pkgname       string                 `protobuf:"bytes,1,opt,name=pkgname,proto3" json:"pkgname,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateResponse) Reset() {
+	*x = GenerateResponse{}
+	mi := &file_pkgname_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateResponse) ProtoMessage() {}
+
+func (x *GenerateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkgname_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateResponse.ProtoReflect.Descriptor instead.
+func (*GenerateResponse) Descriptor() ([]byte, []int) {
+	return file_pkgname_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GenerateResponse) GetPkgname() string {
+	if x != nil {
+		return x.Pkgname
+	}
+	return ""
+}
+
+type HistoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// How many names of each kind to list, 10 if unset.
+	Last          int32 `protobuf:"varint,1,opt,name=last,proto3" json:"last,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRequest) Reset() {
+	*x = HistoryRequest{}
+	mi := &file_pkgname_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRequest) ProtoMessage() {}
+
+func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkgname_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return file_pkgname_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *HistoryRequest) GetLast() int32 {
+	if x != nil {
+		return x.Last
+	}
+	return 0
+}
+
+type HistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Goods         []string               `protobuf:"bytes,1,rep,name=goods,proto3" json:"goods,omitempty"`
+	Bads          []string               `protobuf:"bytes,2,rep,name=bads,proto3" json:"bads,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryResponse) Reset() {
+	*x = HistoryResponse{}
+	mi := &file_pkgname_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryResponse) ProtoMessage() {}
+
+func (x *HistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkgname_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryResponse.ProtoReflect.Descriptor instead.
+func (*HistoryResponse) Descriptor() ([]byte, []int) {
+	return file_pkgname_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *HistoryResponse) GetGoods() []string {
+	if x != nil {
+		return x.Goods
+	}
+	return nil
+}
+
+func (x *HistoryResponse) GetBads() []string {
+	if x != nil {
+		return x.Bads
+	}
+	return nil
+}
+
+var File_pkgname_proto protoreflect.FileDescriptor
+
+const file_pkgname_proto_rawDesc = "" +
+	"\n" +
+	"\rpkgname.proto\x12\n" +
+	"pkgname.v1\"\xa6\x01\n" +
+	"\x0fValidateRequest\x12\x18\n" +
+	"\apkgname\x18\x01 \x01(\tR\apkgname\x12\x16\n" +
+	"\x06record\x18\x02 \x01(\bR\x06record\x12\x18\n" +
+	"\aprofile\x18\x03 \x01(\tR\aprofile\x12\x1f\n" +
+	"\vimport_path\x18\x04 \x01(\tR\n" +
+	"importPath\x12\x12\n" +
+	"\x04tone\x18\x05 \x01(\tR\x04tone\x12\x12\n" +
+	"\x04lang\x18\x06 \x01(\tR\x04lang\"\xab\x01\n" +
+	"\x10ValidateResponse\x12\x18\n" +
+	"\apkgname\x18\x01 \x01(\tR\apkgname\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x16\n" +
+	"\x06causes\x18\x03 \x03(\tR\x06causes\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x125\n" +
+	"\n" +
+	"violations\x18\x05 \x03(\v2\x15.pkgname.v1.ViolationR\n" +
+	"violations\"U\n" +
+	"\tViolation\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1a\n" +
+	"\bseverity\x18\x03 \x01(\tR\bseverity\"\x11\n" +
+	"\x0fGenerateRequest\",\n" +
+	"\x10GenerateResponse\x12\x18\n" +
+	"\apkgname\x18\x01 \x01(\tR\apkgname\"$\n" +
+	"\x0eHistoryRequest\x12\x12\n" +
+	"\x04last\x18\x01 \x01(\x05R\x04last\";\n" +
+	"\x0fHistoryResponse\x12\x14\n" +
+	"\x05goods\x18\x01 \x03(\tR\x05goods\x12\x12\n" +
+	"\x04bads\x18\x02 \x03(\tR\x04bads2\xac\x02\n" +
+	"\aPkgname\x12E\n" +
+	"\bValidate\x12\x1b.pkgname.v1.ValidateRequest\x1a\x1c.pkgname.v1.ValidateResponse\x12O\n" +
+	"\x0eValidateStream\x12\x1b.pkgname.v1.ValidateRequest\x1a\x1c.pkgname.v1.ValidateResponse(\x010\x01\x12E\n" +
+	"\bGenerate\x12\x1b.pkgname.v1.GenerateRequest\x1a\x1c.pkgname.v1.GenerateResponse\x12B\n" +
+	"\aHistory\x12\x1a.pkgname.v1.HistoryRequest\x1a\x1b.pkgname.v1.HistoryResponseB'Z%github.com/aybabtme/pkgname/pkgnamepbb\x06proto3"
+
+var (
+	file_pkgname_proto_rawDescOnce sync.Once
+	file_pkgname_proto_rawDescData []byte
+)
+
+func file_pkgname_proto_rawDescGZIP() []byte {
+	file_pkgname_proto_rawDescOnce.Do(func() {
+		file_pkgname_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkgname_proto_rawDesc), len(file_pkgname_proto_rawDesc)))
+	})
+	return file_pkgname_proto_rawDescData
+}
+
+var file_pkgname_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_pkgname_proto_goTypes = []any{
+	(*ValidateRequest)(nil),  // 0: pkgname.v1.ValidateRequest
+	(*ValidateResponse)(nil), // 1: pkgname.v1.ValidateResponse
+	(*Violation)(nil),        // 2: pkgname.v1.Violation
+	(*GenerateRequest)(nil),  // 3: pkgname.v1.GenerateRequest
+	(*GenerateResponse)(nil), // 4: pkgname.v1.GenerateResponse
+	(*HistoryRequest)(nil),   // 5: pkgname.v1.HistoryRequest
+	(*HistoryResponse)(nil),  // 6: pkgname.v1.HistoryResponse
+}
+var file_pkgname_proto_depIdxs = []int32{
+	2, // 0: pkgname.v1.ValidateResponse.violations:type_name -> pkgname.v1.Violation
+	0, // 1: pkgname.v1.Pkgname.Validate:input_type -> pkgname.v1.ValidateRequest
+	0, // 2: pkgname.v1.Pkgname.ValidateStream:input_type -> pkgname.v1.ValidateRequest
+	3, // 3: pkgname.v1.Pkgname.Generate:input_type -> pkgname.v1.GenerateRequest
+	5, // 4: pkgname.v1.Pkgname.History:input_type -> pkgname.v1.HistoryRequest
+	1, // 5: pkgname.v1.Pkgname.Validate:output_type -> pkgname.v1.ValidateResponse
+	1, // 6: pkgname.v1.Pkgname.ValidateStream:output_type -> pkgname.v1.ValidateResponse
+	4, // 7: pkgname.v1.Pkgname.Generate:output_type -> pkgname.v1.GenerateResponse
+	6, // 8: pkgname.v1.Pkgname.History:output_type -> pkgname.v1.HistoryResponse
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_pkgname_proto_init() }
+func file_pkgname_proto_init() {
+	if File_pkgname_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkgname_proto_rawDesc), len(file_pkgname_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkgname_proto_goTypes,
+		DependencyIndexes: file_pkgname_proto_depIdxs,
+		MessageInfos:      file_pkgname_proto_msgTypes,
+	}.Build()
+	File_pkgname_proto = out.File
+	file_pkgname_proto_goTypes = nil
+	file_pkgname_proto_depIdxs = nil
+}
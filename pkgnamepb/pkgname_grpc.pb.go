@@ -0,0 +1,246 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: pkgname.proto
+
+package pkgnamepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Pkgname_Validate_FullMethodName       = "/pkgname.v1.Pkgname/Validate"
+	Pkgname_ValidateStream_FullMethodName = "/pkgname.v1.Pkgname/ValidateStream"
+	Pkgname_Generate_FullMethodName       = "/pkgname.v1.Pkgname/Generate"
+	Pkgname_History_FullMethodName        = "/pkgname.v1.Pkgname/History"
+)
+
+// PkgnameClient is the client API for Pkgname service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Pkgname tells if the name of a Go package is shit.
+type PkgnameClient interface {
+	// Validate checks a single name.
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error)
+	// ValidateStream checks names as they are sent, answering each in order.
+	// A name that can't be checked is answered with an error, and the stream
+	// goes on.
+	ValidateStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ValidateRequest, ValidateResponse], error)
+	// Generate picks a good name at random.
+	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error)
+	// History lists the most recently recorded names, newest first.
+	History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+}
+
+type pkgnameClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPkgnameClient(cc grpc.ClientConnInterface) PkgnameClient {
+	return &pkgnameClient{cc}
+}
+
+func (c *pkgnameClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateResponse)
+	err := c.cc.Invoke(ctx, Pkgname_Validate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pkgnameClient) ValidateStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ValidateRequest, ValidateResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Pkgname_ServiceDesc.Streams[0], Pkgname_ValidateStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ValidateRequest, ValidateResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Pkgname_ValidateStreamClient = grpc.BidiStreamingClient[ValidateRequest, ValidateResponse]
+
+func (c *pkgnameClient) Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GenerateResponse)
+	err := c.cc.Invoke(ctx, Pkgname_Generate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pkgnameClient) History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoryResponse)
+	err := c.cc.Invoke(ctx, Pkgname_History_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PkgnameServer is the server API for Pkgname service.
+// All implementations must embed UnimplementedPkgnameServer
+// for forward compatibility.
+//
+// Pkgname tells if the name of a Go package is shit.
+type PkgnameServer interface {
+	// Validate checks a single name.
+	Validate(context.Context, *ValidateRequest) (*ValidateResponse, error)
+	// ValidateStream checks names as they are sent, answering each in order.
+	// A name that can't be checked is answered with an error, and the stream
+	// goes on.
+	ValidateStream(grpc.BidiStreamingServer[ValidateRequest, ValidateResponse]) error
+	// Generate picks a good name at random.
+	Generate(context.Context, *GenerateRequest) (*GenerateResponse, error)
+	// History lists the most recently recorded names, newest first.
+	History(context.Context, *HistoryRequest) (*HistoryResponse, error)
+	mustEmbedUnimplementedPkgnameServer()
+}
+
+// UnimplementedPkgnameServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPkgnameServer struct{}
+
+func (UnimplementedPkgnameServer) Validate(context.Context, *ValidateRequest) (*ValidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedPkgnameServer) ValidateStream(grpc.BidiStreamingServer[ValidateRequest, ValidateResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ValidateStream not implemented")
+}
+func (UnimplementedPkgnameServer) Generate(context.Context, *GenerateRequest) (*GenerateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Generate not implemented")
+}
+func (UnimplementedPkgnameServer) History(context.Context, *HistoryRequest) (*HistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method History not implemented")
+}
+func (UnimplementedPkgnameServer) mustEmbedUnimplementedPkgnameServer() {}
+func (UnimplementedPkgnameServer) testEmbeddedByValue()                 {}
+
+// UnsafePkgnameServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PkgnameServer will
+// result in compilation errors.
+type UnsafePkgnameServer interface {
+	mustEmbedUnimplementedPkgnameServer()
+}
+
+func RegisterPkgnameServer(s grpc.ServiceRegistrar, srv PkgnameServer) {
+	// If the following call pancis, it indicates UnimplementedPkgnameServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Pkgname_ServiceDesc, srv)
+}
+
+func _Pkgname_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PkgnameServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pkgname_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PkgnameServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pkgname_ValidateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PkgnameServer).ValidateStream(&grpc.GenericServerStream[ValidateRequest, ValidateResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Pkgname_ValidateStreamServer = grpc.BidiStreamingServer[ValidateRequest, ValidateResponse]
+
+func _Pkgname_Generate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PkgnameServer).Generate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pkgname_Generate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PkgnameServer).Generate(ctx, req.(*GenerateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pkgname_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PkgnameServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pkgname_History_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PkgnameServer).History(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Pkgname_ServiceDesc is the grpc.ServiceDesc for Pkgname service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Pkgname_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pkgname.v1.Pkgname",
+	HandlerType: (*PkgnameServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Validate",
+			Handler:    _Pkgname_Validate_Handler,
+		},
+		{
+			MethodName: "Generate",
+			Handler:    _Pkgname_Generate_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _Pkgname_History_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ValidateStream",
+			Handler:       _Pkgname_ValidateStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pkgname.proto",
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// rateLimit is how many requests a second each client can make on the
+	// endpoints that validate names or show them, on average. Disabled if
+	// zero.
+	rateLimit = 5.0
+	// rateBurst is how many requests a client can make at once before being
+	// held to rateLimit.
+	rateBurst = 20
+	// rateClients is how many clients are tracked at most. Past that, those
+	// who are back to a full bucket are forgotten, or else the one seen the
+	// longest ago.
+	rateClients = 10000
+	// trustProxy takes the client address from the last X-Forwarded-For
+	// entry, which is only right when a proxy we control sets it.
+	trustProxy = false
+)
+
+// bucket holds the tokens of a client, as of last.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter rate limits clients with a token bucket each: buckets fill up at
+// rate tokens a second up to burst, and every request takes one.
+type limiter struct {
+	rate  float64
+	burst float64
+	max   int
+	now   func() time.Time
+
+	lock    sync.Mutex
+	buckets map[string]*bucket
+}
+
+// newLimiter makes a limiter out of the settings, or nil if rate limiting
+// is disabled.
+func newLimiter() *limiter {
+	if rateLimit <= 0 {
+		return nil
+	}
+	return &limiter{
+		rate:    rateLimit,
+		burst:   math.Max(1, float64(rateBurst)),
+		max:     rateClients,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow takes a token from the bucket of key, or tells how long until
+// there's one.
+func (l *limiter) allow(key string) (bool, time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= l.max {
+			l.evict(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	l.refill(b, now)
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// wait takes a token from the bucket of key, waiting for one as long as ctx
+// lets it.
+func (l *limiter) wait(ctx context.Context, key string) error {
+	for {
+		ok, wait := l.allow(key)
+		if ok {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *limiter) refill(b *bucket, now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+	}
+	b.last = now
+}
+
+// evict forgets the clients whose bucket filled up again, since they start
+// over the same, or the one seen the longest ago if none did.
+func (l *limiter) evict(now time.Time) {
+	var oldest string
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+			continue
+		}
+		if oldest == "" || b.last.Before(l.buckets[oldest].last) {
+			oldest = key
+		}
+	}
+	if len(l.buckets) >= l.max {
+		delete(l.buckets, oldest)
+	}
+}
+
+// clientIP is who a request is from, as far as rate limiting goes.
+func clientIP(r *http.Request) string {
+	if trustProxy {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			hops := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	return hostOf(r.RemoteAddr)
+}
+
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// limit serves with h the clients that are under the limit, and the others
+// with tooMany. A nil limiter lets everything through.
+func limit(l *limiter, h http.Handler, tooMany func(w http.ResponseWriter)) http.Handler {
+	if l == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := l.allow(clientIP(r))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			tooMany(w)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+const rateLimitedMsg = "Too many requests, slow down."
+
+// Each kind of endpoint says it's rate limited in its own format.
+var (
+	tooManyAPI = func(w http.ResponseWriter) {
+		writeAPIError(w, &apiError{http.StatusTooManyRequests, "rate_limited", rateLimitedMsg})
+	}
+	tooManyLegacy = func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusTooManyRequests, rateLimitedMsg)
+	}
+	tooManyPage = func(w http.ResponseWriter) {
+		http.Error(w, rateLimitedMsg, http.StatusTooManyRequests)
+	}
+)
+
+// grpcOptions rate limit the gRPC calls by peer address, and the messages
+// of streams past the first.
+func (l *limiter) grpcOptions() []grpc.ServerOption {
+	if l == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := l.allowPeer(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := l.allowPeer(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, &limitedStream{ServerStream: ss, l: l})
+		}),
+	}
+}
+
+// limitedStream takes a token for every message it receives after the
+// first, so that a stream can't validate more names than as many calls. It
+// waits for them rather than failing, which holds back the client.
+type limitedStream struct {
+	grpc.ServerStream
+	l        *limiter
+	received int
+}
+
+func (s *limitedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.received++
+	if s.received == 1 {
+		return nil
+	}
+	if err := s.l.wait(s.Context(), peerKey(s.Context())); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
+
+// peerKey is who a gRPC call is from, as far as rate limiting goes.
+func peerKey(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return hostOf(p.Addr.String())
+	}
+	return ""
+}
+
+func (l *limiter) allowPeer(ctx context.Context) error {
+	if ok, wait := l.allow(peerKey(ctx)); !ok {
+		return status.Errorf(codes.ResourceExhausted, "%s Retry in %v.", rateLimitedMsg, wait.Round(time.Millisecond))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(rate float64, burst, max int) (*limiter, *time.Time) {
+	now := time.Unix(0, 0)
+	l := &limiter{rate: rate, burst: float64(burst), max: max, now: func() time.Time { return now }, buckets: make(map[string]*bucket)}
+	return l, &now
+}
+
+func TestLimiterBucket(t *testing.T) {
+	l, now := newTestLimiter(2, 3, 10)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("want request %d of the burst allowed", i)
+		}
+	}
+	ok, wait := l.allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("want to wait 500ms past the burst, got %v %v", ok, wait)
+	}
+	if ok, _ := l.allow("b"); !ok {
+		t.Fatalf("want other clients unaffected")
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("a"); !ok {
+		t.Fatalf("want a token back after 500ms")
+	}
+	if ok, _ := l.allow("a"); ok {
+		t.Fatalf("want a single token back after 500ms")
+	}
+
+	*now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("want the bucket to fill up to the burst only, denied at %d", i)
+		}
+	}
+	if ok, _ := l.allow("a"); ok {
+		t.Fatalf("want the bucket to fill up to the burst only")
+	}
+}
+
+func TestLimiterEvict(t *testing.T) {
+	l, now := newTestLimiter(1, 2, 2)
+
+	l.allow("a")
+	*now = now.Add(time.Millisecond)
+	l.allow("b")
+	*now = now.Add(time.Millisecond)
+	l.allow("c")
+	if _, ok := l.buckets["a"]; ok || len(l.buckets) != 2 {
+		t.Fatalf("want the client seen the longest ago forgotten, got %v", l.buckets)
+	}
+
+	*now = now.Add(time.Hour)
+	l.allow("d")
+	if len(l.buckets) != 1 {
+		t.Fatalf("want the clients with full buckets forgotten, got %v", l.buckets)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	defer func(trust bool) { trustProxy = trust }(trustProxy)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Add("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+	r.Header.Add("X-Forwarded-For", "3.3.3.3")
+
+	trustProxy = false
+	if got := clientIP(r); got != "10.0.0.1" {
+		t.Errorf("want the remote address, got %q", got)
+	}
+	trustProxy = true
+	if got := clientIP(r); got != "3.3.3.3" {
+		t.Errorf("want the last forwarded address, got %q", got)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	l, _ := newTestLimiter(1, 1, 10)
+	h := limit(l, http.NotFoundHandler(), tooManyAPI)
+
+	serve := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/history", nil))
+		return rr
+	}
+	if rr := serve(); rr.Code != http.StatusNotFound {
+		t.Fatalf("want the first request through, got %d", rr.Code)
+	}
+	rr := serve()
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "1" {
+		t.Fatalf("want the second request limited, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if want := `{"error":{"status":429,"code":"rate_limited","message":"Too many requests, slow down."}}`; rr.Body.String() != want {
+		t.Errorf("want %s, got %s", want, rr.Body.String())
+	}
+
+	if h := limit(nil, http.NotFoundHandler(), tooManyAPI); h == nil {
+		t.Errorf("want a nil limiter to let requests through")
+	}
+}
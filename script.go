@@ -0,0 +1,270 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aybabtme/pkgname/pkgname"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"time"
+)
+
+var (
+	// scriptMaxSteps is how many steps a script rule can take to check a
+	// name, unless it sets its own.
+	scriptMaxSteps = 100000
+	// scriptTimeout is how long a script rule has to check a name, unless it
+	// sets its own.
+	scriptTimeout = 50 * time.Millisecond
+)
+
+// scriptRules are the rules written as scripts in the config, checked on
+// top of the enabled ones by the default profile like customRules.
+var scriptRules []scriptRule
+
+// scriptRule is a rule written in Starlark, for the house rules a RuleDef
+// can't say. The script defines
+//
+//	def check(name, import_path, ctx):
+//
+// which returns None when the name is fine, or a message, or a list of
+// them, saying what's wrong with it. The import path is empty when the
+// request didn't give it. ctx has the rule id, the params of the rule and
+// the mean_length and stdev_length of the names of the corpus.
+//
+// Scripts can't load anything, nor loop forever: they're stopped after
+// MaxSteps or Timeout, and the name passes.
+type scriptRule struct {
+	ID string `toml:"id"`
+	// File is where the script is, or Source is the script itself.
+	File   string `toml:"file"`
+	Source string `toml:"source"`
+	// Params are handed to the script as ctx.params.
+	Params map[string]any `toml:"params"`
+	// Severity is error, the default, or warning.
+	Severity string        `toml:"severity"`
+	MaxSteps int           `toml:"max_steps"`
+	Timeout  time.Duration `toml:"timeout"`
+}
+
+// scriptOptions are the dialect of the scripts, which has sets but no while
+// loops nor recursion.
+var scriptOptions = &syntax.FileOptions{Set: true}
+
+// compile makes the rule s is, with the length stats of the corpus, or
+// tells everything that's wrong with it.
+func (s scriptRule) compile(mean, stdev float64) (pkgname.Rule, error) {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch {
+	case s.ID == "":
+		fail("id is missing")
+	case !slug.MatchString(s.ID):
+		fail("id %q isn't made of lowercase letters, digits and hyphens", s.ID)
+	case contains(pkgname.RuleIDs(), s.ID):
+		fail("id %q is taken by a builtin rule", s.ID)
+	}
+	severity, err := pkgname.ParseSeverity(s.Severity)
+	if err != nil {
+		fail("%v", err)
+	}
+	if s.MaxSteps < 0 {
+		fail("max_steps can't be negative")
+	}
+	if s.Timeout < 0 {
+		fail("timeout can't be negative")
+	}
+	params, err := toStarlark(s.Params)
+	if err != nil {
+		fail("params: %v", err)
+	}
+
+	var src []byte
+	switch {
+	case s.File != "" && s.Source != "":
+		fail("set file or source, not both")
+	case s.File != "":
+		if src, err = os.ReadFile(s.File); err != nil {
+			fail("%v", err)
+		}
+	case s.Source != "":
+		src = []byte(s.Source)
+	default:
+		fail("set the file of the script, or its source")
+	}
+	if len(errs) > 0 {
+		return pkgname.Rule{}, errors.Join(errs...)
+	}
+
+	filename := s.File
+	if filename == "" {
+		filename = s.ID + ".star"
+	}
+	thread, stop := s.thread(true)
+	globals, err := starlark.ExecFileOptions(scriptOptions, thread, filename, src, nil)
+	stop()
+	if err != nil {
+		return pkgname.Rule{}, scriptError(err)
+	}
+	check, ok := globals["check"].(*starlark.Function)
+	if !ok || check.NumParams() != 3 || check.HasVarargs() || check.HasKwargs() {
+		return pkgname.Rule{}, errors.New("the script doesn't define check(name, import_path, ctx)")
+	}
+	globals.Freeze()
+
+	ctx := starlarkstruct.FromStringDict(starlark.String("ctx"), starlark.StringDict{
+		"rule":         starlark.String(s.ID),
+		"params":       params,
+		"mean_length":  starlark.Float(mean),
+		"stdev_length": starlark.Float(stdev),
+	})
+	ctx.Freeze()
+
+	filter := func(name, importPath string) error {
+		// The names checked may not be recorded, so neither they nor what
+		// the script prints about them end up in the logs.
+		thread, stop := s.thread(false)
+		defer stop()
+		v, err := starlark.Call(thread, check, starlark.Tuple{starlark.String(name), starlark.String(importPath), ctx}, nil)
+		if err == nil {
+			var msgs []string
+			if msgs, err = scriptMessages(v); err == nil {
+				var errs []error
+				for _, msg := range msgs {
+					errs = append(errs, errors.New(msg))
+				}
+				return errors.Join(errs...)
+			}
+		}
+		slog.Warn("Script rule failed, letting the name through", "rule", s.ID, "err", scriptError(err))
+		return nil
+	}
+	return pkgname.Rule{
+		ID:         s.ID,
+		Filter:     func(name string) error { return filter(name, "") },
+		PathFilter: filter,
+		Severity:   severity,
+	}, nil
+}
+
+// thread is a thread to run s on within its budget, and what stops its
+// clock once it's done. What the script prints is logged if logPrints is
+// set, and dropped otherwise.
+func (s scriptRule) thread(logPrints bool) (*starlark.Thread, func() bool) {
+	steps, timeout := s.MaxSteps, s.Timeout
+	if steps == 0 {
+		steps = scriptMaxSteps
+	}
+	if timeout == 0 {
+		timeout = scriptTimeout
+	}
+	thread := &starlark.Thread{
+		Name: s.ID,
+		Print: func(_ *starlark.Thread, msg string) {
+			if logPrints {
+				slog.Debug("Script rule printed", "rule", s.ID, "msg", msg)
+			}
+		},
+	}
+	thread.Load = func(*starlark.Thread, string) (starlark.StringDict, error) {
+		return nil, errors.New("scripts can't load anything")
+	}
+	thread.SetMaxExecutionSteps(uint64(steps))
+	timer := time.AfterFunc(timeout, func() { thread.Cancel("out of time") })
+	return thread, timer.Stop
+}
+
+// scriptError is err with the backtrace of the script, if it has one.
+func scriptError(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(evalErr.Backtrace())
+	}
+	return err
+}
+
+// scriptMessages are the messages check returned as v.
+func scriptMessages(v starlark.Value) ([]string, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.String:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{string(v)}, nil
+	case *starlark.List, starlark.Tuple:
+		list := v.(starlark.Indexable)
+		var msgs []string
+		for i := 0; i < list.Len(); i++ {
+			item := list.Index(i)
+			msg, ok := item.(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("check returned a %s in its messages, not a string", item.Type())
+			}
+			if msg != "" {
+				msgs = append(msgs, string(msg))
+			}
+		}
+		return msgs, nil
+	}
+	return nil, fmt.Errorf("check returned a %s, not None, a string or a list of strings", v.Type())
+}
+
+// toStarlark is the Starlark value of v, as decoded from TOML.
+func toStarlark(v any) (starlark.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case []any:
+		list := make([]starlark.Value, len(v))
+		for i, item := range v {
+			var err error
+			if list[i], err = toStarlark(item); err != nil {
+				return nil, err
+			}
+		}
+		return starlark.NewList(list), nil
+	case map[string]any:
+		dict := starlark.NewDict(len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			item, err := toStarlark(v[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			dict.SetKey(starlark.String(key), item)
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("can't hand a %T to a script", v)
+}
+
+// compileScripts makes the rules of scripts, with the length stats of the
+// corpus.
+func compileScripts(scripts []scriptRule, mean, stdev float64) ([]pkgname.Rule, error) {
+	var rules []pkgname.Rule
+	for _, s := range scripts {
+		rule, err := s.compile(mean, stdev)
+		if err != nil {
+			return nil, fmt.Errorf("script %s: %v", s.ID, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
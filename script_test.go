@@ -0,0 +1,156 @@
+package main
+
+import (
+	"github.com/aybabtme/pkgname/pkgname"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const matchesPath = `
+def check(name, import_path, ctx):
+    if not import_path:
+        return None
+    last = import_path.split("/")[-1]
+    if last != name and last not in ctx.params["aliases"].get(name, []):
+        return "Name your package %s, like its directory." % last
+`
+
+func TestScriptRule(t *testing.T) {
+	s := scriptRule{
+		ID:     "matches-path",
+		Source: matchesPath,
+		Params: map[string]any{"aliases": map[string]any{"yaml": []any{"yaml.v3"}}},
+	}
+	rule, err := s.compile(7, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, importPath string
+		want             []string
+	}{
+		{"web", "", nil},
+		{"web", "example.com/web", nil},
+		{"yaml", "gopkg.in/yaml.v3", nil},
+		{"web", "example.com/server", []string{"Name your package server, like its directory."}},
+	}
+	for _, tt := range tests {
+		got := pkgname.Validate(tt.name, &pkgname.Options{Rules: []pkgname.Rule{rule}, ImportPath: tt.importPath})
+		if msgs := causes(got); !reflect.DeepEqual(msgs, tt.want) {
+			t.Errorf("%q at %q: want %q, got %q", tt.name, tt.importPath, tt.want, msgs)
+		}
+	}
+}
+
+func TestScriptRuleReturns(t *testing.T) {
+	tests := []struct {
+		ret  string
+		want []string
+	}{
+		{`None`, nil},
+		{`""`, nil},
+		{`[]`, nil},
+		{`"Nope."`, []string{"Nope."}},
+		{`["Nope.", "", "Still nope."]`, []string{"Nope.", "Still nope."}},
+		{`("Nope.",)`, []string{"Nope."}},
+		{`ctx.rule + " " + str(ctx.mean_length)`, []string{"returns 7.0"}},
+		// Scripts that fail let the name through.
+		{`42`, nil},
+		{`[42]`, nil},
+		{`1 // 0`, nil},
+	}
+	for _, tt := range tests {
+		s := scriptRule{ID: "returns", Source: "def check(name, import_path, ctx):\n    return " + tt.ret + "\n"}
+		rule, err := s.compile(7, 2)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.ret, err)
+		}
+		got := causes(pkgname.Validate("web", &pkgname.Options{Rules: []pkgname.Rule{rule}}))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: want %q, got %q", tt.ret, tt.want, got)
+		}
+	}
+}
+
+func TestScriptRuleBudget(t *testing.T) {
+	tests := []scriptRule{
+		{ID: "steps", Source: "def check(name, import_path, ctx):\n    for i in range(1000000):\n        pass\n    return 'Nope.'\n"},
+		{ID: "time", Timeout: time.Millisecond, MaxSteps: 1 << 40, Source: "def check(name, import_path, ctx):\n    for i in range(1 << 40):\n        pass\n    return 'Nope.'\n"},
+	}
+	for _, s := range tests {
+		rule, err := s.compile(0, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", s.ID, err)
+		}
+		start := time.Now()
+		if got := pkgname.Validate("web", &pkgname.Options{Rules: []pkgname.Rule{rule}}); len(got) != 0 {
+			t.Errorf("%s: want the name through once out of budget, got %v", s.ID, got)
+		}
+		if took := time.Since(start); took > 5*time.Second {
+			t.Errorf("%s: want the script stopped, it ran %v", s.ID, took)
+		}
+	}
+}
+
+func TestScriptRuleKeepsSecrets(t *testing.T) {
+	logs := captureLogs(t, "debug")
+	s := scriptRule{ID: "leaky", Source: "print('loaded')\ndef check(name, import_path, ctx):\n    print(name, import_path)\n    return 1 // 0\n"}
+	rule, err := s.compile(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgname.Validate("codename", &pkgname.Options{Rules: []pkgname.Rule{rule}, ImportPath: "example.com/codename"})
+
+	if out := logs.String(); strings.Contains(out, "codename") || !strings.Contains(out, "loaded") || !strings.Contains(out, "Script rule failed") {
+		t.Errorf("want the failure logged without the name, got %s", out)
+	}
+}
+
+func TestScriptRuleErrors(t *testing.T) {
+	check := "def check(name, import_path, ctx):\n    return None\n"
+	tests := []struct {
+		rule scriptRule
+		want string
+	}{
+		{scriptRule{Source: check}, "id is missing"},
+		{scriptRule{ID: "No", Source: check}, "lowercase"},
+		{scriptRule{ID: pkgname.RuleNoHyphens, Source: check}, "builtin"},
+		{scriptRule{ID: "s", Source: check, Severity: "fatal"}, "severity"},
+		{scriptRule{ID: "s", Source: check, MaxSteps: -1}, "max_steps"},
+		{scriptRule{ID: "s", Source: check, Params: map[string]any{"when": time.Now()}}, "params"},
+		{scriptRule{ID: "s"}, "set the file"},
+		{scriptRule{ID: "s", Source: check, File: "check.star"}, "not both"},
+		{scriptRule{ID: "s", File: "missing.star"}, "missing.star"},
+		{scriptRule{ID: "s", Source: "def check(name):\n    return None\n"}, "doesn't define check"},
+		{scriptRule{ID: "s", Source: "check = None\n"}, "doesn't define check"},
+		{scriptRule{ID: "s", Source: "def check(name, import_path, ctx)\n"}, "s.star:2"},
+		{scriptRule{ID: "s", Source: "load('os.star', 'os')\n" + check}, "can't load"},
+		{scriptRule{ID: "s", Source: "def check(name, import_path, ctx):\n    while True:\n        pass\n"}, "while"},
+	}
+	for _, tt := range tests {
+		_, err := tt.rule.compile(0, 0)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: want an error about %q, got %v", tt.rule, tt.want, err)
+		}
+	}
+}
+
+func TestScriptRuleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matches_path.star")
+	if err := os.WriteFile(path, []byte(matchesPath), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rule, err := scriptRule{ID: "matches-path", File: path, Params: map[string]any{"aliases": map[string]any{}}, Severity: "warning"}.compile(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := pkgname.Validate("web", &pkgname.Options{Rules: []pkgname.Rule{rule}, ImportPath: "example.com/server"})
+	if len(got) != 1 || got[0].Rule != "matches-path" || !pkgname.Passed(got) {
+		t.Errorf("want a warning from the script, got %+v", got)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// readHeaderTimeout is how long clients have to send the headers of a
+	// request, so that slow ones can't hold on to connections.
+	readHeaderTimeout = 5 * time.Second
+	// readTimeout is how long clients have to send a whole request.
+	readTimeout = 30 * time.Second
+	// writeTimeout is how long we have to send a whole response.
+	writeTimeout = 60 * time.Second
+	// idleTimeout is how long idle keep-alive connections are kept.
+	idleTimeout = 120 * time.Second
+	// shutdownDelay is how long the server keeps serving once it's told to
+	// stop while /readyz fails, so that load balancers stop sending it
+	// requests first.
+	shutdownDelay time.Duration
+	// shutdownTimeout is how long requests in flight get to finish once the
+	// server stops.
+	shutdownTimeout = 30 * time.Second
+)
+
+func newHTTPServer(h http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
+
+// readiness tells if the server can take requests: when the names are
+// loaded and the rules built, and until it's draining to stop.
+type readiness struct {
+	lock     sync.RWMutex
+	db       *DB
+	draining bool
+}
+
+func (rd *readiness) setDB(db *DB) {
+	rd.lock.Lock()
+	defer rd.lock.Unlock()
+	rd.db = db
+}
+
+func (rd *readiness) drain() {
+	rd.lock.Lock()
+	defer rd.lock.Unlock()
+	rd.draining = true
+}
+
+// check tells why the server isn't ready, if it isn't.
+func (rd *readiness) check() error {
+	rd.lock.RLock()
+	defer rd.lock.RUnlock()
+	switch {
+	case rd.draining:
+		return errors.New("shutting down")
+	case rd.db == nil:
+		return errors.New("loading names")
+	}
+	info := rd.db.Info()
+	switch {
+	case info.Corpus == 0:
+		return errors.New("no names loaded")
+	case info.Rules == 0:
+		return errors.New("no rules built")
+	}
+	return nil
+}
+
+// health answers the probes of /healthz and /readyz, and leaves the other
+// requests to app. Probes aren't logged, they'd drown the rest.
+func health(rd *readiness, app http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/healthz":
+			probe(w, nil)
+		case "/readyz":
+			probe(w, rd.check())
+		default:
+			app.ServeHTTP(w, r)
+		}
+	}
+}
+
+func probe(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(err.Error() + "\n"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// pending serves with its handler once it's set, and tells clients to come
+// back later until then.
+type pending struct {
+	lock sync.RWMutex
+	h    http.Handler
+}
+
+func (p *pending) set(h http.Handler) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.h = h
+}
+
+func (p *pending) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.lock.RLock()
+	h := p.h
+	p.lock.RUnlock()
+	if h == nil {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Starting up.", http.StatusServiceUnavailable)
+		return
+	}
+	h.ServeHTTP(w, r)
+}
+
+// shutdown stops the servers gracefully: they stop accepting connections
+// and wait for the requests in flight, until ctx is done.
+func shutdown(ctx context.Context, servers []*http.Server, rpc *grpc.Server) {
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				slog.Warn("Couldn't drain connections in time", "err", err)
+				_ = srv.Close()
+			}
+		}(srv)
+	}
+
+	if rpc != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stopped := make(chan struct{})
+			go func() {
+				rpc.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				slog.Warn("Couldn't drain gRPC calls in time")
+				rpc.Stop()
+			}
+		}()
+	}
+	wg.Wait()
+}
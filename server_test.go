@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	rd := &readiness{}
+	h := health(rd, http.NotFoundHandler())
+
+	probe := func(path string) (int, string) {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", path, nil))
+		return rr.Code, strings.TrimSpace(rr.Body.String())
+	}
+
+	if code, _ := probe("/healthz"); code != http.StatusOK {
+		t.Errorf("want healthy while starting, got %d", code)
+	}
+	if code, body := probe("/readyz"); code != http.StatusServiceUnavailable || body != "loading names" {
+		t.Errorf("want not ready while loading, got %d %q", code, body)
+	}
+
+	rd.setDB(NewDB())
+	if code, body := probe("/readyz"); code != http.StatusOK || body != "ok" {
+		t.Errorf("want ready, got %d %q", code, body)
+	}
+
+	rd.drain()
+	if code, body := probe("/readyz"); code != http.StatusServiceUnavailable || body != "shutting down" {
+		t.Errorf("want not ready while draining, got %d %q", code, body)
+	}
+	if code, _ := probe("/healthz"); code != http.StatusOK {
+		t.Errorf("want healthy while draining, got %d", code)
+	}
+
+	if code, _ := probe("/other"); code != http.StatusNotFound {
+		t.Errorf("want other paths left to the app, got %d", code)
+	}
+}
+
+func TestReadinessNeedsNames(t *testing.T) {
+	rd := &readiness{db: &DB{goods: newQueue(1), bads: newQueue(1)}}
+	if err := rd.check(); err == nil || err.Error() != "no names loaded" {
+		t.Errorf("want no names, got %v", err)
+	}
+}
+
+func TestPending(t *testing.T) {
+	p := &pending{}
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("want 503 while starting, got %d", rr.Code)
+	}
+
+	p.set(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("up")) }))
+	rr = httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Body.String() != "up" {
+		t.Errorf("want the app, got %d %q", rr.Code, rr.Body)
+	}
+}
+
+func TestShutdownDrains(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := newHTTPServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	}))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+
+	type result struct {
+		body string
+		err  error
+	}
+	res := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			res <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		res <- result{string(body), err}
+	}()
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		shutdown(context.Background(), []*http.Server{srv}, nil)
+		close(stopped)
+	}()
+
+	// New connections are refused while the request in flight finishes.
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("want the listener closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-stopped:
+		t.Fatal("want shutdown to wait for the request")
+	default:
+	}
+
+	close(release)
+	if r := <-res; r.err != nil || r.body != "done" {
+		t.Errorf("want the request to finish, got %q %v", r.body, r.err)
+	}
+	<-stopped
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	srv := newHTTPServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	go http.Get("http://" + l.Addr().String())
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		shutdown(ctx, []*http.Server{srv}, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want shutdown to give up on stuck requests")
+	}
+}
+
+func TestSlowHeadersTimeOut(t *testing.T) {
+	defer func(old time.Duration) { readHeaderTimeout = old }(readHeaderTimeout)
+	readHeaderTimeout = 50 * time.Millisecond
+
+	srv := newHTTPServer(http.NotFoundHandler())
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server gives up on us rather than waiting for the rest.
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Errorf("want the connection closed by the server, got %v", err)
+	}
+}
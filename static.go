@@ -2,67 +2,116 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/md5"
+	"embed"
 	"encoding/hex"
 	"fmt"
-	"io"
-	"log"
+	"github.com/andybalholm/brotli"
+	"io/fs"
+	"log/slog"
 	"mime"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+)
+
+// embedded holds the static assets, so the binary can run from anywhere.
+//
+//go:embed static
+var embedded embed.FS
+
+func staticFS() fs.FS {
+	sub, err := fs.Sub(embedded, "static")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Content-Encodings the assets are available in, by order of preference.
+const (
+	encBrotli   = "br"
+	encGzip     = "gzip"
+	encIdentity = "identity"
 )
 
 type asset struct {
 	md5hex   string
 	mimetype string
-	content  []byte
-}
-
-func (a *asset) Reader() io.Reader {
-	return bytes.NewBuffer(a.content)
+	modtime  time.Time
+	// fingerprinted is the path of the asset with its hash in it, so it can
+	// be cached forever.
+	fingerprinted string
+	// encoded holds the content under each encoding it's available in. It
+	// always has the identity encoding, and the compressed ones only when
+	// they are smaller.
+	encoded map[string][]byte
 }
 
 type staticDB struct {
-	basePath string
-	assets   map[string]*asset
-	lock     sync.RWMutex
+	assets map[string]*asset
+	lock   sync.RWMutex
 }
 
-func (s *staticDB) Put(file *os.File, size int) error {
+// Put adds an asset, compressing it ahead of time so that requests don't
+// pay for it. Unless it's a page, the asset is also available under a
+// fingerprinted path.
+func (s *staticDB) Put(name string, data []byte, modtime time.Time) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	if _, ok := s.assets[file.Name()]; ok {
-		return fmt.Errorf("file already known, %q", file.Name())
-	}
-
-	buf := bytes.NewBuffer(nil)
-
-	_, err := io.Copy(buf, file)
-	if err != nil {
-		return err
+	if _, ok := s.assets[name]; ok {
+		return fmt.Errorf("file already known, %q", name)
 	}
 
-	data := buf.Bytes()
-
-	mimetype := mime.TypeByExtension(filepath.Ext(file.Name()))
+	mimetype := mime.TypeByExtension(path.Ext(name))
 	if mimetype == "" {
-		log.Printf("[INFO] Couldn't detect mimetype from exntension, sniffing content: %q", file.Name())
+		slog.Debug("Couldn't detect mimetype from extension, sniffing content", "name", name)
 		mimetype = http.DetectContentType(data)
 	}
-	log.Printf("[INFO] Mimetype of %q: %q", file.Name(), mimetype)
+	slog.Debug("Detected mimetype", "name", name, "mimetype", mimetype)
 
 	h := md5.New()
 	_, _ = h.Write(data)
 
-	s.assets[file.Name()] = &asset{
+	a := &asset{
 		md5hex:   hex.EncodeToString(h.Sum(nil)),
-		content:  data,
 		mimetype: mimetype,
+		modtime:  modtime,
+		encoded:  map[string][]byte{encIdentity: data},
+	}
+	if !isPage(name) {
+		ext := path.Ext(name)
+		a.fingerprinted = strings.TrimSuffix(name, ext) + "." + a.md5hex[:12] + ext
+		if _, ok := s.assets[a.fingerprinted]; ok {
+			return fmt.Errorf("fingerprinted file already known, %q", a.fingerprinted)
+		}
+	}
+
+	gzipped, err := gzipBytes(data)
+	if err != nil {
+		return fmt.Errorf("gzipping %q, %v", name, err)
+	}
+	brotlied, err := brotliBytes(data)
+	if err != nil {
+		return fmt.Errorf("brotli compressing %q, %v", name, err)
+	}
+	for enc, compressed := range map[string][]byte{encGzip: gzipped, encBrotli: brotlied} {
+		if len(compressed) < len(data) {
+			a.encoded[enc] = compressed
+		}
 	}
 
+	s.assets[name] = a
+	if a.fingerprinted != "" {
+		s.assets[a.fingerprinted] = a
+	}
 	return nil
 }
 
@@ -74,64 +123,321 @@ func (s *staticDB) Get(path string) (*asset, bool) {
 	return a, ok
 }
 
-func staticHandler(path string) (http.HandlerFunc, error) {
+// URL is the path to use when referring to the asset called name, which
+// is fingerprinted when possible.
+func (s *staticDB) URL(name string) (string, bool) {
+	a, ok := s.Get(name)
+	if !ok {
+		return "", false
+	}
+	if a.fingerprinted != "" {
+		return "/" + a.fingerprinted, true
+	}
+	return "/" + name, true
+}
+
+func isPage(name string) bool {
+	ext := path.Ext(name)
+	return ext == ".html" || ext == ".htm"
+}
+
+var assetRef = regexp.MustCompile(`(?i)\b(href|src)=("[^"]*"|'[^']*')`)
+
+// fingerprintRefs rewrites the references a page makes to assets so that
+// they point to the fingerprinted assets.
+func (s *staticDB) fingerprintRefs(page string, data []byte) []byte {
+	return assetRef.ReplaceAllFunc(data, func(attr []byte) []byte {
+		m := assetRef.FindSubmatch(attr)
+		quote, ref := m[2][:1], string(m[2][1:len(m[2])-1])
+
+		if ref == "" || strings.Contains(ref, ":") || strings.HasPrefix(ref, "//") ||
+			strings.ContainsAny(ref, "?#") {
+			return attr
+		}
+
+		var name string
+		if strings.HasPrefix(ref, "/") {
+			name = path.Clean(ref)[1:]
+		} else {
+			name = path.Join(path.Dir(page), ref)
+		}
+
+		url, ok := s.URL(name)
+		if !ok {
+			return attr
+		}
+		return []byte(string(m[1]) + "=" + string(quote) + url + string(quote))
+	})
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	w, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func brotliBytes(data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	w := brotli.NewWriterLevel(buf, brotli.BestCompression)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// negotiateEncoding picks the Content-Encoding to respond with among
+// those available, according to an Accept-Encoding header, or "" if the
+// client refuses them all, identity included.
+func negotiateEncoding(acceptEncoding string, available map[string][]byte) string {
+	qs := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				if q, err = strconv.ParseFloat(param[2:], 64); err != nil {
+					q = 0
+				}
+			}
+		}
+		qs[coding] = q
+	}
+
+	quality := func(coding string) float64 {
+		if q, ok := qs[coding]; ok {
+			return q
+		}
+		if q, ok := qs["*"]; ok {
+			return q
+		}
+		if coding == encIdentity {
+			// Identity is acceptable unless said otherwise.
+			return 0.001
+		}
+		return 0
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{encBrotli, encGzip, encIdentity} {
+		if _, ok := available[coding]; !ok {
+			continue
+		}
+		if q := quality(coding); q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+var loadTime = time.Now().Truncate(time.Second)
+
+// loadStatic puts all the files of fsys in a staticDB.
+func loadStatic(fsys fs.FS) (*staticDB, error) {
 	static := &staticDB{
-		basePath: path,
-		assets:   make(map[string]*asset),
+		assets: make(map[string]*asset),
 	}
 
-	err := filepath.Walk(path, func(name string, fi os.FileInfo, err error) error {
+	type page struct {
+		name string
+		d    fs.DirEntry
+	}
+	var pages []page
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if fi.IsDir() {
+		if d.IsDir() {
 			return err
 		}
 
-		log.Printf("[INFO] StaticDB: Considering %q", name)
+		// Pages are added last, once the assets they refer to are
+		// fingerprinted.
+		if isPage(name) {
+			pages = append(pages, page{name, d})
+			return nil
+		}
 
-		file, err := os.Open(name)
+		return putFile(static, fsys, name, d)
+	})
+	for _, p := range pages {
 		if err != nil {
-			return fmt.Errorf("opening file for static DB, %v", err)
+			break
 		}
+		err = putFile(static, fsys, p.name, p.d)
+	}
 
-		if err := static.Put(file, int(fi.Size())); err != nil {
-			return fmt.Errorf("putting file in static DB, %v", err)
+	return static, err
+}
+
+func (s *staticDB) serve(w http.ResponseWriter, r *http.Request) {
+	name, ok := s.resolve(r.URL.Path)
+	if !ok {
+		// Directories are only known by their index, and only with a
+		// trailing slash.
+		if index := path.Join(name, "index.html"); name != "" && !isDir(r.URL.Path) {
+			if _, ok := s.Get(index); ok {
+				target := "/" + name + "/"
+				if r.URL.RawQuery != "" {
+					target += "?" + r.URL.RawQuery
+				}
+				http.Redirect(w, r, target, http.StatusMovedPermanently)
+				return
+			}
 		}
 
-		log.Printf("[INFO] StaticDB: Added to DB, %q", name)
+		s.notFound(w, r)
+		return
+	}
 
-		return err
-	})
+	asset, _ := s.Get(name)
+	enc := s.setHeaders(w, r, asset)
+	if enc == "" {
+		http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+		return
+	}
+	if name == asset.fingerprinted {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
 
-	return func(w http.ResponseWriter, r *http.Request) {
+	// Takes care of conditional requests, HEAD and ranges.
+	http.ServeContent(w, r, "", asset.modtime, bytes.NewReader(asset.encoded[enc]))
+}
 
-		var path string
-		if r.URL.Path == "/" {
-			path = static.basePath + "index.html"
-		} else {
-			path = static.basePath + r.URL.Path[1:]
-		}
+// resolve maps a URL path to the name of an asset, which is also where a
+// missing asset would be. The path is cleaned first, so it can't escape
+// the assets, and directories resolve to their index.html.
+func (s *staticDB) resolve(urlPath string) (name string, ok bool) {
+	if strings.ContainsAny(urlPath, "\\\x00") {
+		return "", false
+	}
 
-		asset, ok := static.Get(path)
-		if !ok {
-			http.NotFound(w, r)
-			log.Printf("[INFO] Not found.")
-			return
-		}
+	name = strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" || isDir(urlPath) {
+		name = path.Join(name, "index.html")
+	}
 
-		for _, etag := range r.Header["If-None-Match"] {
-			if etag == asset.md5hex {
-				w.WriteHeader(http.StatusNotModified)
-				log.Printf("[Info] Not modified")
-				return
-			}
+	_, ok = s.Get(name)
+	return name, ok
+}
+
+func isDir(urlPath string) bool {
+	return strings.HasSuffix(urlPath, "/")
+}
+
+// setHeaders prepares the response for an asset, and tells under which
+// encoding it's to be sent, "" if none is acceptable.
+func (s *staticDB) setHeaders(w http.ResponseWriter, r *http.Request, asset *asset) string {
+	enc := negotiateEncoding(r.Header.Get("Accept-Encoding"), asset.encoded)
+	w.Header().Add("Vary", "Accept-Encoding")
+	if enc == "" {
+		return enc
+	}
+	etag := asset.md5hex
+	if enc != encIdentity {
+		etag += "-" + enc
+	}
+
+	h := w.Header()
+	h.Set("ETag", strconv.Quote(etag))
+	h.Set("Content-Type", asset.mimetype)
+	if enc != encIdentity {
+		h.Set("Content-Encoding", enc)
+	}
+	return enc
+}
+
+// notFound answers with the 404.html page if there's one.
+func (s *staticDB) notFound(w http.ResponseWriter, r *http.Request) {
+	asset, ok := s.Get("404.html")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	enc := s.setHeaders(w, r, asset)
+	if enc == "" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Del("ETag")
+	w.Header().Set("Cache-Control", "no-cache")
+	content := asset.encoded[enc]
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusNotFound)
+	if r.Method != "HEAD" {
+		_, _ = w.Write(content)
+	}
+}
+
+// noListing is a http.FileSystem that hides directories without an index,
+// so that http.FileServer doesn't list them.
+type noListing struct {
+	fs http.FileSystem
+}
+
+func (n noListing) Open(name string) (http.File, error) {
+	f, err := n.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if fi.IsDir() {
+		index, err := n.fs.Open(path.Join(name, "index.html"))
+		if err != nil {
+			_ = f.Close()
+			return nil, os.ErrNotExist
 		}
+		_ = index.Close()
+	}
+	return f, nil
+}
 
-		w.Header().Set("ETag", asset.md5hex)
-		w.Header().Set("Content-Type", asset.mimetype)
-		io.Copy(w, asset.Reader())
+func putFile(static *staticDB, fsys fs.FS, name string, d fs.DirEntry) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("reading file for static DB, %v", err)
+	}
+	if isPage(name) {
+		data = static.fingerprintRefs(name, data)
+	}
+
+	// Embedded files have no modification time, but they can't change
+	// while we run either.
+	modtime := loadTime
+	if fi, err := d.Info(); err == nil && !fi.ModTime().IsZero() {
+		modtime = fi.ModTime()
+	}
 
-	}, err
+	if err := static.Put(name, data, modtime); err != nil {
+		return fmt.Errorf("putting file in static DB, %v", err)
+	}
+
+	slog.Debug("Added static asset", "name", name, "size", len(data))
+	return nil
 }
@@ -1,123 +1,132 @@
-$(function() {
-  var main = $('.main');
-  var pkgnameField = $('#pkgname');
-  var pkgnameForm = $('#pkgnameform');
-  var validMessage = $('#validmessage');
-  var invalidMessage = $('#invalidmessage');
-  var historyMessage = $('#historymessage');
-
-  function hideMessages() {
-    validMessage.hide();
-    invalidMessage.hide();
-    invalidMessage.find('ul').empty();
-    historyMessage.hide();
-    historyMessage.find('ul').empty();
+document.addEventListener('DOMContentLoaded', function() {
+  var pkgnameField = document.getElementById('pkgname');
+  var recordField = document.getElementById('record');
+  var toneField = document.getElementById('tone');
+  var langField = document.getElementById('lang');
+  var pkgnameForm = document.getElementById('pkgnameform');
+  var validMessage = document.getElementById('validmessage');
+  var invalidMessage = document.getElementById('invalidmessage');
+  var historyMessage = document.getElementById('historymessage');
+  var errorMessage = document.getElementById('errormessage');
+
+  function empty(el) {
+    while (el.firstChild) el.removeChild(el.firstChild);
   }
 
-  function showValidPkgname(name) {
-    validMessage.find('.name')
-      .attr('href', '/?pkgname=' + encodeURIComponent(name))
-      .text(name);
-    validMessage.show();
+  function fill(ul, items) {
+    items.forEach(function(item) {
+      var li = document.createElement('li');
+      li.textContent = item;
+      ul.appendChild(li);
+    });
   }
 
-  function showInvalidPkgname(name, causes) {
-    var ul = invalidMessage.find('ul');
+  function setName(message, name) {
+    var link = message.querySelector('.name');
+    link.setAttribute('href', '/?pkgname=' + encodeURIComponent(name));
+    link.textContent = name;
+  }
 
-    causes.forEach(function(cause) {
-      var li = document.createElement('li');
-      $(li).text(cause);
-      ul.append(li);
+  function hideMessages() {
+    [validMessage, invalidMessage, historyMessage, errorMessage].forEach(function(message) {
+      message.classList.remove('shown');
+      Array.prototype.forEach.call(message.querySelectorAll('ul'), empty);
     });
+  }
 
-    invalidMessage.find('.name')
-      .attr('href', '/?pkgname=' + encodeURIComponent(name))
-      .text(name);
-    invalidMessage.show();
+  function showValidPkgname(name, warnings) {
+    fill(validMessage.querySelector('.warnings'), warnings);
+    setName(validMessage, name);
+    validMessage.classList.add('shown');
+  }
+
+  function showInvalidPkgname(name, causes, warnings) {
+    fill(invalidMessage.querySelector('.causes'), causes);
+    fill(invalidMessage.querySelector('.warnings'), warnings);
+    setName(invalidMessage, name);
+    invalidMessage.classList.add('shown');
+  }
+
+  function showError(error) {
+    errorMessage.querySelector('.error').textContent = error;
+    errorMessage.classList.add('shown');
   }
 
   function afterValidate(data) {
     hideMessages();
 
     if (data.error != '') {
-      console.log(data.error);
+      showError(data.error);
       return;
     }
 
+    var causes = [], warnings = [];
+    (data.violations || []).forEach(function(violation) {
+      (violation.severity === 'warning' ? warnings : causes).push(violation.message);
+    });
+
     if (data.success === false) {
-      showInvalidPkgname(data.pkgname, data.causes);
+      showInvalidPkgname(data.pkgname, causes, warnings);
     } else {
-      showValidPkgname(data.pkgname);
+      showValidPkgname(data.pkgname, warnings);
     }
   }
 
   function afterHistory(data) {
     hideMessages();
 
-    var ul = historyMessage.find('ul');
-    var badsUl = ul.first();
-    var goodsUl = ul.last();
+    if (data.error) {
+      showError(data.error);
+      return;
+    }
 
-    data.bads.forEach(function(bad) {
-      var li = document.createElement('li');
-      $(li).text(bad);
-      badsUl.append(li);
-    });
+    var uls = historyMessage.querySelectorAll('ul');
+    fill(uls[0], data.bads);
+    fill(uls[uls.length - 1], data.goods);
 
-    data.goods.forEach(function(good) {
-      var li = document.createElement('li');
-      $(li).text(good);
-      goodsUl.append(li);
-    });
+    historyMessage.classList.add('shown');
+  }
 
-    historyMessage.show();
+  function request(method, url, body, done) {
+    var xhr = new XMLHttpRequest();
+    xhr.open(method, url);
+    xhr.onload = function() {
+      try {
+        done(JSON.parse(xhr.responseText));
+      } catch (e) {
+        console.log(e);
+      }
+    };
+    if (body) {
+      xhr.setRequestHeader('Content-Type', 'application/x-www-form-urlencoded');
+    }
+    xhr.send(body);
   }
 
-  pkgnameForm.on('submit', function(e) {
+  pkgnameForm.addEventListener('submit', function(e) {
     e.preventDefault();
 
-    var name = pkgnameField.val().trim();
+    var name = pkgnameField.value.trim();
     if (name == '') return;
 
-    $.ajax({
-      url: '/validate',
-      type: 'post',
-      data: { pkgname: name },
-      success: afterValidate
-    });
-  });
-
-  $('#example').on('click', function(e) {
-    e.preventDefault();
-    $.get('/generate', afterValidate);
+    var body = 'pkgname=' + encodeURIComponent(name) + '&record=' + recordField.checked;
+    if (toneField.value != '') {
+      body += '&tone=' + encodeURIComponent(toneField.value);
+    }
+    body += '&lang=' + encodeURIComponent(langField.value);
+    request('POST', '/validate', body, afterValidate);
   });
 
-  $('#history').on('click', function(e) {
+  document.getElementById('example').addEventListener('click', function(e) {
     e.preventDefault();
-    $.get('/history', afterHistory);
+    request('GET', '/generate', null, afterValidate);
   });
 
-  if (window.location.search.length) {
-    var pkgParam = null;
-    var queryString = window.location.search.substr(1);
-    queryString.split('&').forEach(function(p) {
-      var pair = p.split('=');
-
-      if (pair[0].toLowerCase() == 'pkgname') {
-        pkgParam = pair[1];
-      }
+  var historyLink = document.getElementById('history');
+  if (historyLink) {
+    historyLink.addEventListener('click', function(e) {
+      e.preventDefault();
+      request('GET', '/history', null, afterHistory);
     });
-
-    if (pkgParam) {
-      main.hide();
-      $('#tryagainmessage').css('display', 'block');
-      pkgnameField.val(pkgParam);
-      pkgnameForm.trigger('submit');
-      
-    } else {
-      main.show();
-    }
-  } else {
-    main.show();
   }
-})
+});
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testAssets = map[string]string{
+	"404.html":        "<h1>not found</h1>",
+	"sub/index.html":  "<h1>sub</h1>",
+	"sub/notes.txt":   "notes",
+	"index.html":      `<h1>pkgname</h1><script src="application.js"></script>`,
+	"application.js":  "alert('pkgname')",
+	"application.css": strings.Repeat("body { color: #333; }\n", 100),
+}
+
+func newTestStatic(t testing.TB) http.HandlerFunc {
+	dir := t.TempDir()
+	for name, content := range testAssets {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Something the handler must never serve.
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "secret"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	static, err := loadStatic(os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return static.serve
+}
+
+func TestStaticHandler(t *testing.T) {
+	h := newTestStatic(t)
+
+	tests := []struct {
+		path, body, mimetype string
+	}{
+		{"/index.html", getStatic(h, "GET", "/").Body.String(), "text/html; charset=utf-8"},
+		{"/application.js", testAssets["application.js"], "text/javascript; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", tt.path, nil))
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: want 200, got %d", tt.path, rr.Code)
+		}
+		if rr.Body.String() != tt.body {
+			t.Errorf("%s: want %q, got %q", tt.path, tt.body, rr.Body.String())
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != tt.mimetype {
+			t.Errorf("%s: want %q, got %q", tt.path, tt.mimetype, ct)
+		}
+		if rr.Header().Get("ETag") == "" {
+			t.Errorf("%s: want an ETag", tt.path)
+		}
+	}
+}
+
+func TestStaticHandlerEmbedded(t *testing.T) {
+	static, err := loadStatic(staticFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := static.serve
+
+	for _, path := range []string{"/404.html", "/application.js", "/application.css", "/favicon.ico"} {
+		rr := httptest.NewRecorder()
+		h(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK || rr.Body.Len() == 0 {
+			t.Errorf("%s: want 200 with content, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestStaticHandlerEncodings(t *testing.T) {
+	h := newTestStatic(t)
+	want := testAssets["application.css"]
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"": func(r io.Reader) (io.Reader, error) { return r, nil },
+		"gzip": func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+		"br": func(r io.Reader) (io.Reader, error) {
+			return brotli.NewReader(r), nil
+		},
+	}
+
+	tests := []struct {
+		acceptEncoding, want string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"*", "br"},
+		{"br;q=0, gzip;q=0", ""},
+		{"deflate", ""},
+	}
+
+	etags := make(map[string]string)
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/application.css", nil)
+		r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		rr := httptest.NewRecorder()
+		h(rr, r)
+
+		if got := rr.Header().Get("Content-Encoding"); got != tt.want {
+			t.Errorf("Accept-Encoding %q: want %q, got %q", tt.acceptEncoding, tt.want, got)
+			continue
+		}
+		if got := rr.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: want Vary: Accept-Encoding, got %q", tt.acceptEncoding, got)
+		}
+
+		body, err := decoders[tt.want](bytes.NewReader(rr.Body.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("Accept-Encoding %q: content doesn't match", tt.acceptEncoding)
+		}
+		etags[tt.want] = rr.Header().Get("ETag")
+	}
+
+	if etags[""] == etags["gzip"] || etags["gzip"] == etags["br"] || etags[""] == etags["br"] {
+		t.Errorf("want an ETag per encoding, got %q", etags)
+	}
+
+	for _, acceptEncoding := range []string{"identity;q=0", "br;q=0, gzip;q=0, identity;q=0", "*;q=0", "deflate, *;q=0"} {
+		r := httptest.NewRequest("GET", "/application.css", nil)
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+		rr := httptest.NewRecorder()
+		h(rr, r)
+		if rr.Code != http.StatusNotAcceptable {
+			t.Errorf("Accept-Encoding %q: want 406, got %d", acceptEncoding, rr.Code)
+		}
+	}
+}
+
+func getStatic(h http.HandlerFunc, method, path string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	rr := httptest.NewRecorder()
+	h(rr, r)
+	return rr
+}
+
+func TestStaticHandlerNotModified(t *testing.T) {
+	h := newTestStatic(t)
+
+	etag := getStatic(h, "GET", "/application.js").Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		t.Fatalf("want a quoted ETag, got %s", etag)
+	}
+
+	for _, inm := range []string{
+		etag,
+		"W/" + etag,
+		"*",
+		`"nope", ` + etag,
+		`"nope",W/` + etag + `, "other"`,
+	} {
+		rr := getStatic(h, "GET", "/application.js", "If-None-Match", inm)
+		if rr.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: want 304, got %d", inm, rr.Code)
+		}
+		if rr.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: want no body, got %q", inm, rr.Body.String())
+		}
+	}
+
+	rr := getStatic(h, "GET", "/application.js", "If-None-Match", `"nope"`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("want 200 for another ETag, got %d", rr.Code)
+	}
+
+	lastModified := rr.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatalf("want Last-Modified")
+	}
+	rr = getStatic(h, "GET", "/application.js", "If-Modified-Since", lastModified)
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since: want 304, got %d", rr.Code)
+	}
+}
+
+func TestStaticHandlerHead(t *testing.T) {
+	h := newTestStatic(t)
+
+	rr := getStatic(h, "HEAD", "/application.js")
+	if rr.Code != http.StatusOK || rr.Body.Len() != 0 {
+		t.Errorf("want 200 without a body, got %d %q", rr.Code, rr.Body.String())
+	}
+	if got, want := rr.Header().Get("Content-Length"), "16"; got != want {
+		t.Errorf("want Content-Length %s, got %s", want, got)
+	}
+}
+
+func TestStaticHandlerRange(t *testing.T) {
+	h := newTestStatic(t)
+
+	rr := getStatic(h, "GET", "/application.js", "Range", "bytes=0-4")
+	if rr.Code != http.StatusPartialContent || rr.Body.String() != "alert" {
+		t.Errorf("want 206 with the range, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = getStatic(h, "GET", "/application.js", "Range", "bytes=100-")
+	if rr.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("want 416, got %d", rr.Code)
+	}
+}
+
+func TestStaticHandlerFingerprints(t *testing.T) {
+	h := newTestStatic(t)
+
+	index := getStatic(h, "GET", "/")
+	if got := index.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("want the index revalidated, got Cache-Control %q", got)
+	}
+
+	m := regexp.MustCompile(`src="(/application\.[0-9a-f]+\.js)"`).FindStringSubmatch(index.Body.String())
+	if m == nil {
+		t.Fatalf("want the index to refer to the fingerprinted script, got %q", index.Body.String())
+	}
+
+	rr := getStatic(h, "GET", m[1])
+	if rr.Code != http.StatusOK || rr.Body.String() != testAssets["application.js"] {
+		t.Fatalf("want the script at %s, got %d", m[1], rr.Code)
+	}
+	if got := rr.Header().Get("Cache-Control"); !strings.Contains(got, "immutable") {
+		t.Errorf("want the fingerprinted script immutable, got Cache-Control %q", got)
+	}
+
+	rr = getStatic(h, "GET", "/application.js")
+	if got := rr.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("want the plain script revalidated, got Cache-Control %q", got)
+	}
+}
+
+func TestFingerprintRefs(t *testing.T) {
+	static := &staticDB{assets: make(map[string]*asset)}
+	for _, name := range []string{"app.js", "css/app.css"} {
+		if err := static.Put(name, []byte(name), loadTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	js, _ := static.URL("app.js")
+	css, _ := static.URL("css/app.css")
+
+	tests := []struct {
+		page, in, want string
+	}{
+		{"index.html", `<script src="app.js">`, `<script src="` + js + `">`},
+		{"index.html", `<script src='/app.js'>`, `<script src='` + js + `'>`},
+		{"index.html", `<link href="/css/app.css">`, `<link href="` + css + `">`},
+		{"css/index.html", `<link HREF="app.css">`, `<link HREF="` + css + `">`},
+		{"index.html", `<a href="/missing.js">`, `<a href="/missing.js">`},
+		{"index.html", `<a href="//cdn.example.com/app.js">`, `<a href="//cdn.example.com/app.js">`},
+		{"index.html", `<a href="https://example.com/app.js">`, `<a href="https://example.com/app.js">`},
+		{"index.html", `<a href="/app.js?v=1">`, `<a href="/app.js?v=1">`},
+	}
+
+	for _, tt := range tests {
+		if got := string(static.fingerprintRefs(tt.page, []byte(tt.in))); got != tt.want {
+			t.Errorf("%s %s: want %s, got %s", tt.page, tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestStaticHandlerNotFound(t *testing.T) {
+	h := newTestStatic(t)
+
+	for _, path := range []string{"/missing.css", "/../secret", "/index.html/", "/sub/missing/"} {
+		rr := getStatic(h, "GET", path)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: want 404, got %d", path, rr.Code)
+		}
+		if rr.Body.String() != testAssets["404.html"] {
+			t.Errorf("%s: want the 404 page, got %q", path, rr.Body.String())
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("%s: want HTML, got %q", path, ct)
+		}
+	}
+}
+
+func TestStaticHandlerIndexes(t *testing.T) {
+	h := newTestStatic(t)
+
+	for _, path := range []string{"/sub/", "/sub/index.html", "/sub/./", "/other/../sub/"} {
+		rr := getStatic(h, "GET", path)
+		if rr.Code != http.StatusOK || rr.Body.String() != testAssets["sub/index.html"] {
+			t.Errorf("%s: want the sub index, got %d %q", path, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := getStatic(h, "GET", "/sub?x=1")
+	if rr.Code != http.StatusMovedPermanently || rr.Header().Get("Location") != "/sub/?x=1" {
+		t.Errorf("want a redirect to the directory, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+}
+
+func TestStaticHandlerTraversal(t *testing.T) {
+	h := newTestStatic(t)
+
+	for _, target := range []string{
+		"/../secret",
+		"/%2e%2e/secret",
+		"/%2E%2E%2Fsecret",
+		"/..%2fsecret",
+		"/..%2f..%2fsecret",
+		"/sub/..%2f..%2fsecret",
+		"/%252e%252e/secret",
+		"/..%5csecret",
+		"/..\\secret",
+		"/sub/%00/../../secret",
+		"/index.html%00.js",
+		"/....//secret",
+	} {
+		rr := getStatic(h, "GET", target)
+		if rr.Code == http.StatusOK || strings.Contains(rr.Body.String(), "secret") {
+			t.Errorf("%s: want nothing, got %d %q", target, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestDevStaticNoListing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "listed", "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "listed", "file.txt"), []byte("file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := http.FileServer(noListing{http.Dir(dir)})
+
+	for path, want := range map[string]int{
+		"/listed/":         http.StatusNotFound,
+		"/listed/nested/":  http.StatusNotFound,
+		"/listed/file.txt": http.StatusOK,
+		"/../secret":       http.StatusNotFound,
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != want {
+			t.Errorf("%s: want %d, got %d", path, want, rr.Code)
+		}
+	}
+}
+
+func FuzzStaticPath(f *testing.F) {
+	h := newTestStatic(f)
+	served := map[string]bool{getStatic(h, "GET", "/").Body.String(): true}
+	for name, content := range testAssets {
+		served[content] = true
+		f.Add("/" + name)
+	}
+	for _, seed := range []string{"", "/", "/../secret", "/..%2fsecret", "//index.html", "/./index.html", "index.html"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, path string) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.URL.Path = path
+		rr := httptest.NewRecorder()
+		h(rr, r)
+
+		if rr.Code == http.StatusOK && !served[rr.Body.String()] {
+			t.Fatalf("%q served something that isn't an asset: %q", path, rr.Body.String())
+		}
+	})
+}
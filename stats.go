@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/aybabtme/pkgname/pkgname"
+	"sort"
+	"time"
+)
+
+var (
+	// statsDays is how many days of validation counts are kept.
+	statsDays = 30
+	// statsRejected is how many distinct rejected names are counted. Past
+	// that, the least rejected are forgotten to make room, so the top of
+	// the list stays accurate while memory stays bounded.
+	statsRejected = 1000
+	// statsAnonymize replaces the rejected names by a keyed hash of them,
+	// so that the stats tell how often a name comes back but not what it is.
+	statsAnonymize = false
+)
+
+// usageStats aggregates what the validations look like, without keeping
+// anything that says who made them.
+type usageStats struct {
+	since       time.Time
+	validations int64
+	passed      int64
+	days        []dayStats
+	rules       map[string]int64
+	rejected    *topCounter
+
+	// key is what rejected names are hashed with when anonymizing.
+	key []byte
+}
+
+type dayStats struct {
+	Date        string  `json:"date"`
+	Validations int64   `json:"validations"`
+	Passed      int64   `json:"passed"`
+	PassRate    float64 `json:"pass_rate"`
+}
+
+type ruleStats struct {
+	Rule string `json:"rule"`
+	Hits int64  `json:"hits"`
+}
+
+type nameStats struct {
+	Pkgname string `json:"pkgname"`
+	Count   int64  `json:"count"`
+}
+
+type statsResponse struct {
+	Since       time.Time   `json:"since"`
+	Validations int64       `json:"validations"`
+	Passed      int64       `json:"passed"`
+	PassRate    float64     `json:"pass_rate"`
+	Days        []dayStats  `json:"days"`
+	Rules       []ruleStats `json:"rules"`
+	TopRejected []nameStats `json:"top_rejected"`
+	Anonymized  bool        `json:"anonymized"`
+}
+
+func newUsageStats(now time.Time) *usageStats {
+	s := &usageStats{
+		since:    now.UTC(),
+		rules:    make(map[string]int64),
+		rejected: newTopCounter(statsRejected),
+	}
+	if statsAnonymize {
+		s.key = make([]byte, 32)
+		if _, err := rand.Read(s.key); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}
+
+// add counts a validation of name which ended with violations. The name
+// isn't counted among the rejected ones if it's empty.
+func (s *usageStats) add(name string, violations []pkgname.Violation, now time.Time) {
+	date := now.UTC().Format("2006-01-02")
+	if len(s.days) == 0 || s.days[len(s.days)-1].Date != date {
+		s.days = append(s.days, dayStats{Date: date})
+		if len(s.days) > statsDays {
+			s.days = s.days[len(s.days)-statsDays:]
+		}
+	}
+	day := &s.days[len(s.days)-1]
+
+	s.validations++
+	day.Validations++
+	// Warnings count against their rule, even on names that pass.
+	for _, v := range violations {
+		s.rules[v.Rule]++
+	}
+	if pkgname.Passed(violations) {
+		s.passed++
+		day.Passed++
+		return
+	}
+
+	if name != "" {
+		s.rejected.add(s.anonymize(name), now)
+	}
+}
+
+// forget stops counting name among the rejected ones, or every name if
+// it's empty.
+func (s *usageStats) forget(name string) {
+	if name == "" {
+		s.rejected = newTopCounter(statsRejected)
+		return
+	}
+	s.rejected.remove(s.anonymize(name))
+}
+
+// expire forgets the rejected names last counted before before.
+func (s *usageStats) expire(before time.Time) {
+	s.rejected.expire(before)
+}
+
+func (s *usageStats) anonymize(name string) string {
+	if s.key == nil {
+		return name
+	}
+	mac := hmac.New(sha256.New, s.key)
+	_, _ = mac.Write([]byte(name))
+	return "anon-" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// snapshot reports the stats, with every one of rules even if they never
+// fired, and the top most rejected names.
+func (s *usageStats) snapshot(rules []pkgname.Rule, top int) statsResponse {
+	res := statsResponse{
+		Since:       s.since,
+		Validations: s.validations,
+		Passed:      s.passed,
+		PassRate:    passRate(s.passed, s.validations),
+		Days:        make([]dayStats, len(s.days)),
+		Anonymized:  s.key != nil,
+	}
+	for i, day := range s.days {
+		day.PassRate = passRate(day.Passed, day.Validations)
+		res.Days[i] = day
+	}
+
+	for _, rule := range rules {
+		res.Rules = append(res.Rules, ruleStats{Rule: rule.ID, Hits: s.rules[rule.ID]})
+	}
+	sort.SliceStable(res.Rules, func(i, j int) bool { return res.Rules[i].Hits > res.Rules[j].Hits })
+
+	res.TopRejected = s.rejected.top(top)
+	return res
+}
+
+func passRate(passed, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(passed) / float64(total)
+}
+
+// topCounter counts the occurrences of names to find the most frequent,
+// with the Space-Saving algorithm: when it's full, the least counted name
+// makes room for the new one, which inherits its count.
+type topCounter struct {
+	max    int
+	counts map[string]int64
+	// seen is when each name was last counted, so that they're forgotten
+	// like the history forgets them.
+	seen map[string]time.Time
+}
+
+func newTopCounter(max int) *topCounter {
+	return &topCounter{max: max, counts: make(map[string]int64), seen: make(map[string]time.Time)}
+}
+
+func (t *topCounter) add(name string, now time.Time) {
+	if t.max <= 0 {
+		return
+	}
+	if _, ok := t.counts[name]; !ok && len(t.counts) >= t.max {
+		var min string
+		found := false
+		for n, c := range t.counts {
+			if !found || c < t.counts[min] || (c == t.counts[min] && n < min) {
+				min, found = n, true
+			}
+		}
+		t.counts[name] = t.counts[min]
+		t.remove(min)
+	}
+	t.counts[name]++
+	t.seen[name] = now
+}
+
+func (t *topCounter) remove(name string) {
+	delete(t.counts, name)
+	delete(t.seen, name)
+}
+
+// expire forgets the names last counted before before.
+func (t *topCounter) expire(before time.Time) {
+	for name, seen := range t.seen {
+		if seen.Before(before) {
+			t.remove(name)
+		}
+	}
+}
+
+func (t *topCounter) top(n int) []nameStats {
+	all := make([]nameStats, 0, len(t.counts))
+	for name, count := range t.counts {
+		all = append(all, nameStats{Pkgname: name, Count: count})
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Count != all[j].Count {
+			return all[i].Count > all[j].Count
+		}
+		return all[i].Pkgname < all[j].Pkgname
+	})
+	if n < len(all) {
+		all = all[:n]
+	}
+	return all
+}
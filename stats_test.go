@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"github.com/aybabtme/pkgname/pkgname"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTopCounter(t *testing.T) {
+	c := newTopCounter(3)
+	for _, name := range strings.Fields("a a a b b c d d d d") {
+		c.add(name, time.Time{})
+	}
+
+	// c was the least counted when d came, so d took its place and count.
+	want := []nameStats{{"d", 5}, {"a", 3}, {"b", 2}}
+	if got := c.top(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if got := c.top(1); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("want %v, got %v", want[:1], got)
+	}
+
+	none := newTopCounter(0)
+	none.add("a", time.Time{})
+	if got := none.top(10); len(got) != 0 {
+		t.Errorf("want nothing counted, got %v", got)
+	}
+}
+
+func TestUsageStats(t *testing.T) {
+	day1 := time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+	hyphens := []pkgname.Violation{{Rule: pkgname.RuleNoHyphens}}
+	both := []pkgname.Violation{{Rule: pkgname.RuleNoHyphens}, {Rule: pkgname.RuleNoReferenceToGo}}
+
+	s := newUsageStats(day1)
+	s.add("lime", nil, day1)
+	s.add("a-b", hyphens, day1)
+	s.add("go-lime", both, day2)
+	s.add("go-lime", both, day2)
+
+	got := s.snapshot(pkgname.BuiltinRules(), 10)
+	if got.Validations != 4 || got.Passed != 1 || got.PassRate != 0.25 {
+		t.Errorf("want 4 validations with 1 pass, got %+v", got)
+	}
+	wantDays := []dayStats{
+		{Date: "2026-10-18", Validations: 2, Passed: 1, PassRate: 0.5},
+		{Date: "2026-10-19", Validations: 2},
+	}
+	if !reflect.DeepEqual(got.Days, wantDays) {
+		t.Errorf("want days %v, got %v", wantDays, got.Days)
+	}
+	if len(got.Rules) != len(pkgname.BuiltinRules()) {
+		t.Errorf("want every rule, got %v", got.Rules)
+	}
+	if got.Rules[0] != (ruleStats{pkgname.RuleNoHyphens, 3}) || got.Rules[1] != (ruleStats{pkgname.RuleNoReferenceToGo, 2}) {
+		t.Errorf("want the rules by hits, got %v", got.Rules)
+	}
+	wantTop := []nameStats{{"go-lime", 2}, {"a-b", 1}}
+	if !reflect.DeepEqual(got.TopRejected, wantTop) {
+		t.Errorf("want top %v, got %v", wantTop, got.TopRejected)
+	}
+}
+
+func TestUsageStatsWarnings(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	short := pkgname.Violation{Rule: "short", Severity: pkgname.SeverityWarning}
+
+	s := newUsageStats(now)
+	s.add("limelight", []pkgname.Violation{short}, now)
+	s.add("lime-light", []pkgname.Violation{{Rule: pkgname.RuleNoHyphens}, short}, now)
+
+	rules := append(pkgname.BuiltinRules(), pkgname.Rule{ID: "short"})
+	got := s.snapshot(rules, 10)
+	if got.Passed != 1 || got.Rules[0] != (ruleStats{"short", 2}) || got.Rules[1] != (ruleStats{pkgname.RuleNoHyphens, 1}) {
+		t.Errorf("want warnings counted on passing names too, got %+v", got)
+	}
+	if want := []nameStats{{"lime-light", 1}}; !reflect.DeepEqual(got.TopRejected, want) {
+		t.Errorf("want only the failing name rejected, got %v", got.TopRejected)
+	}
+}
+
+func TestUsageStatsDays(t *testing.T) {
+	defer func(old int) { statsDays = old }(statsDays)
+	statsDays = 2
+
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := newUsageStats(start)
+	for i := 0; i < 5; i++ {
+		s.add("lime", nil, start.AddDate(0, 0, i))
+	}
+
+	got := s.snapshot(nil, 10)
+	if len(got.Days) != 2 || got.Days[0].Date != "2026-10-04" || got.Days[1].Date != "2026-10-05" {
+		t.Errorf("want the last 2 days, got %v", got.Days)
+	}
+	if got.Validations != 5 {
+		t.Errorf("want the total kept, got %d", got.Validations)
+	}
+}
+
+func TestUsageStatsAnonymize(t *testing.T) {
+	defer func(old bool) { statsAnonymize = old }(statsAnonymize)
+	statsAnonymize = true
+
+	now := time.Now()
+	s := newUsageStats(now)
+	hyphens := []pkgname.Violation{{Rule: pkgname.RuleNoHyphens}}
+	s.add("my-secret", hyphens, now)
+	s.add("my-secret", hyphens, now)
+	s.add("other-one", hyphens, now)
+
+	got := s.snapshot(nil, 10)
+	if !got.Anonymized || len(got.TopRejected) != 2 || got.TopRejected[0].Count != 2 {
+		t.Fatalf("want 2 anonymized names, got %+v", got)
+	}
+	for _, n := range got.TopRejected {
+		if !strings.HasPrefix(n.Pkgname, "anon-") || strings.Contains(n.Pkgname, "secret") {
+			t.Errorf("want %q anonymized", n.Pkgname)
+		}
+	}
+
+	// Another process hashes with another key.
+	if other := newUsageStats(now); other.anonymize("my-secret") == s.anonymize("my-secret") {
+		t.Errorf("want a key per process")
+	}
+}
+
+func TestDBAssessCounts(t *testing.T) {
+	db := NewDB()
+	db.Assess(context.Background(), "lime", query{}, false)
+	db.Assess(context.Background(), "go-lime", query{}, true)
+	db.Check("not-counted", query{})
+	db.Validate(context.Background(), "", "kept", true)
+
+	got := db.Stats(10)
+	if got.Validations != 3 || got.Passed != 2 {
+		t.Errorf("want 3 validations with 2 passes, got %+v", got)
+	}
+	if goods, bads := db.Last(10); !reflect.DeepEqual(goods, []string{"kept"}) || !reflect.DeepEqual(bads, []string{"go-lime"}) {
+		t.Errorf("want only what's recorded in history, got %q %q", goods, bads)
+	}
+	if len(got.Rules) != len(db.allRules()) {
+		t.Errorf("want the rules of every profile, got %v", got.Rules)
+	}
+}
+
+func TestDBAssessKeepsSecrets(t *testing.T) {
+	defer func(ttl time.Duration) { historyTTL = ttl }(historyTTL)
+	historyTTL = time.Hour
+
+	db := NewDB()
+	db.Assess(context.Background(), "codename-x", query{}, false)
+	db.Assess(context.Background(), "go-lime", query{}, true)
+	db.Assess(context.Background(), "go-kiwi", query{}, true)
+
+	rejected := func() []string {
+		var names []string
+		for _, n := range db.Stats(10).TopRejected {
+			names = append(names, n.Pkgname)
+		}
+		return names
+	}
+	if got := db.Stats(10); got.Validations != 3 || !reflect.DeepEqual(rejected(), []string{"go-kiwi", "go-lime"}) {
+		t.Fatalf("want the unrecorded name counted but not named, got %+v", got)
+	}
+
+	db.Purge("go-lime")
+	if got := rejected(); !reflect.DeepEqual(got, []string{"go-kiwi"}) {
+		t.Errorf("want go-lime purged from the stats, got %q", got)
+	}
+	db.Expire(time.Now().Add(historyTTL + time.Second))
+	if got := rejected(); len(got) != 0 {
+		t.Errorf("want the rejected names expired, got %q", got)
+	}
+
+	db.Assess(context.Background(), "go-lime", query{}, true)
+	db.Purge("")
+	if got := rejected(); len(got) != 0 {
+		t.Errorf("want the rejected names purged, got %q", got)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// certReloadInterval is how often the certificate files are checked
+	// for changes, so that renewed certificates are served without a
+	// restart.
+	certReloadInterval = 30 * time.Second
+	// hstsMaxAge is how long browsers are told to only use HTTPS for the
+	// site, when it's served over TLS. No HSTS header is sent if it's zero.
+	hstsMaxAge = 365 * 24 * time.Hour
+	// hstsSubdomains tells browsers to only use HTTPS for the subdomains of
+	// the site too, which breaks those that are only served over HTTP.
+	hstsSubdomains = false
+)
+
+// certReloader serves the certificate of a pair of files, and reloads it
+// when they change.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	lock    sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// modified is when the last of the files changed.
+func (r *certReloader) modified() (time.Time, error) {
+	var last time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(last) {
+			last = fi.ModTime()
+		}
+	}
+	return last, nil
+}
+
+// reload loads the certificate again if its files changed since it was
+// last loaded, and tells if it did. The certificate already loaded is kept
+// if the new one can't be.
+func (r *certReloader) reload() (bool, error) {
+	modTime, err := r.modified()
+	if err != nil {
+		return false, err
+	}
+	r.lock.RLock()
+	unchanged := r.cert != nil && modTime.Equal(r.modTime)
+	r.lock.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("loading %s and %s, %v", r.certFile, r.keyFile, err)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cert, r.modTime = &cert, modTime
+	return true, nil
+}
+
+// watch reloads the certificate when its files change, until ctx is done.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			switch reloaded, err := r.reload(); {
+			case err != nil:
+				slog.Error("Couldn't reload TLS certificate, still serving the previous one", "err", err)
+			case reloaded:
+				slog.Info("Reloaded TLS certificate", "cert", r.certFile)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.cert, nil
+}
+
+// config is what to serve TLS with, always with the latest certificate.
+func (r *certReloader) config() *tls.Config {
+	return &tls.Config{
+		GetCertificate: r.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+}
+
+// hsts tells browsers to only use HTTPS for the site, and its subdomains if
+// asked to, on the responses to requests that came over TLS.
+func hsts(maxAge time.Duration, subdomains bool, h http.Handler) http.Handler {
+	if maxAge <= 0 {
+		return h
+	}
+	value := "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	if subdomains {
+		value += "; includeSubDomains"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", value)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// redirectHTTPS sends the clients of plain HTTP to the same URL over HTTPS,
+// served on the port of httpsAddr.
+func redirectHTTPS(httpsAddr string) http.HandlerFunc {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "Use HTTPS.", http.StatusBadRequest)
+			return
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate for localhost named cn,
+// and its key, to dir. They're dated at modTime.
+func writeTestCert(t *testing.T, dir, cn string, modTime time.Time) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	for name, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err := os.WriteFile(name, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile
+}
+
+func servedCN(t *testing.T, r *certReloader) string {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	certFile, keyFile := writeTestCert(t, dir, "first", start)
+
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cn := servedCN(t, r); cn != "first" {
+		t.Fatalf("want the first certificate, got %q", cn)
+	}
+	if reloaded, err := r.reload(); reloaded || err != nil {
+		t.Errorf("want nothing reloaded when the files didn't change, got %v %v", reloaded, err)
+	}
+
+	writeTestCert(t, dir, "second", start.Add(time.Minute))
+	if reloaded, err := r.reload(); !reloaded || err != nil {
+		t.Fatalf("want the changed files reloaded, got %v %v", reloaded, err)
+	}
+	if cn := servedCN(t, r); cn != "second" {
+		t.Fatalf("want the second certificate, got %q", cn)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.reload(); err == nil {
+		t.Errorf("want a broken key reported")
+	}
+	if cn := servedCN(t, r); cn != "second" {
+		t.Errorf("want the previous certificate kept, got %q", cn)
+	}
+
+	if _, err := newCertReloader(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Errorf("want a missing certificate reported")
+	}
+}
+
+func TestCertReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	certFile, keyFile := writeTestCert(t, dir, "first", start)
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.watch(ctx, 10*time.Millisecond)
+
+	writeTestCert(t, dir, "second", start.Add(time.Minute))
+	deadline := time.Now().Add(5 * time.Second)
+	for servedCN(t, r) != "second" {
+		if time.Now().After(deadline) {
+			t.Fatal("want the certificate reloaded while watching")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServeTLS(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir(), "pkgname", time.Now())
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newHTTPServer(hsts(time.Hour, false, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})))
+	go srv.Serve(tls.NewListener(l, r.config()))
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pem, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.AppendCertsFromPEM(pem)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	res, err := client.Get("https://" + l.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if got := res.Header.Get("Strict-Transport-Security"); got != "max-age=3600" {
+		t.Errorf("want HSTS over TLS, got %q", got)
+	}
+}
+
+func TestHSTS(t *testing.T) {
+	h := hsts(time.Hour, false, http.NotFoundHandler())
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "http://example.com/", nil))
+	if got := rr.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("want no HSTS over plain HTTP, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "https://example.com/", nil))
+	if got := rr.Header().Get("Strict-Transport-Security"); got != "max-age=3600" {
+		t.Errorf("want HSTS over TLS, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	hsts(time.Hour, true, http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "https://example.com/", nil))
+	if got := rr.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains" {
+		t.Errorf("want HSTS for the subdomains when asked, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	hsts(0, false, http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "https://example.com/", nil))
+	if got := rr.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("want no HSTS when disabled, got %q", got)
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	tests := []struct {
+		httpsAddr, target, want string
+	}{
+		{":443", "http://example.com/?pkgname=lime", "https://example.com/?pkgname=lime"},
+		{":443", "http://example.com:80/history", "https://example.com/history"},
+		{":8443", "http://example.com:8080/a/b?c=d", "https://example.com:8443/a/b?c=d"},
+		{"0.0.0.0:8443", "http://[::1]:8080/", "https://[::1]:8443/"},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		redirectHTTPS(tt.httpsAddr)(rr, httptest.NewRequest("GET", tt.target, nil))
+		if rr.Code != http.StatusPermanentRedirect || rr.Header().Get("Location") != tt.want {
+			t.Errorf("%s: want a redirect to %s, got %d %q", tt.target, tt.want, rr.Code, rr.Header().Get("Location"))
+		}
+	}
+}